@@ -0,0 +1,160 @@
+// Package capabilities describes what a tenant can use: the modules its
+// plan and country enable, the limits of its plan, and the API versions
+// served. Clients read it to hide what a tenant does not have instead of
+// hardcoding plans and regions.
+package capabilities
+
+import (
+	"strings"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
+)
+
+// APIVersions are the versions of the API currently served, oldest first
+var APIVersions = []string{"v1"}
+
+// Plans lists the plans from lowest to highest; each includes the modules
+// of the ones below it
+var Plans = []string{"free", "starter", "professional", "enterprise"}
+
+// Why a module is unavailable to a tenant
+const (
+	ReasonPlan   = "plan"   // needs a higher plan
+	ReasonRegion = "region" // does not apply in the tenant's country
+)
+
+// FlagPrefix prefixes the feature flags that switch a module on for a
+// tenant outside its plan, e.g. module.e_invoicing for a trial
+const FlagPrefix = "module."
+
+// Module is a part of the product that is enabled per tenant
+type Module struct {
+	Key       string
+	Name      string
+	MinPlan   string   // lowest plan that includes the module
+	Countries []string // countries the module applies in; empty for all
+}
+
+// Modules is the catalog of modules
+var Modules = []Module{
+	{Key: "invoicing", Name: "Invoicing", MinPlan: "free"},
+	{Key: "bookkeeping", Name: "Bookkeeping", MinPlan: "free"},
+	{Key: "gst", Name: "GST returns", MinPlan: "free", Countries: []string{"India"}},
+	{Key: "quotations", Name: "Quotations", MinPlan: "starter"},
+	{Key: "delivery_challans", Name: "Delivery challans", MinPlan: "starter", Countries: []string{"India"}},
+	{Key: "multi_currency", Name: "Multi-currency and export invoices", MinPlan: "starter"},
+	{Key: "e_invoicing", Name: "E-invoicing", MinPlan: "professional", Countries: []string{"India"}},
+	{Key: "tds", Name: "TDS", MinPlan: "professional", Countries: []string{"India"}},
+	{Key: "purchase_orders", Name: "Purchase orders", MinPlan: "professional"},
+	{Key: "expense_claims", Name: "Expense claims", MinPlan: "professional"},
+	{Key: "bank_feeds", Name: "Bank feeds", MinPlan: "professional"},
+	{Key: "consolidation", Name: "Group consolidation", MinPlan: "enterprise"},
+}
+
+// Tenant is what capabilities are worked out from
+type Tenant struct {
+	Plan                string
+	Country             string
+	MaxUsers            int
+	MaxInvoicesPerMonth int
+}
+
+// ModuleStatus is whether a tenant has a module, and if not, why not
+type ModuleStatus struct {
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	Enabled      bool   `json:"enabled"`
+	Reason       string `json:"reason,omitempty"`        // plan or region, when not enabled
+	RequiredPlan string `json:"required_plan,omitempty"` // plan to upgrade to, when the plan is the reason
+}
+
+// Limits are the usage limits of the tenant's plan
+type Limits struct {
+	MaxUsers            int   `json:"max_users"`
+	MaxInvoicesPerMonth int   `json:"max_invoices_per_month"`
+	StorageBytes        int64 `json:"storage_bytes"`
+}
+
+// Capabilities is what a tenant can use
+type Capabilities struct {
+	Plan           string         `json:"plan"`
+	Country        string         `json:"country"`
+	EnabledModules []string       `json:"enabled_modules"`
+	Modules        []ModuleStatus `json:"modules"`
+	Limits         Limits         `json:"limits"`
+	APIVersions    []string       `json:"api_versions"`
+	Flags          []string       `json:"flags"` // feature flags on for the tenant
+}
+
+// Resolve works out the tenant's capabilities. flags are the feature flags
+// on for the tenant; a module.<key> flag enables a module the plan does not
+// include, but not one outside the tenant's region.
+func Resolve(tenant Tenant, flags []string) Capabilities {
+	plan := tenant.Plan
+	if planRank(plan) < 0 {
+		plan = storage.DefaultPlan
+	}
+
+	granted := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		if key, ok := strings.CutPrefix(flag, FlagPrefix); ok {
+			granted[key] = true
+		}
+	}
+
+	caps := Capabilities{
+		Plan:           plan,
+		Country:        tenant.Country,
+		EnabledModules: []string{},
+		Modules:        make([]ModuleStatus, 0, len(Modules)),
+		Limits: Limits{
+			MaxUsers:            tenant.MaxUsers,
+			MaxInvoicesPerMonth: tenant.MaxInvoicesPerMonth,
+			StorageBytes:        storage.PlanQuotas[plan],
+		},
+		APIVersions: APIVersions,
+		Flags:       flags,
+	}
+	if caps.Flags == nil {
+		caps.Flags = []string{}
+	}
+
+	for _, module := range Modules {
+		status := ModuleStatus{Key: module.Key, Name: module.Name}
+		switch {
+		case !module.appliesIn(tenant.Country):
+			status.Reason = ReasonRegion
+		case planRank(plan) < planRank(module.MinPlan) && !granted[module.Key]:
+			status.Reason = ReasonPlan
+			status.RequiredPlan = module.MinPlan
+		default:
+			status.Enabled = true
+			caps.EnabledModules = append(caps.EnabledModules, module.Key)
+		}
+		caps.Modules = append(caps.Modules, status)
+	}
+
+	return caps
+}
+
+func (m Module) appliesIn(country string) bool {
+	if len(m.Countries) == 0 {
+		return true
+	}
+	for _, c := range m.Countries {
+		if strings.EqualFold(c, strings.TrimSpace(country)) {
+			return true
+		}
+	}
+	return false
+}
+
+// planRank is the plan's position in Plans, or -1 for an unknown plan
+func planRank(plan string) int {
+	for i, p := range Plans {
+		if p == plan {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,105 @@
+// Package comments keeps internal discussion threads on financial documents
+// such as invoices, bills and transactions, so approvers can raise questions
+// where the document is rather than over chat. Comments are only ever
+// shown to the tenant's own users. Mentioned users are notified, and every
+// post, edit and deletion is kept in an append-only history for the audit
+// trail.
+package comments
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Document types that carry comment threads
+const (
+	DocumentInvoice     = "invoice"
+	DocumentBill        = "bill"
+	DocumentTransaction = "transaction"
+)
+
+// Comment history actions
+const (
+	ActionCreated = "created"
+	ActionEdited  = "edited"
+	ActionDeleted = "deleted"
+)
+
+// Limits on a comment
+const (
+	MaxBodyLength = 5000
+	MaxMentions   = 20
+)
+
+// Comment is a note on a document. A deleted comment stays in the thread
+// with its body cleared so replies still make sense; its text remains in
+// the history.
+type Comment struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_comments" json:"tenant_id"`
+	DocumentType string     `gorm:"size:30;not null;index:idx_document_comments" json:"document_type"`
+	DocumentID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_comments" json:"document_id"`
+	AuthorID     uuid.UUID  `gorm:"type:uuid;not null" json:"author_id"`
+	AuthorEmail  string     `gorm:"size:255" json:"author_email,omitempty"`
+	Body         string     `gorm:"type:text" json:"body"`
+	EditedAt     *time.Time `json:"edited_at,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	Mentions []Mention `gorm:"foreignKey:CommentID" json:"mentions,omitempty"`
+}
+
+// TableName returns the table name for Comment
+func (Comment) TableName() string {
+	return "document_comments"
+}
+
+// BeforeCreate hook
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// Mention is a user called out in a comment
+type Mention struct {
+	CommentID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for Mention
+func (Mention) TableName() string {
+	return "document_comment_mentions"
+}
+
+// Event is an entry in a document's comment history: the comment as it
+// stood after it was posted, edited or deleted, and who did it
+type Event struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index:idx_document_comment_events" json:"tenant_id"`
+	DocumentType string    `gorm:"size:30;not null;index:idx_document_comment_events" json:"document_type"`
+	DocumentID   uuid.UUID `gorm:"type:uuid;not null;index:idx_document_comment_events" json:"document_id"`
+	CommentID    uuid.UUID `gorm:"type:uuid;not null;index" json:"comment_id"`
+	Action       string    `gorm:"size:20;not null" json:"action"`
+	Body         string    `gorm:"type:text" json:"body"` // For a deletion, the text that was removed
+	UserID       uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for Event
+func (Event) TableName() string {
+	return "document_comment_events"
+}
+
+// BeforeCreate hook
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Lookup returns the label of a tenant's document, or ErrDocumentNotFound
+// if the tenant has no such document
+type Lookup func(ctx context.Context, tenantID, id uuid.UUID) (string, error)
+
+// DocumentConfig describes the documents a handler serves threads for
+type DocumentConfig struct {
+	Type   string
+	Link   string // Path of a document in the app, with %s for its ID
+	Lookup Lookup
+}
+
+// Handler serves the comment thread of one type of document. Mount it under
+// the document's routes:
+//
+//	GET    /:id/comments
+//	POST   /:id/comments
+//	GET    /:id/comments/history
+//	PUT    /:id/comments/:comment_id
+//	DELETE /:id/comments/:comment_id
+type Handler struct {
+	store  *Store
+	config DocumentConfig
+}
+
+// NewHandler creates a comment handler for a type of document
+func NewHandler(store *Store, config DocumentConfig) *Handler {
+	return &Handler{store: store, config: config}
+}
+
+// List returns the document's thread
+func (h *Handler) List(c *gin.Context) {
+	doc, ok := h.document(c)
+	if !ok {
+		return
+	}
+
+	comments, err := h.store.List(c.Request.Context(), doc)
+	if err != nil {
+		response.InternalError(c, "Failed to list comments")
+		return
+	}
+
+	response.Success(c, comments)
+}
+
+// History returns every post, edit and deletion on the document's thread
+func (h *Handler) History(c *gin.Context) {
+	doc, ok := h.document(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.store.History(c.Request.Context(), doc)
+	if err != nil {
+		response.InternalError(c, "Failed to get comment history")
+		return
+	}
+
+	response.Success(c, events)
+}
+
+// Add posts a comment on the document
+func (h *Handler) Add(c *gin.Context) {
+	var input Input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	doc, ok := h.document(c)
+	if !ok {
+		return
+	}
+	userID, err := userIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	comment, err := h.store.Add(c.Request.Context(), doc, userID, c.GetString("user_email"), input)
+	if err != nil {
+		h.handleError(c, err, "Failed to add comment")
+		return
+	}
+
+	response.Created(c, comment)
+}
+
+// Edit changes the text of one of the user's comments
+func (h *Handler) Edit(c *gin.Context) {
+	var input Input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	doc, commentID, userID, ok := h.comment(c)
+	if !ok {
+		return
+	}
+
+	comment, err := h.store.Edit(c.Request.Context(), doc, commentID, userID, input)
+	if err != nil {
+		h.handleError(c, err, "Failed to edit comment")
+		return
+	}
+
+	response.Success(c, comment)
+}
+
+// Delete removes one of the user's comments from the thread
+func (h *Handler) Delete(c *gin.Context) {
+	doc, commentID, userID, ok := h.comment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(c.Request.Context(), doc, commentID, userID); err != nil {
+		h.handleError(c, err, "Failed to delete comment")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// document resolves the document in the path, writing the error response
+// if it cannot
+func (h *Handler) document(c *gin.Context) (Document, bool) {
+	tenantID, err := tenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return Document{}, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid document ID", nil)
+		return Document{}, false
+	}
+
+	label, err := h.config.Lookup(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to read document")
+		return Document{}, false
+	}
+
+	doc := Document{TenantID: tenantID, Type: h.config.Type, ID: id, Label: label}
+	if h.config.Link != "" {
+		doc.Link = fmt.Sprintf(h.config.Link, id)
+	}
+	return doc, true
+}
+
+// comment resolves the document and comment in the path and the user
+// changing it
+func (h *Handler) comment(c *gin.Context) (Document, uuid.UUID, uuid.UUID, bool) {
+	commentID, err := uuid.Parse(c.Param("comment_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid comment ID", nil)
+		return Document{}, uuid.Nil, uuid.Nil, false
+	}
+	userID, err := userIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Invalid user ID")
+		return Document{}, uuid.Nil, uuid.Nil, false
+	}
+
+	doc, ok := h.document(c)
+	return doc, commentID, userID, ok
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrDocumentNotFound):
+		response.NotFound(c, "Document not found")
+	case errors.Is(err, ErrCommentNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrNotAuthor):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrInvalidComment):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func tenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
+
+func userIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
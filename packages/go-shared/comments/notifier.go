@@ -0,0 +1,91 @@
+package comments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MentionNotice tells a user they were mentioned in a comment
+type MentionNotice struct {
+	Document Document
+	Comment  *Comment
+	UserID   uuid.UUID
+}
+
+// Notifier delivers mention notices
+type Notifier interface {
+	NotifyMention(ctx context.Context, notice MentionNotice) error
+}
+
+// notificationNoticeLength is how much of the comment an in-app
+// notification quotes
+const notificationNoticeLength = 200
+
+// NotificationServiceNotifier sends mention notices to the user's in-app
+// inbox through the notification service
+type NotificationServiceNotifier struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewNotificationServiceNotifier creates a notifier for the notification
+// service at baseURL
+func NewNotificationServiceNotifier(baseURL string) *NotificationServiceNotifier {
+	return &NotificationServiceNotifier{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NotifyMention posts an in-app notification linking to the comment
+func (n *NotificationServiceNotifier) NotifyMention(ctx context.Context, notice MentionNotice) error {
+	author := notice.Comment.AuthorEmail
+	if author == "" {
+		author = "Someone"
+	}
+	quote := []rune(notice.Comment.Body)
+	if len(quote) > notificationNoticeLength {
+		quote = append(quote[:notificationNoticeLength], '…')
+	}
+
+	payload := map[string]interface{}{
+		"tenant_id": notice.Document.TenantID.String(),
+		"channel":   "in_app",
+		"user_id":   notice.UserID,
+		"title":     fmt.Sprintf("%s mentioned you on %s %s", author, notice.Document.Type, notice.Document.Label),
+		"message":   string(quote),
+		"type":      "info",
+	}
+	if notice.Document.Link != "" {
+		payload["link"] = notice.Document.Link + "#comment-" + notice.Comment.ID.String()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/api/v1/notifications", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", notice.Document.TenantID.String())
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to notification service failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("notification service returned %d", resp.StatusCode)
+	}
+	return nil
+}
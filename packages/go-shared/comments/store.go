@@ -0,0 +1,260 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrNotAuthor        = errors.New("only the author can change a comment")
+	ErrInvalidComment   = errors.New("comment must have between 1 and 5000 characters and mention at most 20 users")
+)
+
+// Document identifies the document a thread belongs to. Label is how users
+// know it, such as its number, and is used in mention notifications.
+type Document struct {
+	TenantID uuid.UUID
+	Type     string
+	ID       uuid.UUID
+	Label    string
+	Link     string // Path of the document in the app
+}
+
+// Input is the text of a comment and the users it mentions. The body
+// refers to mentioned users however the app displays them; the mentions
+// list is who is notified.
+type Input struct {
+	Body     string      `json:"body" binding:"required"`
+	Mentions []uuid.UUID `json:"mentions"`
+}
+
+// Store keeps comment threads and their history
+type Store struct {
+	db       *gorm.DB
+	notifier Notifier
+}
+
+// NewStore creates a comment store. Mentions are not notified when
+// notifier is nil.
+func NewStore(db *gorm.DB, notifier Notifier) *Store {
+	return &Store{db: db, notifier: notifier}
+}
+
+// List returns a document's thread, oldest first
+func (s *Store) List(ctx context.Context, doc Document) ([]Comment, error) {
+	var comments []Comment
+	err := s.db.WithContext(ctx).
+		Preload("Mentions").
+		Where("tenant_id = ? AND document_type = ? AND document_id = ?", doc.TenantID, doc.Type, doc.ID).
+		Order("created_at").
+		Find(&comments).Error
+	return comments, err
+}
+
+// History returns every post, edit and deletion on a document's thread,
+// oldest first
+func (s *Store) History(ctx context.Context, doc Document) ([]Event, error) {
+	var events []Event
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_type = ? AND document_id = ?", doc.TenantID, doc.Type, doc.ID).
+		Order("created_at").
+		Find(&events).Error
+	return events, err
+}
+
+// Add posts a comment on a document and notifies the users it mentions
+func (s *Store) Add(ctx context.Context, doc Document, authorID uuid.UUID, authorEmail string, input Input) (*Comment, error) {
+	body, mentions, err := validate(input, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &Comment{
+		TenantID:     doc.TenantID,
+		DocumentType: doc.Type,
+		DocumentID:   doc.ID,
+		AuthorID:     authorID,
+		AuthorEmail:  authorEmail,
+		Body:         body,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Mentions").Create(comment).Error; err != nil {
+			return err
+		}
+		if err := addMentions(tx, comment.ID, mentions); err != nil {
+			return err
+		}
+		return tx.Create(newEvent(comment, ActionCreated, authorID)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Mentions = mentionsOf(comment.ID, mentions)
+	s.notify(ctx, doc, comment, mentions)
+	return comment, nil
+}
+
+// Edit changes the text of a comment. Users newly mentioned are notified;
+// those mentioned before are not notified again.
+func (s *Store) Edit(ctx context.Context, doc Document, commentID, userID uuid.UUID, input Input) (*Comment, error) {
+	body, mentions, err := validate(input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment Comment
+	var added []uuid.UUID
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.lockComment(tx, doc, commentID, userID, &comment); err != nil {
+			return err
+		}
+
+		var existing []uuid.UUID
+		if err := tx.Model(&Mention{}).Where("comment_id = ?", comment.ID).Pluck("user_id", &existing).Error; err != nil {
+			return err
+		}
+		mentioned := make(map[uuid.UUID]bool, len(existing))
+		for _, id := range existing {
+			mentioned[id] = true
+		}
+		for _, id := range mentions {
+			if !mentioned[id] {
+				added = append(added, id)
+			}
+		}
+
+		now := time.Now()
+		comment.Body = body
+		comment.EditedAt = &now
+		if err := tx.Omit("Mentions").Save(&comment).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&Mention{}).Error; err != nil {
+			return err
+		}
+		if err := addMentions(tx, comment.ID, mentions); err != nil {
+			return err
+		}
+		return tx.Create(newEvent(&comment, ActionEdited, userID)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Mentions = mentionsOf(comment.ID, mentions)
+	s.notify(ctx, doc, &comment, added)
+	return &comment, nil
+}
+
+// Delete removes the text of a comment from the thread, keeping it in the
+// history
+func (s *Store) Delete(ctx context.Context, doc Document, commentID, userID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var comment Comment
+		if err := s.lockComment(tx, doc, commentID, userID, &comment); err != nil {
+			return err
+		}
+
+		event := newEvent(&comment, ActionDeleted, userID)
+		now := time.Now()
+		comment.Body = ""
+		comment.DeletedAt = &now
+		if err := tx.Omit("Mentions").Save(&comment).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&Mention{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// lockComment reads a live comment on the document for a change by its
+// author
+func (s *Store) lockComment(tx *gorm.DB, doc Document, commentID, userID uuid.UUID, comment *Comment) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND document_type = ? AND document_id = ? AND deleted_at IS NULL", doc.TenantID, doc.Type, doc.ID).
+		First(comment, "id = ?", commentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != userID {
+		return ErrNotAuthor
+	}
+	return nil
+}
+
+// notify tells each mentioned user about the comment. A failed
+// notification does not undo the comment.
+func (s *Store) notify(ctx context.Context, doc Document, comment *Comment, userIDs []uuid.UUID) {
+	if s.notifier == nil {
+		return
+	}
+	for _, userID := range userIDs {
+		mention := MentionNotice{Document: doc, Comment: comment, UserID: userID}
+		if err := s.notifier.NotifyMention(ctx, mention); err != nil {
+			log.Printf("Failed to notify user %s of comment %s: %v", userID, comment.ID, err)
+		}
+	}
+}
+
+// validate trims the body and returns the users to mention, without
+// duplicates or the author
+func validate(input Input, authorID uuid.UUID) (string, []uuid.UUID, error) {
+	body := strings.TrimSpace(input.Body)
+	if body == "" || len([]rune(body)) > MaxBodyLength || len(input.Mentions) > MaxMentions {
+		return "", nil, ErrInvalidComment
+	}
+
+	seen := make(map[uuid.UUID]bool, len(input.Mentions))
+	mentions := make([]uuid.UUID, 0, len(input.Mentions))
+	for _, id := range input.Mentions {
+		if id == uuid.Nil || id == authorID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		mentions = append(mentions, id)
+	}
+	return body, mentions, nil
+}
+
+func addMentions(tx *gorm.DB, commentID uuid.UUID, userIDs []uuid.UUID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	mentions := mentionsOf(commentID, userIDs)
+	return tx.Create(&mentions).Error
+}
+
+func mentionsOf(commentID uuid.UUID, userIDs []uuid.UUID) []Mention {
+	mentions := make([]Mention, len(userIDs))
+	for i, id := range userIDs {
+		mentions[i] = Mention{CommentID: commentID, UserID: id}
+	}
+	return mentions
+}
+
+func newEvent(comment *Comment, action string, userID uuid.UUID) *Event {
+	return &Event{
+		TenantID:     comment.TenantID,
+		DocumentType: comment.DocumentType,
+		DocumentID:   comment.DocumentID,
+		CommentID:    comment.ID,
+		Action:       action,
+		Body:         comment.Body,
+		UserID:       userID,
+	}
+}
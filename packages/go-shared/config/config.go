@@ -1,7 +1,11 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
 	"os"
 	"strconv"
 	"time"
@@ -9,18 +13,30 @@ import (
 
 // Config holds all configuration for a service
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	NATS     NATSConfig
-	JWT      JWTConfig
-	App      AppConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	NATS      NATSConfig
+	JWT       JWTConfig
+	Network   NetworkConfig
+	CORS      CORSConfig
+	Email     EmailConfig
+	Messaging MessagingConfig
+	App       AppConfig
+
+	// Secrets follows rotations in the secret store. The secret fields
+	// above hold the values at startup.
+	Secrets *Secrets
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Host string
-	Port int
+	Host            string
+	Port            int
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration // how long in-flight requests get to finish on shutdown
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -35,6 +51,18 @@ type DatabaseConfig struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+
+	// Tuning
+	DisablePreparedStatements bool          // required behind PgBouncer in transaction mode
+	StatementTimeout          time.Duration // 0 leaves the server default
+	SlowQueryThreshold        time.Duration
+	LogLevel                  string // silent, error, warn or info
+
+	// Read replica; reads are routed to it when ReplicaHost is set
+	ReplicaHost     string
+	ReplicaPort     int
+	ReplicaUser     string
+	ReplicaPassword string
 }
 
 // RedisConfig holds Redis configuration
@@ -57,6 +85,72 @@ type JWTConfig struct {
 	AccessTokenTTL   time.Duration
 	RefreshTokenTTL  time.Duration
 	SkipPaths        []string
+
+	// StepUpMaxAge is how recently a user must have entered their password
+	// or MFA code to take a sensitive action
+	StepUpMaxAge time.Duration
+}
+
+// NetworkConfig holds the settings for enforcing tenants' network
+// policies (IP allowlists and country restrictions)
+type NetworkConfig struct {
+	TenantServiceURL string        // where tenants' network policies are read from
+	AuthServiceURL   string        // where auditor grants are checked and their requests logged
+	CountryHeader    string        // header the edge reports the client's country in; empty disables country rules
+	TrustedProxies   []string      // addresses or ranges of the proxies in front of the service; client addresses are only read from forwarding headers they set
+	TrustedPlatform  string        // header a CDN reports the client's address in, such as CF-Connecting-IP; empty when there is none
+	PolicyCacheTTL   time.Duration // how long a tenant's policy is cached
+}
+
+// CORSConfig holds the origins browsers may call the API from
+type CORSConfig struct {
+	AllowedOrigins []string
+}
+
+// EmailConfig holds the settings for sending email. Only the chosen
+// provider's settings are needed.
+type EmailConfig struct {
+	Provider string // log, smtp, sendgrid or ses; log only writes emails to the service log
+	From     string // address emails are sent from
+	FromName string
+	AppURL   string // web app links in emails point to
+
+	SMTPHost     string
+	SMTPPort     int // 465 is implicit TLS; other ports upgrade with STARTTLS when offered
+	SMTPUsername string
+	SMTPPassword string
+
+	SendGridAPIKey     string
+	SendGridWebhookKey string // verification key of SendGrid's signed event webhook
+
+	SESRegion           string // credentials are read from the standard AWS environment variables
+	SESConfigurationSet string // publishes delivery, open and bounce events to SNS
+	SESEventTopicARN    string // SNS topic SES events are accepted from
+}
+
+// MessagingConfig holds the settings for sending SMS and WhatsApp
+// messages. Only the chosen providers' settings are needed.
+type MessagingConfig struct {
+	SMSProvider        string // log, msg91 or twilio; log only writes messages to the service log
+	WhatsAppProvider   string // log, twilio or meta (the WhatsApp Business Cloud API)
+	DefaultCountryCode string // dialling code assumed for numbers given without one
+
+	MSG91AuthKey  string
+	MSG91SenderID string
+
+	TwilioAccountSID   string
+	TwilioAuthToken    string
+	TwilioSMSFrom      string // number or messaging service SID SMS is sent from
+	TwilioWhatsAppFrom string // WhatsApp-enabled number, without the whatsapp: prefix
+
+	WhatsAppPhoneNumberID string
+	WhatsAppAccessToken   string
+	WhatsAppLanguage      string // language code of the approved templates
+
+	// Templates maps a channel and message kind, such as "sms.otp", to the
+	// provider's template: the DLT flow ID for MSG91, the content SID for
+	// Twilio and the template name for the WhatsApp Cloud API
+	Templates map[string]string
 }
 
 // AppConfig holds application-specific configuration
@@ -67,65 +161,311 @@ type AppConfig struct {
 	Version     string
 }
 
-// Load loads configuration from environment variables
+// Defaults are a service's own defaults for settings its environment does
+// not set
+type Defaults struct {
+	DatabaseName string        // defaults to <service>_db
+	Port         int           // defaults to 8080
+	ReadTimeout  time.Duration // defaults to 15s
+	WriteTimeout time.Duration // defaults to 15s
+}
+
+// productionOrigins are the web app's origins; development adds the local
+// web and mobile dev servers
+var (
+	productionOrigins = []string{
+		"https://app.bookkeep.in",
+		"https://www.bookkeep.in",
+		"https://bookkeep.in",
+	}
+	developmentOrigins = []string{
+		"http://localhost:3000",
+		"http://localhost:3001",
+		"exp://localhost:19000",
+	}
+)
+
+// Load loads configuration from environment variables with the shared
+// defaults. See LoadWithDefaults.
 func Load(serviceName string) (*Config, error) {
-	environment := GetEnv("GIN_MODE", "debug")
-	jwtSecret := GetEnv("JWT_SECRET", "")
+	return LoadWithDefaults(serviceName, Defaults{})
+}
+
+// LoadWithDefaults loads configuration from environment variables, read
+// through Env so a service's own variables override the shared ones.
+// Secrets come from the store named by SECRETS_PROVIDER when one is set,
+// and are refreshed every SECRETS_REFRESH_INTERVAL for the life of the
+// process. Every invalid or missing setting is reported in one error.
+func LoadWithDefaults(serviceName string, defaults Defaults) (*Config, error) {
+	if defaults.DatabaseName == "" {
+		defaults.DatabaseName = serviceName + "_db"
+	}
+	if defaults.Port == 0 {
+		defaults.Port = 8080
+	}
+	if defaults.ReadTimeout == 0 {
+		defaults.ReadTimeout = 15 * time.Second
+	}
+	if defaults.WriteTimeout == 0 {
+		defaults.WriteTimeout = 15 * time.Second
+	}
 
-	// Validate JWT_SECRET in production
-	if environment == "release" && jwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is required in production mode")
+	env := NewEnv(serviceName)
+	provider, err := newSecretProvider(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	secrets := NewSecrets(provider, env.Duration("SECRETS_REFRESH_INTERVAL", 5*time.Minute))
+	if err := secrets.Refresh(context.Background()); err != nil {
+		return nil, err
 	}
+	go secrets.Watch(context.Background())
 
-	// Validate JWT_SECRET minimum length
-	if jwtSecret != "" && len(jwtSecret) < 32 {
-		return nil, fmt.Errorf("JWT_SECRET must be at least 32 characters long")
+	environment := env.String("GIN_MODE", "debug")
+	origins := productionOrigins
+	if environment != "release" {
+		origins = append(append([]string{}, productionOrigins...), developmentOrigins...)
 	}
 
+	dbPort := env.Int("DB_PORT", 5432)
+	dbUser := env.String("DB_USER", "postgres")
 	config := &Config{
 		Server: ServerConfig{
-			Host: GetEnv("HOST", "0.0.0.0"),
-			Port: GetEnvAsInt("PORT", 8080),
+			Host:            env.String("HOST", "0.0.0.0"),
+			Port:            env.Int("PORT", defaults.Port),
+			ReadTimeout:     env.Duration("SERVER_READ_TIMEOUT", defaults.ReadTimeout),
+			WriteTimeout:    env.Duration("SERVER_WRITE_TIMEOUT", defaults.WriteTimeout),
+			IdleTimeout:     env.Duration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout: env.Duration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
 		},
 		Database: DatabaseConfig{
-			Host:            GetEnv("DB_HOST", "localhost"),
-			Port:            GetEnvAsInt("DB_PORT", 5432),
-			User:            GetEnv("DB_USER", "postgres"),
-			Password:        GetEnv("DB_PASSWORD", "postgres"),
-			DBName:          GetEnv("DB_NAME", serviceName+"_db"),
-			SSLMode:         GetEnv("DB_SSLMODE", "disable"),
-			MaxOpenConns:    GetEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns:    GetEnvAsInt("DB_MAX_IDLE_CONNS", 5),
-			ConnMaxLifetime: GetEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
-			ConnMaxIdleTime: GetEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			Host:            env.String("DB_HOST", "localhost"),
+			Port:            dbPort,
+			User:            dbUser,
+			Password:        secrets.GetOr("DB_PASSWORD", "postgres"),
+			DBName:          env.String("DB_NAME", defaults.DatabaseName),
+			SSLMode:         env.String("DB_SSLMODE", "disable"),
+			MaxOpenConns:    env.Int("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    env.Int("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: env.Duration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			ConnMaxIdleTime: env.Duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+			DisablePreparedStatements: env.Bool("DB_DISABLE_PREPARED_STATEMENTS", false),
+			StatementTimeout:          env.Duration("DB_STATEMENT_TIMEOUT", 0),
+			SlowQueryThreshold:        env.Duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+			LogLevel:                  env.String("DB_LOG_LEVEL", "warn"),
+
+			ReplicaHost:     env.String("DB_REPLICA_HOST", ""),
+			ReplicaPort:     env.Int("DB_REPLICA_PORT", dbPort),
+			ReplicaUser:     env.String("DB_REPLICA_USER", dbUser),
+			ReplicaPassword: secrets.GetOr("DB_REPLICA_PASSWORD", secrets.GetOr("DB_PASSWORD", "postgres")),
 		},
 		Redis: RedisConfig{
-			Host:     GetEnv("REDIS_HOST", "localhost"),
-			Port:     GetEnvAsInt("REDIS_PORT", 6379),
-			Password: GetEnv("REDIS_PASSWORD", ""),
-			DB:       GetEnvAsInt("REDIS_DB", 0),
+			Host:     env.String("REDIS_HOST", "localhost"),
+			Port:     env.Int("REDIS_PORT", 6379),
+			Password: secrets.GetOr("REDIS_PASSWORD", ""),
+			DB:       env.Int("REDIS_DB", 0),
 		},
 		NATS: NATSConfig{
-			URL: GetEnv("NATS_URL", "nats://localhost:4222"),
+			URL: env.String("NATS_URL", "nats://localhost:4222"),
 		},
 		JWT: JWTConfig{
-			Secret:          jwtSecret,
-			Issuer:          GetEnv("JWT_ISSUER", "bookkeeping-auth"),
-			AccessTokenTTL:  GetEnvAsDuration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
-			RefreshTokenTTL: GetEnvAsDuration("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			Secret:          secrets.GetOr("JWT_SECRET", ""),
+			Issuer:          env.String("JWT_ISSUER", "bookkeeping-auth"),
+			AccessTokenTTL:  env.Duration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL: env.Duration("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			StepUpMaxAge:    env.Duration("JWT_STEP_UP_MAX_AGE", 5*time.Minute),
 			SkipPaths:       []string{"/health", "/ready", "/metrics"},
 		},
+		Network: NetworkConfig{
+			TenantServiceURL: env.String("TENANT_SERVICE_URL", "http://bookkeeping-tenant-service:8080"),
+			AuthServiceURL:   env.String("AUTH_SERVICE_URL", "http://bookkeeping-auth-service:8080"),
+			CountryHeader:    env.String("GEO_COUNTRY_HEADER", ""),
+			TrustedProxies:   env.List("TRUSTED_PROXIES", nil),
+			TrustedPlatform:  env.String("TRUSTED_PLATFORM", ""),
+			PolicyCacheTTL:   env.Duration("NETWORK_POLICY_CACHE_TTL", time.Minute),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: env.List("CORS_ALLOWED_ORIGINS", origins),
+		},
+		Email: EmailConfig{
+			Provider:       env.String("EMAIL_PROVIDER", "log"),
+			From:           env.String("EMAIL_FROM", "no-reply@bookkeep.in"),
+			FromName:       env.String("EMAIL_FROM_NAME", "BookKeep"),
+			AppURL:         env.String("APP_URL", "https://app.bookkeep.in"),
+			SMTPHost:       env.String("SMTP_HOST", ""),
+			SMTPPort:       env.Int("SMTP_PORT", 587),
+			SMTPUsername:   env.String("SMTP_USER", ""),
+			SMTPPassword:   secrets.GetOr("SMTP_PASS", ""),
+			SendGridAPIKey: secrets.GetOr("SENDGRID_API_KEY", ""),
+			SESRegion:      env.String("SES_REGION", env.String("AWS_REGION", "ap-south-1")),
+
+			SendGridWebhookKey:  env.String("SENDGRID_WEBHOOK_KEY", ""),
+			SESConfigurationSet: env.String("SES_CONFIGURATION_SET", ""),
+			SESEventTopicARN:    env.String("SES_EVENT_TOPIC_ARN", ""),
+		},
+		Messaging: MessagingConfig{
+			SMSProvider:           env.String("SMS_PROVIDER", "log"),
+			WhatsAppProvider:      env.String("WHATSAPP_PROVIDER", "log"),
+			DefaultCountryCode:    env.String("PHONE_DEFAULT_COUNTRY_CODE", "91"),
+			MSG91AuthKey:          secrets.GetOr("MSG91_AUTH_KEY", ""),
+			MSG91SenderID:         env.String("MSG91_SENDER_ID", ""),
+			TwilioAccountSID:      env.String("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:       secrets.GetOr("TWILIO_AUTH_TOKEN", ""),
+			TwilioSMSFrom:         env.String("TWILIO_SMS_FROM", ""),
+			TwilioWhatsAppFrom:    env.String("TWILIO_WHATSAPP_FROM", ""),
+			WhatsAppPhoneNumberID: env.String("WHATSAPP_PHONE_NUMBER_ID", ""),
+			WhatsAppAccessToken:   secrets.GetOr("WHATSAPP_ACCESS_TOKEN", ""),
+			WhatsAppLanguage:      env.String("WHATSAPP_LANGUAGE", "en"),
+			Templates: map[string]string{
+				// MSG91_TEMPLATE_ID predates the other kinds and is the OTP flow
+				"sms.otp":           env.String("SMS_TEMPLATE_OTP", env.String("MSG91_TEMPLATE_ID", "")),
+				"sms.invoice":       env.String("SMS_TEMPLATE_INVOICE", ""),
+				"sms.reminder":      env.String("SMS_TEMPLATE_REMINDER", ""),
+				"whatsapp.otp":      env.String("WHATSAPP_TEMPLATE_OTP", ""),
+				"whatsapp.invoice":  env.String("WHATSAPP_TEMPLATE_INVOICE", ""),
+				"whatsapp.reminder": env.String("WHATSAPP_TEMPLATE_REMINDER", ""),
+			},
+		},
 		App: AppConfig{
 			Name:        serviceName,
 			Environment: environment,
-			LogLevel:    GetEnv("LOG_LEVEL", "info"),
-			Version:     GetEnv("APP_VERSION", "0.1.0"),
+			LogLevel:    env.String("LOG_LEVEL", "info"),
+			Version:     env.String("APP_VERSION", "0.1.0"),
 		},
+		Secrets: secrets,
 	}
 
+	config.validate(env)
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
+// validate records every setting that is out of range or inconsistent
+func (c *Config) validate(env *Env) {
+	if !oneOf(c.App.Environment, "debug", "release", "test") {
+		env.Fail("GIN_MODE: %q must be debug, release or test", c.App.Environment)
+	}
+
+	// JWT_SECRET is required in production and must be long enough to
+	// resist brute force wherever it is set
+	if c.IsProduction() && c.JWT.Secret == "" {
+		env.Fail("JWT_SECRET is required in production mode")
+	}
+	if c.JWT.Secret != "" && len(c.JWT.Secret) < 32 {
+		env.Fail("JWT_SECRET must be at least 32 characters long")
+	}
+	if c.JWT.AccessTokenTTL <= 0 || c.JWT.RefreshTokenTTL < c.JWT.AccessTokenTTL {
+		env.Fail("JWT_ACCESS_TOKEN_TTL must be positive and no longer than JWT_REFRESH_TOKEN_TTL")
+	}
+
+	checkPort(env, "PORT", c.Server.Port)
+	checkPort(env, "DB_PORT", c.Database.Port)
+	checkPort(env, "REDIS_PORT", c.Redis.Port)
+	if c.Database.ReplicaHost != "" {
+		checkPort(env, "DB_REPLICA_PORT", c.Database.ReplicaPort)
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		env.Fail("SERVER_READ_TIMEOUT, SERVER_WRITE_TIMEOUT, SERVER_IDLE_TIMEOUT and SERVER_SHUTDOWN_TIMEOUT must be positive")
+	}
+
+	if c.Database.DBName == "" || c.Database.User == "" {
+		env.Fail("DB_NAME and DB_USER must not be empty")
+	}
+	if !oneOf(c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full") {
+		env.Fail("DB_SSLMODE: %q is not a PostgreSQL sslmode", c.Database.SSLMode)
+	}
+	if !oneOf(c.Database.LogLevel, "silent", "error", "warn", "info") {
+		env.Fail("DB_LOG_LEVEL: %q must be silent, error, warn or info", c.Database.LogLevel)
+	}
+	if c.Database.MaxOpenConns < 1 || c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		env.Fail("DB_MAX_OPEN_CONNS must be at least 1 and DB_MAX_IDLE_CONNS between 0 and DB_MAX_OPEN_CONNS")
+	}
+	if c.Database.StatementTimeout < 0 || c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		env.Fail("DB_STATEMENT_TIMEOUT, DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative")
+	}
+
+	switch c.Email.Provider {
+	case "log":
+	case "smtp":
+		if c.Email.SMTPHost == "" {
+			env.Fail("SMTP_HOST is required when EMAIL_PROVIDER is smtp")
+		}
+		checkPort(env, "SMTP_PORT", c.Email.SMTPPort)
+	case "sendgrid":
+		if c.Email.SendGridAPIKey == "" {
+			env.Fail("SENDGRID_API_KEY is required when EMAIL_PROVIDER is sendgrid")
+		}
+	case "ses":
+		if c.Email.SESRegion == "" {
+			env.Fail("SES_REGION is required when EMAIL_PROVIDER is ses")
+		}
+	default:
+		env.Fail("EMAIL_PROVIDER: %q must be log, smtp, sendgrid or ses", c.Email.Provider)
+	}
+	if _, err := mail.ParseAddress(c.Email.From); err != nil {
+		env.Fail("EMAIL_FROM: %q is not an email address", c.Email.From)
+	}
+
+	twilio := c.Messaging.TwilioAccountSID != "" && c.Messaging.TwilioAuthToken != ""
+	switch c.Messaging.SMSProvider {
+	case "log":
+	case "msg91":
+		if c.Messaging.MSG91AuthKey == "" {
+			env.Fail("MSG91_AUTH_KEY is required when SMS_PROVIDER is msg91")
+		}
+	case "twilio":
+		if !twilio || c.Messaging.TwilioSMSFrom == "" {
+			env.Fail("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_SMS_FROM are required when SMS_PROVIDER is twilio")
+		}
+	default:
+		env.Fail("SMS_PROVIDER: %q must be log, msg91 or twilio", c.Messaging.SMSProvider)
+	}
+	switch c.Messaging.WhatsAppProvider {
+	case "log":
+	case "twilio":
+		if !twilio || c.Messaging.TwilioWhatsAppFrom == "" {
+			env.Fail("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_WHATSAPP_FROM are required when WHATSAPP_PROVIDER is twilio")
+		}
+	case "meta":
+		if c.Messaging.WhatsAppPhoneNumberID == "" || c.Messaging.WhatsAppAccessToken == "" {
+			env.Fail("WHATSAPP_PHONE_NUMBER_ID and WHATSAPP_ACCESS_TOKEN are required when WHATSAPP_PROVIDER is meta")
+		}
+	default:
+		env.Fail("WHATSAPP_PROVIDER: %q must be log, twilio or meta", c.Messaging.WhatsAppProvider)
+	}
+	if code, err := strconv.Atoi(c.Messaging.DefaultCountryCode); err != nil || code < 1 || code > 999 {
+		env.Fail("PHONE_DEFAULT_COUNTRY_CODE: %q must be a dialling code such as 91", c.Messaging.DefaultCountryCode)
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		env.Fail("CORS_ALLOWED_ORIGINS must list at least one origin")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			env.Fail("CORS_ALLOWED_ORIGINS: %q is not an origin such as https://app.bookkeep.in", origin)
+		}
+	}
+}
+
+func checkPort(env *Env, key string, port int) {
+	if port < 1 || port > 65535 {
+		env.Fail("%s: %d is not a port number", key, port)
+	}
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
 // GetDatabaseDSN returns the database connection string
 func (c *Config) GetDatabaseDSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -138,6 +478,52 @@ func (c *Config) GetDatabaseDSN() string {
 	)
 }
 
+// JWTSecret returns the current JWT signing secret, following rotations
+func (c *Config) JWTSecret() string {
+	if c.Secrets != nil {
+		if secret := c.Secrets.Get("JWT_SECRET"); len(secret) >= 32 {
+			return secret
+		}
+	}
+	return c.JWT.Secret
+}
+
+// JWTSecrets returns the secrets a token may be signed with: the current
+// one and, after a rotation, the previous one, so tokens issued just
+// before the rotation stay valid until they expire
+func (c *Config) JWTSecrets() []string {
+	secrets := []string{c.JWTSecret()}
+	if c.Secrets != nil {
+		if previous := c.Secrets.Previous("JWT_SECRET"); previous != "" && previous != secrets[0] {
+			secrets = append(secrets, previous)
+		}
+	}
+	return secrets
+}
+
+// jwtRefreshInterval is the least time between the fetches tokens signed
+// with an unknown secret cause
+const jwtRefreshInterval = 30 * time.Second
+
+// RefreshJWTSecrets fetches the secrets again when a token matched none of
+// JWTSecrets, as it may be signed with a secret rotated since the last
+// periodic refresh, and reports whether they were fetched. Fetches are at
+// most one per jwtRefreshInterval.
+func (c *Config) RefreshJWTSecrets() bool {
+	if c.Secrets == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	refreshed, err := c.Secrets.RefreshIfOlder(ctx, jwtRefreshInterval)
+	if err != nil {
+		log.Printf("config: %v", err)
+		return false
+	}
+	return refreshed
+}
+
 // GetServerAddress returns the server address
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
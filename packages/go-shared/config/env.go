@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Env reads typed settings from the environment. A value that is set but
+// cannot be parsed is an error, not a silent fall back to the default, and
+// errors are collected so a misconfigured service reports every problem at
+// once instead of one per restart.
+//
+// A service's own variables take precedence over the shared ones: for
+// invoice-service, INVOICE_SERVICE_DB_NAME overrides DB_NAME, so services
+// that share an environment file can still be configured apart.
+type Env struct {
+	service string
+	prefix  string
+	errs    []string
+}
+
+// NewEnv creates an environment reader for a service
+func NewEnv(serviceName string) *Env {
+	prefix := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(serviceName))
+	if prefix != "" {
+		prefix += "_"
+	}
+	return &Env{service: serviceName, prefix: prefix}
+}
+
+// lookup returns the value of key and the variable it was read from
+func (e *Env) lookup(key string) (string, string, bool) {
+	if e.prefix != "" {
+		if value := strings.TrimSpace(os.Getenv(e.prefix + key)); value != "" {
+			return value, e.prefix + key, true
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value, key, true
+	}
+	return "", key, false
+}
+
+// String returns the value of key, or def when it is not set
+func (e *Env) String(key, def string) string {
+	if value, _, ok := e.lookup(key); ok {
+		return value
+	}
+	return def
+}
+
+// Required returns the value of key, recording an error when it is not set
+func (e *Env) Required(key string) string {
+	value, _, ok := e.lookup(key)
+	if !ok {
+		e.Fail("%s is required", key)
+	}
+	return value
+}
+
+// Int returns key as a whole number, or def when it is not set
+func (e *Env) Int(key string, def int) int {
+	value, name, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		e.Fail("%s: %q is not a whole number", name, value)
+		return def
+	}
+	return n
+}
+
+// Bool returns key as a boolean, or def when it is not set
+func (e *Env) Bool(key string, def bool) bool {
+	value, name, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.Fail("%s: %q is not true or false", name, value)
+		return def
+	}
+	return b
+}
+
+// Duration returns key as a duration such as 30s or 5m, or def when it is
+// not set
+func (e *Env) Duration(key string, def time.Duration) time.Duration {
+	value, name, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.Fail("%s: %q is not a duration such as 30s or 5m", name, value)
+		return def
+	}
+	return d
+}
+
+// List returns key split on commas, or def when it is not set
+func (e *Env) List(key string, def []string) []string {
+	value, _, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Fail records a configuration error
+func (e *Env) Fail(format string, args ...interface{}) {
+	e.errs = append(e.errs, fmt.Sprintf(format, args...))
+}
+
+// Err returns every error recorded, or nil if there were none
+func (e *Env) Err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration for %s:\n  %s", e.service, strings.Join(e.errs, "\n  "))
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider reads secrets from a directory with one file per secret,
+// named after the key, as mounted by Kubernetes secret volumes or Docker
+// secrets. Kubernetes updates mounted files in place when the secret
+// changes, so refreshing picks up rotations.
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a provider reading secrets from dir
+func NewFileProvider(dir string) (*FileProvider, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("secrets directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("secrets directory %s is not a directory", dir)
+	}
+	return &FileProvider{dir: dir}, nil
+}
+
+// Name returns the provider name
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+// Fetch reads every secret file in the directory
+func (p *FileProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		// Kubernetes keeps the real files in hidden ..data directories
+		// and links them into place
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.dir, entry.Name()))
+		if err != nil {
+			if entry.IsDir() {
+				continue
+			}
+			return nil, err
+		}
+		values[entry.Name()] = strings.TrimRight(string(data), "\r\n")
+	}
+	return values, nil
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider fetches a service's secrets from an external store. Fetch
+// returns every secret the service has, keyed by the environment variable
+// name it replaces, e.g. JWT_SECRET or DB_PASSWORD.
+type SecretProvider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Secrets holds the values fetched from a SecretProvider and keeps them
+// current. Values not in the store fall back to the environment.
+type Secrets struct {
+	provider SecretProvider
+	interval time.Duration
+
+	mu       sync.RWMutex
+	values   map[string]string
+	previous map[string]string
+	watchers map[string][]func(value string)
+
+	// fetchMu serializes fetches; fetched is when the last one started
+	fetchMu sync.Mutex
+	fetched time.Time
+}
+
+// NewSecrets creates a secret set refreshed from provider every interval.
+// A nil provider reads only the environment.
+func NewSecrets(provider SecretProvider, interval time.Duration) *Secrets {
+	return &Secrets{
+		provider: provider,
+		interval: interval,
+		values:   make(map[string]string),
+		previous: make(map[string]string),
+		watchers: make(map[string][]func(string)),
+	}
+}
+
+// Get returns the current value of a secret, or the environment variable
+// of the same name if the store does not have it
+func (s *Secrets) Get(key string) string {
+	s.mu.RLock()
+	value, ok := s.values[key]
+	s.mu.RUnlock()
+	if ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// GetOr returns the secret like Get, or defaultValue when it is empty
+func (s *Secrets) GetOr(key, defaultValue string) string {
+	if value := s.Get(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// List returns a comma-separated secret as a list, such as the signing
+// secrets of a webhook while one is rotated, or nil when it is not set
+func (s *Secrets) List(key string) []string {
+	var list []string
+	for _, item := range strings.Split(s.Get(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Previous returns the value a secret had before it was last rotated, or ""
+func (s *Secrets) Previous(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.previous[key]
+}
+
+// OnChange calls fn with the new value whenever the secret is rotated
+func (s *Secrets) OnChange(key string, fn func(value string)) {
+	s.mu.Lock()
+	s.watchers[key] = append(s.watchers[key], fn)
+	s.mu.Unlock()
+}
+
+// Refresh fetches the secrets again and notifies watchers of the ones that
+// changed. Secrets missing from the response keep their value, so a
+// partial outage of the store cannot blank them.
+func (s *Secrets) Refresh(ctx context.Context) error {
+	if s.provider == nil {
+		return nil
+	}
+
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	return s.refresh(ctx)
+}
+
+// RefreshIfOlder refreshes the secrets unless they were fetched less than
+// minAge ago, and reports whether it fetched them. It is for callers that
+// find a secret out of date between the periodic refreshes, such as a
+// token signed with a secret rotated since; minAge keeps a flood of them
+// from hammering the store.
+func (s *Secrets) RefreshIfOlder(ctx context.Context, minAge time.Duration) (bool, error) {
+	if s.provider == nil {
+		return false, nil
+	}
+
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	if time.Since(s.fetched) < minAge {
+		return false, nil
+	}
+	return true, s.refresh(ctx)
+}
+
+func (s *Secrets) refresh(ctx context.Context) error {
+	s.fetched = time.Now()
+	fetched, err := s.provider.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch secrets from %s: %w", s.provider.Name(), err)
+	}
+
+	type change struct {
+		key, value string
+		watchers   []func(string)
+	}
+	var changes []change
+
+	s.mu.Lock()
+	for key, value := range fetched {
+		old, known := s.values[key]
+		if known && old == value {
+			continue
+		}
+		s.values[key] = value
+		if known {
+			s.previous[key] = old
+			changes = append(changes, change{key: key, value: value, watchers: s.watchers[key]})
+		}
+	}
+	s.mu.Unlock()
+
+	for _, c := range changes {
+		log.Printf("config: secret %s was rotated", c.key)
+		for _, fn := range c.watchers {
+			fn(c.value)
+		}
+	}
+	return nil
+}
+
+// Watch refreshes the secrets every interval until ctx is done. Failures
+// are logged and the last known values kept.
+func (s *Secrets) Watch(ctx context.Context) {
+	if s.provider == nil || s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				log.Printf("config: %v", err)
+			}
+		}
+	}
+}
+
+// newSecretProvider builds the provider named by SECRETS_PROVIDER, or nil
+// when secrets come from the environment only
+func newSecretProvider(serviceName string) (SecretProvider, error) {
+	defaultPath := "bookkeep/" + serviceName
+
+	switch provider := GetEnv("SECRETS_PROVIDER", ""); provider {
+	case "", "env":
+		return nil, nil
+	case "vault":
+		return NewVaultProvider(VaultConfig{
+			Addr:      GetEnv("VAULT_ADDR", "http://127.0.0.1:8200"),
+			Token:     GetEnv("VAULT_TOKEN", ""),
+			TokenFile: GetEnv("VAULT_TOKEN_FILE", ""),
+			Namespace: GetEnv("VAULT_NAMESPACE", ""),
+			Mount:     GetEnv("VAULT_KV_MOUNT", "secret"),
+			Path:      GetEnv("VAULT_SECRET_PATH", defaultPath),
+		})
+	case "aws":
+		return NewAWSSecretsProvider(AWSSecretsConfig{
+			Region:   GetEnv("AWS_REGION", GetEnv("AWS_DEFAULT_REGION", "ap-south-1")),
+			SecretID: GetEnv("AWS_SECRET_ID", defaultPath),
+		})
+	case "file":
+		return NewFileProvider(GetEnv("SECRETS_DIR", "/run/secrets"))
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q", provider)
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultConfig locates a service's secrets in a HashiCorp Vault KV v2 engine
+type VaultConfig struct {
+	Addr      string
+	Token     string
+	TokenFile string // Read on every fetch, for tokens renewed by a Vault agent
+	Namespace string
+	Mount     string // KV engine mount, e.g. secret
+	Path      string // Secret path within the mount
+}
+
+// VaultProvider reads secrets from Vault's KV v2 HTTP API
+type VaultProvider struct {
+	cfg    VaultConfig
+	client *http.Client
+}
+
+// NewVaultProvider creates a Vault secret provider
+func NewVaultProvider(cfg VaultConfig) (*VaultProvider, error) {
+	if cfg.Token == "" && cfg.TokenFile == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN or VAULT_TOKEN_FILE is required for the vault secrets provider")
+	}
+	return &VaultProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *VaultProvider) Name() string {
+	return "vault"
+}
+
+// Fetch reads the latest version of the secret
+func (p *VaultProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	token := p.cfg.Token
+	if p.cfg.TokenFile != "" {
+		data, err := os.ReadFile(p.cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimRight(p.cfg.Addr, "/"), p.cfg.Mount, strings.TrimLeft(p.cfg.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	return stringValues(secret.Data.Data), nil
+}
+
+// stringValues flattens a JSON secret to strings, so numbers such as
+// ports can be stored unquoted
+func stringValues(data map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		} else if value != nil {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values
+}
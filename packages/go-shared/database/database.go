@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -22,36 +23,102 @@ type Config struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+
+	// Tuning
+	DisablePreparedStatements bool
+	StatementTimeout          time.Duration // 0 leaves the server default
+	SlowQueryThreshold        time.Duration // defaults to 200ms
+	LogLevel                  string        // silent, error, warn (default) or info
+
+	// Read replica. When ReplicaHost is set, queries outside transactions
+	// are routed to the replica; see UsePrimary. The replica shares the
+	// primary's database name and pool settings.
+	ReplicaHost     string
+	ReplicaPort     int
+	ReplicaUser     string
+	ReplicaPassword string
 }
 
 // Connect establishes a connection to PostgreSQL database using GORM
 func Connect(config Config) (*gorm.DB, error) {
+	dsn := buildDSN(config, config.Host, config.Port, config.User, config.Password)
+
+	db, err := gorm.Open(postgres.Open(dsn), newGormConfig(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := configurePool(db, config); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Successfully connected to PostgreSQL database: %s", config.DBName)
+
+	if config.ReplicaHost != "" {
+		if err := useReplica(db, config); err != nil {
+			return nil, err
+		}
+		log.Printf("Routing reads to replica at %s:%d", config.ReplicaHost, config.ReplicaPort)
+	}
+
+	return db, nil
+}
+
+func buildDSN(config Config, host string, port int, user, password string) string {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		config.Host,
-		config.Port,
-		config.User,
-		config.Password,
+		host,
+		port,
+		user,
+		password,
 		config.DBName,
 		config.SSLMode,
 	)
+	if config.StatementTimeout > 0 {
+		// Sent as a run-time parameter when the connection starts
+		dsn += fmt.Sprintf(" statement_timeout=%d", config.StatementTimeout.Milliseconds())
+	}
+	return dsn
+}
 
-	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+func newGormConfig(config Config) *gorm.Config {
+	return &gorm.Config{
+		Logger: newLogger(config),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
-		PrepareStmt: true,
+		PrepareStmt: !config.DisablePreparedStatements,
 	}
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+func newLogger(config Config) logger.Interface {
+	level := logger.Warn
+	switch config.LogLevel {
+	case "silent":
+		level = logger.Silent
+	case "error":
+		level = logger.Error
+	case "info":
+		level = logger.Info
 	}
 
+	slowThreshold := config.SlowQueryThreshold
+	if slowThreshold <= 0 {
+		slowThreshold = 200 * time.Millisecond
+	}
+
+	return logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             slowThreshold,
+		LogLevel:                  level,
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  false,
+	})
+}
+
+func configurePool(db *gorm.DB, config Config) error {
 	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get underlying SQL database: %w", err)
+		return fmt.Errorf("failed to get underlying SQL database: %w", err)
 	}
 
 	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
@@ -60,11 +127,9 @@ func Connect(config Config) (*gorm.DB, error) {
 	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
 	if err := sqlDB.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return fmt.Errorf("failed to ping database: %w", err)
 	}
-
-	log.Printf("Successfully connected to PostgreSQL database: %s", config.DBName)
-	return db, nil
+	return nil
 }
 
 // Close closes the GORM database connection
@@ -82,6 +147,12 @@ func Close(db *gorm.DB) error {
 		return fmt.Errorf("failed to close database connection: %w", err)
 	}
 
+	if replica := replicaOf(db); replica != nil {
+		if err := Close(replica); err != nil {
+			return err
+		}
+	}
+
 	log.Println("Database connection closed")
 	return nil
 }
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// MetricsHandler serves connection pool statistics for the primary and, if
+// configured, the replica in the Prometheus text exposition format
+func MetricsHandler(db *gorm.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w, db)
+	})
+}
+
+// WriteMetrics writes connection pool statistics in the Prometheus text
+// exposition format
+func WriteMetrics(w io.Writer, db *gorm.DB) {
+	pools := map[string]sql.DBStats{}
+	if sqlDB, err := db.DB(); err == nil {
+		pools["primary"] = sqlDB.Stats()
+	}
+	if replica := replicaOf(db); replica != nil {
+		if sqlDB, err := replica.DB(); err == nil {
+			pools["replica"] = sqlDB.Stats()
+		}
+	}
+
+	metrics := []struct {
+		name  string
+		kind  string
+		help  string
+		value func(sql.DBStats) float64
+	}{
+		{"db_pool_max_open_connections", "gauge", "Maximum number of open connections to the database.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"db_pool_open_connections", "gauge", "Number of established connections, in use and idle.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"db_pool_in_use_connections", "gauge", "Number of connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"db_pool_idle_connections", "gauge", "Number of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }},
+		{"db_pool_wait_count_total", "counter", "Total number of connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"db_pool_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"db_pool_max_idle_closed_total", "counter", "Total number of connections closed due to the idle connection limit.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"db_pool_max_idle_time_closed_total", "counter", "Total number of connections closed due to the idle time limit.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+		{"db_pool_max_lifetime_closed_total", "counter", "Total number of connections closed due to the lifetime limit.",
+			func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	}
+
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, pool := range []string{"primary", "replica"} {
+			if stats, ok := pools[pool]; ok {
+				fmt.Fprintf(w, "%s{pool=%q} %g\n", m.name, pool, m.value(stats))
+			}
+		}
+	}
+}
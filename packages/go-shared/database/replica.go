@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// ErrNoReplica is returned when a replica operation is requested on a
+// connection that was opened without one
+var ErrNoReplica = errors.New("no read replica configured")
+
+const (
+	replicaPluginName = "database:replica"
+	usePrimaryKey     = "database:use_primary"
+)
+
+// replicaPlugin routes reads made through the primary *gorm.DB to a read
+// replica. Writes, raw Exec calls and everything inside a transaction stay
+// on the primary.
+type replicaPlugin struct {
+	replica *gorm.DB
+}
+
+func (p *replicaPlugin) Name() string {
+	return replicaPluginName
+}
+
+func (p *replicaPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(replicaPluginName, p.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register(replicaPluginName, p.route)
+}
+
+func (p *replicaPlugin) route(db *gorm.DB) {
+	if _, inTransaction := db.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+		return
+	}
+	if usePrimary, ok := db.Get(usePrimaryKey); ok && usePrimary.(bool) {
+		return
+	}
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return
+	}
+	db.Statement.ConnPool = p.replica.Statement.ConnPool
+}
+
+func useReplica(db *gorm.DB, config Config) error {
+	if config.ReplicaPort == 0 {
+		config.ReplicaPort = config.Port
+	}
+	if config.ReplicaUser == "" {
+		config.ReplicaUser, config.ReplicaPassword = config.User, config.Password
+	}
+	dsn := buildDSN(config, config.ReplicaHost, config.ReplicaPort, config.ReplicaUser, config.ReplicaPassword)
+
+	replica, err := gorm.Open(postgres.Open(dsn), newGormConfig(config))
+	if err != nil {
+		return fmt.Errorf("failed to connect to replica: %w", err)
+	}
+	if err := configurePool(replica, config); err != nil {
+		return fmt.Errorf("replica: %w", err)
+	}
+
+	return db.Use(&replicaPlugin{replica: replica})
+}
+
+// UsePrimary returns a session that reads from the primary even when a
+// replica is configured, for reads that must see the latest writes
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Set(usePrimaryKey, true).Session(&gorm.Session{})
+}
+
+// Replica returns the read replica connection, or nil when none is
+// configured
+func Replica(db *gorm.DB) *gorm.DB {
+	return replicaOf(db)
+}
+
+// ReplicaLag reports how far the replica trails the primary along with the
+// commit time of the newest transaction it has applied. A replica that has
+// replayed all the WAL it received is considered current.
+func ReplicaLag(ctx context.Context, db *gorm.DB) (time.Duration, time.Time, error) {
+	replica := replicaOf(db)
+	if replica == nil {
+		return 0, time.Time{}, ErrNoReplica
+	}
+
+	var row struct {
+		ReplayedAt time.Time
+		Now        time.Time
+	}
+	err := replica.WithContext(ctx).Raw(`
+		SELECT
+			CASE
+				WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN now()
+				ELSE COALESCE(pg_last_xact_replay_timestamp(), 'epoch'::timestamptz)
+			END AS replayed_at,
+			now() AS now
+	`).Scan(&row).Error
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to check replica lag: %w", err)
+	}
+
+	lag := row.Now.Sub(row.ReplayedAt)
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, row.ReplayedAt, nil
+}
+
+func replicaOf(db *gorm.DB) *gorm.DB {
+	if plugin, ok := db.Config.Plugins[replicaPluginName].(*replicaPlugin); ok {
+		return plugin.replica
+	}
+	return nil
+}
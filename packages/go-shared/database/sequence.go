@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NumberSequence is a per-tenant counter behind document numbers such as
+// invoice or bill numbers. Services that allocate numbers migrate it along
+// with their own models.
+type NumberSequence struct {
+	TenantID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name      string    `gorm:"size:100;primaryKey"`
+	LastValue int64     `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// TableName returns the table name for NumberSequence
+func (NumberSequence) TableName() string {
+	return "number_sequences"
+}
+
+// SeedFunc returns the last number issued before a sequence existed
+type SeedFunc func(tx *gorm.DB) (int64, error)
+
+// NextNumber allocates the next value of a tenant's named sequence. The
+// counter row is incremented in place, so concurrent callers block on its
+// row lock and never receive the same value. seed runs only when the
+// sequence is first used.
+//
+// A value is consumed even if the document it was allocated for is never
+// saved, so numbering can have gaps but never duplicates.
+func NextNumber(ctx context.Context, db *gorm.DB, tenantID uuid.UUID, name string, seed SeedFunc) (int64, error) {
+	var value int64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Raw(`
+			UPDATE number_sequences SET last_value = last_value + 1, updated_at = NOW()
+			WHERE tenant_id = ? AND name = ?
+			RETURNING last_value
+		`, tenantID, name).Scan(&value)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		last, err := seed(tx)
+		if err != nil {
+			return fmt.Errorf("failed to seed sequence %s: %w", name, err)
+		}
+
+		// Another caller may create the row first; the conflict clause then
+		// waits for it and increments instead
+		return tx.Raw(`
+			INSERT INTO number_sequences (tenant_id, name, last_value, updated_at)
+			VALUES (?, ?, ?, NOW())
+			ON CONFLICT (tenant_id, name)
+			DO UPDATE SET last_value = number_sequences.last_value + 1, updated_at = NOW()
+			RETURNING last_value
+		`, tenantID, name, last+1).Scan(&value).Error
+	})
+	return value, err
+}
+
+// SeedFromExisting seeds a sequence from numbers already issued in
+// table.column that start with prefix, taking the larger of their count
+// and highest trailing number. Soft-deleted rows are included since their
+// numbers were issued.
+func SeedFromExisting(table, column string, tenantID uuid.UUID, prefix string) SeedFunc {
+	return func(tx *gorm.DB) (int64, error) {
+		var last int64
+		err := tx.Raw(fmt.Sprintf(`
+			SELECT GREATEST(COUNT(*), COALESCE(MAX(CAST(SUBSTRING(%[2]s FROM '[0-9]+$') AS BIGINT)), 0))
+			FROM %[1]s
+			WHERE tenant_id = ? AND %[2]s LIKE ?
+		`, table, column), tenantID, prefix+"%").Scan(&last).Error
+		return last, err
+	}
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The sequence tests need Postgres, for its row locks and ON CONFLICT; they
+// are skipped unless TEST_DATABASE_URL names a database they may create the
+// number_sequences table in.
+func openSequenceDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(64)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&NumberSequence{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// allocate calls NextNumber from n goroutines released together, returning
+// the numbers they were given in order
+func allocate(t *testing.T, db *gorm.DB, tenantID uuid.UUID, n int, seed SeedFunc) []int64 {
+	t.Helper()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		numbers []int64
+		start   = make(chan struct{})
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			number, err := NextNumber(context.Background(), db, tenantID, "invoice", seed)
+			if err != nil {
+				t.Errorf("NextNumber: %v", err)
+				return
+			}
+			mu.Lock()
+			numbers = append(numbers, number)
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers
+}
+
+// assertRun checks numbers are first, first+1, ... with none repeated or
+// skipped
+func assertRun(t *testing.T, numbers []int64, first int64, n int) {
+	t.Helper()
+	if len(numbers) != n {
+		t.Fatalf("got %d numbers, want %d", len(numbers), n)
+	}
+	for i, number := range numbers {
+		if want := first + int64(i); number != want {
+			t.Fatalf("numbers[%d] = %d, want %d (numbers: %v)", i, number, want, numbers)
+		}
+	}
+}
+
+func newSequenceTenant(t *testing.T, db *gorm.DB) uuid.UUID {
+	tenantID := uuid.New()
+	t.Cleanup(func() {
+		db.Where("tenant_id = ?", tenantID).Delete(&NumberSequence{})
+	})
+	return tenantID
+}
+
+func TestNextNumberConcurrentFirstUse(t *testing.T) {
+	db := openSequenceDB(t)
+	tenantID := newSequenceTenant(t, db)
+
+	// Every caller finds no row and seeds; the slow seed keeps them all in
+	// the window before the first insert commits
+	var seeds int
+	var seedMu sync.Mutex
+	seed := func(tx *gorm.DB) (int64, error) {
+		seedMu.Lock()
+		seeds++
+		seedMu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		return 0, nil
+	}
+
+	const n = 40
+	assertRun(t, allocate(t, db, tenantID, n, seed), 1, n)
+	if seeds < 2 {
+		t.Logf("only %d callers raced to seed the sequence", seeds)
+	}
+}
+
+func TestNextNumberConcurrentSeededFromExisting(t *testing.T) {
+	db := openSequenceDB(t)
+	tenantID := newSequenceTenant(t, db)
+
+	seed := func(tx *gorm.DB) (int64, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 117, nil
+	}
+
+	const n = 40
+	assertRun(t, allocate(t, db, tenantID, n, seed), 118, n)
+}
+
+func TestNextNumberConcurrentExistingSequence(t *testing.T) {
+	db := openSequenceDB(t)
+	tenantID := newSequenceTenant(t, db)
+
+	noSeed := func(tx *gorm.DB) (int64, error) { return 0, nil }
+	if _, err := NextNumber(context.Background(), db, tenantID, "invoice", noSeed); err != nil {
+		t.Fatalf("NextNumber: %v", err)
+	}
+
+	const n = 100
+	assertRun(t, allocate(t, db, tenantID, n, func(tx *gorm.DB) (int64, error) {
+		t.Error("seed ran for an existing sequence")
+		return 0, nil
+	}), 2, n)
+}
+
+func TestNextNumberSequencesAreSeparate(t *testing.T) {
+	db := openSequenceDB(t)
+	first := newSequenceTenant(t, db)
+	second := newSequenceTenant(t, db)
+	noSeed := func(tx *gorm.DB) (int64, error) { return 0, nil }
+
+	var wg sync.WaitGroup
+	results := make(map[uuid.UUID][]int64)
+	var mu sync.Mutex
+	for _, tenantID := range []uuid.UUID{first, second} {
+		wg.Add(1)
+		go func(tenantID uuid.UUID) {
+			defer wg.Done()
+			numbers := allocate(t, db, tenantID, 20, noSeed)
+			mu.Lock()
+			results[tenantID] = numbers
+			mu.Unlock()
+		}(tenantID)
+	}
+	wg.Wait()
+
+	assertRun(t, results[first], 1, 20)
+	assertRun(t, results[second], 1, 20)
+}
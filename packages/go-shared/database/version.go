@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ResourceVersion counts the changes to one of a tenant's collections, such
+// as its chart of accounts or product list. Read endpoints derive their
+// ETag and Last-Modified headers from it, so every write to the collection
+// must bump it. Services that serve conditional requests migrate it along
+// with their own models.
+type ResourceVersion struct {
+	TenantID  string `gorm:"size:64;primaryKey"`
+	Resource  string `gorm:"size:100;primaryKey"`
+	Version   int64  `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// TableName returns the table name for ResourceVersion
+func (ResourceVersion) TableName() string {
+	return "resource_versions"
+}
+
+// BumpVersion records a change to a tenant's resource. Call it with the
+// transaction making the change so the two commit together.
+func BumpVersion(ctx context.Context, db *gorm.DB, tenantID, resource string) error {
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO resource_versions (tenant_id, resource, version, updated_at)
+		VALUES (?, ?, 1, NOW())
+		ON CONFLICT (tenant_id, resource)
+		DO UPDATE SET version = resource_versions.version + 1, updated_at = NOW()
+	`, tenantID, resource).Error
+}
+
+// GetVersion returns a tenant's resource version and when it last changed.
+// A resource that has never been written is at version zero with a zero
+// time. The primary is read so a lagging replica never reports a stale
+// version.
+func GetVersion(ctx context.Context, db *gorm.DB, tenantID, resource string) (int64, time.Time, error) {
+	var version ResourceVersion
+	err := UsePrimary(db).WithContext(ctx).
+		Where("tenant_id = ? AND resource = ?", tenantID, resource).
+		Limit(1).
+		Find(&version).Error
+	return version.Version, version.UpdatedAt, err
+}
+
+// VersionStore serves resource versions from the database to the
+// conditional request middleware
+type VersionStore struct {
+	db *gorm.DB
+}
+
+// NewVersionStore creates a version store
+func NewVersionStore(db *gorm.DB) *VersionStore {
+	return &VersionStore{db: db}
+}
+
+// Version returns a tenant's resource version and when it last changed
+func (s *VersionStore) Version(ctx context.Context, tenantID, resource string) (int64, time.Time, error) {
+	return GetVersion(ctx, s.db, tenantID, resource)
+}
+
+// Bump records a change to a tenant's resource
+func (s *VersionStore) Bump(ctx context.Context, tenantID, resource string) error {
+	return BumpVersion(ctx, s.db, tenantID, resource)
+}
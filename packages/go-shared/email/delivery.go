@@ -0,0 +1,121 @@
+package email
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Delivery statuses. A retrying message failed and is waiting for its next
+// attempt; a failed one will not be tried again unless retried by hand.
+// Delivered, opened and bounced follow sent as the provider reports on the
+// message.
+const (
+	StatusQueued    = "queued"
+	StatusRetrying  = "retrying"
+	StatusSent      = "sent"
+	StatusFailed    = "failed"
+	StatusDelivered = "delivered"
+	StatusOpened    = "opened"
+	StatusBounced   = "bounced"
+)
+
+// Delivery is a message and its delivery status. Sent means the provider
+// accepted the message, not that it reached the inbox; providers that
+// report events move it on to delivered, opened or bounced.
+type Delivery struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"` // Nil for emails to users outside a tenant
+	Template  string     `gorm:"size:50" json:"template,omitempty"`
+	Reference string     `gorm:"size:100;index" json:"reference,omitempty"` // What the email is about, such as invoice:<id>
+
+	ToAddress string `gorm:"size:320;not null" json:"to"`
+	ToName    string `gorm:"size:255" json:"to_name,omitempty"`
+	ReplyTo   string `gorm:"size:320" json:"reply_to,omitempty"`
+	Subject   string `gorm:"size:998;not null" json:"subject"`
+	TextBody  string `gorm:"type:text" json:"-"`
+	HTMLBody  string `gorm:"type:text" json:"-"`
+
+	// Sensitive messages, such as password reset links, have their bodies
+	// cleared once they are sent or given up on
+	Sensitive bool `gorm:"default:false" json:"sensitive"`
+
+	Status            string     `gorm:"size:20;not null;default:'queued';index" json:"status"`
+	Provider          string     `gorm:"size:20" json:"provider,omitempty"`
+	ProviderMessageID string     `gorm:"size:255" json:"provider_message_id,omitempty"`
+	Attempts          int        `gorm:"default:0" json:"attempts"`
+	LastError         string     `gorm:"type:text" json:"last_error,omitempty"`
+	JobID             *uuid.UUID `gorm:"type:uuid" json:"job_id,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+
+	// Reported by the provider's event webhook
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+	OpenedAt     *time.Time `json:"opened_at,omitempty"` // First open
+	BouncedAt    *time.Time `json:"bounced_at,omitempty"`
+	BounceType   string     `gorm:"size:20" json:"bounce_type,omitempty"` // permanent or transient
+	BounceReason string     `gorm:"type:text" json:"bounce_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Attachments []DeliveryAttachment `gorm:"foreignKey:DeliveryID" json:"attachments,omitempty"`
+}
+
+// TableName returns the table name for Delivery
+func (Delivery) TableName() string {
+	return "email_deliveries"
+}
+
+// BeforeCreate hook
+func (d *Delivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// message is the delivery as a message to send
+func (d *Delivery) message() Message {
+	msg := Message{
+		To:      d.ToAddress,
+		ToName:  d.ToName,
+		ReplyTo: d.ReplyTo,
+		Subject: d.Subject,
+		Text:    d.TextBody,
+		HTML:    d.HTMLBody,
+	}
+	for _, attachment := range d.Attachments {
+		msg.Attachments = append(msg.Attachments, Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     attachment.Content,
+		})
+	}
+	return msg
+}
+
+// DeliveryAttachment is a file sent with a message. The content is kept
+// only until the message is sent.
+type DeliveryAttachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DeliveryID  uuid.UUID `gorm:"type:uuid;not null;index" json:"delivery_id"`
+	Filename    string    `gorm:"size:255;not null" json:"filename"`
+	ContentType string    `gorm:"size:100" json:"content_type"`
+	Size        int       `json:"size"`
+	Content     []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for DeliveryAttachment
+func (DeliveryAttachment) TableName() string {
+	return "email_delivery_attachments"
+}
+
+// BeforeCreate hook
+func (a *DeliveryAttachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Package email sends the emails services write to people: password
+// resets, invitations, invoices. Messages are recorded in the service's
+// database and delivered by a background job through the configured
+// provider (SMTP, SendGrid or Amazon SES), so a provider outage delays an
+// email rather than failing the request that sent it, and the delivery
+// status of every message is kept.
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+)
+
+// Attachment is a file sent with a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Message is an email to one recipient. Text is required; HTML, when set,
+// is the alternative mail clients show instead.
+type Message struct {
+	To          string
+	ToName      string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Driver delivers messages through an email provider
+type Driver interface {
+	// Name identifies the provider in delivery records
+	Name() string
+	// Send delivers msg and returns the provider's ID for it. Errors the
+	// provider will give again on a retry, such as a rejected address,
+	// are wrapped in a *PermanentError.
+	Send(ctx context.Context, from mail.Address, msg Message) (string, error)
+}
+
+// PermanentError is a failure that retrying the same message will not fix
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a failure retrying will not fix
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// NewDriver creates the driver for the configured provider. Its
+// credentials are read from secrets on every send, so a rotated password
+// or API key applies without a restart.
+func NewDriver(cfg config.EmailConfig, secrets *config.Secrets) (Driver, error) {
+	switch cfg.Provider {
+	case "", "log":
+		return logDriver{}, nil
+	case "smtp":
+		return newSMTPDriver(cfg, secrets), nil
+	case "sendgrid":
+		return newSendGridDriver(secret(secrets, "SENDGRID_API_KEY", cfg.SendGridAPIKey)), nil
+	case "ses":
+		return newSESDriver(cfg.SESRegion, cfg.SESConfigurationSet)
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+// secret returns a function reading the current value of a secret, or
+// fallback, the value it had at startup, when it is not set
+func secret(secrets *config.Secrets, key, fallback string) func() string {
+	if secrets == nil {
+		return func() string { return fallback }
+	}
+	return func() string { return secrets.GetOr(key, fallback) }
+}
+
+// logDriver writes emails to the service log instead of sending them, for
+// development
+type logDriver struct{}
+
+func (logDriver) Name() string {
+	return "log"
+}
+
+func (logDriver) Send(ctx context.Context, from mail.Address, msg Message) (string, error) {
+	log.Printf("[Email] To %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return "", nil
+}
@@ -0,0 +1,135 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Event types reported by providers on sent messages
+const (
+	EventDelivered = "delivered"
+	EventOpened    = "opened"
+	EventBounced   = "bounced" // Includes messages the provider dropped without trying
+)
+
+// Bounce types
+const (
+	BouncePermanent = "permanent" // The address does not exist or refuses mail
+	BounceTransient = "transient" // Such as a full mailbox; a later message may get through
+)
+
+// Event is a provider's report on a message it accepted, matched to the
+// delivery by the provider's message ID
+type Event struct {
+	Provider          string
+	ProviderMessageID string
+	Type              string
+	At                time.Time
+	BounceType        string
+	Reason            string
+}
+
+// Listener is told of a delivery whose status an event changed, such as to
+// flag the document it was about. Listeners run on the webhook request, so
+// should be quick.
+type Listener func(ctx context.Context, delivery *Delivery)
+
+// OnStatusChange adds a listener for deliveries moved on by provider
+// events. Listeners are added at startup, before events are received.
+func (m *Mailer) OnStatusChange(listener Listener) {
+	m.listeners = append(m.listeners, listener)
+}
+
+// RecordEvent applies a provider's event to the delivery it is about and
+// tells the listeners if its status changed. Events for messages this
+// service did not send, such as those of another service sharing the
+// provider account, are ignored.
+func (m *Mailer) RecordEvent(ctx context.Context, event Event) error {
+	if event.ProviderMessageID == "" {
+		return nil
+	}
+
+	var delivery Delivery
+	err := m.db.WithContext(ctx).
+		Where("provider = ? AND provider_message_id = ?", event.Provider, event.ProviderMessageID).
+		First(&delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	before := delivery.Status
+	updates := delivery.apply(event)
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := m.db.WithContext(ctx).Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if delivery.Status != before {
+		for _, listener := range m.listeners {
+			listener(ctx, &delivery)
+		}
+	}
+	return nil
+}
+
+// apply moves the delivery on by event and returns the columns changed.
+// Providers may report events out of order or more than once, so a status
+// only moves forward: sent, delivered, opened. A bounce ends the delivery
+// unless the message was already opened.
+func (d *Delivery) apply(event Event) map[string]interface{} {
+	at := event.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	updates := make(map[string]interface{})
+
+	switch event.Type {
+	case EventDelivered:
+		if d.DeliveredAt == nil {
+			d.DeliveredAt = &at
+			updates["delivered_at"] = at
+		}
+		if d.Status == StatusSent {
+			d.Status = StatusDelivered
+			updates["status"] = StatusDelivered
+		}
+	case EventOpened:
+		if d.OpenedAt == nil {
+			d.OpenedAt = &at
+			updates["opened_at"] = at
+		}
+		// An open proves the message arrived, whatever was said before
+		if d.DeliveredAt == nil {
+			d.DeliveredAt = &at
+			updates["delivered_at"] = at
+		}
+		if d.Status != StatusOpened {
+			d.Status = StatusOpened
+			updates["status"] = StatusOpened
+		}
+	case EventBounced:
+		if d.Status == StatusOpened || d.Status == StatusBounced {
+			break
+		}
+		d.Status = StatusBounced
+		d.BouncedAt = &at
+		d.BounceType = event.BounceType
+		d.BounceReason = event.Reason
+		updates["status"] = StatusBounced
+		updates["bounced_at"] = at
+		updates["bounce_type"] = event.BounceType
+		updates["bounce_reason"] = event.Reason
+	default:
+		log.Printf("email: ignoring %s event %q for %s", event.Provider, event.Type, d.ID)
+	}
+	return updates
+}
@@ -0,0 +1,110 @@
+package email
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves the delivery status of the emails a service sent for the
+// caller's tenant, and the event webhooks providers report it on
+type Handler struct {
+	mailer *Mailer
+	events *eventReceiver
+}
+
+// NewHandler creates a new email delivery handler. SendGrid events are
+// verified with cfg's SendGridWebhookKey and SES events accepted from its
+// SESEventTopicARN; without them those events are refused.
+func NewHandler(mailer *Mailer, cfg config.EmailConfig) *Handler {
+	return &Handler{
+		mailer: mailer,
+		events: newEventReceiver(cfg.SendGridWebhookKey, cfg.SESEventTopicARN),
+	}
+}
+
+// List returns the tenant's recent emails, filtered by status, reference
+// and recipient
+func (h *Handler) List(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	filter := Filter{
+		TenantID:  &tenantID,
+		Status:    c.Query("status"),
+		Reference: c.Query("reference"),
+		To:        c.Query("to"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	deliveries, err := h.mailer.List(c.Request.Context(), filter)
+	if err != nil {
+		response.InternalError(c, "Failed to list emails")
+		return
+	}
+
+	response.Success(c, deliveries)
+}
+
+// Get returns an email and its delivery status
+func (h *Handler) Get(c *gin.Context) {
+	delivery, ok := h.delivery(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, delivery)
+}
+
+// Resend queues a failed email again
+func (h *Handler) Resend(c *gin.Context) {
+	delivery, ok := h.delivery(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.mailer.Resend(c.Request.Context(), delivery.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotResendable) {
+			response.Conflict(c, "Only failed emails can be resent")
+		} else {
+			response.InternalError(c, "Failed to resend email")
+		}
+		return
+	}
+
+	response.Success(c, delivery)
+}
+
+func (h *Handler) delivery(c *gin.Context) (*Delivery, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid email ID", nil)
+		return nil, false
+	}
+
+	delivery, err := h.mailer.Get(c.Request.Context(), id)
+	if err == nil && (delivery.TenantID == nil || delivery.TenantID.String() != c.GetString("tenant_id")) {
+		err = ErrDeliveryNotFound
+	}
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			response.NotFound(c, "Email not found")
+		} else {
+			response.InternalError(c, "Failed to get email")
+		}
+		return nil, false
+	}
+
+	return delivery, true
+}
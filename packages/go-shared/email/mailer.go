@@ -0,0 +1,279 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"gorm.io/gorm"
+)
+
+// JobSendEmail is the job type that delivers a queued message
+const JobSendEmail = "email.send"
+
+// maxAttempts is how often a message is tried before it is failed. With
+// the queue's backoff the last attempt is about an hour after the first.
+const maxAttempts = 8
+
+var (
+	ErrDeliveryNotFound = errors.New("email not found")
+	ErrNotResendable    = errors.New("only failed emails can be resent")
+	ErrInvalidRecipient = errors.New("invalid recipient address")
+)
+
+// SendOptions are the optional settings of a queued message
+type SendOptions struct {
+	TenantID  *uuid.UUID
+	Template  string // Recorded with the delivery
+	Reference string // What the email is about, such as invoice:<id>
+	Sensitive bool   // Clear the bodies once the message is sent or given up on
+}
+
+// Mailer records messages and delivers them through a driver from the job
+// queue, retrying failures with the queue's backoff
+type Mailer struct {
+	db        *gorm.DB
+	queue     *jobs.Queue
+	driver    Driver
+	from      mail.Address
+	listeners []Listener
+}
+
+// NewMailer creates a mailer sending from the configured address and
+// registers its job type on queue. The queue must be started for mail to
+// go out.
+func NewMailer(db *gorm.DB, queue *jobs.Queue, driver Driver, cfg config.EmailConfig) *Mailer {
+	m := &Mailer{
+		db:     db,
+		queue:  queue,
+		driver: driver,
+		from:   mail.Address{Name: cfg.FromName, Address: cfg.From},
+	}
+	queue.Register(JobSendEmail, m.deliver, jobs.Options{MaxAttempts: maxAttempts, Timeout: 2 * time.Minute})
+	return m
+}
+
+type sendPayload struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+// Send records msg and queues it for delivery
+func (m *Mailer) Send(ctx context.Context, msg Message, opts SendOptions) (*Delivery, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRecipient, msg.To)
+	}
+	if msg.ReplyTo != "" {
+		if _, err := mail.ParseAddress(msg.ReplyTo); err != nil {
+			return nil, fmt.Errorf("invalid reply-to address %q", msg.ReplyTo)
+		}
+	}
+
+	delivery := &Delivery{
+		TenantID:  opts.TenantID,
+		Template:  opts.Template,
+		Reference: opts.Reference,
+		ToAddress: to.Address,
+		ToName:    msg.ToName,
+		ReplyTo:   msg.ReplyTo,
+		Subject:   msg.Subject,
+		TextBody:  msg.Text,
+		HTMLBody:  msg.HTML,
+		Sensitive: opts.Sensitive,
+		Status:    StatusQueued,
+	}
+	if delivery.ToName == "" {
+		delivery.ToName = to.Name
+	}
+	for _, attachment := range msg.Attachments {
+		delivery.Attachments = append(delivery.Attachments, DeliveryAttachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        len(attachment.Content),
+			Content:     attachment.Content,
+		})
+	}
+	if err := m.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	if err := m.enqueue(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// SendTemplate renders the named template with data and sends it to the
+// recipient
+func (m *Mailer) SendTemplate(ctx context.Context, to, toName, template string, data interface{}, opts SendOptions) (*Delivery, error) {
+	msg, err := Render(template, data)
+	if err != nil {
+		return nil, err
+	}
+	msg.To, msg.ToName = to, toName
+	if opts.Template == "" {
+		opts.Template = template
+	}
+	return m.Send(ctx, msg, opts)
+}
+
+func (m *Mailer) enqueue(ctx context.Context, delivery *Delivery) error {
+	job, err := m.queue.Enqueue(ctx, JobSendEmail, sendPayload{DeliveryID: delivery.ID}, jobs.EnqueueOptions{TenantID: delivery.TenantID})
+	if err != nil {
+		m.update(delivery.ID, map[string]interface{}{"status": StatusFailed, "last_error": "failed to queue: " + err.Error()})
+		return err
+	}
+	delivery.JobID = &job.ID
+	return m.db.WithContext(ctx).Model(&Delivery{}).Where("id = ?", delivery.ID).Update("job_id", job.ID).Error
+}
+
+// Get returns a delivery
+func (m *Mailer) Get(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	var delivery Delivery
+	err := m.db.WithContext(ctx).
+		Preload("Attachments", func(db *gorm.DB) *gorm.DB { return db.Omit("content") }).
+		First(&delivery, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Filter narrows a delivery listing
+type Filter struct {
+	TenantID  *uuid.UUID
+	Status    string
+	Reference string
+	To        string
+	Limit     int
+}
+
+// List returns the most recent deliveries matching filter
+func (m *Mailer) List(ctx context.Context, filter Filter) ([]Delivery, error) {
+	query := m.db.WithContext(ctx)
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Reference != "" {
+		query = query.Where("reference = ?", filter.Reference)
+	}
+	if filter.To != "" {
+		query = query.Where("LOWER(to_address) = LOWER(?)", filter.To)
+	}
+
+	var deliveries []Delivery
+	err := query.Order("created_at DESC").Limit(filter.Limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Resend queues a failed message again with a fresh set of attempts.
+// Sensitive messages cannot be resent once their bodies are cleared.
+func (m *Mailer) Resend(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	delivery, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != StatusFailed || (delivery.Sensitive && delivery.TextBody == "") {
+		return nil, ErrNotResendable
+	}
+
+	result := m.db.WithContext(ctx).Model(&Delivery{}).
+		Where("id = ? AND status = ?", id, StatusFailed).
+		Updates(map[string]interface{}{"status": StatusQueued, "attempts": 0, "last_error": ""})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotResendable
+	}
+	if err := m.enqueue(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return m.Get(ctx, id)
+}
+
+// deliver sends a queued message. A failure the provider will repeat fails
+// the message at once; others fail the job so the queue retries it.
+func (m *Mailer) deliver(ctx context.Context, job *jobs.Job) error {
+	var payload sendPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	var delivery Delivery
+	err := m.db.WithContext(ctx).Preload("Attachments").First(&delivery, "id = ?", payload.DeliveryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != StatusQueued && delivery.Status != StatusRetrying {
+		return nil
+	}
+
+	providerID, err := m.driver.Send(ctx, m.from, delivery.message())
+	updates := map[string]interface{}{
+		"provider": m.driver.Name(),
+		"attempts": delivery.Attempts + 1,
+	}
+	if err == nil {
+		now := time.Now()
+		updates["status"] = StatusSent
+		updates["provider_message_id"] = providerID
+		updates["last_error"] = ""
+		updates["sent_at"] = now
+		m.finish(&delivery, updates)
+		return nil
+	}
+
+	updates["last_error"] = err.Error()
+	if IsPermanent(err) || job.Attempts >= job.MaxAttempts {
+		updates["status"] = StatusFailed
+		log.Printf("email: giving up on %s to %s: %v", delivery.ID, delivery.ToAddress, err)
+		m.finish(&delivery, updates)
+		if IsPermanent(err) {
+			return nil
+		}
+		return err
+	}
+	updates["status"] = StatusRetrying
+	m.update(delivery.ID, updates)
+	return err
+}
+
+// finish records the final outcome of a delivery, clearing what is not
+// kept once it is done
+func (m *Mailer) finish(delivery *Delivery, updates map[string]interface{}) {
+	if delivery.Sensitive {
+		updates["text_body"] = ""
+		updates["html_body"] = ""
+	}
+	m.update(delivery.ID, updates)
+
+	if updates["status"] == StatusSent && len(delivery.Attachments) > 0 {
+		if err := m.db.Model(&DeliveryAttachment{}).Where("delivery_id = ?", delivery.ID).Update("content", nil).Error; err != nil {
+			log.Printf("email: failed to clear attachments of %s: %v", delivery.ID, err)
+		}
+	}
+}
+
+// update saves a delivery's outcome. It does not use the job's context, so
+// the outcome of an attempt that timed out is still recorded.
+func (m *Mailer) update(id uuid.UUID, updates map[string]interface{}) {
+	if err := m.db.Model(&Delivery{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("email: failed to save status of %s: %v", id, err)
+	}
+}
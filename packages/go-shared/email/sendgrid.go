@@ -0,0 +1,126 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const sendGridURL = "https://api.sendgrid.com/v3/mail/send"
+
+// sendGridDriver sends mail through the SendGrid v3 API
+type sendGridDriver struct {
+	apiKey     func() string
+	httpClient *http.Client
+}
+
+func newSendGridDriver(apiKey func() string) *sendGridDriver {
+	return &sendGridDriver{
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     []byte `json:"content"` // Base64 encoded by encoding/json
+	Filename    string `json:"filename"`
+	Type        string `json:"type,omitempty"`
+	Disposition string `json:"disposition"`
+}
+
+type sendGridMail struct {
+	Personalizations []struct {
+		To []sendGridAddress `json:"to"`
+	} `json:"personalizations"`
+	From        sendGridAddress      `json:"from"`
+	ReplyTo     *sendGridAddress     `json:"reply_to,omitempty"`
+	Subject     string               `json:"subject"`
+	Content     []sendGridContent    `json:"content"`
+	Attachments []sendGridAttachment `json:"attachments,omitempty"`
+}
+
+func (d *sendGridDriver) Name() string {
+	return "sendgrid"
+}
+
+func (d *sendGridDriver) Send(ctx context.Context, from mail.Address, msg Message) (string, error) {
+	body := sendGridMail{
+		From:    sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject: msg.Subject,
+		Content: []sendGridContent{{Type: "text/plain", Value: msg.Text}},
+	}
+	body.Personalizations = make([]struct {
+		To []sendGridAddress `json:"to"`
+	}, 1)
+	body.Personalizations[0].To = []sendGridAddress{{Email: msg.To, Name: msg.ToName}}
+	if msg.ReplyTo != "" {
+		body.ReplyTo = &sendGridAddress{Email: msg.ReplyTo}
+	}
+	if msg.HTML != "" {
+		body.Content = append(body.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	for _, attachment := range msg.Attachments {
+		body.Attachments = append(body.Attachments, sendGridAttachment{
+			Content:     attachment.Content,
+			Filename:    attachment.Filename,
+			Type:        attachment.ContentType,
+			Disposition: "attachment",
+		})
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", &PermanentError{Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendGridURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.apiKey())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to SendGrid failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return "", providerError("SendGrid", resp)
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// providerError describes a failed API call. 4xx responses other than
+// throttling and bad credentials mean the message itself was refused, so
+// are permanent.
+func providerError(provider string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("%s returned %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(msg)))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode >= 500:
+		return err
+	case resp.StatusCode >= 400:
+		return &PermanentError{Err: err}
+	}
+	return err
+}
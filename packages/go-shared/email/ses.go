@@ -0,0 +1,155 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+const sesSendPath = "/v2/email/outbound-emails"
+
+// sesDriver sends mail through the Amazon SES v2 API as raw MIME, so
+// attachments go the same way as the body. Requests are signed with the
+// credentials in the standard AWS environment variables. With a
+// configuration set, SES reports on the messages through its event
+// destinations.
+type sesDriver struct {
+	region           string
+	configurationSet string
+	httpClient       *http.Client
+}
+
+func newSESDriver(region, configurationSet string) (*sesDriver, error) {
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for the ses email provider")
+	}
+	return &sesDriver{
+		region:           region,
+		configurationSet: configurationSet,
+		httpClient:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (d *sesDriver) Name() string {
+	return "ses"
+}
+
+func (d *sesDriver) Send(ctx context.Context, from mail.Address, msg Message) (string, error) {
+	raw, err := buildMIME(from, msg, newMessageID(from), time.Now())
+	if err != nil {
+		return "", &PermanentError{Err: err}
+	}
+
+	var request struct {
+		FromEmailAddress string
+		Destination      struct{ ToAddresses []string }
+		Content          struct{ Raw struct{ Data []byte } } // Base64 encoded by encoding/json
+
+		ConfigurationSetName string `json:",omitempty"`
+	}
+	request.FromEmailAddress = from.String()
+	request.ConfigurationSetName = d.configurationSet
+	request.Destination.ToAddresses = []string{msg.To}
+	request.Content.Raw.Data = raw
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", &PermanentError{Err: err}
+	}
+
+	host := fmt.Sprintf("email.%s.amazonaws.com", d.region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+sesSendPath, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	d.sign(req, host, body, time.Now().UTC())
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to SES failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", providerError("SES", resp)
+	}
+	var result struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode SES response: %w", err)
+	}
+	return result.MessageID, nil
+}
+
+// sign adds an AWS Signature Version 4 to req
+func (d *sesDriver) sign(req *http.Request, host string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/ses/aws4_request", date, d.region)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	// Headers must be listed in sorted order
+	headers := [][2]string{
+		{"content-type", req.Header.Get("Content-Type")},
+		{"host", host},
+		{"x-amz-date", amzDate},
+	}
+	if token := req.Header.Get("X-Amz-Security-Token"); token != "" {
+		headers = append(headers, [2]string{"x-amz-security-token", token})
+	}
+
+	var canonicalHeaders strings.Builder
+	names := make([]string, len(headers))
+	for i, h := range headers {
+		canonicalHeaders.WriteString(h[0] + ":" + strings.TrimSpace(h[1]) + "\n")
+		names[i] = h[0]
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sesSendPath,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+os.Getenv("AWS_SECRET_ACCESS_KEY")), date)
+	key = hmacSHA256(key, d.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		os.Getenv("AWS_ACCESS_KEY_ID"), scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,118 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// smtpDriver sends mail through an SMTP relay. Port 465 is spoken over TLS
+// from the start; on other ports the connection is upgraded with STARTTLS
+// when the server offers it, and credentials are only sent once it is.
+type smtpDriver struct {
+	host     string
+	port     int
+	username string
+	password func() string
+}
+
+func newSMTPDriver(cfg config.EmailConfig, secrets *config.Secrets) *smtpDriver {
+	return &smtpDriver{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: secret(secrets, "SMTP_PASS", cfg.SMTPPassword),
+	}
+}
+
+func (d *smtpDriver) Name() string {
+	return "smtp"
+}
+
+func (d *smtpDriver) Send(ctx context.Context, from mail.Address, msg Message) (string, error) {
+	messageID := newMessageID(from)
+	data, err := buildMIME(from, msg, messageID, time.Now())
+	if err != nil {
+		return "", &PermanentError{Err: err}
+	}
+
+	deadline := time.Now().Add(smtpTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	addr := net.JoinHostPort(d.host, strconv.Itoa(d.port))
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if d.port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: d.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, d.host)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer client.Close()
+
+	if d.port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: d.host}); err != nil {
+				return "", err
+			}
+		}
+	}
+	if d.username != "" {
+		// PlainAuth refuses to send the password over an unencrypted
+		// connection to anything but localhost
+		if err := client.Auth(smtp.PlainAuth("", d.username, d.password(), d.host)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return "", err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return "", smtpError(err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", smtpError(err)
+	}
+	_ = client.Quit()
+	return messageID, nil
+}
+
+// smtpError marks 5xx replies to a recipient or message, which the server
+// will give again, as permanent. Those to the sender or login are more
+// likely the relay's configuration, so are retried.
+func smtpError(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
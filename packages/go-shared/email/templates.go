@@ -0,0 +1,104 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Templates of the emails services send. Each defines its subject, text
+// and HTML body, and is rendered with the data type named after it.
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+	TemplateTenantInvitation  = "tenant_invitation"
+	TemplateInvoice           = "invoice"
+)
+
+// PasswordReset is the data of TemplatePasswordReset
+type PasswordReset struct {
+	Name      string
+	Link      string
+	ExpiresIn string // Such as "1 hour"
+}
+
+// EmailVerification is the data of TemplateEmailVerification
+type EmailVerification struct {
+	Name      string
+	Code      string
+	ExpiresIn string
+}
+
+// TenantInvitation is the data of TemplateTenantInvitation
+type TenantInvitation struct {
+	TenantName  string
+	InviterName string
+	RoleName    string
+	Message     string // The inviter's own note, if any
+	Link        string
+	ExpiresAt   time.Time
+}
+
+// Invoice is the data of TemplateInvoice, sent with the invoice's PDF
+type Invoice struct {
+	SellerName    string
+	CustomerName  string
+	InvoiceNumber string
+	InvoiceDate   time.Time
+	DueDate       time.Time
+	Amount        string // Formatted with its currency
+	Notes         string
+}
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = parseTemplates(TemplatePasswordReset, TemplateEmailVerification, TemplateTenantInvitation, TemplateInvoice)
+
+// parseTemplates parses each template with the shared layout. Templates are
+// embedded, so a broken one fails at startup.
+func parseTemplates(names ...string) map[string]emailTemplate {
+	parsed := make(map[string]emailTemplate, len(names))
+	for _, name := range names {
+		files := []string{"templates/layout.tmpl", "templates/" + name + ".tmpl"}
+		parsed[name] = emailTemplate{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFiles, files...)),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFiles, files...)),
+		}
+	}
+	return parsed
+}
+
+// Render returns the subject and bodies of the named template filled in
+// with data; the caller addresses the message
+func Render(name string, data interface{}) (Message, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
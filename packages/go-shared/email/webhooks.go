@@ -0,0 +1,425 @@
+package email
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/webhook"
+)
+
+// maxEventPayload is the largest event callback accepted. SendGrid batches
+// up to a few thousand events in one request.
+const maxEventPayload = 4 << 20
+
+// snsHost matches the hosts SNS signing certificates and subscription
+// links are served from
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// eventReceiver verifies the event webhooks of the email providers
+type eventReceiver struct {
+	sendGridKey *ecdsa.PublicKey
+	sesTopicARN string
+
+	httpClient *http.Client
+	mu         sync.Mutex
+	snsCerts   map[string]*x509.Certificate
+}
+
+func newEventReceiver(sendGridKey, sesTopicARN string) *eventReceiver {
+	r := &eventReceiver{
+		sesTopicARN: sesTopicARN,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		snsCerts:    make(map[string]*x509.Certificate),
+	}
+	if sendGridKey != "" {
+		key, err := parseSendGridKey(sendGridKey)
+		if err != nil {
+			log.Printf("email: SendGrid events will be refused: %v", err)
+		}
+		r.sendGridKey = key
+	}
+	return r
+}
+
+// SendGridEvents receives SendGrid's Event Webhook (public), a signed batch
+// of events on the messages sent through it
+func (h *Handler) SendGridEvents(c *gin.Context) {
+	if h.events.sendGridKey == nil {
+		response.NotFound(c, "SendGrid events are not configured")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEventPayload))
+	if err != nil {
+		response.BadRequest(c, "Invalid body", nil)
+		return
+	}
+	if err := h.events.verifySendGrid(c.Request.Header, body, time.Now()); err != nil {
+		response.Unauthorized(c, "Invalid SendGrid signature")
+		return
+	}
+
+	var batch []struct {
+		Event     string `json:"event"`
+		Timestamp int64  `json:"timestamp"`
+		MessageID string `json:"sg_message_id"`
+		Type      string `json:"type"` // bounce or blocked, on bounces
+		Reason    string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &batch); err != nil {
+		response.BadRequest(c, "Invalid events", nil)
+		return
+	}
+
+	for _, e := range batch {
+		event := Event{
+			Provider:          "sendgrid",
+			ProviderMessageID: sendGridMessageID(e.MessageID),
+			At:                time.Unix(e.Timestamp, 0),
+			Reason:            e.Reason,
+		}
+		switch e.Event {
+		case "delivered":
+			event.Type = EventDelivered
+		case "open":
+			event.Type = EventOpened
+		case "bounce":
+			event.Type = EventBounced
+			event.BounceType = BouncePermanent
+			if e.Type == "blocked" {
+				event.BounceType = BounceTransient
+			}
+		case "dropped":
+			event.Type = EventBounced
+			event.BounceType = BouncePermanent
+		default:
+			continue
+		}
+		if err := h.mailer.RecordEvent(c.Request.Context(), event); err != nil {
+			log.Printf("email: failed to record SendGrid %s event for %s: %v", e.Event, e.MessageID, err)
+			response.InternalError(c, "Failed to record events")
+			return
+		}
+	}
+
+	response.NoContent(c)
+}
+
+// SESEvents receives the SNS notifications of an SES configuration set's
+// event destination (public), confirming the topic subscription when SNS
+// asks to
+func (h *Handler) SESEvents(c *gin.Context) {
+	if h.events.sesTopicARN == "" {
+		response.NotFound(c, "SES events are not configured")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEventPayload))
+	if err != nil {
+		response.BadRequest(c, "Invalid body", nil)
+		return
+	}
+
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		response.BadRequest(c, "Invalid notification", nil)
+		return
+	}
+	if msg.TopicArn != h.events.sesTopicARN {
+		response.Unauthorized(c, "Unexpected SNS topic")
+		return
+	}
+	if err := h.events.verifySNS(&msg); err != nil {
+		log.Printf("email: refused SNS %s %s: %v", msg.Type, msg.MessageId, err)
+		response.Unauthorized(c, "Invalid SNS signature")
+		return
+	}
+
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		if err := h.events.confirmSubscription(msg.SubscribeURL); err != nil {
+			log.Printf("email: failed to confirm SNS subscription to %s: %v", msg.TopicArn, err)
+			response.InternalError(c, "Failed to confirm subscription")
+			return
+		}
+	case "Notification":
+		event, ok := sesEvent(msg.Message)
+		if !ok {
+			break
+		}
+		if err := h.mailer.RecordEvent(c.Request.Context(), event); err != nil {
+			log.Printf("email: failed to record SES %s event for %s: %v", event.Type, event.ProviderMessageID, err)
+			response.InternalError(c, "Failed to record event")
+			return
+		}
+	}
+
+	response.NoContent(c)
+}
+
+// verifySendGrid checks the ECDSA signature SendGrid makes over the
+// timestamp header followed by the body
+func (r *eventReceiver) verifySendGrid(header http.Header, body []byte, now time.Time) error {
+	signature := header.Get("X-Twilio-Email-Event-Webhook-Signature")
+	timestamp := header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	if signature == "" || timestamp == "" {
+		return webhook.ErrMissingSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return webhook.ErrMalformedSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > webhook.DefaultTolerance || age < -webhook.DefaultTolerance {
+		return webhook.ErrTimestampExpired
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return webhook.ErrMalformedSignature
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(r.sendGridKey, digest[:], sig) {
+		return webhook.ErrSignatureMismatch
+	}
+	return nil
+}
+
+// parseSendGridKey reads the verification key shown in SendGrid's signed
+// event webhook settings, a base64 DER public key
+func parseSendGridKey(key string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("SENDGRID_WEBHOOK_KEY is not base64: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("SENDGRID_WEBHOOK_KEY is not a public key: %w", err)
+	}
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("SENDGRID_WEBHOOK_KEY is not an ECDSA key")
+	}
+	return ecKey, nil
+}
+
+// sendGridMessageID is the X-Message-Id SendGrid returned on sending,
+// which its events carry followed by the ID of the relay that handled the
+// message
+func sendGridMessageID(id string) string {
+	for _, relay := range []string{".filter", ".recvd"} {
+		if i := strings.Index(id, relay); i > 0 {
+			return id[:i]
+		}
+	}
+	return id
+}
+
+// snsMessage is a message SNS posts to an HTTPS subscription
+type snsMessage struct {
+	Type             string
+	MessageId        string
+	TopicArn         string
+	Subject          string
+	Message          string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+	SubscribeURL     string
+	Token            string
+}
+
+// verifySNS checks the message was signed with the SNS certificate it
+// names, which must be served by SNS itself
+func (r *eventReceiver) verifySNS(msg *snsMessage) error {
+	cert, err := r.snsCertificate(msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate does not hold an RSA key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return webhook.ErrMalformedSignature
+	}
+
+	fields := []string{"Message", msg.Message, "MessageId", msg.MessageId}
+	if msg.Type == "Notification" {
+		if msg.Subject != "" {
+			fields = append(fields, "Subject", msg.Subject)
+		}
+	} else {
+		fields = append(fields, "SubscribeURL", msg.SubscribeURL)
+	}
+	fields = append(fields, "Timestamp", msg.Timestamp)
+	if msg.Type != "Notification" {
+		fields = append(fields, "Token", msg.Token)
+	}
+	fields = append(fields, "TopicArn", msg.TopicArn, "Type", msg.Type)
+	signed := []byte(strings.Join(fields, "\n") + "\n")
+
+	switch msg.SignatureVersion {
+	case "1":
+		digest := sha1.Sum(signed)
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA1, digest[:], sig)
+	case "2":
+		digest := sha256.Sum256(signed)
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+	default:
+		return fmt.Errorf("unsupported signature version %q", msg.SignatureVersion)
+	}
+	if err != nil {
+		return webhook.ErrSignatureMismatch
+	}
+	return nil
+}
+
+// snsCertificate fetches an SNS signing certificate, keeping it for later
+// messages
+func (r *eventReceiver) snsCertificate(certURL string) (*x509.Certificate, error) {
+	if err := checkSNSURL(certURL); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	cert, ok := r.snsCerts[certURL]
+	r.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	resp, err := r.httpClient.Get(certURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("certificate request returned %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing certificate is not PEM")
+	}
+	if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.snsCerts[certURL] = cert
+	r.mu.Unlock()
+	return cert, nil
+}
+
+// confirmSubscription visits the link SNS sends to confirm a subscription
+func (r *eventReceiver) confirmSubscription(subscribeURL string) error {
+	if err := checkSNSURL(subscribeURL); err != nil {
+		return err
+	}
+	resp, err := r.httpClient.Get(subscribeURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("subscription confirmation returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func checkSNSURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Hostname()) {
+		return fmt.Errorf("%q is not an SNS URL", rawURL)
+	}
+	return nil
+}
+
+// sesEvent reads the SES event an SNS notification carries. Both event
+// publishing (eventType) and identity notifications (notificationType) are
+// understood.
+func sesEvent(message string) (Event, bool) {
+	var payload struct {
+		EventType        string `json:"eventType"`
+		NotificationType string `json:"notificationType"`
+		Mail             struct {
+			MessageID string `json:"messageId"`
+		} `json:"mail"`
+		Bounce struct {
+			BounceType        string    `json:"bounceType"` // Permanent, Transient or Undetermined
+			BounceSubType     string    `json:"bounceSubType"`
+			Timestamp         time.Time `json:"timestamp"`
+			BouncedRecipients []struct {
+				DiagnosticCode string `json:"diagnosticCode"`
+			} `json:"bouncedRecipients"`
+		} `json:"bounce"`
+		Delivery struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"delivery"`
+		Open struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"open"`
+		Reject struct {
+			Reason string `json:"reason"`
+		} `json:"reject"`
+	}
+	if err := json.Unmarshal([]byte(message), &payload); err != nil {
+		return Event{}, false
+	}
+
+	event := Event{Provider: "ses", ProviderMessageID: payload.Mail.MessageID}
+	eventType := payload.EventType
+	if eventType == "" {
+		eventType = payload.NotificationType
+	}
+	switch eventType {
+	case "Delivery":
+		event.Type = EventDelivered
+		event.At = payload.Delivery.Timestamp
+	case "Open":
+		event.Type = EventOpened
+		event.At = payload.Open.Timestamp
+	case "Bounce":
+		event.Type = EventBounced
+		event.At = payload.Bounce.Timestamp
+		event.BounceType = BounceTransient
+		if payload.Bounce.BounceType == "Permanent" {
+			event.BounceType = BouncePermanent
+		}
+		event.Reason = payload.Bounce.BounceSubType
+		if len(payload.Bounce.BouncedRecipients) > 0 && payload.Bounce.BouncedRecipients[0].DiagnosticCode != "" {
+			event.Reason = payload.Bounce.BouncedRecipients[0].DiagnosticCode
+		}
+	case "Reject":
+		event.Type = EventBounced
+		event.BounceType = BouncePermanent
+		event.Reason = "Rejected by SES: " + payload.Reject.Reason
+	default:
+		return Event{}, false
+	}
+	return event, true
+}
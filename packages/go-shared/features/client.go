@@ -0,0 +1,118 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+)
+
+// Checker reports whether a flag is on for a tenant. The auth service, which
+// owns the flags, checks them with a Store; the other services ask it
+// through a Client.
+type Checker interface {
+	Enabled(ctx context.Context, key string, tenantID uuid.UUID) bool
+}
+
+// Client reads the flags a tenant has from the auth service, caching them
+// per tenant like the Store does. It asks with a service token, so flags
+// can be checked outside requests too, such as in background jobs.
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	credentials *middleware.ServiceCredentials
+	ttl         time.Duration
+
+	mu      sync.Mutex
+	tenants map[uuid.UUID]cachedFlags
+}
+
+type cachedFlags struct {
+	flags    map[string]bool
+	loadedAt time.Time
+}
+
+// NewClient creates a client for the auth service at baseURL
+func NewClient(baseURL string, credentials *middleware.ServiceCredentials, cfg Config) *Client {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		credentials: credentials,
+		ttl:         cfg.CacheTTL,
+		tenants:     make(map[uuid.UUID]cachedFlags),
+	}
+}
+
+// Enabled reports whether a flag is on for tenantID. Flags are off for a
+// tenant whose flags could never be read; a failed refresh keeps serving
+// the last ones read.
+func (c *Client) Enabled(ctx context.Context, key string, tenantID uuid.UUID) bool {
+	return c.flags(ctx, tenantID)[key]
+}
+
+func (c *Client) flags(ctx context.Context, tenantID uuid.UUID) map[string]bool {
+	c.mu.Lock()
+	cached, ok := c.tenants[tenantID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.flags
+	}
+
+	flags, err := c.fetch(ctx, tenantID)
+	if err != nil {
+		log.Printf("features: failed to read flags of tenant %s: %v", tenantID, err)
+		return cached.flags
+	}
+
+	c.mu.Lock()
+	c.tenants[tenantID] = cachedFlags{flags: flags, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return flags
+}
+
+func (c *Client) fetch(ctx context.Context, tenantID uuid.UUID) (map[string]bool, error) {
+	authorization, err := c.credentials.Authorization(tenantID.String())
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/features", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Flags []string `json:"flags"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]bool, len(body.Data.Flags))
+	for _, key := range body.Data.Flags {
+		flags[key] = true
+	}
+	return flags, nil
+}
@@ -0,0 +1,51 @@
+// Package features evaluates feature flags for gradual rollouts. Flags live
+// in the auth service's database, and other services read a tenant's from
+// the auth service; both cache them in each instance. A tenant gets a flag
+// through an explicit override or by falling within its rollout
+// percentage.
+package features
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Flag is a feature that can be rolled out to tenants
+type Flag struct {
+	Key         string `gorm:"size:100;primaryKey" json:"key"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Enabled switches the flag on for the rollout; when false only tenants
+	// with an override get the feature
+	Enabled bool `gorm:"default:false" json:"enabled"`
+	// RolloutPercent is the share of tenants (0-100) that get an enabled
+	// flag without an override
+	RolloutPercent int `gorm:"default:0" json:"rollout_percent"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Flag
+func (Flag) TableName() string {
+	return "feature_flags"
+}
+
+// Override turns a flag on or off for one tenant regardless of the rollout,
+// e.g. for beta customers or to exclude a tenant with a known problem
+type Override struct {
+	FlagKey  string    `gorm:"size:100;primaryKey" json:"flag_key"`
+	TenantID uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	Enabled  bool      `gorm:"not null" json:"enabled"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Override
+func (Override) TableName() string {
+	return "feature_flag_overrides"
+}
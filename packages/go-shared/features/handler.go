@@ -0,0 +1,215 @@
+package features
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves the feature flag endpoints: the flags a tenant has, and
+// the admin API for managing flags, which is limited to super admins since
+// flags apply across all tenants
+type Handler struct {
+	store *Store
+}
+
+// NewHandler creates a new feature flag handler
+func NewHandler(store *Store) *Handler {
+	return &Handler{store: store}
+}
+
+// SaveFlagRequest creates or updates a flag
+type SaveFlagRequest struct {
+	Description    string `json:"description"`
+	Enabled        bool   `json:"enabled"`
+	RolloutPercent int    `json:"rollout_percent" binding:"min=0,max=100"`
+}
+
+// SetOverrideRequest turns a flag on or off for a tenant
+type SetOverrideRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// FlagDetail is a flag with its tenant overrides
+type FlagDetail struct {
+	Flag
+	Overrides []Override `json:"overrides"`
+}
+
+// Enabled returns the keys of the flags that are on for the caller's tenant
+func (h *Handler) Enabled(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	response.Success(c, gin.H{"flags": h.store.EnabledFlags(c.Request.Context(), tenantID)})
+}
+
+// ListFlags returns all flags
+func (h *Handler) ListFlags(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	flags, err := h.store.List(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to list feature flags")
+		return
+	}
+
+	response.Success(c, flags)
+}
+
+// GetFlag returns a flag and its overrides
+func (h *Handler) GetFlag(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	flag, err := h.store.Get(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		h.handleError(c, err, "Failed to get feature flag")
+		return
+	}
+
+	overrides, err := h.store.ListOverrides(c.Request.Context(), flag.Key)
+	if err != nil {
+		response.InternalError(c, "Failed to get feature flag")
+		return
+	}
+
+	response.Success(c, FlagDetail{Flag: *flag, Overrides: overrides})
+}
+
+// SaveFlag creates or updates a flag, e.g. to raise its rollout
+func (h *Handler) SaveFlag(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	var req SaveFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	flag := &Flag{
+		Key:            c.Param("key"),
+		Description:    req.Description,
+		Enabled:        req.Enabled,
+		RolloutPercent: req.RolloutPercent,
+		UpdatedBy:      userIDFromContext(c),
+	}
+	if err := h.store.Save(c.Request.Context(), flag); err != nil {
+		h.handleError(c, err, "Failed to save feature flag")
+		return
+	}
+
+	response.Success(c, flag)
+}
+
+// DeleteFlag removes a flag, turning it off everywhere
+func (h *Handler) DeleteFlag(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	if err := h.store.Delete(c.Request.Context(), c.Param("key")); err != nil {
+		h.handleError(c, err, "Failed to delete feature flag")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// SetOverride turns a flag on or off for one tenant
+func (h *Handler) SetOverride(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	var req SetOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	override := &Override{
+		FlagKey:   c.Param("key"),
+		TenantID:  tenantID,
+		Enabled:   *req.Enabled,
+		UpdatedBy: userIDFromContext(c),
+	}
+	if err := h.store.SetOverride(c.Request.Context(), override); err != nil {
+		h.handleError(c, err, "Failed to set feature flag override")
+		return
+	}
+
+	response.Success(c, override)
+}
+
+// RemoveOverride puts a tenant back on the flag's rollout
+func (h *Handler) RemoveOverride(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	if err := h.store.RemoveOverride(c.Request.Context(), c.Param("key"), tenantID); err != nil {
+		h.handleError(c, err, "Failed to remove feature flag override")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrFlagNotFound):
+		response.NotFound(c, "Feature flag not found")
+	case errors.Is(err, ErrOverrideNotFound):
+		response.NotFound(c, "Feature flag override not found")
+	case errors.Is(err, ErrFlagKeyRequired), errors.Is(err, ErrInvalidRollout):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+// requireSuperAdmin rejects callers without the super_admin role. The
+// shared RequireRole middleware also admits tenant owners, who must not
+// change flags for other tenants.
+func requireSuperAdmin(c *gin.Context) bool {
+	roles, _ := c.Get("user_roles")
+	userRoles, _ := roles.([]string)
+	for _, role := range userRoles {
+		if role == "super_admin" {
+			return true
+		}
+	}
+	response.Forbidden(c, "Feature flags can only be managed by super admins")
+	return false
+}
+
+func userIDFromContext(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
@@ -0,0 +1,50 @@
+package features
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Require lets requests through only for tenants that have the flag,
+// answering 404 otherwise so unreleased endpoints look absent. It runs
+// after the auth middleware, which sets the tenant.
+func (s *Store) Require(key string) gin.HandlerFunc {
+	return require(s, key)
+}
+
+// EnabledFor is a shorthand for checking a flag in a handler
+func (s *Store) EnabledFor(c *gin.Context, key string) bool {
+	return enabledFor(s, c, key)
+}
+
+// Require is Store.Require for services that read flags from the auth
+// service
+func (c *Client) Require(key string) gin.HandlerFunc {
+	return require(c, key)
+}
+
+// EnabledFor is Store.EnabledFor for services that read flags from the
+// auth service
+func (c *Client) EnabledFor(ctx *gin.Context, key string) bool {
+	return enabledFor(c, ctx, key)
+}
+
+func require(checker Checker, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabledFor(checker, c, key) {
+			response.NotFound(c, "Not found")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func enabledFor(checker Checker, c *gin.Context, key string) bool {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		return false
+	}
+	return checker.Enabled(c.Request.Context(), key, tenantID)
+}
@@ -0,0 +1,259 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrFlagNotFound     = errors.New("feature flag not found")
+	ErrInvalidRollout   = errors.New("rollout percent must be between 0 and 100")
+	ErrOverrideNotFound = errors.New("feature flag override not found")
+	ErrFlagKeyRequired  = errors.New("feature flag key is required")
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+// Config tunes the store. Zero values fall back to the defaults.
+type Config struct {
+	// CacheTTL is how long flags are cached. Changes made through another
+	// instance take up to this long to apply.
+	CacheTTL time.Duration
+}
+
+// snapshot is every flag and override as of loadedAt
+type snapshot struct {
+	flags     map[string]Flag
+	overrides map[string]map[uuid.UUID]bool
+	loadedAt  time.Time
+}
+
+// Store reads and manages feature flags
+type Store struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache *snapshot
+}
+
+// NewStore creates a feature flag store backed by db
+func NewStore(db *gorm.DB, cfg Config) *Store {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	return &Store{db: db, ttl: cfg.CacheTTL}
+}
+
+// Enabled reports whether a flag is on for tenantID. Unknown flags are
+// off, and so is everything if flags cannot be loaded.
+func (s *Store) Enabled(ctx context.Context, key string, tenantID uuid.UUID) bool {
+	snap := s.snapshot(ctx)
+	if snap == nil {
+		return false
+	}
+	return snap.enabled(key, tenantID)
+}
+
+// EnabledFlags returns the keys of the flags that are on for tenantID
+func (s *Store) EnabledFlags(ctx context.Context, tenantID uuid.UUID) []string {
+	snap := s.snapshot(ctx)
+	if snap == nil {
+		return []string{}
+	}
+
+	keys := []string{}
+	for key := range snap.flags {
+		if snap.enabled(key, tenantID) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (snap *snapshot) enabled(key string, tenantID uuid.UUID) bool {
+	flag, ok := snap.flags[key]
+	if !ok {
+		return false
+	}
+	if enabled, ok := snap.overrides[key][tenantID]; ok {
+		return enabled
+	}
+	return flag.Enabled && bucket(key, tenantID) < flag.RolloutPercent
+}
+
+// bucket places a tenant in 0-99 for a flag. It is stable, so raising the
+// percentage only ever adds tenants, and differs per flag so the same
+// tenants are not always first.
+func bucket(key string, tenantID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write(tenantID[:])
+	return int(h.Sum32() % 100)
+}
+
+// snapshot returns the cached flags, reloading them when stale. A failed
+// reload keeps serving the previous flags.
+func (s *Store) snapshot(ctx context.Context) *snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cache != nil && time.Since(s.cache.loadedAt) < s.ttl {
+		return s.cache
+	}
+
+	snap, err := s.load(ctx)
+	if err != nil {
+		log.Printf("features: failed to load flags: %v", err)
+		return s.cache
+	}
+	s.cache = snap
+	return snap
+}
+
+func (s *Store) load(ctx context.Context) (*snapshot, error) {
+	var flags []Flag
+	if err := s.db.WithContext(ctx).Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	var overrides []Override
+	if err := s.db.WithContext(ctx).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{
+		flags:     make(map[string]Flag, len(flags)),
+		overrides: make(map[string]map[uuid.UUID]bool),
+		loadedAt:  time.Now(),
+	}
+	for _, flag := range flags {
+		snap.flags[flag.Key] = flag
+	}
+	for _, o := range overrides {
+		if snap.overrides[o.FlagKey] == nil {
+			snap.overrides[o.FlagKey] = make(map[uuid.UUID]bool)
+		}
+		snap.overrides[o.FlagKey][o.TenantID] = o.Enabled
+	}
+	return snap, nil
+}
+
+// invalidate drops the cache so this instance sees its own changes at once
+func (s *Store) invalidate() {
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+}
+
+// List returns all flags
+func (s *Store) List(ctx context.Context) ([]Flag, error) {
+	var flags []Flag
+	err := s.db.WithContext(ctx).Order("key").Find(&flags).Error
+	return flags, err
+}
+
+// Get returns a flag
+func (s *Store) Get(ctx context.Context, key string) (*Flag, error) {
+	var flag Flag
+	err := s.db.WithContext(ctx).First(&flag, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFlagNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// Save creates or updates a flag
+func (s *Store) Save(ctx context.Context, flag *Flag) error {
+	if flag.Key == "" {
+		return ErrFlagKeyRequired
+	}
+	if flag.RolloutPercent < 0 || flag.RolloutPercent > 100 {
+		return ErrInvalidRollout
+	}
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "enabled", "rollout_percent", "updated_by", "updated_at"}),
+	}).Create(flag).Error
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// Delete removes a flag and its overrides
+func (s *Store) Delete(ctx context.Context, key string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&Override{}, "flag_key = ?", key).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&Flag{}, "key = ?", key)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFlagNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// ListOverrides returns the tenant overrides of a flag
+func (s *Store) ListOverrides(ctx context.Context, key string) ([]Override, error) {
+	var overrides []Override
+	err := s.db.WithContext(ctx).Where("flag_key = ?", key).Order("created_at").Find(&overrides).Error
+	return overrides, err
+}
+
+// SetOverride turns a flag on or off for one tenant
+func (s *Store) SetOverride(ctx context.Context, override *Override) error {
+	if _, err := s.Get(ctx, override.FlagKey); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "flag_key"}, {Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_by", "updated_at"}),
+	}).Create(override).Error
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// RemoveOverride puts a tenant back on the flag's rollout
+func (s *Store) RemoveOverride(ctx context.Context, key string, tenantID uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&Override{}, "flag_key = ? AND tenant_id = ?", key, tenantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOverrideNotFound
+	}
+
+	s.invalidate()
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package gst resolves Indian GST state codes, which places of supply are
+// reported by, from the codes or state names documents are entered with.
+package gst
+
+import "strings"
+
+// states are the GST state codes and the states they stand for
+var states = map[string]string{
+	"01": "Jammu and Kashmir",
+	"02": "Himachal Pradesh",
+	"03": "Punjab",
+	"04": "Chandigarh",
+	"05": "Uttarakhand",
+	"06": "Haryana",
+	"07": "Delhi",
+	"08": "Rajasthan",
+	"09": "Uttar Pradesh",
+	"10": "Bihar",
+	"11": "Sikkim",
+	"12": "Arunachal Pradesh",
+	"13": "Nagaland",
+	"14": "Manipur",
+	"15": "Mizoram",
+	"16": "Tripura",
+	"17": "Meghalaya",
+	"18": "Assam",
+	"19": "West Bengal",
+	"20": "Jharkhand",
+	"21": "Odisha",
+	"22": "Chhattisgarh",
+	"23": "Madhya Pradesh",
+	"24": "Gujarat",
+	"26": "Dadra and Nagar Haveli and Daman and Diu",
+	"27": "Maharashtra",
+	"29": "Karnataka",
+	"30": "Goa",
+	"31": "Lakshadweep",
+	"32": "Kerala",
+	"33": "Tamil Nadu",
+	"34": "Puducherry",
+	"35": "Andaman and Nicobar Islands",
+	"36": "Telangana",
+	"37": "Andhra Pradesh",
+	"38": "Ladakh",
+	"96": "Other Countries",
+	"97": "Other Territory",
+}
+
+// stateAliases are other names states are entered by
+var stateAliases = map[string]string{
+	"25":                        "26", // Daman and Diu, merged in 2020
+	"28":                        "37", // Andhra Pradesh before 2014
+	"jammu & kashmir":           "01",
+	"new delhi":                 "07",
+	"nct of delhi":              "07",
+	"orissa":                    "21",
+	"pondicherry":               "34",
+	"daman and diu":             "26",
+	"dadra & nagar haveli":      "26",
+	"dadra and nagar haveli":    "26",
+	"andaman & nicobar islands": "35",
+}
+
+// PlaceOfSupply resolves a place of supply, entered as a GST state code or
+// a state name, to its state code and name. Places that are neither are
+// returned as they were entered, without a code.
+func PlaceOfSupply(place string) (code, name string) {
+	place = strings.TrimSpace(place)
+	if place == "" {
+		return "", "Not specified"
+	}
+
+	key := strings.ToLower(place)
+	if alias, ok := stateAliases[key]; ok {
+		key = alias
+	}
+	if name, ok := states[key]; ok {
+		return key, name
+	}
+	for code, name := range states {
+		if strings.EqualFold(name, place) {
+			return code, name
+		}
+	}
+	return "", place
+}
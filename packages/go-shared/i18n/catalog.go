@@ -0,0 +1,212 @@
+package i18n
+
+// catalog maps English text to its translations. Entries are keyed by the
+// exact English string the code already uses, so handlers keep passing
+// plain messages and anything not yet translated is served in English.
+var catalog = map[string]map[string]string{
+	// API messages, most frequent first
+	"Tenant ID required": {
+		Hindi:    "टेनेंट आईडी आवश्यक है",
+		Gujarati: "ટેનન્ટ આઈડી જરૂરી છે",
+		Tamil:    "டெனன்ட் ஐடி தேவை",
+		Marathi:  "टेनंट आयडी आवश्यक आहे",
+	},
+	"Invalid request body": {
+		Hindi:    "अनुरोध का मुख्य भाग अमान्य है",
+		Gujarati: "વિનંતીનો મુખ્ય ભાગ અમાન્ય છે",
+		Tamil:    "கோரிக்கை உள்ளடக்கம் தவறானது",
+		Marathi:  "विनंतीचा मुख्य भाग अवैध आहे",
+	},
+	"User not authenticated": {
+		Hindi:    "उपयोगकर्ता प्रमाणित नहीं है",
+		Gujarati: "વપરાશકર્તા પ્રમાણિત નથી",
+		Tamil:    "பயனர் அங்கீகரிக்கப்படவில்லை",
+		Marathi:  "वापरकर्ता प्रमाणित नाही",
+	},
+	"Not found": {
+		Hindi:    "नहीं मिला",
+		Gujarati: "મળ્યું નથી",
+		Tamil:    "கிடைக்கவில்லை",
+		Marathi:  "सापडले नाही",
+	},
+	"Invalid bank account ID": {
+		Hindi:    "अमान्य बैंक खाता आईडी",
+		Gujarati: "અમાન્ય બેંક ખાતા આઈડી",
+		Tamil:    "தவறான வங்கிக் கணக்கு ஐடி",
+		Marathi:  "अवैध बँक खाते आयडी",
+	},
+	"Bank account not found": {
+		Hindi:    "बैंक खाता नहीं मिला",
+		Gujarati: "બેંક ખાતું મળ્યું નથી",
+		Tamil:    "வங்கிக் கணக்கு கிடைக்கவில்லை",
+		Marathi:  "बँक खाते सापडले नाही",
+	},
+	"Invalid invoice ID": {
+		Hindi:    "अमान्य इनवॉइस आईडी",
+		Gujarati: "અમાન્ય ઇન્વૉઇસ આઈડી",
+		Tamil:    "தவறான விலைப்பட்டியல் ஐடி",
+		Marathi:  "अवैध इनव्हॉइस आयडी",
+	},
+	"Invoice not found": {
+		Hindi:    "इनवॉइस नहीं मिला",
+		Gujarati: "ઇન્વૉઇસ મળ્યું નથી",
+		Tamil:    "விலைப்பட்டியல் கிடைக்கவில்லை",
+		Marathi:  "इनव्हॉइस सापडले नाही",
+	},
+	"Invalid party ID": {
+		Hindi:    "अमान्य पार्टी आईडी",
+		Gujarati: "અમાન્ય પાર્ટી આઈડી",
+		Tamil:    "தவறான தரப்பினர் ஐடி",
+		Marathi:  "अवैध पार्टी आयडी",
+	},
+	"Party not found": {
+		Hindi:    "पार्टी नहीं मिली",
+		Gujarati: "પાર્ટી મળી નથી",
+		Tamil:    "தரப்பினர் கிடைக்கவில்லை",
+		Marathi:  "पार्टी सापडली नाही",
+	},
+	"Invalid bill ID": {
+		Hindi:    "अमान्य बिल आईडी",
+		Gujarati: "અમાન્ય બિલ આઈડી",
+		Tamil:    "தவறான பில் ஐடி",
+		Marathi:  "अवैध बिल आयडी",
+	},
+	"Bill not found": {
+		Hindi:    "बिल नहीं मिला",
+		Gujarati: "બિલ મળ્યું નથી",
+		Tamil:    "பில் கிடைக்கவில்லை",
+		Marathi:  "बिल सापडले नाही",
+	},
+	"Account not found": {
+		Hindi:    "खाता नहीं मिला",
+		Gujarati: "ખાતું મળ્યું નથી",
+		Tamil:    "கணக்கு கிடைக்கவில்லை",
+		Marathi:  "खाते सापडले नाही",
+	},
+	"Product not found": {
+		Hindi:    "उत्पाद नहीं मिला",
+		Gujarati: "ઉત્પાદન મળ્યું નથી",
+		Tamil:    "தயாரிப்பு கிடைக்கவில்லை",
+		Marathi:  "उत्पादन सापडले नाही",
+	},
+	"Invalid MFA code": {
+		Hindi:    "अमान्य MFA कोड",
+		Gujarati: "અમાન્ય MFA કોડ",
+		Tamil:    "தவறான MFA குறியீடு",
+		Marathi:  "अवैध MFA कोड",
+	},
+	"No file uploaded": {
+		Hindi:    "कोई फ़ाइल अपलोड नहीं की गई",
+		Gujarati: "કોઈ ફાઇલ અપલોડ કરવામાં આવી નથી",
+		Tamil:    "எந்தக் கோப்பும் பதிவேற்றப்படவில்லை",
+		Marathi:  "कोणतीही फाइल अपलोड केलेली नाही",
+	},
+
+	// Document labels, for invoice and statement templates
+	"Tax Invoice": {
+		Hindi:    "कर बीजक",
+		Gujarati: "ટેક્સ ઇન્વૉઇસ",
+		Tamil:    "வரி விலைப்பட்டியல்",
+		Marathi:  "कर बीजक",
+	},
+	"Invoice Number": {
+		Hindi:    "बीजक संख्या",
+		Gujarati: "ઇન્વૉઇસ નંબર",
+		Tamil:    "விலைப்பட்டியல் எண்",
+		Marathi:  "बीजक क्रमांक",
+	},
+	"Invoice Date": {
+		Hindi:    "बीजक दिनांक",
+		Gujarati: "ઇન્વૉઇસ તારીખ",
+		Tamil:    "விலைப்பட்டியல் தேதி",
+		Marathi:  "बीजक दिनांक",
+	},
+	"Due Date": {
+		Hindi:    "देय तिथि",
+		Gujarati: "ચુકવણીની તારીખ",
+		Tamil:    "செலுத்த வேண்டிய தேதி",
+		Marathi:  "देय दिनांक",
+	},
+	"Bill To": {
+		Hindi:    "बिल प्राप्तकर्ता",
+		Gujarati: "બિલ મેળવનાર",
+		Tamil:    "பெறுநர்",
+		Marathi:  "बिल प्राप्तकर्ता",
+	},
+	"Description": {
+		Hindi:    "विवरण",
+		Gujarati: "વર્ણન",
+		Tamil:    "விவரம்",
+		Marathi:  "तपशील",
+	},
+	"Quantity": {
+		Hindi:    "मात्रा",
+		Gujarati: "જથ્થો",
+		Tamil:    "அளவு",
+		Marathi:  "प्रमाण",
+	},
+	"Rate": {
+		Hindi:    "दर",
+		Gujarati: "દર",
+		Tamil:    "விலை",
+		Marathi:  "दर",
+	},
+	"Amount": {
+		Hindi:    "राशि",
+		Gujarati: "રકમ",
+		Tamil:    "தொகை",
+		Marathi:  "रक्कम",
+	},
+	"Taxable Amount": {
+		Hindi:    "कर योग्य राशि",
+		Gujarati: "કરપાત્ર રકમ",
+		Tamil:    "வரிக்குட்பட்ட தொகை",
+		Marathi:  "करपात्र रक्कम",
+	},
+	"Total": {
+		Hindi:    "कुल",
+		Gujarati: "કુલ",
+		Tamil:    "மொத்தம்",
+		Marathi:  "एकूण",
+	},
+	"Balance Due": {
+		Hindi:    "शेष देय राशि",
+		Gujarati: "બાકી રકમ",
+		Tamil:    "நிலுவைத் தொகை",
+		Marathi:  "देय शिल्लक",
+	},
+	"Amount in Words": {
+		Hindi:    "राशि शब्दों में",
+		Gujarati: "રકમ શબ્દોમાં",
+		Tamil:    "தொகை எழுத்தில்",
+		Marathi:  "रक्कम अक्षरी",
+	},
+	"Terms and Conditions": {
+		Hindi:    "नियम और शर्तें",
+		Gujarati: "નિયમો અને શરતો",
+		Tamil:    "விதிமுறைகள் மற்றும் நிபந்தனைகள்",
+		Marathi:  "अटी व शर्ती",
+	},
+	"Authorised Signatory": {
+		Hindi:    "अधिकृत हस्ताक्षरकर्ता",
+		Gujarati: "અધિકૃત સહીકર્તા",
+		Tamil:    "அங்கீகரிக்கப்பட்ட கையொப்பமிடுபவர்",
+		Marathi:  "अधिकृत स्वाक्षरीकर्ता",
+	},
+}
+
+// T translates an English message into lang, returning it unchanged when
+// there is no translation
+func T(lang, message string) string {
+	if translated, ok := catalog[message][lang]; ok {
+		return translated
+	}
+	return message
+}
+
+// Translator returns a function translating messages into lang
+func Translator(lang string) func(string) string {
+	return func(message string) string {
+		return T(lang, message)
+	}
+}
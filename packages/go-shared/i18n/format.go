@@ -0,0 +1,119 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Digit grouping styles
+const (
+	GroupingIndian        = "indian"        // 1,00,00,000.00
+	GroupingInternational = "international" // 10,000,000.00
+)
+
+// CurrencyFormat describes how amounts are written in PDFs, reports and
+// exports
+type CurrencyFormat struct {
+	Code     string
+	Symbol   string
+	Decimals int
+	Grouping string
+}
+
+var currencyFormats = map[string]CurrencyFormat{
+	"INR": {Code: "INR", Symbol: "₹", Decimals: 2, Grouping: GroupingIndian},
+	"USD": {Code: "USD", Symbol: "$", Decimals: 2, Grouping: GroupingInternational},
+	"EUR": {Code: "EUR", Symbol: "€", Decimals: 2, Grouping: GroupingInternational},
+	"GBP": {Code: "GBP", Symbol: "£", Decimals: 2, Grouping: GroupingInternational},
+	"AED": {Code: "AED", Symbol: "AED ", Decimals: 2, Grouping: GroupingInternational},
+	"SGD": {Code: "SGD", Symbol: "S$", Decimals: 2, Grouping: GroupingInternational},
+	"JPY": {Code: "JPY", Symbol: "¥", Decimals: 0, Grouping: GroupingInternational},
+	"KWD": {Code: "KWD", Symbol: "KD ", Decimals: 3, Grouping: GroupingInternational},
+}
+
+// Currency returns the standard format of a currency. Unknown currencies
+// are written with their code and two decimals.
+func Currency(code string) CurrencyFormat {
+	code = strings.ToUpper(code)
+	if f, ok := currencyFormats[code]; ok {
+		return f
+	}
+	if code == "" {
+		return currencyFormats["INR"]
+	}
+	return CurrencyFormat{Code: code, Symbol: code + " ", Decimals: 2, Grouping: GroupingInternational}
+}
+
+// NewCurrencyFormat returns the format of a currency with a tenant's
+// overrides applied. An empty symbol or grouping, or negative decimals,
+// keep the currency's default.
+func NewCurrencyFormat(code, symbol string, decimals int, grouping string) CurrencyFormat {
+	f := Currency(code)
+	if symbol != "" {
+		f.Symbol = symbol
+	}
+	if decimals >= 0 {
+		f.Decimals = decimals
+	}
+	if grouping == GroupingIndian || grouping == GroupingInternational {
+		f.Grouping = grouping
+	}
+	return f
+}
+
+// Format writes an amount with the currency symbol, e.g. ₹1,23,456.70
+func (f CurrencyFormat) Format(amount float64) string {
+	return f.withSymbol(f.FormatNumber(amount))
+}
+
+// FormatNumber writes an amount grouped but without the symbol, for
+// export columns
+func (f CurrencyFormat) FormatNumber(amount float64) string {
+	return GroupDigits(strconv.FormatFloat(amount, 'f', f.Decimals, 64), f.Grouping)
+}
+
+// FormatFixed writes an amount already rendered as a plain decimal string,
+// such as decimal.StringFixed output, with the currency symbol. The string
+// is used as given, so callers round it to the currency's decimals.
+func (f CurrencyFormat) FormatFixed(amount string) string {
+	return f.withSymbol(GroupDigits(amount, f.Grouping))
+}
+
+func (f CurrencyFormat) withSymbol(number string) string {
+	if strings.HasPrefix(number, "-") {
+		return "-" + f.Symbol + number[1:]
+	}
+	return f.Symbol + number
+}
+
+// GroupDigits inserts separators into the integer part of a plain decimal
+// string such as "-1234567.50". Indian grouping separates the last three
+// digits and then every two; international grouping every three.
+func GroupDigits(number, grouping string) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	integer, fraction := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		integer, fraction = number[:i], number[i:]
+	}
+	if len(integer) <= 3 {
+		return sign + integer + fraction
+	}
+
+	head, tail := integer[:len(integer)-3], integer[len(integer)-3:]
+	size := 3
+	if grouping == GroupingIndian {
+		size = 2
+	}
+
+	var groups []string
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+	groups = append(groups, tail)
+	return sign + strings.Join(groups, ",") + fraction
+}
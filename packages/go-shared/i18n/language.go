@@ -0,0 +1,87 @@
+// Package i18n localizes API messages and documents into the Indian
+// languages the app supports.
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Supported languages, as ISO 639-1 codes
+const (
+	English  = "en"
+	Hindi    = "hi"
+	Gujarati = "gu"
+	Tamil    = "ta"
+	Marathi  = "mr"
+)
+
+// DefaultLanguage is used when no supported language is requested
+const DefaultLanguage = English
+
+var languageNames = map[string]string{
+	English:  "English",
+	Hindi:    "हिन्दी",
+	Gujarati: "ગુજરાતી",
+	Tamil:    "தமிழ்",
+	Marathi:  "मराठी",
+}
+
+// Languages returns the supported language codes
+func Languages() []string {
+	return []string{English, Hindi, Gujarati, Tamil, Marathi}
+}
+
+// Name returns a language's name written in that language
+func Name(lang string) string {
+	return languageNames[lang]
+}
+
+// Supported reports whether lang is a supported language code
+func Supported(lang string) bool {
+	_, ok := languageNames[lang]
+	return ok
+}
+
+// Normalize reduces a language tag such as "hi-IN" or "HI" to a supported
+// code, returning "" when the language is not supported
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if Supported(tag) {
+		return tag
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the first supported language in an
+// Accept-Language header, taking quality values into account
+func parseAcceptLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = part[:i]
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				q = parseQuality(param[2:])
+			}
+		}
+		if lang := Normalize(tag); lang != "" && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// parseQuality parses an Accept-Language q value, treating malformed
+// values as 0
+func parseQuality(s string) float64 {
+	q, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0
+	}
+	return q
+}
@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// LanguageKey is the context key of the request's language
+const LanguageKey = "language"
+
+// Middleware picks the language of each request from the lang query
+// parameter, the X-Language header or Accept-Language, in that order, and
+// translates error responses into it. Requests asking for nothing
+// supported get defaultLang.
+func Middleware(defaultLang string) gin.HandlerFunc {
+	if !Supported(defaultLang) {
+		defaultLang = DefaultLanguage
+	}
+
+	return func(c *gin.Context) {
+		lang := Normalize(c.Query("lang"))
+		if lang == "" {
+			lang = Normalize(c.GetHeader("X-Language"))
+		}
+		if lang == "" {
+			lang = parseAcceptLanguage(c.GetHeader("Accept-Language"))
+		}
+		if lang == "" {
+			lang = defaultLang
+		}
+
+		c.Set(LanguageKey, lang)
+		if lang != English {
+			c.Set(response.TranslatorKey, Translator(lang))
+		}
+		c.Header("Content-Language", lang)
+		c.Next()
+	}
+}
+
+// FromContext returns the request's language, or the default language when
+// the middleware did not run
+func FromContext(c *gin.Context) string {
+	if lang := c.GetString(LanguageKey); lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
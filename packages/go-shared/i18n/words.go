@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"strings"
+)
+
+// numberWords spells numbers in one language using the Indian system of
+// lakhs and crores
+type numberWords struct {
+	belowHundred [100]string
+	hundred      string
+	thousand     string
+	lakh         string
+	crore        string
+	minus        string
+
+	// rupees phrases a whole-rupee amount and paise one with paise
+	rupees func(rupees string) string
+	paise  func(rupees, paise string) string
+}
+
+var englishWords = func() *numberWords {
+	ones := []string{"Zero", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine",
+		"Ten", "Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+	tens := []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+
+	w := &numberWords{
+		hundred:  "Hundred",
+		thousand: "Thousand",
+		lakh:     "Lakh",
+		crore:    "Crore",
+		minus:    "Minus",
+		rupees: func(rupees string) string {
+			return "Rupees " + rupees + " Only"
+		},
+		paise: func(rupees, paise string) string {
+			return "Rupees " + rupees + " and " + paise + " Paise Only"
+		},
+	}
+	for n := 0; n < 100; n++ {
+		switch {
+		case n < 20:
+			w.belowHundred[n] = ones[n]
+		case n%10 == 0:
+			w.belowHundred[n] = tens[n/10]
+		default:
+			w.belowHundred[n] = tens[n/10] + " " + ones[n%10]
+		}
+	}
+	return w
+}()
+
+// Hindi has a distinct word for every number below a hundred
+var hindiWords = &numberWords{
+	belowHundred: [100]string{
+		"शून्य", "एक", "दो", "तीन", "चार", "पाँच", "छह", "सात", "आठ", "नौ",
+		"दस", "ग्यारह", "बारह", "तेरह", "चौदह", "पंद्रह", "सोलह", "सत्रह", "अठारह", "उन्नीस",
+		"बीस", "इक्कीस", "बाईस", "तेईस", "चौबीस", "पच्चीस", "छब्बीस", "सत्ताईस", "अट्ठाईस", "उनतीस",
+		"तीस", "इकतीस", "बत्तीस", "तैंतीस", "चौंतीस", "पैंतीस", "छत्तीस", "सैंतीस", "अड़तीस", "उनतालीस",
+		"चालीस", "इकतालीस", "बयालीस", "तैंतालीस", "चौवालीस", "पैंतालीस", "छियालीस", "सैंतालीस", "अड़तालीस", "उनचास",
+		"पचास", "इक्यावन", "बावन", "तिरपन", "चौवन", "पचपन", "छप्पन", "सत्तावन", "अट्ठावन", "उनसठ",
+		"साठ", "इकसठ", "बासठ", "तिरसठ", "चौंसठ", "पैंसठ", "छियासठ", "सड़सठ", "अड़सठ", "उनहत्तर",
+		"सत्तर", "इकहत्तर", "बहत्तर", "तिहत्तर", "चौहत्तर", "पचहत्तर", "छिहत्तर", "सतहत्तर", "अठहत्तर", "उन्यासी",
+		"अस्सी", "इक्यासी", "बयासी", "तिरासी", "चौरासी", "पचासी", "छियासी", "सत्तासी", "अट्ठासी", "नवासी",
+		"नब्बे", "इक्यानबे", "बानबे", "तिरानबे", "चौरानबे", "पंचानबे", "छियानबे", "सत्तानबे", "अट्ठानबे", "निन्यानबे",
+	},
+	hundred:  "सौ",
+	thousand: "हज़ार",
+	lakh:     "लाख",
+	crore:    "करोड़",
+	minus:    "ऋण",
+	rupees: func(rupees string) string {
+		return rupees + " रुपये मात्र"
+	},
+	paise: func(rupees, paise string) string {
+		return rupees + " रुपये और " + paise + " पैसे मात्र"
+	},
+}
+
+// wordsFor returns the number words of lang. Gujarati, Tamil and Marathi
+// documents print amounts in English words until their tables are added.
+func wordsFor(lang string) *numberWords {
+	if lang == Hindi {
+		return hindiWords
+	}
+	return englishWords
+}
+
+// NumberToWords spells n in lang, grouping by crore, lakh and thousand,
+// e.g. 1250000 is "Twelve Lakh Fifty Thousand"
+func NumberToWords(lang string, n int64) string {
+	w := wordsFor(lang)
+	if n < 0 {
+		return w.minus + " " + w.spell(uint64(-n))
+	}
+	return w.spell(uint64(n))
+}
+
+// AmountInWords spells an amount given in paise as it is written on
+// invoices and cheques, e.g. "Rupees One Lakh and Fifty Paise Only"
+func AmountInWords(lang string, paise int64) string {
+	w := wordsFor(lang)
+	if paise < 0 {
+		return w.minus + " " + w.amount(uint64(-paise))
+	}
+	return w.amount(uint64(paise))
+}
+
+func (w *numberWords) amount(paise uint64) string {
+	rupees := w.spell(paise / 100)
+	if paise%100 == 0 {
+		return w.rupees(rupees)
+	}
+	return w.paise(rupees, w.belowHundred[paise%100])
+}
+
+func (w *numberWords) spell(n uint64) string {
+	if n == 0 {
+		return w.belowHundred[0]
+	}
+	return strings.Join(w.parts(n), " ")
+}
+
+// parts spells a non-zero number. Amounts of a hundred crore and more are
+// read as a number of crores, e.g. "One Hundred Twenty Crore".
+func (w *numberWords) parts(n uint64) []string {
+	var parts []string
+	if crores := n / 10000000; crores > 0 {
+		parts = append(parts, w.parts(crores)...)
+		parts = append(parts, w.crore)
+		n %= 10000000
+	}
+	for _, group := range []struct {
+		size uint64
+		name string
+	}{
+		{100000, w.lakh},
+		{1000, w.thousand},
+		{100, w.hundred},
+	} {
+		if count := n / group.size; count > 0 {
+			parts = append(parts, w.belowHundred[count], group.name)
+			n %= group.size
+		}
+	}
+	if n > 0 {
+		parts = append(parts, w.belowHundred[n])
+	}
+	return parts
+}
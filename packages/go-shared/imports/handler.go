@@ -0,0 +1,106 @@
+package imports
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves the import job endpoints shared by every service that
+// runs imports: job status for polling and the failed rows report
+type Handler struct {
+	runner *Runner
+}
+
+// NewHandler creates a new import job handler
+func NewHandler(runner *Runner) *Handler {
+	return &Handler{runner: runner}
+}
+
+// List returns the tenant's recent import jobs, optionally filtered by kind
+func (h *Handler) List(c *gin.Context) {
+	tenantID, err := tenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	jobs, err := h.runner.List(c.Request.Context(), tenantID, c.Query("kind"), limit)
+	if err != nil {
+		response.InternalError(c, "Failed to list import jobs")
+		return
+	}
+
+	response.Success(c, jobs)
+}
+
+// Get returns an import job with its progress
+func (h *Handler) Get(c *gin.Context) {
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// ErrorReport downloads the rows of an import that failed, as CSV
+func (h *Handler) ErrorReport(c *gin.Context) {
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.runner.WriteErrorReport(c.Request.Context(), &buf, job); err != nil {
+		response.InternalError(c, "Failed to build error report")
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"import-"+job.ID.String()+"-errors.csv\"")
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+func (h *Handler) job(c *gin.Context) (*Job, bool) {
+	tenantID, err := tenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid import job ID", nil)
+		return nil, false
+	}
+
+	job, err := h.runner.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			response.NotFound(c, "Import job not found")
+		} else {
+			response.InternalError(c, "Failed to get import job")
+		}
+		return nil, false
+	}
+
+	return job, true
+}
+
+func tenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
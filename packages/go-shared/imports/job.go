@@ -0,0 +1,87 @@
+// Package imports runs CSV imports as background jobs. Uploads are spooled
+// to disk, parsed as a stream and handed to the service's importer in
+// chunks; progress is saved after every chunk and rows that fail are kept
+// for a downloadable error report.
+package imports
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// Job is a single CSV import and its progress
+type Job struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Kind        string     `gorm:"size:50;not null;index" json:"kind"`
+	ReferenceID *uuid.UUID `gorm:"type:uuid;index" json:"reference_id,omitempty"` // Record the rows are imported into, e.g. a bank account
+	FileName    string     `gorm:"size:255" json:"file_name"`
+	Header      string     `gorm:"type:text" json:"-"` // Header line of the file, repeated in the error report
+	Status      string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Progress    int        `gorm:"default:0" json:"progress"` // Percent of the file read
+
+	TotalRows     int    `gorm:"default:0" json:"total_rows"`
+	ImportedRows  int    `gorm:"default:0" json:"imported_rows"`
+	SkippedRows   int    `gorm:"default:0" json:"skipped_rows"`
+	DuplicateRows int    `gorm:"default:0" json:"duplicate_rows"` // Imported by an earlier upload, left out
+	FailedRows    int    `gorm:"default:0" json:"failed_rows"`
+	Error         string `gorm:"type:text" json:"error,omitempty"` // Why the job as a whole failed
+
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Job
+func (Job) TableName() string {
+	return "import_jobs"
+}
+
+// BeforeCreate hook
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsFinished reports whether the job has stopped running
+func (j *Job) IsFinished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed
+}
+
+// RowError is a row that could not be imported, kept with its original
+// values so the user can fix and re-upload just the failed rows
+type RowError struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	JobID     uuid.UUID `gorm:"type:uuid;not null;index" json:"job_id"`
+	RowNumber int       `gorm:"not null" json:"row_number"`
+	Values    string    `gorm:"type:text" json:"-"` // The row as a CSV line
+	Message   string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for RowError
+func (RowError) TableName() string {
+	return "import_row_errors"
+}
+
+// BeforeCreate hook
+func (e *RowError) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
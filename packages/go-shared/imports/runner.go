@@ -0,0 +1,415 @@
+package imports
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobNotFound  = errors.New("import job not found")
+	ErrFileTooLarge = errors.New("file is too large to import")
+	ErrEmptyFile    = errors.New("file is empty or not a valid CSV")
+)
+
+// Config tunes the runner. Zero values fall back to the defaults.
+type Config struct {
+	Dir         string        // Where uploads are spooled, os.TempDir() by default
+	ChunkSize   int           // Rows handed to the importer at a time
+	MaxRunning  int           // Jobs processed at once by this instance
+	MaxFileSize int64         // Largest accepted upload in bytes
+	StaleAfter  time.Duration // Unfinished jobs untouched this long are treated as interrupted
+}
+
+const (
+	defaultChunkSize   = 500
+	defaultMaxRunning  = 2
+	defaultMaxFileSize = 50 << 20
+	defaultStaleAfter  = 15 * time.Minute
+)
+
+// Row is a data row of the file. Number is its line in the file, the
+// header being line 1.
+type Row struct {
+	Number int
+	Values []string
+}
+
+// Value returns the trimmed value in column col, or "" when the row is
+// shorter or the column is missing (-1)
+func (r Row) Value(col int) string {
+	if col < 0 || col >= len(r.Values) {
+		return ""
+	}
+	return strings.TrimSpace(r.Values[col])
+}
+
+// Header maps lower-cased column names to their index
+type Header map[string]int
+
+// Find returns the index of the first of names present in the header, or
+// -1 when none is
+func (h Header) Find(names ...string) int {
+	for _, name := range names {
+		if idx, ok := h[name]; ok {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Chunk is a batch of rows handed to an Importer
+type Chunk struct {
+	Header Header
+	Rows   []Row
+}
+
+// ChunkResult is what the importer made of a chunk. Rows neither imported,
+// skipped, duplicate nor failed are not counted anywhere.
+type ChunkResult struct {
+	Imported   int
+	Skipped    int
+	Duplicates int // Rows imported before, by an earlier upload
+	Failures   []RowFailure
+}
+
+// RowFailure is a row the importer rejected
+type RowFailure struct {
+	Row     Row
+	Message string
+}
+
+// Fail records row as failed with the reason err
+func (r *ChunkResult) Fail(row Row, err error) {
+	r.Failures = append(r.Failures, RowFailure{Row: row, Message: err.Error()})
+}
+
+// Importer imports one chunk of rows. Row problems are reported in the
+// result; a returned error stops the job and marks it failed.
+type Importer func(ctx context.Context, job *Job, chunk Chunk) (ChunkResult, error)
+
+// Runner spools uploads and processes them in the background
+type Runner struct {
+	db    *gorm.DB
+	cfg   Config
+	slots chan struct{}
+}
+
+// NewRunner creates an import runner storing jobs in db
+func NewRunner(db *gorm.DB, cfg Config) *Runner {
+	if cfg.Dir == "" {
+		cfg.Dir = os.TempDir()
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = defaultChunkSize
+	}
+	if cfg.MaxRunning <= 0 {
+		cfg.MaxRunning = defaultMaxRunning
+	}
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = defaultMaxFileSize
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = defaultStaleAfter
+	}
+	return &Runner{
+		db:    db,
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxRunning),
+	}
+}
+
+// Start spools file to disk, saves job as pending and processes it in the
+// background. Only the spooling happens within the caller's request.
+func (r *Runner) Start(ctx context.Context, job *Job, file io.Reader, importer Importer) error {
+	spool, err := os.CreateTemp(r.cfg.Dir, "import-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to spool upload: %w", err)
+	}
+	path := spool.Name()
+
+	written, err := io.Copy(spool, io.LimitReader(file, r.cfg.MaxFileSize+1))
+	if closeErr := spool.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > r.cfg.MaxFileSize {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	job.Status = StatusPending
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	go r.run(*job, path, importer)
+	return nil
+}
+
+// Get returns a tenant's import job
+func (r *Runner) Get(ctx context.Context, tenantID, id uuid.UUID) (*Job, error) {
+	var job Job
+	err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns a tenant's most recent import jobs, optionally of one kind
+func (r *Runner) List(ctx context.Context, tenantID uuid.UUID, kind string, limit int) ([]Job, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var jobs []Job
+	err := query.Order("created_at DESC").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// WriteErrorReport writes the failed rows of job as CSV: the row number
+// and reason followed by the row's original columns
+func (r *Runner) WriteErrorReport(ctx context.Context, w io.Writer, job *Job) error {
+	out := csv.NewWriter(w)
+
+	header := []string{"row", "error"}
+	if job.Header != "" {
+		header = append(header, parseLine(job.Header)...)
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	var rowErrors []RowError
+	err := r.db.WithContext(ctx).
+		Where("job_id = ?", job.ID).
+		Order("row_number").
+		FindInBatches(&rowErrors, 1000, func(tx *gorm.DB, batch int) error {
+			for _, e := range rowErrors {
+				record := append([]string{fmt.Sprint(e.RowNumber), e.Message}, parseLine(e.Values)...)
+				if err := out.Write(record); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// FailInterrupted marks jobs that stopped making progress, such as those
+// running when an instance was restarted, as failed. Their spooled files
+// are gone so they cannot be resumed.
+func (r *Runner) FailInterrupted(ctx context.Context) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&Job{}).
+		Where("status IN ? AND updated_at < ?", []string{StatusPending, StatusProcessing}, now.Add(-r.cfg.StaleAfter)).
+		Updates(map[string]interface{}{
+			"status":       StatusFailed,
+			"error":        "import was interrupted, please upload the file again",
+			"completed_at": now,
+		}).Error
+}
+
+func (r *Runner) run(job Job, path string, importer Importer) {
+	r.slots <- struct{}{}
+	defer func() { <-r.slots }()
+	defer os.Remove(path)
+
+	ctx := context.Background()
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("import job %s panicked: %v", job.ID, p)
+			r.finish(ctx, &job, errors.New("unexpected error while importing"))
+		}
+	}()
+
+	r.finish(ctx, &job, r.process(ctx, &job, path, importer))
+}
+
+func (r *Runner) process(ctx context.Context, job *Job, path string, importer Importer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(bufio.NewReader(file))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1 // Variable number of fields
+
+	columns, err := reader.Read()
+	if err != nil {
+		return ErrEmptyFile
+	}
+	header := make(Header, len(columns))
+	columns[0] = strings.TrimPrefix(columns[0], "\ufeff") // Byte order mark from spreadsheet exports
+	for i, col := range columns {
+		header[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+
+	now := time.Now()
+	job.Status = StatusProcessing
+	job.StartedAt = &now
+	job.Header = formatLine(columns)
+	if err := r.db.WithContext(ctx).Model(job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"started_at": job.StartedAt,
+		"header":     job.Header,
+	}).Error; err != nil {
+		return err
+	}
+
+	rows := make([]Row, 0, r.cfg.ChunkSize)
+	var parseFailures []RowFailure
+
+	flush := func() error {
+		result := ChunkResult{Failures: parseFailures}
+		if len(rows) > 0 {
+			imported, err := importer(ctx, job, Chunk{Header: header, Rows: rows})
+			if err != nil {
+				return err
+			}
+			result.Imported = imported.Imported
+			result.Skipped = imported.Skipped
+			result.Duplicates = imported.Duplicates
+			result.Failures = append(result.Failures, imported.Failures...)
+		}
+
+		job.Progress = int(reader.InputOffset() * 100 / max(info.Size(), 1))
+		if err := r.saveChunk(ctx, job, len(rows)+len(parseFailures), result); err != nil {
+			return err
+		}
+
+		rows = rows[:0]
+		parseFailures = nil
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			parseFailures = append(parseFailures, RowFailure{
+				Row:     Row{Number: parseErr.StartLine, Values: record},
+				Message: parseErr.Err.Error(),
+			})
+		} else if err != nil {
+			return err
+		} else {
+			line, _ := reader.FieldPos(0)
+			rows = append(rows, Row{Number: line, Values: record})
+		}
+
+		if len(rows)+len(parseFailures) >= r.cfg.ChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// saveChunk adds a chunk's counts to the job and stores its failed rows
+func (r *Runner) saveChunk(ctx context.Context, job *Job, rowCount int, result ChunkResult) error {
+	job.TotalRows += rowCount
+	job.ImportedRows += result.Imported
+	job.SkippedRows += result.Skipped
+	job.DuplicateRows += result.Duplicates
+	job.FailedRows += len(result.Failures)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(result.Failures) > 0 {
+			rowErrors := make([]RowError, len(result.Failures))
+			for i, f := range result.Failures {
+				rowErrors[i] = RowError{
+					JobID:     job.ID,
+					RowNumber: f.Row.Number,
+					Values:    formatLine(f.Row.Values),
+					Message:   f.Message,
+				}
+			}
+			if err := tx.CreateInBatches(rowErrors, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(job).Updates(map[string]interface{}{
+			"progress":       job.Progress,
+			"total_rows":     job.TotalRows,
+			"imported_rows":  job.ImportedRows,
+			"skipped_rows":   job.SkippedRows,
+			"duplicate_rows": job.DuplicateRows,
+			"failed_rows":    job.FailedRows,
+		}).Error
+	})
+}
+
+// finish records the outcome of a job. Rows imported before a failure
+// stay imported and are reflected in the counts.
+func (r *Runner) finish(ctx context.Context, job *Job, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       StatusCompleted,
+		"progress":     100,
+		"completed_at": now,
+	}
+	if err != nil {
+		updates["status"] = StatusFailed
+		updates["error"] = err.Error()
+		delete(updates, "progress")
+	}
+
+	if dbErr := r.db.WithContext(ctx).Model(job).Updates(updates).Error; dbErr != nil {
+		log.Printf("failed to save outcome of import job %s: %v", job.ID, dbErr)
+	}
+}
+
+func formatLine(values []string) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(values)
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\r\n")
+}
+
+func parseLine(line string) []string {
+	values, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return []string{line}
+	}
+	return values
+}
@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// AdminHandler serves the admin endpoints for a service's job queue. Tenant
+// admins see their own tenant's jobs; super admins see every tenant's and
+// system jobs.
+type AdminHandler struct {
+	queue *Queue
+}
+
+// NewAdminHandler creates a new job admin handler
+func NewAdminHandler(queue *Queue) *AdminHandler {
+	return &AdminHandler{queue: queue}
+}
+
+// List returns recent jobs, filtered by status, type and, for super
+// admins, tenant
+func (h *AdminHandler) List(c *gin.Context) {
+	filter := Filter{
+		Status: c.Query("status"),
+		Type:   c.Query("type"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	tenantID, ok := scopeTenant(c)
+	if !ok {
+		return
+	}
+	filter.TenantID = tenantID
+
+	jobs, err := h.queue.List(c.Request.Context(), filter)
+	if err != nil {
+		response.InternalError(c, "Failed to list jobs")
+		return
+	}
+
+	response.Success(c, jobs)
+}
+
+// Stats returns job counts by type and status across the queue
+func (h *AdminHandler) Stats(c *gin.Context) {
+	if !isSuperAdmin(c) {
+		response.Forbidden(c, "Queue statistics are limited to super admins")
+		return
+	}
+
+	stats, err := h.queue.Stats(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to get job statistics")
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+// Get returns a job
+func (h *AdminHandler) Get(c *gin.Context) {
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// Retry requeues a dead-lettered or cancelled job
+func (h *AdminHandler) Retry(c *gin.Context) {
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.queue.Retry(c.Request.Context(), job.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotRetryable) {
+			response.Conflict(c, "Only dead or cancelled jobs can be retried")
+		} else {
+			response.InternalError(c, "Failed to retry job")
+		}
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// Cancel stops a pending job from running
+func (h *AdminHandler) Cancel(c *gin.Context) {
+	job, ok := h.job(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.queue.Cancel(c.Request.Context(), job.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotCancellable) {
+			response.Conflict(c, "Only pending jobs can be cancelled")
+		} else {
+			response.InternalError(c, "Failed to cancel job")
+		}
+		return
+	}
+
+	response.Success(c, job)
+}
+
+func (h *AdminHandler) job(c *gin.Context) (*Job, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid job ID", nil)
+		return nil, false
+	}
+
+	tenantID, ok := scopeTenant(c)
+	if !ok {
+		return nil, false
+	}
+
+	job, err := h.queue.Get(c.Request.Context(), id)
+	if err == nil && tenantID != nil && (job.TenantID == nil || *job.TenantID != *tenantID) {
+		err = ErrJobNotFound
+	}
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			response.NotFound(c, "Job not found")
+		} else {
+			response.InternalError(c, "Failed to get job")
+		}
+		return nil, false
+	}
+
+	return job, true
+}
+
+// scopeTenant returns the tenant whose jobs the caller may see: their own,
+// or for super admins the tenant_id query parameter if given
+func scopeTenant(c *gin.Context) (*uuid.UUID, bool) {
+	raw := c.GetString("tenant_id")
+	if isSuperAdmin(c) {
+		raw = c.Query("tenant_id")
+		if raw == "" {
+			return nil, true
+		}
+	}
+
+	tenantID, err := uuid.Parse(raw)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return nil, false
+	}
+	return &tenantID, true
+}
+
+func isSuperAdmin(c *gin.Context) bool {
+	roles, _ := c.Get("user_roles")
+	userRoles, _ := roles.([]string)
+	for _, role := range userRoles {
+		if role == "super_admin" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Package jobs is a Postgres-backed background job queue. Jobs are claimed
+// with FOR UPDATE SKIP LOCKED so any number of service instances can work
+// the same queue; failed jobs are retried with backoff and moved to the
+// dead-letter state once they run out of attempts.
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job statuses. Dead jobs failed every attempt and wait for an operator to
+// retry or discard them.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusDead      = "dead"
+	StatusCancelled = "cancelled"
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID       uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"` // Nil for system jobs
+	Type     string     `gorm:"size:100;not null;index" json:"type"`
+	Payload  string     `gorm:"type:jsonb;not null;default:'{}'" json:"payload"`
+
+	// UniqueKey stops the same job being queued twice, e.g. by two
+	// instances running the same schedule
+	UniqueKey *string `gorm:"size:255;uniqueIndex" json:"unique_key,omitempty"`
+
+	Status      string    `gorm:"size:20;not null;default:'pending';index:idx_jobs_claim,priority:1" json:"status"`
+	RunAt       time.Time `gorm:"not null;index:idx_jobs_claim,priority:2" json:"run_at"`
+	Attempts    int       `gorm:"default:0" json:"attempts"`
+	MaxAttempts int       `gorm:"default:5" json:"max_attempts"`
+	LastError   string    `gorm:"type:text" json:"last_error,omitempty"`
+
+	LockedBy    string     `gorm:"size:100" json:"locked_by,omitempty"` // Worker running the job
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Job
+func (Job) TableName() string {
+	return "background_jobs"
+}
+
+// BeforeCreate hook
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
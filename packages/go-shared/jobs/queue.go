@@ -0,0 +1,449 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrJobNotFound    = errors.New("job not found")
+	ErrDuplicateJob   = errors.New("job with this unique key already queued")
+	ErrUnknownJobType = errors.New("no handler registered for job type")
+	ErrNotRetryable   = errors.New("only dead or cancelled jobs can be retried")
+	ErrNotCancellable = errors.New("only pending jobs can be cancelled")
+)
+
+// Config tunes the queue. Zero values fall back to the defaults.
+type Config struct {
+	Worker            string        // Name recorded on claimed jobs, host and pid by default
+	Concurrency       int           // Jobs run at once by this instance
+	TenantConcurrency int           // Jobs of one tenant running at once across all instances
+	PollInterval      time.Duration // How often idle workers look for due jobs
+	LockTimeout       time.Duration // Running jobs not finished by then are assumed lost and requeued
+}
+
+const (
+	defaultConcurrency       = 4
+	defaultTenantConcurrency = 2
+	defaultPollInterval      = 2 * time.Second
+	defaultLockTimeout       = 15 * time.Minute
+	defaultMaxAttempts       = 5
+	defaultTimeout           = 10 * time.Minute
+
+	maxBackoff = time.Hour
+)
+
+// Handler runs a job. A returned error fails the attempt; the job is
+// retried until it runs out of attempts.
+type Handler func(ctx context.Context, job *Job) error
+
+// Options configures a job type
+type Options struct {
+	MaxAttempts int           // Attempts before the job is dead-lettered
+	Timeout     time.Duration // Deadline of a single attempt
+}
+
+// EnqueueOptions are the optional settings of a queued job
+type EnqueueOptions struct {
+	TenantID  *uuid.UUID
+	RunAt     time.Time // Run no earlier than this, now when zero
+	UniqueKey string    // Refuse the job if one with this key was ever queued
+}
+
+type registration struct {
+	handler Handler
+	opts    Options
+}
+
+type schedule struct {
+	jobType  string
+	interval time.Duration
+}
+
+// Queue stores jobs in Postgres and runs the registered job types
+type Queue struct {
+	db       *gorm.DB
+	cfg      Config
+	handlers map[string]registration
+	schedule []schedule
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// NewQueue creates a job queue storing jobs in db. Job types must be
+// registered before Start.
+func NewQueue(db *gorm.DB, cfg Config) *Queue {
+	if cfg.Worker == "" {
+		host, _ := os.Hostname()
+		cfg.Worker = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.TenantConcurrency <= 0 {
+		cfg.TenantConcurrency = defaultTenantConcurrency
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = defaultLockTimeout
+	}
+	return &Queue{
+		// Claims must see other workers' latest writes
+		db:       database.UsePrimary(db),
+		cfg:      cfg,
+		handlers: make(map[string]registration),
+	}
+}
+
+// Register sets the handler for a job type
+func (q *Queue) Register(jobType string, handler Handler, opts Options) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	q.handlers[jobType] = registration{handler: handler, opts: opts}
+}
+
+// Every queues a system job of jobType once per interval. Every instance
+// runs the schedule, but the job is keyed by its period so it is queued
+// only once.
+func (q *Queue) Every(jobType string, interval time.Duration) {
+	q.schedule = append(q.schedule, schedule{jobType: jobType, interval: interval})
+}
+
+// Enqueue queues a job with payload marshalled as JSON
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions) (*Job, error) {
+	reg, ok := q.handlers[jobType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &Job{
+		TenantID:    opts.TenantID,
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      StatusPending,
+		RunAt:       opts.RunAt,
+		MaxAttempts: reg.opts.MaxAttempts,
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+	}
+
+	result := q.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDuplicateJob
+	}
+	return job, nil
+}
+
+// Start runs the workers and schedules until ctx is cancelled or Stop is
+// called
+func (q *Queue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < q.cfg.Concurrency; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.maintain(ctx)
+	}()
+}
+
+// Stop stops claiming jobs and waits for running ones to finish
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+}
+
+// Get returns a job
+func (q *Queue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	var job Job
+	err := q.db.WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Filter narrows a job listing
+type Filter struct {
+	Status   string
+	Type     string
+	TenantID *uuid.UUID
+	Limit    int
+}
+
+// List returns the most recently updated jobs matching filter
+func (q *Queue) List(ctx context.Context, filter Filter) ([]Job, error) {
+	query := q.db.WithContext(ctx)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+
+	var jobs []Job
+	err := query.Order("updated_at DESC").Limit(filter.Limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// TypeStats counts the jobs of a type by status
+type TypeStats struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// Stats counts jobs by type and status
+func (q *Queue) Stats(ctx context.Context) ([]TypeStats, error) {
+	var stats []TypeStats
+	err := q.db.WithContext(ctx).Model(&Job{}).
+		Select("type, status, COUNT(*) AS count").
+		Group("type, status").
+		Order("type, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// Retry requeues a dead or cancelled job with a fresh set of attempts
+func (q *Queue) Retry(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return q.transition(ctx, id, []string{StatusDead, StatusCancelled}, ErrNotRetryable, map[string]interface{}{
+		"status":       StatusPending,
+		"attempts":     0,
+		"run_at":       time.Now(),
+		"completed_at": nil,
+	})
+}
+
+// Cancel stops a pending job from running
+func (q *Queue) Cancel(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return q.transition(ctx, id, []string{StatusPending}, ErrNotCancellable, map[string]interface{}{
+		"status":       StatusCancelled,
+		"completed_at": time.Now(),
+	})
+}
+
+func (q *Queue) transition(ctx context.Context, id uuid.UUID, from []string, errWrongStatus error, updates map[string]interface{}) (*Job, error) {
+	result := q.db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, errWrongStatus
+	}
+	return job, nil
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		job, err := q.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("jobs: failed to claim job: %v", err)
+		}
+		if job != nil {
+			q.run(job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.cfg.PollInterval):
+		}
+	}
+}
+
+// claim takes the oldest due job of a registered type whose tenant is
+// below its concurrency limit. The limit is checked at claim time, so two
+// instances claiming at the same moment can briefly exceed it.
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	var jobs []Job
+	err := q.db.WithContext(ctx).Raw(`
+		UPDATE background_jobs
+		SET status = @running, attempts = attempts + 1, locked_by = @worker, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT j.id FROM background_jobs j
+			WHERE j.status = @pending AND j.run_at <= NOW() AND j.type IN @types
+			  AND (j.tenant_id IS NULL OR (
+				SELECT COUNT(*) FROM background_jobs r
+				WHERE r.tenant_id = j.tenant_id AND r.status = @running
+			  ) < @tenantLimit)
+			ORDER BY j.run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, map[string]interface{}{
+		"running":     StatusRunning,
+		"pending":     StatusPending,
+		"worker":      q.cfg.Worker,
+		"types":       types,
+		"tenantLimit": q.cfg.TenantConcurrency,
+	}).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// run executes a claimed job and records the outcome. It deliberately does
+// not use the workers' context, so a shutdown lets the job finish.
+func (q *Queue) run(job *Job) {
+	reg := q.handlers[job.Type]
+
+	ctx, cancel := context.WithTimeout(context.Background(), reg.opts.Timeout)
+	defer cancel()
+
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return reg.handler(ctx, job)
+	}()
+
+	q.finish(job, err)
+}
+
+func (q *Queue) finish(job *Job, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       StatusCompleted,
+		"locked_by":    "",
+		"locked_at":    nil,
+		"completed_at": now,
+		"last_error":   "",
+	}
+	if err != nil {
+		updates["last_error"] = err.Error()
+		if job.Attempts >= job.MaxAttempts {
+			updates["status"] = StatusDead
+			log.Printf("jobs: %s job %s failed for the last time: %v", job.Type, job.ID, err)
+		} else {
+			updates["status"] = StatusPending
+			updates["run_at"] = now.Add(backoff(job.Attempts))
+			delete(updates, "completed_at")
+		}
+	}
+
+	// Only the worker holding the job may finish it; a job requeued after
+	// its lock timed out belongs to whoever claimed it next
+	if dbErr := q.db.Model(&Job{}).
+		Where("id = ? AND locked_by = ? AND status = ?", job.ID, q.cfg.Worker, StatusRunning).
+		Updates(updates).Error; dbErr != nil {
+		log.Printf("jobs: failed to save outcome of %s job %s: %v", job.Type, job.ID, dbErr)
+	}
+}
+
+// backoff is the wait before the next attempt: 30s doubling per failed
+// attempt, capped at an hour
+func backoff(attempts int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// maintain requeues jobs whose worker died and queues scheduled jobs
+func (q *Queue) maintain(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		q.requeueLost(ctx)
+		q.enqueueScheduled(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) requeueLost(ctx context.Context) {
+	err := q.db.WithContext(ctx).Model(&Job{}).
+		Where("status = ? AND locked_at < ?", StatusRunning, time.Now().Add(-q.cfg.LockTimeout)).
+		Updates(map[string]interface{}{
+			"status":     gorm.Expr("CASE WHEN attempts >= max_attempts THEN ? ELSE ? END", StatusDead, StatusPending),
+			"last_error": "worker stopped responding",
+			"locked_by":  "",
+			"locked_at":  nil,
+			"run_at":     time.Now(),
+		}).Error
+	if err != nil && ctx.Err() == nil {
+		log.Printf("jobs: failed to requeue lost jobs: %v", err)
+	}
+}
+
+func (q *Queue) enqueueScheduled(ctx context.Context) {
+	now := time.Now()
+	for _, s := range q.schedule {
+		key := fmt.Sprintf("%s@%s", s.jobType, now.Truncate(s.interval).UTC().Format(time.RFC3339))
+		_, err := q.Enqueue(ctx, s.jobType, struct{}{}, EnqueueOptions{UniqueKey: key})
+		if err != nil && !errors.Is(err, ErrDuplicateJob) && ctx.Err() == nil {
+			log.Printf("jobs: failed to queue scheduled %s job: %v", s.jobType, err)
+		}
+	}
+}
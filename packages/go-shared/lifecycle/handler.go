@@ -0,0 +1,92 @@
+package lifecycle
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves the operations view of a tenant's tracked documents:
+//
+//	GET /stuck                  ?kind= to narrow to one kind
+//	GET /documents/:kind/:id    current state and transitions
+type Handler struct {
+	tracker *Tracker
+}
+
+// NewHandler creates a lifecycle handler
+func NewHandler(tracker *Tracker) *Handler {
+	return &Handler{tracker: tracker}
+}
+
+// Stuck lists the tenant's documents held in a state past its SLA
+func (h *Handler) Stuck(c *gin.Context) {
+	tenantID, err := tenantIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Tenant not found")
+		return
+	}
+
+	kind := c.Query("kind")
+	if kind != "" {
+		if _, ok := h.tracker.machines[kind]; !ok {
+			response.BadRequest(c, ErrUnknownKind.Error(), nil)
+			return
+		}
+	}
+
+	stuck, err := h.tracker.Stuck(c.Request.Context(), tenantID, kind, time.Now())
+	if err != nil {
+		response.InternalError(c, "Failed to list stuck documents")
+		return
+	}
+
+	response.Success(c, stuck)
+}
+
+// Document returns a document's current state with its transitions
+func (h *Handler) Document(c *gin.Context) {
+	tenantID, err := tenantIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Tenant not found")
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid document ID", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	state, err := h.tracker.Current(ctx, tenantID, c.Param("kind"), id)
+	if err != nil {
+		if errors.Is(err, ErrNotTracked) {
+			response.NotFound(c, "Document is not tracked")
+		} else {
+			response.InternalError(c, "Failed to get document state")
+		}
+		return
+	}
+	transitions, err := h.tracker.History(ctx, tenantID, state.Kind, state.DocumentID)
+	if err != nil {
+		response.InternalError(c, "Failed to get document state")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"state":       state,
+		"transitions": transitions,
+	})
+}
+
+func tenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,100 @@
+// Package lifecycle tracks documents that are processed asynchronously, such
+// as e-invoices waiting on their IRN, return filings and exports. Each kind
+// of document moves through the states of its Machine; every transition is
+// recorded with its time, and a document held in a state longer than the
+// state's SLA is reported as stuck so operations can chase it.
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Machine describes the states a kind of document moves through
+type Machine struct {
+	Kind string
+	// Initial are the states a document may start in
+	Initial []string
+	// Transitions lists the states each state may move to. States without
+	// any are final.
+	Transitions map[string][]string
+	// SLA is how long a document may stay in a state before it is stuck.
+	// States without one are never stuck.
+	SLA map[string]time.Duration
+}
+
+func (m Machine) allows(from, to string) bool {
+	next := m.Initial
+	if from != "" {
+		next = m.Transitions[from]
+	}
+	for _, state := range next {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// State is where a document currently is in its lifecycle
+type State struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_states_due" json:"tenant_id"`
+	Kind       string     `gorm:"size:50;not null;uniqueIndex:idx_document_states_document" json:"kind"`
+	DocumentID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_document_states_document" json:"document_id"`
+	Label      string     `gorm:"size:255" json:"label,omitempty"` // How users know the document, such as its number
+	State      string     `gorm:"size:30;not null" json:"state"`
+	EnteredAt  time.Time  `gorm:"not null" json:"entered_at"`
+	DueAt      *time.Time `gorm:"index:idx_document_states_due" json:"due_at,omitempty"` // When the state's SLA runs out
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for State
+func (State) TableName() string {
+	return "document_states"
+}
+
+// BeforeCreate hook
+func (s *State) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Transition is a move of a document from one state to another. From is
+// empty for the state the document started in.
+type Transition struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_state_transitions" json:"tenant_id"`
+	Kind       string     `gorm:"size:50;not null;index:idx_document_state_transitions" json:"kind"`
+	DocumentID uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_state_transitions" json:"document_id"`
+	From       string     `gorm:"column:from_state;size:30" json:"from,omitempty"`
+	To         string     `gorm:"column:to_state;size:30;not null" json:"to"`
+	HeldFor    int64      `gorm:"default:0" json:"held_for_seconds"` // Time spent in the previous state
+	Note       string     `gorm:"type:text" json:"note,omitempty"`
+	ActorID    *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+	At         time.Time  `gorm:"not null" json:"at"`
+}
+
+// TableName returns the table name for Transition
+func (Transition) TableName() string {
+	return "document_state_transitions"
+}
+
+// BeforeCreate hook
+func (t *Transition) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Stuck is a document held in a state past its SLA
+type Stuck struct {
+	State
+	OverdueFor int64 `json:"overdue_for_seconds"`
+}
@@ -0,0 +1,166 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownKind       = errors.New("no lifecycle registered for this kind of document")
+	ErrInvalidTransition = errors.New("the document cannot move to this state")
+	ErrNotTracked        = errors.New("document is not tracked")
+)
+
+// Document identifies a tracked document
+type Document struct {
+	TenantID uuid.UUID
+	Kind     string
+	ID       uuid.UUID
+	Label    string
+}
+
+// Move is a transition requested by a caller
+type Move struct {
+	To      string
+	Note    string     // Such as the error of a failed state
+	ActorID *uuid.UUID // The user behind the move, nil for background work
+}
+
+// Tracker records the lifecycle of documents
+type Tracker struct {
+	db       *gorm.DB
+	machines map[string]Machine
+}
+
+// NewTracker creates a tracker storing states in db
+func NewTracker(db *gorm.DB) *Tracker {
+	return &Tracker{db: db, machines: make(map[string]Machine)}
+}
+
+// Register adds the machine of a kind of document
+func (t *Tracker) Register(machine Machine) {
+	t.machines[machine.Kind] = machine
+}
+
+// Move moves the document to a new state, starting to track it if it is
+// new. Moving a document to the state it is in does nothing, so retried
+// work can report its state again.
+func (t *Tracker) Move(ctx context.Context, doc Document, move Move) (*State, error) {
+	machine, ok := t.machines[doc.Kind]
+	if !ok {
+		return nil, ErrUnknownKind
+	}
+
+	var state State
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND document_id = ?", doc.Kind, doc.ID).
+			First(&state).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if state.State == move.To {
+			return nil
+		}
+		if !machine.allows(state.State, move.To) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, orNew(state.State), move.To)
+		}
+
+		now := time.Now()
+		transition := Transition{
+			TenantID:   doc.TenantID,
+			Kind:       doc.Kind,
+			DocumentID: doc.ID,
+			From:       state.State,
+			To:         move.To,
+			Note:       move.Note,
+			ActorID:    move.ActorID,
+			At:         now,
+		}
+		if state.State != "" {
+			transition.HeldFor = int64(now.Sub(state.EnteredAt).Seconds())
+		}
+
+		state.TenantID = doc.TenantID
+		state.Kind = doc.Kind
+		state.DocumentID = doc.ID
+		if doc.Label != "" {
+			state.Label = doc.Label
+		}
+		state.State = move.To
+		state.EnteredAt = now
+		state.DueAt = nil
+		if sla, ok := machine.SLA[move.To]; ok {
+			due := now.Add(sla)
+			state.DueAt = &due
+		}
+
+		if err := tx.Save(&state).Error; err != nil {
+			return err
+		}
+		return tx.Create(&transition).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func orNew(state string) string {
+	if state == "" {
+		return "new"
+	}
+	return state
+}
+
+// Current returns the state of a tenant's document
+func (t *Tracker) Current(ctx context.Context, tenantID uuid.UUID, kind string, id uuid.UUID) (*State, error) {
+	var state State
+	err := t.db.WithContext(ctx).
+		Where("tenant_id = ? AND kind = ? AND document_id = ?", tenantID, kind, id).
+		First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotTracked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// History returns the transitions of a tenant's document, oldest first
+func (t *Tracker) History(ctx context.Context, tenantID uuid.UUID, kind string, id uuid.UUID) ([]Transition, error) {
+	transitions := []Transition{}
+	err := t.db.WithContext(ctx).
+		Where("tenant_id = ? AND kind = ? AND document_id = ?", tenantID, kind, id).
+		Order("at").
+		Find(&transitions).Error
+	return transitions, err
+}
+
+// Stuck returns the tenant's documents held past their SLA at now, longest
+// overdue first, of one kind or of every kind when kind is empty
+func (t *Tracker) Stuck(ctx context.Context, tenantID uuid.UUID, kind string, now time.Time) ([]Stuck, error) {
+	query := t.db.WithContext(ctx).
+		Where("tenant_id = ? AND due_at < ?", tenantID, now)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var states []State
+	if err := query.Order("due_at").Find(&states).Error; err != nil {
+		return nil, err
+	}
+
+	stuck := make([]Stuck, len(states))
+	for i, state := range states {
+		stuck[i] = Stuck{State: state, OverdueFor: int64(now.Sub(*state.DueAt).Seconds())}
+	}
+	return stuck, nil
+}
@@ -0,0 +1,70 @@
+package messaging
+
+import "fmt"
+
+// Kinds of message services send. A provider template can be configured
+// for each kind on each channel; its placeholders take the kind's params
+// in the order listed.
+const (
+	KindOTP      = "otp"      // code, validity
+	KindInvoice  = "invoice"  // seller, invoice number, amount, due date
+	KindReminder = "reminder" // seller, invoice number, amount, due date
+)
+
+// Content is what a message says: its text for providers that send free
+// text and its params for those that send templates
+type Content struct {
+	Kind   string
+	Text   string
+	Params []string
+}
+
+// OTP is a login code, valid for expiresIn such as "10 minutes"
+func OTP(code, expiresIn string) Content {
+	return Content{
+		Kind:   KindOTP,
+		Text:   fmt.Sprintf("%s is your BookKeep verification code. It is valid for %s. Do not share it with anyone.", code, expiresIn),
+		Params: []string{code, expiresIn},
+	}
+}
+
+// Invoice tells a customer about an invoice sent to them. The amount is
+// formatted with its currency and the due date for reading.
+func Invoice(seller, invoiceNumber, amount, dueDate string) Content {
+	return Content{
+		Kind:   KindInvoice,
+		Text:   fmt.Sprintf("%s has sent you invoice %s for %s, due on %s.", sender(seller), invoiceNumber, amount, dueDate),
+		Params: []string{sender(seller), invoiceNumber, amount, dueDate},
+	}
+}
+
+// PaymentReminder reminds a customer of an invoice due on dueDate, which
+// is daysOverdue days ago; negative before the due date
+func PaymentReminder(seller, invoiceNumber, amount, dueDate string, daysOverdue int) Content {
+	text := "Payment reminder"
+	if seller != "" {
+		text += " from " + seller
+	}
+	switch {
+	case daysOverdue < 0:
+		text += fmt.Sprintf(": invoice %s for %s is due on %s.", invoiceNumber, amount, dueDate)
+	case daysOverdue == 0:
+		text += fmt.Sprintf(": invoice %s for %s is due today.", invoiceNumber, amount)
+	default:
+		text += fmt.Sprintf(": invoice %s for %s was due on %s and is %d days overdue.", invoiceNumber, amount, dueDate, daysOverdue)
+	}
+	return Content{
+		Kind:   KindReminder,
+		Text:   text,
+		Params: []string{sender(seller), invoiceNumber, amount, dueDate},
+	}
+}
+
+// sender names the seller of an invoice, who may not be known. Providers
+// refuse templates with an empty param.
+func sender(seller string) string {
+	if seller == "" {
+		return "Your supplier"
+	}
+	return seller
+}
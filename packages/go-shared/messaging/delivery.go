@@ -0,0 +1,98 @@
+package messaging
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Delivery statuses. A retrying message failed and is waiting for its next
+// attempt; a failed one will not be tried again unless resent by hand.
+const (
+	StatusQueued   = "queued"
+	StatusRetrying = "retrying"
+	StatusSent     = "sent"
+	StatusFailed   = "failed"
+)
+
+// Delivery is a message and its delivery status. Sent means the provider
+// accepted the message, not that it reached the phone.
+type Delivery struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"` // Nil for OTPs to users outside a tenant
+	Kind      string     `gorm:"size:20;not null" json:"kind"`
+	Reference string     `gorm:"size:100;index" json:"reference,omitempty"` // What the message is about, such as invoice:<id>
+
+	Channel  string   `gorm:"size:20;not null" json:"channel"`
+	ToPhone  string   `gorm:"size:20;not null;index" json:"to"`
+	Text     string   `gorm:"type:text" json:"-"`
+	Template string   `gorm:"size:255" json:"template,omitempty"`
+	Params   []string `gorm:"type:text;serializer:json" json:"-"`
+
+	// Essential messages, such as OTPs, are sent to recipients who opted
+	// out; sensitive ones have their text and params cleared once they are
+	// sent or given up on
+	Essential bool `gorm:"default:false" json:"essential"`
+	Sensitive bool `gorm:"default:false" json:"sensitive"`
+
+	Status            string     `gorm:"size:20;not null;default:'queued';index" json:"status"`
+	Provider          string     `gorm:"size:20" json:"provider,omitempty"`
+	ProviderMessageID string     `gorm:"size:255" json:"provider_message_id,omitempty"`
+	Attempts          int        `gorm:"default:0" json:"attempts"`
+	LastError         string     `gorm:"type:text" json:"last_error,omitempty"`
+	JobID             *uuid.UUID `gorm:"type:uuid" json:"job_id,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Delivery
+func (Delivery) TableName() string {
+	return "messaging_deliveries"
+}
+
+// BeforeCreate hook
+func (d *Delivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// message is the delivery as a message to send
+func (d *Delivery) message() Message {
+	return Message{
+		To:       d.ToPhone,
+		Text:     d.Text,
+		Template: d.Template,
+		Params:   d.Params,
+	}
+}
+
+// OptOut records that a phone number asked not to be messaged on a
+// channel. Opt-outs without a tenant, such as a STOP replied to our
+// number, apply to messages from every tenant.
+type OptOut struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	Phone     string     `gorm:"size:20;not null;index" json:"phone"`
+	Channel   string     `gorm:"size:20;not null" json:"channel"`
+	Source    string     `gorm:"size:20;not null" json:"source"` // api or the provider the recipient replied through
+	Reason    string     `gorm:"size:255" json:"reason,omitempty"`
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for OptOut
+func (OptOut) TableName() string {
+	return "messaging_opt_outs"
+}
+
+// BeforeCreate hook
+func (o *OptOut) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package messaging
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Replies that opt a recipient out of, or back in to, a channel. They are
+// the keywords Twilio itself honours on SMS.
+var (
+	optOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	optInKeywords  = []string{"START", "YES", "UNSTOP"}
+)
+
+// Handler serves the delivery status of the messages a service sent for
+// the caller's tenant, the tenant's opt-outs, and the callback recipients'
+// replies arrive on
+type Handler struct {
+	messenger       *Messenger
+	twilioAuthToken string
+}
+
+// NewHandler creates a new messaging handler. Replies through Twilio are
+// verified with twilioAuthToken; without one they are refused.
+func NewHandler(messenger *Messenger, twilioAuthToken string) *Handler {
+	return &Handler{messenger: messenger, twilioAuthToken: twilioAuthToken}
+}
+
+// List returns the tenant's recent messages, filtered by channel, status,
+// reference and recipient
+func (h *Handler) List(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	filter := Filter{
+		TenantID:  &tenantID,
+		Channel:   c.Query("channel"),
+		Status:    c.Query("status"),
+		Reference: c.Query("reference"),
+		To:        c.Query("to"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	deliveries, err := h.messenger.List(c.Request.Context(), filter)
+	if err != nil {
+		response.InternalError(c, "Failed to list messages")
+		return
+	}
+
+	response.Success(c, deliveries)
+}
+
+// Get returns a message and its delivery status
+func (h *Handler) Get(c *gin.Context) {
+	delivery, ok := h.delivery(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, delivery)
+}
+
+// Resend queues a failed message again
+func (h *Handler) Resend(c *gin.Context) {
+	delivery, ok := h.delivery(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.messenger.Resend(c.Request.Context(), delivery.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotResendable) {
+			response.Conflict(c, "Only failed messages can be resent")
+		} else {
+			response.InternalError(c, "Failed to resend message")
+		}
+		return
+	}
+
+	response.Success(c, delivery)
+}
+
+func (h *Handler) delivery(c *gin.Context) (*Delivery, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid message ID", nil)
+		return nil, false
+	}
+
+	delivery, err := h.messenger.Get(c.Request.Context(), id)
+	if err == nil && (delivery.TenantID == nil || delivery.TenantID.String() != c.GetString("tenant_id")) {
+		err = ErrDeliveryNotFound
+	}
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			response.NotFound(c, "Message not found")
+		} else {
+			response.InternalError(c, "Failed to get message")
+		}
+		return nil, false
+	}
+
+	return delivery, true
+}
+
+// ListOptOuts returns the opt-outs that apply to the tenant's messages,
+// optionally for one phone number
+func (h *Handler) ListOptOuts(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	optOuts, err := h.messenger.OptOuts(c.Request.Context(), tenantID, c.Query("phone"))
+	if err != nil {
+		response.InternalError(c, "Failed to list opt-outs")
+		return
+	}
+
+	response.Success(c, optOuts)
+}
+
+// CreateOptOutRequest is the body of CreateOptOut
+type CreateOptOutRequest struct {
+	Phone   string `json:"phone" binding:"required"`
+	Channel string `json:"channel" binding:"required,oneof=sms whatsapp"`
+	Reason  string `json:"reason" binding:"max=255"`
+}
+
+// CreateOptOut stops the tenant's non-essential messages to a phone number
+// on a channel, as when a customer asks by other means
+func (h *Handler) CreateOptOut(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req CreateOptOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	optOut := &OptOut{
+		TenantID: &tenantID,
+		Phone:    req.Phone,
+		Channel:  req.Channel,
+		Source:   SourceAPI,
+		Reason:   req.Reason,
+	}
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		optOut.CreatedBy = &userID
+	}
+
+	optOut, err = h.messenger.OptOut(c.Request.Context(), optOut)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPhone) {
+			response.BadRequest(c, "Invalid phone number", nil)
+		} else {
+			response.InternalError(c, "Failed to record opt-out")
+		}
+		return
+	}
+
+	response.Created(c, optOut)
+}
+
+// DeleteOptOut lifts one of the tenant's opt-outs
+func (h *Handler) DeleteOptOut(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid opt-out ID", nil)
+		return
+	}
+
+	if err := h.messenger.RemoveOptOut(c.Request.Context(), tenantID, id); err != nil {
+		if errors.Is(err, ErrOptOutNotFound) {
+			response.NotFound(c, "Opt-out not found")
+		} else {
+			response.InternalError(c, "Failed to remove opt-out")
+		}
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// TwilioInbound receives the SMS and WhatsApp replies Twilio forwards to
+// our numbers (public). A STOP opts the sender out of that channel for
+// every tenant and a START opts them back in; other replies are ignored.
+func (h *Handler) TwilioInbound(c *gin.Context) {
+	if h.twilioAuthToken == "" {
+		response.NotFound(c, "Twilio is not configured")
+		return
+	}
+	if err := c.Request.ParseForm(); err != nil {
+		response.BadRequest(c, "Invalid form", nil)
+		return
+	}
+	if !h.validTwilioSignature(c.Request) {
+		response.Unauthorized(c, "Invalid Twilio signature")
+		return
+	}
+
+	from := c.Request.PostForm.Get("From")
+	channel := ChannelSMS
+	if strings.HasPrefix(from, "whatsapp:") {
+		channel = ChannelWhatsApp
+		from = strings.TrimPrefix(from, "whatsapp:")
+	}
+
+	var err error
+	keyword := strings.ToUpper(strings.TrimSpace(c.Request.PostForm.Get("Body")))
+	switch {
+	case containsString(optOutKeywords, keyword):
+		_, err = h.messenger.OptOut(c.Request.Context(), &OptOut{Phone: from, Channel: channel, Source: "twilio", Reason: keyword})
+	case containsString(optInKeywords, keyword):
+		err = h.messenger.OptIn(c.Request.Context(), from, channel)
+	}
+	if err != nil && !errors.Is(err, ErrInvalidPhone) {
+		log.Printf("messaging: failed to record %s from %s: %v", keyword, from, err)
+		response.InternalError(c, "Failed to record reply")
+		return
+	}
+
+	// An empty TwiML response sends no reply of our own
+	c.Data(http.StatusOK, "text/xml", []byte("<Response></Response>"))
+}
+
+// validTwilioSignature checks X-Twilio-Signature, the base64 HMAC-SHA1 of
+// the URL Twilio called followed by each POST parameter's name and value
+// in name order. Behind a proxy the URL is rebuilt from the forwarded
+// scheme and host.
+func (h *Handler) validTwilioSignature(r *http.Request) bool {
+	signature := r.Header.Get("X-Twilio-Signature")
+	if signature == "" {
+		return false
+	}
+
+	scheme := "https"
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if r.TLS == nil {
+		scheme = "http"
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+
+	var signed strings.Builder
+	signed.WriteString(scheme + "://" + host + r.URL.RequestURI())
+	names := make([]string, 0, len(r.PostForm))
+	for name := range r.PostForm {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.PostForm[name] {
+			signed.WriteString(name + value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(h.twilioAuthToken))
+	mac.Write([]byte(signed.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Package messaging sends the messages services write to phones: login
+// OTPs, invoices and payment reminders, by SMS (MSG91 or Twilio) or
+// WhatsApp (Twilio or the WhatsApp Business Cloud API). Like email,
+// messages are recorded in the service's database and delivered by a
+// background job, and recipients who opted out of a channel are not sent
+// anything on it but essential messages such as OTPs.
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+)
+
+// Channels messages are sent on
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// ValidChannel reports whether channel is one messages can be sent on
+func ValidChannel(channel string) bool {
+	return channel == ChannelSMS || channel == ChannelWhatsApp
+}
+
+// Message is a text to one phone number. Providers that only send
+// pre-approved templates, as MSG91 does under India's DLT rules and
+// WhatsApp does for conversations the business starts, are sent Template
+// with Params; the others are sent Text.
+type Message struct {
+	To       string // E.164, such as +919876543210
+	Text     string
+	Template string   // The provider's ID or name of the template
+	Params   []string // Values of the template's placeholders, in order
+}
+
+// Driver delivers messages on one channel through a provider
+type Driver interface {
+	// Name identifies the provider in delivery records
+	Name() string
+	// Send delivers msg and returns the provider's ID for it. Errors the
+	// provider will give again on a retry, such as an invalid number, are
+	// wrapped in a *PermanentError.
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// PermanentError is a failure that retrying the same message will not fix
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a failure retrying will not fix
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// errNoTemplate is returned by providers that cannot send free text
+var errNoTemplate = &PermanentError{Err: errors.New("no template is configured for this message")}
+
+// NewDrivers creates the drivers of the configured SMS and WhatsApp
+// providers, keyed by channel
+func NewDrivers(cfg config.MessagingConfig) (map[string]Driver, error) {
+	drivers := make(map[string]Driver, 2)
+
+	switch cfg.SMSProvider {
+	case "", "log":
+		drivers[ChannelSMS] = logDriver{channel: ChannelSMS}
+	case "msg91":
+		drivers[ChannelSMS] = newMSG91Driver(cfg.MSG91AuthKey, cfg.MSG91SenderID)
+	case "twilio":
+		drivers[ChannelSMS] = newTwilioDriver(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioSMSFrom, "")
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.SMSProvider)
+	}
+
+	switch cfg.WhatsAppProvider {
+	case "", "log":
+		drivers[ChannelWhatsApp] = logDriver{channel: ChannelWhatsApp}
+	case "twilio":
+		drivers[ChannelWhatsApp] = newTwilioDriver(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioWhatsAppFrom, "whatsapp:")
+	case "meta":
+		drivers[ChannelWhatsApp] = newCloudAPIDriver(cfg.WhatsAppPhoneNumberID, cfg.WhatsAppAccessToken, cfg.WhatsAppLanguage)
+	default:
+		return nil, fmt.Errorf("unknown WhatsApp provider %q", cfg.WhatsAppProvider)
+	}
+
+	return drivers, nil
+}
+
+// logDriver writes messages to the service log instead of sending them,
+// for development
+type logDriver struct {
+	channel string
+}
+
+func (logDriver) Name() string {
+	return "log"
+}
+
+func (d logDriver) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Template != "" {
+		log.Printf("[%s] To %s: template %s (%s)\n%s", strings.ToUpper(d.channel), msg.To, msg.Template, strings.Join(msg.Params, ", "), msg.Text)
+	} else {
+		log.Printf("[%s] To %s: %s", strings.ToUpper(d.channel), msg.To, msg.Text)
+	}
+	return "", nil
+}
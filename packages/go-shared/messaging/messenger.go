@@ -0,0 +1,283 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"gorm.io/gorm"
+)
+
+// JobSendMessage is the job type that delivers a queued message
+const JobSendMessage = "messaging.send"
+
+// maxAttempts is how often a message is tried before it is failed. With
+// the queue's backoff the last attempt is about an hour after the first.
+const maxAttempts = 8
+
+var (
+	ErrDeliveryNotFound = errors.New("message not found")
+	ErrNotResendable    = errors.New("only failed messages can be resent")
+	ErrOptOutNotFound   = errors.New("opt-out not found")
+	ErrUnknownChannel   = errors.New("unknown messaging channel")
+	ErrOptedOut         = errors.New("recipient opted out of this channel")
+)
+
+// SendOptions are the optional settings of a queued message
+type SendOptions struct {
+	TenantID  *uuid.UUID
+	Reference string // What the message is about, such as invoice:<id>
+	Essential bool   // Send even if the recipient opted out, as for OTPs
+	Sensitive bool   // Clear the text once the message is sent or given up on
+}
+
+// Messenger records messages and delivers them through the driver of
+// their channel from the job queue, retrying failures with the queue's
+// backoff
+type Messenger struct {
+	db             *gorm.DB
+	queue          *jobs.Queue
+	drivers        map[string]Driver
+	templates      map[string]string
+	defaultCountry string
+}
+
+// NewMessenger creates a messenger sending through drivers, keyed by
+// channel, and registers its job type on queue. The queue must be started
+// for messages to go out.
+func NewMessenger(db *gorm.DB, queue *jobs.Queue, drivers map[string]Driver, cfg config.MessagingConfig) *Messenger {
+	m := &Messenger{
+		db:             db,
+		queue:          queue,
+		drivers:        drivers,
+		templates:      cfg.Templates,
+		defaultCountry: cfg.DefaultCountryCode,
+	}
+	queue.Register(JobSendMessage, m.deliver, jobs.Options{MaxAttempts: maxAttempts, Timeout: time.Minute})
+	return m
+}
+
+type sendPayload struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+// Send records content to the phone number on channel and queues it for
+// delivery. Unless the message is essential, it returns ErrOptedOut when
+// the recipient opted out of the channel.
+func (m *Messenger) Send(ctx context.Context, to, channel string, content Content, opts SendOptions) (*Delivery, error) {
+	if _, ok := m.drivers[channel]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channel)
+	}
+	phone, err := NormalizePhone(to, m.defaultCountry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPhone, to)
+	}
+	if !opts.Essential {
+		optedOut, err := m.OptedOut(ctx, opts.TenantID, phone, channel)
+		if err != nil {
+			return nil, err
+		}
+		if optedOut {
+			return nil, ErrOptedOut
+		}
+	}
+
+	delivery := &Delivery{
+		TenantID:  opts.TenantID,
+		Kind:      content.Kind,
+		Reference: opts.Reference,
+		Channel:   channel,
+		ToPhone:   phone,
+		Text:      content.Text,
+		Template:  m.templates[channel+"."+content.Kind],
+		Params:    content.Params,
+		Essential: opts.Essential,
+		Sensitive: opts.Sensitive,
+		Status:    StatusQueued,
+	}
+	if err := m.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	if err := m.enqueue(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (m *Messenger) enqueue(ctx context.Context, delivery *Delivery) error {
+	job, err := m.queue.Enqueue(ctx, JobSendMessage, sendPayload{DeliveryID: delivery.ID}, jobs.EnqueueOptions{TenantID: delivery.TenantID})
+	if err != nil {
+		m.update(delivery.ID, map[string]interface{}{"status": StatusFailed, "last_error": "failed to queue: " + err.Error()})
+		return err
+	}
+	delivery.JobID = &job.ID
+	return m.db.WithContext(ctx).Model(&Delivery{}).Where("id = ?", delivery.ID).Update("job_id", job.ID).Error
+}
+
+// Get returns a delivery
+func (m *Messenger) Get(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	var delivery Delivery
+	err := m.db.WithContext(ctx).First(&delivery, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Filter narrows a delivery listing
+type Filter struct {
+	TenantID  *uuid.UUID
+	Channel   string
+	Status    string
+	Reference string
+	To        string
+	Limit     int
+}
+
+// List returns the most recent deliveries matching filter
+func (m *Messenger) List(ctx context.Context, filter Filter) ([]Delivery, error) {
+	query := m.db.WithContext(ctx)
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Reference != "" {
+		query = query.Where("reference = ?", filter.Reference)
+	}
+	if filter.To != "" {
+		phone, err := NormalizePhone(filter.To, m.defaultCountry)
+		if err != nil {
+			return []Delivery{}, nil
+		}
+		query = query.Where("to_phone = ?", phone)
+	}
+
+	var deliveries []Delivery
+	err := query.Order("created_at DESC").Limit(filter.Limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Resend queues a failed message again with a fresh set of attempts.
+// Sensitive messages cannot be resent once their text is cleared.
+func (m *Messenger) Resend(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	delivery, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != StatusFailed || (delivery.Sensitive && delivery.Text == "") {
+		return nil, ErrNotResendable
+	}
+
+	result := m.db.WithContext(ctx).Model(&Delivery{}).
+		Where("id = ? AND status = ?", id, StatusFailed).
+		Updates(map[string]interface{}{"status": StatusQueued, "attempts": 0, "last_error": ""})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotResendable
+	}
+	if err := m.enqueue(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return m.Get(ctx, id)
+}
+
+// deliver sends a queued message. A failure the provider will repeat fails
+// the message at once, as does the recipient opting out while it waited;
+// others fail the job so the queue retries it.
+func (m *Messenger) deliver(ctx context.Context, job *jobs.Job) error {
+	var payload sendPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	var delivery Delivery
+	err := m.db.WithContext(ctx).First(&delivery, "id = ?", payload.DeliveryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status == StatusSent || delivery.Status == StatusFailed {
+		return nil
+	}
+
+	driver, ok := m.drivers[delivery.Channel]
+	if !ok {
+		m.finish(&delivery, map[string]interface{}{"status": StatusFailed, "last_error": ErrUnknownChannel.Error()})
+		return nil
+	}
+	if !delivery.Essential {
+		optedOut, err := m.OptedOut(ctx, delivery.TenantID, delivery.ToPhone, delivery.Channel)
+		if err != nil {
+			return err
+		}
+		if optedOut {
+			m.finish(&delivery, map[string]interface{}{"status": StatusFailed, "last_error": ErrOptedOut.Error()})
+			return nil
+		}
+	}
+
+	providerID, err := driver.Send(ctx, delivery.message())
+	updates := map[string]interface{}{
+		"provider": driver.Name(),
+		"attempts": delivery.Attempts + 1,
+	}
+	if err == nil {
+		now := time.Now()
+		updates["status"] = StatusSent
+		updates["provider_message_id"] = providerID
+		updates["last_error"] = ""
+		updates["sent_at"] = now
+		m.finish(&delivery, updates)
+		return nil
+	}
+
+	updates["last_error"] = err.Error()
+	if IsPermanent(err) || job.Attempts >= job.MaxAttempts {
+		updates["status"] = StatusFailed
+		log.Printf("messaging: giving up on %s to %s: %v", delivery.ID, delivery.ToPhone, err)
+		m.finish(&delivery, updates)
+		if IsPermanent(err) {
+			return nil
+		}
+		return err
+	}
+	updates["status"] = StatusRetrying
+	m.update(delivery.ID, updates)
+	return err
+}
+
+// finish records the final outcome of a delivery, clearing the text of a
+// sensitive one
+func (m *Messenger) finish(delivery *Delivery, updates map[string]interface{}) {
+	if delivery.Sensitive {
+		updates["text"] = ""
+		updates["params"] = nil
+	}
+	m.update(delivery.ID, updates)
+}
+
+// update saves a delivery's outcome. It does not use the job's context, so
+// the outcome of an attempt that timed out is still recorded.
+func (m *Messenger) update(id uuid.UUID, updates map[string]interface{}) {
+	if err := m.db.Model(&Delivery{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("messaging: failed to save status of %s: %v", id, err)
+	}
+}
@@ -0,0 +1,109 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const msg91FlowURL = "https://control.msg91.com/api/v5/flow/"
+
+// msg91Driver sends SMS through MSG91's flow API. Under India's DLT rules
+// only registered templates can be sent, so every message needs one; its
+// placeholders are filled from var1, var2 and so on.
+type msg91Driver struct {
+	authKey    string
+	senderID   string
+	httpClient *http.Client
+}
+
+func newMSG91Driver(authKey, senderID string) *msg91Driver {
+	return &msg91Driver{
+		authKey:    authKey,
+		senderID:   senderID,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (d *msg91Driver) Name() string {
+	return "msg91"
+}
+
+func (d *msg91Driver) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Template == "" {
+		return "", errNoTemplate
+	}
+
+	recipient := map[string]string{"mobiles": strings.TrimPrefix(msg.To, "+")}
+	for i, param := range msg.Params {
+		recipient["var"+strconv.Itoa(i+1)] = param
+	}
+	body := struct {
+		TemplateID string              `json:"template_id"`
+		Sender     string              `json:"sender,omitempty"`
+		ShortURL   string              `json:"short_url"`
+		Recipients []map[string]string `json:"recipients"`
+	}{
+		TemplateID: msg.Template,
+		Sender:     d.senderID,
+		ShortURL:   "0",
+		Recipients: []map[string]string{recipient},
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", &PermanentError{Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg91FlowURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("authkey", d.authKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to MSG91 failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", providerError("MSG91", resp)
+	}
+	// MSG91 reports some failures, such as an unknown template, with a 200
+	var result struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("reading MSG91 response: %w", err)
+	}
+	if result.Type != "success" {
+		return "", fmt.Errorf("MSG91 returned %s: %s", result.Type, result.Message)
+	}
+	return result.Message, nil
+}
+
+// providerError describes a failed API call. 4xx responses other than
+// throttling and bad credentials mean the message itself was refused, so
+// are permanent.
+func providerError(provider string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("%s returned %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(msg)))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode >= 500:
+		return err
+	case resp.StatusCode >= 400:
+		return &PermanentError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Opt-out sources other than the providers recipients reply through
+const SourceAPI = "api"
+
+// OptedOut reports whether phone opted out of channel, for tenantID or for
+// every tenant
+func (m *Messenger) OptedOut(ctx context.Context, tenantID *uuid.UUID, phone, channel string) (bool, error) {
+	query := m.db.WithContext(ctx).Model(&OptOut{}).Where("phone = ? AND channel = ?", phone, channel)
+	if tenantID != nil {
+		query = query.Where("tenant_id IS NULL OR tenant_id = ?", *tenantID)
+	} else {
+		query = query.Where("tenant_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// OptOut stops non-essential messages to phone on channel, from tenantID
+// or, when it is nil, from every tenant. Opting out again returns the
+// existing opt-out.
+func (m *Messenger) OptOut(ctx context.Context, optOut *OptOut) (*OptOut, error) {
+	if !ValidChannel(optOut.Channel) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, optOut.Channel)
+	}
+	phone, err := NormalizePhone(optOut.Phone, m.defaultCountry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPhone, optOut.Phone)
+	}
+	optOut.Phone = phone
+	if optOut.Source == "" {
+		optOut.Source = SourceAPI
+	}
+
+	var existing OptOut
+	query := m.db.WithContext(ctx).Where("phone = ? AND channel = ?", phone, optOut.Channel)
+	if optOut.TenantID != nil {
+		query = query.Where("tenant_id = ?", *optOut.TenantID)
+	} else {
+		query = query.Where("tenant_id IS NULL")
+	}
+	err = query.First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := m.db.WithContext(ctx).Create(optOut).Error; err != nil {
+		return nil, err
+	}
+	return optOut, nil
+}
+
+// OptIn removes phone's opt-out of channel that applies to every tenant,
+// as when the recipient replies START
+func (m *Messenger) OptIn(ctx context.Context, phone, channel string) error {
+	normalized, err := NormalizePhone(phone, m.defaultCountry)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidPhone, phone)
+	}
+	return m.db.WithContext(ctx).
+		Where("tenant_id IS NULL AND phone = ? AND channel = ?", normalized, channel).
+		Delete(&OptOut{}).Error
+}
+
+// OptOuts returns the opt-outs that apply to the tenant's messages, its own
+// and those for every tenant, newest first
+func (m *Messenger) OptOuts(ctx context.Context, tenantID uuid.UUID, phone string) ([]OptOut, error) {
+	query := m.db.WithContext(ctx).Where("tenant_id IS NULL OR tenant_id = ?", tenantID)
+	if phone != "" {
+		normalized, err := NormalizePhone(phone, m.defaultCountry)
+		if err != nil {
+			return []OptOut{}, nil
+		}
+		query = query.Where("phone = ?", normalized)
+	}
+
+	var optOuts []OptOut
+	err := query.Order("created_at DESC").Find(&optOuts).Error
+	return optOuts, err
+}
+
+// RemoveOptOut deletes one of the tenant's own opt-outs. Opt-outs for every
+// tenant are only lifted by the recipient.
+func (m *Messenger) RemoveOptOut(ctx context.Context, tenantID, id uuid.UUID) error {
+	result := m.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&OptOut{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOptOutNotFound
+	}
+	return nil
+}
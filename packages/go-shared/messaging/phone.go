@@ -0,0 +1,46 @@
+package messaging
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidPhone is returned for a number that cannot be put in E.164 form
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// NormalizePhone returns number in E.164 form, such as +919876543210.
+// Spaces, dashes, dots and brackets are ignored. A number without a + or 00
+// prefix is national: its leading zeros are dropped and defaultCountry is
+// put in front, unless it is longer than ten digits and already starts
+// with defaultCountry, as Indian numbers are often written.
+func NormalizePhone(number, defaultCountry string) (string, error) {
+	number = strings.TrimSpace(number)
+
+	var digits strings.Builder
+	for i, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	e164 := digits.String()
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(e164, "00"):
+		e164 = e164[2:]
+	case len(e164) > 10 && strings.HasPrefix(e164, defaultCountry):
+	default:
+		e164 = defaultCountry + strings.TrimLeft(e164, "0")
+	}
+
+	// E.164 numbers have at most 15 digits; none are shorter than 8
+	if len(e164) < 8 || len(e164) > 15 || e164[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + e164, nil
+}
@@ -0,0 +1,101 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPreferencesTTL = time.Minute
+
+// Preferences are the phone channels a tenant's messages go out on, kept
+// by the tenant service. Invoices and reminders are always emailed too.
+type Preferences struct {
+	TenantName       string   `json:"tenant_name"`
+	InvoiceChannels  []string `json:"invoice_channels"`
+	ReminderChannels []string `json:"reminder_channels"`
+	OTPChannel       string   `json:"otp_channel"` // The channel members' login codes are sent on
+}
+
+// PreferencesClient reads tenants' messaging preferences from the tenant
+// service, caching each tenant's for a short while. A failed lookup keeps
+// serving the last preferences it got.
+type PreferencesClient struct {
+	baseURL    string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu          sync.Mutex
+	preferences map[string]cachedPreferences
+}
+
+type cachedPreferences struct {
+	preferences *Preferences
+	loadedAt    time.Time
+}
+
+// NewPreferencesClient creates a client for the tenant service at baseURL.
+// Preference changes take up to ttl to apply; zero means a minute.
+func NewPreferencesClient(baseURL string, ttl time.Duration) *PreferencesClient {
+	if ttl <= 0 {
+		ttl = defaultPreferencesTTL
+	}
+	return &PreferencesClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		ttl:         ttl,
+		preferences: make(map[string]cachedPreferences),
+	}
+}
+
+// Get returns the tenant's messaging preferences
+func (c *PreferencesClient) Get(ctx context.Context, tenantID string) (*Preferences, error) {
+	c.mu.Lock()
+	cached, ok := c.preferences[tenantID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.preferences, nil
+	}
+
+	preferences, err := c.fetch(ctx, tenantID)
+	if err != nil {
+		if ok {
+			return cached.preferences, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.preferences[tenantID] = cachedPreferences{preferences: preferences, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return preferences, nil
+}
+
+func (c *PreferencesClient) fetch(ctx context.Context, tenantID string) (*Preferences, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/tenants/"+tenantID+"/messaging-preferences", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data Preferences `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
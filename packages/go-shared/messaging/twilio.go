@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const twilioAPIURL = "https://api.twilio.com/2010-04-01/Accounts/"
+
+// twilioDriver sends SMS or WhatsApp messages through Twilio's Messages
+// API. Messages with a template are sent as Twilio content, whose
+// variables are numbered from 1; the rest are sent as their text.
+type twilioDriver struct {
+	accountSID string
+	authToken  string
+	from       string
+	prefix     string // "whatsapp:" for WhatsApp, empty for SMS
+	httpClient *http.Client
+}
+
+func newTwilioDriver(accountSID, authToken, from, prefix string) *twilioDriver {
+	return &twilioDriver{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		prefix:     prefix,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (d *twilioDriver) Name() string {
+	return "twilio"
+}
+
+func (d *twilioDriver) Send(ctx context.Context, msg Message) (string, error) {
+	form := url.Values{}
+	form.Set("To", d.prefix+msg.To)
+	// Messaging service SIDs pick the sender from the service's pool
+	if strings.HasPrefix(d.from, "MG") {
+		form.Set("MessagingServiceSid", d.from)
+	} else {
+		form.Set("From", d.prefix+d.from)
+	}
+	if msg.Template != "" {
+		variables := make(map[string]string, len(msg.Params))
+		for i, param := range msg.Params {
+			variables[strconv.Itoa(i+1)] = param
+		}
+		data, err := json.Marshal(variables)
+		if err != nil {
+			return "", &PermanentError{Err: err}
+		}
+		form.Set("ContentSid", msg.Template)
+		form.Set("ContentVariables", string(data))
+	} else {
+		form.Set("Body", msg.Text)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, twilioAPIURL+d.accountSID+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(d.accountSID, d.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to Twilio failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", providerError("Twilio", resp)
+	}
+	var result struct {
+		SID string `json:"sid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("reading Twilio response: %w", err)
+	}
+	return result.SID, nil
+}
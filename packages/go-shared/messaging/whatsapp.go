@@ -0,0 +1,111 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const cloudAPIURL = "https://graph.facebook.com/v19.0/"
+
+// cloudAPIDriver sends WhatsApp messages through Meta's WhatsApp Business
+// Cloud API. Messages with a template are sent as that approved template,
+// its body parameters filled in order; free text is only delivered within
+// 24 hours of the customer's last message to the business.
+type cloudAPIDriver struct {
+	phoneNumberID string
+	accessToken   string
+	language      string
+	httpClient    *http.Client
+}
+
+func newCloudAPIDriver(phoneNumberID, accessToken, language string) *cloudAPIDriver {
+	return &cloudAPIDriver{
+		phoneNumberID: phoneNumberID,
+		accessToken:   accessToken,
+		language:      language,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (d *cloudAPIDriver) Name() string {
+	return "whatsapp"
+}
+
+type cloudAPIParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type cloudAPIComponent struct {
+	Type       string              `json:"type"`
+	Parameters []cloudAPIParameter `json:"parameters"`
+}
+
+type cloudAPITemplate struct {
+	Name     string `json:"name"`
+	Language struct {
+		Code string `json:"code"`
+	} `json:"language"`
+	Components []cloudAPIComponent `json:"components,omitempty"`
+}
+
+func (d *cloudAPIDriver) Send(ctx context.Context, msg Message) (string, error) {
+	body := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(msg.To, "+"),
+	}
+	if msg.Template != "" {
+		template := cloudAPITemplate{Name: msg.Template}
+		template.Language.Code = d.language
+		if len(msg.Params) > 0 {
+			component := cloudAPIComponent{Type: "body"}
+			for _, param := range msg.Params {
+				component.Parameters = append(component.Parameters, cloudAPIParameter{Type: "text", Text: param})
+			}
+			template.Components = []cloudAPIComponent{component}
+		}
+		body["type"] = "template"
+		body["template"] = template
+	} else {
+		body["type"] = "text"
+		body["text"] = map[string]string{"body": msg.Text}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", &PermanentError{Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cloudAPIURL+d.phoneNumberID+"/messages", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to WhatsApp failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", providerError("WhatsApp", resp)
+	}
+	var result struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("reading WhatsApp response: %w", err)
+	}
+	if len(result.Messages) == 0 {
+		return "", nil
+	}
+	return result.Messages[0].ID, nil
+}
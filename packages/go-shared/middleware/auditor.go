@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopeAuditor is the scope of tokens a tenant owner issues to external
+// auditors. They may only read the paths a service lists in
+// JWTConfig.AuditorRoutes, and only within the financial year of their
+// grant: date filters default to the year and may not leave it.
+const ScopeAuditor = "auditor"
+
+// AuditorAccessPath is where services check auditors' grants and log their
+// requests in the auth service. Auditor tokens may check their own grant,
+// and those checks are not logged themselves; only services may log.
+const AuditorAccessPath = "/api/v1/auditor-access"
+
+// auditorGrantPath is where an auditor token's grant is checked
+const auditorGrantPath = AuditorAccessPath + "/grant"
+
+// AuditorDateFormat is the format of the audited period's dates in tokens
+// and of the date filters auditors send
+const AuditorDateFormat = "2006-01-02"
+
+// Date filters held to the audited year. Ranges default to the whole year
+// and balances to its last day; other dates are only checked.
+const (
+	auditorFromParam = "from_date"
+	auditorToParam   = "to_date"
+)
+
+var (
+	auditorAsOfParams = []string{"as_of", "as_of_date"}
+	auditorDateParams = []string{"date"}
+)
+
+// AuditorAccess is a request made with an auditor token
+type AuditorAccess struct {
+	GrantID    string    `json:"grant_id"`
+	TenantID   string    `json:"tenant_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	StatusCode int       `json:"status_code"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// AuditorGrants checks the grants auditor tokens are issued under and logs
+// the requests made with them
+type AuditorGrants interface {
+	// Active reports whether the grant is neither revoked nor expired.
+	// authorization is the auditor's Authorization header, for
+	// implementations that ask the auth service.
+	Active(ctx context.Context, grantID, authorization string) (bool, error)
+	RecordAccess(ctx context.Context, access AuditorAccess) error
+}
+
+// serveAuditor lets an auditor's request through if its grant allows it,
+// and logs it whether or not it was refused
+func (config JWTConfig) serveAuditor(c *gin.Context, claims *Claims) {
+	c.Set("token_scope", claims.Scope)
+	c.Set("auditor_grant_id", claims.GrantID)
+
+	if c.Request.URL.Path == auditorGrantPath && c.Request.Method == http.MethodGet {
+		c.Next()
+		return
+	}
+
+	if config.checkAuditor(c, claims) {
+		c.Next()
+	}
+
+	if config.AuditorGrants == nil {
+		return
+	}
+	access := AuditorAccess{
+		GrantID:    claims.GrantID,
+		TenantID:   claims.TenantID,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Query:      c.Request.URL.RawQuery,
+		StatusCode: c.Writer.Status(),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		RequestID:  c.GetString("request_id"),
+		At:         time.Now().UTC(),
+	}
+	go func() {
+		if err := config.AuditorGrants.RecordAccess(context.Background(), access); err != nil {
+			log.Printf("auditor access: failed to record request of grant %s: %v", access.GrantID, err)
+		}
+	}()
+}
+
+// checkAuditor applies the auditor's restrictions to the request, aborting
+// it when refused. Unlike network policies, a grant that cannot be checked
+// refuses the request: auditors are outsiders, and can retry.
+func (config JWTConfig) checkAuditor(c *gin.Context, claims *Claims) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		abortAuditor(c, http.StatusForbidden, "auditor access is read-only")
+		return false
+	}
+	if !isAuditorRoute(config.AuditorRoutes, c.FullPath()) {
+		abortAuditor(c, http.StatusForbidden, "this is not available to auditors")
+		return false
+	}
+
+	start, startErr := time.Parse(AuditorDateFormat, claims.PeriodStart)
+	end, endErr := time.Parse(AuditorDateFormat, claims.PeriodEnd)
+	if claims.GrantID == "" || startErr != nil || endErr != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "invalid or expired token",
+		})
+		return false
+	}
+
+	if config.AuditorGrants != nil {
+		active, err := config.AuditorGrants.Active(c.Request.Context(), claims.GrantID, c.GetHeader("Authorization"))
+		if err != nil {
+			log.Printf("auditor access: failed to check grant %s: %v", claims.GrantID, err)
+			abortAuditor(c, http.StatusServiceUnavailable, "auditor access could not be checked, try again shortly")
+			return false
+		}
+		if !active {
+			abortAuditor(c, http.StatusUnauthorized, "auditor access has been revoked or has expired")
+			return false
+		}
+	}
+
+	if !limitToPeriod(c, start, end) {
+		abortAuditor(c, http.StatusForbidden, fmt.Sprintf("auditor access is limited to %s to %s", claims.PeriodStart, claims.PeriodEnd))
+		return false
+	}
+
+	c.Set("auditor_period_start", start)
+	c.Set("auditor_period_end", end)
+	return true
+}
+
+// limitToPeriod defaults the request's date range to the audited period,
+// reporting whether the dates it gives are all within it
+func limitToPeriod(c *gin.Context, start, end time.Time) bool {
+	query := c.Request.URL.Query()
+
+	within := func(value string) bool {
+		date, err := time.Parse(AuditorDateFormat, value)
+		return err == nil && !date.Before(start) && !date.After(end)
+	}
+	defaults := map[string]time.Time{auditorFromParam: start, auditorToParam: end}
+	for _, param := range auditorAsOfParams {
+		defaults[param] = end
+	}
+
+	for param, value := range defaults {
+		if query.Get(param) == "" {
+			query.Set(param, value.Format(AuditorDateFormat))
+		} else if !within(query.Get(param)) {
+			return false
+		}
+	}
+	for _, param := range auditorDateParams {
+		if value := query.Get(param); value != "" && !within(value) {
+			return false
+		}
+	}
+
+	c.Request.URL.RawQuery = query.Encode()
+	return true
+}
+
+// isAuditorRoute reports whether route, the matched route's pattern, is
+// one auditors may read. Unmatched requests have no route and never are.
+func isAuditorRoute(routes []string, route string) bool {
+	if route == "" {
+		return false
+	}
+	for _, r := range routes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+func abortAuditor(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":   "auditor_restricted",
+		"message": message,
+	})
+}
+
+// IsAuditor reports whether the request was made with an auditor token
+func IsAuditor(c *gin.Context) bool {
+	return c.GetString("token_scope") == ScopeAuditor
+}
+
+// WithinAuditorPeriod reports whether a record dated date may be shown to
+// the caller: always for users, and for auditors when it falls in the
+// audited year. Handlers that load records by ID check it, as such requests
+// carry no date filter to hold to the year.
+func WithinAuditorPeriod(c *gin.Context, date time.Time) bool {
+	if !IsAuditor(c) {
+		return true
+	}
+	start, startOK := c.Value("auditor_period_start").(time.Time)
+	end, endOK := c.Value("auditor_period_end").(time.Time)
+	if !startOK || !endOK {
+		return false
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(start) && !day.After(end)
+}
+
+// AuditorWatermark returns the text exports made for an auditor are
+// watermarked with, or "" for users
+func AuditorWatermark(c *gin.Context) string {
+	if !IsAuditor(c) {
+		return ""
+	}
+	return fmt.Sprintf("Auditor copy - %s - %s", c.GetString("user_email"), time.Now().Format("02 Jan 2006"))
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultAuditorGrantTTL = time.Minute
+
+// AuditorGrantClient checks auditor grants in the auth service with the
+// auditor's own token, and logs auditors' requests there with a service
+// token, which auditors do not have. Grants are cached for a short while; a
+// failed check keeps serving the last answer it got.
+type AuditorGrantClient struct {
+	baseURL     string
+	httpClient  *http.Client
+	ttl         time.Duration
+	credentials *ServiceCredentials
+
+	mu     sync.Mutex
+	grants map[string]cachedAuditorGrant
+}
+
+type cachedAuditorGrant struct {
+	active   bool
+	loadedAt time.Time
+}
+
+// NewAuditorGrantClient creates a client for the auth service at baseURL.
+// Revocations take up to ttl to apply; zero means a minute.
+func NewAuditorGrantClient(baseURL string, ttl time.Duration, credentials *ServiceCredentials) *AuditorGrantClient {
+	if ttl <= 0 {
+		ttl = defaultAuditorGrantTTL
+	}
+	return &AuditorGrantClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		ttl:         ttl,
+		credentials: credentials,
+		grants:      make(map[string]cachedAuditorGrant),
+	}
+}
+
+// Active reports whether the grant is neither revoked nor expired
+func (c *AuditorGrantClient) Active(ctx context.Context, grantID, authorization string) (bool, error) {
+	c.mu.Lock()
+	cached, ok := c.grants[grantID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.active, nil
+	}
+
+	active, err := c.fetch(ctx, authorization)
+	if err != nil {
+		if ok {
+			return cached.active, nil
+		}
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.grants[grantID] = cachedAuditorGrant{active: active, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return active, nil
+}
+
+func (c *AuditorGrantClient) fetch(ctx context.Context, authorization string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+AuditorAccessPath+"/grant", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnauthorized {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("auth service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Active bool `json:"active"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, err
+	}
+	return body.Data.Active, nil
+}
+
+// RecordAccess adds the request to the grant's access log
+func (c *AuditorGrantClient) RecordAccess(ctx context.Context, access AuditorAccess) error {
+	authorization, err := c.credentials.Authorization(access.TenantID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(access)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+AuditorAccessPath+"/log", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("auth service returned %d", resp.StatusCode)
+	}
+	return nil
+}
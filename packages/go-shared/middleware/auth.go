@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -10,10 +12,19 @@ import (
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID   string   `json:"user_id"`
-	Email    string   `json:"email"`
-	TenantID string   `json:"tenant_id"`
-	Roles    []string `json:"roles"`
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	TenantID  string   `json:"tenant_id"`
+	Roles     []string `json:"roles"`
+	SessionID string   `json:"sid,omitempty"`       // Login session the token was issued for
+	AuthTime  int64    `json:"auth_time,omitempty"` // When the user last entered their password or MFA code
+
+	// Auditor tokens are read-only and limited to a financial year; see
+	// ScopeAuditor
+	Scope       string `json:"scope,omitempty"`
+	GrantID     string `json:"grant_id,omitempty"`     // Auditor access grant the token was issued under
+	PeriodStart string `json:"period_start,omitempty"` // First day of the audited financial year
+	PeriodEnd   string `json:"period_end,omitempty"`   // Last day of the audited financial year
 	jwt.RegisteredClaims
 }
 
@@ -22,6 +33,75 @@ type JWTConfig struct {
 	Secret    string
 	Issuer    string
 	SkipPaths []string
+
+	// Secrets, when set, returns the secrets tokens may be signed with and
+	// replaces Secret, so a rotated secret applies without a restart
+	Secrets func() []string
+
+	// RefreshSecrets, when set, fetches the secrets again after a token
+	// matched none of them, reporting whether it did; see parseToken
+	RefreshSecrets func() bool
+
+	// NetworkPolicies, when set, restricts each tenant's users to the
+	// networks the tenant allows
+	NetworkPolicies NetworkPolicies
+
+	// CountryHeader names the header the edge reports the client's country
+	// in, such as CF-IPCountry. Country rules are not applied without it,
+	// nor to requests that did not come through a trusted proxy (see
+	// TrustProxies).
+	CountryHeader string
+
+	// AuditorRoutes are the routes auditor tokens may read, as registered,
+	// e.g. /api/v1/invoices/:id; they are refused everywhere else. Only
+	// routes that keep to the audited year belong here: ones filtered by
+	// the dates limitToPeriod holds to the year, or loading a record whose
+	// date the handler checks with WithinAuditorPeriod.
+	AuditorRoutes []string
+
+	// AuditorGrants, when set, refuses auditor tokens whose grant was
+	// revoked and logs every request made with one
+	AuditorGrants AuditorGrants
+}
+
+// parseToken validates a token against each accepted secret in turn. A
+// token whose signature matches none of them may be signed with a secret
+// rotated since the service last fetched its secrets; they are fetched
+// again, when RefreshSecrets allows, and the token checked once more.
+func (config JWTConfig) parseToken(tokenString string) (*Claims, bool) {
+	claims, badSignature := config.verifyToken(tokenString)
+	if claims == nil && badSignature && config.RefreshSecrets != nil && config.RefreshSecrets() {
+		claims, _ = config.verifyToken(tokenString)
+	}
+	return claims, claims != nil
+}
+
+// verifyToken returns the token's claims, or nil and whether it was
+// refused only for its signature
+func (config JWTConfig) verifyToken(tokenString string) (*Claims, bool) {
+	secrets := []string{config.Secret}
+	if config.Secrets != nil {
+		secrets = config.Secrets()
+	}
+
+	badSignature := false
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		claims := &Claims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		if err == nil && token.Valid {
+			return claims, false
+		}
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			return nil, false
+		}
+		badSignature = true
+	}
+	return nil, badSignature
 }
 
 // AuthMiddleware validates JWT tokens
@@ -58,12 +138,8 @@ func AuthMiddleware(config JWTConfig) gin.HandlerFunc {
 		tokenString := tokenParts[1]
 
 		// Parse and validate token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.Secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, valid := config.parseToken(tokenString)
+		if !valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error":   "unauthorized",
 				"message": "invalid or expired token",
@@ -79,6 +155,24 @@ func AuthMiddleware(config JWTConfig) gin.HandlerFunc {
 		if claims.TenantID != "" {
 			c.Set("tenant_id", claims.TenantID)
 		}
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+		}
+		if claims.AuthTime != 0 {
+			c.Set("auth_time", time.Unix(claims.AuthTime, 0))
+		}
+		if claims.Scope != "" {
+			c.Set("token_scope", claims.Scope)
+		}
+
+		if !config.checkNetwork(c, claims) {
+			return
+		}
+
+		if claims.Scope == ScopeAuditor {
+			config.serveAuditor(c, claims)
+			return
+		}
 
 		c.Next()
 	}
@@ -192,12 +286,9 @@ func OptionalAuth(config JWTConfig) gin.HandlerFunc {
 		}
 
 		tokenString := tokenParts[1]
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.Secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, valid := config.parseToken(tokenString)
+		// Auditor tokens only open the routes AuthMiddleware lets them read
+		if !valid || claims.Scope == ScopeAuditor {
 			c.Next()
 			return
 		}
@@ -209,6 +300,12 @@ func OptionalAuth(config JWTConfig) gin.HandlerFunc {
 		if claims.TenantID != "" {
 			c.Set("tenant_id", claims.TenantID)
 		}
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+		}
+		if claims.AuthTime != 0 {
+			c.Set("auth_time", time.Unix(claims.AuthTime, 0))
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionStore tracks how many times each tenant's resource has changed
+type VersionStore interface {
+	Version(ctx context.Context, tenantID, resource string) (int64, time.Time, error)
+	Bump(ctx context.Context, tenantID, resource string) error
+}
+
+// ConditionalRequests adds ETag and Last-Modified headers to reads of a
+// route group that serves one resource, and answers 304 Not Modified when
+// the client's copy is still current. Successful writes through the group
+// bump the resource's version, which invalidates every cached read of it;
+// writes made elsewhere must bump it themselves.
+//
+// The validators come from the version alone, so a 304 is answered without
+// running the handler. Use after AuthMiddleware or TenantMiddleware.
+func ConditionalRequests(store VersionStore, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetString("tenant_id")
+		if tenantID == "" {
+			tenantID = c.GetHeader("X-Tenant-ID")
+		}
+		if tenantID == "" {
+			c.Next()
+			return
+		}
+
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			if status := c.Writer.Status(); status >= 200 && status < 300 {
+				if err := store.Bump(c.Request.Context(), tenantID, resource); err != nil {
+					log.Printf("failed to bump %s version for tenant %s: %v", resource, tenantID, err)
+				}
+			}
+			return
+		}
+
+		version, modified, err := store.Version(c.Request.Context(), tenantID, resource)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		etag := resourceETag(tenantID, resource, version, c.Request.URL.RequestURI(), c.GetHeader("Accept-Language"))
+		c.Header("ETag", etag)
+		if !modified.IsZero() {
+			modified = modified.UTC().Truncate(time.Second)
+			c.Header("Last-Modified", modified.Format(http.TimeFormat))
+		}
+		// Clients may keep a copy but must check it is current before use
+		c.Header("Cache-Control", "private, no-cache")
+		c.Header("Vary", "Authorization, X-Tenant-ID, Accept-Language")
+
+		if notModified(c.Request, etag, modified) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		c.Next()
+	}
+}
+
+func resourceETag(tenantID, resource string, version int64, uri, language string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%s\x00%s", tenantID, resource, version, uri, language)))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified applies RFC 9110: If-None-Match wins when present, otherwise
+// If-Modified-Since is compared with the last change
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !modified.IsZero() {
+		if t, err := http.ParseTime(since); err == nil {
+			return !modified.After(t)
+		}
+	}
+	return false
+}
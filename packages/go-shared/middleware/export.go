@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermDataExport is the tenant-service permission to export or download
+// the tenant's data in bulk: statements, CSV and PDF packs, data rooms
+const PermDataExport = "data:export"
+
+// Export statuses
+const (
+	ExportSucceeded = "success"
+	ExportDenied    = "denied"
+)
+
+const (
+	exportRowsKey    = "export_rows"
+	exportFiltersKey = "export_filters"
+)
+
+// ExportRecord is an export made, or refused, in a service, for the
+// tenant's audit log
+type ExportRecord struct {
+	TenantID  string            `json:"tenant_id"`
+	UserID    string            `json:"user_id"`
+	Resource  string            `json:"resource"`
+	Status    string            `json:"status"`
+	Filters   map[string]string `json:"filters,omitempty"`
+	Rows      *int              `json:"rows,omitempty"` // Nil when the handler doesn't count them
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	IPAddress string            `json:"ip_address"`
+	UserAgent string            `json:"user_agent"`
+	RequestID string            `json:"request_id,omitempty"`
+	At        time.Time         `json:"at"`
+}
+
+// ExportAudit checks who may export a tenant's data and records the
+// exports made. authorization is the request's Authorization header, for
+// implementations that ask the tenant service.
+type ExportAudit interface {
+	// CanExport reports whether the caller holds PermDataExport in the
+	// tenant
+	CanExport(ctx context.Context, tenantID, authorization string) (bool, error)
+	RecordExport(ctx context.Context, authorization string, record ExportRecord) error
+}
+
+// ExportGuard gates a service's export and download endpoints behind
+// PermDataExport and adds each export to the tenant's audit log.
+//
+// Auditors are let through: their grant is the owner's consent, their
+// requests are already logged against it and their exports watermarked.
+type ExportGuard struct {
+	exports ExportAudit
+}
+
+// NewExportGuard creates a guard checking and recording exports with
+// exports
+func NewExportGuard(exports ExportAudit) *ExportGuard {
+	return &ExportGuard{exports: exports}
+}
+
+// Permit lets through only callers who may export, without recording the
+// request. It is for endpoints that hand out an export already recorded,
+// such as the download link of a queued export.
+func (g *ExportGuard) Permit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAuditor(c) || g.allow(c, "") {
+			c.Next()
+		}
+	}
+}
+
+// Export gates an endpoint exporting resource and records each successful
+// request. The query string is recorded as the export's filters unless the
+// handler sets its own with SetExportFilters.
+func (g *ExportGuard) Export(resource string) gin.HandlerFunc {
+	return g.ExportWhen(resource, nil)
+}
+
+// ExportWhen is Export for endpoints that only export some of the time,
+// such as a ledger that is downloaded when asked for as PDF and otherwise
+// shown on screen. Requests when doesn't match go straight through.
+func (g *ExportGuard) ExportWhen(resource string, when func(c *gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAuditor(c) || (when != nil && !when(c)) {
+			c.Next()
+			return
+		}
+		if !g.allow(c, resource) {
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		g.record(c, resource, ExportSucceeded)
+	}
+}
+
+// allow checks the caller may export, aborting the request when not.
+// Refusals of an export of resource are recorded.
+func (g *ExportGuard) allow(c *gin.Context, resource string) bool {
+	allowed, err := g.exports.CanExport(c.Request.Context(), c.GetString("tenant_id"), c.GetHeader("Authorization"))
+	if err != nil {
+		log.Printf("export guard: failed to check export permission of user %s: %v", c.GetString("user_id"), err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "service_unavailable",
+			"message": "Could not check permissions",
+		})
+		return false
+	}
+	if !allowed {
+		if resource != "" {
+			g.record(c, resource, ExportDenied)
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Your role does not allow exporting data",
+		})
+		return false
+	}
+	return true
+}
+
+// record sends the export to the audit log in the background, so a slow
+// tenant service doesn't hold up the download
+func (g *ExportGuard) record(c *gin.Context, resource, status string) {
+	record := ExportRecord{
+		TenantID:  c.GetString("tenant_id"),
+		UserID:    c.GetString("user_id"),
+		Resource:  resource,
+		Status:    status,
+		Filters:   exportFilters(c),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+		At:        time.Now().UTC(),
+	}
+	if rows, ok := c.Get(exportRowsKey); ok {
+		if n, ok := rows.(int); ok {
+			record.Rows = &n
+		}
+	}
+
+	authorization := c.GetHeader("Authorization")
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := g.exports.RecordExport(ctx, authorization, record); err != nil {
+			log.Printf("export guard: failed to record export of %s by user %s: %v", record.Resource, record.UserID, err)
+		}
+	}()
+}
+
+// SetExportRows notes how many rows an export handler wrote, for the audit
+// log
+func SetExportRows(c *gin.Context, rows int) {
+	c.Set(exportRowsKey, rows)
+}
+
+// SetExportFilters notes what an export handler was asked for, for
+// endpoints taking their filters in the request body
+func SetExportFilters(c *gin.Context, filters map[string]string) {
+	c.Set(exportFiltersKey, filters)
+}
+
+func exportFilters(c *gin.Context) map[string]string {
+	if filters, ok := c.Get(exportFiltersKey); ok {
+		if f, ok := filters.(map[string]string); ok {
+			return f
+		}
+	}
+
+	query := c.Request.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	filters := make(map[string]string, len(query))
+	for key := range query {
+		filters[key] = query.Get(key)
+	}
+	return filters
+}
+
+// FormatNotJSON matches requests asking with ?format for anything but
+// JSON, for endpoints that download a file in that format and otherwise
+// answer on screen
+func FormatNotJSON(c *gin.Context) bool {
+	format := c.Query("format")
+	return format != "" && format != "json"
+}
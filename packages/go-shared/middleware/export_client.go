@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ExportAuditClient checks export permission and records exports in the
+// tenant service, on behalf of the requesting user
+type ExportAuditClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewExportAuditClient creates a client for the tenant service at baseURL
+func NewExportAuditClient(baseURL string) *ExportAuditClient {
+	return &ExportAuditClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// CanExport reports whether the caller holds PermDataExport in the tenant.
+// Permissions aren't cached, so a role change applies to the next export.
+func (c *ExportAuditClient) CanExport(ctx context.Context, tenantID, authorization string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/tenants/"+tenantID+"/permissions/me", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	// Not a member, or no longer an active one
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, err
+	}
+	for _, permission := range body.Data {
+		if permission == PermDataExport {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RecordExport adds the export to the tenant's audit log
+func (c *ExportAuditClient) RecordExport(ctx context.Context, authorization string, record ExportRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/tenants/"+record.TenantID+"/export-activity", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+	return nil
+}
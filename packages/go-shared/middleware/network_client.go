@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNetworkPolicyTTL = time.Minute
+
+// NetworkPolicyClient reads tenants' network policies from the tenant
+// service on behalf of the requesting user, caching each for a short while.
+// A failed lookup keeps serving the last policy it got.
+type NetworkPolicyClient struct {
+	baseURL    string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu       sync.Mutex
+	policies map[string]cachedNetworkPolicy
+}
+
+type cachedNetworkPolicy struct {
+	policy   *NetworkPolicy
+	loadedAt time.Time
+}
+
+// NewNetworkPolicyClient creates a client for the tenant service at
+// baseURL. Policy changes take up to ttl to apply; zero means a minute.
+func NewNetworkPolicyClient(baseURL string, ttl time.Duration) *NetworkPolicyClient {
+	if ttl <= 0 {
+		ttl = defaultNetworkPolicyTTL
+	}
+	return &NetworkPolicyClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		ttl:        ttl,
+		policies:   make(map[string]cachedNetworkPolicy),
+	}
+}
+
+// Policy returns the tenant's policy, or nil when it has none
+func (c *NetworkPolicyClient) Policy(ctx context.Context, tenantID, authorization string) (*NetworkPolicy, error) {
+	c.mu.Lock()
+	cached, ok := c.policies[tenantID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.policy, nil
+	}
+
+	policy, err := c.fetch(ctx, tenantID, authorization)
+	if err != nil {
+		if ok {
+			return cached.policy, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.policies[tenantID] = cachedNetworkPolicy{policy: policy, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return policy, nil
+}
+
+func (c *NetworkPolicyClient) fetch(ctx context.Context, tenantID, authorization string) (*NetworkPolicy, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/tenants/"+tenantID+"/network-policy", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data *NetworkPolicy `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// RecordBlocked adds the attempt to the tenant's audit log
+func (c *NetworkPolicyClient) RecordBlocked(ctx context.Context, authorization string, attempt BlockedAttempt) error {
+	payload, err := json.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/tenants/"+attempt.TenantID+"/network-policy/blocked", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+	return nil
+}
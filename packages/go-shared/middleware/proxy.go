@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trustedEdgeKey marks requests that reached the service through a trusted
+// proxy or platform
+const trustedEdgeKey = "trusted_edge"
+
+// TrustProxies sets which peers the router takes the client's address from
+// forwarding headers of, such as X-Forwarded-For. With none, ClientIP is
+// the connecting peer's address and forwarding headers are ignored, so they
+// can't be forged to get past a tenant's network policy. platform, when
+// set, names the header a CDN such as Cloudflare reports the client's
+// address in (gin.PlatformCloudflare).
+//
+// It must be called before routes are added, as it also marks the requests
+// whose edge headers, such as the country, may be believed.
+func TrustProxies(router *gin.Engine, proxies []string, platform string) error {
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+	router.TrustedPlatform = platform
+
+	router.Use(func(c *gin.Context) {
+		c.Set(trustedEdgeKey, platform != "" || containsIP(proxies, net.ParseIP(c.RemoteIP())))
+		c.Next()
+	})
+	return nil
+}
+
+// EdgeCountry returns the client's country from header, as reported by the
+// edge, or "" when header is empty or the request did not come through a
+// trusted proxy
+func EdgeCountry(c *gin.Context, header string) string {
+	if header == "" || !c.GetBool(trustedEdgeKey) {
+		return ""
+	}
+	return strings.ToUpper(strings.TrimSpace(c.GetHeader(header)))
+}
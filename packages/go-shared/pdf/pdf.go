@@ -0,0 +1,325 @@
+// Package pdf writes simple text-and-line PDF documents with the standard
+// Helvetica fonts, without external dependencies. Ledger statements and
+// invoices are laid out with it, in colour and with images such as logos.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A4 portrait in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+// helveticaWidths are the widths of the printable ASCII characters in
+// Helvetica, in thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// Document is a PDF built page by page. Each page is a content stream; the
+// document around them is assembled when it is written.
+type Document struct {
+	pages     []*bytes.Buffer
+	page      *bytes.Buffer
+	watermark string
+	images    []string // Image XObjects, shared by every page
+}
+
+// New creates an empty document. Call NewPage before drawing.
+func New() *Document {
+	return &Document{}
+}
+
+// NewPage starts a page; drawing goes to it from then on
+func (d *Document) NewPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// Pages returns how many pages the document has
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// EachPage calls fn for every page with drawing going to that page, e.g. to
+// add "Page x of y" footers once all pages are known
+func (d *Document) EachPage(fn func(page, total int)) {
+	current := d.page
+	for i, page := range d.pages {
+		d.page = page
+		fn(i+1, len(d.pages))
+	}
+	d.page = current
+}
+
+// Text writes value with its baseline starting at x, y
+func (d *Document) Text(x, y float64, value string, bold bool, size float64) {
+	if value == "" {
+		return
+	}
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, encode(value))
+}
+
+// Line draws a thin line
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// Rect draws the outline of a rectangle whose lower left corner is x, y
+func (d *Document) Rect(x, y, width, height float64) {
+	fmt.Fprintf(d.page, "0.5 w %.2f %.2f %.2f %.2f re S\n", x, y, width, height)
+}
+
+// FillRect draws a solid rectangle, in the current colour, whose lower
+// left corner is x, y
+func (d *Document) FillRect(x, y, width, height float64) {
+	fmt.Fprintf(d.page, "%.2f %.2f %.2f %.2f re f\n", x, y, width, height)
+}
+
+// Color is an RGB colour
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black = Color{}
+	White = Color{0xff, 0xff, 0xff}
+)
+
+// ParseColor reads a #rrggbb colour
+func ParseColor(hex string) (Color, bool) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return Color{}, false
+	}
+	rgb, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return Color{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb)}, true
+}
+
+// Tint mixes the colour with white; 0 leaves it as is and 1 makes it white
+func (c Color) Tint(amount float64) Color {
+	mix := func(v uint8) uint8 {
+		return uint8(math.Round(float64(v) + (255-float64(v))*amount))
+	}
+	return Color{mix(c.R), mix(c.G), mix(c.B)}
+}
+
+// SetColor sets the colour text, lines and rectangles are drawn in from
+// then on, until the page ends. Pages start in black.
+func (d *Document) SetColor(c Color) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.3f %.3f %.3f RG\n", r, g, b, r, g, b)
+}
+
+// maxImageSide is the most pixels an image is embedded with along its
+// longer side; larger ones are scaled down, which print does not show
+const maxImageSide = 1024
+
+// Image draws img stretched over the rectangle whose lower left corner is
+// x, y. Transparent parts show white. Each call embeds the image again, so
+// draw a logo once rather than on every page.
+func (d *Document) Image(img image.Image, x, y, width, height float64) error {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil
+	}
+	if scale := float64(maxImageSide) / float64(max(w, h)); scale < 1 {
+		w = max(1, int(float64(w)*scale))
+		h = max(1, int(float64(h)*scale))
+	}
+
+	var pixels bytes.Buffer
+	z := zlib.NewWriter(&pixels)
+	row := make([]byte, 3*w)
+	for py := 0; py < h; py++ {
+		sy := bounds.Min.Y + py*bounds.Dy()/h
+		for px := 0; px < w; px++ {
+			sx := bounds.Min.X + px*bounds.Dx()/w
+			r, g, b, a := img.At(sx, sy).RGBA()
+			// Colours are alpha-premultiplied; white shows through
+			// whatever is transparent
+			white := 0xffff - a
+			row[3*px] = uint8((r + white) >> 8)
+			row[3*px+1] = uint8((g + white) >> 8)
+			row[3*px+2] = uint8((b + white) >> 8)
+		}
+		if _, err := z.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return err
+	}
+
+	d.images = append(d.images, fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+		w, h, pixels.Len(), pixels.Bytes()))
+	fmt.Fprintf(d.page, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", width, height, x, y, len(d.images))
+	return nil
+}
+
+// SetWatermark prints text faintly across every page, behind the content,
+// when the document is written
+func (d *Document) SetWatermark(text string) {
+	d.watermark = text
+}
+
+// watermarkStream draws the watermark diagonally across the page centre,
+// as large as fits
+func (d *Document) watermarkStream() string {
+	if d.watermark == "" {
+		return ""
+	}
+	const cos45 = 0.7071
+	size := math.Min(40, 0.8*math.Hypot(PageWidth, PageHeight)/TextWidth(d.watermark, 1))
+	half := TextWidth(d.watermark, size) / 2
+	x := PageWidth/2 - half*cos45
+	y := PageHeight/2 - half*cos45
+	return fmt.Sprintf("q 0.85 g BT /F1 %.1f Tf %.4f %.4f %.4f %.4f %.2f %.2f Tm (%s) Tj ET Q\n",
+		size, cos45, cos45, -cos45, cos45, x, y, encode(d.watermark))
+}
+
+// Write writes the document
+func (d *Document) Write(w io.Writer) error {
+	var doc bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, doc.Len())
+		fmt.Fprintf(&doc, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	doc.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then
+	// takes two objects, the page and its content stream, and the images
+	// follow the pages
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(d.images) > 0 {
+		images := make([]string, len(d.images))
+		for i := range d.images {
+			images[i] = fmt.Sprintf("/Im%d %d 0 R", i+1, 5+2*len(d.pages)+i)
+		}
+		resources += " /XObject << " + strings.Join(images, " ") + " >>"
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	watermark := d.watermarkStream()
+	for i, page := range d.pages {
+		content := watermark + page.String()
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << %s >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, resources, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+	for _, img := range d.images {
+		object(img)
+	}
+
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// encode encodes text for a PDF string in WinAnsi. Characters outside
+// Latin-1 cannot be shown by the standard fonts and print as '?'.
+func encode(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// TextWidth returns the width of value in points at font size
+func TextWidth(value string, size float64) float64 {
+	width := 0
+	for _, r := range value {
+		if r >= 32 && r < 127 {
+			width += helveticaWidths[r-32]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// FitWidth shortens value with an ellipsis until it fits width
+func FitWidth(value string, width, size float64) string {
+	if TextWidth(value, size) <= width {
+		return value
+	}
+	runes := []rune(value)
+	for len(runes) > 0 && TextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// Wrap breaks value into lines no wider than width, at spaces where it can
+// and at line breaks in value
+func Wrap(value string, width, size float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(value, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(candidate, size) <= width || line == "" {
+				line = candidate
+				continue
+			}
+			lines = append(lines, FitWidth(line, width, size))
+			line = word
+		}
+		if line != "" {
+			lines = append(lines, FitWidth(line, width, size))
+		}
+	}
+	return lines
+}
@@ -21,6 +21,10 @@ type Error struct {
 	Details map[string]string `json:"details,omitempty"`
 }
 
+// TranslatorKey is the context key of a func(string) string that
+// localizes error messages, set by the i18n middleware
+const TranslatorKey = "translator"
+
 // Meta represents pagination metadata
 type Meta struct {
 	Page       int   `json:"page"`
@@ -45,6 +49,15 @@ func Created(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted sends a 202 accepted response for work that continues in the
+// background
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Data:    data,
+	})
+}
+
 // NoContent sends a 204 no content response
 func NoContent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
@@ -75,7 +88,7 @@ func BadRequest(c *gin.Context, message string, details map[string]string) {
 		Success: false,
 		Error: &Error{
 			Code:    "BAD_REQUEST",
-			Message: message,
+			Message: translate(c, message),
 			Details: details,
 		},
 	})
@@ -87,7 +100,7 @@ func Unauthorized(c *gin.Context, message string) {
 		Success: false,
 		Error: &Error{
 			Code:    "UNAUTHORIZED",
-			Message: message,
+			Message: translate(c, message),
 		},
 	})
 }
@@ -98,7 +111,7 @@ func Forbidden(c *gin.Context, message string) {
 		Success: false,
 		Error: &Error{
 			Code:    "FORBIDDEN",
-			Message: message,
+			Message: translate(c, message),
 		},
 	})
 }
@@ -109,7 +122,7 @@ func NotFound(c *gin.Context, message string) {
 		Success: false,
 		Error: &Error{
 			Code:    "NOT_FOUND",
-			Message: message,
+			Message: translate(c, message),
 		},
 	})
 }
@@ -120,7 +133,7 @@ func Conflict(c *gin.Context, message string) {
 		Success: false,
 		Error: &Error{
 			Code:    "CONFLICT",
-			Message: message,
+			Message: translate(c, message),
 		},
 	})
 }
@@ -131,7 +144,7 @@ func ValidationError(c *gin.Context, message string, details map[string]string)
 		Success: false,
 		Error: &Error{
 			Code:    "VALIDATION_ERROR",
-			Message: message,
+			Message: translate(c, message),
 			Details: details,
 		},
 	})
@@ -143,7 +156,7 @@ func InternalError(c *gin.Context, message string) {
 		Success: false,
 		Error: &Error{
 			Code:    "INTERNAL_ERROR",
-			Message: message,
+			Message: translate(c, message),
 		},
 	})
 }
@@ -154,7 +167,18 @@ func ServiceUnavailable(c *gin.Context, message string) {
 		Success: false,
 		Error: &Error{
 			Code:    "SERVICE_UNAVAILABLE",
-			Message: message,
+			Message: translate(c, message),
 		},
 	})
 }
+
+// translate localizes message for the request, leaving it unchanged when no
+// translator is set
+func translate(c *gin.Context, message string) string {
+	if value, exists := c.Get(TranslatorKey); exists {
+		if fn, ok := value.(func(string) string); ok {
+			return fn(message)
+		}
+	}
+	return message
+}
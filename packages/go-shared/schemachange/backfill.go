@@ -0,0 +1,258 @@
+package schemachange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"gorm.io/gorm"
+)
+
+// BackfillJobType is the background job type that backfills a change
+const BackfillJobType = "schema_change.backfill"
+
+// BackfillOptions tunes a backfill. Zero values fall back to the defaults.
+type BackfillOptions struct {
+	BatchSize     int // Rows read per batch
+	RowsPerSecond int // Most rows read per second, to spare the database
+}
+
+const (
+	defaultBatchSize     = 1000
+	defaultRowsPerSecond = 5000
+)
+
+// Backfill copies the old column to the new one on every row that
+// disagrees, walking the table by key in batches. Each batch is committed
+// with the backfill's progress, so an interrupted backfill resumes after
+// the last batch. It stops early when ctx is done.
+func Backfill(ctx context.Context, db *gorm.DB, r Rename, opts BackfillOptions) (*SchemaChange, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.RowsPerSecond <= 0 {
+		opts.RowsPerSecond = defaultRowsPerSecond
+	}
+
+	state, err := State(ctx, db, r)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrNotExpanded
+	}
+	if state.Phase != PhaseExpanded {
+		return state, nil
+	}
+
+	keyType, err := columnType(ctx, db, r.Table, r.key())
+	if err != nil {
+		return nil, err
+	}
+
+	table, from, to, key := quoteIdent(r.Table), quoteIdent(r.From), quoteIdent(r.To), quoteIdent(r.key())
+	batchSQL := fmt.Sprintf(`
+		WITH batch AS (
+			SELECT %[4]s FROM %[1]s WHERE @after = '' OR %[4]s > @after::text::%[5]s ORDER BY %[4]s LIMIT @limit
+		), updated AS (
+			UPDATE %[1]s t SET %[3]s = t.%[2]s FROM batch
+			WHERE t.%[4]s = batch.%[4]s AND t.%[3]s IS DISTINCT FROM t.%[2]s
+			RETURNING 1
+		)
+		SELECT
+			(SELECT %[4]s::text FROM batch ORDER BY %[4]s DESC LIMIT 1) AS last_key,
+			(SELECT COUNT(*) FROM batch) AS scanned,
+			(SELECT COUNT(*) FROM updated) AS updated`,
+		table, from, to, key, keyType)
+
+	// Each batch may take no less than its share of a second
+	minBatchTime := time.Duration(opts.BatchSize) * time.Second / time.Duration(opts.RowsPerSecond)
+	for {
+		started := time.Now()
+
+		var batch struct {
+			LastKey *string
+			Scanned int64
+			Updated int64
+		}
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			err := tx.Raw(batchSQL, map[string]interface{}{
+				"after": state.LastKey,
+				"limit": opts.BatchSize,
+			}).Scan(&batch).Error
+			if err != nil {
+				return err
+			}
+
+			updates := map[string]interface{}{"backfilled": gorm.Expr("backfilled + ?", batch.Updated)}
+			if batch.LastKey != nil {
+				updates["last_key"] = *batch.LastKey
+			}
+			if batch.Scanned < int64(opts.BatchSize) {
+				updates["phase"] = PhaseBackfilled
+			}
+			return tx.Model(state).Updates(updates).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if state, err = State(ctx, db, r); err != nil {
+			return nil, err
+		}
+		if state.Phase != PhaseExpanded {
+			return state, nil
+		}
+
+		if wait := minBatchTime - time.Since(started); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return state, ctx.Err()
+			case <-time.After(wait):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return state, err
+		}
+	}
+}
+
+// Verification is how well the old and new columns of a change agree
+type Verification struct {
+	Change     string    `json:"change"`
+	Rows       int64     `json:"rows"`
+	Mismatched int64     `json:"mismatched"`  // Rows whose columns disagree
+	SampleKeys []string  `json:"sample_keys"` // Keys of up to ten of them
+	Trigger    bool      `json:"trigger"`     // The trigger keeping them in step is in place
+	Valid      bool      `json:"valid"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// Verify counts the rows whose old and new columns disagree and checks the
+// trigger is in place. A backfilled change that passes is marked verified;
+// one that fails goes back to be backfilled again.
+func Verify(ctx context.Context, db *gorm.DB, r Rename) (*Verification, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	state, err := State(ctx, db, r)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrNotExpanded
+	}
+
+	result := &Verification{Change: r.Name, VerifiedAt: time.Now()}
+	table, from, to, key := quoteIdent(r.Table), quoteIdent(r.From), quoteIdent(r.To), quoteIdent(r.key())
+
+	if err := db.WithContext(ctx).Raw("SELECT COUNT(*) FROM " + table).Scan(&result.Rows).Error; err != nil {
+		return nil, err
+	}
+	if result.Mismatched, err = countMismatched(db.WithContext(ctx), r); err != nil {
+		return nil, err
+	}
+	result.SampleKeys = []string{}
+	if result.Mismatched > 0 {
+		err := db.WithContext(ctx).
+			Raw(fmt.Sprintf("SELECT %[4]s::text FROM %[1]s WHERE %[3]s IS DISTINCT FROM %[2]s ORDER BY %[4]s LIMIT 10", table, from, to, key)).
+			Scan(&result.SampleKeys).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = db.WithContext(ctx).Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM pg_trigger
+			WHERE tgname = ? AND tgrelid = to_regclass(?) AND NOT tgisinternal
+		)`, r.function(), table).Scan(&result.Trigger).Error
+	if err != nil {
+		return nil, err
+	}
+	result.Valid = result.Mismatched == 0 && result.Trigger
+
+	updates := map[string]interface{}{
+		"mismatched":  result.Mismatched,
+		"verified_at": result.VerifiedAt,
+	}
+	switch {
+	case state.Phase == PhaseBackfilled && result.Valid:
+		updates["phase"] = PhaseVerified
+	case (state.Phase == PhaseBackfilled || state.Phase == PhaseVerified) && !result.Valid:
+		updates["phase"] = PhaseExpanded
+		updates["last_key"] = ""
+	}
+	if state.Phase != PhaseContracted {
+		if err := db.WithContext(ctx).Model(state).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// BackfillPayload names the change a backfill job works on
+type BackfillPayload struct {
+	Change string `json:"change"`
+}
+
+// BackfillHandler returns a job handler that backfills and then verifies
+// the change named in the job's payload. An attempt cut off by the job's
+// timeout is resumed by the next one. Register it under BackfillJobType.
+func BackfillHandler(db *gorm.DB, opts BackfillOptions, changes ...Rename) jobs.Handler {
+	byName := make(map[string]Rename, len(changes))
+	for _, change := range changes {
+		byName[change.Name] = change
+	}
+
+	return func(ctx context.Context, job *jobs.Job) error {
+		var payload BackfillPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		change, ok := byName[payload.Change]
+		if !ok {
+			return fmt.Errorf("unknown schema change %q", payload.Change)
+		}
+
+		if _, err := Backfill(ctx, db, change, opts); err != nil {
+			return err
+		}
+		verification, err := Verify(ctx, db, change)
+		if err != nil {
+			return err
+		}
+		if !verification.Valid {
+			return errors.New("backfill left rows whose columns disagree; the next attempt backfills again")
+		}
+		return nil
+	}
+}
+
+func countMismatched(db *gorm.DB, r Rename) (int64, error) {
+	var count int64
+	err := db.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS DISTINCT FROM %s",
+		quoteIdent(r.Table), quoteIdent(r.To), quoteIdent(r.From))).Scan(&count).Error
+	return count, err
+}
+
+// columnType returns a column's SQL type, for casting the backfill's
+// resume key back from text
+func columnType(ctx context.Context, db *gorm.DB, table, column string) (string, error) {
+	var types []string
+	err := db.WithContext(ctx).Raw(`
+		SELECT format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass(?) AND a.attname = ? AND NOT a.attisdropped`,
+		quoteIdent(table), column).Scan(&types).Error
+	if err != nil {
+		return "", err
+	}
+	if len(types) == 0 {
+		return "", fmt.Errorf("%s has no column %s", table, column)
+	}
+	return types[0], nil
+}
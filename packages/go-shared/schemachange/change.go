@@ -0,0 +1,249 @@
+// Package schemachange renames columns without downtime using
+// expand/contract migrations, so old (blue) and new (green) versions of a
+// service can run side by side against the same database:
+//
+//  1. Expand adds the new column and a trigger that keeps it and the old
+//     column in step, whichever of them a version writes.
+//  2. Backfill copies existing rows to the new column in rate-limited
+//     batches. It resumes where it stopped and can run as a background job.
+//  3. Verify checks every row agrees, once the backfill is done.
+//  4. Contract drops the trigger and the old column once no running
+//     version reads or writes it any more.
+//
+// For example, renaming transactions.description to narration:
+//
+//	rename := schemachange.Rename{
+//		Name:  "transactions_description_narration",
+//		Table: "transactions",
+//		From:  "description",
+//		To:    "narration",
+//		Type:  "text",
+//	}
+//
+// Services record the progress of their changes in SchemaChange, migrated
+// along with their own models.
+package schemachange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Phases of a change, in order
+const (
+	PhaseExpanded   = "expanded"
+	PhaseBackfilled = "backfilled"
+	PhaseVerified   = "verified"
+	PhaseContracted = "contracted"
+)
+
+var (
+	ErrInvalidChange = errors.New("schema change needs a name of lower case letters, digits and underscores, a table and two different columns")
+	ErrNotExpanded   = errors.New("schema change has not been expanded")
+	ErrNotVerified   = errors.New("schema change must be verified before it is contracted")
+	ErrMismatched    = errors.New("old and new columns do not agree on every row")
+)
+
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// Rename moves a column to a new name, and optionally a new type
+type Rename struct {
+	Name  string // Unique name of the change; names its trigger
+	Table string
+	From  string // The column being retired
+	To    string // The column replacing it
+	Type  string // SQL type of the new column, e.g. "varchar(500)"
+	Key   string // Unique, ordered column the backfill walks, "id" by default
+}
+
+// Validate checks the change is complete
+func (r Rename) Validate() error {
+	if !nameRegex.MatchString(r.Name) || r.Table == "" || r.From == "" || r.To == "" || r.Type == "" || r.From == r.To {
+		return ErrInvalidChange
+	}
+	return nil
+}
+
+func (r Rename) key() string {
+	if r.Key == "" {
+		return "id"
+	}
+	return r.Key
+}
+
+func (r Rename) function() string {
+	return "schema_change_" + r.Name
+}
+
+// SchemaChange records how far a change has got
+type SchemaChange struct {
+	Name       string     `gorm:"size:50;primaryKey" json:"name"`
+	Table      string     `gorm:"size:100;not null" json:"table"`
+	Phase      string     `gorm:"size:20;not null" json:"phase"`
+	LastKey    string     `gorm:"size:100" json:"last_key,omitempty"` // Where the backfill resumes
+	Backfilled int64      `gorm:"default:0" json:"backfilled"`        // Rows the backfill updated
+	Mismatched int64      `gorm:"default:0" json:"mismatched"`        // Rows that disagreed when last verified
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for SchemaChange
+func (SchemaChange) TableName() string {
+	return "schema_changes"
+}
+
+// State returns how far the change has got, or nil before it is expanded
+func State(ctx context.Context, db *gorm.DB, r Rename) (*SchemaChange, error) {
+	var state SchemaChange
+	err := db.WithContext(ctx).First(&state, "name = ?", r.Name).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Expand adds the new column and the trigger keeping both columns in step.
+// Running it again is harmless. From then on a write to either column is
+// copied to the other, so versions reading either name see the same data.
+func Expand(ctx context.Context, db *gorm.DB, r Rename) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	table, from, to := quoteIdent(r.Table), quoteIdent(r.From), quoteIdent(r.To)
+	function := quoteIdent(r.function())
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, to, r.Type),
+
+			// On insert whichever column was given fills the other; on
+			// update the column that changed wins
+			fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		IF NEW.%[3]s IS NULL THEN
+			NEW.%[3]s := NEW.%[2]s;
+		ELSIF NEW.%[2]s IS NULL THEN
+			NEW.%[2]s := NEW.%[3]s;
+		END IF;
+	ELSIF NEW.%[2]s IS DISTINCT FROM OLD.%[2]s THEN
+		NEW.%[3]s := NEW.%[2]s;
+	ELSIF NEW.%[3]s IS DISTINCT FROM OLD.%[3]s THEN
+		NEW.%[2]s := NEW.%[3]s;
+	END IF;
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`, function, from, to),
+
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", function, table),
+			fmt.Sprintf("CREATE TRIGGER %s BEFORE INSERT OR UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s()", function, table, function),
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&SchemaChange{
+			Name:  r.Name,
+			Table: r.Table,
+			Phase: PhaseExpanded,
+		}).Error
+	})
+}
+
+// Contract drops the trigger and the old column. Only run it once every
+// running version uses the new column. It checks again that the columns
+// agree, under a lock that holds off writes meanwhile.
+func Contract(ctx context.Context, db *gorm.DB, r Rename) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	var mismatched int64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var state SchemaChange
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&state, "name = ?", r.Name).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotExpanded
+			}
+			return err
+		}
+		if state.Phase == PhaseContracted {
+			return nil
+		}
+		if state.Phase != PhaseVerified {
+			return ErrNotVerified
+		}
+
+		table := quoteIdent(r.Table)
+		if err := tx.Exec("LOCK TABLE " + table + " IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		mismatched, err = countMismatched(tx, r)
+		if err != nil {
+			return err
+		}
+		if mismatched > 0 {
+			// Kept, so the change must be backfilled and verified again
+			return tx.Model(&state).Updates(map[string]interface{}{
+				"phase":      PhaseBackfilled,
+				"mismatched": mismatched,
+			}).Error
+		}
+
+		function := quoteIdent(r.function())
+		statements := []string{
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", function, table),
+			fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", function),
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", table, quoteIdent(r.From)),
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&state).Update("phase", PhaseContracted).Error
+	})
+	if err != nil {
+		return err
+	}
+	if mismatched > 0 {
+		return ErrMismatched
+	}
+	return nil
+}
+
+// DualWrite returns updates with the renamed column set under both names,
+// for code that writes the table while the trigger may not yet be in place
+func (r Rename) DualWrite(updates map[string]interface{}) map[string]interface{} {
+	if value, ok := updates[r.To]; ok {
+		updates[r.From] = value
+	} else if value, ok := updates[r.From]; ok {
+		updates[r.To] = value
+	}
+	return updates
+}
+
+// Select returns a select expression reading the column under its new
+// name, falling back to the old column for rows not yet backfilled
+func (r Rename) Select() string {
+	return fmt.Sprintf("COALESCE(%s, %s) AS %s", quoteIdent(r.To), quoteIdent(r.From), quoteIdent(r.To))
+}
+
+// quoteIdent quotes an identifier for use in SQL
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,165 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/pdf"
+)
+
+const (
+	pageWidth  = pdf.PageWidth
+	pageHeight = pdf.PageHeight
+	margin     = 36.0
+	rowHeight  = 13.0
+	fontSize   = 8.0
+)
+
+// pdfColumn is a column of the ledger table. Amount columns are right
+// aligned.
+type pdfColumn struct {
+	heading string
+	width   float64
+	right   bool
+}
+
+var pdfColumns = []pdfColumn{
+	{heading: "Date", width: 55},
+	{heading: "Particulars", width: 150},
+	{heading: "Vch Type", width: 55},
+	{heading: "Vch No.", width: 63},
+	{heading: "Debit", width: 62, right: true},
+	{heading: "Credit", width: 62, right: true},
+	{heading: "Balance", width: 76, right: true},
+}
+
+// pdfWriter lays the statement out page by page
+type pdfWriter struct {
+	s   *Statement
+	doc *pdf.Document
+	y   float64
+}
+
+func writePDF(w io.Writer, s *Statement) error {
+	p := &pdfWriter{s: s, doc: pdf.New()}
+	p.doc.SetWatermark(s.Watermark)
+	p.newPage(true)
+
+	opening := s.opening()
+	p.row(opening.values(s), true)
+	for _, row := range s.Rows {
+		p.row(row.values(s), false)
+	}
+
+	total, closing := s.closing()
+	p.ensureSpace(3 * rowHeight)
+	p.rule(4, 5)
+	p.row(total.values(s)[:6], true) // No running balance on the total
+	p.row(closing.values(s), true)
+	p.rule(4, 5)
+
+	return p.finish(w)
+}
+
+func (p *pdfWriter) newPage(first bool) {
+	p.doc.NewPage()
+	p.y = pageHeight - margin
+
+	if first {
+		if p.s.Company != "" {
+			p.centered(p.s.Company, true, 12)
+			p.y -= 16
+		}
+		p.centered(p.s.Name, true, 11)
+		p.y -= 14
+		p.centered("Ledger Account", false, 9)
+		p.y -= 12
+		for _, line := range p.s.Details {
+			p.centered(line, false, fontSize)
+			p.y -= 11
+		}
+		if period := p.s.period(); period != "" {
+			p.centered(period, false, fontSize)
+			p.y -= 11
+		}
+		p.y -= 6
+	} else {
+		p.text(margin, p.y, p.s.Name+" (continued)", true, fontSize)
+		p.y -= 16
+	}
+
+	// Column headings between two rules
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	x := margin
+	for _, col := range pdfColumns {
+		p.cell(x, col, col.heading, true)
+		x += col.width
+	}
+	p.line(margin, p.y-4, pageWidth-margin, p.y-4)
+	p.y -= rowHeight + 2
+}
+
+// ensureSpace starts a new page unless height fits above the footer
+func (p *pdfWriter) ensureSpace(height float64) {
+	if p.y-height < margin+20 {
+		p.newPage(false)
+	}
+}
+
+func (p *pdfWriter) row(values []string, bold bool) {
+	p.ensureSpace(rowHeight)
+
+	x := margin
+	for i, value := range values {
+		p.cell(x, pdfColumns[i], value, bold)
+		x += pdfColumns[i].width
+	}
+	p.y -= rowHeight
+}
+
+// rule draws a line under columns from..to, inclusive
+func (p *pdfWriter) rule(from, to int) {
+	x := margin
+	for i := 0; i < from; i++ {
+		x += pdfColumns[i].width
+	}
+	end := x
+	for i := from; i <= to; i++ {
+		end += pdfColumns[i].width
+	}
+	p.line(x, p.y+rowHeight-3, end, p.y+rowHeight-3)
+}
+
+// cell writes a value inside a column, cut to fit its width
+func (p *pdfWriter) cell(x float64, col pdfColumn, value string, bold bool) {
+	const padding = 3
+	value = pdf.FitWidth(value, col.width-2*padding, fontSize)
+	if col.right {
+		x += col.width - padding - pdf.TextWidth(value, fontSize)
+	} else {
+		x += padding
+	}
+	p.text(x, p.y, value, bold, fontSize)
+}
+
+func (p *pdfWriter) centered(value string, bold bool, size float64) {
+	value = pdf.FitWidth(value, pageWidth-2*margin, size)
+	p.text((pageWidth-pdf.TextWidth(value, size))/2, p.y, value, bold, size)
+}
+
+func (p *pdfWriter) text(x, y float64, value string, bold bool, size float64) {
+	p.doc.Text(x, y, value, bold, size)
+}
+
+func (p *pdfWriter) line(x1, y1, x2, y2 float64) {
+	p.doc.Line(x1, y1, x2, y2)
+}
+
+// finish numbers the pages and writes the document
+func (p *pdfWriter) finish(w io.Writer) error {
+	p.doc.EachPage(func(page, total int) {
+		footer := fmt.Sprintf("Page %d of %d", page, total)
+		p.text(pageWidth-margin-pdf.TextWidth(footer, 7), margin-12, footer, false, 7)
+	})
+	return p.doc.Write(w)
+}
@@ -0,0 +1,211 @@
+// Package statement writes a ledger in the columnar layout accountants know
+// from Tally: date, particulars, voucher type, voucher number, debit, credit
+// and a running balance marked Dr or Cr, with the opening balance first and
+// the period total and closing balance last. Statements are rendered as PDF
+// or XLSX without external dependencies.
+package statement
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+)
+
+// Export formats. JSON is answered by the endpoint itself.
+const (
+	FormatJSON = "json"
+	FormatPDF  = "pdf"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("format must be json, pdf or xlsx")
+
+// Statement is a ledger ready to be written. Amounts are signed debit
+// positive: a negative balance is a credit balance.
+type Statement struct {
+	Company string   // Printed above the ledger name, optional
+	Name    string   // Party or account the ledger is of
+	Details []string // Further heading lines such as the GSTIN or address
+	From    time.Time
+	To      time.Time // Either may be zero for an open-ended period
+
+	OpeningBalance float64
+	Rows           []Row
+
+	Currency i18n.CurrencyFormat // Rupees when unset
+
+	Watermark string // Printed across each page of the PDF, e.g. for auditors
+}
+
+// Row is a voucher in the ledger
+type Row struct {
+	Date          time.Time
+	Particulars   string // The account on the other side of the voucher
+	VoucherType   string
+	VoucherNumber string
+	Debit         float64
+	Credit        float64
+	Balance       float64 // Running balance after this voucher
+}
+
+// Totals returns the debits and credits of the period, without the opening
+// balance
+func (s *Statement) Totals() (debit, credit float64) {
+	for _, row := range s.Rows {
+		debit += row.Debit
+		credit += row.Credit
+	}
+	return debit, credit
+}
+
+// ClosingBalance returns the balance at the end of the period
+func (s *Statement) ClosingBalance() float64 {
+	debit, credit := s.Totals()
+	return s.round(s.OpeningBalance + debit - credit)
+}
+
+// Write renders the statement as PDF or XLSX
+func Write(w io.Writer, format string, s *Statement) error {
+	switch format {
+	case FormatPDF:
+		return writePDF(w, s)
+	case FormatXLSX:
+		return writeXLSX(w, s)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// ValidFormat reports whether a ledger can be exported in format
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatPDF || format == FormatXLSX
+}
+
+// ContentType returns the MIME type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatPDF:
+		return "application/pdf"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/json"
+	}
+}
+
+// VoucherType returns the Tally voucher type of a transaction type
+func VoucherType(transactionType string) string {
+	switch transactionType {
+	case "sale":
+		return "Sales"
+	case "purchase":
+		return "Purchase"
+	case "receipt":
+		return "Receipt"
+	case "payment", "expense":
+		return "Payment"
+	case "transfer":
+		return "Contra"
+	case "credit_note":
+		return "Credit Note"
+	case "debit_note":
+		return "Debit Note"
+	default:
+		return "Journal"
+	}
+}
+
+// opening returns the opening balance row, shown on the side it falls
+func (s *Statement) opening() Row {
+	row := Row{Particulars: "Opening Balance", Balance: s.OpeningBalance}
+	row.Debit, row.Credit = debitCredit(s.OpeningBalance)
+	return row
+}
+
+// closing returns the period total and closing balance rows
+func (s *Statement) closing() (total, closing Row) {
+	balance := s.ClosingBalance()
+	total = Row{Particulars: "Current Total", Balance: balance}
+	total.Debit, total.Credit = s.Totals()
+	closing = Row{Particulars: "Closing Balance", Balance: balance}
+	closing.Debit, closing.Credit = debitCredit(balance)
+	return total, closing
+}
+
+// values returns the row's columns as printed
+func (r Row) values(s *Statement) []string {
+	date := ""
+	if !r.Date.IsZero() {
+		date = r.Date.Format("2-Jan-2006")
+	}
+	return []string{
+		date,
+		r.Particulars,
+		r.VoucherType,
+		r.VoucherNumber,
+		s.amount(r.Debit),
+		s.amount(r.Credit),
+		s.balance(r.Balance),
+	}
+}
+
+// debitCredit splits a balance into the debit or credit column
+func debitCredit(balance float64) (debit, credit float64) {
+	if balance >= 0 {
+		return balance, 0
+	}
+	return 0, -balance
+}
+
+func (s *Statement) currency() i18n.CurrencyFormat {
+	if s.Currency.Code == "" {
+		return i18n.Currency("INR")
+	}
+	return s.Currency
+}
+
+// period returns the heading line of the statement period
+func (s *Statement) period() string {
+	const layout = "2-Jan-2006"
+	switch {
+	case !s.From.IsZero() && !s.To.IsZero():
+		return s.From.Format(layout) + " to " + s.To.Format(layout)
+	case !s.From.IsZero():
+		return "From " + s.From.Format(layout)
+	case !s.To.IsZero():
+		return "Up to " + s.To.Format(layout)
+	default:
+		return ""
+	}
+}
+
+// amount writes a debit or credit column amount, blank when zero
+func (s *Statement) amount(amount float64) string {
+	if s.round(amount) == 0 {
+		return ""
+	}
+	return s.currency().FormatNumber(amount)
+}
+
+// balance writes a balance with its side, e.g. 1,250.00 Dr
+func (s *Statement) balance(balance float64) string {
+	balance = s.round(balance)
+	switch {
+	case balance > 0:
+		return s.currency().FormatNumber(balance) + " Dr"
+	case balance < 0:
+		return s.currency().FormatNumber(-balance) + " Cr"
+	default:
+		return s.currency().FormatNumber(0)
+	}
+}
+
+// round rounds an amount to the currency's decimals, so float residue does
+// not print as a balance
+func (s *Statement) round(amount float64) float64 {
+	unit := math.Pow10(s.currency().Decimals)
+	return math.Round(amount*unit) / unit
+}
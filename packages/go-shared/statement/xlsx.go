@@ -0,0 +1,182 @@
+package statement
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Cell styles defined in xlsxStyles, by position in cellXfs
+const (
+	styleText = iota
+	styleBold
+	styleAmount
+	styleBoldAmount
+	styleDate
+	styleBalance
+	styleBoldBalance
+)
+
+// xlsxColumnWidths are the widths of the ledger columns in characters
+var xlsxColumnWidths = []int{12, 40, 12, 14, 15, 15, 18}
+
+var xlsxParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Ledger" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`,
+	// Balances use a number format that prints the side, so they stay
+	// numeric: positive is Dr, negative Cr
+	"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="2"><numFmt numFmtId="164" formatCode="d-mmm-yyyy"/><numFmt numFmtId="165" formatCode="#,##0.00 &quot;Dr&quot;;#,##0.00 &quot;Cr&quot;;0.00"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="7"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="4" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1" applyNumberFormat="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1" applyNumberFormat="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`,
+}
+
+// xlsxPartOrder keeps the archive layout stable
+var xlsxPartOrder = []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"}
+
+// sheetWriter writes the rows of the worksheet
+type sheetWriter struct {
+	s   *Statement
+	buf bytes.Buffer
+	row int
+}
+
+func writeXLSX(w io.Writer, s *Statement) error {
+	sheet := &sheetWriter{s: s}
+	sheet.buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><cols>`)
+	for i, width := range xlsxColumnWidths {
+		fmt.Fprintf(&sheet.buf, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	sheet.buf.WriteString(`</cols><sheetData>`)
+
+	if s.Company != "" {
+		sheet.heading(s.Company)
+	}
+	sheet.heading(s.Name)
+	sheet.text("Ledger Account")
+	for _, line := range s.Details {
+		sheet.text(line)
+	}
+	if period := s.period(); period != "" {
+		sheet.text(period)
+	}
+	sheet.row++
+
+	sheet.start()
+	for i, col := range pdfColumns {
+		sheet.string(i, col.heading, styleBold)
+	}
+	sheet.end()
+
+	sheet.ledgerRow(s.opening(), true, true)
+	for _, row := range s.Rows {
+		sheet.ledgerRow(row, false, true)
+	}
+	total, closing := s.closing()
+	sheet.ledgerRow(total, true, false)
+	sheet.ledgerRow(closing, true, true)
+
+	sheet.buf.WriteString(`</sheetData></worksheet>`)
+
+	zw := zip.NewWriter(w)
+	for _, name := range xlsxPartOrder {
+		if err := writeZipPart(zw, name, []byte(xlsxParts[name])); err != nil {
+			return err
+		}
+	}
+	if err := writeZipPart(zw, "xl/worksheets/sheet1.xml", sheet.buf.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeZipPart(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (sw *sheetWriter) heading(value string) {
+	sw.start()
+	sw.string(0, value, styleBold)
+	sw.end()
+}
+
+func (sw *sheetWriter) text(value string) {
+	sw.start()
+	sw.string(0, value, styleText)
+	sw.end()
+}
+
+// ledgerRow writes a voucher or summary row. Zero amounts are left blank.
+func (sw *sheetWriter) ledgerRow(row Row, bold, withBalance bool) {
+	text, amount, balance := styleText, styleAmount, styleBalance
+	if bold {
+		text, amount, balance = styleBold, styleBoldAmount, styleBoldBalance
+	}
+
+	sw.start()
+	if !row.Date.IsZero() {
+		sw.number(0, excelDate(row.Date), styleDate)
+	}
+	sw.string(1, row.Particulars, text)
+	sw.string(2, row.VoucherType, text)
+	sw.string(3, row.VoucherNumber, text)
+	if debit := sw.s.round(row.Debit); debit != 0 {
+		sw.number(4, debit, amount)
+	}
+	if credit := sw.s.round(row.Credit); credit != 0 {
+		sw.number(5, credit, amount)
+	}
+	if withBalance {
+		sw.number(6, sw.s.round(row.Balance), balance)
+	}
+	sw.end()
+}
+
+func (sw *sheetWriter) start() {
+	sw.row++
+	fmt.Fprintf(&sw.buf, `<row r="%d">`, sw.row)
+}
+
+func (sw *sheetWriter) end() {
+	sw.buf.WriteString(`</row>`)
+}
+
+func (sw *sheetWriter) string(col int, value string, style int) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(&sw.buf, `<c r="%s" t="inlineStr" s="%d"><is><t>`, sw.ref(col), style)
+	xml.EscapeText(&sw.buf, []byte(value))
+	sw.buf.WriteString(`</t></is></c>`)
+}
+
+func (sw *sheetWriter) number(col int, value float64, style int) {
+	fmt.Fprintf(&sw.buf, `<c r="%s" s="%d"><v>%s</v></c>`, sw.ref(col), style, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// ref returns the cell reference of a column in the current row; the
+// ledger has fewer than 26 columns
+func (sw *sheetWriter) ref(col int) string {
+	return string(rune('A'+col)) + strconv.Itoa(sw.row)
+}
+
+// excelDate returns the spreadsheet serial number of a date
+func excelDate(t time.Time) float64 {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.Sub(epoch).Hours() / 24
+}
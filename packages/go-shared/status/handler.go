@@ -0,0 +1,178 @@
+package status
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves the public status page and the admin API operators use to
+// post incidents, which is limited to super admins
+type Handler struct {
+	monitor *Monitor
+	store   *Store
+}
+
+// NewHandler creates a new status handler
+func NewHandler(monitor *Monitor, store *Store) *Handler {
+	return &Handler{monitor: monitor, store: store}
+}
+
+// IncidentRequest creates or updates an incident
+type IncidentRequest struct {
+	Title    string   `json:"title" binding:"required,max=200"`
+	Status   string   `json:"status" binding:"required,oneof=investigating identified monitoring resolved"`
+	Impact   string   `json:"impact" binding:"required,oneof=minor major critical"`
+	Services []string `json:"services"`
+	Message  string   `json:"message"`
+}
+
+// Status returns the current state of every service and any open incidents
+func (h *Handler) Status(c *gin.Context) {
+	summary, err := h.monitor.Summary(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to get status")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=30")
+	response.Success(c, summary)
+}
+
+// ListIncidents returns the incidents of the last days (default 30, max
+// 90) and any still open
+func (h *Handler) ListIncidents(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 90 {
+		response.BadRequest(c, "days must be between 1 and 90", nil)
+		return
+	}
+
+	incidents, err := h.store.RecentIncidents(c.Request.Context(), days)
+	if err != nil {
+		response.InternalError(c, "Failed to list incidents")
+		return
+	}
+
+	response.Success(c, incidents)
+}
+
+// CreateIncident posts a new incident
+func (h *Handler) CreateIncident(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	var req IncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+	if !h.knownServices(c, req.Services) {
+		return
+	}
+
+	incident := &Incident{UpdatedBy: userIDFromContext(c)}
+	req.apply(incident)
+	if err := h.store.SaveIncident(c.Request.Context(), incident); err != nil {
+		h.handleError(c, err, "Failed to create incident")
+		return
+	}
+
+	response.Created(c, incident)
+}
+
+// UpdateIncident posts an update to an incident, e.g. to resolve it
+func (h *Handler) UpdateIncident(c *gin.Context) {
+	if !requireSuperAdmin(c) {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid incident ID", nil)
+		return
+	}
+
+	var req IncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+	if !h.knownServices(c, req.Services) {
+		return
+	}
+
+	incident, err := h.store.GetIncident(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get incident")
+		return
+	}
+	req.apply(incident)
+	incident.UpdatedBy = userIDFromContext(c)
+	if err := h.store.SaveIncident(c.Request.Context(), incident); err != nil {
+		h.handleError(c, err, "Failed to update incident")
+		return
+	}
+
+	response.Success(c, incident)
+}
+
+func (req *IncidentRequest) apply(incident *Incident) {
+	incident.Title = req.Title
+	incident.Status = req.Status
+	incident.Impact = req.Impact
+	incident.Services = req.Services
+	incident.Message = req.Message
+}
+
+// knownServices rejects incidents naming services that are not monitored
+func (h *Handler) knownServices(c *gin.Context, services []string) bool {
+	known := make(map[string]bool)
+	for _, name := range h.monitor.Services() {
+		known[name] = true
+	}
+	for _, name := range services {
+		if !known[name] {
+			response.BadRequest(c, "Unknown service: "+name, nil)
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrIncidentNotFound):
+		response.NotFound(c, "Incident not found")
+	case errors.Is(err, ErrIncidentTitleRequired), errors.Is(err, ErrInvalidIncident), errors.Is(err, ErrInvalidImpact):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+// requireSuperAdmin rejects callers without the super_admin role, since
+// incidents are shown to every tenant
+func requireSuperAdmin(c *gin.Context) bool {
+	roles, _ := c.Get("user_roles")
+	userRoles, _ := roles.([]string)
+	for _, role := range userRoles {
+		if role == "super_admin" {
+			return true
+		}
+	}
+	response.Forbidden(c, "Incidents can only be posted by super admins")
+	return false
+}
+
+func userIDFromContext(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
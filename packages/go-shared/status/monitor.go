@@ -0,0 +1,255 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultTimeout  = 5 * time.Second
+	defaultSlow     = 2 * time.Second
+)
+
+// Target is a service to probe
+type Target struct {
+	// Name is shown on the status page, e.g. "invoices"
+	Name string
+	// URL is the service's readiness endpoint
+	URL string
+}
+
+// ParseTargets parses a comma-separated list of name=url pairs, e.g.
+// "auth=http://auth-service:8081/ready,invoices=http://invoice-service:8085/ready"
+func ParseTargets(list string) ([]Target, error) {
+	var targets []Target
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("invalid status target %q", entry)
+		}
+		targets = append(targets, Target{Name: strings.TrimSpace(name), URL: strings.TrimSpace(url)})
+	}
+	return targets, nil
+}
+
+// Config tunes the monitor. Zero values fall back to the defaults.
+type Config struct {
+	// Interval between probes of each service
+	Interval time.Duration
+	// Timeout of a probe; a service that does not answer in time is down
+	Timeout time.Duration
+	// Slow is the response time above which a service is degraded
+	Slow time.Duration
+}
+
+// probe is the outcome of the latest probe of a service
+type probe struct {
+	state     string
+	latency   time.Duration
+	checkedAt time.Time
+}
+
+// Monitor probes the services and assembles the status page
+type Monitor struct {
+	store   *Store
+	targets []Target
+	cfg     Config
+	client  *http.Client
+
+	mu      sync.RWMutex
+	probes  map[string]probe
+	uptimes map[string]uptime
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor creates a monitor for targets
+func NewMonitor(store *Store, targets []Target, cfg Config) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Slow <= 0 {
+		cfg.Slow = defaultSlow
+	}
+	return &Monitor{
+		store:   store,
+		targets: targets,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		probes:  make(map[string]probe),
+		uptimes: make(map[string]uptime),
+	}
+}
+
+// Start probes the services every interval until ctx is cancelled or Stop
+// is called
+func (m *Monitor) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			m.probeAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops probing
+func (m *Monitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
+
+// probeAll probes every service concurrently, records the results and
+// refreshes the uptime figures
+func (m *Monitor) probeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, target := range m.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := m.probe(ctx, target)
+
+			m.mu.Lock()
+			m.probes[target.Name] = result
+			m.mu.Unlock()
+
+			if err := m.store.recordCheck(ctx, target.Name, result.state == StateOutage, result.checkedAt); err != nil {
+				log.Printf("status: failed to record check of %s: %v", target.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	uptimes, err := m.store.uptimes(ctx, time.Now())
+	if err != nil {
+		log.Printf("status: failed to load uptime: %v", err)
+		return
+	}
+	m.mu.Lock()
+	m.uptimes = uptimes
+	m.mu.Unlock()
+}
+
+func (m *Monitor) probe(ctx context.Context, target Target) probe {
+	start := time.Now()
+	result := probe{state: StateOutage, checkedAt: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		return result
+	}
+	resp, err := m.client.Do(req)
+	result.latency = time.Since(start)
+	if err != nil {
+		return result
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusBadRequest:
+		result.state = StateOutage
+	case result.latency > m.cfg.Slow:
+		result.state = StateDegraded
+	default:
+		result.state = StateOperational
+	}
+	return result
+}
+
+// Summary returns each service's state, adjusted for open incidents, with
+// the open incidents themselves
+func (m *Monitor) Summary(ctx context.Context) (*Summary, error) {
+	incidents, err := m.store.OpenIncidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := &Summary{
+		State:     StateOperational,
+		Services:  make([]ServiceStatus, 0, len(m.targets)),
+		Incidents: incidents,
+		UpdatedAt: time.Now(),
+	}
+	for _, target := range m.targets {
+		service := ServiceStatus{Name: target.Name, State: StateUnknown}
+		if result, ok := m.probes[target.Name]; ok {
+			checkedAt := result.checkedAt
+			service.State = result.state
+			service.LatencyMS = result.latency.Milliseconds()
+			service.LastCheckedAt = &checkedAt
+		}
+		for _, incident := range incidents {
+			service.State = worse(service.State, incidentState(incident, target.Name))
+		}
+		if u, ok := m.uptimes[target.Name]; ok {
+			service.Uptime7d, service.Uptime30d, service.Uptime90d = u.Uptime7d, u.Uptime30d, u.Uptime90d
+		}
+
+		summary.State = worse(summary.State, service.State)
+		summary.Services = append(summary.Services, service)
+	}
+	sort.Slice(summary.Services, func(i, j int) bool { return summary.Services[i].Name < summary.Services[j].Name })
+	return summary, nil
+}
+
+// incidentState is the state an open incident imposes on a service
+func incidentState(incident Incident, service string) string {
+	affected := false
+	for _, name := range incident.Services {
+		if name == service {
+			affected = true
+			break
+		}
+	}
+	if !affected {
+		return StateOperational
+	}
+
+	switch incident.Impact {
+	case ImpactCritical:
+		return StateOutage
+	case ImpactMajor:
+		return StateDegraded
+	default:
+		return StateOperational
+	}
+}
+
+// Services returns the names of the monitored services
+func (m *Monitor) Services() []string {
+	names := make([]string, len(m.targets))
+	for i, target := range m.targets {
+		names[i] = target.Name
+	}
+	return names
+}
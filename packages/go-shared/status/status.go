@@ -0,0 +1,123 @@
+// Package status powers the public status page: it probes each service's
+// readiness endpoint, keeps daily uptime counts, and stores the incidents
+// operators post while something is wrong.
+package status
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Service states, from best to worst
+const (
+	StateOperational = "operational"
+	StateDegraded    = "degraded"
+	StateOutage      = "outage"
+	StateUnknown     = "unknown"
+)
+
+// Incident statuses; every status but resolved is open
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+// Incident impacts. Open major incidents show the affected services as
+// degraded and critical ones as down, whatever the probes say.
+const (
+	ImpactMinor    = "minor"
+	ImpactMajor    = "major"
+	ImpactCritical = "critical"
+)
+
+// Incident is an operator-posted notice about a problem
+type Incident struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title  string    `gorm:"size:200;not null" json:"title"`
+	Status string    `gorm:"size:20;not null;default:'investigating';index" json:"status"`
+	Impact string    `gorm:"size:20;not null;default:'minor'" json:"impact"`
+	// Services are the names of the affected services
+	Services []string `gorm:"type:jsonb;serializer:json" json:"services"`
+	// Message is the latest update shown to users
+	Message string `gorm:"type:text" json:"message"`
+
+	StartedAt  time.Time  `gorm:"not null" json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Incident
+func (Incident) TableName() string {
+	return "status_incidents"
+}
+
+// BeforeCreate hook
+func (i *Incident) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// Open reports whether the incident is still ongoing
+func (i *Incident) Open() bool {
+	return i.Status != IncidentResolved
+}
+
+// UptimeDay counts a service's probes on one day (UTC). Every monitor
+// instance adds its own probes, which leaves the ratio unchanged.
+type UptimeDay struct {
+	Service  string    `gorm:"size:50;primaryKey" json:"service"`
+	Day      time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Checks   int64     `gorm:"not null;default:0" json:"checks"`
+	Failures int64     `gorm:"not null;default:0" json:"failures"`
+}
+
+// TableName returns the table name for UptimeDay
+func (UptimeDay) TableName() string {
+	return "status_uptime_days"
+}
+
+// ServiceStatus is a service's current state and recent uptime
+type ServiceStatus struct {
+	Name          string     `json:"name"`
+	State         string     `json:"state"`
+	LatencyMS     int64      `json:"latency_ms"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	// Uptime percentages; nil until the service has been probed in the
+	// window
+	Uptime7d  *float64 `json:"uptime_7d"`
+	Uptime30d *float64 `json:"uptime_30d"`
+	Uptime90d *float64 `json:"uptime_90d"`
+}
+
+// Summary is the platform status shown on the status page
+type Summary struct {
+	State     string          `json:"state"`
+	Services  []ServiceStatus `json:"services"`
+	Incidents []Incident      `json:"incidents"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// stateRank orders states from best to worst
+var stateRank = map[string]int{
+	StateOperational: 0,
+	StateUnknown:     1,
+	StateDegraded:    2,
+	StateOutage:      3,
+}
+
+// worse returns the worse of two states
+func worse(a, b string) string {
+	if stateRank[b] > stateRank[a] {
+		return b
+	}
+	return a
+}
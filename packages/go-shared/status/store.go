@@ -0,0 +1,150 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIncidentNotFound      = errors.New("incident not found")
+	ErrInvalidIncident       = errors.New("incident status must be investigating, identified, monitoring or resolved")
+	ErrInvalidImpact         = errors.New("incident impact must be minor, major or critical")
+	ErrIncidentTitleRequired = errors.New("incident title is required")
+)
+
+// Store keeps incidents and uptime counts
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore creates a status store backed by db
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// OpenIncidents returns the ongoing incidents, newest first
+func (s *Store) OpenIncidents(ctx context.Context) ([]Incident, error) {
+	incidents := []Incident{}
+	err := s.db.WithContext(ctx).
+		Where("status <> ?", IncidentResolved).
+		Order("started_at DESC").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// RecentIncidents returns the incidents started in the last days, newest
+// first
+func (s *Store) RecentIncidents(ctx context.Context, days int) ([]Incident, error) {
+	incidents := []Incident{}
+	err := s.db.WithContext(ctx).
+		Where("started_at >= ? OR status <> ?", time.Now().AddDate(0, 0, -days), IncidentResolved).
+		Order("started_at DESC").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// GetIncident returns an incident
+func (s *Store) GetIncident(ctx context.Context, id uuid.UUID) (*Incident, error) {
+	var incident Incident
+	err := s.db.WithContext(ctx).First(&incident, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// SaveIncident creates or updates an incident, stamping its resolution
+// time when it is resolved
+func (s *Store) SaveIncident(ctx context.Context, incident *Incident) error {
+	if incident.Title == "" {
+		return ErrIncidentTitleRequired
+	}
+	switch incident.Status {
+	case IncidentInvestigating, IncidentIdentified, IncidentMonitoring, IncidentResolved:
+	default:
+		return ErrInvalidIncident
+	}
+	switch incident.Impact {
+	case ImpactMinor, ImpactMajor, ImpactCritical:
+	default:
+		return ErrInvalidImpact
+	}
+
+	if incident.StartedAt.IsZero() {
+		incident.StartedAt = time.Now()
+	}
+	if incident.Open() {
+		incident.ResolvedAt = nil
+	} else if incident.ResolvedAt == nil {
+		now := time.Now()
+		incident.ResolvedAt = &now
+	}
+	if incident.Services == nil {
+		incident.Services = []string{}
+	}
+
+	return s.db.WithContext(ctx).Save(incident).Error
+}
+
+// recordCheck counts a probe of service in today's uptime
+func (s *Store) recordCheck(ctx context.Context, service string, failed bool, at time.Time) error {
+	day := UptimeDay{
+		Service: service,
+		Day:     at.UTC().Truncate(24 * time.Hour),
+		Checks:  1,
+	}
+	if failed {
+		day.Failures = 1
+	}
+
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "service"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"checks":   gorm.Expr("status_uptime_days.checks + EXCLUDED.checks"),
+			"failures": gorm.Expr("status_uptime_days.failures + EXCLUDED.failures"),
+		}),
+	}).Create(&day).Error
+}
+
+// uptime holds a service's uptime percentages over 7, 30 and 90 days
+type uptime struct {
+	Service   string
+	Uptime7d  *float64 `gorm:"column:uptime_7d"`
+	Uptime30d *float64 `gorm:"column:uptime_30d"`
+	Uptime90d *float64 `gorm:"column:uptime_90d"`
+}
+
+// uptimes returns every service's uptime as of now
+func (s *Store) uptimes(ctx context.Context, now time.Time) (map[string]uptime, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	since := func(days int) time.Time { return today.AddDate(0, 0, 1-days) }
+
+	var rows []uptime
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT service,
+			100.0 * (1 - SUM(failures) FILTER (WHERE day >= ?)::float / NULLIF(SUM(checks) FILTER (WHERE day >= ?), 0)) AS uptime_7d,
+			100.0 * (1 - SUM(failures) FILTER (WHERE day >= ?)::float / NULLIF(SUM(checks) FILTER (WHERE day >= ?), 0)) AS uptime_30d,
+			100.0 * (1 - SUM(failures)::float / NULLIF(SUM(checks), 0)) AS uptime_90d
+		FROM status_uptime_days
+		WHERE day >= ?
+		GROUP BY service`,
+		since(7), since(7), since(30), since(30), since(90)).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]uptime, len(rows))
+	for _, row := range rows {
+		result[row.Service] = row
+	}
+	return result, nil
+}
@@ -0,0 +1,174 @@
+// Package storage accounts for the files tenants store and enforces the
+// storage quota of their plan. It is shared by every service that keeps
+// uploaded documents, so usage covers all of them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+const (
+	MB int64 = 1 << 20
+	GB int64 = 1 << 30
+)
+
+// DefaultPlan is assumed for tenants whose plan has no quota listed
+const DefaultPlan = "free"
+
+// PlanQuotas is the storage included in each plan
+var PlanQuotas = map[string]int64{
+	"free":         100 * MB,
+	"starter":      1 * GB,
+	"professional": 10 * GB,
+	"enterprise":   100 * GB,
+}
+
+// Document is a stored file counted against its tenant's quota. Kind and
+// RefID point back at the row of the service that holds the content, e.g.
+// kind vendor_document and the vendor document's ID.
+type Document struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Kind        string    `gorm:"size:50;not null;uniqueIndex:idx_stored_documents_ref" json:"kind"`
+	RefID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_stored_documents_ref" json:"ref_id"`
+	FileName    string    `gorm:"size:255" json:"file_name"`
+	ContentType string    `gorm:"size:100" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for Document
+func (Document) TableName() string {
+	return "stored_documents"
+}
+
+// BeforeCreate hook
+func (d *Document) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// Usage is a tenant's storage use against its quota
+type Usage struct {
+	TenantID       uuid.UUID   `json:"tenant_id"`
+	Plan           string      `json:"plan"`
+	QuotaBytes     int64       `json:"quota_bytes"`
+	UsedBytes      int64       `json:"used_bytes"`
+	AvailableBytes int64       `json:"available_bytes"`
+	DocumentCount  int64       `json:"document_count"`
+	ByKind         []KindUsage `json:"by_kind"`
+}
+
+// KindUsage is the storage used by one kind of document
+type KindUsage struct {
+	Kind          string `json:"kind"`
+	UsedBytes     int64  `json:"used_bytes"`
+	DocumentCount int64  `json:"document_count"`
+}
+
+// Record counts a document against its tenant's quota, replacing any
+// earlier record for the same kind and ref. It fails with ErrQuotaExceeded
+// when the document does not fit. Call it in the transaction that stores
+// the file so the two commit or roll back together.
+func Record(tx *gorm.DB, doc *Document) error {
+	// Serialise uploads per tenant so concurrent ones cannot both squeeze
+	// under the quota
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "storage:"+doc.TenantID.String()).Error; err != nil {
+		return err
+	}
+
+	_, quota, err := quotaOf(tx, doc.TenantID)
+	if err != nil {
+		return err
+	}
+
+	var used int64
+	err = tx.Model(&Document{}).
+		Where("tenant_id = ? AND NOT (kind = ? AND ref_id = ?)", doc.TenantID, doc.Kind, doc.RefID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&used).Error
+	if err != nil {
+		return err
+	}
+	if used+doc.Size > quota {
+		return ErrQuotaExceeded
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "ref_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"file_name", "content_type", "size", "created_at"}),
+	}).Create(doc).Error
+}
+
+// Remove stops counting a deleted document
+func Remove(tx *gorm.DB, kind string, refID uuid.UUID) error {
+	return tx.Where("kind = ? AND ref_id = ?", kind, refID).Delete(&Document{}).Error
+}
+
+// GetUsage returns the tenant's storage use by kind of document
+func GetUsage(ctx context.Context, db *gorm.DB, tenantID uuid.UUID) (*Usage, error) {
+	db = db.WithContext(ctx)
+
+	plan, quota, err := quotaOf(db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{TenantID: tenantID, Plan: plan, QuotaBytes: quota, ByKind: []KindUsage{}}
+	err = db.Model(&Document{}).
+		Select("kind, SUM(size) AS used_bytes, COUNT(*) AS document_count").
+		Where("tenant_id = ?", tenantID).
+		Group("kind").
+		Order("used_bytes DESC").
+		Scan(&usage.ByKind).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range usage.ByKind {
+		usage.UsedBytes += kind.UsedBytes
+		usage.DocumentCount += kind.DocumentCount
+	}
+	usage.AvailableBytes = max(quota-usage.UsedBytes, 0)
+	return usage, nil
+}
+
+// Largest returns the tenant's largest documents, biggest first
+func Largest(ctx context.Context, db *gorm.DB, tenantID uuid.UUID, limit int) ([]Document, error) {
+	var documents []Document
+	err := db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("size DESC, created_at").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
+
+// quotaOf looks up the tenant's plan, kept by the tenant service, and the
+// storage it includes
+func quotaOf(db *gorm.DB, tenantID uuid.UUID) (string, int64, error) {
+	var plans []string
+	if err := db.Table("tenants").Where("id = ?", tenantID).Pluck("plan", &plans).Error; err != nil {
+		return "", 0, err
+	}
+
+	plan := DefaultPlan
+	if len(plans) > 0 && plans[0] != "" {
+		plan = plans[0]
+	}
+	quota, ok := PlanQuotas[plan]
+	if !ok {
+		quota = PlanQuotas[DefaultPlan]
+	}
+	return plan, quota, nil
+}
@@ -0,0 +1,112 @@
+package support
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// maxCapturedBody is how much of an error response is kept to read its
+// code and message from
+const maxCapturedBody = 4096
+
+// RecordErrors is middleware that keeps the error responses of tenants'
+// requests for their bundles. Requests without a tenant, such as failed
+// logins, are not kept; neither are request bodies.
+func (s *Service) RecordErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &errorCapture{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status < 400 {
+			return
+		}
+		tenantID, ok := tenantFromContext(c)
+		if !ok {
+			return
+		}
+
+		entry := ErrorLog{
+			TenantID:  tenantID,
+			RequestID: c.GetString("request_id"),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Status:    status,
+			At:        time.Now(),
+		}
+		if entry.Route == "" {
+			entry.Route = entry.Path
+		}
+		if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			entry.UserID = &userID
+		}
+		var body response.Response
+		if err := json.Unmarshal(writer.body.Bytes(), &body); err == nil && body.Error != nil {
+			entry.Code = body.Error.Code
+			entry.Message = body.Error.Message
+		}
+		// Errors handlers attach are more telling than the message users see
+		if len(c.Errors) > 0 {
+			entry.Message = c.Errors.Last().Error()
+		}
+		if len(entry.Message) > 1000 {
+			entry.Message = entry.Message[:1000]
+		}
+
+		// The response is already written; the error is kept in the
+		// background so the request does not wait on it
+		go func() {
+			if err := s.db.Create(&entry).Error; err != nil {
+				log.Printf("support: failed to keep error of request %s: %v", entry.RequestID, err)
+			}
+		}()
+	}
+}
+
+// errorCapture keeps the start of error responses as they are written
+type errorCapture struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorCapture) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorCapture) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *errorCapture) capture(data []byte) {
+	if w.Status() < 400 || w.body.Len() >= maxCapturedBody {
+		return
+	}
+	if room := maxCapturedBody - w.body.Len(); len(data) > room {
+		data = data[:room]
+	}
+	w.body.Write(data)
+}
+
+// tenantFromContext returns the tenant of the request, which services set
+// as a string or a UUID
+func tenantFromContext(c *gin.Context) (uuid.UUID, bool) {
+	value, _ := c.Get("tenant_id")
+	switch tenantID := value.(type) {
+	case uuid.UUID:
+		return tenantID, tenantID != uuid.Nil
+	case string:
+		id, err := uuid.Parse(tenantID)
+		return id, err == nil
+	}
+	return uuid.Nil, false
+}
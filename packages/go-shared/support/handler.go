@@ -0,0 +1,213 @@
+package support
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves a tenant's support bundles. Mount the consent routes
+// behind an owner check:
+//
+//	POST /support/bundles
+//	GET  /support/bundles
+//	GET  /support/bundles/:id
+//	POST /support/bundles/:id/consent  (owner)
+//	POST /support/bundles/:id/decline  (owner)
+//	GET  /support/bundles/:id/download
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new support bundle handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateBundleRequest is the body of Create
+type CreateBundleRequest struct {
+	DocumentType string    `json:"document_type" binding:"required"`
+	DocumentID   uuid.UUID `json:"document_id" binding:"required"`
+	RequestIDs   []string  `json:"request_ids" binding:"max=20,dive,max=100"`
+	TicketRef    string    `json:"ticket_ref" binding:"max=100"`
+	Description  string    `json:"description" binding:"max=5000"`
+}
+
+// Create requests a bundle on a document. It is built once the tenant's
+// owner consents.
+func (h *Handler) Create(c *gin.Context) {
+	tenantID, userID, ok := identity(c)
+	if !ok {
+		return
+	}
+
+	var req CreateBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	bundle := &Bundle{
+		TenantID:     tenantID,
+		DocumentType: req.DocumentType,
+		DocumentID:   req.DocumentID,
+		RequestIDs:   req.RequestIDs,
+		TicketRef:    req.TicketRef,
+		Description:  req.Description,
+		RequestedBy:  userID,
+	}
+	if err := h.service.Request(c.Request.Context(), bundle); err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownDocument):
+			response.BadRequest(c, "Bundles cannot be made for this type of document", nil)
+		case errors.Is(err, ErrDocumentNotFound):
+			response.NotFound(c, "Document not found")
+		default:
+			response.InternalError(c, "Failed to request support bundle")
+		}
+		return
+	}
+
+	response.Created(c, bundle)
+}
+
+// List returns the tenant's bundles, optionally with one status
+func (h *Handler) List(c *gin.Context) {
+	tenantID, _, ok := identity(c)
+	if !ok {
+		return
+	}
+
+	bundles, err := h.service.List(c.Request.Context(), tenantID, c.Query("status"))
+	if err != nil {
+		response.InternalError(c, "Failed to list support bundles")
+		return
+	}
+
+	response.Success(c, bundles)
+}
+
+// Get returns a bundle's status
+func (h *Handler) Get(c *gin.Context) {
+	tenantID, _, ok := identity(c)
+	if !ok {
+		return
+	}
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.service.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, bundle)
+}
+
+// Consent builds a pending bundle with the owner's consent
+func (h *Handler) Consent(c *gin.Context) {
+	tenantID, userID, ok := identity(c)
+	if !ok {
+		return
+	}
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.service.Consent(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, bundle)
+}
+
+// Decline refuses a pending bundle
+func (h *Handler) Decline(c *gin.Context) {
+	tenantID, userID, ok := identity(c)
+	if !ok {
+		return
+	}
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.service.Decline(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, bundle)
+}
+
+// Download returns a ready bundle as a JSON file to attach to the ticket
+func (h *Handler) Download(c *gin.Context) {
+	tenantID, _, ok := identity(c)
+	if !ok {
+		return
+	}
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.service.Download(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="support-bundle-%s.json"`, bundle.ID))
+	c.Data(http.StatusOK, "application/json", bundle.Content)
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBundleNotFound):
+		response.NotFound(c, "Support bundle not found")
+	case errors.Is(err, ErrDocumentNotFound):
+		response.NotFound(c, "The bundle's document no longer exists")
+	case errors.Is(err, ErrNotPending):
+		response.Conflict(c, "Support bundle is not waiting for consent")
+	case errors.Is(err, ErrNotReady):
+		response.Conflict(c, "Support bundle is not ready or has expired")
+	default:
+		response.InternalError(c, "Failed to process support bundle")
+	}
+}
+
+// identity returns the tenant and user of the request, answering it when
+// either is missing
+func identity(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := tenantFromContext(c)
+	if !ok {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, userID, true
+}
+
+func bundleID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid support bundle ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,100 @@
+package support
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// redactedFields are removed from bundles wherever they appear: secrets,
+// and identifiers support has no need for
+var redactedFields = []string{
+	"password", "password_hash", "token", "secret", "api_key", "otp",
+	"access_token", "refresh_token", "credentials", "signature",
+	"bank_account_number", "account_number", "card_number", "cvv",
+	"pan", "aadhaar", "upi_id",
+}
+
+// maskedFields keep enough of their value to tell records apart
+var maskedFields = []string{"email", "phone", "mobile"}
+
+// Sanitize returns v as JSON values with secrets redacted and contact
+// details masked. Fields are matched by their JSON names, including as a
+// suffix, so customer_email and vendor_pan are covered too.
+func Sanitize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return sanitizeValue(value), nil
+}
+
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			switch {
+			case matchesField(key, redactedFields):
+				if field != nil && field != "" {
+					v[key] = "[REDACTED]"
+				}
+			case matchesField(key, maskedFields):
+				if s, ok := field.(string); ok {
+					v[key] = mask(s)
+				}
+			default:
+				v[key] = sanitizeValue(field)
+			}
+		}
+		// A timeline change names its field rather than being keyed by it
+		if name, ok := v["field"].(string); ok {
+			for _, key := range []string{"from", "to"} {
+				if s, ok := v[key].(string); ok {
+					if matchesField(name, redactedFields) {
+						v[key] = "[REDACTED]"
+					} else if matchesField(name, maskedFields) {
+						v[key] = mask(s)
+					}
+				}
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// matchesField reports whether the JSON field key is one of fields or ends
+// in one, as customer_email ends in email
+func matchesField(key string, fields []string) bool {
+	key = strings.ToLower(key)
+	for _, field := range fields {
+		if key == field || strings.HasSuffix(key, "_"+field) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask keeps the first character and domain of an email address and the
+// last four digits of anything else
+func mask(s string) string {
+	if s == "" {
+		return s
+	}
+	if at := strings.LastIndex(s, "@"); at > 0 {
+		return s[:1] + "***" + s[at:]
+	}
+	if len(s) <= 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
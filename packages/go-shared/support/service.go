@@ -0,0 +1,350 @@
+package support
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"gorm.io/gorm"
+)
+
+// Load returns a tenant's document as its API shows it, or
+// ErrDocumentNotFound if the tenant has no such document
+type Load func(ctx context.Context, tenantID, id uuid.UUID) (interface{}, error)
+
+// DocumentConfig describes a type of document bundles can be made for
+type DocumentConfig struct {
+	Type string
+	Load Load
+	// Timeline composes the document's events beyond those recorded in the
+	// timeline store; nil for documents without a timeline
+	Timeline timeline.Compose
+}
+
+// Config holds the settings of the support service
+type Config struct {
+	Service        string // Named in bundles, as documents live in different services
+	Documents      []DocumentConfig
+	ErrorRetention time.Duration // How long error responses are kept; zero means 14 days
+	ErrorWindow    time.Duration // How far back a bundle's errors go; zero means 7 days
+	BundleTTL      time.Duration // How long a ready bundle can be downloaded; zero means 7 days
+}
+
+// maxBundleErrors caps the errors in a bundle besides those of the
+// requests the user named
+const maxBundleErrors = 100
+
+// Service keeps tenants' error responses and makes their support bundles
+type Service struct {
+	db        *gorm.DB
+	timelines *timeline.Store
+	config    Config
+	documents map[string]DocumentConfig
+}
+
+// NewService creates a support service. timelines may be nil for services
+// whose documents have no recorded timeline.
+func NewService(db *gorm.DB, timelines *timeline.Store, config Config) *Service {
+	if config.ErrorRetention <= 0 {
+		config.ErrorRetention = 14 * 24 * time.Hour
+	}
+	if config.ErrorWindow <= 0 {
+		config.ErrorWindow = 7 * 24 * time.Hour
+	}
+	if config.BundleTTL <= 0 {
+		config.BundleTTL = 7 * 24 * time.Hour
+	}
+	documents := make(map[string]DocumentConfig, len(config.Documents))
+	for _, doc := range config.Documents {
+		documents[doc.Type] = doc
+	}
+	return &Service{db: db, timelines: timelines, config: config, documents: documents}
+}
+
+// Start deletes old error responses and the content of expired bundles
+// every hour until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			s.prune(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Service) prune(ctx context.Context) {
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Where("at < ?", now.Add(-s.config.ErrorRetention)).Delete(&ErrorLog{}).Error; err != nil {
+		log.Printf("support: failed to delete old errors: %v", err)
+	}
+	err := s.db.WithContext(ctx).Model(&Bundle{}).
+		Where("status = ? AND expires_at < ?", StatusReady, now).
+		Updates(map[string]interface{}{"status": StatusExpired, "content": nil}).Error
+	if err != nil {
+		log.Printf("support: failed to expire bundles: %v", err)
+	}
+}
+
+// Request records a member's request for a bundle on a document, to be
+// built once the owner consents
+func (s *Service) Request(ctx context.Context, bundle *Bundle) error {
+	doc, ok := s.documents[bundle.DocumentType]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownDocument, bundle.DocumentType)
+	}
+	if _, err := doc.Load(ctx, bundle.TenantID, bundle.DocumentID); err != nil {
+		return err
+	}
+
+	bundle.Status = StatusPendingConsent
+	return s.db.WithContext(ctx).Create(bundle).Error
+}
+
+// Get returns one of the tenant's bundles, without its content
+func (s *Service) Get(ctx context.Context, tenantID, id uuid.UUID) (*Bundle, error) {
+	var bundle Bundle
+	err := s.db.WithContext(ctx).Omit("content").
+		First(&bundle, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBundleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// List returns the tenant's bundles, newest first, optionally with one
+// status
+func (s *Service) List(ctx context.Context, tenantID uuid.UUID, status string) ([]Bundle, error) {
+	query := s.db.WithContext(ctx).Omit("content").Where("tenant_id = ?", tenantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var bundles []Bundle
+	err := query.Order("created_at DESC").Limit(100).Find(&bundles).Error
+	return bundles, err
+}
+
+// Consent records the owner's consent to a pending bundle and builds it
+// from the document as it is now
+func (s *Service) Consent(ctx context.Context, tenantID, id, ownerID uuid.UUID) (*Bundle, error) {
+	bundle, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Status != StatusPendingConsent {
+		return nil, ErrNotPending
+	}
+
+	now := time.Now()
+	data, err := s.build(ctx, bundle, ownerID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(s.config.BundleTTL)
+	result := s.db.WithContext(ctx).Model(&Bundle{}).
+		Where("id = ? AND status = ?", bundle.ID, StatusPendingConsent).
+		Updates(map[string]interface{}{
+			"status":     StatusReady,
+			"decided_by": ownerID,
+			"decided_at": now,
+			"content":    data,
+			"size":       len(data),
+			"expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotPending
+	}
+	return s.Get(ctx, tenantID, id)
+}
+
+// Decline records the owner's refusal of a pending bundle
+func (s *Service) Decline(ctx context.Context, tenantID, id, ownerID uuid.UUID) (*Bundle, error) {
+	result := s.db.WithContext(ctx).Model(&Bundle{}).
+		Where("id = ? AND tenant_id = ? AND status = ?", id, tenantID, StatusPendingConsent).
+		Updates(map[string]interface{}{
+			"status":     StatusDeclined,
+			"decided_by": ownerID,
+			"decided_at": time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := s.Get(ctx, tenantID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotPending
+	}
+	return s.Get(ctx, tenantID, id)
+}
+
+// Download returns the content of a ready bundle
+func (s *Service) Download(ctx context.Context, tenantID, id uuid.UUID) (*Bundle, error) {
+	var bundle Bundle
+	err := s.db.WithContext(ctx).First(&bundle, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBundleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Status != StatusReady || (bundle.ExpiresAt != nil && time.Now().After(*bundle.ExpiresAt)) {
+		return nil, ErrNotReady
+	}
+	return &bundle, nil
+}
+
+// content is the JSON of a bundle
+type content struct {
+	BundleID    uuid.UUID   `json:"bundle_id"`
+	Service     string      `json:"service"`
+	TenantID    uuid.UUID   `json:"tenant_id"`
+	TicketRef   string      `json:"ticket_ref,omitempty"`
+	Description string      `json:"description,omitempty"`
+	RequestedBy uuid.UUID   `json:"requested_by"`
+	ConsentedBy uuid.UUID   `json:"consented_by"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Document    document    `json:"document"`
+	Events      interface{} `json:"events"`
+	RequestIDs  []string    `json:"request_ids,omitempty"`
+	Errors      interface{} `json:"errors"`
+}
+
+type document struct {
+	Type string      `json:"type"`
+	ID   uuid.UUID   `json:"id"`
+	Data interface{} `json:"data"`
+}
+
+// build gathers and sanitizes what goes in a bundle
+func (s *Service) build(ctx context.Context, bundle *Bundle, ownerID uuid.UUID, now time.Time) ([]byte, error) {
+	doc, ok := s.documents[bundle.DocumentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDocument, bundle.DocumentType)
+	}
+	data, err := doc.Load(ctx, bundle.TenantID, bundle.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	data, err = Sanitize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.events(ctx, doc, bundle)
+	if err != nil {
+		return nil, err
+	}
+	sanitizedEvents, err := Sanitize(events)
+	if err != nil {
+		return nil, err
+	}
+
+	errorLogs, err := s.errorLogs(ctx, bundle, now)
+	if err != nil {
+		return nil, err
+	}
+	sanitizedErrors, err := Sanitize(errorLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(content{
+		BundleID:    bundle.ID,
+		Service:     s.config.Service,
+		TenantID:    bundle.TenantID,
+		TicketRef:   bundle.TicketRef,
+		Description: bundle.Description,
+		RequestedBy: bundle.RequestedBy,
+		ConsentedBy: ownerID,
+		GeneratedAt: now,
+		Document:    document{Type: bundle.DocumentType, ID: bundle.DocumentID, Data: data},
+		Events:      sanitizedEvents,
+		RequestIDs:  bundle.RequestIDs,
+		Errors:      sanitizedErrors,
+	}, "", "  ")
+}
+
+// events returns the document's timeline in the order it happened
+func (s *Service) events(ctx context.Context, doc DocumentConfig, bundle *Bundle) ([]timeline.Event, error) {
+	events := []timeline.Event{}
+	if s.timelines == nil {
+		return events, nil
+	}
+
+	target := timeline.Document{TenantID: bundle.TenantID, Type: bundle.DocumentType, ID: bundle.DocumentID}
+	recorded, err := s.timelines.List(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, recorded...)
+	if doc.Timeline != nil {
+		composed, err := doc.Timeline(ctx, target)
+		if err != nil && !errors.Is(err, timeline.ErrDocumentNotFound) {
+			return nil, err
+		}
+		events = append(events, composed...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+	return events, nil
+}
+
+// errorLogs returns the tenant's recent error responses and those of the
+// requests the user named, however old, newest first
+func (s *Service) errorLogs(ctx context.Context, bundle *Bundle, now time.Time) ([]ErrorLog, error) {
+	var recent []ErrorLog
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND at >= ?", bundle.TenantID, now.Add(-s.config.ErrorWindow)).
+		Order("at DESC").Limit(maxBundleErrors).
+		Find(&recent).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle.RequestIDs) == 0 {
+		return recent, nil
+	}
+
+	var named []ErrorLog
+	err = s.db.WithContext(ctx).
+		Where("tenant_id = ? AND request_id IN ?", bundle.TenantID, bundle.RequestIDs).
+		Order("at DESC").
+		Find(&named).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(recent))
+	for _, entry := range recent {
+		seen[entry.ID] = true
+	}
+	for _, entry := range named {
+		if !seen[entry.ID] {
+			recent = append(recent, entry)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].At.After(recent[j].At)
+	})
+	return recent, nil
+}
@@ -0,0 +1,98 @@
+// Package support packages the context support needs to look into a
+// user's report of a bug on a document, such as an invoice or a
+// reconciliation: the document, its timeline and the errors the tenant's
+// requests ran into. A bundle is requested by any member of the tenant but
+// only built once the tenant's owner consents, and everything in it is
+// sanitized first: secrets are removed and contact details masked. The
+// bundle is a JSON file to attach to the support ticket.
+package support
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Bundle statuses. A pending bundle waits for the owner's consent; a ready
+// one can be downloaded until it expires, when its content is deleted.
+const (
+	StatusPendingConsent = "pending_consent"
+	StatusReady          = "ready"
+	StatusDeclined       = "declined"
+	StatusExpired        = "expired"
+)
+
+var (
+	ErrBundleNotFound   = errors.New("support bundle not found")
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrUnknownDocument  = errors.New("unknown document type")
+	ErrNotPending       = errors.New("support bundle is not waiting for consent")
+	ErrNotReady         = errors.New("support bundle is not ready")
+)
+
+// Bundle is a request for a diagnostic bundle and, once consented to, the
+// bundle itself
+type Bundle struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	DocumentType string    `gorm:"size:50;not null" json:"document_type"`
+	DocumentID   uuid.UUID `gorm:"type:uuid;not null" json:"document_id"`
+	RequestIDs   []string  `gorm:"type:jsonb;serializer:json" json:"request_ids,omitempty"` // Requests the user saw fail, from X-Request-ID
+	TicketRef    string    `gorm:"size:100" json:"ticket_ref,omitempty"`                    // The support ticket the bundle is for
+	Description  string    `gorm:"type:text" json:"description,omitempty"`
+
+	Status      string     `gorm:"size:20;not null;default:'pending_consent';index" json:"status"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null" json:"requested_by"`
+	DecidedBy   *uuid.UUID `gorm:"type:uuid" json:"decided_by,omitempty"` // The owner who consented or declined
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+
+	Content   []byte     `json:"-"`
+	Size      int        `json:"size"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Bundle
+func (Bundle) TableName() string {
+	return "support_bundles"
+}
+
+// BeforeCreate hook
+func (b *Bundle) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// ErrorLog is an error response a tenant's request got, kept for a while
+// so a bundle can show what went wrong around the time of a report
+type ErrorLog struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID  `gorm:"type:uuid;not null;index:idx_support_errors_tenant" json:"-"`
+	UserID    *uuid.UUID `gorm:"type:uuid" json:"user_id,omitempty"`
+	RequestID string     `gorm:"size:100;index" json:"request_id"`
+	Method    string     `gorm:"size:10;not null" json:"method"`
+	Route     string     `gorm:"size:255;not null" json:"route"` // The route pattern, without IDs
+	Path      string     `gorm:"size:500" json:"path"`
+	Status    int        `gorm:"not null" json:"status"`
+	Code      string     `gorm:"size:50" json:"code,omitempty"`
+	Message   string     `gorm:"size:1000" json:"message,omitempty"`
+	At        time.Time  `gorm:"not null;index:idx_support_errors_tenant" json:"at"`
+}
+
+// TableName returns the table name for ErrorLog
+func (ErrorLog) TableName() string {
+	return "support_error_logs"
+}
+
+// BeforeCreate hook
+func (e *ErrorLog) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
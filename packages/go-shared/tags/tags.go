@@ -0,0 +1,65 @@
+// Package tags normalises the free-form tags users put on transactions and
+// invoices, e.g. "#Diwali Campaign" becomes "diwali-campaign", so the same
+// tag typed differently groups together in reports.
+package tags
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxTags is the most tags one document may carry
+const MaxTags = 20
+
+// MaxLength is the longest a tag may be once normalised
+const MaxLength = 50
+
+var (
+	ErrInvalidTag  = errors.New("tags may only contain letters, digits, hyphens and underscores, up to 50 characters")
+	ErrTooManyTags = errors.New("a document may have at most 20 tags")
+)
+
+// Normalize returns the tag in canonical form: lower case, without a
+// leading '#', with runs of spaces turned into a hyphen
+func Normalize(tag string) (string, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+	if tag == "" || len(tag) > MaxLength {
+		return "", ErrInvalidTag
+	}
+	for _, r := range tag {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", ErrInvalidTag
+		}
+	}
+	return tag, nil
+}
+
+// NormalizeAll normalises a document's tags, dropping duplicates and
+// keeping their order. It always returns a non-nil slice so the column
+// stores an empty array rather than NULL.
+func NormalizeAll(list []string) ([]string, error) {
+	normalized := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, tag := range list {
+		tag, err := Normalize(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+// Usage is how many documents carry a tag
+type Usage struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,83 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Compose returns the events a service derives for a tenant's document
+// rather than records, or ErrDocumentNotFound if the tenant has no such
+// document
+type Compose func(ctx context.Context, doc Document) ([]Event, error)
+
+// DocumentConfig describes the documents a handler serves timelines for
+type DocumentConfig struct {
+	Type    string
+	Compose Compose
+}
+
+// Handler serves the timeline of one type of document. Mount it under the
+// document's routes:
+//
+//	GET /:id/history
+type Handler struct {
+	store  *Store
+	config DocumentConfig
+}
+
+// NewHandler creates a timeline handler for a type of document
+func NewHandler(store *Store, config DocumentConfig) *Handler {
+	return &Handler{store: store, config: config}
+}
+
+// History returns the document's events in the order they happened
+func (h *Handler) History(c *gin.Context) {
+	tenantID, err := tenantIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Tenant not found")
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid document ID", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	doc := Document{TenantID: tenantID, Type: h.config.Type, ID: id}
+	composed, err := h.config.Compose(ctx, doc)
+	if err != nil {
+		if errors.Is(err, ErrDocumentNotFound) {
+			response.NotFound(c, "Document not found")
+		} else {
+			response.InternalError(c, "Failed to get document history")
+		}
+		return
+	}
+	recorded, err := h.store.List(ctx, doc)
+	if err != nil {
+		response.InternalError(c, "Failed to get document history")
+		return
+	}
+
+	events := append(recorded, composed...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+
+	response.Success(c, events)
+}
+
+func tenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
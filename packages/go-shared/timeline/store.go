@@ -0,0 +1,91 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrDocumentNotFound is returned by a Compose for a document the tenant
+// does not have
+var ErrDocumentNotFound = errors.New("document not found")
+
+// Document identifies the document a timeline belongs to
+type Document struct {
+	TenantID uuid.UUID
+	Type     string
+	ID       uuid.UUID
+}
+
+// Store keeps the events recorded on documents
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore creates a timeline store
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Record adds an event to the document's timeline, attributed to the actor
+// of ctx if it has one. An edit that changed nothing is not recorded.
+func (s *Store) Record(ctx context.Context, doc Document, eventType, summary string, changes []Change) error {
+	if eventType == EventEdited && len(changes) == 0 {
+		return nil
+	}
+
+	event := Event{
+		TenantID:     doc.TenantID,
+		DocumentType: doc.Type,
+		DocumentID:   doc.ID,
+		Type:         eventType,
+		Summary:      summary,
+		Changes:      changes,
+		Source:       SourceSystem,
+		At:           time.Now(),
+	}
+	if actorID, ok := ActorFrom(ctx); ok {
+		event.ActorID = &actorID
+		event.Source = SourceUser
+	}
+	return s.db.WithContext(ctx).Create(&event).Error
+}
+
+// List returns the events recorded on the document, oldest first
+func (s *Store) List(ctx context.Context, doc Document) ([]Event, error) {
+	var events []Event
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_type = ? AND document_id = ?", doc.TenantID, doc.Type, doc.ID).
+		Order("at").
+		Find(&events).Error
+	return events, err
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose recorded events are attributed to the
+// user
+func WithActor(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFrom returns the user events recorded with ctx are attributed to
+func ActorFrom(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(actorKey{}).(uuid.UUID)
+	return userID, ok
+}
+
+// Actor is middleware attributing the events recorded while serving a
+// request to the authenticated user. Mount it after authentication.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			c.Request = c.Request.WithContext(WithActor(c.Request.Context(), userID))
+		}
+		c.Next()
+	}
+}
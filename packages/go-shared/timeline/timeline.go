@@ -0,0 +1,131 @@
+// Package timeline keeps the history of financial documents such as
+// invoices, bills and transactions for their detail screens: who created,
+// edited, sent, paid or voided a document and when, with the fields each
+// edit changed. Events a service records as it changes a document are
+// merged, when the timeline is read, with the events the service composes
+// from elsewhere, such as the document itself, its payments and the
+// callbacks of its integrations.
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Document types that have a timeline
+const (
+	DocumentInvoice     = "invoice"
+	DocumentBill        = "bill"
+	DocumentTransaction = "transaction"
+)
+
+// Event types
+const (
+	EventCreated            = "created"
+	EventEdited             = "edited"
+	EventSent               = "sent"
+	EventEmailed            = "emailed"
+	EventEmailBounced       = "email_bounced"
+	EventApproved           = "approved"
+	EventPaymentRecorded    = "payment_recorded"
+	EventPaymentBounced     = "payment_bounced"
+	EventCreditApplied      = "credit_applied"
+	EventCreditRemoved      = "credit_removed"
+	EventDebitNoteIssued    = "debit_note_issued"
+	EventDebitNoteCancelled = "debit_note_cancelled"
+	EventVoided             = "voided"
+	EventEInvoiceRequested  = "einvoice_requested"
+	EventEInvoiceGenerated  = "einvoice_generated"
+	EventEInvoiceFailed     = "einvoice_failed"
+	EventEInvoiceCancelled  = "einvoice_cancelled"
+	EventReminderSent       = "reminder_sent"
+	EventLateFeeCharged     = "late_fee_charged"
+	EventDisputed           = "disputed"
+	EventReconciled         = "reconciled"
+)
+
+// Where an event came from
+const (
+	SourceUser        = "user"        // A user's request
+	SourceSystem      = "system"      // Background work of the service
+	SourceIntegration = "integration" // A provider or another service
+)
+
+// Event is one entry in a document's timeline. Events composed when the
+// timeline is read carry the ID of the record they were composed from.
+type Event struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_events" json:"-"`
+	DocumentType string     `gorm:"size:30;not null;index:idx_document_events" json:"document_type"`
+	DocumentID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_events" json:"document_id"`
+	Type         string     `gorm:"size:30;not null" json:"type"`
+	Summary      string     `gorm:"size:500" json:"summary"`
+	Changes      []Change   `gorm:"type:jsonb;serializer:json" json:"changes,omitempty"`
+	ActorID      *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"` // Nil for background work and integrations
+	Source       string     `gorm:"size:20;not null" json:"source"`
+	At           time.Time  `gorm:"not null" json:"at"`
+}
+
+// TableName returns the table name for Event
+func (Event) TableName() string {
+	return "document_events"
+}
+
+// BeforeCreate hook
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Change is a field an edit changed, with its values as the document's
+// JSON shows them
+type Change struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// Diff lists which of the fields, named as in the documents' JSON, differ
+// between two versions of a document
+func Diff(before, after interface{}, fields ...string) []Change {
+	from, err := fieldsOf(before)
+	if err != nil {
+		return nil
+	}
+	to, err := fieldsOf(after)
+	if err != nil {
+		return nil
+	}
+
+	var changes []Change
+	for _, field := range fields {
+		if !bytes.Equal(from[field], to[field]) {
+			changes = append(changes, Change{Field: field, From: orNull(from[field]), To: orNull(to[field])})
+		}
+	}
+	return changes
+}
+
+func fieldsOf(doc interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// orNull stands in null for fields left out of the JSON
+func orNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
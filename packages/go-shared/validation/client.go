@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRulesTTL = time.Minute
+
+// Checker checks documents against their tenant's rules
+type Checker interface {
+	// Check returns an *Error listing the rules the document breaks. The
+	// rules are read on behalf of the caller identified by authorization;
+	// documents saved in the background, without a caller, are not
+	// checked.
+	Check(ctx context.Context, tenantID, authorization string, doc Document) error
+}
+
+// Client reads tenants' validation rules from the tenant service on behalf
+// of the requesting user, caching each tenant's for a short while. A failed
+// lookup keeps serving the last rules it got.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu    sync.Mutex
+	rules map[string]cachedRules
+}
+
+type cachedRules struct {
+	rules    []Rule
+	loadedAt time.Time
+}
+
+// NewClient creates a client for the tenant service at baseURL. Rule
+// changes take up to ttl to apply; zero means a minute.
+func NewClient(baseURL string, ttl time.Duration) *Client {
+	if ttl <= 0 {
+		ttl = defaultRulesTTL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		ttl:        ttl,
+		rules:      make(map[string]cachedRules),
+	}
+}
+
+// Rules returns the tenant's active rules
+func (c *Client) Rules(ctx context.Context, tenantID, authorization string) ([]Rule, error) {
+	c.mu.Lock()
+	cached, ok := c.rules[tenantID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.rules, nil
+	}
+
+	rules, err := c.fetch(ctx, tenantID, authorization)
+	if err != nil {
+		if ok {
+			return cached.rules, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.rules[tenantID] = cachedRules{rules: rules, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return rules, nil
+}
+
+// Check checks the document against the tenant's rules. Rules guard the
+// quality of the tenant's data rather than its security, so a document is
+// let through when they cannot be read.
+func (c *Client) Check(ctx context.Context, tenantID, authorization string, doc Document) error {
+	if authorization == "" {
+		return nil
+	}
+	rules, err := c.Rules(ctx, tenantID, authorization)
+	if err != nil {
+		log.Printf("Failed to read validation rules of tenant %s: %v", tenantID, err)
+		return nil
+	}
+	if violations := Evaluate(rules, doc); len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+func (c *Client) fetch(ctx context.Context, tenantID, authorization string) ([]Rule, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/tenants/"+tenantID+"/validation-rules?active=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []Rule `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
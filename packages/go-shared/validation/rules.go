@@ -0,0 +1,230 @@
+// Package validation holds the data validation rules tenants set for their
+// own documents, such as a narration on every journal or a PO number on
+// large invoices, and checks documents against them when they are saved.
+// The rules are kept by the tenant service; each service checks its own
+// documents.
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Documents rules can be set for
+const (
+	DocumentInvoice = "invoice"
+	DocumentBill    = "bill"
+	DocumentJournal = "journal"
+)
+
+// Checks a rule can make
+const (
+	CheckRequired = "required" // The field must be filled in
+)
+
+// Message keys of violations of rules that set none, for the UI to
+// translate. The field and amount are returned with the violation.
+const (
+	KeyRequired      = "validation_rules.required"
+	KeyRequiredAbove = "validation_rules.required_above"
+)
+
+// Field is a field of a document a rule can check. Line fields are named
+// after the document's list of lines, such as items.hsn_code, and are
+// checked on every line.
+type Field struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Line  bool   `json:"line"`
+}
+
+var catalog = map[string][]Field{
+	DocumentInvoice: {
+		{Name: "po_number", Label: "PO number"},
+		{Name: "customer_gstin", Label: "Customer GSTIN"},
+		{Name: "customer_email", Label: "Customer email"},
+		{Name: "customer_phone", Label: "Customer phone"},
+		{Name: "customer_address", Label: "Customer address"},
+		{Name: "notes", Label: "Notes"},
+		{Name: "terms", Label: "Terms"},
+		{Name: "items.hsn_code", Label: "HSN/SAC code", Line: true},
+		{Name: "items.unit", Label: "Unit", Line: true},
+	},
+	DocumentBill: {
+		{Name: "vendor_bill_no", Label: "Vendor's bill number"},
+		{Name: "vendor_gstin", Label: "Vendor GSTIN"},
+		{Name: "purchase_order_number", Label: "PO number"},
+		{Name: "notes", Label: "Notes"},
+		{Name: "items.hsn_code", Label: "HSN/SAC code", Line: true},
+		{Name: "items.expense_category", Label: "Expense category", Line: true},
+	},
+	DocumentJournal: {
+		{Name: "description", Label: "Narration"},
+		{Name: "notes", Label: "Notes"},
+		{Name: "party_name", Label: "Party"},
+		{Name: "lines.description", Label: "Line narration", Line: true},
+	},
+}
+
+// Documents returns the documents rules can be set for
+func Documents() []string {
+	documents := make([]string, 0, len(catalog))
+	for document := range catalog {
+		documents = append(documents, document)
+	}
+	sort.Strings(documents)
+	return documents
+}
+
+// Fields returns the fields of a document rules can check
+func Fields(document string) []Field {
+	return catalog[document]
+}
+
+// FieldOf returns the named field of a document
+func FieldOf(document, name string) (Field, bool) {
+	for _, field := range catalog[document] {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// Rule is a tenant's rule for a field of one kind of document. A rule with
+// a MinAmount applies only to documents of at least that total.
+type Rule struct {
+	ID         string  `json:"id"`
+	Document   string  `json:"document"`
+	Field      string  `json:"field"`
+	Check      string  `json:"check"`
+	MinAmount  float64 `json:"min_amount,omitempty"`
+	Message    string  `json:"message,omitempty"`     // Shown instead of the default
+	MessageKey string  `json:"message_key,omitempty"` // Returned instead of the default
+	Active     bool    `json:"active"`
+}
+
+// Validate checks that the rule names a known document, field and check
+func (r Rule) Validate() error {
+	if _, ok := catalog[r.Document]; !ok {
+		return fmt.Errorf("unknown document %q", r.Document)
+	}
+	if _, ok := FieldOf(r.Document, r.Field); !ok {
+		return fmt.Errorf("unknown field %q of %s", r.Field, r.Document)
+	}
+	if r.Check != CheckRequired {
+		return fmt.Errorf("unknown check %q", r.Check)
+	}
+	if r.MinAmount < 0 {
+		return fmt.Errorf("min_amount cannot be negative")
+	}
+	return nil
+}
+
+// Document is a document about to be saved, in the form rules check.
+// Fields and each line hold the values of the catalog's fields, unset ones
+// empty; line fields are keyed without the list's name.
+type Document struct {
+	Type   string
+	Amount float64 // Total compared with rules' MinAmount
+	Fields map[string]string
+	Lines  []map[string]string
+}
+
+// Violation is a rule a document breaks. Field is the path of the value
+// that broke it, such as items[2].hsn_code.
+type Violation struct {
+	RuleID    string  `json:"rule_id"`
+	Field     string  `json:"field"`
+	Key       string  `json:"key"`
+	Message   string  `json:"message"`
+	MinAmount float64 `json:"min_amount,omitempty"`
+}
+
+// Evaluate returns the violations of the active rules for the document's
+// type
+func Evaluate(rules []Rule, doc Document) []Violation {
+	var violations []Violation
+	for _, rule := range rules {
+		if !rule.Active || rule.Document != doc.Type || rule.Check != CheckRequired {
+			continue
+		}
+		if rule.MinAmount > 0 && doc.Amount < rule.MinAmount {
+			continue
+		}
+		field, ok := FieldOf(rule.Document, rule.Field)
+		if !ok {
+			continue
+		}
+
+		if !field.Line {
+			if strings.TrimSpace(doc.Fields[field.Name]) == "" {
+				violations = append(violations, rule.violation(field, field.Name))
+			}
+			continue
+		}
+		list, name, _ := strings.Cut(field.Name, ".")
+		for n, line := range doc.Lines {
+			if strings.TrimSpace(line[name]) == "" {
+				violations = append(violations, rule.violation(field, fmt.Sprintf("%s[%d].%s", list, n, name)))
+			}
+		}
+	}
+	return violations
+}
+
+func (r Rule) violation(field Field, path string) Violation {
+	violation := Violation{
+		RuleID:    r.ID,
+		Field:     path,
+		Key:       r.MessageKey,
+		Message:   r.Message,
+		MinAmount: r.MinAmount,
+	}
+	if violation.Key == "" {
+		violation.Key = KeyRequired
+		if r.MinAmount > 0 {
+			violation.Key = KeyRequiredAbove
+		}
+	}
+	if violation.Message == "" {
+		violation.Message = field.Label + " is required"
+		if field.Line {
+			violation.Message += " on every line"
+		}
+		if r.MinAmount > 0 {
+			violation.Message += fmt.Sprintf(" on %ss of %s or more", r.Document, strconv.FormatFloat(r.MinAmount, 'f', -1, 64))
+		}
+	}
+	return violation
+}
+
+// Error is returned when a document breaks the tenant's rules
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Field+": "+violation.Message)
+	}
+	return "document breaks validation rules: " + strings.Join(messages, "; ")
+}
+
+// Details returns the violations as error details: the message key under
+// each field's path, with its message, rule and amount beside it
+func (e *Error) Details() map[string]string {
+	details := make(map[string]string, len(e.Violations)*4)
+	for _, violation := range e.Violations {
+		details[violation.Field] = violation.Key
+		details[violation.Field+".message"] = violation.Message
+		details[violation.Field+".rule_id"] = violation.RuleID
+		if violation.MinAmount > 0 {
+			details[violation.Field+".min_amount"] = strconv.FormatFloat(violation.MinAmount, 'f', -1, 64)
+		}
+	}
+	return details
+}
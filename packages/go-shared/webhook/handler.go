@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// Handler serves the developer tools for integrators checking their
+// signature verification: signing and verifying test payloads, and
+// sample verification code. Use them with a test secret only.
+type Handler struct{}
+
+// NewHandler creates a new webhook tools handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SignRequest asks for the signature of a test payload
+type SignRequest struct {
+	Secret  string `json:"secret" binding:"required"`
+	Payload string `json:"payload"`
+	// Timestamp is the Unix signing time; defaults to now
+	Timestamp int64 `json:"timestamp"`
+}
+
+// VerifyRequest checks a signature the way a receiver should
+type VerifyRequest struct {
+	Secret    string `json:"secret" binding:"required"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature" binding:"required"`
+	// ToleranceSeconds overrides the default five minute tolerance; zero or
+	// a negative value skips the age check, e.g. for recorded payloads
+	ToleranceSeconds *int `json:"tolerance_seconds"`
+}
+
+// VerifyResult explains the outcome of a verification
+type VerifyResult struct {
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"`
+	// SignedPayload and ExpectedSignature show what the receiver should
+	// have computed, to debug mismatches
+	SignedPayload     string `json:"signed_payload,omitempty"`
+	ExpectedSignature string `json:"expected_signature,omitempty"`
+}
+
+// Sign returns the signature header for a test payload
+func (h *Handler) Sign(c *gin.Context) {
+	var req SignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	signedAt := time.Now()
+	if req.Timestamp > 0 {
+		signedAt = time.Unix(req.Timestamp, 0)
+	}
+
+	response.Success(c, gin.H{
+		"header":    SignatureHeader,
+		"signature": Sign(req.Secret, []byte(req.Payload), signedAt),
+	})
+}
+
+// Verify checks a signature against a payload and explains any failure
+func (h *Handler) Verify(c *gin.Context) {
+	var req VerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tolerance := DefaultTolerance
+	if req.ToleranceSeconds != nil {
+		tolerance = time.Duration(*req.ToleranceSeconds) * time.Second
+	}
+
+	payload := []byte(req.Payload)
+	result := VerifyResult{Valid: true}
+	if err := VerifyAt(req.Secret, payload, req.Signature, tolerance, time.Now()); err != nil {
+		result = VerifyResult{Reason: err.Error()}
+		if header, err := ParseHeader(req.Signature); err == nil {
+			result.SignedPayload = formatSigned(header.Timestamp, req.Payload)
+			result.ExpectedSignature = Compute(req.Secret, header.Timestamp, payload)
+		}
+	}
+
+	response.Success(c, result)
+}
+
+// ListSamples returns sample verification code in every language
+func (h *Handler) ListSamples(c *gin.Context) {
+	response.Success(c, Samples())
+}
+
+// GetSample returns the verification sample for a language as plain text
+func (h *Handler) GetSample(c *gin.Context) {
+	sample, ok := SampleFor(c.Param("language"))
+	if !ok {
+		response.NotFound(c, "No sample for this language")
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename="+sample.FileName)
+	c.String(http.StatusOK, sample.Code)
+}
+
+// formatSigned is the string whose HMAC is the signature
+func formatSigned(timestamp int64, payload string) string {
+	return strconv.FormatInt(timestamp, 10) + "." + payload
+}
@@ -0,0 +1,309 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Inbound event statuses. Failed events are the dead letters: payloads
+// that could not be processed, kept for an operator to replay.
+const (
+	InboundStatusProcessing = "processing"
+	InboundStatusProcessed  = "processed"
+	InboundStatusFailed     = "failed"
+)
+
+// MaxInboundPayload is the largest callback body accepted
+const MaxInboundPayload = 1 << 20
+
+// inboundLockTimeout is how long an event may stay processing before a
+// redelivery may take it over, its processing assumed lost
+const inboundLockTimeout = 10 * time.Minute
+
+var (
+	ErrUnknownSource        = errors.New("no webhook source registered with this name")
+	ErrInboundNotFound      = errors.New("inbound event not found")
+	ErrNotReplayable        = errors.New("only failed events can be replayed")
+	ErrNoInboundProcessor   = errors.New("no processor registered for this source")
+	ErrInboundPayloadTooBig = errors.New("payload is too large")
+)
+
+// InboundEvent is a callback received from a provider. Events are unique
+// per source and provider event ID, so redeliveries are processed once.
+type InboundEvent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Source    string    `gorm:"size:50;not null;uniqueIndex:idx_webhook_inbound_event" json:"source"`
+	EventID   string    `gorm:"size:255;not null;uniqueIndex:idx_webhook_inbound_event" json:"event_id"`
+	EventType string    `gorm:"size:100" json:"event_type,omitempty"`
+	Payload   string    `gorm:"type:text;not null" json:"payload"`
+
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	Deliveries  int        `gorm:"default:1" json:"deliveries"` // Times the provider sent it
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+
+	ReplayedAt *time.Time `json:"replayed_at,omitempty"`
+	ReplayedBy string     `gorm:"size:100" json:"replayed_by,omitempty"`
+
+	ReceivedAt time.Time `gorm:"not null;index" json:"received_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for InboundEvent
+func (InboundEvent) TableName() string {
+	return "webhook_inbound_events"
+}
+
+// BeforeCreate hook
+func (e *InboundEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Processor acts on a verified event. A returned error dead-letters it.
+type Processor func(ctx context.Context, event *InboundEvent) error
+
+// SourceOptions configures a webhook source
+type SourceOptions struct {
+	// Tolerance is how far the signing time may be from now, DefaultTolerance
+	// when zero. Redeliveries of an event already received are recognised
+	// by its ID whatever their age.
+	Tolerance time.Duration
+}
+
+type source struct {
+	provider  Provider
+	processor Processor
+	opts      SourceOptions
+}
+
+// Receiver accepts callbacks from payment gateways, e-invoice portals and
+// bank partners. Each is verified by its source's provider, stored, and
+// processed once however often it is delivered.
+type Receiver struct {
+	db      *gorm.DB
+	sources map[string]source
+}
+
+// NewReceiver creates a webhook receiver storing events in db
+func NewReceiver(db *gorm.DB) *Receiver {
+	return &Receiver{db: db, sources: make(map[string]source)}
+}
+
+// Register adds a source of callbacks. Events of a source without a
+// processor are stored as failed, to be replayed once one is registered.
+func (r *Receiver) Register(name string, provider Provider, processor Processor, opts SourceOptions) {
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+	r.sources[name] = source{provider: provider, processor: processor, opts: opts}
+}
+
+// Receipt is the outcome of receiving a callback
+type Receipt struct {
+	Event *InboundEvent
+	// Duplicate is set when the event was already processed, or is being
+	// processed, and the delivery was ignored
+	Duplicate bool
+}
+
+// Receive verifies, stores and processes a callback. Verification errors
+// are returned and nothing is stored; a payload that cannot be identified
+// or processed is dead-lettered, keyed by its hash if it has no ID.
+func (r *Receiver) Receive(ctx context.Context, name string, header http.Header, body []byte) (*Receipt, error) {
+	src, ok := r.sources[name]
+	if !ok {
+		return nil, ErrUnknownSource
+	}
+	if len(body) > MaxInboundPayload {
+		return nil, ErrInboundPayloadTooBig
+	}
+	if err := src.provider.Verify(header, body, src.opts.Tolerance, time.Now()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	event := &InboundEvent{
+		Source:     name,
+		Payload:    string(body),
+		Status:     InboundStatusProcessing,
+		ReceivedAt: now,
+		UpdatedAt:  now,
+	}
+	eventID, eventType, identifyErr := src.provider.Identify(header, body)
+	if identifyErr != nil {
+		sum := sha256.Sum256(body)
+		eventID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	event.EventID = eventID
+	event.EventType = eventType
+
+	claimed, err := r.claim(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return &Receipt{Event: event, Duplicate: true}, nil
+	}
+
+	if identifyErr != nil {
+		return &Receipt{Event: event}, r.finish(ctx, event, fmt.Errorf("unidentifiable payload: %w", identifyErr))
+	}
+	return &Receipt{Event: event}, r.process(ctx, src, event)
+}
+
+// claim stores a new event, or takes over a redelivered one that failed or
+// whose processing was lost. It reports false for an event processed or
+// being processed, loading it into event.
+func (r *Receiver) claim(ctx context.Context, event *InboundEvent) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	result = r.db.WithContext(ctx).Model(&InboundEvent{}).
+		Where("source = ? AND event_id = ?", event.Source, event.EventID).
+		Where("status = ? OR (status = ? AND updated_at < ?)",
+			InboundStatusFailed, InboundStatusProcessing, time.Now().Add(-inboundLockTimeout)).
+		Updates(map[string]interface{}{
+			"status":     InboundStatusProcessing,
+			"deliveries": gorm.Expr("deliveries + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		err := r.db.WithContext(ctx).
+			Model(&InboundEvent{}).
+			Where("source = ? AND event_id = ?", event.Source, event.EventID).
+			Update("deliveries", gorm.Expr("deliveries + 1")).Error
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var stored InboundEvent
+	err := r.db.WithContext(ctx).First(&stored, "source = ? AND event_id = ?", event.Source, event.EventID).Error
+	if err != nil {
+		return false, err
+	}
+	*event = stored
+	return result.RowsAffected == 1, nil
+}
+
+func (r *Receiver) process(ctx context.Context, src source, event *InboundEvent) error {
+	err := ErrNoInboundProcessor
+	if src.processor != nil {
+		err = src.processor(ctx, event)
+	}
+	return r.finish(ctx, event, err)
+}
+
+// finish records the outcome of processing. A processing error is not
+// returned: the event is dead-lettered instead.
+func (r *Receiver) finish(ctx context.Context, event *InboundEvent, processErr error) error {
+	now := time.Now()
+	event.Attempts++
+	event.UpdatedAt = now
+	if processErr != nil {
+		log.Printf("webhook: %s event %s dead-lettered: %v", event.Source, event.EventID, processErr)
+		event.Status = InboundStatusFailed
+		event.LastError = processErr.Error()
+	} else {
+		event.Status = InboundStatusProcessed
+		event.LastError = ""
+		event.ProcessedAt = &now
+	}
+
+	return r.db.WithContext(ctx).Model(event).
+		Select("status", "attempts", "last_error", "processed_at", "replayed_at", "replayed_by", "updated_at").
+		Updates(event).Error
+}
+
+// Replay processes a dead-lettered event again from its stored payload,
+// without re-verifying it
+func (r *Receiver) Replay(ctx context.Context, id uuid.UUID, replayedBy string) (*InboundEvent, error) {
+	event, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	src, ok := r.sources[event.Source]
+	if !ok {
+		return nil, ErrUnknownSource
+	}
+
+	result := r.db.WithContext(ctx).Model(&InboundEvent{}).
+		Where("id = ? AND status = ?", id, InboundStatusFailed).
+		Updates(map[string]interface{}{"status": InboundStatusProcessing, "updated_at": time.Now()})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotReplayable
+	}
+
+	now := time.Now()
+	event.ReplayedAt = &now
+	event.ReplayedBy = replayedBy
+	if err := r.process(ctx, src, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Get returns an inbound event
+func (r *Receiver) Get(ctx context.Context, id uuid.UUID) (*InboundEvent, error) {
+	var event InboundEvent
+	err := r.db.WithContext(ctx).First(&event, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInboundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// InboundFilter narrows a listing of inbound events
+type InboundFilter struct {
+	Source string
+	Status string
+	Limit  int
+}
+
+// List returns the latest inbound events matching the filter
+func (r *Receiver) List(ctx context.Context, filter InboundFilter) ([]InboundEvent, error) {
+	query := r.db.WithContext(ctx).Model(&InboundEvent{})
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	events := []InboundEvent{}
+	err := query.Order("received_at DESC").Limit(filter.Limit).Find(&events).Error
+	return events, err
+}
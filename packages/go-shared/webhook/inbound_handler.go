@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// InboundHandler serves the public callback endpoint of a receiver and the
+// admin endpoints for its dead letters
+type InboundHandler struct {
+	receiver *Receiver
+}
+
+// NewInboundHandler creates a new inbound webhook handler
+func NewInboundHandler(receiver *Receiver) *InboundHandler {
+	return &InboundHandler{receiver: receiver}
+}
+
+// Receive accepts a callback for the :source in the path. Providers get a
+// 2xx once the event is stored, even if it was dead-lettered, so they stop
+// retrying it; a failed verification gets a 401.
+func (h *InboundHandler) Receive(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxInboundPayload+1))
+	if err != nil {
+		response.BadRequest(c, "Could not read payload", nil)
+		return
+	}
+
+	receipt, err := h.receiver.Receive(c.Request.Context(), c.Param("source"), c.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownSource):
+			response.NotFound(c, "Unknown webhook source")
+		case errors.Is(err, ErrInboundPayloadTooBig):
+			response.BadRequest(c, err.Error(), nil)
+		case errors.Is(err, ErrMissingSignature), errors.Is(err, ErrMalformedSignature),
+			errors.Is(err, ErrTimestampExpired), errors.Is(err, ErrSignatureMismatch):
+			response.Unauthorized(c, err.Error())
+		default:
+			response.InternalError(c, "Failed to receive webhook")
+		}
+		return
+	}
+
+	result := gin.H{
+		"id":        receipt.Event.ID,
+		"status":    receipt.Event.Status,
+		"duplicate": receipt.Duplicate,
+	}
+	if receipt.Event.Status == InboundStatusFailed {
+		response.Accepted(c, result)
+		return
+	}
+	response.Success(c, result)
+}
+
+// List returns recent inbound events, filtered by source and status
+func (h *InboundHandler) List(c *gin.Context) {
+	filter := InboundFilter{
+		Source: c.Query("source"),
+		Status: c.Query("status"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	events, err := h.receiver.List(c.Request.Context(), filter)
+	if err != nil {
+		response.InternalError(c, "Failed to list inbound webhooks")
+		return
+	}
+
+	response.Success(c, events)
+}
+
+// Get returns an inbound event with its payload
+func (h *InboundHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid event ID", nil)
+		return
+	}
+
+	event, err := h.receiver.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrInboundNotFound) {
+			response.NotFound(c, "Inbound webhook not found")
+		} else {
+			response.InternalError(c, "Failed to get inbound webhook")
+		}
+		return
+	}
+
+	response.Success(c, event)
+}
+
+// Replay processes a dead-lettered event again
+func (h *InboundHandler) Replay(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid event ID", nil)
+		return
+	}
+
+	event, err := h.receiver.Replay(c.Request.Context(), id, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInboundNotFound):
+			response.NotFound(c, "Inbound webhook not found")
+		case errors.Is(err, ErrNotReplayable):
+			response.Conflict(c, "Only failed events can be replayed")
+		case errors.Is(err, ErrUnknownSource):
+			response.Conflict(c, "The event's source is no longer registered")
+		default:
+			response.InternalError(c, "Failed to replay inbound webhook")
+		}
+		return
+	}
+
+	response.Success(c, event)
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrUnidentifiedEvent is returned when a callback carries no event ID
+var ErrUnidentifiedEvent = errors.New("event has no identifier")
+
+// Provider verifies and identifies the callbacks of one integration
+type Provider interface {
+	// Verify checks the request was signed by the provider within
+	// tolerance of now
+	Verify(header http.Header, body []byte, tolerance time.Duration, now time.Time) error
+	// Identify returns the provider's ID of the event, which it keeps when
+	// redelivering it, and the event's type
+	Identify(header http.Header, body []byte) (id, eventType string, err error)
+}
+
+// Signed verifies callbacks signed the way we sign our own webhooks, with
+// the signature in header. It suits partners that adopted our scheme, such
+// as e-invoice GSPs and bank feeds. Events are identified by the X-Event-Id
+// header, else the payload's "id", and typed by its "type" or "event".
+func Signed(header string, secrets ...string) Provider {
+	return &signedProvider{header: header, secrets: secrets}
+}
+
+// Stripe verifies Stripe webhooks, whose Stripe-Signature header follows
+// the same t=,v1= scheme as ours
+func Stripe(secrets ...string) Provider {
+	return &signedProvider{header: "Stripe-Signature", secrets: secrets}
+}
+
+type signedProvider struct {
+	header  string
+	secrets []string
+}
+
+func (p *signedProvider) Verify(header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	signature := header.Get(p.header)
+	err := ErrSignatureMismatch
+	for _, secret := range p.secrets {
+		err = VerifyAt(secret, body, signature, tolerance, now)
+		if !errors.Is(err, ErrSignatureMismatch) {
+			return err
+		}
+	}
+	return err
+}
+
+func (p *signedProvider) Identify(header http.Header, body []byte) (string, string, error) {
+	var payload struct {
+		ID    string `json:"id"`
+		Type  string `json:"type"`
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", "", err
+	}
+
+	id := header.Get("X-Event-Id")
+	if id == "" {
+		id = payload.ID
+	}
+	if id == "" {
+		return "", "", ErrUnidentifiedEvent
+	}
+	eventType := payload.Type
+	if eventType == "" {
+		eventType = payload.Event
+	}
+	return id, eventType, nil
+}
+
+// Razorpay verifies Razorpay webhooks. Razorpay signs only the body, as
+// the hex HMAC-SHA256 in X-Razorpay-Signature, so the age of an event is
+// taken from the payload's created_at.
+func Razorpay(secrets ...string) Provider {
+	return &razorpayProvider{secrets: secrets}
+}
+
+type razorpayProvider struct {
+	secrets []string
+}
+
+func (p *razorpayProvider) Verify(header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	signature := header.Get("X-Razorpay-Signature")
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	var matched bool
+	for _, secret := range p.secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		matched = matched || hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature))
+	}
+	if !matched {
+		return ErrSignatureMismatch
+	}
+
+	if tolerance > 0 {
+		var payload struct {
+			CreatedAt int64 `json:"created_at"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil || payload.CreatedAt == 0 {
+			return ErrMalformedSignature
+		}
+		age := now.Sub(time.Unix(payload.CreatedAt, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampExpired
+		}
+	}
+	return nil
+}
+
+func (p *razorpayProvider) Identify(header http.Header, body []byte) (string, string, error) {
+	var payload struct {
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", "", err
+	}
+
+	id := header.Get("X-Razorpay-Event-Id")
+	if id == "" {
+		return "", "", ErrUnidentifiedEvent
+	}
+	return id, payload.Event, nil
+}
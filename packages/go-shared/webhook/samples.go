@@ -0,0 +1,149 @@
+package webhook
+
+import "sort"
+
+// Sample is example code for verifying our webhook signatures
+type Sample struct {
+	Language string `json:"language"`
+	FileName string `json:"file_name"`
+	Code     string `json:"code"`
+}
+
+var samples = map[string]Sample{
+	"go": {
+		Language: "go",
+		FileName: "webhook.go",
+		Code: `package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tolerance = 5 * time.Minute
+
+// verify checks the X-Bookkeep-Signature header against the raw body
+func verify(secret string, body []byte, header string) bool {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || math.Abs(time.Since(time.Unix(t, 0)).Seconds()) > tolerance.Seconds() {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := []byte(hex.EncodeToString(mac.Sum(nil)))
+
+	for _, signature := range signatures {
+		if hmac.Equal(expected, []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	secret := os.Getenv("BOOKKEEP_WEBHOOK_SECRET")
+
+	http.HandleFunc("/webhooks/bookkeep", func(w http.ResponseWriter, r *http.Request) {
+		// Verify the raw body; re-encoding parsed JSON changes the bytes
+		body, err := io.ReadAll(r.Body)
+		if err != nil || !verify(secret, body, r.Header.Get("X-Bookkeep-Signature")) {
+			http.Error(w, "invalid signature", http.StatusBadRequest)
+			return
+		}
+		// Handle the event in body
+		w.WriteHeader(http.StatusOK)
+	})
+	http.ListenAndServe(":8080", nil)
+}
+`,
+	},
+	"javascript": {
+		Language: "javascript",
+		FileName: "webhook.js",
+		Code: `const crypto = require("crypto");
+const express = require("express");
+
+const TOLERANCE_SECONDS = 5 * 60;
+
+// verify checks the X-Bookkeep-Signature header against the raw body
+function verify(secret, rawBody, header) {
+  let timestamp;
+  const signatures = [];
+  for (const part of (header || "").split(",")) {
+    const [key, value] = part.trim().split("=");
+    if (key === "t") timestamp = value;
+    if (key === "v1") signatures.push(value);
+  }
+
+  const t = Number.parseInt(timestamp, 10);
+  if (!Number.isFinite(t) || Math.abs(Date.now() / 1000 - t) > TOLERANCE_SECONDS) {
+    return false;
+  }
+
+  const expected = crypto
+    .createHmac("sha256", secret)
+    .update(timestamp + ".")
+    .update(rawBody)
+    .digest("hex");
+
+  return signatures.some(
+    (signature) =>
+      signature.length === expected.length &&
+      crypto.timingSafeEqual(Buffer.from(signature), Buffer.from(expected))
+  );
+}
+
+const app = express();
+
+// Verify the raw body; re-encoding parsed JSON changes the bytes
+app.post("/webhooks/bookkeep", express.raw({ type: "application/json" }), (req, res) => {
+  if (!verify(process.env.BOOKKEEP_WEBHOOK_SECRET, req.body, req.get("X-Bookkeep-Signature"))) {
+    return res.status(400).send("invalid signature");
+  }
+  const event = JSON.parse(req.body);
+  // Handle event
+  res.sendStatus(200);
+});
+
+app.listen(8080);
+`,
+	},
+}
+
+// Samples returns the verification samples, ordered by language
+func Samples() []Sample {
+	list := make([]Sample, 0, len(samples))
+	for _, sample := range samples {
+		list = append(list, sample)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Language < list[j].Language })
+	return list
+}
+
+// SampleFor returns the verification sample for a language
+func SampleFor(language string) (Sample, bool) {
+	sample, ok := samples[language]
+	return sample, ok
+}
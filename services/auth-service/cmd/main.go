@@ -10,13 +10,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/config"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/handlers"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/services"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/email"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/features"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/messaging"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/status"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/webhook"
 )
 
 func main() {
@@ -41,6 +49,15 @@ func main() {
 		MaxIdleConns:    cfg.Database.MaxIdleConns,
 		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
 		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -54,6 +71,19 @@ func main() {
 		&models.Session{},
 		&models.Role{},
 		&models.Permission{},
+		&models.PasswordPolicy{},
+		&models.PasswordHistory{},
+		&models.AuditorGrant{},
+		&models.AuditorAccessLog{},
+		&features.Flag{},
+		&features.Override{},
+		&status.Incident{},
+		&status.UptimeDay{},
+		&jobs.Job{},
+		&email.Delivery{},
+		&email.DeliveryAttachment{},
+		&messaging.Delivery{},
+		&messaging.OptOut{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -62,43 +92,74 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	sessionRepo := repository.NewSessionRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
+	passwordRepo := repository.NewPasswordRepository(db)
+	auditorRepo := repository.NewAuditorRepository(db)
+
+	// Initialize clients
+	var breachChecker clients.BreachChecker
+	if cfg.HIBPBaseURL != "" {
+		breachChecker = clients.NewHIBPClient(cfg.HIBPBaseURL)
+	}
+	tenantClient := clients.NewTenantClient(cfg.Network.TenantServiceURL)
+
+	// Password reset and verification emails and login OTPs are sent from
+	// a background job queue, so a provider outage delays them instead of
+	// failing the request
+	jobQueue := jobs.NewQueue(db, jobs.Config{})
+	emailDriver, err := email.NewDriver(cfg.Email, cfg.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to set up email: %v", err)
+	}
+	mailer := email.NewMailer(db, jobQueue, emailDriver, cfg.Email)
+	messagingDrivers, err := messaging.NewDrivers(cfg.Messaging)
+	if err != nil {
+		log.Fatalf("Failed to set up messaging: %v", err)
+	}
+	messenger := messaging.NewMessenger(db, jobQueue, messagingDrivers, cfg.Messaging)
+	messagingPreferences := messaging.NewPreferencesClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+	jobQueue.Start(context.Background())
 
 	// Initialize services
-	authService := services.NewAuthService(cfg, userRepo, sessionRepo, roleRepo)
+	passwordService := services.NewPasswordService(passwordRepo, breachChecker)
+	authService := services.NewAuthService(cfg, userRepo, sessionRepo, roleRepo, passwordService, mailer, messenger, messagingPreferences)
 	mfaService := services.NewMFAService(userRepo)
+	auditorService := services.NewAuditorService(cfg, auditorRepo, tenantClient)
+	featureStore := features.NewStore(db, features.Config{})
+	statusStore := status.NewStore(db)
+	statusMonitor := status.NewMonitor(statusStore, cfg.StatusTargets, status.Config{})
+	statusMonitor.Start(context.Background())
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	mfaHandler := handlers.NewMFAHandler(mfaService, authService)
+	featureHandler := features.NewHandler(featureStore)
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(tenantClient, featureStore)
+	statusHandler := status.NewHandler(statusMonitor, statusStore)
+	webhookHandler := webhook.NewHandler()
+	passwordPolicyHandler := handlers.NewPasswordPolicyHandler(passwordService)
+	auditorHandler := handlers.NewAuditorHandler(auditorService)
 	healthHandler := handlers.NewHealthHandler(db)
 
 	// Setup router
 	router := gin.New()
 
-	// Allowed CORS origins
-	allowedOrigins := []string{
-		"https://app.bookkeep.in",
-		"https://www.bookkeep.in",
-		"https://bookkeep.in",
-	}
-	// Add development origins in non-production mode
-	if !cfg.IsProduction() {
-		allowedOrigins = append(allowedOrigins,
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"exp://localhost:19000",
-		)
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(router, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// Apply middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.CORSMiddleware(allowedOrigins))
+	router.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
+	router.Use(i18n.Middleware(i18n.DefaultLanguage))
 
 	// Health endpoints (no auth required)
 	router.GET("/health", healthHandler.Health)
 	router.GET("/ready", healthHandler.Ready)
+	router.GET("/metrics", gin.WrapH(database.MetricsHandler(db)))
 
 	// Initialize rate limiters
 	authRateLimiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
@@ -140,11 +201,32 @@ func main() {
 		mfa.POST("/verify-backup", mfaHandler.VerifyBackupCode)
 	}
 
+	// Platform status (public)
+	router.GET("/api/v1/status", statusHandler.Status)
+	router.GET("/api/v1/status/incidents", statusHandler.ListIncidents)
+
+	// Webhook signature tools for integrators (public, test secrets only)
+	developer := router.Group("/api/v1/developer/webhooks")
+	developer.Use(authRateLimiter.Middleware())
+	{
+		developer.POST("/sign", webhookHandler.Sign)
+		developer.POST("/verify", webhookHandler.Verify)
+		developer.GET("/samples", webhookHandler.ListSamples)
+		developer.GET("/samples/:language", webhookHandler.GetSample)
+	}
+
+	// Tenants' IP and country restrictions, read from the tenant service
+	networkPolicies := middleware.NewNetworkPolicyClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+
 	// Protected auth endpoints
 	jwtConfig := middleware.JWTConfig{
-		Secret:    cfg.JWT.Secret,
-		Issuer:    cfg.JWT.Issuer,
-		SkipPaths: []string{"/health", "/ready", "/api/v1/auth"},
+		Secret:          cfg.JWT.Secret,
+		Secrets:         cfg.JWTSecrets,
+		RefreshSecrets:  cfg.RefreshJWTSecrets,
+		Issuer:          cfg.JWT.Issuer,
+		SkipPaths:       []string{"/health", "/ready", "/metrics", "/api/v1/auth"},
+		NetworkPolicies: networkPolicies,
+		CountryHeader:   cfg.Network.CountryHeader,
 	}
 
 	protected := router.Group("/api/v1")
@@ -153,7 +235,32 @@ func main() {
 		protected.GET("/me", authHandler.GetCurrentUser)
 		protected.PUT("/me", authHandler.UpdateProfile)
 		protected.POST("/logout", authHandler.Logout)
+		protected.GET("/me/sessions", authHandler.ListSessions)
+		protected.DELETE("/me/sessions", authHandler.RevokeAllSessions)
+		protected.DELETE("/me/sessions/:id", authHandler.RevokeSession)
 		protected.POST("/change-password", authHandler.ChangePassword)
+		protected.POST("/step-up", authRateLimiter.Middleware(), authHandler.StepUp)
+		protected.GET("/features", featureHandler.Enabled)
+		protected.GET("/capabilities", capabilitiesHandler.Get)
+
+		// Auditor access, granted by the tenant owner
+		auditorGrants := protected.Group("/auditor-grants")
+		auditorGrants.Use(middleware.RequireRole("owner"))
+		{
+			auditorGrants.GET("", auditorHandler.ListGrants)
+			auditorGrants.POST("", auditorHandler.CreateGrant)
+			auditorGrants.POST("/:id/revoke", auditorHandler.RevokeGrant)
+			auditorGrants.GET("/:id/access-log", auditorHandler.ListAccess)
+		}
+
+		// Checks of auditor tokens by the other services, made with the
+		// auditor's token, and the log of their requests, which only
+		// services may write
+		auditorAccess := protected.Group("/auditor-access")
+		{
+			auditorAccess.GET("/grant", auditorHandler.GrantStatus)
+			auditorAccess.POST("/log", middleware.RequireService(), auditorHandler.RecordAccess)
+		}
 
 		// MFA management (requires authentication)
 		mfaGroup := protected.Group("/mfa")
@@ -175,15 +282,31 @@ func main() {
 		admin.GET("/users/:id", authHandler.GetUser)
 		admin.PUT("/users/:id/roles", authHandler.UpdateUserRoles)
 		admin.DELETE("/users/:id", authHandler.DeleteUser)
+
+		// Password policy of the admin's tenant
+		admin.GET("/password-policy", passwordPolicyHandler.GetPolicy)
+		admin.PUT("/password-policy", passwordPolicyHandler.UpdatePolicy)
+
+		// Feature flags (super admins only)
+		admin.GET("/feature-flags", featureHandler.ListFlags)
+		admin.GET("/feature-flags/:key", featureHandler.GetFlag)
+		admin.PUT("/feature-flags/:key", featureHandler.SaveFlag)
+		admin.DELETE("/feature-flags/:key", featureHandler.DeleteFlag)
+		admin.PUT("/feature-flags/:key/overrides/:tenant_id", featureHandler.SetOverride)
+		admin.DELETE("/feature-flags/:key/overrides/:tenant_id", featureHandler.RemoveOverride)
+
+		// Status page incidents (super admins only)
+		admin.POST("/status/incidents", statusHandler.CreateIncident)
+		admin.PUT("/status/incidents/:id", statusHandler.UpdateIncident)
 	}
 
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         cfg.GetServerAddress(),
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Start server in goroutine
@@ -202,13 +325,18 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	statusMonitor.Stop()
+
+	// Let emails being sent finish
+	jobQueue.Stop()
+
 	// Close database connection
 	if err := database.Close(db); err != nil {
 		log.Printf("Error closing database: %v", err)
@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHIBPBaseURL is the Have I Been Pwned Pwned Passwords range API
+const DefaultHIBPBaseURL = "https://api.pwnedpasswords.com"
+
+// ErrBreachCheckUnavailable is returned when the breach check cannot answer;
+// the password is neither confirmed nor cleared.
+var ErrBreachCheckUnavailable = errors.New("password breach check unavailable")
+
+// BreachChecker checks passwords against known data breaches
+type BreachChecker interface {
+	// Breached returns how many times the password appears in known
+	// breaches, zero when it does not
+	Breached(ctx context.Context, password string) (int, error)
+}
+
+type hibpClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHIBPClient creates a breach checker backed by Pwned Passwords. Only the
+// first five characters of the password's SHA-1 hash leave the service
+// (k-anonymity); the match is made locally against the returned suffixes.
+func NewHIBPClient(baseURL string) BreachChecker {
+	if baseURL == "" {
+		baseURL = DefaultHIBPBaseURL
+	}
+	return &hibpClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *hibpClient) Breached(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	// Padding hides the real number of suffixes from anyone on the wire
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "bookkeep-auth-service")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBreachCheckUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: status %d", ErrBreachCheckUnavailable, resp.StatusCode)
+	}
+
+	// Each line is SUFFIX:COUNT; padding lines have a count of zero
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, ok := strings.Cut(line, ":")
+		if !ok || candidate != suffix {
+			continue
+		}
+		var n int
+		if _, err := fmt.Sscanf(count, "%d", &n); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrBreachCheckUnavailable, err)
+		}
+		return n, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBreachCheckUnavailable, err)
+	}
+
+	return 0, nil
+}
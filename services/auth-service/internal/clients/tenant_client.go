@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrTenantNotFound is returned when the tenant service has no such tenant,
+// or the caller cannot see it
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// Tenant is the part of a tenant the auth service reads
+type Tenant struct {
+	ID                  string `json:"id"`
+	Plan                string `json:"plan"`
+	Country             string `json:"country"`
+	MaxUsers            int    `json:"max_users"`
+	MaxInvoicesPerMonth int    `json:"max_invoices_per_month"`
+	FinancialYearStart  int    `json:"financial_year_start"` // Month, 1-12
+}
+
+// TenantClient reads tenants from the tenant service
+type TenantClient interface {
+	// GetTenant reads the tenant on behalf of the caller whose
+	// Authorization header is given
+	GetTenant(ctx context.Context, tenantID, authorization string) (*Tenant, error)
+}
+
+type tenantClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTenantClient creates a client for the tenant service at baseURL
+func NewTenantClient(baseURL string) TenantClient {
+	return &tenantClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *tenantClient) GetTenant(ctx context.Context, tenantID, authorization string) (*Tenant, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/tenants/"+tenantID, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		return nil, ErrTenantNotFound
+	default:
+		return nil, fmt.Errorf("tenant service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data *Tenant `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Data == nil {
+		return nil, ErrTenantNotFound
+	}
+	return body.Data, nil
+}
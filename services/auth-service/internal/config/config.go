@@ -1,35 +1,50 @@
 package config
 
 import (
-	"time"
-
 	sharedConfig "github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/status"
 )
 
+// defaultStatusTargets are the services on the status page as deployed by
+// docker compose. The tenant service has no readiness endpoint yet.
+const defaultStatusTargets = "auth=http://auth-service:8081/ready," +
+	"tenants=http://tenant-service:8083/health," +
+	"customers=http://customer-service:8082/ready," +
+	"bookkeeping=http://bookkeeping-service:8084/ready," +
+	"invoices=http://invoice-service:8085/ready," +
+	"reports=http://report-service:8086/ready"
+
 // Config holds auth service configuration
 type Config struct {
 	*sharedConfig.Config
+
+	// StatusTargets are the services probed for the status page
+	StatusTargets []status.Target
+
+	// HIBPBaseURL is the Pwned Passwords API new passwords are checked
+	// against; empty disables the breach check
+	HIBPBaseURL string
 }
 
 // Load loads auth service configuration
 func Load() (*Config, error) {
-	cfg, err := sharedConfig.Load("auth-service")
+	cfg, err := sharedConfig.LoadWithDefaults("auth-service", sharedConfig.Defaults{DatabaseName: "bookkeep_auth"})
 	if err != nil {
 		return nil, err
 	}
 
-	// Override default database name
-	if cfg.Database.DBName == "auth-service_db" {
-		cfg.Database.DBName = "bookkeep_auth"
+	env := sharedConfig.NewEnv("auth-service")
+	statusTargets, err := status.ParseTargets(env.String("STATUS_TARGETS", defaultStatusTargets))
+	if err != nil {
+		env.Fail("STATUS_TARGETS: %v", err)
 	}
-
-	// Set auth-specific defaults
-	if cfg.JWT.AccessTokenTTL == 0 {
-		cfg.JWT.AccessTokenTTL = 15 * time.Minute
+	authCfg := &Config{
+		Config:        cfg,
+		StatusTargets: statusTargets,
+		HIBPBaseURL:   env.String("HIBP_BASE_URL", "https://api.pwnedpasswords.com"),
 	}
-	if cfg.JWT.RefreshTokenTTL == 0 {
-		cfg.JWT.RefreshTokenTTL = 7 * 24 * time.Hour
+	if err := env.Err(); err != nil {
+		return nil, err
 	}
-
-	return &Config{Config: cfg}, nil
+	return authCfg, nil
 }
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// AuditorHandler serves auditor access: the owner's endpoints for granting
+// and revoking it and reviewing the access log, and the endpoints other
+// services check auditor tokens and log their requests with
+type AuditorHandler struct {
+	auditorService services.AuditorService
+}
+
+// NewAuditorHandler creates a new auditor handler
+func NewAuditorHandler(auditorService services.AuditorService) *AuditorHandler {
+	return &AuditorHandler{auditorService: auditorService}
+}
+
+// CreateGrant gives an auditor read-only access to a financial year and
+// returns their token
+func (h *AuditorHandler) CreateGrant(c *gin.Context) {
+	tenantID, userID, ok := h.caller(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateAuditorGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid auditor grant", map[string]string{"error": err.Error()})
+		return
+	}
+
+	grant, err := h.auditorService.CreateGrant(c.Request.Context(), c.GetHeader("Authorization"), tenantID, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidFinancialYear):
+			response.ValidationError(c, "Invalid auditor grant", map[string]string{"financial_year": err.Error()})
+		case errors.Is(err, clients.ErrTenantNotFound):
+			response.NotFound(c, "Tenant not found")
+		default:
+			response.InternalError(c, "Failed to create auditor grant")
+		}
+		return
+	}
+
+	response.Created(c, grant)
+}
+
+// ListGrants lists the tenant's auditor grants
+func (h *AuditorHandler) ListGrants(c *gin.Context) {
+	tenantID, _, ok := h.caller(c)
+	if !ok {
+		return
+	}
+
+	grants, err := h.auditorService.ListGrants(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list auditor grants")
+		return
+	}
+
+	response.Success(c, grants)
+}
+
+// RevokeGrant ends an auditor's access before it expires
+func (h *AuditorHandler) RevokeGrant(c *gin.Context) {
+	tenantID, userID, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	grantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid auditor grant ID", nil)
+		return
+	}
+
+	grant, err := h.auditorService.RevokeGrant(c.Request.Context(), tenantID, grantID, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to revoke auditor grant")
+		return
+	}
+
+	response.Success(c, grant)
+}
+
+// ListAccess returns what an auditor requested under a grant, latest first
+func (h *AuditorHandler) ListAccess(c *gin.Context) {
+	tenantID, _, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	grantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid auditor grant ID", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	entries, total, err := h.auditorService.ListAccess(c.Request.Context(), tenantID, grantID, page, perPage)
+	if err != nil {
+		h.handleError(c, err, "Failed to list auditor access")
+		return
+	}
+
+	response.Paginated(c, entries, page, perPage, total)
+}
+
+// GrantStatus tells a service whether the calling auditor's grant is still
+// active
+func (h *AuditorHandler) GrantStatus(c *gin.Context) {
+	grantID, ok := h.auditorGrantID(c)
+	if !ok {
+		return
+	}
+
+	grant, err := h.auditorService.GetGrant(c.Request.Context(), grantID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get auditor grant")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"active":     grant.IsActive(),
+		"expires_at": grant.ExpiresAt,
+	})
+}
+
+// RecordAccess adds a request another service served an auditor, or
+// refused them, to the grant's access log. Only services may call it, with
+// a token for the grant's tenant, so auditors cannot write their own log.
+func (h *AuditorHandler) RecordAccess(c *gin.Context) {
+	var access middleware.AuditorAccess
+	if err := c.ShouldBindJSON(&access); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	grantID, err := uuid.Parse(access.GrantID)
+	if err != nil {
+		response.BadRequest(c, "Invalid grant ID", nil)
+		return
+	}
+
+	grant, err := h.auditorService.GetGrant(c.Request.Context(), grantID)
+	if err != nil {
+		h.handleError(c, err, "Failed to record auditor access")
+		return
+	}
+	if access.TenantID != c.GetString("tenant_id") || grant.TenantID.String() != access.TenantID {
+		response.Forbidden(c, "access must be recorded under a grant of the caller's tenant")
+		return
+	}
+
+	if err := h.auditorService.RecordAccess(c.Request.Context(), access); err != nil {
+		h.handleError(c, err, "Failed to record auditor access")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// caller returns the signed-in owner's tenant and user
+func (h *AuditorHandler) caller(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, userID, true
+}
+
+// auditorGrantID returns the grant of the calling auditor token
+func (h *AuditorHandler) auditorGrantID(c *gin.Context) (uuid.UUID, bool) {
+	if !middleware.IsAuditor(c) {
+		response.Forbidden(c, "auditor token required")
+		return uuid.Nil, false
+	}
+	grantID, err := uuid.Parse(c.GetString("auditor_grant_id"))
+	if err != nil {
+		response.Unauthorized(c, "Invalid auditor token")
+		return uuid.Nil, false
+	}
+	return grantID, true
+}
+
+func (h *AuditorHandler) handleError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrAuditorGrantNotFound) {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.InternalError(c, message)
+}
@@ -33,6 +33,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			req.TenantID = tid
 		}
 	}
+	req.Client = clientInfo(c)
 
 	authResp, err := h.authService.Register(c.Request.Context(), req)
 	if err != nil {
@@ -40,6 +41,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			response.Conflict(c, "User with this email already exists")
 			return
 		}
+		if handlePasswordError(c, err) {
+			return
+		}
 		response.InternalError(c, "Failed to register user")
 		return
 	}
@@ -55,6 +59,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	req.Client = clientInfo(c)
+
 	authResp, err := h.authService.Login(c.Request.Context(), req)
 	if err != nil {
 		if err == services.ErrInvalidCredentials {
@@ -78,7 +84,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	authResp, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	authResp, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		if err == services.ErrSessionNotFound || err == services.ErrTokenExpired {
 			response.Unauthorized(c, "Invalid or expired refresh token")
@@ -139,7 +145,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.Logout(c.Request.Context(), userID); err != nil {
+	if err := h.authService.Logout(c.Request.Context(), userID, h.getSessionIDFromContext(c)); err != nil {
 		response.InternalError(c, "Failed to logout")
 		return
 	}
@@ -147,6 +153,106 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	response.NoContent(c)
 }
 
+// ListSessions lists the devices the current user is signed in on
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), userID, h.getSessionIDFromContext(c))
+	if err != nil {
+		response.InternalError(c, "Failed to list sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeSession signs the current user out of one device
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid session ID", nil)
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if err == services.ErrSessionNotFound {
+			response.NotFound(c, "Session not found")
+			return
+		}
+		response.InternalError(c, "Failed to revoke session")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// RevokeAllSessions logs the current user out everywhere. With
+// except_current=true the device making the request stays signed in.
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keepID := uuid.Nil
+	if c.Query("except_current") == "true" {
+		keepID = h.getSessionIDFromContext(c)
+	}
+
+	if err := h.authService.RevokeAllSessions(c.Request.Context(), userID, keepID); err != nil {
+		response.InternalError(c, "Failed to revoke sessions")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// StepUp confirms the current user's password or MFA code and returns a
+// fresh access token that lets them take sensitive actions for a few minutes
+func (h *AuthHandler) StepUp(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req services.StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	authResp, err := h.authService.StepUp(c.Request.Context(), userID, h.getSessionIDFromContext(c), req)
+	if err != nil {
+		switch err {
+		case services.ErrStepUpMethodRequired, services.ErrMFANotEnabled:
+			response.BadRequest(c, err.Error(), nil)
+		case services.ErrInvalidCredentials:
+			response.Unauthorized(c, "Password is incorrect")
+		case services.ErrInvalidMFACode:
+			response.Unauthorized(c, "Invalid MFA code")
+		case services.ErrSessionNotFound:
+			response.Unauthorized(c, "Session has ended, please log in again")
+		default:
+			response.InternalError(c, "Failed to confirm identity")
+		}
+		return
+	}
+
+	response.Success(c, authResp)
+}
+
 // ChangePassword handles password change
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	userID, err := h.getUserIDFromContext(c)
@@ -166,6 +272,9 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 			response.BadRequest(c, "Current password is incorrect", nil)
 			return
 		}
+		if handlePasswordError(c, err) {
+			return
+		}
 		response.InternalError(c, "Failed to change password")
 		return
 	}
@@ -202,6 +311,9 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	}
 
 	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if handlePasswordError(c, err) {
+			return
+		}
 		response.BadRequest(c, "Invalid or expired reset token", nil)
 		return
 	}
@@ -256,7 +368,7 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 		return
 	}
 
-	authResp, err := h.authService.VerifyOTP(c.Request.Context(), req.Phone, req.OTP)
+	authResp, err := h.authService.VerifyOTP(c.Request.Context(), req.Phone, req.OTP, clientInfo(c))
 	if err != nil {
 		response.Unauthorized(c, "Invalid OTP")
 		return
@@ -352,6 +464,20 @@ func (h *AuthHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
 	return uuid.Parse(userIDStr.(string))
 }
 
+// getSessionIDFromContext returns the session the access token was issued
+// for, or uuid.Nil for tokens issued before sessions were identified
+func (h *AuthHandler) getSessionIDFromContext(c *gin.Context) uuid.UUID {
+	sessionIDStr, exists := c.Get("session_id")
+	if !exists {
+		return uuid.Nil
+	}
+	sessionID, err := uuid.Parse(sessionIDStr.(string))
+	if err != nil {
+		return uuid.Nil
+	}
+	return sessionID
+}
+
 func (h *AuthHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
 	tenantIDStr, exists := c.Get("tenant_id")
 	if !exists {
@@ -359,3 +485,11 @@ func (h *AuthHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error)
 	}
 	return uuid.Parse(tenantIDStr.(string))
 }
+
+// clientInfo describes the device making the request
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/capabilities"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/features"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// CapabilitiesHandler tells clients what the caller's tenant can use, from
+// its plan and country in the tenant service and the feature flags on for
+// it
+type CapabilitiesHandler struct {
+	tenants  clients.TenantClient
+	features *features.Store
+}
+
+// NewCapabilitiesHandler creates a new capabilities handler
+func NewCapabilitiesHandler(tenants clients.TenantClient, featureStore *features.Store) *CapabilitiesHandler {
+	return &CapabilitiesHandler{tenants: tenants, features: featureStore}
+}
+
+// Get returns the enabled modules, limits and API versions of the caller's
+// tenant
+func (h *CapabilitiesHandler) Get(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetString("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	tenant, err := h.tenants.GetTenant(c.Request.Context(), tenantID.String(), c.GetHeader("Authorization"))
+	if err != nil {
+		if errors.Is(err, clients.ErrTenantNotFound) {
+			response.NotFound(c, "Tenant not found")
+			return
+		}
+		response.ServiceUnavailable(c, "Tenant service unavailable")
+		return
+	}
+
+	flags := h.features.EnabledFlags(c.Request.Context(), tenantID)
+	response.Success(c, capabilities.Resolve(capabilities.Tenant{
+		Plan:                tenant.Plan,
+		Country:             tenant.Country,
+		MaxUsers:            tenant.MaxUsers,
+		MaxInvoicesPerMonth: tenant.MaxInvoicesPerMonth,
+	}, flags))
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// PasswordPolicyHandler handles tenants' password policy endpoints
+type PasswordPolicyHandler struct {
+	passwordService services.PasswordService
+}
+
+// NewPasswordPolicyHandler creates a new password policy handler
+func NewPasswordPolicyHandler(passwordService services.PasswordService) *PasswordPolicyHandler {
+	return &PasswordPolicyHandler{passwordService: passwordService}
+}
+
+// GetPolicy returns the password policy of the admin's tenant
+func (h *PasswordPolicyHandler) GetPolicy(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Tenant not identified")
+		return
+	}
+
+	policy, err := h.passwordService.GetPolicy(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get password policy")
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// UpdatePolicy replaces the password policy of the admin's tenant. It applies
+// to passwords set from now on.
+func (h *PasswordPolicyHandler) UpdatePolicy(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Tenant not identified")
+		return
+	}
+
+	var req services.UpdatePasswordPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid password policy", map[string]string{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.passwordService.UpdatePolicy(c.Request.Context(), tenantID, req)
+	if err != nil {
+		response.InternalError(c, "Failed to update password policy")
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+func (h *PasswordPolicyHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, errors.New("tenant not found in context")
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
+
+// handlePasswordError responds to a new password being rejected, reporting
+// whether err was such a rejection
+func handlePasswordError(c *gin.Context, err error) bool {
+	var policyErr *services.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		response.ValidationError(c, "Password does not meet the password policy", map[string]string{"password": policyErr.Error()})
+	case err == services.ErrPasswordBreached:
+		response.ValidationError(c, "Password has appeared in a data breach", map[string]string{"password": err.Error()})
+	case err == services.ErrPasswordReused:
+		response.ValidationError(c, "Password was used recently", map[string]string{"password": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
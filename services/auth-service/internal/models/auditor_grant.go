@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditorGrant is read-only access a tenant owner gives an external
+// auditor to the reports, ledgers and documents of one financial year, for
+// a limited time. The auditor signs in with the token issued with the
+// grant; they have no user account.
+type AuditorGrant struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	AuditorName  string    `gorm:"size:200;not null" json:"auditor_name"`
+	AuditorEmail string    `gorm:"size:255;not null" json:"auditor_email"`
+	Firm         string    `gorm:"size:200" json:"firm,omitempty"`
+
+	// Financial year the auditor may see, e.g. 2024-25
+	FinancialYear string    `gorm:"size:10;not null" json:"financial_year"`
+	PeriodStart   time.Time `gorm:"type:date;not null" json:"period_start"`
+	PeriodEnd     time.Time `gorm:"type:date;not null" json:"period_end"`
+
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for AuditorGrant
+func (AuditorGrant) TableName() string {
+	return "auditor_grants"
+}
+
+// IsActive reports whether the grant's token is still accepted
+func (g *AuditorGrant) IsActive() bool {
+	return g.RevokedAt == nil && time.Now().Before(g.ExpiresAt)
+}
+
+// AuditorAccessLog is a request an auditor made under a grant, in any
+// service, refused ones included
+type AuditorAccessLog struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	GrantID    uuid.UUID `gorm:"type:uuid;index;not null" json:"grant_id"`
+	TenantID   uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	Method     string    `gorm:"size:10" json:"method"`
+	Path       string    `gorm:"size:500" json:"path"`
+	Query      string    `gorm:"size:1000" json:"query,omitempty"`
+	StatusCode int       `json:"status_code"`
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	UserAgent  string    `gorm:"size:500" json:"user_agent"`
+	RequestID  string    `gorm:"size:100" json:"request_id,omitempty"`
+	At         time.Time `gorm:"index;not null" json:"at"`
+}
+
+// TableName returns the table name for AuditorAccessLog
+func (AuditorAccessLog) TableName() string {
+	return "auditor_access_logs"
+}
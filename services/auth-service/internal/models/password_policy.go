@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MinPasswordLength is the shortest password any policy can allow
+const MinPasswordLength = 8
+
+// PasswordPolicy is a tenant's rules for its users' passwords. Tenants
+// without one get DefaultPasswordPolicy.
+type PasswordPolicy struct {
+	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID         uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"tenant_id"`
+	MinLength        int       `gorm:"default:8" json:"min_length"`
+	RequireUppercase bool      `gorm:"default:false" json:"require_uppercase"`
+	RequireLowercase bool      `gorm:"default:false" json:"require_lowercase"`
+	RequireDigit     bool      `gorm:"default:false" json:"require_digit"`
+	RequireSymbol    bool      `gorm:"default:false" json:"require_symbol"`
+	MaxAgeDays       int       `gorm:"default:0" json:"max_age_days"`      // 0 never expires passwords
+	HistoryCount     int       `gorm:"default:0" json:"history_count"`     // Recent passwords that cannot be reused
+	CheckBreached    bool      `gorm:"default:true" json:"check_breached"` // Reject passwords found in known breaches
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for PasswordPolicy
+func (PasswordPolicy) TableName() string {
+	return "password_policies"
+}
+
+// DefaultPasswordPolicy returns the policy of tenants that have not set one
+func DefaultPasswordPolicy(tenantID uuid.UUID) *PasswordPolicy {
+	return &PasswordPolicy{
+		TenantID:      tenantID,
+		MinLength:     MinPasswordLength,
+		HistoryCount:  3,
+		CheckBreached: true,
+	}
+}
+
+// IsExpired reports whether a password changed at changedAt has outlived the
+// policy's maximum age
+func (p *PasswordPolicy) IsExpired(changedAt *time.Time, now time.Time) bool {
+	if p.MaxAgeDays <= 0 || changedAt == nil {
+		return false
+	}
+	return now.After(changedAt.AddDate(0, 0, p.MaxAgeDays))
+}
+
+// PasswordHistory is a password hash a user has had, kept to stop them
+// reusing it
+type PasswordHistory struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for PasswordHistory
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
@@ -89,17 +89,27 @@ func (u *User) GetRoleNames() []string {
 	return names
 }
 
-// Session represents a user session
+// Session represents a user session: a login on one device, kept alive
+// by refreshing its token
 type Session struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID       uuid.UUID      `gorm:"type:uuid;index;not null" json:"user_id"`
 	RefreshToken string         `gorm:"not null" json:"-"`
 	UserAgent    string         `gorm:"size:500" json:"user_agent"`
 	IPAddress    string         `gorm:"size:45" json:"ip_address"`
+	LastSeenAt   *time.Time     `json:"last_seen_at"` // Last login or token refresh
+
+	// AuthenticatedAt is when the user last entered their password or MFA
+	// code on this session, at login or stepping up; refreshing keeps it
+	AuthenticatedAt *time.Time `json:"authenticated_at"`
+
 	ExpiresAt    time.Time      `gorm:"not null" json:"expires_at"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Device  string `gorm:"-" json:"device"`  // Browser and OS read from the user agent
+	Current bool   `gorm:"-" json:"current"` // The session of the request
 }
 
 // TableName returns the table name for Session
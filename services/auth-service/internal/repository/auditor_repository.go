@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrAuditorGrantNotFound = errors.New("auditor grant not found")
+
+// AuditorRepository handles auditor grants and their access logs
+type AuditorRepository interface {
+	CreateGrant(ctx context.Context, grant *models.AuditorGrant) error
+	GetGrant(ctx context.Context, id uuid.UUID) (*models.AuditorGrant, error)
+
+	// ListGrants returns the tenant's grants, latest first
+	ListGrants(ctx context.Context, tenantID uuid.UUID) ([]models.AuditorGrant, error)
+	RevokeGrant(ctx context.Context, id, revokedBy uuid.UUID, at time.Time) error
+
+	// AddAccess logs a request and marks the grant used
+	AddAccess(ctx context.Context, entry *models.AuditorAccessLog) error
+
+	// ListAccess returns a grant's requests, latest first
+	ListAccess(ctx context.Context, grantID uuid.UUID, page, perPage int) ([]models.AuditorAccessLog, int64, error)
+}
+
+type auditorRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditorRepository creates a new auditor repository
+func NewAuditorRepository(db *gorm.DB) AuditorRepository {
+	return &auditorRepository{db: db}
+}
+
+func (r *auditorRepository) CreateGrant(ctx context.Context, grant *models.AuditorGrant) error {
+	return r.db.WithContext(ctx).Create(grant).Error
+}
+
+func (r *auditorRepository) GetGrant(ctx context.Context, id uuid.UUID) (*models.AuditorGrant, error) {
+	var grant models.AuditorGrant
+	err := r.db.WithContext(ctx).First(&grant, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuditorGrantNotFound
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *auditorRepository) ListGrants(ctx context.Context, tenantID uuid.UUID) ([]models.AuditorGrant, error) {
+	var grants []models.AuditorGrant
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&grants).Error
+	return grants, err
+}
+
+func (r *auditorRepository) RevokeGrant(ctx context.Context, id, revokedBy uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.AuditorGrant{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_by": revokedBy}).Error
+}
+
+func (r *auditorRepository) AddAccess(ctx context.Context, entry *models.AuditorAccessLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.AuditorGrant{}).
+			Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", entry.GrantID, entry.At).
+			Update("last_used_at", entry.At).Error
+	})
+}
+
+func (r *auditorRepository) ListAccess(ctx context.Context, grantID uuid.UUID, page, perPage int) ([]models.AuditorAccessLog, int64, error) {
+	var entries []models.AuditorAccessLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.AuditorAccessLog{}).Where("grant_id = ?", grantID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("at DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&entries).Error
+	return entries, total, err
+}
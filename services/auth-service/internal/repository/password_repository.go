@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PasswordRepository handles password policies and password history
+type PasswordRepository interface {
+	// GetPolicy returns the tenant's policy, or the default when it has none
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.PasswordPolicy, error)
+	SavePolicy(ctx context.Context, policy *models.PasswordPolicy) error
+
+	AddHistory(ctx context.Context, userID uuid.UUID, passwordHash string) error
+
+	// ListHistory returns the user's most recent password hashes, newest first
+	ListHistory(ctx context.Context, userID uuid.UUID, limit int) ([]models.PasswordHistory, error)
+}
+
+type passwordRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordRepository creates a new password repository
+func NewPasswordRepository(db *gorm.DB) PasswordRepository {
+	return &passwordRepository{db: db}
+}
+
+func (r *passwordRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.PasswordPolicy, error) {
+	var policy models.PasswordPolicy
+	err := r.db.WithContext(ctx).First(&policy, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.DefaultPasswordPolicy(tenantID), nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *passwordRepository) SavePolicy(ctx context.Context, policy *models.PasswordPolicy) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"min_length", "require_uppercase", "require_lowercase", "require_digit",
+				"require_symbol", "max_age_days", "history_count", "check_breached", "updated_at",
+			}),
+		}).
+		Create(policy).Error
+}
+
+func (r *passwordRepository) AddHistory(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	return r.db.WithContext(ctx).Create(&models.PasswordHistory{
+		UserID:       userID,
+		PasswordHash: passwordHash,
+	}).Error
+}
+
+func (r *passwordRepository) ListHistory(ctx context.Context, userID uuid.UUID, limit int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -14,8 +14,19 @@ type SessionRepository interface {
 	Create(ctx context.Context, session *models.Session) error
 	GetByRefreshToken(ctx context.Context, token string) (*models.Session, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+
+	// GetForUser returns one of the user's unexpired sessions
+	GetForUser(ctx context.Context, userID, id uuid.UUID) (*models.Session, error)
+	Update(ctx context.Context, session *models.Session) error
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// DeleteForUser deletes one of the user's sessions and reports whether
+	// it existed
+	DeleteForUser(ctx context.Context, userID, id uuid.UUID) (bool, error)
 	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+
+	// DeleteOthers deletes all of the user's sessions but one
+	DeleteOthers(ctx context.Context, userID, keepID uuid.UUID) error
 	DeleteExpired(ctx context.Context) (int64, error)
 }
 
@@ -47,7 +58,7 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (
 	var sessions []models.Session
 	err := r.db.WithContext(ctx).
 		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
-		Order("created_at DESC").
+		Order("COALESCE(last_seen_at, created_at) DESC").
 		Find(&sessions).Error
 	if err != nil {
 		return nil, err
@@ -55,14 +66,38 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (
 	return sessions, nil
 }
 
+func (r *sessionRepository) GetForUser(ctx context.Context, userID, id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND id = ? AND expires_at > ?", userID, id, time.Now()).
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Update(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Save(session).Error
+}
+
 func (r *sessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.Session{}, "id = ?", id).Error
 }
 
+func (r *sessionRepository) DeleteForUser(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&models.Session{}, "user_id = ? AND id = ?", userID, id)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.Session{}, "user_id = ?", userID).Error
 }
 
+func (r *sessionRepository) DeleteOthers(ctx context.Context, userID, keepID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Session{}, "user_id = ? AND id <> ?", userID, keepID).Error
+}
+
 func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
 	result := r.db.WithContext(ctx).Delete(&models.Session{}, "expires_at < ?", time.Now())
 	return result.RowsAffected, result.Error
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/config"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+)
+
+var (
+	ErrAuditorGrantNotFound = errors.New("auditor grant not found")
+	ErrInvalidFinancialYear = errors.New("financial year must look like 2024-25, or 2024 for years starting in January")
+)
+
+// How long auditor access lasts when the owner does not say
+const defaultAuditorAccessDays = 30
+
+var financialYearPattern = regexp.MustCompile(`^(\d{4})(?:-(\d{2}))?$`)
+
+// CreateAuditorGrantRequest gives an auditor access to a financial year
+type CreateAuditorGrantRequest struct {
+	AuditorName   string `json:"auditor_name" binding:"required,max=200"`
+	AuditorEmail  string `json:"auditor_email" binding:"required,email"`
+	Firm          string `json:"firm" binding:"max=200"`
+	FinancialYear string `json:"financial_year" binding:"required"`
+	ValidDays     int    `json:"valid_days" binding:"gte=0,lte=90"` // Defaults to 30
+}
+
+// IssuedAuditorGrant is a new grant with the auditor's token. The token is
+// only ever shown here; a lost token means a new grant.
+type IssuedAuditorGrant struct {
+	*models.AuditorGrant
+	Token string `json:"token"`
+}
+
+// AuditorService issues and revokes auditors' read-only access, and keeps
+// the log of what they looked at
+type AuditorService interface {
+	// CreateGrant gives an auditor access to one of the tenant's financial
+	// years, whose start month is read from the tenant service
+	CreateGrant(ctx context.Context, authorization string, tenantID, userID uuid.UUID, req CreateAuditorGrantRequest) (*IssuedAuditorGrant, error)
+	ListGrants(ctx context.Context, tenantID uuid.UUID) ([]models.AuditorGrant, error)
+	RevokeGrant(ctx context.Context, tenantID, grantID, userID uuid.UUID) (*models.AuditorGrant, error)
+	ListAccess(ctx context.Context, tenantID, grantID uuid.UUID, page, perPage int) ([]models.AuditorAccessLog, int64, error)
+
+	// GetGrant and RecordAccess serve the other services' checks of
+	// auditor tokens
+	GetGrant(ctx context.Context, grantID uuid.UUID) (*models.AuditorGrant, error)
+	RecordAccess(ctx context.Context, access middleware.AuditorAccess) error
+}
+
+type auditorService struct {
+	cfg     *config.Config
+	repo    repository.AuditorRepository
+	tenants clients.TenantClient
+}
+
+// NewAuditorService creates a new auditor service
+func NewAuditorService(cfg *config.Config, repo repository.AuditorRepository, tenants clients.TenantClient) AuditorService {
+	return &auditorService{cfg: cfg, repo: repo, tenants: tenants}
+}
+
+func (s *auditorService) CreateGrant(ctx context.Context, authorization string, tenantID, userID uuid.UUID, req CreateAuditorGrantRequest) (*IssuedAuditorGrant, error) {
+	tenant, err := s.tenants.GetTenant(ctx, tenantID.String(), authorization)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := financialYearPeriod(strings.TrimSpace(req.FinancialYear), tenant.FinancialYearStart)
+	if err != nil {
+		return nil, err
+	}
+
+	days := req.ValidDays
+	if days == 0 {
+		days = defaultAuditorAccessDays
+	}
+
+	grant := &models.AuditorGrant{
+		ID:            uuid.New(),
+		TenantID:      tenantID,
+		AuditorName:   strings.TrimSpace(req.AuditorName),
+		AuditorEmail:  strings.ToLower(strings.TrimSpace(req.AuditorEmail)),
+		Firm:          strings.TrimSpace(req.Firm),
+		FinancialYear: strings.TrimSpace(req.FinancialYear),
+		PeriodStart:   start,
+		PeriodEnd:     end,
+		ExpiresAt:     time.Now().AddDate(0, 0, days),
+		CreatedBy:     userID,
+	}
+
+	token, err := s.auditorToken(grant)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateGrant(ctx, grant); err != nil {
+		return nil, err
+	}
+
+	return &IssuedAuditorGrant{AuditorGrant: grant, Token: token}, nil
+}
+
+// auditorToken signs the grant's token. The auditor is identified by the
+// grant, as they have no user account.
+func (s *auditorService) auditorToken(grant *models.AuditorGrant) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":      grant.ID.String(),
+		"email":        grant.AuditorEmail,
+		"tenant_id":    grant.TenantID.String(),
+		"roles":        []string{"auditor"},
+		"scope":        middleware.ScopeAuditor,
+		"grant_id":     grant.ID.String(),
+		"period_start": grant.PeriodStart.Format(middleware.AuditorDateFormat),
+		"period_end":   grant.PeriodEnd.Format(middleware.AuditorDateFormat),
+		"iss":          s.cfg.JWT.Issuer,
+		"iat":          time.Now().Unix(),
+		"exp":          grant.ExpiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.cfg.JWTSecret()))
+}
+
+func (s *auditorService) ListGrants(ctx context.Context, tenantID uuid.UUID) ([]models.AuditorGrant, error) {
+	return s.repo.ListGrants(ctx, tenantID)
+}
+
+func (s *auditorService) RevokeGrant(ctx context.Context, tenantID, grantID, userID uuid.UUID) (*models.AuditorGrant, error) {
+	if _, err := s.tenantGrant(ctx, tenantID, grantID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RevokeGrant(ctx, grantID, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGrant(ctx, grantID)
+}
+
+func (s *auditorService) ListAccess(ctx context.Context, tenantID, grantID uuid.UUID, page, perPage int) ([]models.AuditorAccessLog, int64, error) {
+	if _, err := s.tenantGrant(ctx, tenantID, grantID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListAccess(ctx, grantID, page, perPage)
+}
+
+func (s *auditorService) GetGrant(ctx context.Context, grantID uuid.UUID) (*models.AuditorGrant, error) {
+	grant, err := s.repo.GetGrant(ctx, grantID)
+	if errors.Is(err, repository.ErrAuditorGrantNotFound) {
+		return nil, ErrAuditorGrantNotFound
+	}
+	return grant, err
+}
+
+func (s *auditorService) RecordAccess(ctx context.Context, access middleware.AuditorAccess) error {
+	grantID, err := uuid.Parse(access.GrantID)
+	if err != nil {
+		return ErrAuditorGrantNotFound
+	}
+	tenantID, err := uuid.Parse(access.TenantID)
+	if err != nil {
+		return ErrAuditorGrantNotFound
+	}
+	at := access.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	return s.repo.AddAccess(ctx, &models.AuditorAccessLog{
+		GrantID:    grantID,
+		TenantID:   tenantID,
+		Method:     truncate(access.Method, 10),
+		Path:       truncate(access.Path, 500),
+		Query:      truncate(access.Query, 1000),
+		StatusCode: access.StatusCode,
+		IPAddress:  truncate(access.IPAddress, 45),
+		UserAgent:  truncate(access.UserAgent, 500),
+		RequestID:  truncate(access.RequestID, 100),
+		At:         at,
+	})
+}
+
+// tenantGrant returns the grant if it is the tenant's
+func (s *auditorService) tenantGrant(ctx context.Context, tenantID, grantID uuid.UUID) (*models.AuditorGrant, error) {
+	grant, err := s.GetGrant(ctx, grantID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.TenantID != tenantID {
+		return nil, ErrAuditorGrantNotFound
+	}
+	return grant, nil
+}
+
+// financialYearPeriod returns the first and last day of the financial year
+// named, for years starting in startMonth: 2024-25 is April 2024 to March
+// 2025 when they start in April. Years starting in January are named by
+// the calendar year alone.
+func financialYearPeriod(name string, startMonth int) (time.Time, time.Time, error) {
+	if startMonth < 1 || startMonth > 12 {
+		startMonth = int(time.April)
+	}
+
+	match := financialYearPattern.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, time.Time{}, ErrInvalidFinancialYear
+	}
+	year, _ := strconv.Atoi(match[1])
+	if startMonth == 1 {
+		if match[2] != "" {
+			return time.Time{}, time.Time{}, ErrInvalidFinancialYear
+		}
+	} else {
+		next, _ := strconv.Atoi(match[2])
+		if match[2] == "" || next != (year+1)%100 {
+			return time.Time{}, time.Time{}, ErrInvalidFinancialYear
+		}
+	}
+
+	start := time.Date(year, time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(1, 0, -1), nil
+}
@@ -7,14 +7,19 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
-	"fmt"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/config"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/email"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/messaging"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -31,6 +36,7 @@ var (
 	ErrInvalidResetToken       = errors.New("invalid or expired reset token")
 	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
 	ErrPasswordReused          = errors.New("cannot reuse a recent password")
+	ErrStepUpMethodRequired    = errors.New("password or MFA code required")
 )
 
 const (
@@ -45,8 +51,11 @@ const (
 type AuthService interface {
 	Register(ctx context.Context, req RegisterRequest) (*AuthResponse, error)
 	Login(ctx context.Context, req LoginRequest) (*AuthResponse, error)
-	RefreshToken(ctx context.Context, refreshToken string) (*AuthResponse, error)
-	Logout(ctx context.Context, userID uuid.UUID) error
+	RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*AuthResponse, error)
+
+	// Logout ends the given session, or all of the user's sessions when
+	// sessionID is nil (tokens issued before sessions were identified)
+	Logout(ctx context.Context, userID, sessionID uuid.UUID) error
 	GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error)
 	UpdateProfile(ctx context.Context, userID uuid.UUID, req UpdateProfileRequest) (*models.User, error)
 	ChangePassword(ctx context.Context, userID uuid.UUID, req ChangePasswordRequest) error
@@ -54,10 +63,32 @@ type AuthService interface {
 	ResetPassword(ctx context.Context, token, newPassword string) error
 	VerifyEmail(ctx context.Context, token string) error
 	RequestOTP(ctx context.Context, phone string) error
-	VerifyOTP(ctx context.Context, phone, otp string) (*AuthResponse, error)
+	VerifyOTP(ctx context.Context, phone, otp string, client ClientInfo) (*AuthResponse, error)
 	ListUsers(ctx context.Context, tenantID uuid.UUID, page, limit int) ([]models.User, int64, error)
 	UpdateUserRoles(ctx context.Context, userID uuid.UUID, roles []string) error
 	DeleteUser(ctx context.Context, userID uuid.UUID) error
+
+	// ListSessions returns the user's active sessions, most recently used
+	// first, marking currentID as the current one
+	ListSessions(ctx context.Context, userID, currentID uuid.UUID) ([]models.Session, error)
+
+	// RevokeSession ends one of the user's sessions. Its refresh token stops
+	// working at once; access tokens already issued lapse within their TTL.
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+
+	// RevokeAllSessions logs the user out everywhere, except keepID when set
+	RevokeAllSessions(ctx context.Context, userID, keepID uuid.UUID) error
+
+	// StepUp re-authenticates the user on their current session with their
+	// password or MFA code, returning an access token that carries the new
+	// auth time for sensitive actions
+	StepUp(ctx context.Context, userID, sessionID uuid.UUID, req StepUpRequest) (*AuthResponse, error)
+}
+
+// ClientInfo identifies the device a login comes from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
 }
 
 type authService struct {
@@ -65,6 +96,10 @@ type authService struct {
 	userRepo    repository.UserRepository
 	sessionRepo repository.SessionRepository
 	roleRepo    repository.RoleRepository
+	passwords   PasswordService
+	mailer      *email.Mailer
+	messenger   *messaging.Messenger
+	preferences *messaging.PreferencesClient
 }
 
 // NewAuthService creates a new auth service
@@ -73,12 +108,20 @@ func NewAuthService(
 	userRepo repository.UserRepository,
 	sessionRepo repository.SessionRepository,
 	roleRepo repository.RoleRepository,
+	passwords PasswordService,
+	mailer *email.Mailer,
+	messenger *messaging.Messenger,
+	preferences *messaging.PreferencesClient,
 ) AuthService {
 	return &authService{
 		cfg:         cfg,
 		userRepo:    userRepo,
 		sessionRepo: sessionRepo,
 		roleRepo:    roleRepo,
+		passwords:   passwords,
+		mailer:      mailer,
+		messenger:   messenger,
+		preferences: preferences,
 	}
 }
 
@@ -90,12 +133,16 @@ type RegisterRequest struct {
 	LastName  string    `json:"last_name"`
 	Phone     string    `json:"phone"`
 	TenantID  uuid.UUID `json:"tenant_id"`
+
+	Client ClientInfo `json:"-"`
 }
 
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+
+	Client ClientInfo `json:"-"`
 }
 
 // UpdateProfileRequest represents a profile update request
@@ -111,12 +158,23 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"new_password" binding:"required,min=8"`
 }
 
+// StepUpRequest re-authenticates with either the password or, for users
+// with MFA enabled, a current TOTP code
+type StepUpRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
 // AuthResponse represents an authentication response
 type AuthResponse struct {
 	AccessToken  string       `json:"access_token"`
 	RefreshToken string       `json:"refresh_token"`
 	ExpiresIn    int64        `json:"expires_in"`
 	User         *models.User `json:"user"`
+
+	// PasswordExpired asks the client to have the user change their
+	// password, which is older than their tenant's policy allows
+	PasswordExpired bool `json:"password_expired,omitempty"`
 }
 
 func (s *authService) Register(ctx context.Context, req RegisterRequest) (*AuthResponse, error) {
@@ -126,6 +184,10 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*AuthR
 		return nil, ErrUserExists
 	}
 
+	if err := s.passwords.CheckPassword(ctx, &models.User{TenantID: req.TenantID}, req.Password); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -133,19 +195,24 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*AuthR
 	}
 
 	// Create user
+	now := time.Now()
 	user := &models.User{
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Phone:        req.Phone,
-		TenantID:     req.TenantID,
-		IsActive:     true,
+		Email:             req.Email,
+		PasswordHash:      string(hashedPassword),
+		FirstName:         req.FirstName,
+		LastName:          req.LastName,
+		Phone:             req.Phone,
+		TenantID:          req.TenantID,
+		IsActive:          true,
+		PasswordChangedAt: &now,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	if err := s.passwords.RecordPassword(ctx, user.ID, user.PasswordHash); err != nil {
+		return nil, err
+	}
 
 	// Assign default role (staff)
 	if err := s.assignDefaultRole(ctx, user.ID); err != nil {
@@ -159,7 +226,7 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*AuthR
 	}
 
 	// Generate tokens
-	return s.generateAuthResponse(ctx, user)
+	return s.generateAuthResponse(ctx, user, req.Client)
 }
 
 func (s *authService) Login(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
@@ -180,10 +247,15 @@ func (s *authService) Login(ctx context.Context, req LoginRequest) (*AuthRespons
 	// Update last login
 	_ = s.userRepo.UpdateLastLogin(ctx, user.ID)
 
-	return s.generateAuthResponse(ctx, user)
+	resp, err := s.generateAuthResponse(ctx, user, req.Client)
+	if err != nil {
+		return nil, err
+	}
+	resp.PasswordExpired = s.passwords.IsExpired(ctx, user)
+	return resp, nil
 }
 
-func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*AuthResponse, error) {
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*AuthResponse, error) {
 	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, ErrSessionNotFound
@@ -198,14 +270,35 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*A
 		return nil, ErrUserNotFound
 	}
 
-	// Delete old session
-	_ = s.sessionRepo.Delete(ctx, session.ID)
+	// Rotate the refresh token within the session, so the session stays
+	// the same device in the session list
+	newRefreshToken, err := s.generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session.RefreshToken = newRefreshToken
+	session.LastSeenAt = &now
+	session.ExpiresAt = now.Add(s.cfg.JWT.RefreshTokenTTL)
+	if client.IPAddress != "" {
+		session.IPAddress = client.IPAddress
+	}
+	if client.UserAgent != "" {
+		session.UserAgent = truncate(client.UserAgent, 500)
+	}
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, err
+	}
 
-	return s.generateAuthResponse(ctx, user)
+	return s.authResponse(user, session)
 }
 
-func (s *authService) Logout(ctx context.Context, userID uuid.UUID) error {
-	return s.sessionRepo.DeleteByUserID(ctx, userID)
+func (s *authService) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return s.sessionRepo.DeleteByUserID(ctx, userID)
+	}
+	_, err := s.sessionRepo.DeleteForUser(ctx, userID, sessionID)
+	return err
 }
 
 func (s *authService) GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
@@ -246,17 +339,24 @@ func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, req
 		return ErrInvalidCredentials
 	}
 
+	if err := s.passwords.CheckPassword(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword))
+	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		return err
+	}
+	return s.passwords.RecordPassword(ctx, userID, string(hashedPassword))
 }
 
-func (s *authService) ForgotPassword(ctx context.Context, email string) error {
-	user, err := s.userRepo.GetByEmail(ctx, email)
+func (s *authService) ForgotPassword(ctx context.Context, address string) error {
+	user, err := s.userRepo.GetByEmail(ctx, address)
 	if err != nil {
 		// Don't reveal if user exists - always return success
 		return nil
@@ -280,12 +380,13 @@ func (s *authService) ForgotPassword(ctx context.Context, email string) error {
 		return err
 	}
 
-	// TODO: Send email with reset link containing the token
-	// The reset link should be: {frontend_url}/reset-password?token={token}
-	// For now, we log it (in production, use an email service)
-	fmt.Printf("[Password Reset] Token for %s: %s\n", email, token)
-
-	return nil
+	// The token is only ever in the email, whose body is cleared once sent
+	_, err = s.mailer.SendTemplate(ctx, user.Email, fullName(user), email.TemplatePasswordReset, email.PasswordReset{
+		Name:      greetingName(user),
+		Link:      s.cfg.Email.AppURL + "/reset-password?token=" + url.QueryEscape(token),
+		ExpiresIn: "1 hour",
+	}, email.SendOptions{TenantID: userTenant(user), Reference: "user:" + user.ID.String(), Sensitive: true})
+	return err
 }
 
 func (s *authService) ResetPassword(ctx context.Context, token, newPassword string) error {
@@ -303,6 +404,10 @@ func (s *authService) ResetPassword(ctx context.Context, token, newPassword stri
 		return ErrInvalidResetToken
 	}
 
+	if err := s.passwords.CheckPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -319,6 +424,9 @@ func (s *authService) ResetPassword(ctx context.Context, token, newPassword stri
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
+	if err := s.passwords.RecordPassword(ctx, user.ID, user.PasswordHash); err != nil {
+		return err
+	}
 
 	// Invalidate all sessions on password reset
 	_ = s.sessionRepo.DeleteByUserID(ctx, user.ID)
@@ -371,13 +479,40 @@ func (s *authService) SendVerificationEmail(ctx context.Context, userID uuid.UUI
 		return "", err
 	}
 
-	// TODO: Send email with verification code
-	// For now, we log it (in production, use an email service)
-	fmt.Printf("[Email Verification] Code for %s: %s\n", user.Email, code)
+	_, err = s.mailer.SendTemplate(ctx, user.Email, fullName(user), email.TemplateEmailVerification, email.EmailVerification{
+		Name:      greetingName(user),
+		Code:      code,
+		ExpiresIn: "10 minutes",
+	}, email.SendOptions{TenantID: userTenant(user), Reference: "user:" + user.ID.String(), Sensitive: true})
+	if err != nil {
+		return "", err
+	}
 
 	return code, nil
 }
 
+// fullName is the user's name as the recipient of an email
+func fullName(user *models.User) string {
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// greetingName is what emails to the user call them
+func greetingName(user *models.User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	return "there"
+}
+
+// userTenant is the tenant the user's emails are recorded under, nil for
+// users not yet in one
+func userTenant(user *models.User) *uuid.UUID {
+	if user.TenantID == uuid.Nil {
+		return nil
+	}
+	return &user.TenantID
+}
+
 func (s *authService) RequestOTP(ctx context.Context, phone string) error {
 	user, err := s.userRepo.GetByPhone(ctx, phone)
 	if err != nil {
@@ -397,13 +532,36 @@ func (s *authService) RequestOTP(ctx context.Context, phone string) error {
 		return err
 	}
 
-	// TODO: Send OTP via SMS service
-	fmt.Printf("[OTP] Code for %s: %s\n", phone, otp)
+	// The code goes out even to numbers that opted out of the channel, and
+	// the message's text is cleared once sent
+	_, err = s.messenger.Send(ctx, user.Phone, s.otpChannel(ctx, user), messaging.OTP(otp, "10 minutes"), messaging.SendOptions{
+		TenantID:  userTenant(user),
+		Reference: "user:" + user.ID.String(),
+		Essential: true,
+		Sensitive: true,
+	})
+	return err
+}
 
-	return nil
+// otpChannel is the channel the user's login codes are sent on: their
+// tenant's choice, else SMS
+func (s *authService) otpChannel(ctx context.Context, user *models.User) string {
+	tenantID := userTenant(user)
+	if tenantID == nil {
+		return messaging.ChannelSMS
+	}
+	preferences, err := s.preferences.Get(ctx, tenantID.String())
+	if err != nil {
+		log.Printf("Failed to read the messaging preferences of tenant %s, sending the OTP by SMS: %v", tenantID, err)
+		return messaging.ChannelSMS
+	}
+	if !messaging.ValidChannel(preferences.OTPChannel) {
+		return messaging.ChannelSMS
+	}
+	return preferences.OTPChannel
 }
 
-func (s *authService) VerifyOTP(ctx context.Context, phone, otp string) (*AuthResponse, error) {
+func (s *authService) VerifyOTP(ctx context.Context, phone, otp string, client ClientInfo) (*AuthResponse, error) {
 	user, err := s.userRepo.GetByPhone(ctx, phone)
 	if err != nil {
 		return nil, ErrInvalidCredentials
@@ -427,7 +585,7 @@ func (s *authService) VerifyOTP(ctx context.Context, phone, otp string) (*AuthRe
 	}
 
 	// Generate auth tokens
-	return s.generateAuthResponse(ctx, user)
+	return s.generateAuthResponse(ctx, user, client)
 }
 
 // Helper functions
@@ -478,15 +636,128 @@ func (s *authService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	return s.userRepo.Delete(ctx, userID)
 }
 
-// Helper methods
+// Sessions
 
-func (s *authService) generateAuthResponse(ctx context.Context, user *models.User) (*AuthResponse, error) {
-	// Generate access token
-	accessToken, err := s.generateAccessToken(user)
+func (s *authService) ListSessions(ctx context.Context, userID, currentID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	for i := range sessions {
+		sessions[i].Device = describeDevice(sessions[i].UserAgent)
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	deleted, err := s.sessionRepo.DeleteForUser(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID, keepID uuid.UUID) error {
+	if keepID == uuid.Nil {
+		return s.sessionRepo.DeleteByUserID(ctx, userID)
+	}
+	return s.sessionRepo.DeleteOthers(ctx, userID, keepID)
+}
+
+func (s *authService) StepUp(ctx context.Context, userID, sessionID uuid.UUID, req StepUpRequest) (*AuthResponse, error) {
+	if req.Password == "" && req.Code == "" {
+		return nil, ErrStepUpMethodRequired
+	}
+	// Tokens issued before sessions were identified can't be stepped up;
+	// the user has to log in again
+	if sessionID == uuid.Nil {
+		return nil, ErrSessionNotFound
+	}
+	session, err := s.sessionRepo.GetForUser(ctx, userID, sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
 
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if req.Code != "" {
+		if !user.MFAEnabled {
+			return nil, ErrMFANotEnabled
+		}
+		if !totp.Validate(req.Code, user.MFASecret) {
+			return nil, ErrInvalidMFACode
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	session.AuthenticatedAt = &now
+	session.LastSeenAt = &now
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.authResponse(user, session)
+}
+
+// describeDevice names the browser and OS of a user agent, such as
+// "Chrome on Windows", for the session list
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"CFNetwork", "iOS app"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, o := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			return browser + " on " + o.name
+		}
+	}
+	return browser
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// Helper methods
+
+func (s *authService) generateAuthResponse(ctx context.Context, user *models.User, client ClientInfo) (*AuthResponse, error) {
 	// Generate refresh token
 	refreshToken, err := s.generateRefreshToken()
 	if err != nil {
@@ -494,26 +765,43 @@ func (s *authService) generateAuthResponse(ctx context.Context, user *models.Use
 	}
 
 	// Store session
+	now := time.Now()
 	session := &models.Session{
 		UserID:       user.ID,
 		RefreshToken: refreshToken,
-		ExpiresAt:    time.Now().Add(s.cfg.JWT.RefreshTokenTTL),
+		UserAgent:    truncate(client.UserAgent, 500),
+		IPAddress:    client.IPAddress,
+		ExpiresAt:    now.Add(s.cfg.JWT.RefreshTokenTTL),
+		LastSeenAt:   &now,
+
+		AuthenticatedAt: &now,
 	}
 
 	if err := s.sessionRepo.Create(ctx, session); err != nil {
 		return nil, err
 	}
 
+	return s.authResponse(user, session)
+}
+
+// authResponse issues an access token tied to the session
+func (s *authService) authResponse(user *models.User, session *models.Session) (*AuthResponse, error) {
+	accessToken, err := s.generateAccessToken(user, session)
+	if err != nil {
+		return nil, err
+	}
+
 	return &AuthResponse{
 		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		RefreshToken: session.RefreshToken,
 		ExpiresIn:    int64(s.cfg.JWT.AccessTokenTTL.Seconds()),
 		User:         user,
 	}, nil
 }
 
-func (s *authService) generateAccessToken(user *models.User) (string, error) {
+func (s *authService) generateAccessToken(user *models.User, session *models.Session) (string, error) {
 	claims := jwt.MapClaims{
+		"sid":       session.ID.String(),
 		"user_id":   user.ID.String(),
 		"email":     user.Email,
 		"tenant_id": user.TenantID.String(),
@@ -522,9 +810,13 @@ func (s *authService) generateAccessToken(user *models.User) (string, error) {
 		"iat":       time.Now().Unix(),
 		"exp":       time.Now().Add(s.cfg.JWT.AccessTokenTTL).Unix(),
 	}
+	// Services requiring step-up check how recently the user authenticated
+	if session.AuthenticatedAt != nil {
+		claims["auth_time"] = session.AuthenticatedAt.Unix()
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWT.Secret))
+	return token.SignedString([]byte(s.cfg.JWTSecret()))
 }
 
 func (s *authService) generateRefreshToken() (string, error) {
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/auth-service/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrPasswordBreached = errors.New("this password has appeared in a data breach, choose another")
+
+// PasswordPolicyError lists the rules of the tenant's password policy a
+// password does not meet
+type PasswordPolicyError struct {
+	Problems []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password must have " + strings.Join(e.Problems, ", ")
+}
+
+// UpdatePasswordPolicyRequest represents a change to a tenant's password policy
+type UpdatePasswordPolicyRequest struct {
+	MinLength        int  `json:"min_length" binding:"gte=8,lte=128"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	MaxAgeDays       int  `json:"max_age_days" binding:"gte=0,lte=365"`
+	HistoryCount     int  `json:"history_count" binding:"gte=0,lte=24"`
+	CheckBreached    bool `json:"check_breached"`
+}
+
+// PasswordService enforces tenants' password policies
+type PasswordService interface {
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.PasswordPolicy, error)
+	UpdatePolicy(ctx context.Context, tenantID uuid.UUID, req UpdatePasswordPolicyRequest) (*models.PasswordPolicy, error)
+
+	// CheckPassword checks a new password for the user against their
+	// tenant's policy: its rules, known breaches and, for existing users,
+	// their recent passwords
+	CheckPassword(ctx context.Context, user *models.User, password string) error
+
+	// RecordPassword adds the user's new password hash to their history
+	RecordPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+
+	// IsExpired reports whether the user's password has outlived their
+	// tenant's maximum password age
+	IsExpired(ctx context.Context, user *models.User) bool
+}
+
+type passwordService struct {
+	repo     repository.PasswordRepository
+	breaches clients.BreachChecker
+}
+
+// NewPasswordService creates a new password service. A nil breach checker
+// skips the breach check.
+func NewPasswordService(repo repository.PasswordRepository, breaches clients.BreachChecker) PasswordService {
+	return &passwordService{repo: repo, breaches: breaches}
+}
+
+func (s *passwordService) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.PasswordPolicy, error) {
+	return s.repo.GetPolicy(ctx, tenantID)
+}
+
+func (s *passwordService) UpdatePolicy(ctx context.Context, tenantID uuid.UUID, req UpdatePasswordPolicyRequest) (*models.PasswordPolicy, error) {
+	policy := &models.PasswordPolicy{
+		TenantID:         tenantID,
+		MinLength:        req.MinLength,
+		RequireUppercase: req.RequireUppercase,
+		RequireLowercase: req.RequireLowercase,
+		RequireDigit:     req.RequireDigit,
+		RequireSymbol:    req.RequireSymbol,
+		MaxAgeDays:       req.MaxAgeDays,
+		HistoryCount:     req.HistoryCount,
+		CheckBreached:    req.CheckBreached,
+	}
+	if policy.MinLength < models.MinPasswordLength {
+		policy.MinLength = models.MinPasswordLength
+	}
+	if err := s.repo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPolicy(ctx, tenantID)
+}
+
+func (s *passwordService) CheckPassword(ctx context.Context, user *models.User, password string) error {
+	policy, err := s.repo.GetPolicy(ctx, user.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if problems := policyProblems(policy, password); len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+
+	if policy.CheckBreached && s.breaches != nil {
+		count, err := s.breaches.Breached(ctx, password)
+		if err != nil {
+			// An outage of the breach check must not stop people signing up
+			// or changing passwords
+			log.Printf("Skipping password breach check: %v", err)
+		} else if count > 0 {
+			return ErrPasswordBreached
+		}
+	}
+
+	if user.ID == uuid.Nil || policy.HistoryCount <= 0 {
+		return nil
+	}
+
+	hashes := []string{user.PasswordHash}
+	history, err := s.repo.ListHistory(ctx, user.ID, policy.HistoryCount)
+	if err != nil {
+		return err
+	}
+	for _, h := range history {
+		hashes = append(hashes, h.PasswordHash)
+	}
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+func (s *passwordService) RecordPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	return s.repo.AddHistory(ctx, userID, passwordHash)
+}
+
+func (s *passwordService) IsExpired(ctx context.Context, user *models.User) bool {
+	policy, err := s.repo.GetPolicy(ctx, user.TenantID)
+	if err != nil {
+		return false
+	}
+	return policy.IsExpired(user.PasswordChangedAt, time.Now())
+}
+
+// policyProblems lists the rules of the policy the password breaks
+func policyProblems(policy *models.PasswordPolicy, password string) []string {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	var problems []string
+	if len([]rune(password)) < policy.MinLength {
+		problems = append(problems, "at least "+strconv.Itoa(policy.MinLength)+" characters")
+	}
+	if policy.RequireUppercase && !upper {
+		problems = append(problems, "an uppercase letter")
+	}
+	if policy.RequireLowercase && !lower {
+		problems = append(problems, "a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		problems = append(problems, "a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		problems = append(problems, "a symbol")
+	}
+	return problems
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// scenario is a request whose latency is held to a budget. reset, when
+// set, restores the data the request changes before each run.
+type scenario struct {
+	name   string
+	method string
+	url    string
+	budget time.Duration
+	reset  func(ctx context.Context) error
+}
+
+func runBudget(args []string) error {
+	fs := flag.NewFlagSet("budget", flag.ExitOnError)
+	token := tokenFlag(fs)
+	bookkeepingURL := fs.String("bookkeeping-url", "http://localhost:8084", "base URL of the bookkeeping service")
+	invoiceURL := fs.String("invoice-url", "http://localhost:8085", "base URL of the invoice service")
+	reportURL := fs.String("report-url", "http://localhost:8086", "base URL of the report service")
+	runs := fs.Int("runs", 5, "timed runs of each request, after one warm-up run")
+	trialBalance := fs.Duration("trial-balance", 2*time.Second, "p95 budget of the trial balance")
+	profitLoss := fs.Duration("profit-loss", 2*time.Second, "p95 budget of the profit and loss statement")
+	invoiceList := fs.Duration("invoice-list", 500*time.Millisecond, "p95 budget of the first page of invoices")
+	autoReconcile := fs.Duration("auto-reconcile", time.Minute, "p95 budget of auto-reconciling the load-test bank account")
+	_ = fs.Parse(args)
+
+	if *token == "" {
+		return errors.New("a token is required")
+	}
+	if *runs < 1 {
+		return errors.New("runs must be positive")
+	}
+	who, err := callerOf(*token)
+	if err != nil {
+		return err
+	}
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bankAccountID, err := findBankAccount(ctx, db, who.TenantID)
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	yearAgo := time.Now().AddDate(-1, 0, 1).Format("2006-01-02")
+	scenarios := []scenario{
+		{
+			name:   "trial balance",
+			method: http.MethodGet,
+			url:    *reportURL + "/api/v1/reports/trial-balance?as_of=" + today,
+			budget: *trialBalance,
+		},
+		{
+			name:   "profit and loss",
+			method: http.MethodGet,
+			url:    *reportURL + "/api/v1/reports/profit-loss?from_date=" + yearAgo + "&to_date=" + today,
+			budget: *profitLoss,
+		},
+		{
+			name:   "invoice list",
+			method: http.MethodGet,
+			url:    *invoiceURL + "/api/v1/invoices",
+			budget: *invoiceList,
+		},
+		{
+			name:   "auto-reconcile",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/api/v1/bank/accounts/%s/auto-reconcile", *bookkeepingURL, bankAccountID),
+			budget: *autoReconcile,
+			reset: func(ctx context.Context) error {
+				return unreconcile(ctx, db, bankAccountID)
+			},
+		},
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "SCENARIO\tP95\tMAX\tBUDGET\tRESULT")
+	missed := 0
+	for _, s := range scenarios {
+		timings, err := s.measure(ctx, *token, *runs)
+		if err != nil {
+			fmt.Fprintf(out, "%s\t-\t-\t%s\tERROR: %v\n", s.name, s.budget, err)
+			missed++
+			continue
+		}
+		p95 := percentile(timings, 95)
+		result := "ok"
+		if p95 > s.budget {
+			result = "OVER BUDGET"
+			missed++
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\n", s.name, p95.Round(time.Millisecond),
+			timings[len(timings)-1].Round(time.Millisecond), s.budget, result)
+	}
+	_ = out.Flush()
+
+	if missed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d budgets missed\n", missed, len(scenarios))
+		os.Exit(1)
+	}
+	return nil
+}
+
+// measure times runs of the request after a warm-up run, returning the
+// timings sorted
+func (s scenario) measure(ctx context.Context, token string, runs int) ([]time.Duration, error) {
+	timings := make([]time.Duration, 0, runs)
+	for i := 0; i <= runs; i++ {
+		if s.reset != nil {
+			if err := s.reset(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		started := time.Now()
+		status, err := call(ctx, s.method, s.url, token, nil)
+		elapsed := time.Since(started)
+		if err != nil {
+			return nil, err
+		}
+		if status < 200 || status > 299 {
+			return nil, fmt.Errorf("status %d", status)
+		}
+		if i > 0 {
+			timings = append(timings, elapsed)
+		}
+	}
+
+	sort.Slice(timings, func(i, j int) bool { return timings[i] < timings[j] })
+	return timings, nil
+}
+
+// percentile returns the nearest-rank percentile of sorted timings
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// unreconcile undoes the matches of the bank account's statement lines, so
+// each auto-reconcile run has the whole statement to match
+func unreconcile(ctx context.Context, db *gorm.DB, bankAccountID uuid.UUID) error {
+	return db.WithContext(ctx).Model(&models.BankTransaction{}).
+		Where("bank_account_id = ? AND is_reconciled = ?", bankAccountID, true).
+		Updates(map[string]interface{}{
+			"is_reconciled":             false,
+			"reconciled_transaction_id": nil,
+			"reconciled_at":             nil,
+			"reconciled_by":             nil,
+		}).Error
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+// call makes an authenticated JSON request, returning the response status.
+// The body is read to the end so the timing covers all of it.
+func call(ctx context.Context, method, url, token string, body interface{}) (int, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, err
+}
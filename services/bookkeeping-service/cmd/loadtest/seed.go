@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// seedOptions shape the synthetic tenant. The same seed and counts give
+// the same ledger, so results can be compared between releases.
+type seedOptions struct {
+	transactions int
+	months       int
+	seed         int64
+	batch        int
+	invoices     int
+	invoiceURL   string
+	appendLedger bool
+}
+
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	token := tokenFlag(fs)
+	var opts seedOptions
+	fs.IntVar(&opts.transactions, "transactions", 1000000, "ledger transactions to post, of two or three lines each")
+	fs.IntVar(&opts.months, "months", 24, "months up to today the transactions are spread over")
+	fs.Int64Var(&opts.seed, "seed", 1, "random seed of the amounts and mix of transactions")
+	fs.IntVar(&opts.batch, "batch", 2000, "transactions posted per database transaction")
+	fs.IntVar(&opts.invoices, "invoices", 0, "invoices to create through the invoice service")
+	fs.StringVar(&opts.invoiceURL, "invoice-url", "http://localhost:8085", "base URL of the invoice service")
+	fs.BoolVar(&opts.appendLedger, "append", false, "add to a tenant that already has transactions")
+	_ = fs.Parse(args)
+
+	if *token == "" {
+		return errors.New("a token is required")
+	}
+	if opts.transactions < 0 || opts.months < 1 || opts.batch < 1 || opts.invoices < 0 {
+		return errors.New("counts must be positive")
+	}
+	who, err := callerOf(*token)
+	if err != nil {
+		return err
+	}
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	f, err := newFixture(ctx, db, who, opts)
+	if err != nil {
+		return err
+	}
+	if err := f.seedLedger(ctx); err != nil {
+		return err
+	}
+	return seedInvoices(ctx, opts, *token)
+}
+
+// fixture generates a tenant's ledger. Sales and purchases run on credit;
+// receipts, payments and bank charges go through the load-test bank
+// account and also appear on its statement, a day or two later at most,
+// for auto-reconcile to match.
+type fixture struct {
+	db           *gorm.DB
+	transactions repository.TransactionRepository
+	bank         repository.BankRepository
+	who          *caller
+	opts         seedOptions
+	rng          *rand.Rand
+
+	accounts    map[string]uuid.UUID // By code
+	bankAccount *models.BankAccount
+	numberFrom  int64 // Transactions the tenant already has
+	balance     float64
+}
+
+func newFixture(ctx context.Context, db *gorm.DB, who *caller, opts seedOptions) (*fixture, error) {
+	f := &fixture{
+		db:           db,
+		transactions: repository.NewTransactionRepository(db),
+		bank:         repository.NewBankRepository(db),
+		who:          who,
+		opts:         opts,
+		rng:          rand.New(rand.NewSource(opts.seed)),
+		accounts:     make(map[string]uuid.UUID),
+	}
+
+	err := db.WithContext(ctx).Unscoped().Model(&models.Transaction{}).
+		Where("tenant_id = ?", who.TenantID).Count(&f.numberFrom).Error
+	if err != nil {
+		return nil, err
+	}
+	if f.numberFrom > 0 && !opts.appendLedger {
+		return nil, fmt.Errorf("tenant %s already has %d transactions; pass -append to add to them", who.TenantID, f.numberFrom)
+	}
+
+	accountRepo := repository.NewAccountRepository(db)
+	chart, err := accountRepo.GetChartOfAccounts(ctx, who.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chart) == 0 {
+		if err := accountRepo.CreateDefaultAccounts(ctx, who.TenantID); err != nil {
+			return nil, err
+		}
+		if chart, err = accountRepo.GetChartOfAccounts(ctx, who.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	for _, account := range chart {
+		f.accounts[account.Code] = account.ID
+	}
+	for _, code := range []string{"1200", "1300", "1600", "2100", "2200", "4100", "5200", "5300", "5500", "5700"} {
+		if _, ok := f.accounts[code]; !ok {
+			return nil, fmt.Errorf("the tenant's chart of accounts has no account %s", code)
+		}
+	}
+
+	f.bankAccount, err = f.ensureBankAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *fixture) ensureBankAccount(ctx context.Context) (*models.BankAccount, error) {
+	if id, err := findBankAccount(ctx, f.db, f.who.TenantID); err == nil {
+		return f.bank.GetBankAccountByID(ctx, id)
+	}
+
+	ledgerAccount := f.accounts["1200"]
+	account := &models.BankAccount{
+		TenantID:    f.who.TenantID,
+		AccountID:   &ledgerAccount,
+		BankName:    bankAccountName,
+		AccountName: "Synthetic current account",
+		AccountType: "current",
+		IsActive:    true,
+	}
+	if err := f.bank.CreateBankAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (f *fixture) seedLedger(ctx context.Context) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, -f.opts.months, 0)
+	days := int(today.Sub(start).Hours() / 24)
+
+	started := time.Now()
+	for done := 0; done < f.opts.transactions; {
+		size := min(f.opts.batch, f.opts.transactions-done)
+		transactions := make([]models.Transaction, 0, size)
+		var statement []models.BankTransaction
+		for i := done; i < done+size; i++ {
+			// Dates rise with the chain, as they mostly do in real books
+			date := start.AddDate(0, 0, int(int64(i)*int64(days)/int64(f.opts.transactions)))
+			tx, bankLine := f.transaction(f.numberFrom+int64(i)+1, date)
+			transactions = append(transactions, tx)
+			if bankLine != nil {
+				statement = append(statement, *bankLine)
+			}
+		}
+
+		if err := f.transactions.CreateBatch(ctx, transactions); err != nil {
+			return err
+		}
+		if err := f.bank.CreateBankTransactions(ctx, statement); err != nil {
+			return err
+		}
+		done += size
+		log.Printf("posted %d/%d transactions (%s)", done, f.opts.transactions, time.Since(started).Round(time.Second))
+	}
+	return nil
+}
+
+// transaction generates the nth transaction of the tenant, with the line
+// its bank statement shows if it goes through the bank
+func (f *fixture) transaction(n int64, date time.Time) (models.Transaction, *models.BankTransaction) {
+	amount := float64(f.rng.Intn(20000000)+10000) / 100 // ₹100 to ₹2,00,100
+	gst := math.Round(amount*18) / 100
+
+	tx := models.Transaction{
+		TenantID:          f.who.TenantID,
+		TransactionNumber: fmt.Sprintf("LT-%08d", n),
+		TransactionDate:   date,
+		CreatedBy:         f.who.UserID,
+	}
+	line := func(code string, debit, credit float64) models.TransactionLine {
+		return models.TransactionLine{AccountID: f.accounts[code], DebitAmount: debit, CreditAmount: credit, LineOrder: len(tx.Lines)}
+	}
+
+	var bankDebit, bankCredit float64
+	switch roll := f.rng.Intn(100); {
+	case roll < 35:
+		tx.TransactionType = models.TransactionTypeSale
+		tx.Description = "Sale to synthetic customer"
+		tx.Lines = append(tx.Lines, line("1300", amount+gst, 0))
+		tx.Lines = append(tx.Lines, line("4100", 0, amount))
+		tx.Lines = append(tx.Lines, line("2200", 0, gst))
+	case roll < 60:
+		tx.TransactionType = models.TransactionTypeReceipt
+		tx.Description = "Receipt from synthetic customer"
+		tx.PaymentMode = models.PaymentModeBank
+		tx.Lines = append(tx.Lines, line("1200", amount, 0))
+		tx.Lines = append(tx.Lines, line("1300", 0, amount))
+		bankCredit = amount
+	case roll < 80:
+		expense := []string{"5200", "5300", "5500"}[f.rng.Intn(3)]
+		tx.TransactionType = models.TransactionTypePurchase
+		tx.Description = "Purchase from synthetic vendor"
+		tx.Lines = append(tx.Lines, line(expense, amount, 0))
+		tx.Lines = append(tx.Lines, line("1600", gst, 0))
+		tx.Lines = append(tx.Lines, line("2100", 0, amount+gst))
+	case roll < 95:
+		tx.TransactionType = models.TransactionTypePayment
+		tx.Description = "Payment to synthetic vendor"
+		tx.PaymentMode = models.PaymentModeBank
+		tx.Lines = append(tx.Lines, line("2100", amount, 0))
+		tx.Lines = append(tx.Lines, line("1200", 0, amount))
+		bankDebit = amount
+	default:
+		charge := float64(f.rng.Intn(50000)+100) / 100
+		tx.TransactionType = models.TransactionTypeExpense
+		tx.Description = "Bank charges"
+		tx.PaymentMode = models.PaymentModeBank
+		tx.Lines = append(tx.Lines, line("5700", charge, 0))
+		tx.Lines = append(tx.Lines, line("1200", 0, charge))
+		bankDebit = charge
+	}
+	tx.Subtotal = amount
+	tx.TotalAmount = amount
+	if tx.TransactionType == models.TransactionTypeSale || tx.TransactionType == models.TransactionTypePurchase {
+		tx.TaxAmount = gst
+		tx.TotalAmount = amount + gst
+	}
+	if bankDebit == 0 && bankCredit == 0 {
+		return tx, nil
+	}
+
+	tx.Subtotal = bankDebit + bankCredit
+	tx.TotalAmount = tx.Subtotal
+	tx.PaymentReference = fmt.Sprintf("UTR%012d", n)
+	f.balance += bankCredit - bankDebit
+	return tx, &models.BankTransaction{
+		BankAccountID:   f.bankAccount.ID,
+		TenantID:        f.who.TenantID,
+		TransactionDate: date.AddDate(0, 0, f.rng.Intn(3)),
+		Description:     tx.Description,
+		Reference:       tx.PaymentReference,
+		DebitAmount:     bankDebit,
+		CreditAmount:    bankCredit,
+		Balance:         math.Round(f.balance*100) / 100,
+	}
+}
+
+// seedInvoices creates invoices through the invoice service, so they carry
+// everything it derives when issuing one
+func seedInvoices(ctx context.Context, opts seedOptions, token string) error {
+	if opts.invoices == 0 {
+		return nil
+	}
+
+	const workers = 8
+	rng := rand.New(rand.NewSource(opts.seed))
+	rates := make([]int, opts.invoices)
+	for i := range rates {
+		rates[i] = rng.Intn(5000000) + 10000
+	}
+
+	today := time.Now().UTC()
+	var next, failed atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= opts.invoices {
+					return
+				}
+				invoice := map[string]interface{}{
+					"customer_name":  fmt.Sprintf("Synthetic Customer %d", i%500+1),
+					"customer_state": "Karnataka",
+					"invoice_date":   today.AddDate(0, 0, -i*opts.months*30/opts.invoices).Format("2006-01-02"),
+					"items": []map[string]interface{}{{
+						"description": "Synthetic service",
+						"quantity":    "1",
+						"rate":        fmt.Sprintf("%d.%02d", rates[i]/100, rates[i]%100),
+						"cgst_rate":   "9",
+						"sgst_rate":   "9",
+					}},
+				}
+				status, err := call(ctx, http.MethodPost, opts.invoiceURL+"/api/v1/invoices", token, invoice)
+				if err != nil || status != http.StatusCreated {
+					if failed.Add(1) <= 5 {
+						log.Printf("invoice %d: status %d: %v", i+1, status, err)
+					}
+				}
+				if i%1000 == 999 {
+					log.Printf("created %d/%d invoices", i+1, opts.invoices)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d of %d invoices could not be created", n, opts.invoices)
+	}
+	return nil
+}
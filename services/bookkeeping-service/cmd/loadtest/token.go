@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/config"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+)
+
+// runToken prints an admin token for the load-test tenant, signed with the
+// services' JWT secret, for environments without the auth service such as
+// CI. The tenant service must still know the user as a member of the
+// tenant for the report service to answer.
+func runToken(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	tenant := fs.String("tenant", "", "tenant to issue the token for")
+	user := fs.String("user", "", "user to issue the token for")
+	ttl := fs.Duration("ttl", 2*time.Hour, "how long the token stays valid")
+	_ = fs.Parse(args)
+
+	tenantID, err := uuid.Parse(*tenant)
+	if err != nil {
+		return errors.New("a tenant ID is required")
+	}
+	userID, err := uuid.Parse(*user)
+	if err != nil {
+		return errors.New("a user ID is required")
+	}
+	if *ttl <= 0 {
+		return errors.New("ttl must be positive")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	secret := cfg.JWTSecret()
+	if secret == "" {
+		return errors.New("no JWT secret configured")
+	}
+
+	now := time.Now()
+	claims := &middleware.Claims{
+		UserID:   userID.String(),
+		TenantID: tenantID.String(),
+		Roles:    []string{"admin"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    cfg.JWT.Issuer,
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(*ttl)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
@@ -10,13 +10,24 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/config"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/handlers"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/comments"
+	sharedConfig "github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/features"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/support"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/webhook"
 )
 
 func main() {
@@ -41,6 +52,15 @@ func main() {
 		MaxIdleConns:    cfg.Database.MaxIdleConns,
 		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
 		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -53,74 +73,262 @@ func main() {
 		&models.Account{},
 		&models.BankAccount{},
 		&models.FinancialYear{},
+		&models.FinancialPeriod{},
 		&models.Transaction{},
 		&models.TransactionLine{},
+		&models.TransactionSupportingDetail{},
 		&models.BankTransaction{},
+		&models.BankFeedConnection{},
+		&models.ReconciliationRun{},
+		&models.CorporateCard{},
+		&models.CardSpend{},
+		&models.BankRule{},
+		&models.StandingInstruction{},
 		&models.RecurringJournal{},
 		&models.RecurringJournalLine{},
 		&models.GeneratedJournal{},
+		&models.CashClosing{},
+		&models.CashClosingSettings{},
+		&models.AccountReconciliation{},
+		&models.AccountReconciliationItem{},
+		&models.CloseTaskTemplate{},
+		&models.CloseChecklist{},
+		&models.CloseTask{},
+		&models.AccountMapping{},
+		&comments.Comment{},
+		&comments.Mention{},
+		&comments.Event{},
+		&imports.Job{},
+		&imports.RowError{},
+		&jobs.Job{},
+		&webhook.InboundEvent{},
+		&timeline.Event{},
+		&support.Bundle{},
+		&support.ErrorLog{},
+		&database.NumberSequence{},
+		&database.ResourceVersion{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
 	// Initialize repositories
 	accountRepo := repository.NewAccountRepository(db)
+	// Charts created before a posting type's account joined the default
+	// chart, such as Round Off, get it now
+	if added, err := accountRepo.AddMissingPostingAccounts(context.Background()); err != nil {
+		log.Fatalf("Failed to add missing posting accounts: %v", err)
+	} else if added > 0 {
+		log.Printf("Added %d missing posting accounts", added)
+	}
+	versions := database.NewVersionStore(db)
 	transactionRepo := repository.NewTransactionRepository(db)
 	bankRepo := repository.NewBankRepository(db)
+	bankFeedRepo := repository.NewBankFeedRepository(db)
+	cardRepo := repository.NewCardRepository(db)
+	bankRuleRepo := repository.NewBankRuleRepository(db)
+	standingInstructionRepo := repository.NewStandingInstructionRepository(db)
 	recurringJournalRepo := repository.NewRecurringJournalRepository(db)
+	cashClosingRepo := repository.NewCashClosingRepository(db)
+	accountReconciliationRepo := repository.NewAccountReconciliationRepository(db)
+	closeChecklistRepo := repository.NewCloseChecklistRepository(db)
+	accountMappingRepo := repository.NewAccountMappingRepository(db)
+	periodRepo := repository.NewFinancialPeriodRepository(db)
+
+	// Initialize clients
+	invoiceClient := clients.NewInvoiceClient(sharedConfig.GetEnv("INVOICE_SERVICE_URL", "http://bookkeeping-invoice-service:8080"))
+	tenantClient := clients.NewTenantClient(cfg.Network.TenantServiceURL)
+	var bankFeedProvider clients.BankFeedProvider
+	if cfg.BankFeedProviderURL != "" {
+		bankFeedProvider = clients.NewAccountAggregatorClient(cfg.BankFeedProviderURL, cfg.BankFeedClientID, cfg.BankFeedClientSecret)
+	}
+
+	// Statement imports run in the background; jobs cut off by a restart
+	// are failed so they don't show as running forever
+	importRunner := imports.NewRunner(db, imports.Config{})
+	if err := importRunner.FailInterrupted(context.Background()); err != nil {
+		log.Printf("Failed to clean up interrupted imports: %v", err)
+	}
 
 	// Initialize services
 	accountService := services.NewAccountService(accountRepo)
-	transactionService := services.NewTransactionService(transactionRepo, accountRepo)
-	bankService := services.NewBankService(bankRepo, transactionRepo)
+	accountMappingService := services.NewAccountMappingService(accountMappingRepo, accountRepo)
+	periodService := services.NewPeriodService(periodRepo, tenantClient)
+	journalValidator := services.NewJournalValidator(cfg.BaseCurrency, cfg.JournalLineTolerance)
+	// Voids and retags of transactions are kept for their timelines
+	timelineStore := timeline.NewStore(db)
+	// Journals entered by users are checked against the tenant's own
+	// validation rules, kept by the tenant service
+	validationRules := validation.NewClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo, accountMappingService, periodService, journalValidator, timelineStore, validationRules, invoiceClient)
+	bankRuleService := services.NewBankRuleService(bankRuleRepo, bankRepo, transactionRepo, accountRepo, accountMappingService)
+	bankService := services.NewBankService(bankRepo, transactionRepo, cardRepo, bankRuleService, importRunner)
+	cardService := services.NewCardService(cardRepo, bankRepo, invoiceClient)
 	recurringJournalService := services.NewRecurringJournalService(recurringJournalRepo, transactionService)
+	interCompanyService := services.NewInterCompanyService(transactionRepo, accountRepo, accountMappingService, journalValidator, tenantClient)
+	cashClosingService := services.NewCashClosingService(cashClosingRepo, transactionRepo, accountRepo, accountMappingService)
+	accountReconciliationService := services.NewAccountReconciliationService(accountReconciliationRepo, accountRepo, transactionRepo, bankRepo)
+	closeChecklistService := services.NewCloseChecklistService(closeChecklistRepo)
+	ledgerChainService := services.NewLedgerChainService(transactionRepo)
+	standingInstructionService := services.NewStandingInstructionService(standingInstructionRepo, bankRepo, accountRepo, recurringJournalService)
+
+	// Background jobs. Recurring journals are generated by an hourly
+	// job queued once across all instances; bank feeds are scanned for
+	// standing instructions daily. Connected bank feeds are synced hourly
+	// and whenever their provider calls back with new data.
+	jobQueue := jobs.NewQueue(db, jobs.Config{})
+	bankFeedService := services.NewBankFeedService(bankFeedRepo, bankRepo, bankRuleService, bankFeedProvider, jobQueue)
+	jobQueue.Register(services.JobGenerateRecurringJournals, func(ctx context.Context, job *jobs.Job) error {
+		_, err := recurringJournalService.GenerateDueJournals(ctx)
+		return err
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobGenerateRecurringJournals, time.Hour)
+	jobQueue.Register(services.JobDetectStandingInstructions, func(ctx context.Context, job *jobs.Job) error {
+		return standingInstructionService.DetectAll(ctx)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobDetectStandingInstructions, 24*time.Hour)
+	jobQueue.Register(services.JobSyncBankFeed, func(ctx context.Context, job *jobs.Job) error {
+		var payload services.SyncBankFeedPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return bankFeedService.Sync(ctx, payload.ConnectionID)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Register(services.JobSyncBankFeeds, func(ctx context.Context, job *jobs.Job) error {
+		return bankFeedService.SyncAll(ctx)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobSyncBankFeeds, time.Hour)
+	jobQueue.Start(context.Background())
 
 	// Initialize handlers
 	accountHandler := handlers.NewAccountHandler(accountService)
+	accountMappingHandler := handlers.NewAccountMappingHandler(accountMappingService)
+	periodHandler := handlers.NewPeriodHandler(periodService)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 	bankHandler := handlers.NewBankHandler(bankService)
+	bankRuleHandler := handlers.NewBankRuleHandler(bankRuleService)
+	bankFeedHandler := handlers.NewBankFeedHandler(bankFeedService)
+	standingInstructionHandler := handlers.NewStandingInstructionHandler(standingInstructionService)
+	cardHandler := handlers.NewCardHandler(cardService)
 	recurringJournalHandler := handlers.NewRecurringJournalHandler(recurringJournalService)
+	interCompanyHandler := handlers.NewInterCompanyHandler(interCompanyService)
+	cashClosingHandler := handlers.NewCashClosingHandler(cashClosingService)
+	accountReconciliationHandler := handlers.NewAccountReconciliationHandler(accountReconciliationService)
+	closeChecklistHandler := handlers.NewCloseChecklistHandler(closeChecklistService)
+	ledgerChainHandler := handlers.NewLedgerChainHandler(ledgerChainService)
+	// Internal comment threads on transactions; mentioned users get an
+	// in-app notification
+	commentStore := comments.NewStore(db, comments.NewNotificationServiceNotifier(sharedConfig.GetEnv("NOTIFICATION_SERVICE_URL", "http://bookkeeping-notification-service:8080")))
+	transactionCommentHandler := comments.NewHandler(commentStore, comments.DocumentConfig{
+		Type:   comments.DocumentTransaction,
+		Link:   "/transactions/%s",
+		Lookup: services.TransactionCommentLookup(transactionRepo),
+	})
+	transactionTimelineHandler := timeline.NewHandler(timelineStore, timeline.DocumentConfig{
+		Type:    timeline.DocumentTransaction,
+		Compose: services.TransactionTimeline(transactionRepo, bankRepo),
+	})
+	importHandler := imports.NewHandler(importRunner)
+
+	// Support bundles on transactions and reconciliations, built with the
+	// owner's consent from the document, its timeline and the tenant's
+	// recent error responses
+	supportService := support.NewService(db, timelineStore, support.Config{
+		Service: "bookkeeping-service",
+		Documents: []support.DocumentConfig{
+			services.TransactionSupportDocument(transactionRepo, bankRepo),
+			services.AccountReconciliationSupportDocument(accountReconciliationRepo),
+			services.BankReconciliationSupportDocument(bankRepo),
+		},
+	})
+	supportService.Start(context.Background())
+	supportHandler := support.NewHandler(supportService)
+
+	// Callbacks from bank feed partners, enabled by their signing secrets.
+	// Consent changes update the connection and new data queues a sync;
+	// callbacks for unknown consents are kept as dead letters to be
+	// replayed.
+	webhookReceiver := webhook.NewReceiver(db)
+	if len(cfg.BankFeedWebhookSecrets) > 0 {
+		webhookReceiver.Register("bank-feed", webhook.Signed(webhook.SignatureHeader, cfg.BankFeedWebhookSecrets...), bankFeedService.HandleCallback, webhook.SourceOptions{})
+	}
+	webhookHandler := webhook.NewInboundHandler(webhookReceiver)
+	jobHandler := jobs.NewAdminHandler(jobQueue)
 	healthHandler := handlers.NewHealthHandler(db)
 
 	// Setup router
 	router := gin.New()
 
-	// Allowed CORS origins
-	allowedOrigins := []string{
-		"https://app.bookkeep.in",
-		"https://www.bookkeep.in",
-		"https://bookkeep.in",
-	}
-	if !cfg.IsProduction() {
-		allowedOrigins = append(allowedOrigins,
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"exp://localhost:19000",
-		)
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(router, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// Apply middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.RequestIDMiddleware())
+	router.Use(supportService.RecordErrors())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.CORSMiddleware(allowedOrigins))
+	router.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
+	router.Use(i18n.Middleware(i18n.DefaultLanguage))
 
 	// Health endpoints (no auth required)
 	router.GET("/health", healthHandler.Health)
 	router.GET("/ready", healthHandler.Ready)
+	router.GET("/metrics", gin.WrapH(database.MetricsHandler(db)))
+
+	// Provider callbacks (public, authenticated by their signatures)
+	router.POST("/api/v1/public/webhooks/:source", webhookHandler.Receive)
+
+	// Tenants' IP and country restrictions, read from the tenant service
+	networkPolicies := middleware.NewNetworkPolicyClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+
+	// Calls to other services made without a user, such as to read flags
+	// or log auditors' requests, use a service token
+	serviceCredentials := middleware.NewServiceCredentials(cfg.App.Name, cfg.JWT.Issuer, cfg.JWTSecret)
+
+	// Features being rolled out, read from the auth service's flags
+	featureFlags := features.NewClient(cfg.Network.AuthServiceURL, serviceCredentials, features.Config{})
+
+	// Bulk exports need the data:export permission and are recorded in the
+	// tenant's audit log
+	exportGuard := middleware.NewExportGuard(middleware.NewExportAuditClient(cfg.Network.TenantServiceURL))
+
+	// Books auditors may read: the chart of accounts, without today's
+	// balances, ledgers and transactions filtered by the dates their
+	// grant's year is held to, transactions dated in it (see
+	// AuditedYearOnly), and whether a date's period is closed
+	auditorRoutes := []string{
+		"/api/v1/accounts",
+		"/api/v1/accounts/chart",
+		"/api/v1/accounts/:id/ledger",
+		"/api/v1/transactions",
+		"/api/v1/transactions/:id",
+		"/api/v1/transactions/:id/supporting-details",
+		"/api/v1/transactions/:id/history",
+		"/api/v1/transactions/:id/comments",
+		"/api/v1/financial-years/period-status",
+	}
 
 	// Protected endpoints
 	jwtConfig := middleware.JWTConfig{
-		Secret:    cfg.JWT.Secret,
-		Issuer:    cfg.JWT.Issuer,
-		SkipPaths: []string{"/health", "/ready"},
+		Secret:          cfg.JWT.Secret,
+		Secrets:         cfg.JWTSecrets,
+		RefreshSecrets:  cfg.RefreshJWTSecrets,
+		Issuer:          cfg.JWT.Issuer,
+		SkipPaths:       []string{"/health", "/ready", "/metrics"},
+		NetworkPolicies: networkPolicies,
+		CountryHeader:   cfg.Network.CountryHeader,
+		AuditorRoutes:   auditorRoutes,
+		AuditorGrants:   middleware.NewAuditorGrantClient(cfg.Network.AuthServiceURL, cfg.Network.PolicyCacheTTL, serviceCredentials),
 	}
 
 	api := router.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware(jwtConfig))
+	api.Use(timeline.Actor())
 	{
 		// Accounts / Chart of Accounts
-		accounts := api.Group("/accounts")
+		accounts := api.Group("/accounts", middleware.ConditionalRequests(versions, repository.AccountsResource))
 		{
 			accounts.GET("", accountHandler.ListAccounts)
 			accounts.POST("", accountHandler.CreateAccount)
@@ -128,20 +336,70 @@ func main() {
 			accounts.GET("/type/:type", accountHandler.GetAccountsByType)
 			accounts.POST("/initialize", accountHandler.InitializeAccounts)
 			accounts.GET("/:id", accountHandler.GetAccount)
+			accounts.GET("/:id/ledger", exportGuard.ExportWhen("account_ledger", middleware.FormatNotJSON), accountHandler.GetAccountLedger)
 			accounts.PUT("/:id", accountHandler.UpdateAccount)
 			accounts.DELETE("/:id", accountHandler.DeleteAccount)
 		}
 
+		// Accounts that quick entries and automated postings go to
+		accountMappings := api.Group("/account-mappings")
+		{
+			accountMappings.GET("", accountMappingHandler.List)
+			accountMappings.PUT("", accountMappingHandler.Update)
+		}
+
+		// Financial years and period close. Transactions dated in a closed
+		// period cannot be posted, edited or voided, nor posted outside the
+		// tenant's financial years, which roll forward as they are reached.
+		financialYears := api.Group("/financial-years")
+		{
+			financialYears.GET("", periodHandler.ListYears)
+			financialYears.POST("", periodHandler.CreateYear)
+			financialYears.GET("/period-status", periodHandler.Status)
+			financialYears.GET("/:id/periods", periodHandler.ListPeriods)
+			financialYears.GET("/:id/calendar", periodHandler.Calendar)
+			financialYears.POST("/:id/periods/:period/close", periodHandler.ClosePeriod)
+			financialYears.POST("/:id/periods/:period/reopen", handlers.RequirePermission(tenantClient, services.PermPeriodReopen), periodHandler.ReopenPeriod)
+		}
+
 		// Transactions
-		transactions := api.Group("/transactions")
+		transactions := api.Group("/transactions", transactionHandler.AuditedYearOnly())
 		{
 			transactions.GET("", transactionHandler.ListTransactions)
 			transactions.POST("", transactionHandler.CreateTransaction)
 			transactions.POST("/quick-sale", transactionHandler.CreateQuickSale)
 			transactions.POST("/quick-expense", transactionHandler.CreateQuickExpense)
+			transactions.POST("/bill-payment", transactionHandler.CreateBillPayment)
+			transactions.POST("/customer-advance", transactionHandler.CreateCustomerAdvance)
+			transactions.POST("/document", transactionHandler.PostDocument)
 			transactions.GET("/daily-summary", transactionHandler.GetDailySummary)
+			transactions.GET("/tags", transactionHandler.ListTags)
+			transactions.PUT("/tags/:tag", transactionHandler.RenameTag)
+			transactions.DELETE("/tags/:tag", transactionHandler.DeleteTag)
 			transactions.GET("/:id", transactionHandler.GetTransaction)
+			transactions.GET("/:id/supporting-details", transactionHandler.GetSupportingDetails)
+			transactions.GET("/:id/history", transactionTimelineHandler.History)
 			transactions.POST("/:id/void", transactionHandler.VoidTransaction)
+			transactions.PUT("/:id/tags", transactionHandler.SetTags)
+			transactions.GET("/:id/comments", transactionCommentHandler.List)
+			transactions.POST("/:id/comments", transactionCommentHandler.Add)
+			transactions.GET("/:id/comments/history", transactionCommentHandler.History)
+			transactions.PUT("/:id/comments/:comment_id", transactionCommentHandler.Edit)
+			transactions.DELETE("/:id/comments/:comment_id", transactionCommentHandler.Delete)
+		}
+
+		// Tamper-evident hash chain over posted transactions
+		ledgerChain := api.Group("/ledger-chain")
+		{
+			ledgerChain.GET("/head", ledgerChainHandler.GetHead)
+			ledgerChain.GET("/verify", ledgerChainHandler.Verify)
+		}
+
+		// Inter-company transactions between group tenants
+		interCompany := api.Group("/inter-company")
+		{
+			interCompany.POST("/transactions", interCompanyHandler.CreateTransaction)
+			interCompany.GET("/mismatches", interCompanyHandler.MismatchReport)
 		}
 
 		// Bank Accounts & Reconciliation
@@ -156,10 +414,82 @@ func main() {
 			bank.GET("/accounts/:id/transactions", bankHandler.GetBankTransactions)
 			bank.GET("/accounts/:id/unreconciled", bankHandler.GetUnreconciledTransactions)
 			bank.POST("/accounts/:id/auto-reconcile", bankHandler.AutoReconcile)
+			bank.GET("/accounts/:id/auto-reconcile", bankHandler.GetAutoReconcileStatus)
 			bank.GET("/accounts/:id/reconciliation-summary", bankHandler.GetReconciliationSummary)
 			bank.POST("/transactions/:tx_id/reconcile", bankHandler.ReconcileTransaction)
 			bank.POST("/transactions/:tx_id/unreconcile", bankHandler.UnreconcileTransaction)
 			bank.GET("/transactions/:tx_id/suggest-matches", bankHandler.SuggestMatches)
+
+			// Bank feeds
+			bank.GET("/accounts/:id/feed", bankFeedHandler.Get)
+			bank.POST("/accounts/:id/feed", bankFeedHandler.Connect)
+			bank.POST("/accounts/:id/feed/refresh", bankFeedHandler.Refresh)
+			bank.POST("/accounts/:id/feed/pause", bankFeedHandler.Pause)
+			bank.POST("/accounts/:id/feed/resume", bankFeedHandler.Resume)
+			bank.DELETE("/accounts/:id/feed", bankFeedHandler.Disconnect)
+
+			// Corporate card feeds
+			bank.GET("/accounts/:id/cards", cardHandler.ListCards)
+			bank.PUT("/accounts/:id/cards", cardHandler.AssignCard)
+			bank.GET("/card-spends", cardHandler.ListSpends)
+			bank.GET("/card-spends/report", cardHandler.UncategorizedReport)
+			bank.PUT("/card-spends/:spend_id/assign", cardHandler.AssignSpend)
+			bank.PUT("/card-spends/:spend_id/categorize", cardHandler.CategorizeSpend)
+			bank.POST("/card-spends/:spend_id/match", cardHandler.MatchClaim)
+
+			// Charges and interest recognition rules
+			bank.GET("/rules", bankRuleHandler.List)
+			bank.POST("/rules", bankRuleHandler.Create)
+			bank.POST("/rules/defaults", bankRuleHandler.InstallDefaults)
+			bank.PUT("/rules/:rule_id", bankRuleHandler.Update)
+			bank.DELETE("/rules/:rule_id", bankRuleHandler.Delete)
+			bank.POST("/accounts/:id/apply-rules", bankRuleHandler.ApplyToAccount)
+
+			// Standing instructions detected in bank feeds
+			bank.GET("/standing-instructions", standingInstructionHandler.List)
+			bank.POST("/accounts/:id/standing-instructions/detect", standingInstructionHandler.Detect)
+			bank.POST("/standing-instructions/:si_id/recurring", standingInstructionHandler.CreateRecurring)
+			bank.PUT("/standing-instructions/:si_id/link", standingInstructionHandler.Link)
+			bank.POST("/standing-instructions/:si_id/dismiss", standingInstructionHandler.Dismiss)
+		}
+
+		// Import jobs
+		importJobs := api.Group("/imports")
+		{
+			importJobs.GET("", importHandler.List)
+			importJobs.GET("/:id", importHandler.Get)
+			importJobs.GET("/:id/errors", importHandler.ErrorReport)
+		}
+
+		// Support bundles; only the owner can consent to one being built
+		supportBundles := api.Group("/support/bundles")
+		{
+			supportBundles.POST("", supportHandler.Create)
+			supportBundles.GET("", supportHandler.List)
+			supportBundles.GET("/:id", supportHandler.Get)
+			supportBundles.POST("/:id/consent", middleware.RequireRole("owner"), supportHandler.Consent)
+			supportBundles.POST("/:id/decline", middleware.RequireRole("owner"), supportHandler.Decline)
+			supportBundles.GET("/:id/download", exportGuard.Export("support_bundle"), supportHandler.Download)
+		}
+
+		// Background job admin: failed and dead-lettered jobs
+		adminJobs := api.Group("/admin/jobs")
+		adminJobs.Use(middleware.RequireRole("admin"))
+		{
+			adminJobs.GET("", jobHandler.List)
+			adminJobs.GET("/stats", jobHandler.Stats)
+			adminJobs.GET("/:id", jobHandler.Get)
+			adminJobs.POST("/:id/retry", jobHandler.Retry)
+			adminJobs.POST("/:id/cancel", jobHandler.Cancel)
+		}
+
+		// Inbound webhooks and their dead letters, across all tenants
+		adminWebhooks := api.Group("/admin/webhooks/inbound")
+		adminWebhooks.Use(middleware.RequireRole("super_admin"))
+		{
+			adminWebhooks.GET("", webhookHandler.List)
+			adminWebhooks.GET("/:id", webhookHandler.Get)
+			adminWebhooks.POST("/:id/replay", webhookHandler.Replay)
 		}
 
 		// Recurring Journal Entries
@@ -175,15 +505,58 @@ func main() {
 			recurring.POST("/:id/generate", recurringJournalHandler.GenerateNow)
 			recurring.GET("/:id/history", recurringJournalHandler.GetHistory)
 		}
+
+		// Daily cash closing
+		cashClosings := api.Group("/cash-closings")
+		{
+			cashClosings.GET("", cashClosingHandler.List)
+			cashClosings.POST("", cashClosingHandler.Submit)
+			cashClosings.GET("/denominations", cashClosingHandler.DenominationSheet)
+			cashClosings.GET("/report", cashClosingHandler.Report)
+			cashClosings.GET("/settings", cashClosingHandler.GetSettings)
+			cashClosings.PUT("/settings", cashClosingHandler.UpdateSettings)
+			cashClosings.GET("/:id", cashClosingHandler.Get)
+			cashClosings.POST("/:id/approve", cashClosingHandler.Approve)
+			cashClosings.POST("/:id/reject", cashClosingHandler.Reject)
+		}
+
+		// Reconciliation of balance sheet accounts other than banks
+		accountReconciliations := api.Group("/account-reconciliations", featureFlags.Require("account_reconciliations"))
+		{
+			accountReconciliations.GET("", accountReconciliationHandler.List)
+			accountReconciliations.POST("", accountReconciliationHandler.Create)
+			accountReconciliations.GET("/:id", accountReconciliationHandler.Get)
+			accountReconciliations.PUT("/:id", accountReconciliationHandler.Update)
+			accountReconciliations.POST("/:id/refresh", accountReconciliationHandler.Refresh)
+			accountReconciliations.POST("/:id/items/match", accountReconciliationHandler.MatchItems)
+			accountReconciliations.POST("/:id/certify", accountReconciliationHandler.Certify)
+			accountReconciliations.POST("/:id/reopen", middleware.RequireRole("admin"), accountReconciliationHandler.Reopen)
+		}
+
+		// Month-end close checklist
+		closeChecklists := api.Group("/close-checklists")
+		{
+			closeChecklists.GET("", closeChecklistHandler.List)
+			closeChecklists.POST("", closeChecklistHandler.Open)
+			closeChecklists.GET("/readiness", closeChecklistHandler.Readiness)
+			closeChecklists.GET("/tasks", closeChecklistHandler.ListTasks)
+			closeChecklists.GET("/templates", closeChecklistHandler.ListTemplates)
+			closeChecklists.POST("/templates", middleware.RequireRole("admin"), closeChecklistHandler.CreateTemplate)
+			closeChecklists.PUT("/templates/:template_id", middleware.RequireRole("admin"), closeChecklistHandler.UpdateTemplate)
+			closeChecklists.DELETE("/templates/:template_id", middleware.RequireRole("admin"), closeChecklistHandler.DeleteTemplate)
+			closeChecklists.GET("/:id", closeChecklistHandler.Get)
+			closeChecklists.POST("/:id/tasks", closeChecklistHandler.AddTask)
+			closeChecklists.PUT("/:id/tasks/:task_id", closeChecklistHandler.UpdateTask)
+		}
 	}
 
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         cfg.GetServerAddress(),
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Start server in goroutine
@@ -202,13 +575,16 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Let running jobs finish
+	jobQueue.Stop()
+
 	// Close database connection
 	if err := database.Close(db); err != nil {
 		log.Printf("Error closing database: %v", err)
@@ -0,0 +1,167 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrConsentInactive is returned when the account holder's consent has
+// expired or been revoked, so no more data can be fetched under it
+var ErrConsentInactive = errors.New("consent is no longer active")
+
+// Consent states reported by a bank feed provider
+const (
+	ConsentPending  = "PENDING"
+	ConsentActive   = "ACTIVE"
+	ConsentRejected = "REJECTED"
+	ConsentExpired  = "EXPIRED"
+	ConsentRevoked  = "REVOKED"
+	ConsentPaused   = "PAUSED"
+)
+
+// BankFeedLinkRequest asks the account holder to let their bank account's
+// transactions be shared
+type BankFeedLinkRequest struct {
+	CustomerHandle string    `json:"customer_handle"` // Such as 98xxxxxx10@onemoney
+	Reference      string    `json:"reference"`       // Ours, echoed in callbacks
+	From           time.Time `json:"from"`            // Earliest transactions asked for
+	RedirectURL    string    `json:"redirect_url,omitempty"`
+}
+
+// BankFeedConsent is the account holder's consent to share an account's
+// transactions
+type BankFeedConsent struct {
+	ID        string               `json:"id"`
+	Status    string               `json:"status"`
+	URL       string               `json:"url,omitempty"` // Where the account holder approves it
+	ExpiresAt *time.Time           `json:"expires_at,omitempty"`
+	Accounts  []BankFeedAccountRef `json:"accounts,omitempty"` // The accounts approved for sharing
+}
+
+// BankFeedAccountRef is an account shared under a consent
+type BankFeedAccountRef struct {
+	Ref           string `json:"link_ref"`
+	MaskedAccount string `json:"masked_account_number"`
+	IFSC          string `json:"ifsc,omitempty"`
+}
+
+// BankFeedTransaction is a statement line as the provider reports it
+type BankFeedTransaction struct {
+	ID        string    `json:"txn_id"`
+	Date      time.Time `json:"value_date"`
+	Narration string    `json:"narration"`
+	Reference string    `json:"reference"`
+	Type      string    `json:"type"` // DEBIT or CREDIT
+	Amount    float64   `json:"amount"`
+	Balance   float64   `json:"current_balance"`
+}
+
+// BankFeedPage is a page of an account's transactions. Cursor continues
+// after it.
+type BankFeedPage struct {
+	Transactions []BankFeedTransaction `json:"transactions"`
+	Cursor       string                `json:"next_cursor"`
+	HasMore      bool                  `json:"has_more"`
+}
+
+// BankFeedProvider pulls bank transactions shared by the account holder,
+// such as through an account aggregator under India's AA framework
+type BankFeedProvider interface {
+	Name() string
+	// Link asks for the account holder's consent
+	Link(ctx context.Context, req BankFeedLinkRequest) (*BankFeedConsent, error)
+	// Consent returns a consent's current state and the accounts it shares
+	Consent(ctx context.Context, consentID string) (*BankFeedConsent, error)
+	// Transactions returns a shared account's transactions after cursor,
+	// or from the start of the consent when cursor is empty. Returns
+	// ErrConsentInactive once the consent has lapsed.
+	Transactions(ctx context.Context, consentID, accountRef, cursor string) (*BankFeedPage, error)
+	// Revoke withdraws a consent
+	Revoke(ctx context.Context, consentID string) error
+}
+
+type accountAggregatorClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// NewAccountAggregatorClient creates a client of an account aggregator
+// gateway, which runs the consent and FI data flows with the AA and FIPs
+// on our behalf as the FIU and serves the decrypted data
+func NewAccountAggregatorClient(baseURL, clientID, clientSecret string) BankFeedProvider {
+	return &accountAggregatorClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *accountAggregatorClient) Name() string {
+	return "account_aggregator"
+}
+
+func (c *accountAggregatorClient) header() http.Header {
+	header := http.Header{}
+	header.Set("X-Client-Id", c.clientID)
+	header.Set("X-Client-Secret", c.clientSecret)
+	return header
+}
+
+func (c *accountAggregatorClient) Link(ctx context.Context, req BankFeedLinkRequest) (*BankFeedConsent, error) {
+	body := map[string]interface{}{
+		"vua":          req.CustomerHandle,
+		"reference":    req.Reference,
+		"fi_types":     []string{"DEPOSIT"},
+		"purpose":      "Account reconciliation",
+		"fetch_type":   "PERIODIC",
+		"data_from":    req.From.Format(time.RFC3339),
+		"redirect_url": req.RedirectURL,
+	}
+	var consent BankFeedConsent
+	if err := sendJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/consents", c.header(), body, &consent); err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (c *accountAggregatorClient) Consent(ctx context.Context, consentID string) (*BankFeedConsent, error) {
+	var consent BankFeedConsent
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/consents/"+url.PathEscape(consentID), c.header(), &consent); err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (c *accountAggregatorClient) Transactions(ctx context.Context, consentID, accountRef, cursor string) (*BankFeedPage, error) {
+	query := url.Values{}
+	query.Set("link_ref", accountRef)
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	var page BankFeedPage
+	err := getJSON(ctx, c.httpClient, c.baseURL+"/consents/"+url.PathEscape(consentID)+"/transactions?"+query.Encode(), c.header(), &page)
+	if errors.Is(err, ErrForbidden) {
+		// The gateway refuses fetches under a lapsed consent
+		return nil, ErrConsentInactive
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *accountAggregatorClient) Revoke(ctx context.Context, consentID string) error {
+	err := sendJSON(ctx, c.httpClient, http.MethodDelete, c.baseURL+"/consents/"+url.PathEscape(consentID), c.header(), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
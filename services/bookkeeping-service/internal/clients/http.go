@@ -0,0 +1,72 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrNotFound is returned when the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the caller may not read the resource
+	ErrForbidden = errors.New("forbidden")
+)
+
+// getJSON fetches url and decodes the response into out. Responses with a
+// 4xx/5xx status are returned as errors; a 404 is reported as ErrNotFound
+// and a 401 or 403 as ErrForbidden.
+func getJSON(ctx context.Context, httpClient *http.Client, url string, header http.Header, out interface{}) error {
+	return sendJSON(ctx, httpClient, http.MethodGet, url, header, nil, out)
+}
+
+// sendJSON makes a request with body encoded as JSON, unless it is nil, and
+// decodes the response into out, unless it is nil. Errors are reported as
+// by getJSON.
+func sendJSON(ctx context.Context, httpClient *http.Client, method, url string, header http.Header, body, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
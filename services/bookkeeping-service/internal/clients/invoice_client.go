@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpenseClaim is the part of an invoice service expense claim needed to
+// match card spends to it
+type ExpenseClaim struct {
+	ID          uuid.UUID          `json:"id"`
+	ClaimNumber string             `json:"claim_number"`
+	MemberID    uuid.UUID          `json:"member_id"`
+	Status      string             `json:"status"`
+	TotalAmount float64            `json:"total_amount,string"`
+	Items       []ExpenseClaimItem `json:"items"`
+}
+
+// ExpenseClaimItem is a single expense on a claim
+type ExpenseClaimItem struct {
+	ExpenseDate time.Time `json:"expense_date"`
+	Category    string    `json:"category"`
+	Amount      float64   `json:"amount,string"`
+}
+
+// RoundingRule is how the tenant rounds the totals of a type of document,
+// and the account the difference is posted to when it names one
+type RoundingRule struct {
+	DocumentType      string     `json:"document_type"`
+	Mode              string     `json:"mode"` // none, nearest, up or down
+	RoundTo           float64    `json:"round_to,string"`
+	RoundingAccountID *uuid.UUID `json:"rounding_account_id,omitempty"`
+}
+
+// InvoiceClient reads from the invoice service
+type InvoiceClient interface {
+	// GetExpenseClaim fetches a claim on behalf of the caller identified by
+	// authorization (the incoming Authorization header)
+	GetExpenseClaim(ctx context.Context, authorization, tenantID string, id uuid.UUID) (*ExpenseClaim, error)
+
+	// GetRoundingRule returns the tenant's rounding rule for a document type
+	// (invoice, bill or credit_note)
+	GetRoundingRule(ctx context.Context, authorization, tenantID, documentType string) (*RoundingRule, error)
+}
+
+type invoiceClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewInvoiceClient creates a new invoice service client
+func NewInvoiceClient(baseURL string) InvoiceClient {
+	return &invoiceClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *invoiceClient) GetExpenseClaim(ctx context.Context, authorization, tenantID string, id uuid.UUID) (*ExpenseClaim, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	header.Set("X-Tenant-ID", tenantID)
+
+	var resp struct {
+		Data ExpenseClaim `json:"data"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/api/v1/expense-claims/"+id.String(), header, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *invoiceClient) GetRoundingRule(ctx context.Context, authorization, tenantID, documentType string) (*RoundingRule, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	header.Set("X-Tenant-ID", tenantID)
+
+	var resp struct {
+		Data []RoundingRule `json:"data"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/api/v1/rounding-rules", header, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.Data {
+		if resp.Data[i].DocumentType == documentType {
+			return &resp.Data[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invoice service has no rounding rule for %s", documentType)
+}
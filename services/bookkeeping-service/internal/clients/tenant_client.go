@@ -0,0 +1,111 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantGroup is the part of a tenant service group needed for
+// inter-company transactions
+type TenantGroup struct {
+	ID             uuid.UUID           `json:"id"`
+	Name           string              `json:"name"`
+	ParentTenantID uuid.UUID           `json:"parent_tenant_id"`
+	Members        []TenantGroupMember `json:"members"`
+}
+
+// TenantGroupMember is a tenant belonging to a group
+type TenantGroupMember struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Tenant   struct {
+		Name string `json:"name"`
+	} `json:"tenant"`
+}
+
+// Member returns the group member with the given tenant ID
+func (g *TenantGroup) Member(tenantID uuid.UUID) (*TenantGroupMember, bool) {
+	for i := range g.Members {
+		if g.Members[i].TenantID == tenantID {
+			return &g.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// Tenant is the part of a tenant service tenant the bookkeeping service
+// reads
+type Tenant struct {
+	ID                 uuid.UUID `json:"id"`
+	Name               string    `json:"name"`
+	FinancialYearStart int       `json:"financial_year_start"` // Month, 1-12
+}
+
+// TenantClient reads from the tenant service
+type TenantClient interface {
+	// GetTenant fetches the tenant on behalf of the caller identified by
+	// authorization
+	GetTenant(ctx context.Context, authorization string, tenantID uuid.UUID) (*Tenant, error)
+	// GetGroup fetches the group the tenant belongs to on behalf of the
+	// caller identified by authorization. Returns ErrNotFound if the tenant
+	// is not in a group.
+	GetGroup(ctx context.Context, authorization string, tenantID uuid.UUID) (*TenantGroup, error)
+	// GetMyPermissions returns the permissions of the caller identified by
+	// authorization in the tenant, from their role there
+	GetMyPermissions(ctx context.Context, authorization string, tenantID uuid.UUID) ([]string, error)
+}
+
+type tenantClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTenantClient creates a new tenant service client
+func NewTenantClient(baseURL string) TenantClient {
+	return &tenantClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *tenantClient) GetTenant(ctx context.Context, authorization string, tenantID uuid.UUID) (*Tenant, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+
+	var resp struct {
+		Data Tenant `json:"data"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/api/v1/tenants/"+tenantID.String(), header, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *tenantClient) GetGroup(ctx context.Context, authorization string, tenantID uuid.UUID) (*TenantGroup, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+
+	var resp struct {
+		Data TenantGroup `json:"data"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/api/v1/tenants/"+tenantID.String()+"/group", header, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *tenantClient) GetMyPermissions(ctx context.Context, authorization string, tenantID uuid.UUID) ([]string, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+
+	var resp struct {
+		Data []string `json:"data"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/api/v1/tenants/"+tenantID.String()+"/permissions/me", header, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
@@ -7,19 +7,50 @@ import (
 // Config holds bookkeeping service configuration
 type Config struct {
 	*sharedConfig.Config
+
+	// Journal validation
+	BaseCurrency         string // Currency of journal lines that don't name one
+	JournalLineTolerance int    // Rounding allowed per line, in minor units (paise)
+
+	// Bank feed provider, such as an account aggregator gateway. Bank
+	// feeds are off when no URL is set.
+	BankFeedProviderURL  string
+	BankFeedClientID     string
+	BankFeedClientSecret string
+
+	// Signing secrets of bank feed callbacks, from the secret store;
+	// several while one is rotated
+	BankFeedWebhookSecrets []string
 }
 
 // Load loads bookkeeping service configuration
 func Load() (*Config, error) {
-	cfg, err := sharedConfig.Load("bookkeeping-service")
+	cfg, err := sharedConfig.LoadWithDefaults("bookkeeping-service", sharedConfig.Defaults{DatabaseName: "bookkeep_core"})
 	if err != nil {
 		return nil, err
 	}
 
-	// Override default database name
-	if cfg.Database.DBName == "bookkeeping-service_db" {
-		cfg.Database.DBName = "bookkeep_core"
+	env := sharedConfig.NewEnv("bookkeeping-service")
+	bookkeepingCfg := &Config{
+		Config:               cfg,
+		BaseCurrency:         env.String("BASE_CURRENCY", "INR"),
+		JournalLineTolerance: env.Int("JOURNAL_LINE_ROUNDING_TOLERANCE", 0),
+
+		BankFeedProviderURL:    env.String("BANK_FEED_PROVIDER_URL", ""),
+		BankFeedClientID:       env.String("BANK_FEED_CLIENT_ID", ""),
+		BankFeedClientSecret:   env.String("BANK_FEED_CLIENT_SECRET", ""),
+		BankFeedWebhookSecrets: cfg.Secrets.List("BANK_FEED_WEBHOOK_SECRETS"),
+	}
+
+	if len(bookkeepingCfg.BaseCurrency) != 3 {
+		env.Fail("BASE_CURRENCY must be a three-letter currency code")
+	}
+	if bookkeepingCfg.JournalLineTolerance < 0 {
+		env.Fail("JOURNAL_LINE_ROUNDING_TOLERANCE must not be negative")
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
 	}
 
-	return &Config{Config: cfg}, nil
+	return bookkeepingCfg, nil
 }
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"bytes"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -8,7 +10,9 @@ import (
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/statement"
 )
 
 // AccountHandler handles account-related endpoints
@@ -179,6 +183,7 @@ func (h *AccountHandler) ListAccounts(c *gin.Context) {
 		return
 	}
 
+	hideBalancesFromAuditor(c, accounts)
 	response.Paginated(c, accounts, filter.Page, filter.PerPage, total)
 }
 
@@ -196,9 +201,23 @@ func (h *AccountHandler) GetChartOfAccounts(c *gin.Context) {
 		return
 	}
 
+	hideBalancesFromAuditor(c, accounts)
 	response.Success(c, accounts)
 }
 
+// hideBalancesFromAuditor blanks accounts' current balances for auditors,
+// as they are as of today rather than the audited year. Auditors read the
+// year's balances from the trial balance.
+func hideBalancesFromAuditor(c *gin.Context, accounts []models.Account) {
+	if !middleware.IsAuditor(c) {
+		return
+	}
+	for i := range accounts {
+		accounts[i].CurrentBalance = 0
+		hideBalancesFromAuditor(c, accounts[i].Children)
+	}
+}
+
 // GetAccountsByType handles getting accounts by type
 func (h *AccountHandler) GetAccountsByType(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
@@ -238,6 +257,59 @@ func (h *AccountHandler) InitializeAccounts(c *gin.Context) {
 	response.Success(c, gin.H{"message": "Default accounts initialized successfully"})
 }
 
+// GetAccountLedger handles getting an account's ledger. With ?format=pdf
+// or xlsx the ledger is downloaded in the columnar statement layout.
+func (h *AccountHandler) GetAccountLedger(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID", nil)
+		return
+	}
+
+	format := c.DefaultQuery("format", statement.FormatJSON)
+	if !statement.ValidFormat(format) {
+		response.BadRequest(c, statement.ErrUnsupportedFormat.Error(), nil)
+		return
+	}
+
+	ledger, err := h.accountService.GetAccountLedger(c.Request.Context(), accountID, tenantID, c.Query("from_date"), c.Query("to_date"))
+	if err != nil {
+		switch err {
+		case services.ErrAccountNotFound:
+			response.NotFound(c, "Account not found")
+		case services.ErrInvalidLedgerDate:
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to get account ledger")
+		}
+		return
+	}
+
+	if format == statement.FormatJSON {
+		response.Success(c, ledger)
+		return
+	}
+
+	middleware.SetExportRows(c, len(ledger.Entries))
+	st := ledger.Statement()
+	st.Watermark = middleware.AuditorWatermark(c)
+
+	var buf bytes.Buffer
+	if err := statement.Write(&buf, format, st); err != nil {
+		response.InternalError(c, "Failed to generate ledger statement")
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"ledger-"+accountID.String()+"."+format+"\"")
+	c.Data(http.StatusOK, statement.ContentType(format), buf.Bytes())
+}
+
 // Helper methods
 
 func (h *AccountHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// AccountMappingHandler handles the tenant's posting account mappings
+type AccountMappingHandler struct {
+	accountMappingService services.AccountMappingService
+}
+
+// NewAccountMappingHandler creates a new account mapping handler
+func NewAccountMappingHandler(accountMappingService services.AccountMappingService) *AccountMappingHandler {
+	return &AccountMappingHandler{accountMappingService: accountMappingService}
+}
+
+// List returns the account each posting type resolves to
+func (h *AccountMappingHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	mappings, err := h.accountMappingService.List(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get account mappings")
+		return
+	}
+
+	response.Success(c, mappings)
+}
+
+// Update maps posting types to accounts; a null account resets the
+// posting type to its default
+func (h *AccountMappingHandler) Update(c *gin.Context) {
+	var req services.UpdateAccountMappingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	mappings, err := h.accountMappingService.Update(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountNotFound):
+			response.BadRequest(c, "One or more accounts not found", nil)
+		case errors.Is(err, services.ErrUnknownPostingType), errors.Is(err, services.ErrUnsuitableAccount):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to update account mappings")
+		}
+		return
+	}
+
+	response.Success(c, mappings)
+}
+
+func (h *AccountMappingHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *AccountMappingHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// AccountReconciliationHandler handles reconciliation workspaces for
+// balance sheet accounts other than banks
+type AccountReconciliationHandler struct {
+	reconciliationService services.AccountReconciliationService
+}
+
+// NewAccountReconciliationHandler creates a new account reconciliation
+// handler
+func NewAccountReconciliationHandler(reconciliationService services.AccountReconciliationService) *AccountReconciliationHandler {
+	return &AccountReconciliationHandler{reconciliationService: reconciliationService}
+}
+
+// List returns account reconciliations, latest period first
+func (h *AccountReconciliationHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.AccountReconciliationFilters{
+		Period: c.Query("period"),
+		Status: models.AccountReconciliationStatus(c.Query("status")),
+	}
+	if accountID := c.Query("account_id"); accountID != "" {
+		id, err := uuid.Parse(accountID)
+		if err != nil {
+			response.BadRequest(c, "Invalid account ID", nil)
+			return
+		}
+		filters.AccountID = id
+	}
+
+	reconciliations, err := h.reconciliationService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list account reconciliations")
+		return
+	}
+
+	response.Success(c, reconciliations)
+}
+
+// Create opens a reconciliation workspace for an account and month
+func (h *AccountReconciliationHandler) Create(c *gin.Context) {
+	var req services.CreateAccountReconciliationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	reconciliation, err := h.reconciliationService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create account reconciliation")
+		return
+	}
+
+	response.Created(c, reconciliation)
+}
+
+// Get returns an account reconciliation with its items
+func (h *AccountReconciliationHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	reconciliation, err := h.reconciliationService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get account reconciliation")
+		return
+	}
+
+	response.Success(c, reconciliation)
+}
+
+// Update sets the balance the outside source reports
+func (h *AccountReconciliationHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", nil)
+		return
+	}
+
+	var req services.UpdateAccountReconciliationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	reconciliation, err := h.reconciliationService.Update(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update account reconciliation")
+		return
+	}
+
+	response.Success(c, reconciliation)
+}
+
+// Refresh pulls in entries posted to the month since the workspace was
+// opened and recomputes its balances
+func (h *AccountReconciliationHandler) Refresh(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	reconciliation, err := h.reconciliationService.Refresh(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to refresh account reconciliation")
+		return
+	}
+
+	response.Success(c, reconciliation)
+}
+
+// MatchItems ticks off, or unticks, items of a reconciliation
+func (h *AccountReconciliationHandler) MatchItems(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", nil)
+		return
+	}
+
+	var req services.MatchReconciliationItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	reconciliation, err := h.reconciliationService.MatchItems(c.Request.Context(), tenantID, userID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to match reconciliation items")
+		return
+	}
+
+	response.Success(c, reconciliation)
+}
+
+// Certify signs off a reconciliation whose open items explain the
+// difference
+func (h *AccountReconciliationHandler) Certify(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	reconciliation, err := h.reconciliationService.Certify(c.Request.Context(), tenantID, userID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to certify account reconciliation")
+		return
+	}
+
+	response.Success(c, reconciliation)
+}
+
+// Reopen takes back the certification of the account's latest
+// reconciliation
+func (h *AccountReconciliationHandler) Reopen(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	reconciliation, err := h.reconciliationService.Reopen(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to reopen account reconciliation")
+		return
+	}
+
+	response.Success(c, reconciliation)
+}
+
+// Helper methods
+
+func (h *AccountReconciliationHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrAccountReconciliationNotFound:
+		response.NotFound(c, "Account reconciliation not found")
+	case services.ErrAccountNotFound:
+		response.NotFound(c, "Account not found")
+	case services.ErrAccountNotReconcilable, services.ErrInvalidReconciliationPeriod:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrAccountReconciliationExists, services.ErrAccountReconciliationCertified,
+		services.ErrAccountReconciliationNotCertified, services.ErrAccountReconciliationUnbalanced,
+		services.ErrPreviousReconciliationOpen, services.ErrLaterReconciliationExists:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *AccountReconciliationHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *AccountReconciliationHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// BankFeedHandler handles bank feed connection endpoints
+type BankFeedHandler struct {
+	feedService services.BankFeedService
+}
+
+// NewBankFeedHandler creates a new bank feed handler
+func NewBankFeedHandler(feedService services.BankFeedService) *BankFeedHandler {
+	return &BankFeedHandler{feedService: feedService}
+}
+
+// Get returns a bank account's feed connection and how its last sync went
+func (h *BankFeedHandler) Get(c *gin.Context) {
+	id, ok := h.bankAccountID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	connection, err := h.feedService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get bank feed")
+		return
+	}
+
+	response.Success(c, connection)
+}
+
+// Connect asks the account holder's consent to feed a bank account. The
+// returned consent URL is where they approve it.
+func (h *BankFeedHandler) Connect(c *gin.Context) {
+	id, ok := h.bankAccountID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ConnectBankFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	connection, err := h.feedService.Connect(c.Request.Context(), tenantID, userID, id, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to connect bank feed")
+		return
+	}
+
+	response.Created(c, connection)
+}
+
+// Refresh queues a sync of a bank account's feed
+func (h *BankFeedHandler) Refresh(c *gin.Context) {
+	id, ok := h.bankAccountID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	connection, err := h.feedService.Refresh(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to refresh bank feed")
+		return
+	}
+
+	response.Success(c, connection)
+}
+
+// Pause stops syncing a bank account's feed until it is resumed
+func (h *BankFeedHandler) Pause(c *gin.Context) {
+	id, ok := h.bankAccountID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	connection, err := h.feedService.Pause(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to pause bank feed")
+		return
+	}
+
+	response.Success(c, connection)
+}
+
+// Resume syncs a paused feed again, catching up on the lines since
+func (h *BankFeedHandler) Resume(c *gin.Context) {
+	id, ok := h.bankAccountID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	connection, err := h.feedService.Resume(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to resume bank feed")
+		return
+	}
+
+	response.Success(c, connection)
+}
+
+// Disconnect revokes a bank account's feed consent
+func (h *BankFeedHandler) Disconnect(c *gin.Context) {
+	id, ok := h.bankAccountID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.feedService.Disconnect(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to disconnect bank feed")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Bank feed disconnected"})
+}
+
+// Helper methods
+
+func (h *BankFeedHandler) bankAccountID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank account ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *BankFeedHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrBankAccountNotFound:
+		response.NotFound(c, "Bank account not found")
+	case services.ErrBankFeedNotFound:
+		response.NotFound(c, "Bank feed not found")
+	case services.ErrBankFeedConnected, services.ErrBankFeedNotActive, services.ErrBankFeedNotPaused:
+		response.Conflict(c, err.Error())
+	case services.ErrInvalidBankFeed, services.ErrBankFeedCardAccount:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrBankFeedUnavailable:
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *BankFeedHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *BankFeedHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
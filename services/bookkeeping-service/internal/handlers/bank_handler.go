@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -51,6 +53,10 @@ func (h *BankHandler) CreateBankAccount(c *gin.Context) {
 
 	account, err := h.bankService.CreateBankAccount(c.Request.Context(), req)
 	if err != nil {
+		if err == services.ErrInvalidFeedType {
+			response.BadRequest(c, "Invalid feed type", nil)
+			return
+		}
 		response.InternalError(c, "Failed to create bank account")
 		return
 	}
@@ -122,7 +128,8 @@ func (h *BankHandler) DeleteBankAccount(c *gin.Context) {
 	response.NoContent(c)
 }
 
-// ImportStatement imports a bank statement
+// ImportStatement queues a bank statement import. The file is processed in
+// the background; progress is polled on the returned import job.
 func (h *BankHandler) ImportStatement(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -131,18 +138,23 @@ func (h *BankHandler) ImportStatement(c *gin.Context) {
 	}
 
 	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
 
 	// Get the uploaded file
-	file, _, err := c.Request.FormFile("file")
+	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		response.BadRequest(c, "No file uploaded", nil)
 		return
 	}
 	defer file.Close()
 
-	format := c.DefaultQuery("format", "csv")
-
-	result, err := h.bankService.ImportBankStatement(c.Request.Context(), id, tenantID, file, format)
+	job, err := h.bankService.ImportBankStatement(c.Request.Context(), services.ImportStatementRequest{
+		BankAccountID: id,
+		TenantID:      tenantID,
+		UserID:        userID,
+		FileName:      header.Filename,
+		Format:        c.DefaultQuery("format", "csv"),
+	}, file)
 	if err != nil {
 		if err == services.ErrBankAccountNotFound {
 			response.NotFound(c, "Bank account not found")
@@ -152,7 +164,7 @@ func (h *BankHandler) ImportStatement(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, result)
+	response.Accepted(c, job)
 }
 
 // GetBankTransactions returns bank transactions
@@ -257,6 +269,14 @@ func (h *BankHandler) AutoReconcile(c *gin.Context) {
 
 	result, err := h.bankService.AutoReconcile(c.Request.Context(), id, userID)
 	if err != nil {
+		if err == services.ErrBankAccountNotFound {
+			response.NotFound(c, "Bank account not found")
+			return
+		}
+		if err == services.ErrAutoReconcileRunning {
+			response.Conflict(c, "Auto-reconcile is already running for this bank account")
+			return
+		}
 		response.InternalError(c, "Failed to auto-reconcile")
 		return
 	}
@@ -264,6 +284,27 @@ func (h *BankHandler) AutoReconcile(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// GetAutoReconcileStatus returns the progress of the latest auto-reconcile
+func (h *BankHandler) GetAutoReconcileStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank account ID", nil)
+		return
+	}
+
+	run, err := h.bankService.GetAutoReconcileStatus(c.Request.Context(), id)
+	if err != nil {
+		if err == services.ErrNoReconciliationRun {
+			response.NotFound(c, "Bank account has not been auto-reconciled")
+			return
+		}
+		response.InternalError(c, "Failed to get auto-reconcile status")
+		return
+	}
+
+	response.Success(c, run)
+}
+
 // UnreconcileTransaction unreconciles a bank transaction
 func (h *BankHandler) UnreconcileTransaction(c *gin.Context) {
 	bankTxID, err := uuid.Parse(c.Param("tx_id"))
@@ -320,7 +361,18 @@ func (h *BankHandler) SuggestMatches(c *gin.Context) {
 		return
 	}
 
-	suggestions, err := h.bankService.SuggestMatches(c.Request.Context(), bankTxID)
+	// max_combination=1 asks for one-to-one matches only
+	opts := services.SuggestOptions{MaxCombination: services.DefaultMatchCombination}
+	if value := c.Query("max_combination"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > services.MaxMatchCombination {
+			response.BadRequest(c, fmt.Sprintf("max_combination must be between 1 and %d", services.MaxMatchCombination), nil)
+			return
+		}
+		opts.MaxCombination = size
+	}
+
+	suggestions, err := h.bankService.SuggestMatches(c.Request.Context(), bankTxID, opts)
 	if err != nil {
 		if err == services.ErrBankTxNotFound {
 			response.NotFound(c, "Bank transaction not found")
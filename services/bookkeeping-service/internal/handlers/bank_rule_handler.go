@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// BankRuleHandler handles bank charge and interest rule endpoints
+type BankRuleHandler struct {
+	ruleService services.BankRuleService
+}
+
+// NewBankRuleHandler creates a new bank rule handler
+func NewBankRuleHandler(ruleService services.BankRuleService) *BankRuleHandler {
+	return &BankRuleHandler{ruleService: ruleService}
+}
+
+// List returns the tenant's bank rules in the order they are tried
+func (h *BankRuleHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	rules, err := h.ruleService.List(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list bank rules")
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// Create adds a bank rule
+func (h *BankRuleHandler) Create(c *gin.Context) {
+	var req services.BankRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	rule, err := h.ruleService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create bank rule")
+		return
+	}
+
+	response.Created(c, rule)
+}
+
+// Update replaces a bank rule
+func (h *BankRuleHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank rule ID", nil)
+		return
+	}
+
+	var req services.BankRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	req.TenantID = tenantID
+
+	rule, err := h.ruleService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update bank rule")
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// Delete removes a bank rule. Lines it already posted stay in the ledger.
+func (h *BankRuleHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank rule ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.ruleService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete bank rule")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// InstallDefaults adds the standard charges, GST and interest rules
+func (h *BankRuleHandler) InstallDefaults(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+
+	rules, err := h.ruleService.InstallDefaults(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		if err == services.ErrAccountNotFound {
+			response.BadRequest(c, "Default chart accounts for bank charges, GST input credit and interest income are missing", nil)
+			return
+		}
+		response.InternalError(c, "Failed to install default bank rules")
+		return
+	}
+
+	response.Created(c, rules)
+}
+
+// ApplyToAccount posts a bank account's unreconciled lines that match a rule
+func (h *BankRuleHandler) ApplyToAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank account ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+
+	result, err := h.ruleService.ApplyToAccount(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to apply bank rules")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Helper methods
+
+func (h *BankRuleHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrBankRuleNotFound:
+		response.NotFound(c, "Bank rule not found")
+	case services.ErrBankAccountNotFound:
+		response.NotFound(c, "Bank account not found")
+	case services.ErrAccountNotFound:
+		response.BadRequest(c, "Ledger account not found", nil)
+	case services.ErrInvalidRulePattern, services.ErrNoLedgerAccount:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *BankRuleHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *BankRuleHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
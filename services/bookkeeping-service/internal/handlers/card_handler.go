@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// CardHandler handles corporate card spend endpoints
+type CardHandler struct {
+	cardService services.CardService
+}
+
+// NewCardHandler creates a new card handler
+func NewCardHandler(cardService services.CardService) *CardHandler {
+	return &CardHandler{cardService: cardService}
+}
+
+// ListCards returns the cards on a corporate card feed and their holders
+func (h *CardHandler) ListCards(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank account ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	cards, err := h.cardService.ListCards(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.InternalError(c, "Failed to list cards")
+		return
+	}
+
+	response.Success(c, cards)
+}
+
+// AssignCard assigns a card on a corporate card feed to a member
+func (h *CardHandler) AssignCard(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank account ID", nil)
+		return
+	}
+
+	var req services.AssignCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	req.TenantID = tenantID
+	req.BankAccountID = id
+
+	card, err := h.cardService.AssignCard(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to assign card")
+		return
+	}
+
+	response.Success(c, card)
+}
+
+// ListSpends returns card spends. mine=true limits the list to the current
+// member's spends.
+func (h *CardHandler) ListSpends(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters, ok := h.parseFilters(c)
+	if !ok {
+		return
+	}
+	filters.Uncategorized = c.Query("uncategorized") == "true"
+	filters.Unmatched = c.Query("unmatched") == "true"
+
+	spends, err := h.cardService.ListSpends(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list card spends")
+		return
+	}
+
+	response.Success(c, spends)
+}
+
+// AssignSpend assigns a single card spend to a member
+func (h *CardHandler) AssignSpend(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("spend_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid card spend ID", nil)
+		return
+	}
+
+	var req struct {
+		MemberID uuid.UUID `json:"member_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	spend, err := h.cardService.AssignSpend(c.Request.Context(), tenantID, id, req.MemberID)
+	if err != nil {
+		h.handleError(c, err, "Failed to assign card spend")
+		return
+	}
+
+	response.Success(c, spend)
+}
+
+// CategorizeSpend records the category and receipt for a card spend
+func (h *CardHandler) CategorizeSpend(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("spend_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid card spend ID", nil)
+		return
+	}
+
+	var req services.CategorizeCardSpendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	spend, err := h.cardService.CategorizeSpend(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to categorize card spend")
+		return
+	}
+
+	response.Success(c, spend)
+}
+
+// MatchClaim matches a card spend to an expense claim
+func (h *CardHandler) MatchClaim(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("spend_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid card spend ID", nil)
+		return
+	}
+
+	var req services.MatchCardSpendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	req.TenantID = tenantID
+	req.Authorization = c.GetHeader("Authorization")
+
+	spend, err := h.cardService.MatchClaim(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to match card spend")
+		return
+	}
+
+	response.Success(c, spend)
+}
+
+// UncategorizedReport returns card spend awaiting categorization per member
+func (h *CardHandler) UncategorizedReport(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters, ok := h.parseFilters(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.cardService.UncategorizedReport(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to generate card spend report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *CardHandler) parseFilters(c *gin.Context) (repository.CardSpendFilters, bool) {
+	filters := repository.CardSpendFilters{
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+	}
+
+	if bankAccountID := c.Query("bank_account_id"); bankAccountID != "" {
+		id, err := uuid.Parse(bankAccountID)
+		if err != nil {
+			response.BadRequest(c, "Invalid bank account ID", nil)
+			return filters, false
+		}
+		filters.BankAccountID = id
+	}
+
+	if c.Query("mine") == "true" {
+		filters.MemberID, _ = h.getUserIDFromContext(c)
+	} else if memberID := c.Query("member_id"); memberID != "" {
+		id, err := uuid.Parse(memberID)
+		if err != nil {
+			response.BadRequest(c, "Invalid member ID", nil)
+			return filters, false
+		}
+		filters.MemberID = id
+	}
+
+	return filters, true
+}
+
+func (h *CardHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrBankAccountNotFound:
+		response.NotFound(c, "Bank account not found")
+	case services.ErrCardSpendNotFound:
+		response.NotFound(c, "Card spend not found")
+	case services.ErrExpenseClaimNotFound:
+		response.NotFound(c, "Expense claim not found")
+	case services.ErrNotCardFeed, services.ErrInvalidCardSpend, services.ErrClaimMismatch:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrInvoiceUnavailable:
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *CardHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *CardHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
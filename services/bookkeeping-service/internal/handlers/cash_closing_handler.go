@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// CashClosingHandler handles daily cash closing endpoints
+type CashClosingHandler struct {
+	cashClosingService services.CashClosingService
+}
+
+// NewCashClosingHandler creates a new cash closing handler
+func NewCashClosingHandler(cashClosingService services.CashClosingService) *CashClosingHandler {
+	return &CashClosingHandler{cashClosingService: cashClosingService}
+}
+
+// GetSettings returns the tenant's cash closing settings
+func (h *CashClosingHandler) GetSettings(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	settings, err := h.cashClosingService.GetSettings(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get cash closing settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// UpdateSettings configures the over/short account and auto-approve limit
+func (h *CashClosingHandler) UpdateSettings(c *gin.Context) {
+	var req services.UpdateCashClosingSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+
+	settings, err := h.cashClosingService.UpdateSettings(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update cash closing settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// DenominationSheet returns the notes and coins to count, with zero counts
+func (h *CashClosingHandler) DenominationSheet(c *gin.Context) {
+	sheet := make([]models.Denomination, len(models.DefaultDenominations))
+	for i, value := range models.DefaultDenominations {
+		sheet[i] = models.Denomination{Value: value}
+	}
+
+	response.Success(c, sheet)
+}
+
+// Submit records the cashier's denomination count for a day
+func (h *CashClosingHandler) Submit(c *gin.Context) {
+	var req services.SubmitCashClosingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	closing, err := h.cashClosingService.Submit(c.Request.Context(), req)
+	if err != nil {
+		if _, ok := err.(*time.ParseError); ok {
+			response.BadRequest(c, "Invalid date format", nil)
+			return
+		}
+		h.handleError(c, err, "Failed to close cash")
+		return
+	}
+
+	response.Created(c, closing)
+}
+
+// List returns cash closings, newest first
+func (h *CashClosingHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.CashClosingFilters{
+		Status:   models.CashClosingStatus(c.Query("status")),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+	}
+	if cashAccountID := c.Query("cash_account_id"); cashAccountID != "" {
+		id, err := uuid.Parse(cashAccountID)
+		if err != nil {
+			response.BadRequest(c, "Invalid cash account ID", nil)
+			return
+		}
+		filters.CashAccountID = id
+	}
+
+	closings, err := h.cashClosingService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list cash closings")
+		return
+	}
+
+	response.Success(c, closings)
+}
+
+// Get returns a cash closing
+func (h *CashClosingHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid cash closing ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	closing, err := h.cashClosingService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get cash closing")
+		return
+	}
+
+	response.Success(c, closing)
+}
+
+// Approve approves a cash closing's over/short and posts it
+func (h *CashClosingHandler) Approve(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid cash closing ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	closing, err := h.cashClosingService.Approve(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to approve cash closing")
+		return
+	}
+
+	response.Success(c, closing)
+}
+
+// Reject sends a cash closing back for a recount
+func (h *CashClosingHandler) Reject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid cash closing ID", nil)
+		return
+	}
+
+	var req services.RejectCashClosingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	req.TenantID = tenantID
+	req.ReviewerID = userID
+
+	closing, err := h.cashClosingService.Reject(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to reject cash closing")
+		return
+	}
+
+	response.Success(c, closing)
+}
+
+// Report returns the daily closing report of a cash account
+func (h *CashClosingHandler) Report(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	dateStr := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		response.BadRequest(c, "Invalid date format", nil)
+		return
+	}
+
+	var cashAccountID *uuid.UUID
+	if idStr := c.Query("cash_account_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid cash account ID", nil)
+			return
+		}
+		cashAccountID = &id
+	}
+
+	report, err := h.cashClosingService.Report(c.Request.Context(), tenantID, cashAccountID, date)
+	if err != nil {
+		h.handleError(c, err, "Failed to generate cash closing report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *CashClosingHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrCashClosingNotFound:
+		response.NotFound(c, "Cash closing not found")
+	case services.ErrAccountNotFound:
+		response.NotFound(c, "Account not found")
+	case services.ErrCashClosingFinal, services.ErrCashClosingReviewed:
+		response.Conflict(c, err.Error())
+	case services.ErrCashClosingSelfReview:
+		response.Forbidden(c, err.Error())
+	case services.ErrNotCashAccount, services.ErrInvalidDenomination, services.ErrFutureClosingDate,
+		services.ErrOverShortAccountNotSet, services.ErrRejectionReasonMissing:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrPeriodClosed:
+		response.Conflict(c, "The accounting period of this date is closed")
+	case services.ErrNoFinancialYear:
+		response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *CashClosingHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *CashClosingHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// CloseChecklistHandler handles month-end close checklist endpoints
+type CloseChecklistHandler struct {
+	checklistService services.CloseChecklistService
+}
+
+// NewCloseChecklistHandler creates a new close checklist handler
+func NewCloseChecklistHandler(checklistService services.CloseChecklistService) *CloseChecklistHandler {
+	return &CloseChecklistHandler{checklistService: checklistService}
+}
+
+// ListTemplates returns the tasks of the tenant's close checklist
+func (h *CloseChecklistHandler) ListTemplates(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	templates, err := h.checklistService.ListTemplates(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list close checklist templates")
+		return
+	}
+
+	response.Success(c, templates)
+}
+
+// CreateTemplate adds a task to the tenant's close checklist
+func (h *CloseChecklistHandler) CreateTemplate(c *gin.Context) {
+	var req services.CloseTaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	template, err := h.checklistService.CreateTemplate(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create close checklist template")
+		return
+	}
+
+	response.Created(c, template)
+}
+
+// UpdateTemplate changes a task of the tenant's close checklist. Months
+// already opened keep their tasks as they were.
+func (h *CloseChecklistHandler) UpdateTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("template_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+
+	var req services.CloseTaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	template, err := h.checklistService.UpdateTemplate(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update close checklist template")
+		return
+	}
+
+	response.Success(c, template)
+}
+
+// DeleteTemplate removes a task from the tenant's close checklist
+func (h *CloseChecklistHandler) DeleteTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("template_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.checklistService.DeleteTemplate(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete close checklist template")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Close checklist template deleted"})
+}
+
+// List returns the tenant's close checklists, latest period first
+func (h *CloseChecklistHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	checklists, err := h.checklistService.List(c.Request.Context(), tenantID, models.CloseChecklistStatus(c.Query("status")))
+	if err != nil {
+		response.InternalError(c, "Failed to list close checklists")
+		return
+	}
+
+	response.Success(c, checklists)
+}
+
+// Open opens the close checklist of a month
+func (h *CloseChecklistHandler) Open(c *gin.Context) {
+	var req services.OpenCloseChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	checklist, err := h.checklistService.Open(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to open close checklist")
+		return
+	}
+
+	response.Created(c, checklist)
+}
+
+// Get returns a close checklist with its tasks
+func (h *CloseChecklistHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid checklist ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	checklist, err := h.checklistService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get close checklist")
+		return
+	}
+
+	response.Success(c, checklist)
+}
+
+// AddTask adds a task to one month's checklist
+func (h *CloseChecklistHandler) AddTask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid checklist ID", nil)
+		return
+	}
+
+	var req services.AddCloseTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	checklist, err := h.checklistService.AddTask(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to add close task")
+		return
+	}
+
+	response.Created(c, checklist)
+}
+
+// UpdateTask changes a task's status, assignee, due date or notes
+func (h *CloseChecklistHandler) UpdateTask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid checklist ID", nil)
+		return
+	}
+	taskID, err := uuid.Parse(c.Param("task_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid task ID", nil)
+		return
+	}
+
+	var req services.UpdateCloseTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	checklist, err := h.checklistService.UpdateTask(c.Request.Context(), tenantID, userID, id, taskID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update close task")
+		return
+	}
+
+	response.Success(c, checklist)
+}
+
+// ListTasks returns close tasks across months. assignee=me returns the
+// caller's own.
+func (h *CloseChecklistHandler) ListTasks(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.CloseTaskFilters{
+		Status:   models.CloseTaskStatus(c.Query("status")),
+		Period:   c.Query("period"),
+		OpenOnly: c.Query("open_only") == "true",
+	}
+	switch assignee := c.Query("assignee"); assignee {
+	case "":
+	case "me":
+		userID, err := h.getUserIDFromContext(c)
+		if err != nil {
+			response.Unauthorized(c, "User not authenticated")
+			return
+		}
+		filters.AssigneeID = userID
+	default:
+		id, err := uuid.Parse(assignee)
+		if err != nil {
+			response.BadRequest(c, "Invalid assignee ID", nil)
+			return
+		}
+		filters.AssigneeID = id
+	}
+
+	tasks, err := h.checklistService.ListTasks(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list close tasks")
+		return
+	}
+
+	response.Success(c, tasks)
+}
+
+// Readiness returns how far a month's close has got, the previous month
+// by default
+func (h *CloseChecklistHandler) Readiness(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	now := time.Now()
+	period := c.DefaultQuery("period", time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC).Format(models.PeriodFormat))
+
+	readiness, err := h.checklistService.Readiness(c.Request.Context(), tenantID, period)
+	if err != nil {
+		h.handleError(c, err, "Failed to get close readiness")
+		return
+	}
+
+	response.Success(c, readiness)
+}
+
+// Helper methods
+
+func (h *CloseChecklistHandler) handleError(c *gin.Context, err error, message string) {
+	if _, ok := err.(*time.ParseError); ok {
+		response.BadRequest(c, "Invalid date format", nil)
+		return
+	}
+
+	switch err {
+	case services.ErrCloseChecklistNotFound, services.ErrCloseTaskNotFound, services.ErrCloseTaskTemplateNotFound:
+		response.NotFound(c, err.Error())
+	case services.ErrInvalidClosePeriod, services.ErrInvalidCloseTaskStatus, services.ErrInvalidCloseTaskWeight:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrCloseChecklistExists:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *CloseChecklistHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *CloseChecklistHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
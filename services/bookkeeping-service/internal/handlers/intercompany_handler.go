@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// InterCompanyHandler handles transactions between tenants of a group
+type InterCompanyHandler struct {
+	interCompanyService services.InterCompanyService
+}
+
+// NewInterCompanyHandler creates a new inter-company handler
+func NewInterCompanyHandler(interCompanyService services.InterCompanyService) *InterCompanyHandler {
+	return &InterCompanyHandler{interCompanyService: interCompanyService}
+}
+
+// CreateTransaction records an inter-company transaction and its mirror in
+// the counterparty's books
+func (h *InterCompanyHandler) CreateTransaction(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req services.InterCompanyTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+	req.UserID = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	result, err := h.interCompanyService.CreateTransaction(c.Request.Context(), req)
+	if err != nil {
+		var unbalanced *services.UnbalancedJournalError
+		if errors.As(err, &unbalanced) {
+			response.BadRequest(c, "Each side of the journal must balance (debits must equal credits)", unbalanced.Details())
+			return
+		}
+		switch err {
+		case services.ErrTransactionNotBalanced:
+			response.BadRequest(c, "Each side of the journal must balance (debits must equal credits)", nil)
+		case services.ErrAccountNotFound:
+			response.BadRequest(c, "One or more accounts not found", nil)
+		case services.ErrInvalidAmount:
+			response.BadRequest(c, "Amounts must be positive, and each journal line a debit or a credit", nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
+		default:
+			h.handleError(c, err, "Failed to create inter-company transaction")
+		}
+		return
+	}
+
+	response.Created(c, result)
+}
+
+// MismatchReport lists inter-company transactions that do not agree with
+// the counterparty's books
+func (h *InterCompanyHandler) MismatchReport(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	report, err := h.interCompanyService.MismatchReport(c.Request.Context(), tenantID,
+		c.GetHeader("Authorization"), c.Query("from_date"), c.Query("to_date"))
+	if err != nil {
+		h.handleError(c, err, "Failed to generate inter-company mismatch report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+func (h *InterCompanyHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrNotInGroup:
+		response.NotFound(c, err.Error())
+	case services.ErrCounterpartyNotInGroup:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrTenantUnavailable:
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		if _, ok := err.(*time.ParseError); ok {
+			response.BadRequest(c, "Invalid transaction_date format", nil)
+			return
+		}
+		response.InternalError(c, message)
+	}
+}
+
+func (h *InterCompanyHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *InterCompanyHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// LedgerChainHandler handles the tamper-evident ledger hash chain endpoints
+type LedgerChainHandler struct {
+	ledgerChainService services.LedgerChainService
+}
+
+// NewLedgerChainHandler creates a new ledger chain handler
+func NewLedgerChainHandler(ledgerChainService services.LedgerChainService) *LedgerChainHandler {
+	return &LedgerChainHandler{ledgerChainService: ledgerChainService}
+}
+
+// GetHead returns the last transaction in the tenant's hash chain, which an
+// auditor can record to detect later changes
+func (h *LedgerChainHandler) GetHead(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	head, err := h.ledgerChainService.GetHead(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get ledger chain head")
+		return
+	}
+	if head == nil {
+		response.NotFound(c, "No transactions have been chained yet")
+		return
+	}
+
+	response.Success(c, head)
+}
+
+// Verify recomputes the tenant's hash chain and reports where it breaks
+func (h *LedgerChainHandler) Verify(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	result, err := h.ledgerChainService.Verify(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to verify ledger chain")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *LedgerChainHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
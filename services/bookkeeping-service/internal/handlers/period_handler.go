@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// PeriodHandler handles financial years and period close endpoints
+type PeriodHandler struct {
+	periodService services.PeriodService
+}
+
+// NewPeriodHandler creates a new period handler
+func NewPeriodHandler(periodService services.PeriodService) *PeriodHandler {
+	return &PeriodHandler{periodService: periodService}
+}
+
+// ListYears returns the tenant's financial years, latest first. The
+// current year is added from the tenant's settings if it is missing.
+func (h *PeriodHandler) ListYears(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	years, err := h.periodService.ListYears(c.Request.Context(), tenantID, c.GetHeader("Authorization"))
+	if err != nil {
+		response.InternalError(c, "Failed to get financial years")
+		return
+	}
+
+	response.Success(c, years)
+}
+
+// CreateYear adds a financial year
+func (h *PeriodHandler) CreateYear(c *gin.Context) {
+	var req services.CreateFinancialYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	year, err := h.periodService.CreateYear(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create financial year")
+		return
+	}
+
+	response.Created(c, year)
+}
+
+// ListPeriods returns the months of a financial year and whether each is
+// closed
+func (h *PeriodHandler) ListPeriods(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	yearID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid financial year ID", nil)
+		return
+	}
+
+	periods, err := h.periodService.ListPeriods(c.Request.Context(), tenantID, yearID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get periods")
+		return
+	}
+
+	response.Success(c, periods)
+}
+
+// Calendar returns the months and quarters of a financial year with
+// whether each is open or closed
+func (h *PeriodHandler) Calendar(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	yearID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid financial year ID", nil)
+		return
+	}
+
+	calendar, err := h.periodService.Calendar(c.Request.Context(), tenantID, yearID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get period calendar")
+		return
+	}
+
+	response.Success(c, calendar)
+}
+
+// ClosePeriod closes a period (e.g. "2024-04") of a financial year
+func (h *PeriodHandler) ClosePeriod(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	yearID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid financial year ID", nil)
+		return
+	}
+
+	period, err := h.periodService.ClosePeriod(c.Request.Context(), tenantID, userID, yearID, c.Param("period"))
+	if err != nil {
+		h.handleError(c, err, "Failed to close period")
+		return
+	}
+
+	response.Success(c, period)
+}
+
+// ReopenPeriod reopens a closed period, with the reason it is reopened
+func (h *PeriodHandler) ReopenPeriod(c *gin.Context) {
+	var req services.ReopenPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "A reason for reopening the period is required", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	yearID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid financial year ID", nil)
+		return
+	}
+
+	period, err := h.periodService.ReopenPeriod(c.Request.Context(), tenantID, userID, yearID, c.Param("period"), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to reopen period")
+		return
+	}
+
+	response.Success(c, period)
+}
+
+// Status reports whether a date falls in a closed period
+func (h *PeriodHandler) Status(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		response.BadRequest(c, "Invalid date format (use YYYY-MM-DD)", nil)
+		return
+	}
+
+	status, err := h.periodService.Status(c.Request.Context(), tenantID, date)
+	if err != nil {
+		response.InternalError(c, "Failed to get period status")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+func (h *PeriodHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrFinancialYearNotFound:
+		response.NotFound(c, "Financial year not found")
+	case services.ErrPeriodNotFound:
+		response.NotFound(c, err.Error())
+	case services.ErrFinancialYearOverlap, services.ErrPeriodAlreadyClosed, services.ErrPeriodNotClosed:
+		response.Conflict(c, err.Error())
+	case services.ErrInvalidFinancialYear:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *PeriodHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *PeriodHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
+
+// RequirePermission allows the request only if the caller's role in the
+// tenant grants the permission, as read from the tenant service
+func RequirePermission(tenants clients.TenantClient, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("tenant_id")
+		tenantIDStr, _ := value.(string)
+		tenantID, err := uuid.Parse(tenantIDStr)
+		if err != nil {
+			response.Forbidden(c, "Tenant not identified")
+			c.Abort()
+			return
+		}
+
+		permissions, err := tenants.GetMyPermissions(c.Request.Context(), c.GetHeader("Authorization"), tenantID)
+		if err != nil {
+			if errors.Is(err, clients.ErrForbidden) || errors.Is(err, clients.ErrNotFound) {
+				response.Forbidden(c, "Your role does not allow this action")
+			} else {
+				response.ServiceUnavailable(c, "Could not check permissions")
+			}
+			c.Abort()
+			return
+		}
+
+		for _, granted := range permissions {
+			if granted == permission {
+				c.Next()
+				return
+			}
+		}
+		response.Forbidden(c, "Your role does not allow this action")
+		c.Abort()
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// StandingInstructionHandler handles standing instruction endpoints
+type StandingInstructionHandler struct {
+	instructionService services.StandingInstructionService
+}
+
+// NewStandingInstructionHandler creates a new standing instruction handler
+func NewStandingInstructionHandler(instructionService services.StandingInstructionService) *StandingInstructionHandler {
+	return &StandingInstructionHandler{instructionService: instructionService}
+}
+
+// List returns detected standing instructions. missing=true limits the list
+// to expected debits and credits that have not arrived.
+func (h *StandingInstructionHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.StandingInstructionFilters{
+		Status: models.StandingInstructionStatus(c.Query("status")),
+	}
+	if bankAccountID := c.Query("bank_account_id"); bankAccountID != "" {
+		id, err := uuid.Parse(bankAccountID)
+		if err != nil {
+			response.BadRequest(c, "Invalid bank account ID", nil)
+			return
+		}
+		filters.BankAccountID = id
+	}
+
+	instructions, err := h.instructionService.List(c.Request.Context(), tenantID, filters, c.Query("missing") == "true")
+	if err != nil {
+		response.InternalError(c, "Failed to list standing instructions")
+		return
+	}
+
+	response.Success(c, instructions)
+}
+
+// Detect scans a bank account's statements for standing instructions
+func (h *StandingInstructionHandler) Detect(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank account ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	instructions, err := h.instructionService.Detect(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to detect standing instructions")
+		return
+	}
+
+	response.Success(c, instructions)
+}
+
+// CreateRecurring creates a recurring journal for a standing instruction
+func (h *StandingInstructionHandler) CreateRecurring(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("si_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid standing instruction ID", nil)
+		return
+	}
+
+	var req services.CreateRecurringFromInstructionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	instruction, err := h.instructionService.CreateRecurring(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create recurring journal")
+		return
+	}
+
+	response.Created(c, instruction)
+}
+
+// Link links a standing instruction to an existing recurring journal
+func (h *StandingInstructionHandler) Link(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("si_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid standing instruction ID", nil)
+		return
+	}
+
+	var req struct {
+		RecurringJournalID uuid.UUID `json:"recurring_journal_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	instruction, err := h.instructionService.Link(c.Request.Context(), tenantID, id, req.RecurringJournalID)
+	if err != nil {
+		h.handleError(c, err, "Failed to link standing instruction")
+		return
+	}
+
+	response.Success(c, instruction)
+}
+
+// Dismiss stops suggesting a standing instruction and alerting when it is
+// missing
+func (h *StandingInstructionHandler) Dismiss(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("si_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid standing instruction ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	instruction, err := h.instructionService.Dismiss(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to dismiss standing instruction")
+		return
+	}
+
+	response.Success(c, instruction)
+}
+
+// Helper methods
+
+func (h *StandingInstructionHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrStandingInstructionNotFound:
+		response.NotFound(c, "Standing instruction not found")
+	case services.ErrBankAccountNotFound:
+		response.NotFound(c, "Bank account not found")
+	case services.ErrRecurringJournalNotFound:
+		response.NotFound(c, "Recurring journal not found")
+	case services.ErrStandingInstructionLinked:
+		response.Conflict(c, err.Error())
+	case services.ErrAccountNotFound:
+		response.BadRequest(c, "Account not found", nil)
+	case services.ErrNoLedgerAccount, services.ErrInvalidRecurrence, services.ErrJournalNotBalanced:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *StandingInstructionHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *StandingInstructionHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 	"time"
 
@@ -8,7 +9,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
 )
 
 // TransactionHandler handles transaction-related endpoints
@@ -21,6 +25,39 @@ func NewTransactionHandler(transactionService services.TransactionService) *Tran
 	return &TransactionHandler{transactionService: transactionService}
 }
 
+// AuditedYearOnly hides transactions outside an auditor's financial year
+// from the routes that load one by ID, as those carry no date filter to
+// hold to the year
+func (h *TransactionHandler) AuditedYearOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !middleware.IsAuditor(c) || c.Param("id") == "" {
+			c.Next()
+			return
+		}
+
+		tenantID, err := h.getTenantIDFromContext(c)
+		if err != nil {
+			response.BadRequest(c, "Tenant ID required", nil)
+			c.Abort()
+			return
+		}
+		transactionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			response.BadRequest(c, "Invalid transaction ID", nil)
+			c.Abort()
+			return
+		}
+
+		transaction, err := h.transactionService.GetTransaction(c.Request.Context(), transactionID, tenantID)
+		if err != nil || !middleware.WithinAuditorPeriod(c, transaction.TransactionDate) {
+			response.NotFound(c, "Transaction not found")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // CreateTransaction handles transaction creation
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
@@ -40,14 +77,33 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		response.BadRequest(c, "Invalid request body", nil)
 		return
 	}
+	req.Authorization = c.GetHeader("Authorization")
 
 	transaction, err := h.transactionService.CreateTransaction(c.Request.Context(), tenantID, userID, req)
 	if err != nil {
+		var unbalanced *services.UnbalancedJournalError
+		if errors.As(err, &unbalanced) {
+			response.BadRequest(c, "Transaction is not balanced (debits must equal credits)", unbalanced.Details())
+			return
+		}
+		var broken *validation.Error
+		if errors.As(err, &broken) {
+			response.ValidationError(c, "The journal breaks the business's validation rules", broken.Details())
+			return
+		}
 		switch err {
 		case services.ErrTransactionNotBalanced:
 			response.BadRequest(c, "Transaction is not balanced (debits must equal credits)", nil)
+		case services.ErrInvalidAmount:
+			response.BadRequest(c, "Each line must be a debit or a credit of a positive amount", nil)
 		case services.ErrAccountNotFound:
 			response.BadRequest(c, "One or more accounts not found", nil)
+		case services.ErrInvalidAdjustment, tags.ErrInvalidTag, tags.ErrTooManyTags:
+			response.BadRequest(c, err.Error(), nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
 		default:
 			response.InternalError(c, "Failed to create transaction")
 		}
@@ -76,14 +132,22 @@ func (h *TransactionHandler) CreateQuickSale(c *gin.Context) {
 		response.BadRequest(c, "Invalid request body", nil)
 		return
 	}
+	req.Authorization = c.GetHeader("Authorization")
 
 	transaction, err := h.transactionService.CreateQuickSale(c.Request.Context(), tenantID, userID, req)
 	if err != nil {
-		if err == services.ErrAccountNotFound {
+		switch err {
+		case services.ErrAccountNotFound:
 			response.BadRequest(c, "Default accounts not configured", nil)
-			return
+		case tags.ErrInvalidTag, tags.ErrTooManyTags:
+			response.BadRequest(c, err.Error(), nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
+		default:
+			response.InternalError(c, "Failed to create sale")
 		}
-		response.InternalError(c, "Failed to create sale")
 		return
 	}
 
@@ -117,6 +181,12 @@ func (h *TransactionHandler) CreateQuickExpense(c *gin.Context) {
 			response.BadRequest(c, "Account not found", nil)
 		case services.ErrInvalidAmount:
 			response.BadRequest(c, "Amount must be greater than zero", nil)
+		case tags.ErrInvalidTag, tags.ErrTooManyTags:
+			response.BadRequest(c, err.Error(), nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
 		default:
 			response.InternalError(c, "Failed to create expense")
 		}
@@ -126,6 +196,128 @@ func (h *TransactionHandler) CreateQuickExpense(c *gin.Context) {
 	response.Created(c, transaction)
 }
 
+// CreateBillPayment handles posting a vendor bill payment with TDS split
+func (h *TransactionHandler) CreateBillPayment(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req services.BillPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	transaction, err := h.transactionService.CreateBillPayment(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		switch err {
+		case services.ErrAccountNotFound:
+			response.BadRequest(c, "Account not found", nil)
+		case services.ErrInvalidAmount:
+			response.BadRequest(c, "Gross amount must be greater than zero and exceed TDS", nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
+		default:
+			response.InternalError(c, "Failed to create bill payment")
+		}
+		return
+	}
+
+	response.Created(c, transaction)
+}
+
+// CreateCustomerAdvance handles posting an advance from a customer, or its
+// refund
+func (h *TransactionHandler) CreateCustomerAdvance(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req services.CustomerAdvanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	transaction, err := h.transactionService.CreateCustomerAdvance(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		switch err {
+		case services.ErrAccountNotFound:
+			response.BadRequest(c, "Account not found", nil)
+		case services.ErrInvalidAmount:
+			response.BadRequest(c, "Amount must be greater than zero and exceed the tax on it", nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
+		default:
+			response.InternalError(c, "Failed to post customer advance")
+		}
+		return
+	}
+
+	response.Created(c, transaction)
+}
+
+// PostDocument handles posting an invoice or bill finalized in the invoice
+// service
+func (h *TransactionHandler) PostDocument(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req services.DocumentPostingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	transaction, err := h.transactionService.PostDocument(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		switch err {
+		case services.ErrAccountNotFound:
+			response.BadRequest(c, "Account not found", nil)
+		case services.ErrInvalidAmount:
+			response.BadRequest(c, "Total amount must be the taxable amount, tax and round off", nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
+		case services.ErrNoFinancialYear:
+			response.Conflict(c, "The date is outside the financial years; add the year it falls in first")
+		default:
+			response.InternalError(c, "Failed to post document")
+		}
+		return
+	}
+
+	response.Created(c, transaction)
+}
+
 // GetTransaction handles getting a single transaction
 func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
@@ -149,6 +341,38 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	response.Success(c, transaction)
 }
 
+// GetSupportingDetails handles getting how a transaction's amounts were
+// derived: the rates, rounding and source documents of the computation it
+// was posted from
+func (h *TransactionHandler) GetSupportingDetails(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid transaction ID", nil)
+		return
+	}
+
+	detail, err := h.transactionService.GetSupportingDetail(c.Request.Context(), transactionID, tenantID)
+	if err != nil {
+		switch err {
+		case services.ErrTransactionNotFound:
+			response.NotFound(c, "Transaction not found")
+		case services.ErrSupportingDetailNotFound:
+			response.NotFound(c, "Transaction was not posted from a computation and has no supporting details")
+		default:
+			response.InternalError(c, "Failed to get supporting details")
+		}
+		return
+	}
+
+	response.Success(c, detail)
+}
+
 // ListTransactions handles listing transactions
 func (h *TransactionHandler) ListTransactions(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
@@ -183,6 +407,14 @@ func (h *TransactionHandler) ListTransactions(c *gin.Context) {
 			filter.StoreID = &id
 		}
 	}
+	if tag := c.Query("tag"); tag != "" {
+		if normalized, err := tags.Normalize(tag); err == nil {
+			filter.Tag = normalized
+		}
+	}
+	if isAdjustment, err := strconv.ParseBool(c.Query("is_adjustment")); err == nil {
+		filter.IsAdjustment = &isAdjustment
+	}
 
 	transactions, total, err := h.transactionService.ListTransactions(c.Request.Context(), tenantID, filter)
 	if err != nil {
@@ -213,6 +445,8 @@ func (h *TransactionHandler) VoidTransaction(c *gin.Context) {
 			response.NotFound(c, "Transaction not found")
 		case services.ErrCannotVoidTransaction:
 			response.BadRequest(c, "Cannot void this transaction", nil)
+		case services.ErrPeriodClosed:
+			response.Conflict(c, "The accounting period of this date is closed")
 		default:
 			response.InternalError(c, "Failed to void transaction")
 		}
@@ -246,6 +480,124 @@ func (h *TransactionHandler) GetDailySummary(c *gin.Context) {
 	response.Success(c, summary)
 }
 
+// SetTagsRequest replaces a transaction's tags
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// RenameTagRequest renames a tag
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// SetTags handles replacing a transaction's tags
+func (h *TransactionHandler) SetTags(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid transaction ID", nil)
+		return
+	}
+
+	var req SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	transaction, err := h.transactionService.SetTags(c.Request.Context(), transactionID, tenantID, userID, req.Tags)
+	if err != nil {
+		switch err {
+		case services.ErrTransactionNotFound:
+			response.NotFound(c, "Transaction not found")
+		case tags.ErrInvalidTag, tags.ErrTooManyTags:
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to update tags")
+		}
+		return
+	}
+
+	response.Success(c, transaction)
+}
+
+// ListTags handles listing the tags in use with their transaction counts
+func (h *TransactionHandler) ListTags(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	usage, err := h.transactionService.ListTags(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list tags")
+		return
+	}
+
+	response.Success(c, usage)
+}
+
+// RenameTag handles renaming a tag on every transaction. Renaming to a tag
+// that already exists merges the two.
+func (h *TransactionHandler) RenameTag(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	updated, err := h.transactionService.RenameTag(c.Request.Context(), tenantID, c.Param("tag"), req.Name)
+	if err != nil {
+		if err == tags.ErrInvalidTag {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to rename tag")
+		return
+	}
+
+	response.Success(c, gin.H{"updated": updated})
+}
+
+// DeleteTag handles removing a tag from every transaction
+func (h *TransactionHandler) DeleteTag(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	updated, err := h.transactionService.DeleteTag(c.Request.Context(), tenantID, c.Param("tag"))
+	if err != nil {
+		if err == tags.ErrInvalidTag {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to delete tag")
+		return
+	}
+
+	response.Success(c, gin.H{"updated": updated})
+}
+
 // Helper methods
 
 func (h *TransactionHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
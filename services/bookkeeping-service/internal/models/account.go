@@ -88,6 +88,12 @@ func (a *Account) IsCreditNature() bool {
 	return a.Type == AccountTypeLiability || a.Type == AccountTypeEquity || a.Type == AccountTypeIncome
 }
 
+// Statement feed types for bank accounts
+const (
+	FeedTypeBank          = "bank"
+	FeedTypeCorporateCard = "corporate_card"
+)
+
 // BankAccount represents a bank account linked to a ledger account
 type BankAccount struct {
 	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -101,7 +107,8 @@ type BankAccount struct {
 	IFSCCode               string `gorm:"size:11" json:"ifsc_code"`
 	Branch                 string `gorm:"size:255" json:"branch"`
 
-	AccountType string `gorm:"size:50" json:"account_type"` // savings, current, overdraft
+	AccountType string `gorm:"size:50" json:"account_type"`             // savings, current, overdraft
+	FeedType    string `gorm:"size:20;default:'bank'" json:"feed_type"` // bank, corporate_card
 
 	OpeningBalance float64 `gorm:"type:decimal(15,2);default:0" json:"opening_balance"`
 	CurrentBalance float64 `gorm:"type:decimal(15,2);default:0" json:"current_balance"`
@@ -122,6 +129,12 @@ func (BankAccount) TableName() string {
 	return "bank_accounts"
 }
 
+// IsCardFeed reports whether the account's statements are corporate card
+// statements, whose spends are assigned to members
+func (b *BankAccount) IsCardFeed() bool {
+	return b.FeedType == FeedTypeCorporateCard
+}
+
 // BeforeCreate hook
 func (b *BankAccount) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == uuid.Nil {
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostingType is a logical account that quick entries and automated
+// postings use, resolved to one of the tenant's accounts
+type PostingType string
+
+const (
+	PostingTypeCash           PostingType = "cash"
+	PostingTypeBank           PostingType = "bank"
+	PostingTypeReceivable     PostingType = "accounts_receivable"
+	PostingTypePayable        PostingType = "accounts_payable"
+	PostingTypeSalesIncome    PostingType = "sales_income"
+	PostingTypePurchases      PostingType = "purchases"
+	PostingTypeGSTInput       PostingType = "gst_input"
+	PostingTypeGSTOutput      PostingType = "gst_output"
+	PostingTypeTDSPayable     PostingType = "tds_payable"
+	PostingTypeTCSPayable     PostingType = "tcs_payable"
+	PostingTypeRoundOff       PostingType = "round_off"
+	PostingTypeBankCharges    PostingType = "bank_charges"
+	PostingTypeInterestIncome PostingType = "interest_income"
+	PostingTypeCashOverShort  PostingType = "cash_over_short"
+)
+
+// PostingAccount describes a posting type: the account it uses in the
+// default chart, and the kinds of account it may be mapped to
+type PostingAccount struct {
+	Type         PostingType
+	DefaultCode  string
+	AccountTypes []AccountType
+	SubType      AccountSubType // The mapped account must have it, if set
+}
+
+// PostingAccounts are the posting types, in the order they are listed
+var PostingAccounts = []PostingAccount{
+	{Type: PostingTypeCash, DefaultCode: "1100", AccountTypes: []AccountType{AccountTypeAsset}, SubType: AccountSubTypeCash},
+	{Type: PostingTypeBank, DefaultCode: "1200", AccountTypes: []AccountType{AccountTypeAsset}, SubType: AccountSubTypeBank},
+	{Type: PostingTypeReceivable, DefaultCode: "1300", AccountTypes: []AccountType{AccountTypeAsset}},
+	{Type: PostingTypePayable, DefaultCode: "2100", AccountTypes: []AccountType{AccountTypeLiability}},
+	{Type: PostingTypeSalesIncome, DefaultCode: "4100", AccountTypes: []AccountType{AccountTypeIncome}},
+	{Type: PostingTypePurchases, DefaultCode: "5200", AccountTypes: []AccountType{AccountTypeExpense}},
+	{Type: PostingTypeGSTInput, DefaultCode: "1600", AccountTypes: []AccountType{AccountTypeAsset}},
+	{Type: PostingTypeGSTOutput, DefaultCode: "2200", AccountTypes: []AccountType{AccountTypeLiability}},
+	{Type: PostingTypeTDSPayable, DefaultCode: "2300", AccountTypes: []AccountType{AccountTypeLiability}},
+	{Type: PostingTypeTCSPayable, DefaultCode: "2350", AccountTypes: []AccountType{AccountTypeLiability}},
+	{Type: PostingTypeRoundOff, DefaultCode: "5800", AccountTypes: []AccountType{AccountTypeExpense, AccountTypeIncome}},
+	{Type: PostingTypeBankCharges, DefaultCode: "5700", AccountTypes: []AccountType{AccountTypeExpense}},
+	{Type: PostingTypeInterestIncome, DefaultCode: "4300", AccountTypes: []AccountType{AccountTypeIncome}},
+	{Type: PostingTypeCashOverShort, DefaultCode: "5850", AccountTypes: []AccountType{AccountTypeExpense, AccountTypeIncome}},
+}
+
+// LookupPostingAccount returns the description of a posting type
+func LookupPostingAccount(postingType PostingType) (PostingAccount, bool) {
+	for _, posting := range PostingAccounts {
+		if posting.Type == postingType {
+			return posting, true
+		}
+	}
+	return PostingAccount{}, false
+}
+
+// Accepts reports whether the account may be mapped to the posting type
+func (p PostingAccount) Accepts(account *Account) bool {
+	if p.SubType != "" && account.SubType != p.SubType {
+		return false
+	}
+	for _, accountType := range p.AccountTypes {
+		if account.Type == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountMapping maps a posting type to one of the tenant's accounts. A
+// posting type without a mapping uses its account in the default chart.
+type AccountMapping struct {
+	TenantID    uuid.UUID   `gorm:"type:uuid;primary_key" json:"tenant_id"`
+	PostingType PostingType `gorm:"type:varchar(30);primary_key" json:"posting_type"`
+	AccountID   uuid.UUID   `gorm:"type:uuid;not null" json:"account_id"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for AccountMapping
+func (AccountMapping) TableName() string {
+	return "account_mappings"
+}
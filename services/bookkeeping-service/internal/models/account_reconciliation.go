@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccountReconciliationStatus represents the status of an account
+// reconciliation
+type AccountReconciliationStatus string
+
+const (
+	AccountReconciliationOpen      AccountReconciliationStatus = "open"      // Being worked on
+	AccountReconciliationCertified AccountReconciliationStatus = "certified" // Signed off for the close
+)
+
+// AccountReconciliation is the month-end reconciliation of a balance sheet
+// account other than a bank, such as GST or TDS payable or a loan, with the
+// balance an outside source reports for it: the GST portal's ledgers,
+// TRACES, or the lender's statement. Ledger entries the source agrees with
+// are ticked off; those left open explain the difference and are carried
+// into the next period's reconciliation.
+type AccountReconciliation struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_account_reconciliation_period" json:"tenant_id"`
+	AccountID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_account_reconciliation_period" json:"account_id"`
+
+	Period    string    `gorm:"size:7;not null;uniqueIndex:idx_account_reconciliation_period;index" json:"period"` // e.g., "2024-04"
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+
+	// Balances in the account's natural direction: credit balances are
+	// positive for liabilities and equity, debit balances for assets
+	BookBalance     float64 `gorm:"type:decimal(15,2);not null" json:"book_balance"`
+	ExternalBalance float64 `gorm:"type:decimal(15,2);not null" json:"external_balance"`
+	ExternalSource  string  `gorm:"size:200" json:"external_source"` // e.g., "GST portal electronic liability ledger"
+
+	// OpenItems is the net of the entries not ticked off; Difference is
+	// what they leave unexplained, and must be zero to certify
+	OpenItems  float64 `gorm:"type:decimal(15,2);not null" json:"open_items"`
+	Difference float64 `gorm:"type:decimal(15,2);not null" json:"difference"`
+
+	Status AccountReconciliationStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Notes  string                      `gorm:"type:text" json:"notes"`
+
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CertifiedBy *uuid.UUID `gorm:"type:uuid" json:"certified_by,omitempty"`
+	CertifiedAt *time.Time `json:"certified_at,omitempty"`
+
+	// Head of the tenant's ledger hash chain when it was certified, so any
+	// later change to the entries it covers breaks the recorded chain
+	ChainSequence *int64 `json:"chain_sequence,omitempty"`
+	ChainHash     string `gorm:"size:64" json:"chain_hash,omitempty"`
+
+	// Relations
+	Account *Account                    `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Items   []AccountReconciliationItem `gorm:"foreignKey:ReconciliationID" json:"items,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for AccountReconciliation
+func (AccountReconciliation) TableName() string {
+	return "account_reconciliations"
+}
+
+// BeforeCreate hook
+func (r *AccountReconciliation) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsCertified reports whether the reconciliation has been signed off
+func (r *AccountReconciliation) IsCertified() bool {
+	return r.Status == AccountReconciliationCertified
+}
+
+// AccountReconciliationItem is a ledger entry of the account in a
+// reconciliation: one dated in its period, or one left open by the
+// previous period's reconciliation
+type AccountReconciliationItem struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReconciliationID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_account_reconciliation_item_line" json:"reconciliation_id"`
+	TransactionLineID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_account_reconciliation_item_line" json:"transaction_line_id"`
+
+	TransactionID     uuid.UUID `gorm:"type:uuid;not null" json:"transaction_id"`
+	TransactionNumber string    `gorm:"size:50" json:"transaction_number"`
+	TransactionDate   time.Time `gorm:"type:date;not null" json:"transaction_date"`
+	Description       string    `gorm:"type:text" json:"description"`
+	DebitAmount       float64   `gorm:"type:decimal(15,2);default:0" json:"debit_amount"`
+	CreditAmount      float64   `gorm:"type:decimal(15,2);default:0" json:"credit_amount"`
+	Amount            float64   `gorm:"type:decimal(15,2);not null" json:"amount"` // In the account's natural direction
+
+	// The reconciliation the entry was left open in, for carried items
+	CarriedFromID *uuid.UUID `gorm:"type:uuid" json:"carried_from_id,omitempty"`
+
+	Matched   bool       `gorm:"default:false" json:"matched"` // Agreed with the outside source
+	MatchedBy *uuid.UUID `gorm:"type:uuid" json:"matched_by,omitempty"`
+	MatchedAt *time.Time `json:"matched_at,omitempty"`
+	Note      string     `gorm:"type:text" json:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for AccountReconciliationItem
+func (AccountReconciliationItem) TableName() string {
+	return "account_reconciliation_items"
+}
+
+// BeforeCreate hook
+func (i *AccountReconciliationItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BankFeedStatus is the state of a bank account's feed
+type BankFeedStatus string
+
+const (
+	BankFeedPending BankFeedStatus = "pending" // Waiting for the account holder to approve the consent
+	BankFeedActive  BankFeedStatus = "active"
+	BankFeedPaused  BankFeedStatus = "paused"  // Not synced until resumed
+	BankFeedExpired BankFeedStatus = "expired" // The consent ran out; the account must be linked again
+	BankFeedRevoked BankFeedStatus = "revoked" // Disconnected by the tenant or the account holder
+	BankFeedFailed  BankFeedStatus = "failed"  // The consent was rejected or the link could not be made
+)
+
+// BankFeedConnection links a bank account to a feed provider, such as an
+// account aggregator, which statement lines are pulled from. Each sync
+// continues from the cursor the last one stopped at.
+type BankFeedConnection struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	BankAccountID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"bank_account_id"`
+
+	Provider       string `gorm:"size:50;not null" json:"provider"`
+	CustomerHandle string `gorm:"size:255" json:"customer_handle"` // The account holder's handle at the aggregator, such as 98xxxxxx10@onemoney
+	ConsentID      string `gorm:"size:255;index" json:"consent_id,omitempty"`
+	ConsentURL     string `gorm:"type:text" json:"consent_url,omitempty"` // Where the account holder approves the consent
+	AccountRef     string `gorm:"size:255" json:"-"`                      // The provider's reference of the linked account
+	MaskedAccount  string `gorm:"size:50" json:"masked_account,omitempty"`
+
+	Status           BankFeedStatus `gorm:"size:20;not null;index" json:"status"`
+	ConsentExpiresAt *time.Time     `json:"consent_expires_at,omitempty"`
+	SyncFrom         time.Time      `gorm:"type:date;not null" json:"sync_from"` // Lines before this date are not pulled
+
+	// Sync progress. Cursor is the provider's position in the feed.
+	Cursor         string     `gorm:"type:text" json:"-"`
+	LastSyncedAt   *time.Time `json:"last_synced_at,omitempty"`
+	LastImported   int        `gorm:"default:0" json:"last_imported"`
+	LastDuplicates int        `gorm:"default:0" json:"last_duplicates"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for BankFeedConnection
+func (BankFeedConnection) TableName() string {
+	return "bank_feed_connections"
+}
+
+// BeforeCreate hook
+func (c *BankFeedConnection) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsLive reports whether the connection is still being synced or waiting
+// to be
+func (c *BankFeedConnection) IsLive() bool {
+	return c.Status == BankFeedPending || c.Status == BankFeedActive || c.Status == BankFeedPaused
+}
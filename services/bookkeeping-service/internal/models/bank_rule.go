@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BankRuleKind is what a bank rule recognizes on a statement
+type BankRuleKind string
+
+const (
+	BankRuleKindCharge    BankRuleKind = "bank_charge" // Charges and fees debited by the bank
+	BankRuleKindChargeGST BankRuleKind = "charge_gst"  // GST the bank levies on its charges
+	BankRuleKindInterest  BankRuleKind = "interest"    // Interest credited by the bank
+)
+
+// BankRule recognizes statement lines the bank raises itself, such as SMS
+// charges or savings interest, and posts them to a ledger account as they
+// are imported. Rules are tried in priority order and the first match wins.
+type BankRule struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+
+	// Limits the rule to one bank account; nil applies it to all
+	BankAccountID *uuid.UUID `gorm:"type:uuid;index" json:"bank_account_id,omitempty"`
+
+	Name string       `gorm:"size:100;not null" json:"name"`
+	Kind BankRuleKind `gorm:"type:varchar(20);not null" json:"kind"`
+
+	// Case-insensitive regular expression matched against the statement
+	// description
+	Pattern string `gorm:"size:500;not null" json:"pattern"`
+
+	// Ledger account the matched amount is posted to: an expense for
+	// charges, the GST input credit account for GST and an income account
+	// for interest
+	AccountID uuid.UUID `gorm:"type:uuid;not null" json:"account_id"`
+
+	Priority int  `gorm:"default:100" json:"priority"` // Lower runs first
+	IsActive bool `gorm:"default:true" json:"is_active"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for BankRule
+func (BankRule) TableName() string {
+	return "bank_rules"
+}
+
+// BeforeCreate hook
+func (r *BankRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsCredit reports whether the rule matches money paid in by the bank
+// rather than taken by it
+func (r *BankRule) IsCredit() bool {
+	return r.Kind == BankRuleKindInterest
+}
+
+// AppliesTo reports whether the rule may match a line of the bank account
+func (r *BankRule) AppliesTo(bankAccountID uuid.UUID) bool {
+	return r.BankAccountID == nil || *r.BankAccountID == bankAccountID
+}
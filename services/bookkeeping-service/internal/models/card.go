@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CorporateCard maps a card on a corporate card feed to the member who
+// holds it
+type CorporateCard struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	BankAccountID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_corporate_card" json:"bank_account_id"`
+	CardLast4     string    `gorm:"size:4;not null;uniqueIndex:idx_corporate_card" json:"card_last4"`
+	MemberID      uuid.UUID `gorm:"type:uuid;not null;index" json:"member_id"`
+	HolderName    string    `gorm:"size:255" json:"holder_name"`
+	IsActive      bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for CorporateCard
+func (CorporateCard) TableName() string {
+	return "corporate_cards"
+}
+
+// BeforeCreate hook
+func (c *CorporateCard) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// CardSpend tracks a purchase on a corporate card feed through
+// categorization by the cardholder and matching to their expense claim
+type CardSpend struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	BankAccountID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"bank_account_id"`
+	BankTransactionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"bank_transaction_id"`
+	CardLast4         string     `gorm:"size:4" json:"card_last4"`
+	TransactionDate   time.Time  `gorm:"type:date;not null" json:"transaction_date"`
+	Description       string     `gorm:"type:text" json:"description"`
+	Amount            float64    `gorm:"type:decimal(15,2);not null" json:"amount"`
+	MemberID          *uuid.UUID `gorm:"type:uuid;index" json:"member_id,omitempty"` // Nil until the card is assigned
+
+	// Categorization by the cardholder
+	Category      string     `gorm:"size:100" json:"category,omitempty"`
+	ReceiptURL    string     `gorm:"size:500" json:"receipt_url,omitempty"`
+	Notes         string     `gorm:"type:text" json:"notes,omitempty"`
+	CategorizedBy *uuid.UUID `gorm:"type:uuid" json:"categorized_by,omitempty"`
+	CategorizedAt *time.Time `json:"categorized_at,omitempty"`
+
+	// Expense claim the spend is accounted for in
+	ExpenseClaimID     *uuid.UUID `gorm:"type:uuid;index" json:"expense_claim_id,omitempty"`
+	ExpenseClaimNumber string     `gorm:"size:50" json:"expense_claim_number,omitempty"`
+	MatchedAt          *time.Time `json:"matched_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for CardSpend
+func (CardSpend) TableName() string {
+	return "card_spends"
+}
+
+// BeforeCreate hook
+func (s *CardSpend) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsCategorized reports whether the spend has a category and a receipt
+func (s *CardSpend) IsCategorized() bool {
+	return s.Category != "" && s.ReceiptURL != ""
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CashClosingStatus represents the status of a daily cash closing
+type CashClosingStatus string
+
+const (
+	CashClosingStatusBalanced        CashClosingStatus = "balanced"         // Count matched the books
+	CashClosingStatusPendingApproval CashClosingStatus = "pending_approval" // Over/short awaiting review
+	CashClosingStatusApproved        CashClosingStatus = "approved"         // Over/short posted
+	CashClosingStatusRejected        CashClosingStatus = "rejected"         // Sent back for a recount
+)
+
+// DefaultDenominations are the Indian currency notes and coins offered on
+// the cash count sheet, largest first
+var DefaultDenominations = []float64{500, 200, 100, 50, 20, 10, 5, 2, 1}
+
+// Denomination is the number of notes or coins of one value counted in the
+// cash drawer
+type Denomination struct {
+	Value float64 `json:"value"`
+	Count int     `json:"count"`
+}
+
+// Amount returns the value of the notes or coins counted
+func (d Denomination) Amount() float64 {
+	return d.Value * float64(d.Count)
+}
+
+// Denominations is a cash count sheet, stored as JSONB
+type Denominations []Denomination
+
+// Total returns the cash counted across all denominations
+func (d Denominations) Total() float64 {
+	var total float64
+	for _, denomination := range d {
+		total += denomination.Amount()
+	}
+	return total
+}
+
+// Value implements driver.Valuer
+func (d Denominations) Value() (driver.Value, error) {
+	if d == nil {
+		return json.Marshal([]Denomination{})
+	}
+	return json.Marshal([]Denomination(d))
+}
+
+// Scan implements sql.Scanner
+func (d *Denominations) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*d = nil
+		return nil
+	default:
+		return errors.New("unsupported type for denominations")
+	}
+	return json.Unmarshal(data, d)
+}
+
+// CashClosing is the end-of-day count of a cash account. The cashier's
+// physical count is compared with the book balance; any difference is
+// posted to the over/short account once approved by someone else.
+type CashClosing struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_cash_closing_day" json:"tenant_id"`
+	CashAccountID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_cash_closing_day" json:"cash_account_id"`
+	ClosingDate   time.Time `gorm:"type:date;not null;uniqueIndex:idx_cash_closing_day" json:"closing_date"`
+
+	Denominations Denominations `gorm:"type:jsonb;not null" json:"denominations"`
+
+	// Book figures for the day, before any over/short adjustment
+	OpeningBalance float64 `gorm:"type:decimal(15,2);not null" json:"opening_balance"`
+	Receipts       float64 `gorm:"type:decimal(15,2);not null" json:"receipts"`
+	Payments       float64 `gorm:"type:decimal(15,2);not null" json:"payments"`
+	BookBalance    float64 `gorm:"type:decimal(15,2);not null" json:"book_balance"`
+
+	CountedAmount float64 `gorm:"type:decimal(15,2);not null" json:"counted_amount"`
+	Difference    float64 `gorm:"type:decimal(15,2);not null" json:"difference"` // Counted less book: positive is excess, negative is short
+
+	Status CashClosingStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Notes  string            `gorm:"type:text" json:"notes"`
+
+	// The over/short journal, set once the difference is posted
+	OverShortAccountID      *uuid.UUID `gorm:"type:uuid" json:"over_short_account_id,omitempty"`
+	AdjustmentTransactionID *uuid.UUID `gorm:"type:uuid" json:"adjustment_transaction_id,omitempty"`
+
+	// Review; an approved closing without a reviewer was within the
+	// auto-approve limit
+	CountedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"counted_by"`
+	ReviewedBy      *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `gorm:"type:text" json:"rejection_reason,omitempty"`
+
+	// Head of the tenant's ledger hash chain when the day was closed, so
+	// any later change to the day's entries breaks the recorded chain
+	ChainSequence *int64 `json:"chain_sequence,omitempty"`
+	ChainHash     string `gorm:"size:64" json:"chain_hash,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for CashClosing
+func (CashClosing) TableName() string {
+	return "cash_closings"
+}
+
+// BeforeCreate hook
+func (cc *CashClosing) BeforeCreate(tx *gorm.DB) error {
+	if cc.ID == uuid.Nil {
+		cc.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsFinal reports whether the closing can no longer be recounted
+func (cc *CashClosing) IsFinal() bool {
+	return cc.Status == CashClosingStatusBalanced || cc.Status == CashClosingStatusApproved
+}
+
+// CashClosingSettings configures a tenant's daily cash closing
+type CashClosingSettings struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primary_key" json:"tenant_id"`
+
+	// Account over/short differences are posted to. When unset, the
+	// tenant's cash over/short account mapping is used.
+	OverShortAccountID *uuid.UUID `gorm:"type:uuid" json:"over_short_account_id"`
+
+	// Differences up to this amount are posted without approval; 0
+	// sends every difference for approval
+	AutoApproveLimit float64 `gorm:"type:decimal(15,2);default:0" json:"auto_approve_limit"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for CashClosingSettings
+func (CashClosingSettings) TableName() string {
+	return "cash_closing_settings"
+}
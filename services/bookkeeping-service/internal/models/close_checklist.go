@@ -0,0 +1,237 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CloseTaskStatus represents the status of a month-end close task
+type CloseTaskStatus string
+
+const (
+	CloseTaskPending    CloseTaskStatus = "pending"
+	CloseTaskInProgress CloseTaskStatus = "in_progress"
+	CloseTaskDone       CloseTaskStatus = "done"
+	CloseTaskSkipped    CloseTaskStatus = "skipped" // Not applicable this month
+)
+
+// IsValid reports whether the status is a known one
+func (s CloseTaskStatus) IsValid() bool {
+	switch s {
+	case CloseTaskPending, CloseTaskInProgress, CloseTaskDone, CloseTaskSkipped:
+		return true
+	}
+	return false
+}
+
+// IsFinished reports whether the task no longer holds up the close
+func (s CloseTaskStatus) IsFinished() bool {
+	return s == CloseTaskDone || s == CloseTaskSkipped
+}
+
+// CloseChecklistStatus represents the status of a month's close checklist
+type CloseChecklistStatus string
+
+const (
+	CloseChecklistOpen     CloseChecklistStatus = "open"
+	CloseChecklistComplete CloseChecklistStatus = "complete" // Every task done or skipped
+)
+
+// CloseTaskTemplate is a task of a tenant's month-end close checklist. Each
+// month's checklist is opened with a copy of the active templates.
+type CloseTaskTemplate struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+
+	Title       string `gorm:"size:200;not null" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+	Category    string `gorm:"size:50" json:"category"` // e.g., "banking", "tax"
+
+	// Where the work is done: a screen of the web app and the API behind it
+	ScreenLink string `gorm:"size:500" json:"screen_link"` // e.g., "/banking"
+	APILink    string `gorm:"size:500" json:"api_link"`    // e.g., "/api/v1/bank/accounts"
+
+	AssigneeID *uuid.UUID `gorm:"type:uuid" json:"assignee_id,omitempty"`
+	DueDays    int        `gorm:"default:0" json:"due_days"` // Days after the month ends
+	Weight     int        `gorm:"default:1" json:"weight"`   // Share of the readiness score
+	SortOrder  int        `gorm:"default:0" json:"sort_order"`
+	IsActive   bool       `gorm:"default:true" json:"is_active"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for CloseTaskTemplate
+func (CloseTaskTemplate) TableName() string {
+	return "close_task_templates"
+}
+
+// BeforeCreate hook
+func (t *CloseTaskTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// DefaultCloseTaskTemplates returns the checklist a tenant starts with
+func DefaultCloseTaskTemplates(tenantID uuid.UUID) []CloseTaskTemplate {
+	templates := []CloseTaskTemplate{
+		{Title: "Import all bank statements", Category: "banking", ScreenLink: "/banking", APILink: "/api/v1/bank/accounts", DueDays: 2,
+			Description: "Import or sync the statements of every bank account up to the last day of the month."},
+		{Title: "Reconcile bank accounts", Category: "banking", ScreenLink: "/banking", APILink: "/api/v1/bank/accounts", DueDays: 3, Weight: 2,
+			Description: "Match every statement line of the month and agree the reconciled balance with the statement."},
+		{Title: "Close cash for the month", Category: "banking", ScreenLink: "/banking", APILink: "/api/v1/cash-closings", DueDays: 1,
+			Description: "Count and close the cash drawer for the month's last day and approve any over or short."},
+		{Title: "Post depreciation", Category: "adjustments", ScreenLink: "/journal", APILink: "/api/v1/transactions", DueDays: 3,
+			Description: "Post the month's depreciation on fixed assets."},
+		{Title: "Post accruals and prepayments", Category: "adjustments", ScreenLink: "/journal", APILink: "/api/v1/recurring-journals", DueDays: 3,
+			Description: "Check the recurring journals ran and post any other accruals and prepayment releases."},
+		{Title: "Review suspense account", Category: "review", ScreenLink: "/accounts", APILink: "/api/v1/accounts", DueDays: 4,
+			Description: "Clear the suspense account, reclassifying every entry to the account it belongs to."},
+		{Title: "Reconcile GST, TDS and loan accounts", Category: "review", ScreenLink: "/accounts", APILink: "/api/v1/account-reconciliations", DueDays: 5, Weight: 2,
+			Description: "Reconcile the balance sheet accounts other than banks with the portal or lender and certify them."},
+		{Title: "Review receivables and payables aging", Category: "review", ScreenLink: "/reports/receivables-aging", APILink: "/api/v1/reports/receivables-aging", DueDays: 5,
+			Description: "Follow up on overdue invoices and confirm the bills due are recorded."},
+		{Title: "Deposit TDS", Category: "tax", ScreenLink: "/tax/tds", APILink: "/api/v1/tds/dashboard", DueDays: 7,
+			Description: "Deposit the TDS deducted in the month by the 7th."},
+		{Title: "File GSTR-1", Category: "tax", ScreenLink: "/tax/gstr-1", APILink: "/api/v1/gstr/filings", DueDays: 11,
+			Description: "File the month's outward supplies by the 11th."},
+		{Title: "File GSTR-3B", Category: "tax", ScreenLink: "/tax/gstr-3b", APILink: "/api/v1/gstr/filings", DueDays: 20,
+			Description: "File the summary return and pay the GST due by the 20th."},
+		{Title: "Review profit and loss and balance sheet", Category: "review", ScreenLink: "/reports/profit-loss", APILink: "/api/v1/reports/profit-loss", DueDays: 6,
+			Description: "Compare the month with the previous one and the budget and explain large movements."},
+		{Title: "Close the period", Category: "review", ScreenLink: "/settings", APILink: "/api/v1/financial-years", DueDays: 7,
+			Description: "Close the month so no more entries can be posted to it."},
+	}
+	for i := range templates {
+		templates[i].TenantID = tenantID
+		templates[i].SortOrder = (i + 1) * 10
+		templates[i].IsActive = true
+		if templates[i].Weight == 0 {
+			templates[i].Weight = 1
+		}
+	}
+	return templates
+}
+
+// CloseChecklist is a tenant's month-end close checklist for a period
+type CloseChecklist struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_close_checklist_period" json:"tenant_id"`
+
+	Period    string    `gorm:"size:7;not null;uniqueIndex:idx_close_checklist_period" json:"period"` // e.g., "2024-04"
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+
+	Status CloseChecklistStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+
+	// Percentage of the weight of the tasks that apply this month which
+	// is done
+	ReadinessScore float64 `gorm:"type:decimal(5,2);not null;default:0" json:"readiness_score"`
+
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// Relations
+	Tasks []CloseTask `gorm:"foreignKey:ChecklistID" json:"tasks,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for CloseChecklist
+func (CloseChecklist) TableName() string {
+	return "close_checklists"
+}
+
+// BeforeCreate hook
+func (c *CloseChecklist) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// UpdateStatus recomputes the readiness score from the tasks and marks the
+// checklist complete once none is left to do
+func (c *CloseChecklist) UpdateStatus() {
+	var total, done int
+	finished := true
+	for _, task := range c.Tasks {
+		if !task.Status.IsFinished() {
+			finished = false
+		}
+		if task.Status == CloseTaskSkipped {
+			continue
+		}
+		total += task.Weight
+		if task.Status == CloseTaskDone {
+			done += task.Weight
+		}
+	}
+
+	c.ReadinessScore = 100
+	if total > 0 {
+		c.ReadinessScore = float64(done*10000/total) / 100
+	}
+
+	if finished {
+		if c.Status != CloseChecklistComplete {
+			now := time.Now()
+			c.CompletedAt = &now
+		}
+		c.Status = CloseChecklistComplete
+	} else {
+		c.Status = CloseChecklistOpen
+		c.CompletedAt = nil
+	}
+}
+
+// CloseTask is a task of a month's close checklist
+type CloseTask struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ChecklistID uuid.UUID  `gorm:"type:uuid;not null;index" json:"checklist_id"`
+	TenantID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	TemplateID  *uuid.UUID `gorm:"type:uuid" json:"template_id,omitempty"` // Nil for tasks added to the month only
+
+	Title       string `gorm:"size:200;not null" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+	Category    string `gorm:"size:50" json:"category"`
+	ScreenLink  string `gorm:"size:500" json:"screen_link"`
+	APILink     string `gorm:"size:500" json:"api_link"`
+	Weight      int    `gorm:"default:1" json:"weight"`
+	SortOrder   int    `gorm:"default:0" json:"sort_order"`
+
+	AssigneeID *uuid.UUID      `gorm:"type:uuid;index" json:"assignee_id,omitempty"`
+	DueDate    time.Time       `gorm:"type:date;not null" json:"due_date"`
+	Status     CloseTaskStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Notes      string          `gorm:"type:text" json:"notes,omitempty"`
+
+	CompletedBy *uuid.UUID `gorm:"type:uuid" json:"completed_by,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for CloseTask
+func (CloseTask) TableName() string {
+	return "close_tasks"
+}
+
+// BeforeCreate hook
+func (t *CloseTask) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsOverdue reports whether the task is unfinished past its due date
+func (t *CloseTask) IsOverdue(today time.Time) bool {
+	return !t.Status.IsFinished() && t.DueDate.Before(today)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PeriodFormat is the layout of a period's name: its year and month
+const PeriodFormat = "2006-01"
+
+// FinancialPeriod is a month of a financial year. Transactions dated in a
+// closed period cannot be posted, edited or voided until it is reopened.
+// Periods are stored once they are first closed; until then they are open.
+type FinancialPeriod struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_financial_periods_tenant_period" json:"tenant_id"`
+	FinancialYearID uuid.UUID `gorm:"type:uuid;not null;index" json:"financial_year_id"`
+
+	Period    string    `gorm:"size:7;not null;uniqueIndex:idx_financial_periods_tenant_period" json:"period"` // e.g., "2024-04"
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+
+	IsClosed bool       `gorm:"default:false;index" json:"is_closed"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	ClosedBy *uuid.UUID `gorm:"type:uuid" json:"closed_by,omitempty"`
+
+	// Head of the tenant's ledger hash chain when the period was closed,
+	// so any later change to the period's entries breaks the recorded chain
+	ChainSequence *int64 `json:"chain_sequence,omitempty"`
+	ChainHash     string `gorm:"size:64" json:"chain_hash,omitempty"`
+
+	// Set when the period was last reopened
+	ReopenedAt   *time.Time `json:"reopened_at,omitempty"`
+	ReopenedBy   *uuid.UUID `gorm:"type:uuid" json:"reopened_by,omitempty"`
+	ReopenReason string     `gorm:"size:500" json:"reopen_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for FinancialPeriod
+func (FinancialPeriod) TableName() string {
+	return "financial_periods"
+}
+
+// BeforeCreate hook
+func (p *FinancialPeriod) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// FinancialYearName is the default name of a financial year, e.g.
+// "FY 2024-25", or "FY 2024" for one within a calendar year
+func FinancialYearName(yearStart, yearEnd time.Time) string {
+	if yearEnd.Year() == yearStart.Year() {
+		return fmt.Sprintf("FY %d", yearStart.Year())
+	}
+	return fmt.Sprintf("FY %d-%02d", yearStart.Year(), yearEnd.Year()%100)
+}
+
+// FinancialYearOn returns the first and last day of the year-long
+// financial year, starting in startMonth, that the date falls in
+func FinancialYearOn(date time.Time, startMonth time.Month) (time.Time, time.Time) {
+	yearStart := time.Date(date.Year(), startMonth, 1, 0, 0, 0, 0, time.UTC)
+	if date.Month() < startMonth {
+		yearStart = yearStart.AddDate(-1, 0, 0)
+	}
+	return yearStart, yearStart.AddDate(1, 0, -1)
+}
+
+// Periods returns the monthly periods of the financial year, all open. The
+// first and last are cut short if the year does not start or end on a
+// month boundary.
+func (f *FinancialYear) Periods() []FinancialPeriod {
+	var periods []FinancialPeriod
+	start := time.Date(f.YearStart.Year(), f.YearStart.Month(), f.YearStart.Day(), 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(f.YearEnd.Year(), f.YearEnd.Month(), f.YearEnd.Day(), 0, 0, 0, 0, time.UTC)
+
+	for !start.After(yearEnd) {
+		end := time.Date(start.Year(), start.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		if end.After(yearEnd) {
+			end = yearEnd
+		}
+		periods = append(periods, FinancialPeriod{
+			TenantID:        f.TenantID,
+			FinancialYearID: f.ID,
+			Period:          start.Format(PeriodFormat),
+			StartDate:       start,
+			EndDate:         end,
+		})
+		start = end.AddDate(0, 0, 1)
+	}
+	return periods
+}
+
+// Period returns the financial year's period with the given name
+func (f *FinancialYear) Period(name string) (FinancialPeriod, bool) {
+	for _, period := range f.Periods() {
+		if period.Period == name {
+			return period, true
+		}
+	}
+	return FinancialPeriod{}, false
+}
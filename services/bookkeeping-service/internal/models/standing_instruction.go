@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StandingInstructionStatus represents what the tenant did with a detected
+// standing instruction
+type StandingInstructionStatus string
+
+const (
+	StandingInstructionSuggested StandingInstructionStatus = "suggested" // Detected, not yet acted on
+	StandingInstructionLinked    StandingInstructionStatus = "linked"    // Accounted for by a recurring journal
+	StandingInstructionDismissed StandingInstructionStatus = "dismissed" // Not a standing instruction, or no longer expected
+)
+
+// Standing instruction directions
+const (
+	StandingInstructionDebit  = "debit"
+	StandingInstructionCredit = "credit"
+)
+
+// StandingInstruction is a recurring debit or credit detected in a bank
+// account's statements: the same payee for about the same amount at a
+// regular interval, such as rent, an EMI or a SIP
+type StandingInstruction struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	BankAccountID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_standing_instruction_payee" json:"bank_account_id"`
+
+	// Payee is the statement description with references, dates and
+	// channel prefixes removed, which identifies the instruction
+	Payee       string `gorm:"size:255;not null;uniqueIndex:idx_standing_instruction_payee" json:"payee"`
+	Direction   string `gorm:"size:10;not null;uniqueIndex:idx_standing_instruction_payee" json:"direction"`
+	Description string `gorm:"type:text" json:"description"` // As on the latest statement line
+
+	Amount    float64             `gorm:"type:decimal(15,2);not null" json:"amount"` // Typical amount
+	Frequency RecurrenceFrequency `gorm:"size:20;not null" json:"frequency"`
+
+	Occurrences      int       `gorm:"not null" json:"occurrences"`
+	FirstDate        time.Time `gorm:"type:date;not null" json:"first_date"`
+	LastDate         time.Time `gorm:"type:date;not null" json:"last_date"`
+	NextExpectedDate time.Time `gorm:"type:date;not null;index" json:"next_expected_date"`
+
+	Status             StandingInstructionStatus `gorm:"size:20;not null;default:'suggested'" json:"status"`
+	RecurringJournalID *uuid.UUID                `gorm:"type:uuid" json:"recurring_journal_id,omitempty"`
+
+	// Set when reading: the expected debit or credit has not arrived
+	Missing     bool `gorm:"-" json:"missing"`
+	DaysOverdue int  `gorm:"-" json:"days_overdue,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for StandingInstruction
+func (StandingInstruction) TableName() string {
+	return "standing_instructions"
+}
+
+// BeforeCreate hook
+func (si *StandingInstruction) BeforeCreate(tx *gorm.DB) error {
+	if si.ID == uuid.Nil {
+		si.ID = uuid.New()
+	}
+	return nil
+}
+
+// GraceDays is how late the next occurrence may be before it is reported
+// missing, allowing for weekends, holidays and bank processing
+func (si *StandingInstruction) GraceDays() int {
+	switch si.Frequency {
+	case FrequencyWeekly:
+		return 2
+	case FrequencyBiweekly:
+		return 3
+	case FrequencyQuarterly:
+		return 10
+	default:
+		return 5
+	}
+}
+
+// CheckMissing sets Missing and DaysOverdue as of the given day
+func (si *StandingInstruction) CheckMissing(today time.Time) {
+	si.Missing = false
+	si.DaysOverdue = 0
+	if si.Status == StandingInstructionDismissed {
+		return
+	}
+	overdue := int(today.Sub(si.NextExpectedDate).Hours() / 24)
+	if overdue > si.GraceDays() {
+		si.Missing = true
+		si.DaysOverdue = overdue
+	}
+}
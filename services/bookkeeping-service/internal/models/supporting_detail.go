@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupportingRate is a tax or withholding rate applied in deriving a
+// transaction's amounts
+type SupportingRate struct {
+	Name    string     `json:"name"` // e.g. CGST, TDS 194C
+	Rate    float64    `json:"rate"` // Percent
+	Base    float64    `json:"base"` // Amount the rate was applied to
+	Amount  float64    `json:"amount"`
+	RateID  *uuid.UUID `json:"rate_id,omitempty"`
+	Section string     `json:"section,omitempty"`
+}
+
+// SupportingExchangeRate is the exchange rate a foreign currency amount was
+// converted at
+type SupportingExchangeRate struct {
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	Rate         float64 `json:"rate"`
+	RateDate     string  `json:"rate_date,omitempty"`
+	Source       string  `json:"source,omitempty"` // e.g. RBI reference rate, manual
+}
+
+// SupportingRounding is the rounding applied to a computed amount
+type SupportingRounding struct {
+	Mode       string  `json:"mode"` // nearest, up, down
+	RoundTo    float64 `json:"round_to"`
+	Unrounded  float64 `json:"unrounded"`
+	Rounded    float64 `json:"rounded"`
+	Difference float64 `json:"difference"`
+}
+
+// SupportingDocument is a document the computation was based on
+type SupportingDocument struct {
+	Type   string     `json:"type"` // e.g. bill, tax_snapshot, advance_receipt
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Number string     `json:"number,omitempty"`
+}
+
+// SupportingDetails records how a computed transaction's amounts were
+// derived: the rates used, any currency conversion and rounding, the
+// documents it came from and the figures in between. Stored as JSONB.
+type SupportingDetails struct {
+	Calculation     string                  `json:"calculation"` // e.g. tds_withholding, advance_gst
+	Rates           []SupportingRate        `json:"rates,omitempty"`
+	ExchangeRate    *SupportingExchangeRate `json:"exchange_rate,omitempty"`
+	Rounding        []SupportingRounding    `json:"rounding,omitempty"`
+	SourceDocuments []SupportingDocument    `json:"source_documents,omitempty"`
+	Figures         map[string]float64      `json:"figures,omitempty"` // Named inputs and intermediate results
+	Notes           string                  `json:"notes,omitempty"`
+}
+
+// Value implements driver.Valuer
+func (d SupportingDetails) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan implements sql.Scanner
+func (d *SupportingDetails) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for supporting details")
+	}
+	return json.Unmarshal(data, d)
+}
+
+// TransactionSupportingDetail is the supporting calculation of a
+// transaction posted from a tax or currency computation, kept to answer
+// how its numbers were derived. It is written with the transaction and
+// never changed.
+type TransactionSupportingDetail struct {
+	TransactionID uuid.UUID         `gorm:"type:uuid;primary_key" json:"transaction_id"`
+	TenantID      uuid.UUID         `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Source        string            `gorm:"size:50;not null" json:"source"` // How the transaction was posted, e.g. bill_payment
+	Details       SupportingDetails `gorm:"type:jsonb;not null" json:"details"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// TableName returns the table name for TransactionSupportingDetail
+func (TransactionSupportingDetail) TableName() string {
+	return "transaction_supporting_details"
+}
@@ -1,9 +1,15 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
@@ -41,10 +47,29 @@ const (
 	PaymentModeCheque PaymentMode = "cheque"
 )
 
+// AdjustmentType is the kind of period-end adjustment journal
+type AdjustmentType string
+
+const (
+	AdjustmentTypeDepreciation AdjustmentType = "depreciation"
+	AdjustmentTypeProvision    AdjustmentType = "provision"
+	AdjustmentTypeAccrual      AdjustmentType = "accrual"
+	AdjustmentTypeOther        AdjustmentType = "other"
+)
+
+// IsValid reports whether the adjustment type is known
+func (a AdjustmentType) IsValid() bool {
+	switch a {
+	case AdjustmentTypeDepreciation, AdjustmentTypeProvision, AdjustmentTypeAccrual, AdjustmentTypeOther:
+		return true
+	}
+	return false
+}
+
 // Transaction represents a journal entry
 type Transaction struct {
 	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	TenantID uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	TenantID uuid.UUID `gorm:"type:uuid;index;not null;uniqueIndex:idx_transactions_chain,priority:1" json:"tenant_id"`
 	StoreID  *uuid.UUID `gorm:"type:uuid;index" json:"store_id,omitempty"`
 
 	TransactionNumber string          `gorm:"size:50;not null" json:"transaction_number"`
@@ -53,11 +78,21 @@ type Transaction struct {
 
 	ReferenceType string     `gorm:"size:50" json:"reference_type,omitempty"` // invoice, bill, manual
 	ReferenceID   *uuid.UUID `gorm:"type:uuid" json:"reference_id,omitempty"`
+	// SourceID is the document within the reference the entry was posted
+	// for, such as a bill's payment; an entry is posted once per source
+	SourceID *uuid.UUID `gorm:"type:uuid;index" json:"source_id,omitempty"`
 
 	PartyID   *uuid.UUID `gorm:"type:uuid;index" json:"party_id,omitempty"`
 	PartyType string     `gorm:"size:20" json:"party_type,omitempty"` // customer, vendor
 	PartyName string     `gorm:"size:255" json:"party_name,omitempty"`
 
+	// Inter-company: the group company on the other side of the
+	// transaction. Tagged transactions are eliminated on consolidation.
+	CounterpartyTenantID *uuid.UUID `gorm:"type:uuid;index" json:"counterparty_tenant_id,omitempty"`
+	// The mirrored entry recorded in the counterparty's books, if this
+	// transaction was entered as an inter-company transaction
+	MirrorTransactionID  *uuid.UUID `gorm:"type:uuid;index" json:"mirror_transaction_id,omitempty"`
+
 	Description string `gorm:"type:text" json:"description"`
 	Notes       string `gorm:"type:text" json:"notes"`
 
@@ -65,6 +100,7 @@ type Transaction struct {
 	Subtotal       float64 `gorm:"type:decimal(15,2);not null" json:"subtotal"`
 	TaxAmount      float64 `gorm:"type:decimal(15,2);default:0" json:"tax_amount"`
 	DiscountAmount float64 `gorm:"type:decimal(15,2);default:0" json:"discount_amount"`
+	RoundOffAmount float64 `gorm:"type:decimal(15,2);default:0" json:"round_off_amount"`
 	TotalAmount    float64 `gorm:"type:decimal(15,2);not null" json:"total_amount"`
 
 	// Payment info
@@ -73,8 +109,27 @@ type Transaction struct {
 
 	Status TransactionStatus `gorm:"type:varchar(20);default:'posted'" json:"status"`
 
+	// Provisional close: adjustment journals (depreciation, provisions,
+	// accruals) form a separate layer that reports can leave out, showing
+	// operational numbers until the books are finalised
+	IsAdjustment   bool           `gorm:"default:false;index" json:"is_adjustment"`
+	AdjustmentType AdjustmentType `gorm:"type:varchar(20)" json:"adjustment_type,omitempty"`
+
+	// Free-form analytical tags, e.g. diwali-campaign. Unlike the amounts
+	// they can be changed after posting.
+	Tags pq.StringArray `gorm:"type:text[];default:'{}';index:idx_transactions_tags,type:gin" json:"tags"`
+
+	// Tamper evidence: a posted transaction is appended to its tenant's
+	// hash chain, its hash covering its content and the previous hash
+	ChainSequence *int64 `gorm:"uniqueIndex:idx_transactions_chain,priority:2" json:"chain_sequence,omitempty"`
+	PrevHash      string `gorm:"size:64" json:"prev_hash,omitempty"`
+	ChainHash     string `gorm:"size:64" json:"chain_hash,omitempty"`
+
 	// Relations
 	Lines []TransactionLine `gorm:"foreignKey:TransactionID" json:"lines,omitempty"`
+	// How the amounts were derived, for entries posted from a tax or
+	// currency computation; served on its own endpoint
+	SupportingDetail *TransactionSupportingDetail `gorm:"foreignKey:TransactionID" json:"-"`
 
 	// Audit
 	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
@@ -107,6 +162,90 @@ func (t *Transaction) IsBalanced() bool {
 	return totalDebit == totalCredit
 }
 
+// GenesisHash is the previous hash of the first transaction in a tenant's
+// hash chain
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Hash returns the transaction's chain hash given the previous one. It
+// covers what may not change once posted: tags may be edited, and status
+// and timestamps are left out so voiding an entry does not break the chain.
+func (t *Transaction) Hash(prevHash string) string {
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	optional := func(id *uuid.UUID) string {
+		if id == nil {
+			return ""
+		}
+		return id.String()
+	}
+
+	fields := []string{
+		prevHash,
+		t.ID.String(),
+		t.TenantID.String(),
+		optional(t.StoreID),
+		t.TransactionNumber,
+		t.TransactionDate.Format("2006-01-02"),
+		string(t.TransactionType),
+		t.ReferenceType,
+		optional(t.ReferenceID),
+		optional(t.PartyID),
+		t.PartyType,
+		t.PartyName,
+		optional(t.CounterpartyTenantID),
+		t.Description,
+		t.Notes,
+		amount(t.Subtotal),
+		amount(t.TaxAmount),
+		amount(t.DiscountAmount),
+		amount(t.RoundOffAmount),
+		amount(t.TotalAmount),
+		string(t.PaymentMode),
+		t.PaymentReference,
+		t.CreatedBy.String(),
+	}
+	// Only adjustment journals carry the layer, so entries posted before
+	// it existed keep their hashes
+	if t.IsAdjustment {
+		fields = append(fields, "adjustment:"+string(t.AdjustmentType))
+	}
+
+	lines := make([]TransactionLine, len(t.Lines))
+	copy(lines, t.Lines)
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].LineOrder != lines[j].LineOrder {
+			return lines[i].LineOrder < lines[j].LineOrder
+		}
+		return lines[i].ID.String() < lines[j].ID.String()
+	})
+	for _, line := range lines {
+		fields = append(fields,
+			line.ID.String(),
+			line.AccountID.String(),
+			line.Description,
+			amount(line.DebitAmount),
+			amount(line.CreditAmount),
+			optional(line.TaxRateID),
+			amount(line.TaxAmount),
+			strconv.Itoa(line.LineOrder),
+		)
+		// Only lines in another currency carry it, so entries in the base
+		// currency keep their hashes
+		if line.Currency != "" {
+			fields = append(fields, "currency:"+line.Currency)
+		}
+	}
+
+	// Length-prefix each field so no two contents share an encoding
+	var content strings.Builder
+	for _, field := range fields {
+		content.WriteString(strconv.Itoa(len(field)))
+		content.WriteByte(':')
+		content.WriteString(field)
+	}
+	sum := sha256.Sum256([]byte(content.String()))
+	return hex.EncodeToString(sum[:])
+}
+
 // TransactionLine represents a line item in a transaction (double-entry)
 type TransactionLine struct {
 	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -117,6 +256,9 @@ type TransactionLine struct {
 
 	DebitAmount  float64 `gorm:"type:decimal(15,2);default:0" json:"debit_amount"`
 	CreditAmount float64 `gorm:"type:decimal(15,2);default:0" json:"credit_amount"`
+	// Currency of the amounts when not the base currency; each currency in
+	// an entry must balance on its own
+	Currency string `gorm:"size:3" json:"currency,omitempty"`
 
 	// Tax tracking
 	TaxRateID *uuid.UUID `gorm:"type:uuid" json:"tax_rate_id,omitempty"`
@@ -153,6 +295,7 @@ type BankTransaction struct {
 	ValueDate       *time.Time `gorm:"type:date" json:"value_date,omitempty"`
 	Description     string     `gorm:"type:text" json:"description"`
 	Reference       string     `gorm:"size:100" json:"reference"`
+	CardLast4       string     `gorm:"size:4" json:"card_last4,omitempty"` // Card statements only
 
 	DebitAmount  float64 `gorm:"type:decimal(15,2);default:0" json:"debit_amount"`
 	CreditAmount float64 `gorm:"type:decimal(15,2);default:0" json:"credit_amount"`
@@ -160,13 +303,15 @@ type BankTransaction struct {
 
 	// Reconciliation
 	IsReconciled            bool       `gorm:"default:false" json:"is_reconciled"`
-	ReconciledTransactionID *uuid.UUID `gorm:"type:uuid" json:"reconciled_transaction_id,omitempty"`
+	ReconciledTransactionID *uuid.UUID `gorm:"type:uuid;index" json:"reconciled_transaction_id,omitempty"`
 	ReconciledAt            *time.Time `json:"reconciled_at,omitempty"`
 	ReconciledBy            *uuid.UUID `gorm:"type:uuid" json:"reconciled_by,omitempty"`
+	BankRuleID              *uuid.UUID `gorm:"type:uuid" json:"bank_rule_id,omitempty"` // Posted by a bank rule on import
 
 	// Import tracking
 	ImportBatchID *uuid.UUID `gorm:"type:uuid" json:"import_batch_id,omitempty"`
 	ExternalID    string     `gorm:"size:100" json:"external_id,omitempty"`
+	DedupeHash    string     `gorm:"size:64;index" json:"-"` // See ComputeDedupeHash
 
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -181,5 +326,67 @@ func (b *BankTransaction) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == uuid.Nil {
 		b.ID = uuid.New()
 	}
+	if b.DedupeHash == "" {
+		b.DedupeHash = b.ComputeDedupeHash()
+	}
+	return nil
+}
+
+// ComputeDedupeHash fingerprints the statement line by its date, amounts,
+// reference and description, ignoring case and spacing in the text. The
+// same line uploaded again in a later statement has the same hash.
+func (b *BankTransaction) ComputeDedupeHash() string {
+	key := strings.Join([]string{
+		b.TransactionDate.Format("2006-01-02"),
+		strconv.FormatFloat(b.DebitAmount, 'f', 2, 64),
+		strconv.FormatFloat(b.CreditAmount, 'f', 2, 64),
+		strings.ToLower(strings.Join(strings.Fields(b.Reference), " ")),
+		strings.ToLower(strings.Join(strings.Fields(b.Description), " ")),
+	}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReconciliationRunStatus represents the state of an auto-reconcile run
+type ReconciliationRunStatus string
+
+const (
+	ReconciliationRunRunning   ReconciliationRunStatus = "running"
+	ReconciliationRunCompleted ReconciliationRunStatus = "completed"
+	ReconciliationRunFailed    ReconciliationRunStatus = "failed"
+)
+
+// ReconciliationRun tracks an auto-reconcile of a bank account. Counts are
+// updated after every batch so a long run can be followed while it works.
+type ReconciliationRun struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	BankAccountID uuid.UUID `gorm:"type:uuid;not null;index" json:"bank_account_id"`
+
+	Status ReconciliationRunStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error  string                  `gorm:"type:text" json:"error,omitempty"`
+
+	Progress           int `gorm:"default:0" json:"progress"` // 0-100
+	TotalCount         int `gorm:"default:0" json:"total_count"`
+	ProcessedCount     int `gorm:"default:0" json:"processed_count"`
+	ExactMatchCount    int `gorm:"default:0" json:"exact_match_count"`
+	ProbableMatchCount int `gorm:"default:0" json:"probable_match_count"`
+
+	StartedBy   uuid.UUID  `gorm:"type:uuid" json:"started_by"`
+	StartedAt   time.Time  `gorm:"not null" json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for ReconciliationRun
+func (ReconciliationRun) TableName() string {
+	return "reconciliation_runs"
+}
+
+// BeforeCreate hook
+func (r *ReconciliationRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
 	return nil
 }
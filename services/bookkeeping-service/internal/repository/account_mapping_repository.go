@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountMappingRepository defines the interface for account mapping data
+// access
+type AccountMappingRepository interface {
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.AccountMapping, error)
+
+	// Find returns the tenant's mapping of the posting type, or nil if it
+	// has none
+	Find(ctx context.Context, tenantID uuid.UUID, postingType models.PostingType) (*models.AccountMapping, error)
+
+	// Update saves the given mappings and removes those of the reset
+	// posting types, in one transaction
+	Update(ctx context.Context, tenantID uuid.UUID, mappings []models.AccountMapping, reset []models.PostingType) error
+}
+
+type accountMappingRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountMappingRepository creates a new account mapping repository
+func NewAccountMappingRepository(db *gorm.DB) AccountMappingRepository {
+	return &accountMappingRepository{db: db}
+}
+
+func (r *accountMappingRepository) List(ctx context.Context, tenantID uuid.UUID) ([]models.AccountMapping, error) {
+	var mappings []models.AccountMapping
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&mappings).Error
+	return mappings, err
+}
+
+func (r *accountMappingRepository) Find(ctx context.Context, tenantID uuid.UUID, postingType models.PostingType) (*models.AccountMapping, error) {
+	var mapping models.AccountMapping
+	err := r.db.WithContext(ctx).First(&mapping, "tenant_id = ? AND posting_type = ?", tenantID, postingType).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+func (r *accountMappingRepository) Update(ctx context.Context, tenantID uuid.UUID, mappings []models.AccountMapping, reset []models.PostingType) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(reset) > 0 {
+			if err := tx.Where("tenant_id = ? AND posting_type IN ?", tenantID, reset).Delete(&models.AccountMapping{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(mappings) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "posting_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"account_id", "updated_by", "updated_at"}),
+		}).Create(&mappings).Error
+	})
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAccountReconciliationNotFound = errors.New("account reconciliation not found")
+
+// AccountReconciliationFilters defines filters for listing account
+// reconciliations
+type AccountReconciliationFilters struct {
+	AccountID uuid.UUID
+	Period    string
+	Status    models.AccountReconciliationStatus
+}
+
+// ReconcilableLine is a posted ledger line of an account, with its
+// transaction
+type ReconcilableLine struct {
+	LineID            uuid.UUID
+	TransactionID     uuid.UUID
+	TransactionNumber string
+	TransactionDate   time.Time
+	Description       string
+	DebitAmount       float64
+	CreditAmount      float64
+}
+
+// AccountReconciliationRepository defines the interface for account
+// reconciliation data access
+type AccountReconciliationRepository interface {
+	// Create saves a new reconciliation with its items
+	Create(ctx context.Context, reconciliation *models.AccountReconciliation) error
+	// Save updates a reconciliation, leaving its items as they are. A
+	// certified one is stamped with the ledger chain head.
+	Save(ctx context.Context, reconciliation *models.AccountReconciliation) error
+	// GetByID returns a reconciliation with its account and items
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters AccountReconciliationFilters) ([]models.AccountReconciliation, error)
+	// Exists reports whether the account has a reconciliation for the period
+	Exists(ctx context.Context, tenantID, accountID uuid.UUID, period string) (bool, error)
+	// Previous returns the account's latest reconciliation before the
+	// period, with its items
+	Previous(ctx context.Context, tenantID, accountID uuid.UUID, period string) (*models.AccountReconciliation, error)
+	// HasLater reports whether the account has a reconciliation after the
+	// period
+	HasLater(ctx context.Context, tenantID, accountID uuid.UUID, period string) (bool, error)
+
+	// AddItems adds items, skipping lines the reconciliation already has
+	AddItems(ctx context.Context, items []models.AccountReconciliationItem) error
+	RemoveItems(ctx context.Context, reconciliationID uuid.UUID, itemIDs []uuid.UUID) error
+	// MatchItems ticks off, or unticks, items of a reconciliation and
+	// returns how many were changed
+	MatchItems(ctx context.Context, reconciliationID uuid.UUID, itemIDs []uuid.UUID, matched bool, userID uuid.UUID, note string) (int64, error)
+
+	// PostedLines returns the account's posted lines dated within [from,
+	// to], or with the given IDs when lineIDs is not nil
+	PostedLines(ctx context.Context, tenantID, accountID uuid.UUID, from, to time.Time, lineIDs []uuid.UUID) ([]ReconcilableLine, error)
+}
+
+type accountReconciliationRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountReconciliationRepository creates a new account reconciliation
+// repository
+func NewAccountReconciliationRepository(db *gorm.DB) AccountReconciliationRepository {
+	return &accountReconciliationRepository{db: db}
+}
+
+func (r *accountReconciliationRepository) Create(ctx context.Context, reconciliation *models.AccountReconciliation) error {
+	return r.db.WithContext(ctx).Omit("Account").Create(reconciliation).Error
+}
+
+func (r *accountReconciliationRepository) Save(ctx context.Context, reconciliation *models.AccountReconciliation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reconciliation.ChainSequence = nil
+		reconciliation.ChainHash = ""
+		if reconciliation.IsCertified() {
+			head, err := chainHead(tx, reconciliation.TenantID)
+			if err != nil {
+				return err
+			}
+			if head != nil {
+				reconciliation.ChainSequence = &head.Sequence
+				reconciliation.ChainHash = head.Hash
+			}
+		}
+		return tx.Omit(clause.Associations).Save(reconciliation).Error
+	})
+}
+
+func (r *accountReconciliationRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error) {
+	var reconciliation models.AccountReconciliation
+	err := r.db.WithContext(ctx).
+		Preload("Account").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("transaction_date, transaction_number")
+		}).
+		First(&reconciliation, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountReconciliationNotFound
+		}
+		return nil, err
+	}
+	return &reconciliation, nil
+}
+
+func (r *accountReconciliationRepository) List(ctx context.Context, tenantID uuid.UUID, filters AccountReconciliationFilters) ([]models.AccountReconciliation, error) {
+	query := r.db.WithContext(ctx).Preload("Account").Where("tenant_id = ?", tenantID)
+
+	if filters.AccountID != uuid.Nil {
+		query = query.Where("account_id = ?", filters.AccountID)
+	}
+	if filters.Period != "" {
+		query = query.Where("period = ?", filters.Period)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var reconciliations []models.AccountReconciliation
+	err := query.Order("period DESC, created_at").Find(&reconciliations).Error
+	return reconciliations, err
+}
+
+func (r *accountReconciliationRepository) Exists(ctx context.Context, tenantID, accountID uuid.UUID, period string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AccountReconciliation{}).
+		Where("tenant_id = ? AND account_id = ? AND period = ?", tenantID, accountID, period).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *accountReconciliationRepository) Previous(ctx context.Context, tenantID, accountID uuid.UUID, period string) (*models.AccountReconciliation, error) {
+	var reconciliation models.AccountReconciliation
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("tenant_id = ? AND account_id = ? AND period < ?", tenantID, accountID, period).
+		Order("period DESC").
+		First(&reconciliation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountReconciliationNotFound
+		}
+		return nil, err
+	}
+	return &reconciliation, nil
+}
+
+func (r *accountReconciliationRepository) HasLater(ctx context.Context, tenantID, accountID uuid.UUID, period string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AccountReconciliation{}).
+		Where("tenant_id = ? AND account_id = ? AND period > ?", tenantID, accountID, period).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *accountReconciliationRepository) AddItems(ctx context.Context, items []models.AccountReconciliationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(items, 100).Error
+}
+
+func (r *accountReconciliationRepository) RemoveItems(ctx context.Context, reconciliationID uuid.UUID, itemIDs []uuid.UUID) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Where("reconciliation_id = ? AND id IN ?", reconciliationID, itemIDs).
+		Delete(&models.AccountReconciliationItem{}).Error
+}
+
+func (r *accountReconciliationRepository) MatchItems(ctx context.Context, reconciliationID uuid.UUID, itemIDs []uuid.UUID, matched bool, userID uuid.UUID, note string) (int64, error) {
+	updates := map[string]interface{}{"matched": matched, "matched_by": nil, "matched_at": nil}
+	if matched {
+		updates["matched_by"] = userID
+		updates["matched_at"] = time.Now()
+	}
+	if note != "" {
+		updates["note"] = note
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.AccountReconciliationItem{}).
+		Where("reconciliation_id = ? AND id IN ?", reconciliationID, itemIDs).
+		Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+func (r *accountReconciliationRepository) PostedLines(ctx context.Context, tenantID, accountID uuid.UUID, from, to time.Time, lineIDs []uuid.UUID) ([]ReconcilableLine, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.TransactionLine{}).
+		Select(`transaction_lines.id AS line_id, t.id AS transaction_id, t.transaction_number, t.transaction_date,
+			COALESCE(NULLIF(transaction_lines.description, ''), NULLIF(t.party_name, ''), t.description) AS description,
+			transaction_lines.debit_amount, transaction_lines.credit_amount`).
+		Joins("JOIN transactions t ON t.id = transaction_lines.transaction_id").
+		Where("transaction_lines.account_id = ? AND t.tenant_id = ? AND t.status = ? AND t.deleted_at IS NULL",
+			accountID, tenantID, models.TransactionStatusPosted)
+	if lineIDs != nil {
+		if len(lineIDs) == 0 {
+			return []ReconcilableLine{}, nil
+		}
+		query = query.Where("transaction_lines.id IN ?", lineIDs)
+	} else {
+		query = query.Where("t.transaction_date >= ? AND t.transaction_date <= ?", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	lines := []ReconcilableLine{}
+	err := query.Order("t.transaction_date, t.transaction_number").Scan(&lines).Error
+	return lines, err
+}
@@ -8,6 +8,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// AccountsResource names the chart of accounts for conditional requests.
+// Transactions bump it too since they move account balances.
+const AccountsResource = "accounts"
+
 // AccountRepository defines the interface for account data access
 type AccountRepository interface {
 	Create(ctx context.Context, account *models.Account) error
@@ -20,6 +24,34 @@ type AccountRepository interface {
 	GetChartOfAccounts(ctx context.Context, tenantID uuid.UUID) ([]models.Account, error)
 	UpdateBalance(ctx context.Context, id uuid.UUID, amount float64) error
 	CreateDefaultAccounts(ctx context.Context, tenantID uuid.UUID) error
+
+	// AddMissingPostingAccounts gives every tenant the default account of
+	// each posting type it has neither in its chart nor mapped to another
+	// account, for posting types added to the default chart after the
+	// tenant's was created, and returns how many accounts were added
+	AddMissingPostingAccounts(ctx context.Context) (int64, error)
+
+	// GetLedger returns the posted vouchers on the account dated within the
+	// period; either date may be empty
+	GetLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) ([]AccountLedgerEntry, error)
+
+	// GetLedgerMovement returns the account's posted debits less credits
+	// dated before the given date
+	GetLedgerMovement(ctx context.Context, id, tenantID uuid.UUID, beforeDate string) (float64, error)
+}
+
+// AccountLedgerEntry is a voucher as it appears in an account's ledger.
+// Particulars names the other side: the party when there is one, else the
+// largest line on another account.
+type AccountLedgerEntry struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Date          string    `json:"date"`
+	Type          string    `json:"type"`
+	Reference     string    `json:"reference"`
+	Particulars   string    `json:"particulars"`
+	Debit         float64   `json:"debit"`
+	Credit        float64   `json:"credit"`
+	Balance       float64   `json:"balance"`
 }
 
 // AccountFilter defines filter options for listing accounts
@@ -159,7 +191,51 @@ func (r *accountRepository) UpdateBalance(ctx context.Context, id uuid.UUID, amo
 }
 
 func (r *accountRepository) CreateDefaultAccounts(ctx context.Context, tenantID uuid.UUID) error {
-	defaultAccounts := []models.Account{
+	return r.db.WithContext(ctx).CreateInBatches(defaultAccounts(tenantID), 100).Error
+}
+
+func (r *accountRepository) AddMissingPostingAccounts(ctx context.Context) (int64, error) {
+	defaults := make(map[string]models.Account)
+	for _, account := range defaultAccounts(uuid.Nil) {
+		defaults[account.Code] = account
+	}
+
+	var added int64
+	for _, posting := range models.PostingAccounts {
+		account, ok := defaults[posting.DefaultCode]
+		if !ok {
+			continue
+		}
+		result := r.db.WithContext(ctx).Exec(`
+			INSERT INTO accounts (id, tenant_id, code, name, type, sub_type, is_system, is_active, settings, created_at, updated_at)
+			SELECT gen_random_uuid(), t.tenant_id, @code, @name, @type, @sub_type, true, true, '{}', NOW(), NOW()
+			FROM (SELECT DISTINCT tenant_id FROM accounts WHERE deleted_at IS NULL) t
+			WHERE NOT EXISTS (
+				SELECT 1 FROM accounts a
+				WHERE a.tenant_id = t.tenant_id AND a.code = @code AND a.deleted_at IS NULL
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM account_mappings m
+				WHERE m.tenant_id = t.tenant_id AND m.posting_type = @posting_type
+			)`,
+			map[string]interface{}{
+				"code":         account.Code,
+				"name":         account.Name,
+				"type":         account.Type,
+				"sub_type":     account.SubType,
+				"posting_type": posting.Type,
+			})
+		if result.Error != nil {
+			return added, result.Error
+		}
+		added += result.RowsAffected
+	}
+	return added, nil
+}
+
+// defaultAccounts returns the chart of accounts a new tenant starts with
+func defaultAccounts(tenantID uuid.UUID) []models.Account {
+	accounts := []models.Account{
 		// Assets
 		{TenantID: tenantID, Code: "1000", Name: "Assets", Type: models.AccountTypeAsset, IsSystem: true},
 		{TenantID: tenantID, Code: "1100", Name: "Cash", Type: models.AccountTypeAsset, SubType: models.AccountSubTypeCash, IsSystem: true},
@@ -167,12 +243,14 @@ func (r *accountRepository) CreateDefaultAccounts(ctx context.Context, tenantID
 		{TenantID: tenantID, Code: "1300", Name: "Accounts Receivable", Type: models.AccountTypeAsset, SubType: models.AccountSubTypeReceivable, IsSystem: true},
 		{TenantID: tenantID, Code: "1400", Name: "Inventory", Type: models.AccountTypeAsset, SubType: models.AccountSubTypeInventory, IsSystem: true},
 		{TenantID: tenantID, Code: "1500", Name: "Fixed Assets", Type: models.AccountTypeAsset, SubType: models.AccountSubTypeFixedAsset, IsSystem: true},
+		{TenantID: tenantID, Code: "1600", Name: "GST Input Credit", Type: models.AccountTypeAsset, SubType: models.AccountSubTypeTax, IsSystem: true},
 
 		// Liabilities
 		{TenantID: tenantID, Code: "2000", Name: "Liabilities", Type: models.AccountTypeLiability, IsSystem: true},
 		{TenantID: tenantID, Code: "2100", Name: "Accounts Payable", Type: models.AccountTypeLiability, SubType: models.AccountSubTypePayable, IsSystem: true},
 		{TenantID: tenantID, Code: "2200", Name: "GST Payable", Type: models.AccountTypeLiability, SubType: models.AccountSubTypeTax, IsSystem: true},
 		{TenantID: tenantID, Code: "2300", Name: "TDS Payable", Type: models.AccountTypeLiability, SubType: models.AccountSubTypeTax, IsSystem: true},
+		{TenantID: tenantID, Code: "2350", Name: "TCS Payable", Type: models.AccountTypeLiability, SubType: models.AccountSubTypeTax, IsSystem: true},
 
 		// Equity
 		{TenantID: tenantID, Code: "3000", Name: "Equity", Type: models.AccountTypeEquity, IsSystem: true},
@@ -183,6 +261,7 @@ func (r *accountRepository) CreateDefaultAccounts(ctx context.Context, tenantID
 		{TenantID: tenantID, Code: "4000", Name: "Income", Type: models.AccountTypeIncome, IsSystem: true},
 		{TenantID: tenantID, Code: "4100", Name: "Sales Revenue", Type: models.AccountTypeIncome, SubType: models.AccountSubTypeSales, IsSystem: true},
 		{TenantID: tenantID, Code: "4200", Name: "Service Revenue", Type: models.AccountTypeIncome, SubType: models.AccountSubTypeSales, IsSystem: true},
+		{TenantID: tenantID, Code: "4300", Name: "Interest Income", Type: models.AccountTypeIncome, IsSystem: true},
 		{TenantID: tenantID, Code: "4900", Name: "Other Income", Type: models.AccountTypeIncome, IsSystem: true},
 
 		// Expenses
@@ -193,12 +272,63 @@ func (r *accountRepository) CreateDefaultAccounts(ctx context.Context, tenantID
 		{TenantID: tenantID, Code: "5400", Name: "Salary Expense", Type: models.AccountTypeExpense, SubType: models.AccountSubTypeIndirectExpense, IsSystem: true},
 		{TenantID: tenantID, Code: "5500", Name: "Utilities Expense", Type: models.AccountTypeExpense, SubType: models.AccountSubTypeIndirectExpense, IsSystem: true},
 		{TenantID: tenantID, Code: "5600", Name: "Marketing Expense", Type: models.AccountTypeExpense, SubType: models.AccountSubTypeIndirectExpense, IsSystem: true},
+		{TenantID: tenantID, Code: "5700", Name: "Bank Charges", Type: models.AccountTypeExpense, SubType: models.AccountSubTypeIndirectExpense, IsSystem: true},
+		{TenantID: tenantID, Code: "5800", Name: "Round Off", Type: models.AccountTypeExpense, SubType: models.AccountSubTypeIndirectExpense, IsSystem: true},
+		{TenantID: tenantID, Code: "5850", Name: "Cash Short & Excess", Type: models.AccountTypeExpense, SubType: models.AccountSubTypeIndirectExpense, IsSystem: true},
 		{TenantID: tenantID, Code: "5900", Name: "Other Expenses", Type: models.AccountTypeExpense, IsSystem: true},
 	}
 
-	for i := range defaultAccounts {
-		defaultAccounts[i].IsActive = true
+	for i := range accounts {
+		accounts[i].IsActive = true
 	}
+	return accounts
+}
+
+func (r *accountRepository) GetLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) ([]AccountLedgerEntry, error) {
+	params := map[string]interface{}{"tenant": tenantID, "account": id, "from": fromDate, "to": toDate}
+	period := ""
+	if fromDate != "" {
+		period += " AND t.transaction_date >= CAST(@from AS date)"
+	}
+	if toDate != "" {
+		period += " AND t.transaction_date <= CAST(@to AS date)"
+	}
+
+	entries := []AccountLedgerEntry{}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			t.id AS transaction_id,
+			to_char(t.transaction_date, 'YYYY-MM-DD') AS date,
+			t.transaction_type AS type,
+			t.transaction_number AS reference,
+			COALESCE(NULLIF(t.party_name, ''), other.name, t.description) AS particulars,
+			SUM(l.debit_amount) AS debit,
+			SUM(l.credit_amount) AS credit
+		FROM transactions t
+		JOIN transaction_lines l ON l.transaction_id = t.id AND l.account_id = @account
+		LEFT JOIN LATERAL (
+			SELECT oa.name
+			FROM transaction_lines ol
+			JOIN accounts oa ON oa.id = ol.account_id
+			WHERE ol.transaction_id = t.id AND ol.account_id <> @account
+			ORDER BY GREATEST(ol.debit_amount, ol.credit_amount) DESC
+			LIMIT 1
+		) other ON true
+		WHERE t.tenant_id = @tenant AND t.status = 'posted' AND t.deleted_at IS NULL`+period+`
+		GROUP BY t.id, t.transaction_date, t.transaction_type, t.transaction_number, t.party_name, t.description, t.created_at, other.name
+		ORDER BY t.transaction_date, t.created_at
+	`, params).Scan(&entries).Error
+	return entries, err
+}
 
-	return r.db.WithContext(ctx).CreateInBatches(defaultAccounts, 100).Error
+func (r *accountRepository) GetLedgerMovement(ctx context.Context, id, tenantID uuid.UUID, beforeDate string) (float64, error) {
+	var movement float64
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(SUM(l.debit_amount - l.credit_amount), 0)
+		FROM transactions t
+		JOIN transaction_lines l ON l.transaction_id = t.id AND l.account_id = ?
+		WHERE t.tenant_id = ? AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND t.transaction_date < ?
+	`, id, tenantID, beforeDate).Scan(&movement).Error
+	return movement, err
 }
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrBankFeedNotFound = errors.New("bank feed connection not found")
+
+// BankFeedRepository handles bank feed connections
+type BankFeedRepository interface {
+	// Save creates the connection or replaces the one of its bank account
+	Save(ctx context.Context, connection *models.BankFeedConnection) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BankFeedConnection, error)
+	GetByBankAccount(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error)
+	GetByConsentID(ctx context.Context, consentID string) (*models.BankFeedConnection, error)
+	// ListSyncable returns the connections scheduled syncs visit: active
+	// ones, and pending ones whose consent may since have been approved
+	ListSyncable(ctx context.Context) ([]models.BankFeedConnection, error)
+
+	// ExistingFeedLines returns which of a feed's lines the account already
+	// has: by external ID from earlier syncs, and by dedupe hash from
+	// uploaded statements
+	ExistingFeedLines(ctx context.Context, bankAccountID uuid.UUID, externalIDs, hashes []string) (map[string]bool, map[string]bool, error)
+}
+
+type bankFeedRepository struct {
+	db *gorm.DB
+}
+
+// NewBankFeedRepository creates a new bank feed repository
+func NewBankFeedRepository(db *gorm.DB) BankFeedRepository {
+	return &bankFeedRepository{db: db}
+}
+
+func (r *bankFeedRepository) Save(ctx context.Context, connection *models.BankFeedConnection) error {
+	return r.db.WithContext(ctx).Save(connection).Error
+}
+
+func (r *bankFeedRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BankFeedConnection, error) {
+	return r.first(ctx, "id = ?", id)
+}
+
+func (r *bankFeedRepository) GetByBankAccount(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error) {
+	return r.first(ctx, "tenant_id = ? AND bank_account_id = ?", tenantID, bankAccountID)
+}
+
+func (r *bankFeedRepository) GetByConsentID(ctx context.Context, consentID string) (*models.BankFeedConnection, error) {
+	return r.first(ctx, "consent_id = ?", consentID)
+}
+
+func (r *bankFeedRepository) first(ctx context.Context, query string, args ...interface{}) (*models.BankFeedConnection, error) {
+	var connection models.BankFeedConnection
+	err := r.db.WithContext(ctx).Where(query, args...).First(&connection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBankFeedNotFound
+		}
+		return nil, err
+	}
+	return &connection, nil
+}
+
+func (r *bankFeedRepository) ListSyncable(ctx context.Context) ([]models.BankFeedConnection, error) {
+	var connections []models.BankFeedConnection
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []models.BankFeedStatus{models.BankFeedActive, models.BankFeedPending}).
+		Order("last_synced_at NULLS FIRST").
+		Find(&connections).Error
+	return connections, err
+}
+
+func (r *bankFeedRepository) ExistingFeedLines(ctx context.Context, bankAccountID uuid.UUID, externalIDs, hashes []string) (map[string]bool, map[string]bool, error) {
+	synced := make(map[string]bool)
+	uploaded := make(map[string]bool)
+
+	if len(externalIDs) > 0 {
+		var found []string
+		err := r.db.WithContext(ctx).Model(&models.BankTransaction{}).
+			Where("bank_account_id = ? AND external_id IN ?", bankAccountID, externalIDs).
+			Pluck("external_id", &found).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range found {
+			synced[id] = true
+		}
+	}
+
+	if len(hashes) > 0 {
+		var found []string
+		err := r.db.WithContext(ctx).Model(&models.BankTransaction{}).
+			Where("bank_account_id = ? AND dedupe_hash IN ?", bankAccountID, hashes).
+			Where("external_id IS NULL OR external_id = ''").
+			Distinct().Pluck("dedupe_hash", &found).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, hash := range found {
+			uploaded[hash] = true
+		}
+	}
+
+	return synced, uploaded, nil
+}
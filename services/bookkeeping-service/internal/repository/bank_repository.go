@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,7 +27,47 @@ type BankRepository interface {
 	GetUnreconciledTransactions(ctx context.Context, bankAccountID uuid.UUID) ([]models.BankTransaction, error)
 	ReconcileTransaction(ctx context.Context, bankTxID uuid.UUID, ledgerTxID uuid.UUID, reconciledBy uuid.UUID) error
 	UnreconcileTransaction(ctx context.Context, bankTxID uuid.UUID) error
+	// ReconciledLedgerTransactions returns which of the ledger transactions
+	// are reconciled to a bank transaction
+	ReconciledLedgerTransactions(ctx context.Context, ledgerTxIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	// GetReconciledTo returns the bank transactions reconciled to a ledger
+	// transaction
+	GetReconciledTo(ctx context.Context, tenantID, ledgerTxID uuid.UUID) ([]models.BankTransaction, error)
+	// PostRuleMatch posts the ledger transaction a bank rule raised for a
+	// bank transaction and reconciles the two
+	PostRuleMatch(ctx context.Context, bankTx *models.BankTransaction, ruleID uuid.UUID, transaction *models.Transaction, postedBy uuid.UUID) error
 	GetReconciliationSummary(ctx context.Context, bankAccountID uuid.UUID, asOfDate time.Time) (*ReconciliationSummary, error)
+
+	// Statement dedupe
+	// ExistingDedupeHashes returns which of hashes the account already has
+	// transactions for, other than those of the import batch batchID
+	ExistingDedupeHashes(ctx context.Context, bankAccountID, batchID uuid.UUID, hashes []string) (map[string]bool, error)
+	// BackfillDedupeHashes hashes the account's transactions stored before
+	// their hashes were kept
+	BackfillDedupeHashes(ctx context.Context, bankAccountID uuid.UUID) error
+
+	// Auto-reconciliation
+	CountUnreconciledTransactions(ctx context.Context, bankAccountID uuid.UUID) (int64, error)
+	GetUnreconciledBatch(ctx context.Context, bankAccountID uuid.UUID, after *models.BankTransaction, limit int) ([]models.BankTransaction, error)
+	MatchBankTransactions(ctx context.Context, opts MatchOptions) (int64, error)
+	CreateReconciliationRun(ctx context.Context, run *models.ReconciliationRun) error
+	UpdateReconciliationRun(ctx context.Context, run *models.ReconciliationRun) error
+	GetLatestReconciliationRun(ctx context.Context, bankAccountID uuid.UUID) (*models.ReconciliationRun, error)
+}
+
+// MatchOptions controls a matching pass over a batch of bank transactions.
+// A bank transaction matches a posted ledger transaction with a line on the
+// linked ledger account for the same amount, dated within DateWindowDays.
+type MatchOptions struct {
+	BankTransactionIDs []uuid.UUID
+	LedgerAccountID    uuid.UUID
+	ReconciledBy       uuid.UUID
+	DateWindowDays     int
+	AmountTolerance    float64
+
+	// Unambiguous only matches pairs where neither side has another
+	// candidate in the batch. Otherwise the closest candidate wins.
+	Unambiguous bool
 }
 
 // BankTransactionFilters for filtering bank transactions
@@ -110,6 +151,42 @@ func (r *bankRepository) CreateBankTransactions(ctx context.Context, txs []model
 	return r.db.WithContext(ctx).CreateInBatches(txs, 100).Error
 }
 
+func (r *bankRepository) ExistingDedupeHashes(ctx context.Context, bankAccountID, batchID uuid.UUID, hashes []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(hashes) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := r.db.WithContext(ctx).Model(&models.BankTransaction{}).
+		Where("bank_account_id = ? AND dedupe_hash IN ?", bankAccountID, hashes).
+		Where("import_batch_id IS NULL OR import_batch_id <> ?", batchID).
+		Distinct().Pluck("dedupe_hash", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range found {
+		existing[hash] = true
+	}
+	return existing, nil
+}
+
+func (r *bankRepository) BackfillDedupeHashes(ctx context.Context, bankAccountID uuid.UUID) error {
+	var batch []models.BankTransaction
+	return r.db.WithContext(ctx).
+		Where("bank_account_id = ? AND (dedupe_hash IS NULL OR dedupe_hash = '')", bankAccountID).
+		FindInBatches(&batch, 500, func(_ *gorm.DB, _ int) error {
+			for _, bankTx := range batch {
+				err := r.db.WithContext(ctx).Model(&models.BankTransaction{}).Where("id = ?", bankTx.ID).
+					Update("dedupe_hash", bankTx.ComputeDedupeHash()).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
 func (r *bankRepository) GetBankTransactionByID(ctx context.Context, id uuid.UUID) (*models.BankTransaction, error) {
 	var tx models.BankTransaction
 	err := r.db.WithContext(ctx).First(&tx, "id = ?", id).Error
@@ -169,6 +246,34 @@ func (r *bankRepository) GetUnreconciledTransactions(ctx context.Context, bankAc
 	return transactions, err
 }
 
+func (r *bankRepository) ReconciledLedgerTransactions(ctx context.Context, ledgerTxIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	reconciled := make(map[uuid.UUID]bool)
+	if len(ledgerTxIDs) == 0 {
+		return reconciled, nil
+	}
+
+	var found []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.BankTransaction{}).
+		Where("reconciled_transaction_id IN ?", ledgerTxIDs).
+		Distinct().Pluck("reconciled_transaction_id", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		reconciled[id] = true
+	}
+	return reconciled, nil
+}
+
+func (r *bankRepository) GetReconciledTo(ctx context.Context, tenantID, ledgerTxID uuid.UUID) ([]models.BankTransaction, error) {
+	var transactions []models.BankTransaction
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND reconciled_transaction_id = ?", tenantID, ledgerTxID).
+		Order("reconciled_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
 func (r *bankRepository) ReconcileTransaction(ctx context.Context, bankTxID uuid.UUID, ledgerTxID uuid.UUID, reconciledBy uuid.UUID) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).
@@ -182,6 +287,30 @@ func (r *bankRepository) ReconcileTransaction(ctx context.Context, bankTxID uuid
 		}).Error
 }
 
+func (r *bankRepository) PostRuleMatch(ctx context.Context, bankTx *models.BankTransaction, ruleID uuid.UUID, transaction *models.Transaction, postedBy uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createWithBalances(tx, transaction); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		bankTx.IsReconciled = true
+		bankTx.ReconciledTransactionID = &transaction.ID
+		bankTx.ReconciledAt = &now
+		bankTx.ReconciledBy = &postedBy
+		bankTx.BankRuleID = &ruleID
+		return tx.Model(&models.BankTransaction{}).
+			Where("id = ?", bankTx.ID).
+			Updates(map[string]interface{}{
+				"is_reconciled":             true,
+				"reconciled_transaction_id": transaction.ID,
+				"reconciled_at":             now,
+				"reconciled_by":             postedBy,
+				"bank_rule_id":              ruleID,
+			}).Error
+	})
+}
+
 func (r *bankRepository) UnreconcileTransaction(ctx context.Context, bankTxID uuid.UUID) error {
 	return r.db.WithContext(ctx).
 		Model(&models.BankTransaction{}).
@@ -241,3 +370,118 @@ func (r *bankRepository) GetReconciliationSummary(ctx context.Context, bankAccou
 
 	return summary, nil
 }
+
+// Auto-reconciliation methods
+
+func (r *bankRepository) CountUnreconciledTransactions(ctx context.Context, bankAccountID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.BankTransaction{}).
+		Where("bank_account_id = ? AND is_reconciled = false", bankAccountID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetUnreconciledBatch pages through unreconciled transactions by date using
+// the last transaction of the previous batch as the cursor, so matches made
+// in earlier batches do not shift the pages
+func (r *bankRepository) GetUnreconciledBatch(ctx context.Context, bankAccountID uuid.UUID, after *models.BankTransaction, limit int) ([]models.BankTransaction, error) {
+	query := r.db.WithContext(ctx).
+		Select("id", "transaction_date").
+		Where("bank_account_id = ? AND is_reconciled = false", bankAccountID)
+	if after != nil {
+		query = query.Where("(transaction_date, id) > (?, ?)", after.TransactionDate, after.ID)
+	}
+
+	var transactions []models.BankTransaction
+	err := query.Order("transaction_date, id").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+// MatchBankTransactions reconciles a batch of bank transactions against the
+// ledger in a single statement and returns how many were matched. Ledger
+// transactions already reconciled to a bank transaction are not reused, and
+// each is matched at most once per pass.
+func (r *bankRepository) MatchBankTransactions(ctx context.Context, opts MatchOptions) (int64, error) {
+	if len(opts.BankTransactionIDs) == 0 {
+		return 0, nil
+	}
+
+	matches := `
+		best AS (
+			SELECT DISTINCT ON (bank_tx_id) bank_tx_id, ledger_tx_id, day_gap, amount_gap
+			FROM candidates
+			ORDER BY bank_tx_id, day_gap, amount_gap, ledger_created_at
+		),
+		matches AS (
+			SELECT DISTINCT ON (ledger_tx_id) bank_tx_id, ledger_tx_id
+			FROM best
+			ORDER BY ledger_tx_id, day_gap, amount_gap, bank_tx_id
+		)`
+	if opts.Unambiguous {
+		matches = `
+		matches AS (
+			SELECT DISTINCT bank_tx_id, ledger_tx_id
+			FROM candidates
+			WHERE bank_tx_id IN (SELECT bank_tx_id FROM candidates GROUP BY bank_tx_id HAVING COUNT(DISTINCT ledger_tx_id) = 1)
+			  AND ledger_tx_id IN (SELECT ledger_tx_id FROM candidates GROUP BY ledger_tx_id HAVING COUNT(DISTINCT bank_tx_id) = 1)
+		)`
+	}
+
+	// Money into the bank is a debit to the bank's ledger account, so a
+	// bank credit matches a ledger debit
+	result := r.db.WithContext(ctx).Exec(fmt.Sprintf(`
+		WITH batch AS (
+			SELECT id, tenant_id, transaction_date, credit_amount - debit_amount AS amount
+			FROM bank_transactions
+			WHERE id IN @ids AND is_reconciled = false
+		),
+		candidates AS (
+			SELECT b.id AS bank_tx_id, t.id AS ledger_tx_id, t.created_at AS ledger_created_at,
+				ABS(t.transaction_date - b.transaction_date) AS day_gap,
+				ABS((l.debit_amount - l.credit_amount) - b.amount) AS amount_gap
+			FROM batch b
+			JOIN transactions t ON t.tenant_id = b.tenant_id
+				AND t.transaction_date BETWEEN b.transaction_date - CAST(@window AS integer) AND b.transaction_date + CAST(@window AS integer)
+				AND t.status = 'posted'
+				AND t.deleted_at IS NULL
+			JOIN transaction_lines l ON l.transaction_id = t.id AND l.account_id = @account
+			WHERE ABS((l.debit_amount - l.credit_amount) - b.amount) <= @tolerance
+			  AND NOT EXISTS (SELECT 1 FROM bank_transactions r WHERE r.reconciled_transaction_id = t.id)
+		),%s
+		UPDATE bank_transactions bt
+		SET is_reconciled = true,
+			reconciled_transaction_id = m.ledger_tx_id,
+			reconciled_at = NOW(),
+			reconciled_by = @user
+		FROM matches m
+		WHERE bt.id = m.bank_tx_id AND bt.is_reconciled = false
+	`, matches), map[string]interface{}{
+		"ids":       opts.BankTransactionIDs,
+		"window":    opts.DateWindowDays,
+		"account":   opts.LedgerAccountID,
+		"tolerance": opts.AmountTolerance,
+		"user":      opts.ReconciledBy,
+	})
+	return result.RowsAffected, result.Error
+}
+
+func (r *bankRepository) CreateReconciliationRun(ctx context.Context, run *models.ReconciliationRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *bankRepository) UpdateReconciliationRun(ctx context.Context, run *models.ReconciliationRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+func (r *bankRepository) GetLatestReconciliationRun(ctx context.Context, bankAccountID uuid.UUID) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.db.WithContext(ctx).
+		Where("bank_account_id = ?", bankAccountID).
+		Order("started_at DESC").
+		First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
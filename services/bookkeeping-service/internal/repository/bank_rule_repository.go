@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrBankRuleNotFound = errors.New("bank rule not found")
+
+// BankRuleRepository handles bank charge and interest rule data operations
+type BankRuleRepository interface {
+	Create(ctx context.Context, rule *models.BankRule) error
+	CreateMany(ctx context.Context, rules []models.BankRule) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.BankRule, error)
+	Update(ctx context.Context, rule *models.BankRule) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.BankRule, error)
+
+	// ListActive returns the active rules that apply to the bank account,
+	// in the order they are tried
+	ListActive(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.BankRule, error)
+}
+
+type bankRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewBankRuleRepository creates a new bank rule repository
+func NewBankRuleRepository(db *gorm.DB) BankRuleRepository {
+	return &bankRuleRepository{db: db}
+}
+
+func (r *bankRuleRepository) Create(ctx context.Context, rule *models.BankRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *bankRuleRepository) CreateMany(ctx context.Context, rules []models.BankRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&rules).Error
+}
+
+func (r *bankRuleRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.BankRule, error) {
+	var rule models.BankRule
+	err := r.db.WithContext(ctx).First(&rule, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBankRuleNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *bankRuleRepository) Update(ctx context.Context, rule *models.BankRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *bankRuleRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.BankRule{}, "id = ? AND tenant_id = ?", id, tenantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBankRuleNotFound
+	}
+	return nil
+}
+
+func (r *bankRuleRepository) List(ctx context.Context, tenantID uuid.UUID) ([]models.BankRule, error) {
+	var rules []models.BankRule
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("priority, created_at").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *bankRuleRepository) ListActive(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.BankRule, error) {
+	var rules []models.BankRule
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND is_active = true AND (bank_account_id IS NULL OR bank_account_id = ?)", tenantID, bankAccountID).
+		Order("priority, created_at").
+		Find(&rules).Error
+	return rules, err
+}
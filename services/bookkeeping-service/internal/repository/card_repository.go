@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CardRepository handles corporate card and card spend data operations
+type CardRepository interface {
+	// Cards
+	SaveCard(ctx context.Context, card *models.CorporateCard) error
+	ListCards(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.CorporateCard, error)
+
+	// Spends
+	CreateSpends(ctx context.Context, spends []models.CardSpend) error
+	GetSpend(ctx context.Context, tenantID, id uuid.UUID) (*models.CardSpend, error)
+	UpdateSpend(ctx context.Context, spend *models.CardSpend) error
+	ListSpends(ctx context.Context, tenantID uuid.UUID, filters CardSpendFilters) ([]models.CardSpend, error)
+	AssignUnassignedSpends(ctx context.Context, bankAccountID uuid.UUID, cardLast4 string, memberID uuid.UUID) (int64, error)
+	GetUncategorizedSummary(ctx context.Context, tenantID uuid.UUID, filters CardSpendFilters) ([]MemberCardSpendSummary, error)
+}
+
+// CardSpendFilters represents filters for listing card spends
+type CardSpendFilters struct {
+	BankAccountID uuid.UUID
+	MemberID      uuid.UUID
+	Uncategorized bool
+	Unmatched     bool
+	FromDate      string
+	ToDate        string
+}
+
+// MemberCardSpendSummary represents a member's card spend that still needs
+// attention. Spends on cards not yet assigned to a member have no MemberID.
+type MemberCardSpendSummary struct {
+	MemberID            *uuid.UUID `json:"member_id"`
+	HolderName          string     `json:"holder_name"`
+	SpendCount          int64      `json:"spend_count"`
+	TotalSpend          float64    `json:"total_spend"`
+	UncategorizedCount  int64      `json:"uncategorized_count"`
+	UncategorizedAmount float64    `json:"uncategorized_amount"`
+	UnmatchedAmount     float64    `json:"unmatched_amount"` // Not yet in an expense claim
+}
+
+type cardRepository struct {
+	db *gorm.DB
+}
+
+// NewCardRepository creates a new card repository
+func NewCardRepository(db *gorm.DB) CardRepository {
+	return &cardRepository{db: db}
+}
+
+// SaveCard creates the card, or reassigns it if the card is already known
+func (r *cardRepository) SaveCard(ctx context.Context, card *models.CorporateCard) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "bank_account_id"}, {Name: "card_last4"}},
+			DoUpdates: clause.AssignmentColumns([]string{"member_id", "holder_name", "is_active", "updated_at"}),
+		}).
+		Create(card).Error
+}
+
+func (r *cardRepository) ListCards(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.CorporateCard, error) {
+	var cards []models.CorporateCard
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND bank_account_id = ?", tenantID, bankAccountID).
+		Order("card_last4").
+		Find(&cards).Error
+	return cards, err
+}
+
+func (r *cardRepository) CreateSpends(ctx context.Context, spends []models.CardSpend) error {
+	return r.db.WithContext(ctx).CreateInBatches(spends, 100).Error
+}
+
+func (r *cardRepository) GetSpend(ctx context.Context, tenantID, id uuid.UUID) (*models.CardSpend, error) {
+	var spend models.CardSpend
+	err := r.db.WithContext(ctx).First(&spend, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &spend, nil
+}
+
+func (r *cardRepository) UpdateSpend(ctx context.Context, spend *models.CardSpend) error {
+	return r.db.WithContext(ctx).Save(spend).Error
+}
+
+func (r *cardRepository) ListSpends(ctx context.Context, tenantID uuid.UUID, filters CardSpendFilters) ([]models.CardSpend, error) {
+	var spends []models.CardSpend
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filters.BankAccountID != uuid.Nil {
+		query = query.Where("bank_account_id = ?", filters.BankAccountID)
+	}
+	if filters.MemberID != uuid.Nil {
+		query = query.Where("member_id = ?", filters.MemberID)
+	}
+	if filters.Uncategorized {
+		query = query.Where("(category = '' OR category IS NULL OR receipt_url = '' OR receipt_url IS NULL)")
+	}
+	if filters.Unmatched {
+		query = query.Where("expense_claim_id IS NULL")
+	}
+	if filters.FromDate != "" {
+		query = query.Where("transaction_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("transaction_date <= ?", filters.ToDate)
+	}
+
+	err := query.Order("transaction_date DESC, created_at DESC").Find(&spends).Error
+	return spends, err
+}
+
+// AssignUnassignedSpends gives a card's spends imported before the card was
+// assigned to its holder
+func (r *cardRepository) AssignUnassignedSpends(ctx context.Context, bankAccountID uuid.UUID, cardLast4 string, memberID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.CardSpend{}).
+		Where("bank_account_id = ? AND card_last4 = ? AND member_id IS NULL", bankAccountID, cardLast4).
+		Update("member_id", memberID)
+	return result.RowsAffected, result.Error
+}
+
+func (r *cardRepository) GetUncategorizedSummary(ctx context.Context, tenantID uuid.UUID, filters CardSpendFilters) ([]MemberCardSpendSummary, error) {
+	var summaries []MemberCardSpendSummary
+
+	query := r.db.WithContext(ctx).
+		Table("card_spends s").
+		Select(`s.member_id,
+			MAX(c.holder_name) as holder_name,
+			COUNT(*) as spend_count,
+			COALESCE(SUM(s.amount), 0) as total_spend,
+			COUNT(*) FILTER (WHERE COALESCE(s.category, '') = '' OR COALESCE(s.receipt_url, '') = '') as uncategorized_count,
+			COALESCE(SUM(s.amount) FILTER (WHERE COALESCE(s.category, '') = '' OR COALESCE(s.receipt_url, '') = ''), 0) as uncategorized_amount,
+			COALESCE(SUM(s.amount) FILTER (WHERE s.expense_claim_id IS NULL), 0) as unmatched_amount`).
+		Joins("LEFT JOIN corporate_cards c ON c.bank_account_id = s.bank_account_id AND c.card_last4 = s.card_last4").
+		Where("s.tenant_id = ?", tenantID)
+
+	if filters.BankAccountID != uuid.Nil {
+		query = query.Where("s.bank_account_id = ?", filters.BankAccountID)
+	}
+	if filters.MemberID != uuid.Nil {
+		query = query.Where("s.member_id = ?", filters.MemberID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("s.transaction_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("s.transaction_date <= ?", filters.ToDate)
+	}
+
+	err := query.
+		Group("s.member_id").
+		Order("uncategorized_amount DESC").
+		Scan(&summaries).Error
+	return summaries, err
+}
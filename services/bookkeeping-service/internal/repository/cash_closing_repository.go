@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrCashClosingNotFound = errors.New("cash closing not found")
+
+// CashClosingFilters defines filters for listing cash closings
+type CashClosingFilters struct {
+	CashAccountID uuid.UUID
+	Status        models.CashClosingStatus
+	FromDate      string
+	ToDate        string
+}
+
+// CashMovement is a posted transaction that moved money in or out of a cash
+// account
+type CashMovement struct {
+	TransactionID     uuid.UUID              `json:"transaction_id"`
+	TransactionNumber string                 `json:"transaction_number"`
+	TransactionType   models.TransactionType `json:"transaction_type"`
+	PartyName         string                 `json:"party_name,omitempty"`
+	Description       string                 `json:"description"`
+	Receipt           float64                `json:"receipt"`
+	Payment           float64                `json:"payment"`
+}
+
+// CashClosingRepository defines the interface for cash closing data access
+type CashClosingRepository interface {
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashClosingSettings, error)
+	SaveSettings(ctx context.Context, settings *models.CashClosingSettings) error
+
+	Save(ctx context.Context, closing *models.CashClosing) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.CashClosing, error)
+	GetByDate(ctx context.Context, tenantID, cashAccountID uuid.UUID, date time.Time) (*models.CashClosing, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters CashClosingFilters) ([]models.CashClosing, error)
+
+	// PostAdjustment records the over/short journal and saves the closing
+	// that refers to it in one transaction
+	PostAdjustment(ctx context.Context, closing *models.CashClosing, adjustment *models.Transaction) error
+
+	// GetMovements returns the posted transactions through the cash account
+	// on the day
+	GetMovements(ctx context.Context, tenantID, cashAccountID uuid.UUID, date time.Time) ([]CashMovement, error)
+}
+
+type cashClosingRepository struct {
+	db *gorm.DB
+}
+
+// NewCashClosingRepository creates a new cash closing repository
+func NewCashClosingRepository(db *gorm.DB) CashClosingRepository {
+	return &cashClosingRepository{db: db}
+}
+
+// GetSettings returns the tenant's settings, or the defaults if it has none
+func (r *cashClosingRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashClosingSettings, error) {
+	var settings models.CashClosingSettings
+	err := r.db.WithContext(ctx).First(&settings, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.CashClosingSettings{TenantID: tenantID}, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *cashClosingRepository) SaveSettings(ctx context.Context, settings *models.CashClosingSettings) error {
+	return r.db.WithContext(ctx).Save(settings).Error
+}
+
+func (r *cashClosingRepository) Save(ctx context.Context, closing *models.CashClosing) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := stampChainHead(tx, closing); err != nil {
+			return err
+		}
+		return tx.Save(closing).Error
+	})
+}
+
+func (r *cashClosingRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.CashClosing, error) {
+	var closing models.CashClosing
+	err := r.db.WithContext(ctx).First(&closing, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCashClosingNotFound
+		}
+		return nil, err
+	}
+	return &closing, nil
+}
+
+func (r *cashClosingRepository) GetByDate(ctx context.Context, tenantID, cashAccountID uuid.UUID, date time.Time) (*models.CashClosing, error) {
+	var closing models.CashClosing
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND cash_account_id = ? AND closing_date = ?", tenantID, cashAccountID, date.Format("2006-01-02")).
+		First(&closing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCashClosingNotFound
+		}
+		return nil, err
+	}
+	return &closing, nil
+}
+
+func (r *cashClosingRepository) List(ctx context.Context, tenantID uuid.UUID, filters CashClosingFilters) ([]models.CashClosing, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+
+	if filters.CashAccountID != uuid.Nil {
+		query = query.Where("cash_account_id = ?", filters.CashAccountID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("closing_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("closing_date <= ?", filters.ToDate)
+	}
+
+	var closings []models.CashClosing
+	err := query.Order("closing_date DESC").Find(&closings).Error
+	return closings, err
+}
+
+func (r *cashClosingRepository) PostAdjustment(ctx context.Context, closing *models.CashClosing, adjustment *models.Transaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createWithBalances(tx, adjustment); err != nil {
+			return err
+		}
+		closing.AdjustmentTransactionID = &adjustment.ID
+		if err := stampChainHead(tx, closing); err != nil {
+			return err
+		}
+		return tx.Save(closing).Error
+	})
+}
+
+// stampChainHead records the ledger chain head on a closing that closes
+// the day
+func stampChainHead(tx *gorm.DB, closing *models.CashClosing) error {
+	if !closing.IsFinal() {
+		closing.ChainSequence = nil
+		closing.ChainHash = ""
+		return nil
+	}
+	head, err := chainHead(tx, closing.TenantID)
+	if err != nil || head == nil {
+		return err
+	}
+	closing.ChainSequence = &head.Sequence
+	closing.ChainHash = head.Hash
+	return nil
+}
+
+func (r *cashClosingRepository) GetMovements(ctx context.Context, tenantID, cashAccountID uuid.UUID, date time.Time) ([]CashMovement, error) {
+	movements := []CashMovement{}
+	err := r.db.WithContext(ctx).
+		Model(&models.TransactionLine{}).
+		Select(`t.id AS transaction_id, t.transaction_number, t.transaction_type, t.party_name, t.description,
+			SUM(transaction_lines.debit_amount) AS receipt, SUM(transaction_lines.credit_amount) AS payment`).
+		Joins("JOIN transactions t ON t.id = transaction_lines.transaction_id").
+		Where("transaction_lines.account_id = ? AND t.tenant_id = ? AND t.transaction_date = ? AND t.status = ? AND t.deleted_at IS NULL",
+			cashAccountID, tenantID, date.Format("2006-01-02"), models.TransactionStatusPosted).
+		Group("t.id, t.transaction_number, t.transaction_type, t.party_name, t.description, t.created_at").
+		Order("t.created_at").
+		Scan(&movements).Error
+	return movements, err
+}
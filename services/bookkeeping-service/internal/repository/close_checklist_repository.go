@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCloseChecklistNotFound    = errors.New("close checklist not found")
+	ErrCloseTaskNotFound         = errors.New("close task not found")
+	ErrCloseTaskTemplateNotFound = errors.New("close task template not found")
+)
+
+// CloseTaskFilters defines filters for listing close tasks across checklists
+type CloseTaskFilters struct {
+	AssigneeID uuid.UUID
+	Status     models.CloseTaskStatus
+	Period     string
+	// OpenOnly leaves out tasks of completed checklists
+	OpenOnly bool
+}
+
+// CloseChecklistRepository defines the interface for month-end close
+// checklist data access
+type CloseChecklistRepository interface {
+	// ListTemplates returns the tenant's checklist templates in order
+	ListTemplates(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]models.CloseTaskTemplate, error)
+	// HasTemplates reports whether the tenant ever set up its checklist,
+	// counting templates since deleted
+	HasTemplates(ctx context.Context, tenantID uuid.UUID) (bool, error)
+	CreateTemplates(ctx context.Context, templates []models.CloseTaskTemplate) error
+	GetTemplate(ctx context.Context, tenantID, id uuid.UUID) (*models.CloseTaskTemplate, error)
+	SaveTemplate(ctx context.Context, template *models.CloseTaskTemplate) error
+	DeleteTemplate(ctx context.Context, tenantID, id uuid.UUID) error
+
+	// Create saves a new checklist with its tasks
+	Create(ctx context.Context, checklist *models.CloseChecklist) error
+	// Save updates a checklist, leaving its tasks as they are
+	Save(ctx context.Context, checklist *models.CloseChecklist) error
+	// GetByID returns a checklist with its tasks in order
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.CloseChecklist, error)
+	// GetByPeriod returns the tenant's checklist for a period, with its tasks
+	GetByPeriod(ctx context.Context, tenantID uuid.UUID, period string) (*models.CloseChecklist, error)
+	List(ctx context.Context, tenantID uuid.UUID, status models.CloseChecklistStatus) ([]models.CloseChecklist, error)
+	Exists(ctx context.Context, tenantID uuid.UUID, period string) (bool, error)
+
+	CreateTask(ctx context.Context, task *models.CloseTask) error
+	SaveTask(ctx context.Context, task *models.CloseTask) error
+	// ListTasks returns tasks across the tenant's checklists, earliest due
+	// first
+	ListTasks(ctx context.Context, tenantID uuid.UUID, filters CloseTaskFilters) ([]models.CloseTask, error)
+}
+
+type closeChecklistRepository struct {
+	db *gorm.DB
+}
+
+// NewCloseChecklistRepository creates a new close checklist repository
+func NewCloseChecklistRepository(db *gorm.DB) CloseChecklistRepository {
+	return &closeChecklistRepository{db: db}
+}
+
+func (r *closeChecklistRepository) ListTemplates(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]models.CloseTaskTemplate, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var templates []models.CloseTaskTemplate
+	err := query.Order("sort_order, created_at").Find(&templates).Error
+	return templates, err
+}
+
+func (r *closeChecklistRepository) HasTemplates(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.CloseTaskTemplate{}).
+		Where("tenant_id = ?", tenantID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *closeChecklistRepository) CreateTemplates(ctx context.Context, templates []models.CloseTaskTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&templates).Error
+}
+
+func (r *closeChecklistRepository) GetTemplate(ctx context.Context, tenantID, id uuid.UUID) (*models.CloseTaskTemplate, error) {
+	var template models.CloseTaskTemplate
+	err := r.db.WithContext(ctx).First(&template, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCloseTaskTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *closeChecklistRepository) SaveTemplate(ctx context.Context, template *models.CloseTaskTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+func (r *closeChecklistRepository) DeleteTemplate(ctx context.Context, tenantID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&models.CloseTaskTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCloseTaskTemplateNotFound
+	}
+	return nil
+}
+
+func (r *closeChecklistRepository) Create(ctx context.Context, checklist *models.CloseChecklist) error {
+	return r.db.WithContext(ctx).Create(checklist).Error
+}
+
+func (r *closeChecklistRepository) Save(ctx context.Context, checklist *models.CloseChecklist) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(checklist).Error
+}
+
+func (r *closeChecklistRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.CloseChecklist, error) {
+	return r.get(ctx, "id = ? AND tenant_id = ?", id, tenantID)
+}
+
+func (r *closeChecklistRepository) GetByPeriod(ctx context.Context, tenantID uuid.UUID, period string) (*models.CloseChecklist, error) {
+	return r.get(ctx, "tenant_id = ? AND period = ?", tenantID, period)
+}
+
+func (r *closeChecklistRepository) get(ctx context.Context, query string, args ...interface{}) (*models.CloseChecklist, error) {
+	var checklist models.CloseChecklist
+	err := r.db.WithContext(ctx).
+		Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order, created_at")
+		}).
+		Where(query, args...).
+		First(&checklist).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCloseChecklistNotFound
+		}
+		return nil, err
+	}
+	return &checklist, nil
+}
+
+func (r *closeChecklistRepository) List(ctx context.Context, tenantID uuid.UUID, status models.CloseChecklistStatus) ([]models.CloseChecklist, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var checklists []models.CloseChecklist
+	err := query.Order("period DESC").Find(&checklists).Error
+	return checklists, err
+}
+
+func (r *closeChecklistRepository) Exists(ctx context.Context, tenantID uuid.UUID, period string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.CloseChecklist{}).
+		Where("tenant_id = ? AND period = ?", tenantID, period).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *closeChecklistRepository) CreateTask(ctx context.Context, task *models.CloseTask) error {
+	return r.db.WithContext(ctx).Create(task).Error
+}
+
+func (r *closeChecklistRepository) SaveTask(ctx context.Context, task *models.CloseTask) error {
+	return r.db.WithContext(ctx).Save(task).Error
+}
+
+func (r *closeChecklistRepository) ListTasks(ctx context.Context, tenantID uuid.UUID, filters CloseTaskFilters) ([]models.CloseTask, error) {
+	query := r.db.WithContext(ctx).
+		Joins("JOIN close_checklists c ON c.id = close_tasks.checklist_id").
+		Where("close_tasks.tenant_id = ?", tenantID)
+
+	if filters.AssigneeID != uuid.Nil {
+		query = query.Where("close_tasks.assignee_id = ?", filters.AssigneeID)
+	}
+	if filters.Status != "" {
+		query = query.Where("close_tasks.status = ?", filters.Status)
+	}
+	if filters.Period != "" {
+		query = query.Where("c.period = ?", filters.Period)
+	}
+	if filters.OpenOnly {
+		query = query.Where("c.status = ?", models.CloseChecklistOpen)
+	}
+
+	var tasks []models.CloseTask
+	err := query.Order("close_tasks.due_date, c.period, close_tasks.sort_order").Find(&tasks).Error
+	return tasks, err
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrFinancialYearNotFound = errors.New("financial year not found")
+
+	// ErrPeriodClosed is returned when a transaction to be posted, edited
+	// or voided is dated in a closed period or financial year
+	ErrPeriodClosed = errors.New("accounting period is closed")
+
+	// ErrNoFinancialYear is returned when a transaction to be posted is
+	// dated outside the tenant's financial years, and no year can be
+	// added for it
+	ErrNoFinancialYear = errors.New("no financial year covers the transaction date")
+)
+
+// yearsAhead is how far past today financial years are added on their
+// own, so a mistyped date doesn't open years of empty ledger
+const yearsAhead = 1
+
+// FinancialPeriodRepository defines the interface for financial year and
+// period data access
+type FinancialPeriodRepository interface {
+	ListYears(ctx context.Context, tenantID uuid.UUID) ([]models.FinancialYear, error)
+	FindYear(ctx context.Context, id, tenantID uuid.UUID) (*models.FinancialYear, error)
+	CreateYear(ctx context.Context, year *models.FinancialYear) error
+
+	// EnsureYear returns the financial year the date falls in, adding it if
+	// the date is past the tenant's latest year. A tenant without years is
+	// given the one starting in startMonth, or left without if it is 0, in
+	// which case nil is returned.
+	EnsureYear(ctx context.Context, tenantID uuid.UUID, date time.Time, startMonth time.Month) (*models.FinancialYear, error)
+
+	// ListPeriods returns the stored periods of the financial year, those
+	// that have been closed at some point
+	ListPeriods(ctx context.Context, tenantID, yearID uuid.UUID) ([]models.FinancialPeriod, error)
+	FindPeriod(ctx context.Context, tenantID uuid.UUID, period string) (*models.FinancialPeriod, error)
+
+	// SavePeriod stores a period's state. It waits for postings in progress
+	// in the tenant's ledger, so none lands in a period as it closes.
+	SavePeriod(ctx context.Context, period *models.FinancialPeriod) error
+
+	// ClosedOn returns the name of the closed period or financial year the
+	// date falls in, or "" if it is open
+	ClosedOn(ctx context.Context, tenantID uuid.UUID, date time.Time) (string, error)
+}
+
+type financialPeriodRepository struct {
+	db *gorm.DB
+}
+
+// NewFinancialPeriodRepository creates a new financial period repository
+func NewFinancialPeriodRepository(db *gorm.DB) FinancialPeriodRepository {
+	return &financialPeriodRepository{db: db}
+}
+
+func (r *financialPeriodRepository) ListYears(ctx context.Context, tenantID uuid.UUID) ([]models.FinancialYear, error) {
+	var years []models.FinancialYear
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("year_start DESC").
+		Find(&years).Error
+	return years, err
+}
+
+func (r *financialPeriodRepository) FindYear(ctx context.Context, id, tenantID uuid.UUID) (*models.FinancialYear, error) {
+	var year models.FinancialYear
+	err := r.db.WithContext(ctx).First(&year, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFinancialYearNotFound
+		}
+		return nil, err
+	}
+	return &year, nil
+}
+
+func (r *financialPeriodRepository) CreateYear(ctx context.Context, year *models.FinancialYear) error {
+	return r.db.WithContext(ctx).Create(year).Error
+}
+
+func (r *financialPeriodRepository) EnsureYear(ctx context.Context, tenantID uuid.UUID, date time.Time, startMonth time.Month) (*models.FinancialYear, error) {
+	var year *models.FinancialYear
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockChain(tx, tenantID); err != nil {
+			return err
+		}
+		var err error
+		year, err = ensureYear(tx, tenantID, date, startMonth)
+		return err
+	})
+	return year, err
+}
+
+func (r *financialPeriodRepository) ListPeriods(ctx context.Context, tenantID, yearID uuid.UUID) ([]models.FinancialPeriod, error) {
+	var periods []models.FinancialPeriod
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND financial_year_id = ?", tenantID, yearID).
+		Order("start_date").
+		Find(&periods).Error
+	return periods, err
+}
+
+func (r *financialPeriodRepository) FindPeriod(ctx context.Context, tenantID uuid.UUID, period string) (*models.FinancialPeriod, error) {
+	var stored models.FinancialPeriod
+	err := r.db.WithContext(ctx).First(&stored, "tenant_id = ? AND period = ?", tenantID, period).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *financialPeriodRepository) SavePeriod(ctx context.Context, period *models.FinancialPeriod) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockChain(tx, period.TenantID); err != nil {
+			return err
+		}
+
+		period.ChainSequence = nil
+		period.ChainHash = ""
+		if period.IsClosed {
+			head, err := chainHead(tx, period.TenantID)
+			if err != nil {
+				return err
+			}
+			if head != nil {
+				period.ChainSequence = &head.Sequence
+				period.ChainHash = head.Hash
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "period"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"is_closed", "closed_at", "closed_by", "chain_sequence", "chain_hash",
+				"reopened_at", "reopened_by", "reopen_reason", "updated_at",
+			}),
+		}).Create(period).Error
+	})
+}
+
+func (r *financialPeriodRepository) ClosedOn(ctx context.Context, tenantID uuid.UUID, date time.Time) (string, error) {
+	return closedOn(r.db.WithContext(ctx), tenantID, date)
+}
+
+func closedOn(tx *gorm.DB, tenantID uuid.UUID, date time.Time) (string, error) {
+	var periods []string
+	err := tx.Model(&models.FinancialPeriod{}).
+		Where("tenant_id = ? AND is_closed AND start_date <= ? AND end_date >= ?", tenantID, date, date).
+		Limit(1).
+		Pluck("period", &periods).Error
+	if err != nil || len(periods) > 0 {
+		return firstOf(periods), err
+	}
+
+	var years []string
+	err = tx.Model(&models.FinancialYear{}).
+		Where("tenant_id = ? AND is_closed AND year_start <= ? AND year_end >= ?", tenantID, date, date).
+		Limit(1).
+		Pluck("COALESCE(NULLIF(name, ''), 'FY ' || to_char(year_start, 'YYYY'))", &years).Error
+	return firstOf(years), err
+}
+
+func firstOf(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// ensureYear returns the tenant's financial year the date falls in. Years
+// are rolled forward, a year at a time from the latest, to a date up to a
+// year from today; earlier dates, and dates between years, have no year.
+// Callers hold the tenant's chain lock.
+func ensureYear(tx *gorm.DB, tenantID uuid.UUID, date time.Time, startMonth time.Month) (*models.FinancialYear, error) {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	var years []models.FinancialYear
+	err := tx.Where("tenant_id = ? AND year_start <= ? AND year_end >= ?", tenantID, date, date).
+		Limit(1).
+		Find(&years).Error
+	if err != nil || len(years) > 0 {
+		return firstYear(years), err
+	}
+
+	err = tx.Where("tenant_id = ?", tenantID).
+		Order("year_end DESC").
+		Limit(1).
+		Find(&years).Error
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var added []models.FinancialYear
+	switch {
+	case len(years) == 0 && startMonth == 0:
+		return nil, nil
+	case len(years) == 0:
+		yearStart, yearEnd := models.FinancialYearOn(date, startMonth)
+		added = append(added, models.FinancialYear{TenantID: tenantID, YearStart: yearStart, YearEnd: yearEnd})
+	case date.After(years[0].YearEnd) && !date.After(today.AddDate(yearsAhead, 0, 0)):
+		for yearEnd := years[0].YearEnd; date.After(yearEnd); {
+			yearStart := yearEnd.AddDate(0, 0, 1)
+			yearEnd = yearStart.AddDate(1, 0, -1)
+			added = append(added, models.FinancialYear{TenantID: tenantID, YearStart: yearStart, YearEnd: yearEnd})
+		}
+	default:
+		return nil, ErrNoFinancialYear
+	}
+
+	for i := range added {
+		added[i].Name = models.FinancialYearName(added[i].YearStart, added[i].YearEnd)
+	}
+	if err := tx.Create(&added).Error; err != nil {
+		return nil, err
+	}
+	// The current year may be one just added
+	if err := tx.Model(&models.FinancialYear{}).
+		Where("tenant_id = ?", tenantID).
+		Update("is_current", gorm.Expr("year_start <= ? AND year_end >= ?", today, today)).Error; err != nil {
+		return nil, err
+	}
+	year := added[len(added)-1]
+	year.IsCurrent = !today.Before(year.YearStart) && !today.After(year.YearEnd)
+	return &year, nil
+}
+
+func firstYear(years []models.FinancialYear) *models.FinancialYear {
+	if len(years) == 0 {
+		return nil
+	}
+	return &years[0]
+}
+
+// ensurePostingPeriod is ensurePeriodOpen for a transaction about to be
+// posted on the date, which must also fall in one of the tenant's financial
+// years, if it keeps any. Years past the latest are added as needed.
+func ensurePostingPeriod(tx *gorm.DB, tenantID uuid.UUID, date time.Time) error {
+	if err := ensurePeriodOpen(tx, tenantID, date); err != nil {
+		return err
+	}
+	_, err := ensureYear(tx, tenantID, date, 0)
+	return err
+}
+
+// ensurePeriodOpen fails with ErrPeriodClosed if the date falls in a closed
+// period of the tenant. It takes the tenant's chain lock first, so the
+// check holds until the database transaction ends.
+func ensurePeriodOpen(tx *gorm.DB, tenantID uuid.UUID, date time.Time) error {
+	if err := lockChain(tx, tenantID); err != nil {
+		return err
+	}
+	closed, err := closedOn(tx, tenantID, date)
+	if err != nil {
+		return err
+	}
+	if closed != "" {
+		return ErrPeriodClosed
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrStandingInstructionNotFound = errors.New("standing instruction not found")
+
+// StandingInstructionFilters defines filters for listing standing instructions
+type StandingInstructionFilters struct {
+	BankAccountID uuid.UUID
+	Status        models.StandingInstructionStatus
+}
+
+// StandingInstructionRepository handles detected standing instructions
+type StandingInstructionRepository interface {
+	// SaveDetected records detected instructions, refreshing the cadence
+	// of ones already known while keeping what the tenant did with them
+	SaveDetected(ctx context.Context, instructions []models.StandingInstruction) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.StandingInstruction, error)
+	Update(ctx context.Context, instruction *models.StandingInstruction) error
+	List(ctx context.Context, tenantID uuid.UUID, filters StandingInstructionFilters) ([]models.StandingInstruction, error)
+
+	// GetStatementSince returns a bank account's statement lines from the
+	// given date, oldest first
+	GetStatementSince(ctx context.Context, bankAccountID uuid.UUID, since time.Time) ([]models.BankTransaction, error)
+
+	// ListBankFeeds returns the active bank accounts that have statement
+	// lines since the given date
+	ListBankFeeds(ctx context.Context, since time.Time) ([]models.BankAccount, error)
+
+	// FindRecurringJournal returns an active recurring journal of the given
+	// frequency that moves about amount through the ledger account
+	FindRecurringJournal(ctx context.Context, tenantID, ledgerAccountID uuid.UUID, frequency models.RecurrenceFrequency, amount, tolerance float64) (*models.RecurringJournal, error)
+}
+
+type standingInstructionRepository struct {
+	db *gorm.DB
+}
+
+// NewStandingInstructionRepository creates a new standing instruction repository
+func NewStandingInstructionRepository(db *gorm.DB) StandingInstructionRepository {
+	return &standingInstructionRepository{db: db}
+}
+
+func (r *standingInstructionRepository) SaveDetected(ctx context.Context, instructions []models.StandingInstruction) error {
+	if len(instructions) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "bank_account_id"}, {Name: "payee"}, {Name: "direction"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"description", "amount", "frequency", "occurrences",
+			"first_date", "last_date", "next_expected_date", "updated_at",
+		}),
+	}).Create(&instructions).Error
+}
+
+func (r *standingInstructionRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.StandingInstruction, error) {
+	var instruction models.StandingInstruction
+	err := r.db.WithContext(ctx).First(&instruction, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStandingInstructionNotFound
+		}
+		return nil, err
+	}
+	return &instruction, nil
+}
+
+func (r *standingInstructionRepository) Update(ctx context.Context, instruction *models.StandingInstruction) error {
+	return r.db.WithContext(ctx).Save(instruction).Error
+}
+
+func (r *standingInstructionRepository) List(ctx context.Context, tenantID uuid.UUID, filters StandingInstructionFilters) ([]models.StandingInstruction, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+
+	if filters.BankAccountID != uuid.Nil {
+		query = query.Where("bank_account_id = ?", filters.BankAccountID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var instructions []models.StandingInstruction
+	err := query.Order("next_expected_date, payee").Find(&instructions).Error
+	return instructions, err
+}
+
+func (r *standingInstructionRepository) GetStatementSince(ctx context.Context, bankAccountID uuid.UUID, since time.Time) ([]models.BankTransaction, error) {
+	var transactions []models.BankTransaction
+	err := r.db.WithContext(ctx).
+		Where("bank_account_id = ? AND transaction_date >= ?", bankAccountID, since).
+		Order("transaction_date, created_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *standingInstructionRepository) ListBankFeeds(ctx context.Context, since time.Time) ([]models.BankAccount, error) {
+	var accounts []models.BankAccount
+	err := r.db.WithContext(ctx).
+		Where("is_active = true AND feed_type = ?", models.FeedTypeBank).
+		Where("EXISTS (SELECT 1 FROM bank_transactions bt WHERE bt.bank_account_id = bank_accounts.id AND bt.transaction_date >= ?)", since).
+		Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *standingInstructionRepository) FindRecurringJournal(ctx context.Context, tenantID, ledgerAccountID uuid.UUID, frequency models.RecurrenceFrequency, amount, tolerance float64) (*models.RecurringJournal, error) {
+	var recurring models.RecurringJournal
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ? AND frequency = ? AND total_amount BETWEEN ? AND ?",
+			tenantID, models.RecurringStatusActive, frequency, amount-tolerance, amount+tolerance).
+		Where("EXISTS (SELECT 1 FROM recurring_journal_lines l WHERE l.recurring_journal_id = recurring_journals.id AND l.account_id = ?)", ledgerAccountID).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "ABS(total_amount - ?)", Vars: []interface{}{amount}}}).
+		First(&recurring).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &recurring, nil
+}
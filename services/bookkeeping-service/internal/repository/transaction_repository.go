@@ -2,26 +2,65 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
 	"gorm.io/gorm"
 )
 
+// ErrSupportingDetailNotFound is returned for a transaction posted without
+// supporting details
+var ErrSupportingDetailNotFound = errors.New("supporting detail not found")
+
 // TransactionRepository defines the interface for transaction data access
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *models.Transaction) error
+	CreateMirrored(ctx context.Context, transaction, mirror *models.Transaction) error
+	// CreateBatch posts many transactions of one tenant at once, such as a
+	// migrated ledger or load-test fixtures
+	CreateBatch(ctx context.Context, transactions []models.Transaction) error
 	Update(ctx context.Context, transaction *models.Transaction) error
 	Delete(ctx context.Context, id, tenantID uuid.UUID) error
 	FindByID(ctx context.Context, id, tenantID uuid.UUID) (*models.Transaction, error)
 	FindByNumber(ctx context.Context, number string, tenantID uuid.UUID) (*models.Transaction, error)
+	// FindBySource returns the tenant's live (not void) entry posted for the
+	// source document
+	FindBySource(ctx context.Context, sourceID, tenantID uuid.UUID) (*models.Transaction, error)
+	FindSupportingDetail(ctx context.Context, id, tenantID uuid.UUID) (*models.TransactionSupportingDetail, error)
 	FindAll(ctx context.Context, tenantID uuid.UUID, filter TransactionFilter) ([]models.Transaction, int64, error)
 	GetNextNumber(ctx context.Context, tenantID uuid.UUID, txnType models.TransactionType) (string, error)
 	VoidTransaction(ctx context.Context, id, tenantID uuid.UUID) error
 	GetDailySummary(ctx context.Context, tenantID uuid.UUID, date time.Time) (*DailySummary, error)
 	GetAccountBalance(ctx context.Context, accountID, tenantID uuid.UUID, asOfDate time.Time) (float64, error)
+
+	// Tags
+	UpdateTags(ctx context.Context, transaction *models.Transaction) error
+	ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error)
+	// RenameTag renames a tag on every transaction, merging it into the new
+	// name where both are present, and returns how many were changed
+	RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error)
+	DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error)
+
+	// Inter-company
+	FindInterCompany(ctx context.Context, tenantIDs, counterpartyTenantIDs []uuid.UUID, fromDate, toDate string) ([]models.Transaction, error)
+	FindMirrors(ctx context.Context, ids []uuid.UUID) ([]models.Transaction, error)
+
+	// Hash chain. Chained transactions are read including deleted ones, so
+	// a deletion shows up as a changed transaction rather than a gap.
+	GetChainHead(ctx context.Context, tenantID uuid.UUID) (*ChainHead, error)
+	FindChained(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]models.Transaction, error)
+}
+
+// ChainHead is the last transaction appended to a tenant's hash chain
+type ChainHead struct {
+	Sequence int64  `json:"sequence"`
+	Hash     string `json:"hash"`
 }
 
 // TransactionFilter defines filter options for listing transactions
@@ -32,11 +71,15 @@ type TransactionFilter struct {
 	ToDate    string
 	PartyID   *uuid.UUID
 	StoreID   *uuid.UUID
+	Tag       string
 	Search    string
 	Page      int
 	PerPage   int
 	SortBy    string
 	SortOrder string
+
+	// Adjustment journals only (true), or operational entries only (false)
+	IsAdjustment *bool
 }
 
 // DailySummary represents daily transaction summary
@@ -61,33 +104,260 @@ func NewTransactionRepository(db *gorm.DB) TransactionRepository {
 
 func (r *transactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Create transaction
-		if err := tx.Create(transaction).Error; err != nil {
+		return createWithBalances(tx, transaction)
+	})
+}
+
+// CreateMirrored creates an inter-company transaction and its mirror in the
+// counterparty's books together, linking each to the other
+func (r *transactionRepository) CreateMirrored(ctx context.Context, transaction, mirror *models.Transaction) error {
+	if transaction.ID == uuid.Nil {
+		transaction.ID = uuid.New()
+	}
+	if mirror.ID == uuid.Nil {
+		mirror.ID = uuid.New()
+	}
+	transaction.MirrorTransactionID = &mirror.ID
+	mirror.MirrorTransactionID = &transaction.ID
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Take both tenants' chain locks in a fixed order so mirrored
+		// entries created in opposite directions do not deadlock
+		tenants := []uuid.UUID{transaction.TenantID, mirror.TenantID}
+		if tenants[1].String() < tenants[0].String() {
+			tenants[0], tenants[1] = tenants[1], tenants[0]
+		}
+		for _, tenantID := range tenants {
+			if err := lockChain(tx, tenantID); err != nil {
+				return err
+			}
+		}
+
+		if err := createWithBalances(tx, transaction); err != nil {
 			return err
 		}
+		return createWithBalances(tx, mirror)
+	})
+}
 
-		// Update account balances
-		for _, line := range transaction.Lines {
-			balanceChange := line.DebitAmount - line.CreditAmount
+// CreateBatch posts the transactions in one database transaction, chained in
+// the order given, updating each account's balance once
+func (r *transactionRepository) CreateBatch(ctx context.Context, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	tenantID := transactions[0].TenantID
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		checked := make(map[time.Time]bool)
+		for _, transaction := range transactions {
+			if transaction.TenantID != tenantID {
+				return fmt.Errorf("batch mixes tenants %s and %s", tenantID, transaction.TenantID)
+			}
+			if checked[transaction.TransactionDate] {
+				continue
+			}
+			if err := ensurePostingPeriod(tx, tenantID, transaction.TransactionDate); err != nil {
+				return err
+			}
+			checked[transaction.TransactionDate] = true
+		}
+
+		if err := lockChain(tx, tenantID); err != nil {
+			return err
+		}
+		head, err := chainHead(tx, tenantID)
+		if err != nil {
+			return err
+		}
+		balances := make(map[uuid.UUID]float64)
+		for i := range transactions {
+			transaction := &transactions[i]
+			transaction.Status = models.TransactionStatusPosted
+			head = linkToChain(transaction, head)
+			for _, line := range transaction.Lines {
+				balances[line.AccountID] += line.DebitAmount - line.CreditAmount
+			}
+		}
+
+		if err := tx.CreateInBatches(transactions, 500).Error; err != nil {
+			return err
+		}
+		for accountID, balanceChange := range balances {
 			if err := tx.Model(&models.Account{}).
-				Where("id = ?", line.AccountID).
-				Update("current_balance", gorm.Expr("current_balance + ?", balanceChange)).Error; err != nil {
+				Where("id = ?", accountID).
+				Update("current_balance", gorm.Expr("current_balance + ?", roundAmount(balanceChange))).Error; err != nil {
 				return err
 			}
 		}
 
-		return nil
+		return touchAccounts(tx, tenantID)
 	})
 }
 
+func createWithBalances(tx *gorm.DB, transaction *models.Transaction) error {
+	if err := ensurePostingPeriod(tx, transaction.TenantID, transaction.TransactionDate); err != nil {
+		return err
+	}
+	if transaction.Status == "" || transaction.Status == models.TransactionStatusPosted {
+		if err := appendToChain(tx, transaction); err != nil {
+			return err
+		}
+	}
+
+	// Create transaction
+	if err := tx.Create(transaction).Error; err != nil {
+		return err
+	}
+
+	// Update account balances
+	for _, line := range transaction.Lines {
+		balanceChange := line.DebitAmount - line.CreditAmount
+		if err := tx.Model(&models.Account{}).
+			Where("id = ?", line.AccountID).
+			Update("current_balance", gorm.Expr("current_balance + ?", balanceChange)).Error; err != nil {
+			return err
+		}
+	}
+
+	return touchAccounts(tx, transaction.TenantID)
+}
+
+// touchAccounts invalidates cached reads of the tenant's accounts, whose
+// balances and ledgers follow its transactions
+func touchAccounts(tx *gorm.DB, tenantID uuid.UUID) error {
+	return database.BumpVersion(tx.Statement.Context, tx, tenantID.String(), AccountsResource)
+}
+
+// lockChain serialises appends to a tenant's hash chain until the
+// database transaction ends
+func lockChain(tx *gorm.DB, tenantID uuid.UUID) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", "ledger_chain:"+tenantID.String()).Error
+}
+
+// appendToChain links a transaction about to be posted to the end of its
+// tenant's hash chain. Amounts are rounded to the stored precision first so
+// the hash matches what is read back.
+func appendToChain(tx *gorm.DB, transaction *models.Transaction) error {
+	if err := lockChain(tx, transaction.TenantID); err != nil {
+		return err
+	}
+	head, err := chainHead(tx, transaction.TenantID)
+	if err != nil {
+		return err
+	}
+	linkToChain(transaction, head)
+	return nil
+}
+
+// linkToChain links a transaction to follow head, nil for the start of the
+// chain, and returns the transaction as the new head
+func linkToChain(transaction *models.Transaction, head *ChainHead) *ChainHead {
+	if transaction.ID == uuid.Nil {
+		transaction.ID = uuid.New()
+	}
+	transaction.Subtotal = roundAmount(transaction.Subtotal)
+	transaction.TaxAmount = roundAmount(transaction.TaxAmount)
+	transaction.DiscountAmount = roundAmount(transaction.DiscountAmount)
+	transaction.RoundOffAmount = roundAmount(transaction.RoundOffAmount)
+	transaction.TotalAmount = roundAmount(transaction.TotalAmount)
+	for i := range transaction.Lines {
+		line := &transaction.Lines[i]
+		if line.ID == uuid.Nil {
+			line.ID = uuid.New()
+		}
+		line.DebitAmount = roundAmount(line.DebitAmount)
+		line.CreditAmount = roundAmount(line.CreditAmount)
+		line.TaxAmount = roundAmount(line.TaxAmount)
+	}
+
+	sequence := int64(1)
+	prevHash := models.GenesisHash
+	if head != nil {
+		sequence = head.Sequence + 1
+		prevHash = head.Hash
+	}
+	transaction.ChainSequence = &sequence
+	transaction.PrevHash = prevHash
+	transaction.ChainHash = transaction.Hash(prevHash)
+	return &ChainHead{Sequence: sequence, Hash: transaction.ChainHash}
+}
+
+func chainHead(tx *gorm.DB, tenantID uuid.UUID) (*ChainHead, error) {
+	var heads []ChainHead
+	err := tx.Unscoped().
+		Model(&models.Transaction{}).
+		Select("chain_sequence AS sequence, chain_hash AS hash").
+		Where("tenant_id = ? AND chain_sequence IS NOT NULL", tenantID).
+		Order("chain_sequence DESC").
+		Limit(1).
+		Scan(&heads).Error
+	if err != nil || len(heads) == 0 {
+		return nil, err
+	}
+	return &heads[0], nil
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func (r *transactionRepository) GetChainHead(ctx context.Context, tenantID uuid.UUID) (*ChainHead, error) {
+	return chainHead(r.db.WithContext(ctx), tenantID)
+}
+
+func (r *transactionRepository) FindChained(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("Lines").
+		Where("tenant_id = ? AND chain_sequence > ?", tenantID, afterSequence).
+		Order("chain_sequence ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
 func (r *transactionRepository) Update(ctx context.Context, transaction *models.Transaction) error {
-	return r.db.WithContext(ctx).Save(transaction).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Neither the date it had nor the date it is moved to may be closed
+		if err := ensureStoredPeriodOpen(tx, transaction.ID, transaction.TenantID); err != nil {
+			return err
+		}
+		if err := ensurePeriodOpen(tx, transaction.TenantID, transaction.TransactionDate); err != nil {
+			return err
+		}
+		if err := tx.Save(transaction).Error; err != nil {
+			return err
+		}
+		return touchAccounts(tx, transaction.TenantID)
+	})
 }
 
 func (r *transactionRepository) Delete(ctx context.Context, id, tenantID uuid.UUID) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureStoredPeriodOpen(tx, id, tenantID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND tenant_id = ?", id, tenantID).
+			Delete(&models.Transaction{}).Error; err != nil {
+			return err
+		}
+		return touchAccounts(tx, tenantID)
+	})
+}
+
+// ensureStoredPeriodOpen fails with ErrPeriodClosed if the stored
+// transaction is dated in a closed period
+func ensureStoredPeriodOpen(tx *gorm.DB, id, tenantID uuid.UUID) error {
+	var dates []time.Time
+	err := tx.Model(&models.Transaction{}).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Delete(&models.Transaction{}).Error
+		Pluck("transaction_date", &dates).Error
+	if err != nil || len(dates) == 0 {
+		return err
+	}
+	return ensurePeriodOpen(tx, tenantID, dates[0])
 }
 
 func (r *transactionRepository) FindByID(ctx context.Context, id, tenantID uuid.UUID) (*models.Transaction, error) {
@@ -103,6 +373,20 @@ func (r *transactionRepository) FindByID(ctx context.Context, id, tenantID uuid.
 	return &transaction, nil
 }
 
+func (r *transactionRepository) FindSupportingDetail(ctx context.Context, id, tenantID uuid.UUID) (*models.TransactionSupportingDetail, error) {
+	var detail models.TransactionSupportingDetail
+	err := r.db.WithContext(ctx).
+		Where("transaction_id = ? AND tenant_id = ?", id, tenantID).
+		First(&detail).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSupportingDetailNotFound
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
 func (r *transactionRepository) FindByNumber(ctx context.Context, number string, tenantID uuid.UUID) (*models.Transaction, error) {
 	var transaction models.Transaction
 	err := r.db.WithContext(ctx).
@@ -115,6 +399,18 @@ func (r *transactionRepository) FindByNumber(ctx context.Context, number string,
 	return &transaction, nil
 }
 
+func (r *transactionRepository) FindBySource(ctx context.Context, sourceID, tenantID uuid.UUID) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.WithContext(ctx).
+		Preload("Lines").
+		Where("source_id = ? AND tenant_id = ? AND status <> ?", sourceID, tenantID, models.TransactionStatusVoid).
+		First(&transaction).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
 func (r *transactionRepository) FindAll(ctx context.Context, tenantID uuid.UUID, filter TransactionFilter) ([]models.Transaction, int64, error) {
 	var transactions []models.Transaction
 	var total int64
@@ -139,6 +435,12 @@ func (r *transactionRepository) FindAll(ctx context.Context, tenantID uuid.UUID,
 	if filter.StoreID != nil {
 		query = query.Where("store_id = ?", *filter.StoreID)
 	}
+	if filter.Tag != "" {
+		query = query.Where("tags @> ARRAY[?]::text[]", filter.Tag)
+	}
+	if filter.IsAdjustment != nil {
+		query = query.Where("is_adjustment = ?", *filter.IsAdjustment)
+	}
 	if filter.Search != "" {
 		searchPattern := "%" + filter.Search + "%"
 		query = query.Where("description ILIKE ? OR transaction_number ILIKE ?", searchPattern, searchPattern)
@@ -175,14 +477,46 @@ func (r *transactionRepository) FindAll(ctx context.Context, tenantID uuid.UUID,
 	return transactions, total, err
 }
 
+func (r *transactionRepository) UpdateTags(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Model(transaction).
+		Select("tags", "updated_by", "updated_at").
+		Updates(transaction).Error
+}
+
+func (r *transactionRepository) ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error) {
+	usage := []tags.Usage{}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT tag, COUNT(*) AS count
+		FROM transactions, unnest(tags) AS tag
+		WHERE tenant_id = ? AND deleted_at IS NULL
+		GROUP BY tag
+		ORDER BY count DESC, tag
+	`, tenantID).Scan(&usage).Error
+	return usage, err
+}
+
+func (r *transactionRepository) RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE transactions
+		SET tags = CASE WHEN ? = ANY(tags) THEN array_remove(tags, ?) ELSE array_replace(tags, ?, ?) END,
+			updated_at = NOW()
+		WHERE tenant_id = ? AND tags @> ARRAY[?]::text[]
+	`, to, from, from, to, tenantID, from)
+	return result.RowsAffected, result.Error
+}
+
+func (r *transactionRepository) DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE transactions
+		SET tags = array_remove(tags, ?), updated_at = NOW()
+		WHERE tenant_id = ? AND tags @> ARRAY[?]::text[]
+	`, tag, tenantID, tag)
+	return result.RowsAffected, result.Error
+}
+
 func (r *transactionRepository) GetNextNumber(ctx context.Context, tenantID uuid.UUID, txnType models.TransactionType) (string, error) {
-	var count int64
 	year := time.Now().Year()
 
-	r.db.WithContext(ctx).Model(&models.Transaction{}).
-		Where("tenant_id = ? AND transaction_type = ? AND EXTRACT(YEAR FROM transaction_date) = ?", tenantID, txnType, year).
-		Count(&count)
-
 	prefix := "TXN"
 	switch txnType {
 	case models.TransactionTypeSale:
@@ -201,7 +535,14 @@ func (r *transactionRepository) GetNextNumber(ctx context.Context, tenantID uuid
 		prefix = "TRF"
 	}
 
-	return fmt.Sprintf("%s-%d-%04d", prefix, year, count+1), nil
+	prefix = fmt.Sprintf("%s-%d", prefix, year)
+	next, err := database.NextNumber(ctx, r.db, tenantID, "transaction:"+prefix,
+		database.SeedFromExisting("transactions", "transaction_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%04d", prefix, next), nil
 }
 
 func (r *transactionRepository) VoidTransaction(ctx context.Context, id, tenantID uuid.UUID) error {
@@ -213,22 +554,59 @@ func (r *transactionRepository) VoidTransaction(ctx context.Context, id, tenantI
 			First(&transaction).Error; err != nil {
 			return err
 		}
+		if err := ensurePeriodOpen(tx, tenantID, transaction.TransactionDate); err != nil {
+			return err
+		}
 
-		// Reverse account balances
-		for _, line := range transaction.Lines {
-			balanceChange := line.CreditAmount - line.DebitAmount // Reverse
-			if err := tx.Model(&models.Account{}).
-				Where("id = ?", line.AccountID).
-				Update("current_balance", gorm.Expr("current_balance + ?", balanceChange)).Error; err != nil {
-				return err
-			}
+		if err := voidWithBalances(tx, &transaction); err != nil {
+			return err
 		}
 
-		// Update status to void
-		return tx.Model(&transaction).Update("status", models.TransactionStatusVoid).Error
+		// An inter-company entry and its mirror are voided together
+		if transaction.MirrorTransactionID == nil {
+			return nil
+		}
+		var mirror models.Transaction
+		err := tx.Preload("Lines").
+			Where("id = ? AND status = ?", *transaction.MirrorTransactionID, models.TransactionStatusPosted).
+			First(&mirror).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// The counterparty's chain is not locked here, so voids in opposite
+		// directions cannot deadlock
+		closed, err := closedOn(tx, mirror.TenantID, mirror.TransactionDate)
+		if err != nil {
+			return err
+		}
+		if closed != "" {
+			return ErrPeriodClosed
+		}
+		return voidWithBalances(tx, &mirror)
 	})
 }
 
+func voidWithBalances(tx *gorm.DB, transaction *models.Transaction) error {
+	// Reverse account balances
+	for _, line := range transaction.Lines {
+		balanceChange := line.CreditAmount - line.DebitAmount // Reverse
+		if err := tx.Model(&models.Account{}).
+			Where("id = ?", line.AccountID).
+			Update("current_balance", gorm.Expr("current_balance + ?", balanceChange)).Error; err != nil {
+			return err
+		}
+	}
+
+	// Update status to void
+	if err := tx.Model(transaction).Update("status", models.TransactionStatusVoid).Error; err != nil {
+		return err
+	}
+	return touchAccounts(tx, transaction.TenantID)
+}
+
 func (r *transactionRepository) GetDailySummary(ctx context.Context, tenantID uuid.UUID, date time.Time) (*DailySummary, error) {
 	summary := &DailySummary{Date: date}
 	dateStr := date.Format("2006-01-02")
@@ -282,3 +660,32 @@ func (r *transactionRepository) GetAccountBalance(ctx context.Context, accountID
 
 	return balance, err
 }
+
+// FindInterCompany returns transactions recorded by any of tenantIDs with
+// any of counterpartyTenantIDs, optionally within a date range
+func (r *transactionRepository) FindInterCompany(ctx context.Context, tenantIDs, counterpartyTenantIDs []uuid.UUID, fromDate, toDate string) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+
+	query := r.db.WithContext(ctx).
+		Where("tenant_id IN ? AND counterparty_tenant_id IN ?", tenantIDs, counterpartyTenantIDs)
+	if fromDate != "" {
+		query = query.Where("transaction_date >= ?", fromDate)
+	}
+	if toDate != "" {
+		query = query.Where("transaction_date <= ?", toDate)
+	}
+
+	err := query.Order("transaction_date, transaction_number").Find(&transactions).Error
+	return transactions, err
+}
+
+// FindMirrors returns the transactions with the given IDs, whichever tenant
+// recorded them. Used to look up the other side of inter-company entries.
+func (r *transactionRepository) FindMirrors(ctx context.Context, ids []uuid.UUID) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	if len(ids) == 0 {
+		return transactions, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&transactions).Error
+	return transactions, err
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrUnknownPostingType = errors.New("unknown posting type")
+	ErrUnsuitableAccount  = errors.New("account does not suit the posting type")
+)
+
+// PostingAccountMapping is the account a posting type resolves to
+type PostingAccountMapping struct {
+	PostingType models.PostingType `json:"posting_type"`
+	DefaultCode string             `json:"default_code"`
+	// Nil when the posting type is not mapped and the default chart
+	// account does not exist
+	Account   *models.Account `json:"account"`
+	IsDefault bool            `json:"is_default"`
+}
+
+// UpdateAccountMappingsRequest maps posting types to accounts. A null
+// account resets the posting type to its default chart account.
+type UpdateAccountMappingsRequest struct {
+	Mappings map[models.PostingType]*uuid.UUID `json:"mappings" binding:"required"`
+}
+
+// AccountMappingService resolves the accounts quick entries and automated
+// postings go to
+type AccountMappingService interface {
+	List(ctx context.Context, tenantID uuid.UUID) ([]PostingAccountMapping, error)
+	Update(ctx context.Context, tenantID, userID uuid.UUID, req UpdateAccountMappingsRequest) ([]PostingAccountMapping, error)
+
+	// Resolve returns the tenant's account for the posting type: the one
+	// mapped to it, else the account with its default code
+	Resolve(ctx context.Context, tenantID uuid.UUID, postingType models.PostingType) (*models.Account, error)
+}
+
+type accountMappingService struct {
+	mappingRepo repository.AccountMappingRepository
+	accountRepo repository.AccountRepository
+}
+
+// NewAccountMappingService creates a new account mapping service
+func NewAccountMappingService(mappingRepo repository.AccountMappingRepository, accountRepo repository.AccountRepository) AccountMappingService {
+	return &accountMappingService{
+		mappingRepo: mappingRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+func (s *accountMappingService) List(ctx context.Context, tenantID uuid.UUID) ([]PostingAccountMapping, error) {
+	mappings, err := s.mappingRepo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	mapped := make(map[models.PostingType]uuid.UUID, len(mappings))
+	for _, mapping := range mappings {
+		mapped[mapping.PostingType] = mapping.AccountID
+	}
+
+	result := make([]PostingAccountMapping, 0, len(models.PostingAccounts))
+	for _, posting := range models.PostingAccounts {
+		entry := PostingAccountMapping{PostingType: posting.Type, DefaultCode: posting.DefaultCode}
+		if accountID, ok := mapped[posting.Type]; ok {
+			entry.Account, _ = s.accountRepo.FindByID(ctx, accountID, tenantID)
+		} else {
+			entry.Account, _ = s.accountRepo.FindByCode(ctx, posting.DefaultCode, tenantID)
+			entry.IsDefault = true
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// Update validates each mapped account against the chart of accounts: it
+// must exist, be active and be of a kind the posting type accepts
+func (s *accountMappingService) Update(ctx context.Context, tenantID, userID uuid.UUID, req UpdateAccountMappingsRequest) ([]PostingAccountMapping, error) {
+	now := time.Now()
+	var mappings []models.AccountMapping
+	var reset []models.PostingType
+
+	for postingType, accountID := range req.Mappings {
+		posting, ok := models.LookupPostingAccount(postingType)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPostingType, postingType)
+		}
+		if accountID == nil {
+			reset = append(reset, postingType)
+			continue
+		}
+
+		account, err := s.accountRepo.FindByID(ctx, *accountID, tenantID)
+		if err != nil {
+			return nil, ErrAccountNotFound
+		}
+		if !account.IsActive || !posting.Accepts(account) {
+			return nil, fmt.Errorf("%w: %s cannot use %s %s", ErrUnsuitableAccount, postingType, account.Code, account.Name)
+		}
+
+		mappings = append(mappings, models.AccountMapping{
+			TenantID:    tenantID,
+			PostingType: postingType,
+			AccountID:   account.ID,
+			UpdatedBy:   &userID,
+			UpdatedAt:   now,
+		})
+	}
+
+	if err := s.mappingRepo.Update(ctx, tenantID, mappings, reset); err != nil {
+		return nil, err
+	}
+
+	return s.List(ctx, tenantID)
+}
+
+func (s *accountMappingService) Resolve(ctx context.Context, tenantID uuid.UUID, postingType models.PostingType) (*models.Account, error) {
+	posting, ok := models.LookupPostingAccount(postingType)
+	if !ok {
+		return nil, ErrUnknownPostingType
+	}
+
+	mapping, err := s.mappingRepo.Find(ctx, tenantID, postingType)
+	if err != nil {
+		return nil, err
+	}
+
+	var account *models.Account
+	if mapping != nil {
+		account, err = s.accountRepo.FindByID(ctx, mapping.AccountID, tenantID)
+	} else {
+		account, err = s.accountRepo.FindByCode(ctx, posting.DefaultCode, tenantID)
+	}
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// cashOrBank is the posting type money paid or received in the payment mode
+// goes through: cash for cash, else the bank
+func cashOrBank(paymentMode string) models.PostingType {
+	if paymentMode == string(models.PaymentModeCash) {
+		return models.PostingTypeCash
+	}
+	return models.PostingTypeBank
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrAccountReconciliationNotFound     = errors.New("account reconciliation not found")
+	ErrAccountReconciliationExists       = errors.New("account already has a reconciliation for this period")
+	ErrAccountNotReconcilable            = errors.New("only balance sheet accounts other than bank and cash accounts are reconciled here")
+	ErrAccountReconciliationCertified    = errors.New("account reconciliation is certified; reopen it to make changes")
+	ErrAccountReconciliationNotCertified = errors.New("account reconciliation is not certified")
+	ErrAccountReconciliationUnbalanced   = errors.New("open items do not explain the difference from the external balance")
+	ErrPreviousReconciliationOpen        = errors.New("certify the account's reconciliation for the previous period first")
+	ErrLaterReconciliationExists         = errors.New("account has a reconciliation for a later period")
+	ErrInvalidReconciliationPeriod       = errors.New("period must be a month as YYYY-MM, not in the future")
+)
+
+// CreateAccountReconciliationRequest opens a reconciliation workspace for an
+// account and month
+type CreateAccountReconciliationRequest struct {
+	TenantID        uuid.UUID `json:"-"`
+	UserID          uuid.UUID `json:"-"`
+	AccountID       uuid.UUID `json:"account_id" binding:"required"`
+	Period          string    `json:"period" binding:"required"` // YYYY-MM
+	ExternalBalance float64   `json:"external_balance"`
+	ExternalSource  string    `json:"external_source" binding:"max=200"`
+	Notes           string    `json:"notes"`
+}
+
+// UpdateAccountReconciliationRequest sets the balance the outside source
+// reports
+type UpdateAccountReconciliationRequest struct {
+	ExternalBalance float64 `json:"external_balance"`
+	ExternalSource  string  `json:"external_source" binding:"max=200"`
+	Notes           string  `json:"notes"`
+}
+
+// MatchReconciliationItemsRequest ticks off items the outside source agrees
+// with, or unticks them
+type MatchReconciliationItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"item_ids" binding:"required,min=1"`
+	Matched bool        `json:"matched"`
+	Note    string      `json:"note"`
+}
+
+// AccountReconciliationService reconciles balance sheet accounts other than
+// banks, such as GST and TDS payable and loans, with an outside source at
+// month end. A workspace holds the account's ledger entries for the month
+// and the open items of the previous month; entries the source agrees
+// with are ticked off, and once those left open explain the difference the
+// reconciliation is certified for the close.
+type AccountReconciliationService interface {
+	Create(ctx context.Context, req CreateAccountReconciliationRequest) (*models.AccountReconciliation, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.AccountReconciliationFilters) ([]models.AccountReconciliation, error)
+	Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateAccountReconciliationRequest) (*models.AccountReconciliation, error)
+	// Refresh picks up entries posted or voided since the workspace was
+	// opened and recomputes the book balance
+	Refresh(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error)
+	MatchItems(ctx context.Context, tenantID, userID, id uuid.UUID, req MatchReconciliationItemsRequest) (*models.AccountReconciliation, error)
+
+	// Certify signs the reconciliation off once it is refreshed and its
+	// open items explain the whole difference
+	Certify(ctx context.Context, tenantID, userID, id uuid.UUID) (*models.AccountReconciliation, error)
+	// Reopen withdraws the certification, unless the next period's
+	// reconciliation has carried its open items forward
+	Reopen(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error)
+}
+
+type accountReconciliationService struct {
+	reconciliationRepo repository.AccountReconciliationRepository
+	accountRepo        repository.AccountRepository
+	transactionRepo    repository.TransactionRepository
+	bankRepo           repository.BankRepository
+}
+
+// NewAccountReconciliationService creates a new account reconciliation
+// service
+func NewAccountReconciliationService(
+	reconciliationRepo repository.AccountReconciliationRepository,
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+	bankRepo repository.BankRepository,
+) AccountReconciliationService {
+	return &accountReconciliationService{
+		reconciliationRepo: reconciliationRepo,
+		accountRepo:        accountRepo,
+		transactionRepo:    transactionRepo,
+		bankRepo:           bankRepo,
+	}
+}
+
+func (s *accountReconciliationService) Create(ctx context.Context, req CreateAccountReconciliationRequest) (*models.AccountReconciliation, error) {
+	start, err := time.Parse(models.PeriodFormat, req.Period)
+	if err != nil || start.After(time.Now()) {
+		return nil, ErrInvalidReconciliationPeriod
+	}
+	end := start.AddDate(0, 1, -1)
+
+	account, err := s.accountRepo.FindByID(ctx, req.AccountID, req.TenantID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	if err := s.checkReconcilable(ctx, account); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.reconciliationRepo.Exists(ctx, req.TenantID, account.ID, req.Period)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAccountReconciliationExists
+	}
+
+	reconciliation := &models.AccountReconciliation{
+		ID:              uuid.New(),
+		TenantID:        req.TenantID,
+		AccountID:       account.ID,
+		Period:          req.Period,
+		StartDate:       start,
+		EndDate:         end,
+		ExternalBalance: math.Round(req.ExternalBalance*100) / 100,
+		ExternalSource:  strings.TrimSpace(req.ExternalSource),
+		Status:          models.AccountReconciliationOpen,
+		Notes:           req.Notes,
+		CreatedBy:       req.UserID,
+	}
+
+	// Open items of the previous period are carried forward
+	previous, err := s.reconciliationRepo.Previous(ctx, req.TenantID, account.ID, req.Period)
+	switch {
+	case errors.Is(err, repository.ErrAccountReconciliationNotFound):
+	case err != nil:
+		return nil, err
+	case !previous.IsCertified():
+		return nil, ErrPreviousReconciliationOpen
+	default:
+		lineIDs := []uuid.UUID{}
+		for _, item := range previous.Items {
+			if !item.Matched {
+				lineIDs = append(lineIDs, item.TransactionLineID)
+			}
+		}
+		lines, err := s.reconciliationRepo.PostedLines(ctx, req.TenantID, account.ID, start, end, lineIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			item := reconciliationItem(reconciliation, account, line)
+			item.CarriedFromID = &previous.ID
+			reconciliation.Items = append(reconciliation.Items, item)
+		}
+	}
+
+	lines, err := s.reconciliationRepo.PostedLines(ctx, req.TenantID, account.ID, start, end, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		reconciliation.Items = append(reconciliation.Items, reconciliationItem(reconciliation, account, line))
+	}
+
+	reconciliation.Account = account
+	if err := s.computeBalances(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	if err := s.reconciliationRepo.Create(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, req.TenantID, reconciliation.ID)
+}
+
+func (s *accountReconciliationService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.reconciliationRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAccountReconciliationNotFound) {
+			return nil, ErrAccountReconciliationNotFound
+		}
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+func (s *accountReconciliationService) List(ctx context.Context, tenantID uuid.UUID, filters repository.AccountReconciliationFilters) ([]models.AccountReconciliation, error) {
+	return s.reconciliationRepo.List(ctx, tenantID, filters)
+}
+
+func (s *accountReconciliationService) Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateAccountReconciliationRequest) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.open(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliation.ExternalBalance = math.Round(req.ExternalBalance*100) / 100
+	reconciliation.ExternalSource = strings.TrimSpace(req.ExternalSource)
+	reconciliation.Notes = req.Notes
+	if err := s.computeBalances(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	if err := s.reconciliationRepo.Save(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+
+	return reconciliation, nil
+}
+
+func (s *accountReconciliationService) Refresh(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.open(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, tenantID, id)
+}
+
+func (s *accountReconciliationService) MatchItems(ctx context.Context, tenantID, userID, id uuid.UUID, req MatchReconciliationItemsRequest) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.open(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.reconciliationRepo.MatchItems(ctx, reconciliation.ID, req.ItemIDs, req.Matched, userID, strings.TrimSpace(req.Note)); err != nil {
+		return nil, err
+	}
+
+	reconciliation, err = s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.computeBalances(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	if err := s.reconciliationRepo.Save(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+func (s *accountReconciliationService) Certify(ctx context.Context, tenantID, userID, id uuid.UUID) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.open(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Certify against the ledger as it stands now
+	if err := s.refresh(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	reconciliation, err = s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if reconciliation.Difference != 0 {
+		return nil, ErrAccountReconciliationUnbalanced
+	}
+
+	now := time.Now()
+	reconciliation.Status = models.AccountReconciliationCertified
+	reconciliation.CertifiedBy = &userID
+	reconciliation.CertifiedAt = &now
+	if err := s.reconciliationRepo.Save(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+
+	return reconciliation, nil
+}
+
+func (s *accountReconciliationService) Reopen(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !reconciliation.IsCertified() {
+		return nil, ErrAccountReconciliationNotCertified
+	}
+
+	later, err := s.reconciliationRepo.HasLater(ctx, tenantID, reconciliation.AccountID, reconciliation.Period)
+	if err != nil {
+		return nil, err
+	}
+	if later {
+		return nil, ErrLaterReconciliationExists
+	}
+
+	reconciliation.Status = models.AccountReconciliationOpen
+	reconciliation.CertifiedBy = nil
+	reconciliation.CertifiedAt = nil
+	if err := s.reconciliationRepo.Save(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+
+	return reconciliation, nil
+}
+
+// open loads a reconciliation that can still be worked on
+func (s *accountReconciliationService) open(ctx context.Context, tenantID, id uuid.UUID) (*models.AccountReconciliation, error) {
+	reconciliation, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if reconciliation.IsCertified() {
+		return nil, ErrAccountReconciliationCertified
+	}
+	return reconciliation, nil
+}
+
+// refresh brings the items in line with the ledger: entries posted in the
+// period since are added and those of voided transactions dropped, ticked
+// or not. Carried items stay as long as their transactions are posted.
+func (s *accountReconciliationService) refresh(ctx context.Context, reconciliation *models.AccountReconciliation) error {
+	account := reconciliation.Account
+	if account == nil {
+		var err error
+		if account, err = s.accountRepo.FindByID(ctx, reconciliation.AccountID, reconciliation.TenantID); err != nil {
+			return ErrAccountNotFound
+		}
+	}
+
+	lines, err := s.reconciliationRepo.PostedLines(ctx, reconciliation.TenantID, account.ID, reconciliation.StartDate, reconciliation.EndDate, nil)
+	if err != nil {
+		return err
+	}
+	carriedIDs := []uuid.UUID{}
+	for _, item := range reconciliation.Items {
+		if item.CarriedFromID != nil {
+			carriedIDs = append(carriedIDs, item.TransactionLineID)
+		}
+	}
+	carried, err := s.reconciliationRepo.PostedLines(ctx, reconciliation.TenantID, account.ID, reconciliation.StartDate, reconciliation.EndDate, carriedIDs)
+	if err != nil {
+		return err
+	}
+
+	posted := make(map[uuid.UUID]bool, len(lines)+len(carried))
+	for _, line := range append(lines, carried...) {
+		posted[line.LineID] = true
+	}
+	have := make(map[uuid.UUID]bool, len(reconciliation.Items))
+	var stale []uuid.UUID
+	for _, item := range reconciliation.Items {
+		have[item.TransactionLineID] = true
+		if !posted[item.TransactionLineID] {
+			stale = append(stale, item.ID)
+		}
+	}
+	var added []models.AccountReconciliationItem
+	for _, line := range lines {
+		if !have[line.LineID] {
+			added = append(added, reconciliationItem(reconciliation, account, line))
+		}
+	}
+
+	if err := s.reconciliationRepo.RemoveItems(ctx, reconciliation.ID, stale); err != nil {
+		return err
+	}
+	if err := s.reconciliationRepo.AddItems(ctx, added); err != nil {
+		return err
+	}
+
+	reconciliation, err = s.Get(ctx, reconciliation.TenantID, reconciliation.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.computeBalances(ctx, reconciliation); err != nil {
+		return err
+	}
+	return s.reconciliationRepo.Save(ctx, reconciliation)
+}
+
+// computeBalances works out the book balance at the end of the period, the
+// open items and the difference they leave unexplained
+func (s *accountReconciliationService) computeBalances(ctx context.Context, reconciliation *models.AccountReconciliation) error {
+	account := reconciliation.Account
+	if account == nil {
+		var err error
+		if account, err = s.accountRepo.FindByID(ctx, reconciliation.AccountID, reconciliation.TenantID); err != nil {
+			return ErrAccountNotFound
+		}
+	}
+
+	movement, err := s.transactionRepo.GetAccountBalance(ctx, account.ID, reconciliation.TenantID, reconciliation.EndDate)
+	if err != nil {
+		return err
+	}
+	balance := account.OpeningBalance + movement
+	if account.IsCreditNature() {
+		balance = -balance
+	}
+
+	var open float64
+	for _, item := range reconciliation.Items {
+		if !item.Matched {
+			open += item.Amount
+		}
+	}
+
+	reconciliation.BookBalance = math.Round(balance*100) / 100
+	reconciliation.OpenItems = math.Round(open*100) / 100
+	reconciliation.Difference = math.Round((reconciliation.BookBalance-reconciliation.OpenItems-reconciliation.ExternalBalance)*100) / 100
+	return nil
+}
+
+// checkReconcilable refuses accounts reconciled elsewhere or not carried to
+// the balance sheet
+func (s *accountReconciliationService) checkReconcilable(ctx context.Context, account *models.Account) error {
+	switch {
+	case account.Type == models.AccountTypeIncome || account.Type == models.AccountTypeExpense:
+		return ErrAccountNotReconcilable
+	case account.SubType == models.AccountSubTypeBank || account.SubType == models.AccountSubTypeCash:
+		return ErrAccountNotReconcilable
+	}
+
+	bankAccounts, err := s.bankRepo.GetBankAccountsByTenant(ctx, account.TenantID)
+	if err != nil {
+		return err
+	}
+	for _, bankAccount := range bankAccounts {
+		if bankAccount.AccountID != nil && *bankAccount.AccountID == account.ID {
+			return ErrAccountNotReconcilable
+		}
+	}
+	return nil
+}
+
+// reconciliationItem makes an item of a ledger line, its amount signed in
+// the account's natural direction
+func reconciliationItem(reconciliation *models.AccountReconciliation, account *models.Account, line repository.ReconcilableLine) models.AccountReconciliationItem {
+	amount := line.DebitAmount - line.CreditAmount
+	if account.IsCreditNature() {
+		amount = -amount
+	}
+	return models.AccountReconciliationItem{
+		ReconciliationID:  reconciliation.ID,
+		TransactionLineID: line.LineID,
+		TransactionID:     line.TransactionID,
+		TransactionNumber: line.TransactionNumber,
+		TransactionDate:   line.TransactionDate,
+		Description:       line.Description,
+		DebitAmount:       line.DebitAmount,
+		CreditAmount:      line.CreditAmount,
+		Amount:            math.Round(amount*100) / 100,
+	}
+}
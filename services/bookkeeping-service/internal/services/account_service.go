@@ -3,16 +3,19 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/statement"
 )
 
 var (
 	ErrAccountExists     = errors.New("account with this code already exists")
 	ErrSystemAccount     = errors.New("cannot modify system account")
 	ErrAccountHasBalance = errors.New("account has balance, cannot delete")
+	ErrInvalidLedgerDate = errors.New("ledger dates must be in YYYY-MM-DD format")
 )
 
 // AccountService defines the interface for account business logic
@@ -25,6 +28,50 @@ type AccountService interface {
 	GetChartOfAccounts(ctx context.Context, tenantID uuid.UUID) ([]models.Account, error)
 	GetAccountsByType(ctx context.Context, tenantID uuid.UUID, accountType models.AccountType) ([]models.Account, error)
 	InitializeDefaultAccounts(ctx context.Context, tenantID uuid.UUID) error
+	GetAccountLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) (*AccountLedger, error)
+}
+
+// AccountLedger is an account's vouchers over a period with the running
+// balance. Balances are debit positive, so credit balances are negative.
+type AccountLedger struct {
+	Account        models.Account                  `json:"account"`
+	FromDate       string                          `json:"from_date,omitempty"`
+	ToDate         string                          `json:"to_date,omitempty"`
+	OpeningBalance float64                         `json:"opening_balance"`
+	Entries        []repository.AccountLedgerEntry `json:"entries"`
+	ClosingBalance float64                         `json:"closing_balance"`
+	TotalDebit     float64                         `json:"total_debit"`
+	TotalCredit    float64                         `json:"total_credit"`
+}
+
+// Statement returns the ledger in the columnar statement layout for PDF
+// and XLSX export
+func (l *AccountLedger) Statement() *statement.Statement {
+	name := l.Account.Name
+	if l.Account.Code != "" {
+		name = l.Account.Code + " - " + name
+	}
+	st := &statement.Statement{
+		Name:           name,
+		OpeningBalance: l.OpeningBalance,
+		Rows:           make([]statement.Row, len(l.Entries)),
+	}
+	st.From, _ = time.Parse("2006-01-02", l.FromDate)
+	st.To, _ = time.Parse("2006-01-02", l.ToDate)
+
+	for i, entry := range l.Entries {
+		date, _ := time.Parse("2006-01-02", entry.Date)
+		st.Rows[i] = statement.Row{
+			Date:          date,
+			Particulars:   entry.Particulars,
+			VoucherType:   statement.VoucherType(entry.Type),
+			VoucherNumber: entry.Reference,
+			Debit:         entry.Debit,
+			Credit:        entry.Credit,
+			Balance:       entry.Balance,
+		}
+	}
+	return st
 }
 
 // CreateAccountRequest represents a request to create an account
@@ -159,3 +206,52 @@ func (s *accountService) GetAccountsByType(ctx context.Context, tenantID uuid.UU
 func (s *accountService) InitializeDefaultAccounts(ctx context.Context, tenantID uuid.UUID) error {
 	return s.accountRepo.CreateDefaultAccounts(ctx, tenantID)
 }
+
+func (s *accountService) GetAccountLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) (*AccountLedger, error) {
+	for _, date := range []string{fromDate, toDate} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return nil, ErrInvalidLedgerDate
+		}
+	}
+
+	account, err := s.accountRepo.FindByID(ctx, id, tenantID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	// Opening balances are entered on the account's normal side
+	openingBalance := account.OpeningBalance
+	if account.IsCreditNature() {
+		openingBalance = -openingBalance
+	}
+	if fromDate != "" {
+		movement, err := s.accountRepo.GetLedgerMovement(ctx, id, tenantID, fromDate)
+		if err != nil {
+			return nil, err
+		}
+		openingBalance += movement
+	}
+
+	entries, err := s.accountRepo.GetLedger(ctx, id, tenantID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &AccountLedger{
+		Account:        *account,
+		FromDate:       fromDate,
+		ToDate:         toDate,
+		OpeningBalance: openingBalance,
+		Entries:        entries,
+	}
+	balance := openingBalance
+	for i := range entries {
+		ledger.TotalDebit += entries[i].Debit
+		ledger.TotalCredit += entries[i].Credit
+		balance += entries[i].Debit - entries[i].Credit
+		entries[i].Balance = balance
+	}
+	ledger.ClosingBalance = balance
+
+	return ledger, nil
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/webhook"
+)
+
+var (
+	ErrBankFeedNotFound    = errors.New("bank account has no feed")
+	ErrBankFeedUnavailable = errors.New("no bank feed provider is configured")
+	ErrBankFeedConnected   = errors.New("bank account already has a live feed")
+	ErrBankFeedCardAccount = errors.New("card accounts cannot be connected to a bank feed")
+	ErrBankFeedNotActive   = errors.New("bank feed is not active")
+	ErrBankFeedNotPaused   = errors.New("bank feed is not paused")
+	ErrInvalidBankFeed     = errors.New("customer handle is required and sync_from must be a past date in YYYY-MM-DD format")
+)
+
+const (
+	// JobSyncBankFeed is the queue job that pulls a connection's new lines
+	JobSyncBankFeed = "bank_feed.sync"
+	// JobSyncBankFeeds is the scheduled job that queues a sync of every
+	// active connection
+	JobSyncBankFeeds = "bank_feeds.sync"
+)
+
+const (
+	// bankFeedDefaultHistoryDays is how far back a connection pulls when
+	// no start date is given
+	bankFeedDefaultHistoryDays = 90
+	// maxBankFeedPages bounds a single sync; the next one continues from
+	// its cursor
+	maxBankFeedPages = 50
+)
+
+// SyncBankFeedPayload is the payload of a JobSyncBankFeed job
+type SyncBankFeedPayload struct {
+	ConnectionID uuid.UUID `json:"connection_id"`
+}
+
+// ConnectBankFeedRequest asks for a bank account to be linked to the feed
+type ConnectBankFeedRequest struct {
+	CustomerHandle string `json:"customer_handle"`
+	SyncFrom       string `json:"sync_from"` // YYYY-MM-DD, 90 days back when empty
+	RedirectURL    string `json:"redirect_url"`
+}
+
+// bankFeedCallback is the body of a bank feed provider's callback
+type bankFeedCallback struct {
+	Type      string `json:"type"` // consent.* on consent changes, data.ready once new lines can be fetched
+	ConsentID string `json:"consent_id"`
+	Status    string `json:"status"`
+}
+
+// BankFeedService pulls bank statement lines from a feed provider, such as
+// an account aggregator, into bank accounts. Lines are imported like an
+// uploaded statement: those the account already has are skipped and the
+// bank rules post charges and interest.
+type BankFeedService interface {
+	// Connect asks the account holder's consent to share the account. The
+	// connection is pending until they approve it at its consent URL.
+	Connect(ctx context.Context, tenantID, userID, bankAccountID uuid.UUID, req *ConnectBankFeedRequest) (*models.BankFeedConnection, error)
+	Get(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error)
+	// Refresh queues a sync of the account now
+	Refresh(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error)
+	Pause(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error)
+	Resume(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error)
+	// Disconnect revokes the consent. Lines already pulled are kept.
+	Disconnect(ctx context.Context, tenantID, bankAccountID uuid.UUID) error
+
+	// Sync pulls a connection's lines after its cursor, activating a
+	// pending connection once its consent is approved
+	Sync(ctx context.Context, connectionID uuid.UUID) error
+	// SyncAll queues a sync of every active and pending connection
+	SyncAll(ctx context.Context) error
+	// HandleCallback processes a provider callback: consent changes update
+	// the connection and new data queues a sync
+	HandleCallback(ctx context.Context, event *webhook.InboundEvent) error
+}
+
+type bankFeedService struct {
+	feedRepo    repository.BankFeedRepository
+	bankRepo    repository.BankRepository
+	ruleService BankRuleService
+	provider    clients.BankFeedProvider // Nil when none is configured
+	queue       *jobs.Queue
+}
+
+// NewBankFeedService creates a new bank feed service
+func NewBankFeedService(feedRepo repository.BankFeedRepository, bankRepo repository.BankRepository, ruleService BankRuleService, provider clients.BankFeedProvider, queue *jobs.Queue) BankFeedService {
+	return &bankFeedService{
+		feedRepo:    feedRepo,
+		bankRepo:    bankRepo,
+		ruleService: ruleService,
+		provider:    provider,
+		queue:       queue,
+	}
+}
+
+func (s *bankFeedService) Connect(ctx context.Context, tenantID, userID, bankAccountID uuid.UUID, req *ConnectBankFeedRequest) (*models.BankFeedConnection, error) {
+	if s.provider == nil {
+		return nil, ErrBankFeedUnavailable
+	}
+
+	req.CustomerHandle = strings.TrimSpace(req.CustomerHandle)
+	if req.CustomerHandle == "" {
+		return nil, ErrInvalidBankFeed
+	}
+	today := truncateToDay(time.Now())
+	syncFrom := today.AddDate(0, 0, -bankFeedDefaultHistoryDays)
+	if req.SyncFrom != "" {
+		date, err := time.Parse("2006-01-02", req.SyncFrom)
+		if err != nil || date.After(today) {
+			return nil, ErrInvalidBankFeed
+		}
+		syncFrom = date
+	}
+
+	account, err := s.bankRepo.GetBankAccountByID(ctx, bankAccountID)
+	if err != nil || account.TenantID != tenantID {
+		return nil, ErrBankAccountNotFound
+	}
+	if account.IsCardFeed() {
+		return nil, ErrBankFeedCardAccount
+	}
+
+	connection, err := s.feedRepo.GetByBankAccount(ctx, tenantID, bankAccountID)
+	switch {
+	case errors.Is(err, repository.ErrBankFeedNotFound):
+		connection = &models.BankFeedConnection{TenantID: tenantID, BankAccountID: bankAccountID}
+	case err != nil:
+		return nil, err
+	case connection.IsLive():
+		return nil, ErrBankFeedConnected
+	}
+
+	consent, err := s.provider.Link(ctx, clients.BankFeedLinkRequest{
+		CustomerHandle: req.CustomerHandle,
+		Reference:      bankAccountID.String(),
+		From:           syncFrom,
+		RedirectURL:    req.RedirectURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request consent: %w", err)
+	}
+
+	// A connection made again starts over from its new consent
+	*connection = models.BankFeedConnection{
+		ID:             connection.ID,
+		TenantID:       tenantID,
+		BankAccountID:  bankAccountID,
+		Provider:       s.provider.Name(),
+		CustomerHandle: req.CustomerHandle,
+		ConsentID:      consent.ID,
+		ConsentURL:     consent.URL,
+		Status:         models.BankFeedPending,
+		SyncFrom:       syncFrom,
+		CreatedBy:      userID,
+		CreatedAt:      connection.CreatedAt,
+	}
+	applyConsent(connection, consent)
+	if err := s.feedRepo.Save(ctx, connection); err != nil {
+		return nil, err
+	}
+
+	if connection.Status == models.BankFeedActive {
+		if err := s.enqueueSync(ctx, connection); err != nil {
+			return nil, err
+		}
+	}
+	return connection, nil
+}
+
+func (s *bankFeedService) Get(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error) {
+	connection, err := s.feedRepo.GetByBankAccount(ctx, tenantID, bankAccountID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBankFeedNotFound) {
+			return nil, ErrBankFeedNotFound
+		}
+		return nil, err
+	}
+	return connection, nil
+}
+
+func (s *bankFeedService) Refresh(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error) {
+	connection, err := s.Get(ctx, tenantID, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	// Pending connections are refreshed too, to pick up an approval whose
+	// callback went missing
+	if connection.Status != models.BankFeedActive && connection.Status != models.BankFeedPending {
+		return nil, ErrBankFeedNotActive
+	}
+	if err := s.enqueueSync(ctx, connection); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+func (s *bankFeedService) Pause(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error) {
+	connection, err := s.Get(ctx, tenantID, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if connection.Status != models.BankFeedActive {
+		return nil, ErrBankFeedNotActive
+	}
+	connection.Status = models.BankFeedPaused
+	if err := s.feedRepo.Save(ctx, connection); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+func (s *bankFeedService) Resume(ctx context.Context, tenantID, bankAccountID uuid.UUID) (*models.BankFeedConnection, error) {
+	connection, err := s.Get(ctx, tenantID, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if connection.Status != models.BankFeedPaused {
+		return nil, ErrBankFeedNotPaused
+	}
+	connection.Status = models.BankFeedActive
+	if err := s.feedRepo.Save(ctx, connection); err != nil {
+		return nil, err
+	}
+	// Catch up on what arrived while paused
+	if err := s.enqueueSync(ctx, connection); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+func (s *bankFeedService) Disconnect(ctx context.Context, tenantID, bankAccountID uuid.UUID) error {
+	connection, err := s.Get(ctx, tenantID, bankAccountID)
+	if err != nil {
+		return err
+	}
+	if !connection.IsLive() {
+		return nil
+	}
+
+	if s.provider != nil && connection.ConsentID != "" {
+		if err := s.provider.Revoke(ctx, connection.ConsentID); err != nil {
+			return fmt.Errorf("failed to revoke consent: %w", err)
+		}
+	}
+	connection.Status = models.BankFeedRevoked
+	return s.feedRepo.Save(ctx, connection)
+}
+
+func (s *bankFeedService) Sync(ctx context.Context, connectionID uuid.UUID) error {
+	if s.provider == nil {
+		return ErrBankFeedUnavailable
+	}
+	connection, err := s.feedRepo.GetByID(ctx, connectionID)
+	if err != nil {
+		return err
+	}
+
+	if connection.Status == models.BankFeedPending {
+		consent, err := s.provider.Consent(ctx, connection.ConsentID)
+		if err != nil {
+			return fmt.Errorf("failed to check consent: %w", err)
+		}
+		applyConsent(connection, consent)
+		if connection.Status != models.BankFeedPending {
+			if err := s.feedRepo.Save(ctx, connection); err != nil {
+				return err
+			}
+		}
+	}
+	// Paused, revoked and lapsed connections are left alone; a sync queued
+	// before the change finds nothing to do
+	if connection.Status != models.BankFeedActive {
+		return nil
+	}
+
+	account, err := s.bankRepo.GetBankAccountByID(ctx, connection.BankAccountID)
+	if err != nil {
+		return err
+	}
+
+	batchID := uuid.New()
+	imported, duplicates := 0, 0
+	var syncErr error
+	for page := 0; page < maxBankFeedPages; page++ {
+		feed, err := s.provider.Transactions(ctx, connection.ConsentID, connection.AccountRef, connection.Cursor)
+		if errors.Is(err, clients.ErrConsentInactive) {
+			connection.Status = models.BankFeedExpired
+			break
+		}
+		if err != nil {
+			syncErr = err
+			break
+		}
+
+		n, d, err := s.importPage(ctx, connection, account, batchID, feed.Transactions)
+		if err != nil {
+			syncErr = err
+			break
+		}
+		imported += n
+		duplicates += d
+
+		// The cursor is kept page by page, so a failed sync resumes after
+		// the lines it already imported
+		connection.Cursor = feed.Cursor
+		if err := s.feedRepo.Save(ctx, connection); err != nil {
+			return err
+		}
+		if !feed.HasMore {
+			break
+		}
+	}
+
+	now := time.Now()
+	connection.LastSyncedAt = &now
+	connection.LastImported = imported
+	connection.LastDuplicates = duplicates
+	connection.LastError = ""
+	if syncErr != nil {
+		connection.LastError = syncErr.Error()
+	}
+	if err := s.feedRepo.Save(ctx, connection); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// importPage adds a page of the feed to the account. Lines an earlier sync
+// pulled, or an uploaded statement already has, are counted as duplicates.
+func (s *bankFeedService) importPage(ctx context.Context, connection *models.BankFeedConnection, account *models.BankAccount, batchID uuid.UUID, lines []clients.BankFeedTransaction) (int, int, error) {
+	transactions := make([]models.BankTransaction, 0, len(lines))
+	for _, line := range lines {
+		date := truncateToDay(line.Date)
+		if date.Before(connection.SyncFrom) {
+			continue
+		}
+		tx := models.BankTransaction{
+			BankAccountID:   account.ID,
+			TenantID:        account.TenantID,
+			TransactionDate: date,
+			Description:     line.Narration,
+			Reference:       line.Reference,
+			Balance:         line.Balance,
+			ImportBatchID:   &batchID,
+			ExternalID:      line.ID,
+		}
+		if strings.EqualFold(line.Type, "DEBIT") {
+			tx.DebitAmount = line.Amount
+		} else {
+			tx.CreditAmount = line.Amount
+		}
+		tx.DedupeHash = tx.ComputeDedupeHash()
+		transactions = append(transactions, tx)
+	}
+	if len(transactions) == 0 {
+		return 0, 0, nil
+	}
+
+	externalIDs := make([]string, len(transactions))
+	hashes := make([]string, len(transactions))
+	for i, tx := range transactions {
+		externalIDs[i] = tx.ExternalID
+		hashes[i] = tx.DedupeHash
+	}
+	synced, uploaded, err := s.feedRepo.ExistingFeedLines(ctx, account.ID, externalIDs, hashes)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fresh := transactions[:0]
+	for _, tx := range transactions {
+		if !synced[tx.ExternalID] && !uploaded[tx.DedupeHash] {
+			fresh = append(fresh, tx)
+		}
+	}
+	duplicates := len(transactions) - len(fresh)
+	if len(fresh) == 0 {
+		return 0, duplicates, nil
+	}
+
+	if err := s.bankRepo.CreateBankTransactions(ctx, fresh); err != nil {
+		return 0, 0, err
+	}
+	if _, err := s.ruleService.Recognize(ctx, account, fresh, connection.CreatedBy); err != nil {
+		return len(fresh), duplicates, err
+	}
+	return len(fresh), duplicates, nil
+}
+
+func (s *bankFeedService) SyncAll(ctx context.Context) error {
+	if s.provider == nil {
+		return nil
+	}
+	connections, err := s.feedRepo.ListSyncable(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range connections {
+		if err := s.enqueueSync(ctx, &connections[i]); err != nil {
+			log.Printf("Failed to queue bank feed sync for bank account %s: %v", connections[i].BankAccountID, err)
+		}
+	}
+	return nil
+}
+
+func (s *bankFeedService) HandleCallback(ctx context.Context, event *webhook.InboundEvent) error {
+	if s.provider == nil {
+		return ErrBankFeedUnavailable
+	}
+
+	var callback bankFeedCallback
+	if err := json.Unmarshal([]byte(event.Payload), &callback); err != nil {
+		return fmt.Errorf("invalid bank feed callback: %w", err)
+	}
+	if callback.ConsentID == "" {
+		return errors.New("bank feed callback names no consent")
+	}
+
+	connection, err := s.feedRepo.GetByConsentID(ctx, callback.ConsentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBankFeedNotFound) {
+			return fmt.Errorf("unknown consent %s", callback.ConsentID)
+		}
+		return err
+	}
+	if !connection.IsLive() {
+		return nil
+	}
+
+	if strings.HasPrefix(callback.Type, "consent.") {
+		// The callback only says the consent changed; the provider's own
+		// record has the accounts shared under it
+		consent, err := s.provider.Consent(ctx, connection.ConsentID)
+		if err != nil {
+			return fmt.Errorf("failed to check consent: %w", err)
+		}
+		applyConsent(connection, consent)
+		if err := s.feedRepo.Save(ctx, connection); err != nil {
+			return err
+		}
+	}
+
+	if connection.Status != models.BankFeedActive {
+		return nil
+	}
+	return s.enqueueSync(ctx, connection)
+}
+
+func (s *bankFeedService) enqueueSync(ctx context.Context, connection *models.BankFeedConnection) error {
+	_, err := s.queue.Enqueue(ctx, JobSyncBankFeed, SyncBankFeedPayload{ConnectionID: connection.ID}, jobs.EnqueueOptions{
+		TenantID: &connection.TenantID,
+	})
+	return err
+}
+
+// applyConsent brings the connection's status in line with its consent. An
+// approved consent links the connection to the first account shared under
+// it; one shared with no account cannot be synced.
+func applyConsent(connection *models.BankFeedConnection, consent *clients.BankFeedConsent) {
+	if consent.ExpiresAt != nil {
+		connection.ConsentExpiresAt = consent.ExpiresAt
+	}
+
+	switch consent.Status {
+	case clients.ConsentActive:
+		if connection.AccountRef == "" {
+			if len(consent.Accounts) == 0 {
+				connection.Status = models.BankFeedFailed
+				connection.LastError = "no account was shared under the consent"
+				return
+			}
+			connection.AccountRef = consent.Accounts[0].Ref
+			connection.MaskedAccount = consent.Accounts[0].MaskedAccount
+		}
+		// Accounts paused by the tenant stay paused
+		if connection.Status != models.BankFeedPaused {
+			connection.Status = models.BankFeedActive
+		}
+	case clients.ConsentPaused:
+		connection.Status = models.BankFeedPaused
+	case clients.ConsentRejected:
+		connection.Status = models.BankFeedFailed
+		connection.LastError = "the account holder rejected the consent"
+	case clients.ConsentExpired:
+		connection.Status = models.BankFeedExpired
+	case clients.ConsentRevoked:
+		connection.Status = models.BankFeedRevoked
+	}
+}
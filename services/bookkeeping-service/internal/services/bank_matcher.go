@@ -0,0 +1,232 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+)
+
+// Match types of a suggestion
+const (
+	MatchOneToOne  = "one_to_one"
+	MatchOneToMany = "one_to_many" // The bank line settles several ledger entries
+	MatchManyToOne = "many_to_one" // Several bank lines settle one ledger entry
+)
+
+// Limits on the combinations tried when suggesting matches
+const (
+	DefaultMatchCombination = 3
+	MaxMatchCombination     = 5
+
+	matchWindowDays      = 3
+	maxMatchCandidates   = 30 // Nearest entries tried in combinations
+	maxComboSuggestions  = 5
+	comboAmountTolerance = 0.01
+)
+
+// SuggestOptions controls SuggestMatches
+type SuggestOptions struct {
+	// MaxCombination is the most entries a combined match may sum, 1 for
+	// one-to-one matches only
+	MaxCombination int
+}
+
+// MatchedLedgerEntry is a ledger transaction in a suggested match, its
+// amount signed as on the bank statement
+type MatchedLedgerEntry struct {
+	TransactionID     uuid.UUID `json:"transaction_id"`
+	TransactionNumber string    `json:"transaction_number"`
+	TransactionDate   time.Time `json:"transaction_date"`
+	Description       string    `json:"description"`
+	Amount            float64   `json:"amount"`
+}
+
+// MatchedBankLine is a bank statement line in a suggested match
+type MatchedBankLine struct {
+	BankTransactionID uuid.UUID `json:"bank_transaction_id"`
+	TransactionDate   time.Time `json:"transaction_date"`
+	Description       string    `json:"description"`
+	Reference         string    `json:"reference,omitempty"`
+	Amount            float64   `json:"amount"`
+}
+
+// ScoreFactor is one part of a suggestion's score and why it was given
+type ScoreFactor struct {
+	Factor string  `json:"factor"` // amount, date, description, combination
+	Points float64 `json:"points"`
+	Detail string  `json:"detail"`
+}
+
+// scoreMatch scores bank lines against ledger entries by how closely the
+// amounts agree, how far apart the dates are and whether the descriptions
+// share text, less a little for every entry beyond a one-to-one match
+func scoreMatch(bank []MatchedBankLine, ledger []MatchedLedgerEntry) (float64, []ScoreFactor) {
+	var factors []ScoreFactor
+
+	var bankTotal, ledgerTotal float64
+	for _, line := range bank {
+		bankTotal += line.Amount
+	}
+	for _, entry := range ledger {
+		ledgerTotal += entry.Amount
+	}
+	switch diff := abs(bankTotal - ledgerTotal); {
+	case diff < comboAmountTolerance:
+		factors = append(factors, ScoreFactor{"amount", 50, fmt.Sprintf("Amounts agree at %.2f", bankTotal)})
+	case diff <= 1:
+		factors = append(factors, ScoreFactor{"amount", 40, fmt.Sprintf("Amounts differ by %.2f, within rounding", diff)})
+	}
+
+	var days float64
+	for _, line := range bank {
+		for _, entry := range ledger {
+			days = max(days, abs(entry.TransactionDate.Sub(line.TransactionDate).Hours()/24))
+		}
+	}
+	switch {
+	case days < 1:
+		factors = append(factors, ScoreFactor{"date", 30, "Same date"})
+	case days <= 1:
+		factors = append(factors, ScoreFactor{"date", 20, "Dates a day apart"})
+	case days <= matchWindowDays:
+		factors = append(factors, ScoreFactor{"date", 10, fmt.Sprintf("Dates up to %.0f days apart", days)})
+	}
+
+	if detail, ok := describedAlike(bank, ledger); ok {
+		factors = append(factors, ScoreFactor{"description", 20, detail})
+	}
+
+	if parts := len(bank) + len(ledger); parts > 2 {
+		penalty := -5 * float64(parts-2)
+		factors = append(factors, ScoreFactor{"combination", penalty, fmt.Sprintf("Combines %d bank lines with %d ledger entries", len(bank), len(ledger))})
+	}
+
+	score := 0.0
+	for _, factor := range factors {
+		score += factor.Points
+	}
+	return max(score, 0), factors
+}
+
+// describedAlike finds a bank line and ledger entry whose texts overlap
+func describedAlike(bank []MatchedBankLine, ledger []MatchedLedgerEntry) (string, bool) {
+	for _, line := range bank {
+		for _, entry := range ledger {
+			if similarText(line.Description, entry.Description) || similarText(line.Reference, entry.Description) {
+				return fmt.Sprintf("%q resembles %q", line.Description, entry.Description), true
+			}
+			if ref := sharedReference(line.Description+" "+line.Reference, entry.Description); ref != "" {
+				return fmt.Sprintf("Both cite %s", ref), true
+			}
+		}
+	}
+	return "", false
+}
+
+// sharedReference returns a document reference, such as an invoice number,
+// found in both texts. UPI and other bank narrations wrap the reference the
+// payer was given in text of their own, so the texts rarely contain each
+// other. A reference is a word of at least 5 characters mixing letters and
+// digits, which dates and amounts never do.
+func sharedReference(a, b string) string {
+	words := make(map[string]bool)
+	for _, word := range referenceWords(a) {
+		words[word] = true
+	}
+	for _, word := range referenceWords(b) {
+		if words[word] {
+			return word
+		}
+	}
+	return ""
+}
+
+func referenceWords(text string) []string {
+	var refs []string
+	for _, word := range strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == ':' || r == '(' || r == ')'
+	}) {
+		word = strings.Trim(word, ".-/#")
+		if len(word) >= 5 && strings.ContainsAny(word, "0123456789") && strings.ContainsAny(word, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			refs = append(refs, word)
+		}
+	}
+	return refs
+}
+
+func similarText(a, b string) bool {
+	a, b = strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
+
+// explain joins the details of the positive factors, for clients showing a
+// single reason
+func explain(factors []ScoreFactor) string {
+	var reasons []string
+	for _, factor := range factors {
+		if factor.Points > 0 {
+			reasons = append(reasons, factor.Detail)
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// combinations calls visit for each combination of min to max of the
+// amounts, nearest first, that sums to target within tolerance
+func combinations(amounts []float64, target float64, minSize, maxSize int, visit func(indices []int) bool) {
+	indices := make([]int, 0, maxSize)
+	var walk func(start int, sum float64) bool
+	walk = func(start int, sum float64) bool {
+		if len(indices) >= minSize && abs(sum-target) < comboAmountTolerance {
+			if !visit(append([]int(nil), indices...)) {
+				return false
+			}
+		}
+		if len(indices) == maxSize {
+			return true
+		}
+		for i := start; i < len(amounts); i++ {
+			indices = append(indices, i)
+			ok := walk(i+1, sum+amounts[i])
+			indices = indices[:len(indices)-1]
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	walk(0, 0)
+}
+
+// nearest orders candidates by distance from date and keeps the closest
+func nearest[T any](items []T, date time.Time, dateOf func(T) time.Time, limit int) []T {
+	sort.SliceStable(items, func(i, j int) bool {
+		return abs(dateOf(items[i]).Sub(date).Hours()) < abs(dateOf(items[j]).Sub(date).Hours())
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func bankLineOf(tx models.BankTransaction) MatchedBankLine {
+	return MatchedBankLine{
+		BankTransactionID: tx.ID,
+		TransactionDate:   tx.TransactionDate,
+		Description:       tx.Description,
+		Reference:         tx.Reference,
+		Amount:            tx.CreditAmount - tx.DebitAmount,
+	}
+}
+
+// sameSign reports whether a part can add up towards total
+func sameSign(part, total float64) bool {
+	return part != 0 && (part > 0) == (total > 0) && abs(part) < abs(total)
+}
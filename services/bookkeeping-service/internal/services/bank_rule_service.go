@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrBankRuleNotFound   = errors.New("bank rule not found")
+	ErrInvalidRulePattern = errors.New("rule pattern is not a valid regular expression")
+	ErrNoLedgerAccount    = errors.New("bank account is not linked to a ledger account")
+)
+
+// defaultBankRules recognize the charges, GST on charges and interest that
+// Indian banks commonly print on statements. GST runs first as its lines
+// also name the charge it is levied on.
+var defaultBankRules = []struct {
+	name     string
+	kind     models.BankRuleKind
+	pattern  string
+	posting  models.PostingType
+	priority int
+}{
+	{"GST on bank charges", models.BankRuleKindChargeGST, `\b[cis]?gst\b.*\b(chg|chgs|charges?|fees?|comm)\b|\b(chg|chgs|charges?|fees?)\b.*\b[cis]?gst\b`, models.PostingTypeGSTInput, 10},
+	{"Bank charges", models.BankRuleKindCharge, `\b(chg|chgs|charges?|bank fees?|annual fees?|commission|amc|sms alert|min(imum)? bal(ance)?)\b`, models.PostingTypeBankCharges, 20},
+	{"Interest credited", models.BankRuleKindInterest, `\b(int\.?\s*(pd|paid|cr|credit(ed)?)|interest|sb int)\b`, models.PostingTypeInterestIncome, 30},
+}
+
+// BankRuleRequest creates or replaces a bank rule
+type BankRuleRequest struct {
+	TenantID      uuid.UUID  `json:"-"`
+	UserID        uuid.UUID  `json:"-"`
+	BankAccountID *uuid.UUID `json:"bank_account_id"`
+	Name          string     `json:"name" binding:"required,max=100"`
+	Kind          string     `json:"kind" binding:"required,oneof=bank_charge charge_gst interest"`
+	Pattern       string     `json:"pattern" binding:"required,max=500"`
+	AccountID     uuid.UUID  `json:"account_id" binding:"required"`
+	Priority      *int       `json:"priority"`
+	IsActive      *bool      `json:"is_active"`
+}
+
+// BankRuleResult reports a run of the bank rules over a bank account
+type BankRuleResult struct {
+	Checked int `json:"checked"`
+	Posted  int `json:"posted"`
+}
+
+// BankRuleService manages bank rules and posts the statement lines they
+// recognize
+type BankRuleService interface {
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.BankRule, error)
+	Create(ctx context.Context, req BankRuleRequest) (*models.BankRule, error)
+	Update(ctx context.Context, id uuid.UUID, req BankRuleRequest) (*models.BankRule, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+
+	// InstallDefaults adds the standard rules the tenant does not have yet,
+	// posting to the default chart's accounts
+	InstallDefaults(ctx context.Context, tenantID, userID uuid.UUID) ([]models.BankRule, error)
+
+	// Recognize posts the unreconciled lines of a statement that match a
+	// rule and returns how many were posted
+	Recognize(ctx context.Context, account *models.BankAccount, transactions []models.BankTransaction, userID uuid.UUID) (int, error)
+
+	// ApplyToAccount runs the rules over a bank account's unreconciled
+	// transactions, e.g. after adding a rule
+	ApplyToAccount(ctx context.Context, tenantID, bankAccountID, userID uuid.UUID) (*BankRuleResult, error)
+}
+
+type bankRuleService struct {
+	ruleRepo        repository.BankRuleRepository
+	bankRepo        repository.BankRepository
+	transactionRepo repository.TransactionRepository
+	accountRepo     repository.AccountRepository
+	accounts        AccountMappingService
+}
+
+// NewBankRuleService creates a new bank rule service
+func NewBankRuleService(
+	ruleRepo repository.BankRuleRepository,
+	bankRepo repository.BankRepository,
+	transactionRepo repository.TransactionRepository,
+	accountRepo repository.AccountRepository,
+	accounts AccountMappingService,
+) BankRuleService {
+	return &bankRuleService{
+		ruleRepo:        ruleRepo,
+		bankRepo:        bankRepo,
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		accounts:        accounts,
+	}
+}
+
+func (s *bankRuleService) List(ctx context.Context, tenantID uuid.UUID) ([]models.BankRule, error) {
+	return s.ruleRepo.List(ctx, tenantID)
+}
+
+func (s *bankRuleService) Create(ctx context.Context, req BankRuleRequest) (*models.BankRule, error) {
+	rule := &models.BankRule{
+		TenantID:  req.TenantID,
+		Priority:  100,
+		IsActive:  true,
+		CreatedBy: req.UserID,
+	}
+	if err := s.apply(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *bankRuleService) Update(ctx context.Context, id uuid.UUID, req BankRuleRequest) (*models.BankRule, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, req.TenantID, id)
+	if err != nil {
+		if err == repository.ErrBankRuleNotFound {
+			return nil, ErrBankRuleNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.apply(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *bankRuleService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	err := s.ruleRepo.Delete(ctx, tenantID, id)
+	if err == repository.ErrBankRuleNotFound {
+		return ErrBankRuleNotFound
+	}
+	return err
+}
+
+func (s *bankRuleService) InstallDefaults(ctx context.Context, tenantID, userID uuid.UUID) ([]models.BankRule, error) {
+	existing, err := s.ruleRepo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		names[rule.Name] = true
+	}
+
+	var rules []models.BankRule
+	for _, def := range defaultBankRules {
+		if names[def.name] {
+			continue
+		}
+		account, err := s.accounts.Resolve(ctx, tenantID, def.posting)
+		if err != nil {
+			return nil, ErrAccountNotFound
+		}
+		rules = append(rules, models.BankRule{
+			TenantID:  tenantID,
+			Name:      def.name,
+			Kind:      def.kind,
+			Pattern:   def.pattern,
+			AccountID: account.ID,
+			Priority:  def.priority,
+			IsActive:  true,
+			CreatedBy: userID,
+		})
+	}
+
+	if err := s.ruleRepo.CreateMany(ctx, rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *bankRuleService) Recognize(ctx context.Context, account *models.BankAccount, transactions []models.BankTransaction, userID uuid.UUID) (int, error) {
+	if account.AccountID == nil || len(transactions) == 0 {
+		return 0, nil
+	}
+
+	rules, err := s.ruleRepo.ListActive(ctx, account.TenantID, account.ID)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+	patterns := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		// Patterns are checked when saved; one broken since is skipped
+		patterns[i], _ = compileRulePattern(rule.Pattern)
+	}
+
+	posted := 0
+	for i := range transactions {
+		bankTx := &transactions[i]
+		if bankTx.IsReconciled {
+			continue
+		}
+
+		for j := range rules {
+			rule := &rules[j]
+			if patterns[j] == nil || !ruleMatches(rule, patterns[j], bankTx) {
+				continue
+			}
+			if err := s.post(ctx, account, rule, bankTx, userID); err != nil {
+				return posted, err
+			}
+			posted++
+			break
+		}
+	}
+
+	return posted, nil
+}
+
+func (s *bankRuleService) ApplyToAccount(ctx context.Context, tenantID, bankAccountID, userID uuid.UUID) (*BankRuleResult, error) {
+	account, err := s.bankRepo.GetBankAccountByID(ctx, bankAccountID)
+	if err != nil || account.TenantID != tenantID {
+		return nil, ErrBankAccountNotFound
+	}
+	if account.AccountID == nil {
+		return nil, ErrNoLedgerAccount
+	}
+
+	transactions, err := s.bankRepo.GetUnreconciledTransactions(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	posted, err := s.Recognize(ctx, account, transactions, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BankRuleResult{Checked: len(transactions), Posted: posted}, nil
+}
+
+// apply validates req and copies it onto rule
+func (s *bankRuleService) apply(ctx context.Context, rule *models.BankRule, req BankRuleRequest) error {
+	if _, err := compileRulePattern(req.Pattern); err != nil {
+		return ErrInvalidRulePattern
+	}
+	if _, err := s.accountRepo.FindByID(ctx, req.AccountID, req.TenantID); err != nil {
+		return ErrAccountNotFound
+	}
+	if req.BankAccountID != nil {
+		account, err := s.bankRepo.GetBankAccountByID(ctx, *req.BankAccountID)
+		if err != nil || account.TenantID != req.TenantID {
+			return ErrBankAccountNotFound
+		}
+	}
+
+	rule.BankAccountID = req.BankAccountID
+	rule.Name = req.Name
+	rule.Kind = models.BankRuleKind(req.Kind)
+	rule.Pattern = req.Pattern
+	rule.AccountID = req.AccountID
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// post records a matched statement line in the ledger against the bank
+// account's ledger account and reconciles it
+func (s *bankRuleService) post(ctx context.Context, account *models.BankAccount, rule *models.BankRule, bankTx *models.BankTransaction, userID uuid.UUID) error {
+	txnType := models.TransactionTypeExpense
+	amount := bankTx.DebitAmount
+	ruleLine := models.TransactionLine{AccountID: rule.AccountID, Description: bankTx.Description, DebitAmount: amount, LineOrder: 0}
+	bankLine := models.TransactionLine{AccountID: *account.AccountID, Description: bankTx.Description, CreditAmount: amount, LineOrder: 1}
+	if rule.IsCredit() {
+		txnType = models.TransactionTypeReceipt
+		amount = bankTx.CreditAmount
+		ruleLine = models.TransactionLine{AccountID: rule.AccountID, Description: bankTx.Description, CreditAmount: amount, LineOrder: 1}
+		bankLine = models.TransactionLine{AccountID: *account.AccountID, Description: bankTx.Description, DebitAmount: amount, LineOrder: 0}
+	}
+
+	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, account.TenantID, txnType)
+	if err != nil {
+		return err
+	}
+
+	transaction := &models.Transaction{
+		TenantID:          account.TenantID,
+		TransactionNumber: txnNumber,
+		TransactionDate:   bankTx.TransactionDate,
+		TransactionType:   txnType,
+		ReferenceType:     "bank_transaction",
+		ReferenceID:       &bankTx.ID,
+		PartyName:         account.BankName,
+		Description:       bankTx.Description,
+		Notes:             "Posted by bank rule " + rule.Name,
+		Subtotal:          amount,
+		TotalAmount:       amount,
+		PaymentMode:       models.PaymentModeBank,
+		PaymentReference:  bankTx.Reference,
+		Status:            models.TransactionStatusPosted,
+		Lines:             []models.TransactionLine{bankLine, ruleLine},
+		CreatedBy:         userID,
+	}
+
+	return s.bankRepo.PostRuleMatch(ctx, bankTx, rule.ID, transaction, userID)
+}
+
+// ruleMatches reports whether a statement line is the kind of money
+// movement the rule recognizes and its description matches
+func ruleMatches(rule *models.BankRule, pattern *regexp.Regexp, bankTx *models.BankTransaction) bool {
+	if !rule.AppliesTo(bankTx.BankAccountID) {
+		return false
+	}
+	if rule.IsCredit() {
+		if bankTx.CreditAmount <= 0 || bankTx.DebitAmount > 0 {
+			return false
+		}
+	} else if bankTx.DebitAmount <= 0 || bankTx.CreditAmount > 0 {
+		return false
+	}
+	return pattern.MatchString(bankTx.Description)
+}
+
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
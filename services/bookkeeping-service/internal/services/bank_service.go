@@ -1,12 +1,11 @@
 package services
 
 import (
-	"bufio"
 	"context"
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,13 +13,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
 )
 
 var (
 	ErrBankAccountNotFound = errors.New("bank account not found")
 	ErrBankTxNotFound      = errors.New("bank transaction not found")
 	ErrAlreadyReconciled   = errors.New("transaction already reconciled")
-	ErrInvalidCSV          = errors.New("invalid CSV format")
+	ErrInvalidFeedType     = errors.New("invalid feed type")
+
+	ErrAutoReconcileRunning = errors.New("auto-reconcile is already running for this bank account")
+	ErrNoReconciliationRun  = errors.New("bank account has not been auto-reconciled")
 )
 
 // BankService handles bank account and reconciliation business logic
@@ -33,26 +36,48 @@ type BankService interface {
 	DeleteBankAccount(ctx context.Context, id uuid.UUID) error
 
 	// Bank Transactions & Reconciliation
-	ImportBankStatement(ctx context.Context, bankAccountID uuid.UUID, tenantID uuid.UUID, reader io.Reader, format string) (*ImportResult, error)
+	ImportBankStatement(ctx context.Context, req ImportStatementRequest, reader io.Reader) (*imports.Job, error)
 	GetBankTransactions(ctx context.Context, bankAccountID uuid.UUID, filters repository.BankTransactionFilters) ([]models.BankTransaction, int64, error)
 	GetUnreconciledTransactions(ctx context.Context, bankAccountID uuid.UUID) ([]models.BankTransaction, error)
 	ReconcileTransaction(ctx context.Context, bankTxID uuid.UUID, ledgerTxID uuid.UUID, userID uuid.UUID) error
 	AutoReconcile(ctx context.Context, bankAccountID uuid.UUID, userID uuid.UUID) (*AutoReconcileResult, error)
+	GetAutoReconcileStatus(ctx context.Context, bankAccountID uuid.UUID) (*models.ReconciliationRun, error)
 	UnreconcileTransaction(ctx context.Context, bankTxID uuid.UUID) error
 	GetReconciliationSummary(ctx context.Context, bankAccountID uuid.UUID, asOfDate time.Time) (*repository.ReconciliationSummary, error)
-	SuggestMatches(ctx context.Context, bankTxID uuid.UUID) ([]MatchSuggestion, error)
+	SuggestMatches(ctx context.Context, bankTxID uuid.UUID, opts SuggestOptions) ([]MatchSuggestion, error)
 }
 
+// ImportKindBankStatement is the import job kind for bank statement uploads
+const ImportKindBankStatement = "bank_statement"
+
+const (
+	autoReconcileBatchSize = 500
+
+	// Probable matches allow for cheques and transfers that clear a few days
+	// after they were booked, and for paise lost to rounding
+	probableMatchWindowDays = 3
+	probableMatchTolerance  = 1.0
+
+	// A running auto-reconcile not updated for this long is assumed dead
+	staleReconciliationRun = 10 * time.Minute
+)
+
 type bankService struct {
 	bankRepo        repository.BankRepository
 	transactionRepo repository.TransactionRepository
+	cardRepo        repository.CardRepository
+	ruleService     BankRuleService
+	importRunner    *imports.Runner
 }
 
 // NewBankService creates a new bank service
-func NewBankService(bankRepo repository.BankRepository, transactionRepo repository.TransactionRepository) BankService {
+func NewBankService(bankRepo repository.BankRepository, transactionRepo repository.TransactionRepository, cardRepo repository.CardRepository, ruleService BankRuleService, importRunner *imports.Runner) BankService {
 	return &bankService{
 		bankRepo:        bankRepo,
 		transactionRepo: transactionRepo,
+		cardRepo:        cardRepo,
+		ruleService:     ruleService,
+		importRunner:    importRunner,
 	}
 }
 
@@ -66,6 +91,7 @@ type CreateBankAccountRequest struct {
 	IFSCCode      string     `json:"ifsc_code" binding:"required"`
 	Branch        string     `json:"branch"`
 	AccountType   string     `json:"account_type"` // savings, current, overdraft
+	FeedType      string     `json:"feed_type"`    // bank (default) or corporate_card
 	OpeningBalance float64   `json:"opening_balance"`
 	IsPrimary     bool       `json:"is_primary"`
 }
@@ -83,24 +109,28 @@ type UpdateBankAccountRequest struct {
 	IsActive       bool       `json:"is_active"`
 }
 
-// ImportResult represents the result of a bank statement import
-type ImportResult struct {
-	TotalRows       int   `json:"total_rows"`
-	ImportedRows    int   `json:"imported_rows"`
-	SkippedRows     int   `json:"skipped_rows"`
-	DuplicateRows   int   `json:"duplicate_rows"`
-	ErrorRows       int   `json:"error_rows"`
-	Errors          []string `json:"errors,omitempty"`
+// ImportStatementRequest identifies a bank statement upload
+type ImportStatementRequest struct {
+	BankAccountID uuid.UUID
+	TenantID      uuid.UUID
+	UserID        uuid.UUID
+	FileName      string
+	Format        string
 }
 
 // AutoReconcileResult represents the result of auto-reconciliation
 type AutoReconcileResult struct {
-	MatchedCount    int `json:"matched_count"`
-	UnmatchedCount  int `json:"unmatched_count"`
-	TotalProcessed  int `json:"total_processed"`
+	RunID              uuid.UUID `json:"run_id,omitempty"`
+	MatchedCount       int       `json:"matched_count"`
+	ExactMatchCount    int       `json:"exact_match_count"`
+	ProbableMatchCount int       `json:"probable_match_count"`
+	UnmatchedCount     int       `json:"unmatched_count"`
+	TotalProcessed     int       `json:"total_processed"`
 }
 
-// MatchSuggestion represents a suggested match for reconciliation
+// MatchSuggestion represents a suggested match for reconciliation. The
+// transaction fields describe the first ledger entry of the match, and the
+// amount is that of all of them.
 type MatchSuggestion struct {
 	TransactionID uuid.UUID `json:"transaction_id"`
 	TransactionNumber string `json:"transaction_number"`
@@ -109,11 +139,24 @@ type MatchSuggestion struct {
 	Amount          float64   `json:"amount"`
 	MatchScore      float64   `json:"match_score"` // 0-100
 	MatchReason     string    `json:"match_reason"`
+
+	MatchType        string               `json:"match_type"`
+	Transactions     []MatchedLedgerEntry `json:"transactions"`
+	BankTransactions []MatchedBankLine    `json:"bank_transactions"`
+	ScoreFactors     []ScoreFactor        `json:"score_factors"` // How the score was made up
 }
 
 // Bank Account methods
 
 func (s *bankService) CreateBankAccount(ctx context.Context, req CreateBankAccountRequest) (*models.BankAccount, error) {
+	switch req.FeedType {
+	case "":
+		req.FeedType = models.FeedTypeBank
+	case models.FeedTypeBank, models.FeedTypeCorporateCard:
+	default:
+		return nil, ErrInvalidFeedType
+	}
+
 	account := &models.BankAccount{
 		TenantID:       req.TenantID,
 		AccountID:      req.AccountID,
@@ -123,6 +166,7 @@ func (s *bankService) CreateBankAccount(ctx context.Context, req CreateBankAccou
 		IFSCCode:       req.IFSCCode,
 		Branch:         req.Branch,
 		AccountType:    req.AccountType,
+		FeedType:       req.FeedType,
 		OpeningBalance: req.OpeningBalance,
 		CurrentBalance: req.OpeningBalance,
 		IsPrimary:      req.IsPrimary,
@@ -191,146 +235,194 @@ func (s *bankService) DeleteBankAccount(ctx context.Context, id uuid.UUID) error
 
 // Bank Transaction & Reconciliation methods
 
-func (s *bankService) ImportBankStatement(ctx context.Context, bankAccountID uuid.UUID, tenantID uuid.UUID, reader io.Reader, format string) (*ImportResult, error) {
-	result := &ImportResult{}
-
+func (s *bankService) ImportBankStatement(ctx context.Context, req ImportStatementRequest, reader io.Reader) (*imports.Job, error) {
 	// Verify bank account exists
-	_, err := s.bankRepo.GetBankAccountByID(ctx, bankAccountID)
+	account, err := s.bankRepo.GetBankAccountByID(ctx, req.BankAccountID)
 	if err != nil {
 		return nil, ErrBankAccountNotFound
 	}
 
-	// Generate batch ID for this import
-	batchID := uuid.New()
-
-	var transactions []models.BankTransaction
-
-	switch strings.ToLower(format) {
+	switch strings.ToLower(req.Format) {
 	case "csv", "":
-		transactions, result, err = s.parseCSVStatement(reader, bankAccountID, tenantID, batchID)
 	default:
-		return nil, fmt.Errorf("unsupported format: %s", format)
+		return nil, fmt.Errorf("unsupported format: %s", req.Format)
 	}
 
-	if err != nil {
-		return result, err
+	// Lines imported before hashes were kept must be found too
+	if err := s.bankRepo.BackfillDedupeHashes(ctx, account.ID); err != nil {
+		return nil, err
 	}
 
-	if len(transactions) > 0 {
-		if err := s.bankRepo.CreateBankTransactions(ctx, transactions); err != nil {
-			return result, err
-		}
-		result.ImportedRows = len(transactions)
+	job := &imports.Job{
+		TenantID:    req.TenantID,
+		Kind:        ImportKindBankStatement,
+		ReferenceID: &account.ID,
+		FileName:    req.FileName,
+		CreatedBy:   &req.UserID,
+	}
+	if err := s.importRunner.Start(ctx, job, reader, s.importStatementChunk(account)); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return job, nil
 }
 
-func (s *bankService) parseCSVStatement(reader io.Reader, bankAccountID, tenantID, batchID uuid.UUID) ([]models.BankTransaction, *ImportResult, error) {
-	result := &ImportResult{}
-	var transactions []models.BankTransaction
+// importStatementChunk returns the importer for a statement into account.
+// All rows of a job share the job ID as their import batch. Lines the
+// account already has from another upload are counted as duplicates and
+// left out, so statements overlapping an earlier one import only their new
+// lines. Charges and interest on bank statements are posted by the bank
+// rules; card statement lines become card spends instead.
+func (s *bankService) importStatementChunk(account *models.BankAccount) imports.Importer {
+	return func(ctx context.Context, job *imports.Job, chunk imports.Chunk) (imports.ChunkResult, error) {
+		var result imports.ChunkResult
+
+		cols := statementColumnsOf(chunk.Header)
+		if cols.date == -1 || cols.desc == -1 {
+			return result, fmt.Errorf("required columns not found: need at least date and description")
+		}
 
-	csvReader := csv.NewReader(bufio.NewReader(reader))
-	csvReader.TrimLeadingSpace = true
-	csvReader.FieldsPerRecord = -1 // Variable number of fields
+		transactions := make([]models.BankTransaction, 0, len(chunk.Rows))
+		for _, row := range chunk.Rows {
+			tx, err := cols.parse(row, account, job.ID)
+			if err != nil {
+				result.Fail(row, err)
+				continue
+			}
+			tx.DedupeHash = tx.ComputeDedupeHash()
+			transactions = append(transactions, tx)
+		}
 
-	// Read header
-	header, err := csvReader.Read()
-	if err != nil {
-		return nil, result, ErrInvalidCSV
-	}
+		transactions, err := s.dropImportedLines(ctx, account, job.ID, transactions)
+		if err != nil {
+			return result, err
+		}
+		result.Duplicates = len(chunk.Rows) - len(result.Failures) - len(transactions)
 
-	// Map column indices
-	colMap := make(map[string]int)
-	for i, col := range header {
-		colMap[strings.ToLower(strings.TrimSpace(col))] = i
-	}
+		if len(transactions) == 0 {
+			return result, nil
+		}
+		if err := s.bankRepo.CreateBankTransactions(ctx, transactions); err != nil {
+			return result, err
+		}
+		result.Imported = len(transactions)
 
-	// Required columns
-	dateCol := findColumn(colMap, "date", "transaction date", "txn date", "value date")
-	descCol := findColumn(colMap, "description", "narration", "particulars", "remarks")
-	debitCol := findColumn(colMap, "debit", "withdrawal", "dr", "debit amount")
-	creditCol := findColumn(colMap, "credit", "deposit", "cr", "credit amount")
-	balanceCol := findColumn(colMap, "balance", "closing balance", "available balance")
-	refCol := findColumn(colMap, "reference", "ref no", "cheque no", "utr")
+		if account.IsCardFeed() {
+			if err := s.createCardSpends(ctx, account, transactions); err != nil {
+				return result, err
+			}
+		} else {
+			var postedBy uuid.UUID
+			if job.CreatedBy != nil {
+				postedBy = *job.CreatedBy
+			}
+			if _, err := s.ruleService.Recognize(ctx, account, transactions, postedBy); err != nil {
+				return result, err
+			}
+		}
 
-	if dateCol == -1 || descCol == -1 {
-		return nil, result, fmt.Errorf("required columns not found: need at least date and description")
+		return result, nil
 	}
+}
 
-	lineNum := 1
-	for {
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			result.ErrorRows++
-			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", lineNum, err))
-			lineNum++
-			continue
-		}
-
-		result.TotalRows++
-		lineNum++
+// dropImportedLines removes the transactions the account already has from
+// an earlier upload. Identical lines within the upload are all kept: a
+// statement may well show two equal payments on one day.
+func (s *bankService) dropImportedLines(ctx context.Context, account *models.BankAccount, batchID uuid.UUID, transactions []models.BankTransaction) ([]models.BankTransaction, error) {
+	hashes := make([]string, len(transactions))
+	for i, tx := range transactions {
+		hashes[i] = tx.DedupeHash
+	}
+	existing, err := s.bankRepo.ExistingDedupeHashes(ctx, account.ID, batchID, hashes)
+	if err != nil {
+		return nil, err
+	}
 
-		// Skip empty rows
-		if len(record) < 2 {
-			result.SkippedRows++
-			continue
+	fresh := transactions[:0]
+	for _, tx := range transactions {
+		if !existing[tx.DedupeHash] {
+			fresh = append(fresh, tx)
 		}
+	}
+	return fresh, nil
+}
 
-		// Parse date
-		dateStr := ""
-		if dateCol >= 0 && dateCol < len(record) {
-			dateStr = strings.TrimSpace(record[dateCol])
-		}
-		txDate, err := parseDate(dateStr)
-		if err != nil {
-			result.SkippedRows++
-			continue
+// createCardSpends opens a card spend for every purchase on a corporate card
+// statement, assigned to the member holding the card where it is known
+func (s *bankService) createCardSpends(ctx context.Context, account *models.BankAccount, transactions []models.BankTransaction) error {
+	cards, err := s.cardRepo.ListCards(ctx, account.TenantID, account.ID)
+	if err != nil {
+		return err
+	}
+	holders := make(map[string]uuid.UUID, len(cards))
+	for _, card := range cards {
+		if card.IsActive {
+			holders[card.CardLast4] = card.MemberID
 		}
+	}
 
-		// Parse amounts
-		var debitAmt, creditAmt, balance float64
-		if debitCol >= 0 && debitCol < len(record) {
-			debitAmt = parseAmount(record[debitCol])
+	var spends []models.CardSpend
+	for _, tx := range transactions {
+		if tx.DebitAmount <= 0 {
+			continue // Card payments and refunds
 		}
-		if creditCol >= 0 && creditCol < len(record) {
-			creditAmt = parseAmount(record[creditCol])
+		spend := models.CardSpend{
+			TenantID:          tx.TenantID,
+			BankAccountID:     tx.BankAccountID,
+			BankTransactionID: tx.ID,
+			CardLast4:         tx.CardLast4,
+			TransactionDate:   tx.TransactionDate,
+			Description:       tx.Description,
+			Amount:            tx.DebitAmount,
 		}
-		if balanceCol >= 0 && balanceCol < len(record) {
-			balance = parseAmount(record[balanceCol])
-		}
-
-		// Get description
-		desc := ""
-		if descCol >= 0 && descCol < len(record) {
-			desc = strings.TrimSpace(record[descCol])
+		if memberID, ok := holders[tx.CardLast4]; ok {
+			spend.MemberID = &memberID
 		}
+		spends = append(spends, spend)
+	}
 
-		// Get reference
-		ref := ""
-		if refCol >= 0 && refCol < len(record) {
-			ref = strings.TrimSpace(record[refCol])
-		}
+	if len(spends) == 0 {
+		return nil
+	}
+	return s.cardRepo.CreateSpends(ctx, spends)
+}
 
-		tx := models.BankTransaction{
-			BankAccountID:   bankAccountID,
-			TenantID:        tenantID,
-			TransactionDate: txDate,
-			Description:     desc,
-			Reference:       ref,
-			DebitAmount:     debitAmt,
-			CreditAmount:    creditAmt,
-			Balance:         balance,
-			ImportBatchID:   &batchID,
-		}
+// statementColumns are the column indices of a bank statement CSV, -1 for
+// columns the file does not have
+type statementColumns struct {
+	date, desc, debit, credit, balance, ref, card int
+}
 
-		transactions = append(transactions, tx)
+func statementColumnsOf(header imports.Header) statementColumns {
+	return statementColumns{
+		date:    header.Find("date", "transaction date", "txn date", "value date"),
+		desc:    header.Find("description", "narration", "particulars", "remarks"),
+		debit:   header.Find("debit", "withdrawal", "dr", "debit amount"),
+		credit:  header.Find("credit", "deposit", "cr", "credit amount"),
+		balance: header.Find("balance", "closing balance", "available balance"),
+		ref:     header.Find("reference", "ref no", "cheque no", "utr"),
+		card:    header.Find("card number", "card no", "card"),
 	}
+}
 
-	return transactions, result, nil
+func (cols statementColumns) parse(row imports.Row, account *models.BankAccount, batchID uuid.UUID) (models.BankTransaction, error) {
+	txDate, err := parseDate(row.Value(cols.date))
+	if err != nil {
+		return models.BankTransaction{}, err
+	}
+
+	return models.BankTransaction{
+		BankAccountID:   account.ID,
+		TenantID:        account.TenantID,
+		TransactionDate: txDate,
+		Description:     row.Value(cols.desc),
+		Reference:       row.Value(cols.ref),
+		CardLast4:       lastDigits(row.Value(cols.card), 4), // Card statements, last 4 digits only
+		DebitAmount:     parseAmount(row.Value(cols.debit)),
+		CreditAmount:    parseAmount(row.Value(cols.credit)),
+		Balance:         parseAmount(row.Value(cols.balance)),
+		ImportBatchID:   &batchID,
+	}, nil
 }
 
 func (s *bankService) GetBankTransactions(ctx context.Context, bankAccountID uuid.UUID, filters repository.BankTransactionFilters) ([]models.BankTransaction, int64, error) {
@@ -354,63 +446,131 @@ func (s *bankService) ReconcileTransaction(ctx context.Context, bankTxID uuid.UU
 	return s.bankRepo.ReconcileTransaction(ctx, bankTxID, ledgerTxID, userID)
 }
 
+// AutoReconcile matches the account's unreconciled bank transactions to the
+// ledger in batches. Each batch gets an exact pass on date and amount,
+// then a pass for probable matches near the date that is limited to
+// unambiguous pairs. Progress is recorded on a ReconciliationRun.
 func (s *bankService) AutoReconcile(ctx context.Context, bankAccountID uuid.UUID, userID uuid.UUID) (*AutoReconcileResult, error) {
-	result := &AutoReconcileResult{}
-
-	// Get unreconciled bank transactions
-	bankTxs, err := s.bankRepo.GetUnreconciledTransactions(ctx, bankAccountID)
+	bankAccount, err := s.bankRepo.GetBankAccountByID(ctx, bankAccountID)
 	if err != nil {
-		return nil, err
+		return nil, ErrBankAccountNotFound
 	}
 
-	result.TotalProcessed = len(bankTxs)
+	if last, err := s.bankRepo.GetLatestReconciliationRun(ctx, bankAccountID); err == nil &&
+		last.Status == models.ReconciliationRunRunning && time.Since(last.UpdatedAt) < staleReconciliationRun {
+		return nil, ErrAutoReconcileRunning
+	}
 
-	// Get bank account to find linked ledger account
-	bankAccount, err := s.bankRepo.GetBankAccountByID(ctx, bankAccountID)
+	total, err := s.bankRepo.CountUnreconciledTransactions(ctx, bankAccountID)
 	if err != nil {
 		return nil, err
 	}
 
+	result := &AutoReconcileResult{TotalProcessed: int(total)}
 	if bankAccount.AccountID == nil {
+		result.UnmatchedCount = result.TotalProcessed
 		return result, nil // No linked ledger account
 	}
 
-	for _, bankTx := range bankTxs {
-		// Find matching ledger transaction by amount and date
-		amount := bankTx.CreditAmount - bankTx.DebitAmount
+	run := &models.ReconciliationRun{
+		TenantID:      bankAccount.TenantID,
+		BankAccountID: bankAccountID,
+		Status:        models.ReconciliationRunRunning,
+		TotalCount:    int(total),
+		StartedBy:     userID,
+		StartedAt:     time.Now(),
+	}
+	if err := s.bankRepo.CreateReconciliationRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	runErr := s.reconcileInBatches(ctx, run, *bankAccount.AccountID)
+
+	now := time.Now()
+	run.CompletedAt = &now
+	if runErr != nil {
+		run.Status = models.ReconciliationRunFailed
+		run.Error = runErr.Error()
+	} else {
+		run.Status = models.ReconciliationRunCompleted
+		run.Progress = 100
+	}
+	// The run is saved even if the request was cancelled, so it does not
+	// block the next one until it goes stale
+	if err := s.bankRepo.UpdateReconciliationRun(context.WithoutCancel(ctx), run); err != nil && runErr == nil {
+		runErr = err
+	}
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	result.RunID = run.ID
+	result.TotalProcessed = run.ProcessedCount
+	result.ExactMatchCount = run.ExactMatchCount
+	result.ProbableMatchCount = run.ProbableMatchCount
+	result.MatchedCount = run.ExactMatchCount + run.ProbableMatchCount
+	result.UnmatchedCount = result.TotalProcessed - result.MatchedCount
+	return result, nil
+}
+
+func (s *bankService) reconcileInBatches(ctx context.Context, run *models.ReconciliationRun, ledgerAccountID uuid.UUID) error {
+	var after *models.BankTransaction
+	for {
+		batch, err := s.bankRepo.GetUnreconciledBatch(ctx, run.BankAccountID, after, autoReconcileBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
 
-		// Search for transactions on same date with matching amount
-		filters := repository.TransactionFilter{
-			FromDate: bankTx.TransactionDate.Format("2006-01-02"),
-			ToDate:   bankTx.TransactionDate.Format("2006-01-02"),
-			Page:     1,
-			PerPage:  100,
+		ids := make([]uuid.UUID, len(batch))
+		for i, bankTx := range batch {
+			ids[i] = bankTx.ID
 		}
 
-		txs, _, err := s.transactionRepo.FindAll(ctx, bankTx.TenantID, filters)
+		exact, err := s.bankRepo.MatchBankTransactions(ctx, repository.MatchOptions{
+			BankTransactionIDs: ids,
+			LedgerAccountID:    ledgerAccountID,
+			ReconciledBy:       run.StartedBy,
+		})
 		if err != nil {
-			continue
+			return err
 		}
 
-		for _, tx := range txs {
-			// Check if any line matches the bank account and amount
-			for _, line := range tx.Lines {
-				if line.AccountID == *bankAccount.AccountID {
-					lineAmount := line.CreditAmount - line.DebitAmount
-					if lineAmount == amount {
-						// Match found
-						if err := s.bankRepo.ReconcileTransaction(ctx, bankTx.ID, tx.ID, userID); err == nil {
-							result.MatchedCount++
-						}
-						break
-					}
-				}
-			}
+		probable, err := s.bankRepo.MatchBankTransactions(ctx, repository.MatchOptions{
+			BankTransactionIDs: ids,
+			LedgerAccountID:    ledgerAccountID,
+			ReconciledBy:       run.StartedBy,
+			DateWindowDays:     probableMatchWindowDays,
+			AmountTolerance:    probableMatchTolerance,
+			Unambiguous:        true,
+		})
+		if err != nil {
+			return err
 		}
+
+		run.ProcessedCount += len(batch)
+		run.ExactMatchCount += int(exact)
+		run.ProbableMatchCount += int(probable)
+		// Statements imported mid-run can push processed past the total
+		run.Progress = min(run.ProcessedCount*100/max(run.TotalCount, 1), 99)
+		if err := s.bankRepo.UpdateReconciliationRun(ctx, run); err != nil {
+			return err
+		}
+
+		after = &batch[len(batch)-1]
 	}
+}
 
-	result.UnmatchedCount = result.TotalProcessed - result.MatchedCount
-	return result, nil
+// GetAutoReconcileStatus returns the latest auto-reconcile run of a bank
+// account, which can be polled while it is in progress
+func (s *bankService) GetAutoReconcileStatus(ctx context.Context, bankAccountID uuid.UUID) (*models.ReconciliationRun, error) {
+	run, err := s.bankRepo.GetLatestReconciliationRun(ctx, bankAccountID)
+	if err != nil {
+		return nil, ErrNoReconciliationRun
+	}
+	return run, nil
 }
 
 func (s *bankService) UnreconcileTransaction(ctx context.Context, bankTxID uuid.UUID) error {
@@ -430,7 +590,12 @@ func (s *bankService) GetReconciliationSummary(ctx context.Context, bankAccountI
 	return s.bankRepo.GetReconciliationSummary(ctx, bankAccountID, asOfDate)
 }
 
-func (s *bankService) SuggestMatches(ctx context.Context, bankTxID uuid.UUID) ([]MatchSuggestion, error) {
+func (s *bankService) SuggestMatches(ctx context.Context, bankTxID uuid.UUID, opts SuggestOptions) ([]MatchSuggestion, error) {
+	if opts.MaxCombination < 1 {
+		opts.MaxCombination = DefaultMatchCombination
+	}
+	opts.MaxCombination = min(opts.MaxCombination, MaxMatchCombination)
+
 	bankTx, err := s.bankRepo.GetBankTransactionByID(ctx, bankTxID)
 	if err != nil {
 		return nil, ErrBankTxNotFound
@@ -446,14 +611,12 @@ func (s *bankService) SuggestMatches(ctx context.Context, bankTxID uuid.UUID) ([
 		return nil, nil
 	}
 
-	var suggestions []MatchSuggestion
-	amount := bankTx.CreditAmount - bankTx.DebitAmount
-
-	// Search for transactions within 3 days with similar amount
-	startDate := bankTx.TransactionDate.AddDate(0, 0, -3)
-	endDate := bankTx.TransactionDate.AddDate(0, 0, 3)
+	// Search for transactions within 3 days
+	startDate := bankTx.TransactionDate.AddDate(0, 0, -matchWindowDays)
+	endDate := bankTx.TransactionDate.AddDate(0, 0, matchWindowDays)
 
 	filters := repository.TransactionFilter{
+		Status:   string(models.TransactionStatusPosted),
 		FromDate: startDate.Format("2006-01-02"),
 		ToDate:   endDate.Format("2006-01-02"),
 		Page:     1,
@@ -464,81 +627,175 @@ func (s *bankService) SuggestMatches(ctx context.Context, bankTxID uuid.UUID) ([
 	if err != nil {
 		return nil, err
 	}
+	ids := make([]uuid.UUID, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID
+	}
+	reconciled, err := s.bankRepo.ReconciledLedgerTransactions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
 
+	// Money into the bank is a debit to its ledger account, so entries are
+	// signed as debits to compare with the statement's credits
+	var entries []MatchedLedgerEntry
 	for _, tx := range txs {
+		if reconciled[tx.ID] {
+			continue
+		}
+		var amount float64
+		var onAccount bool
 		for _, line := range tx.Lines {
-			if line.AccountID != *bankAccount.AccountID {
-				continue
+			if line.AccountID == *bankAccount.AccountID {
+				amount += line.DebitAmount - line.CreditAmount
+				onAccount = true
 			}
+		}
+		if onAccount {
+			entries = append(entries, MatchedLedgerEntry{
+				TransactionID:     tx.ID,
+				TransactionNumber: tx.TransactionNumber,
+				TransactionDate:   tx.TransactionDate,
+				Description:       tx.Description,
+				Amount:            amount,
+			})
+		}
+	}
 
-			lineAmount := line.CreditAmount - line.DebitAmount
+	line := bankLineOf(*bankTx)
+	suggestions := []MatchSuggestion{}
+	for _, entry := range entries {
+		if suggestion, ok := suggest(MatchOneToOne, []MatchedBankLine{line}, []MatchedLedgerEntry{entry}); ok {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
 
-			// Calculate match score
-			score := 0.0
-			reason := ""
+	if opts.MaxCombination > 1 {
+		suggestions = append(suggestions, suggestOneToMany(line, entries, opts.MaxCombination)...)
 
-			// Exact amount match
-			if lineAmount == amount {
-				score += 50
-				reason = "Exact amount match"
-			} else if abs(lineAmount-amount) < 0.01 {
-				score += 40
-				reason = "Amount match within rounding"
+		unreconciled := false
+		others, _, err := s.bankRepo.GetBankTransactions(ctx, bankTx.BankAccountID, repository.BankTransactionFilters{
+			FromDate:     filters.FromDate,
+			ToDate:       filters.ToDate,
+			IsReconciled: &unreconciled,
+			Page:         1,
+			Limit:        200,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var lines []MatchedBankLine
+		for _, other := range others {
+			if other.ID != bankTx.ID {
+				lines = append(lines, bankLineOf(other))
 			}
+		}
+		suggestions = append(suggestions, suggestManyToOne(line, lines, entries, opts.MaxCombination)...)
+	}
 
-			// Same date
-			if tx.TransactionDate.Format("2006-01-02") == bankTx.TransactionDate.Format("2006-01-02") {
-				score += 30
-				if reason != "" {
-					reason += ", same date"
-				} else {
-					reason = "Same date"
-				}
-			} else {
-				// Within 1 day
-				diff := abs(float64(tx.TransactionDate.Sub(bankTx.TransactionDate).Hours() / 24))
-				if diff <= 1 {
-					score += 20
-				}
-			}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].MatchScore > suggestions[j].MatchScore
+	})
+	return suggestions, nil
+}
 
-			// Description similarity
-			if strings.Contains(strings.ToLower(tx.Description), strings.ToLower(bankTx.Description)) ||
-				strings.Contains(strings.ToLower(bankTx.Description), strings.ToLower(tx.Description)) {
-				score += 20
-				if reason != "" {
-					reason += ", similar description"
-				}
-			}
+// suggest scores a match, reporting whether it is worth suggesting
+func suggest(matchType string, bank []MatchedBankLine, ledger []MatchedLedgerEntry) (MatchSuggestion, bool) {
+	score, factors := scoreMatch(bank, ledger)
+	if score <= 30 {
+		return MatchSuggestion{}, false
+	}
+
+	var amount float64
+	for _, entry := range ledger {
+		amount += entry.Amount
+	}
+	return MatchSuggestion{
+		TransactionID:     ledger[0].TransactionID,
+		TransactionNumber: ledger[0].TransactionNumber,
+		TransactionDate:   ledger[0].TransactionDate,
+		Description:       ledger[0].Description,
+		Amount:            amount,
+		MatchScore:        score,
+		MatchReason:       explain(factors),
+
+		MatchType:        matchType,
+		Transactions:     ledger,
+		BankTransactions: bank,
+		ScoreFactors:     factors,
+	}, true
+}
 
-			if score > 30 {
-				suggestions = append(suggestions, MatchSuggestion{
-					TransactionID:     tx.ID,
-					TransactionNumber: tx.TransactionNumber,
-					TransactionDate:   tx.TransactionDate,
-					Description:       tx.Description,
-					Amount:            lineAmount,
-					MatchScore:        score,
-					MatchReason:       reason,
-				})
-			}
+// suggestOneToMany finds ledger entries that together make up the bank
+// line, such as one deposit of several customer receipts
+func suggestOneToMany(line MatchedBankLine, entries []MatchedLedgerEntry, maxCombination int) []MatchSuggestion {
+	var parts []MatchedLedgerEntry
+	for _, entry := range entries {
+		if sameSign(entry.Amount, line.Amount) {
+			parts = append(parts, entry)
 		}
 	}
+	parts = nearest(parts, line.TransactionDate, func(e MatchedLedgerEntry) time.Time { return e.TransactionDate }, maxMatchCandidates)
 
-	return suggestions, nil
+	amounts := make([]float64, len(parts))
+	for i, part := range parts {
+		amounts[i] = part.Amount
+	}
+
+	var suggestions []MatchSuggestion
+	combinations(amounts, line.Amount, 2, maxCombination, func(indices []int) bool {
+		combo := make([]MatchedLedgerEntry, len(indices))
+		for i, idx := range indices {
+			combo[i] = parts[idx]
+		}
+		if suggestion, ok := suggest(MatchOneToMany, []MatchedBankLine{line}, combo); ok {
+			suggestions = append(suggestions, suggestion)
+		}
+		return len(suggestions) < maxComboSuggestions
+	})
+	return suggestions
 }
 
-// Helper functions
+// suggestManyToOne finds other bank lines that together with the line make
+// up a ledger entry, such as a payment the bank split in instalments
+func suggestManyToOne(line MatchedBankLine, others []MatchedBankLine, entries []MatchedLedgerEntry, maxCombination int) []MatchSuggestion {
+	var suggestions []MatchSuggestion
+	for _, entry := range entries {
+		if !sameSign(line.Amount, entry.Amount) {
+			continue
+		}
 
-func findColumn(colMap map[string]int, names ...string) int {
-	for _, name := range names {
-		if idx, ok := colMap[name]; ok {
-			return idx
+		var parts []MatchedBankLine
+		for _, other := range others {
+			if sameSign(other.Amount, entry.Amount) {
+				parts = append(parts, other)
+			}
+		}
+		parts = nearest(parts, entry.TransactionDate, func(l MatchedBankLine) time.Time { return l.TransactionDate }, maxMatchCandidates)
+
+		amounts := make([]float64, len(parts))
+		for i, part := range parts {
+			amounts[i] = part.Amount
+		}
+		combinations(amounts, entry.Amount-line.Amount, 1, maxCombination-1, func(indices []int) bool {
+			combo := []MatchedBankLine{line}
+			for _, idx := range indices {
+				combo = append(combo, parts[idx])
+			}
+			if suggestion, ok := suggest(MatchManyToOne, combo, []MatchedLedgerEntry{entry}); ok {
+				suggestions = append(suggestions, suggestion)
+			}
+			return len(suggestions) < maxComboSuggestions
+		})
+		if len(suggestions) >= maxComboSuggestions {
+			break
 		}
 	}
-	return -1
+	return suggestions
 }
 
+// Helper functions
+
 func parseDate(s string) (time.Time, error) {
 	s = strings.TrimSpace(s)
 	formats := []string{
@@ -574,6 +831,20 @@ func parseAmount(s string) float64 {
 	return amount
 }
 
+// lastDigits returns the last n digits of a (possibly masked) card number
+func lastDigits(s string, n int) string {
+	var digits []rune
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) > n {
+		digits = digits[len(digits)-n:]
+	}
+	return string(digits)
+}
+
 func abs(x float64) float64 {
 	if x < 0 {
 		return -x
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrNotCardFeed          = errors.New("bank account is not a corporate card feed")
+	ErrCardSpendNotFound    = errors.New("card spend not found")
+	ErrInvalidCardSpend     = errors.New("invalid card spend data")
+	ErrExpenseClaimNotFound = errors.New("expense claim not found")
+	ErrClaimMismatch        = errors.New("expense claim does not match the card spend")
+	ErrInvoiceUnavailable   = errors.New("unable to reach the invoice service")
+)
+
+// AssignCardRequest assigns a card on a corporate card feed to a member
+type AssignCardRequest struct {
+	TenantID      uuid.UUID `json:"-"`
+	BankAccountID uuid.UUID `json:"-"`
+	CardLast4     string    `json:"card_last4" binding:"required,len=4,numeric"`
+	MemberID      uuid.UUID `json:"member_id" binding:"required"`
+	HolderName    string    `json:"holder_name"`
+}
+
+// CategorizeCardSpendRequest records what a card spend was for
+type CategorizeCardSpendRequest struct {
+	TenantID   uuid.UUID `json:"-"`
+	UserID     uuid.UUID `json:"-"`
+	Category   string    `json:"category" binding:"required"`
+	ReceiptURL string    `json:"receipt_url"`
+	Notes      string    `json:"notes"`
+}
+
+// MatchCardSpendRequest matches a card spend to the expense claim it is
+// accounted for in
+type MatchCardSpendRequest struct {
+	TenantID       uuid.UUID `json:"-"`
+	Authorization  string    `json:"-"` // forwarded to the invoice service
+	ExpenseClaimID uuid.UUID `json:"expense_claim_id" binding:"required"`
+}
+
+// CardService handles corporate card spend management
+type CardService interface {
+	AssignCard(ctx context.Context, req AssignCardRequest) (*models.CorporateCard, error)
+	ListCards(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.CorporateCard, error)
+	ListSpends(ctx context.Context, tenantID uuid.UUID, filters repository.CardSpendFilters) ([]models.CardSpend, error)
+	AssignSpend(ctx context.Context, tenantID, id, memberID uuid.UUID) (*models.CardSpend, error)
+	CategorizeSpend(ctx context.Context, id uuid.UUID, req CategorizeCardSpendRequest) (*models.CardSpend, error)
+	MatchClaim(ctx context.Context, id uuid.UUID, req MatchCardSpendRequest) (*models.CardSpend, error)
+	UncategorizedReport(ctx context.Context, tenantID uuid.UUID, filters repository.CardSpendFilters) ([]repository.MemberCardSpendSummary, error)
+}
+
+type cardService struct {
+	cardRepo      repository.CardRepository
+	bankRepo      repository.BankRepository
+	invoiceClient clients.InvoiceClient
+}
+
+// NewCardService creates a new card service
+func NewCardService(cardRepo repository.CardRepository, bankRepo repository.BankRepository, invoiceClient clients.InvoiceClient) CardService {
+	return &cardService{
+		cardRepo:      cardRepo,
+		bankRepo:      bankRepo,
+		invoiceClient: invoiceClient,
+	}
+}
+
+// AssignCard assigns a card to its holder. Spends already imported for the
+// card without a holder are assigned too.
+func (s *cardService) AssignCard(ctx context.Context, req AssignCardRequest) (*models.CorporateCard, error) {
+	account, err := s.bankRepo.GetBankAccountByID(ctx, req.BankAccountID)
+	if err != nil || account.TenantID != req.TenantID {
+		return nil, ErrBankAccountNotFound
+	}
+	if !account.IsCardFeed() {
+		return nil, ErrNotCardFeed
+	}
+
+	card := &models.CorporateCard{
+		TenantID:      req.TenantID,
+		BankAccountID: account.ID,
+		CardLast4:     req.CardLast4,
+		MemberID:      req.MemberID,
+		HolderName:    strings.TrimSpace(req.HolderName),
+		IsActive:      true,
+	}
+	if err := s.cardRepo.SaveCard(ctx, card); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.cardRepo.AssignUnassignedSpends(ctx, account.ID, card.CardLast4, card.MemberID); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+func (s *cardService) ListCards(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.CorporateCard, error) {
+	return s.cardRepo.ListCards(ctx, tenantID, bankAccountID)
+}
+
+func (s *cardService) ListSpends(ctx context.Context, tenantID uuid.UUID, filters repository.CardSpendFilters) ([]models.CardSpend, error) {
+	return s.cardRepo.ListSpends(ctx, tenantID, filters)
+}
+
+// AssignSpend assigns a single spend to a member, e.g. a purchase made on a
+// shared card
+func (s *cardService) AssignSpend(ctx context.Context, tenantID, id, memberID uuid.UUID) (*models.CardSpend, error) {
+	spend, err := s.cardRepo.GetSpend(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrCardSpendNotFound
+	}
+
+	spend.MemberID = &memberID
+	if err := s.cardRepo.UpdateSpend(ctx, spend); err != nil {
+		return nil, err
+	}
+
+	return spend, nil
+}
+
+func (s *cardService) CategorizeSpend(ctx context.Context, id uuid.UUID, req CategorizeCardSpendRequest) (*models.CardSpend, error) {
+	if strings.TrimSpace(req.Category) == "" {
+		return nil, ErrInvalidCardSpend
+	}
+
+	spend, err := s.cardRepo.GetSpend(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrCardSpendNotFound
+	}
+
+	now := time.Now()
+	spend.Category = strings.TrimSpace(req.Category)
+	if req.ReceiptURL != "" {
+		spend.ReceiptURL = req.ReceiptURL
+	}
+	if req.Notes != "" {
+		spend.Notes = req.Notes
+	}
+	spend.CategorizedBy = &req.UserID
+	spend.CategorizedAt = &now
+
+	if err := s.cardRepo.UpdateSpend(ctx, spend); err != nil {
+		return nil, err
+	}
+
+	return spend, nil
+}
+
+// MatchClaim links a spend to the cardholder's expense claim. The claim must
+// belong to the same member and contain an expense of the spend's amount.
+func (s *cardService) MatchClaim(ctx context.Context, id uuid.UUID, req MatchCardSpendRequest) (*models.CardSpend, error) {
+	spend, err := s.cardRepo.GetSpend(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrCardSpendNotFound
+	}
+	if spend.MemberID == nil {
+		return nil, ErrClaimMismatch
+	}
+
+	claim, err := s.invoiceClient.GetExpenseClaim(ctx, req.Authorization, req.TenantID.String(), req.ExpenseClaimID)
+	if err != nil {
+		if errors.Is(err, clients.ErrNotFound) {
+			return nil, ErrExpenseClaimNotFound
+		}
+		return nil, ErrInvoiceUnavailable
+	}
+	if claim.MemberID != *spend.MemberID || claim.Status == "rejected" || !claimHasAmount(claim, spend.Amount) {
+		return nil, ErrClaimMismatch
+	}
+
+	now := time.Now()
+	spend.ExpenseClaimID = &claim.ID
+	spend.ExpenseClaimNumber = claim.ClaimNumber
+	spend.MatchedAt = &now
+
+	if err := s.cardRepo.UpdateSpend(ctx, spend); err != nil {
+		return nil, err
+	}
+
+	return spend, nil
+}
+
+func (s *cardService) UncategorizedReport(ctx context.Context, tenantID uuid.UUID, filters repository.CardSpendFilters) ([]repository.MemberCardSpendSummary, error) {
+	return s.cardRepo.GetUncategorizedSummary(ctx, tenantID, filters)
+}
+
+// claimHasAmount reports whether the claim has an expense, or a total, equal
+// to amount
+func claimHasAmount(claim *clients.ExpenseClaim, amount float64) bool {
+	if math.Abs(claim.TotalAmount-amount) < 0.01 {
+		return true
+	}
+	for _, item := range claim.Items {
+		if math.Abs(item.Amount-amount) < 0.01 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrCashClosingNotFound    = errors.New("cash closing not found")
+	ErrCashClosingFinal       = errors.New("cash for this day has already been closed")
+	ErrCashClosingReviewed    = errors.New("cash closing is not awaiting approval")
+	ErrCashClosingSelfReview  = errors.New("cash closings cannot be reviewed by the cashier who counted them")
+	ErrNotCashAccount         = errors.New("account is not a cash account")
+	ErrInvalidDenomination    = errors.New("denominations must have a positive value and a non-negative count")
+	ErrFutureClosingDate      = errors.New("cash cannot be closed for a future date")
+	ErrOverShortAccountNotSet = errors.New("no over/short account is configured for cash closing")
+	ErrRejectionReasonMissing = errors.New("a reason is required to reject a cash closing")
+)
+
+// SubmitCashClosingRequest records the cashier's count of a cash account at
+// the end of the day. Submitting again for a day awaiting approval or sent
+// back replaces the earlier count.
+type SubmitCashClosingRequest struct {
+	TenantID      uuid.UUID             `json:"-"`
+	UserID        uuid.UUID             `json:"-"`
+	CashAccountID *uuid.UUID            `json:"cash_account_id"` // defaults to the Cash account
+	Date          string                `json:"date" binding:"required"`
+	Denominations []models.Denomination `json:"denominations" binding:"required,min=1"`
+	Notes         string                `json:"notes"`
+}
+
+// RejectCashClosingRequest sends a cash closing back for a recount
+type RejectCashClosingRequest struct {
+	TenantID   uuid.UUID `json:"-"`
+	ReviewerID uuid.UUID `json:"-"`
+	Reason     string    `json:"reason" binding:"required"`
+}
+
+// UpdateCashClosingSettingsRequest configures daily cash closing
+type UpdateCashClosingSettingsRequest struct {
+	OverShortAccountID *uuid.UUID `json:"over_short_account_id"`
+	AutoApproveLimit   float64    `json:"auto_approve_limit" binding:"gte=0"`
+}
+
+// CashClosingReport is the daily closing report of a cash account: the book
+// movement for the day, the count and the over/short posted
+type CashClosingReport struct {
+	Date        string          `json:"date"`
+	CashAccount *models.Account `json:"cash_account"`
+
+	OpeningBalance float64                   `json:"opening_balance"`
+	Receipts       float64                   `json:"receipts"`
+	Payments       float64                   `json:"payments"`
+	BookBalance    float64                   `json:"book_balance"`
+	Movements      []repository.CashMovement `json:"movements"`
+
+	// The count, if cash has been counted for the day
+	Closing *models.CashClosing `json:"closing,omitempty"`
+
+	// Over/short posted for the day and the resulting closing balance,
+	// which is carried forward as the next day's opening balance
+	Adjustment     float64 `json:"adjustment"`
+	ClosingBalance float64 `json:"closing_balance"`
+}
+
+// CashClosingService handles the daily cash count and over/short approval
+type CashClosingService interface {
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashClosingSettings, error)
+	UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, req UpdateCashClosingSettingsRequest) (*models.CashClosingSettings, error)
+
+	Submit(ctx context.Context, req SubmitCashClosingRequest) (*models.CashClosing, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.CashClosing, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.CashClosingFilters) ([]models.CashClosing, error)
+	Approve(ctx context.Context, tenantID, id, reviewerID uuid.UUID) (*models.CashClosing, error)
+	Reject(ctx context.Context, id uuid.UUID, req RejectCashClosingRequest) (*models.CashClosing, error)
+
+	// Report returns the closing report of a cash account for a day, whether
+	// or not its cash has been counted yet
+	Report(ctx context.Context, tenantID uuid.UUID, cashAccountID *uuid.UUID, date time.Time) (*CashClosingReport, error)
+}
+
+type cashClosingService struct {
+	closingRepo     repository.CashClosingRepository
+	transactionRepo repository.TransactionRepository
+	accountRepo     repository.AccountRepository
+	accounts        AccountMappingService
+}
+
+// NewCashClosingService creates a new cash closing service
+func NewCashClosingService(
+	closingRepo repository.CashClosingRepository,
+	transactionRepo repository.TransactionRepository,
+	accountRepo repository.AccountRepository,
+	accounts AccountMappingService,
+) CashClosingService {
+	return &cashClosingService{
+		closingRepo:     closingRepo,
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		accounts:        accounts,
+	}
+}
+
+func (s *cashClosingService) GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashClosingSettings, error) {
+	return s.closingRepo.GetSettings(ctx, tenantID)
+}
+
+func (s *cashClosingService) UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, req UpdateCashClosingSettingsRequest) (*models.CashClosingSettings, error) {
+	if req.OverShortAccountID != nil {
+		if _, err := s.accountRepo.FindByID(ctx, *req.OverShortAccountID, tenantID); err != nil {
+			return nil, ErrAccountNotFound
+		}
+	}
+
+	settings := &models.CashClosingSettings{
+		TenantID:           tenantID,
+		OverShortAccountID: req.OverShortAccountID,
+		AutoApproveLimit:   math.Round(req.AutoApproveLimit*100) / 100,
+		UpdatedBy:          &userID,
+	}
+	if err := s.closingRepo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// Submit compares the cashier's count with the book balance. A count that
+// matches closes the day; a difference within the auto-approve limit is
+// posted straight away, and anything larger waits for approval.
+func (s *cashClosingService) Submit(ctx context.Context, req SubmitCashClosingRequest) (*models.CashClosing, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, err
+	}
+	if date.After(time.Now()) {
+		return nil, ErrFutureClosingDate
+	}
+
+	for _, denomination := range req.Denominations {
+		if denomination.Value <= 0 || denomination.Count < 0 {
+			return nil, ErrInvalidDenomination
+		}
+	}
+
+	account, err := s.cashAccount(ctx, req.TenantID, req.CashAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	closing, err := s.closingRepo.GetByDate(ctx, req.TenantID, account.ID, date)
+	switch {
+	case err == repository.ErrCashClosingNotFound:
+		closing = &models.CashClosing{
+			ID:            uuid.New(), // referenced by an over/short journal posted on submit
+			TenantID:      req.TenantID,
+			CashAccountID: account.ID,
+			ClosingDate:   date,
+		}
+	case err != nil:
+		return nil, err
+	case closing.IsFinal():
+		return nil, ErrCashClosingFinal
+	}
+
+	report, err := s.bookPosition(ctx, req.TenantID, account, date, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	closing.Denominations = models.Denominations(req.Denominations)
+	closing.OpeningBalance = report.OpeningBalance
+	closing.Receipts = report.Receipts
+	closing.Payments = report.Payments
+	closing.BookBalance = report.BookBalance
+	closing.CountedAmount = math.Round(closing.Denominations.Total()*100) / 100
+	closing.Difference = math.Round((closing.CountedAmount-closing.BookBalance)*100) / 100
+	closing.Notes = req.Notes
+	closing.CountedBy = req.UserID
+	closing.ReviewedBy = nil
+	closing.ReviewedAt = nil
+	closing.RejectionReason = ""
+
+	if closing.Difference == 0 {
+		closing.Status = models.CashClosingStatusBalanced
+		if err := s.closingRepo.Save(ctx, closing); err != nil {
+			return nil, err
+		}
+		return closing, nil
+	}
+
+	settings, err := s.closingRepo.GetSettings(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if math.Abs(closing.Difference) <= settings.AutoApproveLimit {
+		closing.Status = models.CashClosingStatusApproved
+		if err := s.postDifference(ctx, closing, settings, req.UserID); err != nil {
+			return nil, err
+		}
+		return closing, nil
+	}
+
+	closing.Status = models.CashClosingStatusPendingApproval
+	if err := s.closingRepo.Save(ctx, closing); err != nil {
+		return nil, err
+	}
+
+	return closing, nil
+}
+
+func (s *cashClosingService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.CashClosing, error) {
+	closing, err := s.closingRepo.GetByID(ctx, tenantID, id)
+	if err == repository.ErrCashClosingNotFound {
+		return nil, ErrCashClosingNotFound
+	}
+	return closing, err
+}
+
+func (s *cashClosingService) List(ctx context.Context, tenantID uuid.UUID, filters repository.CashClosingFilters) ([]models.CashClosing, error) {
+	return s.closingRepo.List(ctx, tenantID, filters)
+}
+
+// Approve posts the counted over/short to the over/short account
+func (s *cashClosingService) Approve(ctx context.Context, tenantID, id, reviewerID uuid.UUID) (*models.CashClosing, error) {
+	closing, err := s.reviewable(ctx, tenantID, id, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.closingRepo.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	closing.Status = models.CashClosingStatusApproved
+	closing.ReviewedBy = &reviewerID
+	closing.ReviewedAt = &now
+
+	if err := s.postDifference(ctx, closing, settings, reviewerID); err != nil {
+		return nil, err
+	}
+
+	return closing, nil
+}
+
+// Reject sends the closing back to the cashier to recount
+func (s *cashClosingService) Reject(ctx context.Context, id uuid.UUID, req RejectCashClosingRequest) (*models.CashClosing, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrRejectionReasonMissing
+	}
+
+	closing, err := s.reviewable(ctx, req.TenantID, id, req.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	closing.Status = models.CashClosingStatusRejected
+	closing.ReviewedBy = &req.ReviewerID
+	closing.ReviewedAt = &now
+	closing.RejectionReason = reason
+
+	if err := s.closingRepo.Save(ctx, closing); err != nil {
+		return nil, err
+	}
+
+	return closing, nil
+}
+
+func (s *cashClosingService) Report(ctx context.Context, tenantID uuid.UUID, cashAccountID *uuid.UUID, date time.Time) (*CashClosingReport, error) {
+	account, err := s.cashAccount(ctx, tenantID, cashAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	closing, err := s.closingRepo.GetByDate(ctx, tenantID, account.ID, date)
+	if err != nil && err != repository.ErrCashClosingNotFound {
+		return nil, err
+	}
+
+	report, err := s.bookPosition(ctx, tenantID, account, date, closing)
+	if err != nil {
+		return nil, err
+	}
+	report.Closing = closing
+	report.ClosingBalance = math.Round((report.BookBalance+report.Adjustment)*100) / 100
+
+	return report, nil
+}
+
+// reviewable loads a closing that is awaiting approval by someone other than
+// the cashier who counted it
+func (s *cashClosingService) reviewable(ctx context.Context, tenantID, id, reviewerID uuid.UUID) (*models.CashClosing, error) {
+	closing, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if closing.Status != models.CashClosingStatusPendingApproval {
+		return nil, ErrCashClosingReviewed
+	}
+	if closing.CountedBy == reviewerID {
+		return nil, ErrCashClosingSelfReview
+	}
+	return closing, nil
+}
+
+// cashAccount returns the given cash account, or the tenant's cash account
+func (s *cashClosingService) cashAccount(ctx context.Context, tenantID uuid.UUID, id *uuid.UUID) (*models.Account, error) {
+	var account *models.Account
+	var err error
+	if id != nil {
+		account, err = s.accountRepo.FindByID(ctx, *id, tenantID)
+	} else {
+		account, err = s.accounts.Resolve(ctx, tenantID, models.PostingTypeCash)
+	}
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	if account.SubType != models.AccountSubTypeCash {
+		return nil, ErrNotCashAccount
+	}
+	return account, nil
+}
+
+// bookPosition works out the cash account's movement for the day from its
+// posted transactions. The over/short journal of the day's closing is kept
+// out of the book figures and reported as the adjustment.
+func (s *cashClosingService) bookPosition(ctx context.Context, tenantID uuid.UUID, account *models.Account, date time.Time, closing *models.CashClosing) (*CashClosingReport, error) {
+	previous, err := s.transactionRepo.GetAccountBalance(ctx, account.ID, tenantID, date.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := s.closingRepo.GetMovements(ctx, tenantID, account.ID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CashClosingReport{
+		Date:           date.Format("2006-01-02"),
+		CashAccount:    account,
+		OpeningBalance: math.Round((account.OpeningBalance+previous)*100) / 100,
+		Movements:      make([]repository.CashMovement, 0, len(movements)),
+	}
+	for _, movement := range movements {
+		if closing != nil && closing.AdjustmentTransactionID != nil && movement.TransactionID == *closing.AdjustmentTransactionID {
+			report.Adjustment += movement.Receipt - movement.Payment
+			continue
+		}
+		report.Receipts += movement.Receipt
+		report.Payments += movement.Payment
+		report.Movements = append(report.Movements, movement)
+	}
+
+	report.Receipts = math.Round(report.Receipts*100) / 100
+	report.Payments = math.Round(report.Payments*100) / 100
+	report.Adjustment = math.Round(report.Adjustment*100) / 100
+	report.BookBalance = math.Round((report.OpeningBalance+report.Receipts-report.Payments)*100) / 100
+	return report, nil
+}
+
+// postDifference journals the closing's over/short against the over/short
+// account and saves the closing. A shortage is written off as an expense;
+// an excess is credited to the same account.
+func (s *cashClosingService) postDifference(ctx context.Context, closing *models.CashClosing, settings *models.CashClosingSettings, userID uuid.UUID) error {
+	var overShort *models.Account
+	if settings.OverShortAccountID != nil {
+		overShort, _ = s.accountRepo.FindByID(ctx, *settings.OverShortAccountID, closing.TenantID)
+	} else {
+		overShort, _ = s.accounts.Resolve(ctx, closing.TenantID, models.PostingTypeCashOverShort)
+	}
+	if overShort == nil {
+		return ErrOverShortAccountNotSet
+	}
+
+	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, closing.TenantID, models.TransactionTypeJournal)
+	if err != nil {
+		return err
+	}
+
+	amount := math.Abs(closing.Difference)
+	date := closing.ClosingDate.Format("2006-01-02")
+	cashLine := models.TransactionLine{AccountID: closing.CashAccountID, LineOrder: 0}
+	overShortLine := models.TransactionLine{AccountID: overShort.ID, LineOrder: 1}
+	var description string
+	if closing.Difference < 0 {
+		description = "Cash short on " + date
+		cashLine.CreditAmount = amount
+		overShortLine.DebitAmount = amount
+	} else {
+		description = "Cash excess on " + date
+		cashLine.DebitAmount = amount
+		overShortLine.CreditAmount = amount
+	}
+	cashLine.Description = description
+	overShortLine.Description = description
+
+	adjustment := &models.Transaction{
+		TenantID:          closing.TenantID,
+		TransactionNumber: txnNumber,
+		TransactionDate:   closing.ClosingDate,
+		TransactionType:   models.TransactionTypeJournal,
+		ReferenceType:     "cash_closing",
+		ReferenceID:       &closing.ID,
+		Description:       description,
+		Notes:             closing.Notes,
+		Subtotal:          amount,
+		TotalAmount:       amount,
+		PaymentMode:       models.PaymentModeCash,
+		Status:            models.TransactionStatusPosted,
+		Lines:             []models.TransactionLine{cashLine, overShortLine},
+		CreatedBy:         userID,
+	}
+
+	closing.OverShortAccountID = &overShort.ID
+	return s.closingRepo.PostAdjustment(ctx, closing, adjustment)
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrCloseChecklistNotFound    = errors.New("close checklist not found")
+	ErrCloseChecklistExists      = errors.New("a close checklist already exists for this period")
+	ErrCloseTaskNotFound         = errors.New("close task not found")
+	ErrCloseTaskTemplateNotFound = errors.New("close task template not found")
+	ErrInvalidCloseTaskStatus    = errors.New("invalid close task status")
+	ErrInvalidClosePeriod        = errors.New("period must be a month as YYYY-MM")
+	ErrInvalidCloseTaskWeight    = errors.New("weight must be between 1 and 100")
+)
+
+// CloseTaskTemplateRequest configures a task of the tenant's month-end
+// close checklist
+type CloseTaskTemplateRequest struct {
+	Title       string     `json:"title" binding:"required,max=200"`
+	Description string     `json:"description"`
+	Category    string     `json:"category" binding:"max=50"`
+	ScreenLink  string     `json:"screen_link" binding:"max=500"`
+	APILink     string     `json:"api_link" binding:"max=500"`
+	AssigneeID  *uuid.UUID `json:"assignee_id"`
+	DueDays     int        `json:"due_days" binding:"min=0,max=90"`
+	Weight      int        `json:"weight"` // Defaults to 1
+	SortOrder   int        `json:"sort_order"`
+	IsActive    *bool      `json:"is_active"` // Defaults to true
+}
+
+// OpenCloseChecklistRequest opens the close checklist of a month from the
+// tenant's templates
+type OpenCloseChecklistRequest struct {
+	TenantID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	Period   string    `json:"period" binding:"required"` // YYYY-MM
+}
+
+// AddCloseTaskRequest adds a task to one month's checklist only
+type AddCloseTaskRequest struct {
+	Title       string     `json:"title" binding:"required,max=200"`
+	Description string     `json:"description"`
+	Category    string     `json:"category" binding:"max=50"`
+	ScreenLink  string     `json:"screen_link" binding:"max=500"`
+	APILink     string     `json:"api_link" binding:"max=500"`
+	AssigneeID  *uuid.UUID `json:"assignee_id"`
+	DueDate     string     `json:"due_date"` // YYYY-MM-DD, defaults to the month's last day
+	Weight      int        `json:"weight"`
+}
+
+// UpdateCloseTaskRequest changes a task's status, assignee, due date or
+// notes. Fields left out are unchanged; a nil assignee ID unassigns it.
+type UpdateCloseTaskRequest struct {
+	Status     *models.CloseTaskStatus `json:"status"`
+	AssigneeID *uuid.UUID              `json:"assignee_id"`
+	DueDate    *string                 `json:"due_date"` // YYYY-MM-DD
+	Notes      *string                 `json:"notes"`
+}
+
+// CloseReadiness is how far a month's close has got
+type CloseReadiness struct {
+	Period      string                      `json:"period"`
+	ChecklistID *uuid.UUID                  `json:"checklist_id,omitempty"` // Nil until the checklist is opened
+	Status      models.CloseChecklistStatus `json:"status,omitempty"`
+	Score       float64                     `json:"score"`
+	TasksTotal  int                         `json:"tasks_total"` // Leaving out skipped tasks
+	TasksDone   int                         `json:"tasks_done"`
+	Overdue     []models.CloseTask          `json:"overdue"`
+}
+
+// CloseChecklistService tracks the month-end close. Each tenant configures
+// the tasks of its close, starting from a default list; a month's
+// checklist copies them with due dates, and its readiness score is the
+// weighted share of the tasks that apply which are done.
+type CloseChecklistService interface {
+	ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]models.CloseTaskTemplate, error)
+	CreateTemplate(ctx context.Context, tenantID uuid.UUID, req CloseTaskTemplateRequest) (*models.CloseTaskTemplate, error)
+	UpdateTemplate(ctx context.Context, tenantID, id uuid.UUID, req CloseTaskTemplateRequest) (*models.CloseTaskTemplate, error)
+	DeleteTemplate(ctx context.Context, tenantID, id uuid.UUID) error
+
+	Open(ctx context.Context, req OpenCloseChecklistRequest) (*models.CloseChecklist, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.CloseChecklist, error)
+	List(ctx context.Context, tenantID uuid.UUID, status models.CloseChecklistStatus) ([]models.CloseChecklist, error)
+	AddTask(ctx context.Context, tenantID, id uuid.UUID, req AddCloseTaskRequest) (*models.CloseChecklist, error)
+	UpdateTask(ctx context.Context, tenantID, userID, id, taskID uuid.UUID, req UpdateCloseTaskRequest) (*models.CloseChecklist, error)
+	// ListTasks returns tasks across the tenant's checklists, such as those
+	// assigned to a user
+	ListTasks(ctx context.Context, tenantID uuid.UUID, filters repository.CloseTaskFilters) ([]models.CloseTask, error)
+
+	// Readiness returns how far the close of a period has got
+	Readiness(ctx context.Context, tenantID uuid.UUID, period string) (*CloseReadiness, error)
+}
+
+type closeChecklistService struct {
+	checklistRepo repository.CloseChecklistRepository
+}
+
+// NewCloseChecklistService creates a new close checklist service
+func NewCloseChecklistService(checklistRepo repository.CloseChecklistRepository) CloseChecklistService {
+	return &closeChecklistService{checklistRepo: checklistRepo}
+}
+
+func (s *closeChecklistService) ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]models.CloseTaskTemplate, error) {
+	if err := s.ensureTemplates(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	return s.checklistRepo.ListTemplates(ctx, tenantID, false)
+}
+
+func (s *closeChecklistService) CreateTemplate(ctx context.Context, tenantID uuid.UUID, req CloseTaskTemplateRequest) (*models.CloseTaskTemplate, error) {
+	if err := s.ensureTemplates(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	template := &models.CloseTaskTemplate{ID: uuid.New(), TenantID: tenantID}
+	if err := applyCloseTaskTemplate(template, req); err != nil {
+		return nil, err
+	}
+	if err := s.checklistRepo.CreateTemplates(ctx, []models.CloseTaskTemplate{*template}); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *closeChecklistService) UpdateTemplate(ctx context.Context, tenantID, id uuid.UUID, req CloseTaskTemplateRequest) (*models.CloseTaskTemplate, error) {
+	template, err := s.checklistRepo.GetTemplate(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrCloseTaskTemplateNotFound) {
+			return nil, ErrCloseTaskTemplateNotFound
+		}
+		return nil, err
+	}
+
+	if err := applyCloseTaskTemplate(template, req); err != nil {
+		return nil, err
+	}
+	if err := s.checklistRepo.SaveTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *closeChecklistService) DeleteTemplate(ctx context.Context, tenantID, id uuid.UUID) error {
+	err := s.checklistRepo.DeleteTemplate(ctx, tenantID, id)
+	if errors.Is(err, repository.ErrCloseTaskTemplateNotFound) {
+		return ErrCloseTaskTemplateNotFound
+	}
+	return err
+}
+
+func (s *closeChecklistService) Open(ctx context.Context, req OpenCloseChecklistRequest) (*models.CloseChecklist, error) {
+	start, err := time.Parse(models.PeriodFormat, req.Period)
+	if err != nil {
+		return nil, ErrInvalidClosePeriod
+	}
+	end := start.AddDate(0, 1, -1)
+
+	exists, err := s.checklistRepo.Exists(ctx, req.TenantID, req.Period)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrCloseChecklistExists
+	}
+
+	if err := s.ensureTemplates(ctx, req.TenantID); err != nil {
+		return nil, err
+	}
+	templates, err := s.checklistRepo.ListTemplates(ctx, req.TenantID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := &models.CloseChecklist{
+		ID:        uuid.New(),
+		TenantID:  req.TenantID,
+		Period:    req.Period,
+		StartDate: start,
+		EndDate:   end,
+		CreatedBy: req.UserID,
+	}
+	for _, template := range templates {
+		templateID := template.ID
+		checklist.Tasks = append(checklist.Tasks, models.CloseTask{
+			ID:          uuid.New(),
+			ChecklistID: checklist.ID,
+			TenantID:    req.TenantID,
+			TemplateID:  &templateID,
+			Title:       template.Title,
+			Description: template.Description,
+			Category:    template.Category,
+			ScreenLink:  template.ScreenLink,
+			APILink:     template.APILink,
+			Weight:      template.Weight,
+			SortOrder:   template.SortOrder,
+			AssigneeID:  template.AssigneeID,
+			DueDate:     end.AddDate(0, 0, template.DueDays),
+			Status:      models.CloseTaskPending,
+		})
+	}
+	checklist.UpdateStatus()
+
+	if err := s.checklistRepo.Create(ctx, checklist); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, req.TenantID, checklist.ID)
+}
+
+func (s *closeChecklistService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.CloseChecklist, error) {
+	checklist, err := s.checklistRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrCloseChecklistNotFound) {
+			return nil, ErrCloseChecklistNotFound
+		}
+		return nil, err
+	}
+	return checklist, nil
+}
+
+func (s *closeChecklistService) List(ctx context.Context, tenantID uuid.UUID, status models.CloseChecklistStatus) ([]models.CloseChecklist, error) {
+	return s.checklistRepo.List(ctx, tenantID, status)
+}
+
+func (s *closeChecklistService) AddTask(ctx context.Context, tenantID, id uuid.UUID, req AddCloseTaskRequest) (*models.CloseChecklist, error) {
+	checklist, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	weight, err := closeTaskWeight(req.Weight)
+	if err != nil {
+		return nil, err
+	}
+	dueDate := checklist.EndDate
+	if req.DueDate != "" {
+		if dueDate, err = time.Parse("2006-01-02", req.DueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	sortOrder := 10
+	if n := len(checklist.Tasks); n > 0 {
+		sortOrder = checklist.Tasks[n-1].SortOrder + 10
+	}
+
+	task := models.CloseTask{
+		ID:          uuid.New(),
+		ChecklistID: checklist.ID,
+		TenantID:    tenantID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Category:    req.Category,
+		ScreenLink:  req.ScreenLink,
+		APILink:     req.APILink,
+		Weight:      weight,
+		SortOrder:   sortOrder,
+		AssigneeID:  req.AssigneeID,
+		DueDate:     dueDate,
+		Status:      models.CloseTaskPending,
+	}
+	if err := s.checklistRepo.CreateTask(ctx, &task); err != nil {
+		return nil, err
+	}
+
+	checklist.Tasks = append(checklist.Tasks, task)
+	checklist.UpdateStatus()
+	if err := s.checklistRepo.Save(ctx, checklist); err != nil {
+		return nil, err
+	}
+	return checklist, nil
+}
+
+func (s *closeChecklistService) UpdateTask(ctx context.Context, tenantID, userID, id, taskID uuid.UUID, req UpdateCloseTaskRequest) (*models.CloseChecklist, error) {
+	checklist, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *models.CloseTask
+	for i := range checklist.Tasks {
+		if checklist.Tasks[i].ID == taskID {
+			task = &checklist.Tasks[i]
+			break
+		}
+	}
+	if task == nil {
+		return nil, ErrCloseTaskNotFound
+	}
+
+	if req.Status != nil && *req.Status != task.Status {
+		if !req.Status.IsValid() {
+			return nil, ErrInvalidCloseTaskStatus
+		}
+		task.Status = *req.Status
+		task.CompletedBy = nil
+		task.CompletedAt = nil
+		if task.Status.IsFinished() {
+			now := time.Now()
+			task.CompletedBy = &userID
+			task.CompletedAt = &now
+		}
+	}
+	if req.AssigneeID != nil {
+		task.AssigneeID = req.AssigneeID
+		if *req.AssigneeID == uuid.Nil {
+			task.AssigneeID = nil
+		}
+	}
+	if req.DueDate != nil {
+		dueDate, err := time.Parse("2006-01-02", *req.DueDate)
+		if err != nil {
+			return nil, err
+		}
+		task.DueDate = dueDate
+	}
+	if req.Notes != nil {
+		task.Notes = *req.Notes
+	}
+
+	if err := s.checklistRepo.SaveTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	checklist.UpdateStatus()
+	if err := s.checklistRepo.Save(ctx, checklist); err != nil {
+		return nil, err
+	}
+	return checklist, nil
+}
+
+func (s *closeChecklistService) ListTasks(ctx context.Context, tenantID uuid.UUID, filters repository.CloseTaskFilters) ([]models.CloseTask, error) {
+	return s.checklistRepo.ListTasks(ctx, tenantID, filters)
+}
+
+func (s *closeChecklistService) Readiness(ctx context.Context, tenantID uuid.UUID, period string) (*CloseReadiness, error) {
+	if _, err := time.Parse(models.PeriodFormat, period); err != nil {
+		return nil, ErrInvalidClosePeriod
+	}
+
+	readiness := &CloseReadiness{Period: period, Overdue: []models.CloseTask{}}
+	checklist, err := s.checklistRepo.GetByPeriod(ctx, tenantID, period)
+	if errors.Is(err, repository.ErrCloseChecklistNotFound) {
+		return readiness, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	readiness.ChecklistID = &checklist.ID
+	readiness.Status = checklist.Status
+	readiness.Score = checklist.ReadinessScore
+
+	today := time.Now().Truncate(24 * time.Hour)
+	for _, task := range checklist.Tasks {
+		if task.Status == models.CloseTaskSkipped {
+			continue
+		}
+		readiness.TasksTotal++
+		if task.Status == models.CloseTaskDone {
+			readiness.TasksDone++
+		}
+		if task.IsOverdue(today) {
+			readiness.Overdue = append(readiness.Overdue, task)
+		}
+	}
+	return readiness, nil
+}
+
+// ensureTemplates sets up the default checklist for a tenant that has
+// never configured one
+func (s *closeChecklistService) ensureTemplates(ctx context.Context, tenantID uuid.UUID) error {
+	exists, err := s.checklistRepo.HasTemplates(ctx, tenantID)
+	if err != nil || exists {
+		return err
+	}
+	return s.checklistRepo.CreateTemplates(ctx, models.DefaultCloseTaskTemplates(tenantID))
+}
+
+func applyCloseTaskTemplate(template *models.CloseTaskTemplate, req CloseTaskTemplateRequest) error {
+	weight, err := closeTaskWeight(req.Weight)
+	if err != nil {
+		return err
+	}
+
+	template.Title = strings.TrimSpace(req.Title)
+	template.Description = req.Description
+	template.Category = req.Category
+	template.ScreenLink = req.ScreenLink
+	template.APILink = req.APILink
+	template.AssigneeID = req.AssigneeID
+	template.DueDays = req.DueDays
+	template.Weight = weight
+	template.SortOrder = req.SortOrder
+	template.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+func closeTaskWeight(weight int) (int, error) {
+	if weight == 0 {
+		return 1, nil
+	}
+	if weight < 0 || weight > 100 {
+		return 0, ErrInvalidCloseTaskWeight
+	}
+	return weight, nil
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/comments"
+)
+
+// TransactionCommentLookup finds the transactions comment threads are kept
+// on
+func TransactionCommentLookup(repo repository.TransactionRepository) comments.Lookup {
+	return func(ctx context.Context, tenantID, id uuid.UUID) (string, error) {
+		transaction, err := repo.FindByID(ctx, id, tenantID)
+		if err != nil {
+			return "", comments.ErrDocumentNotFound
+		}
+		return transaction.TransactionNumber, nil
+	}
+}
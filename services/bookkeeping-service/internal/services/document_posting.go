@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+)
+
+// Documents the invoice service posts to the ledger
+const (
+	DocumentPostingInvoice = "invoice"
+	DocumentPostingBill    = "bill"
+)
+
+// Rounding modes of the invoice service's rounding rules
+const (
+	roundingNone    = "none"
+	roundingNearest = "nearest"
+	roundingUp      = "up"
+	roundingDown    = "down"
+)
+
+// defaultRoundingRule is the rule the invoice service uses for a tenant
+// that has not set one: the nearest rupee
+var defaultRoundingRule = clients.RoundingRule{Mode: roundingNearest, RoundTo: 1}
+
+// DocumentPostingRequest is an invoice sent or a bill approved in the
+// invoice service. TotalAmount is the amount due after rounding; RoundOff
+// is what rounding added to it, and goes to RoundingAccountID, or to the
+// Round Off account when the document's rule names none.
+type DocumentPostingRequest struct {
+	Kind           string     `json:"kind" binding:"required,oneof=invoice bill"`
+	Date           string     `json:"date" binding:"required"`
+	DocumentID     *uuid.UUID `json:"document_id" binding:"required"` // posted once per document
+	DocumentNumber string     `json:"document_number"`
+	PartyID        *uuid.UUID `json:"party_id"`
+	PartyName      string     `json:"party_name"`
+	TaxableAmount  float64    `json:"taxable_amount"`
+	TaxAmount      float64    `json:"tax_amount"` // GST
+	TCSAmount      float64    `json:"tcs_amount"` // TCS collected on an invoice
+	RoundOff       float64    `json:"round_off"`
+	TotalAmount    float64    `json:"total_amount" binding:"required"`
+
+	// A bill's GST is input credit when eligible, else part of its cost.
+	// Under reverse charge the tenant owes the GST rather than the vendor.
+	ITCEligible   bool `json:"itc_eligible"`
+	ReverseCharge bool `json:"reverse_charge"`
+
+	RoundingAccountID *uuid.UUID `json:"rounding_account_id"`
+
+	// How the amounts were computed
+	SupportingDetails *models.SupportingDetails `json:"supporting_details"`
+}
+
+// PostDocument posts an invoice (receivable against sales, GST and TCS) or
+// a bill (purchases and GST input against payable), with the round-off on
+// its own line. A document already posted is returned rather than posted
+// again.
+func (s *transactionService) PostDocument(ctx context.Context, tenantID, userID uuid.UUID, req DocumentPostingRequest) (*models.Transaction, error) {
+	txnDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	taxAmount := req.TaxAmount
+	if req.ReverseCharge {
+		taxAmount = 0
+	}
+	expected := req.TaxableAmount + taxAmount + req.TCSAmount + req.RoundOff
+	if req.TotalAmount <= 0 || req.TaxAmount < 0 || req.TCSAmount < 0 ||
+		math.Abs(expected-req.TotalAmount) >= 0.005 {
+		return nil, ErrInvalidAmount
+	}
+
+	if existing, err := s.transactionRepo.FindBySource(ctx, *req.DocumentID, tenantID); err == nil {
+		return existing, nil
+	}
+
+	transactionType := models.TransactionTypeSale
+	partyType := "customer"
+	description := "Invoice " + req.DocumentNumber
+	var entries []documentEntry
+	if req.Kind == DocumentPostingInvoice {
+		entries = []documentEntry{
+			{models.PostingTypeReceivable, description, req.TotalAmount, true},
+			{models.PostingTypeSalesIncome, "Sales", req.TaxableAmount, false},
+			{models.PostingTypeGSTOutput, "GST on sales", req.TaxAmount, false},
+			{models.PostingTypeTCSPayable, "TCS collected", req.TCSAmount, false},
+		}
+	} else {
+		transactionType = models.TransactionTypePurchase
+		partyType = "vendor"
+		description = "Bill " + req.DocumentNumber
+
+		purchases, inputCredit := req.TaxableAmount, req.TaxAmount
+		if !req.ITCEligible {
+			purchases, inputCredit = purchases+req.TaxAmount, 0
+		}
+		entries = []documentEntry{
+			{models.PostingTypePurchases, "Purchases", purchases, true},
+			{models.PostingTypeGSTInput, "GST input credit", inputCredit, true},
+			{models.PostingTypePayable, description, req.TotalAmount, false},
+		}
+		if req.ReverseCharge {
+			entries = append(entries, documentEntry{models.PostingTypeGSTOutput, "GST payable under reverse charge", req.TaxAmount, false})
+		}
+	}
+
+	var lines []models.TransactionLine
+	for _, entry := range entries {
+		if entry.amount == 0 {
+			continue
+		}
+		account, _ := s.accounts.Resolve(ctx, tenantID, entry.postingType)
+		if account == nil {
+			return nil, ErrAccountNotFound
+		}
+		lines = append(lines, documentLine(account.ID, entry.description, entry.amount, entry.debit))
+	}
+
+	// Rounding up adds to what the customer owes, an income, and to what
+	// the tenant owes the vendor, an expense
+	if req.RoundOff != 0 {
+		roundOffAccount, _ := s.roundOffAccount(ctx, tenantID, req.RoundingAccountID)
+		if roundOffAccount == nil {
+			return nil, ErrAccountNotFound
+		}
+		debit := req.Kind == DocumentPostingBill
+		if req.RoundOff < 0 {
+			debit = !debit
+		}
+		lines = append(lines, documentLine(roundOffAccount.ID, "Round off", math.Abs(req.RoundOff), debit))
+	}
+	for i := range lines {
+		lines[i].LineOrder = i
+	}
+
+	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, tenantID, transactionType)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		TenantID:          tenantID,
+		TransactionNumber: txnNumber,
+		TransactionDate:   txnDate,
+		TransactionType:   transactionType,
+		ReferenceType:     req.Kind,
+		ReferenceID:       req.DocumentID,
+		SourceID:          req.DocumentID,
+		PartyID:           req.PartyID,
+		PartyName:         req.PartyName,
+		PartyType:         partyType,
+		Description:       description,
+		Subtotal:          req.TaxableAmount,
+		TaxAmount:         req.TaxAmount,
+		RoundOffAmount:    req.RoundOff,
+		TotalAmount:       req.TotalAmount,
+		Status:            models.TransactionStatusPosted,
+		Lines:             lines,
+		SupportingDetail:  supportingDetail(tenantID, req.Kind, req.SupportingDetails),
+		CreatedBy:         userID,
+	}
+
+	if transaction.Lines, err = s.validate(ctx, tenantID, transaction.Lines); err != nil {
+		return nil, err
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// roundingRule returns the tenant's rounding rule for the document type,
+// or the default when the invoice service could not be asked
+func (s *transactionService) roundingRule(ctx context.Context, tenantID uuid.UUID, authorization, documentType string) clients.RoundingRule {
+	if s.invoices == nil || authorization == "" {
+		return defaultRoundingRule
+	}
+	rule, err := s.invoices.GetRoundingRule(ctx, authorization, tenantID.String(), documentType)
+	if err != nil {
+		log.Printf("rounding: failed to read %s rule of tenant %s, rounding to the rupee: %v", documentType, tenantID, err)
+		return defaultRoundingRule
+	}
+	return *rule
+}
+
+// roundOffAccount returns the account a rounding difference is posted to:
+// the one a rounding rule names, else the Round Off account
+func (s *transactionService) roundOffAccount(ctx context.Context, tenantID uuid.UUID, accountID *uuid.UUID) (*models.Account, error) {
+	if accountID != nil {
+		return s.accountRepo.FindByID(ctx, *accountID, tenantID)
+	}
+	return s.accounts.Resolve(ctx, tenantID, models.PostingTypeRoundOff)
+}
+
+// roundToUnit rounds amount to a multiple of unit in the given mode, as the
+// invoice service rounds document totals. Amounts are returned unchanged
+// when rounding is off. The arithmetic is in paise, so a total already on
+// a multiple is never pushed past it.
+func roundToUnit(amount, unit float64, mode string) float64 {
+	paise := math.Round(amount * 100)
+	step := math.Round(unit * 100)
+	if step <= 0 {
+		return amount
+	}
+
+	units := paise / step
+	switch mode {
+	case roundingNearest:
+		units = math.Round(units)
+	case roundingUp:
+		units = math.Ceil(units)
+	case roundingDown:
+		units = math.Floor(units)
+	default:
+		return amount
+	}
+	return units * step / 100
+}
+
+// documentEntry is a line of a document's posting, before its posting type
+// is resolved to one of the tenant's accounts
+type documentEntry struct {
+	postingType models.PostingType
+	description string
+	amount      float64
+	debit       bool
+}
+
+func documentLine(accountID uuid.UUID, description string, amount float64, debit bool) models.TransactionLine {
+	line := models.TransactionLine{AccountID: accountID, Description: description}
+	if debit {
+		line.DebitAmount = amount
+	} else {
+		line.CreditAmount = amount
+	}
+	return line
+}
@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrNotInGroup             = errors.New("tenant is not part of a group")
+	ErrCounterpartyNotInGroup = errors.New("counterparty is not another tenant in the same group")
+	ErrTenantUnavailable      = errors.New("unable to reach the tenant service")
+)
+
+// Inter-company mismatch issues
+const (
+	InterCompanyNotMirrored   = "not_mirrored"    // tagged by us, no mirror in their books
+	InterCompanyMissingHere   = "missing_here"    // tagged by them, no mirror in our books
+	InterCompanyMirrorMissing = "mirror_missing"  // the mirror was deleted
+	InterCompanyStatusDiffers = "status_mismatch" // one side voided
+	InterCompanyAmountDiffers = "amount_mismatch"
+	InterCompanyDateDiffers   = "date_mismatch"
+)
+
+// mirrorTypes maps an inter-company transaction type to the type recorded
+// in the counterparty's books
+var mirrorTypes = map[models.TransactionType]models.TransactionType{
+	models.TransactionTypeSale:     models.TransactionTypePurchase,
+	models.TransactionTypePurchase: models.TransactionTypeSale,
+	models.TransactionTypeReceipt:  models.TransactionTypePayment,
+	models.TransactionTypePayment:  models.TransactionTypeReceipt,
+	models.TransactionTypeJournal:  models.TransactionTypeJournal,
+}
+
+// InterCompanyTransactionRequest records a transaction with another group
+// tenant. Sales, purchases, receipts and payments are posted to the default
+// accounts on both sides; journals give the lines for each side.
+type InterCompanyTransactionRequest struct {
+	TenantID      uuid.UUID `json:"-"`
+	UserID        uuid.UUID `json:"-"`
+	Authorization string    `json:"-"` // forwarded to the tenant service
+
+	TransactionDate       string     `json:"transaction_date" binding:"required"`
+	TransactionType       string     `json:"transaction_type" binding:"required,oneof=sale purchase receipt payment journal"`
+	CounterpartyTenantID  uuid.UUID  `json:"counterparty_tenant_id" binding:"required"`
+	Amount                float64    `json:"amount"`
+	AccountID             *uuid.UUID `json:"account_id"`              // income or expense account, sales and purchases only
+	CounterpartyAccountID *uuid.UUID `json:"counterparty_account_id"` // the same, in the counterparty's books
+	PaymentMode           string     `json:"payment_mode"`            // cash or bank, receipts and payments only
+	Description           string     `json:"description"`
+	Notes                 string     `json:"notes"`
+
+	Lines             []TransactionLineRequest `json:"lines"`              // journals only
+	CounterpartyLines []TransactionLineRequest `json:"counterparty_lines"` // journals only
+}
+
+// InterCompanyTransaction is an inter-company transaction and its mirror
+type InterCompanyTransaction struct {
+	Transaction *models.Transaction `json:"transaction"`
+	Mirror      *models.Transaction `json:"mirror"`
+}
+
+// InterCompanyReport reconciles inter-company transactions with the other
+// tenants of the group
+type InterCompanyReport struct {
+	FromDate       string                 `json:"from_date,omitempty"`
+	ToDate         string                 `json:"to_date,omitempty"`
+	Counterparties []InterCompanyBalance  `json:"counterparties"`
+	Mismatches     []InterCompanyMismatch `json:"mismatches"`
+}
+
+// InterCompanyBalance compares posted inter-company totals with a tenant as
+// recorded on each side
+type InterCompanyBalance struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	TenantName string    `json:"tenant_name"`
+	OurTotal   float64   `json:"our_total"`
+	TheirTotal float64   `json:"their_total"`
+	Difference float64   `json:"difference"`
+}
+
+// InterCompanyMismatch is an inter-company transaction whose two sides do
+// not agree
+type InterCompanyMismatch struct {
+	Issue                string             `json:"issue"`
+	CounterpartyTenantID uuid.UUID          `json:"counterparty_tenant_id"`
+	CounterpartyName     string             `json:"counterparty_name"`
+	Ours                 *InterCompanyEntry `json:"ours,omitempty"`
+	Theirs               *InterCompanyEntry `json:"theirs,omitempty"`
+}
+
+// InterCompanyEntry summarizes one side of an inter-company transaction
+type InterCompanyEntry struct {
+	TransactionID     uuid.UUID                `json:"transaction_id"`
+	TransactionNumber string                   `json:"transaction_number"`
+	TransactionDate   time.Time                `json:"transaction_date"`
+	TransactionType   models.TransactionType   `json:"transaction_type"`
+	TotalAmount       float64                  `json:"total_amount"`
+	Status            models.TransactionStatus `json:"status"`
+}
+
+// InterCompanyService handles transactions between tenants of a group
+type InterCompanyService interface {
+	CreateTransaction(ctx context.Context, req InterCompanyTransactionRequest) (*InterCompanyTransaction, error)
+	MismatchReport(ctx context.Context, tenantID uuid.UUID, authorization, fromDate, toDate string) (*InterCompanyReport, error)
+}
+
+type interCompanyService struct {
+	transactionRepo repository.TransactionRepository
+	accountRepo     repository.AccountRepository
+	accounts        AccountMappingService
+	validator       *JournalValidator
+	tenantClient    clients.TenantClient
+}
+
+// NewInterCompanyService creates a new inter-company service
+func NewInterCompanyService(
+	transactionRepo repository.TransactionRepository,
+	accountRepo repository.AccountRepository,
+	accounts AccountMappingService,
+	validator *JournalValidator,
+	tenantClient clients.TenantClient,
+) InterCompanyService {
+	return &interCompanyService{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		accounts:        accounts,
+		validator:       validator,
+		tenantClient:    tenantClient,
+	}
+}
+
+// CreateTransaction posts the transaction in the tenant's books and the
+// mirrored transaction in the counterparty's (a sale becomes a purchase, a
+// receipt a payment). Both are tagged with the other tenant so they are
+// eliminated on consolidation.
+func (s *interCompanyService) CreateTransaction(ctx context.Context, req InterCompanyTransactionRequest) (*InterCompanyTransaction, error) {
+	txnDate, err := time.Parse("2006-01-02", req.TransactionDate)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.getGroup(ctx, req.Authorization, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	self, ok := group.Member(req.TenantID)
+	if !ok {
+		return nil, ErrNotInGroup
+	}
+	counterparty, ok := group.Member(req.CounterpartyTenantID)
+	if !ok || req.CounterpartyTenantID == req.TenantID {
+		return nil, ErrCounterpartyNotInGroup
+	}
+
+	txnType := models.TransactionType(req.TransactionType)
+	mirrorType := mirrorTypes[txnType]
+
+	var lines, mirrorLines []models.TransactionLine
+	var amount, mirrorAmount float64
+	if txnType == models.TransactionTypeJournal {
+		if lines, amount, err = s.journalLines(ctx, req.TenantID, req.Lines); err != nil {
+			return nil, err
+		}
+		if mirrorLines, mirrorAmount, err = s.journalLines(ctx, req.CounterpartyTenantID, req.CounterpartyLines); err != nil {
+			return nil, err
+		}
+	} else {
+		if req.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+		amount = math.Round(req.Amount*100) / 100
+		mirrorAmount = amount
+		if lines, err = s.entryLines(ctx, req.TenantID, txnType, req.AccountID, req.PaymentMode, amount); err != nil {
+			return nil, err
+		}
+		if mirrorLines, err = s.entryLines(ctx, req.CounterpartyTenantID, mirrorType, req.CounterpartyAccountID, req.PaymentMode, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, req.TenantID, txnType)
+	if err != nil {
+		return nil, err
+	}
+	mirrorNumber, err := s.transactionRepo.GetNextNumber(ctx, req.CounterpartyTenantID, mirrorType)
+	if err != nil {
+		return nil, err
+	}
+
+	var paymentMode models.PaymentMode
+	if txnType == models.TransactionTypeReceipt || txnType == models.TransactionTypePayment {
+		paymentMode = models.PaymentModeBank
+		if req.PaymentMode == string(models.PaymentModeCash) {
+			paymentMode = models.PaymentModeCash
+		}
+	}
+
+	transaction := &models.Transaction{
+		TenantID:             req.TenantID,
+		TransactionNumber:    txnNumber,
+		TransactionDate:      txnDate,
+		TransactionType:      txnType,
+		ReferenceType:        "inter_company",
+		PartyName:            counterparty.Tenant.Name,
+		PartyType:            interCompanyPartyType(txnType),
+		CounterpartyTenantID: &req.CounterpartyTenantID,
+		Description:          req.Description,
+		Notes:                req.Notes,
+		Subtotal:             amount,
+		TotalAmount:          amount,
+		PaymentMode:          paymentMode,
+		Status:               models.TransactionStatusPosted,
+		Lines:                lines,
+		CreatedBy:            req.UserID,
+	}
+
+	mirror := &models.Transaction{
+		TenantID:             req.CounterpartyTenantID,
+		TransactionNumber:    mirrorNumber,
+		TransactionDate:      txnDate,
+		TransactionType:      mirrorType,
+		ReferenceType:        "inter_company",
+		PartyName:            self.Tenant.Name,
+		PartyType:            interCompanyPartyType(mirrorType),
+		CounterpartyTenantID: &req.TenantID,
+		Description:          req.Description,
+		Subtotal:             mirrorAmount,
+		TotalAmount:          mirrorAmount,
+		PaymentMode:          paymentMode,
+		Status:               models.TransactionStatusPosted,
+		Lines:                mirrorLines,
+		CreatedBy:            req.UserID,
+	}
+
+	if err := s.transactionRepo.CreateMirrored(ctx, transaction, mirror); err != nil {
+		return nil, err
+	}
+
+	return &InterCompanyTransaction{Transaction: transaction, Mirror: mirror}, nil
+}
+
+// MismatchReport compares the tenant's inter-company transactions with the
+// other side's records, both for mirrored pairs and for transactions tagged
+// by hand on either side
+func (s *interCompanyService) MismatchReport(ctx context.Context, tenantID uuid.UUID, authorization, fromDate, toDate string) (*InterCompanyReport, error) {
+	group, err := s.getGroup(ctx, authorization, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var others []uuid.UUID
+	for _, m := range group.Members {
+		if m.TenantID != tenantID {
+			others = append(others, m.TenantID)
+		}
+	}
+
+	report := &InterCompanyReport{
+		FromDate:       fromDate,
+		ToDate:         toDate,
+		Counterparties: []InterCompanyBalance{},
+		Mismatches:     []InterCompanyMismatch{},
+	}
+	if len(others) == 0 {
+		return report, nil
+	}
+
+	ours, err := s.transactionRepo.FindInterCompany(ctx, []uuid.UUID{tenantID}, others, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := s.transactionRepo.FindInterCompany(ctx, others, []uuid.UUID{tenantID}, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[uuid.UUID]*InterCompanyBalance)
+	for _, id := range others {
+		member, _ := group.Member(id)
+		balances[id] = &InterCompanyBalance{TenantID: id, TenantName: member.Tenant.Name}
+	}
+	for _, t := range ours {
+		if t.Status == models.TransactionStatusPosted {
+			balances[*t.CounterpartyTenantID].OurTotal += t.TotalAmount
+		}
+	}
+	for _, t := range theirs {
+		if t.Status == models.TransactionStatusPosted {
+			balances[t.TenantID].TheirTotal += t.TotalAmount
+		}
+	}
+	for _, id := range others {
+		b := balances[id]
+		b.OurTotal = math.Round(b.OurTotal*100) / 100
+		b.TheirTotal = math.Round(b.TheirTotal*100) / 100
+		b.Difference = math.Round((b.OurTotal-b.TheirTotal)*100) / 100
+		report.Counterparties = append(report.Counterparties, *b)
+	}
+
+	// Index both sides, fetching partners that fall outside the date range
+	byID := make(map[uuid.UUID]models.Transaction, len(ours)+len(theirs))
+	for _, t := range ours {
+		byID[t.ID] = t
+	}
+	for _, t := range theirs {
+		byID[t.ID] = t
+	}
+	var missing []uuid.UUID
+	for _, t := range append(append([]models.Transaction{}, ours...), theirs...) {
+		if t.MirrorTransactionID != nil {
+			if _, ok := byID[*t.MirrorTransactionID]; !ok {
+				missing = append(missing, *t.MirrorTransactionID)
+			}
+		}
+	}
+	partners, err := s.transactionRepo.FindMirrors(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range partners {
+		byID[t.ID] = t
+	}
+
+	name := func(id uuid.UUID) string {
+		if member, ok := group.Member(id); ok {
+			return member.Tenant.Name
+		}
+		return ""
+	}
+
+	paired := make(map[uuid.UUID]bool)
+	for _, t := range ours {
+		mismatch := InterCompanyMismatch{
+			CounterpartyTenantID: *t.CounterpartyTenantID,
+			CounterpartyName:     name(*t.CounterpartyTenantID),
+			Ours:                 interCompanyEntry(&t),
+		}
+
+		if t.MirrorTransactionID == nil {
+			mismatch.Issue = InterCompanyNotMirrored
+			report.Mismatches = append(report.Mismatches, mismatch)
+			continue
+		}
+
+		mirror, ok := byID[*t.MirrorTransactionID]
+		if !ok {
+			mismatch.Issue = InterCompanyMirrorMissing
+			report.Mismatches = append(report.Mismatches, mismatch)
+			continue
+		}
+		paired[mirror.ID] = true
+		mismatch.Theirs = interCompanyEntry(&mirror)
+
+		switch {
+		case t.Status != mirror.Status:
+			mismatch.Issue = InterCompanyStatusDiffers
+		case math.Abs(t.TotalAmount-mirror.TotalAmount) >= 0.01:
+			mismatch.Issue = InterCompanyAmountDiffers
+		case !t.TransactionDate.Equal(mirror.TransactionDate):
+			mismatch.Issue = InterCompanyDateDiffers
+		default:
+			continue
+		}
+		report.Mismatches = append(report.Mismatches, mismatch)
+	}
+
+	for _, t := range theirs {
+		if paired[t.ID] {
+			continue
+		}
+
+		mismatch := InterCompanyMismatch{
+			CounterpartyTenantID: t.TenantID,
+			CounterpartyName:     name(t.TenantID),
+			Theirs:               interCompanyEntry(&t),
+		}
+
+		// A mirror of ours that is out of range or was changed on our side
+		if t.MirrorTransactionID != nil {
+			if own, ok := byID[*t.MirrorTransactionID]; ok {
+				mismatch.Ours = interCompanyEntry(&own)
+				switch {
+				case own.Status != t.Status:
+					mismatch.Issue = InterCompanyStatusDiffers
+				case math.Abs(own.TotalAmount-t.TotalAmount) >= 0.01:
+					mismatch.Issue = InterCompanyAmountDiffers
+				default:
+					mismatch.Issue = InterCompanyDateDiffers
+				}
+				report.Mismatches = append(report.Mismatches, mismatch)
+				continue
+			}
+		}
+
+		mismatch.Issue = InterCompanyMissingHere
+		report.Mismatches = append(report.Mismatches, mismatch)
+	}
+
+	return report, nil
+}
+
+func (s *interCompanyService) getGroup(ctx context.Context, authorization string, tenantID uuid.UUID) (*clients.TenantGroup, error) {
+	group, err := s.tenantClient.GetGroup(ctx, authorization, tenantID)
+	if err != nil {
+		if errors.Is(err, clients.ErrNotFound) {
+			return nil, ErrNotInGroup
+		}
+		return nil, ErrTenantUnavailable
+	}
+	return group, nil
+}
+
+// entryLines builds the two-line entry for an inter-company sale, purchase,
+// receipt or payment from the tenant's default accounts. accountID overrides
+// the income or expense account of a sale or purchase.
+func (s *interCompanyService) entryLines(ctx context.Context, tenantID uuid.UUID, txnType models.TransactionType, accountID *uuid.UUID, paymentMode string, amount float64) ([]models.TransactionLine, error) {
+	cash := cashOrBank(paymentMode)
+
+	var debit, credit models.PostingType
+	var description string
+	switch txnType {
+	case models.TransactionTypeSale:
+		debit, credit, description = models.PostingTypeReceivable, models.PostingTypeSalesIncome, "Inter-company sale"
+	case models.TransactionTypePurchase:
+		debit, credit, description = models.PostingTypePurchases, models.PostingTypePayable, "Inter-company purchase"
+	case models.TransactionTypeReceipt:
+		debit, credit, description = cash, models.PostingTypeReceivable, "Inter-company receipt"
+	case models.TransactionTypePayment:
+		debit, credit, description = models.PostingTypePayable, cash, "Inter-company payment"
+	}
+
+	debitAccount, _ := s.accounts.Resolve(ctx, tenantID, debit)
+	creditAccount, _ := s.accounts.Resolve(ctx, tenantID, credit)
+
+	if accountID != nil {
+		account, err := s.accountRepo.FindByID(ctx, *accountID, tenantID)
+		if err != nil {
+			return nil, ErrAccountNotFound
+		}
+		switch txnType {
+		case models.TransactionTypeSale:
+			creditAccount = account
+		case models.TransactionTypePurchase:
+			debitAccount = account
+		}
+	}
+
+	if debitAccount == nil || creditAccount == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return []models.TransactionLine{
+		{
+			AccountID:   debitAccount.ID,
+			Description: description,
+			DebitAmount: amount,
+			LineOrder:   0,
+		},
+		{
+			AccountID:    creditAccount.ID,
+			Description:  description,
+			CreditAmount: amount,
+			LineOrder:    1,
+		},
+	}, nil
+}
+
+// journalLines validates one side of an inter-company journal the way
+// journals are validated in the tenant's own books, posting any rounding
+// difference to its Round Off account
+func (s *interCompanyService) journalLines(ctx context.Context, tenantID uuid.UUID, reqLines []TransactionLineRequest) ([]models.TransactionLine, float64, error) {
+	var lines []models.TransactionLine
+	var totalDebit float64
+	for i, lineReq := range reqLines {
+		if _, err := s.accountRepo.FindByID(ctx, lineReq.AccountID, tenantID); err != nil {
+			return nil, 0, ErrAccountNotFound
+		}
+
+		lines = append(lines, models.TransactionLine{
+			AccountID:    lineReq.AccountID,
+			Description:  lineReq.Description,
+			DebitAmount:  lineReq.DebitAmount,
+			CreditAmount: lineReq.CreditAmount,
+			Currency:     strings.ToUpper(strings.TrimSpace(lineReq.Currency)),
+			LineOrder:    i,
+		})
+		totalDebit += lineReq.DebitAmount
+	}
+
+	lines, err := balanceJournal(ctx, s.validator, s.accounts, tenantID, lines)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return lines, totalDebit, nil
+}
+
+func interCompanyPartyType(txnType models.TransactionType) string {
+	switch txnType {
+	case models.TransactionTypeSale, models.TransactionTypeReceipt:
+		return "customer"
+	case models.TransactionTypePurchase, models.TransactionTypePayment:
+		return "vendor"
+	}
+	return ""
+}
+
+func interCompanyEntry(t *models.Transaction) *InterCompanyEntry {
+	return &InterCompanyEntry{
+		TransactionID:     t.ID,
+		TransactionNumber: t.TransactionNumber,
+		TransactionDate:   t.TransactionDate,
+		TransactionType:   t.TransactionType,
+		TotalAmount:       t.TotalAmount,
+		Status:            t.Status,
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+)
+
+// JournalImbalance is how far the debits and credits of one currency in a
+// journal entry are apart
+type JournalImbalance struct {
+	Currency   string  `json:"currency"`
+	Debit      float64 `json:"debit"`
+	Credit     float64 `json:"credit"`
+	Difference float64 `json:"difference"` // Debit - Credit
+	Tolerance  float64 `json:"tolerance"`
+}
+
+// UnbalancedJournalError is returned when a journal entry's debits and
+// credits do not agree in one or more currencies. It matches
+// ErrTransactionNotBalanced with errors.Is.
+type UnbalancedJournalError struct {
+	Imbalances []JournalImbalance
+}
+
+func (e *UnbalancedJournalError) Error() string {
+	parts := make([]string, 0, len(e.Imbalances))
+	for _, imbalance := range e.Imbalances {
+		parts = append(parts, fmt.Sprintf("%s debits %.2f, credits %.2f", imbalance.Currency, imbalance.Debit, imbalance.Credit))
+	}
+	return ErrTransactionNotBalanced.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *UnbalancedJournalError) Is(target error) bool {
+	return target == ErrTransactionNotBalanced
+}
+
+// Details returns the imbalances as error details, keyed by currency
+func (e *UnbalancedJournalError) Details() map[string]string {
+	details := make(map[string]string, len(e.Imbalances)*3)
+	for _, imbalance := range e.Imbalances {
+		details[imbalance.Currency+".debit"] = strconv.FormatFloat(imbalance.Debit, 'f', 2, 64)
+		details[imbalance.Currency+".credit"] = strconv.FormatFloat(imbalance.Credit, 'f', 2, 64)
+		details[imbalance.Currency+".difference"] = strconv.FormatFloat(imbalance.Difference, 'f', 2, 64)
+	}
+	return details
+}
+
+// JournalValidator checks a journal entry before it is posted. Amounts are
+// compared in minor units (paise, cents) as they will be stored, so float
+// noise in computed amounts never unbalances an entry.
+type JournalValidator struct {
+	baseCurrency  string
+	lineTolerance int64
+}
+
+// NewJournalValidator creates a journal validator. Lines without a currency
+// are in baseCurrency; each currency may be out by up to lineTolerance minor
+// units per line to absorb rounding of computed amounts; Balance posts what
+// is left over.
+func NewJournalValidator(baseCurrency string, lineTolerance int) *JournalValidator {
+	if lineTolerance < 0 {
+		lineTolerance = 0
+	}
+	return &JournalValidator{baseCurrency: strings.ToUpper(baseCurrency), lineTolerance: int64(lineTolerance)}
+}
+
+// Validate runs the checks in order and returns the first failure
+func (v *JournalValidator) Validate(lines []models.TransactionLine) error {
+	for _, check := range []func([]models.TransactionLine) error{
+		v.checkLines,
+		v.checkBalance,
+	} {
+		if err := check(lines); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkLines requires at least two lines, each a debit or a credit of a
+// non-negative amount, and something posted
+func (v *JournalValidator) checkLines(lines []models.TransactionLine) error {
+	if len(lines) < 2 {
+		return ErrTransactionNotBalanced
+	}
+	var posted bool
+	for _, line := range lines {
+		if line.DebitAmount < 0 || line.CreditAmount < 0 {
+			return ErrInvalidAmount
+		}
+		if line.DebitAmount > 0 && line.CreditAmount > 0 {
+			return ErrInvalidAmount
+		}
+		posted = posted || line.DebitAmount > 0 || line.CreditAmount > 0
+	}
+	if !posted {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// checkBalance requires debits to equal credits in each currency, within
+// the rounding tolerance for the number of lines in it
+func (v *JournalValidator) checkBalance(lines []models.TransactionLine) error {
+	var imbalances []JournalImbalance
+	for _, t := range v.totals(lines) {
+		diff := t.debit - t.credit
+		tolerance := v.lineTolerance * t.lines
+		if diff > tolerance || -diff > tolerance {
+			imbalances = append(imbalances, JournalImbalance{
+				Currency:   t.currency,
+				Debit:      float64(t.debit) / 100,
+				Credit:     float64(t.credit) / 100,
+				Difference: float64(diff) / 100,
+				Tolerance:  float64(tolerance) / 100,
+			})
+		}
+	}
+	if len(imbalances) == 0 {
+		return nil
+	}
+	return &UnbalancedJournalError{Imbalances: imbalances}
+}
+
+// Balance returns the lines of a validated entry with what the rounding
+// tolerance let through posted to roundOffAccountID, one line per currency
+// that is out, so the entry saved balances exactly
+func (v *JournalValidator) Balance(lines []models.TransactionLine, roundOffAccountID uuid.UUID) []models.TransactionLine {
+	for _, t := range v.totals(lines) {
+		diff := t.debit - t.credit
+		if diff == 0 {
+			continue
+		}
+		line := models.TransactionLine{
+			AccountID:   roundOffAccountID,
+			Description: "Round off",
+			Currency:    t.lineCurrency,
+			LineOrder:   len(lines),
+		}
+		if diff > 0 {
+			line.CreditAmount = float64(diff) / 100
+		} else {
+			line.DebitAmount = float64(-diff) / 100
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// HasRoundingDifference reports whether a validated entry is out within
+// the rounding tolerance, and so needs Balance before it is saved
+func (v *JournalValidator) HasRoundingDifference(lines []models.TransactionLine) bool {
+	for _, t := range v.totals(lines) {
+		if t.debit != t.credit {
+			return true
+		}
+	}
+	return false
+}
+
+// currencyTotals are the debits and credits of one currency in an entry,
+// in minor units
+type currencyTotals struct {
+	currency     string
+	lineCurrency string // as the currency's first line has it, "" for the base currency
+	debit        int64
+	credit       int64
+	lines        int64
+}
+
+// totals adds up an entry's lines by currency, in currency order
+func (v *JournalValidator) totals(lines []models.TransactionLine) []*currencyTotals {
+	byCurrency := make(map[string]*currencyTotals)
+	var totals []*currencyTotals
+	for _, line := range lines {
+		currency := v.currency(line)
+		t, ok := byCurrency[currency]
+		if !ok {
+			t = &currencyTotals{currency: currency, lineCurrency: line.Currency}
+			byCurrency[currency] = t
+			totals = append(totals, t)
+		}
+		t.debit += minorUnits(line.DebitAmount)
+		t.credit += minorUnits(line.CreditAmount)
+		t.lines++
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].currency < totals[j].currency })
+	return totals
+}
+
+func (v *JournalValidator) currency(line models.TransactionLine) string {
+	if line.Currency == "" {
+		return v.baseCurrency
+	}
+	return strings.ToUpper(line.Currency)
+}
+
+// minorUnits rounds an amount to the paise (or cents) it is stored as
+func minorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
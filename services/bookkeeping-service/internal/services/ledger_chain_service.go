@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+const chainVerifyBatchSize = 500
+
+// Reasons a hash chain fails verification
+const (
+	ChainBreakContentChanged = "content_changed" // The transaction no longer matches its hash
+	ChainBreakLinkBroken     = "link_broken"     // The previous hash is not the prior transaction's hash
+	ChainBreakMissing        = "missing"         // A sequence number is missing: a transaction was removed
+)
+
+// ChainVerification is the result of recomputing a tenant's hash chain
+type ChainVerification struct {
+	Valid      bool                  `json:"valid"`
+	Checked    int64                 `json:"checked"`
+	Head       *repository.ChainHead `json:"head,omitempty"`
+	Break      *ChainBreak           `json:"break,omitempty"`
+	VerifiedAt time.Time             `json:"verified_at"`
+}
+
+// ChainBreak is the first point at which a hash chain fails verification
+type ChainBreak struct {
+	Sequence          int64      `json:"sequence"`
+	TransactionID     *uuid.UUID `json:"transaction_id,omitempty"`
+	TransactionNumber string     `json:"transaction_number,omitempty"`
+	Reason            string     `json:"reason"`
+}
+
+// LedgerChainService exposes the tamper-evident hash chain over each
+// tenant's posted transactions. Transactions posted before the chain was
+// introduced are not part of it.
+type LedgerChainService interface {
+	GetHead(ctx context.Context, tenantID uuid.UUID) (*repository.ChainHead, error)
+
+	// Verify recomputes every hash in the tenant's chain and reports the
+	// first break, if any
+	Verify(ctx context.Context, tenantID uuid.UUID) (*ChainVerification, error)
+}
+
+type ledgerChainService struct {
+	transactionRepo repository.TransactionRepository
+}
+
+// NewLedgerChainService creates a new ledger chain service
+func NewLedgerChainService(transactionRepo repository.TransactionRepository) LedgerChainService {
+	return &ledgerChainService{transactionRepo: transactionRepo}
+}
+
+func (s *ledgerChainService) GetHead(ctx context.Context, tenantID uuid.UUID) (*repository.ChainHead, error) {
+	return s.transactionRepo.GetChainHead(ctx, tenantID)
+}
+
+func (s *ledgerChainService) Verify(ctx context.Context, tenantID uuid.UUID) (*ChainVerification, error) {
+	result := &ChainVerification{Valid: true}
+
+	prevHash := models.GenesisHash
+	var sequence int64
+	for {
+		batch, err := s.transactionRepo.FindChained(ctx, tenantID, sequence, chainVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range batch {
+			transaction := &batch[i]
+			sequence++
+
+			var reason string
+			switch {
+			case *transaction.ChainSequence != sequence:
+				reason = ChainBreakMissing
+			case transaction.PrevHash != prevHash:
+				reason = ChainBreakLinkBroken
+			case transaction.Hash(prevHash) != transaction.ChainHash:
+				reason = ChainBreakContentChanged
+			}
+			if reason != "" {
+				result.Valid = false
+				result.Break = &ChainBreak{Sequence: sequence, Reason: reason}
+				if reason != ChainBreakMissing {
+					result.Break.TransactionID = &transaction.ID
+					result.Break.TransactionNumber = transaction.TransactionNumber
+				}
+				return s.finish(ctx, tenantID, result)
+			}
+
+			prevHash = transaction.ChainHash
+			result.Checked++
+		}
+
+		if len(batch) < chainVerifyBatchSize {
+			return s.finish(ctx, tenantID, result)
+		}
+	}
+}
+
+func (s *ledgerChainService) finish(ctx context.Context, tenantID uuid.UUID, result *ChainVerification) (*ChainVerification, error) {
+	head, err := s.transactionRepo.GetChainHead(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	result.Head = head
+	result.VerifiedAt = time.Now()
+	return result, nil
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+// PermPeriodReopen is the tenant service permission needed to reopen a
+// closed period
+const PermPeriodReopen = "period:reopen"
+
+var (
+	ErrFinancialYearNotFound = errors.New("financial year not found")
+	ErrInvalidFinancialYear  = errors.New("a financial year must end after it starts and last at most a year")
+	ErrFinancialYearOverlap  = errors.New("financial year overlaps an existing one")
+	ErrPeriodNotFound        = errors.New("period is not in the financial year")
+	ErrPeriodAlreadyClosed   = errors.New("period is already closed")
+	ErrPeriodNotClosed       = errors.New("period is not closed")
+
+	// ErrPeriodClosed is returned when a transaction is dated in a closed
+	// period. The ledger enforces it, so it comes up from any posting.
+	ErrPeriodClosed = repository.ErrPeriodClosed
+
+	// ErrNoFinancialYear is returned when a transaction is dated before
+	// the tenant's financial years, between them, or too far past them to
+	// add years on its own
+	ErrNoFinancialYear = repository.ErrNoFinancialYear
+)
+
+// Period statuses in the calendar of a financial year
+const (
+	PeriodStatusOpen            = "open"
+	PeriodStatusClosed          = "closed"
+	PeriodStatusPartiallyClosed = "partially_closed" // A quarter with some of its months closed
+)
+
+// CreateFinancialYearRequest represents a request to create a financial year
+type CreateFinancialYearRequest struct {
+	Name      string `json:"name"`                          // Defaults to e.g. "FY 2024-25"
+	YearStart string `json:"year_start" binding:"required"` // YYYY-MM-DD
+	YearEnd   string `json:"year_end"`                      // Defaults to the day before the start a year on
+}
+
+// ReopenPeriodRequest represents a request to reopen a closed period
+type ReopenPeriodRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// PeriodStatus is whether transactions dated on a day may be posted
+type PeriodStatus struct {
+	Date   string `json:"date"`
+	Closed bool   `json:"closed"`
+	// The closed period or financial year the date falls in
+	Period string `json:"period,omitempty"`
+}
+
+// CalendarPeriod is a month or quarter of a financial year
+type CalendarPeriod struct {
+	Name      string `json:"name"` // "2024-04" for a month, "Q1" for a quarter
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Status    string `json:"status"`
+	// The months of a quarter
+	Months []string `json:"months,omitempty"`
+}
+
+// PeriodCalendar is a financial year's months and quarters and whether
+// transactions may be posted in each
+type PeriodCalendar struct {
+	FinancialYear models.FinancialYear `json:"financial_year"`
+	Months        []CalendarPeriod     `json:"months"`
+	Quarters      []CalendarPeriod     `json:"quarters"`
+}
+
+// PeriodService manages financial years and the closing of their periods
+type PeriodService interface {
+	// ListYears returns the tenant's financial years, adding the current
+	// one first if it is missing
+	ListYears(ctx context.Context, tenantID uuid.UUID, authorization string) ([]models.FinancialYear, error)
+	CreateYear(ctx context.Context, tenantID uuid.UUID, req CreateFinancialYearRequest) (*models.FinancialYear, error)
+	ListPeriods(ctx context.Context, tenantID, yearID uuid.UUID) ([]models.FinancialPeriod, error)
+
+	// EnsureYear returns the financial year the date falls in, adding it
+	// when the date is past the tenant's latest year. A tenant's first
+	// year starts in the month set as its financial year start in the
+	// tenant service, read on behalf of the caller identified by
+	// authorization.
+	EnsureYear(ctx context.Context, tenantID uuid.UUID, date time.Time, authorization string) (*models.FinancialYear, error)
+
+	// Calendar returns the months and quarters of a financial year, each
+	// open, closed or, for quarters, partially closed
+	Calendar(ctx context.Context, tenantID, yearID uuid.UUID) (*PeriodCalendar, error)
+
+	// ClosePeriod stops transactions dated in the period from being
+	// posted, edited or voided
+	ClosePeriod(ctx context.Context, tenantID, userID, yearID uuid.UUID, period string) (*models.FinancialPeriod, error)
+	ReopenPeriod(ctx context.Context, tenantID, userID, yearID uuid.UUID, period string, req ReopenPeriodRequest) (*models.FinancialPeriod, error)
+
+	// Status reports whether the date falls in a closed period, for
+	// services that keep documents posted to the ledger
+	Status(ctx context.Context, tenantID uuid.UUID, date time.Time) (*PeriodStatus, error)
+}
+
+type periodService struct {
+	periodRepo   repository.FinancialPeriodRepository
+	tenantClient clients.TenantClient
+}
+
+// NewPeriodService creates a new period service
+func NewPeriodService(periodRepo repository.FinancialPeriodRepository, tenantClient clients.TenantClient) PeriodService {
+	return &periodService{
+		periodRepo:   periodRepo,
+		tenantClient: tenantClient,
+	}
+}
+
+func (s *periodService) ListYears(ctx context.Context, tenantID uuid.UUID, authorization string) ([]models.FinancialYear, error) {
+	// Years a tenant has let lapse, or years before the first, are left
+	// for it to add, so a failure here still lists what there is
+	if _, err := s.EnsureYear(ctx, tenantID, time.Now().UTC(), authorization); err != nil && err != ErrNoFinancialYear {
+		log.Printf("Failed to add the current financial year of tenant %s: %v", tenantID, err)
+	}
+	return s.periodRepo.ListYears(ctx, tenantID)
+}
+
+func (s *periodService) EnsureYear(ctx context.Context, tenantID uuid.UUID, date time.Time, authorization string) (*models.FinancialYear, error) {
+	year, err := s.periodRepo.EnsureYear(ctx, tenantID, date, 0)
+	if err != nil || year != nil {
+		return year, err
+	}
+
+	tenant, err := s.tenantClient.GetTenant(ctx, authorization, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	startMonth := time.Month(tenant.FinancialYearStart)
+	if startMonth < time.January || startMonth > time.December {
+		startMonth = time.April
+	}
+	return s.periodRepo.EnsureYear(ctx, tenantID, date, startMonth)
+}
+
+func (s *periodService) CreateYear(ctx context.Context, tenantID uuid.UUID, req CreateFinancialYearRequest) (*models.FinancialYear, error) {
+	yearStart, err := time.Parse("2006-01-02", req.YearStart)
+	if err != nil {
+		return nil, ErrInvalidFinancialYear
+	}
+	yearEnd := yearStart.AddDate(1, 0, -1)
+	if req.YearEnd != "" {
+		if yearEnd, err = time.Parse("2006-01-02", req.YearEnd); err != nil {
+			return nil, ErrInvalidFinancialYear
+		}
+	}
+	if !yearEnd.After(yearStart) || yearEnd.After(yearStart.AddDate(1, 0, -1)) {
+		return nil, ErrInvalidFinancialYear
+	}
+
+	existing, err := s.periodRepo.ListYears(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, year := range existing {
+		if !yearStart.After(year.YearEnd) && !yearEnd.Before(year.YearStart) {
+			return nil, ErrFinancialYearOverlap
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = models.FinancialYearName(yearStart, yearEnd)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	year := &models.FinancialYear{
+		TenantID:  tenantID,
+		YearStart: yearStart,
+		YearEnd:   yearEnd,
+		Name:      name,
+		IsCurrent: !today.Before(yearStart) && !today.After(yearEnd),
+	}
+	if err := s.periodRepo.CreateYear(ctx, year); err != nil {
+		return nil, err
+	}
+	return year, nil
+}
+
+// ListPeriods returns every month of the financial year with its state
+func (s *periodService) ListPeriods(ctx context.Context, tenantID, yearID uuid.UUID) ([]models.FinancialPeriod, error) {
+	year, err := s.findYear(ctx, tenantID, yearID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.periodRepo.ListPeriods(ctx, tenantID, yearID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.FinancialPeriod, len(stored))
+	for _, period := range stored {
+		byName[period.Period] = period
+	}
+
+	periods := year.Periods()
+	for i, period := range periods {
+		if saved, ok := byName[period.Period]; ok {
+			periods[i] = saved
+		}
+	}
+	return periods, nil
+}
+
+func (s *periodService) Calendar(ctx context.Context, tenantID, yearID uuid.UUID) (*PeriodCalendar, error) {
+	year, err := s.findYear(ctx, tenantID, yearID)
+	if err != nil {
+		return nil, err
+	}
+	periods, err := s.ListPeriods(ctx, tenantID, yearID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &PeriodCalendar{
+		FinancialYear: *year,
+		Months:        make([]CalendarPeriod, 0, len(periods)),
+		Quarters:      []CalendarPeriod{},
+	}
+	for i, period := range periods {
+		// Closing the year closes every period in it
+		status := PeriodStatusOpen
+		if period.IsClosed || year.IsClosed {
+			status = PeriodStatusClosed
+		}
+		calendar.Months = append(calendar.Months, CalendarPeriod{
+			Name:      period.Period,
+			StartDate: period.StartDate.Format("2006-01-02"),
+			EndDate:   period.EndDate.Format("2006-01-02"),
+			Status:    status,
+		})
+
+		if i%3 == 0 {
+			calendar.Quarters = append(calendar.Quarters, CalendarPeriod{
+				Name:      fmt.Sprintf("Q%d", i/3+1),
+				StartDate: period.StartDate.Format("2006-01-02"),
+				Status:    status,
+			})
+		}
+		quarter := &calendar.Quarters[len(calendar.Quarters)-1]
+		quarter.EndDate = period.EndDate.Format("2006-01-02")
+		quarter.Months = append(quarter.Months, period.Period)
+		if quarter.Status != status {
+			quarter.Status = PeriodStatusPartiallyClosed
+		}
+	}
+	return calendar, nil
+}
+
+func (s *periodService) ClosePeriod(ctx context.Context, tenantID, userID, yearID uuid.UUID, name string) (*models.FinancialPeriod, error) {
+	period, err := s.findPeriod(ctx, tenantID, yearID, name)
+	if err != nil {
+		return nil, err
+	}
+	if period.IsClosed {
+		return nil, ErrPeriodAlreadyClosed
+	}
+
+	now := time.Now()
+	period.IsClosed = true
+	period.ClosedAt = &now
+	period.ClosedBy = &userID
+	period.UpdatedAt = now
+	if err := s.periodRepo.SavePeriod(ctx, period); err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+// ReopenPeriod opens a closed period again, recording who reopened it and
+// why. Callers need the period:reopen permission.
+func (s *periodService) ReopenPeriod(ctx context.Context, tenantID, userID, yearID uuid.UUID, name string, req ReopenPeriodRequest) (*models.FinancialPeriod, error) {
+	period, err := s.findPeriod(ctx, tenantID, yearID, name)
+	if err != nil {
+		return nil, err
+	}
+	if !period.IsClosed {
+		return nil, ErrPeriodNotClosed
+	}
+
+	now := time.Now()
+	period.IsClosed = false
+	period.ReopenedAt = &now
+	period.ReopenedBy = &userID
+	period.ReopenReason = req.Reason
+	period.UpdatedAt = now
+	if err := s.periodRepo.SavePeriod(ctx, period); err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+func (s *periodService) Status(ctx context.Context, tenantID uuid.UUID, date time.Time) (*PeriodStatus, error) {
+	closed, err := s.periodRepo.ClosedOn(ctx, tenantID, date)
+	if err != nil {
+		return nil, err
+	}
+	return &PeriodStatus{
+		Date:   date.Format("2006-01-02"),
+		Closed: closed != "",
+		Period: closed,
+	}, nil
+}
+
+func (s *periodService) findYear(ctx context.Context, tenantID, yearID uuid.UUID) (*models.FinancialYear, error) {
+	year, err := s.periodRepo.FindYear(ctx, yearID, tenantID)
+	if err == repository.ErrFinancialYearNotFound {
+		return nil, ErrFinancialYearNotFound
+	}
+	return year, err
+}
+
+// findPeriod returns the named period of the financial year, as stored if
+// it has been closed before
+func (s *periodService) findPeriod(ctx context.Context, tenantID, yearID uuid.UUID, name string) (*models.FinancialPeriod, error) {
+	year, err := s.findYear(ctx, tenantID, yearID)
+	if err != nil {
+		return nil, err
+	}
+	period, ok := year.Period(name)
+	if !ok {
+		return nil, ErrPeriodNotFound
+	}
+
+	stored, err := s.periodRepo.FindPeriod(ctx, tenantID, name)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		return stored, nil
+	}
+	return &period, nil
+}
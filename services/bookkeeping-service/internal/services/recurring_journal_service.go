@@ -50,6 +50,10 @@ type UpdateRecurringJournalRequest struct {
 	Lines          []RecurringJournalLineReq `json:"lines"`
 }
 
+// JobGenerateRecurringJournals is the scheduled job that posts the
+// recurring journals that have fallen due
+const JobGenerateRecurringJournals = "recurring_journals.generate"
+
 // RecurringJournalService defines the interface for recurring journal business logic
 type RecurringJournalService interface {
 	Create(ctx context.Context, req CreateRecurringJournalRequest) (*models.RecurringJournal, error)
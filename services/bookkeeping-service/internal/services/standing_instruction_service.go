@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+)
+
+var (
+	ErrStandingInstructionNotFound = errors.New("standing instruction not found")
+	ErrStandingInstructionLinked   = errors.New("standing instruction is already linked to a recurring journal")
+)
+
+// JobDetectStandingInstructions is the scheduled job that looks for
+// standing instructions in every bank feed
+const JobDetectStandingInstructions = "standing_instructions.detect"
+
+const (
+	// Statement history searched for standing instructions
+	standingInstructionLookbackMonths = 13
+
+	// Occurrences needed before a pattern counts as a standing instruction
+	minStandingInstructionOccurrences = 3
+
+	// Occurrences may differ from the typical amount by this fraction, as
+	// utility debits and interest-bearing EMIs vary a little
+	standingInstructionAmountTolerance = 0.1
+)
+
+// cadences are the intervals standing instructions run at, with the range
+// of days between occurrences accepted for each
+var cadences = []struct {
+	frequency models.RecurrenceFrequency
+	minDays   int
+	maxDays   int
+}{
+	{models.FrequencyWeekly, 6, 8},
+	{models.FrequencyBiweekly, 13, 16},
+	{models.FrequencyMonthly, 27, 34},
+	{models.FrequencyQuarterly, 85, 97},
+}
+
+// payeeNoise are statement words that name the payment channel or the
+// period rather than the payee
+var payeeNoise = map[string]bool{
+	"neft": true, "imps": true, "rtgs": true, "upi": true, "nach": true, "ach": true,
+	"ecs": true, "si": true, "dr": true, "cr": true, "debit": true, "credit": true,
+	"ref": true, "txn": true, "trf": true, "transfer": true, "to": true, "from": true,
+	"by": true, "mb": true, "ib": true, "inb": true, "pos": true, "auto": true,
+	"for": true, "of": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "may": true, "jun": true,
+	"jul": true, "aug": true, "sep": true, "oct": true, "nov": true, "dec": true,
+	"january": true, "february": true, "march": true, "april": true, "june": true,
+	"july": true, "august": true, "september": true, "october": true, "november": true,
+	"december": true,
+}
+
+// CreateRecurringFromInstructionRequest accounts for a standing instruction
+// with a new recurring journal
+type CreateRecurringFromInstructionRequest struct {
+	TenantID  uuid.UUID `json:"-"`
+	UserID    uuid.UUID `json:"-"`
+	Name      string    `json:"name"`
+	AccountID uuid.UUID `json:"account_id" binding:"required"` // Expense for debits, income for credits
+}
+
+// StandingInstructionService detects recurring bank debits and credits and
+// tracks whether they are accounted for and still arriving
+type StandingInstructionService interface {
+	// Detect scans a bank account's statements and records the standing
+	// instructions found
+	Detect(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.StandingInstruction, error)
+	// DetectAll scans every bank feed with recent statements
+	DetectAll(ctx context.Context) error
+
+	// List returns the standing instructions, with missing ones flagged.
+	// missingOnly limits the list to expected debits and credits that
+	// have not arrived.
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.StandingInstructionFilters, missingOnly bool) ([]models.StandingInstruction, error)
+	CreateRecurring(ctx context.Context, id uuid.UUID, req CreateRecurringFromInstructionRequest) (*models.StandingInstruction, error)
+	Link(ctx context.Context, tenantID, id, recurringJournalID uuid.UUID) (*models.StandingInstruction, error)
+	Dismiss(ctx context.Context, tenantID, id uuid.UUID) (*models.StandingInstruction, error)
+}
+
+type standingInstructionService struct {
+	instructionRepo  repository.StandingInstructionRepository
+	bankRepo         repository.BankRepository
+	accountRepo      repository.AccountRepository
+	recurringService RecurringJournalService
+}
+
+// NewStandingInstructionService creates a new standing instruction service
+func NewStandingInstructionService(
+	instructionRepo repository.StandingInstructionRepository,
+	bankRepo repository.BankRepository,
+	accountRepo repository.AccountRepository,
+	recurringService RecurringJournalService,
+) StandingInstructionService {
+	return &standingInstructionService{
+		instructionRepo:  instructionRepo,
+		bankRepo:         bankRepo,
+		accountRepo:      accountRepo,
+		recurringService: recurringService,
+	}
+}
+
+func (s *standingInstructionService) Detect(ctx context.Context, tenantID, bankAccountID uuid.UUID) ([]models.StandingInstruction, error) {
+	account, err := s.bankRepo.GetBankAccountByID(ctx, bankAccountID)
+	if err != nil || account.TenantID != tenantID {
+		return nil, ErrBankAccountNotFound
+	}
+
+	if err := s.detect(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return s.List(ctx, tenantID, repository.StandingInstructionFilters{BankAccountID: account.ID}, false)
+}
+
+func (s *standingInstructionService) DetectAll(ctx context.Context) error {
+	since := time.Now().AddDate(0, -standingInstructionLookbackMonths, 0)
+	accounts, err := s.instructionRepo.ListBankFeeds(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	for i := range accounts {
+		if err := s.detect(ctx, &accounts[i]); err != nil {
+			log.Printf("Standing instruction detection failed for bank account %s: %v", accounts[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *standingInstructionService) List(ctx context.Context, tenantID uuid.UUID, filters repository.StandingInstructionFilters, missingOnly bool) ([]models.StandingInstruction, error) {
+	instructions, err := s.instructionRepo.List(ctx, tenantID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	today := truncateToDay(time.Now())
+	result := make([]models.StandingInstruction, 0, len(instructions))
+	for _, instruction := range instructions {
+		instruction.CheckMissing(today)
+		if missingOnly && !instruction.Missing {
+			continue
+		}
+		result = append(result, instruction)
+	}
+	return result, nil
+}
+
+func (s *standingInstructionService) CreateRecurring(ctx context.Context, id uuid.UUID, req CreateRecurringFromInstructionRequest) (*models.StandingInstruction, error) {
+	instruction, account, err := s.linkable(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.accountRepo.FindByID(ctx, req.AccountID, req.TenantID); err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	name := req.Name
+	if name == "" {
+		name = instruction.Payee
+	}
+
+	// Lines mirror the bank debit or credit: a debit is an expense paid
+	// from the bank, a credit income received into it
+	bankLine := RecurringJournalLineReq{AccountID: *account.AccountID, Description: instruction.Description}
+	otherLine := RecurringJournalLineReq{AccountID: req.AccountID, Description: instruction.Description}
+	txnType := models.TransactionTypeExpense
+	if instruction.Direction == models.StandingInstructionCredit {
+		txnType = models.TransactionTypeReceipt
+		bankLine.DebitAmount = instruction.Amount
+		otherLine.CreditAmount = instruction.Amount
+	} else {
+		otherLine.DebitAmount = instruction.Amount
+		bankLine.CreditAmount = instruction.Amount
+	}
+
+	recurring, err := s.recurringService.Create(ctx, CreateRecurringJournalRequest{
+		TenantID:        req.TenantID,
+		CreatedBy:       req.UserID,
+		Name:            name,
+		Description:     "From standing instruction: " + instruction.Description,
+		TransactionType: string(txnType),
+		Frequency:       string(instruction.Frequency),
+		StartDate:       instruction.NextExpectedDate,
+		Lines:           []RecurringJournalLineReq{otherLine, bankLine},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.markLinked(ctx, instruction, recurring.ID)
+}
+
+func (s *standingInstructionService) Link(ctx context.Context, tenantID, id, recurringJournalID uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, _, err := s.linkable(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recurring, err := s.recurringService.GetByID(ctx, recurringJournalID)
+	if err != nil || recurring.TenantID != tenantID {
+		return nil, ErrRecurringJournalNotFound
+	}
+
+	return s.markLinked(ctx, instruction, recurring.ID)
+}
+
+func (s *standingInstructionService) Dismiss(ctx context.Context, tenantID, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	instruction.Status = models.StandingInstructionDismissed
+	if err := s.instructionRepo.Update(ctx, instruction); err != nil {
+		return nil, err
+	}
+
+	instruction.CheckMissing(truncateToDay(time.Now()))
+	return instruction, nil
+}
+
+// detect records the standing instructions in an account's statements and
+// links new ones to a matching recurring journal where there is one
+func (s *standingInstructionService) detect(ctx context.Context, account *models.BankAccount) error {
+	since := time.Now().AddDate(0, -standingInstructionLookbackMonths, 0)
+	statement, err := s.instructionRepo.GetStatementSince(ctx, account.ID, since)
+	if err != nil {
+		return err
+	}
+
+	detected := detectStandingInstructions(account, statement)
+	if len(detected) == 0 {
+		return nil
+	}
+	if err := s.instructionRepo.SaveDetected(ctx, detected); err != nil {
+		return err
+	}
+
+	if account.AccountID == nil {
+		return nil
+	}
+	saved, err := s.instructionRepo.List(ctx, account.TenantID, repository.StandingInstructionFilters{
+		BankAccountID: account.ID,
+		Status:        models.StandingInstructionSuggested,
+	})
+	if err != nil {
+		return err
+	}
+	for i := range saved {
+		instruction := &saved[i]
+		recurring, err := s.instructionRepo.FindRecurringJournal(ctx, account.TenantID, *account.AccountID,
+			instruction.Frequency, instruction.Amount, instruction.Amount*standingInstructionAmountTolerance)
+		if err != nil {
+			return err
+		}
+		if recurring == nil {
+			continue
+		}
+		if _, err := s.markLinked(ctx, instruction, recurring.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *standingInstructionService) get(ctx context.Context, tenantID, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, err := s.instructionRepo.GetByID(ctx, tenantID, id)
+	if err == repository.ErrStandingInstructionNotFound {
+		return nil, ErrStandingInstructionNotFound
+	}
+	return instruction, err
+}
+
+// linkable loads an instruction not yet accounted for, with the bank
+// account whose ledger account a recurring journal would post through
+func (s *standingInstructionService) linkable(ctx context.Context, tenantID, id uuid.UUID) (*models.StandingInstruction, *models.BankAccount, error) {
+	instruction, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if instruction.Status == models.StandingInstructionLinked {
+		return nil, nil, ErrStandingInstructionLinked
+	}
+
+	account, err := s.bankRepo.GetBankAccountByID(ctx, instruction.BankAccountID)
+	if err != nil {
+		return nil, nil, ErrBankAccountNotFound
+	}
+	if account.AccountID == nil {
+		return nil, nil, ErrNoLedgerAccount
+	}
+	return instruction, account, nil
+}
+
+func (s *standingInstructionService) markLinked(ctx context.Context, instruction *models.StandingInstruction, recurringJournalID uuid.UUID) (*models.StandingInstruction, error) {
+	instruction.Status = models.StandingInstructionLinked
+	instruction.RecurringJournalID = &recurringJournalID
+	if err := s.instructionRepo.Update(ctx, instruction); err != nil {
+		return nil, err
+	}
+
+	instruction.CheckMissing(truncateToDay(time.Now()))
+	return instruction, nil
+}
+
+// detectStandingInstructions finds the payees in a statement, oldest line
+// first, that were paid or paid in about the same amount at a regular
+// interval
+func detectStandingInstructions(account *models.BankAccount, statement []models.BankTransaction) []models.StandingInstruction {
+	type key struct{ payee, direction string }
+	groups := make(map[key][]models.BankTransaction)
+	var keys []key
+	for _, tx := range statement {
+		k := key{direction: models.StandingInstructionDebit}
+		switch {
+		case tx.DebitAmount > 0 && tx.CreditAmount == 0:
+		case tx.CreditAmount > 0 && tx.DebitAmount == 0:
+			k.direction = models.StandingInstructionCredit
+		default:
+			continue
+		}
+		if k.payee = normalizePayee(tx.Description); k.payee == "" {
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], tx)
+	}
+
+	var detected []models.StandingInstruction
+	for _, k := range keys {
+		lines := groups[k]
+		if len(lines) < minStandingInstructionOccurrences {
+			continue
+		}
+
+		amounts := make([]float64, len(lines))
+		for i, tx := range lines {
+			amounts[i] = tx.DebitAmount + tx.CreditAmount
+		}
+		typical := median(amounts)
+
+		// Drop one-off payments to the same payee
+		var occurrences []models.BankTransaction
+		for i, tx := range lines {
+			if math.Abs(amounts[i]-typical) <= typical*standingInstructionAmountTolerance {
+				occurrences = append(occurrences, tx)
+			}
+		}
+		if len(occurrences) < minStandingInstructionOccurrences {
+			continue
+		}
+
+		frequency, ok := cadenceOf(occurrences)
+		if !ok {
+			continue
+		}
+
+		first, last := occurrences[0], occurrences[len(occurrences)-1]
+		detected = append(detected, models.StandingInstruction{
+			TenantID:         account.TenantID,
+			BankAccountID:    account.ID,
+			Payee:            k.payee,
+			Direction:        k.direction,
+			Description:      last.Description,
+			Amount:           math.Round(typical*100) / 100,
+			Frequency:        frequency,
+			Occurrences:      len(occurrences),
+			FirstDate:        first.TransactionDate,
+			LastDate:         last.TransactionDate,
+			NextExpectedDate: nextOccurrence(last.TransactionDate, frequency),
+			Status:           models.StandingInstructionSuggested,
+		})
+	}
+	return detected
+}
+
+// cadenceOf returns the frequency most gaps between occurrences fit. At
+// least three in four must fit so a skipped month or a one-off repeat does
+// not hide an instruction, while irregular payments are not mistaken for one.
+func cadenceOf(occurrences []models.BankTransaction) (models.RecurrenceFrequency, bool) {
+	gaps := make([]int, 0, len(occurrences)-1)
+	for i := 1; i < len(occurrences); i++ {
+		gaps = append(gaps, int(occurrences[i].TransactionDate.Sub(occurrences[i-1].TransactionDate).Hours()/24))
+	}
+
+	for _, cadence := range cadences {
+		fit := 0
+		for _, gap := range gaps {
+			if gap >= cadence.minDays && gap <= cadence.maxDays {
+				fit++
+			}
+		}
+		if fit*4 >= len(gaps)*3 {
+			return cadence.frequency, true
+		}
+	}
+	return "", false
+}
+
+func nextOccurrence(last time.Time, frequency models.RecurrenceFrequency) time.Time {
+	switch frequency {
+	case models.FrequencyWeekly:
+		return last.AddDate(0, 0, 7)
+	case models.FrequencyBiweekly:
+		return last.AddDate(0, 0, 14)
+	case models.FrequencyQuarterly:
+		return last.AddDate(0, 3, 0)
+	default:
+		return last.AddDate(0, 1, 0)
+	}
+}
+
+// normalizePayee reduces a statement description to the words naming the
+// payee, dropping channel prefixes, references and dates so every
+// occurrence of an instruction has the same payee
+func normalizePayee(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return r < 'a' || r > 'z'
+	})
+
+	var payee []string
+	for _, word := range words {
+		if len(word) < 2 || payeeNoise[word] {
+			continue
+		}
+		payee = append(payee, word)
+		if len(payee) == 4 {
+			break
+		}
+	}
+	return strings.Join(payee, " ")
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
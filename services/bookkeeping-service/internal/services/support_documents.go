@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/support"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+)
+
+// Documents support bundles can be made for besides transactions
+const (
+	SupportDocumentAccountReconciliation = "account_reconciliation"
+	SupportDocumentBankReconciliation    = "bank_reconciliation"
+)
+
+// TransactionSupportDocument loads transactions, with their lines, for
+// support bundles
+func TransactionSupportDocument(repo repository.TransactionRepository, bankRepo repository.BankRepository) support.DocumentConfig {
+	return support.DocumentConfig{
+		Type: timeline.DocumentTransaction,
+		Load: func(ctx context.Context, tenantID, id uuid.UUID) (interface{}, error) {
+			transaction, err := repo.FindByID(ctx, id, tenantID)
+			if err != nil {
+				return nil, support.ErrDocumentNotFound
+			}
+			return transaction, nil
+		},
+		Timeline: TransactionTimeline(repo, bankRepo),
+	}
+}
+
+// AccountReconciliationSupportDocument loads account reconciliations, with
+// their items, for support bundles
+func AccountReconciliationSupportDocument(repo repository.AccountReconciliationRepository) support.DocumentConfig {
+	return support.DocumentConfig{
+		Type: SupportDocumentAccountReconciliation,
+		Load: func(ctx context.Context, tenantID, id uuid.UUID) (interface{}, error) {
+			reconciliation, err := repo.GetByID(ctx, tenantID, id)
+			if errors.Is(err, repository.ErrAccountReconciliationNotFound) {
+				return nil, support.ErrDocumentNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			return reconciliation, nil
+		},
+	}
+}
+
+// bankReconciliation is a bank account's reconciliation state as a support
+// bundle shows it
+type bankReconciliation struct {
+	Account      *models.BankAccount               `json:"account"`
+	Summary      *repository.ReconciliationSummary `json:"summary"`
+	LatestRun    *models.ReconciliationRun         `json:"latest_run,omitempty"`
+	Unreconciled []models.BankTransaction          `json:"unreconciled"`
+}
+
+// BankReconciliationSupportDocument loads a bank account's reconciliation
+// for support bundles: its summary as of now, its latest auto-reconcile
+// and the statement lines still unreconciled. The document ID is the
+// bank account's.
+func BankReconciliationSupportDocument(bankRepo repository.BankRepository) support.DocumentConfig {
+	return support.DocumentConfig{
+		Type: SupportDocumentBankReconciliation,
+		Load: func(ctx context.Context, tenantID, id uuid.UUID) (interface{}, error) {
+			account, err := bankRepo.GetBankAccountByID(ctx, id)
+			if err != nil || account.TenantID != tenantID {
+				return nil, support.ErrDocumentNotFound
+			}
+
+			doc := bankReconciliation{Account: account}
+			if doc.Summary, err = bankRepo.GetReconciliationSummary(ctx, id, time.Now()); err != nil {
+				return nil, err
+			}
+			if run, err := bankRepo.GetLatestReconciliationRun(ctx, id); err == nil {
+				doc.LatestRun = run
+			}
+			if doc.Unreconciled, err = bankRepo.GetUnreconciledTransactions(ctx, id); err != nil {
+				return nil, err
+			}
+			return doc, nil
+		},
+	}
+}
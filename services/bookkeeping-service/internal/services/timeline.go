@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+)
+
+// record adds an event to a document's timeline. The timeline is kept for
+// display, so a failure to record is logged and the work goes on.
+func record(ctx context.Context, store *timeline.Store, doc timeline.Document, eventType, summary string, changes []timeline.Change) {
+	if store == nil {
+		return
+	}
+	if err := store.Record(ctx, doc, eventType, summary, changes); err != nil {
+		log.Printf("Failed to record %s on %s %s: %v", eventType, doc.Type, doc.ID, err)
+	}
+}
+
+func transactionTimelineDocument(transaction *models.Transaction) timeline.Document {
+	return timeline.Document{TenantID: transaction.TenantID, Type: timeline.DocumentTransaction, ID: transaction.ID}
+}
+
+// TransactionTimeline composes a transaction's posting from the transaction
+// and its reconciliation from the bank statement lines matched to it
+func TransactionTimeline(transactionRepo repository.TransactionRepository, bankRepo repository.BankRepository) timeline.Compose {
+	return func(ctx context.Context, doc timeline.Document) ([]timeline.Event, error) {
+		transaction, err := transactionRepo.FindByID(ctx, doc.ID, doc.TenantID)
+		if err != nil {
+			return nil, timeline.ErrDocumentNotFound
+		}
+
+		created := timeline.Event{
+			ID:           transaction.ID,
+			TenantID:     doc.TenantID,
+			DocumentType: doc.Type,
+			DocumentID:   doc.ID,
+			Type:         timeline.EventCreated,
+			Summary:      fmt.Sprintf("Transaction %s posted for %.2f", transaction.TransactionNumber, transaction.TotalAmount),
+			Source:       timeline.SourceSystem,
+			At:           transaction.CreatedAt,
+		}
+		if transaction.CreatedBy != uuid.Nil {
+			created.ActorID = &transaction.CreatedBy
+			created.Source = timeline.SourceUser
+		}
+		if transaction.ReferenceType != "" {
+			created.Summary += " from " + transaction.ReferenceType
+		}
+		events := []timeline.Event{created}
+
+		lines, err := bankRepo.GetReconciledTo(ctx, doc.TenantID, doc.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			if line.ReconciledAt == nil {
+				continue
+			}
+			event := timeline.Event{
+				ID:           line.ID,
+				TenantID:     doc.TenantID,
+				DocumentType: doc.Type,
+				DocumentID:   doc.ID,
+				Type:         timeline.EventReconciled,
+				Summary:      fmt.Sprintf("Matched to bank statement line %q of %s", line.Description, line.TransactionDate.Format("2006-01-02")),
+				Source:       timeline.SourceSystem,
+				At:           *line.ReconciledAt,
+			}
+			if line.ReconciledBy != nil && *line.ReconciledBy != uuid.Nil {
+				event.ActorID = line.ReconciledBy
+				event.Source = timeline.SourceUser
+			}
+			events = append(events, event)
+		}
+
+		return events, nil
+	}
+}
@@ -3,11 +3,18 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/bookkeeping-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
 )
 
 var (
@@ -16,6 +23,8 @@ var (
 	ErrAccountNotFound       = errors.New("account not found")
 	ErrInvalidAmount         = errors.New("invalid amount")
 	ErrCannotVoidTransaction = errors.New("cannot void this transaction")
+	ErrSupportingDetailNotFound = errors.New("transaction has no supporting details")
+	ErrInvalidAdjustment        = errors.New("adjustments must be journals of type depreciation, provision, accrual or other")
 )
 
 // TransactionService defines the interface for transaction business logic
@@ -23,23 +32,46 @@ type TransactionService interface {
 	CreateTransaction(ctx context.Context, tenantID, userID uuid.UUID, req CreateTransactionRequest) (*models.Transaction, error)
 	CreateQuickSale(ctx context.Context, tenantID, userID uuid.UUID, req QuickSaleRequest) (*models.Transaction, error)
 	CreateQuickExpense(ctx context.Context, tenantID, userID uuid.UUID, req QuickExpenseRequest) (*models.Transaction, error)
+	CreateBillPayment(ctx context.Context, tenantID, userID uuid.UUID, req BillPaymentRequest) (*models.Transaction, error)
+	CreateCustomerAdvance(ctx context.Context, tenantID, userID uuid.UUID, req CustomerAdvanceRequest) (*models.Transaction, error)
+	PostDocument(ctx context.Context, tenantID, userID uuid.UUID, req DocumentPostingRequest) (*models.Transaction, error)
 	GetTransaction(ctx context.Context, id, tenantID uuid.UUID) (*models.Transaction, error)
+	GetSupportingDetail(ctx context.Context, id, tenantID uuid.UUID) (*models.TransactionSupportingDetail, error)
 	ListTransactions(ctx context.Context, tenantID uuid.UUID, filter repository.TransactionFilter) ([]models.Transaction, int64, error)
 	VoidTransaction(ctx context.Context, id, tenantID uuid.UUID) error
 	GetDailySummary(ctx context.Context, tenantID uuid.UUID, date time.Time) (*repository.DailySummary, error)
+
+	// Tags
+	SetTags(ctx context.Context, id, tenantID, userID uuid.UUID, tagList []string) (*models.Transaction, error)
+	ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error)
+	RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error)
+	DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error)
 }
 
 // CreateTransactionRequest represents a request to create a transaction
 type CreateTransactionRequest struct {
-	TransactionDate   string                   `json:"transaction_date" binding:"required"`
-	TransactionType   string                   `json:"transaction_type" binding:"required"`
-	PartyID           *uuid.UUID               `json:"party_id"`
-	PartyName         string                   `json:"party_name"`
-	Description       string                   `json:"description"`
-	Notes             string                   `json:"notes"`
-	Lines             []TransactionLineRequest `json:"lines" binding:"required,min=2"`
-	PaymentMode       string                   `json:"payment_mode"`
-	PaymentReference  string                   `json:"payment_reference"`
+	TransactionDate      string                   `json:"transaction_date" binding:"required"`
+	TransactionType      string                   `json:"transaction_type" binding:"required"`
+	PartyID              *uuid.UUID               `json:"party_id"`
+	PartyName            string                   `json:"party_name"`
+	CounterpartyTenantID *uuid.UUID               `json:"counterparty_tenant_id"`
+	Description          string                   `json:"description"`
+	Notes                string                   `json:"notes"`
+	Lines                []TransactionLineRequest `json:"lines" binding:"required,min=2"`
+	PaymentMode          string                   `json:"payment_mode"`
+	PaymentReference     string                   `json:"payment_reference"`
+	Tags                 []string                 `json:"tags"`
+	// How the amounts were computed, for journals posted from a tax or
+	// currency calculation
+	SupportingDetails *models.SupportingDetails `json:"supporting_details"`
+	// Period-end adjustment journals are kept in a separate layer that
+	// trial balance and P&L can leave out
+	IsAdjustment   bool   `json:"is_adjustment"`
+	AdjustmentType string `json:"adjustment_type"`
+
+	// Used to check journals against the tenant's validation rules;
+	// entries posted in the background, without a caller, are not checked
+	Authorization string `json:"-"`
 }
 
 // TransactionLineRequest represents a transaction line in a request
@@ -50,6 +82,7 @@ type TransactionLineRequest struct {
 	CreditAmount float64   `json:"credit_amount"`
 	TaxRateID    *uuid.UUID `json:"tax_rate_id"`
 	TaxAmount    float64   `json:"tax_amount"`
+	Currency     string    `json:"currency"` // Empty for the base currency
 }
 
 // QuickSaleRequest represents a simplified sale transaction request
@@ -61,6 +94,10 @@ type QuickSaleRequest struct {
 	PaymentMode      string              `json:"payment_mode" binding:"required"`
 	PaymentReference string              `json:"payment_reference"`
 	Notes            string              `json:"notes"`
+	Tags             []string            `json:"tags"`
+
+	// Used to read the tenant's invoice rounding rule
+	Authorization string `json:"-"`
 }
 
 // QuickSaleItem represents an item in a quick sale
@@ -82,21 +119,92 @@ type QuickExpenseRequest struct {
 	PaymentMode      string     `json:"payment_mode" binding:"required"`
 	PaymentReference string     `json:"payment_reference"`
 	Notes            string     `json:"notes"`
+	Tags             []string   `json:"tags"`
+}
+
+// BillPaymentRequest represents a vendor bill payment, optionally with TDS
+// withheld from the amount paid to the vendor
+type BillPaymentRequest struct {
+	Date             string     `json:"date" binding:"required"`
+	BillID           *uuid.UUID `json:"bill_id"`
+	BillNumber       string     `json:"bill_number"`
+	// The payment in the invoice service; a payment already posted is
+	// returned rather than posted again, so a retried payment is safe
+	PaymentID        *uuid.UUID `json:"payment_id"`
+	VendorID         *uuid.UUID `json:"vendor_id"`
+	VendorName       string     `json:"vendor_name"`
+	GrossAmount      float64    `json:"gross_amount" binding:"required"` // payable settled, including TDS
+	TDSAmount        float64    `json:"tds_amount"`
+	TDSSection       string     `json:"tds_section"`
+	PaymentMode      string     `json:"payment_mode" binding:"required"`
+	PaymentReference string     `json:"payment_reference"`
+	Notes            string     `json:"notes"`
+
+	// How the TDS was computed
+	SupportingDetails *models.SupportingDetails `json:"supporting_details"`
+}
+
+// Customer advance postings
+const (
+	CustomerAdvanceReceipt = "receipt"
+	CustomerAdvanceRefund  = "refund"
+)
+
+// CustomerAdvanceRequest represents an advance received from a customer
+// before the invoice, or the refund of one. GST paid on the advance is
+// carried in TaxAmount: the customer is credited with the advance net of
+// it, and a refund reverses the tax in proportion.
+type CustomerAdvanceRequest struct {
+	Kind             string     `json:"kind" binding:"required,oneof=receipt refund"`
+	Date             string     `json:"date" binding:"required"`
+	DocumentID       *uuid.UUID `json:"document_id"`     // Receipt or refund voucher
+	DocumentNumber   string     `json:"document_number"`
+	CustomerID       *uuid.UUID `json:"customer_id"`
+	CustomerName     string     `json:"customer_name"`
+	Amount           float64    `json:"amount" binding:"required"` // Received or refunded, including GST
+	TaxAmount        float64    `json:"tax_amount"`
+	PaymentMode      string     `json:"payment_mode" binding:"required"`
+	PaymentReference string     `json:"payment_reference"`
+	Notes            string     `json:"notes"`
+
+	// How the GST on the advance was computed
+	SupportingDetails *models.SupportingDetails `json:"supporting_details"`
 }
 
 type transactionService struct {
 	transactionRepo repository.TransactionRepository
 	accountRepo     repository.AccountRepository
+	accounts        AccountMappingService
+	periods         PeriodService
+	validator       *JournalValidator
+	history         *timeline.Store
+	rules           validation.Checker
+	invoices        clients.InvoiceClient
 }
 
-// NewTransactionService creates a new transaction service
+// NewTransactionService creates a new transaction service. Every entry is
+// checked by validator before it is posted, and journals entered by a user
+// against the tenant's validation rules. Quick sales are rounded by the
+// tenant's invoice rounding rule, read from invoices.
 func NewTransactionService(
 	transactionRepo repository.TransactionRepository,
 	accountRepo repository.AccountRepository,
+	accounts AccountMappingService,
+	periods PeriodService,
+	validator *JournalValidator,
+	history *timeline.Store,
+	rules validation.Checker,
+	invoices clients.InvoiceClient,
 ) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
 		accountRepo:     accountRepo,
+		accounts:        accounts,
+		periods:         periods,
+		validator:       validator,
+		history:         history,
+		rules:           rules,
+		invoices:        invoices,
 	}
 }
 
@@ -107,6 +215,28 @@ func (s *transactionService) CreateTransaction(ctx context.Context, tenantID, us
 		return nil, err
 	}
 
+	// A tenant's first financial year is added from its settings when it
+	// first posts. The ledger checks the date against the years either
+	// way, so a failure to add it is left to that check.
+	if _, err := s.periods.EnsureYear(ctx, tenantID, txnDate, req.Authorization); err != nil && err != ErrNoFinancialYear {
+		log.Printf("Failed to add the financial year of tenant %s for %s: %v", tenantID, req.TransactionDate, err)
+	}
+
+	tagList, err := tags.NormalizeAll(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustmentType models.AdjustmentType
+	if req.IsAdjustment {
+		adjustmentType = models.AdjustmentType(req.AdjustmentType)
+		if models.TransactionType(req.TransactionType) != models.TransactionTypeJournal || !adjustmentType.IsValid() {
+			return nil, ErrInvalidAdjustment
+		}
+	} else if req.AdjustmentType != "" {
+		return nil, ErrInvalidAdjustment
+	}
+
 	// Get next transaction number
 	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, tenantID, models.TransactionType(req.TransactionType))
 	if err != nil {
@@ -115,7 +245,7 @@ func (s *transactionService) CreateTransaction(ctx context.Context, tenantID, us
 
 	// Validate and create lines
 	var lines []models.TransactionLine
-	var totalDebit, totalCredit float64
+	var totalDebit float64
 	var subtotal float64
 
 	for i, lineReq := range req.Lines {
@@ -132,40 +262,51 @@ func (s *transactionService) CreateTransaction(ctx context.Context, tenantID, us
 			CreditAmount: lineReq.CreditAmount,
 			TaxRateID:    lineReq.TaxRateID,
 			TaxAmount:    lineReq.TaxAmount,
+			Currency:     strings.ToUpper(strings.TrimSpace(lineReq.Currency)),
 			LineOrder:    i,
 		}
 		line.Account = account
 
 		lines = append(lines, line)
 		totalDebit += lineReq.DebitAmount
-		totalCredit += lineReq.CreditAmount
 
 		if lineReq.DebitAmount > 0 {
 			subtotal += lineReq.DebitAmount
 		}
 	}
 
-	// Check if balanced
-	if totalDebit != totalCredit {
-		return nil, ErrTransactionNotBalanced
+	lines, err = s.validate(ctx, tenantID, lines)
+	if err != nil {
+		return nil, err
 	}
 
 	transaction := &models.Transaction{
-		TenantID:          tenantID,
-		TransactionNumber: txnNumber,
-		TransactionDate:   txnDate,
-		TransactionType:   models.TransactionType(req.TransactionType),
-		PartyID:           req.PartyID,
-		PartyName:         req.PartyName,
-		Description:       req.Description,
-		Notes:             req.Notes,
-		Subtotal:          subtotal,
-		TotalAmount:       totalDebit,
-		PaymentMode:       models.PaymentMode(req.PaymentMode),
-		PaymentReference:  req.PaymentReference,
-		Status:            models.TransactionStatusPosted,
-		Lines:             lines,
-		CreatedBy:         userID,
+		TenantID:             tenantID,
+		TransactionNumber:    txnNumber,
+		TransactionDate:      txnDate,
+		TransactionType:      models.TransactionType(req.TransactionType),
+		PartyID:              req.PartyID,
+		PartyName:            req.PartyName,
+		CounterpartyTenantID: req.CounterpartyTenantID,
+		Description:          req.Description,
+		Notes:                req.Notes,
+		Subtotal:             subtotal,
+		TotalAmount:          totalDebit,
+		PaymentMode:          models.PaymentMode(req.PaymentMode),
+		PaymentReference:     req.PaymentReference,
+		Status:               models.TransactionStatusPosted,
+		IsAdjustment:         req.IsAdjustment,
+		AdjustmentType:       adjustmentType,
+		Tags:                 tagList,
+		Lines:                lines,
+		SupportingDetail:     supportingDetail(tenantID, "journal", req.SupportingDetails),
+		CreatedBy:            userID,
+	}
+
+	if transaction.TransactionType == models.TransactionTypeJournal {
+		if err := s.rules.Check(ctx, tenantID.String(), req.Authorization, journalRuleDocument(transaction)); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
@@ -175,6 +316,24 @@ func (s *transactionService) CreateTransaction(ctx context.Context, tenantID, us
 	return transaction, nil
 }
 
+// journalRuleDocument is a journal in the form the tenant's validation rules
+// check
+func journalRuleDocument(transaction *models.Transaction) validation.Document {
+	doc := validation.Document{
+		Type:   validation.DocumentJournal,
+		Amount: transaction.TotalAmount,
+		Fields: map[string]string{
+			"description": transaction.Description,
+			"notes":       transaction.Notes,
+			"party_name":  transaction.PartyName,
+		},
+	}
+	for _, line := range transaction.Lines {
+		doc.Lines = append(doc.Lines, map[string]string{"description": line.Description})
+	}
+	return doc
+}
+
 func (s *transactionService) CreateQuickSale(ctx context.Context, tenantID, userID uuid.UUID, req QuickSaleRequest) (*models.Transaction, error) {
 	// Parse date
 	txnDate, err := time.Parse("2006-01-02", req.Date)
@@ -182,34 +341,71 @@ func (s *transactionService) CreateQuickSale(ctx context.Context, tenantID, user
 		return nil, err
 	}
 
+	tagList, err := tags.NormalizeAll(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate totals
 	var subtotal, taxAmount float64
+	details := &models.SupportingDetails{Calculation: "quick_sale_tax"}
 	for _, item := range req.Items {
 		itemTotal := item.Quantity * item.Rate
 		subtotal += itemTotal
 		if item.TaxRate > 0 {
-			taxAmount += itemTotal * (item.TaxRate / 100)
+			itemTax := itemTotal * (item.TaxRate / 100)
+			taxAmount += itemTax
+			details.Rates = append(details.Rates, models.SupportingRate{
+				Name:   "Tax on " + item.Description,
+				Rate:   item.TaxRate,
+				Base:   itemTotal,
+				Amount: itemTax,
+			})
 		}
 	}
-	totalAmount := subtotal + taxAmount
+	grossAmount := subtotal + taxAmount
 
-	// Get default accounts
-	salesAccount, _ := s.accountRepo.FindByCode(ctx, "4100", tenantID)
-	var paymentAccountCode string
+	// Get the tenant's accounts for sales
+	salesAccount, _ := s.accounts.Resolve(ctx, tenantID, models.PostingTypeSalesIncome)
+	var paymentPosting models.PostingType
 	switch req.PaymentMode {
 	case "cash":
-		paymentAccountCode = "1100"
+		paymentPosting = models.PostingTypeCash
 	case "bank", "upi", "card":
-		paymentAccountCode = "1200"
+		paymentPosting = models.PostingTypeBank
 	default:
-		paymentAccountCode = "1300" // Accounts Receivable
+		paymentPosting = models.PostingTypeReceivable
 	}
-	paymentAccount, _ := s.accountRepo.FindByCode(ctx, paymentAccountCode, tenantID)
+	paymentAccount, _ := s.accounts.Resolve(ctx, tenantID, paymentPosting)
 
 	if salesAccount == nil || paymentAccount == nil {
 		return nil, ErrAccountNotFound
 	}
 
+	// Round the bill as the tenant rounds invoices; the difference goes to
+	// the rule's account, or Round Off
+	totalAmount := grossAmount
+	var roundOff float64
+	rule := s.roundingRule(ctx, tenantID, req.Authorization, DocumentPostingInvoice)
+	roundOffAccount, _ := s.roundOffAccount(ctx, tenantID, rule.RoundingAccountID)
+	if roundOffAccount != nil && rule.Mode != roundingNone {
+		totalAmount = roundToUnit(grossAmount, rule.RoundTo, rule.Mode)
+		roundOff = math.Round((totalAmount-grossAmount)*100) / 100
+		details.Rounding = append(details.Rounding, models.SupportingRounding{
+			Mode:       rule.Mode,
+			RoundTo:    rule.RoundTo,
+			Unrounded:  grossAmount,
+			Rounded:    totalAmount,
+			Difference: roundOff,
+		})
+	}
+	details.Figures = map[string]float64{
+		"subtotal":     subtotal,
+		"tax_amount":   taxAmount,
+		"gross_amount": grossAmount,
+		"total_amount": totalAmount,
+	}
+
 	// Get next transaction number
 	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, tenantID, models.TransactionTypeSale)
 	if err != nil {
@@ -238,11 +434,25 @@ func (s *transactionService) CreateQuickSale(ctx context.Context, tenantID, user
 			AccountID:    salesAccount.ID,
 			Description:  "Sales revenue",
 			DebitAmount:  0,
-			CreditAmount: totalAmount,
+			CreditAmount: grossAmount,
 			LineOrder:    1,
 		},
 	}
 
+	if roundOff != 0 {
+		line := models.TransactionLine{
+			AccountID:   roundOffAccount.ID,
+			Description: "Round off",
+			LineOrder:   2,
+		}
+		if roundOff > 0 {
+			line.CreditAmount = roundOff
+		} else {
+			line.DebitAmount = -roundOff
+		}
+		lines = append(lines, line)
+	}
+
 	transaction := &models.Transaction{
 		TenantID:          tenantID,
 		TransactionNumber: txnNumber,
@@ -255,14 +465,20 @@ func (s *transactionService) CreateQuickSale(ctx context.Context, tenantID, user
 		Notes:             req.Notes,
 		Subtotal:          subtotal,
 		TaxAmount:         taxAmount,
+		RoundOffAmount:    roundOff,
 		TotalAmount:       totalAmount,
 		PaymentMode:       models.PaymentMode(req.PaymentMode),
 		PaymentReference:  req.PaymentReference,
 		Status:            models.TransactionStatusPosted,
+		Tags:              tagList,
 		Lines:             lines,
+		SupportingDetail:  supportingDetail(tenantID, "quick_sale", details),
 		CreatedBy:         userID,
 	}
 
+	if transaction.Lines, err = s.validate(ctx, tenantID, transaction.Lines); err != nil {
+		return nil, err
+	}
 	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
 		return nil, err
 	}
@@ -277,6 +493,11 @@ func (s *transactionService) CreateQuickExpense(ctx context.Context, tenantID, u
 		return nil, err
 	}
 
+	tagList, err := tags.NormalizeAll(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Amount <= 0 {
 		return nil, ErrInvalidAmount
 	}
@@ -288,16 +509,16 @@ func (s *transactionService) CreateQuickExpense(ctx context.Context, tenantID, u
 	}
 
 	// Get payment account
-	var paymentAccountCode string
+	var paymentPosting models.PostingType
 	switch req.PaymentMode {
 	case "cash":
-		paymentAccountCode = "1100"
+		paymentPosting = models.PostingTypeCash
 	case "bank", "upi", "card":
-		paymentAccountCode = "1200"
+		paymentPosting = models.PostingTypeBank
 	default:
-		paymentAccountCode = "2100" // Accounts Payable
+		paymentPosting = models.PostingTypePayable
 	}
-	paymentAccount, _ := s.accountRepo.FindByCode(ctx, paymentAccountCode, tenantID)
+	paymentAccount, _ := s.accounts.Resolve(ctx, tenantID, paymentPosting)
 
 	if paymentAccount == nil {
 		return nil, ErrAccountNotFound
@@ -342,10 +563,221 @@ func (s *transactionService) CreateQuickExpense(ctx context.Context, tenantID, u
 		PaymentMode:       models.PaymentMode(req.PaymentMode),
 		PaymentReference:  req.PaymentReference,
 		Status:            models.TransactionStatusPosted,
+		Tags:              tagList,
+		Lines:             lines,
+		CreatedBy:         userID,
+	}
+
+	if transaction.Lines, err = s.validate(ctx, tenantID, transaction.Lines); err != nil {
+		return nil, err
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (s *transactionService) CreateBillPayment(ctx context.Context, tenantID, userID uuid.UUID, req BillPaymentRequest) (*models.Transaction, error) {
+	// Parse date
+	txnDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GrossAmount <= 0 || req.TDSAmount < 0 || req.TDSAmount >= req.GrossAmount {
+		return nil, ErrInvalidAmount
+	}
+
+	if req.PaymentID != nil {
+		if existing, err := s.transactionRepo.FindBySource(ctx, *req.PaymentID, tenantID); err == nil {
+			return existing, nil
+		}
+	}
+
+	payableAccount, _ := s.accounts.Resolve(ctx, tenantID, models.PostingTypePayable)
+	if payableAccount == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	paymentAccount, _ := s.accounts.Resolve(ctx, tenantID, cashOrBank(req.PaymentMode))
+	if paymentAccount == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	// Get next transaction number
+	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, tenantID, models.TransactionTypePayment)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Bill payment"
+	if req.BillNumber != "" {
+		description = "Payment for bill " + req.BillNumber
+	}
+
+	// The full payable is cleared; TDS withheld is owed to the government
+	// rather than the vendor
+	netAmount := math.Round((req.GrossAmount-req.TDSAmount)*100) / 100
+	lines := []models.TransactionLine{
+		{
+			AccountID:    payableAccount.ID,
+			Description:  description,
+			DebitAmount:  req.GrossAmount,
+			CreditAmount: 0,
+			LineOrder:    0,
+		},
+		{
+			AccountID:    paymentAccount.ID,
+			Description:  "Payment made",
+			DebitAmount:  0,
+			CreditAmount: netAmount,
+			LineOrder:    1,
+		},
+	}
+
+	if req.TDSAmount > 0 {
+		tdsAccount, _ := s.accounts.Resolve(ctx, tenantID, models.PostingTypeTDSPayable)
+		if tdsAccount == nil {
+			return nil, ErrAccountNotFound
+		}
+
+		tdsDescription := "TDS deducted"
+		if req.TDSSection != "" {
+			tdsDescription = "TDS deducted u/s " + req.TDSSection
+		}
+		lines = append(lines, models.TransactionLine{
+			AccountID:    tdsAccount.ID,
+			Description:  tdsDescription,
+			DebitAmount:  0,
+			CreditAmount: req.GrossAmount - netAmount,
+			LineOrder:    2,
+		})
+	}
+
+	transaction := &models.Transaction{
+		TenantID:          tenantID,
+		TransactionNumber: txnNumber,
+		TransactionDate:   txnDate,
+		TransactionType:   models.TransactionTypePayment,
+		ReferenceType:     "bill",
+		ReferenceID:       req.BillID,
+		SourceID:          req.PaymentID,
+		PartyID:           req.VendorID,
+		PartyName:         req.VendorName,
+		PartyType:         "vendor",
+		Description:       description,
+		Notes:             req.Notes,
+		Subtotal:          req.GrossAmount,
+		TotalAmount:       req.GrossAmount,
+		PaymentMode:       models.PaymentMode(req.PaymentMode),
+		PaymentReference:  req.PaymentReference,
+		Status:            models.TransactionStatusPosted,
+		Lines:             lines,
+		SupportingDetail:  supportingDetail(tenantID, "bill_payment", req.SupportingDetails),
+		CreatedBy:         userID,
+	}
+
+	if transaction.Lines, err = s.validate(ctx, tenantID, transaction.Lines); err != nil {
+		return nil, err
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// CreateCustomerAdvance posts an advance receipt (bank or cash against the
+// customer and GST payable) or its refund, which reverses the same lines
+func (s *transactionService) CreateCustomerAdvance(ctx context.Context, tenantID, userID uuid.UUID, req CustomerAdvanceRequest) (*models.Transaction, error) {
+	txnDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount <= 0 || req.TaxAmount < 0 || req.TaxAmount >= req.Amount {
+		return nil, ErrInvalidAmount
+	}
+
+	receivableAccount, _ := s.accounts.Resolve(ctx, tenantID, models.PostingTypeReceivable)
+	if receivableAccount == nil {
+		return nil, ErrAccountNotFound
+	}
+	gstAccount, _ := s.accounts.Resolve(ctx, tenantID, models.PostingTypeGSTOutput)
+	if gstAccount == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	paymentAccount, _ := s.accounts.Resolve(ctx, tenantID, cashOrBank(req.PaymentMode))
+	if paymentAccount == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	transactionType := models.TransactionTypeReceipt
+	description := "Advance received"
+	if req.Kind == CustomerAdvanceRefund {
+		transactionType = models.TransactionTypePayment
+		description = "Advance refunded"
+	}
+	if req.DocumentNumber != "" {
+		description += " - " + req.DocumentNumber
+	}
+
+	txnNumber, err := s.transactionRepo.GetNextNumber(ctx, tenantID, transactionType)
+	if err != nil {
+		return nil, err
+	}
+
+	// The customer's credit is the advance net of the GST paid on it
+	netAmount := math.Round((req.Amount-req.TaxAmount)*100) / 100
+	taxAmount := math.Round((req.Amount-netAmount)*100) / 100
+
+	var lines []models.TransactionLine
+	if req.Kind == CustomerAdvanceReceipt {
+		lines = append(lines,
+			models.TransactionLine{AccountID: paymentAccount.ID, Description: description, DebitAmount: req.Amount},
+			models.TransactionLine{AccountID: receivableAccount.ID, Description: "Advance from customer", CreditAmount: netAmount})
+		if taxAmount > 0 {
+			lines = append(lines, models.TransactionLine{AccountID: gstAccount.ID, Description: "GST on advance", CreditAmount: taxAmount})
+		}
+	} else {
+		lines = append(lines, models.TransactionLine{AccountID: receivableAccount.ID, Description: description, DebitAmount: netAmount})
+		if taxAmount > 0 {
+			lines = append(lines, models.TransactionLine{AccountID: gstAccount.ID, Description: "GST on advance reversed", DebitAmount: taxAmount})
+		}
+		lines = append(lines, models.TransactionLine{AccountID: paymentAccount.ID, Description: "Refund paid", CreditAmount: req.Amount})
+	}
+	for i := range lines {
+		lines[i].LineOrder = i
+	}
+
+	transaction := &models.Transaction{
+		TenantID:          tenantID,
+		TransactionNumber: txnNumber,
+		TransactionDate:   txnDate,
+		TransactionType:   transactionType,
+		ReferenceType:     "advance_" + req.Kind,
+		ReferenceID:       req.DocumentID,
+		PartyID:           req.CustomerID,
+		PartyName:         req.CustomerName,
+		PartyType:         "customer",
+		Description:       description,
+		Notes:             req.Notes,
+		Subtotal:          netAmount,
+		TaxAmount:         taxAmount,
+		TotalAmount:       req.Amount,
+		PaymentMode:       models.PaymentMode(req.PaymentMode),
+		PaymentReference:  req.PaymentReference,
+		Status:            models.TransactionStatusPosted,
 		Lines:             lines,
+		SupportingDetail:  supportingDetail(tenantID, "customer_advance", req.SupportingDetails),
 		CreatedBy:         userID,
 	}
 
+	if transaction.Lines, err = s.validate(ctx, tenantID, transaction.Lines); err != nil {
+		return nil, err
+	}
 	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
 		return nil, err
 	}
@@ -357,6 +789,22 @@ func (s *transactionService) GetTransaction(ctx context.Context, id, tenantID uu
 	return s.transactionRepo.FindByID(ctx, id, tenantID)
 }
 
+// GetSupportingDetail returns how a transaction's amounts were derived
+func (s *transactionService) GetSupportingDetail(ctx context.Context, id, tenantID uuid.UUID) (*models.TransactionSupportingDetail, error) {
+	if _, err := s.transactionRepo.FindByID(ctx, id, tenantID); err != nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	detail, err := s.transactionRepo.FindSupportingDetail(ctx, id, tenantID)
+	if err != nil {
+		if err == repository.ErrSupportingDetailNotFound {
+			return nil, ErrSupportingDetailNotFound
+		}
+		return nil, err
+	}
+	return detail, nil
+}
+
 func (s *transactionService) ListTransactions(ctx context.Context, tenantID uuid.UUID, filter repository.TransactionFilter) ([]models.Transaction, int64, error) {
 	return s.transactionRepo.FindAll(ctx, tenantID, filter)
 }
@@ -371,9 +819,106 @@ func (s *transactionService) VoidTransaction(ctx context.Context, id, tenantID u
 		return ErrCannotVoidTransaction
 	}
 
-	return s.transactionRepo.VoidTransaction(ctx, id, tenantID)
+	if err := s.transactionRepo.VoidTransaction(ctx, id, tenantID); err != nil {
+		return err
+	}
+	record(ctx, s.history, transactionTimelineDocument(transaction), timeline.EventVoided,
+		"Transaction "+transaction.TransactionNumber+" voided", nil)
+	return nil
 }
 
 func (s *transactionService) GetDailySummary(ctx context.Context, tenantID uuid.UUID, date time.Time) (*repository.DailySummary, error) {
 	return s.transactionRepo.GetDailySummary(ctx, tenantID, date)
 }
+
+// SetTags replaces a transaction's tags. Tags are analytical only, so
+// posted and voided transactions can be retagged.
+func (s *transactionService) SetTags(ctx context.Context, id, tenantID, userID uuid.UUID, tagList []string) (*models.Transaction, error) {
+	normalized, err := tags.NormalizeAll(tagList)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.transactionRepo.FindByID(ctx, id, tenantID)
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	before := *transaction
+	transaction.Tags = normalized
+	transaction.UpdatedBy = &userID
+	if err := s.transactionRepo.UpdateTags(ctx, transaction); err != nil {
+		return nil, err
+	}
+	record(ctx, s.history, transactionTimelineDocument(transaction), timeline.EventEdited, "Tags changed",
+		timeline.Diff(before, transaction, "tags"))
+
+	return transaction, nil
+}
+
+func (s *transactionService) ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error) {
+	return s.transactionRepo.ListTags(ctx, tenantID)
+}
+
+func (s *transactionService) RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error) {
+	from, err := tags.Normalize(from)
+	if err != nil {
+		return 0, err
+	}
+	to, err = tags.Normalize(to)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, nil
+	}
+	return s.transactionRepo.RenameTag(ctx, tenantID, from, to)
+}
+
+func (s *transactionService) DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error) {
+	tag, err := tags.Normalize(tag)
+	if err != nil {
+		return 0, err
+	}
+	return s.transactionRepo.DeleteTag(ctx, tenantID, tag)
+}
+
+// validate checks a journal entry's lines and posts any difference the
+// rounding tolerance let through to the Round Off account, so every entry
+// saved balances exactly
+func (s *transactionService) validate(ctx context.Context, tenantID uuid.UUID, lines []models.TransactionLine) ([]models.TransactionLine, error) {
+	return balanceJournal(ctx, s.validator, s.accounts, tenantID, lines)
+}
+
+// balanceJournal is transactionService.validate for services posting
+// journals of their own
+func balanceJournal(ctx context.Context, validator *JournalValidator, accounts AccountMappingService, tenantID uuid.UUID, lines []models.TransactionLine) ([]models.TransactionLine, error) {
+	if err := validator.Validate(lines); err != nil {
+		return nil, err
+	}
+	if !validator.HasRoundingDifference(lines) {
+		return lines, nil
+	}
+	account, _ := accounts.Resolve(ctx, tenantID, models.PostingTypeRoundOff)
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	return validator.Balance(lines, account.ID), nil
+}
+
+// supportingDetail wraps the supporting details sent with a posting, or
+// returns nil if there were none. Details without a calculation name are
+// named after how the transaction was posted.
+func supportingDetail(tenantID uuid.UUID, source string, details *models.SupportingDetails) *models.TransactionSupportingDetail {
+	if details == nil {
+		return nil
+	}
+	if details.Calculation == "" {
+		details.Calculation = source
+	}
+	return &models.TransactionSupportingDetail{
+		TenantID: tenantID,
+		Source:   source,
+		Details:  *details,
+	}
+}
@@ -10,13 +10,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/config"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/handlers"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/services"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
 )
 
 func main() {
@@ -41,6 +45,15 @@ func main() {
 		MaxIdleConns:    cfg.Database.MaxIdleConns,
 		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
 		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -53,91 +66,181 @@ func main() {
 		&models.Party{},
 		&models.PartyContact{},
 		&models.PartyBankDetail{},
+		&models.VendorOnboarding{},
+		&models.VendorDocument{},
+		&imports.Job{},
+		&imports.RowError{},
+		&storage.Document{},
+		&database.ResourceVersion{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
 	// Initialize repositories
 	partyRepo := repository.NewPartyRepository(db)
+	vendorOnboardingRepo := repository.NewVendorOnboardingRepository(db)
+	bankDetailRepo := repository.NewBankDetailRepository(db)
+	if err := vendorOnboardingRepo.RecordStorage(context.Background()); err != nil {
+		log.Printf("Failed to record vendor document storage: %v", err)
+	}
+
+	bankDetailCipher, err := services.NewBankDetailCipher(cfg.BankDetailsKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize bank detail encryption: %v", err)
+	}
+
+	// Party imports run in the background; jobs cut off by a restart are
+	// failed so they don't show as running forever
+	importRunner := imports.NewRunner(db, imports.Config{})
+	if err := importRunner.FailInterrupted(context.Background()); err != nil {
+		log.Printf("Failed to clean up interrupted imports: %v", err)
+	}
+
+	tenantClient := clients.NewTenantClient(cfg.Network.TenantServiceURL)
+	notificationClient := clients.NewNotificationClient(cfg.NotificationServiceURL)
 
 	// Initialize services
-	partyService := services.NewPartyService(partyRepo)
+	partyService := services.NewPartyService(partyRepo, importRunner)
+	bankDetailService := services.NewBankDetailService(bankDetailRepo, partyRepo, bankDetailCipher, tenantClient, notificationClient, cfg.BankDetailPaymentHold)
+	vendorOnboardingService := services.NewVendorOnboardingService(vendorOnboardingRepo, partyRepo, partyService, bankDetailCipher, cfg.VendorPortalURL)
 
 	// Initialize handlers
 	partyHandler := handlers.NewPartyHandler(partyService)
+	bankDetailHandler := handlers.NewBankDetailHandler(bankDetailService)
+	vendorOnboardingHandler := handlers.NewVendorOnboardingHandler(vendorOnboardingService)
+	importHandler := imports.NewHandler(importRunner)
 	healthHandler := handlers.NewHealthHandler(db)
 
 	// Setup router
 	router := gin.New()
 
-	// Allowed CORS origins
-	allowedOrigins := []string{
-		"https://app.bookkeep.in",
-		"https://www.bookkeep.in",
-		"https://bookkeep.in",
-	}
-	if !cfg.IsProduction() {
-		allowedOrigins = append(allowedOrigins,
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"exp://localhost:19000",
-		)
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(router, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// Apply middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.CORSMiddleware(allowedOrigins))
+	router.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
+	router.Use(i18n.Middleware(i18n.DefaultLanguage))
 
 	// Health endpoints (no auth required)
 	router.GET("/health", healthHandler.Health)
 	router.GET("/ready", healthHandler.Ready)
+	router.GET("/metrics", gin.WrapH(database.MetricsHandler(db)))
+
+	// Vendor onboarding form (public, authenticated by the link token)
+	onboardingRateLimiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
+		RequestsPerMinute: 20,
+		BurstSize:         5,
+		CleanupInterval:   5 * time.Minute,
+	})
+	vendorForm := router.Group("/api/v1/public/vendor-onboarding")
+	vendorForm.Use(onboardingRateLimiter.Middleware())
+	{
+		vendorForm.GET("/:token", vendorOnboardingHandler.GetForm)
+		vendorForm.POST("/:token", vendorOnboardingHandler.Submit)
+	}
+
+	// Tenants' IP and country restrictions, read from the tenant service
+	networkPolicies := middleware.NewNetworkPolicyClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+
+	// Bulk exports need the data:export permission and are recorded in the
+	// tenant's audit log
+	exportGuard := middleware.NewExportGuard(middleware.NewExportAuditClient(cfg.Network.TenantServiceURL))
 
 	// Protected endpoints
 	jwtConfig := middleware.JWTConfig{
-		Secret:    cfg.JWT.Secret,
-		Issuer:    cfg.JWT.Issuer,
-		SkipPaths: []string{"/health", "/ready"},
+		Secret:          cfg.JWT.Secret,
+		Secrets:         cfg.JWTSecrets,
+		RefreshSecrets:  cfg.RefreshJWTSecrets,
+		Issuer:          cfg.JWT.Issuer,
+		SkipPaths:       []string{"/health", "/ready", "/metrics"},
+		NetworkPolicies: networkPolicies,
+		CountryHeader:   cfg.Network.CountryHeader,
 	}
 
+	// Changing where a party is paid is how payment fraud starts, so it
+	// needs the user to have entered their password or MFA code recently
+	stepUp := middleware.RequireRecentAuth(cfg.JWT.StepUpMaxAge)
+
+	// Party lists answer conditional requests so mobile clients can
+	// revalidate instead of re-downloading them. A party's own page carries
+	// its credit score and ledger, which change outside this service, so it
+	// is not covered.
+	cached := middleware.ConditionalRequests(database.NewVersionStore(db), repository.PartiesResource)
+
 	api := router.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware(jwtConfig))
 	{
 		// Customers (parties with type=customer)
 		customers := api.Group("/customers")
 		{
-			customers.GET("", partyHandler.ListParties)
+			customers.GET("", cached, partyHandler.ListParties)
 			customers.POST("", partyHandler.CreateParty)
 			customers.GET("/:id", partyHandler.GetParty)
 			customers.PUT("/:id", partyHandler.UpdateParty)
 			customers.DELETE("/:id", partyHandler.DeleteParty)
-			customers.GET("/:id/ledger", partyHandler.GetPartyLedger)
+			customers.GET("/:id/ledger", exportGuard.ExportWhen("customer_ledger", middleware.FormatNotJSON), partyHandler.GetPartyLedger)
 			customers.POST("/:id/contacts", partyHandler.AddContact)
-			customers.POST("/:id/bank-details", partyHandler.AddBankDetail)
+			customers.POST("/:id/bank-details", stepUp, bankDetailHandler.Request)
 		}
 
 		// Vendors (parties with type=vendor)
 		vendors := api.Group("/vendors")
 		{
-			vendors.GET("", partyHandler.ListParties)
+			vendors.GET("", cached, partyHandler.ListParties)
 			vendors.POST("", partyHandler.CreateParty)
 			vendors.GET("/:id", partyHandler.GetParty)
 			vendors.PUT("/:id", partyHandler.UpdateParty)
 			vendors.DELETE("/:id", partyHandler.DeleteParty)
-			vendors.GET("/:id/ledger", partyHandler.GetPartyLedger)
+			vendors.GET("/:id/ledger", exportGuard.ExportWhen("vendor_ledger", middleware.FormatNotJSON), partyHandler.GetPartyLedger)
+			vendors.POST("/:id/bank-details", stepUp, bankDetailHandler.Request)
+			vendors.GET("/:id/payment-hold", bankDetailHandler.PaymentHold)
 		}
 
 		// General parties endpoint
 		parties := api.Group("/parties")
 		{
-			parties.GET("", partyHandler.ListParties)
+			parties.GET("", cached, partyHandler.ListParties)
 			parties.POST("", partyHandler.CreateParty)
+			parties.POST("/import", partyHandler.ImportParties)
 			parties.GET("/:id", partyHandler.GetParty)
 			parties.PUT("/:id", partyHandler.UpdateParty)
 			parties.DELETE("/:id", partyHandler.DeleteParty)
 		}
 
+		// Bank detail changes need a second user's approval
+		bankDetails := api.Group("/bank-details")
+		{
+			bankDetails.GET("/pending", bankDetailHandler.ListPending)
+			bankDetails.POST("/:id/approve", stepUp, bankDetailHandler.Approve)
+			bankDetails.POST("/:id/reject", bankDetailHandler.Reject)
+		}
+
+		// Import jobs
+		importJobs := api.Group("/imports")
+		{
+			importJobs.GET("", importHandler.List)
+			importJobs.GET("/:id", importHandler.Get)
+			importJobs.GET("/:id/errors", importHandler.ErrorReport)
+		}
+
+		// Vendor self-onboarding
+		vendorOnboarding := api.Group("/vendor-onboarding")
+		{
+			vendorOnboarding.GET("", vendorOnboardingHandler.List)
+			vendorOnboarding.POST("", vendorOnboardingHandler.Invite)
+			vendorOnboarding.GET("/:id", vendorOnboardingHandler.Get)
+			vendorOnboarding.DELETE("/:id", vendorOnboardingHandler.Cancel)
+			vendorOnboarding.GET("/:id/documents/:document_id", vendorOnboardingHandler.GetDocument)
+			vendorOnboarding.POST("/:id/approve", stepUp, vendorOnboardingHandler.Approve)
+			vendorOnboarding.POST("/:id/reject", vendorOnboardingHandler.Reject)
+		}
+
 		// GSTIN validation
 		api.GET("/validate-gstin/:gstin", partyHandler.ValidateGSTIN)
 	}
@@ -146,9 +249,9 @@ func main() {
 	srv := &http.Server{
 		Addr:         cfg.GetServerAddress(),
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Start server in goroutine
@@ -167,7 +270,7 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -0,0 +1,47 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantOwner is an owner of a tenant
+type TenantOwner struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+}
+
+// TenantClient reads tenant membership from the tenant service
+type TenantClient interface {
+	// ListOwners returns the tenant's owners, read on behalf of the member
+	// identified by authorization (the incoming Authorization header)
+	ListOwners(ctx context.Context, authorization string, tenantID uuid.UUID) ([]TenantOwner, error)
+}
+
+type tenantClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTenantClient creates a new tenant service client
+func NewTenantClient(baseURL string) TenantClient {
+	return &tenantClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *tenantClient) ListOwners(ctx context.Context, authorization string, tenantID uuid.UUID) ([]TenantOwner, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+
+	var owners []TenantOwner
+	err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/api/v1/tenants/"+tenantID.String()+"/owners", header, nil, &owners)
+	return owners, err
+}
@@ -1,25 +1,63 @@
 package config
 
 import (
+	"encoding/hex"
+	"time"
+
 	sharedConfig "github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
 )
 
 // Config holds customer service configuration
 type Config struct {
 	*sharedConfig.Config
+
+	// BankDetailsKey encrypts party bank account numbers (32 bytes, hex)
+	BankDetailsKey string
+
+	// VendorPortalURL is the page vendor onboarding links point to
+	VendorPortalURL string
+
+	// NotificationServiceURL is where owners' bank detail alerts are sent
+	NotificationServiceURL string
+
+	// BankDetailPaymentHold is how long payments to a party are held after
+	// its bank details change
+	BankDetailPaymentHold time.Duration
 }
 
 // Load loads customer service configuration
 func Load() (*Config, error) {
-	cfg, err := sharedConfig.Load("customer-service")
+	cfg, err := sharedConfig.LoadWithDefaults("customer-service", sharedConfig.Defaults{DatabaseName: "bookkeep_customer"})
 	if err != nil {
 		return nil, err
 	}
 
-	// Override default database name
-	if cfg.Database.DBName == "customer-service_db" {
-		cfg.Database.DBName = "bookkeep_customer"
+	env := sharedConfig.NewEnv("customer-service")
+	bankDetailsKey := env.String("BANK_DETAILS_KEY", "")
+	if cfg.IsProduction() && bankDetailsKey == "" {
+		env.Fail("BANK_DETAILS_KEY is required in production mode")
+	}
+	if bankDetailsKey == "" {
+		// Development only; never use for real bank details
+		bankDetailsKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
 	}
+	if key, err := hex.DecodeString(bankDetailsKey); err != nil || len(key) != 32 {
+		env.Fail("BANK_DETAILS_KEY must be 32 bytes written as 64 hex characters")
+	}
+
+	customerCfg := &Config{
+		Config:          cfg,
+		BankDetailsKey:  bankDetailsKey,
+		VendorPortalURL: env.String("VENDOR_PORTAL_URL", "https://app.bookkeep.in/vendor-onboarding"),
 
-	return &Config{Config: cfg}, nil
+		NotificationServiceURL: env.String("NOTIFICATION_SERVICE_URL", "http://bookkeeping-notification-service:8080"),
+		BankDetailPaymentHold:  time.Duration(env.Int("BANK_DETAIL_PAYMENT_HOLD_DAYS", 3)) * 24 * time.Hour,
+	}
+	if customerCfg.BankDetailPaymentHold < 0 {
+		env.Fail("BANK_DETAIL_PAYMENT_HOLD_DAYS must not be negative")
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
+	return customerCfg, nil
 }
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// BankDetailHandler handles party bank detail changes and their approval
+type BankDetailHandler struct {
+	bankDetailService services.BankDetailService
+}
+
+// NewBankDetailHandler creates a new bank detail handler
+func NewBankDetailHandler(bankDetailService services.BankDetailService) *BankDetailHandler {
+	return &BankDetailHandler{bankDetailService: bankDetailService}
+}
+
+// Request adds bank details to a party. They are not used until another
+// user approves them.
+func (h *BankDetailHandler) Request(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	partyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid party ID", nil)
+		return
+	}
+
+	var req services.CreateBankDetailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	detail, err := h.bankDetailService.Request(c.Request.Context(), tenantID, partyID, userID, req, c.GetHeader("Authorization"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, detail)
+}
+
+// ListPending lists bank detail changes awaiting approval
+func (h *BankDetailHandler) ListPending(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	details, err := h.bankDetailService.ListPending(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, details)
+}
+
+// Approve activates a bank detail change requested by another user
+func (h *BankDetailHandler) Approve(c *gin.Context) {
+	h.review(c, h.bankDetailService.Approve)
+}
+
+// Reject discards a bank detail change
+func (h *BankDetailHandler) Reject(c *gin.Context) {
+	h.review(c, h.bankDetailService.Reject)
+}
+
+// PaymentHold reports whether payments to a party are held after a recent
+// bank detail change. The invoice service checks it before paying bills.
+func (h *BankDetailHandler) PaymentHold(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	partyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid party ID", nil)
+		return
+	}
+
+	hold, err := h.bankDetailService.PaymentHold(c.Request.Context(), tenantID, partyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, hold)
+}
+
+func (h *BankDetailHandler) review(c *gin.Context, review func(ctx context.Context, tenantID, id, userID uuid.UUID, authorization string) (*models.PartyBankDetail, error)) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bank detail ID", nil)
+		return
+	}
+
+	detail, err := review(c.Request.Context(), tenantID, id, userID, c.GetHeader("Authorization"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+func (h *BankDetailHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPartyNotFound):
+		response.NotFound(c, "Party not found")
+	case errors.Is(err, services.ErrBankDetailNotFound):
+		response.NotFound(c, "Bank detail not found")
+	case errors.Is(err, services.ErrSelfApproval):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, services.ErrBankDetailNotPending):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, services.ErrInvalidIFSC):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, "Failed to process bank details")
+	}
+}
+
+// Helper methods
+
+func (h *BankDetailHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, services.ErrPartyNotFound
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *BankDetailHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, services.ErrPartyNotFound
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
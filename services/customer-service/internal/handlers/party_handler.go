@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"bytes"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/statement"
 )
 
 // PartyHandler handles party-related endpoints
@@ -160,6 +165,7 @@ func (h *PartyHandler) ListParties(c *gin.Context) {
 	filter := repository.PartyFilter{
 		PartyType: c.Query("type"),
 		Search:    c.Query("search"),
+		Approval:  c.Query("approval_status"),
 		SortBy:    c.Query("sort_by"),
 		SortOrder: c.Query("sort_order"),
 	}
@@ -187,7 +193,8 @@ func (h *PartyHandler) ListParties(c *gin.Context) {
 	response.Paginated(c, parties, filter.Page, filter.PerPage, total)
 }
 
-// GetPartyLedger handles getting party ledger
+// GetPartyLedger handles getting party ledger. With ?format=pdf or xlsx
+// the ledger is downloaded as a columnar statement of account.
 func (h *PartyHandler) GetPartyLedger(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
 	if err != nil {
@@ -203,18 +210,39 @@ func (h *PartyHandler) GetPartyLedger(c *gin.Context) {
 
 	fromDate := c.Query("from_date")
 	toDate := c.Query("to_date")
+	format := c.DefaultQuery("format", statement.FormatJSON)
+	if !statement.ValidFormat(format) {
+		response.BadRequest(c, statement.ErrUnsupportedFormat.Error(), nil)
+		return
+	}
 
 	ledger, err := h.partyService.GetPartyLedger(c.Request.Context(), partyID, tenantID, fromDate, toDate)
 	if err != nil {
-		if err == services.ErrPartyNotFound {
+		switch err {
+		case services.ErrPartyNotFound:
 			response.NotFound(c, "Party not found")
-			return
+		case services.ErrInvalidLedgerDate:
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to get party ledger")
 		}
-		response.InternalError(c, "Failed to get party ledger")
 		return
 	}
 
-	response.Success(c, ledger)
+	if format == statement.FormatJSON {
+		response.Success(c, ledger)
+		return
+	}
+
+	middleware.SetExportRows(c, len(ledger.Entries))
+	var buf bytes.Buffer
+	if err := statement.Write(&buf, format, ledger.Statement()); err != nil {
+		response.InternalError(c, "Failed to generate ledger statement")
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"ledger-"+partyID.String()+"."+format+"\"")
+	c.Data(http.StatusOK, statement.ContentType(format), buf.Bytes())
 }
 
 // ValidateGSTIN handles GSTIN validation
@@ -270,37 +298,46 @@ func (h *PartyHandler) AddContact(c *gin.Context) {
 	response.Created(c, contact)
 }
 
-// AddBankDetail handles adding bank details to a party
-func (h *PartyHandler) AddBankDetail(c *gin.Context) {
+// ImportParties queues a party import from an uploaded CSV file. Progress
+// and failed rows are available on the returned import job.
+func (h *PartyHandler) ImportParties(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
 	if err != nil {
 		response.BadRequest(c, "Tenant ID required", nil)
 		return
 	}
 
-	partyID, err := uuid.Parse(c.Param("id"))
+	userID, err := h.getUserIDFromContext(c)
 	if err != nil {
-		response.BadRequest(c, "Invalid party ID", nil)
+		response.Unauthorized(c, "User not authenticated")
 		return
 	}
 
-	var req services.CreateBankDetailRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "Invalid request body", nil)
+	var req services.ImportPartiesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid party type", nil)
 		return
 	}
 
-	bankDetail, err := h.partyService.AddBankDetail(c.Request.Context(), partyID, tenantID, req)
+	file, header, err := c.Request.FormFile("file")
 	if err != nil {
-		if err == services.ErrPartyNotFound {
-			response.NotFound(c, "Party not found")
+		response.BadRequest(c, "No file uploaded", nil)
+		return
+	}
+	defer file.Close()
+	req.FileName = header.Filename
+
+	job, err := h.partyService.ImportParties(c.Request.Context(), tenantID, userID, req, file)
+	if err != nil {
+		if err == imports.ErrFileTooLarge {
+			response.BadRequest(c, err.Error(), nil)
 			return
 		}
-		response.InternalError(c, "Failed to add bank detail")
+		response.InternalError(c, "Failed to start party import")
 		return
 	}
 
-	response.Created(c, bankDetail)
+	response.Accepted(c, job)
 }
 
 // Helper methods
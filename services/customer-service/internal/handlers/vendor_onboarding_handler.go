@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
+)
+
+// VendorOnboardingHandler handles vendor self-onboarding endpoints
+type VendorOnboardingHandler struct {
+	onboardingService services.VendorOnboardingService
+}
+
+// NewVendorOnboardingHandler creates a new vendor onboarding handler
+func NewVendorOnboardingHandler(onboardingService services.VendorOnboardingService) *VendorOnboardingHandler {
+	return &VendorOnboardingHandler{onboardingService: onboardingService}
+}
+
+// RejectVendorRequest represents a request to reject a vendor submission
+type RejectVendorRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Invite creates an onboarding link for a vendor
+func (h *VendorOnboardingHandler) Invite(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req services.InviteVendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	invitation, err := h.onboardingService.Invite(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		response.InternalError(c, "Failed to create onboarding link")
+		return
+	}
+
+	response.Created(c, invitation)
+}
+
+// List lists vendor onboardings
+func (h *VendorOnboardingHandler) List(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	onboardings, err := h.onboardingService.List(c.Request.Context(), tenantID, c.Query("status"))
+	if err != nil {
+		response.InternalError(c, "Failed to list vendor onboardings")
+		return
+	}
+
+	response.Success(c, onboardings)
+}
+
+// Get returns an onboarding with the vendor's submitted details
+func (h *VendorOnboardingHandler) Get(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid onboarding ID", nil)
+		return
+	}
+
+	detail, err := h.onboardingService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// GetDocument downloads a document uploaded by the vendor
+func (h *VendorOnboardingHandler) GetDocument(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid onboarding ID", nil)
+		return
+	}
+
+	documentID, err := uuid.Parse(c.Param("document_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid document ID", nil)
+		return
+	}
+
+	document, err := h.onboardingService.GetDocument(c.Request.Context(), tenantID, id, documentID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+document.FileName+"\"")
+	c.Data(http.StatusOK, document.ContentType, document.Content)
+}
+
+// Approve activates the vendor's pending party
+func (h *VendorOnboardingHandler) Approve(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid onboarding ID", nil)
+		return
+	}
+
+	onboarding, err := h.onboardingService.Approve(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, onboarding)
+}
+
+// Reject rejects the vendor's submission
+func (h *VendorOnboardingHandler) Reject(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid onboarding ID", nil)
+		return
+	}
+
+	var req RejectVendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Rejection reason is required", nil)
+		return
+	}
+
+	onboarding, err := h.onboardingService.Reject(c.Request.Context(), tenantID, id, userID, req.Reason)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, onboarding)
+}
+
+// Cancel revokes an unused onboarding link
+func (h *VendorOnboardingHandler) Cancel(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid onboarding ID", nil)
+		return
+	}
+
+	if err := h.onboardingService.Cancel(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Onboarding link cancelled"})
+}
+
+// GetForm returns the invitation details for the vendor's form (public)
+func (h *VendorOnboardingHandler) GetForm(c *gin.Context) {
+	onboarding, err := h.onboardingService.GetByToken(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"name":       onboarding.Name,
+		"email":      onboarding.Email,
+		"phone":      onboarding.Phone,
+		"message":    onboarding.Message,
+		"expires_at": onboarding.ExpiresAt,
+	})
+}
+
+// Submit accepts the vendor's details and cancelled cheque (public,
+// multipart form)
+func (h *VendorOnboardingHandler) Submit(c *gin.Context) {
+	var req services.SubmitVendorDetailsRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	file, err := c.FormFile("cancelled_cheque")
+	if err != nil {
+		response.BadRequest(c, "Cancelled cheque is required", nil)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "Failed to read cancelled cheque", nil)
+		return
+	}
+	defer f.Close()
+
+	// Read one byte past the limit so oversized files are rejected by the
+	// service rather than silently truncated
+	content, err := io.ReadAll(io.LimitReader(f, services.MaxVendorDocumentSize+1))
+	if err != nil {
+		response.BadRequest(c, "Failed to read cancelled cheque", nil)
+		return
+	}
+
+	req.CancelledCheque = &services.VendorDocumentUpload{
+		FileName:    file.Filename,
+		ContentType: http.DetectContentType(content),
+		Content:     content,
+	}
+	req.ClientIP = c.ClientIP()
+
+	onboarding, err := h.onboardingService.Submit(c.Request.Context(), c.Param("token"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"status":       onboarding.Status,
+		"submitted_at": onboarding.SubmittedAt,
+	})
+}
+
+func (h *VendorOnboardingHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOnboardingNotFound):
+		response.NotFound(c, "Vendor onboarding not found")
+	case errors.Is(err, services.ErrPartyNotFound):
+		response.NotFound(c, "Party not found")
+	case errors.Is(err, services.ErrOnboardingClosed):
+		response.BadRequest(c, "This onboarding link has expired or was already used", nil)
+	case errors.Is(err, services.ErrPartyExists):
+		response.Conflict(c, "A party with this GSTIN already exists")
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		response.Forbidden(c, "The business has run out of document storage; please contact them")
+	case errors.Is(err, services.ErrOnboardingNotSubmitted),
+		errors.Is(err, services.ErrDeclarationRequired),
+		errors.Is(err, services.ErrInvalidPAN),
+		errors.Is(err, services.ErrInvalidGSTIN),
+		errors.Is(err, services.ErrInvalidIFSC),
+		errors.Is(err, services.ErrInvalidMSME),
+		errors.Is(err, services.ErrChequeRequired),
+		errors.Is(err, services.ErrDocumentTooLarge),
+		errors.Is(err, services.ErrUnsupportedDocumentType):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, "Failed to process vendor onboarding")
+	}
+}
+
+// Helper methods
+
+func (h *VendorOnboardingHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, services.ErrPartyNotFound
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *VendorOnboardingHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, services.ErrPartyNotFound
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
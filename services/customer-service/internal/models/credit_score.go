@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PartyCreditScore is a customer's payment behaviour score. The invoice
+// service owns the table and recalculates it daily from invoices, payments
+// and disputes; it is only read here.
+type PartyCreditScore struct {
+	TenantID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	CustomerID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+
+	Score int    `json:"score"` // 0-100
+	Grade string `json:"grade"` // A, B, C, D or unrated
+
+	InvoicesIssued   int     `json:"invoices_issued"`
+	InvoicesDue      int     `json:"invoices_due"`
+	InvoicesPaidLate int     `json:"invoices_paid_late"`
+	LatePaymentRate  float64 `json:"late_payment_rate"`
+	AverageDaysToPay float64 `json:"average_days_to_pay"`
+	AverageDaysLate  float64 `json:"average_days_late"`
+	BouncedPayments  int     `json:"bounced_payments"`
+	DisputedInvoices int     `json:"disputed_invoices"`
+	OverdueAmount    float64 `json:"overdue_amount"`
+
+	AverageMonthlySales  float64 `json:"average_monthly_sales"`
+	SuggestedCreditLimit float64 `json:"suggested_credit_limit"`
+
+	CalculatedAt time.Time `json:"calculated_at"`
+}
+
+// TableName returns the table name for PartyCreditScore
+func (PartyCreditScore) TableName() string {
+	return "customer_credit_scores"
+}
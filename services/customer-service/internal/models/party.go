@@ -65,12 +65,19 @@ type Party struct {
 	TDSSection    string  `gorm:"size:20" json:"tds_section"`
 	TDSRate       float64 `gorm:"type:decimal(5,2)" json:"tds_rate"`
 
+	// MSME registration (for vendors; payments to micro and small
+	// enterprises fall under the MSMED Act's 45-day limit)
+	MSMERegistered bool   `gorm:"default:false" json:"msme_registered"`
+	MSMECategory   string `gorm:"size:20" json:"msme_category,omitempty"` // micro, small, medium
+	UdyamNumber    string `gorm:"size:19" json:"udyam_number,omitempty"`
+
 	// Balances
 	OpeningBalance float64 `gorm:"type:decimal(15,2);default:0" json:"opening_balance"`
 	CurrentBalance float64 `gorm:"type:decimal(15,2);default:0" json:"current_balance"`
 
 	// Status
-	IsActive bool `gorm:"default:true" json:"is_active"`
+	IsActive       bool   `gorm:"default:true" json:"is_active"`
+	ApprovalStatus string `gorm:"size:20;default:'approved';index" json:"approval_status"` // pending until a self-onboarded vendor is reviewed
 
 	// Metadata
 	Tags         pq.StringArray `gorm:"type:text[]" json:"tags"`
@@ -81,6 +88,9 @@ type Party struct {
 	Contacts    []PartyContact    `gorm:"foreignKey:PartyID" json:"contacts,omitempty"`
 	BankDetails []PartyBankDetail `gorm:"foreignKey:PartyID" json:"bank_details,omitempty"`
 
+	// Payment behaviour of a customer, filled in on the party detail
+	CreditScore *PartyCreditScore `gorm:"-" json:"credit_score,omitempty"`
+
 	// Audit
 	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -122,6 +132,13 @@ func (p *Party) GetFullAddress() string {
 	return address
 }
 
+// Party approval statuses
+const (
+	PartyApprovalPending  = "pending"
+	PartyApprovalApproved = "approved"
+	PartyApprovalRejected = "rejected"
+)
+
 // PartyContact represents a contact person for a party
 type PartyContact struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -158,9 +175,25 @@ type PartyBankDetail struct {
 	IFSCCode               string    `gorm:"size:11" json:"ifsc_code"`
 	Branch                 string    `gorm:"size:255" json:"branch"`
 	IsPrimary              bool      `gorm:"default:false" json:"is_primary"`
-	CreatedAt              time.Time `json:"created_at"`
+
+	// Where a party is paid changes only once a second user approves it,
+	// and payments to the party are held for a while after it does
+	Status      string     `gorm:"size:20;default:'active';index" json:"status"`
+	RequestedBy *uuid.UUID `gorm:"type:uuid" json:"requested_by,omitempty"`
+	ReviewedBy  *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	HoldUntil   *time.Time `json:"hold_until,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
 }
 
+// Bank detail statuses
+const (
+	BankDetailPending  = "pending"
+	BankDetailActive   = "active"
+	BankDetailRejected = "rejected"
+)
+
 // TableName returns the table name for PartyBankDetail
 func (PartyBankDetail) TableName() string {
 	return "party_bank_details"
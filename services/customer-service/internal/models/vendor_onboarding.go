@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VendorOnboardingStatus represents the status of a vendor onboarding
+type VendorOnboardingStatus string
+
+const (
+	VendorOnboardingInvited   VendorOnboardingStatus = "invited"
+	VendorOnboardingSubmitted VendorOnboardingStatus = "submitted" // Pending party awaiting review
+	VendorOnboardingApproved  VendorOnboardingStatus = "approved"
+	VendorOnboardingRejected  VendorOnboardingStatus = "rejected"
+	VendorOnboardingCancelled VendorOnboardingStatus = "cancelled"
+)
+
+// VendorOnboarding is an invitation for a vendor to enter their own details
+// through a secure link. The vendor's submission is held as a pending party
+// until the tenant approves it.
+type VendorOnboarding struct {
+	ID        uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID              `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	TokenHash string                 `gorm:"size:64;not null;uniqueIndex" json:"-"` // SHA-256 of the link token
+	Name      string                 `gorm:"size:255;not null" json:"name"`
+	Email     string                 `gorm:"size:255;not null" json:"email"`
+	Phone     string                 `gorm:"size:20" json:"phone,omitempty"`
+	Message   string                 `gorm:"type:text" json:"message,omitempty"`
+	Status    VendorOnboardingStatus `gorm:"size:20;not null;default:'invited';index" json:"status"`
+	ExpiresAt time.Time              `gorm:"not null" json:"expires_at"`
+
+	// Submission. A vendor without a GSTIN declares that they are not
+	// registered under GST.
+	PartyID       *uuid.UUID `gorm:"type:uuid" json:"party_id,omitempty"`
+	GSTRegistered bool       `gorm:"default:false" json:"gst_registered"`
+	DeclarantName string     `gorm:"size:255" json:"declarant_name,omitempty"`
+	DeclarationIP string     `gorm:"size:45" json:"declaration_ip,omitempty"`
+	SubmittedAt   *time.Time `json:"submitted_at,omitempty"`
+
+	// Review
+	ReviewedBy      *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `gorm:"type:text" json:"rejection_reason,omitempty"`
+
+	Documents []VendorDocument `gorm:"foreignKey:OnboardingID" json:"documents,omitempty"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for VendorOnboarding
+func (VendorOnboarding) TableName() string {
+	return "vendor_onboardings"
+}
+
+// BeforeCreate hook
+func (o *VendorOnboarding) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsOpen reports whether the vendor can still use the link
+func (o *VendorOnboarding) IsOpen() bool {
+	return o.Status == VendorOnboardingInvited && time.Now().Before(o.ExpiresAt)
+}
+
+// StorageKindVendorDocument identifies vendor documents in storage usage
+const StorageKindVendorDocument = "vendor_document"
+
+// VendorDocument is a document uploaded by a vendor during onboarding, such
+// as a cancelled cheque
+type VendorDocument struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	OnboardingID uuid.UUID `gorm:"type:uuid;not null;index" json:"onboarding_id"`
+	PartyID      uuid.UUID `gorm:"type:uuid;not null;index" json:"party_id"`
+	DocumentType string    `gorm:"size:50;not null" json:"document_type"` // cancelled_cheque
+	FileName     string    `gorm:"size:255" json:"file_name"`
+	ContentType  string    `gorm:"size:100" json:"content_type"`
+	Size         int64     `json:"size"`
+	Content      []byte    `gorm:"type:bytea" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for VendorDocument
+func (VendorDocument) TableName() string {
+	return "vendor_documents"
+}
+
+// BeforeCreate hook
+func (d *VendorDocument) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
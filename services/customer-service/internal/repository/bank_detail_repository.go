@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// BankDetailRepository handles party bank detail data operations. Bank
+// details have no tenant of their own, so lookups go through their party.
+type BankDetailRepository interface {
+	Create(ctx context.Context, detail *models.PartyBankDetail) error
+	Update(ctx context.Context, detail *models.PartyBankDetail) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.PartyBankDetail, error)
+	ListPending(ctx context.Context, tenantID uuid.UUID) ([]models.PartyBankDetail, error)
+
+	// Activate saves an approved bank detail. A primary one replaces the
+	// party's current primary bank detail.
+	Activate(ctx context.Context, detail *models.PartyBankDetail) error
+
+	// LatestHold returns the latest hold on payments to the party that has
+	// not yet lapsed, or nil when there is none
+	LatestHold(ctx context.Context, tenantID, partyID uuid.UUID, now time.Time) (*time.Time, error)
+}
+
+type bankDetailRepository struct {
+	db *gorm.DB
+}
+
+// NewBankDetailRepository creates a new bank detail repository
+func NewBankDetailRepository(db *gorm.DB) BankDetailRepository {
+	return &bankDetailRepository{db: db}
+}
+
+func (r *bankDetailRepository) Create(ctx context.Context, detail *models.PartyBankDetail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(detail).Error; err != nil {
+			return err
+		}
+		return touchPartiesOf(tx, detail.PartyID)
+	})
+}
+
+func (r *bankDetailRepository) Update(ctx context.Context, detail *models.PartyBankDetail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(detail).Error; err != nil {
+			return err
+		}
+		return touchPartiesOf(tx, detail.PartyID)
+	})
+}
+
+func (r *bankDetailRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.PartyBankDetail, error) {
+	var detail models.PartyBankDetail
+	err := r.db.WithContext(ctx).
+		Joins("JOIN parties ON parties.id = party_bank_details.party_id").
+		Where("party_bank_details.id = ? AND parties.tenant_id = ? AND parties.deleted_at IS NULL", id, tenantID).
+		First(&detail).Error
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (r *bankDetailRepository) ListPending(ctx context.Context, tenantID uuid.UUID) ([]models.PartyBankDetail, error) {
+	details := []models.PartyBankDetail{}
+	err := r.db.WithContext(ctx).
+		Joins("JOIN parties ON parties.id = party_bank_details.party_id").
+		Where("parties.tenant_id = ? AND parties.deleted_at IS NULL", tenantID).
+		Where("party_bank_details.status = ?", models.BankDetailPending).
+		Order("party_bank_details.created_at ASC").
+		Find(&details).Error
+	return details, err
+}
+
+func (r *bankDetailRepository) Activate(ctx context.Context, detail *models.PartyBankDetail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if detail.IsPrimary {
+			err := tx.Model(&models.PartyBankDetail{}).
+				Where("party_id = ? AND id <> ?", detail.PartyID, detail.ID).
+				Update("is_primary", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(detail).Error; err != nil {
+			return err
+		}
+		return touchPartiesOf(tx, detail.PartyID)
+	})
+}
+
+func (r *bankDetailRepository) LatestHold(ctx context.Context, tenantID, partyID uuid.UUID, now time.Time) (*time.Time, error) {
+	var holds []time.Time
+	err := r.db.WithContext(ctx).
+		Model(&models.PartyBankDetail{}).
+		Joins("JOIN parties ON parties.id = party_bank_details.party_id").
+		Where("parties.id = ? AND parties.tenant_id = ?", partyID, tenantID).
+		Where("party_bank_details.status = ? AND party_bank_details.hold_until > ?", models.BankDetailActive, now).
+		Order("party_bank_details.hold_until DESC").
+		Limit(1).
+		Pluck("party_bank_details.hold_until", &holds).Error
+	if err != nil || len(holds) == 0 {
+		return nil, err
+	}
+	return &holds[0], nil
+}
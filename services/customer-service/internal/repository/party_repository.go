@@ -5,9 +5,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
 	"gorm.io/gorm"
 )
 
+// PartiesResource names a tenant's parties for conditional requests. Every
+// write to a party, its contacts or its bank details bumps it.
+const PartiesResource = "parties"
+
 // PartyRepository defines the interface for party data access
 type PartyRepository interface {
 	Create(ctx context.Context, party *models.Party) error
@@ -18,6 +23,14 @@ type PartyRepository interface {
 	FindAll(ctx context.Context, tenantID uuid.UUID, filter PartyFilter) ([]models.Party, int64, error)
 	UpdateBalance(ctx context.Context, id uuid.UUID, amount float64) error
 	GetLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) ([]LedgerEntry, error)
+
+	// GetLedgerMovement returns the party's posted debits less credits
+	// dated before the given date
+	GetLedgerMovement(ctx context.Context, id, tenantID uuid.UUID, beforeDate string) (float64, error)
+
+	// GetCreditScore returns a customer's payment behaviour score, or nil
+	// when it has not been scored
+	GetCreditScore(ctx context.Context, id, tenantID uuid.UUID) (*models.PartyCreditScore, error)
 }
 
 // PartyFilter defines filter options for listing parties
@@ -26,6 +39,7 @@ type PartyFilter struct {
 	Search     string
 	HasBalance bool
 	IsActive   *bool
+	Approval   string
 	Tags       []string
 	Page       int
 	PerPage    int
@@ -33,11 +47,13 @@ type PartyFilter struct {
 	SortOrder  string
 }
 
-// LedgerEntry represents a ledger entry for a party
+// LedgerEntry represents a ledger entry for a party. Description names the
+// account on the other side of the voucher, as the particulars column of a
+// ledger does.
 type LedgerEntry struct {
 	Date        string  `json:"date"`
-	Type        string  `json:"type"`
-	Reference   string  `json:"reference"`
+	Type        string  `json:"type"`      // Transaction type
+	Reference   string  `json:"reference"` // Transaction number
 	Description string  `json:"description"`
 	Debit       float64 `json:"debit"`
 	Credit      float64 `json:"credit"`
@@ -54,17 +70,50 @@ func NewPartyRepository(db *gorm.DB) PartyRepository {
 }
 
 func (r *partyRepository) Create(ctx context.Context, party *models.Party) error {
-	return r.db.WithContext(ctx).Create(party).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(party).Error; err != nil {
+			return err
+		}
+		return touchParties(tx, party.TenantID)
+	})
 }
 
 func (r *partyRepository) Update(ctx context.Context, party *models.Party) error {
-	return r.db.WithContext(ctx).Save(party).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(party).Error; err != nil {
+			return err
+		}
+		return touchParties(tx, party.TenantID)
+	})
 }
 
 func (r *partyRepository) Delete(ctx context.Context, id, tenantID uuid.UUID) error {
-	return r.db.WithContext(ctx).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Delete(&models.Party{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND tenant_id = ?", id, tenantID).
+			Delete(&models.Party{}).Error; err != nil {
+			return err
+		}
+		return touchParties(tx, tenantID)
+	})
+}
+
+// touchParties invalidates cached reads of the tenant's parties
+func touchParties(tx *gorm.DB, tenantID uuid.UUID) error {
+	return database.BumpVersion(tx.Statement.Context, tx, tenantID.String(), PartiesResource)
+}
+
+// touchPartiesOf invalidates cached reads of the parties of the tenant
+// that owns partyID
+func touchPartiesOf(tx *gorm.DB, partyID uuid.UUID) error {
+	var tenantID uuid.UUID
+	err := tx.Unscoped().Model(&models.Party{}).
+		Select("tenant_id").
+		Where("id = ?", partyID).
+		Scan(&tenantID).Error
+	if err != nil {
+		return err
+	}
+	return touchParties(tx, tenantID)
 }
 
 func (r *partyRepository) FindByID(ctx context.Context, id, tenantID uuid.UUID) (*models.Party, error) {
@@ -116,6 +165,10 @@ func (r *partyRepository) FindAll(ctx context.Context, tenantID uuid.UUID, filte
 		query = query.Where("is_active = ?", *filter.IsActive)
 	}
 
+	if filter.Approval != "" {
+		query = query.Where("approval_status = ?", filter.Approval)
+	}
+
 	if len(filter.Tags) > 0 {
 		query = query.Where("tags && ?", filter.Tags)
 	}
@@ -166,7 +219,67 @@ func (r *partyRepository) UpdateBalance(ctx context.Context, id uuid.UUID, amoun
 }
 
 func (r *partyRepository) GetLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) ([]LedgerEntry, error) {
-	// This would typically query transactions and invoices
-	// For now, return empty slice - will be populated when transaction service is integrated
-	return []LedgerEntry{}, nil
+	params := map[string]interface{}{"tenant": tenantID, "party": id, "from": fromDate, "to": toDate}
+	period := ""
+	if fromDate != "" {
+		period += " AND t.transaction_date >= CAST(@from AS date)"
+	}
+	if toDate != "" {
+		period += " AND t.transaction_date <= CAST(@to AS date)"
+	}
+
+	// The party's balance is kept on the receivable and payable accounts;
+	// the particulars are the largest line on any other account
+	entries := []LedgerEntry{}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			to_char(t.transaction_date, 'YYYY-MM-DD') AS date,
+			t.transaction_type AS type,
+			t.transaction_number AS reference,
+			COALESCE(other.name, t.description) AS description,
+			SUM(l.debit_amount) AS debit,
+			SUM(l.credit_amount) AS credit
+		FROM transactions t
+		JOIN transaction_lines l ON l.transaction_id = t.id
+		JOIN accounts a ON a.id = l.account_id AND a.sub_type IN ('receivable', 'payable')
+		LEFT JOIN LATERAL (
+			SELECT oa.name
+			FROM transaction_lines ol
+			JOIN accounts oa ON oa.id = ol.account_id
+			WHERE ol.transaction_id = t.id AND oa.sub_type NOT IN ('receivable', 'payable')
+			ORDER BY GREATEST(ol.debit_amount, ol.credit_amount) DESC
+			LIMIT 1
+		) other ON true
+		WHERE t.tenant_id = @tenant AND t.party_id = @party
+		AND t.status = 'posted' AND t.deleted_at IS NULL`+period+`
+		GROUP BY t.id, t.transaction_date, t.transaction_type, t.transaction_number, t.description, t.created_at, other.name
+		ORDER BY t.transaction_date, t.created_at
+	`, params).Scan(&entries).Error
+	return entries, err
+}
+
+func (r *partyRepository) GetLedgerMovement(ctx context.Context, id, tenantID uuid.UUID, beforeDate string) (float64, error) {
+	var movement float64
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(SUM(l.debit_amount - l.credit_amount), 0)
+		FROM transactions t
+		JOIN transaction_lines l ON l.transaction_id = t.id
+		JOIN accounts a ON a.id = l.account_id AND a.sub_type IN ('receivable', 'payable')
+		WHERE t.tenant_id = @tenant AND t.party_id = @party
+		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND t.transaction_date < CAST(@before AS date)
+	`, map[string]interface{}{"tenant": tenantID, "party": id, "before": beforeDate}).Scan(&movement).Error
+	return movement, err
+}
+
+func (r *partyRepository) GetCreditScore(ctx context.Context, id, tenantID uuid.UUID) (*models.PartyCreditScore, error) {
+	var scores []models.PartyCreditScore
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ? AND tenant_id = ?", id, tenantID).
+		Limit(1).
+		Find(&scores).Error
+	if err != nil || len(scores) == 0 {
+		return nil, err
+	}
+	return &scores[0], nil
 }
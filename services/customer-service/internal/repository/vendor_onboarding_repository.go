@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
+	"gorm.io/gorm"
+)
+
+// VendorOnboardingRepository handles vendor onboarding data operations
+type VendorOnboardingRepository interface {
+	Create(ctx context.Context, onboarding *models.VendorOnboarding) error
+	Update(ctx context.Context, onboarding *models.VendorOnboarding) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.VendorOnboarding, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.VendorOnboarding, error)
+	List(ctx context.Context, tenantID uuid.UUID, status string) ([]models.VendorOnboarding, error)
+	GetDocument(ctx context.Context, tenantID, id uuid.UUID) (*models.VendorDocument, error)
+
+	// Submit stores the vendor's pending party and documents and marks the
+	// onboarding submitted, in one transaction. Fails with
+	// storage.ErrQuotaExceeded if the documents do not fit the tenant's
+	// storage quota.
+	Submit(ctx context.Context, onboarding *models.VendorOnboarding, party *models.Party, documents []models.VendorDocument) error
+
+	// Review saves the reviewed onboarding together with its party
+	Review(ctx context.Context, onboarding *models.VendorOnboarding, party *models.Party) error
+
+	// RecordStorage counts documents uploaded before storage accounting
+	// against their tenants' usage
+	RecordStorage(ctx context.Context) error
+}
+
+type vendorOnboardingRepository struct {
+	db *gorm.DB
+}
+
+// NewVendorOnboardingRepository creates a new vendor onboarding repository
+func NewVendorOnboardingRepository(db *gorm.DB) VendorOnboardingRepository {
+	return &vendorOnboardingRepository{db: db}
+}
+
+func (r *vendorOnboardingRepository) Create(ctx context.Context, onboarding *models.VendorOnboarding) error {
+	return r.db.WithContext(ctx).Create(onboarding).Error
+}
+
+func (r *vendorOnboardingRepository) Update(ctx context.Context, onboarding *models.VendorOnboarding) error {
+	return r.db.WithContext(ctx).Omit("Documents").Save(onboarding).Error
+}
+
+func (r *vendorOnboardingRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.VendorOnboarding, error) {
+	var onboarding models.VendorOnboarding
+	err := r.db.WithContext(ctx).
+		Preload("Documents", func(db *gorm.DB) *gorm.DB {
+			return db.Omit("content")
+		}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&onboarding).Error
+	if err != nil {
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+func (r *vendorOnboardingRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.VendorOnboarding, error) {
+	var onboarding models.VendorOnboarding
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&onboarding).Error
+	if err != nil {
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+func (r *vendorOnboardingRepository) List(ctx context.Context, tenantID uuid.UUID, status string) ([]models.VendorOnboarding, error) {
+	var onboardings []models.VendorOnboarding
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("created_at DESC").Find(&onboardings).Error
+	return onboardings, err
+}
+
+func (r *vendorOnboardingRepository) GetDocument(ctx context.Context, tenantID, id uuid.UUID) (*models.VendorDocument, error) {
+	var document models.VendorDocument
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *vendorOnboardingRepository) Submit(ctx context.Context, onboarding *models.VendorOnboarding, party *models.Party, documents []models.VendorDocument) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(party).Error; err != nil {
+			return err
+		}
+		// is_active defaults to true, so a false value is not written on
+		// create
+		if err := tx.Model(party).Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		for i := range documents {
+			documents[i].PartyID = party.ID
+		}
+		if len(documents) > 0 {
+			if err := tx.Create(&documents).Error; err != nil {
+				return err
+			}
+		}
+		for _, document := range documents {
+			err := storage.Record(tx, &storage.Document{
+				TenantID:    document.TenantID,
+				Kind:        models.StorageKindVendorDocument,
+				RefID:       document.ID,
+				FileName:    document.FileName,
+				ContentType: document.ContentType,
+				Size:        document.Size,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		onboarding.PartyID = &party.ID
+		if err := tx.Omit("Documents").Save(onboarding).Error; err != nil {
+			return err
+		}
+		return touchParties(tx, party.TenantID)
+	})
+}
+
+func (r *vendorOnboardingRepository) Review(ctx context.Context, onboarding *models.VendorOnboarding, party *models.Party) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(party).Updates(map[string]interface{}{
+			"is_active":       party.IsActive,
+			"approval_status": party.ApprovalStatus,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit("Documents").Save(onboarding).Error; err != nil {
+			return err
+		}
+		return touchParties(tx, onboarding.TenantID)
+	})
+}
+
+func (r *vendorOnboardingRepository) RecordStorage(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO stored_documents (id, tenant_id, kind, ref_id, file_name, content_type, size, created_at)
+		SELECT gen_random_uuid(), tenant_id, ?, id, file_name, content_type, size, created_at
+		FROM vendor_documents
+		ON CONFLICT (kind, ref_id) DO NOTHING`, models.StorageKindVendorDocument).Error
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBankDetailNotFound   = errors.New("bank detail not found")
+	ErrBankDetailNotPending = errors.New("bank detail change has already been reviewed")
+	ErrSelfApproval         = errors.New("a bank detail change must be approved by someone other than who requested it")
+)
+
+// BankDetailService handles changes to where parties are paid. A change
+// needs a second user's approval, payments to the party are held for a
+// while after it is approved, and the tenant's owners are told about it.
+type BankDetailService interface {
+	// Request adds bank details to a party, pending approval
+	Request(ctx context.Context, tenantID, partyID, userID uuid.UUID, req CreateBankDetailRequest, authorization string) (*models.PartyBankDetail, error)
+	Approve(ctx context.Context, tenantID, id, userID uuid.UUID, authorization string) (*models.PartyBankDetail, error)
+	Reject(ctx context.Context, tenantID, id, userID uuid.UUID, authorization string) (*models.PartyBankDetail, error)
+	ListPending(ctx context.Context, tenantID uuid.UUID) ([]models.PartyBankDetail, error)
+
+	// PaymentHold reports whether payments to the party are held after a
+	// recent bank detail change
+	PaymentHold(ctx context.Context, tenantID, partyID uuid.UUID) (*PaymentHold, error)
+}
+
+// PaymentHold is the hold on payments to a party
+type PaymentHold struct {
+	PartyID   uuid.UUID  `json:"party_id"`
+	OnHold    bool       `json:"on_hold"`
+	HoldUntil *time.Time `json:"hold_until,omitempty"`
+}
+
+type bankDetailService struct {
+	bankDetailRepo repository.BankDetailRepository
+	partyRepo      repository.PartyRepository
+	cipher         BankDetailCipher
+	tenantClient   clients.TenantClient
+	notifier       clients.NotificationClient
+	holdPeriod     time.Duration
+}
+
+// NewBankDetailService creates a new bank detail service. Payments to a
+// party are held for holdPeriod after a change to its bank details.
+func NewBankDetailService(
+	bankDetailRepo repository.BankDetailRepository,
+	partyRepo repository.PartyRepository,
+	cipher BankDetailCipher,
+	tenantClient clients.TenantClient,
+	notifier clients.NotificationClient,
+	holdPeriod time.Duration,
+) BankDetailService {
+	return &bankDetailService{
+		bankDetailRepo: bankDetailRepo,
+		partyRepo:      partyRepo,
+		cipher:         cipher,
+		tenantClient:   tenantClient,
+		notifier:       notifier,
+		holdPeriod:     holdPeriod,
+	}
+}
+
+func (s *bankDetailService) Request(ctx context.Context, tenantID, partyID, userID uuid.UUID, req CreateBankDetailRequest, authorization string) (*models.PartyBankDetail, error) {
+	party, err := s.partyRepo.FindByID(ctx, partyID, tenantID)
+	if err != nil {
+		return nil, ErrPartyNotFound
+	}
+
+	req.IFSCCode = strings.ToUpper(strings.TrimSpace(req.IFSCCode))
+	if !ifscRegex.MatchString(req.IFSCCode) {
+		return nil, ErrInvalidIFSC
+	}
+
+	accountNumber := strings.TrimSpace(req.AccountNumber)
+	encrypted, err := s.cipher.Encrypt(accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.PartyBankDetail{
+		PartyID:                partyID,
+		BankName:               req.BankName,
+		AccountName:            req.AccountName,
+		AccountNumberEncrypted: encrypted,
+		IFSCCode:               req.IFSCCode,
+		Branch:                 req.Branch,
+		IsPrimary:              req.IsPrimary,
+		Status:                 models.BankDetailPending,
+		RequestedBy:            &userID,
+	}
+	if err := s.bankDetailRepo.Create(ctx, detail); err != nil {
+		return nil, err
+	}
+	detail.AccountNumber = maskAccountNumber(accountNumber)
+
+	s.notifyOwners(ctx, tenantID, authorization, clients.Notification{
+		Title: fmt.Sprintf("Bank details change requested for %s", party.Name),
+		Message: fmt.Sprintf("New bank details (%s, account %s) were added to %s and are waiting for a second user's approval. If you did not expect this, reject the change.",
+			detail.BankName, detail.AccountNumber, party.Name),
+		Type: "warning",
+		Link: "/bank-details/pending",
+	})
+
+	return detail, nil
+}
+
+func (s *bankDetailService) Approve(ctx context.Context, tenantID, id, userID uuid.UUID, authorization string) (*models.PartyBankDetail, error) {
+	detail, party, err := s.getPending(ctx, tenantID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	detail.Status = models.BankDetailActive
+	detail.ReviewedBy = &userID
+	detail.ReviewedAt = &now
+	if s.holdPeriod > 0 {
+		holdUntil := now.Add(s.holdPeriod)
+		detail.HoldUntil = &holdUntil
+	}
+	if err := s.bankDetailRepo.Activate(ctx, detail); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("New bank details (%s, account %s) for %s were approved.", detail.BankName, s.maskedAccount(detail), party.Name)
+	if detail.HoldUntil != nil {
+		message += fmt.Sprintf(" Payments to %s are on hold until %s.", party.Name, detail.HoldUntil.Format("02 Jan 2006 15:04"))
+	}
+	s.notifyOwners(ctx, tenantID, authorization, clients.Notification{
+		Title:   fmt.Sprintf("Bank details changed for %s", party.Name),
+		Message: message,
+		Type:    "warning",
+		Link:    "/parties/" + party.ID.String(),
+	})
+
+	return detail, nil
+}
+
+func (s *bankDetailService) Reject(ctx context.Context, tenantID, id, userID uuid.UUID, authorization string) (*models.PartyBankDetail, error) {
+	detail, party, err := s.getPending(ctx, tenantID, id, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	detail.Status = models.BankDetailRejected
+	detail.IsPrimary = false
+	detail.ReviewedBy = &userID
+	detail.ReviewedAt = &now
+	if err := s.bankDetailRepo.Update(ctx, detail); err != nil {
+		return nil, err
+	}
+
+	s.notifyOwners(ctx, tenantID, authorization, clients.Notification{
+		Title: fmt.Sprintf("Bank details change rejected for %s", party.Name),
+		Message: fmt.Sprintf("The change of %s's bank details to %s, account %s was rejected.",
+			party.Name, detail.BankName, s.maskedAccount(detail)),
+		Type: "info",
+		Link: "/parties/" + party.ID.String(),
+	})
+
+	return detail, nil
+}
+
+func (s *bankDetailService) ListPending(ctx context.Context, tenantID uuid.UUID) ([]models.PartyBankDetail, error) {
+	details, err := s.bankDetailRepo.ListPending(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range details {
+		details[i].AccountNumber = s.maskedAccount(&details[i])
+	}
+	return details, nil
+}
+
+func (s *bankDetailService) PaymentHold(ctx context.Context, tenantID, partyID uuid.UUID) (*PaymentHold, error) {
+	if _, err := s.partyRepo.FindByID(ctx, partyID, tenantID); err != nil {
+		return nil, ErrPartyNotFound
+	}
+
+	holdUntil, err := s.bankDetailRepo.LatestHold(ctx, tenantID, partyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentHold{
+		PartyID:   partyID,
+		OnHold:    holdUntil != nil,
+		HoldUntil: holdUntil,
+	}, nil
+}
+
+// getPending loads a bank detail change awaiting review with its party.
+// A non-nil approver must not be the user who requested the change.
+func (s *bankDetailService) getPending(ctx context.Context, tenantID, id, approverID uuid.UUID) (*models.PartyBankDetail, *models.Party, error) {
+	detail, err := s.bankDetailRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrBankDetailNotFound
+		}
+		return nil, nil, err
+	}
+	if detail.Status != models.BankDetailPending {
+		return nil, nil, ErrBankDetailNotPending
+	}
+	if approverID != uuid.Nil && detail.RequestedBy != nil && *detail.RequestedBy == approverID {
+		return nil, nil, ErrSelfApproval
+	}
+
+	party, err := s.partyRepo.FindByID(ctx, detail.PartyID, tenantID)
+	if err != nil {
+		return nil, nil, ErrPartyNotFound
+	}
+	return detail, party, nil
+}
+
+func (s *bankDetailService) maskedAccount(detail *models.PartyBankDetail) string {
+	accountNumber, err := s.cipher.Decrypt(detail.AccountNumberEncrypted)
+	if err != nil {
+		return ""
+	}
+	return maskAccountNumber(accountNumber)
+}
+
+// notifyOwners alerts the tenant's owners in the background; a failure to
+// notify does not undo the change
+func (s *bankDetailService) notifyOwners(ctx context.Context, tenantID uuid.UUID, authorization string, notification clients.Notification) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		owners, err := s.tenantClient.ListOwners(ctx, authorization, tenantID)
+		if err != nil {
+			log.Printf("Failed to list owners of tenant %s for bank detail alert: %v", tenantID, err)
+			return
+		}
+		for _, owner := range owners {
+			recipient := owner.UserID
+			for _, channel := range []string{clients.NotificationChannelInApp, clients.NotificationChannelEmail} {
+				message := notification
+				message.TenantID = tenantID.String()
+				message.Channel = channel
+				message.UserID = &recipient
+				if channel == clients.NotificationChannelEmail {
+					message.Email = owner.Email
+				}
+				if err := s.notifier.Send(ctx, message); err != nil {
+					log.Printf("Failed to send bank detail alert to %s: %v", recipient, err)
+				}
+			}
+		}
+	}()
+}
+
+// maskAccountNumber shows only the last four digits of an account number
+func maskAccountNumber(accountNumber string) string {
+	if len(accountNumber) <= 4 {
+		return accountNumber
+	}
+	return strings.Repeat("X", len(accountNumber)-4) + accountNumber[len(accountNumber)-4:]
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
+)
+
+// ImportKindParties is the import job kind for party CSV uploads
+const ImportKindParties = "parties"
+
+var (
+	ErrPartyNameRequired = errors.New("name is required")
+	ErrInvalidPartyType  = errors.New("party type must be customer, vendor or both")
+)
+
+// ImportPartiesRequest represents a party CSV upload
+type ImportPartiesRequest struct {
+	FileName string `json:"-"`
+
+	// PartyType applies to rows without a type column value
+	PartyType string `form:"party_type" binding:"omitempty,oneof=customer vendor both"`
+}
+
+// partyColumns are the column indices of a party CSV, -1 for columns the
+// file does not have
+type partyColumns struct {
+	partyType, name, displayName, email, phone, alternatePhone, gstin, pan int
+	address1, address2, city, state, stateCode, pincode                    int
+	creditLimit, creditPeriodDays, openingBalance, tags, notes             int
+}
+
+func partyColumnsOf(header imports.Header) partyColumns {
+	return partyColumns{
+		partyType:        header.Find("party_type", "party type", "type"),
+		name:             header.Find("name", "party name", "customer name", "vendor name"),
+		displayName:      header.Find("display_name", "display name"),
+		email:            header.Find("email", "email address"),
+		phone:            header.Find("phone", "mobile", "phone number"),
+		alternatePhone:   header.Find("alternate_phone", "alternate phone"),
+		gstin:            header.Find("gstin", "gst number", "gst no"),
+		pan:              header.Find("pan", "pan number"),
+		address1:         header.Find("billing_address_line1", "address line 1", "address"),
+		address2:         header.Find("billing_address_line2", "address line 2"),
+		city:             header.Find("billing_city", "city"),
+		state:            header.Find("billing_state", "state"),
+		stateCode:        header.Find("billing_state_code", "state code"),
+		pincode:          header.Find("billing_pincode", "pincode", "pin code"),
+		creditLimit:      header.Find("credit_limit", "credit limit"),
+		creditPeriodDays: header.Find("credit_period_days", "credit period", "credit days"),
+		openingBalance:   header.Find("opening_balance", "opening balance"),
+		tags:             header.Find("tags"),
+		notes:            header.Find("notes"),
+	}
+}
+
+// ImportParties queues a party CSV import. Each row goes through
+// CreateParty, so GSTIN and PAN checks and duplicate GSTINs fail just that
+// row.
+func (s *partyService) ImportParties(ctx context.Context, tenantID, userID uuid.UUID, req ImportPartiesRequest, file io.Reader) (*imports.Job, error) {
+	defaultType := req.PartyType
+	if defaultType == "" {
+		defaultType = string(models.PartyTypeCustomer)
+	}
+
+	job := &imports.Job{
+		TenantID:  tenantID,
+		Kind:      ImportKindParties,
+		FileName:  req.FileName,
+		CreatedBy: &userID,
+	}
+	if err := s.importRunner.Start(ctx, job, file, s.importPartyChunk(defaultType)); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *partyService) importPartyChunk(defaultType string) imports.Importer {
+	return func(ctx context.Context, job *imports.Job, chunk imports.Chunk) (imports.ChunkResult, error) {
+		var result imports.ChunkResult
+
+		cols := partyColumnsOf(chunk.Header)
+		if cols.name == -1 {
+			return result, fmt.Errorf("required column not found: name")
+		}
+
+		var userID uuid.UUID
+		if job.CreatedBy != nil {
+			userID = *job.CreatedBy
+		}
+
+		for _, row := range chunk.Rows {
+			req, err := cols.parse(row, defaultType)
+			if err != nil {
+				result.Fail(row, err)
+				continue
+			}
+
+			if _, err := s.CreateParty(ctx, job.TenantID, userID, req); err != nil {
+				result.Fail(row, err)
+				continue
+			}
+			result.Imported++
+		}
+
+		return result, nil
+	}
+}
+
+func (cols partyColumns) parse(row imports.Row, defaultType string) (CreatePartyRequest, error) {
+	req := CreatePartyRequest{
+		PartyType:           strings.ToLower(row.Value(cols.partyType)),
+		Name:                row.Value(cols.name),
+		DisplayName:         row.Value(cols.displayName),
+		Email:               row.Value(cols.email),
+		Phone:               row.Value(cols.phone),
+		AlternatePhone:      row.Value(cols.alternatePhone),
+		GSTIN:               row.Value(cols.gstin),
+		PAN:                 row.Value(cols.pan),
+		BillingAddressLine1: row.Value(cols.address1),
+		BillingAddressLine2: row.Value(cols.address2),
+		BillingCity:         row.Value(cols.city),
+		BillingState:        row.Value(cols.state),
+		BillingStateCode:    row.Value(cols.stateCode),
+		BillingPincode:      row.Value(cols.pincode),
+		Notes:               row.Value(cols.notes),
+	}
+
+	if req.Name == "" {
+		return req, ErrPartyNameRequired
+	}
+	if req.PartyType == "" {
+		req.PartyType = defaultType
+	}
+	switch models.PartyType(req.PartyType) {
+	case models.PartyTypeCustomer, models.PartyTypeVendor, models.PartyTypeBoth:
+	default:
+		return req, ErrInvalidPartyType
+	}
+
+	var err error
+	if req.CreditLimit, err = parseImportAmount(row.Value(cols.creditLimit)); err != nil {
+		return req, fmt.Errorf("invalid credit limit: %q", row.Value(cols.creditLimit))
+	}
+	if req.OpeningBalance, err = parseImportAmount(row.Value(cols.openingBalance)); err != nil {
+		return req, fmt.Errorf("invalid opening balance: %q", row.Value(cols.openingBalance))
+	}
+	if days := row.Value(cols.creditPeriodDays); days != "" {
+		if req.CreditPeriodDays, err = strconv.Atoi(days); err != nil {
+			return req, fmt.Errorf("invalid credit period: %q", days)
+		}
+	}
+
+	for _, tag := range strings.FieldsFunc(row.Value(cols.tags), func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+
+	return req, nil
+}
+
+// parseImportAmount parses an amount as typed in a spreadsheet, allowing
+// thousands separators
+func parseImportAmount(s string) (float64, error) {
+	s = strings.ReplaceAll(s, ",", "")
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
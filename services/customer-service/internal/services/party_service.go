@@ -3,21 +3,26 @@ package services
 import (
 	"context"
 	"errors"
+	"io"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/statement"
 )
 
 var (
-	ErrPartyNotFound    = errors.New("party not found")
-	ErrPartyExists      = errors.New("party already exists")
-	ErrInvalidGSTIN     = errors.New("invalid GSTIN format")
-	ErrInvalidPAN       = errors.New("invalid PAN format")
-	ErrInvalidPhone     = errors.New("invalid phone number")
-	ErrInvalidEmail     = errors.New("invalid email format")
+	ErrPartyNotFound     = errors.New("party not found")
+	ErrPartyExists       = errors.New("party already exists")
+	ErrInvalidGSTIN      = errors.New("invalid GSTIN format")
+	ErrInvalidPAN        = errors.New("invalid PAN format")
+	ErrInvalidPhone      = errors.New("invalid phone number")
+	ErrInvalidEmail      = errors.New("invalid email format")
+	ErrInvalidLedgerDate = errors.New("ledger dates must be in YYYY-MM-DD format")
 )
 
 // PartyService defines the interface for party business logic
@@ -30,7 +35,7 @@ type PartyService interface {
 	GetPartyLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) (*PartyLedgerResponse, error)
 	ValidateGSTIN(gstin string) (bool, error)
 	AddContact(ctx context.Context, partyID, tenantID uuid.UUID, req CreateContactRequest) (*models.PartyContact, error)
-	AddBankDetail(ctx context.Context, partyID, tenantID uuid.UUID, req CreateBankDetailRequest) (*models.PartyBankDetail, error)
+	ImportParties(ctx context.Context, tenantID, userID uuid.UUID, req ImportPartiesRequest, file io.Reader) (*imports.Job, error)
 }
 
 // CreatePartyRequest represents a request to create a party
@@ -106,9 +111,12 @@ type CreateBankDetailRequest struct {
 	IsPrimary     bool   `json:"is_primary"`
 }
 
-// PartyLedgerResponse represents the ledger response for a party
+// PartyLedgerResponse represents the ledger response for a party. Balances
+// are debit positive, so what a vendor is owed shows as a negative balance.
 type PartyLedgerResponse struct {
 	Party          models.Party             `json:"party"`
+	FromDate       string                   `json:"from_date,omitempty"`
+	ToDate         string                   `json:"to_date,omitempty"`
 	OpeningBalance float64                  `json:"opening_balance"`
 	Entries        []repository.LedgerEntry `json:"entries"`
 	ClosingBalance float64                  `json:"closing_balance"`
@@ -116,13 +124,46 @@ type PartyLedgerResponse struct {
 	TotalCredit    float64                  `json:"total_credit"`
 }
 
+// Statement returns the ledger in the columnar statement layout for PDF
+// and XLSX export
+func (l *PartyLedgerResponse) Statement() *statement.Statement {
+	st := &statement.Statement{
+		Name:           l.Party.Name,
+		OpeningBalance: l.OpeningBalance,
+		Rows:           make([]statement.Row, len(l.Entries)),
+	}
+	if l.Party.GSTIN != "" {
+		st.Details = append(st.Details, "GSTIN: "+l.Party.GSTIN)
+	}
+	if address := strings.TrimPrefix(l.Party.GetFullAddress(), ", "); address != "" {
+		st.Details = append(st.Details, address)
+	}
+	st.From, _ = time.Parse("2006-01-02", l.FromDate)
+	st.To, _ = time.Parse("2006-01-02", l.ToDate)
+
+	for i, entry := range l.Entries {
+		date, _ := time.Parse("2006-01-02", entry.Date)
+		st.Rows[i] = statement.Row{
+			Date:          date,
+			Particulars:   entry.Description,
+			VoucherType:   statement.VoucherType(entry.Type),
+			VoucherNumber: entry.Reference,
+			Debit:         entry.Debit,
+			Credit:        entry.Credit,
+			Balance:       entry.Balance,
+		}
+	}
+	return st
+}
+
 type partyService struct {
-	partyRepo repository.PartyRepository
+	partyRepo    repository.PartyRepository
+	importRunner *imports.Runner
 }
 
 // NewPartyService creates a new party service
-func NewPartyService(partyRepo repository.PartyRepository) PartyService {
-	return &partyService{partyRepo: partyRepo}
+func NewPartyService(partyRepo repository.PartyRepository, importRunner *imports.Runner) PartyService {
+	return &partyService{partyRepo: partyRepo, importRunner: importRunner}
 }
 
 func (s *partyService) CreateParty(ctx context.Context, tenantID, userID uuid.UUID, req CreatePartyRequest) (*models.Party, error) {
@@ -294,6 +335,12 @@ func (s *partyService) GetParty(ctx context.Context, id, tenantID uuid.UUID) (*m
 	if err != nil {
 		return nil, ErrPartyNotFound
 	}
+
+	if party.PartyType != models.PartyTypeVendor {
+		if party.CreditScore, err = s.partyRepo.GetCreditScore(ctx, id, tenantID); err != nil {
+			return nil, err
+		}
+	}
 	return party, nil
 }
 
@@ -302,27 +349,51 @@ func (s *partyService) ListParties(ctx context.Context, tenantID uuid.UUID, filt
 }
 
 func (s *partyService) GetPartyLedger(ctx context.Context, id, tenantID uuid.UUID, fromDate, toDate string) (*PartyLedgerResponse, error) {
+	for _, date := range []string{fromDate, toDate} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return nil, ErrInvalidLedgerDate
+		}
+	}
+
 	party, err := s.partyRepo.FindByID(ctx, id, tenantID)
 	if err != nil {
 		return nil, ErrPartyNotFound
 	}
 
+	// A vendor's opening balance is what we owe them, a credit
+	openingBalance := party.OpeningBalance
+	if party.PartyType == models.PartyTypeVendor {
+		openingBalance = -openingBalance
+	}
+	if fromDate != "" {
+		movement, err := s.partyRepo.GetLedgerMovement(ctx, id, tenantID, fromDate)
+		if err != nil {
+			return nil, err
+		}
+		openingBalance += movement
+	}
+
 	entries, err := s.partyRepo.GetLedger(ctx, id, tenantID, fromDate, toDate)
 	if err != nil {
 		return nil, err
 	}
 
 	var totalDebit, totalCredit float64
-	for _, entry := range entries {
-		totalDebit += entry.Debit
-		totalCredit += entry.Credit
+	balance := openingBalance
+	for i := range entries {
+		totalDebit += entries[i].Debit
+		totalCredit += entries[i].Credit
+		balance += entries[i].Debit - entries[i].Credit
+		entries[i].Balance = balance
 	}
 
 	return &PartyLedgerResponse{
 		Party:          *party,
-		OpeningBalance: party.OpeningBalance,
+		FromDate:       fromDate,
+		ToDate:         toDate,
+		OpeningBalance: openingBalance,
 		Entries:        entries,
-		ClosingBalance: party.CurrentBalance,
+		ClosingBalance: balance,
 		TotalDebit:     totalDebit,
 		TotalCredit:    totalCredit,
 	}, nil
@@ -358,26 +429,6 @@ func (s *partyService) AddContact(ctx context.Context, partyID, tenantID uuid.UU
 	return contact, nil
 }
 
-func (s *partyService) AddBankDetail(ctx context.Context, partyID, tenantID uuid.UUID, req CreateBankDetailRequest) (*models.PartyBankDetail, error) {
-	// Verify party exists
-	_, err := s.partyRepo.FindByID(ctx, partyID, tenantID)
-	if err != nil {
-		return nil, ErrPartyNotFound
-	}
-
-	bankDetail := &models.PartyBankDetail{
-		PartyID:       partyID,
-		BankName:      req.BankName,
-		AccountName:   req.AccountName,
-		AccountNumber: req.AccountNumber,
-		IFSCCode:      strings.ToUpper(req.IFSCCode),
-		Branch:        req.Branch,
-		IsPrimary:     req.IsPrimary,
-	}
-
-	return bankDetail, nil
-}
-
 // Helper functions
 
 func isValidPAN(pan string) bool {
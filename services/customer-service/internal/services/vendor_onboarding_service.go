@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/customer-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOnboardingNotFound      = errors.New("vendor onboarding not found")
+	ErrOnboardingClosed        = errors.New("vendor onboarding link is no longer valid")
+	ErrOnboardingNotSubmitted  = errors.New("vendor onboarding has not been submitted")
+	ErrDeclarationRequired     = errors.New("GST declaration must be accepted")
+	ErrInvalidIFSC             = errors.New("invalid IFSC code")
+	ErrInvalidMSME             = errors.New("invalid MSME details")
+	ErrChequeRequired          = errors.New("cancelled cheque is required")
+	ErrDocumentTooLarge        = errors.New("document exceeds the maximum size")
+	ErrUnsupportedDocumentType = errors.New("unsupported document type")
+)
+
+const onboardingLinkValidity = 14 * 24 * time.Hour
+
+// MaxVendorDocumentSize is the largest document a vendor may upload
+const MaxVendorDocumentSize = 5 << 20
+
+var (
+	ifscRegex  = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	udyamRegex = regexp.MustCompile(`^UDYAM-[A-Z]{2}-[0-9]{2}-[0-9]{7}$`)
+
+	vendorDocumentTypes = map[string]bool{
+		"application/pdf": true,
+		"image/jpeg":      true,
+		"image/png":       true,
+	}
+)
+
+// VendorOnboardingService handles vendor self-onboarding
+type VendorOnboardingService interface {
+	Invite(ctx context.Context, tenantID, userID uuid.UUID, req InviteVendorRequest) (*VendorInvitation, error)
+	List(ctx context.Context, tenantID uuid.UUID, status string) ([]models.VendorOnboarding, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*VendorOnboardingDetail, error)
+	GetDocument(ctx context.Context, tenantID, id, documentID uuid.UUID) (*models.VendorDocument, error)
+	Approve(ctx context.Context, tenantID, id, userID uuid.UUID) (*models.VendorOnboarding, error)
+	Reject(ctx context.Context, tenantID, id, userID uuid.UUID, reason string) (*models.VendorOnboarding, error)
+	Cancel(ctx context.Context, tenantID, id uuid.UUID) error
+
+	// Public, token-authenticated operations used by the vendor
+	GetByToken(ctx context.Context, token string) (*models.VendorOnboarding, error)
+	Submit(ctx context.Context, token string, req SubmitVendorDetailsRequest) (*models.VendorOnboarding, error)
+}
+
+// InviteVendorRequest represents a request to send an onboarding link
+type InviteVendorRequest struct {
+	Name    string `json:"name" binding:"required,max=255"`
+	Email   string `json:"email" binding:"required,email"`
+	Phone   string `json:"phone"`
+	Message string `json:"message"`
+}
+
+// VendorInvitation is returned once on invite; the token is not stored and
+// cannot be retrieved again
+type VendorInvitation struct {
+	Onboarding *models.VendorOnboarding `json:"onboarding"`
+	Link       string                   `json:"link"`
+}
+
+// SubmitVendorDetailsRequest is the form a vendor fills in through the link
+type SubmitVendorDetailsRequest struct {
+	Name                string `form:"name" binding:"required,max=255"`
+	Email               string `form:"email"`
+	Phone               string `form:"phone"`
+	PAN                 string `form:"pan" binding:"required"`
+	GSTIN               string `form:"gstin"`
+	BillingAddressLine1 string `form:"billing_address_line1" binding:"required"`
+	BillingAddressLine2 string `form:"billing_address_line2"`
+	BillingCity         string `form:"billing_city" binding:"required"`
+	BillingState        string `form:"billing_state" binding:"required"`
+	BillingStateCode    string `form:"billing_state_code"`
+	BillingPincode      string `form:"billing_pincode" binding:"required"`
+
+	MSMERegistered bool   `form:"msme_registered"`
+	MSMECategory   string `form:"msme_category"`
+	UdyamNumber    string `form:"udyam_number"`
+
+	BankName      string `form:"bank_name" binding:"required"`
+	AccountName   string `form:"account_name" binding:"required"`
+	AccountNumber string `form:"account_number" binding:"required"`
+	IFSCCode      string `form:"ifsc_code" binding:"required"`
+	Branch        string `form:"branch"`
+
+	// DeclarationAccepted confirms the GSTIN given (or that the vendor is
+	// not registered under GST) is correct
+	DeclarationAccepted bool   `form:"declaration_accepted"`
+	DeclarantName       string `form:"declarant_name" binding:"required"`
+
+	CancelledCheque *VendorDocumentUpload `form:"-"`
+	ClientIP        string                `form:"-"`
+}
+
+// VendorDocumentUpload is a file uploaded with the vendor form
+type VendorDocumentUpload struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// VendorOnboardingDetail is an onboarding with its pending party, for review
+type VendorOnboardingDetail struct {
+	models.VendorOnboarding
+	Party *models.Party `json:"party,omitempty"`
+}
+
+type vendorOnboardingService struct {
+	onboardingRepo repository.VendorOnboardingRepository
+	partyRepo      repository.PartyRepository
+	partyService   PartyService
+	cipher         BankDetailCipher
+	portalURL      string
+}
+
+// NewVendorOnboardingService creates a new vendor onboarding service
+func NewVendorOnboardingService(
+	onboardingRepo repository.VendorOnboardingRepository,
+	partyRepo repository.PartyRepository,
+	partyService PartyService,
+	cipher BankDetailCipher,
+	portalURL string,
+) VendorOnboardingService {
+	return &vendorOnboardingService{
+		onboardingRepo: onboardingRepo,
+		partyRepo:      partyRepo,
+		partyService:   partyService,
+		cipher:         cipher,
+		portalURL:      portalURL,
+	}
+}
+
+func (s *vendorOnboardingService) Invite(ctx context.Context, tenantID, userID uuid.UUID, req InviteVendorRequest) (*VendorInvitation, error) {
+	token, err := newOnboardingToken()
+	if err != nil {
+		return nil, err
+	}
+
+	onboarding := &models.VendorOnboarding{
+		TenantID:  tenantID,
+		TokenHash: hashOnboardingToken(token),
+		Name:      req.Name,
+		Email:     req.Email,
+		Phone:     req.Phone,
+		Message:   req.Message,
+		Status:    models.VendorOnboardingInvited,
+		ExpiresAt: time.Now().Add(onboardingLinkValidity),
+		CreatedBy: userID,
+	}
+
+	if err := s.onboardingRepo.Create(ctx, onboarding); err != nil {
+		return nil, err
+	}
+
+	return &VendorInvitation{
+		Onboarding: onboarding,
+		Link:       s.portalURL + "?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+func (s *vendorOnboardingService) List(ctx context.Context, tenantID uuid.UUID, status string) ([]models.VendorOnboarding, error) {
+	return s.onboardingRepo.List(ctx, tenantID, status)
+}
+
+func (s *vendorOnboardingService) Get(ctx context.Context, tenantID, id uuid.UUID) (*VendorOnboardingDetail, error) {
+	onboarding, err := s.onboardingRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrOnboardingNotFound
+	}
+
+	detail := &VendorOnboardingDetail{VendorOnboarding: *onboarding}
+	if onboarding.PartyID == nil {
+		return detail, nil
+	}
+
+	party, err := s.partyRepo.FindByID(ctx, *onboarding.PartyID, tenantID)
+	if err != nil {
+		return nil, ErrPartyNotFound
+	}
+	for i := range party.BankDetails {
+		bank := &party.BankDetails[i]
+		if bank.AccountNumberEncrypted == "" {
+			continue
+		}
+		if bank.AccountNumber, err = s.cipher.Decrypt(bank.AccountNumberEncrypted); err != nil {
+			return nil, err
+		}
+	}
+	detail.Party = party
+
+	return detail, nil
+}
+
+func (s *vendorOnboardingService) GetDocument(ctx context.Context, tenantID, id, documentID uuid.UUID) (*models.VendorDocument, error) {
+	document, err := s.onboardingRepo.GetDocument(ctx, tenantID, documentID)
+	if err != nil || document.OnboardingID != id {
+		return nil, ErrOnboardingNotFound
+	}
+	return document, nil
+}
+
+func (s *vendorOnboardingService) Approve(ctx context.Context, tenantID, id, userID uuid.UUID) (*models.VendorOnboarding, error) {
+	return s.review(ctx, tenantID, id, userID, true, "")
+}
+
+func (s *vendorOnboardingService) Reject(ctx context.Context, tenantID, id, userID uuid.UUID, reason string) (*models.VendorOnboarding, error) {
+	return s.review(ctx, tenantID, id, userID, false, reason)
+}
+
+func (s *vendorOnboardingService) review(ctx context.Context, tenantID, id, userID uuid.UUID, approve bool, reason string) (*models.VendorOnboarding, error) {
+	onboarding, err := s.onboardingRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrOnboardingNotFound
+	}
+	if onboarding.Status != models.VendorOnboardingSubmitted || onboarding.PartyID == nil {
+		return nil, ErrOnboardingNotSubmitted
+	}
+
+	party, err := s.partyRepo.FindByID(ctx, *onboarding.PartyID, tenantID)
+	if err != nil {
+		return nil, ErrPartyNotFound
+	}
+
+	now := time.Now()
+	onboarding.ReviewedBy = &userID
+	onboarding.ReviewedAt = &now
+	if approve {
+		onboarding.Status = models.VendorOnboardingApproved
+		party.IsActive = true
+		party.ApprovalStatus = models.PartyApprovalApproved
+	} else {
+		onboarding.Status = models.VendorOnboardingRejected
+		onboarding.RejectionReason = reason
+		party.IsActive = false
+		party.ApprovalStatus = models.PartyApprovalRejected
+	}
+
+	if err := s.onboardingRepo.Review(ctx, onboarding, party); err != nil {
+		return nil, err
+	}
+
+	return onboarding, nil
+}
+
+func (s *vendorOnboardingService) Cancel(ctx context.Context, tenantID, id uuid.UUID) error {
+	onboarding, err := s.onboardingRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return ErrOnboardingNotFound
+	}
+	if onboarding.Status != models.VendorOnboardingInvited {
+		return ErrOnboardingClosed
+	}
+
+	onboarding.Status = models.VendorOnboardingCancelled
+	return s.onboardingRepo.Update(ctx, onboarding)
+}
+
+func (s *vendorOnboardingService) GetByToken(ctx context.Context, token string) (*models.VendorOnboarding, error) {
+	onboarding, err := s.onboardingRepo.GetByTokenHash(ctx, hashOnboardingToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOnboardingNotFound
+		}
+		return nil, err
+	}
+	if !onboarding.IsOpen() {
+		return nil, ErrOnboardingClosed
+	}
+	return onboarding, nil
+}
+
+func (s *vendorOnboardingService) Submit(ctx context.Context, token string, req SubmitVendorDetailsRequest) (*models.VendorOnboarding, error) {
+	onboarding, err := s.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateSubmission(&req); err != nil {
+		return nil, err
+	}
+
+	if req.GSTIN != "" {
+		existing, _ := s.partyRepo.FindByGSTIN(ctx, req.GSTIN, onboarding.TenantID)
+		if existing != nil {
+			return nil, ErrPartyExists
+		}
+	}
+
+	encrypted, err := s.cipher.Encrypt(req.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	email := req.Email
+	if email == "" {
+		email = onboarding.Email
+	}
+
+	party := &models.Party{
+		TenantID:            onboarding.TenantID,
+		PartyType:           models.PartyTypeVendor,
+		Name:                req.Name,
+		Email:               email,
+		Phone:               req.Phone,
+		GSTIN:               req.GSTIN,
+		PAN:                 req.PAN,
+		BillingAddressLine1: req.BillingAddressLine1,
+		BillingAddressLine2: req.BillingAddressLine2,
+		BillingCity:         req.BillingCity,
+		BillingState:        req.BillingState,
+		BillingStateCode:    req.BillingStateCode,
+		BillingPincode:      req.BillingPincode,
+		MSMERegistered:      req.MSMERegistered,
+		MSMECategory:        req.MSMECategory,
+		UdyamNumber:         req.UdyamNumber,
+		ApprovalStatus:      models.PartyApprovalPending,
+		BankDetails: []models.PartyBankDetail{{
+			BankName:               req.BankName,
+			AccountName:            req.AccountName,
+			AccountNumberEncrypted: encrypted,
+			IFSCCode:               req.IFSCCode,
+			Branch:                 req.Branch,
+			IsPrimary:              true,
+		}},
+		CreatedBy: onboarding.CreatedBy,
+	}
+
+	cheque := req.CancelledCheque
+	documents := []models.VendorDocument{{
+		TenantID:     onboarding.TenantID,
+		OnboardingID: onboarding.ID,
+		DocumentType: "cancelled_cheque",
+		FileName:     cheque.FileName,
+		ContentType:  cheque.ContentType,
+		Size:         int64(len(cheque.Content)),
+		Content:      cheque.Content,
+	}}
+
+	now := time.Now()
+	onboarding.Status = models.VendorOnboardingSubmitted
+	onboarding.GSTRegistered = req.GSTIN != ""
+	onboarding.DeclarantName = req.DeclarantName
+	onboarding.DeclarationIP = req.ClientIP
+	onboarding.SubmittedAt = &now
+
+	if err := s.onboardingRepo.Submit(ctx, onboarding, party, documents); err != nil {
+		return nil, err
+	}
+
+	return onboarding, nil
+}
+
+func (s *vendorOnboardingService) validateSubmission(req *SubmitVendorDetailsRequest) error {
+	req.PAN = strings.ToUpper(strings.TrimSpace(req.PAN))
+	req.GSTIN = strings.ToUpper(strings.TrimSpace(req.GSTIN))
+	req.IFSCCode = strings.ToUpper(strings.TrimSpace(req.IFSCCode))
+	req.UdyamNumber = strings.ToUpper(strings.TrimSpace(req.UdyamNumber))
+
+	if !req.DeclarationAccepted {
+		return ErrDeclarationRequired
+	}
+	if !isValidPAN(req.PAN) {
+		return ErrInvalidPAN
+	}
+	if req.GSTIN != "" {
+		// The PAN is embedded in characters 3-12 of the GSTIN
+		if valid, _ := s.partyService.ValidateGSTIN(req.GSTIN); !valid || req.GSTIN[2:12] != req.PAN {
+			return ErrInvalidGSTIN
+		}
+	}
+	if !ifscRegex.MatchString(req.IFSCCode) {
+		return ErrInvalidIFSC
+	}
+
+	if req.MSMERegistered {
+		switch req.MSMECategory {
+		case "micro", "small", "medium":
+		default:
+			return ErrInvalidMSME
+		}
+		if !udyamRegex.MatchString(req.UdyamNumber) {
+			return ErrInvalidMSME
+		}
+	} else {
+		req.MSMECategory = ""
+		req.UdyamNumber = ""
+	}
+
+	cheque := req.CancelledCheque
+	if cheque == nil || len(cheque.Content) == 0 {
+		return ErrChequeRequired
+	}
+	if len(cheque.Content) > MaxVendorDocumentSize {
+		return ErrDocumentTooLarge
+	}
+	if !vendorDocumentTypes[cheque.ContentType] {
+		return ErrUnsupportedDocumentType
+	}
+
+	return nil
+}
+
+// Helper functions
+
+func newOnboardingToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashOnboardingToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
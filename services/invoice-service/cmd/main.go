@@ -10,27 +10,38 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/comments"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/email"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/features"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/lifecycle"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/messaging"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/support"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/webhook"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/einvoice"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/handlers"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/ocr"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
 )
 
 func main() {
 	// Load configuration
-	cfg, err := config.Load("invoice-service")
+	cfg, err := config.LoadWithDefaults("invoice-service", config.Defaults{DatabaseName: "bookkeep_invoice"})
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Override default database name
-	if cfg.Database.DBName == "invoice-service_db" {
-		cfg.Database.DBName = "bookkeep_invoice"
-	}
-
 	// Set Gin mode
 	gin.SetMode(cfg.App.Environment)
 
@@ -46,6 +57,15 @@ func main() {
 		MaxIdleConns:    cfg.Database.MaxIdleConns,
 		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
 		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -61,13 +81,71 @@ func main() {
 		&models.Bill{},
 		&models.BillItem{},
 		&models.BillPayment{},
+		&models.BillMatchSettings{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.CashLimitSettings{},
+		&models.CashLimitFlag{},
+		&models.EInvoiceCredential{},
+		&models.Quote{},
+		&models.QuoteItem{},
+		&models.DeliveryChallan{},
+		&models.DeliveryChallanItem{},
 		&models.Product{},
 		&models.CreditNote{},
 		&models.CreditNoteItem{},
 		&models.CreditNoteApplication{},
+		&models.DebitNote{},
+		&models.DebitNoteItem{},
 		&models.RecurringInvoice{},
 		&models.RecurringInvoiceItem{},
 		&models.GeneratedInvoice{},
+		&models.RoundingRule{},
+		&models.TaxSnapshot{},
+		&models.PaymentTerm{},
+		&models.CustomerPaymentTerm{},
+		&models.DocumentTemplate{},
+		&models.DunningPolicy{},
+		&models.CustomerDunningProfile{},
+		&models.DunningEvent{},
+		&models.InvoiceDispute{},
+		&models.InvoiceDisputeEvent{},
+		&models.ExpenseClaim{},
+		&models.ExpenseClaimItem{},
+		&models.ExpensePolicy{},
+		&models.ExpenseCategoryRule{},
+		&models.ExpensePolicyViolation{},
+		&models.Contract{},
+		&models.FinancingConsent{},
+		&models.FinancingExport{},
+		&models.CustomerCreditScore{},
+		&models.StatementRun{},
+		&models.AdvanceReceipt{},
+		&models.RefundVoucher{},
+		&models.SelfInvoice{},
+		&models.SelfInvoiceItem{},
+		&models.StatementDelivery{},
+		&models.InvoiceExport{},
+		&comments.Comment{},
+		&comments.Mention{},
+		&comments.Event{},
+		&imports.Job{},
+		&imports.RowError{},
+		&jobs.Job{},
+		&email.Delivery{},
+		&email.DeliveryAttachment{},
+		&messaging.Delivery{},
+		&messaging.OptOut{},
+		&webhook.InboundEvent{},
+		&lifecycle.State{},
+		&lifecycle.Transition{},
+		&timeline.Event{},
+		&support.Bundle{},
+		&support.ErrorLog{},
+		&database.NumberSequence{},
+		&database.ResourceVersion{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -76,98 +154,475 @@ func main() {
 	invoiceRepo := repository.NewInvoiceRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
 	billRepo := repository.NewBillRepository(db)
+	billMatchRepo := repository.NewBillMatchRepository(db)
+	cashLimitRepo := repository.NewCashLimitRepository(db)
+	einvoiceRepo := repository.NewEInvoiceRepository(db)
+	quoteRepo := repository.NewQuoteRepository(db)
+	deliveryChallanRepo := repository.NewDeliveryChallanRepository(db)
+	creditNoteRepo := repository.NewCreditNoteRepository(db)
+	debitNoteRepo := repository.NewDebitNoteRepository(db)
+	purchaseOrderRepo := repository.NewPurchaseOrderRepository(db)
 	billPaymentRepo := repository.NewBillPaymentRepository(db)
 	productRepo := repository.NewProductRepository(db)
 	recurringInvoiceRepo := repository.NewRecurringInvoiceRepository(db)
+	roundingRuleRepo := repository.NewRoundingRuleRepository(db)
+	taxSnapshotRepo := repository.NewTaxSnapshotRepository(db)
+	paymentTermRepo := repository.NewPaymentTermRepository(db)
+	documentTemplateRepo := repository.NewDocumentTemplateRepository(db)
+	dunningRepo := repository.NewDunningRepository(db)
+	disputeRepo := repository.NewDisputeRepository(db)
+	expenseClaimRepo := repository.NewExpenseClaimRepository(db)
+	expensePolicyRepo := repository.NewExpensePolicyRepository(db)
+	contractRepo := repository.NewContractRepository(db)
+	financingRepo := repository.NewFinancingRepository(db)
+	creditScoreRepo := repository.NewCreditScoreRepository(db)
+	statementRepo := repository.NewStatementRepository(db)
+	advanceRepo := repository.NewAdvanceRepository(db)
+	selfInvoiceRepo := repository.NewSelfInvoiceRepository(db)
+	invoiceExportRepo := repository.NewInvoiceExportRepository(db)
+	gstAnnualRepo := repository.NewGSTAnnualRepository(db)
+
+	// Initialize service clients. Work done without a user, such as
+	// recurring invoices, calls other services with a service token.
+	serviceCredentials := middleware.NewServiceCredentials(cfg.App.Name, cfg.JWT.Issuer, cfg.JWTSecret)
+	taxClient := clients.NewTaxClient(config.GetEnv("TAX_SERVICE_URL", "http://bookkeeping-tax-service:8080"))
+	bookkeepingClient := clients.NewBookkeepingClient(config.GetEnv("BOOKKEEPING_SERVICE_URL", "http://bookkeeping-core-service:8080"))
+	customerClient := clients.NewCustomerClient(config.GetEnv("CUSTOMER_SERVICE_URL", "http://bookkeeping-customer-service:8080"))
+	notificationClient := clients.NewNotificationClient(config.GetEnv("NOTIFICATION_SERVICE_URL", "http://bookkeeping-notification-service:8080"))
+	tenantClient := clients.NewTenantClient(cfg.Network.TenantServiceURL)
+	brandingClient := clients.NewBrandingClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+
+	// Invoices are registered with the IRP through the NIC e-invoice API,
+	// or a GSP serving it; the sandbox is used unless another URL is set.
+	// Without client credentials e-invoicing is off.
+	var einvoiceClient einvoice.Client
+	if clientID := config.GetEnv("EINVOICE_CLIENT_ID", ""); clientID != "" {
+		einvoiceClient, err = einvoice.NewNICClient(
+			config.GetEnv("EINVOICE_API_URL", einvoice.SandboxURL),
+			clientID,
+			config.GetEnv("EINVOICE_CLIENT_SECRET", ""),
+			config.GetEnv("EINVOICE_PUBLIC_KEY", ""),
+		)
+		if err != nil {
+			log.Fatalf("Failed to initialize e-invoice client: %v", err)
+		}
+	}
+	// Vendor bills are scanned into drafts by a document extraction
+	// service; without its URL scanning is off
+	var billReader ocr.Provider
+	if url := config.GetEnv("BILL_OCR_URL", ""); url != "" {
+		billReader = ocr.NewHTTPProvider(url, cfg.Secrets.GetOr("BILL_OCR_API_KEY", ""))
+	}
+	// Tenants' IRP passwords are encrypted at rest
+	credentialsKey := config.GetEnv("EINVOICE_CREDENTIALS_KEY", "")
+	if credentialsKey == "" {
+		if cfg.IsProduction() {
+			log.Fatal("EINVOICE_CREDENTIALS_KEY is required in production mode")
+		}
+		// Development only; never use for real credentials
+		credentialsKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	}
+	credentialCipher, err := services.NewCredentialCipher(credentialsKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize credential encryption: %v", err)
+	}
+
+	// Product imports run in the background; jobs cut off by a restart are
+	// failed so they don't show as running forever
+	importRunner := imports.NewRunner(db, imports.Config{})
+	if err := importRunner.FailInterrupted(context.Background()); err != nil {
+		log.Printf("Failed to clean up interrupted imports: %v", err)
+	}
+
+	// Background jobs run on a queue shared by all instances
+	jobQueue := jobs.NewQueue(db, jobs.Config{})
+
+	// Invoices are emailed to customers from the job queue
+	emailDriver, err := email.NewDriver(cfg.Email, cfg.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to set up email: %v", err)
+	}
+	mailer := email.NewMailer(db, jobQueue, emailDriver, cfg.Email)
+
+	// Invoices and payment reminders are also messaged to customers' phones,
+	// on the channels their seller chose
+	messagingDrivers, err := messaging.NewDrivers(cfg.Messaging)
+	if err != nil {
+		log.Fatalf("Failed to set up messaging: %v", err)
+	}
+	messenger := messaging.NewMessenger(db, jobQueue, messagingDrivers, cfg.Messaging)
+	messagingPreferences := messaging.NewPreferencesClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
 
 	// Initialize services
-	invoiceService := services.NewInvoiceService(invoiceRepo, paymentRepo)
-	billService := services.NewBillService(billRepo, billPaymentRepo)
-	productService := services.NewProductService(productRepo)
+	roundingService := services.NewRoundingService(roundingRuleRepo)
+	taxSnapshotService := services.NewTaxSnapshotService(taxSnapshotRepo, productRepo)
+	paymentTermService := services.NewPaymentTermService(paymentTermRepo)
+	periodLock := services.NewPeriodLock(bookkeepingClient, serviceCredentials)
+	documentLedger := services.NewDocumentLedger(bookkeepingClient, serviceCredentials)
+	// E-invoices and exports are processed asynchronously; their states are
+	// tracked so those stuck past their SLA can be chased
+	lifecycleTracker := lifecycle.NewTracker(db)
+	lifecycleTracker.Register(services.EInvoiceLifecycle)
+	lifecycleTracker.Register(services.InvoiceExportLifecycle)
+	// Edits and sends of invoices and bills are kept for their timelines
+	timelineStore := timeline.NewStore(db)
+	// Cash payments and receipts are checked against sections 40A(3) and
+	// 269ST of the Income-tax Act
+	cashLimitService := services.NewCashLimitService(cashLimitRepo)
+	expensePolicyService := services.NewExpensePolicyService(expensePolicyRepo)
+	// Invoices and bills are checked against the tenant's own validation
+	// rules, kept by the tenant service
+	validationRules := validation.NewClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+	invoiceService := services.NewInvoiceService(invoiceRepo, paymentRepo, roundingService, taxClient, taxSnapshotService, paymentTermService, periodLock, timelineStore, cashLimitService, validationRules, documentLedger)
+	einvoiceService := services.NewEInvoiceService(einvoiceRepo, invoiceRepo, einvoiceClient, credentialCipher, tenantClient, lifecycleTracker)
+	quoteService := services.NewQuoteService(quoteRepo, invoiceService, notificationClient,
+		config.GetEnv("QUOTE_PORTAL_URL", "https://app.bookkeep.in/quotes/respond"))
+	deliveryChallanService := services.NewDeliveryChallanService(deliveryChallanRepo, invoiceService)
+	creditNoteService := services.NewCreditNoteService(creditNoteRepo, invoiceRepo, periodLock, timelineStore)
+	debitNoteService := services.NewDebitNoteService(debitNoteRepo, billRepo, periodLock, timelineStore)
+	billMatchService := services.NewBillMatchService(billMatchRepo)
+	billService := services.NewBillService(billRepo, billPaymentRepo, roundingService, taxClient, bookkeepingClient, customerClient, taxSnapshotService, periodLock, timelineStore, cashLimitService, expensePolicyService, validationRules, documentLedger)
+	billScanService := services.NewBillScanService(billReader, customerClient)
+	documentTemplateService := services.NewDocumentTemplateService(documentTemplateRepo, invoiceRepo, billRepo)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, billService, billMatchService)
+	productService := services.NewProductService(productRepo, importRunner)
 	recurringInvoiceService := services.NewRecurringInvoiceService(recurringInvoiceRepo, invoiceRepo, invoiceService)
+	invoiceMessageService := services.NewInvoiceMessageService(messenger, messagingPreferences)
+	dunningService := services.NewDunningService(dunningRepo, invoiceRepo, creditScoreRepo, notificationClient, invoiceMessageService, periodLock)
+	disputeService := services.NewDisputeService(disputeRepo, invoiceRepo)
+	expenseClaimService := services.NewExpenseClaimService(expenseClaimRepo, billService, expensePolicyService)
+	advanceService := services.NewAdvanceService(advanceRepo, bookkeepingClient)
+	selfInvoiceService := services.NewSelfInvoiceService(selfInvoiceRepo, billRepo)
+	gstAnnualService := services.NewGSTAnnualService(gstAnnualRepo)
+	contractService := services.NewContractService(contractRepo, notificationClient)
+	financingService := services.NewFinancingService(financingRepo)
+	creditScoreService := services.NewCreditScoreService(creditScoreRepo)
+	statementService := services.NewStatementService(statementRepo, notificationClient, jobQueue)
+	// Download links for invoice exports are signed with their own secret
+	// where one is set
+	invoiceExportService := services.NewInvoiceExportService(invoiceExportRepo, tenantClient, brandingClient, jobQueue,
+		config.GetEnv("INVOICE_EXPORT_LINK_SECRET", cfg.JWT.Secret), lifecycleTracker)
+
+	invoiceEmailService := services.NewInvoiceEmailService(invoiceRepo, tenantClient, brandingClient, mailer, timelineStore)
+	mailer.OnStatusChange(invoiceEmailService.TrackDelivery)
+
+	// Recurring invoices are generated by an hourly job queued once across
+	// all instances; customers are rescored and lapsed quotes expired daily.
+	// Statement runs are queued on request, as are invoice exports, whose
+	// zips are purged daily once expired.
+	jobQueue.Register(services.JobGenerateRecurringInvoices, func(ctx context.Context, job *jobs.Job) error {
+		_, err := recurringInvoiceService.GenerateDueInvoices(ctx)
+		return err
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobGenerateRecurringInvoices, time.Hour)
+	jobQueue.Register(services.JobCalculateCreditScores, func(ctx context.Context, job *jobs.Job) error {
+		return creditScoreService.RecalculateAll(ctx)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobCalculateCreditScores, 24*time.Hour)
+	jobQueue.Register(services.JobExpireQuotes, func(ctx context.Context, job *jobs.Job) error {
+		return quoteService.ExpireLapsed(ctx)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobExpireQuotes, 24*time.Hour)
+	jobQueue.Register(services.JobSendStatements, func(ctx context.Context, job *jobs.Job) error {
+		var payload services.SendStatementsPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return statementService.ProcessRun(ctx, payload.RunID)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Register(services.JobExportInvoicePDFs, func(ctx context.Context, job *jobs.Job) error {
+		var payload services.ExportInvoicePDFsPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return invoiceExportService.Process(ctx, payload)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Register(services.JobPurgeInvoiceExports, func(ctx context.Context, job *jobs.Job) error {
+		return invoiceExportService.PurgeExpired(ctx)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobPurgeInvoiceExports, 24*time.Hour)
+	jobQueue.Start(context.Background())
 
 	// Initialize handlers
-	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
-	billHandler := handlers.NewBillHandler(billService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService, invoiceEmailService, invoiceMessageService, documentTemplateService, tenantClient, brandingClient)
+	einvoiceHandler := handlers.NewEInvoiceHandler(einvoiceService)
+	quoteHandler := handlers.NewQuoteHandler(quoteService)
+	deliveryChallanHandler := handlers.NewDeliveryChallanHandler(deliveryChallanService, tenantClient)
+	creditNoteHandler := handlers.NewCreditNoteHandler(creditNoteService)
+	debitNoteHandler := handlers.NewDebitNoteHandler(debitNoteService)
+	// Paying a bill above this amount needs a recent password or MFA check
+	billPaymentStepUpAmount := decimal.NewFromInt(int64(config.GetEnvAsInt("BILL_PAYMENT_STEP_UP_AMOUNT", 100000)))
+	billHandler := handlers.NewBillHandler(billService, documentTemplateService, billPaymentStepUpAmount, cfg.JWT.StepUpMaxAge)
+	billMatchHandler := handlers.NewBillMatchHandler(billMatchService)
+	billScanHandler := handlers.NewBillScanHandler(billScanService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	cashLimitHandler := handlers.NewCashLimitHandler(cashLimitService)
 	productHandler := handlers.NewProductHandler(productService)
 	recurringInvoiceHandler := handlers.NewRecurringInvoiceHandler(recurringInvoiceService)
+	roundingHandler := handlers.NewRoundingHandler(roundingService)
+	paymentTermHandler := handlers.NewPaymentTermHandler(paymentTermService)
+	documentTemplateHandler := handlers.NewDocumentTemplateHandler(documentTemplateService)
+	dunningHandler := handlers.NewDunningHandler(dunningService)
+	disputeHandler := handlers.NewDisputeHandler(disputeService)
+	expenseClaimHandler := handlers.NewExpenseClaimHandler(expenseClaimService)
+	expensePolicyHandler := handlers.NewExpensePolicyHandler(expensePolicyService)
+	advanceHandler := handlers.NewAdvanceHandler(advanceService)
+	selfInvoiceHandler := handlers.NewSelfInvoiceHandler(selfInvoiceService)
+	gstAnnualHandler := handlers.NewGSTAnnualHandler(gstAnnualService)
+	contractHandler := handlers.NewContractHandler(contractService)
+	financingHandler := handlers.NewFinancingHandler(financingService)
+	creditScoreHandler := handlers.NewCreditScoreHandler(creditScoreService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	invoiceExportHandler := handlers.NewInvoiceExportHandler(invoiceExportService)
+	// Internal comment threads on invoices and bills; mentioned users get an
+	// in-app notification
+	commentStore := comments.NewStore(db, comments.NewNotificationServiceNotifier(config.GetEnv("NOTIFICATION_SERVICE_URL", "http://bookkeeping-notification-service:8080")))
+	invoiceCommentHandler := comments.NewHandler(commentStore, comments.DocumentConfig{
+		Type:   comments.DocumentInvoice,
+		Link:   "/invoices/%s",
+		Lookup: services.InvoiceCommentLookup(invoiceRepo),
+	})
+	billCommentHandler := comments.NewHandler(commentStore, comments.DocumentConfig{
+		Type:   comments.DocumentBill,
+		Link:   "/bills/%s",
+		Lookup: services.BillCommentLookup(billRepo),
+	})
+	taxSnapshotHandler := handlers.NewTaxSnapshotHandler(taxSnapshotService)
+	invoiceTimelineHandler := timeline.NewHandler(timelineStore, timeline.DocumentConfig{
+		Type:    timeline.DocumentInvoice,
+		Compose: services.InvoiceTimeline(invoiceRepo, dunningRepo, disputeRepo, lifecycleTracker),
+	})
+	billTimelineHandler := timeline.NewHandler(timelineStore, timeline.DocumentConfig{
+		Type:    timeline.DocumentBill,
+		Compose: services.BillTimeline(billRepo),
+	})
+
+	// Callbacks from payment gateways and the e-invoice portal. A source is
+	// enabled by its signing secrets, read from the secret store and
+	// comma-separated while one is rotated. None is processed here yet, so
+	// their events are kept as dead letters to be replayed.
+	webhookReceiver := webhook.NewReceiver(db)
+	if secrets := cfg.Secrets.List("RAZORPAY_WEBHOOK_SECRETS"); len(secrets) > 0 {
+		// Razorpay retries a delivery for a day, keeping its created_at
+		webhookReceiver.Register("razorpay", webhook.Razorpay(secrets...), nil, webhook.SourceOptions{Tolerance: 25 * time.Hour})
+	}
+	if secrets := cfg.Secrets.List("STRIPE_WEBHOOK_SECRETS"); len(secrets) > 0 {
+		webhookReceiver.Register("stripe", webhook.Stripe(secrets...), nil, webhook.SourceOptions{})
+	}
+	if secrets := cfg.Secrets.List("EINVOICE_WEBHOOK_SECRETS"); len(secrets) > 0 {
+		webhookReceiver.Register("einvoice", webhook.Signed(webhook.SignatureHeader, secrets...), nil, webhook.SourceOptions{})
+	}
+	webhookHandler := webhook.NewInboundHandler(webhookReceiver)
+	lifecycleHandler := lifecycle.NewHandler(lifecycleTracker)
+	importHandler := imports.NewHandler(importRunner)
+
+	// Support bundles on invoices and bills, built with the owner's
+	// consent from the document, its timeline and the tenant's recent
+	// error responses
+	supportService := support.NewService(db, timelineStore, support.Config{
+		Service: "invoice-service",
+		Documents: []support.DocumentConfig{
+			services.InvoiceSupportDocument(invoiceRepo, dunningRepo, disputeRepo, lifecycleTracker),
+			services.BillSupportDocument(billRepo),
+		},
+	})
+	supportService.Start(context.Background())
+	supportHandler := support.NewHandler(supportService)
+	jobHandler := jobs.NewAdminHandler(jobQueue)
+	emailHandler := email.NewHandler(mailer, cfg.Email)
+	messagingHandler := messaging.NewHandler(messenger, cfg.Messaging.TwilioAuthToken)
 	healthHandler := handlers.NewHealthHandler(db)
 
 	// Setup router
 	router := gin.New()
 
-	// Allowed CORS origins
-	allowedOrigins := []string{
-		"https://app.bookkeep.in",
-		"https://www.bookkeep.in",
-		"https://bookkeep.in",
-	}
-	if !cfg.IsProduction() {
-		allowedOrigins = append(allowedOrigins,
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"exp://localhost:19000",
-		)
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(router, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// Apply middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.RequestIDMiddleware())
+	router.Use(supportService.RecordErrors())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.CORSMiddleware(allowedOrigins))
+	router.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
+	router.Use(i18n.Middleware(i18n.DefaultLanguage))
 
 	// Health endpoints (no auth required)
 	router.GET("/health", healthHandler.Health)
 	router.GET("/ready", healthHandler.Ready)
+	router.GET("/metrics", gin.WrapH(database.MetricsHandler(db)))
+
+	// Invoice export downloads (public, authenticated by the signed link)
+	exportDownloadRateLimiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
+		RequestsPerMinute: 20,
+		BurstSize:         5,
+		CleanupInterval:   5 * time.Minute,
+	})
+	router.GET("/api/v1/public/invoice-exports/:id/download", exportDownloadRateLimiter.Middleware(), invoiceExportHandler.Download)
+
+	// Customers' answers to quotes (public, authenticated by the link token)
+	quoteResponseRateLimiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
+		RequestsPerMinute: 20,
+		BurstSize:         5,
+		CleanupInterval:   5 * time.Minute,
+	})
+	quoteResponses := router.Group("/api/v1/public/quotes")
+	quoteResponses.Use(quoteResponseRateLimiter.Middleware())
+	{
+		quoteResponses.GET("/:token", quoteHandler.GetPublic)
+		quoteResponses.POST("/:token/accept", quoteHandler.Accept)
+		quoteResponses.POST("/:token/decline", quoteHandler.Decline)
+	}
+
+	// Provider callbacks (public, authenticated by their signatures)
+	router.POST("/api/v1/public/webhooks/:source", webhookHandler.Receive)
+	// Customers' SMS and WhatsApp replies, for STOP and START
+	router.POST("/api/v1/public/messaging/twilio", messagingHandler.TwilioInbound)
+	// Email providers' delivery, open and bounce events
+	router.POST("/api/v1/public/email/sendgrid", emailHandler.SendGridEvents)
+	router.POST("/api/v1/public/email/ses", emailHandler.SESEvents)
+
+	// Tenants' IP and country restrictions, read from the tenant service
+	networkPolicies := middleware.NewNetworkPolicyClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+
+	// Features being rolled out, read from the auth service's flags
+	featureFlags := features.NewClient(cfg.Network.AuthServiceURL, serviceCredentials, features.Config{})
+
+	// Bulk exports need the data:export permission and are recorded in the
+	// tenant's audit log
+	exportGuard := middleware.NewExportGuard(middleware.NewExportAuditClient(cfg.Network.TenantServiceURL))
+
+	// Invoices and bills auditors may read: lists filtered by the dates
+	// their grant's year is held to, and documents dated in it (see
+	// AuditedYearOnly). Overdue and payables summaries are as of today.
+	auditorRoutes := []string{
+		"/api/v1/invoices",
+		"/api/v1/invoices/:id",
+		"/api/v1/invoices/:id/pdf",
+		"/api/v1/invoices/:id/history",
+		"/api/v1/invoices/:id/comments",
+		"/api/v1/invoices/:id/tax-snapshot",
+		"/api/v1/bills",
+		"/api/v1/bills/:id",
+		"/api/v1/bills/:id/history",
+		"/api/v1/bills/:id/comments",
+		"/api/v1/bills/:id/tax-snapshot",
+	}
 
 	// Protected endpoints
 	jwtConfig := middleware.JWTConfig{
-		Secret:    cfg.JWT.Secret,
-		Issuer:    cfg.JWT.Issuer,
-		SkipPaths: []string{"/health", "/ready"},
+		Secret:          cfg.JWT.Secret,
+		Secrets:         cfg.JWTSecrets,
+		RefreshSecrets:  cfg.RefreshJWTSecrets,
+		Issuer:          cfg.JWT.Issuer,
+		SkipPaths:       []string{"/health", "/ready", "/metrics"},
+		NetworkPolicies: networkPolicies,
+		CountryHeader:   cfg.Network.CountryHeader,
+		AuditorRoutes:   auditorRoutes,
+		AuditorGrants:   middleware.NewAuditorGrantClient(cfg.Network.AuthServiceURL, cfg.Network.PolicyCacheTTL, serviceCredentials),
 	}
 
 	api := router.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware(jwtConfig))
 	api.Use(middleware.TenantMiddleware())
+	api.Use(timeline.Actor())
 	{
 		// Invoice endpoints
-		invoices := api.Group("/invoices")
+		invoices := api.Group("/invoices", invoiceHandler.AuditedYearOnly())
 		{
 			invoices.GET("", invoiceHandler.List)
 			invoices.POST("", invoiceHandler.Create)
+			invoices.POST("/from-template/:template_id", invoiceHandler.CreateFromTemplate)
+			invoices.GET("/tags", invoiceHandler.ListTags)
+			invoices.GET("/pdf-templates", invoiceHandler.ListPDFTemplates)
+			invoices.GET("/pdf-templates/:template/preview", invoiceHandler.PreviewPDFTemplate)
+			invoices.PUT("/tags/:tag", invoiceHandler.RenameTag)
+			invoices.DELETE("/tags/:tag", invoiceHandler.DeleteTag)
 			invoices.GET("/:id", invoiceHandler.Get)
 			invoices.PUT("/:id", invoiceHandler.Update)
 			invoices.DELETE("/:id", invoiceHandler.Delete)
+			invoices.POST("/:id/duplicate", invoiceHandler.Duplicate)
+			invoices.GET("/:id/history", invoiceTimelineHandler.History)
+			invoices.GET("/:id/comments", invoiceCommentHandler.List)
+			invoices.POST("/:id/comments", invoiceCommentHandler.Add)
+			invoices.GET("/:id/comments/history", invoiceCommentHandler.History)
+			invoices.PUT("/:id/comments/:comment_id", invoiceCommentHandler.Edit)
+			invoices.DELETE("/:id/comments/:comment_id", invoiceCommentHandler.Delete)
 			invoices.POST("/:id/send", invoiceHandler.Send)
+			invoices.POST("/:id/email", invoiceHandler.Email)
+			invoices.PUT("/:id/tags", invoiceHandler.SetTags)
 			invoices.POST("/:id/payments", invoiceHandler.RecordPayment)
+			invoices.POST("/:id/payments/:payment_id/bounce", invoiceHandler.BouncePayment)
+			invoices.GET("/:id/dunning", dunningHandler.History)
+			invoices.POST("/:id/disputes", disputeHandler.Raise)
+			invoices.GET("/:id/disputes", disputeHandler.ListForInvoice)
 			invoices.GET("/:id/pdf", invoiceHandler.GeneratePDF)
+			invoices.GET("/:id/upi-qr", invoiceHandler.UPIQR)
+			invoices.PUT("/:id/shipping-bill", invoiceHandler.UpdateShippingBill)
+			invoices.GET("/:id/tax-snapshot", taxSnapshotHandler.GetInvoiceSnapshot)
 		}
 
 		// E-Invoice endpoints (GST)
-		einvoice := api.Group("/einvoice")
+		einvoices := api.Group("/einvoice", featureFlags.Require("e_invoicing"))
 		{
-			einvoice.POST("/:id/generate", invoiceHandler.GenerateEInvoice)
-			einvoice.GET("/:id/status", invoiceHandler.GetEInvoiceStatus)
-			einvoice.POST("/:id/cancel", invoiceHandler.CancelEInvoice)
+			einvoices.GET("/credentials", einvoiceHandler.GetCredentials)
+			einvoices.PUT("/credentials", middleware.RequireRole("admin"), einvoiceHandler.SaveCredentials)
+			einvoices.POST("/:id/generate", einvoiceHandler.Generate)
+			einvoices.GET("/:id/status", einvoiceHandler.GetStatus)
+			einvoices.POST("/:id/cancel", einvoiceHandler.Cancel)
 		}
 
 		// Bill endpoints
-		bills := api.Group("/bills")
+		bills := api.Group("/bills", billHandler.AuditedYearOnly())
 		{
 			bills.GET("", billHandler.List)
 			bills.POST("", billHandler.Create)
+			bills.POST("/scan", billScanHandler.Scan)
+			bills.POST("/from-template/:template_id", billHandler.CreateFromTemplate)
 			bills.GET("/overdue", billHandler.GetOverdue)
 			bills.GET("/payables-summary", billHandler.GetPayablesSummary)
+			bills.GET("/match-report", billMatchHandler.Report)
+			bills.GET("/match-settings", billMatchHandler.GetSettings)
+			bills.PUT("/match-settings", billMatchHandler.UpdateSettings)
 			bills.GET("/:id", billHandler.Get)
 			bills.PUT("/:id", billHandler.Update)
 			bills.DELETE("/:id", billHandler.Delete)
+			bills.POST("/:id/duplicate", billHandler.Duplicate)
+			bills.GET("/:id/history", billTimelineHandler.History)
+			bills.GET("/:id/comments", billCommentHandler.List)
+			bills.POST("/:id/comments", billCommentHandler.Add)
+			bills.GET("/:id/comments/history", billCommentHandler.History)
+			bills.PUT("/:id/comments/:comment_id", billCommentHandler.Edit)
+			bills.DELETE("/:id/comments/:comment_id", billCommentHandler.Delete)
 			bills.POST("/:id/approve", billHandler.Approve)
 			bills.POST("/:id/payments", billHandler.RecordPayment)
+			bills.GET("/:id/tax-snapshot", taxSnapshotHandler.GetBillSnapshot)
+			bills.POST("/:id/self-invoice", selfInvoiceHandler.Generate)
+		}
+
+		// Purchase orders, received against and billed from
+		purchaseOrders := api.Group("/purchase-orders")
+		{
+			purchaseOrders.GET("", purchaseOrderHandler.List)
+			purchaseOrders.POST("", purchaseOrderHandler.Create)
+			purchaseOrders.GET("/open", purchaseOrderHandler.OpenReport)
+			purchaseOrders.GET("/:id", purchaseOrderHandler.Get)
+			purchaseOrders.PUT("/:id", purchaseOrderHandler.Update)
+			purchaseOrders.DELETE("/:id", purchaseOrderHandler.Delete)
+			purchaseOrders.POST("/:id/issue", purchaseOrderHandler.Issue)
+			purchaseOrders.POST("/:id/close", purchaseOrderHandler.Close)
+			purchaseOrders.POST("/:id/cancel", purchaseOrderHandler.Cancel)
+			purchaseOrders.GET("/:id/receipts", purchaseOrderHandler.ListReceipts)
+			purchaseOrders.POST("/:id/receipts", purchaseOrderHandler.Receive)
+			purchaseOrders.POST("/:id/bills", purchaseOrderHandler.CreateBill)
+			purchaseOrders.GET("/:id/match", purchaseOrderHandler.Match)
 		}
 
 		// Product/Service catalog endpoints
-		products := api.Group("/products")
+		products := api.Group("/products", middleware.ConditionalRequests(database.NewVersionStore(db), repository.ProductsResource))
 		{
 			products.GET("", productHandler.List)
 			products.POST("", productHandler.Create)
@@ -180,6 +635,76 @@ func main() {
 			products.POST("/:id/stock", productHandler.UpdateStock)
 		}
 
+		// Import jobs
+		importJobs := api.Group("/imports")
+		{
+			importJobs.GET("", importHandler.List)
+			importJobs.GET("/:id", importHandler.Get)
+			importJobs.GET("/:id/errors", importHandler.ErrorReport)
+		}
+
+		// Delivery status of the tenant's emails, such as sent invoices
+		emails := api.Group("/emails")
+		{
+			emails.GET("", emailHandler.List)
+			emails.GET("/:id", emailHandler.Get)
+			emails.POST("/:id/resend", middleware.RequireRole("admin"), emailHandler.Resend)
+		}
+
+		// Delivery status of the tenant's SMS and WhatsApp messages, and the
+		// customers who opted out of them
+		messages := api.Group("/messages")
+		{
+			messages.GET("", messagingHandler.List)
+			messages.GET("/:id", messagingHandler.Get)
+			messages.POST("/:id/resend", middleware.RequireRole("admin"), messagingHandler.Resend)
+		}
+		optOuts := api.Group("/messaging/opt-outs")
+		{
+			optOuts.GET("", messagingHandler.ListOptOuts)
+			optOuts.POST("", messagingHandler.CreateOptOut)
+			optOuts.DELETE("/:id", middleware.RequireRole("admin"), messagingHandler.DeleteOptOut)
+		}
+
+		// Support bundles; only the owner can consent to one being built
+		supportBundles := api.Group("/support/bundles")
+		{
+			supportBundles.POST("", supportHandler.Create)
+			supportBundles.GET("", supportHandler.List)
+			supportBundles.GET("/:id", supportHandler.Get)
+			supportBundles.POST("/:id/consent", middleware.RequireRole("owner"), supportHandler.Consent)
+			supportBundles.POST("/:id/decline", middleware.RequireRole("owner"), supportHandler.Decline)
+			supportBundles.GET("/:id/download", exportGuard.Export("support_bundle"), supportHandler.Download)
+		}
+
+		// Background job admin: failed and dead-lettered jobs
+		adminJobs := api.Group("/admin/jobs")
+		adminJobs.Use(middleware.RequireRole("admin"))
+		{
+			adminJobs.GET("", jobHandler.List)
+			adminJobs.GET("/stats", jobHandler.Stats)
+			adminJobs.GET("/:id", jobHandler.Get)
+			adminJobs.POST("/:id/retry", jobHandler.Retry)
+			adminJobs.POST("/:id/cancel", jobHandler.Cancel)
+		}
+
+		// Documents stuck in asynchronous processing
+		operations := api.Group("/operations")
+		operations.Use(middleware.RequireRole("admin"))
+		{
+			operations.GET("/stuck", lifecycleHandler.Stuck)
+			operations.GET("/documents/:kind/:id", lifecycleHandler.Document)
+		}
+
+		// Inbound webhooks and their dead letters, across all tenants
+		adminWebhooks := api.Group("/admin/webhooks/inbound")
+		adminWebhooks.Use(middleware.RequireRole("super_admin"))
+		{
+			adminWebhooks.GET("", webhookHandler.List)
+			adminWebhooks.GET("/:id", webhookHandler.Get)
+			adminWebhooks.POST("/:id/replay", webhookHandler.Replay)
+		}
+
 		// Recurring Invoice endpoints
 		recurring := api.Group("/recurring-invoices")
 		{
@@ -193,15 +718,225 @@ func main() {
 			recurring.POST("/:id/generate", recurringInvoiceHandler.GenerateNow)
 			recurring.GET("/:id/history", recurringInvoiceHandler.GetHistory)
 		}
+
+		// Document rounding settings
+		rounding := api.Group("/rounding-rules")
+		{
+			rounding.GET("", roundingHandler.List)
+			rounding.PUT("/:document_type", roundingHandler.Update)
+		}
+
+		// Payment terms catalog and customer defaults
+		paymentTerms := api.Group("/payment-terms")
+		{
+			paymentTerms.GET("", paymentTermHandler.List)
+			paymentTerms.POST("", paymentTermHandler.Create)
+			paymentTerms.GET("/customers/:customer_id", paymentTermHandler.GetCustomerTerm)
+			paymentTerms.PUT("/customers/:customer_id", paymentTermHandler.SetCustomerTerm)
+			paymentTerms.GET("/:id", paymentTermHandler.Get)
+			paymentTerms.PUT("/:id", paymentTermHandler.Update)
+			paymentTerms.DELETE("/:id", paymentTermHandler.Delete)
+		}
+
+		// Saved invoice and bill templates, raised through the invoice and
+		// bill from-template endpoints
+		documentTemplates := api.Group("/document-templates")
+		{
+			documentTemplates.GET("", documentTemplateHandler.List)
+			documentTemplates.POST("", documentTemplateHandler.Create)
+			documentTemplates.GET("/:id", documentTemplateHandler.Get)
+			documentTemplates.PUT("/:id", documentTemplateHandler.Update)
+			documentTemplates.DELETE("/:id", documentTemplateHandler.Delete)
+		}
+
+		// Dunning policies per customer segment
+		dunning := api.Group("/dunning")
+		{
+			dunning.GET("/policies", dunningHandler.ListPolicies)
+			dunning.POST("/policies", dunningHandler.CreatePolicy)
+			dunning.GET("/policies/:id", dunningHandler.GetPolicy)
+			dunning.PUT("/policies/:id", dunningHandler.UpdatePolicy)
+			dunning.DELETE("/policies/:id", dunningHandler.DeletePolicy)
+			dunning.GET("/customers/:customer_id", dunningHandler.GetCustomerProfile)
+			dunning.PUT("/customers/:customer_id", dunningHandler.SetCustomerProfile)
+			dunning.POST("/run", dunningHandler.Run)
+		}
+
+		// Invoice disputes
+		disputes := api.Group("/disputes")
+		{
+			disputes.GET("", disputeHandler.List)
+			disputes.GET("/:id", disputeHandler.Get)
+			disputes.PUT("/:id", disputeHandler.Update)
+			disputes.POST("/:id/close", disputeHandler.Close)
+		}
+
+		// Employee expense claims
+		expenseClaims := api.Group("/expense-claims")
+		{
+			expenseClaims.GET("", expenseClaimHandler.List)
+			expenseClaims.POST("", expenseClaimHandler.Submit)
+			expenseClaims.GET("/report", expenseClaimHandler.StatusReport)
+			expenseClaims.GET("/:id", expenseClaimHandler.Get)
+			expenseClaims.POST("/:id/approve", expenseClaimHandler.Approve)
+			expenseClaims.POST("/:id/reject", expenseClaimHandler.Reject)
+		}
+
+		// Expense policy checked on claims and bills, and its violations
+		expensePolicy := api.Group("/expense-policy")
+		{
+			expensePolicy.GET("", expensePolicyHandler.GetPolicy)
+			expensePolicy.PUT("", middleware.RequireRole("admin"), expensePolicyHandler.UpdatePolicy)
+			expensePolicy.GET("/violations", expensePolicyHandler.Violations)
+		}
+
+		// Self-invoices for reverse charge purchases from unregistered vendors
+		selfInvoices := api.Group("/self-invoices")
+		{
+			selfInvoices.GET("", selfInvoiceHandler.List)
+			selfInvoices.GET("/gstr3b", selfInvoiceHandler.GSTR3B)
+			selfInvoices.GET("/:id", selfInvoiceHandler.Get)
+		}
+
+		// Books totals for the annual GST return (GSTR-9)
+		api.GET("/gst-annual/books", gstAnnualHandler.Books)
+		api.GET("/gst-monthly/books", gstAnnualHandler.MonthlyBooks)
+		api.GET("/gst-monthly/invoices", invoiceHandler.ForReturnPeriod)
+		api.GET("/gst-monthly/credit-notes", creditNoteHandler.ForReturnPeriod)
+		api.GET("/gst-monthly/debit-notes", debitNoteHandler.ForReturnPeriod)
+
+		// Advance receipts and refund vouchers
+		advances := api.Group("/advances")
+		{
+			advances.GET("", advanceHandler.List)
+			advances.POST("", advanceHandler.Create)
+			advances.GET("/returns", advanceHandler.ReturnSummary)
+			advances.GET("/:id", advanceHandler.Get)
+			advances.POST("/:id/refund", advanceHandler.Refund)
+		}
+
+		// Contracts and renewals
+		contracts := api.Group("/contracts")
+		{
+			contracts.GET("", contractHandler.List)
+			contracts.POST("", contractHandler.Create)
+			contracts.GET("/expiring", contractHandler.ExpiringReport)
+			contracts.POST("/reminders/run", contractHandler.RunReminders)
+			contracts.GET("/:id", contractHandler.Get)
+			contracts.PUT("/:id", contractHandler.Update)
+			contracts.DELETE("/:id", contractHandler.Delete)
+			contracts.POST("/:id/renew", contractHandler.Renew)
+			contracts.POST("/:id/terminate", contractHandler.Terminate)
+			contracts.POST("/:id/recurring-invoices", contractHandler.LinkRecurringInvoice)
+			contracts.DELETE("/:id/recurring-invoices/:recurring_id", contractHandler.UnlinkRecurringInvoice)
+		}
+
+		// Customer payment behaviour scores
+		creditScores := api.Group("/credit-scores")
+		{
+			creditScores.GET("", creditScoreHandler.List)
+			creditScores.POST("/recalculate", creditScoreHandler.Recalculate)
+			creditScores.GET("/:customer_id", creditScoreHandler.Get)
+		}
+
+		// Month-end customer statements, emailed in bulk
+		statementRuns := api.Group("/statement-runs")
+		{
+			statementRuns.GET("", statementHandler.ListRuns)
+			statementRuns.POST("", statementHandler.StartRun)
+			statementRuns.GET("/:id", statementHandler.GetRun)
+		}
+
+		// Invoice PDFs for a period, zipped by month for auditors
+		invoiceExports := api.Group("/invoice-exports")
+		{
+			invoiceExports.GET("", invoiceExportHandler.List)
+			invoiceExports.POST("", exportGuard.Export("invoice_pdfs"), invoiceExportHandler.Start)
+			invoiceExports.GET("/:id", exportGuard.Permit(), invoiceExportHandler.Get)
+		}
+
+		// Invoice financing: consent log and lender data packs
+		financing := api.Group("/financing")
+		financing.Use(middleware.RequireRole("admin"))
+		{
+			financing.GET("/consents", financingHandler.ListConsents)
+			financing.POST("/consents", financingHandler.GrantConsent)
+			financing.POST("/consents/:id/revoke", financingHandler.RevokeConsent)
+			financing.GET("/consents/:id/export", exportGuard.Export("financing_data_pack"), financingHandler.Export)
+			financing.GET("/exports", financingHandler.ListExports)
+		}
+
+		// Cash payments and receipts over the statutory limits, for the CA
+		cashLimits := api.Group("/cash-limits")
+		{
+			cashLimits.GET("/report", cashLimitHandler.Report)
+			cashLimits.GET("/settings", cashLimitHandler.GetSettings)
+			cashLimits.PUT("/settings", middleware.RequireRole("admin"), cashLimitHandler.UpdateSettings)
+		}
+
+		// Quotations, converted to invoices once accepted
+		quotes := api.Group("/quotes")
+		{
+			quotes.GET("", quoteHandler.List)
+			quotes.POST("", quoteHandler.Create)
+			quotes.GET("/:id", quoteHandler.Get)
+			quotes.PUT("/:id", quoteHandler.Update)
+			quotes.DELETE("/:id", quoteHandler.Delete)
+			quotes.POST("/:id/send", quoteHandler.Send)
+			quotes.POST("/:id/convert", quoteHandler.Convert)
+		}
+
+		// Delivery challans for goods moved without an invoice, linked to
+		// the invoice that bills them later
+		deliveryChallans := api.Group("/delivery-challans")
+		{
+			deliveryChallans.GET("", deliveryChallanHandler.List)
+			deliveryChallans.POST("", deliveryChallanHandler.Create)
+			deliveryChallans.GET("/:id", deliveryChallanHandler.Get)
+			deliveryChallans.PUT("/:id", deliveryChallanHandler.Update)
+			deliveryChallans.DELETE("/:id", deliveryChallanHandler.Delete)
+			deliveryChallans.GET("/:id/pdf", deliveryChallanHandler.GeneratePDF)
+			deliveryChallans.POST("/:id/issue", deliveryChallanHandler.Issue)
+			deliveryChallans.POST("/:id/cancel", deliveryChallanHandler.Cancel)
+			deliveryChallans.POST("/:id/invoice", deliveryChallanHandler.Invoice)
+		}
+
+		// Credit notes issued to customers, applied against their open
+		// invoices
+		creditNotes := api.Group("/credit-notes")
+		{
+			creditNotes.GET("", creditNoteHandler.List)
+			creditNotes.POST("", creditNoteHandler.Create)
+			creditNotes.GET("/:id", creditNoteHandler.Get)
+			creditNotes.PUT("/:id", creditNoteHandler.Update)
+			creditNotes.DELETE("/:id", creditNoteHandler.Delete)
+			creditNotes.POST("/:id/approve", creditNoteHandler.Approve)
+			creditNotes.POST("/:id/cancel", creditNoteHandler.Cancel)
+			creditNotes.POST("/:id/apply", creditNoteHandler.Apply)
+			creditNotes.DELETE("/:id/applications/:application_id", creditNoteHandler.RemoveApplication)
+		}
+
+		// Debit notes raised on vendors for purchase returns, reversing the
+		// input tax credit taken on their bills
+		debitNotes := api.Group("/debit-notes")
+		{
+			debitNotes.GET("", debitNoteHandler.List)
+			debitNotes.POST("", debitNoteHandler.Create)
+			debitNotes.GET("/:id", debitNoteHandler.Get)
+			debitNotes.PUT("/:id", debitNoteHandler.Update)
+			debitNotes.DELETE("/:id", debitNoteHandler.Delete)
+			debitNotes.POST("/:id/issue", debitNoteHandler.Issue)
+			debitNotes.POST("/:id/cancel", debitNoteHandler.Cancel)
+		}
 	}
 
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         cfg.GetServerAddress(),
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Start server in goroutine
@@ -220,13 +955,16 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Let running jobs finish
+	jobQueue.Stop()
+
 	if err := database.Close(db); err != nil {
 		log.Printf("Error closing database: %v", err)
 	}
@@ -3,8 +3,10 @@ module github.com/tesseract-nexus/bookkeeping-app/invoice-service
 go 1.25
 
 require (
+	github.com/boombuler/barcode v1.0.1-0.20190219062509-6c824513bacc
 	github.com/gin-gonic/gin v1.10.0
 	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
 	github.com/shopspring/decimal v1.4.0
 	github.com/tesseract-nexus/bookkeeping-app/go-shared v0.0.0
 	gorm.io/gorm v1.25.12
@@ -0,0 +1,192 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupportingDetails records how a posting's amounts were computed, kept by
+// the ledger with the transaction so auditors can trace each figure back to
+// its rates, rounding and source documents
+type SupportingDetails struct {
+	Calculation     string               `json:"calculation"`
+	Rates           []SupportingRate     `json:"rates,omitempty"`
+	Rounding        []SupportingRounding `json:"rounding,omitempty"`
+	SourceDocuments []SupportingDocument `json:"source_documents,omitempty"`
+	Figures         map[string]float64   `json:"figures,omitempty"`
+	Notes           string               `json:"notes,omitempty"`
+}
+
+// SupportingRate is a rate applied in the computation
+type SupportingRate struct {
+	Name    string  `json:"name"`
+	Rate    float64 `json:"rate"`
+	Base    float64 `json:"base"`
+	Amount  float64 `json:"amount"`
+	Section string  `json:"section,omitempty"`
+}
+
+// SupportingRounding is rounding applied to a computed amount
+type SupportingRounding struct {
+	Mode       string  `json:"mode"`
+	RoundTo    float64 `json:"round_to"`
+	Unrounded  float64 `json:"unrounded"`
+	Rounded    float64 `json:"rounded"`
+	Difference float64 `json:"difference"`
+}
+
+// SupportingDocument is a document the computation was based on
+type SupportingDocument struct {
+	Type   string     `json:"type"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Number string     `json:"number,omitempty"`
+}
+
+// BillPaymentPosting is a vendor payment to be posted to the ledger. The
+// gross amount clears accounts payable and is split between the payment
+// account and TDS payable.
+type BillPaymentPosting struct {
+	Date             string     `json:"date"`
+	BillID           *uuid.UUID `json:"bill_id,omitempty"`
+	BillNumber       string     `json:"bill_number"`
+	PaymentID        *uuid.UUID `json:"payment_id,omitempty"` // posted once per payment
+	VendorID         *uuid.UUID `json:"vendor_id,omitempty"`
+	VendorName       string     `json:"vendor_name"`
+	GrossAmount      float64    `json:"gross_amount"`
+	TDSAmount        float64    `json:"tds_amount"`
+	TDSSection       string     `json:"tds_section"`
+	PaymentMode      string     `json:"payment_mode"`
+	PaymentReference string     `json:"payment_reference"`
+	Notes            string     `json:"notes"`
+
+	SupportingDetails *SupportingDetails `json:"supporting_details,omitempty"`
+}
+
+// CustomerAdvancePosting is an advance received from a customer, or its
+// refund, to be posted to the ledger. TaxAmount is the GST paid on the
+// advance, or reversed by the refund.
+type CustomerAdvancePosting struct {
+	Kind             string     `json:"kind"` // receipt or refund
+	Date             string     `json:"date"`
+	DocumentID       *uuid.UUID `json:"document_id,omitempty"`
+	DocumentNumber   string     `json:"document_number"`
+	CustomerID       *uuid.UUID `json:"customer_id,omitempty"`
+	CustomerName     string     `json:"customer_name"`
+	Amount           float64    `json:"amount"`
+	TaxAmount        float64    `json:"tax_amount"`
+	PaymentMode      string     `json:"payment_mode"`
+	PaymentReference string     `json:"payment_reference"`
+	Notes            string     `json:"notes"`
+
+	SupportingDetails *SupportingDetails `json:"supporting_details,omitempty"`
+}
+
+// DocumentPosting is an invoice sent or a bill approved, to be posted to
+// the ledger. TotalAmount is the amount due after rounding, RoundOff what
+// rounding added to it, posted to RoundingAccountID when set, else to the
+// ledger's Round Off account.
+type DocumentPosting struct {
+	Kind              string     `json:"kind"` // invoice or bill
+	Date              string     `json:"date"`
+	DocumentID        *uuid.UUID `json:"document_id"` // posted once per document
+	DocumentNumber    string     `json:"document_number"`
+	PartyID           *uuid.UUID `json:"party_id,omitempty"`
+	PartyName         string     `json:"party_name"`
+	TaxableAmount     float64    `json:"taxable_amount"`
+	TaxAmount         float64    `json:"tax_amount"`
+	TCSAmount         float64    `json:"tcs_amount"`
+	RoundOff          float64    `json:"round_off"`
+	TotalAmount       float64    `json:"total_amount"`
+	ITCEligible       bool       `json:"itc_eligible"`
+	ReverseCharge     bool       `json:"reverse_charge"`
+	RoundingAccountID *uuid.UUID `json:"rounding_account_id,omitempty"`
+
+	SupportingDetails *SupportingDetails `json:"supporting_details,omitempty"`
+}
+
+// PeriodStatus is whether a date falls in a closed accounting period, in
+// which the ledger accepts no postings
+type PeriodStatus struct {
+	Date   string `json:"date"`
+	Closed bool   `json:"closed"`
+	Period string `json:"period,omitempty"`
+}
+
+// BookkeepingClient posts journal entries to the bookkeeping service
+type BookkeepingClient interface {
+	// PostBillPayment posts a bill payment on behalf of the caller identified
+	// by authorization (the incoming Authorization header)
+	PostBillPayment(ctx context.Context, authorization string, posting BillPaymentPosting) error
+	// PostCustomerAdvance posts an advance receipt or refund the same way
+	PostCustomerAdvance(ctx context.Context, authorization string, posting CustomerAdvancePosting) error
+	// PostDocument posts a finalized invoice or bill the same way
+	PostDocument(ctx context.Context, authorization string, posting DocumentPosting) error
+	// GetPeriodStatus reports whether the date (YYYY-MM-DD) falls in a
+	// closed period of the caller's tenant
+	GetPeriodStatus(ctx context.Context, authorization, date string) (*PeriodStatus, error)
+}
+
+type bookkeepingClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewBookkeepingClient creates a new bookkeeping service client
+func NewBookkeepingClient(baseURL string) BookkeepingClient {
+	return &bookkeepingClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *bookkeepingClient) PostBillPayment(ctx context.Context, authorization string, posting BillPaymentPosting) error {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	return postJSON(ctx, c.httpClient, c.baseURL+"/api/v1/transactions/bill-payment", header, posting, nil)
+}
+
+func (c *bookkeepingClient) PostCustomerAdvance(ctx context.Context, authorization string, posting CustomerAdvancePosting) error {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	return postJSON(ctx, c.httpClient, c.baseURL+"/api/v1/transactions/customer-advance", header, posting, nil)
+}
+
+func (c *bookkeepingClient) PostDocument(ctx context.Context, authorization string, posting DocumentPosting) error {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	return postJSON(ctx, c.httpClient, c.baseURL+"/api/v1/transactions/document", header, posting, nil)
+}
+
+func (c *bookkeepingClient) GetPeriodStatus(ctx context.Context, authorization, date string) (*PeriodStatus, error) {
+	endpoint := c.baseURL + "/api/v1/financial-years/period-status?date=" + url.QueryEscape(date)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", endpoint, resp.StatusCode)
+	}
+
+	var body struct {
+		Data PeriodStatus `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
@@ -0,0 +1,166 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // Logos may be GIFs
+	_ "image/jpeg" // or JPEGs
+	_ "image/png"  // or PNGs
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultBrandingTTL = time.Minute
+
+// maxLogoSize is more than the tenant service accepts for a logo
+const maxLogoSize = 2 << 20
+
+// Branding is how a tenant's invoices look, from its public branding. Colors
+// are #rrggbb and empty where unset.
+type Branding struct {
+	PrimaryColor    string `json:"primary_color"`
+	SecondaryColor  string `json:"secondary_color"`
+	AccentColor     string `json:"accent_color"`
+	LogoURL         string `json:"logo_url"`
+	InvoiceTemplate string `json:"invoice_template"`
+	InvoiceFooter   string `json:"invoice_footer"`
+	InvoiceTerms    string `json:"invoice_terms"`
+
+	// Logo is the tenant's uploaded logo, nil when it has none or it is in
+	// a format that cannot be printed (WebP)
+	Logo image.Image `json:"-"`
+}
+
+// BrandingClient reads tenants' branding from the tenant service
+type BrandingClient interface {
+	// GetBranding returns the tenant's branding with its logo
+	GetBranding(ctx context.Context, tenantID uuid.UUID) (*Branding, error)
+}
+
+// brandingClient caches each tenant's branding for a short while, as
+// invoice exports print hundreds of invoices with it. A failed lookup keeps
+// serving the last branding it got.
+type brandingClient struct {
+	baseURL    string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu       sync.Mutex
+	branding map[uuid.UUID]cachedBranding
+}
+
+type cachedBranding struct {
+	branding *Branding
+	loadedAt time.Time
+}
+
+// NewBrandingClient creates a client for the tenant service at baseURL.
+// Branding changes take up to ttl to show on invoices; zero means a minute.
+func NewBrandingClient(baseURL string, ttl time.Duration) BrandingClient {
+	if ttl <= 0 {
+		ttl = defaultBrandingTTL
+	}
+	return &brandingClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		ttl:        ttl,
+		branding:   make(map[uuid.UUID]cachedBranding),
+	}
+}
+
+func (c *brandingClient) GetBranding(ctx context.Context, tenantID uuid.UUID) (*Branding, error) {
+	c.mu.Lock()
+	cached, ok := c.branding[tenantID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.branding, nil
+	}
+
+	branding, err := c.fetch(ctx, tenantID)
+	if err != nil {
+		if ok {
+			return cached.branding, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.branding[tenantID] = cachedBranding{branding: branding, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return branding, nil
+}
+
+// fetch reads the public branding, then the logo. Invoices print without
+// the logo when it cannot be read.
+func (c *brandingClient) fetch(ctx context.Context, tenantID uuid.UUID) (*Branding, error) {
+	url := c.baseURL + "/api/v1/tenants/" + tenantID.String() + "/branding/public"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	var body struct {
+		Data Branding `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	branding := &body.Data
+
+	if branding.LogoURL != "" {
+		logo, err := c.fetchLogo(ctx, tenantID)
+		if err != nil {
+			log.Printf("Failed to read the logo of tenant %s: %v", tenantID, err)
+		}
+		branding.Logo = logo
+	}
+	return branding, nil
+}
+
+// fetchLogo reads the uploaded logo from the tenant service itself rather
+// than the logo URL, which is public and, for tenants set up before logos
+// were uploaded, may point anywhere. Those tenants have no uploaded logo.
+func (c *brandingClient) fetchLogo(ctx context.Context, tenantID uuid.UUID) (image.Image, error) {
+	url := c.baseURL + "/api/v1/tenants/" + tenantID.String() + "/branding/logo"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	logo, _, err := image.Decode(io.LimitReader(resp.Body, maxLogoSize))
+	if err == image.ErrFormat {
+		return nil, nil
+	}
+	return logo, err
+}
@@ -0,0 +1,139 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentHold is the customer service's hold on payments to a vendor after
+// a recent change to its bank details
+type PaymentHold struct {
+	PartyID   uuid.UUID  `json:"party_id"`
+	OnHold    bool       `json:"on_hold"`
+	HoldUntil *time.Time `json:"hold_until,omitempty"`
+}
+
+// Vendor is the part of a customer service vendor used to fill in a bill
+type Vendor struct {
+	ID                  uuid.UUID `json:"id"`
+	Name                string    `json:"name"`
+	GSTIN               string    `json:"gstin"`
+	PAN                 string    `json:"pan"`
+	Email               string    `json:"email"`
+	Phone               string    `json:"phone"`
+	BillingAddressLine1 string    `json:"billing_address_line1"`
+	BillingAddressLine2 string    `json:"billing_address_line2"`
+	BillingCity         string    `json:"billing_city"`
+	BillingState        string    `json:"billing_state"`
+	BillingPincode      string    `json:"billing_pincode"`
+	CreditPeriodDays    int       `json:"credit_period_days"`
+}
+
+// Address is the vendor's billing address on one line
+func (v *Vendor) Address() string {
+	var parts []string
+	for _, part := range []string{v.BillingAddressLine1, v.BillingAddressLine2, v.BillingCity, v.BillingPincode} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CustomerClient reads parties from the customer service
+type CustomerClient interface {
+	// FindVendorByGSTIN returns the tenant's vendor registered under the
+	// GSTIN, or nil if there is none
+	FindVendorByGSTIN(ctx context.Context, authorization, gstin string) (*Vendor, error)
+
+	// GetPaymentHold returns the hold on payments to a vendor, read on
+	// behalf of the caller identified by authorization (the incoming
+	// Authorization header)
+	GetPaymentHold(ctx context.Context, authorization string, vendorID uuid.UUID) (*PaymentHold, error)
+}
+
+type customerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCustomerClient creates a new customer service client
+func NewCustomerClient(baseURL string) CustomerClient {
+	return &customerClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *customerClient) GetPaymentHold(ctx context.Context, authorization string, vendorID uuid.UUID) (*PaymentHold, error) {
+	url := c.baseURL + "/api/v1/vendors/" + vendorID.String() + "/payment-hold"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	var body struct {
+		Data PaymentHold `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
+
+func (c *customerClient) FindVendorByGSTIN(ctx context.Context, authorization, gstin string) (*Vendor, error) {
+	query := url.Values{}
+	query.Set("type", "vendor")
+	query.Set("search", gstin)
+	query.Set("per_page", "10")
+	endpoint := c.baseURL + "/api/v1/vendors?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", endpoint, resp.StatusCode)
+	}
+
+	var body struct {
+		Data []Vendor `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	// The search also matches names and contacts, so only an exact GSTIN
+	// will do
+	for i := range body.Data {
+		if strings.EqualFold(body.Data[i].GSTIN, gstin) {
+			return &body.Data[i], nil
+		}
+	}
+	return nil, nil
+}
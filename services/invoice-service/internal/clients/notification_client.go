@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelInApp = "in_app"
+)
+
+// Notification is a message for a customer contact or a tenant user. Either
+// UserID or Email identifies the recipient.
+type Notification struct {
+	TenantID string     `json:"tenant_id"`
+	Channel  string     `json:"channel"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	Email    string     `json:"email,omitempty"`
+	Title    string     `json:"title"`
+	Message  string     `json:"message"`
+	Type     string     `json:"type"` // info, success, warning, error
+	Link     string     `json:"link,omitempty"`
+	Language string     `json:"language,omitempty"` // Template language for customer emails
+
+	Attachments []NotificationAttachment `json:"attachments,omitempty"` // Email only
+}
+
+// NotificationAttachment is a file sent with an email notification. The
+// content is base64 encoded in the request.
+type NotificationAttachment struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// NotificationClient delivers notifications through the notification service
+type NotificationClient interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+type notificationClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewNotificationClient creates a new notification service client
+func NewNotificationClient(baseURL string) NotificationClient {
+	return &notificationClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *notificationClient) Send(ctx context.Context, notification Notification) error {
+	header := http.Header{}
+	header.Set("X-Tenant-ID", notification.TenantID)
+	return postJSON(ctx, c.httpClient, c.baseURL+"/api/v1/notifications", header, notification, nil)
+}
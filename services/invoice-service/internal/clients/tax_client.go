@@ -0,0 +1,226 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TCS sections understood by the tax service
+const (
+	TCSSection206C1H = "206C(1H)"
+)
+
+// TCSCalculationRequest is sent to the tax service to calculate TCS on a sale
+type TCSCalculationRequest struct {
+	TenantID        string           `json:"tenantId"`
+	CustomerID      uuid.UUID        `json:"customerId"`
+	CustomerName    string           `json:"customerName"`
+	CustomerPAN     string           `json:"customerPan"`
+	Section         string           `json:"section"`
+	SaleAmount      decimal.Decimal  `json:"saleAmount"`
+	InvoiceID       uuid.UUID        `json:"invoiceId"`
+	InvoiceDate     string           `json:"invoiceDate"`
+	CumulativeSales *decimal.Decimal `json:"cumulativeSales,omitempty"`
+}
+
+// TCSCalculation is the tax service's TCS calculation result
+type TCSCalculation struct {
+	Section          string          `json:"section"`
+	SaleAmount       decimal.Decimal `json:"saleAmount"`
+	TCSRate          decimal.Decimal `json:"tcsRate"`
+	TCSAmount        decimal.Decimal `json:"tcsAmount"`
+	ThresholdApplied bool            `json:"thresholdApplied"`
+	ThresholdAmount  decimal.Decimal `json:"thresholdAmount"`
+	FinancialYear    string          `json:"financialYear"`
+	Quarter          int             `json:"quarter"`
+}
+
+// TCSCollectionRequest records TCS collected on a finalized invoice
+type TCSCollectionRequest struct {
+	TenantID       string          `json:"tenantId"`
+	InvoiceID      uuid.UUID       `json:"invoiceId"`
+	CustomerID     uuid.UUID       `json:"customerId"`
+	CustomerName   string          `json:"customerName"`
+	CustomerPAN    string          `json:"customerPan"`
+	Section        string          `json:"section"`
+	SaleAmount     decimal.Decimal `json:"saleAmount"`
+	TCSRate        decimal.Decimal `json:"tcsRate"`
+	TCSAmount      decimal.Decimal `json:"tcsAmount"`
+	CollectionDate string          `json:"collectionDate"`
+}
+
+// TDSCalculationRequest is sent to the tax service to calculate TDS on a payment
+type TDSCalculationRequest struct {
+	TenantID         string           `json:"tenantId"`
+	DeducteeID       uuid.UUID        `json:"deducteeId"`
+	DeducteeName     string           `json:"deducteeName"`
+	DeducteePAN      string           `json:"deducteePan"`
+	Section          string           `json:"section"`
+	GrossAmount      decimal.Decimal  `json:"grossAmount"`
+	PaymentDate      string           `json:"paymentDate"`
+	InvoiceID        *uuid.UUID       `json:"invoiceId,omitempty"`
+	CumulativeAmount *decimal.Decimal `json:"cumulativeAmount,omitempty"`
+}
+
+// TDSCalculation is the tax service's TDS calculation result
+type TDSCalculation struct {
+	Section          string          `json:"section"`
+	GrossAmount      decimal.Decimal `json:"grossAmount"`
+	TDSRate          decimal.Decimal `json:"tdsRate"`
+	TDSAmount        decimal.Decimal `json:"tdsAmount"`
+	NetAmount        decimal.Decimal `json:"netAmount"`
+	ThresholdApplied bool            `json:"thresholdApplied"`
+	FinancialYear    string          `json:"financialYear"`
+	Quarter          int             `json:"quarter"`
+}
+
+// TDSDeductionRequest records TDS withheld from a vendor payment
+type TDSDeductionRequest struct {
+	TenantID      string          `json:"tenantId"`
+	InvoiceID     *uuid.UUID      `json:"invoiceId,omitempty"` // the vendor's bill
+	PaymentID     *uuid.UUID      `json:"paymentId,omitempty"`
+	DeducteeID    uuid.UUID       `json:"deducteeId"`
+	DeducteeName  string          `json:"deducteeName"`
+	DeducteePAN   string          `json:"deducteePan"`
+	Section       string          `json:"section"`
+	GrossAmount   decimal.Decimal `json:"grossAmount"`
+	TDSRate       decimal.Decimal `json:"tdsRate"`
+	TDSAmount     decimal.Decimal `json:"tdsAmount"`
+	DeductionDate string          `json:"deductionDate"`
+}
+
+// GSTINFilingCheck is the tax service's registration and GSTR-1 filing
+// status of a counterparty GSTIN
+type GSTINFilingCheck struct {
+	Status struct {
+		GSTIN              string `json:"gstin"`
+		RegistrationStatus string `json:"registrationStatus"`
+		LastGSTR1Period    string `json:"lastGstr1Period"`
+		HabituallyLate     bool   `json:"habituallyLate"`
+		Flagged            bool   `json:"flagged"`
+	} `json:"status"`
+	Warnings []string `json:"warnings"`
+}
+
+// ITCEligibilityLine is a bill line checked against the blocked credit rules
+type ITCEligibilityLine struct {
+	HSNCode   string          `json:"hsnCode"`
+	SACCode   string          `json:"sacCode"`
+	AccountID *uuid.UUID      `json:"accountId,omitempty"`
+	TaxAmount decimal.Decimal `json:"taxAmount"`
+}
+
+// ITCLineEligibility is the tax service's ruling on one line, in request order
+type ITCLineEligibility struct {
+	Eligible   bool            `json:"eligible"`
+	Category   string          `json:"category"`
+	Section    string          `json:"section"`
+	Reason     string          `json:"reason"`
+	BlockedITC decimal.Decimal `json:"blockedItc"`
+}
+
+// TaxClient talks to the tax service
+type TaxClient interface {
+	CalculateTCS(ctx context.Context, req TCSCalculationRequest) (*TCSCalculation, error)
+	RecordTCSCollection(ctx context.Context, req TCSCollectionRequest) error
+	CalculateTDS(ctx context.Context, req TDSCalculationRequest) (*TDSCalculation, error)
+	RecordTDSDeduction(ctx context.Context, req TDSDeductionRequest) error
+	// VoidTDSDeduction withdraws the deduction recorded for a payment that
+	// could not be completed
+	VoidTDSDeduction(ctx context.Context, tenantID string, paymentID uuid.UUID) error
+	CheckGSTINFilingStatus(ctx context.Context, tenantID, gstin string) (*GSTINFilingCheck, error)
+	CheckITCEligibility(ctx context.Context, tenantID string, lines []ITCEligibilityLine) ([]ITCLineEligibility, error)
+}
+
+type taxClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTaxClient creates a new tax service client
+func NewTaxClient(baseURL string) TaxClient {
+	return &taxClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *taxClient) CalculateTCS(ctx context.Context, req TCSCalculationRequest) (*TCSCalculation, error) {
+	var result TCSCalculation
+	if err := c.post(ctx, "/api/v1/tcs/calculate", req.TenantID, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *taxClient) RecordTCSCollection(ctx context.Context, req TCSCollectionRequest) error {
+	return c.post(ctx, "/api/v1/tcs/collections", req.TenantID, req, nil)
+}
+
+func (c *taxClient) CalculateTDS(ctx context.Context, req TDSCalculationRequest) (*TDSCalculation, error) {
+	var result TDSCalculation
+	if err := c.post(ctx, "/api/v1/tds/calculate", req.TenantID, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *taxClient) RecordTDSDeduction(ctx context.Context, req TDSDeductionRequest) error {
+	return c.post(ctx, "/api/v1/tds/deductions", req.TenantID, req, nil)
+}
+
+func (c *taxClient) VoidTDSDeduction(ctx context.Context, tenantID string, paymentID uuid.UUID) error {
+	return c.post(ctx, "/api/v1/tds/deductions/payments/"+paymentID.String()+"/void", tenantID, struct{}{}, nil)
+}
+
+func (c *taxClient) CheckGSTINFilingStatus(ctx context.Context, tenantID, gstin string) (*GSTINFilingCheck, error) {
+	url := c.baseURL + "/api/v1/filing-status/" + gstin
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	var result GSTINFilingCheck
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *taxClient) CheckITCEligibility(ctx context.Context, tenantID string, lines []ITCEligibilityLine) ([]ITCLineEligibility, error) {
+	var result struct {
+		Lines []ITCLineEligibility `json:"lines"`
+	}
+	body := map[string]interface{}{"tenantId": tenantID, "lines": lines}
+	if err := c.post(ctx, "/api/v1/itc/eligibility", tenantID, body, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Lines) != len(lines) {
+		return nil, fmt.Errorf("tax service returned %d eligibility results for %d lines", len(result.Lines), len(lines))
+	}
+	return result.Lines, nil
+}
+
+func (c *taxClient) post(ctx context.Context, path, tenantID string, body, out interface{}) error {
+	header := http.Header{}
+	header.Set("X-Tenant-ID", tenantID)
+	return postJSON(ctx, c.httpClient, c.baseURL+path, header, body, out)
+}
@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant is the business a document is issued by, as the tenant service
+// reports it
+type Tenant struct {
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	LegalName         string    `json:"legal_name"`
+	GSTIN             *string   `json:"gstin"`
+	PAN               *string   `json:"pan"`
+	Email             string    `json:"email"`
+	Phone             string    `json:"phone"`
+	AddressLine1      string    `json:"address_line1"`
+	AddressLine2      *string   `json:"address_line2"`
+	City              string    `json:"city"`
+	State             string    `json:"state"`
+	StateCode         string    `json:"state_code"`
+	PinCode           string    `json:"pin_code"`
+	Country           string    `json:"country"`
+	BankName          *string   `json:"bank_name"`
+	BankAccountNumber *string   `json:"bank_account_number"`
+	BankIFSC          *string   `json:"bank_ifsc"`
+	UPIID             *string   `json:"upi_id"`
+}
+
+// TenantClient reads tenants from the tenant service
+type TenantClient interface {
+	// GetTenant returns a tenant, read on behalf of the caller identified
+	// by authorization (the incoming Authorization header)
+	GetTenant(ctx context.Context, authorization string, tenantID uuid.UUID) (*Tenant, error)
+}
+
+type tenantClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTenantClient creates a new tenant service client
+func NewTenantClient(baseURL string) TenantClient {
+	return &tenantClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *tenantClient) GetTenant(ctx context.Context, authorization string, tenantID uuid.UUID) (*Tenant, error) {
+	url := c.baseURL + "/api/v1/tenants/" + tenantID.String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+
+	var body struct {
+		Data Tenant `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
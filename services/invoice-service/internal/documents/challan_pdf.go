@@ -0,0 +1,324 @@
+package documents
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/pdf"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// challanPurposes are the printed reasons goods move under a challan
+var challanPurposes = map[string]string{
+	models.ChallanPurposeJobWork:          "Job Work",
+	models.ChallanPurposeStockTransfer:    "Stock Transfer",
+	models.ChallanPurposeSupplyOnApproval: "Supply on Approval",
+	models.ChallanPurposeLiquidGas:        "Supply of Liquid Gas",
+	models.ChallanPurposeOther:            "Other",
+}
+
+// challanColumn is a column of a delivery challan's items table
+type challanColumn struct {
+	heading string
+	width   float64
+	right   bool
+	value   func(n int, item models.DeliveryChallanItem) string
+}
+
+// challanPDF lays a delivery challan out page by page
+type challanPDF struct {
+	challan *models.DeliveryChallan
+	seller  *clients.Tenant
+	columns []challanColumn
+	doc     *pdf.Document
+	y       float64
+}
+
+// WriteDeliveryChallanPDF renders a delivery challan as a PDF, in the form
+// Rule 55 of the CGST Rules asks for: the consignor and consignee, the
+// purpose of the movement, the goods with their HSN codes and value, and
+// the tax that would be charged on them. seller may be nil when the tenant
+// could not be read; its block is then left out.
+func WriteDeliveryChallanPDF(w io.Writer, challan *models.DeliveryChallan, seller *clients.Tenant) error {
+	p := &challanPDF{challan: challan, seller: seller, doc: pdf.New()}
+	p.columns = challanColumns()
+
+	p.doc.NewPage()
+	p.y = pageHeight - margin
+	p.centered("DELIVERY CHALLAN", true, 14)
+	p.y -= 14
+	if purpose := challanPurposes[challan.Purpose]; purpose != "" {
+		p.centered("Purpose: "+purpose, true, fontSize)
+		p.y -= lineHeight
+	}
+	p.y -= 8
+	p.parties()
+	p.tableHeading()
+	for n, item := range challan.Items {
+		p.itemRow(n, item)
+	}
+	p.totals()
+	p.footer()
+
+	return p.finish(w)
+}
+
+// parties writes the consignor and the challan details side by side, then
+// the consignee
+func (p *challanPDF) parties() {
+	half := (pageWidth - 2*margin) / 2
+	top := p.y
+
+	var seller []string
+	if s := p.seller; s != nil {
+		seller = append(seller, joinNonEmpty(", ", s.AddressLine1, deref(s.AddressLine2)))
+		seller = append(seller, joinNonEmpty(" - ", joinNonEmpty(", ", s.City, s.State), s.PinCode))
+		if gstin := deref(s.GSTIN); gstin != "" {
+			seller = append(seller, "GSTIN: "+gstin)
+		}
+		seller = append(seller, joinNonEmpty(" | ", s.Email, s.Phone))
+
+		name := s.LegalName
+		if name == "" {
+			name = s.Name
+		}
+		p.text(margin, p.y, pdf.FitWidth(name, half-10, 10), true, 10)
+		p.y -= 12
+	}
+	p.block(margin, half-10, seller)
+	left := p.y
+
+	dc := p.challan
+	rows := [][2]string{
+		{"Challan No.", dc.ChallanNumber},
+		{"Challan Date", dc.ChallanDate.Format("02/01/2006")},
+		{"Place of Supply", dc.CustomerState},
+	}
+	if dc.TransportMode != "" {
+		rows = append(rows, [2]string{"Transport", strings.ToUpper(dc.TransportMode[:1]) + dc.TransportMode[1:]})
+	}
+	if dc.TransporterName != "" {
+		rows = append(rows, [2]string{"Transporter", dc.TransporterName})
+	}
+	if dc.VehicleNumber != "" {
+		rows = append(rows, [2]string{"Vehicle No.", dc.VehicleNumber})
+	}
+	if dc.EWayBillNumber != "" {
+		rows = append(rows, [2]string{"E-Way Bill No.", dc.EWayBillNumber})
+	}
+	if dc.InvoiceNumber != "" {
+		rows = append(rows, [2]string{"Invoice No.", dc.InvoiceNumber})
+	}
+
+	p.y = top
+	x := margin + half + 10
+	for _, row := range rows {
+		p.text(x, p.y, row[0], true, fontSize)
+		p.text(x+90, p.y, pdf.FitWidth(row[1], half-100, fontSize), false, fontSize)
+		p.y -= lineHeight
+	}
+	if left < p.y {
+		p.y = left
+	}
+	p.y -= 8
+
+	p.line(margin, p.y+lineHeight, pageWidth-margin, p.y+lineHeight)
+	p.y -= 2
+	p.text(margin, p.y, "Consignee", true, fontSize)
+	p.y -= lineHeight + 2
+	p.text(margin, p.y, pdf.FitWidth(dc.CustomerName, pageWidth-2*margin, 10), true, 10)
+	p.y -= 12
+
+	consignee := pdf.Wrap(dc.CustomerAddress, half*2, fontSize)
+	consignee = append(consignee, "State: "+dc.CustomerState)
+	if dc.CustomerGSTIN != "" {
+		consignee = append(consignee, "GSTIN: "+dc.CustomerGSTIN)
+	}
+	consignee = append(consignee, joinNonEmpty(" | ", dc.CustomerEmail, dc.CustomerPhone))
+	p.block(margin, half*2, consignee)
+	p.y -= 8
+}
+
+// challanColumns are the columns of the items table
+func challanColumns() []challanColumn {
+	return []challanColumn{
+		{heading: "#", width: 20, value: func(n int, _ models.DeliveryChallanItem) string {
+			return fmt.Sprint(n + 1)
+		}},
+		{heading: "Description", width: 178, value: func(_ int, item models.DeliveryChallanItem) string {
+			return item.Description
+		}},
+		{heading: "HSN", width: 45, value: func(_ int, item models.DeliveryChallanItem) string {
+			return item.HSNCode
+		}},
+		{heading: "Qty", width: 55, right: true, value: func(_ int, item models.DeliveryChallanItem) string {
+			return strings.TrimSpace(item.Quantity.String() + " " + item.Unit)
+		}},
+		{heading: "Rate", width: 60, right: true, value: func(_ int, item models.DeliveryChallanItem) string {
+			return amount(item.Rate, "INR")
+		}},
+		{heading: "Value", width: 65, right: true, value: func(_ int, item models.DeliveryChallanItem) string {
+			return amount(item.Amount, "INR")
+		}},
+		{heading: "GST %", width: 35, right: true, value: func(_ int, item models.DeliveryChallanItem) string {
+			return item.CGSTRate.Add(item.SGSTRate).Add(item.IGSTRate).String()
+		}},
+		{heading: "GST", width: 65, right: true, value: func(_ int, item models.DeliveryChallanItem) string {
+			return amount(item.TotalAmount.Sub(item.Amount), "INR")
+		}},
+	}
+}
+
+func (p *challanPDF) tableHeading() {
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	x := margin
+	for _, col := range p.columns {
+		p.cell(x, col, col.heading, true)
+		x += col.width
+	}
+	p.line(margin, p.y-4, pageWidth-margin, p.y-4)
+	p.y -= rowHeight + 2
+}
+
+func (p *challanPDF) itemRow(n int, item models.DeliveryChallanItem) {
+	if p.y-rowHeight < margin+20 {
+		p.newPage()
+	}
+	x := margin
+	for _, col := range p.columns {
+		p.cell(x, col, col.value(n, item), false)
+		x += col.width
+	}
+	p.y -= rowHeight
+}
+
+// totals writes the value of the goods and the tax on them
+func (p *challanPDF) totals() {
+	dc := p.challan
+	type total struct {
+		label string
+		value string
+		show  bool
+		bold  bool
+	}
+	rows := []total{
+		{"Value of Goods", amount(dc.Subtotal, "INR"), true, false},
+		{"CGST", amount(dc.CGSTAmount, "INR"), dc.CGSTAmount.IsPositive(), false},
+		{"SGST", amount(dc.SGSTAmount, "INR"), dc.SGSTAmount.IsPositive(), false},
+		{"IGST", amount(dc.IGSTAmount, "INR"), dc.IGSTAmount.IsPositive(), false},
+		{"Cess", amount(dc.CessAmount, "INR"), dc.CessAmount.IsPositive(), false},
+		{"Total (INR)", amount(dc.TotalAmount, "INR"), true, true},
+	}
+
+	p.ensureSpace(float64(len(rows)+2) * rowHeight)
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	right := pageWidth - margin - 3
+	for _, row := range rows {
+		if !row.show {
+			continue
+		}
+		p.text(right-200, p.y, row.label, row.bold, fontSize)
+		p.text(right-pdf.TextWidth(row.value, fontSize), p.y, row.value, row.bold, fontSize)
+		p.y -= rowHeight
+	}
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	p.y -= 6
+}
+
+// footer writes the notes, the receiver's acknowledgement and the signature
+// block
+func (p *challanPDF) footer() {
+	if p.challan.Notes != "" {
+		p.ensureSpace(2 * lineHeight)
+		p.text(margin, p.y, "Notes", true, fontSize)
+		p.y -= lineHeight
+		for _, line := range pdf.Wrap(p.challan.Notes, pageWidth-2*margin, fontSize) {
+			p.ensureSpace(lineHeight)
+			p.text(margin, p.y, line, false, fontSize)
+			p.y -= lineHeight
+		}
+		p.y -= 4
+	}
+
+	p.ensureSpace(50)
+	p.y -= 16
+	name := ""
+	if p.seller != nil {
+		name = p.seller.LegalName
+		if name == "" {
+			name = p.seller.Name
+		}
+	}
+	right := pageWidth - margin
+	p.text(margin, p.y, "Received the above goods in good condition", false, fontSize)
+	signFor := "For " + name
+	p.text(right-pdf.TextWidth(signFor, fontSize), p.y, signFor, true, fontSize)
+	p.y -= 30
+	p.text(margin, p.y, "Receiver's Signature", false, fontSize)
+	signatory := "Authorised Signatory"
+	p.text(right-pdf.TextWidth(signatory, fontSize), p.y, signatory, false, fontSize)
+}
+
+// block writes lines at x, skipping empty ones
+func (p *challanPDF) block(x, width float64, lines []string) {
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		p.text(x, p.y, pdf.FitWidth(line, width, fontSize), false, fontSize)
+		p.y -= lineHeight
+	}
+}
+
+// ensureSpace starts a new page unless height fits above the footer
+func (p *challanPDF) ensureSpace(height float64) {
+	if p.y-height < margin+20 {
+		p.newPage()
+	}
+}
+
+// newPage continues the challan on a new page, repeating the items table
+// heading
+func (p *challanPDF) newPage() {
+	p.doc.NewPage()
+	p.y = pageHeight - margin
+	p.text(margin, p.y, "DELIVERY CHALLAN "+p.challan.ChallanNumber+" (continued)", true, fontSize)
+	p.y -= 16
+	p.tableHeading()
+}
+
+// cell writes a value inside a column, cut to fit its width
+func (p *challanPDF) cell(x float64, col challanColumn, value string, bold bool) {
+	const padding = 3
+	value = pdf.FitWidth(value, col.width-2*padding, fontSize)
+	if col.right {
+		x += col.width - padding - pdf.TextWidth(value, fontSize)
+	} else {
+		x += padding
+	}
+	p.text(x, p.y, value, bold, fontSize)
+}
+
+func (p *challanPDF) centered(value string, bold bool, size float64) {
+	value = pdf.FitWidth(value, pageWidth-2*margin, size)
+	p.text((pageWidth-pdf.TextWidth(value, size))/2, p.y, value, bold, size)
+}
+
+func (p *challanPDF) text(x, y float64, value string, bold bool, size float64) {
+	p.doc.Text(x, y, value, bold, size)
+}
+
+func (p *challanPDF) line(x1, y1, x2, y2 float64) {
+	p.doc.Line(x1, y1, x2, y2)
+}
+
+// finish numbers the pages and writes the document
+func (p *challanPDF) finish(w io.Writer) error {
+	p.doc.EachPage(func(page, total int) {
+		footer := fmt.Sprintf("Delivery Challan %s - Page %d of %d", p.challan.ChallanNumber, page, total)
+		p.text(pageWidth-margin-pdf.TextWidth(footer, 7), margin-12, footer, false, 7)
+	})
+	return p.doc.Write(w)
+}
@@ -0,0 +1,778 @@
+// Package documents renders invoices and delivery challans as printable
+// documents. Invoices are laid out in one of several templates, in the
+// tenant's colours with its logo.
+package documents
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/pdf"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/upi"
+)
+
+const (
+	pageWidth  = pdf.PageWidth
+	pageHeight = pdf.PageHeight
+	margin     = 36.0
+	rowHeight  = 13.0
+	fontSize   = 8.0
+	lineHeight = 10.0
+	upiQRSize  = 84.0
+
+	logoMaxWidth  = 120.0
+	logoMaxHeight = 40.0
+	bannerHeight  = 72.0 // Of modern invoices
+)
+
+// itemColumn is a column of the items table. Amount columns are right
+// aligned.
+type itemColumn struct {
+	heading string
+	width   float64
+	right   bool
+	value   func(n int, item models.InvoiceItem) string
+}
+
+// invoicePDF lays an invoice out page by page
+type invoicePDF struct {
+	invoice *models.Invoice
+	seller  *clients.Tenant
+	style   style
+	columns []itemColumn
+	doc     *pdf.Document
+	y       float64
+}
+
+// InvoicePDFOptions are how an invoice is printed
+type InvoicePDFOptions struct {
+	// Template is one of Templates; empty means the one the tenant chose in
+	// its branding, or classic
+	Template string
+	// Branding gives the logo, colours, footer and default terms. It may be
+	// nil when it could not be read; the invoice then prints plain.
+	Branding *clients.Branding
+	// Watermark, when set, is printed across each page, e.g. on auditors'
+	// copies
+	Watermark string
+}
+
+// WriteInvoicePDF renders an invoice as a PDF. Export invoices carry the
+// export declaration, the exporter's IEC, the LUT or bond and shipping bill
+// details, and amounts in the invoice currency alongside rupees. When the
+// seller has a UPI ID and a rupee balance is due, a QR code to pay it is
+// printed with the bank details. seller may be nil when the tenant could
+// not be read; its block is then left out.
+func WriteInvoicePDF(w io.Writer, invoice *models.Invoice, seller *clients.Tenant, opts InvoicePDFOptions) error {
+	p := &invoicePDF{invoice: invoice, seller: seller, style: newStyle(opts), doc: pdf.New()}
+	p.columns = p.itemColumns()
+	p.doc.SetWatermark(opts.Watermark)
+
+	p.doc.NewPage()
+	p.y = pageHeight - margin
+	p.header()
+	p.parties()
+	p.tableHeading()
+	for n, item := range invoice.Items {
+		p.itemRow(n, item)
+	}
+	p.totals()
+	if p.style.template == TemplateGSTDetailed {
+		p.hsnSummary()
+	}
+	p.footer()
+
+	return p.finish(w)
+}
+
+func (p *invoicePDF) title() string {
+	if p.invoice.IsExport() {
+		return "EXPORT INVOICE"
+	}
+	return "TAX INVOICE"
+}
+
+// header writes the title, with the logo at the left, or on modern
+// invoices the banner
+func (p *invoicePDF) header() {
+	if p.style.template == TemplateModern {
+		p.banner()
+	} else {
+		bottom := p.y
+		if p.style.logo != nil {
+			bottom = p.logo(margin, p.y+10, logoMaxWidth, logoMaxHeight) - 4
+		}
+		p.colored(p.style.primary, func() {
+			p.centered(p.title(), true, 14)
+		})
+		p.y = math.Min(p.y-14, bottom)
+	}
+	if declaration := p.invoice.ExportDeclaration(); declaration != "" {
+		for _, line := range pdf.Wrap(declaration, pageWidth-2*margin, fontSize) {
+			p.centered(line, true, fontSize)
+			p.y -= lineHeight
+		}
+	}
+	p.y -= 8
+}
+
+// banner fills the top of the first page with the primary colour, with the
+// logo on a white panel, or the seller's name, at its left and the title
+// and invoice number at its right
+func (p *invoicePDF) banner() {
+	bottom := pageHeight - bannerHeight
+	ink := textOn(p.style.primary)
+	p.colored(p.style.primary, func() {
+		p.doc.FillRect(0, bottom, pageWidth, bannerHeight)
+	})
+	p.colored(p.style.accent, func() {
+		p.doc.FillRect(0, bottom-3, pageWidth, 3)
+	})
+
+	right := pageWidth - margin
+	title := p.title()
+	number := "No. " + p.invoice.InvoiceNumber
+	p.colored(ink, func() {
+		p.text(right-pdf.TextWidth(title, 18), pageHeight-34, title, true, 18)
+		p.text(right-pdf.TextWidth(number, 9), pageHeight-50, number, false, 9)
+	})
+
+	if logo := p.style.logo; logo != nil {
+		width, height := fit(logo, logoMaxWidth, bannerHeight-16)
+		p.colored(pdf.White, func() {
+			p.doc.FillRect(margin-4, bottom+(bannerHeight-height)/2-4, width+8, height+8)
+		})
+		p.logo(margin, bottom+(bannerHeight+height)/2, width, height)
+	} else if p.seller != nil {
+		name := p.seller.Name
+		p.colored(ink, func() {
+			p.text(margin, bottom+bannerHeight/2-5, pdf.FitWidth(name, right-margin-pdf.TextWidth(title, 18)-20, 14), true, 14)
+		})
+	}
+	p.y = bottom - 3 - 22
+}
+
+// logo draws the logo as large as fits in width and height, with its top
+// left corner at x, top, and returns where its bottom is
+func (p *invoicePDF) logo(x, top, width, height float64) float64 {
+	width, height = fit(p.style.logo, width, height)
+	if err := p.doc.Image(p.style.logo, x, top-height, width, height); err != nil {
+		return top
+	}
+	return top - height
+}
+
+// fit returns the size of img scaled to fit in width and height
+func fit(img image.Image, width, height float64) (float64, float64) {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return 0, 0
+	}
+	scale := math.Min(width/float64(bounds.Dx()), height/float64(bounds.Dy()))
+	return float64(bounds.Dx()) * scale, float64(bounds.Dy()) * scale
+}
+
+// parties writes the seller and the invoice details side by side, then the
+// buyer
+func (p *invoicePDF) parties() {
+	half := (pageWidth - 2*margin) / 2
+	top := p.y
+
+	var seller []string
+	if s := p.seller; s != nil {
+		seller = append(seller, joinNonEmpty(", ", s.AddressLine1, deref(s.AddressLine2)))
+		seller = append(seller, joinNonEmpty(" - ", joinNonEmpty(", ", s.City, s.State), s.PinCode))
+		if gstin := deref(s.GSTIN); gstin != "" {
+			seller = append(seller, "GSTIN: "+gstin)
+		}
+		if pan := deref(s.PAN); pan != "" {
+			seller = append(seller, "PAN: "+pan)
+		}
+		if p.invoice.IECCode != "" {
+			seller = append(seller, "IEC: "+p.invoice.IECCode)
+		}
+		seller = append(seller, joinNonEmpty(" | ", s.Email, s.Phone))
+
+		name := s.LegalName
+		if name == "" {
+			name = s.Name
+		}
+		p.text(margin, p.y, pdf.FitWidth(name, half-10, 10), true, 10)
+		p.y -= 12
+	} else if p.invoice.IECCode != "" {
+		seller = append(seller, "IEC: "+p.invoice.IECCode)
+	}
+	p.block(margin, half-10, seller)
+	left := p.y
+
+	p.y = top
+	x := margin + half + 10
+	for _, row := range p.details() {
+		p.text(x, p.y, row[0], true, fontSize)
+		p.text(x+90, p.y, pdf.FitWidth(row[1], half-100, fontSize), false, fontSize)
+		p.y -= lineHeight
+	}
+	if left < p.y {
+		p.y = left
+	}
+	p.y -= 8
+
+	inv := p.invoice
+	p.line(margin, p.y+lineHeight, pageWidth-margin, p.y+lineHeight)
+	p.y -= 2
+	p.text(margin, p.y, "Bill To", true, fontSize)
+	p.y -= lineHeight + 2
+	p.text(margin, p.y, pdf.FitWidth(inv.CustomerName, pageWidth-2*margin, 10), true, 10)
+	p.y -= 12
+
+	buyer := pdf.Wrap(inv.CustomerAddress, half*2, fontSize)
+	if inv.IsExport() {
+		buyer = append(buyer, joinNonEmpty(": ", labelIf("Country", inv.DestinationCountry), inv.DestinationCountry))
+	} else {
+		buyer = append(buyer, "State: "+inv.CustomerState)
+		if inv.CustomerGSTIN != "" {
+			buyer = append(buyer, "GSTIN: "+inv.CustomerGSTIN)
+		}
+	}
+	buyer = append(buyer, joinNonEmpty(" | ", inv.CustomerEmail, inv.CustomerPhone))
+	p.block(margin, half*2, buyer)
+	p.y -= 8
+}
+
+// details are the label and value pairs of the invoice details block
+func (p *invoicePDF) details() [][2]string {
+	inv := p.invoice
+	rows := [][2]string{
+		{"Invoice No.", inv.InvoiceNumber},
+		{"Invoice Date", inv.InvoiceDate.Format("02/01/2006")},
+	}
+	if !inv.DueDate.IsZero() {
+		rows = append(rows, [2]string{"Due Date", inv.DueDate.Format("02/01/2006")})
+	}
+	if inv.PONumber != "" {
+		rows = append(rows, [2]string{"PO No.", inv.PONumber})
+	}
+	if inv.IRN != "" {
+		rows = append(rows, [2]string{"IRN", inv.IRN})
+	}
+	if inv.AckNo != "" && inv.AckDate != nil {
+		rows = append(rows,
+			[2]string{"Ack No.", inv.AckNo},
+			[2]string{"Ack Date", inv.AckDate.Format("02/01/2006")})
+	}
+	if p.style.template == TemplateGSTDetailed {
+		placeOfSupply := inv.CustomerState
+		if inv.IsExport() {
+			placeOfSupply = "Outside India"
+		}
+		rows = append(rows,
+			[2]string{"Place of Supply", placeOfSupply},
+			[2]string{"Reverse Charge", "No"})
+	}
+	if !inv.IsExport() {
+		return rows
+	}
+
+	if inv.IsForeignCurrency() {
+		rows = append(rows,
+			[2]string{"Currency", inv.Currency},
+			[2]string{"Exchange Rate", fmt.Sprintf("1 %s = INR %s", inv.Currency, inv.ExchangeRate.String())})
+	}
+	if inv.LUTReference != "" {
+		rows = append(rows, [2]string{"LUT / Bond", inv.LUTReference})
+	}
+	if inv.PortCode != "" {
+		rows = append(rows, [2]string{"Port Code", inv.PortCode})
+	}
+	if inv.ShippingBillNumber != "" {
+		bill := inv.ShippingBillNumber
+		if inv.ShippingBillDate != nil {
+			bill += " dated " + inv.ShippingBillDate.Format("02/01/2006")
+		}
+		rows = append(rows, [2]string{"Shipping Bill", bill})
+	}
+	return rows
+}
+
+// itemColumns are the columns of the items table. Foreign currency exports
+// show each line in that currency as well as its taxable value in rupees;
+// the GST-detailed template shows rupee lines' tax under each head.
+func (p *invoicePDF) itemColumns() []itemColumn {
+	number := itemColumn{heading: "#", width: 20, value: func(n int, _ models.InvoiceItem) string {
+		return fmt.Sprint(n + 1)
+	}}
+	hsn := itemColumn{heading: "HSN/SAC", width: 45, value: func(_ int, item models.InvoiceItem) string {
+		return item.HSNCode
+	}}
+	quantity := itemColumn{heading: "Qty", width: 45, right: true, value: func(_ int, item models.InvoiceItem) string {
+		return strings.TrimSpace(item.Quantity.String() + " " + item.Unit)
+	}}
+	taxable := itemColumn{heading: "Taxable (INR)", width: 65, right: true, value: func(_ int, item models.InvoiceItem) string {
+		return amount(item.Amount, "INR")
+	}}
+
+	if !p.invoice.IsForeignCurrency() && p.style.template == TemplateGSTDetailed {
+		tax := func(heading string, value func(item models.InvoiceItem) decimal.Decimal) itemColumn {
+			return itemColumn{heading: heading, width: 50, right: true, value: func(_ int, item models.InvoiceItem) string {
+				return amount(value(item), "INR")
+			}}
+		}
+		return []itemColumn{
+			number,
+			{heading: "Description", width: 91, value: description},
+			hsn,
+			{heading: "Qty", width: 40, right: true, value: quantity.value},
+			{heading: "Rate", width: 55, right: true, value: func(_ int, item models.InvoiceItem) string {
+				return amount(item.Rate, "INR")
+			}},
+			{heading: "Taxable", width: 62, right: true, value: taxable.value},
+			tax("CGST", func(item models.InvoiceItem) decimal.Decimal { return item.CGSTAmount }),
+			tax("SGST", func(item models.InvoiceItem) decimal.Decimal { return item.SGSTAmount }),
+			tax("IGST", func(item models.InvoiceItem) decimal.Decimal { return item.IGSTAmount }),
+			{heading: "Total", width: 60, right: true, value: func(_ int, item models.InvoiceItem) string {
+				return amount(item.TotalAmount, "INR")
+			}},
+		}
+	}
+	if !p.invoice.IsForeignCurrency() {
+		taxRate, taxAmount := "GST %", "GST"
+		if p.invoice.IsExport() {
+			taxRate, taxAmount = "IGST %", "IGST"
+		}
+		return []itemColumn{
+			number,
+			{heading: "Description", width: 178, value: description},
+			hsn,
+			quantity,
+			{heading: "Rate", width: 60, right: true, value: func(_ int, item models.InvoiceItem) string {
+				return amount(item.Rate, "INR")
+			}},
+			taxable,
+			{heading: taxRate, width: 45, right: true, value: func(_ int, item models.InvoiceItem) string {
+				return item.CGSTRate.Add(item.SGSTRate).Add(item.IGSTRate).String()
+			}},
+			{heading: taxAmount, width: 65, right: true, value: func(_ int, item models.InvoiceItem) string {
+				return amount(item.TotalAmount.Sub(item.Amount), "INR")
+			}},
+		}
+	}
+
+	currency := p.invoice.Currency
+	return []itemColumn{
+		number,
+		{heading: "Description", width: 138, value: description},
+		hsn,
+		quantity,
+		{heading: "Rate (" + currency + ")", width: 55, right: true, value: func(_ int, item models.InvoiceItem) string {
+			return amount(item.ForeignRate, currency)
+		}},
+		{heading: "Amount (" + currency + ")", width: 60, right: true, value: func(_ int, item models.InvoiceItem) string {
+			return amount(item.Quantity.Mul(item.ForeignRate), currency)
+		}},
+		taxable,
+		{heading: "IGST %", width: 35, right: true, value: func(_ int, item models.InvoiceItem) string {
+			return item.IGSTRate.String()
+		}},
+		{heading: "IGST (INR)", width: 60, right: true, value: func(_ int, item models.InvoiceItem) string {
+			return amount(item.IGSTAmount.Add(item.CessAmount), "INR")
+		}},
+	}
+}
+
+func description(_ int, item models.InvoiceItem) string {
+	return item.Description
+}
+
+// tableHeading writes the items table's headings between rules, or on
+// modern invoices on a bar of the primary colour
+func (p *invoicePDF) tableHeading() {
+	headings := func() {
+		x := margin
+		for _, col := range p.columns {
+			p.cell(x, col, col.heading, true)
+			x += col.width
+		}
+	}
+	if p.style.template == TemplateModern {
+		p.colored(p.style.primary, func() {
+			p.doc.FillRect(margin, p.y-4, pageWidth-2*margin, rowHeight+1)
+		})
+		p.colored(textOn(p.style.primary), headings)
+	} else {
+		p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+		headings()
+		p.line(margin, p.y-4, pageWidth-margin, p.y-4)
+	}
+	p.y -= rowHeight + 2
+}
+
+func (p *invoicePDF) itemRow(n int, item models.InvoiceItem) {
+	if p.y-rowHeight < margin+20 {
+		p.newPage()
+	}
+	// Modern invoices shade every other row
+	if p.style.template == TemplateModern && n%2 == 1 {
+		p.colored(p.style.primary.Tint(0.9), func() {
+			p.doc.FillRect(margin, p.y-4, pageWidth-2*margin, rowHeight)
+		})
+	}
+	x := margin
+	for _, col := range p.columns {
+		p.cell(x, col, col.value(n, item), false)
+		x += col.width
+	}
+	p.y -= rowHeight
+}
+
+// totals writes the totals under the amount columns, then the total in
+// words
+func (p *invoicePDF) totals() {
+	inv := p.invoice
+	type total struct {
+		label string
+		value decimal.Decimal
+		show  bool
+		bold  bool
+	}
+	rows := []total{
+		{"Subtotal", inv.Subtotal, true, false},
+		{"Discount", inv.DiscountAmount.Neg(), inv.DiscountAmount.IsPositive(), false},
+		{"Taxable Value", inv.TaxableAmount, true, false},
+		{"CGST", inv.CGSTAmount, inv.CGSTAmount.IsPositive(), false},
+		{"SGST", inv.SGSTAmount, inv.SGSTAmount.IsPositive(), false},
+		{"IGST", inv.IGSTAmount, inv.IGSTAmount.IsPositive() || inv.IsExport(), false},
+		{"Cess", inv.CessAmount, inv.CessAmount.IsPositive(), false},
+		{"TCS", inv.TCSAmount, inv.TCSAmount.IsPositive(), false},
+		{"Round Off", inv.RoundOff, !inv.RoundOff.IsZero(), false},
+		{"Total (INR)", inv.TotalAmount, true, true},
+	}
+
+	p.ensureSpace(float64(len(rows)+6) * rowHeight)
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	right := pageWidth - margin - 3
+	for _, row := range rows {
+		if !row.show {
+			continue
+		}
+		ink := pdf.Black
+		if row.bold {
+			ink = p.style.primary
+		}
+		value := amount(row.value, "INR")
+		p.colored(ink, func() {
+			p.text(right-200, p.y, row.label, row.bold, fontSize)
+			p.text(right-pdf.TextWidth(value, fontSize), p.y, value, row.bold, fontSize)
+		})
+		p.y -= rowHeight
+	}
+	if inv.IsForeignCurrency() {
+		label := "Total (" + inv.Currency + ")"
+		value := amount(inv.ForeignTotal, inv.Currency)
+		p.colored(p.style.primary, func() {
+			p.text(right-200, p.y, label, true, fontSize)
+			p.text(right-pdf.TextWidth(value, fontSize), p.y, value, true, fontSize)
+		})
+		p.y -= rowHeight
+	}
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+
+	// Other languages are printed in English, which the standard fonts can
+	// show
+	words := i18n.AmountInWords("en", inv.TotalAmount.Mul(decimal.NewFromInt(100)).Round(0).IntPart())
+	p.y -= 2
+	p.text(margin, p.y, "Amount in words:", true, fontSize)
+	p.y -= lineHeight
+	p.paragraph(words)
+	p.y -= 6
+}
+
+// hsnSummary writes the tax on the invoice by HSN/SAC code and rate, as the
+// GST-detailed template shows under the totals
+func (p *invoicePDF) hsnSummary() {
+	type group struct {
+		hsn                             string
+		rate                            decimal.Decimal
+		taxable, cgst, sgst, igst, cess decimal.Decimal
+	}
+	var groups []*group
+	byKey := make(map[string]*group)
+	for _, item := range p.invoice.Items {
+		rate := item.CGSTRate.Add(item.SGSTRate).Add(item.IGSTRate)
+		key := item.HSNCode + "|" + rate.String()
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{hsn: item.HSNCode, rate: rate}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.taxable = g.taxable.Add(item.Amount)
+		g.cgst = g.cgst.Add(item.CGSTAmount)
+		g.sgst = g.sgst.Add(item.SGSTAmount)
+		g.igst = g.igst.Add(item.IGSTAmount)
+		g.cess = g.cess.Add(item.CessAmount)
+	}
+
+	columns := []itemColumn{
+		{heading: "HSN/SAC", width: 78},
+		{heading: "GST %", width: 45, right: true},
+		{heading: "Taxable", width: 80, right: true},
+		{heading: "CGST", width: 70, right: true},
+		{heading: "SGST", width: 70, right: true},
+		{heading: "IGST", width: 70, right: true},
+		{heading: "Cess", width: 50, right: true},
+		{heading: "Total Tax", width: 60, right: true},
+	}
+	row := func(values []string, bold bool) {
+		x := margin
+		for i, col := range columns {
+			p.cell(x, col, values[i], bold)
+			x += col.width
+		}
+		p.y -= rowHeight
+	}
+
+	p.ensureSpace(math.Min(float64(len(groups)+4)*rowHeight, pageHeight/2))
+	p.heading("Tax Summary by HSN/SAC")
+	p.y -= 2
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	headings := make([]string, len(columns))
+	for i, col := range columns {
+		headings[i] = col.heading
+	}
+	row(headings, true)
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+
+	var total group
+	for _, g := range groups {
+		p.ensureSpace(rowHeight)
+		tax := g.cgst.Add(g.sgst).Add(g.igst).Add(g.cess)
+		row([]string{g.hsn, g.rate.String(), amount(g.taxable, "INR"), amount(g.cgst, "INR"), amount(g.sgst, "INR"),
+			amount(g.igst, "INR"), amount(g.cess, "INR"), amount(tax, "INR")}, false)
+		total.taxable = total.taxable.Add(g.taxable)
+		total.cgst = total.cgst.Add(g.cgst)
+		total.sgst = total.sgst.Add(g.sgst)
+		total.igst = total.igst.Add(g.igst)
+		total.cess = total.cess.Add(g.cess)
+	}
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	tax := total.cgst.Add(total.sgst).Add(total.igst).Add(total.cess)
+	row([]string{"Total", "", amount(total.taxable, "INR"), amount(total.cgst, "INR"), amount(total.sgst, "INR"),
+		amount(total.igst, "INR"), amount(total.cess, "INR"), amount(tax, "INR")}, true)
+	p.line(margin, p.y+rowHeight-3, pageWidth-margin, p.y+rowHeight-3)
+	p.y -= 6
+}
+
+// footer writes the notes, terms, bank details and signature block, then
+// the tenant's footer. Invoices without terms of their own print the
+// tenant's default terms.
+func (p *invoicePDF) footer() {
+	inv := p.invoice
+	if inv.Notes != "" {
+		p.heading("Notes")
+		p.paragraph(inv.Notes)
+	}
+	terms := inv.Terms
+	if terms == "" {
+		terms = p.style.terms
+	}
+	if terms != "" {
+		p.heading("Terms & Conditions")
+		p.paragraph(terms)
+	}
+	if s := p.seller; s != nil && deref(s.BankAccountNumber) != "" {
+		p.heading("Bank Details")
+		p.paragraph(joinNonEmpty(" | ", deref(s.BankName), "A/c "+deref(s.BankAccountNumber), labelIf("IFSC ", deref(s.BankIFSC))+deref(s.BankIFSC)))
+	}
+	p.upiQR()
+
+	p.ensureSpace(50)
+	p.y -= 16
+	name := ""
+	if p.seller != nil {
+		name = p.seller.LegalName
+		if name == "" {
+			name = p.seller.Name
+		}
+	}
+	right := pageWidth - margin
+	signFor := "For " + name
+	p.text(right-pdf.TextWidth(signFor, fontSize), p.y, signFor, true, fontSize)
+	p.y -= 30
+	signatory := "Authorised Signatory"
+	p.text(right-pdf.TextWidth(signatory, fontSize), p.y, signatory, false, fontSize)
+
+	if p.style.footer != "" {
+		p.y -= 24
+		for _, line := range pdf.Wrap(p.style.footer, pageWidth-2*margin, 7) {
+			p.ensureSpace(lineHeight)
+			p.centered(line, false, 7)
+			p.y -= 9
+		}
+	}
+}
+
+// upiQR prints a QR code any UPI app can scan to pay the balance due, with
+// the invoice number as the payment's reference. Nothing is printed when
+// the invoice cannot be paid by UPI.
+func (p *invoicePDF) upiQR() {
+	payment, err := upi.ForInvoice(p.invoice, p.seller)
+	if err != nil {
+		return
+	}
+	code, err := payment.QR()
+	if err != nil {
+		return
+	}
+
+	p.ensureSpace(upiQRSize + 2*lineHeight)
+	p.heading("Pay by UPI")
+	top := p.y + lineHeight - 4
+	module := upiQRSize / float64(code.Size())
+	for row, modules := range code {
+		for col, dark := range modules {
+			if dark {
+				p.doc.FillRect(margin+float64(col)*module, top-float64(row+1)*module, module, module)
+			}
+		}
+	}
+
+	x := margin + upiQRSize + 12
+	p.text(x, p.y, "Scan with any UPI app to pay INR "+amount(payment.Amount, "INR"), true, fontSize)
+	p.text(x, p.y-lineHeight, "UPI ID: "+payment.PayeeVPA, false, fontSize)
+	p.text(x, p.y-2*lineHeight, "Reference: "+payment.Reference, false, fontSize)
+	p.y = top - upiQRSize - 6
+}
+
+func (p *invoicePDF) heading(value string) {
+	p.ensureSpace(2 * lineHeight)
+	p.colored(p.style.primary, func() {
+		p.text(margin, p.y, value, true, fontSize)
+	})
+	p.y -= lineHeight
+}
+
+func (p *invoicePDF) paragraph(value string) {
+	for _, line := range pdf.Wrap(value, pageWidth-2*margin, fontSize) {
+		p.ensureSpace(lineHeight)
+		p.text(margin, p.y, line, false, fontSize)
+		p.y -= lineHeight
+	}
+	p.y -= 4
+}
+
+// block writes lines at x, skipping empty ones
+func (p *invoicePDF) block(x, width float64, lines []string) {
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		p.text(x, p.y, pdf.FitWidth(line, width, fontSize), false, fontSize)
+		p.y -= lineHeight
+	}
+}
+
+// ensureSpace starts a new page unless height fits above the footer
+func (p *invoicePDF) ensureSpace(height float64) {
+	if p.y-height < margin+20 {
+		p.newPage()
+	}
+}
+
+// newPage continues the invoice on a new page, repeating the items table
+// heading. Modern invoices carry a strip of the banner's colour at the top.
+func (p *invoicePDF) newPage() {
+	p.doc.NewPage()
+	p.y = pageHeight - margin
+	if p.style.template == TemplateModern {
+		p.colored(p.style.primary, func() {
+			p.doc.FillRect(0, pageHeight-8, pageWidth, 8)
+		})
+	}
+	p.text(margin, p.y, p.title()+" "+p.invoice.InvoiceNumber+" (continued)", true, fontSize)
+	p.y -= 16
+	p.tableHeading()
+}
+
+// cell writes a value inside a column, cut to fit its width
+func (p *invoicePDF) cell(x float64, col itemColumn, value string, bold bool) {
+	const padding = 3
+	value = pdf.FitWidth(value, col.width-2*padding, fontSize)
+	if col.right {
+		x += col.width - padding - pdf.TextWidth(value, fontSize)
+	} else {
+		x += padding
+	}
+	p.text(x, p.y, value, bold, fontSize)
+}
+
+func (p *invoicePDF) centered(value string, bold bool, size float64) {
+	value = pdf.FitWidth(value, pageWidth-2*margin, size)
+	p.text((pageWidth-pdf.TextWidth(value, size))/2, p.y, value, bold, size)
+}
+
+func (p *invoicePDF) text(x, y float64, value string, bold bool, size float64) {
+	p.doc.Text(x, y, value, bold, size)
+}
+
+func (p *invoicePDF) line(x1, y1, x2, y2 float64) {
+	p.doc.Line(x1, y1, x2, y2)
+}
+
+// colored draws in color, then goes back to black
+func (p *invoicePDF) colored(color pdf.Color, draw func()) {
+	if color == pdf.Black {
+		draw()
+		return
+	}
+	p.doc.SetColor(color)
+	draw()
+	p.doc.SetColor(pdf.Black)
+}
+
+// finish numbers the pages and writes the document
+func (p *invoicePDF) finish(w io.Writer) error {
+	p.doc.EachPage(func(page, total int) {
+		footer := fmt.Sprintf("%s %s - Page %d of %d", p.title(), p.invoice.InvoiceNumber, page, total)
+		p.text(pageWidth-margin-pdf.TextWidth(footer, 7), margin-12, footer, false, 7)
+	})
+	return p.doc.Write(w)
+}
+
+// amount writes an amount grouped for its currency, without the symbol:
+// the standard fonts cannot show the rupee sign
+func amount(value decimal.Decimal, currency string) string {
+	f := i18n.Currency(currency)
+	return i18n.GroupDigits(value.StringFixed(int32(f.Decimals)), f.Grouping)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// labelIf returns label when value is set
+func labelIf(label, value string) string {
+	if value == "" {
+		return ""
+	}
+	return label
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, sep)
+}
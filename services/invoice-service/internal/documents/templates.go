@@ -0,0 +1,162 @@
+package documents
+
+import (
+	"image"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/pdf"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// Invoice templates
+const (
+	TemplateClassic     = "classic"
+	TemplateModern      = "modern"
+	TemplateGSTDetailed = "gst_detailed"
+)
+
+// Template is a layout invoices can be printed in
+type Template struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+var templates = []Template{
+	{
+		Name:        TemplateClassic,
+		Label:       "Classic",
+		Description: "Plain black-and-white layout with the logo beside the title",
+	},
+	{
+		Name:        TemplateModern,
+		Label:       "Modern",
+		Description: "Banner and items table in the brand's primary colour",
+	},
+	{
+		Name:        TemplateGSTDetailed,
+		Label:       "GST detailed",
+		Description: "CGST, SGST and IGST on every line, place of supply and an HSN/SAC-wise tax summary",
+	},
+}
+
+// Templates returns the templates invoices can be printed in
+func Templates() []Template {
+	return templates
+}
+
+// IsTemplate reports whether name is one of the templates
+func IsTemplate(name string) bool {
+	for _, template := range templates {
+		if template.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultModernColor is the banner of modern invoices of tenants that have
+// not set a primary colour
+var defaultModernColor = pdf.Color{R: 0x1f, G: 0x4e, B: 0x79}
+
+// style is how an invoice is printed: its template and the tenant's
+// branding
+type style struct {
+	template string
+	primary  pdf.Color // Title, headings and total; the banner and table of modern invoices
+	accent   pdf.Color // Rule under the banner of modern invoices
+	logo     image.Image
+	footer   string
+	terms    string // Printed on invoices that carry no terms of their own
+}
+
+func newStyle(opts InvoicePDFOptions) style {
+	branding := opts.Branding
+	if branding == nil {
+		branding = &clients.Branding{}
+	}
+
+	s := style{
+		template: opts.Template,
+		primary:  pdf.Black,
+		logo:     branding.Logo,
+		footer:   branding.InvoiceFooter,
+		terms:    branding.InvoiceTerms,
+	}
+	if s.template == "" {
+		s.template = branding.InvoiceTemplate
+	}
+	if !IsTemplate(s.template) {
+		s.template = TemplateClassic
+	}
+	if s.template == TemplateModern {
+		s.primary = defaultModernColor
+	}
+	if color, ok := pdf.ParseColor(branding.PrimaryColor); ok {
+		s.primary = color
+	}
+	s.accent = s.primary.Tint(0.5)
+	if color, ok := pdf.ParseColor(branding.AccentColor); ok {
+		s.accent = color
+	}
+	return s
+}
+
+// textOn returns the colour text reads best in on a background: white on
+// dark colours, black on light ones
+func textOn(background pdf.Color) pdf.Color {
+	luminance := 0.299*float64(background.R) + 0.587*float64(background.G) + 0.114*float64(background.B)
+	if luminance > 160 {
+		return pdf.Black
+	}
+	return pdf.White
+}
+
+// SampleInvoice is an invoice from seller to a made-up customer in the same
+// state, to preview templates with. Its lines carry two GST rates, so the
+// GST-detailed template's summary has more than one row.
+func SampleInvoice(seller *clients.Tenant) *models.Invoice {
+	state, stateCode := "Karnataka", "29"
+	if seller != nil && seller.State != "" {
+		state = seller.State
+		if len(seller.StateCode) == 2 {
+			stateCode = seller.StateCode
+		}
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	invoice := &models.Invoice{
+		InvoiceNumber:   "SAMPLE-0001",
+		CustomerName:    "Sample Customer Pvt Ltd",
+		CustomerGSTIN:   stateCode + "AABCS1234F1Z5",
+		CustomerAddress: "12, Example Street, Business District",
+		CustomerState:   state,
+		CustomerEmail:   "accounts@example.com",
+		CustomerPhone:   "9800000000",
+		PONumber:        "PO-1234",
+		InvoiceDate:     today,
+		DueDate:         today.AddDate(0, 0, 30),
+		Status:          models.InvoiceStatusDraft,
+		Currency:        "INR",
+		ExchangeRate:    decimal.NewFromInt(1),
+		Notes:           "Thank you for your business.",
+	}
+
+	nine, six := decimal.NewFromInt(9), decimal.NewFromInt(6)
+	items := []models.InvoiceItem{
+		{Description: "Website design and development", HSNCode: "998314", Quantity: decimal.NewFromInt(1), Unit: "nos",
+			Rate: decimal.NewFromInt(45000), CGSTRate: nine, SGSTRate: nine},
+		{Description: "Annual hosting", HSNCode: "998315", Quantity: decimal.NewFromInt(12), Unit: "months",
+			Rate: decimal.NewFromInt(1500), CGSTRate: nine, SGSTRate: nine},
+		{Description: "Printed brochures", HSNCode: "4911", Quantity: decimal.NewFromInt(500), Unit: "pcs",
+			Rate: decimal.NewFromInt(12), CGSTRate: six, SGSTRate: six},
+	}
+	for i := range items {
+		items[i].CalculateAmounts()
+	}
+	invoice.Items = items
+	invoice.CalculateTotals()
+	return invoice
+}
@@ -0,0 +1,271 @@
+package einvoice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// foreignStateCode is the state code and place of supply of buyers abroad
+const foreignStateCode = "96"
+
+var (
+	gstinRegex     = regexp.MustCompile(`^[0-9]{2}[0-9A-Z]{13}$`)
+	docNumberRegex = regexp.MustCompile(`^[A-Za-z1-9][A-Za-z0-9/-]{0,15}$`)
+	pinCodeRegex   = regexp.MustCompile(`\b[1-9][0-9]{5}\b`)
+)
+
+// uqcs maps the units of measure items are sold in to GST unit quantity
+// codes. Other units are reported as OTH.
+var uqcs = map[string]string{
+	"PCS":   "PCS",
+	"NOS":   "NOS",
+	"KG":    "KGS",
+	"G":     "GMS",
+	"L":     "LTR",
+	"ML":    "MLT",
+	"M":     "MTR",
+	"CM":    "CMS",
+	"SQM":   "SQM",
+	"CUM":   "CBM",
+	"SET":   "SET",
+	"BOX":   "BOX",
+	"PKT":   "PAC",
+	"PAIR":  "PRS",
+	"DOZEN": "DOZ",
+}
+
+// ValidationError lists what keeps an invoice from being reported
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invoice cannot be e-invoiced: " + strings.Join(e.Problems, "; ")
+}
+
+// Build writes an invoice in the INV-01 schema. Invoices to registered
+// buyers and export invoices are e-invoiced; others return a
+// ValidationError, as do invoices missing what the schema requires.
+func Build(invoice *models.Invoice, seller *clients.Tenant) (*Document, error) {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	doc := &Document{
+		Version:  SchemaVersion,
+		TranDtls: TranDetails{TaxSch: "GST", SupTyp: SupplyB2B, RegRev: "N", IgstOnIntra: "N"},
+		DocDtls: DocDetails{
+			Typ: "INV",
+			No:  invoice.InvoiceNumber,
+			Dt:  invoice.InvoiceDate.Format("02/01/2006"),
+		},
+	}
+	if !docNumberRegex.MatchString(invoice.InvoiceNumber) {
+		problem("invoice number must be at most 16 letters, digits, / or -, not starting with 0, / or -")
+	}
+
+	// Seller
+	sellerGSTIN := ""
+	if seller.GSTIN != nil {
+		sellerGSTIN = strings.ToUpper(strings.TrimSpace(*seller.GSTIN))
+	}
+	if !gstinRegex.MatchString(sellerGSTIN) {
+		problem("seller GSTIN is missing or invalid")
+	}
+	doc.SellerDtls = Party{
+		Gstin: sellerGSTIN,
+		LglNm: firstNonEmpty(seller.LegalName, seller.Name),
+		TrdNm: seller.Name,
+		Addr1: truncate(seller.AddressLine1, 100),
+		Loc:   truncate(seller.City, 50),
+		Stcd:  seller.StateCode,
+		Ph:    digits(seller.Phone, 12),
+		Em:    seller.Email,
+	}
+	if seller.AddressLine2 != nil {
+		doc.SellerDtls.Addr2 = truncate(*seller.AddressLine2, 100)
+	}
+	if pin, err := strconv.Atoi(seller.PinCode); err == nil && pinCodeRegex.MatchString(seller.PinCode) {
+		doc.SellerDtls.Pin = pin
+	} else {
+		problem("seller PIN code is missing or invalid")
+	}
+	if doc.SellerDtls.Addr1 == "" || len(doc.SellerDtls.Loc) < 3 || doc.SellerDtls.Stcd == "" {
+		problem("seller address, city and state code are required")
+	}
+
+	// Buyer
+	address := strings.TrimSpace(invoice.CustomerAddress)
+	doc.BuyerDtls = Party{
+		LglNm: invoice.CustomerName,
+		Addr1: truncate(firstLine(address), 100),
+		Loc:   truncate(firstNonEmpty(invoice.CustomerState, invoice.DestinationCountry), 50),
+		Ph:    digits(invoice.CustomerPhone, 12),
+		Em:    invoice.CustomerEmail,
+	}
+	if invoice.IsExport() {
+		doc.TranDtls.SupTyp = SupplyExpWOP
+		if invoice.ExportType == models.ExportWithPayment {
+			doc.TranDtls.SupTyp = SupplyExpWP
+		}
+		doc.BuyerDtls.Gstin = "URP"
+		doc.BuyerDtls.Pos = foreignStateCode
+		doc.BuyerDtls.Stcd = foreignStateCode
+		doc.BuyerDtls.Pin = 999999
+	} else {
+		buyerGSTIN := strings.ToUpper(strings.TrimSpace(invoice.CustomerGSTIN))
+		if !gstinRegex.MatchString(buyerGSTIN) {
+			problem("only invoices to GST registered customers and exports are e-invoiced; customer GSTIN is missing or invalid")
+		} else {
+			doc.BuyerDtls.Gstin = buyerGSTIN
+			doc.BuyerDtls.Pos = buyerGSTIN[:2]
+			doc.BuyerDtls.Stcd = buyerGSTIN[:2]
+		}
+		if pin := pinCodeRegex.FindString(address); pin != "" {
+			doc.BuyerDtls.Pin, _ = strconv.Atoi(pin)
+		} else {
+			problem("customer address must include its PIN code")
+		}
+	}
+	if doc.BuyerDtls.LglNm == "" || doc.BuyerDtls.Addr1 == "" || len(doc.BuyerDtls.Loc) < 3 {
+		problem("customer name, address and state are required")
+	}
+
+	// Items
+	if len(invoice.Items) == 0 {
+		problem("invoice has no items")
+	}
+	for n, item := range invoice.Items {
+		hsn := strings.TrimSpace(item.HSNCode)
+		if len(hsn) < 4 {
+			problem("item %d needs an HSN or SAC code", n+1)
+		}
+		cessNonAdvalorem := item.Quantity.Mul(item.CessSpecificRate)
+		gstRate := item.IGSTRate
+		if !gstRate.IsPositive() {
+			gstRate = item.CGSTRate.Add(item.SGSTRate)
+		}
+		entry := Item{
+			SlNo:          strconv.Itoa(n + 1),
+			PrdDesc:       truncate(item.Description, 300),
+			IsServc:       "N",
+			HsnCd:         hsn,
+			Qty:           Quantity(item.Quantity),
+			Unit:          uqc(item.Unit),
+			UnitPrice:     Quantity(item.Rate),
+			TotAmt:        Amount(item.Amount),
+			Discount:      Amount(decimal.Zero),
+			AssAmt:        Amount(item.Amount),
+			GstRt:         Amount(gstRate),
+			IgstAmt:       Amount(item.IGSTAmount),
+			CgstAmt:       Amount(item.CGSTAmount),
+			SgstAmt:       Amount(item.SGSTAmount),
+			CesRt:         Amount(item.CessRate),
+			CesAmt:        Amount(item.CessAmount.Sub(cessNonAdvalorem)),
+			CesNonAdvlAmt: Amount(cessNonAdvalorem),
+			TotItemVal:    Amount(item.TotalAmount),
+		}
+		// SAC codes of services all start with 99
+		if strings.HasPrefix(hsn, "99") {
+			entry.IsServc = "Y"
+		}
+		doc.ItemList = append(doc.ItemList, entry)
+	}
+
+	// Totals. Items are taxed on their full amount, so the invoice
+	// discount comes off after tax.
+	doc.ValDtls = ValueDetails{
+		AssVal:    Amount(invoice.Subtotal),
+		CgstVal:   Amount(invoice.CGSTAmount),
+		SgstVal:   Amount(invoice.SGSTAmount),
+		IgstVal:   Amount(invoice.IGSTAmount),
+		CesVal:    Amount(invoice.CessAmount),
+		Discount:  Amount(invoice.DiscountAmount),
+		OthChrg:   Amount(invoice.TCSAmount),
+		RndOffAmt: Amount(invoice.RoundOff),
+		TotInvVal: Amount(invoice.TotalAmount),
+	}
+	if invoice.IsForeignCurrency() {
+		foreignTotal := Amount(invoice.ForeignTotal)
+		doc.ValDtls.TotInvValFc = &foreignTotal
+	}
+
+	if invoice.IsExport() {
+		doc.ExpDtls = &ExportDetails{
+			ShipBNo: invoice.ShippingBillNumber,
+			Port:    invoice.PortCode,
+			RefClm:  "N",
+			ForCur:  invoice.Currency,
+		}
+		if invoice.ShippingBillDate != nil {
+			doc.ExpDtls.ShipBDt = invoice.ShippingBillDate.Format("02/01/2006")
+		}
+		if invoice.ExportType == models.ExportWithPayment {
+			doc.ExpDtls.RefClm = "Y"
+		}
+		// Countries are kept as entered; only ISO codes can be reported
+		if country := strings.ToUpper(strings.TrimSpace(invoice.DestinationCountry)); len(country) == 2 {
+			doc.ExpDtls.CntCode = country
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return doc, nil
+}
+
+func uqc(unit string) string {
+	if code, ok := uqcs[strings.ToUpper(strings.TrimSpace(unit))]; ok {
+		return code
+	}
+	return "OTH"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return strings.TrimSpace(line)
+}
+
+func truncate(text string, max int) string {
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max])
+	}
+	return text
+}
+
+// digits keeps the digits of a phone number, which the schema takes as
+// 6 to 12 digits
+func digits(phone string, max int) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	number := b.String()
+	if len(number) > max {
+		number = number[len(number)-max:]
+	}
+	if len(number) < 6 {
+		return ""
+	}
+	return number
+}
@@ -0,0 +1,409 @@
+package einvoice
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SandboxURL is the NIC sandbox, used unless another base URL is given
+const SandboxURL = "https://einv-apisandbox.nic.in"
+
+// API paths, relative to the base URL
+const (
+	authPath     = "/eivital/v1.04/auth"
+	invoicePath  = "/eicore/v1.03/Invoice"
+	byIRNPath    = "/eicore/v1.03/Invoice/irn/"
+	cancelPath   = "/eicore/v1.03/Invoice/Cancel"
+	irpTimestamp = "2006-01-02 15:04:05"
+)
+
+// IRP error codes acted on
+const (
+	codeInvalidToken = "1005"
+	codeDuplicateIRN = "2150"
+)
+
+// CancelReason is why an IRN is cancelled, as the IRP codes it
+type CancelReason string
+
+const (
+	CancelDuplicate        CancelReason = "1"
+	CancelDataEntryMistake CancelReason = "2"
+	CancelOrderCancelled   CancelReason = "3"
+	CancelOther            CancelReason = "4"
+)
+
+// CancelReasons maps the reasons the API accepts to their IRP codes
+var CancelReasons = map[string]CancelReason{
+	"duplicate":          CancelDuplicate,
+	"data_entry_mistake": CancelDataEntryMistake,
+	"order_cancelled":    CancelOrderCancelled,
+	"other":              CancelOther,
+}
+
+// ist is the zone the IRP reports times in
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+// ErrUnavailable is returned when the IRP could not be reached or failed
+// to answer; the request may be retried
+var ErrUnavailable = errors.New("e-invoice portal is unavailable")
+
+// APIError is the IRP rejecting a request, such as for a schema error in
+// the invoice
+type APIError struct {
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("IRP error %s: %s", e.Code, e.Message)
+}
+
+// Credentials are a taxpayer's API user on the IRP, created on the portal
+// for our GSP or direct integration
+type Credentials struct {
+	GSTIN    string
+	Username string
+	Password string
+}
+
+// IRN is an invoice's registration with the IRP
+type IRN struct {
+	IRN           string
+	AckNo         string
+	AckDate       time.Time
+	SignedInvoice string // JWT signed by the IRP
+	SignedQRCode  string // JWT to print as the invoice's QR code
+}
+
+// Cancellation confirms an IRN was cancelled
+type Cancellation struct {
+	IRN         string
+	CancelledAt time.Time
+}
+
+// Client registers invoices with the IRP
+type Client interface {
+	// Generate registers an invoice and returns its IRN. An invoice the
+	// IRP already has returns its existing IRN.
+	Generate(ctx context.Context, creds Credentials, doc *Document) (*IRN, error)
+	// Cancel cancels an IRN, which the IRP allows within 24 hours of its
+	// acknowledgement
+	Cancel(ctx context.Context, creds Credentials, irn string, reason CancelReason, remark string) (*Cancellation, error)
+}
+
+// session is a logged in API user: its token, and the session key (SEK)
+// payloads are encrypted with
+type session struct {
+	token     string
+	sek       []byte
+	expiresAt time.Time
+}
+
+type nicClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	publicKey    *rsa.PublicKey
+	httpClient   *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]*session // By GSTIN and username
+}
+
+// NewNICClient creates a client of the NIC e-invoice API, or of a GSP
+// exposing it unchanged. publicKey is the IRP's public key for the
+// environment baseURL points to.
+func NewNICClient(baseURL, clientID, clientSecret, publicKey string) (Client, error) {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		baseURL = SandboxURL
+	}
+	return &nicClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		publicKey:    key,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		sessions:     make(map[string]*session),
+	}, nil
+}
+
+// envelope is how the IRP wraps every response
+type envelope struct {
+	Status       json.Number `json:"Status"`
+	Data         string      `json:"Data"`
+	ErrorDetails []struct {
+		ErrorCode    string `json:"ErrorCode"`
+		ErrorMessage string `json:"ErrorMessage"`
+	} `json:"ErrorDetails"`
+	InfoDtls []struct {
+		InfCd string          `json:"InfCd"`
+		Desc  json.RawMessage `json:"Desc"`
+	} `json:"InfoDtls"`
+}
+
+func (e *envelope) err() error {
+	if e.Status.String() == "1" {
+		return nil
+	}
+	if len(e.ErrorDetails) == 0 {
+		return &APIError{Code: "unknown", Message: "request failed"}
+	}
+	messages := make([]string, 0, len(e.ErrorDetails))
+	for _, detail := range e.ErrorDetails {
+		messages = append(messages, detail.ErrorMessage)
+	}
+	return &APIError{Code: e.ErrorDetails[0].ErrorCode, Message: strings.Join(messages, "; ")}
+}
+
+// irnData is the decrypted Data of an IRN
+type irnData struct {
+	AckNo         json.Number `json:"AckNo"`
+	AckDt         string      `json:"AckDt"`
+	Irn           string      `json:"Irn"`
+	SignedInvoice string      `json:"SignedInvoice"`
+	SignedQRCode  string      `json:"SignedQRCode"`
+}
+
+func (d *irnData) irn() *IRN {
+	irn := &IRN{
+		IRN:           d.Irn,
+		AckNo:         d.AckNo.String(),
+		SignedInvoice: d.SignedInvoice,
+		SignedQRCode:  d.SignedQRCode,
+	}
+	irn.AckDate, _ = time.ParseInLocation(irpTimestamp, d.AckDt, ist)
+	return irn
+}
+
+func (c *nicClient) Generate(ctx context.Context, creds Credentials, doc *Document) (*IRN, error) {
+	var data irnData
+	env, err := c.call(ctx, creds, http.MethodPost, invoicePath, doc, &data)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == codeDuplicateIRN && env != nil {
+		// The IRP already registered this invoice, likely on an attempt
+		// whose answer was lost; fetch what it registered
+		for _, info := range env.InfoDtls {
+			if info.InfCd != "DUPIRN" {
+				continue
+			}
+			var duplicate struct {
+				Irn string `json:"Irn"`
+			}
+			if json.Unmarshal(info.Desc, &duplicate) == nil && duplicate.Irn != "" {
+				return c.get(ctx, creds, duplicate.Irn)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data.irn(), nil
+}
+
+func (c *nicClient) get(ctx context.Context, creds Credentials, irn string) (*IRN, error) {
+	var data irnData
+	if _, err := c.call(ctx, creds, http.MethodGet, byIRNPath+url.PathEscape(irn), nil, &data); err != nil {
+		return nil, err
+	}
+	return data.irn(), nil
+}
+
+func (c *nicClient) Cancel(ctx context.Context, creds Credentials, irn string, reason CancelReason, remark string) (*Cancellation, error) {
+	body := map[string]string{
+		"Irn":    irn,
+		"CnlRsn": string(reason),
+		"CnlRem": remark,
+	}
+	var data struct {
+		Irn        string `json:"Irn"`
+		CancelDate string `json:"CancelDate"`
+	}
+	if _, err := c.call(ctx, creds, http.MethodPost, cancelPath, body, &data); err != nil {
+		return nil, err
+	}
+
+	cancellation := &Cancellation{IRN: data.Irn}
+	cancellation.CancelledAt, _ = time.ParseInLocation(irpTimestamp, data.CancelDate, ist)
+	return cancellation, nil
+}
+
+// call makes an authenticated request, encrypting body and decrypting the
+// response's Data into out. A session the IRP no longer accepts is
+// renewed and the request retried once.
+func (c *nicClient) call(ctx context.Context, creds Credentials, method, path string, body, out interface{}) (*envelope, error) {
+	for attempt := 0; ; attempt++ {
+		sess, err := c.session(ctx, creds)
+		if err != nil {
+			return nil, err
+		}
+
+		var payload interface{}
+		if body != nil {
+			plaintext, err := json.Marshal(body)
+			if err != nil {
+				return nil, err
+			}
+			data, err := aesEncrypt(sess.sek, plaintext)
+			if err != nil {
+				return nil, err
+			}
+			payload = map[string]string{"Data": data}
+		}
+
+		header := c.header(creds)
+		header.Set("user_name", creds.Username)
+		header.Set("AuthToken", sess.token)
+		env, err := c.send(ctx, method, path, header, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := env.err(); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.Code == codeInvalidToken && attempt == 0 {
+				c.forget(creds)
+				continue
+			}
+			return env, err
+		}
+
+		plaintext, err := aesDecrypt(sess.sek, env.Data)
+		if err != nil {
+			return env, fmt.Errorf("einvoice: decrypt response: %w", err)
+		}
+		return env, json.Unmarshal(plaintext, out)
+	}
+}
+
+// session returns the API user's session, logging in when it has none or
+// its token is about to expire
+func (c *nicClient) session(ctx context.Context, creds Credentials) (*session, error) {
+	key := creds.GSTIN + "/" + creds.Username
+	c.mu.Lock()
+	sess := c.sessions[key]
+	c.mu.Unlock()
+	if sess != nil && time.Now().Add(10*time.Minute).Before(sess.expiresAt) {
+		return sess, nil
+	}
+
+	appKey := make([]byte, 32)
+	if _, err := rand.Read(appKey); err != nil {
+		return nil, err
+	}
+	login, err := json.Marshal(map[string]interface{}{
+		"UserName":                creds.Username,
+		"Password":                creds.Password,
+		"AppKey":                  base64.StdEncoding.EncodeToString(appKey),
+		"ForceRefreshAccessToken": sess != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := rsaEncrypt(c.publicKey, login)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := c.send(ctx, http.MethodPost, authPath, c.header(creds), map[string]string{"Data": data})
+	if err != nil {
+		return nil, err
+	}
+	if err := env.err(); err != nil {
+		return nil, err
+	}
+
+	// Login responses are not encrypted, other than the SEK
+	var auth struct {
+		AuthToken   string `json:"AuthToken"`
+		Sek         string `json:"Sek"`
+		TokenExpiry string `json:"TokenExpiry"`
+	}
+	if err := json.Unmarshal([]byte(env.Data), &auth); err != nil {
+		return nil, fmt.Errorf("einvoice: decode login: %w", err)
+	}
+	sek, err := aesDecrypt(appKey, auth.Sek)
+	if err != nil {
+		return nil, fmt.Errorf("einvoice: decrypt session key: %w", err)
+	}
+	expiresAt, err := time.ParseInLocation(irpTimestamp, auth.TokenExpiry, ist)
+	if err != nil {
+		// Tokens last six hours
+		expiresAt = time.Now().Add(6 * time.Hour)
+	}
+
+	sess = &session{token: auth.AuthToken, sek: sek, expiresAt: expiresAt}
+	c.mu.Lock()
+	c.sessions[key] = sess
+	c.mu.Unlock()
+	return sess, nil
+}
+
+func (c *nicClient) forget(creds Credentials) {
+	c.mu.Lock()
+	delete(c.sessions, creds.GSTIN+"/"+creds.Username)
+	c.mu.Unlock()
+}
+
+func (c *nicClient) header(creds Credentials) http.Header {
+	header := http.Header{}
+	header.Set("client_id", c.clientID)
+	header.Set("client_secret", c.clientSecret)
+	header.Set("Gstin", creds.GSTIN)
+	return header
+}
+
+// send makes a request and decodes the IRP's envelope. Failures to reach
+// the IRP or get an answer from it are reported as ErrUnavailable.
+func (c *nicClient) send(ctx context.Context, method, path string, header http.Header, body interface{}) (*envelope, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, payload)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: %s returned %d", ErrUnavailable, path, resp.StatusCode)
+	}
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, fmt.Errorf("%w: decode response: %v", ErrUnavailable, err)
+	}
+	return &env, nil
+}
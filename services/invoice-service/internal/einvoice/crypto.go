@@ -0,0 +1,98 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The IRP encrypts with the schemes of its API specification: logins with
+// its RSA public key, and payloads with AES-256 in ECB mode under the
+// session key (SEK) it hands out at login.
+
+// parsePublicKey reads the IRP public key, given as PEM or as the bare
+// base64 the portal publishes
+func parsePublicKey(key string) (*rsa.PublicKey, error) {
+	key = strings.TrimSpace(key)
+	var der []byte
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("einvoice: public key is neither PEM nor base64: %w", err)
+		}
+		der = decoded
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("einvoice: parse public key: %w", err)
+	}
+	publicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("einvoice: public key is not an RSA key")
+	}
+	return publicKey, nil
+}
+
+func rsaEncrypt(publicKey *rsa.PublicKey, plaintext []byte) (string, error) {
+	ciphertext, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// aesEncrypt encrypts plaintext with AES in ECB mode and PKCS#7 padding,
+// returning base64
+func aesEncrypt(key, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	size := block.BlockSize()
+	padding := size - len(plaintext)%size
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, len(padded))
+	for start := 0; start < len(padded); start += size {
+		block.Encrypt(ciphertext[start:start+size], padded[start:start+size])
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// aesDecrypt reverses aesEncrypt
+func aesDecrypt(key []byte, encoded string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	size := block.BlockSize()
+	if len(ciphertext) == 0 || len(ciphertext)%size != 0 {
+		return nil, errors.New("einvoice: ciphertext is not a whole number of blocks")
+	}
+	plaintext := make([]byte, len(ciphertext))
+	for start := 0; start < len(ciphertext); start += size {
+		block.Decrypt(plaintext[start:start+size], ciphertext[start:start+size])
+	}
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > size || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("einvoice: invalid padding")
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
@@ -0,0 +1,124 @@
+// Package einvoice reports invoices to the GST Invoice Registration Portal
+// (IRP): it builds the INV-01 e-invoice schema and calls the NIC API, or a
+// GSP serving the same API, to register invoices and cancel their IRNs.
+package einvoice
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// SchemaVersion is the version of the INV-01 schema the documents follow
+const SchemaVersion = "1.1"
+
+// Supply types of an e-invoice
+const (
+	SupplyB2B    = "B2B"
+	SupplyExpWP  = "EXPWP"  // Export with payment of IGST
+	SupplyExpWOP = "EXPWOP" // Export under bond or LUT
+)
+
+// Amount is a value in the schema, written as a JSON number with two
+// decimals
+type Amount decimal.Decimal
+
+// MarshalJSON implements json.Marshaler
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(decimal.Decimal(a).StringFixed(2)), nil
+}
+
+// Quantity is a quantity or unit price in the schema, written as a JSON
+// number with three decimals
+type Quantity decimal.Decimal
+
+// MarshalJSON implements json.Marshaler
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(decimal.Decimal(q).StringFixed(3)), nil
+}
+
+// Document is an invoice in the INV-01 schema
+type Document struct {
+	Version    string         `json:"Version"`
+	TranDtls   TranDetails    `json:"TranDtls"`
+	DocDtls    DocDetails     `json:"DocDtls"`
+	SellerDtls Party          `json:"SellerDtls"`
+	BuyerDtls  Party          `json:"BuyerDtls"`
+	ItemList   []Item         `json:"ItemList"`
+	ValDtls    ValueDetails   `json:"ValDtls"`
+	ExpDtls    *ExportDetails `json:"ExpDtls,omitempty"`
+}
+
+// TranDetails describes the supply
+type TranDetails struct {
+	TaxSch      string `json:"TaxSch"` // Always GST
+	SupTyp      string `json:"SupTyp"`
+	RegRev      string `json:"RegRev"`      // Y under reverse charge
+	IgstOnIntra string `json:"IgstOnIntra"` // Y for IGST on an intra-state supply
+}
+
+// DocDetails identifies the document
+type DocDetails struct {
+	Typ string `json:"Typ"` // INV, CRN or DBN
+	No  string `json:"No"`
+	Dt  string `json:"Dt"` // DD/MM/YYYY
+}
+
+// Party is the seller or buyer
+type Party struct {
+	Gstin string `json:"Gstin"` // URP for unregistered buyers abroad
+	LglNm string `json:"LglNm"`
+	TrdNm string `json:"TrdNm,omitempty"`
+	Pos   string `json:"Pos,omitempty"` // Place of supply state code, buyer only
+	Addr1 string `json:"Addr1"`
+	Addr2 string `json:"Addr2,omitempty"`
+	Loc   string `json:"Loc"`
+	Pin   int    `json:"Pin"`
+	Stcd  string `json:"Stcd"`
+	Ph    string `json:"Ph,omitempty"`
+	Em    string `json:"Em,omitempty"`
+}
+
+// Item is a line of the invoice
+type Item struct {
+	SlNo          string   `json:"SlNo"`
+	PrdDesc       string   `json:"PrdDesc,omitempty"`
+	IsServc       string   `json:"IsServc"` // Y or N
+	HsnCd         string   `json:"HsnCd"`
+	Qty           Quantity `json:"Qty"`
+	Unit          string   `json:"Unit,omitempty"` // GST UQC
+	UnitPrice     Quantity `json:"UnitPrice"`
+	TotAmt        Amount   `json:"TotAmt"`
+	Discount      Amount   `json:"Discount"`
+	AssAmt        Amount   `json:"AssAmt"`
+	GstRt         Amount   `json:"GstRt"`
+	IgstAmt       Amount   `json:"IgstAmt"`
+	CgstAmt       Amount   `json:"CgstAmt"`
+	SgstAmt       Amount   `json:"SgstAmt"`
+	CesRt         Amount   `json:"CesRt"`
+	CesAmt        Amount   `json:"CesAmt"`
+	CesNonAdvlAmt Amount   `json:"CesNonAdvlAmt"`
+	TotItemVal    Amount   `json:"TotItemVal"`
+}
+
+// ValueDetails are the invoice totals
+type ValueDetails struct {
+	AssVal      Amount  `json:"AssVal"`
+	CgstVal     Amount  `json:"CgstVal"`
+	SgstVal     Amount  `json:"SgstVal"`
+	IgstVal     Amount  `json:"IgstVal"`
+	CesVal      Amount  `json:"CesVal"`
+	Discount    Amount  `json:"Discount"` // Off the invoice after tax
+	OthChrg     Amount  `json:"OthChrg"`  // Such as TCS
+	RndOffAmt   Amount  `json:"RndOffAmt"`
+	TotInvVal   Amount  `json:"TotInvVal"`
+	TotInvValFc *Amount `json:"TotInvValFc,omitempty"` // In the foreign currency
+}
+
+// ExportDetails are the shipping details of an export invoice
+type ExportDetails struct {
+	ShipBNo string `json:"ShipBNo,omitempty"`
+	ShipBDt string `json:"ShipBDt,omitempty"`
+	Port    string `json:"Port,omitempty"`
+	RefClm  string `json:"RefClm"` // Y when a refund of the IGST paid is claimed
+	ForCur  string `json:"ForCur,omitempty"`
+	CntCode string `json:"CntCode,omitempty"`
+}
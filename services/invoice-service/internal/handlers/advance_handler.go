@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// AdvanceHandler handles advance receipt and refund voucher endpoints
+type AdvanceHandler struct {
+	advanceService services.AdvanceService
+}
+
+// NewAdvanceHandler creates a new advance handler
+func NewAdvanceHandler(advanceService services.AdvanceService) *AdvanceHandler {
+	return &AdvanceHandler{advanceService: advanceService}
+}
+
+// Create records an advance received from a customer and issues its
+// receipt voucher
+func (h *AdvanceHandler) Create(c *gin.Context) {
+	var req services.CreateAdvanceReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	advance, err := h.advanceService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to record advance")
+		return
+	}
+
+	response.Created(c, advance)
+}
+
+// List returns advance receipts
+func (h *AdvanceHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.AdvanceFilters{
+		Status:   c.Query("status"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+	}
+	if customerID := c.Query("customer_id"); customerID != "" {
+		id, err := uuid.Parse(customerID)
+		if err != nil {
+			response.BadRequest(c, "Invalid customer ID", nil)
+			return
+		}
+		filters.CustomerID = id
+	}
+
+	advances, err := h.advanceService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list advances")
+		return
+	}
+
+	response.Success(c, advances)
+}
+
+// Get returns an advance receipt with its refund vouchers
+func (h *AdvanceHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid advance ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	advance, err := h.advanceService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get advance")
+		return
+	}
+
+	response.Success(c, advance)
+}
+
+// Refund refunds all or part of an advance and issues a refund voucher
+func (h *AdvanceHandler) Refund(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid advance ID", nil)
+		return
+	}
+
+	var req services.RefundAdvanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	voucher, err := h.advanceService.Refund(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to refund advance")
+		return
+	}
+
+	response.Created(c, voucher)
+}
+
+// ReturnSummary returns the tax on advances received and refunded in a
+// return period, for GSTR-1 tables 11A and 11B and GSTR-3B
+func (h *AdvanceHandler) ReturnSummary(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	summary, err := h.advanceService.ReturnSummary(c.Request.Context(), tenantID, c.Query("period"))
+	if err != nil {
+		h.handleError(c, err, "Failed to summarise advances")
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// Helper methods
+
+func (h *AdvanceHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrAdvanceNotFound:
+		response.NotFound(c, "Advance not found")
+	case services.ErrInvalidAdvance, services.ErrInvalidRefund, services.ErrInvalidPeriod:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrAdvanceRefunded, services.ErrAdvanceRefundRaced:
+		response.Conflict(c, err.Error())
+	case services.ErrLedgerUnavailable:
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *AdvanceHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *AdvanceHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
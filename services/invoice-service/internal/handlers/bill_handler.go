@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
@@ -12,12 +17,51 @@ import (
 
 // BillHandler handles bill endpoints
 type BillHandler struct {
-	billService services.BillService
+	billService       services.BillService
+	documentTemplates services.DocumentTemplateService
+
+	// Payments above stepUpAmount need the user to have entered their
+	// password or MFA code within stepUpMaxAge. Zero turns the check off.
+	stepUpAmount decimal.Decimal
+	stepUpMaxAge time.Duration
+}
+
+// AuditedYearOnly hides bills outside an auditor's financial year from the
+// routes that load one by ID, as those carry no date filter to hold to the
+// year
+func (h *BillHandler) AuditedYearOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !middleware.IsAuditor(c) || c.Param("id") == "" {
+			c.Next()
+			return
+		}
+
+		billID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			response.BadRequest(c, "Invalid bill ID", nil)
+			c.Abort()
+			return
+		}
+
+		bill, err := h.billService.Get(c.Request.Context(), billID)
+		if err != nil || bill.TenantID.String() != c.GetString("tenant_id") ||
+			!middleware.WithinAuditorPeriod(c, bill.BillDate) {
+			response.NotFound(c, "Bill not found")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
 }
 
 // NewBillHandler creates a new bill handler
-func NewBillHandler(billService services.BillService) *BillHandler {
-	return &BillHandler{billService: billService}
+func NewBillHandler(billService services.BillService, documentTemplates services.DocumentTemplateService, stepUpAmount decimal.Decimal, stepUpMaxAge time.Duration) *BillHandler {
+	return &BillHandler{
+		billService:       billService,
+		documentTemplates: documentTemplates,
+		stepUpAmount:      stepUpAmount,
+		stepUpMaxAge:      stepUpMaxAge,
+	}
 }
 
 // List returns a list of bills
@@ -64,9 +108,61 @@ func (h *BillHandler) Create(c *gin.Context) {
 	userID, _ := h.getUserIDFromContext(c)
 	req.TenantID = tenantID
 	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+	h.create(c, req)
+}
+
+// Duplicate creates a bill for the vendor, lines and tax treatment of an
+// earlier one. The vendor's bill number is taken from the body, never
+// copied.
+func (h *BillHandler) Duplicate(c *gin.Context) {
+	billID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bill ID", nil)
+		return
+	}
+	req, ok := bindCopyRequest(c)
+	if !ok {
+		return
+	}
+
+	create, err := h.documentTemplates.DuplicateBill(c.Request.Context(), billID, req)
+	if err != nil {
+		handleCopyError(c, err)
+		return
+	}
+	h.create(c, *create)
+}
+
+// CreateFromTemplate creates a bill from a saved bill template
+func (h *BillHandler) CreateFromTemplate(c *gin.Context) {
+	templateID, err := uuid.Parse(c.Param("template_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+	req, ok := bindCopyRequest(c)
+	if !ok {
+		return
+	}
+
+	create, err := h.documentTemplates.BillFromTemplate(c.Request.Context(), templateID, req)
+	if err != nil {
+		handleCopyError(c, err)
+		return
+	}
+	h.create(c, *create)
+}
 
+func (h *BillHandler) create(c *gin.Context, req services.CreateBillRequest) {
 	bill, err := h.billService.Create(c.Request.Context(), req)
 	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
 		if err == services.ErrInvalidBill {
 			response.BadRequest(c, "Invalid bill data", nil)
 			return
@@ -109,8 +205,16 @@ func (h *BillHandler) Update(c *gin.Context) {
 		return
 	}
 
+	req.Authorization = c.GetHeader("Authorization")
+
 	bill, err := h.billService.Update(c.Request.Context(), billID, req)
 	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
 		if err == services.ErrBillNotFound {
 			response.NotFound(c, "Bill not found")
 			return
@@ -119,6 +223,10 @@ func (h *BillHandler) Update(c *gin.Context) {
 			response.Conflict(c, "Cannot modify bill in current status")
 			return
 		}
+		if err == services.ErrInvalidBill {
+			response.BadRequest(c, "Invalid bill data", nil)
+			return
+		}
 		response.InternalError(c, "Failed to update bill")
 		return
 	}
@@ -134,7 +242,10 @@ func (h *BillHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.billService.Delete(c.Request.Context(), billID); err != nil {
+	if err := h.billService.Delete(c.Request.Context(), billID, c.GetHeader("Authorization")); err != nil {
+		if periodLocked(c, err) {
+			return
+		}
 		if err == services.ErrBillNotFound {
 			response.NotFound(c, "Bill not found")
 			return
@@ -150,7 +261,8 @@ func (h *BillHandler) Delete(c *gin.Context) {
 	response.NoContent(c)
 }
 
-// Approve approves a bill for payment
+// Approve approves a bill for payment. A bill breaking the expense policy
+// needs a policy_override_reason.
 func (h *BillHandler) Approve(c *gin.Context) {
 	billID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -158,10 +270,22 @@ func (h *BillHandler) Approve(c *gin.Context) {
 		return
 	}
 
+	// The body is optional when the bill keeps to the expense policy
+	var req struct {
+		PolicyOverrideReason string `json:"policy_override_reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
 	userID, _ := h.getUserIDFromContext(c)
 
-	bill, err := h.billService.Approve(c.Request.Context(), billID, userID)
+	bill, err := h.billService.Approve(c.Request.Context(), billID, userID, req.PolicyOverrideReason, c.GetHeader("Authorization"))
 	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
 		if err == services.ErrBillNotFound {
 			response.NotFound(c, "Bill not found")
 			return
@@ -170,6 +294,14 @@ func (h *BillHandler) Approve(c *gin.Context) {
 			response.Conflict(c, "Cannot approve bill in current status")
 			return
 		}
+		if err == services.ErrPolicyOverrideRequired {
+			response.Conflict(c, err.Error())
+			return
+		}
+		if err == services.ErrDocumentNotPosted {
+			response.ServiceUnavailable(c, err.Error())
+			return
+		}
 		response.InternalError(c, "Failed to approve bill")
 		return
 	}
@@ -191,18 +323,33 @@ func (h *BillHandler) RecordPayment(c *gin.Context) {
 		return
 	}
 
+	if h.stepUpAmount.IsPositive() && req.Amount.GreaterThan(h.stepUpAmount) &&
+		!middleware.RecentlyAuthenticated(c, h.stepUpMaxAge) {
+		middleware.AbortStepUpRequired(c, h.stepUpMaxAge)
+		return
+	}
+
 	tenantID, _ := h.getTenantIDFromContext(c)
 	userID, _ := h.getUserIDFromContext(c)
 	req.TenantID = tenantID
 	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
 
 	payment, err := h.billService.RecordPayment(c.Request.Context(), billID, req)
 	if err != nil {
-		if err == services.ErrBillNotFound {
+		switch err {
+		case services.ErrBillNotFound:
 			response.NotFound(c, "Bill not found")
-			return
+		case services.ErrInvalidBill, services.ErrTDSSectionRequired:
+			response.BadRequest(c, err.Error(), nil)
+		case services.ErrVendorPaymentHold, services.ErrCashLimitExceeded:
+			response.Conflict(c, err.Error())
+		case services.ErrTDSUnavailable, services.ErrLedgerUnavailable, services.ErrPaymentHoldUnknown:
+			response.ServiceUnavailable(c, err.Error())
+		default:
+			response.InternalError(c, "Failed to record payment")
 		}
-		response.InternalError(c, "Failed to record payment")
 		return
 	}
 
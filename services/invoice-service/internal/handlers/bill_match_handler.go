@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// BillMatchHandler handles the bill match report and its settings
+type BillMatchHandler struct {
+	matchService services.BillMatchService
+}
+
+// NewBillMatchHandler creates a new bill match handler
+func NewBillMatchHandler(matchService services.BillMatchService) *BillMatchHandler {
+	return &BillMatchHandler{matchService: matchService}
+}
+
+// GetSettings returns the tenant's bill match tolerances
+func (h *BillMatchHandler) GetSettings(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	settings, err := h.matchService.GetSettings(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get bill match settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// UpdateSettings configures the bill match tolerances
+func (h *BillMatchHandler) UpdateSettings(c *gin.Context) {
+	var req services.UpdateBillMatchSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	settings, err := h.matchService.UpdateSettings(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		if err == services.ErrInvalidBillMatchSettings {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to update bill match settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// Report lists bills that don't match their payments beyond the tenant's
+// tolerances, optionally for one vendor and a range of bill dates
+func (h *BillMatchHandler) Report(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.BillMatchFilters{
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+	}
+	for _, date := range []string{filters.FromDate, filters.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			response.BadRequest(c, "Invalid date, use YYYY-MM-DD", nil)
+			return
+		}
+	}
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		id, err := uuid.Parse(vendorID)
+		if err != nil {
+			response.BadRequest(c, "Invalid vendor ID", nil)
+			return
+		}
+		filters.VendorID = id
+	}
+
+	report, err := h.matchService.Report(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to generate bill match report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *BillMatchHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *BillMatchHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
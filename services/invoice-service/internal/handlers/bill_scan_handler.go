@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/ocr"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// BillScanHandler drafts bills from uploaded vendor bills
+type BillScanHandler struct {
+	scanService services.BillScanService
+}
+
+// NewBillScanHandler creates a new bill scan handler
+func NewBillScanHandler(scanService services.BillScanService) *BillScanHandler {
+	return &BillScanHandler{scanService: scanService}
+}
+
+// Scan reads a vendor's bill uploaded as a PDF or image (multipart field
+// "file") and returns a draft of the bill to confirm and save with the
+// create bill endpoint
+func (h *BillScanHandler) Scan(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file uploaded", nil)
+		return
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so oversized files are rejected by the
+	// service rather than silently truncated
+	content, err := io.ReadAll(io.LimitReader(file, services.MaxBillScanSize+1))
+	if err != nil {
+		response.BadRequest(c, "Failed to read the uploaded file", nil)
+		return
+	}
+
+	scan, err := h.scanService.Scan(c.Request.Context(), c.GetHeader("Authorization"), ocr.Document{
+		FileName:    header.Filename,
+		ContentType: http.DetectContentType(content),
+		Content:     content,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, scan)
+}
+
+func (h *BillScanHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBillScanTooLarge), errors.Is(err, services.ErrBillScanUnsupportedType):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, ocr.ErrUnreadable):
+		response.ValidationError(c, err.Error(), nil)
+	case errors.Is(err, services.ErrBillScanUnavailable):
+		response.ServiceUnavailable(c, "Bill scanning is not available")
+	case errors.Is(err, ocr.ErrUnavailable):
+		response.ServiceUnavailable(c, "The document could not be read right now; try again shortly")
+	default:
+		response.InternalError(c, "Failed to scan bill")
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// CashLimitHandler handles the cash limit settings and the report of
+// flagged cash payments and receipts
+type CashLimitHandler struct {
+	limitService services.CashLimitService
+}
+
+// NewCashLimitHandler creates a new cash limit handler
+func NewCashLimitHandler(limitService services.CashLimitService) *CashLimitHandler {
+	return &CashLimitHandler{limitService: limitService}
+}
+
+// GetSettings returns whether the tenant warns on or blocks cash over the
+// limits
+func (h *CashLimitHandler) GetSettings(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	settings, err := h.limitService.GetSettings(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get cash limit settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// UpdateSettings sets whether cash over the limits is warned on or blocked
+func (h *CashLimitHandler) UpdateSettings(c *gin.Context) {
+	var req services.UpdateCashLimitSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	settings, err := h.limitService.UpdateSettings(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		if err == services.ErrInvalidCashLimitSettings {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to update cash limit settings")
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// Report lists the flagged cash payments and receipts, optionally for one
+// section and a range of payment dates
+func (h *CashLimitHandler) Report(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.CashLimitFilters{
+		Section:  c.Query("section"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+	}
+	if filters.Section != "" && filters.Section != models.CashSection40A3 && filters.Section != models.CashSection269ST {
+		response.BadRequest(c, "Invalid section", nil)
+		return
+	}
+	for _, date := range []string{filters.FromDate, filters.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			response.BadRequest(c, "Invalid date, use YYYY-MM-DD", nil)
+			return
+		}
+	}
+
+	report, err := h.limitService.Report(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to generate cash limit report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *CashLimitHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *CashLimitHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
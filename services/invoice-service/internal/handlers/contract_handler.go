@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// ContractHandler handles contract endpoints
+type ContractHandler struct {
+	contractService services.ContractService
+}
+
+// NewContractHandler creates a new contract handler
+func NewContractHandler(contractService services.ContractService) *ContractHandler {
+	return &ContractHandler{contractService: contractService}
+}
+
+// LinkRecurringInvoiceRequest represents a request to link a recurring
+// invoice to a contract
+type LinkRecurringInvoiceRequest struct {
+	RecurringInvoiceID uuid.UUID `json:"recurring_invoice_id" binding:"required"`
+}
+
+// Create creates a contract
+func (h *ContractHandler) Create(c *gin.Context) {
+	var req services.ContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	contract, err := h.contractService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create contract")
+		return
+	}
+
+	response.Created(c, contract)
+}
+
+// List returns contracts, optionally filtered by party and status
+func (h *ContractHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.ContractFilters{
+		Status: c.Query("status"),
+		Search: c.Query("search"),
+	}
+	if partyID := c.Query("party_id"); partyID != "" {
+		id, err := uuid.Parse(partyID)
+		if err != nil {
+			response.BadRequest(c, "Invalid party ID", nil)
+			return
+		}
+		filters.PartyID = id
+	}
+
+	contracts, err := h.contractService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list contracts")
+		return
+	}
+
+	response.Success(c, contracts)
+}
+
+// Get returns a contract with its linked recurring invoices
+func (h *ContractHandler) Get(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	contract, err := h.contractService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get contract")
+		return
+	}
+
+	response.Success(c, contract)
+}
+
+// Update updates a contract
+func (h *ContractHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	var req services.ContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	req.TenantID = tenantID
+
+	contract, err := h.contractService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update contract")
+		return
+	}
+
+	response.Success(c, contract)
+}
+
+// Delete deletes a contract and unlinks its recurring invoices
+func (h *ContractHandler) Delete(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	if err := h.contractService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete contract")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Contract deleted successfully"})
+}
+
+// Renew extends a contract for another term
+func (h *ContractHandler) Renew(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	// The body is optional; without one the renewal term applies
+	var req services.RenewContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	contract, err := h.contractService.Renew(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to renew contract")
+		return
+	}
+
+	response.Success(c, contract)
+}
+
+// Terminate ends a contract and cancels its recurring invoices
+func (h *ContractHandler) Terminate(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	contract, err := h.contractService.Terminate(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to terminate contract")
+		return
+	}
+
+	response.Success(c, contract)
+}
+
+// LinkRecurringInvoice links a recurring invoice to a contract
+func (h *ContractHandler) LinkRecurringInvoice(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	var req LinkRecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	contract, err := h.contractService.LinkRecurringInvoice(c.Request.Context(), tenantID, id, req.RecurringInvoiceID)
+	if err != nil {
+		h.handleError(c, err, "Failed to link recurring invoice")
+		return
+	}
+
+	response.Success(c, contract)
+}
+
+// UnlinkRecurringInvoice removes a recurring invoice from a contract
+func (h *ContractHandler) UnlinkRecurringInvoice(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid contract ID", nil)
+		return
+	}
+
+	recurringID, err := uuid.Parse(c.Param("recurring_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid recurring invoice ID", nil)
+		return
+	}
+
+	contract, err := h.contractService.UnlinkRecurringInvoice(c.Request.Context(), tenantID, id, recurringID)
+	if err != nil {
+		h.handleError(c, err, "Failed to unlink recurring invoice")
+		return
+	}
+
+	response.Success(c, contract)
+}
+
+// RunReminders sends the renewal reminders due today, or on the as_of date
+func (h *ContractHandler) RunReminders(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	asOf, ok := h.parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.contractService.RunReminders(c.Request.Context(), tenantID, asOf)
+	if err != nil {
+		response.InternalError(c, "Failed to run contract reminders")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ExpiringReport lists contracts ending in the next 90 days, or the given
+// number of days
+func (h *ContractHandler) ExpiringReport(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	asOf, ok := h.parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days <= 0 || days > 3650 {
+		response.BadRequest(c, "Invalid days", nil)
+		return
+	}
+
+	report, err := h.contractService.ExpiringReport(c.Request.Context(), tenantID, asOf, days)
+	if err != nil {
+		response.InternalError(c, "Failed to generate expiring contracts report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *ContractHandler) parseAsOf(c *gin.Context) (time.Time, bool) {
+	asOfStr := c.Query("as_of")
+	if asOfStr == "" {
+		return time.Now(), true
+	}
+	asOf, err := time.Parse("2006-01-02", asOfStr)
+	if err != nil {
+		response.BadRequest(c, "Invalid as_of date", nil)
+		return time.Time{}, false
+	}
+	return asOf, true
+}
+
+func (h *ContractHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrContractNotFound:
+		response.NotFound(c, "Contract not found")
+	case services.ErrRecurringInvoiceNotFound:
+		response.NotFound(c, "Recurring invoice not found")
+	case services.ErrInvalidContract:
+		response.BadRequest(c, "Invalid contract data", nil)
+	case services.ErrContractClosed:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *ContractHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *ContractHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
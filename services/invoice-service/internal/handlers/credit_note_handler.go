@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// CreditNoteHandler handles credit note endpoints
+type CreditNoteHandler struct {
+	creditNoteService services.CreditNoteService
+}
+
+// NewCreditNoteHandler creates a new credit note handler
+func NewCreditNoteHandler(creditNoteService services.CreditNoteService) *CreditNoteHandler {
+	return &CreditNoteHandler{creditNoteService: creditNoteService}
+}
+
+// List returns the tenant's credit notes
+func (h *CreditNoteHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.CreditNoteFilters{
+		Status:   c.Query("status"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+		Page:     1,
+		Limit:    20,
+	}
+	if customerID := c.Query("customer_id"); customerID != "" {
+		if cid, err := uuid.Parse(customerID); err == nil {
+			filters.CustomerID = cid
+		}
+	}
+	if invoiceID := c.Query("invoice_id"); invoiceID != "" {
+		if iid, err := uuid.Parse(invoiceID); err == nil {
+			filters.InvoiceID = iid
+		}
+	}
+
+	notes, total, err := h.creditNoteService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list credit notes")
+		return
+	}
+
+	response.Paginated(c, notes, filters.Page, filters.Limit, total)
+}
+
+// Create creates a draft credit note
+func (h *CreditNoteHandler) Create(c *gin.Context) {
+	var req services.CreateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	note, err := h.creditNoteService.Create(c.Request.Context(), req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to create credit note")
+		return
+	}
+
+	response.Created(c, note)
+}
+
+// Get returns a credit note with its items and applications
+func (h *CreditNoteHandler) Get(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.creditNoteService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get credit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Update edits a draft credit note
+func (h *CreditNoteHandler) Update(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.UpdateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.Authorization = c.GetHeader("Authorization")
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.creditNoteService.Update(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to update credit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Delete deletes a draft credit note
+func (h *CreditNoteHandler) Delete(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.creditNoteService.Delete(c.Request.Context(), tenantID, id, c.GetHeader("Authorization")); err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to delete credit note")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Credit note deleted"})
+}
+
+// Approve issues a draft credit note
+func (h *CreditNoteHandler) Approve(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	note, err := h.creditNoteService.Approve(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to approve credit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Cancel cancels a credit note that has not been applied
+func (h *CreditNoteHandler) Cancel(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.creditNoteService.Cancel(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to cancel credit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Apply applies an approved credit note across one or more open invoices
+func (h *CreditNoteHandler) Apply(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ApplyCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.AppliedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	note, err := h.creditNoteService.Apply(c.Request.Context(), id, req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to apply credit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// RemoveApplication takes an application of a credit note back off its
+// invoice
+func (h *CreditNoteHandler) RemoveApplication(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	applicationID, err := uuid.Parse(c.Param("application_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid application ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.creditNoteService.RemoveApplication(c.Request.Context(), tenantID, id, applicationID, c.GetHeader("Authorization"))
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to remove credit note application")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// ForReturnPeriod returns the credit notes issued in a month, for
+// ?period=MMYYYY, as reported in the credit/debit note sections of GSTR-1
+func (h *CreditNoteHandler) ForReturnPeriod(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	notes, err := h.creditNoteService.ForReturnPeriod(c.Request.Context(), tenantID, c.Query("period"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReturnPeriod) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to list the month's credit notes")
+		return
+	}
+
+	response.Success(c, notes)
+}
+
+// Helper methods
+
+func (h *CreditNoteHandler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid credit note ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *CreditNoteHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrCreditNoteNotFound):
+		response.NotFound(c, "Credit note not found")
+	case errors.Is(err, services.ErrCreditApplicationMissing):
+		response.NotFound(c, "Credit note application not found")
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		response.NotFound(c, "Invoice not found")
+	case errors.Is(err, services.ErrInvalidCreditNote), errors.Is(err, services.ErrInvalidCreditApplication):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrCreditNoteNotEditable), errors.Is(err, services.ErrCreditNoteNotApprovable),
+		errors.Is(err, services.ErrCreditNoteNotCancelable), errors.Is(err, services.ErrCreditNoteNotApplicable),
+		errors.Is(err, services.ErrCreditApplicationRaced):
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *CreditNoteHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *CreditNoteHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// CreditScoreHandler handles customer credit score endpoints
+type CreditScoreHandler struct {
+	creditScoreService services.CreditScoreService
+}
+
+// NewCreditScoreHandler creates a new credit score handler
+func NewCreditScoreHandler(creditScoreService services.CreditScoreService) *CreditScoreHandler {
+	return &CreditScoreHandler{creditScoreService: creditScoreService}
+}
+
+// List returns the scored customers, worst first, optionally of one grade
+func (h *CreditScoreHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	scores, err := h.creditScoreService.List(c.Request.Context(), tenantID, c.Query("grade"))
+	if err != nil {
+		response.InternalError(c, "Failed to list credit scores")
+		return
+	}
+
+	response.Success(c, scores)
+}
+
+// Get returns a customer's credit score and the indicators behind it
+func (h *CreditScoreHandler) Get(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	score, err := h.creditScoreService.Get(c.Request.Context(), tenantID, customerID)
+	if err != nil {
+		response.InternalError(c, "Failed to get credit score")
+		return
+	}
+
+	response.Success(c, score)
+}
+
+// Recalculate rescores all of the tenant's customers now
+func (h *CreditScoreHandler) Recalculate(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	scored, err := h.creditScoreService.Recalculate(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to recalculate credit scores")
+		return
+	}
+
+	response.Success(c, gin.H{"customers_scored": scored})
+}
+
+func (h *CreditScoreHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// DebitNoteHandler handles debit note endpoints
+type DebitNoteHandler struct {
+	debitNoteService services.DebitNoteService
+}
+
+// NewDebitNoteHandler creates a new debit note handler
+func NewDebitNoteHandler(debitNoteService services.DebitNoteService) *DebitNoteHandler {
+	return &DebitNoteHandler{debitNoteService: debitNoteService}
+}
+
+// List returns the tenant's debit notes
+func (h *DebitNoteHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.DebitNoteFilters{
+		Status:   c.Query("status"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+		Page:     1,
+		Limit:    20,
+	}
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		if vid, err := uuid.Parse(vendorID); err == nil {
+			filters.VendorID = vid
+		}
+	}
+	if billID := c.Query("bill_id"); billID != "" {
+		if bid, err := uuid.Parse(billID); err == nil {
+			filters.BillID = bid
+		}
+	}
+
+	notes, total, err := h.debitNoteService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list debit notes")
+		return
+	}
+
+	response.Paginated(c, notes, filters.Page, filters.Limit, total)
+}
+
+// Create creates a draft debit note against a bill
+func (h *DebitNoteHandler) Create(c *gin.Context) {
+	var req services.CreateDebitNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	note, err := h.debitNoteService.Create(c.Request.Context(), req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to create debit note")
+		return
+	}
+
+	response.Created(c, note)
+}
+
+// Get returns a debit note with its items
+func (h *DebitNoteHandler) Get(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.debitNoteService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get debit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Update edits a draft debit note
+func (h *DebitNoteHandler) Update(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.UpdateDebitNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.Authorization = c.GetHeader("Authorization")
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.debitNoteService.Update(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to update debit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Delete deletes a draft debit note
+func (h *DebitNoteHandler) Delete(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.debitNoteService.Delete(c.Request.Context(), tenantID, id, c.GetHeader("Authorization")); err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to delete debit note")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Debit note deleted"})
+}
+
+// Issue issues a draft debit note, reversing its input tax credit and
+// setting it off against the bill
+func (h *DebitNoteHandler) Issue(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	note, err := h.debitNoteService.Issue(c.Request.Context(), tenantID, id, userID, c.GetHeader("Authorization"))
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to issue debit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// Cancel cancels a draft or issued debit note
+func (h *DebitNoteHandler) Cancel(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	note, err := h.debitNoteService.Cancel(c.Request.Context(), tenantID, id, c.GetHeader("Authorization"))
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to cancel debit note")
+		return
+	}
+
+	response.Success(c, note)
+}
+
+// ForReturnPeriod returns the debit notes issued in a month, for
+// ?period=MMYYYY, as reported in the credit/debit note sections of GSTR-1
+func (h *DebitNoteHandler) ForReturnPeriod(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	notes, err := h.debitNoteService.ForReturnPeriod(c.Request.Context(), tenantID, c.Query("period"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReturnPeriod) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to list the month's debit notes")
+		return
+	}
+
+	response.Success(c, notes)
+}
+
+// Helper methods
+
+func (h *DebitNoteHandler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid debit note ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *DebitNoteHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrDebitNoteNotFound):
+		response.NotFound(c, "Debit note not found")
+	case errors.Is(err, services.ErrBillNotFound):
+		response.NotFound(c, "Bill not found")
+	case errors.Is(err, services.ErrInvalidDebitNote):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrDebitNoteNotEditable), errors.Is(err, services.ErrDebitNoteNotIssuable),
+		errors.Is(err, services.ErrDebitNoteNotCancelable), errors.Is(err, services.ErrBillNotDebitable),
+		errors.Is(err, services.ErrDebitNoteRaced):
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *DebitNoteHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *DebitNoteHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/documents"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// DeliveryChallanHandler handles delivery challan endpoints
+type DeliveryChallanHandler struct {
+	challanService services.DeliveryChallanService
+	tenantClient   clients.TenantClient
+}
+
+// NewDeliveryChallanHandler creates a new delivery challan handler
+func NewDeliveryChallanHandler(challanService services.DeliveryChallanService, tenantClient clients.TenantClient) *DeliveryChallanHandler {
+	return &DeliveryChallanHandler{challanService: challanService, tenantClient: tenantClient}
+}
+
+// List returns the tenant's delivery challans
+func (h *DeliveryChallanHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.DeliveryChallanFilters{
+		Status:   c.Query("status"),
+		Purpose:  c.Query("purpose"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+		Page:     1,
+		Limit:    20,
+	}
+	if customerID := c.Query("customer_id"); customerID != "" {
+		if cid, err := uuid.Parse(customerID); err == nil {
+			filters.CustomerID = cid
+		}
+	}
+	if invoiceID := c.Query("invoice_id"); invoiceID != "" {
+		if iid, err := uuid.Parse(invoiceID); err == nil {
+			filters.InvoiceID = iid
+		}
+	}
+
+	challans, total, err := h.challanService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list delivery challans")
+		return
+	}
+
+	response.Paginated(c, challans, filters.Page, filters.Limit, total)
+}
+
+// Create creates a draft delivery challan
+func (h *DeliveryChallanHandler) Create(c *gin.Context) {
+	var req services.CreateDeliveryChallanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+
+	challan, err := h.challanService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create delivery challan")
+		return
+	}
+
+	response.Created(c, challan)
+}
+
+// Get returns a delivery challan
+func (h *DeliveryChallanHandler) Get(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	challan, err := h.challanService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get delivery challan")
+		return
+	}
+
+	response.Success(c, challan)
+}
+
+// Update edits a draft delivery challan
+func (h *DeliveryChallanHandler) Update(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.UpdateDeliveryChallanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	challan, err := h.challanService.Update(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update delivery challan")
+		return
+	}
+
+	response.Success(c, challan)
+}
+
+// Delete deletes a draft delivery challan
+func (h *DeliveryChallanHandler) Delete(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.challanService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete delivery challan")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Delivery challan deleted"})
+}
+
+// Issue marks a draft delivery challan's goods dispatched
+func (h *DeliveryChallanHandler) Issue(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	challan, err := h.challanService.Issue(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to issue delivery challan")
+		return
+	}
+
+	response.Success(c, challan)
+}
+
+// Cancel cancels a delivery challan that is not invoiced, with an optional
+// reason
+func (h *DeliveryChallanHandler) Cancel(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	challan, err := h.challanService.Cancel(c.Request.Context(), tenantID, id, req.Reason)
+	if err != nil {
+		h.handleError(c, err, "Failed to cancel delivery challan")
+		return
+	}
+
+	response.Success(c, challan)
+}
+
+// Invoice links an issued delivery challan to the invoice that bills its
+// goods, or raises a draft invoice from it, and returns the invoice
+func (h *DeliveryChallanHandler) Invoice(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.InvoiceDeliveryChallanRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	invoice, err := h.challanService.Invoice(c.Request.Context(), id, req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to invoice delivery challan")
+		return
+	}
+
+	response.Created(c, invoice)
+}
+
+// GeneratePDF renders a delivery challan as a PDF
+func (h *DeliveryChallanHandler) GeneratePDF(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	challan, err := h.challanService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get delivery challan")
+		return
+	}
+
+	// The challan still prints without the consignor's details if the
+	// tenant service cannot be reached
+	seller, err := h.tenantClient.GetTenant(c.Request.Context(), c.GetHeader("Authorization"), challan.TenantID)
+	if err != nil {
+		log.Printf("Failed to read tenant %s for delivery challan %s: %v", challan.TenantID, challan.ID, err)
+		seller = nil
+	}
+
+	var buf bytes.Buffer
+	if err := documents.WriteDeliveryChallanPDF(&buf, challan, seller); err != nil {
+		response.InternalError(c, "Failed to generate PDF")
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename=\""+challan.ChallanNumber+".pdf\"")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// Helper methods
+
+func (h *DeliveryChallanHandler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid delivery challan ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *DeliveryChallanHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrDeliveryChallanNotFound):
+		response.NotFound(c, "Delivery challan not found")
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		response.NotFound(c, "Invoice not found")
+	case errors.Is(err, services.ErrInvalidDeliveryChallan), errors.Is(err, services.ErrInvalidInvoice),
+		errors.Is(err, services.ErrPaymentTermNotFound):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrDeliveryChallanNotEditable), errors.Is(err, services.ErrDeliveryChallanNotIssuable),
+		errors.Is(err, services.ErrDeliveryChallanNotCancelable), errors.Is(err, services.ErrDeliveryChallanNotInvoicable),
+		errors.Is(err, services.ErrDeliveryChallanInvoiced):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, services.ErrTCSUnavailable):
+		response.ServiceUnavailable(c, "Unable to determine TCS for invoice")
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *DeliveryChallanHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *DeliveryChallanHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
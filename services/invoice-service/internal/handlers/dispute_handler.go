@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// DisputeHandler handles invoice dispute endpoints
+type DisputeHandler struct {
+	disputeService services.DisputeService
+}
+
+// NewDisputeHandler creates a new dispute handler
+func NewDisputeHandler(disputeService services.DisputeService) *DisputeHandler {
+	return &DisputeHandler{disputeService: disputeService}
+}
+
+// Raise disputes an invoice, or one of its lines
+func (h *DisputeHandler) Raise(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	var req services.RaiseDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.RaisedBy = userID
+
+	dispute, err := h.disputeService.Raise(c.Request.Context(), invoiceID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to raise dispute")
+		return
+	}
+
+	response.Created(c, dispute)
+}
+
+// ListForInvoice returns the disputes raised against an invoice
+func (h *DisputeHandler) ListForInvoice(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	disputes, err := h.disputeService.List(c.Request.Context(), tenantID, repository.DisputeFilters{InvoiceID: invoiceID})
+	if err != nil {
+		response.InternalError(c, "Failed to list disputes")
+		return
+	}
+
+	response.Success(c, disputes)
+}
+
+// List returns the tenant's disputes, optionally filtered by status
+func (h *DisputeHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.DisputeFilters{Status: models.DisputeStatus(c.Query("status"))}
+	disputes, err := h.disputeService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list disputes")
+		return
+	}
+
+	response.Success(c, disputes)
+}
+
+// Get returns a dispute with its resolution history
+func (h *DisputeHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid dispute ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	dispute, err := h.disputeService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.NotFound(c, "Dispute not found")
+		return
+	}
+
+	response.Success(c, dispute)
+}
+
+// Update changes an open dispute
+func (h *DisputeHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid dispute ID", nil)
+		return
+	}
+
+	var req services.UpdateDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	dispute, err := h.disputeService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update dispute")
+		return
+	}
+
+	response.Success(c, dispute)
+}
+
+// Close records the outcome of a dispute
+func (h *DisputeHandler) Close(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid dispute ID", nil)
+		return
+	}
+
+	var req services.CloseDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	dispute, err := h.disputeService.Close(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to close dispute")
+		return
+	}
+
+	response.Success(c, dispute)
+}
+
+// Helper methods
+
+func (h *DisputeHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrInvoiceNotFound:
+		response.NotFound(c, "Invoice not found")
+	case services.ErrDisputeNotFound:
+		response.NotFound(c, "Dispute not found")
+	case services.ErrInvalidDispute:
+		response.BadRequest(c, "Invalid dispute data", nil)
+	case services.ErrDisputeClosed, services.ErrLineAlreadyDisputed, services.ErrInvoiceNotDisputable:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *DisputeHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *DisputeHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
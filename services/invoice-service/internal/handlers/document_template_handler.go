@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// DocumentTemplateHandler handles saved invoice and bill template endpoints
+type DocumentTemplateHandler struct {
+	templateService services.DocumentTemplateService
+}
+
+// NewDocumentTemplateHandler creates a new document template handler
+func NewDocumentTemplateHandler(templateService services.DocumentTemplateService) *DocumentTemplateHandler {
+	return &DocumentTemplateHandler{templateService: templateService}
+}
+
+// List returns the tenant's templates, of one document type when ?type=
+// is invoice or bill
+func (h *DocumentTemplateHandler) List(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	documentType := c.Query("type")
+	if documentType != "" && documentType != models.TemplateDocumentInvoice && documentType != models.TemplateDocumentBill {
+		response.BadRequest(c, "type must be invoice or bill", nil)
+		return
+	}
+
+	templates, err := h.templateService.List(c.Request.Context(), tenantID, documentType)
+	if err != nil {
+		response.InternalError(c, "Failed to list templates")
+		return
+	}
+
+	response.Success(c, templates)
+}
+
+// Get returns a template
+func (h *DocumentTemplateHandler) Get(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+
+	template, err := h.templateService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.NotFound(c, "Template not found")
+		return
+	}
+
+	response.Success(c, template)
+}
+
+// Create saves a template, from the content given or from the invoice or
+// bill named by from_document_id
+func (h *DocumentTemplateHandler) Create(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req services.DocumentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+	req.CreatedBy, _ = h.getUserIDFromContext(c)
+
+	template, err := h.templateService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create template")
+		return
+	}
+
+	response.Created(c, template)
+}
+
+// Update replaces a template's name and content. Documents already raised
+// from it are not changed.
+func (h *DocumentTemplateHandler) Update(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+
+	var req services.DocumentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+
+	template, err := h.templateService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update template")
+		return
+	}
+
+	response.Success(c, template)
+}
+
+// Delete removes a template
+func (h *DocumentTemplateHandler) Delete(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+
+	if err := h.templateService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete template")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Template deleted"})
+}
+
+func (h *DocumentTemplateHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrDocumentTemplateNotFound:
+		response.NotFound(c, "Template not found")
+	case services.ErrInvoiceNotFound:
+		response.NotFound(c, "Invoice not found")
+	case services.ErrBillNotFound:
+		response.NotFound(c, "Bill not found")
+	case services.ErrInvalidDocumentTemplate:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrDuplicateDocumentTemplate:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *DocumentTemplateHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *DocumentTemplateHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
+
+// bindCopyRequest reads the optional body of a duplicate or create from
+// template request, for the caller's tenant
+func bindCopyRequest(c *gin.Context) (services.CopyDocumentRequest, bool) {
+	var req services.CopyDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return req, false
+	}
+	req.TenantID, _ = uuid.Parse(c.GetString("tenant_id"))
+	req.CreatedBy, _ = uuid.Parse(c.GetString("user_id"))
+	req.Authorization = c.GetHeader("Authorization")
+	return req, true
+}
+
+// handleCopyError reports why a document could not be copied from another
+// or from a template
+func handleCopyError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvoiceNotFound:
+		response.NotFound(c, "Invoice not found")
+	case services.ErrBillNotFound:
+		response.NotFound(c, "Bill not found")
+	case services.ErrDocumentTemplateNotFound:
+		response.NotFound(c, "Template not found")
+	case services.ErrTemplateDocumentType, services.ErrInvalidDocumentCopy:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, "Failed to copy document")
+	}
+}
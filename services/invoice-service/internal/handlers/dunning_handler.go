@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// DunningHandler handles dunning policy and run endpoints
+type DunningHandler struct {
+	dunningService services.DunningService
+}
+
+// NewDunningHandler creates a new dunning handler
+func NewDunningHandler(dunningService services.DunningService) *DunningHandler {
+	return &DunningHandler{dunningService: dunningService}
+}
+
+// ListPolicies returns the tenant's dunning policies
+func (h *DunningHandler) ListPolicies(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	policies, err := h.dunningService.ListPolicies(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list dunning policies")
+		return
+	}
+
+	response.Success(c, policies)
+}
+
+// GetPolicy returns a dunning policy
+func (h *DunningHandler) GetPolicy(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid policy ID", nil)
+		return
+	}
+
+	policy, err := h.dunningService.GetPolicy(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.NotFound(c, "Dunning policy not found")
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// CreatePolicy adds a dunning policy for a customer segment
+func (h *DunningHandler) CreatePolicy(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req services.DunningPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+
+	policy, err := h.dunningService.CreatePolicy(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create dunning policy")
+		return
+	}
+
+	response.Created(c, policy)
+}
+
+// UpdatePolicy changes a dunning policy
+func (h *DunningHandler) UpdatePolicy(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid policy ID", nil)
+		return
+	}
+
+	var req services.DunningPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+
+	policy, err := h.dunningService.UpdatePolicy(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update dunning policy")
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// DeletePolicy removes a dunning policy
+func (h *DunningHandler) DeletePolicy(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid policy ID", nil)
+		return
+	}
+
+	if err := h.dunningService.DeletePolicy(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete dunning policy")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Dunning policy deleted"})
+}
+
+// GetCustomerProfile returns a customer's dunning segment and account manager
+func (h *DunningHandler) GetCustomerProfile(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID", nil)
+		return
+	}
+
+	profile, err := h.dunningService.GetCustomerProfile(c.Request.Context(), tenantID, customerID)
+	if err != nil {
+		response.NotFound(c, "Customer is not assigned to a dunning segment")
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// SetCustomerProfile assigns a customer's dunning segment and account manager
+func (h *DunningHandler) SetCustomerProfile(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID", nil)
+		return
+	}
+
+	var req services.CustomerDunningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	profile, err := h.dunningService.SetCustomerProfile(c.Request.Context(), tenantID, customerID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update customer dunning segment")
+		return
+	}
+	if profile == nil {
+		response.Success(c, gin.H{"message": "Customer returned to the default dunning policy"})
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// Run takes the dunning steps due today, or on the as_of date
+func (h *DunningHandler) Run(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	asOf := time.Now()
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		asOf, err = time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid as_of date", nil)
+			return
+		}
+	}
+
+	result, err := h.dunningService.Run(c.Request.Context(), tenantID, asOf)
+	if err != nil {
+		response.InternalError(c, "Failed to run dunning")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// History returns the dunning steps taken on an invoice
+func (h *DunningHandler) History(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	events, err := h.dunningService.History(c.Request.Context(), tenantID, invoiceID)
+	if err != nil {
+		response.InternalError(c, "Failed to get dunning history")
+		return
+	}
+
+	response.Success(c, events)
+}
+
+// Helper methods
+
+func (h *DunningHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrDunningPolicyNotFound:
+		response.NotFound(c, "Dunning policy not found")
+	case services.ErrInvalidDunningPolicy:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrDuplicateDunningPolicy, services.ErrDunningPolicyInUse, services.ErrCreditGradeTaken:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *DunningHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/einvoice"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// EInvoiceHandler handles e-invoice generation and cancellation with the
+// IRP, and the tenant's portal credentials
+type EInvoiceHandler struct {
+	einvoiceService services.EInvoiceService
+}
+
+// NewEInvoiceHandler creates a new e-invoice handler
+func NewEInvoiceHandler(einvoiceService services.EInvoiceService) *EInvoiceHandler {
+	return &EInvoiceHandler{einvoiceService: einvoiceService}
+}
+
+// GetCredentials returns the tenant's IRP API user, without its password
+func (h *EInvoiceHandler) GetCredentials(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	credential, err := h.einvoiceService.GetCredentials(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get e-invoice credentials")
+		return
+	}
+
+	response.Success(c, credential)
+}
+
+// SaveCredentials sets the tenant's IRP API user
+func (h *EInvoiceHandler) SaveCredentials(c *gin.Context) {
+	var req services.SaveEInvoiceCredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	credential, err := h.einvoiceService.SaveCredentials(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to save e-invoice credentials")
+		return
+	}
+
+	response.Success(c, credential)
+}
+
+// Generate registers an invoice with the IRP and returns it with its IRN
+// and signed QR code
+func (h *EInvoiceHandler) Generate(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	invoice, err := h.einvoiceService.Generate(c.Request.Context(), services.GenerateEInvoiceRequest{
+		TenantID:      tenantID,
+		InvoiceID:     invoiceID,
+		Authorization: c.GetHeader("Authorization"),
+	})
+	if err != nil {
+		h.handleError(c, err, "Failed to generate E-Invoice")
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// GetStatus returns where an invoice stands with the IRP
+func (h *EInvoiceHandler) GetStatus(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	status, err := h.einvoiceService.Status(c.Request.Context(), tenantID, invoiceID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get E-Invoice status")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// Cancel cancels an invoice's IRN, within 24 hours of its generation
+func (h *EInvoiceHandler) Cancel(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	var req services.CancelEInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	req.TenantID, _ = h.getTenantIDFromContext(c)
+	req.InvoiceID = invoiceID
+	invoice, err := h.einvoiceService.Cancel(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to cancel E-Invoice")
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// Helper methods
+
+func (h *EInvoiceHandler) handleError(c *gin.Context, err error, message string) {
+	var validationErr *einvoice.ValidationError
+	var apiErr *einvoice.APIError
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		response.NotFound(c, "Invoice not found")
+	case errors.Is(err, services.ErrEInvoiceNoCredentials):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidGSTIN), errors.Is(err, services.ErrInvalidCancelReason):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrEInvoiceNotIssued), errors.Is(err, services.ErrEInvoiceGenerated),
+		errors.Is(err, services.ErrEInvoiceNotGenerated), errors.Is(err, services.ErrEInvoiceCancelWindow):
+		response.Conflict(c, err.Error())
+	case errors.As(err, &validationErr):
+		response.ValidationError(c, err.Error(), nil)
+	case errors.As(err, &apiErr):
+		response.ValidationError(c, apiErr.Message, map[string]string{"irp_error_code": apiErr.Code})
+	case errors.Is(err, services.ErrEInvoiceUnavailable), errors.Is(err, einvoice.ErrUnavailable):
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *EInvoiceHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *EInvoiceHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// ExpenseClaimHandler handles expense claim endpoints
+type ExpenseClaimHandler struct {
+	claimService services.ExpenseClaimService
+}
+
+// NewExpenseClaimHandler creates a new expense claim handler
+func NewExpenseClaimHandler(claimService services.ExpenseClaimService) *ExpenseClaimHandler {
+	return &ExpenseClaimHandler{claimService: claimService}
+}
+
+// Submit submits an expense claim for the current member
+func (h *ExpenseClaimHandler) Submit(c *gin.Context) {
+	var req services.SubmitExpenseClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.MemberID = userID
+
+	claim, err := h.claimService.Submit(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to submit expense claim")
+		return
+	}
+
+	response.Created(c, claim)
+}
+
+// List returns expense claims. mine=true limits the list to the current
+// member's claims.
+func (h *ExpenseClaimHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters, ok := h.parseFilters(c)
+	if !ok {
+		return
+	}
+	filters.Status = c.Query("status")
+
+	claims, err := h.claimService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list expense claims")
+		return
+	}
+
+	response.Success(c, claims)
+}
+
+// Get returns an expense claim with its items
+func (h *ExpenseClaimHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid expense claim ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	claim, err := h.claimService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.NotFound(c, "Expense claim not found")
+		return
+	}
+
+	response.Success(c, claim)
+}
+
+// Approve approves an expense claim and raises its bill
+func (h *ExpenseClaimHandler) Approve(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid expense claim ID", nil)
+		return
+	}
+
+	// The body is optional when the claim already names the employee party
+	var req services.ApproveExpenseClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.ReviewerID = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	claim, err := h.claimService.Approve(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to approve expense claim")
+		return
+	}
+
+	response.Success(c, claim)
+}
+
+// Reject rejects an expense claim
+func (h *ExpenseClaimHandler) Reject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid expense claim ID", nil)
+		return
+	}
+
+	var req services.RejectExpenseClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.ReviewerID = userID
+
+	claim, err := h.claimService.Reject(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to reject expense claim")
+		return
+	}
+
+	response.Success(c, claim)
+}
+
+// StatusReport returns claim totals by status for each member
+func (h *ExpenseClaimHandler) StatusReport(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters, ok := h.parseFilters(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.claimService.StatusReport(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to generate expense claims report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *ExpenseClaimHandler) parseFilters(c *gin.Context) (repository.ExpenseClaimFilters, bool) {
+	filters := repository.ExpenseClaimFilters{
+		Project:  c.Query("project"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+	}
+
+	if c.Query("mine") == "true" {
+		filters.MemberID, _ = h.getUserIDFromContext(c)
+	} else if memberID := c.Query("member_id"); memberID != "" {
+		id, err := uuid.Parse(memberID)
+		if err != nil {
+			response.BadRequest(c, "Invalid member ID", nil)
+			return filters, false
+		}
+		filters.MemberID = id
+	}
+
+	return filters, true
+}
+
+func (h *ExpenseClaimHandler) handleError(c *gin.Context, err error, message string) {
+	if periodLocked(c, err) {
+		return
+	}
+	switch err {
+	case services.ErrExpenseClaimNotFound:
+		response.NotFound(c, "Expense claim not found")
+	case services.ErrInvalidExpenseClaim:
+		response.BadRequest(c, "Invalid expense claim data", nil)
+	case services.ErrEmployeePartyRequired:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrExpenseClaimReviewed, services.ErrSelfApproval, services.ErrPolicyOverrideRequired:
+		response.Conflict(c, err.Error())
+	case services.ErrDocumentNotPosted:
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *ExpenseClaimHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *ExpenseClaimHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
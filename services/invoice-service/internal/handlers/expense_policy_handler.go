@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// ExpensePolicyHandler handles the expense policy and the report of its
+// violations
+type ExpensePolicyHandler struct {
+	policyService services.ExpensePolicyService
+}
+
+// NewExpensePolicyHandler creates a new expense policy handler
+func NewExpensePolicyHandler(policyService services.ExpensePolicyService) *ExpensePolicyHandler {
+	return &ExpensePolicyHandler{policyService: policyService}
+}
+
+// GetPolicy returns the tenant's expense policy
+func (h *ExpensePolicyHandler) GetPolicy(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	policy, err := h.policyService.GetPolicy(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to get expense policy")
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// UpdatePolicy replaces the tenant's expense policy. Claims and bills
+// already submitted keep the violations they were checked with.
+func (h *ExpensePolicyHandler) UpdatePolicy(c *gin.Context) {
+	var req services.UpdateExpensePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	policy, err := h.policyService.UpdatePolicy(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		if err == services.ErrInvalidExpensePolicy {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to update expense policy")
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// Violations lists the recorded violations of the expense policy, optionally
+// for one kind of document, one rule, whether overridden, and a range of
+// expense dates
+func (h *ExpensePolicyHandler) Violations(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.ExpenseViolationFilters{
+		DocumentType: c.Query("document_type"),
+		Rule:         c.Query("rule"),
+		FromDate:     c.Query("from_date"),
+		ToDate:       c.Query("to_date"),
+	}
+	switch filters.DocumentType {
+	case "", models.PolicyDocumentExpenseClaim, models.PolicyDocumentBill:
+	default:
+		response.BadRequest(c, "Invalid document type", nil)
+		return
+	}
+	switch filters.Rule {
+	case "", models.PolicyRuleProhibited, models.PolicyRuleDailyLimit, models.PolicyRulePerDiem, models.PolicyRuleReceiptRequired:
+	default:
+		response.BadRequest(c, "Invalid rule", nil)
+		return
+	}
+	if overridden := c.Query("overridden"); overridden != "" {
+		value, err := strconv.ParseBool(overridden)
+		if err != nil {
+			response.BadRequest(c, "Invalid overridden flag", nil)
+			return
+		}
+		filters.Overridden = &value
+	}
+	for _, date := range []string{filters.FromDate, filters.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			response.BadRequest(c, "Invalid date, use YYYY-MM-DD", nil)
+			return
+		}
+	}
+
+	report, err := h.policyService.Report(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to generate expense policy report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *ExpensePolicyHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *ExpensePolicyHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
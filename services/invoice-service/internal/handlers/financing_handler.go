@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// FinancingHandler handles invoice financing consent and export endpoints
+type FinancingHandler struct {
+	financingService services.FinancingService
+}
+
+// NewFinancingHandler creates a new financing handler
+func NewFinancingHandler(financingService services.FinancingService) *FinancingHandler {
+	return &FinancingHandler{financingService: financingService}
+}
+
+// ListConsents returns the consent log, latest first
+func (h *FinancingHandler) ListConsents(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	consents, err := h.financingService.ListConsents(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list financing consents")
+		return
+	}
+
+	response.Success(c, consents)
+}
+
+// GrantConsent records consent to share receivables data with a lender
+func (h *FinancingHandler) GrantConsent(c *gin.Context) {
+	var req services.GrantFinancingConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+	req.ClientIP = c.ClientIP()
+
+	consent, err := h.financingService.GrantConsent(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to record financing consent")
+		return
+	}
+
+	response.Created(c, consent)
+}
+
+// RevokeConsent stops further exports under a consent
+func (h *FinancingHandler) RevokeConsent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid consent ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	consent, err := h.financingService.RevokeConsent(c.Request.Context(), tenantID, id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to revoke financing consent")
+		return
+	}
+
+	response.Success(c, consent)
+}
+
+// ListExports returns the data packs released, optionally for one consent
+func (h *FinancingHandler) ListExports(c *gin.Context) {
+	var consentID uuid.UUID
+	if id := c.Query("consent_id"); id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			response.BadRequest(c, "Invalid consent ID", nil)
+			return
+		}
+		consentID = parsed
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	exports, err := h.financingService.ListExports(c.Request.Context(), tenantID, consentID)
+	if err != nil {
+		response.InternalError(c, "Failed to list financing exports")
+		return
+	}
+
+	response.Success(c, exports)
+}
+
+// Export downloads the receivables data pack for the lender of a consent,
+// as CSV (the default) or JSON
+func (h *FinancingHandler) Export(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid consent ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req := services.FinancingExportRequest{
+		TenantID:  tenantID,
+		UserID:    userID,
+		ClientIP:  c.ClientIP(),
+		ConsentID: id,
+		Format:    c.DefaultQuery("format", models.FinancingFormatCSV),
+		AsOf:      c.Query("as_of"),
+	}
+
+	pack, err := h.financingService.Export(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to export financing data")
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv"
+	if req.Format == models.FinancingFormatJSON {
+		contentType = "application/json"
+		err = json.NewEncoder(&buf).Encode(pack)
+	} else {
+		err = pack.WriteCSV(&buf)
+	}
+	if err != nil {
+		response.InternalError(c, "Failed to export financing data")
+		return
+	}
+
+	middleware.SetExportRows(c, len(pack.Invoices))
+	c.Header("Content-Disposition", "attachment; filename=\"receivables-"+pack.AsOf+"-"+pack.ExportID.String()+"."+req.Format+"\"")
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// Helper methods
+
+func (h *FinancingHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrFinancingConsentNotFound:
+		response.NotFound(c, "Financing consent not found")
+	case services.ErrFinancingConsentInactive:
+		response.Forbidden(c, err.Error())
+	case services.ErrFinancingConsentRequired, services.ErrInvalidConsentPeriod,
+		services.ErrInvalidFinancingFormat, services.ErrInvalidAsOfDate:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *FinancingHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *FinancingHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// GSTAnnualHandler handles the books totals behind the annual GST return
+type GSTAnnualHandler struct {
+	gstAnnualService services.GSTAnnualService
+}
+
+// NewGSTAnnualHandler creates a new annual GST handler
+func NewGSTAnnualHandler(gstAnnualService services.GSTAnnualService) *GSTAnnualHandler {
+	return &GSTAnnualHandler{gstAnnualService: gstAnnualService}
+}
+
+// Books returns a financial year's books totals in the heads of GSTR-9
+func (h *GSTAnnualHandler) Books(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	books, err := h.gstAnnualService.Books(c.Request.Context(), tenantID, c.Query("financial_year"))
+	if err != nil {
+		if err == services.ErrInvalidFinancialYear {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to total the books for the annual return")
+		return
+	}
+
+	response.Success(c, books)
+}
+
+// MonthlyBooks returns a month's books totals in the heads of GSTR-3B, for
+// ?period=MMYYYY
+func (h *GSTAnnualHandler) MonthlyBooks(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	books, err := h.gstAnnualService.MonthlyBooks(c.Request.Context(), tenantID, c.Query("period"))
+	if err != nil {
+		if err == services.ErrInvalidReturnPeriod {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to total the books for the month")
+		return
+	}
+
+	response.Success(c, books)
+}
+
+func (h *GSTAnnualHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// InvoiceExportHandler handles bulk invoice PDF export endpoints
+type InvoiceExportHandler struct {
+	exportService services.InvoiceExportService
+}
+
+// NewInvoiceExportHandler creates a new invoice export handler
+func NewInvoiceExportHandler(exportService services.InvoiceExportService) *InvoiceExportHandler {
+	return &InvoiceExportHandler{exportService: exportService}
+}
+
+// Start queues a zip of the PDFs of the invoices dated from from_date to
+// to_date
+func (h *InvoiceExportHandler) Start(c *gin.Context) {
+	var req services.StartInvoiceExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+	req.Authorization = c.GetHeader("Authorization")
+	middleware.SetExportFilters(c, map[string]string{"from_date": req.FromDate, "to_date": req.ToDate})
+
+	export, err := h.exportService.Start(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to start invoice export")
+		return
+	}
+
+	response.Accepted(c, export)
+}
+
+// List returns the invoice exports, latest first
+func (h *InvoiceExportHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	exports, err := h.exportService.List(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list invoice exports")
+		return
+	}
+
+	response.Success(c, exports)
+}
+
+// Get returns an export's progress and, once it has completed, a signed
+// download link
+func (h *InvoiceExportHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice export ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	export, err := h.exportService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get invoice export")
+		return
+	}
+
+	response.Success(c, export)
+}
+
+// Download serves an export's zip to the holder of its signed link
+func (h *InvoiceExportHandler) Download(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.NotFound(c, "Invoice export not found")
+		return
+	}
+
+	export, err := h.exportService.Download(c.Request.Context(), id, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		h.handleError(c, err, "Failed to download invoice export")
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+export.FileName+"\"")
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/zip", export.Content)
+}
+
+func (h *InvoiceExportHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrInvoiceExportNotFound:
+		response.NotFound(c, "Invoice export not found")
+	case services.ErrInvoiceExportActive:
+		response.Conflict(c, err.Error())
+	case services.ErrInvoiceExportUnavailable:
+		response.NotFound(c, err.Error())
+	case services.ErrInvalidExportLink:
+		response.Forbidden(c, err.Error())
+	case services.ErrInvalidInvoiceExport:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *InvoiceExportHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *InvoiceExportHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -1,23 +1,76 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/pdf"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/documents"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/upi"
 )
 
 // InvoiceHandler handles invoice endpoints
 type InvoiceHandler struct {
-	invoiceService services.InvoiceService
+	invoiceService      services.InvoiceService
+	invoiceEmailService services.InvoiceEmailService
+	invoiceMessages     services.InvoiceMessageService
+	documentTemplates   services.DocumentTemplateService
+	tenantClient        clients.TenantClient
+	brandingClient      clients.BrandingClient
 }
 
 // NewInvoiceHandler creates a new invoice handler
-func NewInvoiceHandler(invoiceService services.InvoiceService) *InvoiceHandler {
-	return &InvoiceHandler{invoiceService: invoiceService}
+func NewInvoiceHandler(invoiceService services.InvoiceService, invoiceEmailService services.InvoiceEmailService, invoiceMessages services.InvoiceMessageService, documentTemplates services.DocumentTemplateService, tenantClient clients.TenantClient, brandingClient clients.BrandingClient) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService:      invoiceService,
+		invoiceEmailService: invoiceEmailService,
+		invoiceMessages:     invoiceMessages,
+		documentTemplates:   documentTemplates,
+		tenantClient:        tenantClient,
+		brandingClient:      brandingClient,
+	}
+}
+
+// AuditedYearOnly hides invoices outside an auditor's financial year from
+// the routes that load one by ID, as those carry no date filter to hold to
+// the year
+func (h *InvoiceHandler) AuditedYearOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !middleware.IsAuditor(c) || c.Param("id") == "" {
+			c.Next()
+			return
+		}
+
+		invoiceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			response.BadRequest(c, "Invalid invoice ID", nil)
+			c.Abort()
+			return
+		}
+
+		invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID)
+		if err != nil || invoice.TenantID.String() != c.GetString("tenant_id") ||
+			!middleware.WithinAuditorPeriod(c, invoice.InvoiceDate) {
+			response.NotFound(c, "Invoice not found")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
 }
 
 // List returns a list of invoices
@@ -29,11 +82,12 @@ func (h *InvoiceHandler) List(c *gin.Context) {
 	}
 
 	filters := repository.InvoiceFilters{
-		Status:   c.Query("status"),
-		FromDate: c.Query("from_date"),
-		ToDate:   c.Query("to_date"),
-		Page:     1,
-		Limit:    20,
+		Status:      c.Query("status"),
+		FromDate:    c.Query("from_date"),
+		ToDate:      c.Query("to_date"),
+		EmailStatus: c.Query("email_status"),
+		Page:        1,
+		Limit:       20,
 	}
 
 	if customerID := c.Query("customer_id"); customerID != "" {
@@ -41,6 +95,11 @@ func (h *InvoiceHandler) List(c *gin.Context) {
 			filters.CustomerID = cid
 		}
 	}
+	if tag := c.Query("tag"); tag != "" {
+		if normalized, err := tags.Normalize(tag); err == nil {
+			filters.Tag = normalized
+		}
+	}
 
 	invoices, total, err := h.invoiceService.List(c.Request.Context(), tenantID, filters)
 	if err != nil {
@@ -63,13 +122,85 @@ func (h *InvoiceHandler) Create(c *gin.Context) {
 	userID, _ := h.getUserIDFromContext(c)
 	req.TenantID = tenantID
 	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+	h.create(c, req)
+}
+
+// Duplicate creates an invoice for the customer, lines and terms of an
+// earlier one, dated today unless the body gives a date
+func (h *InvoiceHandler) Duplicate(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+	req, ok := bindCopyRequest(c)
+	if !ok {
+		return
+	}
+
+	create, err := h.documentTemplates.DuplicateInvoice(c.Request.Context(), invoiceID, req)
+	if err != nil {
+		handleCopyError(c, err)
+		return
+	}
+	h.create(c, *create)
+}
+
+// CreateFromTemplate creates an invoice from a saved invoice template
+func (h *InvoiceHandler) CreateFromTemplate(c *gin.Context) {
+	templateID, err := uuid.Parse(c.Param("template_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid template ID", nil)
+		return
+	}
+	req, ok := bindCopyRequest(c)
+	if !ok {
+		return
+	}
+
+	create, err := h.documentTemplates.InvoiceFromTemplate(c.Request.Context(), templateID, req)
+	if err != nil {
+		handleCopyError(c, err)
+		return
+	}
+	h.create(c, *create)
+}
+
+func (h *InvoiceHandler) create(c *gin.Context, req services.CreateInvoiceRequest) {
+	if req.Language == "" {
+		// Default to the language the user is working in
+		req.Language = i18n.FromContext(c)
+	}
 
 	invoice, err := h.invoiceService.Create(c.Request.Context(), req)
 	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
 		if err == services.ErrInvalidInvoice {
 			response.BadRequest(c, "Invalid invoice data", nil)
 			return
 		}
+		if errors.Is(err, services.ErrInvalidExport) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		if err == services.ErrPaymentTermNotFound {
+			response.BadRequest(c, "Payment term not found", nil)
+			return
+		}
+		if err == services.ErrTCSUnavailable {
+			response.ServiceUnavailable(c, "Unable to determine TCS for invoice")
+			return
+		}
+		if err == tags.ErrInvalidTag || err == tags.ErrTooManyTags {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
 		response.InternalError(c, "Failed to create invoice")
 		return
 	}
@@ -108,8 +239,16 @@ func (h *InvoiceHandler) Update(c *gin.Context) {
 		return
 	}
 
+	req.Authorization = c.GetHeader("Authorization")
+
 	invoice, err := h.invoiceService.Update(c.Request.Context(), invoiceID, req)
 	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
 		if err == services.ErrInvoiceNotFound {
 			response.NotFound(c, "Invoice not found")
 			return
@@ -118,6 +257,22 @@ func (h *InvoiceHandler) Update(c *gin.Context) {
 			response.Conflict(c, "Cannot modify invoice in current status")
 			return
 		}
+		if errors.Is(err, services.ErrInvalidExport) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		if err == services.ErrPaymentTermNotFound {
+			response.BadRequest(c, "Payment term not found", nil)
+			return
+		}
+		if err == services.ErrTCSUnavailable {
+			response.ServiceUnavailable(c, "Unable to determine TCS for invoice")
+			return
+		}
+		if err == tags.ErrInvalidTag || err == tags.ErrTooManyTags {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
 		response.InternalError(c, "Failed to update invoice")
 		return
 	}
@@ -133,7 +288,10 @@ func (h *InvoiceHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.invoiceService.Delete(c.Request.Context(), invoiceID); err != nil {
+	if err := h.invoiceService.Delete(c.Request.Context(), invoiceID, c.GetHeader("Authorization")); err != nil {
+		if periodLocked(c, err) {
+			return
+		}
 		if err == services.ErrInvoiceNotFound {
 			response.NotFound(c, "Invoice not found")
 			return
@@ -157,16 +315,96 @@ func (h *InvoiceHandler) Send(c *gin.Context) {
 		return
 	}
 
-	if err := h.invoiceService.Send(c.Request.Context(), invoiceID); err != nil {
+	if err := h.invoiceService.Send(c.Request.Context(), invoiceID, c.GetHeader("Authorization")); err != nil {
+		if periodLocked(c, err) {
+			return
+		}
 		if err == services.ErrInvoiceNotFound {
 			response.NotFound(c, "Invoice not found")
 			return
 		}
+		if err == services.ErrCannotModify {
+			response.Conflict(c, "Invoice has already been sent")
+			return
+		}
+		if err == services.ErrTCSUnavailable {
+			response.ServiceUnavailable(c, "Unable to record TCS for invoice")
+			return
+		}
+		if err == services.ErrDocumentNotPosted {
+			response.ServiceUnavailable(c, err.Error())
+			return
+		}
 		response.InternalError(c, "Failed to send invoice")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "Invoice sent successfully"})
+	// The invoice is sent once finalized; emailing it to the customer and
+	// messaging their phone are best effort, and their delivery is tracked
+	// under the email and messages returned
+	result := gin.H{"message": "Invoice sent successfully"}
+	invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID)
+	if err == nil {
+		delivery, err := h.invoiceEmailService.Send(c.Request.Context(), c.GetHeader("Authorization"), invoice)
+		if err != nil {
+			log.Printf("Failed to email invoice %s: %v", invoice.ID, err)
+			result["email_error"] = "The invoice could not be emailed to the customer"
+		} else if delivery != nil {
+			result["email"] = delivery
+		}
+
+		messages, err := h.invoiceMessages.SendInvoice(c.Request.Context(), invoice)
+		if err != nil {
+			log.Printf("Failed to message invoice %s: %v", invoice.ID, err)
+			result["messages_error"] = "The invoice could not be messaged to the customer's phone"
+		}
+		if len(messages) > 0 {
+			result["messages"] = messages
+		}
+	}
+
+	response.Success(c, result)
+}
+
+// Email emails a sent invoice to the customer again, optionally to a
+// corrected address, such as after the last email bounced
+func (h *InvoiceHandler) Email(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	var req struct {
+		CustomerEmail string `json:"customer_email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID)
+	if err != nil || invoice.TenantID != tenantID {
+		response.NotFound(c, "Invoice not found")
+		return
+	}
+
+	delivery, err := h.invoiceEmailService.Email(c.Request.Context(), c.GetHeader("Authorization"), invoice, req.CustomerEmail)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCustomerEmail), errors.Is(err, services.ErrNoCustomerEmail):
+			response.BadRequest(c, err.Error(), nil)
+		case errors.Is(err, services.ErrInvoiceNotEmailable):
+			response.Conflict(c, err.Error())
+		default:
+			log.Printf("Failed to email invoice %s: %v", invoice.ID, err)
+			response.InternalError(c, "Failed to email invoice")
+		}
+		return
+	}
+
+	response.Success(c, delivery)
 }
 
 // RecordPayment records a payment for an invoice
@@ -187,49 +425,185 @@ func (h *InvoiceHandler) RecordPayment(c *gin.Context) {
 	userID, _ := h.getUserIDFromContext(c)
 	req.TenantID = tenantID
 	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
 
 	payment, err := h.invoiceService.RecordPayment(c.Request.Context(), invoiceID, req)
 	if err != nil {
-		if err == services.ErrInvoiceNotFound {
-			response.NotFound(c, "Invoice not found")
+		if periodLocked(c, err) {
 			return
 		}
-		response.InternalError(c, "Failed to record payment")
+		switch err {
+		case services.ErrInvoiceNotFound:
+			response.NotFound(c, "Invoice not found")
+		case services.ErrCashLimitExceeded:
+			response.Conflict(c, err.Error())
+		default:
+			response.InternalError(c, "Failed to record payment")
+		}
 		return
 	}
 
 	response.Created(c, payment)
 }
 
-// GeneratePDF generates a PDF for an invoice
-func (h *InvoiceHandler) GeneratePDF(c *gin.Context) {
-	// TODO: Implement PDF generation
-	response.Success(c, gin.H{"message": "PDF generation not implemented"})
+// BouncePayment records that a payment against an invoice was dishonoured
+func (h *InvoiceHandler) BouncePayment(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+	paymentID, err := uuid.Parse(c.Param("payment_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid payment ID", nil)
+		return
+	}
+
+	var req services.BouncePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	req.TenantID = tenantID
+
+	payment, err := h.invoiceService.BouncePayment(c.Request.Context(), invoiceID, paymentID, req)
+	if err != nil {
+		switch err {
+		case services.ErrInvoiceNotFound:
+			response.NotFound(c, "Invoice not found")
+		case services.ErrPaymentNotFound:
+			response.NotFound(c, "Payment not found")
+		case services.ErrPaymentBounced:
+			response.Conflict(c, err.Error())
+		case services.ErrInvalidInvoice:
+			response.BadRequest(c, "Invalid bounce date", nil)
+		default:
+			response.InternalError(c, "Failed to record bounced payment")
+		}
+		return
+	}
+
+	response.Success(c, payment)
 }
 
-// GenerateEInvoice generates an E-Invoice for GST
-func (h *InvoiceHandler) GenerateEInvoice(c *gin.Context) {
+// GeneratePDF renders an invoice as a PDF: a tax invoice, or for exports an
+// export invoice with its declaration, LUT and shipping bill details. It is
+// laid out in the tenant's template and branding, or the template given
+// with ?template=.
+func (h *InvoiceHandler) GeneratePDF(c *gin.Context) {
 	invoiceID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		response.BadRequest(c, "Invalid invoice ID", nil)
 		return
 	}
 
-	invoice, err := h.invoiceService.GenerateEInvoice(c.Request.Context(), invoiceID)
+	template := c.Query("template")
+	if template != "" && !documents.IsTemplate(template) {
+		response.BadRequest(c, "Unknown invoice template", nil)
+		return
+	}
+
+	invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID)
 	if err != nil {
-		if err == services.ErrInvoiceNotFound {
-			response.NotFound(c, "Invoice not found")
+		response.NotFound(c, "Invoice not found")
+		return
+	}
+
+	// The invoice still prints without the seller's details if the tenant
+	// service cannot be reached
+	seller, err := h.tenantClient.GetTenant(c.Request.Context(), c.GetHeader("Authorization"), invoice.TenantID)
+	if err != nil {
+		log.Printf("Failed to read tenant %s for invoice %s: %v", invoice.TenantID, invoice.ID, err)
+		seller = nil
+	}
+
+	var buf bytes.Buffer
+	opts := documents.InvoicePDFOptions{
+		Template:  template,
+		Branding:  h.branding(c, invoice.TenantID),
+		Watermark: middleware.AuditorWatermark(c),
+	}
+	if err := documents.WriteInvoicePDF(&buf, invoice, seller, opts); err != nil {
+		response.InternalError(c, "Failed to generate PDF")
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename=\""+invoice.InvoiceNumber+".pdf\"")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// ListPDFTemplates returns the templates invoices can be printed in
+func (h *InvoiceHandler) ListPDFTemplates(c *gin.Context) {
+	response.Success(c, documents.Templates())
+}
+
+// PreviewPDFTemplate renders a sample invoice from the tenant in a template
+// with its branding, watermarked as a sample. The colours can be tried out
+// before they are saved with ?primary_color= and ?accent_color=.
+func (h *InvoiceHandler) PreviewPDFTemplate(c *gin.Context) {
+	template := c.Param("template")
+	if !documents.IsTemplate(template) {
+		response.NotFound(c, "Invoice template not found")
+		return
+	}
+
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "Tenant not found")
+		return
+	}
+
+	seller, err := h.tenantClient.GetTenant(c.Request.Context(), c.GetHeader("Authorization"), tenantID)
+	if err != nil {
+		log.Printf("Failed to read tenant %s for a template preview: %v", tenantID, err)
+		seller = nil
+	}
+
+	// The cached branding is copied before the colours are changed
+	branding := clients.Branding{}
+	if current := h.branding(c, tenantID); current != nil {
+		branding = *current
+	}
+	for param, color := range map[string]*string{"primary_color": &branding.PrimaryColor, "accent_color": &branding.AccentColor} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		if _, ok := pdf.ParseColor(value); !ok {
+			response.BadRequest(c, "Colors must be #rrggbb", map[string]string{param: value})
 			return
 		}
-		response.InternalError(c, "Failed to generate E-Invoice")
+		*color = value
+	}
+
+	var buf bytes.Buffer
+	opts := documents.InvoicePDFOptions{Template: template, Branding: &branding, Watermark: "SAMPLE"}
+	if err := documents.WriteInvoicePDF(&buf, documents.SampleInvoice(seller), seller, opts); err != nil {
+		response.InternalError(c, "Failed to generate PDF")
 		return
 	}
 
-	response.Success(c, invoice)
+	c.Header("Content-Disposition", "inline; filename=\"sample-"+template+".pdf\"")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
 }
 
-// GetEInvoiceStatus returns the E-Invoice status
-func (h *InvoiceHandler) GetEInvoiceStatus(c *gin.Context) {
+// branding returns the tenant's branding, or nil when it cannot be read; the
+// invoice then prints plain
+func (h *InvoiceHandler) branding(c *gin.Context, tenantID uuid.UUID) *clients.Branding {
+	branding, err := h.brandingClient.GetBranding(c.Request.Context(), tenantID)
+	if err != nil {
+		log.Printf("Failed to read the branding of tenant %s: %v", tenantID, err)
+		return nil
+	}
+	return branding
+}
+
+// UPIQR returns the UPI intent and QR code to pay an invoice's balance,
+// referenced by the invoice number. The QR code is a base64 PNG in the
+// JSON, or the PNG itself with ?format=png.
+func (h *InvoiceHandler) UPIQR(c *gin.Context) {
 	invoiceID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		response.BadRequest(c, "Invalid invoice ID", nil)
@@ -242,39 +616,212 @@ func (h *InvoiceHandler) GetEInvoiceStatus(c *gin.Context) {
 		return
 	}
 
+	payee, err := h.tenantClient.GetTenant(c.Request.Context(), c.GetHeader("Authorization"), invoice.TenantID)
+	if err != nil {
+		log.Printf("Failed to read tenant %s for invoice %s: %v", invoice.TenantID, invoice.ID, err)
+		response.InternalError(c, "Failed to read the business's UPI ID")
+		return
+	}
+
+	payment, err := upi.ForInvoice(invoice, payee)
+	if err != nil {
+		switch err {
+		case upi.ErrNoUPIID, upi.ErrInvalidVPA, upi.ErrNotPayable:
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to create UPI payment request")
+		}
+		return
+	}
+
+	code, err := payment.QR()
+	if err != nil {
+		response.InternalError(c, "Failed to generate QR code")
+		return
+	}
+	image, err := code.PNG(8)
+	if err != nil {
+		response.InternalError(c, "Failed to generate QR code")
+		return
+	}
+
+	if c.Query("format") == "png" {
+		c.Header("Content-Disposition", "inline; filename=\""+invoice.InvoiceNumber+"-upi.png\"")
+		c.Data(http.StatusOK, "image/png", image)
+		return
+	}
+
 	response.Success(c, gin.H{
-		"irn":    invoice.IRN,
-		"status": invoice.EInvoiceStatus,
-		"date":   invoice.EInvoiceDate,
+		"upi_id":     payment.PayeeVPA,
+		"payee_name": payment.PayeeName,
+		"amount":     payment.Amount,
+		"reference":  payment.Reference,
+		"note":       payment.Note,
+		"intent":     payment.Intent(),
+		"qr_png":     base64.StdEncoding.EncodeToString(image),
 	})
 }
 
-// CancelEInvoice cancels an E-Invoice
-func (h *InvoiceHandler) CancelEInvoice(c *gin.Context) {
+// UpdateShippingBill records the shipping bill of an export invoice
+func (h *InvoiceHandler) UpdateShippingBill(c *gin.Context) {
 	invoiceID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		response.BadRequest(c, "Invalid invoice ID", nil)
 		return
 	}
 
-	var req struct {
-		Reason string `json:"reason" binding:"required"`
+	var req services.ShippingBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	invoice, err := h.invoiceService.UpdateShippingBill(c.Request.Context(), invoiceID, req)
+	if err != nil {
+		if err == services.ErrInvoiceNotFound {
+			response.NotFound(c, "Invoice not found")
+			return
+		}
+		if err == services.ErrNotExport || errors.Is(err, services.ErrInvalidExport) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to update shipping bill")
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// ForReturnPeriod returns the invoices issued in a month, for
+// ?period=MMYYYY, as reported in the outward supply sections of GSTR-1
+func (h *InvoiceHandler) ForReturnPeriod(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	invoices, err := h.invoiceService.ForReturnPeriod(c.Request.Context(), tenantID, c.Query("period"))
+	if err != nil {
+		if err == services.ErrInvalidReturnPeriod {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to list the month's invoices")
+		return
 	}
+
+	response.Success(c, invoices)
+}
+
+// SetTagsRequest replaces an invoice's tags
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// RenameTagRequest renames a tag
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// SetTags replaces an invoice's tags, in any status
+func (h *InvoiceHandler) SetTags(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID", nil)
+		return
+	}
+
+	var req SetTagsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "Invalid request body", nil)
 		return
 	}
 
-	if err := h.invoiceService.CancelEInvoice(c.Request.Context(), invoiceID, req.Reason); err != nil {
+	invoice, err := h.invoiceService.SetTags(c.Request.Context(), invoiceID, tenantID, req.Tags)
+	if err != nil {
 		if err == services.ErrInvoiceNotFound {
 			response.NotFound(c, "Invoice not found")
 			return
 		}
-		response.InternalError(c, "Failed to cancel E-Invoice")
+		if err == tags.ErrInvalidTag || err == tags.ErrTooManyTags {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to update tags")
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// ListTags returns the tags in use with their invoice counts
+func (h *InvoiceHandler) ListTags(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	usage, err := h.invoiceService.ListTags(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list tags")
+		return
+	}
+
+	response.Success(c, usage)
+}
+
+// RenameTag renames a tag on every invoice. Renaming to a tag that already
+// exists merges the two.
+func (h *InvoiceHandler) RenameTag(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	updated, err := h.invoiceService.RenameTag(c.Request.Context(), tenantID, c.Param("tag"), req.Name)
+	if err != nil {
+		if err == tags.ErrInvalidTag {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to rename tag")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "E-Invoice cancelled successfully"})
+	response.Success(c, gin.H{"updated": updated})
+}
+
+// DeleteTag removes a tag from every invoice
+func (h *InvoiceHandler) DeleteTag(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	updated, err := h.invoiceService.DeleteTag(c.Request.Context(), tenantID, c.Param("tag"))
+	if err != nil {
+		if err == tags.ErrInvalidTag {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to delete tag")
+		return
+	}
+
+	response.Success(c, gin.H{"updated": updated})
 }
 
 // Helper methods
@@ -293,3 +840,29 @@ func (h *InvoiceHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, erro
 	}
 	return uuid.Parse(tenantIDStr.(string))
 }
+
+// periodLocked responds to an error from checking a document's accounting
+// period, reporting whether it was one
+func periodLocked(c *gin.Context, err error) bool {
+	switch err {
+	case services.ErrPeriodClosed:
+		response.Conflict(c, "The accounting period of this date is closed")
+	case services.ErrPeriodLockUnavailable:
+		response.ServiceUnavailable(c, "Unable to check whether the accounting period is closed")
+	default:
+		return false
+	}
+	return true
+}
+
+// brokeRules responds to a document breaking the tenant's validation
+// rules, reporting whether the error was that. The details carry each
+// violation's message key under the path of the field that broke it.
+func brokeRules(c *gin.Context, err error) bool {
+	var broken *validation.Error
+	if !errors.As(err, &broken) {
+		return false
+	}
+	response.ValidationError(c, "The document breaks the business's validation rules", broken.Details())
+	return true
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// PaymentTermHandler handles payment term endpoints
+type PaymentTermHandler struct {
+	paymentTermService services.PaymentTermService
+}
+
+// NewPaymentTermHandler creates a new payment term handler
+func NewPaymentTermHandler(paymentTermService services.PaymentTermService) *PaymentTermHandler {
+	return &PaymentTermHandler{paymentTermService: paymentTermService}
+}
+
+// SetCustomerTermRequest assigns a default payment term to a customer
+type SetCustomerTermRequest struct {
+	PaymentTermID *uuid.UUID `json:"payment_term_id"` // null clears the assignment
+}
+
+// List returns the tenant's payment terms
+func (h *PaymentTermHandler) List(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	terms, err := h.paymentTermService.List(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list payment terms")
+		return
+	}
+
+	response.Success(c, terms)
+}
+
+// Get returns a payment term
+func (h *PaymentTermHandler) Get(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid payment term ID", nil)
+		return
+	}
+
+	term, err := h.paymentTermService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.NotFound(c, "Payment term not found")
+		return
+	}
+
+	response.Success(c, term)
+}
+
+// Create adds a payment term
+func (h *PaymentTermHandler) Create(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req services.PaymentTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+
+	term, err := h.paymentTermService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create payment term")
+		return
+	}
+
+	response.Created(c, term)
+}
+
+// Update changes a payment term. Existing invoices keep the due date they
+// were issued with.
+func (h *PaymentTermHandler) Update(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid payment term ID", nil)
+		return
+	}
+
+	var req services.PaymentTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.TenantID = tenantID
+
+	term, err := h.paymentTermService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update payment term")
+		return
+	}
+
+	response.Success(c, term)
+}
+
+// Delete removes a payment term
+func (h *PaymentTermHandler) Delete(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid payment term ID", nil)
+		return
+	}
+
+	if err := h.paymentTermService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete payment term")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Payment term deleted"})
+}
+
+// GetCustomerTerm returns the payment term applied to a customer's invoices
+func (h *PaymentTermHandler) GetCustomerTerm(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID", nil)
+		return
+	}
+
+	term, err := h.paymentTermService.GetCustomerTerm(c.Request.Context(), tenantID, customerID)
+	if err != nil {
+		response.InternalError(c, "Failed to get customer payment term")
+		return
+	}
+	if term == nil {
+		response.NotFound(c, "No payment term configured")
+		return
+	}
+
+	response.Success(c, term)
+}
+
+// SetCustomerTerm sets a customer's default payment term
+func (h *PaymentTermHandler) SetCustomerTerm(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid customer ID", nil)
+		return
+	}
+
+	var req SetCustomerTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	term, err := h.paymentTermService.SetCustomerTerm(c.Request.Context(), tenantID, customerID, req.PaymentTermID)
+	if err != nil {
+		h.handleError(c, err, "Failed to set customer payment term")
+		return
+	}
+
+	response.Success(c, term)
+}
+
+// Helper methods
+
+func (h *PaymentTermHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrPaymentTermNotFound:
+		response.NotFound(c, "Payment term not found")
+	case services.ErrInvalidPaymentTerm:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrDuplicatePaymentTerm, services.ErrPaymentTermInUse:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *PaymentTermHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
@@ -205,7 +206,8 @@ func (h *ProductHandler) GetUnitsOfMeasure(c *gin.Context) {
 	response.Success(c, gin.H{"units": models.StandardUnitsOfMeasure})
 }
 
-// Import imports products from a list
+// Import queues a product import from an uploaded CSV file. Progress and
+// failed rows are available on the returned import job.
 func (h *ProductHandler) Import(c *gin.Context) {
 	tenantID, err := h.getTenantIDFromContext(c)
 	if err != nil {
@@ -219,26 +221,24 @@ func (h *ProductHandler) Import(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Products []services.CreateProductRequest `json:"products" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "Invalid request body", nil)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file uploaded", nil)
 		return
 	}
+	defer file.Close()
 
-	successCount, errs := h.productService.ImportProducts(c.Request.Context(), tenantID, userID, req.Products)
-
-	errorMessages := make([]string, len(errs))
-	for i, err := range errs {
-		errorMessages[i] = err.Error()
+	job, err := h.productService.ImportProducts(c.Request.Context(), tenantID, userID, header.Filename, file)
+	if err != nil {
+		if err == imports.ErrFileTooLarge {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to start product import")
+		return
 	}
 
-	response.Success(c, gin.H{
-		"imported":     successCount,
-		"failed":       len(errs),
-		"errors":       errorMessages,
-	})
+	response.Accepted(c, job)
 }
 
 // UpdateStock updates product stock
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// PurchaseOrderHandler handles purchase order endpoints, with their goods
+// receipts, the bills raised from them and their three-way match
+type PurchaseOrderHandler struct {
+	poService services.PurchaseOrderService
+}
+
+// NewPurchaseOrderHandler creates a new purchase order handler
+func NewPurchaseOrderHandler(poService services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{poService: poService}
+}
+
+// List returns the tenant's purchase orders
+func (h *PurchaseOrderHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.PurchaseOrderFilters{
+		Status:   c.Query("status"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+		Page:     1,
+		Limit:    20,
+	}
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		if vid, err := uuid.Parse(vendorID); err == nil {
+			filters.VendorID = vid
+		}
+	}
+
+	orders, total, err := h.poService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list purchase orders")
+		return
+	}
+
+	response.Paginated(c, orders, filters.Page, filters.Limit, total)
+}
+
+// Create creates a draft purchase order
+func (h *PurchaseOrderHandler) Create(c *gin.Context) {
+	var req services.CreatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+
+	po, err := h.poService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create purchase order")
+		return
+	}
+
+	response.Created(c, po)
+}
+
+// Get returns a purchase order
+func (h *PurchaseOrderHandler) Get(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	po, err := h.poService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get purchase order")
+		return
+	}
+
+	response.Success(c, po)
+}
+
+// Update edits a draft purchase order
+func (h *PurchaseOrderHandler) Update(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.UpdatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	po, err := h.poService.Update(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update purchase order")
+		return
+	}
+
+	response.Success(c, po)
+}
+
+// Delete deletes a draft purchase order
+func (h *PurchaseOrderHandler) Delete(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.poService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete purchase order")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Purchase order deleted"})
+}
+
+// Issue issues a draft purchase order to the vendor
+func (h *PurchaseOrderHandler) Issue(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	po, err := h.poService.Issue(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to issue purchase order")
+		return
+	}
+
+	response.Success(c, po)
+}
+
+// Close short-closes a purchase order, with an optional reason
+func (h *PurchaseOrderHandler) Close(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	po, err := h.poService.Close(c.Request.Context(), tenantID, id, req.Reason)
+	if err != nil {
+		h.handleError(c, err, "Failed to close purchase order")
+		return
+	}
+
+	response.Success(c, po)
+}
+
+// Cancel cancels a purchase order nothing was received or billed against
+func (h *PurchaseOrderHandler) Cancel(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	po, err := h.poService.Cancel(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to cancel purchase order")
+		return
+	}
+
+	response.Success(c, po)
+}
+
+// Receive records goods received against a purchase order
+func (h *PurchaseOrderHandler) Receive(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ReceiveGoodsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.ReceivedBy = userID
+
+	receipt, err := h.poService.Receive(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to record goods receipt")
+		return
+	}
+
+	response.Created(c, receipt)
+}
+
+// ListReceipts returns the goods receipts of a purchase order
+func (h *PurchaseOrderHandler) ListReceipts(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	receipts, err := h.poService.ListReceipts(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to list goods receipts")
+		return
+	}
+
+	response.Success(c, receipts)
+}
+
+// CreateBill raises the vendor's bill from a purchase order and returns it
+// with the order's three-way match
+func (h *PurchaseOrderHandler) CreateBill(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req services.BillPurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	bill, err := h.poService.CreateBill(c.Request.Context(), id, req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to create bill from purchase order")
+		return
+	}
+
+	response.Created(c, bill)
+}
+
+// Match returns the three-way match of a purchase order against its goods
+// receipts and bills
+func (h *PurchaseOrderHandler) Match(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	match, err := h.poService.Match(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to match purchase order")
+		return
+	}
+
+	response.Success(c, match)
+}
+
+// OpenReport lists the purchase orders with goods still to be received or
+// billed, optionally for one vendor
+func (h *PurchaseOrderHandler) OpenReport(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	var vendorID uuid.UUID
+	if vendor := c.Query("vendor_id"); vendor != "" {
+		id, err := uuid.Parse(vendor)
+		if err != nil {
+			response.BadRequest(c, "Invalid vendor ID", nil)
+			return
+		}
+		vendorID = id
+	}
+
+	report, err := h.poService.OpenReport(c.Request.Context(), tenantID, vendorID)
+	if err != nil {
+		response.InternalError(c, "Failed to generate open purchase orders report")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Helper methods
+
+func (h *PurchaseOrderHandler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid purchase order ID", nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *PurchaseOrderHandler) handleError(c *gin.Context, err error, message string) {
+	if _, ok := err.(*time.ParseError); ok {
+		response.BadRequest(c, "Invalid date format", nil)
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrPurchaseOrderNotFound):
+		response.NotFound(c, "Purchase order not found")
+	case errors.Is(err, services.ErrInvalidPurchaseOrder), errors.Is(err, services.ErrInvalidGoodsReceipt),
+		errors.Is(err, services.ErrInvalidBill):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrPurchaseOrderNotEditable), errors.Is(err, services.ErrPurchaseOrderNotReceiving),
+		errors.Is(err, services.ErrPurchaseOrderNotBillable), errors.Is(err, services.ErrPurchaseOrderNotClosable),
+		errors.Is(err, services.ErrPurchaseOrderInUse), errors.Is(err, services.ErrReceiptExceedsOrder),
+		errors.Is(err, services.ErrNothingToBill):
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *PurchaseOrderHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *PurchaseOrderHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
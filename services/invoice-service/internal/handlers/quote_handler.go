@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// QuoteHandler handles quotation endpoints, and the customer's page to
+// accept or decline a quote
+type QuoteHandler struct {
+	quoteService services.QuoteService
+}
+
+// NewQuoteHandler creates a new quote handler
+func NewQuoteHandler(quoteService services.QuoteService) *QuoteHandler {
+	return &QuoteHandler{quoteService: quoteService}
+}
+
+// List returns the tenant's quotes
+func (h *QuoteHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	filters := repository.QuoteFilters{
+		Status:   c.Query("status"),
+		FromDate: c.Query("from_date"),
+		ToDate:   c.Query("to_date"),
+		Page:     1,
+		Limit:    20,
+	}
+	if customerID := c.Query("customer_id"); customerID != "" {
+		if cid, err := uuid.Parse(customerID); err == nil {
+			filters.CustomerID = cid
+		}
+	}
+
+	quotes, total, err := h.quoteService.List(c.Request.Context(), tenantID, filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list quotes")
+		return
+	}
+
+	response.Paginated(c, quotes, filters.Page, filters.Limit, total)
+}
+
+// Create creates a draft quote
+func (h *QuoteHandler) Create(c *gin.Context) {
+	var req services.CreateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	if req.Language == "" {
+		// Default to the language the user is working in
+		req.Language = i18n.FromContext(c)
+	}
+
+	quote, err := h.quoteService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create quote")
+		return
+	}
+
+	response.Created(c, quote)
+}
+
+// Get returns a quote
+func (h *QuoteHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid quote ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	quote, err := h.quoteService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get quote")
+		return
+	}
+
+	response.Success(c, quote)
+}
+
+// Update edits a draft quote
+func (h *QuoteHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid quote ID", nil)
+		return
+	}
+
+	var req services.UpdateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	quote, err := h.quoteService.Update(c.Request.Context(), tenantID, id, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update quote")
+		return
+	}
+
+	response.Success(c, quote)
+}
+
+// Delete deletes a draft quote
+func (h *QuoteHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid quote ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	if err := h.quoteService.Delete(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err, "Failed to delete quote")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Quote deleted"})
+}
+
+// Send sends a quote to the customer and returns its accept link
+func (h *QuoteHandler) Send(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid quote ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	sent, err := h.quoteService.Send(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to send quote")
+		return
+	}
+
+	response.Success(c, sent)
+}
+
+// Convert raises a draft invoice from a quote with its items and taxes
+func (h *QuoteHandler) Convert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid quote ID", nil)
+		return
+	}
+
+	var req services.ConvertQuoteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", nil)
+			return
+		}
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+	req.Authorization = c.GetHeader("Authorization")
+
+	invoice, err := h.quoteService.Convert(c.Request.Context(), id, req)
+	if err != nil {
+		if periodLocked(c, err) {
+			return
+		}
+		if brokeRules(c, err) {
+			return
+		}
+		h.handleError(c, err, "Failed to convert quote")
+		return
+	}
+
+	response.Created(c, invoice)
+}
+
+// GetPublic returns the quote a customer's link is for (public)
+func (h *QuoteHandler) GetPublic(c *gin.Context) {
+	quote, err := h.quoteService.GetByToken(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.handleError(c, err, "Failed to get quote")
+		return
+	}
+
+	response.Success(c, publicQuote(quote))
+}
+
+// Accept records the customer's acceptance of a quote (public)
+func (h *QuoteHandler) Accept(c *gin.Context) {
+	var req services.AcceptQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	req.IP = c.ClientIP()
+
+	quote, err := h.quoteService.Accept(c.Request.Context(), c.Param("token"), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to accept quote")
+		return
+	}
+
+	response.Success(c, publicQuote(quote))
+}
+
+// Decline records the customer's refusal of a quote (public)
+func (h *QuoteHandler) Decline(c *gin.Context) {
+	var req services.DeclineQuoteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", nil)
+			return
+		}
+	}
+	req.IP = c.ClientIP()
+
+	quote, err := h.quoteService.Decline(c.Request.Context(), c.Param("token"), req)
+	if err != nil {
+		h.handleError(c, err, "Failed to decline quote")
+		return
+	}
+
+	response.Success(c, publicQuote(quote))
+}
+
+// Helper methods
+
+// publicQuote is what the customer sees of a quote
+func publicQuote(quote *models.Quote) gin.H {
+	return gin.H{
+		"quote_number":    quote.QuoteNumber,
+		"customer_name":   quote.CustomerName,
+		"quote_date":      quote.QuoteDate,
+		"valid_until":     quote.ValidUntil,
+		"status":          quote.Status,
+		"items":           quote.Items,
+		"subtotal":        quote.Subtotal,
+		"discount_amount": quote.DiscountAmount,
+		"taxable_amount":  quote.TaxableAmount,
+		"total_tax":       quote.TotalTax,
+		"total_amount":    quote.TotalAmount,
+		"notes":           quote.Notes,
+		"terms":           quote.Terms,
+		"language":        quote.Language,
+		"accepted_by":     quote.AcceptedBy,
+		"responded_at":    quote.RespondedAt,
+	}
+}
+
+func (h *QuoteHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrQuoteNotFound):
+		response.NotFound(c, "Quote not found")
+	case errors.Is(err, services.ErrInvalidQuoteLink):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidQuote), errors.Is(err, services.ErrInvalidInvoice),
+		errors.Is(err, services.ErrPaymentTermNotFound):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrQuoteNotEditable), errors.Is(err, services.ErrQuoteNotSendable),
+		errors.Is(err, services.ErrQuoteExpired), errors.Is(err, services.ErrQuoteAnswered),
+		errors.Is(err, services.ErrQuoteNotConvertible), errors.Is(err, services.ErrQuoteConverted):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, services.ErrTCSUnavailable):
+		response.ServiceUnavailable(c, "Unable to determine TCS for invoice")
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *QuoteHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *QuoteHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -40,6 +40,11 @@ func (h *RecurringInvoiceHandler) List(c *gin.Context) {
 			filters.CustomerID = id
 		}
 	}
+	if contractID := c.Query("contract_id"); contractID != "" {
+		if id, err := uuid.Parse(contractID); err == nil {
+			filters.ContractID = id
+		}
+	}
 	if search := c.Query("search"); search != "" {
 		filters.Search = search
 	}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// RoundingHandler handles rounding rule endpoints
+type RoundingHandler struct {
+	roundingService services.RoundingService
+}
+
+// NewRoundingHandler creates a new rounding handler
+func NewRoundingHandler(roundingService services.RoundingService) *RoundingHandler {
+	return &RoundingHandler{roundingService: roundingService}
+}
+
+// List returns the effective rounding rule for each document type
+func (h *RoundingHandler) List(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	rules, err := h.roundingService.ListRules(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list rounding rules")
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// Update configures rounding for a document type
+func (h *RoundingHandler) Update(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req services.UpdateRoundingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	docType := models.DocumentType(c.Param("document_type"))
+	rule, err := h.roundingService.UpdateRule(c.Request.Context(), tenantID, docType, req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidDocumentType, services.ErrInvalidRoundingMode, services.ErrInvalidRoundingValue:
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to update rounding rule")
+		}
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// Helper methods
+
+func (h *RoundingHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// SelfInvoiceHandler handles self-invoice endpoints
+type SelfInvoiceHandler struct {
+	selfInvoiceService services.SelfInvoiceService
+}
+
+// NewSelfInvoiceHandler creates a new self-invoice handler
+func NewSelfInvoiceHandler(selfInvoiceService services.SelfInvoiceService) *SelfInvoiceHandler {
+	return &SelfInvoiceHandler{selfInvoiceService: selfInvoiceService}
+}
+
+// Generate issues the self-invoice for a bill flagged URD-RCM
+func (h *SelfInvoiceHandler) Generate(c *gin.Context) {
+	billID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid bill ID", nil)
+		return
+	}
+
+	var req services.GenerateSelfInvoiceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", nil)
+			return
+		}
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.CreatedBy = userID
+
+	invoice, err := h.selfInvoiceService.Generate(c.Request.Context(), billID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to generate self-invoice")
+		return
+	}
+
+	response.Created(c, invoice)
+}
+
+// List returns the self-invoices dated between from_date and to_date
+func (h *SelfInvoiceHandler) List(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	var from time.Time
+	to := time.Now()
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		parsed, err := time.Parse("2006-01-02", fromDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid from_date", nil)
+			return
+		}
+		from = parsed
+	}
+	if toDate := c.Query("to_date"); toDate != "" {
+		parsed, err := time.Parse("2006-01-02", toDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid to_date", nil)
+			return
+		}
+		to = parsed
+	}
+
+	invoices, err := h.selfInvoiceService.List(c.Request.Context(), tenantID, from, to)
+	if err != nil {
+		response.InternalError(c, "Failed to list self-invoices")
+		return
+	}
+
+	response.Success(c, invoices)
+}
+
+// Get returns a self-invoice
+func (h *SelfInvoiceHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid self-invoice ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	invoice, err := h.selfInvoiceService.Get(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get self-invoice")
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// GSTR3B returns what a period's self-invoices add to GSTR-3B tables 3.1(d)
+// and 4(A)(3)
+func (h *SelfInvoiceHandler) GSTR3B(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+
+	tax, err := h.selfInvoiceService.GSTR3B(c.Request.Context(), tenantID, c.Query("period"))
+	if err != nil {
+		h.handleError(c, err, "Failed to summarise self-invoices")
+		return
+	}
+
+	response.Success(c, tax)
+}
+
+// Helper methods
+
+func (h *SelfInvoiceHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrBillNotFound:
+		response.NotFound(c, "Bill not found")
+	case services.ErrSelfInvoiceNotFound:
+		response.NotFound(c, "Self-invoice not found")
+	case services.ErrInvalidSelfInvoice, services.ErrNotReverseCharge, services.ErrInvalidPeriod:
+		response.BadRequest(c, err.Error(), nil)
+	case services.ErrBillNotApproved, services.ErrBillSelfInvoiced:
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *SelfInvoiceHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *SelfInvoiceHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
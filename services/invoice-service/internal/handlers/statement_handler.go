@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// StatementHandler handles customer statement run endpoints
+type StatementHandler struct {
+	statementService services.StatementService
+}
+
+// NewStatementHandler creates a new statement handler
+func NewStatementHandler(statementService services.StatementService) *StatementHandler {
+	return &StatementHandler{statementService: statementService}
+}
+
+// StartRun queues statements of a month for every customer with a balance.
+// An empty body sends last month's statements to everyone owing anything.
+func (h *StatementHandler) StartRun(c *gin.Context) {
+	var req services.StartStatementRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	userID, _ := h.getUserIDFromContext(c)
+	req.TenantID = tenantID
+	req.UserID = userID
+
+	run, err := h.statementService.StartRun(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to start statement run")
+		return
+	}
+
+	response.Accepted(c, run)
+}
+
+// ListRuns returns the statement runs, latest first
+func (h *StatementHandler) ListRuns(c *gin.Context) {
+	tenantID, _ := h.getTenantIDFromContext(c)
+	runs, err := h.statementService.ListRuns(c.Request.Context(), tenantID)
+	if err != nil {
+		response.InternalError(c, "Failed to list statement runs")
+		return
+	}
+
+	response.Success(c, runs)
+}
+
+// GetRun returns a statement run with the delivery status of each customer
+func (h *StatementHandler) GetRun(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid statement run ID", nil)
+		return
+	}
+
+	tenantID, _ := h.getTenantIDFromContext(c)
+	run, err := h.statementService.GetRun(c.Request.Context(), tenantID, id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get statement run")
+		return
+	}
+
+	response.Success(c, run)
+}
+
+func (h *StatementHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrStatementRunNotFound:
+		response.NotFound(c, "Statement run not found")
+	case services.ErrStatementRunActive:
+		response.Conflict(c, err.Error())
+	case services.ErrInvalidStatementRun:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message)
+	}
+}
+
+func (h *StatementHandler) getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(userIDStr.(string))
+}
+
+func (h *StatementHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/services"
+)
+
+// TaxSnapshotHandler serves the tax calculation captured on finalized documents
+type TaxSnapshotHandler struct {
+	snapshotService services.TaxSnapshotService
+}
+
+// NewTaxSnapshotHandler creates a new tax snapshot handler
+func NewTaxSnapshotHandler(snapshotService services.TaxSnapshotService) *TaxSnapshotHandler {
+	return &TaxSnapshotHandler{snapshotService: snapshotService}
+}
+
+// GetInvoiceSnapshot returns the tax snapshot of a sent invoice
+func (h *TaxSnapshotHandler) GetInvoiceSnapshot(c *gin.Context) {
+	h.get(c, models.DocumentTypeInvoice)
+}
+
+// GetBillSnapshot returns the tax snapshot of an approved bill
+func (h *TaxSnapshotHandler) GetBillSnapshot(c *gin.Context) {
+	h.get(c, models.DocumentTypeBill)
+}
+
+func (h *TaxSnapshotHandler) get(c *gin.Context, docType models.DocumentType) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	docID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid document ID", nil)
+		return
+	}
+
+	snapshot, err := h.snapshotService.Get(c.Request.Context(), tenantID, docType, docID)
+	if err != nil {
+		if err == services.ErrTaxSnapshotNotFound {
+			response.NotFound(c, "No tax snapshot for this document; it is captured when the document is finalized")
+			return
+		}
+		response.InternalError(c, "Failed to get tax snapshot")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"snapshot": snapshot,
+		"verified": snapshot.Verify(),
+	})
+}
+
+// Helper methods
+
+func (h *TaxSnapshotHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, http.ErrNoLocation
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// AdvanceReceiptStatus represents the status of an advance receipt
+type AdvanceReceiptStatus string
+
+const (
+	AdvanceReceiptStatusOpen     AdvanceReceiptStatus = "open"
+	AdvanceReceiptStatusRefunded AdvanceReceiptStatus = "refunded" // Refunded in full
+)
+
+// AdvanceReceipt is the receipt voucher (rule 50 of the CGST Rules) issued
+// for an advance received before the invoice. GST is paid on advances for
+// services when they are received, so the tax is worked out of the amount
+// received.
+type AdvanceReceipt struct {
+	ID            uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID            `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	ReceiptNumber string               `gorm:"size:50;uniqueIndex:idx_tenant_advance_num" json:"receipt_number"`
+	ReceiptDate   time.Time            `gorm:"not null" json:"receipt_date"`
+	Status        AdvanceReceiptStatus `gorm:"size:20;default:'open'" json:"status"`
+
+	CustomerID    uuid.UUID `gorm:"type:uuid;index" json:"customer_id"`
+	CustomerName  string    `gorm:"size:200" json:"customer_name"`
+	CustomerGSTIN string    `gorm:"size:15" json:"customer_gstin,omitempty"`
+	PlaceOfSupply string    `gorm:"size:2" json:"place_of_supply"` // State code, e.g. "27"
+	Description   string    `gorm:"type:text" json:"description"`
+
+	// Amount is what was received, GST included
+	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	CGSTRate      decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate      decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate      decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate      decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	TaxableAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"taxable_amount"`
+	CGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+	TotalTax      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
+
+	AmountRefunded decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"amount_refunded"`
+	Balance        decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"balance"`
+
+	PaymentMethod string `gorm:"size:50" json:"payment_method"`
+	Reference     string `gorm:"size:100" json:"reference"`
+
+	Refunds []RefundVoucher `gorm:"foreignKey:AdvanceReceiptID" json:"refunds,omitempty"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for AdvanceReceipt
+func (AdvanceReceipt) TableName() string {
+	return "advance_receipts"
+}
+
+// BeforeCreate hook
+func (a *AdvanceReceipt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// TotalRate is the GST and cess rate on the advance
+func (a *AdvanceReceipt) TotalRate() decimal.Decimal {
+	return a.CGSTRate.Add(a.SGSTRate).Add(a.IGSTRate).Add(a.CessRate)
+}
+
+// CalculateTax works the tax out of the amount received. CGST and SGST are
+// split evenly from their total so the parts add up.
+func (a *AdvanceReceipt) CalculateTax() {
+	tax := splitTax(a.Amount, a.CGSTRate, a.SGSTRate, a.IGSTRate, a.CessRate, a.TotalRate())
+	a.CGSTAmount, a.SGSTAmount, a.IGSTAmount, a.CessAmount = tax[0], tax[1], tax[2], tax[3]
+	a.TotalTax = a.CGSTAmount.Add(a.SGSTAmount).Add(a.IGSTAmount).Add(a.CessAmount)
+	a.TaxableAmount = a.Amount.Sub(a.TotalTax)
+	a.Balance = a.Amount.Sub(a.AmountRefunded)
+}
+
+// RefundVoucher is the refund voucher (rule 51 of the CGST Rules) issued
+// when an advance is returned without the supply being made. The GST paid
+// on the refunded part of the advance is reversed.
+type RefundVoucher struct {
+	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID         uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	VoucherNumber    string    `gorm:"size:50;uniqueIndex:idx_tenant_refund_num" json:"voucher_number"`
+	VoucherDate      time.Time `gorm:"not null" json:"voucher_date"`
+	AdvanceReceiptID uuid.UUID `gorm:"type:uuid;index;not null" json:"advance_receipt_id"`
+	ReceiptNumber    string    `gorm:"size:50" json:"receipt_number"`
+	ReceiptDate      time.Time `json:"receipt_date"`
+
+	CustomerID    uuid.UUID `gorm:"type:uuid;index" json:"customer_id"`
+	CustomerName  string    `gorm:"size:200" json:"customer_name"`
+	CustomerGSTIN string    `gorm:"size:15" json:"customer_gstin,omitempty"`
+	PlaceOfSupply string    `gorm:"size:2" json:"place_of_supply"`
+
+	// Amount is what was refunded, GST included; the tax is reversed at the
+	// advance's rates
+	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	TaxableAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"taxable_amount"`
+	CGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+	TotalTax      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
+
+	Reason        string `gorm:"type:text" json:"reason"`
+	PaymentMethod string `gorm:"size:50" json:"payment_method"`
+	Reference     string `gorm:"size:100" json:"reference"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for RefundVoucher
+func (RefundVoucher) TableName() string {
+	return "refund_vouchers"
+}
+
+// BeforeCreate hook
+func (v *RefundVoucher) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateTax reverses the advance's GST on the refunded amount. A refund
+// of the whole balance takes whatever tax is left on the advance, so
+// rounding never leaves tax behind.
+func (v *RefundVoucher) CalculateTax(advance *AdvanceReceipt) {
+	if v.Amount.Equal(advance.Balance) {
+		refunded := advance.Refunds
+		v.CGSTAmount, v.SGSTAmount = advance.CGSTAmount, advance.SGSTAmount
+		v.IGSTAmount, v.CessAmount = advance.IGSTAmount, advance.CessAmount
+		for _, r := range refunded {
+			v.CGSTAmount = v.CGSTAmount.Sub(r.CGSTAmount)
+			v.SGSTAmount = v.SGSTAmount.Sub(r.SGSTAmount)
+			v.IGSTAmount = v.IGSTAmount.Sub(r.IGSTAmount)
+			v.CessAmount = v.CessAmount.Sub(r.CessAmount)
+		}
+	} else {
+		tax := splitTax(v.Amount, advance.CGSTRate, advance.SGSTRate, advance.IGSTRate, advance.CessRate, advance.TotalRate())
+		v.CGSTAmount, v.SGSTAmount, v.IGSTAmount, v.CessAmount = tax[0], tax[1], tax[2], tax[3]
+	}
+	v.TotalTax = v.CGSTAmount.Add(v.SGSTAmount).Add(v.IGSTAmount).Add(v.CessAmount)
+	v.TaxableAmount = v.Amount.Sub(v.TotalTax)
+}
+
+// splitTax works out the CGST, SGST, IGST and cess included in a
+// tax-inclusive amount
+func splitTax(amount, cgstRate, sgstRate, igstRate, cessRate, totalRate decimal.Decimal) [4]decimal.Decimal {
+	hundred := decimal.NewFromInt(100)
+	taxable := amount.Mul(hundred).Div(hundred.Add(totalRate))
+	part := func(rate decimal.Decimal) decimal.Decimal {
+		return taxable.Mul(rate).Div(hundred).Round(2)
+	}
+
+	cgstSGST := part(cgstRate.Add(sgstRate))
+	cgst := decimal.Zero
+	if cgstSGST.IsPositive() {
+		cgst = cgstSGST.Div(decimal.NewFromInt(2)).RoundDown(2)
+	}
+	return [4]decimal.Decimal{cgst, cgstSGST.Sub(cgst), part(igstRate), part(cessRate)}
+}
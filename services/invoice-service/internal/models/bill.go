@@ -1,10 +1,12 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
 	"gorm.io/gorm"
 )
 
@@ -30,6 +32,7 @@ type Bill struct {
 	VendorID      uuid.UUID       `gorm:"type:uuid;index" json:"vendor_id"`
 	VendorName    string          `gorm:"size:200" json:"vendor_name"`
 	VendorGSTIN   string          `gorm:"size:15" json:"vendor_gstin,omitempty"`
+	VendorPAN     string          `gorm:"size:10" json:"vendor_pan,omitempty"`
 	VendorAddress string          `gorm:"type:text" json:"vendor_address"`
 	VendorState   string          `gorm:"size:50" json:"vendor_state"`
 	VendorEmail   string          `gorm:"size:255" json:"vendor_email"`
@@ -54,14 +57,22 @@ type Bill struct {
 	CessAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
 	TotalTax       decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
 
-	// TDS fields
+	// TDS fields (TDS is deducted as payments are made; TDSAmount is the
+	// total withheld so far)
 	TDSApplicable  bool            `gorm:"default:false" json:"tds_applicable"`
 	TDSSection     string          `gorm:"size:20" json:"tds_section,omitempty"`
 	TDSRate        decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"tds_rate"`
 	TDSAmount      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"tds_amount"`
 
+	// Rounding (applied to the bill value before TDS)
+	RoundingMode      RoundingMode    `gorm:"size:20" json:"rounding_mode,omitempty"`
+	RoundTo           decimal.Decimal `gorm:"type:decimal(10,2);default:0" json:"round_to"`
+	RoundOff          decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"round_off"`
+	RoundingAccountID *uuid.UUID      `gorm:"type:uuid" json:"rounding_account_id,omitempty"`
+
 	TotalAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
 	AmountPaid     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"amount_paid"`
+	AmountDebited  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"amount_debited"` // Set off by debit notes
 	BalanceDue     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"balance_due"`
 
 	// ITC eligibility
@@ -69,6 +80,24 @@ type Bill struct {
 	ITCCategory    string `gorm:"size:20" json:"itc_category"` // goods, services, capital
 	ITCClaimedDate *time.Time `json:"itc_claimed_date,omitempty"`
 
+	// Reverse charge on purchases from unregistered vendors. The GST is paid
+	// by us rather than charged by the vendor, so it is left out of the
+	// amount owed, and a self-invoice has to be issued for the purchase.
+	URDReverseCharge  bool       `gorm:"default:false" json:"urd_rcm"`
+	SelfInvoiceID     *uuid.UUID `gorm:"type:uuid" json:"self_invoice_id,omitempty"`
+	SelfInvoiceNumber string     `gorm:"size:50" json:"self_invoice_number,omitempty"`
+
+	// Purchase order the bill was raised from, matched against what was
+	// ordered and received
+	PurchaseOrderID     *uuid.UUID `gorm:"type:uuid;index" json:"purchase_order_id,omitempty"`
+	PurchaseOrderNumber string     `gorm:"size:50" json:"purchase_order_number,omitempty"`
+
+	// Warnings about the vendor's GST registration or filing record, returned
+	// when the bill is entered
+	Warnings []string `gorm:"-" json:"warnings,omitempty"`
+	// Lines breaking the expense policy, which the approver has to override
+	PolicyViolations []ExpensePolicyViolation `gorm:"-" json:"policy_violations,omitempty"`
+
 	Notes          string         `gorm:"type:text" json:"notes"`
 	Attachments    string         `gorm:"type:jsonb" json:"attachments"` // JSON array of attachment URLs
 	ApprovedBy     *uuid.UUID     `gorm:"type:uuid" json:"approved_by,omitempty"`
@@ -118,13 +147,40 @@ func (b *Bill) CalculateTotals() {
 	b.TaxableAmount = b.Subtotal.Sub(b.DiscountAmount)
 	b.TotalTax = b.CGSTAmount.Add(b.SGSTAmount).Add(b.IGSTAmount).Add(b.CessAmount)
 
-	// Calculate TDS if applicable
-	if b.TDSApplicable && b.TDSRate.GreaterThan(decimal.Zero) {
-		b.TDSAmount = b.TaxableAmount.Mul(b.TDSRate.Div(decimal.NewFromInt(100)))
+	grossTotal := b.TaxableAmount.Add(b.TotalTax)
+	if b.URDReverseCharge {
+		grossTotal = b.TaxableAmount
 	}
+	b.TotalAmount = RoundAmount(grossTotal, b.RoundTo, b.RoundingMode)
+	b.RoundOff = b.TotalAmount.Sub(grossTotal)
 
-	b.TotalAmount = b.TaxableAmount.Add(b.TotalTax).Sub(b.TDSAmount)
-	b.BalanceDue = b.TotalAmount.Sub(b.AmountPaid)
+	b.BalanceDue = b.TotalAmount.Sub(b.AmountPaid).Sub(b.AmountDebited)
+}
+
+// TDSBase returns the part of amount (a settlement against the bill) on which
+// TDS is computed. TDS is levied on the value excluding GST.
+func (b *Bill) TDSBase(amount decimal.Decimal) decimal.Decimal {
+	if !b.TotalAmount.IsPositive() {
+		return decimal.Zero
+	}
+	return amount.Mul(b.TaxableAmount).Div(b.TotalAmount).Round(2)
+}
+
+// HasReceipt reports whether the bill is backed by the vendor's own bill,
+// either its number or an attachment
+func (b *Bill) HasReceipt() bool {
+	if b.VendorBillNo != "" {
+		return true
+	}
+	attachments := strings.TrimSpace(b.Attachments)
+	return attachments != "" && attachments != "[]" && attachments != "null"
+}
+
+// ApplyRoundingRule copies the rounding settings onto the bill
+func (b *Bill) ApplyRoundingRule(rule *RoundingRule) {
+	b.RoundingMode = rule.Mode
+	b.RoundTo = rule.RoundTo
+	b.RoundingAccountID = rule.RoundingAccountID
 }
 
 // BillItem represents a line item in a bill
@@ -141,10 +197,14 @@ type BillItem struct {
 	Amount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
 
 	// Tax rates
-	CGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
-	SGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
-	IGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
-	CessRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+
+	// Specific cess per unit of quantity, charged on top of the ad valorem
+	// CessRate (e.g. ₹4.17 per stick for ₹4,170 per thousand cigarettes)
+	CessSpecificRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"cess_specific_rate"`
 
 	// Tax amounts
 	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
@@ -152,9 +212,14 @@ type BillItem struct {
 	IGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
 	CessAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
 
-	// ITC
-	ITCEligible bool            `gorm:"default:true" json:"itc_eligible"`
-	TotalAmount decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_amount"`
+	// ITC. Lines whose credit is blocked under section 17(5) are marked
+	// ineligible when the bill is entered.
+	ITCEligible      bool            `gorm:"default:true" json:"itc_eligible"`
+	ITCBlockedReason string          `gorm:"size:255" json:"itc_blocked_reason,omitempty"`
+	ExpenseAccountID *uuid.UUID      `gorm:"type:uuid" json:"expense_account_id,omitempty"`
+	ExpenseCategory  string          `gorm:"size:100" json:"expense_category,omitempty"` // Checked against the expense policy
+	PurchaseOrderItemID *uuid.UUID   `gorm:"type:uuid;index" json:"purchase_order_item_id,omitempty"`
+	TotalAmount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_amount"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
 }
@@ -180,7 +245,7 @@ func (i *BillItem) CalculateAmounts() {
 	i.CGSTAmount = i.Amount.Mul(i.CGSTRate.Div(hundred))
 	i.SGSTAmount = i.Amount.Mul(i.SGSTRate.Div(hundred))
 	i.IGSTAmount = i.Amount.Mul(i.IGSTRate.Div(hundred))
-	i.CessAmount = i.Amount.Mul(i.CessRate.Div(hundred))
+	i.CessAmount = i.Amount.Mul(i.CessRate.Div(hundred)).Add(i.Quantity.Mul(i.CessSpecificRate))
 
 	i.TotalAmount = i.Amount.Add(i.CGSTAmount).Add(i.SGSTAmount).Add(i.IGSTAmount).Add(i.CessAmount)
 }
@@ -192,11 +257,24 @@ type BillPayment struct {
 	BillID        uuid.UUID       `gorm:"type:uuid;index;not null" json:"bill_id"`
 	PaymentNumber string          `gorm:"size:50" json:"payment_number"`
 	PaymentDate   time.Time       `gorm:"not null" json:"payment_date"`
-	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
-	PaymentMethod string          `gorm:"size:50" json:"payment_method"` // cash, bank, upi, card
+	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"` // paid to the vendor
+	PaymentMethod string          `gorm:"size:50" json:"payment_method"` // cash, bank, upi, card, cheque
+	AmountInWords string          `gorm:"-" json:"amount_in_words"`      // As written on a cheque
+
+	// TDS withheld from this payment; Amount + TDSAmount is settled against the bill
+	TDSSection string          `gorm:"size:20" json:"tds_section,omitempty"`
+	TDSRate    decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"tds_rate"`
+	TDSAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"tds_amount"`
+
 	BankAccountID *uuid.UUID      `gorm:"type:uuid" json:"bank_account_id,omitempty"`
 	Reference     string          `gorm:"size:100" json:"reference"`
 	Notes         string          `gorm:"type:text" json:"notes"`
+
+	// Warnings about cash limits, returned when the payment is recorded
+	Warnings []string `gorm:"-" json:"warnings,omitempty"`
+	// Lines breaking the expense policy, which the approver has to override
+	PolicyViolations []ExpensePolicyViolation `gorm:"-" json:"policy_violations,omitempty"`
+
 	CreatedBy     uuid.UUID       `gorm:"type:uuid" json:"created_by"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
@@ -215,3 +293,21 @@ func (p *BillPayment) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// AfterCreate fills in the amount in words, which is not stored
+func (p *BillPayment) AfterCreate(tx *gorm.DB) error {
+	p.setAmountInWords()
+	return nil
+}
+
+// AfterFind fills in the amount in words
+func (p *BillPayment) AfterFind(tx *gorm.DB) error {
+	p.setAmountInWords()
+	return nil
+}
+
+// setAmountInWords spells the amount in English, which banks require on
+// cheques
+func (p *BillPayment) setAmountInWords() {
+	p.AmountInWords = i18n.AmountInWords(i18n.English, toPaise(p.Amount))
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// BillMatchSettings configures how far a tenant's bills may drift before
+// the bill match report flags them
+type BillMatchSettings struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primary_key" json:"tenant_id"`
+
+	// Payments may settle up to this percentage more than the bill's total
+	// before being flagged; 0 flags any overpayment
+	PaymentTolerancePct decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"payment_tolerance_pct"`
+	// Bills raised from a purchase order may bill up to this percentage more
+	// than was received on a line; 0 flags any excess
+	QuantityTolerancePct decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"quantity_tolerance_pct"`
+	// ... and charge up to this percentage over the order's rate
+	RateTolerancePct decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"rate_tolerance_pct"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for BillMatchSettings
+func (BillMatchSettings) TableName() string {
+	return "bill_match_settings"
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Income-tax sections limiting cash dealings
+const (
+	// CashSection40A3 disallows an expense paid to a person in cash above
+	// ₹10,000 in a day
+	CashSection40A3 = "40A(3)"
+	// CashSection269ST bars receiving ₹2,00,000 or more in cash from a
+	// person in a day or for a single transaction
+	CashSection269ST = "269ST"
+)
+
+// CashLimitMode is how payments and receipts over the cash limits are
+// handled
+type CashLimitMode string
+
+const (
+	CashLimitWarn  CashLimitMode = "warn"  // Recorded with a warning and flagged
+	CashLimitBlock CashLimitMode = "block" // Refused unless an override reason is given
+)
+
+// CashLimitSettings configures a tenant's cash limit checks
+type CashLimitSettings struct {
+	TenantID uuid.UUID     `gorm:"type:uuid;primary_key" json:"tenant_id"`
+	Mode     CashLimitMode `gorm:"size:10;not null;default:'block'" json:"mode"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for CashLimitSettings
+func (CashLimitSettings) TableName() string {
+	return "cash_limit_settings"
+}
+
+// CashLimitFlag records a cash payment or receipt that took the party over
+// a statutory cash limit, for the CA to review at audit
+type CashLimitFlag struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null;index:idx_cash_limit_flag_date" json:"tenant_id"`
+	Section  string    `gorm:"size:10;not null" json:"section"`
+
+	PartyID        uuid.UUID `gorm:"type:uuid;index" json:"party_id"`
+	PartyName      string    `gorm:"size:200" json:"party_name"`
+	DocumentType   string    `gorm:"size:20;not null" json:"document_type"` // invoice or bill
+	DocumentID     uuid.UUID `gorm:"type:uuid;not null" json:"document_id"`
+	DocumentNumber string    `gorm:"size:50" json:"document_number"`
+	PaymentID      uuid.UUID `gorm:"type:uuid;not null" json:"payment_id"`
+
+	PaymentDate time.Time       `gorm:"type:date;not null;index:idx_cash_limit_flag_date" json:"payment_date"`
+	Amount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"` // Of this payment
+	Total       decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total"`  // In cash with the party that day, or on the document, with this payment
+	Limit       decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"limit"`
+
+	// A blocked payment recorded anyway gives its reason
+	Overridden     bool   `gorm:"default:false" json:"overridden"`
+	OverrideReason string `gorm:"type:text" json:"override_reason,omitempty"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for CashLimitFlag
+func (CashLimitFlag) TableName() string {
+	return "cash_limit_flags"
+}
+
+// BeforeCreate hook
+func (f *CashLimitFlag) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
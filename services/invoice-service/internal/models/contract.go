@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ContractStatus represents the status of a contract
+type ContractStatus string
+
+const (
+	ContractStatusActive     ContractStatus = "active"
+	ContractStatusExpired    ContractStatus = "expired"
+	ContractStatusTerminated ContractStatus = "terminated"
+)
+
+// ContractRenewalType represents how a contract renews at the end of its term
+type ContractRenewalType string
+
+const (
+	RenewalTypeManual ContractRenewalType = "manual" // Renewed explicitly before the end date
+	RenewalTypeAuto   ContractRenewalType = "auto"   // Rolls over for another term unless terminated
+	RenewalTypeNone   ContractRenewalType = "none"   // Fixed term; expires at the end date
+)
+
+// Contract is an agreement with a customer or vendor. Recurring invoices
+// that bill the agreement link to it.
+type Contract struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID       uuid.UUID       `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	ContractNumber string          `gorm:"size:50;not null" json:"contract_number"`
+	Title          string          `gorm:"size:255;not null" json:"title"`
+	PartyID        uuid.UUID       `gorm:"type:uuid;not null;index" json:"party_id"`
+	PartyName      string          `gorm:"size:255" json:"party_name"`
+	Value          decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"value"`
+	StartDate      time.Time       `gorm:"type:date;not null" json:"start_date"`
+	EndDate        time.Time       `gorm:"type:date;not null;index" json:"end_date"`
+	Status         ContractStatus  `gorm:"size:20;not null;default:'active';index" json:"status"`
+
+	// Renewal terms
+	RenewalType       ContractRenewalType `gorm:"size:20;not null;default:'manual'" json:"renewal_type"`
+	RenewalTermMonths int                 `gorm:"default:12" json:"renewal_term_months"`
+	NoticePeriodDays  int                 `gorm:"default:30" json:"notice_period_days"`
+	ReminderDays      int                 `gorm:"default:30" json:"reminder_days"` // Days before the end date to send a renewal reminder
+	RenewalCount      int                 `gorm:"default:0" json:"renewal_count"`
+	ReminderSentFor   *time.Time          `gorm:"type:date" json:"reminder_sent_for,omitempty"` // End date the last reminder was sent for
+
+	// Signed agreement
+	DocumentURL  string `gorm:"size:500" json:"document_url,omitempty"`
+	DocumentName string `gorm:"size:255" json:"document_name,omitempty"`
+
+	OwnerID *uuid.UUID `gorm:"type:uuid" json:"owner_id,omitempty"` // Receives renewal reminders; defaults to the creator
+	Notes   string     `gorm:"type:text" json:"notes,omitempty"`
+
+	RecurringInvoices []RecurringInvoice `gorm:"foreignKey:ContractID" json:"recurring_invoices,omitempty"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for Contract
+func (Contract) TableName() string {
+	return "contracts"
+}
+
+// BeforeCreate hook
+func (c *Contract) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// ReminderDue reports whether a renewal reminder should be sent on asOf
+func (c *Contract) ReminderDue(asOf time.Time) bool {
+	if c.Status != ContractStatusActive || c.RenewalType == RenewalTypeNone {
+		return false
+	}
+	if c.ReminderSentFor != nil && c.ReminderSentFor.Equal(c.EndDate) {
+		return false
+	}
+	return !asOf.Before(c.EndDate.AddDate(0, 0, -c.ReminderDays))
+}
+
+// NoticeDeadline is the last day notice of non-renewal can be given
+func (c *Contract) NoticeDeadline() time.Time {
+	return c.EndDate.AddDate(0, 0, -c.NoticePeriodDays)
+}
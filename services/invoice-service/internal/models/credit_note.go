@@ -109,6 +109,30 @@ func (cn *CreditNote) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// CalculateTotals recalculates the credit note's totals from its items and
+// what has been applied or refunded
+func (cn *CreditNote) CalculateTotals() {
+	cn.Subtotal = decimal.Zero
+	cn.CGSTAmount = decimal.Zero
+	cn.SGSTAmount = decimal.Zero
+	cn.IGSTAmount = decimal.Zero
+	cn.CessAmount = decimal.Zero
+	cn.GSTAmount = decimal.Zero
+
+	for _, item := range cn.Items {
+		cn.Subtotal = cn.Subtotal.Add(item.Amount())
+		cn.CGSTAmount = cn.CGSTAmount.Add(item.CGSTAmount)
+		cn.SGSTAmount = cn.SGSTAmount.Add(item.SGSTAmount)
+		cn.IGSTAmount = cn.IGSTAmount.Add(item.IGSTAmount)
+		cn.CessAmount = cn.CessAmount.Add(item.CessAmount)
+		cn.GSTAmount = cn.GSTAmount.Add(item.GSTAmount)
+	}
+
+	cn.TotalTax = cn.CGSTAmount.Add(cn.SGSTAmount).Add(cn.IGSTAmount).Add(cn.CessAmount).Add(cn.GSTAmount)
+	cn.TotalAmount = cn.Subtotal.Add(cn.TotalTax)
+	cn.BalanceAmount = cn.TotalAmount.Sub(cn.AmountApplied).Sub(cn.AmountRefunded)
+}
+
 // CreditNoteItem represents a line item in a credit note
 type CreditNoteItem struct {
 	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -133,6 +157,8 @@ type CreditNoteItem struct {
 	SGSTAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"sgst_amount"`
 	IGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
 	IGSTAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"igst_amount"`
+	CessRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CessAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"cess_amount"`
 	GSTRate    decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"gst_rate"` // Australia
 	GSTAmount  decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"gst_amount"`
 
@@ -149,6 +175,24 @@ func (CreditNoteItem) TableName() string {
 	return "credit_note_items"
 }
 
+// Amount is the line's taxable value
+func (i *CreditNoteItem) Amount() decimal.Decimal {
+	return i.Quantity.Mul(i.UnitPrice).Round(2)
+}
+
+// CalculateAmounts calculates the line's taxes and total, as an invoice
+// line does
+func (i *CreditNoteItem) CalculateAmounts() {
+	amount := i.Amount()
+	hundred := decimal.NewFromInt(100)
+	i.CGSTAmount = amount.Mul(i.CGSTRate).Div(hundred).Round(2)
+	i.SGSTAmount = amount.Mul(i.SGSTRate).Div(hundred).Round(2)
+	i.IGSTAmount = amount.Mul(i.IGSTRate).Div(hundred).Round(2)
+	i.CessAmount = amount.Mul(i.CessRate).Div(hundred).Round(2)
+	i.GSTAmount = amount.Mul(i.GSTRate).Div(hundred).Round(2)
+	i.LineTotal = amount.Add(i.CGSTAmount).Add(i.SGSTAmount).Add(i.IGSTAmount).Add(i.CessAmount).Add(i.GSTAmount)
+}
+
 // BeforeCreate hook
 func (cni *CreditNoteItem) BeforeCreate(tx *gorm.DB) error {
 	if cni.ID == uuid.Nil {
@@ -159,9 +203,10 @@ func (cni *CreditNoteItem) BeforeCreate(tx *gorm.DB) error {
 
 // CreditNoteApplication represents an application of credit to an invoice
 type CreditNoteApplication struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	CreditNoteID uuid.UUID `gorm:"type:uuid;index;not null" json:"credit_note_id"`
-	InvoiceID    uuid.UUID `gorm:"type:uuid;index;not null" json:"invoice_id"`
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CreditNoteID  uuid.UUID `gorm:"type:uuid;index;not null" json:"credit_note_id"`
+	InvoiceID     uuid.UUID `gorm:"type:uuid;index;not null" json:"invoice_id"`
+	InvoiceNumber string    `gorm:"size:50" json:"invoice_number"`
 
 	Amount      decimal.Decimal `gorm:"type:decimal(18,2);not null" json:"amount"`
 	AppliedAt   time.Time       `gorm:"not null" json:"applied_at"`
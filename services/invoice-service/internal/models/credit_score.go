@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Credit grades, from the best payers down. Customers without invoices due
+// in the scoring window are unrated.
+const (
+	CreditGradeA       = "A"
+	CreditGradeB       = "B"
+	CreditGradeC       = "C"
+	CreditGradeD       = "D"
+	CreditGradeUnrated = "unrated"
+)
+
+// CreditGrades lists the valid credit grades
+var CreditGrades = []string{CreditGradeA, CreditGradeB, CreditGradeC, CreditGradeD, CreditGradeUnrated}
+
+// CustomerCreditScore is a customer's payment behaviour over the last year,
+// scored 0-100 and graded. It is recalculated daily and read by the party
+// detail API, credit-limit suggestions and dunning policy selection.
+type CustomerCreditScore struct {
+	TenantID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	CustomerID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"customer_id"`
+	CustomerName string    `gorm:"size:200" json:"customer_name"`
+
+	Score int    `gorm:"not null" json:"score"`
+	Grade string `gorm:"size:10;not null;index" json:"grade"`
+
+	// Indicators the score is built from
+	InvoicesIssued   int             `gorm:"not null" json:"invoices_issued"`
+	InvoicesDue      int             `gorm:"not null" json:"invoices_due"`       // Issued invoices whose due date has passed
+	InvoicesPaidLate int             `gorm:"not null" json:"invoices_paid_late"` // Settled after the due date, or still unpaid past it
+	LatePaymentRate  decimal.Decimal `gorm:"type:decimal(5,4);default:0" json:"late_payment_rate"`
+	AverageDaysToPay decimal.Decimal `gorm:"type:decimal(8,1);default:0" json:"average_days_to_pay"`
+	AverageDaysLate  decimal.Decimal `gorm:"type:decimal(8,1);default:0" json:"average_days_late"`
+	BouncedPayments  int             `gorm:"not null" json:"bounced_payments"`
+	DisputedInvoices int             `gorm:"not null" json:"disputed_invoices"`
+	OverdueAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"overdue_amount"`
+
+	// Credit-limit suggestion: the receivable a month of typical sales
+	// builds up over the customer's payment cycle, scaled by the grade
+	AverageMonthlySales  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"average_monthly_sales"`
+	SuggestedCreditLimit decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"suggested_credit_limit"`
+
+	CalculatedAt time.Time `gorm:"not null" json:"calculated_at"`
+}
+
+// TableName returns the table name for CustomerCreditScore
+func (CustomerCreditScore) TableName() string {
+	return "customer_credit_scores"
+}
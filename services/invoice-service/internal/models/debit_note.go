@@ -0,0 +1,222 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DebitNoteStatus represents the status of a debit note
+type DebitNoteStatus string
+
+const (
+	DebitNoteStatusDraft     DebitNoteStatus = "draft"
+	DebitNoteStatusIssued    DebitNoteStatus = "issued"
+	DebitNoteStatusCancelled DebitNoteStatus = "cancelled"
+)
+
+// DebitNoteReason represents the reason for issuing a debit note
+type DebitNoteReason string
+
+const (
+	DebitNoteReasonReturn     DebitNoteReason = "goods_returned"
+	DebitNoteReasonDefective  DebitNoteReason = "defective_goods"
+	DebitNoteReasonShortage   DebitNoteReason = "short_supply"
+	DebitNoteReasonOvercharge DebitNoteReason = "overcharge"
+	DebitNoteReasonOther      DebitNoteReason = "other"
+)
+
+// DebitNote is raised on a vendor for goods returned against one of their
+// bills. The GST on the returned value was claimed as input tax credit
+// when the bill was entered, so the credit taken on that share of the bill
+// is reversed when the note is issued.
+type DebitNote struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID        uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	DebitNoteNumber string    `gorm:"size:50;uniqueIndex:idx_tenant_dn_num" json:"debit_note_number"`
+	DebitNoteDate   time.Time `gorm:"not null" json:"debit_note_date"`
+
+	// Vendor, as on the bill
+	VendorID    uuid.UUID `gorm:"type:uuid;index;not null" json:"vendor_id"`
+	VendorName  string    `gorm:"size:200" json:"vendor_name"`
+	VendorGSTIN string    `gorm:"size:15" json:"vendor_gstin,omitempty"`
+
+	// Original bill
+	BillID       uuid.UUID `gorm:"type:uuid;index;not null" json:"bill_id"`
+	BillNumber   string    `gorm:"size:50" json:"bill_number"`
+	VendorBillNo string    `gorm:"size:50" json:"vendor_bill_no"`
+	BillDate     time.Time `json:"bill_date"`
+
+	// Reason
+	Reason       DebitNoteReason `gorm:"size:50;not null" json:"reason"`
+	ReasonDetail string          `gorm:"type:text" json:"reason_detail"`
+
+	// Status
+	Status   DebitNoteStatus `gorm:"size:20;default:'draft'" json:"status"`
+	IssuedAt *time.Time      `json:"issued_at,omitempty"`
+	IssuedBy *uuid.UUID      `gorm:"type:uuid" json:"issued_by,omitempty"`
+
+	// Amounts
+	Subtotal    decimal.Decimal `gorm:"type:decimal(18,2);not null" json:"subtotal"`
+	CGSTAmount  decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"cgst_amount"`
+	SGSTAmount  decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"sgst_amount"`
+	IGSTAmount  decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"igst_amount"`
+	CessAmount  decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"cess_amount"`
+	TotalTax    decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"total_tax"`
+	TotalAmount decimal.Decimal `gorm:"type:decimal(18,2);not null" json:"total_amount"`
+
+	// Input tax credit reversed, the part of the note's GST on lines whose
+	// credit was claimed on the bill
+	ITCReversedCGST decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"itc_reversed_cgst"`
+	ITCReversedSGST decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"itc_reversed_sgst"`
+	ITCReversedIGST decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"itc_reversed_igst"`
+	ITCReversedCess decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"itc_reversed_cess"`
+
+	// When issued, the note is set off against what is left to pay on the
+	// bill; any more is owed back by the vendor
+	AmountAdjusted decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"amount_adjusted"`
+	RefundDue      decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"refund_due"`
+
+	// GST Place of Supply, a state code such as "27"
+	PlaceOfSupply string `gorm:"size:2" json:"place_of_supply"`
+
+	Notes string `gorm:"type:text" json:"notes"`
+
+	Items []DebitNoteItem `gorm:"foreignKey:DebitNoteID" json:"items"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for DebitNote
+func (DebitNote) TableName() string {
+	return "debit_notes"
+}
+
+// BeforeCreate hook
+func (dn *DebitNote) BeforeCreate(tx *gorm.DB) error {
+	if dn.ID == uuid.Nil {
+		dn.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateTotals recalculates the debit note's totals and reversed credit
+// from its items
+func (dn *DebitNote) CalculateTotals() {
+	dn.Subtotal = decimal.Zero
+	dn.CGSTAmount = decimal.Zero
+	dn.SGSTAmount = decimal.Zero
+	dn.IGSTAmount = decimal.Zero
+	dn.CessAmount = decimal.Zero
+	dn.ITCReversedCGST = decimal.Zero
+	dn.ITCReversedSGST = decimal.Zero
+	dn.ITCReversedIGST = decimal.Zero
+	dn.ITCReversedCess = decimal.Zero
+
+	for _, item := range dn.Items {
+		dn.Subtotal = dn.Subtotal.Add(item.Amount)
+		dn.CGSTAmount = dn.CGSTAmount.Add(item.CGSTAmount)
+		dn.SGSTAmount = dn.SGSTAmount.Add(item.SGSTAmount)
+		dn.IGSTAmount = dn.IGSTAmount.Add(item.IGSTAmount)
+		dn.CessAmount = dn.CessAmount.Add(item.CessAmount)
+		if item.ITCReversed {
+			dn.ITCReversedCGST = dn.ITCReversedCGST.Add(item.CGSTAmount)
+			dn.ITCReversedSGST = dn.ITCReversedSGST.Add(item.SGSTAmount)
+			dn.ITCReversedIGST = dn.ITCReversedIGST.Add(item.IGSTAmount)
+			dn.ITCReversedCess = dn.ITCReversedCess.Add(item.CessAmount)
+		}
+	}
+
+	dn.TotalTax = dn.CGSTAmount.Add(dn.SGSTAmount).Add(dn.IGSTAmount).Add(dn.CessAmount)
+	dn.TotalAmount = dn.Subtotal.Add(dn.TotalTax)
+}
+
+// ITCReversedTotal is the input tax credit the note reverses in all
+func (dn *DebitNote) ITCReversedTotal() decimal.Decimal {
+	return dn.ITCReversedCGST.Add(dn.ITCReversedSGST).Add(dn.ITCReversedIGST).Add(dn.ITCReversedCess)
+}
+
+// DebitNoteItem is a line of a debit note, returning part of a bill line
+type DebitNoteItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DebitNoteID uuid.UUID `gorm:"type:uuid;index;not null" json:"debit_note_id"`
+	LineNumber  int       `gorm:"not null" json:"line_number"`
+
+	// Bill line returned, whose description, codes and rates the line takes
+	BillItemID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"bill_item_id"`
+	ProductID   *uuid.UUID `gorm:"type:uuid" json:"product_id,omitempty"`
+	Description string     `gorm:"type:text;not null" json:"description"`
+	HSNSACCode  string     `gorm:"size:20" json:"hsn_sac_code"`
+
+	Quantity  decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"quantity"`
+	UnitPrice decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"unit_price"`
+	Amount    decimal.Decimal `gorm:"type:decimal(18,2);not null" json:"amount"`
+
+	// Tax, the bill line's tax in proportion to the value returned
+	CGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	CGSTAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"cgst_amount"`
+	SGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	SGSTAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"sgst_amount"`
+	IGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	IGSTAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"igst_amount"`
+	CessRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CessAmount decimal.Decimal `gorm:"type:decimal(18,2);default:0" json:"cess_amount"`
+
+	// ITCReversed is set when credit was claimed on the bill line, so the
+	// line's GST is reversed rather than just refunded
+	ITCReversed bool `gorm:"default:false" json:"itc_reversed"`
+
+	LineTotal decimal.Decimal `gorm:"type:decimal(18,2);not null" json:"line_total"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for DebitNoteItem
+func (DebitNoteItem) TableName() string {
+	return "debit_note_items"
+}
+
+// BeforeCreate hook
+func (dni *DebitNoteItem) BeforeCreate(tx *gorm.DB) error {
+	if dni.ID == uuid.Nil {
+		dni.ID = uuid.New()
+	}
+	return nil
+}
+
+// TakeFromBillItem fills the line from the bill line it returns. The value
+// returned is quantity times the unit price, and the tax is the bill line's
+// tax in the same proportion to its value, so that specific cess and any
+// rounding on the bill are reversed as they were charged.
+func (dni *DebitNoteItem) TakeFromBillItem(billItem *BillItem, billITCEligible bool) {
+	dni.BillItemID = billItem.ID
+	dni.ProductID = billItem.ProductID
+	if dni.Description == "" {
+		dni.Description = billItem.Description
+	}
+	dni.HSNSACCode = billItem.HSNCode
+	if dni.HSNSACCode == "" {
+		dni.HSNSACCode = billItem.SACCode
+	}
+	dni.CGSTRate = billItem.CGSTRate
+	dni.SGSTRate = billItem.SGSTRate
+	dni.IGSTRate = billItem.IGSTRate
+	dni.CessRate = billItem.CessRate
+	dni.ITCReversed = billITCEligible && billItem.ITCEligible
+
+	dni.Amount = dni.Quantity.Mul(dni.UnitPrice).Round(2)
+	share := decimal.Zero
+	if billItem.Amount.IsPositive() {
+		share = dni.Amount.Div(billItem.Amount)
+	}
+	dni.CGSTAmount = billItem.CGSTAmount.Mul(share).Round(2)
+	dni.SGSTAmount = billItem.SGSTAmount.Mul(share).Round(2)
+	dni.IGSTAmount = billItem.IGSTAmount.Mul(share).Round(2)
+	dni.CessAmount = billItem.CessAmount.Mul(share).Round(2)
+	dni.LineTotal = dni.Amount.Add(dni.CGSTAmount).Add(dni.SGSTAmount).Add(dni.IGSTAmount).Add(dni.CessAmount)
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DeliveryChallanStatus represents the status of a delivery challan
+type DeliveryChallanStatus string
+
+const (
+	DeliveryChallanStatusDraft     DeliveryChallanStatus = "draft"
+	DeliveryChallanStatusIssued    DeliveryChallanStatus = "issued"   // Goods dispatched
+	DeliveryChallanStatusInvoiced  DeliveryChallanStatus = "invoiced" // Billed by a later invoice
+	DeliveryChallanStatusCancelled DeliveryChallanStatus = "cancelled"
+)
+
+// Purposes a delivery challan may be issued for, as Rule 55 of the CGST
+// Rules allows goods to move without an invoice
+const (
+	ChallanPurposeJobWork          = "job_work"
+	ChallanPurposeStockTransfer    = "stock_transfer"
+	ChallanPurposeSupplyOnApproval = "supply_on_approval"
+	ChallanPurposeLiquidGas        = "liquid_gas" // Quantity not known at removal
+	ChallanPurposeOther            = "other"
+)
+
+// IsChallanPurpose reports whether purpose is one a challan can be issued for
+func IsChallanPurpose(purpose string) bool {
+	switch purpose {
+	case ChallanPurposeJobWork, ChallanPurposeStockTransfer, ChallanPurposeSupplyOnApproval,
+		ChallanPurposeLiquidGas, ChallanPurposeOther:
+		return true
+	}
+	return false
+}
+
+// DeliveryChallan is a document goods are dispatched under when no tax
+// invoice is raised at removal: sent for job work, transferred to another
+// branch, or supplied on approval. It has its own numbering series, and is
+// linked to the invoice that bills the goods later, if any.
+type DeliveryChallan struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	ChallanNumber string    `gorm:"size:50;uniqueIndex:idx_tenant_challan_num" json:"challan_number"`
+	Purpose       string    `gorm:"size:30;not null" json:"purpose"`
+
+	// Consignee the goods are sent to; the tenant's own branch for stock
+	// transfers
+	CustomerID      uuid.UUID `gorm:"type:uuid;index" json:"customer_id"`
+	CustomerName    string    `gorm:"size:200" json:"customer_name"`
+	CustomerGSTIN   string    `gorm:"size:15" json:"customer_gstin,omitempty"`
+	CustomerAddress string    `gorm:"type:text" json:"customer_address"`
+	CustomerState   string    `gorm:"size:50" json:"customer_state"` // Place of supply
+	CustomerEmail   string    `gorm:"size:255" json:"customer_email"`
+	CustomerPhone   string    `gorm:"size:20" json:"customer_phone"`
+
+	ChallanDate time.Time             `gorm:"not null" json:"challan_date"`
+	Status      DeliveryChallanStatus `gorm:"size:20;default:'draft';index" json:"status"`
+	Items       []DeliveryChallanItem `gorm:"foreignKey:DeliveryChallanID" json:"items"`
+
+	// Transport
+	TransportMode   string `gorm:"size:20" json:"transport_mode,omitempty"` // road, rail, air or ship
+	TransporterName string `gorm:"size:200" json:"transporter_name,omitempty"`
+	VehicleNumber   string `gorm:"size:20" json:"vehicle_number,omitempty"`
+	EWayBillNumber  string `gorm:"size:20" json:"eway_bill_number,omitempty"`
+
+	// Value of the goods, with the tax that would be charged on them
+	Subtotal    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"subtotal"`
+	CGSTAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+	TotalTax    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
+	TotalAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
+
+	Notes string `gorm:"type:text" json:"notes"`
+
+	IssuedAt     *time.Time `json:"issued_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CancelReason string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+
+	// Invoice that billed the goods
+	InvoiceID     *uuid.UUID `gorm:"type:uuid;index" json:"invoice_id,omitempty"`
+	InvoiceNumber string     `gorm:"size:50" json:"invoice_number,omitempty"`
+	InvoicedAt    *time.Time `json:"invoiced_at,omitempty"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for DeliveryChallan
+func (DeliveryChallan) TableName() string {
+	return "delivery_challans"
+}
+
+// BeforeCreate hook
+func (dc *DeliveryChallan) BeforeCreate(tx *gorm.DB) error {
+	if dc.ID == uuid.Nil {
+		dc.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateTotals recalculates the challan's totals from its items
+func (dc *DeliveryChallan) CalculateTotals() {
+	dc.Subtotal = decimal.Zero
+	dc.CGSTAmount = decimal.Zero
+	dc.SGSTAmount = decimal.Zero
+	dc.IGSTAmount = decimal.Zero
+	dc.CessAmount = decimal.Zero
+
+	for _, item := range dc.Items {
+		dc.Subtotal = dc.Subtotal.Add(item.Amount)
+		dc.CGSTAmount = dc.CGSTAmount.Add(item.CGSTAmount)
+		dc.SGSTAmount = dc.SGSTAmount.Add(item.SGSTAmount)
+		dc.IGSTAmount = dc.IGSTAmount.Add(item.IGSTAmount)
+		dc.CessAmount = dc.CessAmount.Add(item.CessAmount)
+	}
+
+	dc.TotalTax = dc.CGSTAmount.Add(dc.SGSTAmount).Add(dc.IGSTAmount).Add(dc.CessAmount)
+	dc.TotalAmount = dc.Subtotal.Add(dc.TotalTax)
+}
+
+// IsInvoiced reports whether an invoice has billed the challan's goods
+func (dc *DeliveryChallan) IsInvoiced() bool {
+	return dc.InvoiceID != nil
+}
+
+// DeliveryChallanItem represents a line of goods on a delivery challan
+type DeliveryChallanItem struct {
+	ID                uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DeliveryChallanID uuid.UUID       `gorm:"type:uuid;index;not null" json:"delivery_challan_id"`
+	ProductID         *uuid.UUID      `gorm:"type:uuid" json:"product_id,omitempty"`
+	Description       string          `gorm:"size:500;not null" json:"description"`
+	HSNCode           string          `gorm:"size:10" json:"hsn_code"`
+	Quantity          decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
+	Unit              string          `gorm:"size:20;default:'pcs'" json:"unit"`
+	Rate              decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
+	Amount            decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+
+	CGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"cess_specific_rate"`
+
+	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+
+	TotalAmount decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_amount"`
+	SortOrder   int             `gorm:"default:0" json:"sort_order"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for DeliveryChallanItem
+func (DeliveryChallanItem) TableName() string {
+	return "delivery_challan_items"
+}
+
+// BeforeCreate hook
+func (i *DeliveryChallanItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateAmounts calculates the line's amounts including taxes, as an
+// invoice line does
+func (i *DeliveryChallanItem) CalculateAmounts() {
+	line := InvoiceItem{
+		Quantity:         i.Quantity,
+		Rate:             i.Rate,
+		CGSTRate:         i.CGSTRate,
+		SGSTRate:         i.SGSTRate,
+		IGSTRate:         i.IGSTRate,
+		CessRate:         i.CessRate,
+		CessSpecificRate: i.CessSpecificRate,
+	}
+	line.CalculateAmounts()
+
+	i.Amount = line.Amount
+	i.CGSTAmount = line.CGSTAmount
+	i.SGSTAmount = line.SGSTAmount
+	i.IGSTAmount = line.IGSTAmount
+	i.CessAmount = line.CessAmount
+	i.TotalAmount = line.TotalAmount
+}
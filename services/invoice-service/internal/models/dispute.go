@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DisputeStatus represents the status of an invoice dispute
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen      DisputeStatus = "open"
+	DisputeStatusResolved  DisputeStatus = "resolved"  // Settled in the customer's favour, e.g. by a credit note
+	DisputeStatusRejected  DisputeStatus = "rejected"  // Claim not accepted; the amount is collectible again
+	DisputeStatusWithdrawn DisputeStatus = "withdrawn" // Withdrawn by the customer
+)
+
+// IsValidOutcome reports whether the status closes a dispute
+func (s DisputeStatus) IsValidOutcome() bool {
+	switch s {
+	case DisputeStatusResolved, DisputeStatusRejected, DisputeStatusWithdrawn:
+		return true
+	}
+	return false
+}
+
+// InvoiceDispute records a customer's dispute of an invoice, or of one line
+// on it. The disputed amount is held out of dunning and the collectible
+// receivables until the dispute is closed.
+type InvoiceDispute struct {
+	ID                     uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID               uuid.UUID       `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	InvoiceID              uuid.UUID       `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	InvoiceItemID          *uuid.UUID      `gorm:"type:uuid" json:"invoice_item_id,omitempty"` // Nil when the whole invoice is disputed
+	Amount                 decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Reason                 string          `gorm:"type:text;not null" json:"reason"`
+	ExpectedResolutionDate *time.Time      `json:"expected_resolution_date,omitempty"`
+	Status                 DisputeStatus   `gorm:"size:20;not null;default:'open';index" json:"status"`
+	Resolution             string          `gorm:"type:text" json:"resolution,omitempty"`
+	ResolvedAt             *time.Time      `json:"resolved_at,omitempty"`
+	RaisedBy               uuid.UUID       `gorm:"type:uuid" json:"raised_by"`
+	CreatedAt              time.Time       `json:"created_at"`
+	UpdatedAt              time.Time       `json:"updated_at"`
+
+	History []InvoiceDisputeEvent `gorm:"foreignKey:DisputeID" json:"history,omitempty"`
+}
+
+// TableName returns the table name for InvoiceDispute
+func (InvoiceDispute) TableName() string {
+	return "invoice_disputes"
+}
+
+// BeforeCreate hook
+func (d *InvoiceDispute) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// InvoiceDisputeEvent is an entry in a dispute's resolution history
+type InvoiceDisputeEvent struct {
+	ID                     uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DisputeID              uuid.UUID       `gorm:"type:uuid;not null;index" json:"dispute_id"`
+	Action                 string          `gorm:"size:20;not null" json:"action"` // opened, updated, resolved, rejected, withdrawn
+	Status                 DisputeStatus   `gorm:"size:20;not null" json:"status"`
+	Amount                 decimal.Decimal `gorm:"type:decimal(15,2)" json:"amount"`
+	ExpectedResolutionDate *time.Time      `json:"expected_resolution_date,omitempty"`
+	Note                   string          `gorm:"type:text" json:"note,omitempty"`
+	UserID                 uuid.UUID       `gorm:"type:uuid" json:"user_id"`
+	CreatedAt              time.Time       `json:"created_at"`
+}
+
+// TableName returns the table name for InvoiceDisputeEvent
+func (InvoiceDisputeEvent) TableName() string {
+	return "invoice_dispute_events"
+}
+
+// BeforeCreate hook
+func (e *InvoiceDisputeEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Document types a template can be saved for
+const (
+	TemplateDocumentInvoice = "invoice"
+	TemplateDocumentBill    = "bill"
+)
+
+// DocumentTemplate is a saved invoice or bill to raise again: the party,
+// lines and terms of repeat business billed without a fixed schedule
+type DocumentTemplate struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_document_template_name" json:"tenant_id"`
+	DocumentType string    `gorm:"size:20;not null;uniqueIndex:idx_tenant_document_template_name" json:"document_type"` // invoice or bill
+	Name         string    `gorm:"size:200;not null;uniqueIndex:idx_tenant_document_template_name" json:"name"`
+
+	DocumentTemplateContent `gorm:"embedded"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DocumentTemplateContent is what a template fills in on the document
+// raised from it. The party is the customer of an invoice or the vendor of
+// a bill; fields of the other document type are left empty.
+type DocumentTemplateContent struct {
+	PartyID      uuid.UUID `gorm:"type:uuid;index" json:"party_id"`
+	PartyName    string    `gorm:"size:200" json:"party_name"`
+	PartyGSTIN   string    `gorm:"size:15" json:"party_gstin,omitempty"`
+	PartyPAN     string    `gorm:"size:10" json:"party_pan,omitempty"`
+	PartyAddress string    `gorm:"type:text" json:"party_address"`
+	PartyState   string    `gorm:"size:50" json:"party_state"`
+	PartyEmail   string    `gorm:"size:255" json:"party_email"`
+	PartyPhone   string    `gorm:"size:20" json:"party_phone"`
+
+	Items         DocumentTemplateItems `gorm:"type:jsonb;not null" json:"items"`
+	DiscountType  string                `gorm:"size:20" json:"discount_type"`
+	DiscountValue decimal.Decimal       `gorm:"type:decimal(15,2);default:0" json:"discount_value"`
+	DueInDays     int                   `gorm:"default:0" json:"due_in_days"` // Credit days when no payment term applies; 0 for the default
+	Notes         string                `gorm:"type:text" json:"notes"`
+
+	// Invoices
+	PaymentTermID *uuid.UUID     `gorm:"type:uuid" json:"payment_term_id,omitempty"`
+	Terms         string         `gorm:"type:text" json:"terms,omitempty"`
+	Language      string         `gorm:"size:5" json:"language,omitempty"`
+	Tags          pq.StringArray `gorm:"type:text[];default:'{}'" json:"tags"`
+
+	// Bills
+	TDSApplicable    bool            `gorm:"default:false" json:"tds_applicable"`
+	TDSSection       string          `gorm:"size:20" json:"tds_section,omitempty"`
+	TDSRate          decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"tds_rate"`
+	ITCEligible      bool            `gorm:"default:false" json:"itc_eligible"`
+	ITCCategory      string          `gorm:"size:20" json:"itc_category,omitempty"`
+	URDReverseCharge bool            `gorm:"default:false" json:"urd_rcm"`
+}
+
+// DocumentTemplateItem is a line of a template
+type DocumentTemplateItem struct {
+	ProductID        *uuid.UUID      `json:"product_id,omitempty"`
+	Description      string          `json:"description"`
+	HSNCode          string          `json:"hsn_code,omitempty"`
+	SACCode          string          `json:"sac_code,omitempty"` // Bills
+	Quantity         decimal.Decimal `json:"quantity"`
+	Unit             string          `json:"unit,omitempty"`
+	Rate             decimal.Decimal `json:"rate"`
+	CGSTRate         decimal.Decimal `json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `json:"igst_rate"`
+	CessRate         decimal.Decimal `json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `json:"cess_specific_rate"`
+
+	// Bills
+	ITCEligible      bool       `json:"itc_eligible,omitempty"`
+	ExpenseAccountID *uuid.UUID `json:"expense_account_id,omitempty"`
+	ExpenseCategory  string     `json:"expense_category,omitempty"`
+}
+
+// DocumentTemplateItems is stored as a JSON array
+type DocumentTemplateItems []DocumentTemplateItem
+
+// Value implements driver.Valuer
+func (i DocumentTemplateItems) Value() (driver.Value, error) {
+	if i == nil {
+		return "[]", nil
+	}
+	return json.Marshal([]DocumentTemplateItem(i))
+}
+
+// Scan implements sql.Scanner
+func (i *DocumentTemplateItems) Scan(value interface{}) error {
+	return scanJSON(value, i)
+}
+
+// TableName returns the table name for DocumentTemplate
+func (DocumentTemplate) TableName() string {
+	return "document_templates"
+}
+
+// BeforeCreate hook
+func (t *DocumentTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DunningAction is a step taken while collecting an overdue invoice
+type DunningAction string
+
+const (
+	DunningActionReminder   DunningAction = "reminder"
+	DunningActionEscalation DunningAction = "escalation"
+	DunningActionLateFee    DunningAction = "late_fee"
+)
+
+// LateFeeType is how the late fee is computed
+type LateFeeType string
+
+const (
+	LateFeeTypePercentage LateFeeType = "percentage" // Of the balance due
+	LateFeeTypeFixed      LateFeeType = "fixed"
+)
+
+// ReminderSchedule lists the days relative to the due date on which
+// reminders go out; negative days are before the due date. Stored as JSONB.
+type ReminderSchedule []int
+
+// Value implements driver.Valuer
+func (s ReminderSchedule) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]int{})
+	}
+	return json.Marshal([]int(s))
+}
+
+// Scan implements sql.Scanner
+func (s *ReminderSchedule) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+// DunningPolicy configures how overdue invoices of a customer segment
+// (e.g. enterprise, retail) are chased
+type DunningPolicy struct {
+	ID           uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_dunning_segment" json:"tenant_id"`
+	Segment      string           `gorm:"size:50;not null;uniqueIndex:idx_tenant_dunning_segment" json:"segment"`
+	Name         string           `gorm:"size:100;not null" json:"name"`
+	ReminderDays ReminderSchedule `gorm:"type:jsonb;not null" json:"reminder_days"`
+
+	// Escalation to the customer's account manager; 0 disables it
+	EscalateAfterDays int        `gorm:"default:0" json:"escalate_after_days"`
+	EscalationUserID  *uuid.UUID `gorm:"type:uuid" json:"escalation_user_id,omitempty"` // When the customer has no account manager
+
+	// Late fee line added once the invoice is this many days overdue; 0 disables it
+	LateFeeAfterDays int             `gorm:"default:0" json:"late_fee_after_days"`
+	LateFeeType      LateFeeType     `gorm:"size:20;default:'percentage'" json:"late_fee_type"`
+	LateFeeValue     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"late_fee_value"`
+	LateFeeGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"late_fee_gst_rate"`
+
+	// Customers not put in a segment are chased under the policy for their
+	// credit grade before falling back to the default policy
+	CreditGrades pq.StringArray `gorm:"type:text[];default:'{}'" json:"credit_grades"`
+
+	StopOnDispute bool      `gorm:"default:false" json:"stop_on_dispute"`
+	IsDefault     bool      `gorm:"default:false" json:"is_default"`
+	IsActive      bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for DunningPolicy
+func (DunningPolicy) TableName() string {
+	return "dunning_policies"
+}
+
+// BeforeCreate hook
+func (p *DunningPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChargesLateFee reports whether the policy adds a late fee
+func (p *DunningPolicy) ChargesLateFee() bool {
+	return p.LateFeeAfterDays > 0 && p.LateFeeValue.IsPositive()
+}
+
+// LateFee returns the late fee, before GST, on an outstanding balance
+func (p *DunningPolicy) LateFee(balanceDue decimal.Decimal) decimal.Decimal {
+	if p.LateFeeType == LateFeeTypeFixed {
+		return p.LateFeeValue
+	}
+	return balanceDue.Mul(p.LateFeeValue).Div(decimal.NewFromInt(100)).Round(2)
+}
+
+// DueReminder returns the latest reminder step reached daysOverdue days
+// after the due date, and false if none has been reached yet
+func (p *DunningPolicy) DueReminder(daysOverdue int) (int, bool) {
+	step, found := 0, false
+	for _, day := range p.ReminderDays {
+		if day <= daysOverdue && (!found || day > step) {
+			step, found = day, true
+		}
+	}
+	return step, found
+}
+
+// CustomerDunningProfile puts a customer in a dunning segment and names the
+// account manager escalations go to
+type CustomerDunningProfile struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_customer_dunning" json:"tenant_id"`
+	CustomerID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_customer_dunning" json:"customer_id"`
+	PolicyID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"policy_id"`
+	AccountManagerID *uuid.UUID `gorm:"type:uuid" json:"account_manager_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	Policy *DunningPolicy `gorm:"foreignKey:PolicyID" json:"policy,omitempty"`
+}
+
+// TableName returns the table name for CustomerDunningProfile
+func (CustomerDunningProfile) TableName() string {
+	return "customer_dunning_profiles"
+}
+
+// BeforeCreate hook
+func (p *CustomerDunningProfile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// DunningEvent records a dunning step taken on an invoice. Each step is taken
+// at most once per invoice.
+type DunningEvent struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	InvoiceID   uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_dunning_event_step" json:"invoice_id"`
+	Action      DunningAction   `gorm:"size:20;not null;uniqueIndex:idx_dunning_event_step" json:"action"`
+	Step        int             `gorm:"not null;uniqueIndex:idx_dunning_event_step" json:"step"` // Days relative to the due date
+	PolicyID    uuid.UUID       `gorm:"type:uuid" json:"policy_id"`
+	DaysOverdue int             `json:"days_overdue"`
+	Amount      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"amount"` // Balance due, or the late fee charged
+	Recipient   string          `gorm:"size:255" json:"recipient"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// TableName returns the table name for DunningEvent
+func (DunningEvent) TableName() string {
+	return "dunning_events"
+}
+
+// BeforeCreate hook
+func (e *DunningEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// E-invoice states of an invoice
+const (
+	EInvoicePending   = "pending"
+	EInvoiceGenerated = "generated"
+	EInvoiceFailed    = "failed"
+	EInvoiceCancelled = "cancelled"
+)
+
+// EInvoiceCredential is a tenant's API user on the e-invoice portal (IRP).
+// The password is stored encrypted.
+type EInvoiceCredential struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primary_key" json:"tenant_id"`
+	GSTIN    string    `gorm:"size:15;not null" json:"gstin"`
+	Username string    `gorm:"size:100;not null" json:"username"`
+	Password string    `gorm:"type:text;not null" json:"-"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for EInvoiceCredential
+func (EInvoiceCredential) TableName() string {
+	return "einvoice_credentials"
+}
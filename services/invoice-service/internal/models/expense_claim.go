@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ExpenseClaimStatus represents the status of an expense claim
+type ExpenseClaimStatus string
+
+const (
+	ExpenseClaimStatusSubmitted ExpenseClaimStatus = "submitted"
+	ExpenseClaimStatusApproved  ExpenseClaimStatus = "approved" // Converted into a bill payable to the employee
+	ExpenseClaimStatusRejected  ExpenseClaimStatus = "rejected"
+)
+
+// ExpenseClaim is a member's claim for reimbursement of expenses paid on the
+// business's behalf. Once approved it becomes a bill against the employee's
+// party and is reimbursed like any other payable.
+type ExpenseClaim struct {
+	ID              uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID        uuid.UUID          `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	ClaimNumber     string             `gorm:"size:50;uniqueIndex:idx_tenant_claim_num" json:"claim_number"`
+	MemberID        uuid.UUID          `gorm:"type:uuid;index;not null" json:"member_id"` // User who submitted the claim
+	EmployeeName    string             `gorm:"size:200;not null" json:"employee_name"`
+	EmployeePartyID *uuid.UUID         `gorm:"type:uuid" json:"employee_party_id,omitempty"` // Party the bill is raised against
+	Title           string             `gorm:"size:200;not null" json:"title"`
+	Project         string             `gorm:"size:100;index" json:"project,omitempty"`
+	Status          ExpenseClaimStatus `gorm:"size:20;not null;default:'submitted';index" json:"status"`
+	Items           []ExpenseClaimItem `gorm:"foreignKey:ClaimID" json:"items"`
+	TotalAmount     decimal.Decimal    `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
+	Notes           string             `gorm:"type:text" json:"notes,omitempty"`
+
+	// Review
+	ReviewedBy      *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `gorm:"type:text" json:"rejection_reason,omitempty"`
+	BillID          *uuid.UUID `gorm:"type:uuid" json:"bill_id,omitempty"`
+
+	// Lines breaking the expense policy, which the reviewer has to override
+	PolicyViolations []ExpensePolicyViolation `gorm:"-" json:"policy_violations,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for ExpenseClaim
+func (ExpenseClaim) TableName() string {
+	return "expense_claims"
+}
+
+// BeforeCreate hook
+func (c *ExpenseClaim) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateTotal recalculates the claim total from its items
+func (c *ExpenseClaim) CalculateTotal() {
+	c.TotalAmount = decimal.Zero
+	for _, item := range c.Items {
+		c.TotalAmount = c.TotalAmount.Add(item.Amount)
+	}
+}
+
+// ExpenseClaimItem is a single expense on a claim, backed by a receipt
+type ExpenseClaimItem struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ClaimID     uuid.UUID       `gorm:"type:uuid;index;not null" json:"claim_id"`
+	ExpenseDate time.Time       `gorm:"not null" json:"expense_date"`
+	Category    string          `gorm:"size:100;not null" json:"category"` // travel, meals, fuel, etc.
+	Description string          `gorm:"size:500;not null" json:"description"`
+	Amount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"` // Including any tax on the receipt
+	ReceiptURL  string          `gorm:"size:500" json:"receipt_url,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for ExpenseClaimItem
+func (ExpenseClaimItem) TableName() string {
+	return "expense_claim_items"
+}
+
+// BeforeCreate hook
+func (i *ExpenseClaimItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Expense policy rules a claim or bill line can break
+const (
+	PolicyRuleProhibited      = "prohibited_category" // The category may not be claimed at all
+	PolicyRuleDailyLimit      = "daily_limit"         // Over the category's cap for the day
+	PolicyRulePerDiem         = "per_diem"            // Over the category's per-diem rate for the day
+	PolicyRuleReceiptRequired = "receipt_required"    // Over the receipt threshold with no receipt
+)
+
+// Documents checked against the expense policy
+const (
+	PolicyDocumentExpenseClaim = "expense_claim"
+	PolicyDocumentBill         = "bill"
+)
+
+// ExpensePolicy is a tenant's rules for what may be spent. Expense claims and
+// bills are checked against it when they are submitted, and the lines that
+// break it have to be overridden with a reason before they are approved.
+type ExpensePolicy struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primary_key" json:"tenant_id"`
+	// Lines above this amount need a receipt; zero never asks for one
+	ReceiptRequiredAbove decimal.Decimal       `gorm:"type:decimal(15,2);default:0" json:"receipt_required_above"`
+	Rules                []ExpenseCategoryRule `gorm:"foreignKey:TenantID;references:TenantID" json:"rules"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for ExpensePolicy
+func (ExpensePolicy) TableName() string {
+	return "expense_policies"
+}
+
+// Rule returns the policy's rule for a category, or nil if it has none
+func (p *ExpensePolicy) Rule(category string) *ExpenseCategoryRule {
+	category = NormalizeExpenseCategory(category)
+	for i := range p.Rules {
+		if p.Rules[i].Category == category {
+			return &p.Rules[i]
+		}
+	}
+	return nil
+}
+
+// ExpenseCategoryRule limits what may be spent in one expense category.
+// Categories are matched ignoring case.
+type ExpenseCategoryRule struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_expense_rule_category" json:"tenant_id"`
+	Category   string    `gorm:"size:100;not null;uniqueIndex:idx_expense_rule_category" json:"category"`
+	Prohibited bool      `gorm:"default:false" json:"prohibited"`
+	// Most that may be spent in the category in a day; zero is no cap
+	DailyLimit decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"daily_limit"`
+	// Fixed daily allowance paid without receipts instead of actuals (meals
+	// or lodging on tour); zero means actuals are claimed
+	PerDiemRate decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"per_diem_rate"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for ExpenseCategoryRule
+func (ExpenseCategoryRule) TableName() string {
+	return "expense_category_rules"
+}
+
+// BeforeCreate hook
+func (r *ExpenseCategoryRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsPerDiem reports whether the category is paid as a per-diem allowance
+func (r *ExpenseCategoryRule) IsPerDiem() bool {
+	return r.PerDiemRate.IsPositive()
+}
+
+// NormalizeExpenseCategory returns the form categories are matched in
+func NormalizeExpenseCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// ExpensePolicyViolation records a line of an expense claim or bill that
+// breaks the tenant's expense policy. The document can't be approved until
+// its violations are overridden with a reason.
+type ExpensePolicyViolation struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID       uuid.UUID `gorm:"type:uuid;not null;index:idx_expense_violation_document" json:"tenant_id"`
+	DocumentType   string    `gorm:"size:20;not null;index:idx_expense_violation_document" json:"document_type"` // expense_claim or bill
+	DocumentID     uuid.UUID `gorm:"type:uuid;not null;index:idx_expense_violation_document" json:"document_id"`
+	DocumentNumber string    `gorm:"size:50" json:"document_number"`
+	PartyName      string    `gorm:"size:200" json:"party_name"` // Employee or vendor
+
+	Rule        string          `gorm:"size:30;not null;index" json:"rule"`
+	Category    string          `gorm:"size:100" json:"category"`
+	ExpenseDate time.Time       `gorm:"type:date;not null" json:"expense_date"`
+	Amount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"` // Of the line, or spent in the category that day
+	Limit       decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"limit"`
+	Message     string          `gorm:"size:500" json:"message"`
+
+	Overridden     bool       `gorm:"default:false" json:"overridden"`
+	OverrideReason string     `gorm:"type:text" json:"override_reason,omitempty"`
+	OverriddenBy   *uuid.UUID `gorm:"type:uuid" json:"overridden_by,omitempty"`
+	OverriddenAt   *time.Time `json:"overridden_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for ExpensePolicyViolation
+func (ExpensePolicyViolation) TableName() string {
+	return "expense_policy_violations"
+}
+
+// BeforeCreate hook
+func (v *ExpensePolicyViolation) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Financing data pack formats
+const (
+	FinancingFormatCSV  = "csv"
+	FinancingFormatJSON = "json"
+)
+
+// FinancingConsent records a tenant's explicit consent to share its
+// receivables with a lender for invoice financing or a loan against
+// receivables. Receivables data is only exported under an active consent.
+type FinancingConsent struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID        uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	LenderName      string    `gorm:"size:200;not null" json:"lender_name"`
+	LenderReference string    `gorm:"size:100" json:"lender_reference,omitempty"` // Loan application or facility number
+	Purpose         string    `gorm:"type:text" json:"purpose"`
+
+	// ShareCustomerIdentity allows customer names and GSTINs in the data
+	// pack; without it customers are identified by an opaque reference
+	ShareCustomerIdentity bool `gorm:"default:false" json:"share_customer_identity"`
+
+	// ConsentText is the statement the user agreed to, kept verbatim
+	ConsentText string    `gorm:"type:text;not null" json:"consent_text"`
+	GrantedBy   uuid.UUID `gorm:"type:uuid;not null" json:"granted_by"`
+	GrantedAt   time.Time `gorm:"not null" json:"granted_at"`
+	GrantedIP   string    `gorm:"size:45" json:"granted_ip"`
+	ValidUntil  time.Time `gorm:"type:date;not null" json:"valid_until"`
+
+	RevokedBy *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for FinancingConsent
+func (FinancingConsent) TableName() string {
+	return "financing_consents"
+}
+
+// BeforeCreate hook
+func (fc *FinancingConsent) BeforeCreate(tx *gorm.DB) error {
+	if fc.ID == uuid.Nil {
+		fc.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive reports whether data may be exported under the consent at the
+// given time
+func (fc *FinancingConsent) IsActive(now time.Time) bool {
+	if fc.RevokedAt != nil {
+		return false
+	}
+	return now.Before(fc.ValidUntil.AddDate(0, 0, 1))
+}
+
+// FinancingExport logs a data pack released to a lender under a consent
+type FinancingExport struct {
+	ID               uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID         uuid.UUID       `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	ConsentID        uuid.UUID       `gorm:"type:uuid;index;not null" json:"consent_id"`
+	LenderName       string          `gorm:"size:200;not null" json:"lender_name"`
+	Format           string          `gorm:"size:10;not null" json:"format"`
+	AsOf             time.Time       `gorm:"type:date;not null" json:"as_of"`
+	InvoiceCount     int             `gorm:"not null" json:"invoice_count"`
+	CustomerCount    int             `gorm:"not null" json:"customer_count"`
+	TotalOutstanding decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_outstanding"`
+	ExportedBy       uuid.UUID       `gorm:"type:uuid;not null" json:"exported_by"`
+	ExportedIP       string          `gorm:"size:45" json:"exported_ip"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// TableName returns the table name for FinancingExport
+func (FinancingExport) TableName() string {
+	return "financing_exports"
+}
+
+// BeforeCreate hook
+func (fe *FinancingExport) BeforeCreate(tx *gorm.DB) error {
+	if fe.ID == uuid.Nil {
+		fe.ID = uuid.New()
+	}
+	return nil
+}
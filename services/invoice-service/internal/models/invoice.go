@@ -1,10 +1,15 @@
 package models
 
 import (
+	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
 	"gorm.io/gorm"
 )
 
@@ -21,6 +26,22 @@ const (
 	InvoiceStatusCancelled InvoiceStatus = "cancelled"
 )
 
+// ExportType is how an export invoice is zero-rated under section 16 of the
+// IGST Act; the values are the GSTR-1 EXP types
+type ExportType string
+
+const (
+	ExportWithPayment    ExportType = "WPAY"  // IGST paid, to be refunded
+	ExportWithoutPayment ExportType = "WOPAY" // Under a letter of undertaking or bond
+)
+
+var (
+	iecRegex          = regexp.MustCompile(`^[A-Z0-9]{10}$`)
+	portCodeRegex     = regexp.MustCompile(`^[A-Z0-9]{6}$`)
+	shippingBillRegex = regexp.MustCompile(`^[0-9]{1,7}$`)
+	currencyRegex     = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
 // Invoice represents a sales invoice
 type Invoice struct {
 	ID              uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -29,13 +50,18 @@ type Invoice struct {
 	CustomerID      uuid.UUID       `gorm:"type:uuid;index" json:"customer_id"`
 	CustomerName    string          `gorm:"size:200" json:"customer_name"`
 	CustomerGSTIN   string          `gorm:"size:15" json:"customer_gstin,omitempty"`
+	CustomerPAN     string          `gorm:"size:10" json:"customer_pan,omitempty"`
 	CustomerAddress string          `gorm:"type:text" json:"customer_address"`
 	CustomerState   string          `gorm:"size:50" json:"customer_state"`
 	CustomerEmail   string          `gorm:"size:255" json:"customer_email"`
 	CustomerPhone   string          `gorm:"size:20" json:"customer_phone"`
+	PONumber        string          `gorm:"size:50" json:"po_number,omitempty"` // Buyer's purchase order
 	InvoiceDate     time.Time       `gorm:"not null" json:"invoice_date"`
 	DueDate         time.Time       `json:"due_date"`
+	PaymentTermID   *uuid.UUID      `gorm:"type:uuid" json:"payment_term_id,omitempty"`
+	PaymentTermName string          `gorm:"size:100" json:"payment_term_name,omitempty"`
 	Status          InvoiceStatus   `gorm:"size:20;default:'draft'" json:"status"`
+	Disputed        bool            `gorm:"default:false" json:"disputed"` // Has open disputes
 	Items           []InvoiceItem   `gorm:"foreignKey:InvoiceID" json:"items"`
 	Payments        []Payment       `gorm:"foreignKey:InvoiceID" json:"payments,omitempty"`
 
@@ -53,18 +79,71 @@ type Invoice struct {
 	CessAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
 	TotalTax       decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
 
+	// TCS fields (section 206C)
+	TCSApplicable bool            `gorm:"default:false" json:"tcs_applicable"`
+	TCSSection    string          `gorm:"size:20" json:"tcs_section,omitempty"`
+	TCSRate       decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"tcs_rate"`
+	TCSAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"tcs_amount"`
+	TCSRecorded   bool            `gorm:"default:false" json:"tcs_recorded"`
+
+	// Rounding (GST components are never rounded; the difference is carried in RoundOff)
+	RoundingMode      RoundingMode    `gorm:"size:20" json:"rounding_mode,omitempty"`
+	RoundTo           decimal.Decimal `gorm:"type:decimal(10,2);default:0" json:"round_to"`
+	RoundOff          decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"round_off"`
+	RoundingAccountID *uuid.UUID      `gorm:"type:uuid" json:"rounding_account_id,omitempty"`
+
+	// Early-payment discount offered by the payment term, and the amount
+	// allowed once the invoice was settled within the window
+	EarlyPaymentDiscountPercent decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"early_payment_discount_percent"`
+	EarlyPaymentDiscountDate    *time.Time      `json:"early_payment_discount_date,omitempty"`
+	EarlyPaymentDiscount        decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"early_payment_discount"`
+
 	TotalAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
 	AmountPaid     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"amount_paid"`
+	AmountCredited decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"amount_credited"` // Credit notes applied
 	BalanceDue     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"balance_due"`
+	DisputedAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"disputed_amount"` // Held out of collection by open disputes
+
+	// Language the invoice is printed and emailed in, and its total spelled
+	// out in that language
+	Language      string `gorm:"size:5;default:'en'" json:"language"`
+	AmountInWords string `gorm:"-" json:"amount_in_words"`
+
+	// Delivery of the latest email of the invoice to the customer, as the
+	// email provider reports it. A bounce flags the invoice so the address
+	// can be corrected and the invoice emailed again.
+	EmailDeliveryID   *uuid.UUID `gorm:"type:uuid;index" json:"email_delivery_id,omitempty"`
+	EmailStatus       string     `gorm:"size:20;index" json:"email_status,omitempty"` // queued, sent, delivered, opened, bounced or failed
+	EmailStatusAt     *time.Time `json:"email_status_at,omitempty"`
+	EmailBounceReason string     `gorm:"type:text" json:"email_bounce_reason,omitempty"`
 
 	// E-Invoice fields
-	IRN            string     `gorm:"size:100" json:"irn,omitempty"`
-	EInvoiceStatus string     `gorm:"size:20" json:"einvoice_status,omitempty"`
-	EInvoiceDate   *time.Time `json:"einvoice_date,omitempty"`
-	QRCode         string     `gorm:"type:text" json:"qr_code,omitempty"`
+	IRN                 string     `gorm:"size:100" json:"irn,omitempty"`
+	EInvoiceStatus      string     `gorm:"size:20" json:"einvoice_status,omitempty"`
+	EInvoiceDate        *time.Time `json:"einvoice_date,omitempty"`
+	QRCode              string     `gorm:"type:text" json:"qr_code,omitempty"` // Signed QR code from the IRP
+	AckNo               string     `gorm:"size:20" json:"ack_no,omitempty"`
+	AckDate             *time.Time `json:"ack_date,omitempty"`
+	SignedInvoice       string     `gorm:"type:text" json:"-"`
+	EInvoiceError       string     `gorm:"type:text" json:"einvoice_error,omitempty"` // Why the IRP last refused it
+	EInvoiceCancelledAt *time.Time `json:"einvoice_cancelled_at,omitempty"`
+
+	// Export fields, set on export invoices only. Amounts on the invoice are
+	// in rupees; items also keep their rate in the invoice currency.
+	ExportType         ExportType      `gorm:"size:10;index" json:"export_type,omitempty"`
+	Currency           string          `gorm:"size:3;default:'INR'" json:"currency"`
+	ExchangeRate       decimal.Decimal `gorm:"type:decimal(15,6);default:1" json:"exchange_rate"` // Rupees per unit of Currency
+	ForeignTotal       decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"foreign_total"` // Total in Currency
+	IECCode            string          `gorm:"size:10" json:"iec_code,omitempty"`                 // Importer-Exporter Code
+	LUTReference       string          `gorm:"size:50" json:"lut_reference,omitempty"`            // LUT ARN or bond number
+	PortCode           string          `gorm:"size:6" json:"port_code,omitempty"`
+	ShippingBillNumber string          `gorm:"size:7" json:"shipping_bill_number,omitempty"`
+	ShippingBillDate   *time.Time      `json:"shipping_bill_date,omitempty"`
+	DestinationCountry string          `gorm:"size:100" json:"destination_country,omitempty"`
 
 	Notes          string         `gorm:"type:text" json:"notes"`
 	Terms          string         `gorm:"type:text" json:"terms"`
+	Tags           pq.StringArray `gorm:"type:text[];default:'{}';index:idx_invoices_tags,type:gin" json:"tags"` // Free-form analytical tags, editable in any status
 	CreatedBy      uuid.UUID      `gorm:"type:uuid" json:"created_by"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
@@ -109,8 +188,197 @@ func (i *Invoice) CalculateTotals() {
 
 	i.TaxableAmount = i.Subtotal.Sub(i.DiscountAmount)
 	i.TotalTax = i.CGSTAmount.Add(i.SGSTAmount).Add(i.IGSTAmount).Add(i.CessAmount)
-	i.TotalAmount = i.TaxableAmount.Add(i.TotalTax)
-	i.BalanceDue = i.TotalAmount.Sub(i.AmountPaid)
+
+	grossTotal := i.TaxableAmount.Add(i.TotalTax).Add(i.TCSAmount)
+	i.TotalAmount = RoundAmount(grossTotal, i.RoundTo, i.RoundingMode)
+	i.RoundOff = i.TotalAmount.Sub(grossTotal)
+	i.BalanceDue = i.TotalAmount.Sub(i.AmountPaid).Sub(i.EarlyPaymentDiscount).Sub(i.AmountCredited)
+	i.ForeignTotal = decimal.Zero
+	if i.IsForeignCurrency() {
+		i.ForeignTotal = i.TotalAmount.Div(i.ExchangeRate).Round(2)
+	}
+	i.SetAmountInWords()
+}
+
+// IsExport reports whether the invoice is for an export of goods or
+// services
+func (i *Invoice) IsExport() bool {
+	return i.ExportType != ""
+}
+
+// IsForeignCurrency reports whether the invoice is raised in a currency
+// other than rupees
+func (i *Invoice) IsForeignCurrency() bool {
+	return i.Currency != "" && i.Currency != "INR" && i.ExchangeRate.IsPositive()
+}
+
+// ApplyExchangeRate sets each item's rupee rate from its rate in the
+// invoice currency and recalculates the amounts. Only foreign currency
+// invoices are affected.
+func (i *Invoice) ApplyExchangeRate() {
+	if !i.IsForeignCurrency() {
+		return
+	}
+	for n := range i.Items {
+		i.Items[n].Rate = i.Items[n].ForeignRate.Mul(i.ExchangeRate).Round(2)
+		i.Items[n].CalculateAmounts()
+	}
+}
+
+// ExportDeclaration is the declaration rule 46 of the CGST Rules requires on
+// an export invoice
+func (i *Invoice) ExportDeclaration() string {
+	switch i.ExportType {
+	case ExportWithPayment:
+		return "SUPPLY MEANT FOR EXPORT ON PAYMENT OF INTEGRATED TAX"
+	case ExportWithoutPayment:
+		return "SUPPLY MEANT FOR EXPORT UNDER BOND OR LETTER OF UNDERTAKING WITHOUT PAYMENT OF INTEGRATED TAX"
+	default:
+		return ""
+	}
+}
+
+// ValidateExport checks the export fields of an export invoice: exports
+// under LUT carry no tax, exports on payment only IGST
+func (i *Invoice) ValidateExport() error {
+	if !i.IsExport() {
+		return nil
+	}
+	if i.ExportType != ExportWithPayment && i.ExportType != ExportWithoutPayment {
+		return errors.New("export type must be WPAY or WOPAY")
+	}
+	if !iecRegex.MatchString(i.IECCode) {
+		return errors.New("IEC code must be 10 letters or digits")
+	}
+	if !currencyRegex.MatchString(i.Currency) {
+		return errors.New("currency must be a 3-letter ISO code")
+	}
+	if !i.ExchangeRate.IsPositive() || (i.Currency == "INR" && !i.ExchangeRate.Equal(decimal.NewFromInt(1))) {
+		return errors.New("exchange rate must be positive, and 1 for rupee invoices")
+	}
+	if i.ExportType == ExportWithoutPayment && strings.TrimSpace(i.LUTReference) == "" {
+		return errors.New("LUT or bond reference is required for exports without payment of IGST")
+	}
+	if i.PortCode != "" && !portCodeRegex.MatchString(i.PortCode) {
+		return errors.New("port code must be 6 letters or digits")
+	}
+	if i.ShippingBillNumber != "" && !shippingBillRegex.MatchString(i.ShippingBillNumber) {
+		return errors.New("shipping bill number must be up to 7 digits")
+	}
+	if i.ShippingBillDate != nil && i.ShippingBillDate.Before(i.InvoiceDate) {
+		return errors.New("shipping bill date cannot be before the invoice date")
+	}
+	for _, item := range i.Items {
+		if item.CGSTRate.IsPositive() || item.SGSTRate.IsPositive() {
+			return errors.New("exports are inter-state supplies and carry IGST, not CGST and SGST")
+		}
+		if i.ExportType == ExportWithoutPayment && (item.IGSTRate.IsPositive() || item.CessRate.IsPositive() || item.CessSpecificRate.IsPositive()) {
+			return errors.New("exports under LUT or bond are made without payment of tax")
+		}
+	}
+	return nil
+}
+
+// SetAmountInWords spells out the total in the invoice's language
+func (i *Invoice) SetAmountInWords() {
+	i.AmountInWords = i18n.AmountInWords(i.Language, toPaise(i.TotalAmount))
+}
+
+// AfterFind fills in the amount in words, which is not stored
+func (i *Invoice) AfterFind(tx *gorm.DB) error {
+	i.SetAmountInWords()
+	return nil
+}
+
+// FormatINR writes an amount as documents and messages show it, with
+// Indian digit grouping, e.g. ₹1,00,000.00
+func FormatINR(amount decimal.Decimal) string {
+	inr := i18n.Currency("INR")
+	return inr.FormatFixed(amount.StringFixed(int32(inr.Decimals)))
+}
+
+func toPaise(amount decimal.Decimal) int64 {
+	return amount.Mul(decimal.NewFromInt(100)).Round(0).IntPart()
+}
+
+// ApplyPaymentTerm sets the due date and early-payment discount window from
+// a payment term
+func (i *Invoice) ApplyPaymentTerm(term *PaymentTerm) {
+	i.PaymentTermID = &term.ID
+	i.PaymentTermName = term.Name
+	i.DueDate = term.DueDate(i.InvoiceDate)
+
+	i.EarlyPaymentDiscountPercent = decimal.Zero
+	i.EarlyPaymentDiscountDate = nil
+	if term.HasDiscount() {
+		discountDate := term.DiscountDate(i.InvoiceDate)
+		i.EarlyPaymentDiscountPercent = term.DiscountPercent
+		i.EarlyPaymentDiscountDate = &discountDate
+	}
+}
+
+// EarlyPaymentDiscountFor returns the discount allowed if the outstanding
+// balance is settled on paymentDate, or zero when the window has passed or
+// the discount was already taken
+func (i *Invoice) EarlyPaymentDiscountFor(paymentDate time.Time) decimal.Decimal {
+	if !i.EarlyPaymentDiscountPercent.IsPositive() || i.EarlyPaymentDiscountDate == nil {
+		return decimal.Zero
+	}
+	if !i.EarlyPaymentDiscount.IsZero() || paymentDate.After(*i.EarlyPaymentDiscountDate) {
+		return decimal.Zero
+	}
+	return i.BalanceDue.Mul(i.EarlyPaymentDiscountPercent).Div(decimal.NewFromInt(100)).Round(2)
+}
+
+// CollectibleAmount returns the balance due less the amount held by open
+// disputes
+func (i *Invoice) CollectibleAmount() decimal.Decimal {
+	collectible := i.BalanceDue.Sub(i.DisputedAmount)
+	if collectible.IsNegative() {
+		return decimal.Zero
+	}
+	return collectible
+}
+
+// AddLateFee appends a late payment fee line, with GST at gstRate split the
+// same way as the rest of the invoice, and recalculates the totals. A
+// percentage discount is frozen at its current amount so it does not reduce
+// the fee.
+func (i *Invoice) AddLateFee(description string, fee, gstRate decimal.Decimal) {
+	if i.DiscountType == "percentage" {
+		i.DiscountType = "flat"
+		i.DiscountValue = i.DiscountAmount
+	}
+
+	item := InvoiceItem{
+		InvoiceID:   i.ID,
+		Description: description,
+		Quantity:    decimal.NewFromInt(1),
+		Unit:        "nos",
+		Rate:        fee,
+	}
+	if i.IGSTAmount.IsPositive() {
+		item.IGSTRate = gstRate
+	} else {
+		item.CGSTRate = gstRate.Div(decimal.NewFromInt(2))
+		item.SGSTRate = item.CGSTRate
+	}
+	item.CalculateAmounts()
+
+	i.Items = append(i.Items, item)
+	i.CalculateTotals()
+}
+
+// SaleValue returns the sale consideration used for TCS (taxable amount plus GST)
+func (i *Invoice) SaleValue() decimal.Decimal {
+	return i.TaxableAmount.Add(i.TotalTax)
+}
+
+// ApplyRoundingRule copies the rounding settings onto the invoice
+func (i *Invoice) ApplyRoundingRule(rule *RoundingRule) {
+	i.RoundingMode = rule.Mode
+	i.RoundTo = rule.RoundTo
+	i.RoundingAccountID = rule.RoundingAccountID
 }
 
 // InvoiceItem represents a line item in an invoice
@@ -125,11 +393,19 @@ type InvoiceItem struct {
 	Rate        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
 	Amount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
 
+	// Rate in the invoice currency on foreign currency invoices; Rate is
+	// then its rupee equivalent
+	ForeignRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"foreign_rate"`
+
 	// Tax rates
-	CGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
-	SGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
-	IGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
-	CessRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+
+	// Specific cess per unit of quantity, charged on top of the ad valorem
+	// CessRate (e.g. ₹4.17 per stick for ₹4,170 per thousand cigarettes)
+	CessSpecificRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"cess_specific_rate"`
 
 	// Tax amounts
 	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
@@ -163,7 +439,7 @@ func (i *InvoiceItem) CalculateAmounts() {
 	i.CGSTAmount = i.Amount.Mul(i.CGSTRate.Div(hundred))
 	i.SGSTAmount = i.Amount.Mul(i.SGSTRate.Div(hundred))
 	i.IGSTAmount = i.Amount.Mul(i.IGSTRate.Div(hundred))
-	i.CessAmount = i.Amount.Mul(i.CessRate.Div(hundred))
+	i.CessAmount = i.Amount.Mul(i.CessRate.Div(hundred)).Add(i.Quantity.Mul(i.CessSpecificRate))
 
 	i.TotalAmount = i.Amount.Add(i.CGSTAmount).Add(i.SGSTAmount).Add(i.IGSTAmount).Add(i.CessAmount)
 }
@@ -177,8 +453,18 @@ type Payment struct {
 	PaymentDate   time.Time       `gorm:"not null" json:"payment_date"`
 	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
 	PaymentMethod string          `gorm:"size:50" json:"payment_method"` // cash, bank, upi, card
+	Discount      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"discount"` // Early-payment discount allowed
 	Reference     string          `gorm:"size:100" json:"reference"`
 	Notes         string          `gorm:"type:text" json:"notes"`
+
+	// A bounced payment (a dishonoured cheque, a returned NACH debit) no
+	// longer counts towards the invoice
+	BouncedAt    *time.Time `json:"bounced_at,omitempty"`
+	BounceReason string     `gorm:"size:255" json:"bounce_reason,omitempty"`
+
+	// Warnings about cash limits, returned when the payment is recorded
+	Warnings []string `gorm:"-" json:"warnings,omitempty"`
+
 	CreatedBy     uuid.UUID       `gorm:"type:uuid" json:"created_by"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
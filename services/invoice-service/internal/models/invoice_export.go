@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invoice export statuses
+const (
+	InvoiceExportQueued    = "queued"
+	InvoiceExportRunning   = "running"
+	InvoiceExportCompleted = "completed"
+	InvoiceExportFailed    = "failed"
+)
+
+// InvoiceExportRetention is how long a finished export can be downloaded
+// before its zip is purged
+const InvoiceExportRetention = 7 * 24 * time.Hour
+
+// InvoiceExport is a zip of the PDFs of every issued invoice dated within a
+// range, in one folder per month, for handing to auditors. It is built by a
+// background job that reports its progress as it renders.
+type InvoiceExport struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	FromDate time.Time `gorm:"type:date;not null" json:"from_date"`
+	ToDate   time.Time `gorm:"type:date;not null" json:"to_date"`
+	Status   string    `gorm:"size:20;not null;default:'queued'" json:"status"`
+	Error    string    `gorm:"type:text" json:"error,omitempty"`
+
+	// Progress, kept up to date as the invoices are rendered
+	Total    int `gorm:"default:0" json:"total"`
+	Rendered int `gorm:"default:0" json:"rendered"`
+
+	FileName string `gorm:"size:255" json:"file_name,omitempty"`
+	Size     int64  `gorm:"default:0" json:"size"`
+	Content  []byte `gorm:"type:bytea" json:"-"`
+
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // The zip is purged after this
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// DownloadURL is a signed link to the zip, filled in on reads of a
+	// completed export
+	DownloadURL string     `gorm:"-" json:"download_url,omitempty"`
+	LinkExpires *time.Time `gorm:"-" json:"download_url_expires_at,omitempty"`
+}
+
+// TableName returns the table name for InvoiceExport
+func (InvoiceExport) TableName() string {
+	return "invoice_exports"
+}
+
+// BeforeCreate hook
+func (e *InvoiceExport) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Available reports whether the export completed and its zip can still be
+// downloaded
+func (e *InvoiceExport) Available(now time.Time) bool {
+	return e.Status == InvoiceExportCompleted && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
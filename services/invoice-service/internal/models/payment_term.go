@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PaymentTerm is a named credit period, optionally with an early-payment
+// discount, e.g. "2/10 Net 30": due in 30 days, 2% off if paid within 10
+type PaymentTerm struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_payment_term_name" json:"tenant_id"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex:idx_tenant_payment_term_name" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	NetDays     int       `gorm:"not null;default:0" json:"net_days"` // 0 = due on receipt
+
+	// Early-payment discount
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"discount_percent"`
+	DiscountDays    int             `gorm:"default:0" json:"discount_days"`
+
+	IsDefault bool      `gorm:"default:false" json:"is_default"`
+	IsActive  bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for PaymentTerm
+func (PaymentTerm) TableName() string {
+	return "payment_terms"
+}
+
+// BeforeCreate hook
+func (t *PaymentTerm) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// HasDiscount reports whether the term offers an early-payment discount
+func (t *PaymentTerm) HasDiscount() bool {
+	return t.DiscountPercent.IsPositive() && t.DiscountDays > 0
+}
+
+// DueDate returns the due date for a document dated docDate
+func (t *PaymentTerm) DueDate(docDate time.Time) time.Time {
+	return docDate.AddDate(0, 0, t.NetDays)
+}
+
+// DiscountDate returns the last day the early-payment discount can be taken
+func (t *PaymentTerm) DiscountDate(docDate time.Time) time.Time {
+	return docDate.AddDate(0, 0, t.DiscountDays)
+}
+
+// DefaultPaymentTerms returns the terms every tenant starts with
+func DefaultPaymentTerms(tenantID uuid.UUID) []PaymentTerm {
+	return []PaymentTerm{
+		{TenantID: tenantID, Name: "Due on Receipt", NetDays: 0, IsActive: true},
+		{TenantID: tenantID, Name: "Net 15", NetDays: 15, IsActive: true},
+		{TenantID: tenantID, Name: "Net 30", NetDays: 30, IsActive: true, IsDefault: true},
+		{TenantID: tenantID, Name: "Net 45", NetDays: 45, IsActive: true},
+		{
+			TenantID:        tenantID,
+			Name:            "2/10 Net 30",
+			Description:     "2% discount if paid within 10 days, otherwise due in 30 days",
+			NetDays:         30,
+			DiscountPercent: decimal.NewFromInt(2),
+			DiscountDays:    10,
+			IsActive:        true,
+		},
+	}
+}
+
+// CustomerPaymentTerm records a customer's default payment term
+type CustomerPaymentTerm struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_customer_payment_term" json:"tenant_id"`
+	CustomerID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_customer_payment_term" json:"customer_id"`
+	PaymentTermID uuid.UUID `gorm:"type:uuid;not null" json:"payment_term_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	PaymentTerm *PaymentTerm `gorm:"foreignKey:PaymentTermID" json:"payment_term,omitempty"`
+}
+
+// TableName returns the table name for CustomerPaymentTerm
+func (CustomerPaymentTerm) TableName() string {
+	return "customer_payment_terms"
+}
+
+// BeforeCreate hook
+func (t *CustomerPaymentTerm) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
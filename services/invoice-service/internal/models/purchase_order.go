@@ -0,0 +1,254 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PurchaseOrderStatus represents the status of a purchase order
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderStatusIssued            PurchaseOrderStatus = "issued" // Sent to the vendor, nothing received yet
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "closed" // Short-closed, nothing more is expected
+	PurchaseOrderStatusCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// PurchaseOrder is an order placed with a vendor. Goods are received against
+// it, in part or in full, and the vendor's bills are raised from it so each
+// bill line can be matched to what was ordered and received.
+type PurchaseOrder struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	PONumber string    `gorm:"size:50;uniqueIndex:idx_tenant_po_num" json:"po_number"`
+
+	VendorID      uuid.UUID `gorm:"type:uuid;index" json:"vendor_id"`
+	VendorName    string    `gorm:"size:200" json:"vendor_name"`
+	VendorGSTIN   string    `gorm:"size:15" json:"vendor_gstin,omitempty"`
+	VendorPAN     string    `gorm:"size:10" json:"vendor_pan,omitempty"`
+	VendorAddress string    `gorm:"type:text" json:"vendor_address"`
+	VendorState   string    `gorm:"size:50" json:"vendor_state"`
+	VendorEmail   string    `gorm:"size:255" json:"vendor_email"`
+	VendorPhone   string    `gorm:"size:20" json:"vendor_phone"`
+
+	OrderDate    time.Time           `gorm:"not null" json:"order_date"`
+	ExpectedDate *time.Time          `json:"expected_date,omitempty"` // Delivery expected by
+	Status       PurchaseOrderStatus `gorm:"size:20;default:'draft';index" json:"status"`
+	Items        []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID" json:"items"`
+
+	// Amounts
+	Subtotal    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"subtotal"`
+	CGSTAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+	TotalTax    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
+	TotalAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
+
+	DeliveryAddress string `gorm:"type:text" json:"delivery_address,omitempty"`
+	Notes           string `gorm:"type:text" json:"notes"`
+	Terms           string `gorm:"type:text" json:"terms"`
+
+	IssuedAt    *time.Time `json:"issued_at,omitempty"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	CloseReason string     `gorm:"type:text" json:"close_reason,omitempty"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for PurchaseOrder
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+// BeforeCreate hook
+func (po *PurchaseOrder) BeforeCreate(tx *gorm.DB) error {
+	if po.ID == uuid.Nil {
+		po.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateTotals recalculates the order's totals from its items
+func (po *PurchaseOrder) CalculateTotals() {
+	po.Subtotal = decimal.Zero
+	po.CGSTAmount = decimal.Zero
+	po.SGSTAmount = decimal.Zero
+	po.IGSTAmount = decimal.Zero
+	po.CessAmount = decimal.Zero
+
+	for _, item := range po.Items {
+		po.Subtotal = po.Subtotal.Add(item.Amount)
+		po.CGSTAmount = po.CGSTAmount.Add(item.CGSTAmount)
+		po.SGSTAmount = po.SGSTAmount.Add(item.SGSTAmount)
+		po.IGSTAmount = po.IGSTAmount.Add(item.IGSTAmount)
+		po.CessAmount = po.CessAmount.Add(item.CessAmount)
+	}
+
+	po.TotalTax = po.CGSTAmount.Add(po.SGSTAmount).Add(po.IGSTAmount).Add(po.CessAmount)
+	po.TotalAmount = po.Subtotal.Add(po.TotalTax)
+}
+
+// ReceiptStatus returns the status the order's receipts put it in
+func (po *PurchaseOrder) ReceiptStatus() PurchaseOrderStatus {
+	received, complete := false, true
+	for _, item := range po.Items {
+		if item.QuantityReceived.IsPositive() {
+			received = true
+		}
+		if item.PendingReceipt().IsPositive() {
+			complete = false
+		}
+	}
+
+	switch {
+	case complete:
+		return PurchaseOrderStatusReceived
+	case received:
+		return PurchaseOrderStatusPartiallyReceived
+	default:
+		return PurchaseOrderStatusIssued
+	}
+}
+
+// CanReceive reports whether goods can be received against the order
+func (po *PurchaseOrder) CanReceive() bool {
+	return po.Status == PurchaseOrderStatusIssued || po.Status == PurchaseOrderStatusPartiallyReceived
+}
+
+// CanBill reports whether the vendor's bills can be raised from the order
+func (po *PurchaseOrder) CanBill() bool {
+	return po.Status != PurchaseOrderStatusDraft && po.Status != PurchaseOrderStatusCancelled
+}
+
+// PurchaseOrderItem represents a line of a purchase order
+type PurchaseOrderItem struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PurchaseOrderID uuid.UUID       `gorm:"type:uuid;index;not null" json:"purchase_order_id"`
+	ProductID       *uuid.UUID      `gorm:"type:uuid" json:"product_id,omitempty"`
+	Description     string          `gorm:"size:500;not null" json:"description"`
+	HSNCode         string          `gorm:"size:10" json:"hsn_code"`
+	SACCode         string          `gorm:"size:10" json:"sac_code"`
+	Quantity        decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
+	Unit            string          `gorm:"size:20;default:'pcs'" json:"unit"`
+	Rate            decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
+	Amount          decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+
+	CGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"cess_specific_rate"`
+
+	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+
+	TotalAmount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_amount"`
+	ExpenseAccountID *uuid.UUID      `gorm:"type:uuid" json:"expense_account_id,omitempty"` // Carried to the bill lines
+
+	QuantityReceived decimal.Decimal `gorm:"type:decimal(10,3);default:0" json:"quantity_received"`
+	SortOrder        int             `gorm:"default:0" json:"sort_order"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for PurchaseOrderItem
+func (PurchaseOrderItem) TableName() string {
+	return "purchase_order_items"
+}
+
+// BeforeCreate hook
+func (i *PurchaseOrderItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateAmounts calculates the line's amounts including taxes, as a bill
+// line does
+func (i *PurchaseOrderItem) CalculateAmounts() {
+	line := BillItem{
+		Quantity:         i.Quantity,
+		Rate:             i.Rate,
+		CGSTRate:         i.CGSTRate,
+		SGSTRate:         i.SGSTRate,
+		IGSTRate:         i.IGSTRate,
+		CessRate:         i.CessRate,
+		CessSpecificRate: i.CessSpecificRate,
+	}
+	line.CalculateAmounts()
+
+	i.Amount = line.Amount
+	i.CGSTAmount = line.CGSTAmount
+	i.SGSTAmount = line.SGSTAmount
+	i.IGSTAmount = line.IGSTAmount
+	i.CessAmount = line.CessAmount
+	i.TotalAmount = line.TotalAmount
+}
+
+// PendingReceipt returns how much of the line is still to be received
+func (i *PurchaseOrderItem) PendingReceipt() decimal.Decimal {
+	return decimal.Max(i.Quantity.Sub(i.QuantityReceived), decimal.Zero)
+}
+
+// GoodsReceipt records goods received against a purchase order (a GRN)
+type GoodsReceipt struct {
+	ID              uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID        uuid.UUID          `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	ReceiptNumber   string             `gorm:"size:50;uniqueIndex:idx_tenant_grn_num" json:"receipt_number"`
+	PurchaseOrderID uuid.UUID          `gorm:"type:uuid;index;not null" json:"purchase_order_id"`
+	ReceivedDate    time.Time          `gorm:"type:date;not null" json:"received_date"`
+	DeliveryNote    string             `gorm:"size:100" json:"delivery_note,omitempty"` // Vendor's challan or delivery note number
+	Notes           string             `gorm:"type:text" json:"notes,omitempty"`
+	Items           []GoodsReceiptItem `gorm:"foreignKey:ReceiptID" json:"items"`
+	ReceivedBy      uuid.UUID          `gorm:"type:uuid" json:"received_by"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+// TableName returns the table name for GoodsReceipt
+func (GoodsReceipt) TableName() string {
+	return "goods_receipts"
+}
+
+// BeforeCreate hook
+func (r *GoodsReceipt) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// GoodsReceiptItem is the quantity of a purchase order line received
+type GoodsReceiptItem struct {
+	ID                  uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReceiptID           uuid.UUID       `gorm:"type:uuid;index;not null" json:"receipt_id"`
+	PurchaseOrderItemID uuid.UUID       `gorm:"type:uuid;index;not null" json:"purchase_order_item_id"`
+	Description         string          `gorm:"size:500" json:"description"`
+	Quantity            decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
+
+// TableName returns the table name for GoodsReceiptItem
+func (GoodsReceiptItem) TableName() string {
+	return "goods_receipt_items"
+}
+
+// BeforeCreate hook
+func (i *GoodsReceiptItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// QuoteStatus represents the status of a quotation
+type QuoteStatus string
+
+const (
+	QuoteStatusDraft    QuoteStatus = "draft"
+	QuoteStatusSent     QuoteStatus = "sent"
+	QuoteStatusAccepted QuoteStatus = "accepted"
+	QuoteStatusDeclined QuoteStatus = "declined"
+	QuoteStatusExpired  QuoteStatus = "expired" // Not answered by its validity date
+)
+
+// Quote is a quotation or estimate offered to a customer. A sent quote is
+// answered by the customer through a link that needs no login, and is
+// converted to an invoice with the same items and taxes. Its totals leave
+// out rounding and TCS, which the invoice works out when it is raised.
+type Quote struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	QuoteNumber string    `gorm:"size:50;uniqueIndex:idx_tenant_quote_num" json:"quote_number"`
+
+	CustomerID      uuid.UUID `gorm:"type:uuid;index" json:"customer_id"`
+	CustomerName    string    `gorm:"size:200" json:"customer_name"`
+	CustomerGSTIN   string    `gorm:"size:15" json:"customer_gstin,omitempty"`
+	CustomerPAN     string    `gorm:"size:10" json:"customer_pan,omitempty"`
+	CustomerAddress string    `gorm:"type:text" json:"customer_address"`
+	CustomerState   string    `gorm:"size:50" json:"customer_state"`
+	CustomerEmail   string    `gorm:"size:255" json:"customer_email"`
+	CustomerPhone   string    `gorm:"size:20" json:"customer_phone"`
+
+	QuoteDate  time.Time   `gorm:"not null" json:"quote_date"`
+	ValidUntil time.Time   `gorm:"not null" json:"valid_until"`
+	Status     QuoteStatus `gorm:"size:20;default:'draft';index" json:"status"`
+	Items      []QuoteItem `gorm:"foreignKey:QuoteID" json:"items"`
+
+	// Amounts
+	Subtotal       decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"subtotal"`
+	DiscountType   string          `gorm:"size:20" json:"discount_type"` // percentage or fixed
+	DiscountValue  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"discount_value"`
+	DiscountAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"discount_amount"`
+	TaxableAmount  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"taxable_amount"`
+	CGSTAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount     decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+	TotalTax       decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
+	TotalAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
+
+	Notes    string `gorm:"type:text" json:"notes"`
+	Terms    string `gorm:"type:text" json:"terms"`
+	Language string `gorm:"size:5;default:'en'" json:"language"`
+
+	// Customer's answer through the accept link
+	TokenHash     string     `gorm:"size:64;index" json:"-"` // SHA-256 of the link token
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	RespondedAt   *time.Time `json:"responded_at,omitempty"`
+	AcceptedBy    string     `gorm:"size:200" json:"accepted_by,omitempty"` // Name the customer signed with
+	DeclineReason string     `gorm:"type:text" json:"decline_reason,omitempty"`
+	ResponseIP    string     `gorm:"size:45" json:"response_ip,omitempty"`
+
+	// Invoice the quote was converted to
+	InvoiceID     *uuid.UUID `gorm:"type:uuid;uniqueIndex" json:"invoice_id,omitempty"`
+	InvoiceNumber string     `gorm:"size:50" json:"invoice_number,omitempty"`
+	ConvertedAt   *time.Time `json:"converted_at,omitempty"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for Quote
+func (Quote) TableName() string {
+	return "quotes"
+}
+
+// BeforeCreate hook
+func (q *Quote) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == uuid.Nil {
+		q.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateTotals recalculates the quote's totals from its items
+func (q *Quote) CalculateTotals() {
+	q.Subtotal = decimal.Zero
+	q.CGSTAmount = decimal.Zero
+	q.SGSTAmount = decimal.Zero
+	q.IGSTAmount = decimal.Zero
+	q.CessAmount = decimal.Zero
+
+	for _, item := range q.Items {
+		q.Subtotal = q.Subtotal.Add(item.Amount)
+		q.CGSTAmount = q.CGSTAmount.Add(item.CGSTAmount)
+		q.SGSTAmount = q.SGSTAmount.Add(item.SGSTAmount)
+		q.IGSTAmount = q.IGSTAmount.Add(item.IGSTAmount)
+		q.CessAmount = q.CessAmount.Add(item.CessAmount)
+	}
+
+	if q.DiscountType == "percentage" {
+		q.DiscountAmount = q.Subtotal.Mul(q.DiscountValue.Div(decimal.NewFromInt(100)))
+	} else {
+		q.DiscountAmount = q.DiscountValue
+	}
+
+	q.TaxableAmount = q.Subtotal.Sub(q.DiscountAmount)
+	q.TotalTax = q.CGSTAmount.Add(q.SGSTAmount).Add(q.IGSTAmount).Add(q.CessAmount)
+	q.TotalAmount = q.TaxableAmount.Add(q.TotalTax)
+}
+
+// IsConverted reports whether an invoice was raised from the quote
+func (q *Quote) IsConverted() bool {
+	return q.InvoiceID != nil
+}
+
+// HasLapsed reports whether a sent quote's validity ended before today
+func (q *Quote) HasLapsed(today time.Time) bool {
+	return q.Status == QuoteStatusSent && q.ValidUntil.Before(today)
+}
+
+// QuoteItem represents a line item of a quote
+type QuoteItem struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	QuoteID     uuid.UUID       `gorm:"type:uuid;index;not null" json:"quote_id"`
+	ProductID   *uuid.UUID      `gorm:"type:uuid" json:"product_id,omitempty"`
+	Description string          `gorm:"size:500;not null" json:"description"`
+	HSNCode     string          `gorm:"size:10" json:"hsn_code"`
+	Quantity    decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
+	Unit        string          `gorm:"size:20;default:'pcs'" json:"unit"`
+	Rate        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
+	Amount      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+
+	CGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate         decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"cess_specific_rate"`
+
+	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+
+	TotalAmount decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_amount"`
+	SortOrder   int             `gorm:"default:0" json:"sort_order"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for QuoteItem
+func (QuoteItem) TableName() string {
+	return "quote_items"
+}
+
+// BeforeCreate hook
+func (i *QuoteItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// CalculateAmounts calculates the line's amounts including taxes, as an
+// invoice line does
+func (i *QuoteItem) CalculateAmounts() {
+	line := InvoiceItem{
+		Quantity:         i.Quantity,
+		Rate:             i.Rate,
+		CGSTRate:         i.CGSTRate,
+		SGSTRate:         i.SGSTRate,
+		IGSTRate:         i.IGSTRate,
+		CessRate:         i.CessRate,
+		CessSpecificRate: i.CessSpecificRate,
+	}
+	line.CalculateAmounts()
+
+	i.Amount = line.Amount
+	i.CGSTAmount = line.CGSTAmount
+	i.SGSTAmount = line.SGSTAmount
+	i.IGSTAmount = line.IGSTAmount
+	i.CessAmount = line.CessAmount
+	i.TotalAmount = line.TotalAmount
+}
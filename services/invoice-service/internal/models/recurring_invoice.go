@@ -35,6 +35,7 @@ type RecurringInvoice struct {
 	ID              uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	TenantID        uuid.UUID              `gorm:"type:uuid;index;not null" json:"tenant_id"`
 	Name            string                 `gorm:"size:200;not null" json:"name"`
+	ContractID      *uuid.UUID             `gorm:"type:uuid;index" json:"contract_id,omitempty"`
 
 	// Customer info (copied to generated invoices)
 	CustomerID      uuid.UUID              `gorm:"type:uuid;index;not null" json:"customer_id"`
@@ -183,21 +184,25 @@ func (ri *RecurringInvoice) ShouldGenerate() bool {
 
 // RecurringInvoiceItem represents a line item template
 type RecurringInvoiceItem struct {
-	ID                  uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	RecurringInvoiceID  uuid.UUID       `gorm:"type:uuid;index;not null" json:"recurring_invoice_id"`
-	ProductID           *uuid.UUID      `gorm:"type:uuid" json:"product_id,omitempty"`
-	Description         string          `gorm:"size:500;not null" json:"description"`
-	HSNCode             string          `gorm:"size:10" json:"hsn_code"`
-	Quantity            decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
-	Unit                string          `gorm:"size:20;default:'pcs'" json:"unit"`
-	Rate                decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
-	Amount              decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	ID                 uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RecurringInvoiceID uuid.UUID       `gorm:"type:uuid;index;not null" json:"recurring_invoice_id"`
+	ProductID          *uuid.UUID      `gorm:"type:uuid" json:"product_id,omitempty"`
+	Description        string          `gorm:"size:500;not null" json:"description"`
+	HSNCode            string          `gorm:"size:10" json:"hsn_code"`
+	Quantity           decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
+	Unit               string          `gorm:"size:20;default:'pcs'" json:"unit"`
+	Rate               decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
+	Amount             decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
 
 	// Tax rates
-	CGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
-	SGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
-	IGSTRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
-	CessRate   decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+	CGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+
+	// Specific cess per unit of quantity, charged on top of the ad valorem
+	// CessRate (e.g. ₹4.17 per stick for ₹4,170 per thousand cigarettes)
+	CessSpecificRate decimal.Decimal `gorm:"type:decimal(15,4);default:0" json:"cess_specific_rate"`
 
 	// Tax amounts
 	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
@@ -231,7 +236,7 @@ func (rii *RecurringInvoiceItem) CalculateAmounts() {
 	rii.CGSTAmount = rii.Amount.Mul(rii.CGSTRate.Div(hundred))
 	rii.SGSTAmount = rii.Amount.Mul(rii.SGSTRate.Div(hundred))
 	rii.IGSTAmount = rii.Amount.Mul(rii.IGSTRate.Div(hundred))
-	rii.CessAmount = rii.Amount.Mul(rii.CessRate.Div(hundred))
+	rii.CessAmount = rii.Amount.Mul(rii.CessRate.Div(hundred)).Add(rii.Quantity.Mul(rii.CessSpecificRate))
 
 	rii.TotalAmount = rii.Amount.Add(rii.CGSTAmount).Add(rii.SGSTAmount).Add(rii.IGSTAmount).Add(rii.CessAmount)
 }
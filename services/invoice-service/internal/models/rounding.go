@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DocumentType identifies the kind of document a setting applies to
+type DocumentType string
+
+const (
+	DocumentTypeInvoice    DocumentType = "invoice"
+	DocumentTypeBill       DocumentType = "bill"
+	DocumentTypeCreditNote DocumentType = "credit_note"
+)
+
+// RoundingMode represents how a document total is rounded
+type RoundingMode string
+
+const (
+	RoundingModeNone    RoundingMode = "none"
+	RoundingModeNearest RoundingMode = "nearest"
+	RoundingModeUp      RoundingMode = "up"
+	RoundingModeDown    RoundingMode = "down"
+)
+
+// IsValid reports whether the rounding mode is supported
+func (m RoundingMode) IsValid() bool {
+	switch m {
+	case RoundingModeNone, RoundingModeNearest, RoundingModeUp, RoundingModeDown:
+		return true
+	}
+	return false
+}
+
+// RoundingRule configures total rounding for a document type
+type RoundingRule struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_rounding_doc" json:"tenant_id"`
+	DocumentType DocumentType    `gorm:"size:20;not null;uniqueIndex:idx_tenant_rounding_doc" json:"document_type"`
+	Mode         RoundingMode    `gorm:"size:20;not null;default:'nearest'" json:"mode"`
+	RoundTo      decimal.Decimal `gorm:"type:decimal(10,2);default:1" json:"round_to"` // 1 = nearest rupee
+
+	// Ledger account that receives the round-off difference
+	RoundingAccountID *uuid.UUID `gorm:"type:uuid" json:"rounding_account_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for RoundingRule
+func (RoundingRule) TableName() string {
+	return "rounding_rules"
+}
+
+// BeforeCreate hook
+func (r *RoundingRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// DefaultRoundingRule returns the rule used when a tenant has not configured one.
+// Indian practice is to round document totals to the nearest rupee.
+func DefaultRoundingRule(tenantID uuid.UUID, docType DocumentType) *RoundingRule {
+	return &RoundingRule{
+		TenantID:     tenantID,
+		DocumentType: docType,
+		Mode:         RoundingModeNearest,
+		RoundTo:      decimal.NewFromInt(1),
+	}
+}
+
+// RoundAmount rounds amount to a multiple of unit using the given mode.
+// Amounts are returned unchanged when rounding is disabled.
+func RoundAmount(amount, unit decimal.Decimal, mode RoundingMode) decimal.Decimal {
+	if !unit.IsPositive() {
+		return amount
+	}
+
+	units := amount.Div(unit)
+	switch mode {
+	case RoundingModeNearest:
+		units = units.Round(0)
+	case RoundingModeUp:
+		units = units.Ceil()
+	case RoundingModeDown:
+		units = units.Floor()
+	default:
+		return amount
+	}
+
+	return units.Mul(unit)
+}
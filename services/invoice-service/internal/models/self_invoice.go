@@ -0,0 +1,147 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SelfInvoice is the invoice a registered buyer issues to itself (section
+// 31(3)(f) of the CGST Act) for a purchase from an unregistered vendor on
+// which it pays GST under reverse charge. It has its own numbering series
+// and is issued once per bill.
+type SelfInvoice struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      uuid.UUID `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	InvoiceNumber string    `gorm:"size:50;uniqueIndex:idx_tenant_self_invoice_num" json:"invoice_number"`
+	InvoiceDate   time.Time `gorm:"not null" json:"invoice_date"`
+	BillID        uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"bill_id"`
+	BillNumber    string    `gorm:"size:50" json:"bill_number"`
+	BillDate      time.Time `json:"bill_date"`
+
+	// The unregistered vendor who made the supply
+	VendorID      uuid.UUID `gorm:"type:uuid;index" json:"vendor_id"`
+	VendorName    string    `gorm:"size:200" json:"vendor_name"`
+	VendorPAN     string    `gorm:"size:10" json:"vendor_pan,omitempty"`
+	VendorAddress string    `gorm:"type:text" json:"vendor_address"`
+	VendorState   string    `gorm:"size:50" json:"vendor_state"`
+
+	Items []SelfInvoiceItem `gorm:"foreignKey:SelfInvoiceID" json:"items"`
+
+	// GST payable by us under reverse charge
+	TaxableAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"taxable_amount"`
+	CGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount    decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+	TotalTax      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_tax"`
+	TotalAmount   decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"total_amount"`
+
+	CreatedBy uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for SelfInvoice
+func (SelfInvoice) TableName() string {
+	return "self_invoices"
+}
+
+// BeforeCreate hook
+func (s *SelfInvoice) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// SelfInvoiceItem is a line of a self-invoice, copied from the bill
+type SelfInvoiceItem struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SelfInvoiceID uuid.UUID       `gorm:"type:uuid;index;not null" json:"self_invoice_id"`
+	Description   string          `gorm:"size:500;not null" json:"description"`
+	HSNCode       string          `gorm:"size:10" json:"hsn_code"`
+	SACCode       string          `gorm:"size:10" json:"sac_code"`
+	Quantity      decimal.Decimal `gorm:"type:decimal(10,3);not null" json:"quantity"`
+	Unit          string          `gorm:"size:20" json:"unit"`
+	Rate          decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"rate"`
+	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+
+	CGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cgst_rate"`
+	SGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"sgst_rate"`
+	IGSTRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"igst_rate"`
+	CessRate decimal.Decimal `gorm:"type:decimal(5,2);default:0" json:"cess_rate"`
+
+	CGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cgst_amount"`
+	SGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"sgst_amount"`
+	IGSTAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"igst_amount"`
+	CessAmount decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"cess_amount"`
+
+	// ITCEligible is whether the tax paid can be claimed back as input tax
+	// credit, which follows the bill line
+	ITCEligible bool      `gorm:"default:true" json:"itc_eligible"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for SelfInvoiceItem
+func (SelfInvoiceItem) TableName() string {
+	return "self_invoice_items"
+}
+
+// BeforeCreate hook
+func (i *SelfInvoiceItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewSelfInvoiceFromBill builds the self-invoice for a reverse charge bill,
+// carrying over the bill's lines and the tax worked out on them
+func NewSelfInvoiceFromBill(bill *Bill) *SelfInvoice {
+	invoice := &SelfInvoice{
+		ID:            uuid.New(),
+		TenantID:      bill.TenantID,
+		BillID:        bill.ID,
+		BillNumber:    bill.BillNumber,
+		BillDate:      bill.BillDate,
+		VendorID:      bill.VendorID,
+		VendorName:    bill.VendorName,
+		VendorPAN:     bill.VendorPAN,
+		VendorAddress: bill.VendorAddress,
+		VendorState:   bill.VendorState,
+		TaxableAmount: bill.TaxableAmount,
+		CGSTAmount:    bill.CGSTAmount,
+		SGSTAmount:    bill.SGSTAmount,
+		IGSTAmount:    bill.IGSTAmount,
+		CessAmount:    bill.CessAmount,
+		TotalTax:      bill.TotalTax,
+	}
+	invoice.TotalAmount = invoice.TaxableAmount.Add(invoice.TotalTax)
+
+	for _, item := range bill.Items {
+		invoice.Items = append(invoice.Items, SelfInvoiceItem{
+			SelfInvoiceID: invoice.ID,
+			Description:   item.Description,
+			HSNCode:       item.HSNCode,
+			SACCode:       item.SACCode,
+			Quantity:      item.Quantity,
+			Unit:          item.Unit,
+			Rate:          item.Rate,
+			Amount:        item.Amount,
+			CGSTRate:      item.CGSTRate,
+			SGSTRate:      item.SGSTRate,
+			IGSTRate:      item.IGSTRate,
+			CessRate:      item.CessRate,
+			CGSTAmount:    item.CGSTAmount,
+			SGSTAmount:    item.SGSTAmount,
+			IGSTAmount:    item.IGSTAmount,
+			CessAmount:    item.CessAmount,
+			ITCEligible:   bill.ITCEligible && item.ITCEligible,
+		})
+	}
+	return invoice
+}
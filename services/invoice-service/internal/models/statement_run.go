@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Statement run statuses
+const (
+	StatementRunQueued    = "queued"
+	StatementRunRunning   = "running"
+	StatementRunCompleted = "completed"
+	StatementRunFailed    = "failed"
+)
+
+// Statement delivery statuses
+const (
+	StatementDeliveryPending = "pending"
+	StatementDeliverySent    = "sent"
+	StatementDeliveryFailed  = "failed"
+	StatementDeliverySkipped = "skipped" // No email address to send to
+)
+
+// StatementRun emails statements of account for a month to every customer
+// whose balance at the month end is at least MinBalance. It runs as a
+// background job; each customer's delivery is tracked separately.
+type StatementRun struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID       `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	PeriodStart time.Time       `gorm:"type:date;not null" json:"period_start"`
+	PeriodEnd   time.Time       `gorm:"type:date;not null" json:"period_end"`
+	MinBalance  decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"min_balance"`
+	Status      string          `gorm:"size:20;not null;default:'queued'" json:"status"`
+	Error       string          `gorm:"type:text" json:"error,omitempty"`
+
+	// Delivery counts, kept up to date as the run progresses
+	Recipients int `gorm:"default:0" json:"recipients"`
+	Sent       int `gorm:"default:0" json:"sent"`
+	Failed     int `gorm:"default:0" json:"failed"`
+	Skipped    int `gorm:"default:0" json:"skipped"`
+
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Deliveries []StatementDelivery `gorm:"foreignKey:RunID" json:"deliveries,omitempty"`
+}
+
+// TableName returns the table name for StatementRun
+func (StatementRun) TableName() string {
+	return "statement_runs"
+}
+
+// BeforeCreate hook
+func (r *StatementRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// StatementDelivery is the statement of one customer in a run
+type StatementDelivery struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RunID        uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_statement_delivery" json:"run_id"`
+	TenantID     uuid.UUID       `gorm:"type:uuid;index;not null" json:"tenant_id"`
+	CustomerID   uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_statement_delivery" json:"customer_id"`
+	CustomerName string          `gorm:"size:200" json:"customer_name"`
+	Email        string          `gorm:"size:255" json:"email"`
+	Language     string          `gorm:"size:5" json:"language"`
+	Balance      decimal.Decimal `gorm:"type:decimal(15,2);default:0" json:"balance"` // At the period end
+	Status       string          `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Attempts     int             `gorm:"default:0" json:"attempts"`
+	Error        string          `gorm:"type:text" json:"error,omitempty"`
+	SentAt       *time.Time      `json:"sent_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for StatementDelivery
+func (StatementDelivery) TableName() string {
+	return "statement_deliveries"
+}
+
+// BeforeCreate hook
+func (d *StatementDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// TaxSnapshotVersion is bumped whenever the snapshot layout changes
+const TaxSnapshotVersion = 1
+
+// TaxSnapshotLine records the tax treatment applied to a document line and
+// the catalog data it was derived from
+type TaxSnapshotLine struct {
+	LineID        uuid.UUID       `json:"line_id"`
+	ProductID     *uuid.UUID      `json:"product_id,omitempty"`
+	Description   string          `json:"description"`
+	HSNCode       string          `json:"hsn_code,omitempty"`
+	SACCode       string          `json:"sac_code,omitempty"`
+	TaxableAmount decimal.Decimal `json:"taxable_amount"`
+
+	// Rates and amounts as applied on the document
+	CGSTRate   decimal.Decimal `json:"cgst_rate"`
+	SGSTRate   decimal.Decimal `json:"sgst_rate"`
+	IGSTRate   decimal.Decimal `json:"igst_rate"`
+	CessRate   decimal.Decimal `json:"cess_rate"`
+	CGSTAmount decimal.Decimal `json:"cgst_amount"`
+	SGSTAmount decimal.Decimal `json:"sgst_amount"`
+	IGSTAmount decimal.Decimal `json:"igst_amount"`
+	CessAmount decimal.Decimal `json:"cess_amount"`
+
+	// Per-unit cess; omitted when none so older snapshots still verify
+	CessSpecificRate *decimal.Decimal `json:"cess_specific_rate,omitempty"`
+	Quantity         *decimal.Decimal `json:"quantity,omitempty"`
+
+	// Catalog tax settings of the product at finalization
+	ProductCategory  string           `json:"product_category,omitempty"`
+	ProductTaxRateID *uuid.UUID       `json:"product_tax_rate_id,omitempty"`
+	ProductGSTRate   *decimal.Decimal `json:"product_gst_rate,omitempty"`
+	ProductExempt    bool             `json:"product_exempt,omitempty"`
+}
+
+// TaxSnapshotRules records the document-level rules in force at finalization
+type TaxSnapshotRules struct {
+	PartyState string `json:"party_state"` // Customer state for invoices, vendor state for bills
+	Interstate bool   `json:"interstate"`
+
+	RoundingMode      RoundingMode    `json:"rounding_mode,omitempty"`
+	RoundTo           decimal.Decimal `json:"round_to"`
+	RoundingAccountID *uuid.UUID      `json:"rounding_account_id,omitempty"`
+
+	WithholdingType    string          `json:"withholding_type,omitempty"` // TCS or TDS
+	WithholdingSection string          `json:"withholding_section,omitempty"`
+	WithholdingRate    decimal.Decimal `json:"withholding_rate"`
+}
+
+// TaxSnapshotLines is stored as JSONB
+type TaxSnapshotLines []TaxSnapshotLine
+
+// Value implements driver.Valuer
+func (l TaxSnapshotLines) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan implements sql.Scanner
+func (l *TaxSnapshotLines) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+// Value implements driver.Valuer
+func (r TaxSnapshotRules) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner
+func (r *TaxSnapshotRules) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
+
+func scanJSON(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.New("unsupported JSONB value")
+	}
+}
+
+// TaxSnapshot is an immutable record of the tax calculation applied to an
+// invoice or bill when it was finalized. Later changes to rates or product
+// settings do not affect it, so it can be used to resolve disputes.
+type TaxSnapshot struct {
+	ID             uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID       uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_tax_snapshot_doc" json:"tenant_id"`
+	DocumentType   DocumentType     `gorm:"size:20;not null;uniqueIndex:idx_tenant_tax_snapshot_doc" json:"document_type"`
+	DocumentID     uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_tax_snapshot_doc" json:"document_id"`
+	DocumentNumber string           `gorm:"size:50" json:"document_number"`
+	DocumentDate   time.Time        `json:"document_date"`
+	Version        int              `gorm:"not null" json:"version"`
+	Lines          TaxSnapshotLines `gorm:"type:jsonb;not null" json:"lines"`
+	Rules          TaxSnapshotRules `gorm:"type:jsonb;not null" json:"rules"`
+
+	TaxableAmount decimal.Decimal `gorm:"type:decimal(15,2)" json:"taxable_amount"`
+	TotalTax      decimal.Decimal `gorm:"type:decimal(15,2)" json:"total_tax"`
+	TotalAmount   decimal.Decimal `gorm:"type:decimal(15,2)" json:"total_amount"`
+
+	// SHA-256 of the lines, rules and totals, to detect tampering
+	Checksum   string    `gorm:"size:64;not null" json:"checksum"`
+	CapturedAt time.Time `gorm:"not null" json:"captured_at"`
+}
+
+// TableName returns the table name for TaxSnapshot
+func (TaxSnapshot) TableName() string {
+	return "tax_snapshots"
+}
+
+// BeforeCreate hook
+func (s *TaxSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// ComputeChecksum hashes the snapshot contents
+func (s *TaxSnapshot) ComputeChecksum() string {
+	payload, _ := json.Marshal(struct {
+		Lines         TaxSnapshotLines `json:"lines"`
+		Rules         TaxSnapshotRules `json:"rules"`
+		TaxableAmount string           `json:"taxable_amount"`
+		TotalTax      string           `json:"total_tax"`
+		TotalAmount   string           `json:"total_amount"`
+	}{s.Lines, s.Rules, s.TaxableAmount.StringFixed(2), s.TotalTax.StringFixed(2), s.TotalAmount.StringFixed(2)})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether the snapshot still matches its checksum
+func (s *TaxSnapshot) Verify() bool {
+	return s.Checksum == s.ComputeChecksum()
+}
@@ -0,0 +1,83 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// extractBillPath is the provider's endpoint for reading invoices, relative
+// to its base URL
+const extractBillPath = "/v1/extract/invoice"
+
+type httpProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewHTTPProvider creates a client of a document extraction service that
+// takes the document as a multipart upload and answers with the fields of
+// Bill as JSON. Reading a long PDF can take a while, hence the timeout.
+func NewHTTPProvider(baseURL, apiKey string) Provider {
+	return &httpProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (p *httpProvider) Name() string {
+	return "http"
+}
+
+func (p *httpProvider) ExtractBill(ctx context.Context, doc Document) (*Bill, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreatePart(map[string][]string{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="document"; filename=%q`, doc.FileName)},
+		"Content-Type":        {doc.ContentType},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(doc.Content); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+extractBillPath, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return nil, ErrUnreadable
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("%w: provider returned %d", ErrUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("document reader returned %d", resp.StatusCode)
+	}
+
+	var bill Bill
+	if err := json.NewDecoder(resp.Body).Decode(&bill); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return &bill, nil
+}
@@ -0,0 +1,75 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrUnavailable is returned when the OCR provider could not be
+	// reached or failed to answer; the scan may be retried
+	ErrUnavailable = errors.New("document reader is unavailable")
+
+	// ErrUnreadable is returned when the provider found no bill in the
+	// document, such as a blank page or an image too blurred to read
+	ErrUnreadable = errors.New("no bill could be read from the document")
+)
+
+// Document is a scanned or digital bill to be read
+type Document struct {
+	FileName    string
+	ContentType string // application/pdf, image/jpeg, image/png or image/webp
+	Content     []byte
+}
+
+// Bill is what a provider read from a vendor's bill. Fields it could not
+// find are left empty; amounts are as printed, in rupees.
+type Bill struct {
+	VendorName    string `json:"vendor_name"`
+	VendorGSTIN   string `json:"vendor_gstin"`
+	VendorAddress string `json:"vendor_address"`
+	InvoiceNumber string `json:"invoice_number"`
+	InvoiceDate   string `json:"invoice_date"` // As printed, e.g. "05/04/2024" or "5 Apr 2024"
+	DueDate       string `json:"due_date"`
+	PlaceOfSupply string `json:"place_of_supply"`
+
+	Lines []BillLine `json:"lines"`
+
+	TaxableAmount decimal.Decimal `json:"taxable_amount"`
+	CGSTAmount    decimal.Decimal `json:"cgst_amount"`
+	SGSTAmount    decimal.Decimal `json:"sgst_amount"`
+	IGSTAmount    decimal.Decimal `json:"igst_amount"`
+	CessAmount    decimal.Decimal `json:"cess_amount"`
+	TotalAmount   decimal.Decimal `json:"total_amount"`
+
+	// Confidence is the provider's confidence in the reading as a whole,
+	// from 0 to 1
+	Confidence float64 `json:"confidence"`
+}
+
+// BillLine is a line item read from a bill
+type BillLine struct {
+	Description string          `json:"description"`
+	HSNCode     string          `json:"hsn_code"` // HSN or SAC
+	Quantity    decimal.Decimal `json:"quantity"`
+	Unit        string          `json:"unit"`
+	Rate        decimal.Decimal `json:"rate"`
+	Amount      decimal.Decimal `json:"amount"`   // Taxable value of the line
+	TaxRate     decimal.Decimal `json:"tax_rate"` // Combined GST rate, when printed per line
+
+	CGSTAmount decimal.Decimal `json:"cgst_amount"`
+	SGSTAmount decimal.Decimal `json:"sgst_amount"`
+	IGSTAmount decimal.Decimal `json:"igst_amount"`
+	CessAmount decimal.Decimal `json:"cess_amount"`
+}
+
+// Provider reads bills from PDFs and images. Implementations wrap an OCR
+// or document AI service.
+type Provider interface {
+	Name() string
+	// ExtractBill reads the vendor, number, date, lines and taxes of the
+	// bill in the document. Returns ErrUnreadable if there is none.
+	ExtractBill(ctx context.Context, doc Document) (*Bill, error)
+}
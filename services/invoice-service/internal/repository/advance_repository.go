@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrAdvanceChanged is returned when an advance was refunded by someone else
+// while a refund was being made
+var ErrAdvanceChanged = errors.New("advance receipt was changed by another refund")
+
+// AdvanceRepository handles advance receipt and refund voucher data
+// operations
+type AdvanceRepository interface {
+	Create(ctx context.Context, advance *models.AdvanceReceipt) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.AdvanceReceipt, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters AdvanceFilters) ([]models.AdvanceReceipt, error)
+	// CreateRefund saves a refund voucher and the advance's new refunded
+	// amount, provided no other refund was made since refundedBefore
+	CreateRefund(ctx context.Context, advance *models.AdvanceReceipt, refundedBefore decimal.Decimal, voucher *models.RefundVoucher) error
+	ListRefunds(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.RefundVoucher, error)
+	GetNextNumber(ctx context.Context, tenantID uuid.UUID, table, column, prefix string) (string, error)
+}
+
+// AdvanceFilters represents filters for listing advance receipts
+type AdvanceFilters struct {
+	CustomerID uuid.UUID
+	Status     string
+	FromDate   string
+	ToDate     string
+}
+
+type advanceRepository struct {
+	db *gorm.DB
+}
+
+// NewAdvanceRepository creates a new advance repository
+func NewAdvanceRepository(db *gorm.DB) AdvanceRepository {
+	return &advanceRepository{db: db}
+}
+
+func (r *advanceRepository) Create(ctx context.Context, advance *models.AdvanceReceipt) error {
+	return r.db.WithContext(ctx).Omit("Refunds").Create(advance).Error
+}
+
+func (r *advanceRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.AdvanceReceipt, error) {
+	var advance models.AdvanceReceipt
+	err := r.db.WithContext(ctx).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+			return db.Order("voucher_date, created_at")
+		}).
+		First(&advance, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &advance, nil
+}
+
+func (r *advanceRepository) List(ctx context.Context, tenantID uuid.UUID, filters AdvanceFilters) ([]models.AdvanceReceipt, error) {
+	var advances []models.AdvanceReceipt
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filters.CustomerID != uuid.Nil {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("receipt_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("receipt_date <= ?", filters.ToDate)
+	}
+
+	err := query.Preload("Refunds").Order("receipt_date DESC, created_at DESC").Find(&advances).Error
+	return advances, err
+}
+
+func (r *advanceRepository) CreateRefund(ctx context.Context, advance *models.AdvanceReceipt, refundedBefore decimal.Decimal, voucher *models.RefundVoucher) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.AdvanceReceipt{}).
+			Where("id = ? AND amount_refunded = ?", advance.ID, refundedBefore).
+			Updates(map[string]interface{}{
+				"amount_refunded": advance.AmountRefunded,
+				"balance":         advance.Balance,
+				"status":          advance.Status,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAdvanceChanged
+		}
+		return tx.Create(voucher).Error
+	})
+}
+
+// ListRefunds returns the refund vouchers dated within [from, to]
+func (r *advanceRepository) ListRefunds(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.RefundVoucher, error) {
+	var vouchers []models.RefundVoucher
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND voucher_date >= ? AND voucher_date <= ?", tenantID, from, to).
+		Order("voucher_date, voucher_number").
+		Find(&vouchers).Error
+	return vouchers, err
+}
+
+// GetNextNumber returns the next number of a receipt or refund voucher
+// series
+func (r *advanceRepository) GetNextNumber(ctx context.Context, tenantID uuid.UUID, table, column, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, table+":"+prefix,
+		database.SeedFromExisting(table, column, tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
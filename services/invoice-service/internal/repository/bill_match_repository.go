@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// BillMatchRepository handles bill match settings and the bills the match
+// report checks
+type BillMatchRepository interface {
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.BillMatchSettings, error)
+	SaveSettings(ctx context.Context, settings *models.BillMatchSettings) error
+
+	// ListOverpaid returns the bills whose payments, with the TDS withheld
+	// from them, settle more than the bill's total by over tolerancePct
+	ListOverpaid(ctx context.Context, tenantID uuid.UUID, tolerancePct decimal.Decimal, filters BillMatchFilters) ([]OverpaidBill, error)
+	// ListPurchaseOrderLines returns the lines of bills raised from purchase
+	// orders with what was ordered, received and billed in all on the order
+	// lines they were raised against
+	ListPurchaseOrderLines(ctx context.Context, tenantID uuid.UUID, filters BillMatchFilters) ([]PurchaseOrderBillLine, error)
+}
+
+// BillMatchFilters limits the bills the match report checks
+type BillMatchFilters struct {
+	VendorID uuid.UUID
+	FromDate string // Bill date, YYYY-MM-DD
+	ToDate   string
+}
+
+// OverpaidBill is a bill with what its payments settled
+type OverpaidBill struct {
+	models.Bill
+	Settled decimal.Decimal `gorm:"column:settled"`
+}
+
+// PurchaseOrderBillLine is a bill line raised against a purchase order line
+type PurchaseOrderBillLine struct {
+	models.Bill
+	PurchaseOrderItemID uuid.UUID       `gorm:"column:purchase_order_item_id"`
+	Description         string          `gorm:"column:line_description"`
+	Quantity            decimal.Decimal `gorm:"column:line_quantity"` // On this bill line
+	Rate                decimal.Decimal `gorm:"column:line_rate"`
+	OrderedQuantity     decimal.Decimal `gorm:"column:ordered_quantity"`
+	OrderRate           decimal.Decimal `gorm:"column:order_rate"`
+	ReceivedQuantity    decimal.Decimal `gorm:"column:received_quantity"`
+	BilledQuantity      decimal.Decimal `gorm:"column:billed_quantity"` // On all bills against the order line
+}
+
+type billMatchRepository struct {
+	db *gorm.DB
+}
+
+// NewBillMatchRepository creates a new bill match repository
+func NewBillMatchRepository(db *gorm.DB) BillMatchRepository {
+	return &billMatchRepository{db: db}
+}
+
+// GetSettings returns the tenant's settings, or the defaults if it has none
+func (r *billMatchRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.BillMatchSettings, error) {
+	var settings models.BillMatchSettings
+	err := r.db.WithContext(ctx).First(&settings, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.BillMatchSettings{TenantID: tenantID}, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *billMatchRepository) SaveSettings(ctx context.Context, settings *models.BillMatchSettings) error {
+	return r.db.WithContext(ctx).Save(settings).Error
+}
+
+func (r *billMatchRepository) ListOverpaid(ctx context.Context, tenantID uuid.UUID, tolerancePct decimal.Decimal, filters BillMatchFilters) ([]OverpaidBill, error) {
+	settled := r.db.Model(&models.BillPayment{}).
+		Select("bill_id, SUM(amount + tds_amount) AS settled").
+		Where("tenant_id = ?", tenantID).
+		Group("bill_id")
+
+	query := r.db.WithContext(ctx).
+		Table("bills").
+		Select("bills.*, paid.settled").
+		Joins("JOIN (?) AS paid ON paid.bill_id = bills.id", settled).
+		Where("bills.tenant_id = ? AND bills.deleted_at IS NULL", tenantID).
+		Where("bills.status <> ?", models.BillStatusCancelled).
+		Where("paid.settled > bills.total_amount * (1 + ? / 100.0)", tolerancePct)
+
+	if filters.VendorID != uuid.Nil {
+		query = query.Where("bills.vendor_id = ?", filters.VendorID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("bills.bill_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("bills.bill_date <= ?", filters.ToDate)
+	}
+
+	var bills []OverpaidBill
+	err := query.Order("bills.bill_date ASC, bills.bill_number ASC").Scan(&bills).Error
+	return bills, err
+}
+
+func (r *billMatchRepository) ListPurchaseOrderLines(ctx context.Context, tenantID uuid.UUID, filters BillMatchFilters) ([]PurchaseOrderBillLine, error) {
+	billed := r.db.Table("bill_items").
+		Select("bill_items.purchase_order_item_id, SUM(bill_items.quantity) AS billed_quantity").
+		Joins("JOIN bills ON bills.id = bill_items.bill_id").
+		Where("bills.tenant_id = ? AND bills.deleted_at IS NULL AND bills.status <> ?", tenantID, models.BillStatusCancelled).
+		Where("bill_items.purchase_order_item_id IS NOT NULL").
+		Group("bill_items.purchase_order_item_id")
+
+	query := r.db.WithContext(ctx).
+		Table("bill_items").
+		Select(`bills.*, bill_items.purchase_order_item_id, bill_items.description AS line_description,
+			bill_items.quantity AS line_quantity, bill_items.rate AS line_rate,
+			purchase_order_items.quantity AS ordered_quantity, purchase_order_items.rate AS order_rate,
+			purchase_order_items.quantity_received AS received_quantity, billed.billed_quantity`).
+		Joins("JOIN bills ON bills.id = bill_items.bill_id").
+		Joins("JOIN purchase_order_items ON purchase_order_items.id = bill_items.purchase_order_item_id").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id AND purchase_orders.tenant_id = bills.tenant_id").
+		Joins("JOIN (?) AS billed ON billed.purchase_order_item_id = bill_items.purchase_order_item_id", billed).
+		Where("bills.tenant_id = ? AND bills.deleted_at IS NULL", tenantID).
+		Where("bills.status <> ?", models.BillStatusCancelled)
+
+	if filters.VendorID != uuid.Nil {
+		query = query.Where("bills.vendor_id = ?", filters.VendorID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("bills.bill_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("bills.bill_date <= ?", filters.ToDate)
+	}
+
+	var lines []PurchaseOrderBillLine
+	err := query.Order("bills.bill_date ASC, bills.bill_number ASC").Scan(&lines).Error
+	return lines, err
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"gorm.io/gorm"
 )
@@ -124,16 +126,13 @@ func (r *billRepository) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (r *billRepository) GetNextBillNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
-	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.Bill{}).
-		Where("tenant_id = ? AND bill_number LIKE ?", tenantID, prefix+"%").
-		Count(&count).Error
+	next, err := database.NextNumber(ctx, r.db, tenantID, "bill:"+prefix,
+		database.SeedFromExisting("bills", "bill_number", tenantID, prefix))
 	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("%s-%05d", prefix, count+1), nil
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
 }
 
 func (r *billRepository) GetOverdueBills(ctx context.Context, tenantID uuid.UUID) ([]models.Bill, error) {
@@ -211,7 +210,10 @@ func (r *billRepository) GetPayablesSummary(ctx context.Context, tenantID uuid.U
 // BillPaymentRepository handles bill payment operations
 type BillPaymentRepository interface {
 	Create(ctx context.Context, payment *models.BillPayment) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.BillPayment, error)
+	GetNextPaymentNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
 	GetByBillID(ctx context.Context, billID uuid.UUID) ([]models.BillPayment, error)
+	GetVendorTDSBaseTotal(ctx context.Context, tenantID, vendorID uuid.UUID, from, to time.Time) (decimal.Decimal, error)
 }
 
 type billPaymentRepository struct {
@@ -227,6 +229,25 @@ func (r *billPaymentRepository) Create(ctx context.Context, payment *models.Bill
 	return r.db.WithContext(ctx).Create(payment).Error
 }
 
+func (r *billPaymentRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.BillPayment, error) {
+	var payment models.BillPayment
+	err := r.db.WithContext(ctx).First(&payment, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *billPaymentRepository) GetNextPaymentNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "bill_payment:"+prefix,
+		database.SeedFromExisting("bill_payments", "payment_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
 func (r *billPaymentRepository) GetByBillID(ctx context.Context, billID uuid.UUID) ([]models.BillPayment, error) {
 	var payments []models.BillPayment
 	err := r.db.WithContext(ctx).
@@ -235,3 +256,18 @@ func (r *billPaymentRepository) GetByBillID(ctx context.Context, billID uuid.UUI
 		Find(&payments).Error
 	return payments, err
 }
+
+// GetVendorTDSBaseTotal returns the value excluding GST of bill settlements
+// made to a vendor with payment dates within [from, to]
+func (r *billPaymentRepository) GetVendorTDSBaseTotal(ctx context.Context, tenantID, vendorID uuid.UUID, from, to time.Time) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.WithContext(ctx).
+		Table("bill_payments bp").
+		Joins("JOIN bills b ON b.id = bp.bill_id").
+		Select("COALESCE(SUM((bp.amount + bp.tds_amount) * b.taxable_amount / NULLIF(b.total_amount, 0)), 0)").
+		Where("bp.tenant_id = ? AND b.vendor_id = ?", tenantID, vendorID).
+		Where("bp.payment_date BETWEEN ? AND ?", from, to).
+		Where("bp.deleted_at IS NULL").
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// CashLimitRepository handles cash limit settings and flags, and totals the
+// cash already paid to or received from a party
+type CashLimitRepository interface {
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashLimitSettings, error)
+	SaveSettings(ctx context.Context, settings *models.CashLimitSettings) error
+
+	CreateFlag(ctx context.Context, flag *models.CashLimitFlag) error
+	ListFlags(ctx context.Context, tenantID uuid.UUID, filters CashLimitFilters) ([]models.CashLimitFlag, error)
+
+	// CashPaidToVendor totals the cash paid on the vendor's bills on date
+	CashPaidToVendor(ctx context.Context, tenantID, vendorID uuid.UUID, date time.Time) (decimal.Decimal, error)
+	// CashReceivedFromCustomer totals the cash received on the customer's
+	// invoices on date, leaving out bounced payments
+	CashReceivedFromCustomer(ctx context.Context, tenantID, customerID uuid.UUID, date time.Time) (decimal.Decimal, error)
+	// CashReceivedOnInvoice totals the cash received on the invoice
+	CashReceivedOnInvoice(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error)
+}
+
+// CashLimitFilters filters the cash limit flags
+type CashLimitFilters struct {
+	Section  string
+	FromDate string
+	ToDate   string
+}
+
+type cashLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewCashLimitRepository creates a new cash limit repository
+func NewCashLimitRepository(db *gorm.DB) CashLimitRepository {
+	return &cashLimitRepository{db: db}
+}
+
+// GetSettings returns the tenant's settings, or the defaults if it has none
+func (r *cashLimitRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashLimitSettings, error) {
+	var settings models.CashLimitSettings
+	err := r.db.WithContext(ctx).First(&settings, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.CashLimitSettings{TenantID: tenantID, Mode: models.CashLimitBlock}, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *cashLimitRepository) SaveSettings(ctx context.Context, settings *models.CashLimitSettings) error {
+	return r.db.WithContext(ctx).Save(settings).Error
+}
+
+func (r *cashLimitRepository) CreateFlag(ctx context.Context, flag *models.CashLimitFlag) error {
+	return r.db.WithContext(ctx).Create(flag).Error
+}
+
+func (r *cashLimitRepository) ListFlags(ctx context.Context, tenantID uuid.UUID, filters CashLimitFilters) ([]models.CashLimitFlag, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filters.Section != "" {
+		query = query.Where("section = ?", filters.Section)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("payment_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("payment_date <= ?", filters.ToDate)
+	}
+
+	var flags []models.CashLimitFlag
+	err := query.Order("payment_date ASC, created_at ASC").Find(&flags).Error
+	return flags, err
+}
+
+func (r *cashLimitRepository) CashPaidToVendor(ctx context.Context, tenantID, vendorID uuid.UUID, date time.Time) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.WithContext(ctx).Model(&models.BillPayment{}).
+		Select("COALESCE(SUM(bill_payments.amount), 0)").
+		Joins("JOIN bills ON bills.id = bill_payments.bill_id").
+		Where("bill_payments.tenant_id = ? AND bills.vendor_id = ?", tenantID, vendorID).
+		Where("LOWER(bill_payments.payment_method) = 'cash' AND DATE(bill_payments.payment_date) = ?", date.Format("2006-01-02")).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *cashLimitRepository) CashReceivedFromCustomer(ctx context.Context, tenantID, customerID uuid.UUID, date time.Time) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Select("COALESCE(SUM(payments.amount), 0)").
+		Joins("JOIN invoices ON invoices.id = payments.invoice_id").
+		Where("payments.tenant_id = ? AND invoices.customer_id = ?", tenantID, customerID).
+		Where("LOWER(payments.payment_method) = 'cash' AND DATE(payments.payment_date) = ?", date.Format("2006-01-02")).
+		Where("payments.bounced_at IS NULL").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *cashLimitRepository) CashReceivedOnInvoice(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("invoice_id = ? AND LOWER(payment_method) = 'cash' AND bounced_at IS NULL", invoiceID).
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// ContractRepository handles contract data operations
+type ContractRepository interface {
+	Create(ctx context.Context, contract *models.Contract) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.Contract, error)
+	Update(ctx context.Context, contract *models.Contract) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	List(ctx context.Context, tenantID uuid.UUID, filters ContractFilters) ([]models.Contract, error)
+	GetNextContractNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+
+	// ListDueForReminder returns active contracts that are inside their
+	// reminder window or past their end date on asOf
+	ListDueForReminder(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]models.Contract, error)
+	ListEndingBetween(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.Contract, error)
+	GetBillingSummary(ctx context.Context, tenantID uuid.UUID, contractIDs []uuid.UUID) (map[uuid.UUID]ContractBilling, error)
+
+	// SetRecurringInvoiceContract links a recurring invoice to a contract, or
+	// unlinks it when contractID is nil
+	SetRecurringInvoiceContract(ctx context.Context, tenantID, recurringID uuid.UUID, contractID *uuid.UUID) error
+
+	// Renew saves the renewed contract and moves the end date of linked
+	// recurring invoices that stopped at the old end date
+	Renew(ctx context.Context, contract *models.Contract, previousEndDate time.Time) error
+
+	// Close saves an expired or terminated contract and stops its linked
+	// recurring invoices
+	Close(ctx context.Context, contract *models.Contract, recurringStatus models.RecurringInvoiceStatus) error
+}
+
+// ContractFilters represents filters for listing contracts
+type ContractFilters struct {
+	PartyID uuid.UUID
+	Status  string
+	Search  string
+}
+
+// ContractBilling summarises the recurring billing linked to a contract
+type ContractBilling struct {
+	ContractID      uuid.UUID       `json:"contract_id"`
+	RecurringCount  int64           `json:"recurring_count"`
+	RecurringAmount decimal.Decimal `json:"recurring_amount"` // Per occurrence, active schedules only
+	InvoicedAmount  decimal.Decimal `json:"invoiced_amount"`  // Generated from the linked schedules to date
+}
+
+type contractRepository struct {
+	db *gorm.DB
+}
+
+// NewContractRepository creates a new contract repository
+func NewContractRepository(db *gorm.DB) ContractRepository {
+	return &contractRepository{db: db}
+}
+
+func (r *contractRepository) Create(ctx context.Context, contract *models.Contract) error {
+	return r.db.WithContext(ctx).Omit("RecurringInvoices").Create(contract).Error
+}
+
+func (r *contractRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.Contract, error) {
+	var contract models.Contract
+	err := r.db.WithContext(ctx).
+		Preload("RecurringInvoices").
+		First(&contract, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (r *contractRepository) Update(ctx context.Context, contract *models.Contract) error {
+	return r.db.WithContext(ctx).Omit("RecurringInvoices").Save(contract).Error
+}
+
+func (r *contractRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RecurringInvoice{}).
+			Where("tenant_id = ? AND contract_id = ?", tenantID, id).
+			Update("contract_id", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.Contract{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+	})
+}
+
+func (r *contractRepository) List(ctx context.Context, tenantID uuid.UUID, filters ContractFilters) ([]models.Contract, error) {
+	var contracts []models.Contract
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filters.PartyID != uuid.Nil {
+		query = query.Where("party_id = ?", filters.PartyID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Search != "" {
+		search := "%" + filters.Search + "%"
+		query = query.Where("title ILIKE ? OR party_name ILIKE ? OR contract_number ILIKE ?", search, search, search)
+	}
+
+	err := query.Order("end_date").Find(&contracts).Error
+	return contracts, err
+}
+
+func (r *contractRepository) GetNextContractNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "contract:"+prefix,
+		database.SeedFromExisting("contracts", "contract_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *contractRepository) ListDueForReminder(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]models.Contract, error) {
+	var contracts []models.Contract
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ?", tenantID, models.ContractStatusActive).
+		Where("end_date - reminder_days <= ?", asOf.Format("2006-01-02")).
+		Order("end_date").
+		Find(&contracts).Error
+	return contracts, err
+}
+
+func (r *contractRepository) ListEndingBetween(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.Contract, error) {
+	var contracts []models.Contract
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ?", tenantID, models.ContractStatusActive).
+		Where("end_date >= ? AND end_date <= ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("end_date").
+		Find(&contracts).Error
+	return contracts, err
+}
+
+func (r *contractRepository) GetBillingSummary(ctx context.Context, tenantID uuid.UUID, contractIDs []uuid.UUID) (map[uuid.UUID]ContractBilling, error) {
+	summary := make(map[uuid.UUID]ContractBilling)
+	if len(contractIDs) == 0 {
+		return summary, nil
+	}
+
+	var scheduled []ContractBilling
+	err := r.db.WithContext(ctx).
+		Table("recurring_invoices").
+		Select(`contract_id,
+			COUNT(*) as recurring_count,
+			COALESCE(SUM(CASE WHEN status = ? THEN total_amount ELSE 0 END), 0) as recurring_amount`,
+			models.RecurringStatusActive).
+		Where("tenant_id = ? AND contract_id IN ? AND deleted_at IS NULL", tenantID, contractIDs).
+		Group("contract_id").
+		Scan(&scheduled).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scheduled {
+		summary[s.ContractID] = s
+	}
+
+	var invoiced []struct {
+		ContractID uuid.UUID
+		Amount     decimal.Decimal
+	}
+	err = r.db.WithContext(ctx).
+		Table("generated_invoices").
+		Select("recurring_invoices.contract_id, COALESCE(SUM(invoices.total_amount), 0) as amount").
+		Joins("JOIN recurring_invoices ON recurring_invoices.id = generated_invoices.recurring_invoice_id").
+		Joins("JOIN invoices ON invoices.id = generated_invoices.invoice_id AND invoices.deleted_at IS NULL").
+		Where("recurring_invoices.tenant_id = ? AND recurring_invoices.contract_id IN ?", tenantID, contractIDs).
+		Where("invoices.status NOT IN ?", []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusCancelled}).
+		Group("recurring_invoices.contract_id").
+		Scan(&invoiced).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range invoiced {
+		s := summary[i.ContractID]
+		s.ContractID = i.ContractID
+		s.InvoicedAmount = i.Amount
+		summary[i.ContractID] = s
+	}
+
+	return summary, nil
+}
+
+func (r *contractRepository) SetRecurringInvoiceContract(ctx context.Context, tenantID, recurringID uuid.UUID, contractID *uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.RecurringInvoice{}).
+		Where("tenant_id = ? AND id = ?", tenantID, recurringID).
+		Update("contract_id", contractID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *contractRepository) Renew(ctx context.Context, contract *models.Contract, previousEndDate time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("RecurringInvoices").Save(contract).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RecurringInvoice{}).
+			Where("tenant_id = ? AND contract_id = ?", contract.TenantID, contract.ID).
+			Where("end_date IS NOT NULL AND end_date::date = ?", previousEndDate.Format("2006-01-02")).
+			Where("status IN ?", []models.RecurringInvoiceStatus{models.RecurringStatusActive, models.RecurringStatusPaused}).
+			Update("end_date", contract.EndDate).Error
+	})
+}
+
+func (r *contractRepository) Close(ctx context.Context, contract *models.Contract, recurringStatus models.RecurringInvoiceStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("RecurringInvoices").Save(contract).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RecurringInvoice{}).
+			Where("tenant_id = ? AND contract_id = ?", contract.TenantID, contract.ID).
+			Where("status IN ?", []models.RecurringInvoiceStatus{models.RecurringStatusActive, models.RecurringStatusPaused}).
+			Update("status", recurringStatus).Error
+	})
+}
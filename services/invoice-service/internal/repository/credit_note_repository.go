@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreditNoteNotFound = errors.New("credit note not found")
+	// ErrCreditNoteChanged is returned when the credit note or an invoice it
+	// is applied to changed while the application was being saved
+	ErrCreditNoteChanged = errors.New("credit note or invoice was changed by another request")
+)
+
+// CreditNoteFilters represents filters for listing credit notes
+type CreditNoteFilters struct {
+	Status     string
+	CustomerID uuid.UUID
+	InvoiceID  uuid.UUID
+	FromDate   string
+	ToDate     string
+	Page       int
+	Limit      int
+}
+
+// CreditNoteRepository handles credit note data operations
+type CreditNoteRepository interface {
+	Create(ctx context.Context, note *models.CreditNote) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.CreditNote, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters CreditNoteFilters) ([]models.CreditNote, int64, error)
+	// Update saves a credit note, replacing its items
+	Update(ctx context.Context, note *models.CreditNote) error
+	// Save saves a credit note's own fields without touching its items
+	Save(ctx context.Context, note *models.CreditNote) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetNextCreditNoteNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	// ListIssued returns the credit notes issued (not draft or cancelled)
+	// dated within [from, to) with their items
+	ListIssued(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.CreditNote, error)
+	// Apply saves applications of a credit note with the new amounts of the
+	// note and the invoices, provided neither changed since they were read:
+	// the note's applied amount was appliedBefore and each invoice's balance
+	// was balancesBefore[invoice ID]
+	Apply(ctx context.Context, note *models.CreditNote, appliedBefore decimal.Decimal, invoices []*models.Invoice, balancesBefore map[uuid.UUID]decimal.Decimal, applications []models.CreditNoteApplication) error
+	// RemoveApplication deletes an application with the new amounts of the
+	// note and its invoice, under the same conditions as Apply
+	RemoveApplication(ctx context.Context, note *models.CreditNote, appliedBefore decimal.Decimal, invoice *models.Invoice, balanceBefore decimal.Decimal, application *models.CreditNoteApplication) error
+}
+
+type creditNoteRepository struct {
+	db *gorm.DB
+}
+
+// NewCreditNoteRepository creates a new credit note repository
+func NewCreditNoteRepository(db *gorm.DB) CreditNoteRepository {
+	return &creditNoteRepository{db: db}
+}
+
+func (r *creditNoteRepository) Create(ctx context.Context, note *models.CreditNote) error {
+	return r.db.WithContext(ctx).Omit("Applications").Create(note).Error
+}
+
+func (r *creditNoteRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.CreditNote, error) {
+	var note models.CreditNote
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number")
+		}).
+		Preload("Applications", func(db *gorm.DB) *gorm.DB {
+			return db.Order("applied_at, created_at")
+		}).
+		First(&note, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCreditNoteNotFound
+		}
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *creditNoteRepository) List(ctx context.Context, tenantID uuid.UUID, filters CreditNoteFilters) ([]models.CreditNote, int64, error) {
+	var notes []models.CreditNote
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.CreditNote{}).
+		Where("tenant_id = ?", tenantID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.CustomerID != uuid.Nil {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.InvoiceID != uuid.Nil {
+		query = query.Where("invoice_id = ? OR id IN (?)", filters.InvoiceID,
+			r.db.Model(&models.CreditNoteApplication{}).Select("credit_note_id").Where("invoice_id = ?", filters.InvoiceID))
+	}
+	if filters.FromDate != "" {
+		query = query.Where("credit_note_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("credit_note_date <= ?", filters.ToDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filters.Page - 1) * filters.Limit
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number")
+		}).
+		Preload("Applications").
+		Offset(offset).
+		Limit(filters.Limit).
+		Order("credit_note_date DESC, created_at DESC").
+		Find(&notes).Error
+
+	return notes, total, err
+}
+
+func (r *creditNoteRepository) Update(ctx context.Context, note *models.CreditNote) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("credit_note_id = ?", note.ID).Delete(&models.CreditNoteItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Applications").Save(note).Error
+	})
+}
+
+func (r *creditNoteRepository) Save(ctx context.Context, note *models.CreditNote) error {
+	return r.db.WithContext(ctx).Omit("Items", "Applications").Save(note).Error
+}
+
+func (r *creditNoteRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.CreditNote{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *creditNoteRepository) GetNextCreditNoteNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "credit_note:"+prefix,
+		database.SeedFromExisting("credit_notes", "credit_note_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *creditNoteRepository) ListIssued(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.CreditNote, error) {
+	var notes []models.CreditNote
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number")
+		}).
+		Where("tenant_id = ?", tenantID).
+		Where("credit_note_date >= ? AND credit_note_date < ?", from, to).
+		Where("status NOT IN ?", []models.CreditNoteStatus{models.CreditNoteStatusDraft, models.CreditNoteStatusCancelled}).
+		Order("credit_note_date, credit_note_number").
+		Find(&notes).Error
+	return notes, err
+}
+
+func (r *creditNoteRepository) Apply(ctx context.Context, note *models.CreditNote, appliedBefore decimal.Decimal, invoices []*models.Invoice, balancesBefore map[uuid.UUID]decimal.Decimal, applications []models.CreditNoteApplication) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveCreditNoteAmounts(tx, note, appliedBefore); err != nil {
+			return err
+		}
+		for _, invoice := range invoices {
+			if err := saveCreditedInvoice(tx, invoice, balancesBefore[invoice.ID]); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&applications).Error
+	})
+}
+
+func (r *creditNoteRepository) RemoveApplication(ctx context.Context, note *models.CreditNote, appliedBefore decimal.Decimal, invoice *models.Invoice, balanceBefore decimal.Decimal, application *models.CreditNoteApplication) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveCreditNoteAmounts(tx, note, appliedBefore); err != nil {
+			return err
+		}
+		if err := saveCreditedInvoice(tx, invoice, balanceBefore); err != nil {
+			return err
+		}
+		return tx.Delete(application).Error
+	})
+}
+
+// saveCreditNoteAmounts saves a credit note's applied amount, balance and
+// status, unless its applied amount is no longer appliedBefore
+func saveCreditNoteAmounts(tx *gorm.DB, note *models.CreditNote, appliedBefore decimal.Decimal) error {
+	result := tx.Model(&models.CreditNote{}).
+		Where("id = ? AND amount_applied = ?", note.ID, appliedBefore).
+		Updates(map[string]interface{}{
+			"amount_applied": note.AmountApplied,
+			"balance_amount": note.BalanceAmount,
+			"status":         note.Status,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCreditNoteChanged
+	}
+	return nil
+}
+
+// saveCreditedInvoice saves an invoice's credited amount, balance and
+// status, unless its balance is no longer balanceBefore
+func saveCreditedInvoice(tx *gorm.DB, invoice *models.Invoice, balanceBefore decimal.Decimal) error {
+	result := tx.Model(&models.Invoice{}).
+		Where("id = ? AND balance_due = ?", invoice.ID, balanceBefore).
+		Updates(map[string]interface{}{
+			"amount_credited": invoice.AmountCredited,
+			"balance_due":     invoice.BalanceDue,
+			"status":          invoice.Status,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCreditNoteChanged
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PaymentBehaviourRow is a customer's raw payment behaviour over a window
+type PaymentBehaviourRow struct {
+	CustomerID       uuid.UUID
+	CustomerName     string
+	InvoicesIssued   int
+	TotalInvoiced    decimal.Decimal
+	InvoicesDue      int
+	InvoicesPaidLate int
+	AverageDaysToPay float64
+	AverageDaysLate  float64
+	OverdueAmount    decimal.Decimal
+	DisputedInvoices int
+	BouncedPayments  int
+}
+
+// CreditScoreRepository handles customer credit scores and the payment
+// history they are calculated from
+type CreditScoreRepository interface {
+	SaveMany(ctx context.Context, scores []models.CustomerCreditScore) error
+	Get(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerCreditScore, error)
+	List(ctx context.Context, tenantID uuid.UUID, grade string) ([]models.CustomerCreditScore, error)
+
+	// ListGrades returns the grade of each scored customer of the tenant
+	ListGrades(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID]string, error)
+
+	// ListTenantsWithInvoices returns the tenants that issued invoices since
+	// the given date
+	ListTenantsWithInvoices(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+
+	// GetPaymentBehaviour summarises, per customer, the invoices issued from
+	// since and how they were paid as of asOf. customerID limits it to one
+	// customer.
+	GetPaymentBehaviour(ctx context.Context, tenantID, customerID uuid.UUID, since, asOf time.Time) ([]PaymentBehaviourRow, error)
+}
+
+type creditScoreRepository struct {
+	db *gorm.DB
+}
+
+// NewCreditScoreRepository creates a new credit score repository
+func NewCreditScoreRepository(db *gorm.DB) CreditScoreRepository {
+	return &creditScoreRepository{db: db}
+}
+
+func (r *creditScoreRepository) SaveMany(ctx context.Context, scores []models.CustomerCreditScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		CreateInBatches(&scores, 500).Error
+}
+
+func (r *creditScoreRepository) Get(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerCreditScore, error) {
+	var score models.CustomerCreditScore
+	err := r.db.WithContext(ctx).First(&score, "tenant_id = ? AND customer_id = ?", tenantID, customerID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+func (r *creditScoreRepository) List(ctx context.Context, tenantID uuid.UUID, grade string) ([]models.CustomerCreditScore, error) {
+	var scores []models.CustomerCreditScore
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if grade != "" {
+		query = query.Where("grade = ?", grade)
+	}
+
+	err := query.Order("score, customer_name").Find(&scores).Error
+	return scores, err
+}
+
+func (r *creditScoreRepository) ListGrades(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID]string, error) {
+	var rows []struct {
+		CustomerID uuid.UUID
+		Grade      string
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.CustomerCreditScore{}).
+		Select("customer_id, grade").
+		Where("tenant_id = ?", tenantID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	grades := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		grades[row.CustomerID] = row.Grade
+	}
+	return grades, nil
+}
+
+func (r *creditScoreRepository) ListTenantsWithInvoices(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var tenantIDs []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Distinct("tenant_id").
+		Where("invoice_date >= ? AND status NOT IN ?", since, []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusCancelled}).
+		Pluck("tenant_id", &tenantIDs).Error
+	return tenantIDs, err
+}
+
+func (r *creditScoreRepository) GetPaymentBehaviour(ctx context.Context, tenantID, customerID uuid.UUID, since, asOf time.Time) ([]PaymentBehaviourRow, error) {
+	customerFilter := ""
+	if customerID != uuid.Nil {
+		customerFilter = "AND i.customer_id = @customer"
+	}
+	params := map[string]interface{}{
+		"tenant":   tenantID,
+		"customer": customerID,
+		"since":    since,
+		"as_of":    asOf,
+	}
+
+	// An invoice is settled on its last good payment. Invoices due by asOf
+	// count as late when settled after the due date or still open past it.
+	var rows []PaymentBehaviourRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH settled AS (
+			SELECT invoice_id, MAX(payment_date)::date AS settled_on
+			FROM payments
+			WHERE tenant_id = @tenant AND bounced_at IS NULL AND deleted_at IS NULL
+			GROUP BY invoice_id
+		), behaviour AS (
+			SELECT
+				i.customer_id,
+				i.customer_name,
+				i.total_amount,
+				i.balance_due,
+				i.invoice_date::date AS invoice_date,
+				i.due_date::date AS due_date,
+				i.status = 'paid' AS paid,
+				s.settled_on,
+				(i.disputed OR EXISTS (SELECT 1 FROM invoice_disputes d WHERE d.invoice_id = i.id)) AS disputed
+			FROM invoices i
+			LEFT JOIN settled s ON s.invoice_id = i.id
+			WHERE i.tenant_id = @tenant
+			AND i.status NOT IN ('draft', 'cancelled')
+			AND i.invoice_date >= @since
+			AND i.deleted_at IS NULL
+			`+customerFilter+`
+		)
+		SELECT
+			customer_id,
+			MAX(customer_name) AS customer_name,
+			COUNT(*) AS invoices_issued,
+			SUM(total_amount) AS total_invoiced,
+			COUNT(*) FILTER (WHERE due_date < CAST(@as_of AS date)) AS invoices_due,
+			COUNT(*) FILTER (WHERE due_date < CAST(@as_of AS date) AND (NOT paid OR settled_on > due_date)) AS invoices_paid_late,
+			COALESCE(AVG(settled_on - invoice_date) FILTER (WHERE paid AND settled_on IS NOT NULL), 0) AS average_days_to_pay,
+			COALESCE(AVG(GREATEST(CASE WHEN paid THEN settled_on ELSE CAST(@as_of AS date) END - due_date, 0)) FILTER (WHERE due_date < CAST(@as_of AS date)), 0) AS average_days_late,
+			COALESCE(SUM(balance_due) FILTER (WHERE NOT paid AND due_date < CAST(@as_of AS date)), 0) AS overdue_amount,
+			COUNT(*) FILTER (WHERE disputed) AS disputed_invoices
+		FROM behaviour
+		GROUP BY customer_id
+	`, params).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var bounced []struct {
+		CustomerID      uuid.UUID
+		BouncedPayments int
+	}
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT i.customer_id, COUNT(*) AS bounced_payments
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.tenant_id = @tenant
+		AND p.bounced_at >= @since
+		AND p.deleted_at IS NULL
+		`+customerFilter+`
+		GROUP BY i.customer_id
+	`, params).Scan(&bounced).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byCustomer := make(map[uuid.UUID]int, len(bounced))
+	for _, row := range bounced {
+		byCustomer[row.CustomerID] = row.BouncedPayments
+	}
+	for i := range rows {
+		rows[i].BouncedPayments = byCustomer[rows[i].CustomerID]
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDebitNoteNotFound = errors.New("debit note not found")
+	// ErrDebitNoteChanged is returned when the debit note or its bill
+	// changed while the note was being issued or cancelled
+	ErrDebitNoteChanged = errors.New("debit note or bill was changed by another request")
+)
+
+// DebitNoteFilters represents filters for listing debit notes
+type DebitNoteFilters struct {
+	Status   string
+	VendorID uuid.UUID
+	BillID   uuid.UUID
+	FromDate string
+	ToDate   string
+	Page     int
+	Limit    int
+}
+
+// DebitNoteRepository handles debit note data operations
+type DebitNoteRepository interface {
+	Create(ctx context.Context, note *models.DebitNote) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.DebitNote, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters DebitNoteFilters) ([]models.DebitNote, int64, error)
+	// ListIssued returns the debit notes issued with a date within [from, to),
+	// with their items, for the outward return
+	ListIssued(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.DebitNote, error)
+	// Update saves a draft debit note, replacing its items
+	Update(ctx context.Context, note *models.DebitNote) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetNextDebitNoteNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	// ReturnedAmounts totals the value already returned against each of a
+	// bill's lines by debit notes not cancelled, other than excludeID
+	ReturnedAmounts(ctx context.Context, billID, excludeID uuid.UUID) (map[uuid.UUID]decimal.Decimal, error)
+	// Issue saves a debit note as issued with the bill's new amounts, provided
+	// the note is still a draft and the bill's balance is still balanceBefore
+	Issue(ctx context.Context, note *models.DebitNote, bill *models.Bill, balanceBefore decimal.Decimal) error
+	// Cancel saves a debit note as cancelled with the bill's new amounts,
+	// provided the note's status is still statusBefore and the bill's
+	// balance is still balanceBefore. A nil bill leaves it untouched.
+	Cancel(ctx context.Context, note *models.DebitNote, statusBefore models.DebitNoteStatus, bill *models.Bill, balanceBefore decimal.Decimal) error
+}
+
+type debitNoteRepository struct {
+	db *gorm.DB
+}
+
+// NewDebitNoteRepository creates a new debit note repository
+func NewDebitNoteRepository(db *gorm.DB) DebitNoteRepository {
+	return &debitNoteRepository{db: db}
+}
+
+func (r *debitNoteRepository) Create(ctx context.Context, note *models.DebitNote) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+func (r *debitNoteRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.DebitNote, error) {
+	var note models.DebitNote
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number")
+		}).
+		First(&note, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDebitNoteNotFound
+		}
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *debitNoteRepository) List(ctx context.Context, tenantID uuid.UUID, filters DebitNoteFilters) ([]models.DebitNote, int64, error) {
+	var notes []models.DebitNote
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.DebitNote{}).
+		Where("tenant_id = ?", tenantID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.VendorID != uuid.Nil {
+		query = query.Where("vendor_id = ?", filters.VendorID)
+	}
+	if filters.BillID != uuid.Nil {
+		query = query.Where("bill_id = ?", filters.BillID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("debit_note_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("debit_note_date <= ?", filters.ToDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filters.Page - 1) * filters.Limit
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number")
+		}).
+		Offset(offset).
+		Limit(filters.Limit).
+		Order("debit_note_date DESC, created_at DESC").
+		Find(&notes).Error
+
+	return notes, total, err
+}
+
+func (r *debitNoteRepository) ListIssued(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.DebitNote, error) {
+	var notes []models.DebitNote
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number")
+		}).
+		Where("tenant_id = ? AND status = ?", tenantID, models.DebitNoteStatusIssued).
+		Where("debit_note_date >= ? AND debit_note_date < ?", from, to).
+		Order("debit_note_date, debit_note_number").
+		Find(&notes).Error
+	return notes, err
+}
+
+func (r *debitNoteRepository) Update(ctx context.Context, note *models.DebitNote) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("debit_note_id = ?", note.ID).Delete(&models.DebitNoteItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(note).Error
+	})
+}
+
+func (r *debitNoteRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.DebitNote{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *debitNoteRepository) GetNextDebitNoteNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "debit_note:"+prefix,
+		database.SeedFromExisting("debit_notes", "debit_note_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *debitNoteRepository) ReturnedAmounts(ctx context.Context, billID, excludeID uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	var rows []struct {
+		BillItemID uuid.UUID
+		Amount     decimal.Decimal
+	}
+	err := r.db.WithContext(ctx).
+		Table("debit_note_items dni").
+		Joins("JOIN debit_notes dn ON dn.id = dni.debit_note_id").
+		Select("dni.bill_item_id, COALESCE(SUM(dni.amount), 0) AS amount").
+		Where("dn.bill_id = ? AND dn.id <> ? AND dn.status <> ? AND dn.deleted_at IS NULL",
+			billID, excludeID, models.DebitNoteStatusCancelled).
+		Group("dni.bill_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[uuid.UUID]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		returned[row.BillItemID] = row.Amount
+	}
+	return returned, nil
+}
+
+func (r *debitNoteRepository) Issue(ctx context.Context, note *models.DebitNote, bill *models.Bill, balanceBefore decimal.Decimal) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.DebitNote{}).
+			Where("id = ? AND status = ?", note.ID, models.DebitNoteStatusDraft).
+			Updates(map[string]interface{}{
+				"status":          note.Status,
+				"issued_at":       note.IssuedAt,
+				"issued_by":       note.IssuedBy,
+				"amount_adjusted": note.AmountAdjusted,
+				"refund_due":      note.RefundDue,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDebitNoteChanged
+		}
+		return saveDebitedBill(tx, bill, balanceBefore)
+	})
+}
+
+func (r *debitNoteRepository) Cancel(ctx context.Context, note *models.DebitNote, statusBefore models.DebitNoteStatus, bill *models.Bill, balanceBefore decimal.Decimal) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.DebitNote{}).
+			Where("id = ? AND status = ?", note.ID, statusBefore).
+			Update("status", note.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDebitNoteChanged
+		}
+		if bill == nil {
+			return nil
+		}
+		return saveDebitedBill(tx, bill, balanceBefore)
+	})
+}
+
+// saveDebitedBill saves a bill's debited amount, balance and status, unless
+// its balance is no longer balanceBefore
+func saveDebitedBill(tx *gorm.DB, bill *models.Bill, balanceBefore decimal.Decimal) error {
+	result := tx.Model(&models.Bill{}).
+		Where("id = ? AND balance_due = ?", bill.ID, balanceBefore).
+		Updates(map[string]interface{}{
+			"amount_debited": bill.AmountDebited,
+			"balance_due":    bill.BalanceDue,
+			"status":         bill.Status,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDebitNoteChanged
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeliveryChallanNotFound = errors.New("delivery challan not found")
+	ErrDeliveryChallanInvoiced = errors.New("delivery challan has already been invoiced")
+)
+
+// DeliveryChallanFilters represents filters for listing delivery challans
+type DeliveryChallanFilters struct {
+	Status     string
+	Purpose    string
+	CustomerID uuid.UUID
+	InvoiceID  uuid.UUID
+	FromDate   string
+	ToDate     string
+	Page       int
+	Limit      int
+}
+
+// DeliveryChallanRepository handles delivery challan data operations
+type DeliveryChallanRepository interface {
+	Create(ctx context.Context, challan *models.DeliveryChallan) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.DeliveryChallan, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters DeliveryChallanFilters) ([]models.DeliveryChallan, int64, error)
+	// Update saves a challan, replacing its items
+	Update(ctx context.Context, challan *models.DeliveryChallan) error
+	// Save saves a challan's own fields without touching its items
+	Save(ctx context.Context, challan *models.DeliveryChallan) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetNextChallanNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	// MarkInvoiced records the invoice that billed a challan, unless it
+	// already has one
+	MarkInvoiced(ctx context.Context, challan *models.DeliveryChallan) error
+}
+
+type deliveryChallanRepository struct {
+	db *gorm.DB
+}
+
+// NewDeliveryChallanRepository creates a new delivery challan repository
+func NewDeliveryChallanRepository(db *gorm.DB) DeliveryChallanRepository {
+	return &deliveryChallanRepository{db: db}
+}
+
+func (r *deliveryChallanRepository) Create(ctx context.Context, challan *models.DeliveryChallan) error {
+	return r.db.WithContext(ctx).Create(challan).Error
+}
+
+func (r *deliveryChallanRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.DeliveryChallan, error) {
+	var challan models.DeliveryChallan
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		First(&challan, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryChallanNotFound
+		}
+		return nil, err
+	}
+	return &challan, nil
+}
+
+func (r *deliveryChallanRepository) List(ctx context.Context, tenantID uuid.UUID, filters DeliveryChallanFilters) ([]models.DeliveryChallan, int64, error) {
+	var challans []models.DeliveryChallan
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.DeliveryChallan{}).
+		Where("tenant_id = ?", tenantID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Purpose != "" {
+		query = query.Where("purpose = ?", filters.Purpose)
+	}
+	if filters.CustomerID != uuid.Nil {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.InvoiceID != uuid.Nil {
+		query = query.Where("invoice_id = ?", filters.InvoiceID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("challan_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("challan_date <= ?", filters.ToDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filters.Page - 1) * filters.Limit
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		Offset(offset).
+		Limit(filters.Limit).
+		Order("challan_date DESC, created_at DESC").
+		Find(&challans).Error
+
+	return challans, total, err
+}
+
+func (r *deliveryChallanRepository) Update(ctx context.Context, challan *models.DeliveryChallan) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("delivery_challan_id = ?", challan.ID).Delete(&models.DeliveryChallanItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(challan).Error
+	})
+}
+
+func (r *deliveryChallanRepository) Save(ctx context.Context, challan *models.DeliveryChallan) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(challan).Error
+}
+
+func (r *deliveryChallanRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.DeliveryChallan{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *deliveryChallanRepository) GetNextChallanNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "delivery_challan:"+prefix,
+		database.SeedFromExisting("delivery_challans", "challan_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *deliveryChallanRepository) MarkInvoiced(ctx context.Context, challan *models.DeliveryChallan) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.DeliveryChallan{}).
+		Where("id = ? AND invoice_id IS NULL", challan.ID).
+		Updates(map[string]interface{}{
+			"invoice_id":     challan.InvoiceID,
+			"invoice_number": challan.InvoiceNumber,
+			"invoiced_at":    challan.InvoicedAt,
+			"status":         challan.Status,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeliveryChallanInvoiced
+	}
+	return nil
+}
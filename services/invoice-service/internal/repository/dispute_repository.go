@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// DisputeRepository handles invoice dispute data operations. Every change to
+// a dispute is written together with its history entry.
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *models.InvoiceDispute, event *models.InvoiceDisputeEvent) error
+	Update(ctx context.Context, dispute *models.InvoiceDispute, event *models.InvoiceDisputeEvent) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceDispute, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters DisputeFilters) ([]models.InvoiceDispute, error)
+	HasOpenForItem(ctx context.Context, invoiceID, invoiceItemID uuid.UUID) (bool, error)
+	SumOpen(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error)
+}
+
+// DisputeFilters represents filters for listing disputes
+type DisputeFilters struct {
+	InvoiceID uuid.UUID
+	Status    models.DisputeStatus
+}
+
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+// NewDisputeRepository creates a new dispute repository
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *models.InvoiceDispute, event *models.InvoiceDisputeEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("History").Create(dispute).Error; err != nil {
+			return err
+		}
+		event.DisputeID = dispute.ID
+		return tx.Create(event).Error
+	})
+}
+
+func (r *disputeRepository) Update(ctx context.Context, dispute *models.InvoiceDispute, event *models.InvoiceDisputeEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("History").Save(dispute).Error; err != nil {
+			return err
+		}
+		event.DisputeID = dispute.ID
+		return tx.Create(event).Error
+	})
+}
+
+func (r *disputeRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceDispute, error) {
+	var dispute models.InvoiceDispute
+	err := r.db.WithContext(ctx).
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		}).
+		First(&dispute, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) List(ctx context.Context, tenantID uuid.UUID, filters DisputeFilters) ([]models.InvoiceDispute, error) {
+	var disputes []models.InvoiceDispute
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filters.InvoiceID != uuid.Nil {
+		query = query.Where("invoice_id = ?", filters.InvoiceID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	err := query.Order("created_at DESC").Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) HasOpenForItem(ctx context.Context, invoiceID, invoiceItemID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.InvoiceDispute{}).
+		Where("invoice_id = ? AND invoice_item_id = ? AND status = ?", invoiceID, invoiceItemID, models.DisputeStatusOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SumOpen returns the total amount of an invoice's open disputes
+func (r *disputeRepository) SumOpen(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.WithContext(ctx).
+		Model(&models.InvoiceDispute{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("invoice_id = ? AND status = ?", invoiceID, models.DisputeStatusOpen).
+		Scan(&total).Error
+	return total, err
+}
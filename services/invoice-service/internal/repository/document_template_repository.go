@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// DocumentTemplateRepository handles saved invoice and bill templates
+type DocumentTemplateRepository interface {
+	Create(ctx context.Context, template *models.DocumentTemplate) error
+	Update(ctx context.Context, template *models.DocumentTemplate) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.DocumentTemplate, error)
+	GetByName(ctx context.Context, tenantID uuid.UUID, documentType, name string) (*models.DocumentTemplate, error)
+	// List returns the tenant's templates of the document type, or of all
+	// types when it is empty
+	List(ctx context.Context, tenantID uuid.UUID, documentType string) ([]models.DocumentTemplate, error)
+}
+
+type documentTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewDocumentTemplateRepository creates a new document template repository
+func NewDocumentTemplateRepository(db *gorm.DB) DocumentTemplateRepository {
+	return &documentTemplateRepository{db: db}
+}
+
+func (r *documentTemplateRepository) Create(ctx context.Context, template *models.DocumentTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+func (r *documentTemplateRepository) Update(ctx context.Context, template *models.DocumentTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+func (r *documentTemplateRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Delete(&models.DocumentTemplate{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *documentTemplateRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.DocumentTemplate, error) {
+	var template models.DocumentTemplate
+	err := r.db.WithContext(ctx).
+		First(&template, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *documentTemplateRepository) GetByName(ctx context.Context, tenantID uuid.UUID, documentType, name string) (*models.DocumentTemplate, error) {
+	var template models.DocumentTemplate
+	err := r.db.WithContext(ctx).
+		First(&template, "tenant_id = ? AND document_type = ? AND LOWER(name) = LOWER(?)", tenantID, documentType, name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *documentTemplateRepository) List(ctx context.Context, tenantID uuid.UUID, documentType string) ([]models.DocumentTemplate, error) {
+	var templates []models.DocumentTemplate
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if documentType != "" {
+		query = query.Where("document_type = ?", documentType)
+	}
+	err := query.Order("name").Find(&templates).Error
+	return templates, err
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// DunningRepository handles dunning policy, customer segment and event data
+// operations
+type DunningRepository interface {
+	CreatePolicy(ctx context.Context, policy *models.DunningPolicy) error
+	UpdatePolicy(ctx context.Context, policy *models.DunningPolicy) error
+	DeletePolicy(ctx context.Context, tenantID, id uuid.UUID) error
+	GetPolicy(ctx context.Context, tenantID, id uuid.UUID) (*models.DunningPolicy, error)
+	GetPolicyBySegment(ctx context.Context, tenantID uuid.UUID, segment string) (*models.DunningPolicy, error)
+	GetDefaultPolicy(ctx context.Context, tenantID uuid.UUID) (*models.DunningPolicy, error)
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]models.DunningPolicy, error)
+	ClearDefaultPolicy(ctx context.Context, tenantID uuid.UUID) error
+	CountProfiles(ctx context.Context, tenantID, policyID uuid.UUID) (int64, error)
+
+	GetProfile(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerDunningProfile, error)
+	ListProfiles(ctx context.Context, tenantID uuid.UUID) ([]models.CustomerDunningProfile, error)
+	SaveProfile(ctx context.Context, profile *models.CustomerDunningProfile) error
+	DeleteProfile(ctx context.Context, tenantID, customerID uuid.UUID) error
+
+	ListOutstandingInvoices(ctx context.Context, tenantID uuid.UUID) ([]models.Invoice, error)
+	HasEvent(ctx context.Context, invoiceID uuid.UUID, action models.DunningAction, step int) (bool, error)
+	CreateEvent(ctx context.Context, event *models.DunningEvent) error
+	ListEvents(ctx context.Context, tenantID, invoiceID uuid.UUID) ([]models.DunningEvent, error)
+}
+
+type dunningRepository struct {
+	db *gorm.DB
+}
+
+// NewDunningRepository creates a new dunning repository
+func NewDunningRepository(db *gorm.DB) DunningRepository {
+	return &dunningRepository{db: db}
+}
+
+func (r *dunningRepository) CreatePolicy(ctx context.Context, policy *models.DunningPolicy) error {
+	return r.db.WithContext(ctx).Create(policy).Error
+}
+
+func (r *dunningRepository) UpdatePolicy(ctx context.Context, policy *models.DunningPolicy) error {
+	return r.db.WithContext(ctx).Save(policy).Error
+}
+
+func (r *dunningRepository) DeletePolicy(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Delete(&models.DunningPolicy{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *dunningRepository) GetPolicy(ctx context.Context, tenantID, id uuid.UUID) (*models.DunningPolicy, error) {
+	var policy models.DunningPolicy
+	err := r.db.WithContext(ctx).
+		First(&policy, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *dunningRepository) GetPolicyBySegment(ctx context.Context, tenantID uuid.UUID, segment string) (*models.DunningPolicy, error) {
+	var policy models.DunningPolicy
+	err := r.db.WithContext(ctx).
+		First(&policy, "tenant_id = ? AND LOWER(segment) = LOWER(?)", tenantID, segment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *dunningRepository) GetDefaultPolicy(ctx context.Context, tenantID uuid.UUID) (*models.DunningPolicy, error) {
+	var policy models.DunningPolicy
+	err := r.db.WithContext(ctx).
+		First(&policy, "tenant_id = ? AND is_default = true AND is_active = true", tenantID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *dunningRepository) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]models.DunningPolicy, error) {
+	var policies []models.DunningPolicy
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("segment").
+		Find(&policies).Error
+	return policies, err
+}
+
+func (r *dunningRepository) ClearDefaultPolicy(ctx context.Context, tenantID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&models.DunningPolicy{}).
+		Where("tenant_id = ? AND is_default = true", tenantID).
+		Update("is_default", false).Error
+}
+
+func (r *dunningRepository) CountProfiles(ctx context.Context, tenantID, policyID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.CustomerDunningProfile{}).
+		Where("tenant_id = ? AND policy_id = ?", tenantID, policyID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *dunningRepository) GetProfile(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerDunningProfile, error) {
+	var profile models.CustomerDunningProfile
+	err := r.db.WithContext(ctx).
+		Preload("Policy").
+		First(&profile, "tenant_id = ? AND customer_id = ?", tenantID, customerID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *dunningRepository) ListProfiles(ctx context.Context, tenantID uuid.UUID) ([]models.CustomerDunningProfile, error) {
+	var profiles []models.CustomerDunningProfile
+	err := r.db.WithContext(ctx).
+		Preload("Policy").
+		Where("tenant_id = ?", tenantID).
+		Find(&profiles).Error
+	return profiles, err
+}
+
+func (r *dunningRepository) SaveProfile(ctx context.Context, profile *models.CustomerDunningProfile) error {
+	return r.db.WithContext(ctx).Omit("Policy").Save(profile).Error
+}
+
+func (r *dunningRepository) DeleteProfile(ctx context.Context, tenantID, customerID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Delete(&models.CustomerDunningProfile{}, "tenant_id = ? AND customer_id = ?", tenantID, customerID).Error
+}
+
+// ListOutstandingInvoices returns issued invoices with a balance still due
+func (r *dunningRepository) ListOutstandingInvoices(ctx context.Context, tenantID uuid.UUID) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("tenant_id = ? AND balance_due > 0", tenantID).
+		Where("status IN ?", []models.InvoiceStatus{
+			models.InvoiceStatusSent,
+			models.InvoiceStatusViewed,
+			models.InvoiceStatusPartial,
+			models.InvoiceStatusOverdue,
+		}).
+		Order("due_date").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *dunningRepository) HasEvent(ctx context.Context, invoiceID uuid.UUID, action models.DunningAction, step int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.DunningEvent{}).
+		Where("invoice_id = ? AND action = ? AND step = ?", invoiceID, action, step).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *dunningRepository) CreateEvent(ctx context.Context, event *models.DunningEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *dunningRepository) ListEvents(ctx context.Context, tenantID, invoiceID uuid.UUID) ([]models.DunningEvent, error) {
+	var events []models.DunningEvent
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND invoice_id = ?", tenantID, invoiceID).
+		Order("created_at").
+		Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrEInvoiceCredentialNotFound = errors.New("e-invoice credentials not found")
+
+// EInvoiceRepository handles tenants' e-invoice portal credentials
+type EInvoiceRepository interface {
+	GetCredential(ctx context.Context, tenantID uuid.UUID) (*models.EInvoiceCredential, error)
+	SaveCredential(ctx context.Context, credential *models.EInvoiceCredential) error
+}
+
+type einvoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewEInvoiceRepository creates a new e-invoice repository
+func NewEInvoiceRepository(db *gorm.DB) EInvoiceRepository {
+	return &einvoiceRepository{db: db}
+}
+
+func (r *einvoiceRepository) GetCredential(ctx context.Context, tenantID uuid.UUID) (*models.EInvoiceCredential, error) {
+	var credential models.EInvoiceCredential
+	err := r.db.WithContext(ctx).First(&credential, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEInvoiceCredentialNotFound
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *einvoiceRepository) SaveCredential(ctx context.Context, credential *models.EInvoiceCredential) error {
+	return r.db.WithContext(ctx).Save(credential).Error
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// ExpenseClaimRepository handles expense claim data operations
+type ExpenseClaimRepository interface {
+	Create(ctx context.Context, claim *models.ExpenseClaim) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.ExpenseClaim, error)
+	Update(ctx context.Context, claim *models.ExpenseClaim) error
+	List(ctx context.Context, tenantID uuid.UUID, filters ExpenseClaimFilters) ([]models.ExpenseClaim, error)
+	GetNextClaimNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	GetMemberSummary(ctx context.Context, tenantID uuid.UUID, filters ExpenseClaimFilters) ([]MemberClaimSummary, error)
+}
+
+// ExpenseClaimFilters represents filters for listing expense claims
+type ExpenseClaimFilters struct {
+	MemberID uuid.UUID
+	Status   string
+	Project  string
+	FromDate string
+	ToDate   string
+}
+
+// MemberClaimSummary represents the status of a member's expense claims
+type MemberClaimSummary struct {
+	MemberID          uuid.UUID `json:"member_id"`
+	EmployeeName      string    `json:"employee_name"`
+	ClaimCount        int64     `json:"claim_count"`
+	SubmittedAmount   float64   `json:"submitted_amount"` // Awaiting review
+	ApprovedAmount    float64   `json:"approved_amount"`
+	RejectedAmount    float64   `json:"rejected_amount"`
+	ReimbursedAmount  float64   `json:"reimbursed_amount"` // Paid against the approved claims' bills
+	OutstandingAmount float64   `json:"outstanding_amount"`
+}
+
+type expenseClaimRepository struct {
+	db *gorm.DB
+}
+
+// NewExpenseClaimRepository creates a new expense claim repository
+func NewExpenseClaimRepository(db *gorm.DB) ExpenseClaimRepository {
+	return &expenseClaimRepository{db: db}
+}
+
+func (r *expenseClaimRepository) Create(ctx context.Context, claim *models.ExpenseClaim) error {
+	return r.db.WithContext(ctx).Create(claim).Error
+}
+
+func (r *expenseClaimRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.ExpenseClaim, error) {
+	var claim models.ExpenseClaim
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("expense_date")
+		}).
+		First(&claim, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *expenseClaimRepository) Update(ctx context.Context, claim *models.ExpenseClaim) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(claim).Error
+}
+
+func (r *expenseClaimRepository) List(ctx context.Context, tenantID uuid.UUID, filters ExpenseClaimFilters) ([]models.ExpenseClaim, error) {
+	var claims []models.ExpenseClaim
+
+	query := r.applyFilters(r.db.WithContext(ctx).Where("tenant_id = ?", tenantID), filters)
+	err := query.Preload("Items").Order("created_at DESC").Find(&claims).Error
+	return claims, err
+}
+
+func (r *expenseClaimRepository) GetNextClaimNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "expense_claim:"+prefix,
+		database.SeedFromExisting("expense_claims", "claim_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+// GetMemberSummary totals each member's claims by status. Reimbursement is
+// read from the bills raised for approved claims.
+func (r *expenseClaimRepository) GetMemberSummary(ctx context.Context, tenantID uuid.UUID, filters ExpenseClaimFilters) ([]MemberClaimSummary, error) {
+	var summaries []MemberClaimSummary
+
+	query := r.db.WithContext(ctx).
+		Table("expense_claims").
+		Select(`expense_claims.member_id,
+			MAX(expense_claims.employee_name) as employee_name,
+			COUNT(*) as claim_count,
+			COALESCE(SUM(CASE WHEN expense_claims.status = 'submitted' THEN expense_claims.total_amount ELSE 0 END), 0) as submitted_amount,
+			COALESCE(SUM(CASE WHEN expense_claims.status = 'approved' THEN expense_claims.total_amount ELSE 0 END), 0) as approved_amount,
+			COALESCE(SUM(CASE WHEN expense_claims.status = 'rejected' THEN expense_claims.total_amount ELSE 0 END), 0) as rejected_amount,
+			COALESCE(SUM(bills.amount_paid), 0) as reimbursed_amount,
+			COALESCE(SUM(bills.balance_due), 0) as outstanding_amount`).
+		Joins("LEFT JOIN bills ON bills.id = expense_claims.bill_id AND bills.deleted_at IS NULL").
+		Where("expense_claims.tenant_id = ? AND expense_claims.deleted_at IS NULL", tenantID)
+
+	if filters.MemberID != uuid.Nil {
+		query = query.Where("expense_claims.member_id = ?", filters.MemberID)
+	}
+	if filters.Project != "" {
+		query = query.Where("expense_claims.project = ?", filters.Project)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("expense_claims.created_at >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("expense_claims.created_at < CAST(? AS date) + 1", filters.ToDate)
+	}
+
+	err := query.
+		Group("expense_claims.member_id").
+		Order("employee_name").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+func (r *expenseClaimRepository) applyFilters(query *gorm.DB, filters ExpenseClaimFilters) *gorm.DB {
+	if filters.MemberID != uuid.Nil {
+		query = query.Where("member_id = ?", filters.MemberID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Project != "" {
+		query = query.Where("project = ?", filters.Project)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("created_at >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("created_at < CAST(? AS date) + 1", filters.ToDate)
+	}
+	return query
+}
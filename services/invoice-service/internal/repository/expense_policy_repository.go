@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// ExpensePolicyRepository handles expense policies and the violations
+// recorded against claims and bills
+type ExpensePolicyRepository interface {
+	// GetPolicy returns the tenant's policy, or an empty one if it has none
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.ExpensePolicy, error)
+	// SavePolicy saves a policy, replacing its category rules
+	SavePolicy(ctx context.Context, policy *models.ExpensePolicy) error
+
+	// ReplaceViolations replaces a document's violations with those given
+	ReplaceViolations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID, violations []models.ExpensePolicyViolation) error
+	ListDocumentViolations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID) ([]models.ExpensePolicyViolation, error)
+	// OverrideViolations marks a document's open violations overridden and
+	// returns how many were
+	OverrideViolations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID, userID uuid.UUID, reason string) (int64, error)
+	ListViolations(ctx context.Context, tenantID uuid.UUID, filters ExpenseViolationFilters) ([]models.ExpensePolicyViolation, error)
+
+	// ClaimedByMember totals what a member has claimed in each category on
+	// each day between from and to, leaving out rejected claims and the
+	// claim excluded
+	ClaimedByMember(ctx context.Context, tenantID, memberID, excludeClaimID uuid.UUID, from, to time.Time) ([]DailyCategorySpend, error)
+}
+
+// ExpenseViolationFilters filters the expense policy violations
+type ExpenseViolationFilters struct {
+	DocumentType string
+	Rule         string
+	Overridden   *bool
+	FromDate     string
+	ToDate       string
+}
+
+// DailyCategorySpend is what was spent in a category on a day
+type DailyCategorySpend struct {
+	Category    string          `json:"category"`
+	ExpenseDate time.Time       `json:"expense_date"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+type expensePolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewExpensePolicyRepository creates a new expense policy repository
+func NewExpensePolicyRepository(db *gorm.DB) ExpensePolicyRepository {
+	return &expensePolicyRepository{db: db}
+}
+
+func (r *expensePolicyRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.ExpensePolicy, error) {
+	var policy models.ExpensePolicy
+	err := r.db.WithContext(ctx).
+		Preload("Rules", func(db *gorm.DB) *gorm.DB {
+			return db.Order("category")
+		}).
+		First(&policy, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.ExpensePolicy{TenantID: tenantID, Rules: []models.ExpenseCategoryRule{}}, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *expensePolicyRepository) SavePolicy(ctx context.Context, policy *models.ExpensePolicy) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ?", policy.TenantID).Delete(&models.ExpenseCategoryRule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Rules").Save(policy).Error; err != nil {
+			return err
+		}
+		if len(policy.Rules) == 0 {
+			return nil
+		}
+		return tx.Create(&policy.Rules).Error
+	})
+}
+
+func (r *expensePolicyRepository) ReplaceViolations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID, violations []models.ExpensePolicyViolation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("tenant_id = ? AND document_type = ? AND document_id = ?", tenantID, documentType, documentID).
+			Delete(&models.ExpensePolicyViolation{}).Error
+		if err != nil {
+			return err
+		}
+		if len(violations) == 0 {
+			return nil
+		}
+		return tx.Create(&violations).Error
+	})
+}
+
+func (r *expensePolicyRepository) ListDocumentViolations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID) ([]models.ExpensePolicyViolation, error) {
+	var violations []models.ExpensePolicyViolation
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_type = ? AND document_id = ?", tenantID, documentType, documentID).
+		Order("expense_date, rule").
+		Find(&violations).Error
+	return violations, err
+}
+
+func (r *expensePolicyRepository) OverrideViolations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID, userID uuid.UUID, reason string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.ExpensePolicyViolation{}).
+		Where("tenant_id = ? AND document_type = ? AND document_id = ? AND overridden = ?", tenantID, documentType, documentID, false).
+		Updates(map[string]interface{}{
+			"overridden":      true,
+			"override_reason": reason,
+			"overridden_by":   userID,
+			"overridden_at":   time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *expensePolicyRepository) ListViolations(ctx context.Context, tenantID uuid.UUID, filters ExpenseViolationFilters) ([]models.ExpensePolicyViolation, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filters.DocumentType != "" {
+		query = query.Where("document_type = ?", filters.DocumentType)
+	}
+	if filters.Rule != "" {
+		query = query.Where("rule = ?", filters.Rule)
+	}
+	if filters.Overridden != nil {
+		query = query.Where("overridden = ?", *filters.Overridden)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("expense_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("expense_date <= ?", filters.ToDate)
+	}
+
+	var violations []models.ExpensePolicyViolation
+	err := query.Order("expense_date DESC, created_at DESC").Find(&violations).Error
+	return violations, err
+}
+
+func (r *expensePolicyRepository) ClaimedByMember(ctx context.Context, tenantID, memberID, excludeClaimID uuid.UUID, from, to time.Time) ([]DailyCategorySpend, error) {
+	var spend []DailyCategorySpend
+	err := r.db.WithContext(ctx).
+		Table("expense_claim_items i").
+		Select("LOWER(TRIM(i.category)) AS category, i.expense_date::date AS expense_date, COALESCE(SUM(i.amount), 0) AS amount").
+		Joins("JOIN expense_claims c ON c.id = i.claim_id").
+		Where("c.tenant_id = ? AND c.member_id = ? AND c.id <> ? AND c.deleted_at IS NULL", tenantID, memberID, excludeClaimID).
+		Where("c.status <> ?", models.ExpenseClaimStatusRejected).
+		Where("i.expense_date::date BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Group("LOWER(TRIM(i.category)), i.expense_date::date").
+		Scan(&spend).Error
+	return spend, err
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// CustomerCreditRow summarises a customer's invoicing, payment behaviour and
+// credit notes over a period
+type CustomerCreditRow struct {
+	CustomerID       uuid.UUID
+	InvoicesIssued   int
+	TotalInvoiced    decimal.Decimal
+	TotalCollected   decimal.Decimal
+	CreditNotes      decimal.Decimal
+	AverageDaysToPay float64 // Weighted by amount paid
+	OnTimeShare      float64 // Share of the amount paid that arrived by the due date
+}
+
+// FinancingRepository handles financing consents, the export log and the
+// receivables data lenders ask for
+type FinancingRepository interface {
+	CreateConsent(ctx context.Context, consent *models.FinancingConsent) error
+	UpdateConsent(ctx context.Context, consent *models.FinancingConsent) error
+	GetConsent(ctx context.Context, tenantID, id uuid.UUID) (*models.FinancingConsent, error)
+	ListConsents(ctx context.Context, tenantID uuid.UUID) ([]models.FinancingConsent, error)
+	CreateExport(ctx context.Context, export *models.FinancingExport) error
+	ListExports(ctx context.Context, tenantID, consentID uuid.UUID) ([]models.FinancingExport, error)
+
+	// ListOpenInvoices returns issued invoices dated up to asOf that still
+	// have a balance due
+	ListOpenInvoices(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]models.Invoice, error)
+
+	// SumCreditNotesByInvoice returns the credit notes issued against each
+	// of the invoices
+	SumCreditNotesByInvoice(ctx context.Context, tenantID uuid.UUID, invoiceIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error)
+
+	// GetCustomerCreditHistory summarises each customer's invoices, payments
+	// and credit notes dated between from and to
+	GetCustomerCreditHistory(ctx context.Context, tenantID uuid.UUID, customerIDs []uuid.UUID, from, to time.Time) (map[uuid.UUID]*CustomerCreditRow, error)
+}
+
+type financingRepository struct {
+	db *gorm.DB
+}
+
+// NewFinancingRepository creates a new financing repository
+func NewFinancingRepository(db *gorm.DB) FinancingRepository {
+	return &financingRepository{db: db}
+}
+
+func (r *financingRepository) CreateConsent(ctx context.Context, consent *models.FinancingConsent) error {
+	return r.db.WithContext(ctx).Create(consent).Error
+}
+
+func (r *financingRepository) UpdateConsent(ctx context.Context, consent *models.FinancingConsent) error {
+	return r.db.WithContext(ctx).Save(consent).Error
+}
+
+func (r *financingRepository) GetConsent(ctx context.Context, tenantID, id uuid.UUID) (*models.FinancingConsent, error) {
+	var consent models.FinancingConsent
+	err := r.db.WithContext(ctx).First(&consent, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *financingRepository) ListConsents(ctx context.Context, tenantID uuid.UUID) ([]models.FinancingConsent, error) {
+	var consents []models.FinancingConsent
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("granted_at DESC").
+		Find(&consents).Error
+	return consents, err
+}
+
+func (r *financingRepository) CreateExport(ctx context.Context, export *models.FinancingExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *financingRepository) ListExports(ctx context.Context, tenantID, consentID uuid.UUID) ([]models.FinancingExport, error) {
+	var exports []models.FinancingExport
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if consentID != uuid.Nil {
+		query = query.Where("consent_id = ?", consentID)
+	}
+
+	err := query.Order("created_at DESC").Find(&exports).Error
+	return exports, err
+}
+
+func (r *financingRepository) ListOpenInvoices(ctx context.Context, tenantID uuid.UUID, asOf time.Time) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND invoice_date < ? AND balance_due > 0", tenantID, asOf.AddDate(0, 0, 1)).
+		Where("status NOT IN ?", []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusPaid, models.InvoiceStatusCancelled}).
+		Order("customer_name, due_date, invoice_number").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *financingRepository) SumCreditNotesByInvoice(ctx context.Context, tenantID uuid.UUID, invoiceIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	totals := make(map[uuid.UUID]decimal.Decimal)
+	if len(invoiceIDs) == 0 {
+		return totals, nil
+	}
+
+	var rows []struct {
+		InvoiceID uuid.UUID
+		Amount    decimal.Decimal
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.CreditNote{}).
+		Select("invoice_id, SUM(total_amount) AS amount").
+		Where("tenant_id = ? AND invoice_id IN ?", tenantID, invoiceIDs).
+		Where("status NOT IN ?", []models.CreditNoteStatus{models.CreditNoteStatusDraft, models.CreditNoteStatusCancelled}).
+		Group("invoice_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		totals[row.InvoiceID] = row.Amount
+	}
+	return totals, nil
+}
+
+func (r *financingRepository) GetCustomerCreditHistory(ctx context.Context, tenantID uuid.UUID, customerIDs []uuid.UUID, from, to time.Time) (map[uuid.UUID]*CustomerCreditRow, error) {
+	history := make(map[uuid.UUID]*CustomerCreditRow, len(customerIDs))
+	if len(customerIDs) == 0 {
+		return history, nil
+	}
+	for _, id := range customerIDs {
+		history[id] = &CustomerCreditRow{CustomerID: id}
+	}
+	until := to.AddDate(0, 0, 1)
+
+	var invoiced []struct {
+		CustomerID     uuid.UUID
+		InvoicesIssued int
+		TotalInvoiced  decimal.Decimal
+		TotalCollected decimal.Decimal
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Select("customer_id, COUNT(*) AS invoices_issued, SUM(total_amount) AS total_invoiced, SUM(amount_paid) AS total_collected").
+		Where("tenant_id = ? AND customer_id IN ? AND invoice_date >= ? AND invoice_date < ?", tenantID, customerIDs, from, until).
+		Where("status NOT IN ?", []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusCancelled}).
+		Group("customer_id").
+		Scan(&invoiced).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range invoiced {
+		h := history[row.CustomerID]
+		h.InvoicesIssued = row.InvoicesIssued
+		h.TotalInvoiced = row.TotalInvoiced
+		h.TotalCollected = row.TotalCollected
+	}
+
+	var paid []struct {
+		CustomerID       uuid.UUID
+		AverageDaysToPay float64
+		OnTimeShare      float64
+	}
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT
+			i.customer_id,
+			COALESCE(SUM(p.amount * (p.payment_date::date - i.invoice_date::date)) / NULLIF(SUM(p.amount), 0), 0) AS average_days_to_pay,
+			COALESCE(SUM(CASE WHEN p.payment_date::date <= i.due_date::date THEN p.amount ELSE 0 END) / NULLIF(SUM(p.amount), 0), 0) AS on_time_share
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.tenant_id = ?
+		AND i.customer_id IN ?
+		AND p.payment_date >= ? AND p.payment_date < ?
+		AND p.bounced_at IS NULL
+		AND p.deleted_at IS NULL
+		AND i.deleted_at IS NULL
+		GROUP BY i.customer_id
+	`, tenantID, customerIDs, from, until).Scan(&paid).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range paid {
+		h := history[row.CustomerID]
+		h.AverageDaysToPay = row.AverageDaysToPay
+		h.OnTimeShare = row.OnTimeShare
+	}
+
+	var credited []struct {
+		CustomerID uuid.UUID
+		Amount     decimal.Decimal
+	}
+	err = r.db.WithContext(ctx).
+		Model(&models.CreditNote{}).
+		Select("customer_id, SUM(total_amount) AS amount").
+		Where("tenant_id = ? AND customer_id IN ? AND credit_note_date >= ? AND credit_note_date < ?", tenantID, customerIDs, from, until).
+		Where("status NOT IN ?", []models.CreditNoteStatus{models.CreditNoteStatusDraft, models.CreditNoteStatusCancelled}).
+		Group("customer_id").
+		Scan(&credited).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range credited {
+		history[row.CustomerID].CreditNotes = row.Amount
+	}
+
+	return history, nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// GSTTotalsRow totals the taxable value and GST of a group of documents
+type GSTTotalsRow struct {
+	Category string
+	Taxable  decimal.Decimal
+	CGST     decimal.Decimal
+	SGST     decimal.Decimal
+	IGST     decimal.Decimal
+	Cess     decimal.Decimal
+}
+
+// Outward supply categories returned by OutwardTotals
+const (
+	OutwardB2B                  = "b2b"
+	OutwardB2C                  = "b2c"
+	OutwardExportWithPayment    = "export_with_payment"
+	OutwardExportWithoutPayment = "export_without_payment"
+	OutwardNilExempt            = "nil_exempt"
+)
+
+// outwardTotals groups issued invoices dated within [@from, @to) and
+// recorded on or after @recorded_from by how they are reported in GSTR-1
+const outwardTotals = `
+	SELECT CASE
+			WHEN export_type = 'WPAY' THEN 'export_with_payment'
+			WHEN export_type = 'WOPAY' THEN 'export_without_payment'
+			WHEN total_tax = 0 THEN 'nil_exempt'
+			WHEN COALESCE(customer_gstin, '') <> '' THEN 'b2b'
+			ELSE 'b2c'
+		END AS category,
+		COALESCE(SUM(taxable_amount), 0) AS taxable,
+		COALESCE(SUM(cgst_amount), 0) AS cgst,
+		COALESCE(SUM(sgst_amount), 0) AS sgst,
+		COALESCE(SUM(igst_amount), 0) AS igst,
+		COALESCE(SUM(cess_amount), 0) AS cess
+	FROM invoices
+	WHERE tenant_id = @tenant AND status NOT IN ('draft', 'cancelled') AND deleted_at IS NULL
+		AND invoice_date >= @from AND invoice_date < @to AND created_at >= @recorded_from
+	GROUP BY 1`
+
+// GSTAnnualRepository totals the year's documents for the annual GST return
+type GSTAnnualRepository interface {
+	// OutwardTotals totals issued invoices dated within [from, to) by
+	// category, counting only those recorded on or after recordedFrom
+	OutwardTotals(ctx context.Context, tenantID uuid.UUID, from, to, recordedFrom time.Time) ([]GSTTotalsRow, error)
+
+	// CreditNoteTotals totals issued credit notes dated within [from, to).
+	// When invoiceFrom is set, only credit notes against invoices dated
+	// within [invoiceFrom, invoiceTo) are counted.
+	CreditNoteTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time, invoiceFrom, invoiceTo *time.Time) (*GSTTotalsRow, error)
+
+	// AdvanceTotals totals the tax on advances received within [from, to),
+	// less refund vouchers issued in the same window
+	AdvanceTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, error)
+
+	// ReverseChargeTotals totals self-invoices dated within [from, to); the
+	// second row is the input tax credit on their eligible lines
+	ReverseChargeTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, *GSTTotalsRow, error)
+
+	// InwardITCTotals totals the tax on bill lines dated within [from, to),
+	// other than reverse charge bills, split into eligible and blocked credit
+	InwardITCTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, *GSTTotalsRow, error)
+
+	// DebitNoteITCTotals totals the input tax credit reversed by debit notes
+	// issued within [from, to), on the lines whose credit was claimed
+	DebitNoteITCTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, error)
+}
+
+type gstAnnualRepository struct {
+	db *gorm.DB
+}
+
+// NewGSTAnnualRepository creates a new annual GST repository
+func NewGSTAnnualRepository(db *gorm.DB) GSTAnnualRepository {
+	return &gstAnnualRepository{db: db}
+}
+
+func (r *gstAnnualRepository) OutwardTotals(ctx context.Context, tenantID uuid.UUID, from, to, recordedFrom time.Time) ([]GSTTotalsRow, error) {
+	var rows []GSTTotalsRow
+	err := r.db.WithContext(ctx).Raw(outwardTotals, map[string]interface{}{
+		"tenant":        tenantID,
+		"from":          from,
+		"to":            to,
+		"recorded_from": recordedFrom,
+	}).Scan(&rows).Error
+	return rows, err
+}
+
+func (r *gstAnnualRepository) CreditNoteTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time, invoiceFrom, invoiceTo *time.Time) (*GSTTotalsRow, error) {
+	query := r.db.WithContext(ctx).
+		Table("credit_notes cn").
+		Select(`COALESCE(SUM(cn.subtotal), 0) AS taxable,
+			COALESCE(SUM(cn.cgst_amount), 0) AS cgst,
+			COALESCE(SUM(cn.sgst_amount), 0) AS sgst,
+			COALESCE(SUM(cn.igst_amount), 0) AS igst,
+			COALESCE(SUM(cn.cess_amount), 0) AS cess`).
+		Where("cn.tenant_id = ? AND cn.status NOT IN ('draft', 'cancelled') AND cn.deleted_at IS NULL", tenantID).
+		Where("cn.credit_note_date >= ? AND cn.credit_note_date < ?", from, to)
+	if invoiceFrom != nil && invoiceTo != nil {
+		query = query.
+			Joins("JOIN invoices i ON i.id = cn.invoice_id").
+			Where("i.invoice_date >= ? AND i.invoice_date < ?", *invoiceFrom, *invoiceTo)
+	}
+
+	var row GSTTotalsRow
+	if err := query.Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (r *gstAnnualRepository) AdvanceTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, error) {
+	var row GSTTotalsRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(SUM(taxable), 0) AS taxable, COALESCE(SUM(cgst), 0) AS cgst, COALESCE(SUM(sgst), 0) AS sgst,
+			COALESCE(SUM(igst), 0) AS igst, COALESCE(SUM(cess), 0) AS cess
+		FROM (
+			SELECT taxable_amount AS taxable, cgst_amount AS cgst, sgst_amount AS sgst, igst_amount AS igst, cess_amount AS cess
+			FROM advance_receipts
+			WHERE tenant_id = @tenant AND deleted_at IS NULL AND receipt_date >= @from AND receipt_date < @to
+			UNION ALL
+			SELECT -taxable_amount, -cgst_amount, -sgst_amount, -igst_amount, -cess_amount
+			FROM refund_vouchers
+			WHERE tenant_id = @tenant AND voucher_date >= @from AND voucher_date < @to
+		) advances`, map[string]interface{}{"tenant": tenantID, "from": from, "to": to}).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (r *gstAnnualRepository) ReverseChargeTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, *GSTTotalsRow, error) {
+	var supply GSTTotalsRow
+	err := r.db.WithContext(ctx).
+		Table("self_invoices").
+		Select(`COALESCE(SUM(taxable_amount), 0) AS taxable,
+			COALESCE(SUM(cgst_amount), 0) AS cgst,
+			COALESCE(SUM(sgst_amount), 0) AS sgst,
+			COALESCE(SUM(igst_amount), 0) AS igst,
+			COALESCE(SUM(cess_amount), 0) AS cess`).
+		Where("tenant_id = ? AND deleted_at IS NULL AND invoice_date >= ? AND invoice_date < ?", tenantID, from, to).
+		Scan(&supply).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var itc GSTTotalsRow
+	err = r.db.WithContext(ctx).
+		Table("self_invoice_items sii").
+		Joins("JOIN self_invoices si ON si.id = sii.self_invoice_id").
+		Select(`COALESCE(SUM(sii.amount), 0) AS taxable,
+			COALESCE(SUM(sii.cgst_amount), 0) AS cgst,
+			COALESCE(SUM(sii.sgst_amount), 0) AS sgst,
+			COALESCE(SUM(sii.igst_amount), 0) AS igst,
+			COALESCE(SUM(sii.cess_amount), 0) AS cess`).
+		Where("si.tenant_id = ? AND si.deleted_at IS NULL AND si.invoice_date >= ? AND si.invoice_date < ?", tenantID, from, to).
+		Where("sii.itc_eligible = ?", true).
+		Scan(&itc).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return &supply, &itc, nil
+}
+
+func (r *gstAnnualRepository) InwardITCTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, *GSTTotalsRow, error) {
+	var rows []struct {
+		Eligible bool
+		GSTTotalsRow
+	}
+	err := r.db.WithContext(ctx).
+		Table("bill_items bi").
+		Joins("JOIN bills b ON b.id = bi.bill_id").
+		Select(`b.itc_eligible AND bi.itc_eligible AS eligible,
+			COALESCE(SUM(bi.amount), 0) AS taxable,
+			COALESCE(SUM(bi.cgst_amount), 0) AS cgst,
+			COALESCE(SUM(bi.sgst_amount), 0) AS sgst,
+			COALESCE(SUM(bi.igst_amount), 0) AS igst,
+			COALESCE(SUM(bi.cess_amount), 0) AS cess`).
+		Where("b.tenant_id = ? AND b.status NOT IN ('draft', 'cancelled') AND b.deleted_at IS NULL", tenantID).
+		Where("b.bill_date >= ? AND b.bill_date < ? AND b.urd_reverse_charge = ?", from, to, false).
+		Group("1").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var eligible, blocked GSTTotalsRow
+	for _, row := range rows {
+		if row.Eligible {
+			eligible = row.GSTTotalsRow
+		} else {
+			blocked = row.GSTTotalsRow
+		}
+	}
+	return &eligible, &blocked, nil
+}
+
+func (r *gstAnnualRepository) DebitNoteITCTotals(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*GSTTotalsRow, error) {
+	var row GSTTotalsRow
+	err := r.db.WithContext(ctx).
+		Table("debit_note_items dni").
+		Joins("JOIN debit_notes dn ON dn.id = dni.debit_note_id").
+		Select(`COALESCE(SUM(dni.amount), 0) AS taxable,
+			COALESCE(SUM(dni.cgst_amount), 0) AS cgst,
+			COALESCE(SUM(dni.sgst_amount), 0) AS sgst,
+			COALESCE(SUM(dni.igst_amount), 0) AS igst,
+			COALESCE(SUM(dni.cess_amount), 0) AS cess`).
+		Where("dn.tenant_id = ? AND dn.status = 'issued' AND dn.deleted_at IS NULL", tenantID).
+		Where("dn.debit_note_date >= ? AND dn.debit_note_date < ?", from, to).
+		Where("dni.itc_reversed = ?", true).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// InvoiceExportRepository handles bulk invoice PDF exports and the invoices
+// they are built from
+type InvoiceExportRepository interface {
+	Create(ctx context.Context, export *models.InvoiceExport) error
+	// Update saves an export's status and progress, leaving its zip alone
+	Update(ctx context.Context, export *models.InvoiceExport) error
+	UpdateProgress(ctx context.Context, id uuid.UUID, rendered int) error
+	// Complete saves a finished export along with its zip
+	Complete(ctx context.Context, export *models.InvoiceExport) error
+
+	// GetByID and List leave out the zip; GetContent reads it
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceExport, error)
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.InvoiceExport, error)
+	GetContent(ctx context.Context, id uuid.UUID) (*models.InvoiceExport, error)
+
+	// GetByIDAny returns an export of any tenant, for the job that builds it
+	GetByIDAny(ctx context.Context, id uuid.UUID) (*models.InvoiceExport, error)
+
+	// HasActive reports whether the tenant has an export queued or running
+	HasActive(ctx context.Context, tenantID uuid.UUID) (bool, error)
+
+	// PurgeExpired drops the zips of exports past their expiry and returns
+	// how many were purged
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+
+	// ListInvoices returns the issued invoices dated within [from, to] with
+	// their items
+	ListInvoices(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.Invoice, error)
+}
+
+type invoiceExportRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceExportRepository creates a new invoice export repository
+func NewInvoiceExportRepository(db *gorm.DB) InvoiceExportRepository {
+	return &invoiceExportRepository{db: db}
+}
+
+func (r *invoiceExportRepository) Create(ctx context.Context, export *models.InvoiceExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *invoiceExportRepository) Update(ctx context.Context, export *models.InvoiceExport) error {
+	return r.db.WithContext(ctx).Omit("Content").Save(export).Error
+}
+
+func (r *invoiceExportRepository) UpdateProgress(ctx context.Context, id uuid.UUID, rendered int) error {
+	return r.db.WithContext(ctx).
+		Model(&models.InvoiceExport{}).
+		Where("id = ?", id).
+		Update("rendered", rendered).Error
+}
+
+func (r *invoiceExportRepository) Complete(ctx context.Context, export *models.InvoiceExport) error {
+	return r.db.WithContext(ctx).Save(export).Error
+}
+
+func (r *invoiceExportRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceExport, error) {
+	var export models.InvoiceExport
+	err := r.db.WithContext(ctx).
+		Omit("Content").
+		First(&export, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *invoiceExportRepository) List(ctx context.Context, tenantID uuid.UUID) ([]models.InvoiceExport, error) {
+	var exports []models.InvoiceExport
+	err := r.db.WithContext(ctx).
+		Omit("Content").
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *invoiceExportRepository) GetContent(ctx context.Context, id uuid.UUID) (*models.InvoiceExport, error) {
+	var export models.InvoiceExport
+	if err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *invoiceExportRepository) GetByIDAny(ctx context.Context, id uuid.UUID) (*models.InvoiceExport, error) {
+	var export models.InvoiceExport
+	if err := r.db.WithContext(ctx).Omit("Content").First(&export, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *invoiceExportRepository) HasActive(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.InvoiceExport{}).
+		Where("tenant_id = ? AND status IN ?", tenantID, []string{models.InvoiceExportQueued, models.InvoiceExportRunning}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *invoiceExportRepository) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.InvoiceExport{}).
+		Where("expires_at < ? AND content IS NOT NULL", now).
+		Update("content", nil)
+	return result.RowsAffected, result.Error
+}
+
+func (r *invoiceExportRepository) ListInvoices(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("tenant_id = ?", tenantID).
+		Where("invoice_date >= ? AND invoice_date <= ?", from, to).
+		Where("status NOT IN ?", []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusCancelled}).
+		Order("invoice_date, invoice_number").
+		Find(&invoices).Error
+	return invoices, err
+}
@@ -2,8 +2,14 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"gorm.io/gorm"
 )
@@ -16,6 +22,29 @@ type InvoiceRepository interface {
 	Update(ctx context.Context, invoice *models.Invoice) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetNextInvoiceNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	GetCustomerSalesTotal(ctx context.Context, tenantID, customerID uuid.UUID, from, to time.Time, excludeID uuid.UUID) (decimal.Decimal, error)
+	UpdateDisputed(ctx context.Context, id uuid.UUID, disputed bool, disputedAmount decimal.Decimal) error
+	UpdateShippingBill(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
+	// UpdateEInvoice saves an invoice's e-invoice fields without touching
+	// its items
+	UpdateEInvoice(ctx context.Context, invoice *models.Invoice) error
+	// UpdateEmail saves an invoice's customer email and the delivery of its
+	// latest email without touching its items
+	UpdateEmail(ctx context.Context, invoice *models.Invoice) error
+	// UpdateEmailStatus saves the status of an email of an invoice, if it is
+	// still the invoice's latest, and reports whether it was
+	UpdateEmailStatus(ctx context.Context, id, deliveryID uuid.UUID, status, bounceReason string, at time.Time) (bool, error)
+	// ListIssued returns the invoices issued (not draft or cancelled) dated
+	// within [from, to) with their items
+	ListIssued(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.Invoice, error)
+
+	// Tags
+	UpdateTags(ctx context.Context, id uuid.UUID, tagList []string) error
+	ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error)
+	// RenameTag renames a tag on every invoice, merging it into the new
+	// name where both are present, and returns how many were changed
+	RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error)
+	DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error)
 }
 
 // InvoiceFilters represents filters for listing invoices
@@ -24,8 +53,12 @@ type InvoiceFilters struct {
 	CustomerID uuid.UUID
 	FromDate   string
 	ToDate     string
-	Page       int
-	Limit      int
+	Tag        string
+	// EmailStatus lists invoices whose latest email is in this status, such
+	// as bounced
+	EmailStatus string
+	Page        int
+	Limit       int
 }
 
 type invoiceRepository struct {
@@ -73,6 +106,12 @@ func (r *invoiceRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUI
 	if filters.ToDate != "" {
 		query = query.Where("invoice_date <= ?", filters.ToDate)
 	}
+	if filters.Tag != "" {
+		query = query.Where("tags @> ARRAY[?]::text[]", filters.Tag)
+	}
+	if filters.EmailStatus != "" {
+		query = query.Where("email_status = ?", filters.EmailStatus)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -106,23 +145,121 @@ func (r *invoiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (r *invoiceRepository) GetNextInvoiceNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
-	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.Invoice{}).
-		Where("tenant_id = ? AND invoice_number LIKE ?", tenantID, prefix+"%").
-		Count(&count).Error
+	next, err := database.NextNumber(ctx, r.db, tenantID, "invoice:"+prefix,
+		database.SeedFromExisting("invoices", "invoice_number", tenantID, prefix))
 	if err != nil {
 		return "", err
 	}
 
-	return prefix + "-" + padNumber(int(count)+1, 5), nil
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
 }
 
-func padNumber(n int, width int) string {
-	s := ""
-	for i := 0; i < width; i++ {
-		s = "0" + s
-	}
-	result := s + string(rune(n))
-	return result[len(result)-width:]
+// GetCustomerSalesTotal returns the sale value (taxable amount plus GST) of a
+// customer's issued invoices dated within [from, to]
+func (r *invoiceRepository) GetCustomerSalesTotal(ctx context.Context, tenantID, customerID uuid.UUID, from, to time.Time, excludeID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Select("COALESCE(SUM(taxable_amount + total_tax), 0)").
+		Where("tenant_id = ? AND customer_id = ? AND id <> ?", tenantID, customerID, excludeID).
+		Where("invoice_date >= ? AND invoice_date <= ?", from, to).
+		Where("status NOT IN ?", []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusCancelled}).
+		Scan(&total).Error
+	return total, err
+}
+
+// UpdateDisputed sets the dispute flag and held amount without touching the
+// invoice's items
+func (r *invoiceRepository) UpdateDisputed(ctx context.Context, id uuid.UUID, disputed bool, disputedAmount decimal.Decimal) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"disputed": disputed, "disputed_amount": disputedAmount}).Error
+}
+
+// UpdateShippingBill sets the shipping bill details of an export invoice
+// without touching its items
+func (r *invoiceRepository) UpdateShippingBill(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+func (r *invoiceRepository) UpdateEInvoice(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).
+		Model(invoice).
+		Select("IRN", "EInvoiceStatus", "EInvoiceDate", "QRCode", "AckNo", "AckDate",
+			"SignedInvoice", "EInvoiceError", "EInvoiceCancelledAt").
+		Updates(invoice).Error
+}
+
+func (r *invoiceRepository) UpdateEmail(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).
+		Model(invoice).
+		Select("CustomerEmail", "EmailDeliveryID", "EmailStatus", "EmailStatusAt", "EmailBounceReason").
+		Updates(invoice).Error
+}
+
+func (r *invoiceRepository) UpdateEmailStatus(ctx context.Context, id, deliveryID uuid.UUID, status, bounceReason string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ? AND email_delivery_id = ?", id, deliveryID).
+		Updates(map[string]interface{}{
+			"email_status":        status,
+			"email_status_at":     at,
+			"email_bounce_reason": bounceReason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *invoiceRepository) ListIssued(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("tenant_id = ?", tenantID).
+		Where("invoice_date >= ? AND invoice_date < ?", from, to).
+		Where("status NOT IN ?", []models.InvoiceStatus{models.InvoiceStatusDraft, models.InvoiceStatusCancelled}).
+		Order("invoice_date, invoice_number").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// UpdateTags replaces the invoice's tags without touching its items
+func (r *invoiceRepository) UpdateTags(ctx context.Context, id uuid.UUID, tagList []string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Invoice{}).
+		Where("id = ?", id).
+		Update("tags", pq.StringArray(tagList)).Error
+}
+
+func (r *invoiceRepository) ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error) {
+	usage := []tags.Usage{}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT tag, COUNT(*) AS count
+		FROM invoices, unnest(tags) AS tag
+		WHERE tenant_id = ? AND deleted_at IS NULL
+		GROUP BY tag
+		ORDER BY count DESC, tag
+	`, tenantID).Scan(&usage).Error
+	return usage, err
+}
+
+func (r *invoiceRepository) RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE invoices
+		SET tags = CASE WHEN ? = ANY(tags) THEN array_remove(tags, ?) ELSE array_replace(tags, ?, ?) END,
+			updated_at = NOW()
+		WHERE tenant_id = ? AND tags @> ARRAY[?]::text[]
+	`, to, from, from, to, tenantID, from)
+	return result.RowsAffected, result.Error
+}
+
+func (r *invoiceRepository) DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE invoices
+		SET tags = array_remove(tags, ?), updated_at = NOW()
+		WHERE tenant_id = ? AND tags @> ARRAY[?]::text[]
+	`, tag, tenantID, tag)
+	return result.RowsAffected, result.Error
 }
@@ -13,6 +13,7 @@ type PaymentRepository interface {
 	Create(ctx context.Context, payment *models.Payment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error)
 	GetByInvoiceID(ctx context.Context, invoiceID uuid.UUID) ([]models.Payment, error)
+	Update(ctx context.Context, payment *models.Payment) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -47,6 +48,10 @@ func (r *paymentRepository) GetByInvoiceID(ctx context.Context, invoiceID uuid.U
 	return payments, err
 }
 
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
+
 func (r *paymentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.Payment{}, "id = ?", id).Error
 }
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// PaymentTermRepository handles payment term data operations
+type PaymentTermRepository interface {
+	Create(ctx context.Context, term *models.PaymentTerm) error
+	CreateBatch(ctx context.Context, terms []models.PaymentTerm) error
+	Update(ctx context.Context, term *models.PaymentTerm) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.PaymentTerm, error)
+	GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.PaymentTerm, error)
+	GetDefault(ctx context.Context, tenantID uuid.UUID) (*models.PaymentTerm, error)
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.PaymentTerm, error)
+	ClearDefault(ctx context.Context, tenantID uuid.UUID) error
+	GetCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerPaymentTerm, error)
+	SaveCustomerTerm(ctx context.Context, term *models.CustomerPaymentTerm) error
+	DeleteCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID) error
+	CountCustomerTerms(ctx context.Context, tenantID, paymentTermID uuid.UUID) (int64, error)
+}
+
+type paymentTermRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentTermRepository creates a new payment term repository
+func NewPaymentTermRepository(db *gorm.DB) PaymentTermRepository {
+	return &paymentTermRepository{db: db}
+}
+
+func (r *paymentTermRepository) Create(ctx context.Context, term *models.PaymentTerm) error {
+	return r.db.WithContext(ctx).Create(term).Error
+}
+
+func (r *paymentTermRepository) CreateBatch(ctx context.Context, terms []models.PaymentTerm) error {
+	return r.db.WithContext(ctx).Create(&terms).Error
+}
+
+func (r *paymentTermRepository) Update(ctx context.Context, term *models.PaymentTerm) error {
+	return r.db.WithContext(ctx).Save(term).Error
+}
+
+func (r *paymentTermRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Delete(&models.PaymentTerm{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *paymentTermRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.PaymentTerm, error) {
+	var term models.PaymentTerm
+	err := r.db.WithContext(ctx).
+		First(&term, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+func (r *paymentTermRepository) GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.PaymentTerm, error) {
+	var term models.PaymentTerm
+	err := r.db.WithContext(ctx).
+		First(&term, "tenant_id = ? AND LOWER(name) = LOWER(?)", tenantID, name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+func (r *paymentTermRepository) GetDefault(ctx context.Context, tenantID uuid.UUID) (*models.PaymentTerm, error) {
+	var term models.PaymentTerm
+	err := r.db.WithContext(ctx).
+		First(&term, "tenant_id = ? AND is_default = true AND is_active = true", tenantID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+func (r *paymentTermRepository) List(ctx context.Context, tenantID uuid.UUID) ([]models.PaymentTerm, error) {
+	var terms []models.PaymentTerm
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("net_days ASC, name ASC").
+		Find(&terms).Error
+	return terms, err
+}
+
+func (r *paymentTermRepository) ClearDefault(ctx context.Context, tenantID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&models.PaymentTerm{}).
+		Where("tenant_id = ? AND is_default = true", tenantID).
+		Update("is_default", false).Error
+}
+
+func (r *paymentTermRepository) GetCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerPaymentTerm, error) {
+	var term models.CustomerPaymentTerm
+	err := r.db.WithContext(ctx).
+		Preload("PaymentTerm").
+		First(&term, "tenant_id = ? AND customer_id = ?", tenantID, customerID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+func (r *paymentTermRepository) SaveCustomerTerm(ctx context.Context, term *models.CustomerPaymentTerm) error {
+	return r.db.WithContext(ctx).Omit("PaymentTerm").Save(term).Error
+}
+
+func (r *paymentTermRepository) DeleteCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Delete(&models.CustomerPaymentTerm{}, "tenant_id = ? AND customer_id = ?", tenantID, customerID).Error
+}
+
+func (r *paymentTermRepository) CountCustomerTerms(ctx context.Context, tenantID, paymentTermID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.CustomerPaymentTerm{}).
+		Where("tenant_id = ? AND payment_term_id = ?", tenantID, paymentTermID).
+		Count(&count).Error
+	return count, err
+}
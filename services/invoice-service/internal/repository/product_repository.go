@@ -4,10 +4,14 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"gorm.io/gorm"
 )
 
+// ProductsResource names the product list for conditional requests
+const ProductsResource = "products"
+
 // ProductFilters represents filters for product queries
 type ProductFilters struct {
 	Type       models.ProductType
@@ -29,6 +33,9 @@ type ProductRepository interface {
 	GetCategories(ctx context.Context, tenantID uuid.UUID) ([]string, error)
 	BulkCreate(ctx context.Context, products []models.Product) error
 	UpdateStock(ctx context.Context, productID uuid.UUID, quantity float64) error
+	// Touch invalidates cached reads of the tenant's products after changes
+	// made outside the product endpoints
+	Touch(ctx context.Context, tenantID uuid.UUID) error
 }
 
 type productRepository struct {
@@ -142,3 +149,7 @@ func (r *productRepository) UpdateStock(ctx context.Context, productID uuid.UUID
 		Where("id = ?", productID).
 		Update("current_stock", gorm.Expr("current_stock + ?", quantity)).Error
 }
+
+func (r *productRepository) Touch(ctx context.Context, tenantID uuid.UUID) error {
+	return database.BumpVersion(ctx, r.db, tenantID.String(), ProductsResource)
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+
+// PurchaseOrderFilters represents filters for listing purchase orders
+type PurchaseOrderFilters struct {
+	Status   string
+	VendorID uuid.UUID
+	FromDate string
+	ToDate   string
+	Page     int
+	Limit    int
+}
+
+// BilledQuantity is what has been billed against a purchase order line
+type BilledQuantity struct {
+	PurchaseOrderItemID uuid.UUID       `json:"purchase_order_item_id"`
+	Quantity            decimal.Decimal `json:"quantity"`
+	MaxRate             decimal.Decimal `json:"max_rate"` // Highest rate any bill charged
+}
+
+// PurchaseOrderRepository handles purchase order and goods receipt data
+// operations
+type PurchaseOrderRepository interface {
+	Create(ctx context.Context, po *models.PurchaseOrder) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters PurchaseOrderFilters) ([]models.PurchaseOrder, int64, error)
+	// Update saves an order, replacing its items
+	Update(ctx context.Context, po *models.PurchaseOrder) error
+	// Save saves an order's own fields without touching its items
+	Save(ctx context.Context, po *models.PurchaseOrder) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetNextPONumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	// ListOpen returns the issued orders not yet closed or cancelled, with
+	// their items, oldest first
+	ListOpen(ctx context.Context, tenantID, vendorID uuid.UUID) ([]models.PurchaseOrder, error)
+
+	// CreateReceipt saves a goods receipt and adds its quantities to the
+	// order's lines
+	CreateReceipt(ctx context.Context, receipt *models.GoodsReceipt) error
+	ListReceipts(ctx context.Context, tenantID, purchaseOrderID uuid.UUID) ([]models.GoodsReceipt, error)
+	GetNextReceiptNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+
+	// BilledQuantities totals what bills that are not cancelled charged on
+	// the lines of the orders, by order line
+	BilledQuantities(ctx context.Context, purchaseOrderIDs []uuid.UUID) (map[uuid.UUID]BilledQuantity, error)
+}
+
+type purchaseOrderRepository struct {
+	db *gorm.DB
+}
+
+// NewPurchaseOrderRepository creates a new purchase order repository
+func NewPurchaseOrderRepository(db *gorm.DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{db: db}
+}
+
+func (r *purchaseOrderRepository) Create(ctx context.Context, po *models.PurchaseOrder) error {
+	return r.db.WithContext(ctx).Create(po).Error
+}
+
+func (r *purchaseOrderRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		First(&po, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		return nil, err
+	}
+	return &po, nil
+}
+
+func (r *purchaseOrderRepository) List(ctx context.Context, tenantID uuid.UUID, filters PurchaseOrderFilters) ([]models.PurchaseOrder, int64, error) {
+	var orders []models.PurchaseOrder
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.PurchaseOrder{}).
+		Where("tenant_id = ?", tenantID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.VendorID != uuid.Nil {
+		query = query.Where("vendor_id = ?", filters.VendorID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("order_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("order_date <= ?", filters.ToDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filters.Page - 1) * filters.Limit
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		Offset(offset).
+		Limit(filters.Limit).
+		Order("order_date DESC, created_at DESC").
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
+func (r *purchaseOrderRepository) Update(ctx context.Context, po *models.PurchaseOrder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("purchase_order_id = ?", po.ID).Delete(&models.PurchaseOrderItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(po).Error
+	})
+}
+
+func (r *purchaseOrderRepository) Save(ctx context.Context, po *models.PurchaseOrder) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(po).Error
+}
+
+func (r *purchaseOrderRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.PurchaseOrder{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *purchaseOrderRepository) GetNextPONumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "purchase_order:"+prefix,
+		database.SeedFromExisting("purchase_orders", "po_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *purchaseOrderRepository) ListOpen(ctx context.Context, tenantID, vendorID uuid.UUID) ([]models.PurchaseOrder, error) {
+	query := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Where("status IN ?", []models.PurchaseOrderStatus{
+			models.PurchaseOrderStatusIssued,
+			models.PurchaseOrderStatusPartiallyReceived,
+			models.PurchaseOrderStatusReceived,
+		})
+	if vendorID != uuid.Nil {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+
+	var orders []models.PurchaseOrder
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		Order("order_date ASC, po_number ASC").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *purchaseOrderRepository) CreateReceipt(ctx context.Context, receipt *models.GoodsReceipt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+		for _, item := range receipt.Items {
+			err := tx.Model(&models.PurchaseOrderItem{}).
+				Where("id = ? AND purchase_order_id = ?", item.PurchaseOrderItemID, receipt.PurchaseOrderID).
+				Update("quantity_received", gorm.Expr("quantity_received + ?", item.Quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *purchaseOrderRepository) ListReceipts(ctx context.Context, tenantID, purchaseOrderID uuid.UUID) ([]models.GoodsReceipt, error) {
+	var receipts []models.GoodsReceipt
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("tenant_id = ? AND purchase_order_id = ?", tenantID, purchaseOrderID).
+		Order("received_date, created_at").
+		Find(&receipts).Error
+	return receipts, err
+}
+
+func (r *purchaseOrderRepository) GetNextReceiptNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "goods_receipt:"+prefix,
+		database.SeedFromExisting("goods_receipts", "receipt_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *purchaseOrderRepository) BilledQuantities(ctx context.Context, purchaseOrderIDs []uuid.UUID) (map[uuid.UUID]BilledQuantity, error) {
+	billed := make(map[uuid.UUID]BilledQuantity)
+	if len(purchaseOrderIDs) == 0 {
+		return billed, nil
+	}
+
+	var rows []BilledQuantity
+	err := r.db.WithContext(ctx).
+		Table("bill_items").
+		Select("bill_items.purchase_order_item_id, SUM(bill_items.quantity) AS quantity, MAX(bill_items.rate) AS max_rate").
+		Joins("JOIN bills ON bills.id = bill_items.bill_id").
+		Where("bills.purchase_order_id IN ? AND bills.deleted_at IS NULL AND bills.status <> ?", purchaseOrderIDs, models.BillStatusCancelled).
+		Where("bill_items.purchase_order_item_id IS NOT NULL").
+		Group("bill_items.purchase_order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		billed[row.PurchaseOrderItemID] = row
+	}
+	return billed, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQuoteNotFound  = errors.New("quote not found")
+	ErrQuoteConverted = errors.New("quote has already been converted to an invoice")
+)
+
+// QuoteFilters represents filters for listing quotes
+type QuoteFilters struct {
+	Status     string
+	CustomerID uuid.UUID
+	FromDate   string
+	ToDate     string
+	Page       int
+	Limit      int
+}
+
+// QuoteRepository handles quote data operations
+type QuoteRepository interface {
+	Create(ctx context.Context, quote *models.Quote) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.Quote, error)
+	// GetByTokenHash returns the quote a customer's accept link is for
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.Quote, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters QuoteFilters) ([]models.Quote, int64, error)
+	// Update saves a quote, replacing its items
+	Update(ctx context.Context, quote *models.Quote) error
+	// Save saves a quote's own fields without touching its items
+	Save(ctx context.Context, quote *models.Quote) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetNextQuoteNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+	// MarkConverted records the invoice a quote was converted to, unless it
+	// already has one
+	MarkConverted(ctx context.Context, quote *models.Quote) error
+	// ExpireLapsed marks sent quotes valid until before the date expired
+	// and returns how many were
+	ExpireLapsed(ctx context.Context, before time.Time) (int64, error)
+}
+
+type quoteRepository struct {
+	db *gorm.DB
+}
+
+// NewQuoteRepository creates a new quote repository
+func NewQuoteRepository(db *gorm.DB) QuoteRepository {
+	return &quoteRepository{db: db}
+}
+
+func (r *quoteRepository) Create(ctx context.Context, quote *models.Quote) error {
+	return r.db.WithContext(ctx).Create(quote).Error
+}
+
+func (r *quoteRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.Quote, error) {
+	return r.get(ctx, "tenant_id = ? AND id = ?", tenantID, id)
+}
+
+func (r *quoteRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Quote, error) {
+	return r.get(ctx, "token_hash = ?", tokenHash)
+}
+
+func (r *quoteRepository) get(ctx context.Context, query string, args ...interface{}) (*models.Quote, error) {
+	var quote models.Quote
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		Where(query, args...).
+		First(&quote).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *quoteRepository) List(ctx context.Context, tenantID uuid.UUID, filters QuoteFilters) ([]models.Quote, int64, error) {
+	var quotes []models.Quote
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Quote{}).
+		Where("tenant_id = ?", tenantID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.CustomerID != uuid.Nil {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.FromDate != "" {
+		query = query.Where("quote_date >= ?", filters.FromDate)
+	}
+	if filters.ToDate != "" {
+		query = query.Where("quote_date <= ?", filters.ToDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filters.Page - 1) * filters.Limit
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		Offset(offset).
+		Limit(filters.Limit).
+		Order("quote_date DESC, created_at DESC").
+		Find(&quotes).Error
+
+	return quotes, total, err
+}
+
+func (r *quoteRepository) Update(ctx context.Context, quote *models.Quote) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("quote_id = ?", quote.ID).Delete(&models.QuoteItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(quote).Error
+	})
+}
+
+func (r *quoteRepository) Save(ctx context.Context, quote *models.Quote) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(quote).Error
+}
+
+func (r *quoteRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Quote{}, "tenant_id = ? AND id = ?", tenantID, id).Error
+}
+
+func (r *quoteRepository) GetNextQuoteNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "quote:"+prefix,
+		database.SeedFromExisting("quotes", "quote_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
+
+func (r *quoteRepository) MarkConverted(ctx context.Context, quote *models.Quote) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Quote{}).
+		Where("id = ? AND invoice_id IS NULL", quote.ID).
+		Updates(map[string]interface{}{
+			"invoice_id":     quote.InvoiceID,
+			"invoice_number": quote.InvoiceNumber,
+			"converted_at":   quote.ConvertedAt,
+			"status":         quote.Status,
+			"responded_at":   quote.RespondedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuoteConverted
+	}
+	return nil
+}
+
+func (r *quoteRepository) ExpireLapsed(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Quote{}).
+		Where("status = ? AND valid_until < ?", models.QuoteStatusSent, before.Format("2006-01-02")).
+		Update("status", models.QuoteStatusExpired)
+	return result.RowsAffected, result.Error
+}
@@ -13,6 +13,7 @@ import (
 type RecurringInvoiceFilters struct {
 	Status     models.RecurringInvoiceStatus
 	CustomerID uuid.UUID
+	ContractID uuid.UUID
 	Search     string
 	Page       int
 	Limit      int
@@ -84,6 +85,10 @@ func (r *recurringInvoiceRepository) List(ctx context.Context, tenantID uuid.UUI
 		query = query.Where("customer_id = ?", filters.CustomerID)
 	}
 
+	if filters.ContractID != uuid.Nil {
+		query = query.Where("contract_id = ?", filters.ContractID)
+	}
+
 	if filters.Search != "" {
 		search := "%" + filters.Search + "%"
 		query = query.Where("name ILIKE ? OR customer_name ILIKE ?", search, search)
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// RoundingRuleRepository handles rounding rule data operations
+type RoundingRuleRepository interface {
+	GetByDocumentType(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType) (*models.RoundingRule, error)
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.RoundingRule, error)
+	Save(ctx context.Context, rule *models.RoundingRule) error
+}
+
+type roundingRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoundingRuleRepository creates a new rounding rule repository
+func NewRoundingRuleRepository(db *gorm.DB) RoundingRuleRepository {
+	return &roundingRuleRepository{db: db}
+}
+
+func (r *roundingRuleRepository) GetByDocumentType(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType) (*models.RoundingRule, error) {
+	var rule models.RoundingRule
+	err := r.db.WithContext(ctx).
+		First(&rule, "tenant_id = ? AND document_type = ?", tenantID, docType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *roundingRuleRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.RoundingRule, error) {
+	var rules []models.RoundingRule
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("document_type ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *roundingRuleRepository) Save(ctx context.Context, rule *models.RoundingRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrBillSelfInvoiced is returned when a self-invoice already exists for
+// the bill
+var ErrBillSelfInvoiced = errors.New("bill already has a self-invoice")
+
+// SelfInvoiceRepository handles self-invoice data operations
+type SelfInvoiceRepository interface {
+	// Create saves a self-invoice and links it to its bill, provided the bill
+	// has no self-invoice yet
+	Create(ctx context.Context, invoice *models.SelfInvoice) error
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.SelfInvoice, error)
+	List(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.SelfInvoice, error)
+	GetNextNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error)
+}
+
+type selfInvoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewSelfInvoiceRepository creates a new self-invoice repository
+func NewSelfInvoiceRepository(db *gorm.DB) SelfInvoiceRepository {
+	return &selfInvoiceRepository{db: db}
+}
+
+func (r *selfInvoiceRepository) Create(ctx context.Context, invoice *models.SelfInvoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Bill{}).
+			Where("id = ? AND self_invoice_id IS NULL", invoice.BillID).
+			Updates(map[string]interface{}{
+				"self_invoice_id":     invoice.ID,
+				"self_invoice_number": invoice.InvoiceNumber,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrBillSelfInvoiced
+		}
+		return tx.Create(invoice).Error
+	})
+}
+
+func (r *selfInvoiceRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.SelfInvoice, error) {
+	var invoice models.SelfInvoice
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		First(&invoice, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// List returns the self-invoices dated within [from, to]
+func (r *selfInvoiceRepository) List(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.SelfInvoice, error) {
+	var invoices []models.SelfInvoice
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Where("tenant_id = ? AND invoice_date >= ? AND invoice_date <= ?", tenantID, from, to).
+		Order("invoice_date, invoice_number").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *selfInvoiceRepository) GetNextNumber(ctx context.Context, tenantID uuid.UUID, prefix string) (string, error) {
+	next, err := database.NextNumber(ctx, r.db, tenantID, "self_invoice:"+prefix,
+		database.SeedFromExisting("self_invoices", "invoice_number", tenantID, prefix))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%05d", prefix, next), nil
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CustomerBalanceRow is a customer's receivable balance and where to send
+// their statement
+type CustomerBalanceRow struct {
+	CustomerID    uuid.UUID
+	CustomerName  string
+	CustomerEmail string
+	Language      string
+	Balance       decimal.Decimal
+}
+
+// CustomerLedgerRow is an invoice, payment or credit note on a customer's
+// account
+type CustomerLedgerRow struct {
+	Date        time.Time
+	Kind        string // invoice, payment or credit_note
+	Number      string
+	Particulars string
+	Debit       decimal.Decimal
+	Credit      decimal.Decimal
+}
+
+// customerLedger lists every issued invoice as a debit and every good
+// payment (with the discount allowed) and issued credit note as a credit on
+// the customer's account
+const customerLedger = `
+	WITH ledger AS (
+		SELECT customer_id, invoice_date::date AS date, 'invoice' AS kind, invoice_number AS number,
+			'Sales' AS particulars, total_amount AS debit, 0 AS credit, created_at
+		FROM invoices
+		WHERE tenant_id = @tenant AND status NOT IN ('draft', 'cancelled') AND deleted_at IS NULL
+		UNION ALL
+		SELECT i.customer_id, p.payment_date::date, 'payment', COALESCE(NULLIF(p.payment_number, ''), i.invoice_number),
+			'Payment against ' || i.invoice_number, 0, p.amount + COALESCE(p.discount, 0), p.created_at
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.tenant_id = @tenant AND p.bounced_at IS NULL AND p.deleted_at IS NULL AND i.deleted_at IS NULL
+		UNION ALL
+		SELECT customer_id, credit_note_date::date, 'credit_note', credit_note_number,
+			CASE WHEN invoice_number <> '' THEN 'Credit note against ' || invoice_number ELSE 'Credit note' END,
+			0, total_amount, created_at
+		FROM credit_notes
+		WHERE tenant_id = @tenant AND status NOT IN ('draft', 'cancelled') AND deleted_at IS NULL
+	)`
+
+// StatementRepository handles statement runs, their deliveries and the
+// customer ledgers statements are built from
+type StatementRepository interface {
+	CreateRun(ctx context.Context, run *models.StatementRun) error
+	UpdateRun(ctx context.Context, run *models.StatementRun) error
+	GetRun(ctx context.Context, tenantID, id uuid.UUID) (*models.StatementRun, error)
+	ListRuns(ctx context.Context, tenantID uuid.UUID) ([]models.StatementRun, error)
+
+	// GetRunByID returns a run of any tenant, for the job that sends it
+	GetRunByID(ctx context.Context, id uuid.UUID) (*models.StatementRun, error)
+
+	// HasActiveRun reports whether the tenant has a run queued or running
+	HasActiveRun(ctx context.Context, tenantID uuid.UUID) (bool, error)
+
+	// CreateDeliveries adds the deliveries of a run, leaving any customer
+	// already in it alone
+	CreateDeliveries(ctx context.Context, deliveries []models.StatementDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.StatementDelivery) error
+	ListDeliveries(ctx context.Context, runID uuid.UUID, statuses []string) ([]models.StatementDelivery, error)
+
+	// CountDeliveries returns the number of deliveries of a run by status
+	CountDeliveries(ctx context.Context, runID uuid.UUID) (map[string]int, error)
+
+	// GetTenantName returns the name the tenant trades under
+	GetTenantName(ctx context.Context, tenantID uuid.UUID) (string, error)
+
+	// ListCustomerBalances returns the customers whose balance as of asOf is
+	// positive and at least minBalance, with the contact details of their
+	// latest invoice
+	ListCustomerBalances(ctx context.Context, tenantID uuid.UUID, asOf time.Time, minBalance decimal.Decimal) ([]CustomerBalanceRow, error)
+
+	// GetCustomerBalance returns a customer's balance before the given date
+	GetCustomerBalance(ctx context.Context, tenantID, customerID uuid.UUID, before time.Time) (decimal.Decimal, error)
+
+	// ListCustomerLedger returns a customer's ledger entries dated from
+	// from to to, oldest first
+	ListCustomerLedger(ctx context.Context, tenantID, customerID uuid.UUID, from, to time.Time) ([]CustomerLedgerRow, error)
+}
+
+type statementRepository struct {
+	db *gorm.DB
+}
+
+// NewStatementRepository creates a new statement repository
+func NewStatementRepository(db *gorm.DB) StatementRepository {
+	return &statementRepository{db: db}
+}
+
+func (r *statementRepository) CreateRun(ctx context.Context, run *models.StatementRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *statementRepository) UpdateRun(ctx context.Context, run *models.StatementRun) error {
+	return r.db.WithContext(ctx).Omit("Deliveries").Save(run).Error
+}
+
+func (r *statementRepository) GetRun(ctx context.Context, tenantID, id uuid.UUID) (*models.StatementRun, error) {
+	var run models.StatementRun
+	err := r.db.WithContext(ctx).
+		Preload("Deliveries", func(db *gorm.DB) *gorm.DB {
+			return db.Order("customer_name")
+		}).
+		First(&run, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *statementRepository) ListRuns(ctx context.Context, tenantID uuid.UUID) ([]models.StatementRun, error) {
+	var runs []models.StatementRun
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&runs).Error
+	return runs, err
+}
+
+func (r *statementRepository) GetRunByID(ctx context.Context, id uuid.UUID) (*models.StatementRun, error) {
+	var run models.StatementRun
+	if err := r.db.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *statementRepository) HasActiveRun(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.StatementRun{}).
+		Where("tenant_id = ? AND status IN ?", tenantID, []string{models.StatementRunQueued, models.StatementRunRunning}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *statementRepository) CreateDeliveries(ctx context.Context, deliveries []models.StatementDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&deliveries, 500).Error
+}
+
+func (r *statementRepository) UpdateDelivery(ctx context.Context, delivery *models.StatementDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *statementRepository) ListDeliveries(ctx context.Context, runID uuid.UUID, statuses []string) ([]models.StatementDelivery, error) {
+	var deliveries []models.StatementDelivery
+
+	query := r.db.WithContext(ctx).Where("run_id = ?", runID)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	err := query.Order("customer_name").Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *statementRepository) CountDeliveries(ctx context.Context, runID uuid.UUID) (map[string]int, error) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.StatementDelivery{}).
+		Select("status, COUNT(*) AS count").
+		Where("run_id = ?", runID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *statementRepository) GetTenantName(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	var name string
+	err := r.db.WithContext(ctx).
+		Raw("SELECT COALESCE(NULLIF(legal_name, ''), name) FROM tenants WHERE id = ?", tenantID).
+		Scan(&name).Error
+	return name, err
+}
+
+func (r *statementRepository) ListCustomerBalances(ctx context.Context, tenantID uuid.UUID, asOf time.Time, minBalance decimal.Decimal) ([]CustomerBalanceRow, error) {
+	// Contact details come from the customer's latest invoice that has an
+	// email address, or their latest invoice when none has
+	var rows []CustomerBalanceRow
+	err := r.db.WithContext(ctx).Raw(customerLedger+`, balances AS (
+			SELECT customer_id, SUM(debit - credit) AS balance
+			FROM ledger
+			WHERE date <= CAST(@as_of AS date)
+			GROUP BY customer_id
+		)
+		SELECT b.customer_id, c.customer_name, c.customer_email, c.language, b.balance
+		FROM balances b
+		LEFT JOIN LATERAL (
+			SELECT customer_name, customer_email, language
+			FROM invoices i
+			WHERE i.tenant_id = @tenant AND i.customer_id = b.customer_id AND i.deleted_at IS NULL
+			ORDER BY (i.customer_email <> '') DESC, i.invoice_date DESC
+			LIMIT 1
+		) c ON true
+		WHERE b.balance > 0 AND b.balance >= @min_balance
+		ORDER BY c.customer_name
+	`, map[string]interface{}{
+		"tenant":      tenantID,
+		"as_of":       asOf.Format("2006-01-02"),
+		"min_balance": minBalance,
+	}).Scan(&rows).Error
+	return rows, err
+}
+
+func (r *statementRepository) GetCustomerBalance(ctx context.Context, tenantID, customerID uuid.UUID, before time.Time) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := r.db.WithContext(ctx).Raw(customerLedger+`
+		SELECT COALESCE(SUM(debit - credit), 0)
+		FROM ledger
+		WHERE customer_id = @customer AND date < CAST(@before AS date)
+	`, map[string]interface{}{
+		"tenant":   tenantID,
+		"customer": customerID,
+		"before":   before.Format("2006-01-02"),
+	}).Scan(&balance).Error
+	return balance, err
+}
+
+func (r *statementRepository) ListCustomerLedger(ctx context.Context, tenantID, customerID uuid.UUID, from, to time.Time) ([]CustomerLedgerRow, error) {
+	var rows []CustomerLedgerRow
+	err := r.db.WithContext(ctx).Raw(customerLedger+`
+		SELECT date, kind, number, particulars, debit, credit
+		FROM ledger
+		WHERE customer_id = @customer
+		AND date >= CAST(@from AS date) AND date <= CAST(@to AS date)
+		ORDER BY date, created_at
+	`, map[string]interface{}{
+		"tenant":   tenantID,
+		"customer": customerID,
+		"from":     from.Format("2006-01-02"),
+		"to":       to.Format("2006-01-02"),
+	}).Scan(&rows).Error
+	return rows, err
+}
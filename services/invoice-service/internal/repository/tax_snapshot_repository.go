@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// TaxSnapshotRepository handles tax snapshot data operations. Snapshots are
+// never updated once written.
+type TaxSnapshotRepository interface {
+	Create(ctx context.Context, snapshot *models.TaxSnapshot) error
+	GetByDocument(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType, docID uuid.UUID) (*models.TaxSnapshot, error)
+}
+
+type taxSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewTaxSnapshotRepository creates a new tax snapshot repository
+func NewTaxSnapshotRepository(db *gorm.DB) TaxSnapshotRepository {
+	return &taxSnapshotRepository{db: db}
+}
+
+func (r *taxSnapshotRepository) Create(ctx context.Context, snapshot *models.TaxSnapshot) error {
+	return r.db.WithContext(ctx).Create(snapshot).Error
+}
+
+func (r *taxSnapshotRepository) GetByDocument(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType, docID uuid.UUID) (*models.TaxSnapshot, error) {
+	var snapshot models.TaxSnapshot
+	err := r.db.WithContext(ctx).
+		First(&snapshot, "tenant_id = ? AND document_type = ? AND document_id = ?", tenantID, docType, docID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrAdvanceNotFound    = errors.New("advance receipt not found")
+	ErrInvalidAdvance     = errors.New("invalid advance receipt data")
+	ErrInvalidRefund      = errors.New("refund must be more than zero, no more than the advance's balance and dated on or after the advance")
+	ErrAdvanceRefunded    = errors.New("advance has already been refunded in full")
+	ErrAdvanceRefundRaced = errors.New("advance was refunded by someone else meanwhile; review it and try again")
+)
+
+var stateCodeRegex = regexp.MustCompile(`^[0-9]{2}$`)
+
+// CreateAdvanceReceiptRequest records an advance received from a customer.
+// Rates are those of the supply the advance is for; leave them zero for
+// advances for goods, which carry no GST when received.
+type CreateAdvanceReceiptRequest struct {
+	TenantID      uuid.UUID       `json:"-"`
+	CreatedBy     uuid.UUID       `json:"-"`
+	Authorization string          `json:"-"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	CustomerName  string          `json:"customer_name" binding:"required"`
+	CustomerGSTIN string          `json:"customer_gstin"`
+	PlaceOfSupply string          `json:"place_of_supply" binding:"required"` // State code
+	ReceiptDate   string          `json:"receipt_date" binding:"required"`
+	Description   string          `json:"description"`
+	Amount        decimal.Decimal `json:"amount" binding:"required"` // Including GST
+	CGSTRate      decimal.Decimal `json:"cgst_rate"`
+	SGSTRate      decimal.Decimal `json:"sgst_rate"`
+	IGSTRate      decimal.Decimal `json:"igst_rate"`
+	CessRate      decimal.Decimal `json:"cess_rate"`
+	PaymentMethod string          `json:"payment_method" binding:"required"`
+	Reference     string          `json:"reference"`
+}
+
+// RefundAdvanceRequest refunds all or part of an advance's balance
+type RefundAdvanceRequest struct {
+	TenantID      uuid.UUID       `json:"-"`
+	CreatedBy     uuid.UUID       `json:"-"`
+	Authorization string          `json:"-"`
+	VoucherDate   string          `json:"voucher_date" binding:"required"`
+	Amount        decimal.Decimal `json:"amount" binding:"required"` // Including GST
+	Reason        string          `json:"reason" binding:"required"`
+	PaymentMethod string          `json:"payment_method" binding:"required"`
+	Reference     string          `json:"reference"`
+}
+
+// AdvanceTaxRow totals advances for one place of supply and rate, in the
+// shape of GSTR-1 tables 11A and 11B
+type AdvanceTaxRow struct {
+	PlaceOfSupply string          `json:"pos"`
+	Rate          decimal.Decimal `json:"rt"`
+	Taxable       decimal.Decimal `json:"ad_amt"`
+	IGST          decimal.Decimal `json:"iamt"`
+	CGST          decimal.Decimal `json:"camt"`
+	SGST          decimal.Decimal `json:"samt"`
+	Cess          decimal.Decimal `json:"csamt"`
+}
+
+// AdvanceTax is the tax on advances for a return period. Advances received
+// go in GSTR-1 table 11A, net of refunds made in the same period; refunds
+// of earlier periods' advances go in table 11B. Net is what the advances
+// add to the period's output tax in GSTR-3B, and is negative when refunds
+// outweigh new advances.
+type AdvanceTax struct {
+	Period   string          `json:"period"`
+	Received []AdvanceTaxRow `json:"at"`
+	Refunded []AdvanceTaxRow `json:"txpd"`
+	Net      AdvanceTaxRow   `json:"net"`
+}
+
+// AdvanceService handles advance receipts and their refund vouchers
+type AdvanceService interface {
+	Create(ctx context.Context, req CreateAdvanceReceiptRequest) (*models.AdvanceReceipt, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.AdvanceReceipt, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.AdvanceFilters) ([]models.AdvanceReceipt, error)
+	Refund(ctx context.Context, id uuid.UUID, req RefundAdvanceRequest) (*models.RefundVoucher, error)
+	ReturnSummary(ctx context.Context, tenantID uuid.UUID, period string) (*AdvanceTax, error)
+}
+
+type advanceService struct {
+	repo              repository.AdvanceRepository
+	bookkeepingClient clients.BookkeepingClient
+}
+
+// NewAdvanceService creates a new advance service
+func NewAdvanceService(repo repository.AdvanceRepository, bookkeepingClient clients.BookkeepingClient) AdvanceService {
+	return &advanceService{repo: repo, bookkeepingClient: bookkeepingClient}
+}
+
+func (s *advanceService) Create(ctx context.Context, req CreateAdvanceReceiptRequest) (*models.AdvanceReceipt, error) {
+	receiptDate, err := time.Parse("2006-01-02", req.ReceiptDate)
+	if err != nil {
+		return nil, ErrInvalidAdvance
+	}
+	if !req.Amount.IsPositive() || !stateCodeRegex.MatchString(req.PlaceOfSupply) {
+		return nil, ErrInvalidAdvance
+	}
+	// Intra-state advances carry equal CGST and SGST, inter-state ones IGST
+	if !req.CGSTRate.Equal(req.SGSTRate) || (req.CGSTRate.IsPositive() && req.IGSTRate.IsPositive()) ||
+		req.CGSTRate.IsNegative() || req.IGSTRate.IsNegative() || req.CessRate.IsNegative() {
+		return nil, ErrInvalidAdvance
+	}
+
+	number, err := s.repo.GetNextNumber(ctx, req.TenantID, "advance_receipts", "receipt_number",
+		fmt.Sprintf("ADV-%s", receiptDate.Format("0601")))
+	if err != nil {
+		return nil, err
+	}
+
+	advance := &models.AdvanceReceipt{
+		ID:            uuid.New(),
+		TenantID:      req.TenantID,
+		ReceiptNumber: number,
+		ReceiptDate:   receiptDate,
+		Status:        models.AdvanceReceiptStatusOpen,
+		CustomerID:    req.CustomerID,
+		CustomerName:  req.CustomerName,
+		CustomerGSTIN: req.CustomerGSTIN,
+		PlaceOfSupply: req.PlaceOfSupply,
+		Description:   req.Description,
+		Amount:        req.Amount.Round(2),
+		CGSTRate:      req.CGSTRate,
+		SGSTRate:      req.SGSTRate,
+		IGSTRate:      req.IGSTRate,
+		CessRate:      req.CessRate,
+		PaymentMethod: req.PaymentMethod,
+		Reference:     req.Reference,
+		CreatedBy:     req.CreatedBy,
+	}
+	advance.CalculateTax()
+
+	if err := s.post(ctx, req.Authorization, advance, clients.CustomerAdvancePosting{
+		Kind:             "receipt",
+		Date:             req.ReceiptDate,
+		DocumentID:       &advance.ID,
+		DocumentNumber:   advance.ReceiptNumber,
+		Amount:           advance.Amount.InexactFloat64(),
+		TaxAmount:        advance.TotalTax.InexactFloat64(),
+		PaymentMode:      req.PaymentMethod,
+		PaymentReference: req.Reference,
+		Notes:            req.Description,
+		SupportingDetails: advanceSupportingDetails(advance, advance.Amount, advance.TaxableAmount,
+			[4]decimal.Decimal{advance.CGSTAmount, advance.SGSTAmount, advance.IGSTAmount, advance.CessAmount},
+			clients.SupportingDocument{Type: "advance_receipt", ID: &advance.ID, Number: advance.ReceiptNumber}),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, advance); err != nil {
+		return nil, err
+	}
+	return advance, nil
+}
+
+func (s *advanceService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.AdvanceReceipt, error) {
+	advance, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrAdvanceNotFound
+	}
+	return advance, nil
+}
+
+func (s *advanceService) List(ctx context.Context, tenantID uuid.UUID, filters repository.AdvanceFilters) ([]models.AdvanceReceipt, error) {
+	return s.repo.List(ctx, tenantID, filters)
+}
+
+// Refund issues a refund voucher against an advance, reverses the GST paid
+// on the refunded part and posts the refund to the ledger
+func (s *advanceService) Refund(ctx context.Context, id uuid.UUID, req RefundAdvanceRequest) (*models.RefundVoucher, error) {
+	advance, err := s.repo.GetByID(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrAdvanceNotFound
+	}
+	if advance.Status == models.AdvanceReceiptStatusRefunded {
+		return nil, ErrAdvanceRefunded
+	}
+
+	voucherDate, err := time.Parse("2006-01-02", req.VoucherDate)
+	if err != nil || voucherDate.Before(advance.ReceiptDate) {
+		return nil, ErrInvalidRefund
+	}
+	amount := req.Amount.Round(2)
+	if !amount.IsPositive() || amount.GreaterThan(advance.Balance) {
+		return nil, ErrInvalidRefund
+	}
+
+	number, err := s.repo.GetNextNumber(ctx, req.TenantID, "refund_vouchers", "voucher_number",
+		fmt.Sprintf("RFV-%s", voucherDate.Format("0601")))
+	if err != nil {
+		return nil, err
+	}
+
+	voucher := &models.RefundVoucher{
+		ID:               uuid.New(),
+		TenantID:         req.TenantID,
+		VoucherNumber:    number,
+		VoucherDate:      voucherDate,
+		AdvanceReceiptID: advance.ID,
+		ReceiptNumber:    advance.ReceiptNumber,
+		ReceiptDate:      advance.ReceiptDate,
+		CustomerID:       advance.CustomerID,
+		CustomerName:     advance.CustomerName,
+		CustomerGSTIN:    advance.CustomerGSTIN,
+		PlaceOfSupply:    advance.PlaceOfSupply,
+		Amount:           amount,
+		Reason:           req.Reason,
+		PaymentMethod:    req.PaymentMethod,
+		Reference:        req.Reference,
+		CreatedBy:        req.CreatedBy,
+	}
+	voucher.CalculateTax(advance)
+
+	refundedBefore := advance.AmountRefunded
+	advance.AmountRefunded = advance.AmountRefunded.Add(amount)
+	advance.CalculateTax()
+	if !advance.Balance.IsPositive() {
+		advance.Status = models.AdvanceReceiptStatusRefunded
+	}
+
+	if err := s.post(ctx, req.Authorization, advance, clients.CustomerAdvancePosting{
+		Kind:             "refund",
+		Date:             req.VoucherDate,
+		DocumentID:       &voucher.ID,
+		DocumentNumber:   voucher.VoucherNumber,
+		Amount:           voucher.Amount.InexactFloat64(),
+		TaxAmount:        voucher.TotalTax.InexactFloat64(),
+		PaymentMode:      req.PaymentMethod,
+		PaymentReference: req.Reference,
+		Notes:            "Refund of advance " + advance.ReceiptNumber + ": " + req.Reason,
+		SupportingDetails: advanceSupportingDetails(advance, voucher.Amount, voucher.TaxableAmount,
+			[4]decimal.Decimal{voucher.CGSTAmount, voucher.SGSTAmount, voucher.IGSTAmount, voucher.CessAmount},
+			clients.SupportingDocument{Type: "refund_voucher", ID: &voucher.ID, Number: voucher.VoucherNumber},
+			clients.SupportingDocument{Type: "advance_receipt", ID: &advance.ID, Number: advance.ReceiptNumber}),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRefund(ctx, advance, refundedBefore, voucher); err != nil {
+		if err == repository.ErrAdvanceChanged {
+			return nil, ErrAdvanceRefundRaced
+		}
+		return nil, err
+	}
+	return voucher, nil
+}
+
+func (s *advanceService) post(ctx context.Context, authorization string, advance *models.AdvanceReceipt, posting clients.CustomerAdvancePosting) error {
+	if advance.CustomerID != uuid.Nil {
+		posting.CustomerID = &advance.CustomerID
+	}
+	posting.CustomerName = advance.CustomerName
+	if err := s.bookkeepingClient.PostCustomerAdvance(ctx, authorization, posting); err != nil {
+		return ErrLedgerUnavailable
+	}
+	return nil
+}
+
+// advanceSupportingDetails describes how the GST included in an advance or
+// its refund was worked out: at the advance's rates on the amount less tax,
+// each part rounded to the paisa and CGST and SGST split evenly from their
+// total
+func advanceSupportingDetails(advance *models.AdvanceReceipt, amount, taxable decimal.Decimal, tax [4]decimal.Decimal, documents ...clients.SupportingDocument) *clients.SupportingDetails {
+	details := &clients.SupportingDetails{
+		Calculation:     "advance_gst",
+		SourceDocuments: documents,
+		Figures: map[string]float64{
+			"amount":         amount.InexactFloat64(),
+			"taxable_amount": taxable.InexactFloat64(),
+			"total_rate":     advance.TotalRate().InexactFloat64(),
+		},
+		Notes: "Tax-inclusive amount: taxable = amount x 100 / (100 + total rate); place of supply " + advance.PlaceOfSupply,
+	}
+	rates := [4]decimal.Decimal{advance.CGSTRate, advance.SGSTRate, advance.IGSTRate, advance.CessRate}
+	for i, name := range [4]string{"CGST", "SGST", "IGST", "Cess"} {
+		if !rates[i].IsPositive() {
+			continue
+		}
+		details.Rates = append(details.Rates, clients.SupportingRate{
+			Name:   name,
+			Rate:   rates[i].InexactFloat64(),
+			Base:   taxable.InexactFloat64(),
+			Amount: tax[i].InexactFloat64(),
+		})
+	}
+	if advance.CGSTRate.IsPositive() {
+		// CGST is half the total rounded down; SGST takes the remainder
+		half := tax[0].Add(tax[1]).Div(decimal.NewFromInt(2))
+		details.Rounding = append(details.Rounding, clients.SupportingRounding{
+			Mode:       string(models.RoundingModeDown),
+			RoundTo:    0.01,
+			Unrounded:  half.InexactFloat64(),
+			Rounded:    tax[0].InexactFloat64(),
+			Difference: tax[0].Sub(half).InexactFloat64(),
+		})
+	}
+	return details
+}
+
+// ReturnSummary totals the tax on advances received and refunded in a
+// return period (MMYYYY) for GSTR-1 and GSTR-3B
+func (s *advanceService) ReturnSummary(ctx context.Context, tenantID uuid.UUID, period string) (*AdvanceTax, error) {
+	start, err := time.Parse("012006", period)
+	if err != nil {
+		return nil, ErrInvalidPeriod
+	}
+	end := start.AddDate(0, 1, -1)
+
+	advances, err := s.repo.List(ctx, tenantID, repository.AdvanceFilters{
+		FromDate: start.Format("2006-01-02"),
+		ToDate:   end.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.repo.ListRefunds(ctx, tenantID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	received := advanceTaxRows{}
+	refunded := advanceTaxRows{}
+	thisPeriod := map[uuid.UUID]*models.AdvanceReceipt{}
+	earlier := map[uuid.UUID]*models.AdvanceReceipt{}
+	for i := range advances {
+		advance := &advances[i]
+		thisPeriod[advance.ID] = advance
+		received.add(advance, advance.TaxableAmount, advance.IGSTAmount, advance.CGSTAmount, advance.SGSTAmount, advance.CessAmount, false)
+	}
+	for _, refund := range refunds {
+		if advance, ok := thisPeriod[refund.AdvanceReceiptID]; ok {
+			// Received this period, so reported net of the refund
+			received.add(advance, refund.TaxableAmount, refund.IGSTAmount, refund.CGSTAmount, refund.SGSTAmount, refund.CessAmount, true)
+			continue
+		}
+		advance, ok := earlier[refund.AdvanceReceiptID]
+		if !ok {
+			if advance, err = s.repo.GetByID(ctx, tenantID, refund.AdvanceReceiptID); err != nil {
+				return nil, err
+			}
+			earlier[advance.ID] = advance
+		}
+		refunded.add(advance, refund.TaxableAmount, refund.IGSTAmount, refund.CGSTAmount, refund.SGSTAmount, refund.CessAmount, false)
+	}
+
+	summary := &AdvanceTax{
+		Period:   period,
+		Received: received.sorted(),
+		Refunded: refunded.sorted(),
+	}
+	for _, row := range summary.Received {
+		summary.Net.add(row, false)
+	}
+	for _, row := range summary.Refunded {
+		summary.Net.add(row, true)
+	}
+	return summary, nil
+}
+
+// advanceTaxRows totals advances by place of supply and rate
+type advanceTaxRows map[string]*AdvanceTaxRow
+
+func (rows advanceTaxRows) add(advance *models.AdvanceReceipt, taxable, igst, cgst, sgst, cess decimal.Decimal, subtract bool) {
+	rate := advance.CGSTRate.Add(advance.SGSTRate).Add(advance.IGSTRate)
+	key := advance.PlaceOfSupply + "|" + rate.String()
+	row, ok := rows[key]
+	if !ok {
+		row = &AdvanceTaxRow{PlaceOfSupply: advance.PlaceOfSupply, Rate: rate}
+		rows[key] = row
+	}
+	row.add(AdvanceTaxRow{Taxable: taxable, IGST: igst, CGST: cgst, SGST: sgst, Cess: cess}, subtract)
+}
+
+func (rows advanceTaxRows) sorted() []AdvanceTaxRow {
+	sorted := make([]AdvanceTaxRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, *row)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].PlaceOfSupply != sorted[b].PlaceOfSupply {
+			return sorted[a].PlaceOfSupply < sorted[b].PlaceOfSupply
+		}
+		return sorted[a].Rate.LessThan(sorted[b].Rate)
+	})
+	return sorted
+}
+
+func (r *AdvanceTaxRow) add(other AdvanceTaxRow, subtract bool) {
+	if subtract {
+		other.Taxable, other.IGST, other.CGST, other.SGST, other.Cess =
+			other.Taxable.Neg(), other.IGST.Neg(), other.CGST.Neg(), other.SGST.Neg(), other.Cess.Neg()
+	}
+	r.Taxable = r.Taxable.Add(other.Taxable)
+	r.IGST = r.IGST.Add(other.IGST)
+	r.CGST = r.CGST.Add(other.CGST)
+	r.SGST = r.SGST.Add(other.SGST)
+	r.Cess = r.Cess.Add(other.Cess)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var ErrInvalidBillMatchSettings = errors.New("tolerance percentages must be between 0 and 100")
+
+// Exceptions the bill match report raises
+const (
+	BillMatchPaymentExceedsBill = "payment_exceeds_bill"
+	// A purchase order line billed for more than was received on it
+	BillMatchQuantityExceedsReceipt = "quantity_exceeds_receipt"
+	// A bill line charging more than the purchase order's rate
+	BillMatchRateExceedsOrder = "rate_exceeds_order"
+)
+
+// UpdateBillMatchSettingsRequest configures the bill match tolerances
+type UpdateBillMatchSettingsRequest struct {
+	PaymentTolerancePct  decimal.Decimal `json:"payment_tolerance_pct"`
+	QuantityTolerancePct decimal.Decimal `json:"quantity_tolerance_pct"`
+	RateTolerancePct     decimal.Decimal `json:"rate_tolerance_pct"`
+}
+
+// BillMatchException is a bill the match report flags, with what was
+// expected, what was found and the variance beyond it
+type BillMatchException struct {
+	Type        string          `json:"type"`
+	BillID      uuid.UUID       `json:"bill_id"`
+	BillNumber  string          `json:"bill_number"`
+	BillDate    string          `json:"bill_date"`
+	VendorID    uuid.UUID       `json:"vendor_id"`
+	VendorName  string          `json:"vendor_name"`
+	Expected    decimal.Decimal `json:"expected"`
+	Actual      decimal.Decimal `json:"actual"`
+	Variance    decimal.Decimal `json:"variance"`
+	VariancePct decimal.Decimal `json:"variance_pct"`
+
+	// The purchase order line, for the three-way match exceptions
+	PurchaseOrderNumber string `json:"purchase_order_number,omitempty"`
+	Description         string `json:"description,omitempty"`
+}
+
+// BillMatchReport lists the bills that don't match what was paid for them
+// beyond the tenant's tolerances
+type BillMatchReport struct {
+	Settings   *models.BillMatchSettings `json:"settings"`
+	Exceptions []BillMatchException      `json:"exceptions"`
+	Count      int                       `json:"count"`
+}
+
+// BillMatchService checks bills against their payments, and bills raised
+// from purchase orders against what was ordered and received
+type BillMatchService interface {
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.BillMatchSettings, error)
+	UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, req UpdateBillMatchSettingsRequest) (*models.BillMatchSettings, error)
+	// Report flags bills whose payments settled more than the bill beyond
+	// the payment tolerance, purchase order lines billed for more than was
+	// received beyond the quantity tolerance, and bill lines charging more
+	// than the order's rate beyond the rate tolerance
+	Report(ctx context.Context, tenantID uuid.UUID, filters repository.BillMatchFilters) (*BillMatchReport, error)
+}
+
+type billMatchService struct {
+	matchRepo repository.BillMatchRepository
+}
+
+// NewBillMatchService creates a new bill match service
+func NewBillMatchService(matchRepo repository.BillMatchRepository) BillMatchService {
+	return &billMatchService{matchRepo: matchRepo}
+}
+
+func (s *billMatchService) GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.BillMatchSettings, error) {
+	return s.matchRepo.GetSettings(ctx, tenantID)
+}
+
+func (s *billMatchService) UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, req UpdateBillMatchSettingsRequest) (*models.BillMatchSettings, error) {
+	for _, pct := range []decimal.Decimal{req.PaymentTolerancePct, req.QuantityTolerancePct, req.RateTolerancePct} {
+		if pct.IsNegative() || pct.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, ErrInvalidBillMatchSettings
+		}
+	}
+
+	settings := &models.BillMatchSettings{
+		TenantID:             tenantID,
+		PaymentTolerancePct:  req.PaymentTolerancePct.Round(2),
+		QuantityTolerancePct: req.QuantityTolerancePct.Round(2),
+		RateTolerancePct:     req.RateTolerancePct.Round(2),
+		UpdatedBy:            &userID,
+	}
+	if err := s.matchRepo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *billMatchService) Report(ctx context.Context, tenantID uuid.UUID, filters repository.BillMatchFilters) (*BillMatchReport, error) {
+	settings, err := s.matchRepo.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	overpaid, err := s.matchRepo.ListOverpaid(ctx, tenantID, settings.PaymentTolerancePct, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BillMatchReport{Settings: settings, Exceptions: []BillMatchException{}}
+	for _, bill := range overpaid {
+		report.Exceptions = append(report.Exceptions, billMatchException(BillMatchPaymentExceedsBill, &bill.Bill, bill.TotalAmount, bill.Settled))
+	}
+
+	lines, err := s.matchRepo.ListPurchaseOrderLines(ctx, tenantID, filters)
+	if err != nil {
+		return nil, err
+	}
+	// An order line billed for more than was received is flagged once, on
+	// the last bill against it
+	lastBill := make(map[uuid.UUID]int, len(lines))
+	for i, line := range lines {
+		lastBill[line.PurchaseOrderItemID] = i
+	}
+	for i, line := range lines {
+		if !withinTolerance(line.OrderRate, line.Rate, settings.RateTolerancePct) {
+			report.Exceptions = append(report.Exceptions, purchaseOrderException(BillMatchRateExceedsOrder, line, line.OrderRate, line.Rate))
+		}
+		if lastBill[line.PurchaseOrderItemID] == i && !withinTolerance(line.ReceivedQuantity, line.BilledQuantity, settings.QuantityTolerancePct) {
+			report.Exceptions = append(report.Exceptions, purchaseOrderException(BillMatchQuantityExceedsReceipt, line, line.ReceivedQuantity, line.BilledQuantity))
+		}
+	}
+	report.Count = len(report.Exceptions)
+
+	return report, nil
+}
+
+func billMatchException(exceptionType string, bill *models.Bill, expected, actual decimal.Decimal) BillMatchException {
+	exception := BillMatchException{
+		Type:       exceptionType,
+		BillID:     bill.ID,
+		BillNumber: bill.BillNumber,
+		BillDate:   bill.BillDate.Format("2006-01-02"),
+		VendorID:   bill.VendorID,
+		VendorName: bill.VendorName,
+		Expected:   expected,
+		Actual:     actual,
+		Variance:   actual.Sub(expected),
+	}
+	if !expected.IsZero() {
+		exception.VariancePct = exception.Variance.Div(expected).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+	return exception
+}
+
+func purchaseOrderException(exceptionType string, line repository.PurchaseOrderBillLine, expected, actual decimal.Decimal) BillMatchException {
+	exception := billMatchException(exceptionType, &line.Bill, expected, actual)
+	exception.PurchaseOrderNumber = line.PurchaseOrderNumber
+	exception.Description = line.Description
+	return exception
+}
+
+// withinTolerance reports whether actual is no more than tolerancePct over
+// expected
+func withinTolerance(expected, actual, tolerancePct decimal.Decimal) bool {
+	limit := expected.Mul(decimal.NewFromInt(100).Add(tolerancePct)).Div(decimal.NewFromInt(100))
+	return actual.LessThanOrEqual(limit)
+}
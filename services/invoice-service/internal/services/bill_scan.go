@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/ocr"
+)
+
+// MaxBillScanSize is the largest document that may be scanned for a bill
+const MaxBillScanSize = 10 << 20
+
+var (
+	ErrBillScanUnavailable     = errors.New("bill scanning is not configured")
+	ErrBillScanTooLarge        = errors.New("document is larger than 10 MB")
+	ErrBillScanUnsupportedType = errors.New("document must be a PDF, JPEG, PNG or WebP")
+
+	billScanTypes = map[string]bool{
+		"application/pdf": true,
+		"image/jpeg":      true,
+		"image/png":       true,
+		"image/webp":      true,
+	}
+
+	scannedGSTINRegex = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+	// Layouts dates are printed on Indian bills in, day first
+	scannedDateLayouts = []string{
+		"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006", "02.01.2006",
+		"02/01/06", "02-01-06", "02-Jan-2006", "2-Jan-2006", "02 Jan 2006", "2 Jan 2006",
+		"02-Jan-06", "2 January 2006", "January 2, 2006", "Jan 2, 2006",
+	}
+)
+
+// scanTotalTolerance is how far the drafted bill's total may be from the
+// total printed before the user is warned to check the lines
+var scanTotalTolerance = decimal.NewFromInt(1)
+
+// BillScan is a bill read from a vendor's PDF or image, drafted for the
+// user to check and save through the create bill endpoint. Nothing is
+// saved until they do.
+type BillScan struct {
+	Draft CreateBillRequest `json:"draft"`
+	// Extracted is what the document reader found, as printed
+	Extracted *ocr.Bill `json:"extracted"`
+	Provider  string    `json:"provider"`
+	// VendorMatched is set when the vendor was found by its GSTIN; the
+	// draft's vendor must otherwise be chosen before saving
+	VendorMatched bool     `json:"vendor_matched"`
+	Warnings      []string `json:"warnings"`
+}
+
+// BillScanService drafts bills from scanned or digital vendor bills
+type BillScanService interface {
+	// Scan reads the document with the OCR provider and drafts a bill
+	// from it. Vendors are looked up on behalf of the caller identified by
+	// authorization.
+	Scan(ctx context.Context, authorization string, doc ocr.Document) (*BillScan, error)
+}
+
+type billScanService struct {
+	provider       ocr.Provider // Nil when none is configured
+	customerClient clients.CustomerClient
+}
+
+// NewBillScanService creates a new bill scan service. provider may be nil,
+// in which case scans fail with ErrBillScanUnavailable.
+func NewBillScanService(provider ocr.Provider, customerClient clients.CustomerClient) BillScanService {
+	return &billScanService{
+		provider:       provider,
+		customerClient: customerClient,
+	}
+}
+
+func (s *billScanService) Scan(ctx context.Context, authorization string, doc ocr.Document) (*BillScan, error) {
+	if s.provider == nil {
+		return nil, ErrBillScanUnavailable
+	}
+	if len(doc.Content) > MaxBillScanSize {
+		return nil, ErrBillScanTooLarge
+	}
+	if !billScanTypes[doc.ContentType] {
+		return nil, ErrBillScanUnsupportedType
+	}
+
+	extracted, err := s.provider.ExtractBill(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &BillScan{
+		Extracted: extracted,
+		Provider:  s.provider.Name(),
+		Warnings:  []string{},
+	}
+	s.draftVendor(ctx, authorization, scan)
+	draftDates(scan)
+	draftItems(scan)
+	return scan, nil
+}
+
+// draftVendor fills in the vendor, from the tenant's vendors when one has
+// the GSTIN read
+func (s *billScanService) draftVendor(ctx context.Context, authorization string, scan *BillScan) {
+	extracted := scan.Extracted
+	draft := &scan.Draft
+	draft.VendorName = strings.TrimSpace(extracted.VendorName)
+	draft.VendorAddress = strings.TrimSpace(extracted.VendorAddress)
+	draft.VendorBillNo = strings.TrimSpace(extracted.InvoiceNumber)
+	if draft.VendorBillNo == "" {
+		scan.Warnings = append(scan.Warnings, "The vendor's invoice number could not be read")
+	}
+
+	gstin := strings.ToUpper(strings.ReplaceAll(extracted.VendorGSTIN, " ", ""))
+	switch {
+	case gstin == "":
+		scan.Warnings = append(scan.Warnings, "No vendor GSTIN was found; for an unregistered vendor, check whether reverse charge applies")
+		return
+	case !scannedGSTINRegex.MatchString(gstin):
+		scan.Warnings = append(scan.Warnings, fmt.Sprintf("The vendor GSTIN read, %s, is not valid; check it against the bill", gstin))
+		return
+	}
+	draft.VendorGSTIN = gstin
+	draft.VendorPAN = gstin[2:12]
+	// The state of a registered vendor is the first two digits of its
+	// GSTIN, unless the vendor is on record with its state
+	draft.VendorState = gstin[:2]
+	draft.ITCEligible = true
+
+	vendor, err := s.customerClient.FindVendorByGSTIN(ctx, authorization, gstin)
+	if err != nil {
+		scan.Warnings = append(scan.Warnings, "Vendors could not be searched; choose the vendor before saving")
+		return
+	}
+	if vendor == nil {
+		scan.Warnings = append(scan.Warnings, fmt.Sprintf("No vendor has GSTIN %s; add the vendor or choose one before saving", gstin))
+		return
+	}
+
+	scan.VendorMatched = true
+	draft.VendorID = vendor.ID
+	draft.VendorName = vendor.Name
+	if vendor.PAN != "" {
+		draft.VendorPAN = vendor.PAN
+	}
+	draft.VendorEmail = vendor.Email
+	draft.VendorPhone = vendor.Phone
+	if address := vendor.Address(); address != "" {
+		draft.VendorAddress = address
+	}
+	if vendor.BillingState != "" {
+		draft.VendorState = vendor.BillingState
+	}
+}
+
+// draftDates fills in the bill and due dates, in the layout the create bill
+// endpoint takes
+func draftDates(scan *BillScan) {
+	billDate, ok := parseScannedDate(scan.Extracted.InvoiceDate)
+	if !ok {
+		scan.Warnings = append(scan.Warnings, "The bill date could not be read")
+		return
+	}
+	scan.Draft.BillDate = billDate.Format("2006-01-02")
+	if billDate.After(time.Now()) {
+		scan.Warnings = append(scan.Warnings, "The bill date read is in the future; check it against the bill")
+	}
+
+	if dueDate, ok := parseScannedDate(scan.Extracted.DueDate); ok && !dueDate.Before(billDate) {
+		scan.Draft.DueDate = dueDate.Format("2006-01-02")
+	}
+}
+
+func parseScannedDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range scannedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// draftItems turns the lines read into bill lines, with GST rates worked
+// out from the tax charged on each. A bill whose lines could not be read
+// is drafted as a single line for its taxable value.
+func draftItems(scan *BillScan) {
+	extracted := scan.Extracted
+	lines := extracted.Lines
+	if len(lines) == 0 {
+		if !extracted.TaxableAmount.IsPositive() {
+			scan.Warnings = append(scan.Warnings, "No line items or taxable value could be read; add the lines before saving")
+			scan.Draft.Items = []CreateBillItemRequest{}
+			return
+		}
+		lines = []ocr.BillLine{{
+			Description: strings.TrimSpace("As per vendor invoice " + scan.Draft.VendorBillNo),
+			Quantity:    decimal.NewFromInt(1),
+			Amount:      extracted.TaxableAmount,
+			CGSTAmount:  extracted.CGSTAmount,
+			SGSTAmount:  extracted.SGSTAmount,
+			IGSTAmount:  extracted.IGSTAmount,
+			CessAmount:  extracted.CessAmount,
+		}}
+		scan.Warnings = append(scan.Warnings, "No line items could be read; the bill is drafted as a single line for its taxable value")
+	}
+
+	interState := extracted.IGSTAmount.IsPositive()
+	hundred := decimal.NewFromInt(100)
+	total := decimal.Zero
+	for i, line := range lines {
+		quantity := line.Quantity
+		if !quantity.IsPositive() {
+			quantity = decimal.NewFromInt(1)
+		}
+		amount := line.Amount
+		if !amount.IsPositive() {
+			amount = line.Rate.Mul(quantity)
+		}
+		rate := line.Rate
+		if !rate.IsPositive() && amount.IsPositive() {
+			rate = amount.Div(quantity).Round(2)
+		}
+
+		item := CreateBillItemRequest{
+			Description: strings.TrimSpace(line.Description),
+			Quantity:    quantity,
+			Unit:        strings.TrimSpace(line.Unit),
+			Rate:        rate,
+			ITCEligible: scan.Draft.ITCEligible,
+		}
+		if item.Description == "" {
+			item.Description = fmt.Sprintf("Line %d", i+1)
+		}
+		// Codes of services (SAC) start with 99
+		if code := strings.TrimSpace(line.HSNCode); strings.HasPrefix(code, "99") {
+			item.SACCode = code
+		} else {
+			item.HSNCode = code
+		}
+
+		rateOf := func(tax decimal.Decimal) decimal.Decimal {
+			if !amount.IsPositive() {
+				return decimal.Zero
+			}
+			return tax.Div(amount).Mul(hundred).Round(2)
+		}
+		switch {
+		case line.IGSTAmount.IsPositive():
+			item.IGSTRate = rateOf(line.IGSTAmount)
+		case line.CGSTAmount.IsPositive() || line.SGSTAmount.IsPositive():
+			item.CGSTRate = rateOf(line.CGSTAmount)
+			item.SGSTRate = rateOf(line.SGSTAmount)
+		case line.TaxRate.IsPositive() && interState:
+			item.IGSTRate = line.TaxRate
+		case line.TaxRate.IsPositive():
+			item.CGSTRate = line.TaxRate.Div(decimal.NewFromInt(2))
+			item.SGSTRate = item.CGSTRate
+		}
+		item.CessRate = rateOf(line.CessAmount)
+
+		taxRate := item.CGSTRate.Add(item.SGSTRate).Add(item.IGSTRate).Add(item.CessRate)
+		lineAmount := rate.Mul(quantity)
+		total = total.Add(lineAmount).Add(lineAmount.Mul(taxRate).Div(hundred))
+		scan.Draft.Items = append(scan.Draft.Items, item)
+	}
+
+	if extracted.TotalAmount.IsPositive() && total.Sub(extracted.TotalAmount).Abs().GreaterThan(scanTotalTolerance) {
+		scan.Warnings = append(scan.Warnings, fmt.Sprintf(
+			"The lines add up to %s but the bill's total reads %s; check the lines and taxes",
+			total.StringFixed(2), extracted.TotalAmount.StringFixed(2)))
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
 )
@@ -16,6 +21,11 @@ var (
 	ErrBillNotFound = errors.New("bill not found")
 	ErrInvalidBill  = errors.New("invalid bill data")
 	ErrCannotModifyBill = errors.New("cannot modify bill in current status")
+	ErrTDSSectionRequired = errors.New("tds section is required for tds applicable bills")
+	ErrTDSUnavailable     = errors.New("unable to deduct TDS for payment")
+	ErrLedgerUnavailable  = errors.New("unable to post payment to ledger")
+	ErrVendorPaymentHold  = errors.New("payments to this vendor are on hold after a recent change to its bank details")
+	ErrPaymentHoldUnknown = errors.New("unable to check the vendor's payment hold")
 )
 
 // BillService handles bill business logic
@@ -24,8 +34,13 @@ type BillService interface {
 	Get(ctx context.Context, id uuid.UUID) (*models.Bill, error)
 	List(ctx context.Context, tenantID uuid.UUID, filters repository.BillFilters) ([]models.Bill, int64, error)
 	Update(ctx context.Context, id uuid.UUID, req UpdateBillRequest) (*models.Bill, error)
-	Delete(ctx context.Context, id uuid.UUID) error
-	Approve(ctx context.Context, id uuid.UUID, approverID uuid.UUID) (*models.Bill, error)
+	// Delete checks the bill's period is open on behalf of the caller
+	// identified by authorization
+	Delete(ctx context.Context, id uuid.UUID, authorization string) error
+	// Approve approves a bill and posts it to the ledger on behalf of the
+	// caller identified by authorization. One breaking the expense policy
+	// needs an override reason.
+	Approve(ctx context.Context, id uuid.UUID, approverID uuid.UUID, policyOverrideReason, authorization string) (*models.Bill, error)
 	RecordPayment(ctx context.Context, billID uuid.UUID, req RecordBillPaymentRequest) (*models.BillPayment, error)
 	GetOverdueBills(ctx context.Context, tenantID uuid.UUID) ([]models.Bill, error)
 	GetPayablesSummary(ctx context.Context, tenantID uuid.UUID) (*repository.PayablesSummary, error)
@@ -33,18 +48,51 @@ type BillService interface {
 }
 
 type billService struct {
-	billRepo    repository.BillRepository
-	paymentRepo repository.BillPaymentRepository
+	billRepo          repository.BillRepository
+	paymentRepo       repository.BillPaymentRepository
+	roundingService   RoundingService
+	taxClient         clients.TaxClient
+	bookkeepingClient clients.BookkeepingClient
+	customerClient    clients.CustomerClient
+	snapshotService   TaxSnapshotService
+	periodLock        PeriodLock
+	history           *timeline.Store
+	cashLimits        CashLimitService
+	expensePolicy     ExpensePolicyService
+	rules             validation.Checker
+	ledger            DocumentLedger
 }
 
 // NewBillService creates a new bill service
 func NewBillService(
 	billRepo repository.BillRepository,
 	paymentRepo repository.BillPaymentRepository,
+	roundingService RoundingService,
+	taxClient clients.TaxClient,
+	bookkeepingClient clients.BookkeepingClient,
+	customerClient clients.CustomerClient,
+	snapshotService TaxSnapshotService,
+	periodLock PeriodLock,
+	history *timeline.Store,
+	cashLimits CashLimitService,
+	expensePolicy ExpensePolicyService,
+	rules validation.Checker,
+	ledger DocumentLedger,
 ) BillService {
 	return &billService{
-		billRepo:    billRepo,
-		paymentRepo: paymentRepo,
+		billRepo:          billRepo,
+		paymentRepo:       paymentRepo,
+		roundingService:   roundingService,
+		taxClient:         taxClient,
+		bookkeepingClient: bookkeepingClient,
+		customerClient:    customerClient,
+		snapshotService:   snapshotService,
+		periodLock:        periodLock,
+		history:           history,
+		cashLimits:        cashLimits,
+		expensePolicy:     expensePolicy,
+		rules:             rules,
+		ledger:            ledger,
 	}
 }
 
@@ -52,9 +100,11 @@ func NewBillService(
 type CreateBillRequest struct {
 	TenantID      uuid.UUID              `json:"-"`
 	CreatedBy     uuid.UUID              `json:"-"`
+	Authorization string                 `json:"-"` // Used to check the bill's period is open and the tenant's validation rules
 	VendorID      uuid.UUID              `json:"vendor_id" binding:"required"`
 	VendorName    string                 `json:"vendor_name" binding:"required"`
 	VendorGSTIN   string                 `json:"vendor_gstin"`
+	VendorPAN     string                 `json:"vendor_pan"`
 	VendorAddress string                 `json:"vendor_address"`
 	VendorState   string                 `json:"vendor_state" binding:"required"`
 	VendorEmail   string                 `json:"vendor_email"`
@@ -70,29 +120,43 @@ type CreateBillRequest struct {
 	TDSRate       decimal.Decimal        `json:"tds_rate"`
 	ITCEligible   bool                   `json:"itc_eligible"`
 	ITCCategory   string                 `json:"itc_category"`
+	URDReverseCharge bool                `json:"urd_rcm"` // Unregistered vendor, GST paid by us
 	Notes         string                 `json:"notes"`
+
+	// Set when the bill is raised from a purchase order
+	PurchaseOrderID     *uuid.UUID `json:"-"`
+	PurchaseOrderNumber string     `json:"-"`
 }
 
 // CreateBillItemRequest represents a line item in the bill
 type CreateBillItemRequest struct {
-	ProductID   *uuid.UUID      `json:"product_id"`
-	Description string          `json:"description" binding:"required"`
-	HSNCode     string          `json:"hsn_code"`
-	SACCode     string          `json:"sac_code"`
-	Quantity    decimal.Decimal `json:"quantity" binding:"required"`
-	Unit        string          `json:"unit"`
-	Rate        decimal.Decimal `json:"rate" binding:"required"`
-	CGSTRate    decimal.Decimal `json:"cgst_rate"`
-	SGSTRate    decimal.Decimal `json:"sgst_rate"`
-	IGSTRate    decimal.Decimal `json:"igst_rate"`
-	CessRate    decimal.Decimal `json:"cess_rate"`
-	ITCEligible bool            `json:"itc_eligible"`
+	ProductID        *uuid.UUID      `json:"product_id"`
+	Description      string          `json:"description" binding:"required"`
+	HSNCode          string          `json:"hsn_code"`
+	SACCode          string          `json:"sac_code"`
+	Quantity         decimal.Decimal `json:"quantity" binding:"required"`
+	Unit             string          `json:"unit"`
+	Rate             decimal.Decimal `json:"rate" binding:"required"`
+	CGSTRate         decimal.Decimal `json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `json:"igst_rate"`
+	CessRate         decimal.Decimal `json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `json:"cess_specific_rate"`
+	ITCEligible      bool            `json:"itc_eligible"`
+	ExpenseAccountID *uuid.UUID      `json:"expense_account_id"`
+	ExpenseCategory  string          `json:"expense_category"` // Checked against the expense policy
+
+	// Purchase order line the bill line is for; kept only on bills raised
+	// from a purchase order
+	PurchaseOrderItemID *uuid.UUID `json:"purchase_order_item_id"`
 }
 
 // UpdateBillRequest represents a request to update a bill
 type UpdateBillRequest struct {
+	Authorization string                 `json:"-"` // Used to check the bill's period is open and the tenant's validation rules
 	VendorName    string                 `json:"vendor_name"`
 	VendorGSTIN   string                 `json:"vendor_gstin"`
+	VendorPAN     string                 `json:"vendor_pan"`
 	VendorAddress string                 `json:"vendor_address"`
 	VendorState   string                 `json:"vendor_state"`
 	VendorEmail   string                 `json:"vendor_email"`
@@ -107,6 +171,7 @@ type UpdateBillRequest struct {
 	TDSRate       decimal.Decimal        `json:"tds_rate"`
 	ITCEligible   bool                   `json:"itc_eligible"`
 	ITCCategory   string                 `json:"itc_category"`
+	URDReverseCharge bool                `json:"urd_rcm"`
 	Notes         string                 `json:"notes"`
 }
 
@@ -114,12 +179,20 @@ type UpdateBillRequest struct {
 type RecordBillPaymentRequest struct {
 	TenantID      uuid.UUID       `json:"-"`
 	CreatedBy     uuid.UUID       `json:"-"`
+	Authorization string          `json:"-"` // forwarded when posting to the ledger
+	// IdempotencyKey identifies a payment across the client's retries; see
+	// billPaymentID
+	IdempotencyKey string          `json:"-"`
 	PaymentDate   string          `json:"payment_date" binding:"required"`
-	Amount        decimal.Decimal `json:"amount" binding:"required"`
+	Amount        decimal.Decimal `json:"amount" binding:"required"` // settled against the bill, before TDS
 	PaymentMethod string          `json:"payment_method" binding:"required"`
 	BankAccountID *uuid.UUID      `json:"bank_account_id"`
 	Reference     string          `json:"reference"`
 	Notes         string          `json:"notes"`
+
+	// Why a cash payment over the section 40A(3) limit is made anyway,
+	// where the tenant blocks those
+	CashOverrideReason string `json:"cash_override_reason"`
 }
 
 func (s *billService) Create(ctx context.Context, req CreateBillRequest) (*models.Bill, error) {
@@ -135,6 +208,15 @@ func (s *billService) Create(ctx context.Context, req CreateBillRequest) (*model
 		dueDate = billDate.AddDate(0, 0, 30) // Default 30 days
 	}
 
+	// Reverse charge under section 9(4) applies only to unregistered vendors
+	if req.URDReverseCharge && req.VendorGSTIN != "" {
+		return nil, ErrInvalidBill
+	}
+
+	if err := s.periodLock.CheckOpen(ctx, req.TenantID, req.Authorization, billDate); err != nil {
+		return nil, err
+	}
+
 	// Generate bill number
 	prefix := fmt.Sprintf("BILL-%s", time.Now().Format("0601"))
 	billNumber, err := s.billRepo.GetNextBillNumber(ctx, req.TenantID, prefix)
@@ -149,6 +231,7 @@ func (s *billService) Create(ctx context.Context, req CreateBillRequest) (*model
 		VendorID:      req.VendorID,
 		VendorName:    req.VendorName,
 		VendorGSTIN:   req.VendorGSTIN,
+		VendorPAN:     req.VendorPAN,
 		VendorAddress: req.VendorAddress,
 		VendorState:   req.VendorState,
 		VendorEmail:   req.VendorEmail,
@@ -163,6 +246,9 @@ func (s *billService) Create(ctx context.Context, req CreateBillRequest) (*model
 		TDSRate:       req.TDSRate,
 		ITCEligible:   req.ITCEligible,
 		ITCCategory:   req.ITCCategory,
+		URDReverseCharge: req.URDReverseCharge,
+		PurchaseOrderID:     req.PurchaseOrderID,
+		PurchaseOrderNumber: req.PurchaseOrderNumber,
 		Notes:         req.Notes,
 		CreatedBy:     req.CreatedBy,
 	}
@@ -170,34 +256,115 @@ func (s *billService) Create(ctx context.Context, req CreateBillRequest) (*model
 	// Create bill items
 	for _, itemReq := range req.Items {
 		item := models.BillItem{
-			ProductID:   itemReq.ProductID,
-			Description: itemReq.Description,
-			HSNCode:     itemReq.HSNCode,
-			SACCode:     itemReq.SACCode,
-			Quantity:    itemReq.Quantity,
-			Unit:        itemReq.Unit,
-			Rate:        itemReq.Rate,
-			CGSTRate:    itemReq.CGSTRate,
-			SGSTRate:    itemReq.SGSTRate,
-			IGSTRate:    itemReq.IGSTRate,
-			CessRate:    itemReq.CessRate,
-			ITCEligible: itemReq.ITCEligible,
+			ProductID:        itemReq.ProductID,
+			Description:      itemReq.Description,
+			HSNCode:          itemReq.HSNCode,
+			SACCode:          itemReq.SACCode,
+			Quantity:         itemReq.Quantity,
+			Unit:             itemReq.Unit,
+			Rate:             itemReq.Rate,
+			CGSTRate:         itemReq.CGSTRate,
+			SGSTRate:         itemReq.SGSTRate,
+			IGSTRate:         itemReq.IGSTRate,
+			CessRate:         itemReq.CessRate,
+			CessSpecificRate: itemReq.CessSpecificRate,
+			ITCEligible:      itemReq.ITCEligible,
+			ExpenseAccountID: itemReq.ExpenseAccountID,
+			ExpenseCategory:  strings.TrimSpace(itemReq.ExpenseCategory),
+		}
+		if bill.PurchaseOrderID != nil {
+			item.PurchaseOrderItemID = itemReq.PurchaseOrderItemID
 		}
 		item.CalculateAmounts()
 		bill.Items = append(bill.Items, item)
 	}
 
+	bill.ApplyRoundingRule(s.roundingService.GetRule(ctx, req.TenantID, models.DocumentTypeBill))
 	bill.CalculateTotals()
+	blockedWarnings := s.applyITCBlocks(ctx, bill)
+	if err := s.rules.Check(ctx, bill.TenantID.String(), req.Authorization, billRuleDocument(bill)); err != nil {
+		return nil, err
+	}
+	violations, err := s.expensePolicy.CheckBill(ctx, bill)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := s.billRepo.Create(ctx, bill); err != nil {
 		return nil, err
 	}
+	if err := s.expensePolicy.Record(ctx, bill.TenantID, models.PolicyDocumentBill, bill.ID, violations); err != nil {
+		return nil, err
+	}
+	bill.PolicyViolations = violations
 
+	bill.Warnings = append(blockedWarnings, s.vendorFilingWarnings(ctx, bill)...)
 	return bill, nil
 }
 
+// applyITCBlocks marks lines whose input tax credit is blocked under section
+// 17(5) (food and beverages, motor vehicles, personal consumption, ...) as
+// ineligible, going by their HSN/SAC code and expense account, and returns a
+// warning for each. If the tax service cannot be reached the lines are left
+// as entered.
+func (s *billService) applyITCBlocks(ctx context.Context, bill *models.Bill) []string {
+	if !bill.ITCEligible {
+		return nil
+	}
+
+	lines := make([]clients.ITCEligibilityLine, len(bill.Items))
+	for i, item := range bill.Items {
+		lines[i] = clients.ITCEligibilityLine{
+			HSNCode:   item.HSNCode,
+			SACCode:   item.SACCode,
+			AccountID: item.ExpenseAccountID,
+			TaxAmount: item.CGSTAmount.Add(item.SGSTAmount).Add(item.IGSTAmount).Add(item.CessAmount),
+		}
+	}
+	results, err := s.taxClient.CheckITCEligibility(ctx, bill.TenantID.String(), lines)
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	for i, result := range results {
+		item := &bill.Items[i]
+		item.ITCBlockedReason = ""
+		if result.Eligible {
+			continue
+		}
+		item.ITCEligible = false
+		item.ITCBlockedReason = result.Reason
+		warnings = append(warnings, fmt.Sprintf("Line %d (%s): %s", i+1, item.Description, result.Reason))
+	}
+	return warnings
+}
+
+// vendorFilingWarnings returns warnings about a cancelled GSTIN or a vendor
+// that is behind on (or habitually late with) GSTR-1, whose ITC may not show
+// up in GSTR-2B. The check is advisory, so a tax service failure is ignored.
+func (s *billService) vendorFilingWarnings(ctx context.Context, bill *models.Bill) []string {
+	if bill.VendorGSTIN == "" {
+		return nil
+	}
+	check, err := s.taxClient.CheckGSTINFilingStatus(ctx, bill.TenantID.String(), bill.VendorGSTIN)
+	if err != nil {
+		return nil
+	}
+	return check.Warnings
+}
+
 func (s *billService) Get(ctx context.Context, id uuid.UUID) (*models.Bill, error) {
-	return s.billRepo.GetByID(ctx, id)
+	bill, err := s.billRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bill.PolicyViolations, err = s.expensePolicy.Violations(ctx, bill.TenantID, models.PolicyDocumentBill, bill.ID)
+	if err != nil {
+		return nil, err
+	}
+	return bill, nil
 }
 
 func (s *billService) List(ctx context.Context, tenantID uuid.UUID, filters repository.BillFilters) ([]models.Bill, int64, error) {
@@ -214,6 +381,10 @@ func (s *billService) Update(ctx context.Context, id uuid.UUID, req UpdateBillRe
 	if bill.Status != models.BillStatusDraft && bill.Status != models.BillStatusPending {
 		return nil, ErrCannotModifyBill
 	}
+	if err := s.periodLock.CheckOpen(ctx, bill.TenantID, req.Authorization, bill.BillDate); err != nil {
+		return nil, err
+	}
+	before := *bill
 
 	// Update fields
 	if req.VendorName != "" {
@@ -222,6 +393,9 @@ func (s *billService) Update(ctx context.Context, id uuid.UUID, req UpdateBillRe
 	if req.VendorGSTIN != "" {
 		bill.VendorGSTIN = req.VendorGSTIN
 	}
+	if req.VendorPAN != "" {
+		bill.VendorPAN = req.VendorPAN
+	}
 	if req.VendorAddress != "" {
 		bill.VendorAddress = req.VendorAddress
 	}
@@ -250,26 +424,36 @@ func (s *billService) Update(ctx context.Context, id uuid.UUID, req UpdateBillRe
 	bill.TDSRate = req.TDSRate
 	bill.ITCEligible = req.ITCEligible
 	bill.ITCCategory = req.ITCCategory
+	bill.URDReverseCharge = req.URDReverseCharge
 	bill.Notes = req.Notes
+	if bill.URDReverseCharge && bill.VendorGSTIN != "" {
+		return nil, ErrInvalidBill
+	}
 
 	// Update items if provided
 	if len(req.Items) > 0 {
 		bill.Items = nil
 		for _, itemReq := range req.Items {
 			item := models.BillItem{
-				BillID:      bill.ID,
-				ProductID:   itemReq.ProductID,
-				Description: itemReq.Description,
-				HSNCode:     itemReq.HSNCode,
-				SACCode:     itemReq.SACCode,
-				Quantity:    itemReq.Quantity,
-				Unit:        itemReq.Unit,
-				Rate:        itemReq.Rate,
-				CGSTRate:    itemReq.CGSTRate,
-				SGSTRate:    itemReq.SGSTRate,
-				IGSTRate:    itemReq.IGSTRate,
-				CessRate:    itemReq.CessRate,
-				ITCEligible: itemReq.ITCEligible,
+				BillID:           bill.ID,
+				ProductID:        itemReq.ProductID,
+				Description:      itemReq.Description,
+				HSNCode:          itemReq.HSNCode,
+				SACCode:          itemReq.SACCode,
+				Quantity:         itemReq.Quantity,
+				Unit:             itemReq.Unit,
+				Rate:             itemReq.Rate,
+				CGSTRate:         itemReq.CGSTRate,
+				SGSTRate:         itemReq.SGSTRate,
+				IGSTRate:         itemReq.IGSTRate,
+				CessRate:         itemReq.CessRate,
+				CessSpecificRate: itemReq.CessSpecificRate,
+				ITCEligible:      itemReq.ITCEligible,
+				ExpenseAccountID: itemReq.ExpenseAccountID,
+				ExpenseCategory:  strings.TrimSpace(itemReq.ExpenseCategory),
+			}
+			if bill.PurchaseOrderID != nil {
+				item.PurchaseOrderItemID = itemReq.PurchaseOrderItemID
 			}
 			item.CalculateAmounts()
 			bill.Items = append(bill.Items, item)
@@ -277,15 +461,36 @@ func (s *billService) Update(ctx context.Context, id uuid.UUID, req UpdateBillRe
 	}
 
 	bill.CalculateTotals()
+	var blockedWarnings []string
+	if len(req.Items) > 0 {
+		blockedWarnings = s.applyITCBlocks(ctx, bill)
+	}
+	if err := s.rules.Check(ctx, bill.TenantID.String(), req.Authorization, billRuleDocument(bill)); err != nil {
+		return nil, err
+	}
+	violations, err := s.expensePolicy.CheckBill(ctx, bill)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := s.billRepo.Update(ctx, bill); err != nil {
 		return nil, err
 	}
+	if err := s.expensePolicy.Record(ctx, bill.TenantID, models.PolicyDocumentBill, bill.ID, violations); err != nil {
+		return nil, err
+	}
+	bill.PolicyViolations = violations
+	record(ctx, s.history, billTimelineDocument(bill), timeline.EventEdited, "Bill edited",
+		timeline.Diff(before, bill, billTimelineFields...))
 
+	bill.Warnings = blockedWarnings
+	if req.VendorGSTIN != "" {
+		bill.Warnings = append(bill.Warnings, s.vendorFilingWarnings(ctx, bill)...)
+	}
 	return bill, nil
 }
 
-func (s *billService) Delete(ctx context.Context, id uuid.UUID) error {
+func (s *billService) Delete(ctx context.Context, id uuid.UUID, authorization string) error {
 	bill, err := s.billRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrBillNotFound
@@ -295,11 +500,14 @@ func (s *billService) Delete(ctx context.Context, id uuid.UUID) error {
 	if bill.Status != models.BillStatusDraft {
 		return ErrCannotModifyBill
 	}
+	if err := s.periodLock.CheckOpen(ctx, bill.TenantID, authorization, bill.BillDate); err != nil {
+		return err
+	}
 
 	return s.billRepo.Delete(ctx, id)
 }
 
-func (s *billService) Approve(ctx context.Context, id uuid.UUID, approverID uuid.UUID) (*models.Bill, error) {
+func (s *billService) Approve(ctx context.Context, id uuid.UUID, approverID uuid.UUID, policyOverrideReason, authorization string) (*models.Bill, error) {
 	bill, err := s.billRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, ErrBillNotFound
@@ -308,6 +516,19 @@ func (s *billService) Approve(ctx context.Context, id uuid.UUID, approverID uuid
 	if bill.Status != models.BillStatusDraft && bill.Status != models.BillStatusPending {
 		return nil, ErrCannotModifyBill
 	}
+	if err := s.periodLock.CheckOpen(ctx, bill.TenantID, authorization, bill.BillDate); err != nil {
+		return nil, err
+	}
+	if err := s.expensePolicy.Override(ctx, bill.TenantID, models.PolicyDocumentBill, bill.ID, approverID, policyOverrideReason); err != nil {
+		return nil, err
+	}
+
+	if err := s.snapshotService.CaptureBill(ctx, bill); err != nil {
+		return nil, err
+	}
+	if err := s.ledger.PostBill(ctx, authorization, bill); err != nil {
+		return nil, err
+	}
 
 	bill.Status = models.BillStatusApproved
 	bill.ApprovedBy = &approverID
@@ -332,10 +553,32 @@ func (s *billService) RecordPayment(ctx context.Context, billID uuid.UUID, req R
 		return nil, ErrInvalidBill
 	}
 
+	// A retry of a payment already recorded returns it rather than paying
+	// the vendor twice
+	paymentID := billPaymentID(bill, req)
+	if existing, err := s.paymentRepo.GetByID(ctx, req.TenantID, paymentID); err == nil {
+		return existing, nil
+	}
+
+	// A fraudster who changed the vendor's bank details must not be paid
+	// before the change can be noticed, so an unknown hold refuses the
+	// payment too
+	hold, err := s.customerClient.GetPaymentHold(ctx, req.Authorization, bill.VendorID)
+	if err != nil {
+		return nil, ErrPaymentHoldUnknown
+	}
+	if hold.OnHold {
+		return nil, ErrVendorPaymentHold
+	}
+
 	// Generate payment number
-	paymentNumber := fmt.Sprintf("PAY-%s-%05d", time.Now().Format("060102"), time.Now().UnixNano()%100000)
+	paymentNumber, err := s.paymentRepo.GetNextPaymentNumber(ctx, req.TenantID, fmt.Sprintf("PAY-%s", time.Now().Format("060102")))
+	if err != nil {
+		return nil, err
+	}
 
 	payment := &models.BillPayment{
+		ID:            paymentID,
 		TenantID:      req.TenantID,
 		BillID:        billID,
 		PaymentNumber: paymentNumber,
@@ -348,13 +591,35 @@ func (s *billService) RecordPayment(ctx context.Context, billID uuid.UUID, req R
 		CreatedBy:     req.CreatedBy,
 	}
 
+	cashFlag, err := s.cashLimits.CheckPayment(ctx, bill, payment, req.CashOverrideReason)
+	if err != nil {
+		return nil, err
+	}
+
+	if bill.TDSApplicable {
+		if err := s.deductTDS(ctx, bill, payment, req); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		// The TDS deduction and ledger entry stay recorded under the
+		// payment's ID, and a retry completes the payment against them
+		log.Printf("bill %s: failed to save payment %s after recording its TDS and ledger entry: %v", bill.ID, payment.ID, err)
 		return nil, err
 	}
+	if cashFlag != nil {
+		s.cashLimits.Flag(ctx, cashFlag, payment.ID)
+		payment.Warnings = append(payment.Warnings, cashLimitWarning(cashFlag))
+	}
 
 	// Update bill amounts
 	bill.AmountPaid = bill.AmountPaid.Add(req.Amount)
-	bill.BalanceDue = bill.TotalAmount.Sub(bill.AmountPaid)
+	bill.BalanceDue = bill.TotalAmount.Sub(bill.AmountPaid).Sub(bill.AmountDebited)
+	if payment.TDSAmount.IsPositive() {
+		bill.TDSRate = payment.TDSRate
+		bill.TDSAmount = bill.TDSAmount.Add(payment.TDSAmount)
+	}
 
 	if bill.BalanceDue.LessThanOrEqual(decimal.Zero) {
 		bill.Status = models.BillStatusPaid
@@ -369,6 +634,147 @@ func (s *billService) RecordPayment(ctx context.Context, billID uuid.UUID, req R
 	return payment, nil
 }
 
+// billPaymentNamespace scopes the IDs billPaymentID derives
+var billPaymentNamespace = uuid.MustParse("6f1d0c59-3b8e-4b8a-9f5e-2c7a1e0d4b63")
+
+// billPaymentID identifies a payment by the client's idempotency key or,
+// without one, by the bill's settlement so far and the payment's details, so
+// a retry of a payment that failed part way gets the same ID. The tax
+// service and the ledger record a payment once per ID.
+func billPaymentID(bill *models.Bill, req RecordBillPaymentRequest) uuid.UUID {
+	key := req.IdempotencyKey
+	if key == "" {
+		key = strings.Join([]string{bill.AmountPaid.String(), req.PaymentDate, req.Amount.String(),
+			req.PaymentMethod, req.Reference}, "|")
+	}
+	return uuid.NewSHA1(billPaymentNamespace, []byte(bill.TenantID.String()+"|"+bill.ID.String()+"|"+key))
+}
+
+// deductTDS withholds TDS from a payment on a TDS applicable bill. The
+// deduction is recorded with the tax service and the payable split (vendor
+// vs TDS payable) is posted to the ledger before the payment is saved. Both
+// are keyed by the payment's ID, so a retried payment records them once,
+// and the deduction is withdrawn when the ledger post fails.
+func (s *billService) deductTDS(ctx context.Context, bill *models.Bill, payment *models.BillPayment, req RecordBillPaymentRequest) error {
+	if bill.TDSSection == "" {
+		return ErrTDSSectionRequired
+	}
+
+	fyStart, fyEnd := financialYearRange(payment.PaymentDate)
+	cumulative, err := s.paymentRepo.GetVendorTDSBaseTotal(ctx, bill.TenantID, bill.VendorID, fyStart, fyEnd)
+	if err != nil {
+		return err
+	}
+
+	base := bill.TDSBase(req.Amount)
+	calc, err := s.taxClient.CalculateTDS(ctx, clients.TDSCalculationRequest{
+		TenantID:         bill.TenantID.String(),
+		DeducteeID:       bill.VendorID,
+		DeducteeName:     bill.VendorName,
+		DeducteePAN:      bill.VendorPAN,
+		Section:          bill.TDSSection,
+		GrossAmount:      base,
+		PaymentDate:      payment.PaymentDate.Format("2006-01-02"),
+		InvoiceID:        &bill.ID,
+		CumulativeAmount: &cumulative,
+	})
+	if err != nil {
+		return ErrTDSUnavailable
+	}
+
+	tdsAmount := calc.TDSAmount.Round(2)
+	if !tdsAmount.IsPositive() {
+		return nil
+	}
+
+	err = s.taxClient.RecordTDSDeduction(ctx, clients.TDSDeductionRequest{
+		TenantID:      bill.TenantID.String(),
+		InvoiceID:     &bill.ID,
+		PaymentID:     &payment.ID,
+		DeducteeID:    bill.VendorID,
+		DeducteeName:  bill.VendorName,
+		DeducteePAN:   bill.VendorPAN,
+		Section:       bill.TDSSection,
+		GrossAmount:   base,
+		TDSRate:       calc.TDSRate,
+		TDSAmount:     tdsAmount,
+		DeductionDate: payment.PaymentDate.Format("2006-01-02"),
+	})
+	if err != nil {
+		return ErrTDSUnavailable
+	}
+
+	gross, _ := req.Amount.Float64()
+	tds, _ := tdsAmount.Float64()
+	details := &clients.SupportingDetails{
+		Calculation: "tds_withholding",
+		Rates: []clients.SupportingRate{{
+			Name:    "TDS",
+			Section: bill.TDSSection,
+			Rate:    calc.TDSRate.InexactFloat64(),
+			Base:    base.InexactFloat64(),
+			Amount:  tds,
+		}},
+		Rounding: []clients.SupportingRounding{{
+			Mode:       string(models.RoundingModeNearest),
+			RoundTo:    0.01,
+			Unrounded:  calc.TDSAmount.InexactFloat64(),
+			Rounded:    tds,
+			Difference: tdsAmount.Sub(calc.TDSAmount).InexactFloat64(),
+		}},
+		SourceDocuments: []clients.SupportingDocument{
+			{Type: "bill", ID: &bill.ID, Number: bill.BillNumber},
+			{Type: "bill_payment", ID: &payment.ID},
+		},
+		Figures: map[string]float64{
+			"gross_amount":           gross,
+			"tds_base":               base.InexactFloat64(),
+			"bill_taxable_amount":    bill.TaxableAmount.InexactFloat64(),
+			"bill_total_amount":      bill.TotalAmount.InexactFloat64(),
+			"cumulative_fy_tds_base": cumulative.InexactFloat64(),
+			"net_amount":             req.Amount.Sub(tdsAmount).InexactFloat64(),
+		},
+		Notes: fmt.Sprintf("TDS base is the payment excluding GST; FY %s Q%d", calc.FinancialYear, calc.Quarter),
+	}
+	if calc.ThresholdApplied {
+		details.Notes += "; threshold crossed with cumulative payments this FY"
+	}
+	if snapshot, err := s.snapshotService.Get(ctx, bill.TenantID, models.DocumentTypeBill, bill.ID); err == nil {
+		details.SourceDocuments = append(details.SourceDocuments,
+			clients.SupportingDocument{Type: "tax_snapshot", ID: &snapshot.ID})
+	}
+
+	err = s.bookkeepingClient.PostBillPayment(ctx, req.Authorization, clients.BillPaymentPosting{
+		Date:             payment.PaymentDate.Format("2006-01-02"),
+		BillID:           &bill.ID,
+		BillNumber:       bill.BillNumber,
+		PaymentID:        &payment.ID,
+		VendorID:         &bill.VendorID,
+		VendorName:       bill.VendorName,
+		GrossAmount:      gross,
+		TDSAmount:        tds,
+		TDSSection:       bill.TDSSection,
+		PaymentMode:      req.PaymentMethod,
+		PaymentReference:  req.Reference,
+		Notes:             req.Notes,
+		SupportingDetails: details,
+	})
+	if err != nil {
+		// A payment that isn't made must not show on the vendor's TDS
+		// returns
+		if err := s.taxClient.VoidTDSDeduction(ctx, bill.TenantID.String(), payment.ID); err != nil {
+			log.Printf("bill %s: failed to void TDS deduction of unposted payment %s: %v", bill.ID, payment.ID, err)
+		}
+		return ErrLedgerUnavailable
+	}
+
+	payment.Amount = req.Amount.Sub(tdsAmount)
+	payment.TDSSection = bill.TDSSection
+	payment.TDSRate = calc.TDSRate
+	payment.TDSAmount = tdsAmount
+	return nil
+}
+
 func (s *billService) GetOverdueBills(ctx context.Context, tenantID uuid.UUID) ([]models.Bill, error) {
 	return s.billRepo.GetOverdueBills(ctx, tenantID)
 }
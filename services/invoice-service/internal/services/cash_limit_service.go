@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	// ErrCashLimitExceeded is returned when a cash payment or receipt takes
+	// the party over a statutory cash limit and the tenant blocks those
+	ErrCashLimitExceeded        = errors.New("cash amount exceeds the statutory limit; pay by bank or give a cash override reason")
+	ErrInvalidCashLimitSettings = errors.New("mode must be warn or block")
+)
+
+var (
+	// Expenses paid to a person in cash above this in a day are disallowed
+	// under section 40A(3)
+	cashPaymentLimit = decimal.NewFromInt(10000)
+	// Receiving this much or more in cash from a person in a day, or for a
+	// single transaction, is barred under section 269ST
+	cashReceiptLimit = decimal.NewFromInt(200000)
+)
+
+// UpdateCashLimitSettingsRequest configures the cash limit checks
+type UpdateCashLimitSettingsRequest struct {
+	Mode models.CashLimitMode `json:"mode" binding:"required"`
+}
+
+// CashLimitReport lists the flagged cash payments and receipts for the CA
+type CashLimitReport struct {
+	Flags      []models.CashLimitFlag `json:"flags"`
+	Count      int                    `json:"count"`
+	Overridden int                    `json:"overridden"`
+	Total      decimal.Decimal        `json:"total"` // Of the flagged payments and receipts
+}
+
+// CashLimitService checks cash payments to vendors against section 40A(3)
+// and cash receipts from customers against section 269ST. Depending on the
+// tenant's mode, one over the limit is refused unless an override reason is
+// given, or recorded with a warning; either way it is flagged for review.
+type CashLimitService interface {
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashLimitSettings, error)
+	UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, req UpdateCashLimitSettingsRequest) (*models.CashLimitSettings, error)
+
+	// CheckPayment checks a bill payment before it is saved. It returns the
+	// flag to record once it is, or nil when the payment is within the limit.
+	CheckPayment(ctx context.Context, bill *models.Bill, payment *models.BillPayment, overrideReason string) (*models.CashLimitFlag, error)
+	// CheckReceipt checks an invoice payment before it is saved, as
+	// CheckPayment does
+	CheckReceipt(ctx context.Context, invoice *models.Invoice, payment *models.Payment, overrideReason string) (*models.CashLimitFlag, error)
+	// Flag records a flag returned by a check against the saved payment
+	Flag(ctx context.Context, flag *models.CashLimitFlag, paymentID uuid.UUID)
+
+	Report(ctx context.Context, tenantID uuid.UUID, filters repository.CashLimitFilters) (*CashLimitReport, error)
+}
+
+type cashLimitService struct {
+	limitRepo repository.CashLimitRepository
+}
+
+// NewCashLimitService creates a new cash limit service
+func NewCashLimitService(limitRepo repository.CashLimitRepository) CashLimitService {
+	return &cashLimitService{limitRepo: limitRepo}
+}
+
+func (s *cashLimitService) GetSettings(ctx context.Context, tenantID uuid.UUID) (*models.CashLimitSettings, error) {
+	return s.limitRepo.GetSettings(ctx, tenantID)
+}
+
+func (s *cashLimitService) UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, req UpdateCashLimitSettingsRequest) (*models.CashLimitSettings, error) {
+	if req.Mode != models.CashLimitWarn && req.Mode != models.CashLimitBlock {
+		return nil, ErrInvalidCashLimitSettings
+	}
+
+	settings := &models.CashLimitSettings{
+		TenantID:  tenantID,
+		Mode:      req.Mode,
+		UpdatedBy: &userID,
+	}
+	if err := s.limitRepo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *cashLimitService) CheckPayment(ctx context.Context, bill *models.Bill, payment *models.BillPayment, overrideReason string) (*models.CashLimitFlag, error) {
+	if !isCash(payment.PaymentMethod) {
+		return nil, nil
+	}
+
+	paid, err := s.limitRepo.CashPaidToVendor(ctx, bill.TenantID, bill.VendorID, payment.PaymentDate)
+	if err != nil {
+		return nil, err
+	}
+	total := paid.Add(payment.Amount)
+	if total.LessThanOrEqual(cashPaymentLimit) {
+		return nil, nil
+	}
+
+	flag := &models.CashLimitFlag{
+		TenantID:       bill.TenantID,
+		Section:        models.CashSection40A3,
+		PartyID:        bill.VendorID,
+		PartyName:      bill.VendorName,
+		DocumentType:   "bill",
+		DocumentID:     bill.ID,
+		DocumentNumber: bill.BillNumber,
+		PaymentDate:    payment.PaymentDate,
+		Amount:         payment.Amount,
+		Total:          total,
+		Limit:          cashPaymentLimit,
+		CreatedBy:      payment.CreatedBy,
+	}
+	return s.decide(ctx, flag, overrideReason)
+}
+
+func (s *cashLimitService) CheckReceipt(ctx context.Context, invoice *models.Invoice, payment *models.Payment, overrideReason string) (*models.CashLimitFlag, error) {
+	if !isCash(payment.PaymentMethod) {
+		return nil, nil
+	}
+
+	// The limit applies to the day's receipts from the customer and,
+	// separately, to the receipts for the invoice
+	received, err := s.limitRepo.CashReceivedFromCustomer(ctx, invoice.TenantID, invoice.CustomerID, payment.PaymentDate)
+	if err != nil {
+		return nil, err
+	}
+	onInvoice, err := s.limitRepo.CashReceivedOnInvoice(ctx, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	total := decimal.Max(received, onInvoice).Add(payment.Amount)
+	if total.LessThan(cashReceiptLimit) {
+		return nil, nil
+	}
+
+	flag := &models.CashLimitFlag{
+		TenantID:       invoice.TenantID,
+		Section:        models.CashSection269ST,
+		PartyID:        invoice.CustomerID,
+		PartyName:      invoice.CustomerName,
+		DocumentType:   "invoice",
+		DocumentID:     invoice.ID,
+		DocumentNumber: invoice.InvoiceNumber,
+		PaymentDate:    payment.PaymentDate,
+		Amount:         payment.Amount,
+		Total:          total,
+		Limit:          cashReceiptLimit,
+		CreatedBy:      payment.CreatedBy,
+	}
+	return s.decide(ctx, flag, overrideReason)
+}
+
+// decide refuses a payment over the limit when the tenant blocks those and
+// no override reason was given
+func (s *cashLimitService) decide(ctx context.Context, flag *models.CashLimitFlag, overrideReason string) (*models.CashLimitFlag, error) {
+	settings, err := s.limitRepo.GetSettings(ctx, flag.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	overrideReason = strings.TrimSpace(overrideReason)
+	if settings.Mode == models.CashLimitBlock {
+		if overrideReason == "" {
+			return nil, ErrCashLimitExceeded
+		}
+		flag.Overridden = true
+	}
+	flag.OverrideReason = overrideReason
+	return flag, nil
+}
+
+// Flag records the flag once its payment is saved. A failure is logged, as
+// the payment can't be taken back by then.
+func (s *cashLimitService) Flag(ctx context.Context, flag *models.CashLimitFlag, paymentID uuid.UUID) {
+	if flag == nil {
+		return
+	}
+	flag.PaymentID = paymentID
+	if err := s.limitRepo.CreateFlag(ctx, flag); err != nil {
+		log.Printf("Failed to flag cash payment %s under section %s: %v", paymentID, flag.Section, err)
+	}
+}
+
+func (s *cashLimitService) Report(ctx context.Context, tenantID uuid.UUID, filters repository.CashLimitFilters) (*CashLimitReport, error) {
+	flags, err := s.limitRepo.ListFlags(ctx, tenantID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CashLimitReport{Flags: flags, Count: len(flags)}
+	for _, flag := range flags {
+		report.Total = report.Total.Add(flag.Amount)
+		if flag.Overridden {
+			report.Overridden++
+		}
+	}
+	return report, nil
+}
+
+// cashLimitWarning describes a flagged payment for the warnings returned
+// with it
+func cashLimitWarning(flag *models.CashLimitFlag) string {
+	return fmt.Sprintf("Cash of %s with %s on %s breaches the section %s limit of %s",
+		flag.Total.StringFixed(2), flag.PartyName, flag.PaymentDate.Format("2006-01-02"), flag.Section, flag.Limit.StringFixed(0))
+}
+
+func isCash(paymentMethod string) bool {
+	return strings.EqualFold(strings.TrimSpace(paymentMethod), "cash")
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/comments"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+// InvoiceCommentLookup finds the invoices comment threads are kept on
+func InvoiceCommentLookup(repo repository.InvoiceRepository) comments.Lookup {
+	return func(ctx context.Context, tenantID, id uuid.UUID) (string, error) {
+		invoice, err := repo.GetByID(ctx, id)
+		if err != nil || invoice.TenantID != tenantID {
+			return "", comments.ErrDocumentNotFound
+		}
+		return invoice.InvoiceNumber, nil
+	}
+}
+
+// BillCommentLookup finds the bills comment threads are kept on
+func BillCommentLookup(repo repository.BillRepository) comments.Lookup {
+	return func(ctx context.Context, tenantID, id uuid.UUID) (string, error) {
+		bill, err := repo.GetByID(ctx, id)
+		if err != nil || bill.TenantID != tenantID {
+			return "", comments.ErrDocumentNotFound
+		}
+		return bill.BillNumber, nil
+	}
+}
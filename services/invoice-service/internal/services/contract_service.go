@@ -0,0 +1,471 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrContractNotFound = errors.New("contract not found")
+	ErrInvalidContract  = errors.New("invalid contract data")
+	ErrContractClosed   = errors.New("contract has expired or been terminated")
+)
+
+// ContractRequest represents a request to create or update a contract
+type ContractRequest struct {
+	TenantID          uuid.UUID       `json:"-"`
+	UserID            uuid.UUID       `json:"-"`
+	ContractNumber    string          `json:"contract_number"`
+	Title             string          `json:"title" binding:"required"`
+	PartyID           uuid.UUID       `json:"party_id" binding:"required"`
+	PartyName         string          `json:"party_name"`
+	Value             decimal.Decimal `json:"value"`
+	StartDate         string          `json:"start_date" binding:"required"`
+	EndDate           string          `json:"end_date" binding:"required"`
+	RenewalType       string          `json:"renewal_type"`
+	RenewalTermMonths int             `json:"renewal_term_months"`
+	NoticePeriodDays  *int            `json:"notice_period_days"`
+	ReminderDays      *int            `json:"reminder_days"`
+	DocumentURL       string          `json:"document_url"`
+	DocumentName      string          `json:"document_name"`
+	OwnerID           *uuid.UUID      `json:"owner_id"`
+	Notes             string          `json:"notes"`
+}
+
+// RenewContractRequest represents a request to renew a contract. Without an
+// end date the contract is extended by its renewal term.
+type RenewContractRequest struct {
+	EndDate string           `json:"end_date"`
+	Value   *decimal.Decimal `json:"value"`
+}
+
+// ContractReminderResult summarises a renewal reminder run
+type ContractReminderResult struct {
+	AsOf          string            `json:"as_of"`
+	RemindersSent int               `json:"reminders_sent"`
+	AutoRenewed   int               `json:"auto_renewed"`
+	Expired       int               `json:"expired"`
+	Failures      []ContractFailure `json:"failures"`
+}
+
+// ContractFailure is a reminder that could not be sent; it is retried on the
+// next run
+type ContractFailure struct {
+	ContractID     uuid.UUID `json:"contract_id"`
+	ContractNumber string    `json:"contract_number"`
+	Error          string    `json:"error"`
+}
+
+// ExpiringContractsReport lists active contracts ending within a window
+type ExpiringContractsReport struct {
+	AsOf       string             `json:"as_of"`
+	Days       int                `json:"days"`
+	Contracts  []ExpiringContract `json:"contracts"`
+	TotalValue decimal.Decimal    `json:"total_value"`
+}
+
+// ExpiringContract is a contract in the expiring report with its billing
+type ExpiringContract struct {
+	models.Contract
+	DaysRemaining    int                        `json:"days_remaining"`
+	NoticeDeadline   string                     `json:"notice_deadline"`
+	NoticePeriodOver bool                       `json:"notice_period_over"`
+	Billing          repository.ContractBilling `json:"billing"`
+}
+
+// ContractService manages contracts, their linked recurring invoices and
+// renewals
+type ContractService interface {
+	Create(ctx context.Context, req ContractRequest) (*models.Contract, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.Contract, error)
+	Update(ctx context.Context, id uuid.UUID, req ContractRequest) (*models.Contract, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.ContractFilters) ([]models.Contract, error)
+	Renew(ctx context.Context, tenantID, id uuid.UUID, req RenewContractRequest) (*models.Contract, error)
+	Terminate(ctx context.Context, tenantID, id uuid.UUID) (*models.Contract, error)
+	LinkRecurringInvoice(ctx context.Context, tenantID, id, recurringID uuid.UUID) (*models.Contract, error)
+	UnlinkRecurringInvoice(ctx context.Context, tenantID, id, recurringID uuid.UUID) (*models.Contract, error)
+	RunReminders(ctx context.Context, tenantID uuid.UUID, asOf time.Time) (*ContractReminderResult, error)
+	ExpiringReport(ctx context.Context, tenantID uuid.UUID, asOf time.Time, days int) (*ExpiringContractsReport, error)
+}
+
+type contractService struct {
+	repo     repository.ContractRepository
+	notifier clients.NotificationClient
+}
+
+// NewContractService creates a new contract service
+func NewContractService(repo repository.ContractRepository, notifier clients.NotificationClient) ContractService {
+	return &contractService{
+		repo:     repo,
+		notifier: notifier,
+	}
+}
+
+func (s *contractService) Create(ctx context.Context, req ContractRequest) (*models.Contract, error) {
+	contract := &models.Contract{
+		TenantID:  req.TenantID,
+		Status:    models.ContractStatusActive,
+		CreatedBy: req.UserID,
+	}
+	if err := s.apply(contract, req); err != nil {
+		return nil, err
+	}
+
+	if contract.ContractNumber == "" {
+		number, err := s.repo.GetNextContractNumber(ctx, req.TenantID, fmt.Sprintf("CON-%s", time.Now().Format("0601")))
+		if err != nil {
+			return nil, err
+		}
+		contract.ContractNumber = number
+	}
+
+	if err := s.repo.Create(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+func (s *contractService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.Contract, error) {
+	contract, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrContractNotFound
+	}
+	return contract, nil
+}
+
+func (s *contractService) Update(ctx context.Context, id uuid.UUID, req ContractRequest) (*models.Contract, error) {
+	contract, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != models.ContractStatusActive {
+		return nil, ErrContractClosed
+	}
+
+	previousEndDate := contract.EndDate
+	if err := s.apply(contract, req); err != nil {
+		return nil, err
+	}
+	// A new end date starts a new reminder cycle
+	if !contract.EndDate.Equal(previousEndDate) {
+		contract.ReminderSentFor = nil
+	}
+
+	if err := s.repo.Update(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+func (s *contractService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	if _, err := s.Get(ctx, tenantID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, tenantID, id)
+}
+
+func (s *contractService) List(ctx context.Context, tenantID uuid.UUID, filters repository.ContractFilters) ([]models.Contract, error) {
+	return s.repo.List(ctx, tenantID, filters)
+}
+
+// Renew extends the contract for another term. Linked recurring invoices
+// that were set to stop at the old end date are extended with it.
+func (s *contractService) Renew(ctx context.Context, tenantID, id uuid.UUID, req RenewContractRequest) (*models.Contract, error) {
+	contract, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status == models.ContractStatusTerminated {
+		return nil, ErrContractClosed
+	}
+
+	endDate := contract.EndDate.AddDate(0, contract.RenewalTermMonths, 0)
+	if req.EndDate != "" {
+		endDate, err = time.Parse("2006-01-02", req.EndDate)
+		if err != nil || !endDate.After(contract.EndDate) {
+			return nil, ErrInvalidContract
+		}
+	} else if contract.RenewalTermMonths <= 0 {
+		return nil, ErrInvalidContract
+	}
+	if req.Value != nil {
+		if req.Value.IsNegative() {
+			return nil, ErrInvalidContract
+		}
+		contract.Value = *req.Value
+	}
+
+	if err := s.renew(ctx, contract, endDate); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// Terminate ends the contract early and cancels its linked recurring
+// invoices
+func (s *contractService) Terminate(ctx context.Context, tenantID, id uuid.UUID) (*models.Contract, error) {
+	contract, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != models.ContractStatusActive {
+		return nil, ErrContractClosed
+	}
+
+	contract.Status = models.ContractStatusTerminated
+	if err := s.repo.Close(ctx, contract, models.RecurringStatusCancelled); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, tenantID, id)
+}
+
+func (s *contractService) LinkRecurringInvoice(ctx context.Context, tenantID, id, recurringID uuid.UUID) (*models.Contract, error) {
+	contract, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != models.ContractStatusActive {
+		return nil, ErrContractClosed
+	}
+
+	if err := s.repo.SetRecurringInvoiceContract(ctx, tenantID, recurringID, &contract.ID); err != nil {
+		return nil, ErrRecurringInvoiceNotFound
+	}
+
+	return s.Get(ctx, tenantID, id)
+}
+
+func (s *contractService) UnlinkRecurringInvoice(ctx context.Context, tenantID, id, recurringID uuid.UUID) (*models.Contract, error) {
+	contract, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := false
+	for _, recurring := range contract.RecurringInvoices {
+		if recurring.ID == recurringID {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		return nil, ErrRecurringInvoiceNotFound
+	}
+
+	if err := s.repo.SetRecurringInvoiceContract(ctx, tenantID, recurringID, nil); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, tenantID, id)
+}
+
+// RunReminders sends a renewal reminder to each contract's owner once the
+// contract enters its reminder window, and handles contracts that have
+// passed their end date: auto-renewing ones roll over for another term and
+// the rest expire, completing their linked recurring invoices.
+func (s *contractService) RunReminders(ctx context.Context, tenantID uuid.UUID, asOf time.Time) (*ContractReminderResult, error) {
+	contracts, err := s.repo.ListDueForReminder(ctx, tenantID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContractReminderResult{
+		AsOf:     asOf.Format("2006-01-02"),
+		Failures: []ContractFailure{},
+	}
+
+	for i := range contracts {
+		contract := &contracts[i]
+
+		if daysBetween(asOf, contract.EndDate) < 0 {
+			if contract.RenewalType == models.RenewalTypeAuto && contract.RenewalTermMonths > 0 {
+				if err := s.renew(ctx, contract, contract.EndDate.AddDate(0, contract.RenewalTermMonths, 0)); err != nil {
+					return nil, err
+				}
+				result.AutoRenewed++
+			} else {
+				contract.Status = models.ContractStatusExpired
+				if err := s.repo.Close(ctx, contract, models.RecurringStatusCompleted); err != nil {
+					return nil, err
+				}
+				result.Expired++
+			}
+			continue
+		}
+
+		if !contract.ReminderDue(asOf) {
+			continue
+		}
+		if err := s.sendReminder(ctx, contract, asOf); err != nil {
+			result.Failures = append(result.Failures, ContractFailure{
+				ContractID:     contract.ID,
+				ContractNumber: contract.ContractNumber,
+				Error:          err.Error(),
+			})
+			continue
+		}
+		result.RemindersSent++
+	}
+
+	return result, nil
+}
+
+func (s *contractService) ExpiringReport(ctx context.Context, tenantID uuid.UUID, asOf time.Time, days int) (*ExpiringContractsReport, error) {
+	contracts, err := s.repo.ListEndingBetween(ctx, tenantID, asOf, asOf.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(contracts))
+	for i := range contracts {
+		ids[i] = contracts[i].ID
+	}
+	billing, err := s.repo.GetBillingSummary(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ExpiringContractsReport{
+		AsOf:       asOf.Format("2006-01-02"),
+		Days:       days,
+		Contracts:  make([]ExpiringContract, 0, len(contracts)),
+		TotalValue: decimal.Zero,
+	}
+	for _, contract := range contracts {
+		contractBilling, ok := billing[contract.ID]
+		if !ok {
+			contractBilling = repository.ContractBilling{
+				ContractID:      contract.ID,
+				RecurringAmount: decimal.Zero,
+				InvoicedAmount:  decimal.Zero,
+			}
+		}
+		report.Contracts = append(report.Contracts, ExpiringContract{
+			Contract:         contract,
+			DaysRemaining:    daysBetween(asOf, contract.EndDate),
+			NoticeDeadline:   contract.NoticeDeadline().Format("2006-01-02"),
+			NoticePeriodOver: daysBetween(asOf, contract.NoticeDeadline()) < 0,
+			Billing:          contractBilling,
+		})
+		report.TotalValue = report.TotalValue.Add(contract.Value)
+	}
+
+	return report, nil
+}
+
+func (s *contractService) renew(ctx context.Context, contract *models.Contract, endDate time.Time) error {
+	previousEndDate := contract.EndDate
+	contract.EndDate = endDate
+	contract.Status = models.ContractStatusActive
+	contract.RenewalCount++
+	contract.ReminderSentFor = nil
+
+	return s.repo.Renew(ctx, contract, previousEndDate)
+}
+
+func (s *contractService) sendReminder(ctx context.Context, contract *models.Contract, asOf time.Time) error {
+	recipient := contract.CreatedBy
+	if contract.OwnerID != nil {
+		recipient = *contract.OwnerID
+	}
+
+	var action string
+	switch contract.RenewalType {
+	case models.RenewalTypeAuto:
+		action = fmt.Sprintf("It renews automatically for %d months unless notice is given by %s.",
+			contract.RenewalTermMonths, contract.NoticeDeadline().Format("02 Jan 2006"))
+	default:
+		action = "Renew it before then to keep billing."
+	}
+
+	err := s.notifier.Send(ctx, clients.Notification{
+		TenantID: contract.TenantID.String(),
+		Channel:  clients.NotificationChannelInApp,
+		UserID:   &recipient,
+		Title:    fmt.Sprintf("Contract %s is up for renewal", contract.ContractNumber),
+		Message: fmt.Sprintf("%s with %s ends on %s (%d days). %s",
+			contract.Title, contract.PartyName, contract.EndDate.Format("02 Jan 2006"), daysBetween(asOf, contract.EndDate), action),
+		Type: "warning",
+		Link: "/contracts/" + contract.ID.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	endDate := contract.EndDate
+	contract.ReminderSentFor = &endDate
+	return s.repo.Update(ctx, contract)
+}
+
+// apply validates the request and copies it onto the contract
+func (s *contractService) apply(contract *models.Contract, req ContractRequest) error {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return ErrInvalidContract
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil || !endDate.After(startDate) {
+		return ErrInvalidContract
+	}
+	if strings.TrimSpace(req.Title) == "" || req.Value.IsNegative() {
+		return ErrInvalidContract
+	}
+
+	renewalType := models.ContractRenewalType(req.RenewalType)
+	switch renewalType {
+	case "":
+		renewalType = models.RenewalTypeManual
+	case models.RenewalTypeManual, models.RenewalTypeAuto, models.RenewalTypeNone:
+	default:
+		return ErrInvalidContract
+	}
+
+	renewalTermMonths := req.RenewalTermMonths
+	if renewalTermMonths == 0 {
+		renewalTermMonths = 12
+	}
+	noticePeriodDays, reminderDays := 30, 30
+	if req.NoticePeriodDays != nil {
+		noticePeriodDays = *req.NoticePeriodDays
+	}
+	if req.ReminderDays != nil {
+		reminderDays = *req.ReminderDays
+	}
+	if renewalTermMonths < 0 || noticePeriodDays < 0 || reminderDays < 0 {
+		return ErrInvalidContract
+	}
+
+	if number := strings.TrimSpace(req.ContractNumber); number != "" {
+		contract.ContractNumber = number
+	}
+	contract.Title = strings.TrimSpace(req.Title)
+	contract.PartyID = req.PartyID
+	contract.PartyName = req.PartyName
+	contract.Value = req.Value
+	contract.StartDate = startDate
+	contract.EndDate = endDate
+	contract.RenewalType = renewalType
+	contract.RenewalTermMonths = renewalTermMonths
+	contract.NoticePeriodDays = noticePeriodDays
+	contract.ReminderDays = reminderDays
+	contract.DocumentURL = req.DocumentURL
+	contract.DocumentName = req.DocumentName
+	contract.OwnerID = req.OwnerID
+	contract.Notes = req.Notes
+
+	return nil
+}
@@ -0,0 +1,570 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrCreditNoteNotFound       = errors.New("credit note not found")
+	ErrInvalidCreditNote        = errors.New("credit_note_date must be a date in YYYY-MM-DD format and reason one of goods_returned, defective_goods, price_reduction, discount_after_sale, overcharge or other")
+	ErrCreditNoteNotEditable    = errors.New("only draft credit notes can be edited or deleted")
+	ErrCreditNoteNotApprovable  = errors.New("only draft credit notes can be approved")
+	ErrCreditNoteNotCancelable  = errors.New("only draft credit notes, or approved ones not yet applied, can be cancelled")
+	ErrCreditNoteNotApplicable  = errors.New("only approved credit notes with a balance can be applied")
+	ErrInvalidCreditApplication = errors.New("invalid credit note application")
+	ErrCreditApplicationRaced   = errors.New("the credit note or an invoice was changed by someone else meanwhile; review them and try again")
+	ErrCreditApplicationMissing = errors.New("credit note application not found")
+)
+
+// CreditNoteItemRequest represents a line of a credit note
+type CreditNoteItemRequest struct {
+	ProductID   *uuid.UUID      `json:"product_id"`
+	Description string          `json:"description" binding:"required"`
+	HSNSACCode  string          `json:"hsn_sac_code"`
+	Quantity    decimal.Decimal `json:"quantity" binding:"required"`
+	UnitPrice   decimal.Decimal `json:"unit_price" binding:"required"`
+	CGSTRate    decimal.Decimal `json:"cgst_rate"`
+	SGSTRate    decimal.Decimal `json:"sgst_rate"`
+	IGSTRate    decimal.Decimal `json:"igst_rate"`
+	CessRate    decimal.Decimal `json:"cess_rate"`
+	GSTRate     decimal.Decimal `json:"gst_rate"`
+	AccountID   *uuid.UUID      `json:"account_id"`
+}
+
+// CreateCreditNoteRequest represents a request to create a credit note.
+// With an invoice ID the customer, place of supply and currency are taken
+// from that invoice.
+type CreateCreditNoteRequest struct {
+	TenantID       uuid.UUID               `json:"-"`
+	CreatedBy      uuid.UUID               `json:"-"`
+	Authorization  string                  `json:"-"` // Used to check the credit note's period is open
+	CreditNoteDate string                  `json:"credit_note_date" binding:"required"`
+	InvoiceID      *uuid.UUID              `json:"invoice_id"`
+	CustomerID     uuid.UUID               `json:"customer_id"`
+	CustomerName   string                  `json:"customer_name"`
+	Reason         string                  `json:"reason" binding:"required"`
+	ReasonDetail   string                  `json:"reason_detail"`
+	PlaceOfSupply  string                  `json:"place_of_supply"`
+	Currency       string                  `json:"currency"`
+	ExchangeRate   decimal.Decimal         `json:"exchange_rate"`
+	Items          []CreditNoteItemRequest `json:"items" binding:"required,min=1"`
+	Notes          string                  `json:"notes"`
+}
+
+// UpdateCreditNoteRequest represents a request to update a draft credit
+// note. Empty fields are left as they are; items are replaced when present.
+type UpdateCreditNoteRequest struct {
+	Authorization  string                  `json:"-"`
+	CreditNoteDate string                  `json:"credit_note_date"`
+	Reason         string                  `json:"reason"`
+	ReasonDetail   string                  `json:"reason_detail"`
+	PlaceOfSupply  string                  `json:"place_of_supply"`
+	Items          []CreditNoteItemRequest `json:"items"`
+	Notes          string                  `json:"notes"`
+}
+
+// ApplyCreditNoteRequest applies a credit note across one or more of the
+// customer's open invoices
+type ApplyCreditNoteRequest struct {
+	TenantID      uuid.UUID                `json:"-"`
+	AppliedBy     uuid.UUID                `json:"-"`
+	Authorization string                   `json:"-"`
+	Date          string                   `json:"date"` // YYYY-MM-DD, defaults to today
+	Applications  []CreditApplicationInput `json:"applications" binding:"required,min=1,dive"`
+	Notes         string                   `json:"notes"`
+}
+
+// CreditApplicationInput is the amount of a credit note applied to one
+// invoice
+type CreditApplicationInput struct {
+	InvoiceID uuid.UUID       `json:"invoice_id" binding:"required"`
+	Amount    decimal.Decimal `json:"amount" binding:"required"`
+}
+
+// ReturnCreditNote is a credit note as the outward return reports it. The
+// section it goes in depends on the customer's GSTIN and on the invoice it
+// was raised against, whose export type and value are included.
+type ReturnCreditNote struct {
+	models.CreditNote
+	CustomerGSTIN     string            `json:"customer_gstin,omitempty"`
+	InvoiceExportType models.ExportType `json:"invoice_export_type,omitempty"`
+	InvoiceValue      decimal.Decimal   `json:"invoice_value"`
+}
+
+// CreditNoteService manages credit notes issued to customers and their
+// application against the customers' open invoices, which lowers what the
+// invoices have left to pay
+type CreditNoteService interface {
+	Create(ctx context.Context, req CreateCreditNoteRequest) (*models.CreditNote, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.CreditNote, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.CreditNoteFilters) ([]models.CreditNote, int64, error)
+	Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateCreditNoteRequest) (*models.CreditNote, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID, authorization string) error
+	// Approve issues a draft credit note, making it available to apply
+	Approve(ctx context.Context, tenantID, id, approvedBy uuid.UUID) (*models.CreditNote, error)
+	Cancel(ctx context.Context, tenantID, id uuid.UUID) (*models.CreditNote, error)
+	// Apply applies an approved credit note to open invoices, never more
+	// than the note's balance in all or an invoice's balance on any one
+	Apply(ctx context.Context, id uuid.UUID, req ApplyCreditNoteRequest) (*models.CreditNote, error)
+	// RemoveApplication takes an application back off its invoice and
+	// returns the amount to the credit note's balance
+	RemoveApplication(ctx context.Context, tenantID, id, applicationID uuid.UUID, authorization string) (*models.CreditNote, error)
+	// ForReturnPeriod returns the credit notes issued in a month, such as
+	// 072025, for the outward return
+	ForReturnPeriod(ctx context.Context, tenantID uuid.UUID, period string) ([]ReturnCreditNote, error)
+}
+
+type creditNoteService struct {
+	creditNoteRepo repository.CreditNoteRepository
+	invoiceRepo    repository.InvoiceRepository
+	periodLock     PeriodLock
+	history        *timeline.Store
+}
+
+// NewCreditNoteService creates a new credit note service
+func NewCreditNoteService(creditNoteRepo repository.CreditNoteRepository, invoiceRepo repository.InvoiceRepository, periodLock PeriodLock, history *timeline.Store) CreditNoteService {
+	return &creditNoteService{
+		creditNoteRepo: creditNoteRepo,
+		invoiceRepo:    invoiceRepo,
+		periodLock:     periodLock,
+		history:        history,
+	}
+}
+
+func (s *creditNoteService) Create(ctx context.Context, req CreateCreditNoteRequest) (*models.CreditNote, error) {
+	creditNoteDate, err := time.Parse("2006-01-02", req.CreditNoteDate)
+	if err != nil || !isCreditNoteReason(req.Reason) {
+		return nil, ErrInvalidCreditNote
+	}
+	if err := s.periodLock.CheckOpen(ctx, req.TenantID, req.Authorization, creditNoteDate); err != nil {
+		return nil, err
+	}
+
+	note := &models.CreditNote{
+		ID:             uuid.New(),
+		TenantID:       req.TenantID,
+		CreditNoteDate: creditNoteDate,
+		CustomerID:     req.CustomerID,
+		CustomerName:   req.CustomerName,
+		Reason:         models.CreditNoteReason(req.Reason),
+		ReasonDetail:   req.ReasonDetail,
+		Status:         models.CreditNoteStatusDraft,
+		Currency:       req.Currency,
+		ExchangeRate:   req.ExchangeRate,
+		PlaceOfSupply:  req.PlaceOfSupply,
+		Notes:          req.Notes,
+		CreatedBy:      req.CreatedBy,
+	}
+
+	if req.InvoiceID != nil {
+		invoice, err := s.invoiceRepo.GetByID(ctx, *req.InvoiceID)
+		if err != nil || invoice.TenantID != req.TenantID {
+			return nil, ErrInvoiceNotFound
+		}
+		note.InvoiceID = &invoice.ID
+		note.InvoiceNumber = invoice.InvoiceNumber
+		note.CustomerID = invoice.CustomerID
+		note.CustomerName = invoice.CustomerName
+		note.Currency = invoice.Currency
+		note.ExchangeRate = invoice.ExchangeRate
+		if note.PlaceOfSupply == "" {
+			note.PlaceOfSupply = invoice.CustomerState
+		}
+	}
+	if note.CustomerID == uuid.Nil || note.CustomerName == "" {
+		return nil, fmt.Errorf("%w: customer_id and customer_name are required without an invoice_id", ErrInvalidCreditNote)
+	}
+	if note.Currency == "" {
+		note.Currency = "INR"
+	}
+	if !note.ExchangeRate.IsPositive() {
+		note.ExchangeRate = decimal.NewFromInt(1)
+	}
+
+	if note.Items, err = creditNoteItems(note.ID, req.Items); err != nil {
+		return nil, err
+	}
+	note.CalculateTotals()
+
+	prefix := fmt.Sprintf("CN-%s", time.Now().Format("0601"))
+	if note.CreditNoteNumber, err = s.creditNoteRepo.GetNextCreditNoteNumber(ctx, req.TenantID, prefix); err != nil {
+		return nil, err
+	}
+
+	if err := s.creditNoteRepo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *creditNoteService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.CreditNote, error) {
+	note, err := s.creditNoteRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrCreditNoteNotFound) {
+			return nil, ErrCreditNoteNotFound
+		}
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *creditNoteService) List(ctx context.Context, tenantID uuid.UUID, filters repository.CreditNoteFilters) ([]models.CreditNote, int64, error) {
+	return s.creditNoteRepo.List(ctx, tenantID, filters)
+}
+
+func (s *creditNoteService) Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateCreditNoteRequest) (*models.CreditNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.Status != models.CreditNoteStatusDraft {
+		return nil, ErrCreditNoteNotEditable
+	}
+	if err := s.periodLock.CheckOpen(ctx, note.TenantID, req.Authorization, note.CreditNoteDate); err != nil {
+		return nil, err
+	}
+
+	if req.CreditNoteDate != "" {
+		if note.CreditNoteDate, err = time.Parse("2006-01-02", req.CreditNoteDate); err != nil {
+			return nil, ErrInvalidCreditNote
+		}
+		if err := s.periodLock.CheckOpen(ctx, note.TenantID, req.Authorization, note.CreditNoteDate); err != nil {
+			return nil, err
+		}
+	}
+	if req.Reason != "" {
+		if !isCreditNoteReason(req.Reason) {
+			return nil, ErrInvalidCreditNote
+		}
+		note.Reason = models.CreditNoteReason(req.Reason)
+	}
+	if req.ReasonDetail != "" {
+		note.ReasonDetail = req.ReasonDetail
+	}
+	if req.PlaceOfSupply != "" {
+		note.PlaceOfSupply = req.PlaceOfSupply
+	}
+	note.Notes = req.Notes
+	if len(req.Items) > 0 {
+		if note.Items, err = creditNoteItems(note.ID, req.Items); err != nil {
+			return nil, err
+		}
+	}
+	note.CalculateTotals()
+
+	if err := s.creditNoteRepo.Update(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *creditNoteService) Delete(ctx context.Context, tenantID, id uuid.UUID, authorization string) error {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if note.Status != models.CreditNoteStatusDraft {
+		return ErrCreditNoteNotEditable
+	}
+	if err := s.periodLock.CheckOpen(ctx, note.TenantID, authorization, note.CreditNoteDate); err != nil {
+		return err
+	}
+	return s.creditNoteRepo.Delete(ctx, tenantID, id)
+}
+
+func (s *creditNoteService) Approve(ctx context.Context, tenantID, id, approvedBy uuid.UUID) (*models.CreditNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.Status != models.CreditNoteStatusDraft {
+		return nil, ErrCreditNoteNotApprovable
+	}
+
+	now := time.Now()
+	note.Status = models.CreditNoteStatusApproved
+	note.ApprovedAt = &now
+	note.ApprovedBy = &approvedBy
+	if err := s.creditNoteRepo.Save(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *creditNoteService) Cancel(ctx context.Context, tenantID, id uuid.UUID) (*models.CreditNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case note.Status == models.CreditNoteStatusDraft:
+	case note.Status == models.CreditNoteStatusApproved && note.AmountApplied.IsZero() && note.AmountRefunded.IsZero():
+	default:
+		return nil, ErrCreditNoteNotCancelable
+	}
+
+	note.Status = models.CreditNoteStatusCancelled
+	if err := s.creditNoteRepo.Save(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *creditNoteService) Apply(ctx context.Context, id uuid.UUID, req ApplyCreditNoteRequest) (*models.CreditNote, error) {
+	note, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.Status != models.CreditNoteStatusApproved || !note.BalanceAmount.IsPositive() {
+		return nil, ErrCreditNoteNotApplicable
+	}
+
+	appliedAt := time.Now()
+	if req.Date != "" {
+		if appliedAt, err = time.Parse("2006-01-02", req.Date); err != nil {
+			return nil, fmt.Errorf("%w: date must be in YYYY-MM-DD format", ErrInvalidCreditApplication)
+		}
+	}
+	if appliedAt.Before(note.CreditNoteDate) {
+		return nil, fmt.Errorf("%w: date is before the credit note's date", ErrInvalidCreditApplication)
+	}
+	if err := s.periodLock.CheckOpen(ctx, req.TenantID, req.Authorization, appliedAt); err != nil {
+		return nil, err
+	}
+
+	invoices := make([]*models.Invoice, 0, len(req.Applications))
+	balancesBefore := make(map[uuid.UUID]decimal.Decimal, len(req.Applications))
+	applications := make([]models.CreditNoteApplication, 0, len(req.Applications))
+	total := decimal.Zero
+	for _, input := range req.Applications {
+		if _, seen := balancesBefore[input.InvoiceID]; seen {
+			return nil, fmt.Errorf("%w: invoice %s is listed more than once", ErrInvalidCreditApplication, input.InvoiceID)
+		}
+
+		invoice, err := s.invoiceRepo.GetByID(ctx, input.InvoiceID)
+		if err != nil || invoice.TenantID != req.TenantID {
+			return nil, ErrInvoiceNotFound
+		}
+		if err := applicableInvoice(note, invoice); err != nil {
+			return nil, err
+		}
+		amount := input.Amount.Round(2)
+		if !amount.IsPositive() {
+			return nil, fmt.Errorf("%w: the amount applied to invoice %s must be more than zero", ErrInvalidCreditApplication, invoice.InvoiceNumber)
+		}
+		if amount.GreaterThan(invoice.BalanceDue) {
+			return nil, fmt.Errorf("%w: %s is more than the %s due on invoice %s", ErrInvalidCreditApplication,
+				amount.StringFixed(2), invoice.BalanceDue.StringFixed(2), invoice.InvoiceNumber)
+		}
+
+		balancesBefore[invoice.ID] = invoice.BalanceDue
+		invoice.AmountCredited = invoice.AmountCredited.Add(amount)
+		invoice.BalanceDue = invoice.BalanceDue.Sub(amount)
+		invoice.Status = creditedInvoiceStatus(invoice)
+		invoices = append(invoices, invoice)
+
+		applications = append(applications, models.CreditNoteApplication{
+			CreditNoteID:  note.ID,
+			InvoiceID:     invoice.ID,
+			InvoiceNumber: invoice.InvoiceNumber,
+			Amount:        amount,
+			AppliedAt:     appliedAt,
+			AppliedBy:     req.AppliedBy,
+			Notes:         req.Notes,
+		})
+		total = total.Add(amount)
+	}
+	if total.GreaterThan(note.BalanceAmount) {
+		return nil, fmt.Errorf("%w: %s in all is more than the credit note's balance of %s", ErrInvalidCreditApplication,
+			total.StringFixed(2), note.BalanceAmount.StringFixed(2))
+	}
+
+	appliedBefore := note.AmountApplied
+	note.AmountApplied = note.AmountApplied.Add(total)
+	note.CalculateTotals()
+	if !note.BalanceAmount.IsPositive() {
+		note.Status = models.CreditNoteStatusApplied
+	}
+
+	if err := s.creditNoteRepo.Apply(ctx, note, appliedBefore, invoices, balancesBefore, applications); err != nil {
+		if errors.Is(err, repository.ErrCreditNoteChanged) {
+			return nil, ErrCreditApplicationRaced
+		}
+		return nil, err
+	}
+
+	for i, invoice := range invoices {
+		record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventCreditApplied,
+			fmt.Sprintf("Credit note %s of %s applied", note.CreditNoteNumber, applications[i].Amount.StringFixed(2)), nil)
+	}
+	note.Applications = append(note.Applications, applications...)
+	return note, nil
+}
+
+func (s *creditNoteService) RemoveApplication(ctx context.Context, tenantID, id, applicationID uuid.UUID, authorization string) (*models.CreditNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i := range note.Applications {
+		if note.Applications[i].ID == applicationID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, ErrCreditApplicationMissing
+	}
+	application := note.Applications[index]
+	if err := s.periodLock.CheckOpen(ctx, tenantID, authorization, application.AppliedAt); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.invoiceRepo.GetByID(ctx, application.InvoiceID)
+	if err != nil || invoice.TenantID != tenantID {
+		return nil, ErrInvoiceNotFound
+	}
+
+	balanceBefore := invoice.BalanceDue
+	invoice.AmountCredited = invoice.AmountCredited.Sub(application.Amount)
+	invoice.BalanceDue = invoice.BalanceDue.Add(application.Amount)
+	invoice.Status = creditedInvoiceStatus(invoice)
+
+	appliedBefore := note.AmountApplied
+	note.AmountApplied = note.AmountApplied.Sub(application.Amount)
+	note.CalculateTotals()
+	if note.Status == models.CreditNoteStatusApplied {
+		note.Status = models.CreditNoteStatusApproved
+	}
+
+	if err := s.creditNoteRepo.RemoveApplication(ctx, note, appliedBefore, invoice, balanceBefore, &application); err != nil {
+		if errors.Is(err, repository.ErrCreditNoteChanged) {
+			return nil, ErrCreditApplicationRaced
+		}
+		return nil, err
+	}
+
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventCreditRemoved,
+		fmt.Sprintf("Credit note %s of %s removed", note.CreditNoteNumber, application.Amount.StringFixed(2)), nil)
+	note.Applications = append(note.Applications[:index], note.Applications[index+1:]...)
+	return note, nil
+}
+
+func (s *creditNoteService) ForReturnPeriod(ctx context.Context, tenantID uuid.UUID, period string) ([]ReturnCreditNote, error) {
+	from, err := time.Parse("012006", period)
+	if err != nil {
+		return nil, ErrInvalidReturnPeriod
+	}
+	notes, err := s.creditNoteRepo.ListIssued(ctx, tenantID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ReturnCreditNote, 0, len(notes))
+	invoices := make(map[uuid.UUID]*models.Invoice)
+	for _, note := range notes {
+		returned := ReturnCreditNote{CreditNote: note}
+		if note.InvoiceID != nil {
+			invoice, ok := invoices[*note.InvoiceID]
+			if !ok {
+				if invoice, err = s.invoiceRepo.GetByID(ctx, *note.InvoiceID); err != nil {
+					return nil, err
+				}
+				invoices[*note.InvoiceID] = invoice
+			}
+			returned.CustomerGSTIN = invoice.CustomerGSTIN
+			returned.InvoiceExportType = invoice.ExportType
+			returned.InvoiceValue = invoice.TotalAmount
+		}
+		result = append(result, returned)
+	}
+	return result, nil
+}
+
+// Helper functions
+
+func isCreditNoteReason(reason string) bool {
+	switch models.CreditNoteReason(reason) {
+	case models.CreditNoteReasonReturn, models.CreditNoteReasonDefective, models.CreditNoteReasonPriceReduction,
+		models.CreditNoteReasonDiscountAfter, models.CreditNoteReasonOvercharge, models.CreditNoteReasonOther:
+		return true
+	}
+	return false
+}
+
+func creditNoteItems(creditNoteID uuid.UUID, requests []CreditNoteItemRequest) ([]models.CreditNoteItem, error) {
+	items := make([]models.CreditNoteItem, 0, len(requests))
+	for n, itemReq := range requests {
+		if !itemReq.Quantity.IsPositive() || itemReq.UnitPrice.IsNegative() {
+			return nil, fmt.Errorf("%w: line %d needs a quantity more than zero and a unit price of zero or more", ErrInvalidCreditNote, n+1)
+		}
+		item := models.CreditNoteItem{
+			CreditNoteID: creditNoteID,
+			LineNumber:   n + 1,
+			ProductID:    itemReq.ProductID,
+			Description:  itemReq.Description,
+			HSNSACCode:   itemReq.HSNSACCode,
+			Quantity:     itemReq.Quantity,
+			UnitPrice:    itemReq.UnitPrice,
+			CGSTRate:     itemReq.CGSTRate,
+			SGSTRate:     itemReq.SGSTRate,
+			IGSTRate:     itemReq.IGSTRate,
+			CessRate:     itemReq.CessRate,
+			GSTRate:      itemReq.GSTRate,
+			AccountID:    itemReq.AccountID,
+		}
+		item.CalculateAmounts()
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// applicableInvoice checks a credit note can be applied to an invoice: an
+// issued, unpaid invoice of the same customer in the same currency
+func applicableInvoice(note *models.CreditNote, invoice *models.Invoice) error {
+	if invoice.CustomerID != note.CustomerID {
+		return fmt.Errorf("%w: invoice %s is not the credit note customer's", ErrInvalidCreditApplication, invoice.InvoiceNumber)
+	}
+	currency := invoice.Currency
+	if currency == "" {
+		currency = "INR"
+	}
+	if currency != note.Currency {
+		return fmt.Errorf("%w: invoice %s is in %s, the credit note in %s", ErrInvalidCreditApplication, invoice.InvoiceNumber, currency, note.Currency)
+	}
+	switch invoice.Status {
+	case models.InvoiceStatusDraft, models.InvoiceStatusCancelled, models.InvoiceStatusPaid:
+		return fmt.Errorf("%w: invoice %s is %s", ErrInvalidCreditApplication, invoice.InvoiceNumber, invoice.Status)
+	}
+	if !invoice.BalanceDue.IsPositive() {
+		return fmt.Errorf("%w: invoice %s has nothing left to pay", ErrInvalidCreditApplication, invoice.InvoiceNumber)
+	}
+	return nil
+}
+
+// creditedInvoiceStatus is an invoice's status once credit is applied to
+// or removed from it
+func creditedInvoiceStatus(invoice *models.Invoice) models.InvoiceStatus {
+	switch {
+	case !invoice.BalanceDue.IsPositive():
+		return models.InvoiceStatusPaid
+	case invoice.AmountPaid.IsPositive(), invoice.AmountCredited.IsPositive():
+		return models.InvoiceStatusPartial
+	case invoice.Status != models.InvoiceStatusPaid && invoice.Status != models.InvoiceStatusPartial:
+		return invoice.Status
+	case time.Now().After(invoice.DueDate):
+		return models.InvoiceStatusOverdue
+	}
+	return models.InvoiceStatusSent
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+// JobCalculateCreditScores is the queue job that rescores every customer
+const JobCalculateCreditScores = "credit_scores.calculate"
+
+// creditScoreWindowMonths is how much payment history a score looks at
+const creditScoreWindowMonths = 12
+
+// creditScoreMaxAge is how old a stored score may be before it is
+// recalculated when read
+const creditScoreMaxAge = 24 * time.Hour
+
+// creditLimitFactors scale the suggested credit limit by grade; unrated
+// customers get no suggestion
+var creditLimitFactors = map[string]decimal.Decimal{
+	models.CreditGradeA: decimal.NewFromFloat(1.25),
+	models.CreditGradeB: decimal.NewFromInt(1),
+	models.CreditGradeC: decimal.NewFromFloat(0.75),
+	models.CreditGradeD: decimal.NewFromFloat(0.5),
+}
+
+// CreditScoreService scores customers on how they pay: how long they take,
+// how often they pay late, bounced payments and disputed invoices
+type CreditScoreService interface {
+	// Get returns a customer's score, recalculating it when missing or
+	// more than a day old
+	Get(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerCreditScore, error)
+	List(ctx context.Context, tenantID uuid.UUID, grade string) ([]models.CustomerCreditScore, error)
+
+	// Recalculate rescores every customer the tenant invoiced in the
+	// scoring window and returns how many were scored
+	Recalculate(ctx context.Context, tenantID uuid.UUID) (int, error)
+
+	// RecalculateAll rescores the customers of every tenant
+	RecalculateAll(ctx context.Context) error
+}
+
+type creditScoreService struct {
+	repo repository.CreditScoreRepository
+}
+
+// NewCreditScoreService creates a new credit score service
+func NewCreditScoreService(repo repository.CreditScoreRepository) CreditScoreService {
+	return &creditScoreService{repo: repo}
+}
+
+func (s *creditScoreService) Get(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerCreditScore, error) {
+	score, err := s.repo.Get(ctx, tenantID, customerID)
+	if err == nil && time.Since(score.CalculatedAt) < creditScoreMaxAge {
+		return score, nil
+	}
+
+	scores, err := s.calculate(ctx, tenantID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		// Not invoiced in the scoring window
+		return &models.CustomerCreditScore{
+			TenantID:     tenantID,
+			CustomerID:   customerID,
+			Grade:        models.CreditGradeUnrated,
+			CalculatedAt: time.Now(),
+		}, nil
+	}
+	return &scores[0], nil
+}
+
+func (s *creditScoreService) List(ctx context.Context, tenantID uuid.UUID, grade string) ([]models.CustomerCreditScore, error) {
+	return s.repo.List(ctx, tenantID, grade)
+}
+
+func (s *creditScoreService) Recalculate(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	scores, err := s.calculate(ctx, tenantID, uuid.Nil)
+	if err != nil {
+		return 0, err
+	}
+	return len(scores), nil
+}
+
+func (s *creditScoreService) RecalculateAll(ctx context.Context) error {
+	since := time.Now().AddDate(0, -creditScoreWindowMonths, 0)
+	tenantIDs, err := s.repo.ListTenantsWithInvoices(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		if _, err := s.Recalculate(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// calculate scores the tenant's customers, or just customerID when set, and
+// stores the scores
+func (s *creditScoreService) calculate(ctx context.Context, tenantID, customerID uuid.UUID) ([]models.CustomerCreditScore, error) {
+	now := time.Now()
+	rows, err := s.repo.GetPaymentBehaviour(ctx, tenantID, customerID, now.AddDate(0, -creditScoreWindowMonths, 0), now)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]models.CustomerCreditScore, len(rows))
+	for i := range rows {
+		scores[i] = scoreCustomer(tenantID, &rows[i], now)
+	}
+
+	if err := s.repo.SaveMany(ctx, scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// scoreCustomer turns a customer's payment behaviour into a score out of
+// 100. Points are lost for the share of invoices paid late (up to 40), how
+// late they were on average (25, reached at 60 days), bounced payments (10
+// each, up to 20) and the share of invoices disputed (15).
+func scoreCustomer(tenantID uuid.UUID, row *repository.PaymentBehaviourRow, now time.Time) models.CustomerCreditScore {
+	monthlySales := row.TotalInvoiced.Div(decimal.NewFromInt(creditScoreWindowMonths)).Round(2)
+	score := models.CustomerCreditScore{
+		TenantID:             tenantID,
+		CustomerID:           row.CustomerID,
+		CustomerName:         row.CustomerName,
+		Grade:                models.CreditGradeUnrated,
+		InvoicesIssued:       row.InvoicesIssued,
+		InvoicesDue:          row.InvoicesDue,
+		InvoicesPaidLate:     row.InvoicesPaidLate,
+		LatePaymentRate:      decimal.Zero,
+		AverageDaysToPay:     decimal.NewFromFloat(row.AverageDaysToPay).Round(1),
+		AverageDaysLate:      decimal.NewFromFloat(row.AverageDaysLate).Round(1),
+		BouncedPayments:      row.BouncedPayments,
+		DisputedInvoices:     row.DisputedInvoices,
+		OverdueAmount:        row.OverdueAmount,
+		AverageMonthlySales:  monthlySales,
+		SuggestedCreditLimit: decimal.Zero,
+		CalculatedAt:         now,
+	}
+
+	// Nothing has fallen due yet, so there is no behaviour to judge
+	if row.InvoicesDue == 0 {
+		return score
+	}
+
+	lateShare := float64(row.InvoicesPaidLate) / float64(row.InvoicesDue)
+	disputedShare := float64(row.DisputedInvoices) / float64(row.InvoicesIssued)
+	penalty := lateShare*40 +
+		math.Min(row.AverageDaysLate, 60)/60*25 +
+		math.Min(float64(row.BouncedPayments)*10, 20) +
+		math.Min(disputedShare, 1)*15
+
+	score.Score = int(math.Max(0, math.Round(100-penalty)))
+	score.LatePaymentRate = decimal.NewFromFloat(lateShare).Round(4)
+	switch {
+	case score.Score >= 80:
+		score.Grade = models.CreditGradeA
+	case score.Score >= 60:
+		score.Grade = models.CreditGradeB
+	case score.Score >= 40:
+		score.Grade = models.CreditGradeC
+	default:
+		score.Grade = models.CreditGradeD
+	}
+
+	// A month of sales stays outstanding for the payment cycle, never
+	// taken as shorter than a month; rounded up to the next thousand
+	cycleMonths := decimal.NewFromFloat(math.Max(row.AverageDaysToPay, 30) / 30)
+	thousand := decimal.NewFromInt(1000)
+	score.SuggestedCreditLimit = score.AverageMonthlySales.
+		Mul(cycleMonths).
+		Mul(creditLimitFactors[score.Grade]).
+		Div(thousand).Ceil().Mul(thousand)
+
+	return score
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrDebitNoteNotFound      = errors.New("debit note not found")
+	ErrInvalidDebitNote       = errors.New("debit_note_date must be a date in YYYY-MM-DD format and reason one of goods_returned, defective_goods, short_supply, overcharge or other")
+	ErrDebitNoteNotEditable   = errors.New("only draft debit notes can be edited or deleted")
+	ErrDebitNoteNotIssuable   = errors.New("only draft debit notes can be issued")
+	ErrDebitNoteNotCancelable = errors.New("only draft or issued debit notes can be cancelled")
+	ErrBillNotDebitable       = errors.New("debit notes can only be raised against approved bills that are not under reverse charge")
+	ErrDebitNoteRaced         = errors.New("the debit note or its bill was changed by someone else meanwhile; review them and try again")
+)
+
+// DebitNoteItemRequest represents a line of a debit note, returning part
+// of a line of the bill. The unit price defaults to the bill line's rate.
+type DebitNoteItemRequest struct {
+	BillItemID  uuid.UUID       `json:"bill_item_id" binding:"required"`
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity" binding:"required"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+}
+
+// CreateDebitNoteRequest represents a request to create a debit note
+// against a bill. The vendor and tax rates are taken from the bill.
+type CreateDebitNoteRequest struct {
+	TenantID      uuid.UUID              `json:"-"`
+	CreatedBy     uuid.UUID              `json:"-"`
+	Authorization string                 `json:"-"` // Used to check the debit note's period is open
+	DebitNoteDate string                 `json:"debit_note_date" binding:"required"`
+	BillID        uuid.UUID              `json:"bill_id" binding:"required"`
+	Reason        string                 `json:"reason" binding:"required"`
+	ReasonDetail  string                 `json:"reason_detail"`
+	PlaceOfSupply string                 `json:"place_of_supply"` // Defaults to the vendor's state
+	Items         []DebitNoteItemRequest `json:"items" binding:"required,min=1,dive"`
+	Notes         string                 `json:"notes"`
+}
+
+// UpdateDebitNoteRequest represents a request to update a draft debit note.
+// Empty fields are left as they are; items are replaced when present.
+type UpdateDebitNoteRequest struct {
+	Authorization string                 `json:"-"`
+	DebitNoteDate string                 `json:"debit_note_date"`
+	Reason        string                 `json:"reason"`
+	ReasonDetail  string                 `json:"reason_detail"`
+	PlaceOfSupply string                 `json:"place_of_supply"`
+	Items         []DebitNoteItemRequest `json:"items" binding:"omitempty,dive"`
+	Notes         string                 `json:"notes"`
+}
+
+// DebitNoteService manages debit notes raised on vendors for purchase
+// returns. Issuing a note reverses the input tax credit claimed on the
+// returned share of the bill and sets the note off against what is left to
+// pay on it.
+type DebitNoteService interface {
+	Create(ctx context.Context, req CreateDebitNoteRequest) (*models.DebitNote, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.DebitNote, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.DebitNoteFilters) ([]models.DebitNote, int64, error)
+	Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateDebitNoteRequest) (*models.DebitNote, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID, authorization string) error
+	Issue(ctx context.Context, tenantID, id, issuedBy uuid.UUID, authorization string) (*models.DebitNote, error)
+	// Cancel cancels a draft or issued debit note, giving back to the bill
+	// what an issued note set off against it
+	Cancel(ctx context.Context, tenantID, id uuid.UUID, authorization string) (*models.DebitNote, error)
+	// ForReturnPeriod returns the debit notes issued in a month, such as
+	// 072025, for the outward return
+	ForReturnPeriod(ctx context.Context, tenantID uuid.UUID, period string) ([]models.DebitNote, error)
+}
+
+type debitNoteService struct {
+	debitNoteRepo repository.DebitNoteRepository
+	billRepo      repository.BillRepository
+	periodLock    PeriodLock
+	history       *timeline.Store
+}
+
+// NewDebitNoteService creates a new debit note service
+func NewDebitNoteService(debitNoteRepo repository.DebitNoteRepository, billRepo repository.BillRepository, periodLock PeriodLock, history *timeline.Store) DebitNoteService {
+	return &debitNoteService{
+		debitNoteRepo: debitNoteRepo,
+		billRepo:      billRepo,
+		periodLock:    periodLock,
+		history:       history,
+	}
+}
+
+func (s *debitNoteService) Create(ctx context.Context, req CreateDebitNoteRequest) (*models.DebitNote, error) {
+	debitNoteDate, err := time.Parse("2006-01-02", req.DebitNoteDate)
+	if err != nil || !isDebitNoteReason(req.Reason) {
+		return nil, ErrInvalidDebitNote
+	}
+	if err := s.periodLock.CheckOpen(ctx, req.TenantID, req.Authorization, debitNoteDate); err != nil {
+		return nil, err
+	}
+
+	bill, err := s.debitableBill(ctx, req.TenantID, req.BillID)
+	if err != nil {
+		return nil, err
+	}
+	if debitNoteDate.Before(bill.BillDate) {
+		return nil, fmt.Errorf("%w: debit_note_date is before the bill's date", ErrInvalidDebitNote)
+	}
+
+	note := &models.DebitNote{
+		ID:            uuid.New(),
+		TenantID:      req.TenantID,
+		DebitNoteDate: debitNoteDate,
+		VendorID:      bill.VendorID,
+		VendorName:    bill.VendorName,
+		VendorGSTIN:   bill.VendorGSTIN,
+		BillID:        bill.ID,
+		BillNumber:    bill.BillNumber,
+		VendorBillNo:  bill.VendorBillNo,
+		BillDate:      bill.BillDate,
+		Reason:        models.DebitNoteReason(req.Reason),
+		ReasonDetail:  req.ReasonDetail,
+		Status:        models.DebitNoteStatusDraft,
+		PlaceOfSupply: debitNotePlaceOfSupply(req.PlaceOfSupply, bill),
+		Notes:         req.Notes,
+		CreatedBy:     req.CreatedBy,
+	}
+	if len(note.PlaceOfSupply) > 2 {
+		return nil, fmt.Errorf("%w: place_of_supply must be a state code such as 27", ErrInvalidDebitNote)
+	}
+
+	returned, err := s.debitNoteRepo.ReturnedAmounts(ctx, bill.ID, note.ID)
+	if err != nil {
+		return nil, err
+	}
+	if note.Items, err = debitNoteItems(note.ID, bill, req.Items, returned); err != nil {
+		return nil, err
+	}
+	note.CalculateTotals()
+
+	prefix := fmt.Sprintf("DN-%s", time.Now().Format("0601"))
+	if note.DebitNoteNumber, err = s.debitNoteRepo.GetNextDebitNoteNumber(ctx, req.TenantID, prefix); err != nil {
+		return nil, err
+	}
+
+	if err := s.debitNoteRepo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *debitNoteService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.DebitNote, error) {
+	note, err := s.debitNoteRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDebitNoteNotFound) {
+			return nil, ErrDebitNoteNotFound
+		}
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *debitNoteService) List(ctx context.Context, tenantID uuid.UUID, filters repository.DebitNoteFilters) ([]models.DebitNote, int64, error) {
+	return s.debitNoteRepo.List(ctx, tenantID, filters)
+}
+
+func (s *debitNoteService) Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateDebitNoteRequest) (*models.DebitNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.Status != models.DebitNoteStatusDraft {
+		return nil, ErrDebitNoteNotEditable
+	}
+	if err := s.periodLock.CheckOpen(ctx, note.TenantID, req.Authorization, note.DebitNoteDate); err != nil {
+		return nil, err
+	}
+
+	if req.DebitNoteDate != "" {
+		if note.DebitNoteDate, err = time.Parse("2006-01-02", req.DebitNoteDate); err != nil {
+			return nil, ErrInvalidDebitNote
+		}
+		if note.DebitNoteDate.Before(note.BillDate) {
+			return nil, fmt.Errorf("%w: debit_note_date is before the bill's date", ErrInvalidDebitNote)
+		}
+		if err := s.periodLock.CheckOpen(ctx, note.TenantID, req.Authorization, note.DebitNoteDate); err != nil {
+			return nil, err
+		}
+	}
+	if req.Reason != "" {
+		if !isDebitNoteReason(req.Reason) {
+			return nil, ErrInvalidDebitNote
+		}
+		note.Reason = models.DebitNoteReason(req.Reason)
+	}
+	if req.ReasonDetail != "" {
+		note.ReasonDetail = req.ReasonDetail
+	}
+	if req.PlaceOfSupply != "" {
+		if len(req.PlaceOfSupply) > 2 {
+			return nil, fmt.Errorf("%w: place_of_supply must be a state code such as 27", ErrInvalidDebitNote)
+		}
+		note.PlaceOfSupply = req.PlaceOfSupply
+	}
+	note.Notes = req.Notes
+	if len(req.Items) > 0 {
+		bill, err := s.debitableBill(ctx, tenantID, note.BillID)
+		if err != nil {
+			return nil, err
+		}
+		returned, err := s.debitNoteRepo.ReturnedAmounts(ctx, bill.ID, note.ID)
+		if err != nil {
+			return nil, err
+		}
+		if note.Items, err = debitNoteItems(note.ID, bill, req.Items, returned); err != nil {
+			return nil, err
+		}
+	}
+	note.CalculateTotals()
+
+	if err := s.debitNoteRepo.Update(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *debitNoteService) Delete(ctx context.Context, tenantID, id uuid.UUID, authorization string) error {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if note.Status != models.DebitNoteStatusDraft {
+		return ErrDebitNoteNotEditable
+	}
+	if err := s.periodLock.CheckOpen(ctx, note.TenantID, authorization, note.DebitNoteDate); err != nil {
+		return err
+	}
+	return s.debitNoteRepo.Delete(ctx, tenantID, id)
+}
+
+func (s *debitNoteService) Issue(ctx context.Context, tenantID, id, issuedBy uuid.UUID, authorization string) (*models.DebitNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.Status != models.DebitNoteStatusDraft {
+		return nil, ErrDebitNoteNotIssuable
+	}
+	if err := s.periodLock.CheckOpen(ctx, note.TenantID, authorization, note.DebitNoteDate); err != nil {
+		return nil, err
+	}
+
+	bill, err := s.debitableBill(ctx, tenantID, note.BillID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other notes against the bill may have been issued since this one was
+	// drafted, so what is returned is checked again
+	returned, err := s.debitNoteRepo.ReturnedAmounts(ctx, bill.ID, note.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range note.Items {
+		returned[item.BillItemID] = returned[item.BillItemID].Add(item.Amount)
+	}
+	for _, billItem := range bill.Items {
+		if returned[billItem.ID].GreaterThan(billItem.Amount) {
+			return nil, fmt.Errorf("%w: more of %q would be returned than the bill charged for", ErrInvalidDebitNote, billItem.Description)
+		}
+	}
+
+	balanceBefore := bill.BalanceDue
+	adjusted := decimal.Min(note.TotalAmount, decimal.Max(bill.BalanceDue, decimal.Zero))
+	bill.AmountDebited = bill.AmountDebited.Add(adjusted)
+	bill.BalanceDue = bill.BalanceDue.Sub(adjusted)
+	bill.Status = debitedBillStatus(bill)
+
+	now := time.Now()
+	note.Status = models.DebitNoteStatusIssued
+	note.IssuedAt = &now
+	note.IssuedBy = &issuedBy
+	note.AmountAdjusted = adjusted
+	note.RefundDue = note.TotalAmount.Sub(adjusted)
+
+	if err := s.debitNoteRepo.Issue(ctx, note, bill, balanceBefore); err != nil {
+		if errors.Is(err, repository.ErrDebitNoteChanged) {
+			return nil, ErrDebitNoteRaced
+		}
+		return nil, err
+	}
+
+	record(ctx, s.history, billTimelineDocument(bill), timeline.EventDebitNoteIssued,
+		fmt.Sprintf("Debit note %s of %s issued, reversing %s of input tax credit", note.DebitNoteNumber,
+			note.TotalAmount.StringFixed(2), note.ITCReversedTotal().StringFixed(2)), nil)
+	return note, nil
+}
+
+func (s *debitNoteService) Cancel(ctx context.Context, tenantID, id uuid.UUID, authorization string) (*models.DebitNote, error) {
+	note, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	statusBefore := note.Status
+	if statusBefore != models.DebitNoteStatusDraft && statusBefore != models.DebitNoteStatusIssued {
+		return nil, ErrDebitNoteNotCancelable
+	}
+	if err := s.periodLock.CheckOpen(ctx, note.TenantID, authorization, note.DebitNoteDate); err != nil {
+		return nil, err
+	}
+
+	var bill *models.Bill
+	balanceBefore := decimal.Zero
+	if statusBefore == models.DebitNoteStatusIssued {
+		bill, err = s.billRepo.GetByID(ctx, note.BillID)
+		if err != nil || bill.TenantID != tenantID {
+			return nil, ErrBillNotFound
+		}
+		balanceBefore = bill.BalanceDue
+		bill.AmountDebited = bill.AmountDebited.Sub(note.AmountAdjusted)
+		bill.BalanceDue = bill.BalanceDue.Add(note.AmountAdjusted)
+		bill.Status = debitedBillStatus(bill)
+	}
+
+	note.Status = models.DebitNoteStatusCancelled
+	if err := s.debitNoteRepo.Cancel(ctx, note, statusBefore, bill, balanceBefore); err != nil {
+		if errors.Is(err, repository.ErrDebitNoteChanged) {
+			return nil, ErrDebitNoteRaced
+		}
+		return nil, err
+	}
+
+	if bill != nil {
+		record(ctx, s.history, billTimelineDocument(bill), timeline.EventDebitNoteCancelled,
+			fmt.Sprintf("Debit note %s of %s cancelled", note.DebitNoteNumber, note.TotalAmount.StringFixed(2)), nil)
+	}
+	return note, nil
+}
+
+func (s *debitNoteService) ForReturnPeriod(ctx context.Context, tenantID uuid.UUID, period string) ([]models.DebitNote, error) {
+	from, err := time.Parse("012006", period)
+	if err != nil {
+		return nil, ErrInvalidReturnPeriod
+	}
+	return s.debitNoteRepo.ListIssued(ctx, tenantID, from, from.AddDate(0, 1, 0))
+}
+
+// debitableBill returns the tenant's bill, provided debit notes can be
+// raised against it
+func (s *debitNoteService) debitableBill(ctx context.Context, tenantID, billID uuid.UUID) (*models.Bill, error) {
+	bill, err := s.billRepo.GetByID(ctx, billID)
+	if err != nil || bill.TenantID != tenantID {
+		return nil, ErrBillNotFound
+	}
+	switch bill.Status {
+	case models.BillStatusDraft, models.BillStatusPending, models.BillStatusCancelled:
+		return nil, ErrBillNotDebitable
+	}
+	// The GST on a reverse charge bill was paid by us on a self-invoice,
+	// not charged by the vendor, so there is nothing to claim back from them
+	if bill.URDReverseCharge {
+		return nil, ErrBillNotDebitable
+	}
+	return bill, nil
+}
+
+// Helper functions
+
+func isDebitNoteReason(reason string) bool {
+	switch models.DebitNoteReason(reason) {
+	case models.DebitNoteReasonReturn, models.DebitNoteReasonDefective, models.DebitNoteReasonShortage,
+		models.DebitNoteReasonOvercharge, models.DebitNoteReasonOther:
+		return true
+	}
+	return false
+}
+
+// debitNotePlaceOfSupply is the state code given, or else the vendor's,
+// from the first two digits of their GSTIN
+func debitNotePlaceOfSupply(placeOfSupply string, bill *models.Bill) string {
+	if placeOfSupply == "" && len(bill.VendorGSTIN) >= 2 {
+		return bill.VendorGSTIN[:2]
+	}
+	return placeOfSupply
+}
+
+// debitNoteItems builds the lines of a debit note from the bill lines they
+// return. returned is the value already returned against each bill line by
+// other notes; no line may take the total past what the bill charged.
+func debitNoteItems(debitNoteID uuid.UUID, bill *models.Bill, requests []DebitNoteItemRequest, returned map[uuid.UUID]decimal.Decimal) ([]models.DebitNoteItem, error) {
+	billItems := make(map[uuid.UUID]*models.BillItem, len(bill.Items))
+	for i := range bill.Items {
+		billItems[bill.Items[i].ID] = &bill.Items[i]
+	}
+
+	items := make([]models.DebitNoteItem, 0, len(requests))
+	for n, itemReq := range requests {
+		billItem, ok := billItems[itemReq.BillItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d is not for a line of bill %s", ErrInvalidDebitNote, n+1, bill.BillNumber)
+		}
+		unitPrice := itemReq.UnitPrice
+		if unitPrice.IsZero() {
+			unitPrice = billItem.Rate
+		}
+		if !itemReq.Quantity.IsPositive() || unitPrice.IsNegative() {
+			return nil, fmt.Errorf("%w: line %d needs a quantity more than zero and a unit price of zero or more", ErrInvalidDebitNote, n+1)
+		}
+
+		item := models.DebitNoteItem{
+			DebitNoteID: debitNoteID,
+			LineNumber:  n + 1,
+			Description: itemReq.Description,
+			Quantity:    itemReq.Quantity,
+			UnitPrice:   unitPrice,
+		}
+		item.TakeFromBillItem(billItem, bill.ITCEligible)
+
+		returned[billItem.ID] = returned[billItem.ID].Add(item.Amount)
+		if returned[billItem.ID].GreaterThan(billItem.Amount) {
+			return nil, fmt.Errorf("%w: line %d returns more of %q than the bill charged for", ErrInvalidDebitNote, n+1, billItem.Description)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// debitedBillStatus is a bill's status once a debit note is set off
+// against it or cancelled
+func debitedBillStatus(bill *models.Bill) models.BillStatus {
+	switch {
+	case !bill.BalanceDue.IsPositive():
+		return models.BillStatusPaid
+	case bill.AmountPaid.IsPositive(), bill.AmountDebited.IsPositive():
+		return models.BillStatusPartial
+	case bill.Status != models.BillStatusPaid && bill.Status != models.BillStatusPartial:
+		return bill.Status
+	case time.Now().After(bill.DueDate):
+		return models.BillStatusOverdue
+	}
+	return models.BillStatusApproved
+}
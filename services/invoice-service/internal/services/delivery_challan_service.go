@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrDeliveryChallanNotFound      = errors.New("delivery challan not found")
+	ErrInvalidDeliveryChallan       = errors.New("challan_date must be a date in YYYY-MM-DD format and purpose one of job_work, stock_transfer, supply_on_approval, liquid_gas or other")
+	ErrDeliveryChallanNotEditable   = errors.New("only draft delivery challans can be edited or deleted")
+	ErrDeliveryChallanNotIssuable   = errors.New("only draft delivery challans can be issued")
+	ErrDeliveryChallanNotCancelable = errors.New("only draft or issued delivery challans that are not invoiced can be cancelled")
+	ErrDeliveryChallanNotInvoicable = errors.New("only issued delivery challans can be invoiced")
+	ErrDeliveryChallanInvoiced      = repository.ErrDeliveryChallanInvoiced
+)
+
+// CreateDeliveryChallanRequest represents a request to create a delivery
+// challan
+type CreateDeliveryChallanRequest struct {
+	TenantID        uuid.UUID                  `json:"-"`
+	CreatedBy       uuid.UUID                  `json:"-"`
+	Purpose         string                     `json:"purpose" binding:"required"`
+	CustomerID      uuid.UUID                  `json:"customer_id"`
+	CustomerName    string                     `json:"customer_name" binding:"required"`
+	CustomerGSTIN   string                     `json:"customer_gstin"`
+	CustomerAddress string                     `json:"customer_address"`
+	CustomerState   string                     `json:"customer_state" binding:"required"`
+	CustomerEmail   string                     `json:"customer_email" binding:"omitempty,email"`
+	CustomerPhone   string                     `json:"customer_phone"`
+	ChallanDate     string                     `json:"challan_date" binding:"required"`
+	Items           []CreateInvoiceItemRequest `json:"items" binding:"required,min=1"`
+	TransportMode   string                     `json:"transport_mode" binding:"omitempty,oneof=road rail air ship"`
+	TransporterName string                     `json:"transporter_name"`
+	VehicleNumber   string                     `json:"vehicle_number"`
+	EWayBillNumber  string                     `json:"eway_bill_number"`
+	Notes           string                     `json:"notes"`
+}
+
+// UpdateDeliveryChallanRequest represents a request to update a draft
+// delivery challan. Empty fields are left as they are; items are replaced
+// when present.
+type UpdateDeliveryChallanRequest struct {
+	Purpose         string                     `json:"purpose"`
+	CustomerName    string                     `json:"customer_name"`
+	CustomerGSTIN   string                     `json:"customer_gstin"`
+	CustomerAddress string                     `json:"customer_address"`
+	CustomerState   string                     `json:"customer_state"`
+	CustomerEmail   string                     `json:"customer_email" binding:"omitempty,email"`
+	CustomerPhone   string                     `json:"customer_phone"`
+	ChallanDate     string                     `json:"challan_date"`
+	Items           []CreateInvoiceItemRequest `json:"items"`
+	TransportMode   string                     `json:"transport_mode" binding:"omitempty,oneof=road rail air ship"`
+	TransporterName string                     `json:"transporter_name"`
+	VehicleNumber   string                     `json:"vehicle_number"`
+	EWayBillNumber  string                     `json:"eway_bill_number"`
+	Notes           string                     `json:"notes"`
+}
+
+// InvoiceDeliveryChallanRequest bills the goods of an issued challan. With
+// an invoice ID the challan is linked to that invoice; without one a draft
+// invoice is raised from the challan's items.
+type InvoiceDeliveryChallanRequest struct {
+	TenantID      uuid.UUID  `json:"-"`
+	CreatedBy     uuid.UUID  `json:"-"`
+	Authorization string     `json:"-"` // Used to check the invoice's period is open
+	InvoiceID     *uuid.UUID `json:"invoice_id"`
+	InvoiceDate   string     `json:"invoice_date"` // YYYY-MM-DD, defaults to today
+	DueDate       string     `json:"due_date"`
+	PaymentTermID *uuid.UUID `json:"payment_term_id"`
+}
+
+// DeliveryChallanService manages delivery challans: the documents goods are
+// dispatched under for job work, stock transfer or supply on approval, with
+// their own numbering series, and the invoice that bills them later
+type DeliveryChallanService interface {
+	Create(ctx context.Context, req CreateDeliveryChallanRequest) (*models.DeliveryChallan, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.DeliveryChallan, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.DeliveryChallanFilters) ([]models.DeliveryChallan, int64, error)
+	Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateDeliveryChallanRequest) (*models.DeliveryChallan, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	// Issue marks a draft challan's goods dispatched
+	Issue(ctx context.Context, tenantID, id uuid.UUID) (*models.DeliveryChallan, error)
+	Cancel(ctx context.Context, tenantID, id uuid.UUID, reason string) (*models.DeliveryChallan, error)
+	// Invoice links an issued challan to the invoice that bills its goods,
+	// raising a draft invoice from it unless one is given, and returns the
+	// invoice
+	Invoice(ctx context.Context, id uuid.UUID, req InvoiceDeliveryChallanRequest) (*models.Invoice, error)
+}
+
+type deliveryChallanService struct {
+	challanRepo    repository.DeliveryChallanRepository
+	invoiceService InvoiceService
+}
+
+// NewDeliveryChallanService creates a new delivery challan service
+func NewDeliveryChallanService(challanRepo repository.DeliveryChallanRepository, invoiceService InvoiceService) DeliveryChallanService {
+	return &deliveryChallanService{
+		challanRepo:    challanRepo,
+		invoiceService: invoiceService,
+	}
+}
+
+func (s *deliveryChallanService) Create(ctx context.Context, req CreateDeliveryChallanRequest) (*models.DeliveryChallan, error) {
+	challanDate, err := time.Parse("2006-01-02", req.ChallanDate)
+	if err != nil || !models.IsChallanPurpose(req.Purpose) {
+		return nil, ErrInvalidDeliveryChallan
+	}
+
+	prefix := fmt.Sprintf("DC-%s", time.Now().Format("0601"))
+	challanNumber, err := s.challanRepo.GetNextChallanNumber(ctx, req.TenantID, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	challan := &models.DeliveryChallan{
+		ID:              uuid.New(),
+		TenantID:        req.TenantID,
+		ChallanNumber:   challanNumber,
+		Purpose:         req.Purpose,
+		CustomerID:      req.CustomerID,
+		CustomerName:    req.CustomerName,
+		CustomerGSTIN:   req.CustomerGSTIN,
+		CustomerAddress: req.CustomerAddress,
+		CustomerState:   req.CustomerState,
+		CustomerEmail:   req.CustomerEmail,
+		CustomerPhone:   req.CustomerPhone,
+		ChallanDate:     challanDate,
+		Status:          models.DeliveryChallanStatusDraft,
+		TransportMode:   req.TransportMode,
+		TransporterName: req.TransporterName,
+		VehicleNumber:   req.VehicleNumber,
+		EWayBillNumber:  req.EWayBillNumber,
+		Notes:           req.Notes,
+		CreatedBy:       req.CreatedBy,
+	}
+	challan.Items = challanItems(challan.ID, req.Items)
+	challan.CalculateTotals()
+
+	if err := s.challanRepo.Create(ctx, challan); err != nil {
+		return nil, err
+	}
+	return challan, nil
+}
+
+func (s *deliveryChallanService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.DeliveryChallan, error) {
+	challan, err := s.challanRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDeliveryChallanNotFound) {
+			return nil, ErrDeliveryChallanNotFound
+		}
+		return nil, err
+	}
+	return challan, nil
+}
+
+func (s *deliveryChallanService) List(ctx context.Context, tenantID uuid.UUID, filters repository.DeliveryChallanFilters) ([]models.DeliveryChallan, int64, error) {
+	return s.challanRepo.List(ctx, tenantID, filters)
+}
+
+func (s *deliveryChallanService) Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateDeliveryChallanRequest) (*models.DeliveryChallan, error) {
+	challan, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if challan.Status != models.DeliveryChallanStatusDraft {
+		return nil, ErrDeliveryChallanNotEditable
+	}
+
+	if req.Purpose != "" {
+		if !models.IsChallanPurpose(req.Purpose) {
+			return nil, ErrInvalidDeliveryChallan
+		}
+		challan.Purpose = req.Purpose
+	}
+	if req.CustomerName != "" {
+		challan.CustomerName = req.CustomerName
+	}
+	if req.CustomerGSTIN != "" {
+		challan.CustomerGSTIN = req.CustomerGSTIN
+	}
+	if req.CustomerAddress != "" {
+		challan.CustomerAddress = req.CustomerAddress
+	}
+	if req.CustomerState != "" {
+		challan.CustomerState = req.CustomerState
+	}
+	if req.CustomerEmail != "" {
+		challan.CustomerEmail = req.CustomerEmail
+	}
+	if req.CustomerPhone != "" {
+		challan.CustomerPhone = req.CustomerPhone
+	}
+	if req.ChallanDate != "" {
+		if challan.ChallanDate, err = time.Parse("2006-01-02", req.ChallanDate); err != nil {
+			return nil, ErrInvalidDeliveryChallan
+		}
+	}
+	if req.TransportMode != "" {
+		challan.TransportMode = req.TransportMode
+	}
+	if req.TransporterName != "" {
+		challan.TransporterName = req.TransporterName
+	}
+	if req.VehicleNumber != "" {
+		challan.VehicleNumber = req.VehicleNumber
+	}
+	if req.EWayBillNumber != "" {
+		challan.EWayBillNumber = req.EWayBillNumber
+	}
+	challan.Notes = req.Notes
+	if len(req.Items) > 0 {
+		challan.Items = challanItems(challan.ID, req.Items)
+	}
+	challan.CalculateTotals()
+
+	if err := s.challanRepo.Update(ctx, challan); err != nil {
+		return nil, err
+	}
+	return challan, nil
+}
+
+func (s *deliveryChallanService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	challan, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if challan.Status != models.DeliveryChallanStatusDraft {
+		return ErrDeliveryChallanNotEditable
+	}
+	return s.challanRepo.Delete(ctx, tenantID, id)
+}
+
+func (s *deliveryChallanService) Issue(ctx context.Context, tenantID, id uuid.UUID) (*models.DeliveryChallan, error) {
+	challan, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if challan.Status != models.DeliveryChallanStatusDraft {
+		return nil, ErrDeliveryChallanNotIssuable
+	}
+
+	now := time.Now()
+	challan.Status = models.DeliveryChallanStatusIssued
+	challan.IssuedAt = &now
+	if err := s.challanRepo.Save(ctx, challan); err != nil {
+		return nil, err
+	}
+	return challan, nil
+}
+
+func (s *deliveryChallanService) Cancel(ctx context.Context, tenantID, id uuid.UUID, reason string) (*models.DeliveryChallan, error) {
+	challan, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if challan.IsInvoiced() || (challan.Status != models.DeliveryChallanStatusDraft && challan.Status != models.DeliveryChallanStatusIssued) {
+		return nil, ErrDeliveryChallanNotCancelable
+	}
+
+	now := time.Now()
+	challan.Status = models.DeliveryChallanStatusCancelled
+	challan.CancelledAt = &now
+	challan.CancelReason = reason
+	if err := s.challanRepo.Save(ctx, challan); err != nil {
+		return nil, err
+	}
+	return challan, nil
+}
+
+func (s *deliveryChallanService) Invoice(ctx context.Context, id uuid.UUID, req InvoiceDeliveryChallanRequest) (*models.Invoice, error) {
+	challan, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if challan.IsInvoiced() {
+		return nil, ErrDeliveryChallanInvoiced
+	}
+	if challan.Status != models.DeliveryChallanStatusIssued {
+		return nil, ErrDeliveryChallanNotInvoicable
+	}
+
+	var invoice *models.Invoice
+	raised := req.InvoiceID == nil
+	if raised {
+		if invoice, err = s.raiseInvoice(ctx, challan, req); err != nil {
+			return nil, err
+		}
+	} else {
+		invoice, err = s.invoiceService.Get(ctx, *req.InvoiceID)
+		if err != nil || invoice.TenantID != req.TenantID {
+			return nil, ErrInvoiceNotFound
+		}
+		if invoice.Status == models.InvoiceStatusCancelled {
+			return nil, fmt.Errorf("%w: invoice %s is cancelled", ErrInvalidInvoice, invoice.InvoiceNumber)
+		}
+	}
+
+	now := time.Now()
+	challan.Status = models.DeliveryChallanStatusInvoiced
+	challan.InvoiceID = &invoice.ID
+	challan.InvoiceNumber = invoice.InvoiceNumber
+	challan.InvoicedAt = &now
+
+	if err := s.challanRepo.MarkInvoiced(ctx, challan); err != nil {
+		// Another request invoiced it first; drop the duplicate draft
+		if raised {
+			if delErr := s.invoiceService.Delete(ctx, invoice.ID, req.Authorization); delErr != nil {
+				log.Printf("Failed to delete duplicate invoice %s of delivery challan %s: %v", invoice.ID, challan.ID, delErr)
+			}
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// raiseInvoice creates a draft invoice for the challan's consignee with its
+// items and taxes
+func (s *deliveryChallanService) raiseInvoice(ctx context.Context, challan *models.DeliveryChallan, req InvoiceDeliveryChallanRequest) (*models.Invoice, error) {
+	invoiceDate := req.InvoiceDate
+	if invoiceDate == "" {
+		invoiceDate = time.Now().Format("2006-01-02")
+	}
+	invoiceReq := CreateInvoiceRequest{
+		TenantID:        req.TenantID,
+		CreatedBy:       req.CreatedBy,
+		Authorization:   req.Authorization,
+		CustomerID:      challan.CustomerID,
+		CustomerName:    challan.CustomerName,
+		CustomerGSTIN:   challan.CustomerGSTIN,
+		CustomerAddress: challan.CustomerAddress,
+		CustomerState:   challan.CustomerState,
+		CustomerEmail:   challan.CustomerEmail,
+		CustomerPhone:   challan.CustomerPhone,
+		InvoiceDate:     invoiceDate,
+		DueDate:         req.DueDate,
+		PaymentTermID:   req.PaymentTermID,
+		Notes:           fmt.Sprintf("Goods delivered under delivery challan %s dated %s", challan.ChallanNumber, challan.ChallanDate.Format("02/01/2006")),
+	}
+	for _, item := range challan.Items {
+		invoiceReq.Items = append(invoiceReq.Items, CreateInvoiceItemRequest{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
+		})
+	}
+	return s.invoiceService.Create(ctx, invoiceReq)
+}
+
+// Helper functions
+
+func challanItems(challanID uuid.UUID, requests []CreateInvoiceItemRequest) []models.DeliveryChallanItem {
+	items := make([]models.DeliveryChallanItem, 0, len(requests))
+	for n, itemReq := range requests {
+		item := models.DeliveryChallanItem{
+			DeliveryChallanID: challanID,
+			ProductID:         itemReq.ProductID,
+			Description:       itemReq.Description,
+			HSNCode:           itemReq.HSNCode,
+			Quantity:          itemReq.Quantity,
+			Unit:              itemReq.Unit,
+			Rate:              itemReq.Rate,
+			CGSTRate:          itemReq.CGSTRate,
+			SGSTRate:          itemReq.SGSTRate,
+			IGSTRate:          itemReq.IGSTRate,
+			CessRate:          itemReq.CessRate,
+			CessSpecificRate:  itemReq.CessSpecificRate,
+			SortOrder:         n,
+		}
+		item.CalculateAmounts()
+		items = append(items, item)
+	}
+	return items
+}
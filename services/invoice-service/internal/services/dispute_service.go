@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrDisputeNotFound      = errors.New("dispute not found")
+	ErrInvalidDispute       = errors.New("invalid dispute data")
+	ErrDisputeClosed        = errors.New("dispute is already closed")
+	ErrLineAlreadyDisputed  = errors.New("invoice line already has an open dispute")
+	ErrInvoiceNotDisputable = errors.New("only issued invoices with a balance due can be disputed")
+)
+
+// RaiseDisputeRequest represents a request to dispute an invoice or a line
+type RaiseDisputeRequest struct {
+	TenantID               uuid.UUID       `json:"-"`
+	RaisedBy               uuid.UUID       `json:"-"`
+	InvoiceItemID          *uuid.UUID      `json:"invoice_item_id"`
+	Amount                 decimal.Decimal `json:"amount"` // Defaults to the line total, or the balance due
+	Reason                 string          `json:"reason" binding:"required"`
+	ExpectedResolutionDate string          `json:"expected_resolution_date"`
+}
+
+// UpdateDisputeRequest changes an open dispute
+type UpdateDisputeRequest struct {
+	TenantID               uuid.UUID        `json:"-"`
+	UserID                 uuid.UUID        `json:"-"`
+	Amount                 *decimal.Decimal `json:"amount"`
+	ExpectedResolutionDate string           `json:"expected_resolution_date"`
+	Note                   string           `json:"note"`
+}
+
+// CloseDisputeRequest closes a dispute with an outcome
+type CloseDisputeRequest struct {
+	TenantID   uuid.UUID            `json:"-"`
+	UserID     uuid.UUID            `json:"-"`
+	Status     models.DisputeStatus `json:"status" binding:"required"` // resolved, rejected or withdrawn
+	Resolution string               `json:"resolution" binding:"required"`
+}
+
+// DisputeService manages disputes raised against invoices and keeps the
+// invoice's disputed amount in step with its open disputes
+type DisputeService interface {
+	Raise(ctx context.Context, invoiceID uuid.UUID, req RaiseDisputeRequest) (*models.InvoiceDispute, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceDispute, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.DisputeFilters) ([]models.InvoiceDispute, error)
+	Update(ctx context.Context, id uuid.UUID, req UpdateDisputeRequest) (*models.InvoiceDispute, error)
+	Close(ctx context.Context, id uuid.UUID, req CloseDisputeRequest) (*models.InvoiceDispute, error)
+}
+
+type disputeService struct {
+	repo        repository.DisputeRepository
+	invoiceRepo repository.InvoiceRepository
+}
+
+// NewDisputeService creates a new dispute service
+func NewDisputeService(repo repository.DisputeRepository, invoiceRepo repository.InvoiceRepository) DisputeService {
+	return &disputeService{
+		repo:        repo,
+		invoiceRepo: invoiceRepo,
+	}
+}
+
+func (s *disputeService) Raise(ctx context.Context, invoiceID uuid.UUID, req RaiseDisputeRequest) (*models.InvoiceDispute, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil || invoice.TenantID != req.TenantID {
+		return nil, ErrInvoiceNotFound
+	}
+
+	switch invoice.Status {
+	case models.InvoiceStatusDraft, models.InvoiceStatusPaid, models.InvoiceStatusCancelled:
+		return nil, ErrInvoiceNotDisputable
+	}
+	if !invoice.BalanceDue.IsPositive() {
+		return nil, ErrInvoiceNotDisputable
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, ErrInvalidDispute
+	}
+
+	amount := req.Amount
+	if req.InvoiceItemID != nil {
+		item := findInvoiceItem(invoice, *req.InvoiceItemID)
+		if item == nil {
+			return nil, ErrInvalidDispute
+		}
+		open, err := s.repo.HasOpenForItem(ctx, invoice.ID, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		if open {
+			return nil, ErrLineAlreadyDisputed
+		}
+		if amount.IsZero() {
+			amount = item.TotalAmount
+		}
+	} else if amount.IsZero() {
+		amount = invoice.BalanceDue
+	}
+	if !amount.IsPositive() || amount.GreaterThan(invoice.BalanceDue) {
+		return nil, ErrInvalidDispute
+	}
+
+	expected, err := parseOptionalDate(req.ExpectedResolutionDate)
+	if err != nil {
+		return nil, ErrInvalidDispute
+	}
+
+	dispute := &models.InvoiceDispute{
+		TenantID:               invoice.TenantID,
+		InvoiceID:              invoice.ID,
+		InvoiceItemID:          req.InvoiceItemID,
+		Amount:                 amount,
+		Reason:                 strings.TrimSpace(req.Reason),
+		ExpectedResolutionDate: expected,
+		Status:                 models.DisputeStatusOpen,
+		RaisedBy:               req.RaisedBy,
+	}
+	event := &models.InvoiceDisputeEvent{
+		Action:                 "opened",
+		Status:                 models.DisputeStatusOpen,
+		Amount:                 amount,
+		ExpectedResolutionDate: expected,
+		Note:                   dispute.Reason,
+		UserID:                 req.RaisedBy,
+	}
+
+	if err := s.repo.Create(ctx, dispute, event); err != nil {
+		return nil, err
+	}
+	if err := s.syncInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	dispute.History = []models.InvoiceDisputeEvent{*event}
+	return dispute, nil
+}
+
+func (s *disputeService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceDispute, error) {
+	dispute, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+	return dispute, nil
+}
+
+func (s *disputeService) List(ctx context.Context, tenantID uuid.UUID, filters repository.DisputeFilters) ([]models.InvoiceDispute, error) {
+	return s.repo.List(ctx, tenantID, filters)
+}
+
+// Update changes the amount or expected resolution date of an open dispute,
+// or adds a note to its history
+func (s *disputeService) Update(ctx context.Context, id uuid.UUID, req UpdateDisputeRequest) (*models.InvoiceDispute, error) {
+	dispute, err := s.repo.GetByID(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+	if dispute.Status != models.DisputeStatusOpen {
+		return nil, ErrDisputeClosed
+	}
+
+	invoice, err := s.invoiceRepo.GetByID(ctx, dispute.InvoiceID)
+	if err != nil {
+		return nil, ErrInvoiceNotFound
+	}
+
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() || req.Amount.GreaterThan(invoice.BalanceDue) {
+			return nil, ErrInvalidDispute
+		}
+		dispute.Amount = *req.Amount
+	}
+	if req.ExpectedResolutionDate != "" {
+		expected, err := parseOptionalDate(req.ExpectedResolutionDate)
+		if err != nil {
+			return nil, ErrInvalidDispute
+		}
+		dispute.ExpectedResolutionDate = expected
+	}
+
+	event := &models.InvoiceDisputeEvent{
+		Action:                 "updated",
+		Status:                 dispute.Status,
+		Amount:                 dispute.Amount,
+		ExpectedResolutionDate: dispute.ExpectedResolutionDate,
+		Note:                   req.Note,
+		UserID:                 req.UserID,
+	}
+	if err := s.repo.Update(ctx, dispute, event); err != nil {
+		return nil, err
+	}
+	if err := s.syncInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	dispute.History = append(dispute.History, *event)
+	return dispute, nil
+}
+
+// Close records the outcome of a dispute and releases its amount. Credit
+// notes for disputes resolved in the customer's favour are raised separately.
+func (s *disputeService) Close(ctx context.Context, id uuid.UUID, req CloseDisputeRequest) (*models.InvoiceDispute, error) {
+	if !req.Status.IsValidOutcome() || strings.TrimSpace(req.Resolution) == "" {
+		return nil, ErrInvalidDispute
+	}
+
+	dispute, err := s.repo.GetByID(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+	if dispute.Status != models.DisputeStatusOpen {
+		return nil, ErrDisputeClosed
+	}
+
+	invoice, err := s.invoiceRepo.GetByID(ctx, dispute.InvoiceID)
+	if err != nil {
+		return nil, ErrInvoiceNotFound
+	}
+
+	now := time.Now()
+	dispute.Status = req.Status
+	dispute.Resolution = strings.TrimSpace(req.Resolution)
+	dispute.ResolvedAt = &now
+
+	event := &models.InvoiceDisputeEvent{
+		Action: string(req.Status),
+		Status: req.Status,
+		Amount: dispute.Amount,
+		Note:   dispute.Resolution,
+		UserID: req.UserID,
+	}
+	if err := s.repo.Update(ctx, dispute, event); err != nil {
+		return nil, err
+	}
+	if err := s.syncInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	dispute.History = append(dispute.History, *event)
+	return dispute, nil
+}
+
+// syncInvoice recomputes the invoice's disputed amount from its open
+// disputes
+func (s *disputeService) syncInvoice(ctx context.Context, invoice *models.Invoice) error {
+	total, err := s.repo.SumOpen(ctx, invoice.ID)
+	if err != nil {
+		return err
+	}
+	return s.invoiceRepo.UpdateDisputed(ctx, invoice.ID, total.IsPositive(), total)
+}
+
+func findInvoiceItem(invoice *models.Invoice, itemID uuid.UUID) *models.InvoiceItem {
+	for i := range invoice.Items {
+		if invoice.Items[i].ID == itemID {
+			return &invoice.Items[i]
+		}
+	}
+	return nil
+}
+
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
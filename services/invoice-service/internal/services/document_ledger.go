@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// ErrDocumentNotPosted is returned when a sent invoice or approved bill
+// could not be posted to the ledger; the document is left as it was
+var ErrDocumentNotPosted = errors.New("unable to post the document to the ledger")
+
+// DocumentLedger posts invoices as they are sent and bills as they are
+// approved, with their round-off on the account of the rounding rule they
+// were totalled under
+type DocumentLedger interface {
+	// PostInvoice and PostBill post on behalf of the caller identified by
+	// authorization; documents finalized in the background, without a
+	// caller, are posted with the service's own credentials. A document
+	// already posted is not posted again.
+	PostInvoice(ctx context.Context, authorization string, invoice *models.Invoice) error
+	PostBill(ctx context.Context, authorization string, bill *models.Bill) error
+}
+
+type documentLedger struct {
+	bookkeepingClient clients.BookkeepingClient
+	credentials       *middleware.ServiceCredentials
+}
+
+// NewDocumentLedger creates a document ledger that posts to the bookkeeping
+// service
+func NewDocumentLedger(bookkeepingClient clients.BookkeepingClient, credentials *middleware.ServiceCredentials) DocumentLedger {
+	return &documentLedger{bookkeepingClient: bookkeepingClient, credentials: credentials}
+}
+
+func (l *documentLedger) PostInvoice(ctx context.Context, authorization string, invoice *models.Invoice) error {
+	posting := clients.DocumentPosting{
+		Kind:              string(models.DocumentTypeInvoice),
+		Date:              invoice.InvoiceDate.Format("2006-01-02"),
+		DocumentID:        &invoice.ID,
+		DocumentNumber:    invoice.InvoiceNumber,
+		PartyName:         invoice.CustomerName,
+		TaxableAmount:     invoice.TaxableAmount.InexactFloat64(),
+		TaxAmount:         invoice.TotalTax.InexactFloat64(),
+		TCSAmount:         invoice.TCSAmount.InexactFloat64(),
+		RoundOff:          invoice.RoundOff.InexactFloat64(),
+		TotalAmount:       invoice.TotalAmount.InexactFloat64(),
+		RoundingAccountID: invoice.RoundingAccountID,
+		SupportingDetails: roundingSupportingDetails("invoice_rounding", invoice.RoundingMode, invoice.RoundTo, invoice.TotalAmount, invoice.RoundOff,
+			clients.SupportingDocument{Type: "invoice", ID: &invoice.ID, Number: invoice.InvoiceNumber}),
+	}
+	if invoice.CustomerID != uuid.Nil {
+		posting.PartyID = &invoice.CustomerID
+	}
+	return l.post(ctx, authorization, invoice.TenantID, posting)
+}
+
+func (l *documentLedger) PostBill(ctx context.Context, authorization string, bill *models.Bill) error {
+	posting := clients.DocumentPosting{
+		Kind:              string(models.DocumentTypeBill),
+		Date:              bill.BillDate.Format("2006-01-02"),
+		DocumentID:        &bill.ID,
+		DocumentNumber:    bill.BillNumber,
+		PartyName:         bill.VendorName,
+		TaxableAmount:     bill.TaxableAmount.InexactFloat64(),
+		TaxAmount:         bill.TotalTax.InexactFloat64(),
+		RoundOff:          bill.RoundOff.InexactFloat64(),
+		TotalAmount:       bill.TotalAmount.InexactFloat64(),
+		ITCEligible:       bill.ITCEligible,
+		ReverseCharge:     bill.URDReverseCharge,
+		RoundingAccountID: bill.RoundingAccountID,
+		SupportingDetails: roundingSupportingDetails("bill_rounding", bill.RoundingMode, bill.RoundTo, bill.TotalAmount, bill.RoundOff,
+			clients.SupportingDocument{Type: "bill", ID: &bill.ID, Number: bill.BillNumber}),
+	}
+	if bill.VendorID != uuid.Nil {
+		posting.PartyID = &bill.VendorID
+	}
+	return l.post(ctx, authorization, bill.TenantID, posting)
+}
+
+func (l *documentLedger) post(ctx context.Context, authorization string, tenantID uuid.UUID, posting clients.DocumentPosting) error {
+	if authorization == "" {
+		var err error
+		if authorization, err = l.credentials.Authorization(tenantID.String()); err != nil {
+			return ErrDocumentNotPosted
+		}
+	}
+	if err := l.bookkeepingClient.PostDocument(ctx, authorization, posting); err != nil {
+		return ErrDocumentNotPosted
+	}
+	return nil
+}
+
+// roundingSupportingDetails records how a document's total was rounded
+func roundingSupportingDetails(calculation string, mode models.RoundingMode, roundTo, total, roundOff decimal.Decimal, document clients.SupportingDocument) *clients.SupportingDetails {
+	details := &clients.SupportingDetails{
+		Calculation:     calculation,
+		SourceDocuments: []clients.SupportingDocument{document},
+	}
+	if mode != "" && mode != models.RoundingModeNone {
+		details.Rounding = []clients.SupportingRounding{{
+			Mode:       string(mode),
+			RoundTo:    roundTo.InexactFloat64(),
+			Unrounded:  total.Sub(roundOff).InexactFloat64(),
+			Rounded:    total.InexactFloat64(),
+			Difference: roundOff.InexactFloat64(),
+		}}
+	}
+	return details
+}
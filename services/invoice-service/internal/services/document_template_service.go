@@ -0,0 +1,523 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrDocumentTemplateNotFound  = errors.New("document template not found")
+	ErrInvalidDocumentTemplate   = errors.New("a template needs a name, a party with its state and at least one line with a description and a positive quantity")
+	ErrDuplicateDocumentTemplate = errors.New("a template with this name already exists")
+	ErrTemplateDocumentType      = errors.New("template is not for this type of document")
+	ErrInvalidDocumentCopy       = errors.New("invalid document date")
+)
+
+// DocumentTemplateRequest creates or updates a template, either from the
+// content given or, on create, from an existing invoice or bill
+type DocumentTemplateRequest struct {
+	TenantID     uuid.UUID `json:"-"`
+	CreatedBy    uuid.UUID `json:"-"`
+	Name         string    `json:"name" binding:"required,max=200"`
+	DocumentType string    `json:"document_type" binding:"required,oneof=invoice bill"`
+	// The invoice or bill, of DocumentType, to copy the content of
+	FromDocumentID *uuid.UUID `json:"from_document_id"`
+
+	models.DocumentTemplateContent
+}
+
+// CopyDocumentRequest dates an invoice or bill duplicated from another or
+// raised from a template
+type CopyDocumentRequest struct {
+	TenantID      uuid.UUID `json:"-"`
+	CreatedBy     uuid.UUID `json:"-"`
+	Authorization string    `json:"-"`
+	Date          string    `json:"date"`     // YYYY-MM-DD; today by default
+	DueDate       string    `json:"due_date"` // By default as many days after Date as on the original
+	// The vendor's number of the new bill; never copied
+	VendorBillNo string `json:"vendor_bill_no"`
+	// Rupees per unit of currency on a foreign currency export invoice;
+	// the original's rate by default
+	ExchangeRate decimal.Decimal `json:"exchange_rate"`
+}
+
+// DocumentTemplateService keeps saved invoice and bill templates, and
+// fills in new invoices and bills from them or from earlier documents, for
+// repeat business without a recurring schedule. The requests it returns are
+// created through the invoice and bill services as entered ones are.
+type DocumentTemplateService interface {
+	List(ctx context.Context, tenantID uuid.UUID, documentType string) ([]models.DocumentTemplate, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.DocumentTemplate, error)
+	Create(ctx context.Context, req DocumentTemplateRequest) (*models.DocumentTemplate, error)
+	Update(ctx context.Context, id uuid.UUID, req DocumentTemplateRequest) (*models.DocumentTemplate, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+
+	// DuplicateInvoice and InvoiceFromTemplate copy the customer, lines
+	// and terms of an invoice or template into a new invoice's request.
+	// Numbers, references and payments are not copied.
+	DuplicateInvoice(ctx context.Context, invoiceID uuid.UUID, req CopyDocumentRequest) (*CreateInvoiceRequest, error)
+	InvoiceFromTemplate(ctx context.Context, templateID uuid.UUID, req CopyDocumentRequest) (*CreateInvoiceRequest, error)
+
+	// DuplicateBill and BillFromTemplate do the same for bills
+	DuplicateBill(ctx context.Context, billID uuid.UUID, req CopyDocumentRequest) (*CreateBillRequest, error)
+	BillFromTemplate(ctx context.Context, templateID uuid.UUID, req CopyDocumentRequest) (*CreateBillRequest, error)
+}
+
+type documentTemplateService struct {
+	templateRepo repository.DocumentTemplateRepository
+	invoiceRepo  repository.InvoiceRepository
+	billRepo     repository.BillRepository
+}
+
+// NewDocumentTemplateService creates a new document template service
+func NewDocumentTemplateService(templateRepo repository.DocumentTemplateRepository, invoiceRepo repository.InvoiceRepository, billRepo repository.BillRepository) DocumentTemplateService {
+	return &documentTemplateService{
+		templateRepo: templateRepo,
+		invoiceRepo:  invoiceRepo,
+		billRepo:     billRepo,
+	}
+}
+
+func (s *documentTemplateService) List(ctx context.Context, tenantID uuid.UUID, documentType string) ([]models.DocumentTemplate, error) {
+	return s.templateRepo.List(ctx, tenantID, documentType)
+}
+
+func (s *documentTemplateService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.DocumentTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrDocumentTemplateNotFound
+	}
+	return template, nil
+}
+
+func (s *documentTemplateService) Create(ctx context.Context, req DocumentTemplateRequest) (*models.DocumentTemplate, error) {
+	if req.FromDocumentID != nil {
+		content, err := s.documentContent(ctx, req.TenantID, req.DocumentType, *req.FromDocumentID)
+		if err != nil {
+			return nil, err
+		}
+		req.DocumentTemplateContent = *content
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateDocumentTemplate(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.templateRepo.GetByName(ctx, req.TenantID, req.DocumentType, req.Name); err == nil {
+		return nil, ErrDuplicateDocumentTemplate
+	}
+
+	template := &models.DocumentTemplate{
+		TenantID:                req.TenantID,
+		DocumentType:            req.DocumentType,
+		Name:                    req.Name,
+		DocumentTemplateContent: req.DocumentTemplateContent,
+		CreatedBy:               req.CreatedBy,
+	}
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Update replaces a template's name and content. Its document type stays
+// as it was saved.
+func (s *documentTemplateService) Update(ctx context.Context, id uuid.UUID, req DocumentTemplateRequest) (*models.DocumentTemplate, error) {
+	template, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	req.DocumentType = template.DocumentType
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateDocumentTemplate(req); err != nil {
+		return nil, err
+	}
+	if existing, err := s.templateRepo.GetByName(ctx, req.TenantID, req.DocumentType, req.Name); err == nil && existing.ID != template.ID {
+		return nil, ErrDuplicateDocumentTemplate
+	}
+
+	template.Name = req.Name
+	template.DocumentTemplateContent = req.DocumentTemplateContent
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Delete removes a template. Documents raised from it are not affected.
+func (s *documentTemplateService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	if _, err := s.Get(ctx, tenantID, id); err != nil {
+		return err
+	}
+	return s.templateRepo.Delete(ctx, tenantID, id)
+}
+
+func validateDocumentTemplate(req DocumentTemplateRequest) error {
+	if req.Name == "" || strings.TrimSpace(req.PartyName) == "" || strings.TrimSpace(req.PartyState) == "" || len(req.Items) == 0 {
+		return ErrInvalidDocumentTemplate
+	}
+	for _, item := range req.Items {
+		if strings.TrimSpace(item.Description) == "" || !item.Quantity.IsPositive() || item.Rate.IsNegative() {
+			return ErrInvalidDocumentTemplate
+		}
+	}
+	return nil
+}
+
+// documentContent is the content of the tenant's invoice or bill, for a
+// template saved from it
+func (s *documentTemplateService) documentContent(ctx context.Context, tenantID uuid.UUID, documentType string, id uuid.UUID) (*models.DocumentTemplateContent, error) {
+	if documentType == models.TemplateDocumentBill {
+		bill, err := s.findBill(ctx, tenantID, id)
+		if err != nil {
+			return nil, err
+		}
+		return billTemplateContent(bill), nil
+	}
+	invoice, err := s.findInvoice(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return invoiceTemplateContent(invoice), nil
+}
+
+func (s *documentTemplateService) DuplicateInvoice(ctx context.Context, invoiceID uuid.UUID, req CopyDocumentRequest) (*CreateInvoiceRequest, error) {
+	invoice, err := s.findInvoice(ctx, req.TenantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	date, dueDate, err := copyDates(req, invoice.InvoiceDate, invoice.DueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	content := invoiceTemplateContent(invoice)
+	create := invoiceFromContent(content, req, date)
+	// The new invoice falls due as the original did, unless a payment term
+	// sets its due date
+	if content.PaymentTermID == nil || req.DueDate != "" {
+		create.DueDate = dueDate
+	}
+	if invoice.IsExport() {
+		create.Export = &ExportDetailsRequest{
+			ExportType:         invoice.ExportType,
+			Currency:           invoice.Currency,
+			ExchangeRate:       invoice.ExchangeRate,
+			IECCode:            invoice.IECCode,
+			LUTReference:       invoice.LUTReference,
+			DestinationCountry: invoice.DestinationCountry,
+		}
+		if req.ExchangeRate.IsPositive() {
+			create.Export.ExchangeRate = req.ExchangeRate
+		}
+		// Lines of a foreign currency invoice are entered in its currency
+		if invoice.IsForeignCurrency() {
+			for i := range create.Items {
+				create.Items[i].Rate = invoice.Items[i].ForeignRate
+			}
+		}
+	}
+	return create, nil
+}
+
+func (s *documentTemplateService) InvoiceFromTemplate(ctx context.Context, templateID uuid.UUID, req CopyDocumentRequest) (*CreateInvoiceRequest, error) {
+	template, err := s.findTemplate(ctx, req.TenantID, templateID, models.TemplateDocumentInvoice)
+	if err != nil {
+		return nil, err
+	}
+	date, dueDate, err := templateDates(req, template)
+	if err != nil {
+		return nil, err
+	}
+
+	create := invoiceFromContent(&template.DocumentTemplateContent, req, date)
+	create.DueDate = dueDate
+	return create, nil
+}
+
+func (s *documentTemplateService) DuplicateBill(ctx context.Context, billID uuid.UUID, req CopyDocumentRequest) (*CreateBillRequest, error) {
+	bill, err := s.findBill(ctx, req.TenantID, billID)
+	if err != nil {
+		return nil, err
+	}
+	date, dueDate, err := copyDates(req, bill.BillDate, bill.DueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	create := billFromContent(billTemplateContent(bill), req, date)
+	create.DueDate = dueDate
+	return create, nil
+}
+
+func (s *documentTemplateService) BillFromTemplate(ctx context.Context, templateID uuid.UUID, req CopyDocumentRequest) (*CreateBillRequest, error) {
+	template, err := s.findTemplate(ctx, req.TenantID, templateID, models.TemplateDocumentBill)
+	if err != nil {
+		return nil, err
+	}
+	date, dueDate, err := templateDates(req, template)
+	if err != nil {
+		return nil, err
+	}
+
+	create := billFromContent(&template.DocumentTemplateContent, req, date)
+	create.DueDate = dueDate
+	return create, nil
+}
+
+func (s *documentTemplateService) findInvoice(ctx context.Context, tenantID, id uuid.UUID) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, id)
+	if err != nil || invoice.TenantID != tenantID {
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+func (s *documentTemplateService) findBill(ctx context.Context, tenantID, id uuid.UUID) (*models.Bill, error) {
+	bill, err := s.billRepo.GetByID(ctx, id)
+	if err != nil || bill.TenantID != tenantID {
+		return nil, ErrBillNotFound
+	}
+	return bill, nil
+}
+
+func (s *documentTemplateService) findTemplate(ctx context.Context, tenantID, id uuid.UUID, documentType string) (*models.DocumentTemplate, error) {
+	template, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if template.DocumentType != documentType {
+		return nil, ErrTemplateDocumentType
+	}
+	return template, nil
+}
+
+// copyDates returns the date of a document copied from one dated
+// originalDate and due on originalDue, and its due date, as YYYY-MM-DD
+func copyDates(req CopyDocumentRequest, originalDate, originalDue time.Time) (string, string, error) {
+	date, err := copyDate(req)
+	if err != nil {
+		return "", "", err
+	}
+	if req.DueDate != "" {
+		return date.Format("2006-01-02"), req.DueDate, nil
+	}
+	creditDays := int(originalDue.Sub(originalDate).Hours() / 24)
+	if creditDays < 0 {
+		creditDays = 0
+	}
+	return date.Format("2006-01-02"), date.AddDate(0, 0, creditDays).Format("2006-01-02"), nil
+}
+
+// templateDates returns the date of a document raised from the template,
+// and its due date when the template sets one
+func templateDates(req CopyDocumentRequest, template *models.DocumentTemplate) (string, string, error) {
+	date, err := copyDate(req)
+	if err != nil {
+		return "", "", err
+	}
+	dueDate := req.DueDate
+	if dueDate == "" && template.DueInDays > 0 {
+		dueDate = date.AddDate(0, 0, template.DueInDays).Format("2006-01-02")
+	}
+	return date.Format("2006-01-02"), dueDate, nil
+}
+
+func copyDate(req CopyDocumentRequest) (time.Time, error) {
+	if req.DueDate != "" {
+		if _, err := time.Parse("2006-01-02", req.DueDate); err != nil {
+			return time.Time{}, ErrInvalidDocumentCopy
+		}
+	}
+	if req.Date == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return time.Time{}, ErrInvalidDocumentCopy
+	}
+	return date, nil
+}
+
+func invoiceTemplateContent(invoice *models.Invoice) *models.DocumentTemplateContent {
+	content := &models.DocumentTemplateContent{
+		PartyID:       invoice.CustomerID,
+		PartyName:     invoice.CustomerName,
+		PartyGSTIN:    invoice.CustomerGSTIN,
+		PartyPAN:      invoice.CustomerPAN,
+		PartyAddress:  invoice.CustomerAddress,
+		PartyState:    invoice.CustomerState,
+		PartyEmail:    invoice.CustomerEmail,
+		PartyPhone:    invoice.CustomerPhone,
+		Items:         make(models.DocumentTemplateItems, 0, len(invoice.Items)),
+		DiscountType:  invoice.DiscountType,
+		DiscountValue: invoice.DiscountValue,
+		DueInDays:     int(invoice.DueDate.Sub(invoice.InvoiceDate).Hours() / 24),
+		Notes:         invoice.Notes,
+		PaymentTermID: invoice.PaymentTermID,
+		Terms:         invoice.Terms,
+		Language:      invoice.Language,
+		Tags:          append([]string{}, invoice.Tags...),
+	}
+	if content.DueInDays < 0 {
+		content.DueInDays = 0
+	}
+	for _, item := range invoice.Items {
+		content.Items = append(content.Items, models.DocumentTemplateItem{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
+		})
+	}
+	return content
+}
+
+func billTemplateContent(bill *models.Bill) *models.DocumentTemplateContent {
+	content := &models.DocumentTemplateContent{
+		PartyID:          bill.VendorID,
+		PartyName:        bill.VendorName,
+		PartyGSTIN:       bill.VendorGSTIN,
+		PartyPAN:         bill.VendorPAN,
+		PartyAddress:     bill.VendorAddress,
+		PartyState:       bill.VendorState,
+		PartyEmail:       bill.VendorEmail,
+		PartyPhone:       bill.VendorPhone,
+		Items:            make(models.DocumentTemplateItems, 0, len(bill.Items)),
+		DiscountType:     bill.DiscountType,
+		DiscountValue:    bill.DiscountValue,
+		DueInDays:        int(bill.DueDate.Sub(bill.BillDate).Hours() / 24),
+		Notes:            bill.Notes,
+		TDSApplicable:    bill.TDSApplicable,
+		TDSSection:       bill.TDSSection,
+		TDSRate:          bill.TDSRate,
+		ITCEligible:      bill.ITCEligible,
+		ITCCategory:      bill.ITCCategory,
+		URDReverseCharge: bill.URDReverseCharge,
+	}
+	if content.DueInDays < 0 {
+		content.DueInDays = 0
+	}
+	for _, item := range bill.Items {
+		content.Items = append(content.Items, models.DocumentTemplateItem{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			SACCode:          item.SACCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
+			ITCEligible:      item.ITCEligible,
+			ExpenseAccountID: item.ExpenseAccountID,
+			ExpenseCategory:  item.ExpenseCategory,
+		})
+	}
+	return content
+}
+
+func invoiceFromContent(content *models.DocumentTemplateContent, req CopyDocumentRequest, date string) *CreateInvoiceRequest {
+	create := &CreateInvoiceRequest{
+		TenantID:        req.TenantID,
+		CreatedBy:       req.CreatedBy,
+		Authorization:   req.Authorization,
+		CustomerID:      content.PartyID,
+		CustomerName:    content.PartyName,
+		CustomerGSTIN:   content.PartyGSTIN,
+		CustomerPAN:     content.PartyPAN,
+		CustomerAddress: content.PartyAddress,
+		CustomerState:   content.PartyState,
+		CustomerEmail:   content.PartyEmail,
+		CustomerPhone:   content.PartyPhone,
+		InvoiceDate:     date,
+		PaymentTermID:   content.PaymentTermID,
+		Items:           make([]CreateInvoiceItemRequest, 0, len(content.Items)),
+		DiscountType:    content.DiscountType,
+		DiscountValue:   content.DiscountValue,
+		Notes:           content.Notes,
+		Terms:           content.Terms,
+		Language:        content.Language,
+		Tags:            append([]string{}, content.Tags...),
+	}
+	for _, item := range content.Items {
+		create.Items = append(create.Items, CreateInvoiceItemRequest{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
+		})
+	}
+	return create
+}
+
+func billFromContent(content *models.DocumentTemplateContent, req CopyDocumentRequest, date string) *CreateBillRequest {
+	create := &CreateBillRequest{
+		TenantID:         req.TenantID,
+		CreatedBy:        req.CreatedBy,
+		Authorization:    req.Authorization,
+		VendorID:         content.PartyID,
+		VendorName:       content.PartyName,
+		VendorGSTIN:      content.PartyGSTIN,
+		VendorPAN:        content.PartyPAN,
+		VendorAddress:    content.PartyAddress,
+		VendorState:      content.PartyState,
+		VendorEmail:      content.PartyEmail,
+		VendorPhone:      content.PartyPhone,
+		VendorBillNo:     strings.TrimSpace(req.VendorBillNo),
+		BillDate:         date,
+		Items:            make([]CreateBillItemRequest, 0, len(content.Items)),
+		DiscountType:     content.DiscountType,
+		DiscountValue:    content.DiscountValue,
+		TDSApplicable:    content.TDSApplicable,
+		TDSSection:       content.TDSSection,
+		TDSRate:          content.TDSRate,
+		ITCEligible:      content.ITCEligible,
+		ITCCategory:      content.ITCCategory,
+		URDReverseCharge: content.URDReverseCharge,
+		Notes:            content.Notes,
+	}
+	for _, item := range content.Items {
+		create.Items = append(create.Items, CreateBillItemRequest{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			SACCode:          item.SACCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
+			ITCEligible:      item.ITCEligible,
+			ExpenseAccountID: item.ExpenseAccountID,
+			ExpenseCategory:  item.ExpenseCategory,
+		})
+	}
+	return create
+}
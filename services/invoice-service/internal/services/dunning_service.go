@@ -0,0 +1,592 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrDunningPolicyNotFound  = errors.New("dunning policy not found")
+	ErrInvalidDunningPolicy   = errors.New("invalid dunning policy")
+	ErrDuplicateDunningPolicy = errors.New("a dunning policy for this segment already exists")
+	ErrDunningPolicyInUse     = errors.New("dunning policy is assigned to one or more customers")
+	ErrCreditGradeTaken       = errors.New("another dunning policy already covers this credit grade")
+)
+
+// DunningPolicyRequest creates or updates a dunning policy
+type DunningPolicyRequest struct {
+	TenantID          uuid.UUID          `json:"-"`
+	Segment           string             `json:"segment" binding:"required"`
+	Name              string             `json:"name" binding:"required"`
+	ReminderDays      []int              `json:"reminder_days"`
+	EscalateAfterDays int                `json:"escalate_after_days"`
+	EscalationUserID  *uuid.UUID         `json:"escalation_user_id"`
+	LateFeeAfterDays  int                `json:"late_fee_after_days"`
+	LateFeeType       models.LateFeeType `json:"late_fee_type"`
+	LateFeeValue      decimal.Decimal    `json:"late_fee_value"`
+	LateFeeGSTRate    decimal.Decimal    `json:"late_fee_gst_rate"`
+	StopOnDispute     *bool              `json:"stop_on_dispute"`
+	CreditGrades      []string           `json:"credit_grades"` // A, B, C, D or unrated
+	IsDefault         bool               `json:"is_default"`
+	IsActive          *bool              `json:"is_active"`
+}
+
+// CustomerDunningRequest assigns a customer to a dunning segment
+type CustomerDunningRequest struct {
+	PolicyID         *uuid.UUID `json:"policy_id"` // null removes the customer from their segment
+	AccountManagerID *uuid.UUID `json:"account_manager_id"`
+}
+
+// DunningRunResult summarises a dunning run
+type DunningRunResult struct {
+	AsOf            string           `json:"as_of"`
+	InvoicesChecked int              `json:"invoices_checked"`
+	RemindersSent   int              `json:"reminders_sent"`
+	Escalations     int              `json:"escalations"`
+	LateFeesAdded   int              `json:"late_fees_added"`
+	LateFeeTotal    decimal.Decimal  `json:"late_fee_total"`
+	SkippedDisputed int              `json:"skipped_disputed"`
+	Failures        []DunningFailure `json:"failures"`
+}
+
+// DunningFailure is a dunning step that could not be taken; it is retried on
+// the next run
+type DunningFailure struct {
+	InvoiceID     uuid.UUID            `json:"invoice_id"`
+	InvoiceNumber string               `json:"invoice_number"`
+	Action        models.DunningAction `json:"action"`
+	Error         string               `json:"error"`
+}
+
+// DunningService chases overdue invoices according to the policy of the
+// customer's segment: reminders on a cadence, escalation to the account
+// manager and a late fee line
+type DunningService interface {
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]models.DunningPolicy, error)
+	GetPolicy(ctx context.Context, tenantID, id uuid.UUID) (*models.DunningPolicy, error)
+	CreatePolicy(ctx context.Context, req DunningPolicyRequest) (*models.DunningPolicy, error)
+	UpdatePolicy(ctx context.Context, id uuid.UUID, req DunningPolicyRequest) (*models.DunningPolicy, error)
+	DeletePolicy(ctx context.Context, tenantID, id uuid.UUID) error
+	GetCustomerProfile(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerDunningProfile, error)
+	SetCustomerProfile(ctx context.Context, tenantID, customerID uuid.UUID, req CustomerDunningRequest) (*models.CustomerDunningProfile, error)
+	Run(ctx context.Context, tenantID uuid.UUID, asOf time.Time) (*DunningRunResult, error)
+	History(ctx context.Context, tenantID, invoiceID uuid.UUID) ([]models.DunningEvent, error)
+}
+
+type dunningService struct {
+	repo         repository.DunningRepository
+	invoiceRepo  repository.InvoiceRepository
+	creditScores repository.CreditScoreRepository
+	notifier     clients.NotificationClient
+	messages     InvoiceMessageService
+	periodLock   PeriodLock
+}
+
+// NewDunningService creates a new dunning service
+func NewDunningService(
+	repo repository.DunningRepository,
+	invoiceRepo repository.InvoiceRepository,
+	creditScores repository.CreditScoreRepository,
+	notifier clients.NotificationClient,
+	messages InvoiceMessageService,
+	periodLock PeriodLock,
+) DunningService {
+	return &dunningService{
+		repo:         repo,
+		invoiceRepo:  invoiceRepo,
+		creditScores: creditScores,
+		notifier:     notifier,
+		messages:     messages,
+		periodLock:   periodLock,
+	}
+}
+
+func (s *dunningService) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]models.DunningPolicy, error) {
+	return s.repo.ListPolicies(ctx, tenantID)
+}
+
+func (s *dunningService) GetPolicy(ctx context.Context, tenantID, id uuid.UUID) (*models.DunningPolicy, error) {
+	policy, err := s.repo.GetPolicy(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrDunningPolicyNotFound
+	}
+	return policy, nil
+}
+
+func (s *dunningService) CreatePolicy(ctx context.Context, req DunningPolicyRequest) (*models.DunningPolicy, error) {
+	if err := validateDunningPolicy(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetPolicyBySegment(ctx, req.TenantID, req.Segment); err == nil {
+		return nil, ErrDuplicateDunningPolicy
+	}
+	if err := s.checkCreditGrades(ctx, req.TenantID, uuid.Nil, req.CreditGrades); err != nil {
+		return nil, err
+	}
+
+	policy := &models.DunningPolicy{TenantID: req.TenantID, StopOnDispute: true, IsActive: true}
+	applyDunningPolicyRequest(policy, req)
+
+	if policy.IsDefault {
+		if err := s.repo.ClearDefaultPolicy(ctx, req.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.CreatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *dunningService) UpdatePolicy(ctx context.Context, id uuid.UUID, req DunningPolicyRequest) (*models.DunningPolicy, error) {
+	if err := validateDunningPolicy(req); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetPolicy(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrDunningPolicyNotFound
+	}
+	if existing, err := s.repo.GetPolicyBySegment(ctx, req.TenantID, req.Segment); err == nil && existing.ID != policy.ID {
+		return nil, ErrDuplicateDunningPolicy
+	}
+	if err := s.checkCreditGrades(ctx, req.TenantID, policy.ID, req.CreditGrades); err != nil {
+		return nil, err
+	}
+
+	applyDunningPolicyRequest(policy, req)
+
+	if policy.IsDefault {
+		if err := s.repo.ClearDefaultPolicy(ctx, req.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// DeletePolicy removes a policy once no customer is in its segment
+func (s *dunningService) DeletePolicy(ctx context.Context, tenantID, id uuid.UUID) error {
+	if _, err := s.repo.GetPolicy(ctx, tenantID, id); err != nil {
+		return ErrDunningPolicyNotFound
+	}
+
+	inUse, err := s.repo.CountProfiles(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if inUse > 0 {
+		return ErrDunningPolicyInUse
+	}
+
+	return s.repo.DeletePolicy(ctx, tenantID, id)
+}
+
+func (s *dunningService) GetCustomerProfile(ctx context.Context, tenantID, customerID uuid.UUID) (*models.CustomerDunningProfile, error) {
+	profile, err := s.repo.GetProfile(ctx, tenantID, customerID)
+	if err != nil {
+		return nil, ErrDunningPolicyNotFound
+	}
+	return profile, nil
+}
+
+// SetCustomerProfile assigns the customer's segment and account manager; a
+// nil policy returns the customer to the tenant's default policy
+func (s *dunningService) SetCustomerProfile(ctx context.Context, tenantID, customerID uuid.UUID, req CustomerDunningRequest) (*models.CustomerDunningProfile, error) {
+	if req.PolicyID == nil {
+		return nil, s.repo.DeleteProfile(ctx, tenantID, customerID)
+	}
+
+	policy, err := s.repo.GetPolicy(ctx, tenantID, *req.PolicyID)
+	if err != nil {
+		return nil, ErrDunningPolicyNotFound
+	}
+
+	profile, err := s.repo.GetProfile(ctx, tenantID, customerID)
+	if err != nil {
+		profile = &models.CustomerDunningProfile{TenantID: tenantID, CustomerID: customerID}
+	}
+	profile.PolicyID = policy.ID
+	profile.AccountManagerID = req.AccountManagerID
+
+	if err := s.repo.SaveProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	profile.Policy = policy
+	return profile, nil
+}
+
+// Run takes the dunning steps due on asOf for every outstanding invoice of
+// the tenant. Each step is recorded so it is taken once per invoice; steps
+// whose notification fails are reported and retried on the next run.
+func (s *dunningService) Run(ctx context.Context, tenantID uuid.UUID, asOf time.Time) (*DunningRunResult, error) {
+	profiles, err := s.repo.ListProfiles(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	byCustomer := make(map[uuid.UUID]*models.CustomerDunningProfile, len(profiles))
+	for i := range profiles {
+		byCustomer[profiles[i].CustomerID] = &profiles[i]
+	}
+	defaultPolicy, _ := s.repo.GetDefaultPolicy(ctx, tenantID)
+
+	// Customers outside a segment fall to the policy for their credit grade
+	policies, err := s.repo.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	byGrade := make(map[string]*models.DunningPolicy)
+	for i := range policies {
+		if !policies[i].IsActive {
+			continue
+		}
+		for _, grade := range policies[i].CreditGrades {
+			byGrade[grade] = &policies[i]
+		}
+	}
+	grades := map[uuid.UUID]string{}
+	if len(byGrade) > 0 {
+		if grades, err = s.creditScores.ListGrades(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	invoices, err := s.repo.ListOutstandingInvoices(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DunningRunResult{
+		AsOf:         asOf.Format("2006-01-02"),
+		LateFeeTotal: decimal.Zero,
+		Failures:     []DunningFailure{},
+	}
+
+	for i := range invoices {
+		invoice := &invoices[i]
+
+		profile := byCustomer[invoice.CustomerID]
+		policy := defaultPolicy
+		if profile != nil && profile.Policy != nil {
+			policy = profile.Policy
+		} else if gradePolicy, ok := byGrade[grades[invoice.CustomerID]]; ok {
+			policy = gradePolicy
+		}
+		if policy == nil || !policy.IsActive {
+			continue
+		}
+
+		result.InvoicesChecked++
+		// Only the undisputed part of the balance is chased, and nothing at
+		// all under a policy that stops on dispute
+		if (invoice.Disputed && policy.StopOnDispute) || !invoice.CollectibleAmount().IsPositive() {
+			result.SkippedDisputed++
+			continue
+		}
+
+		if err := s.dunInvoice(ctx, invoice, policy, profile, asOf, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (s *dunningService) History(ctx context.Context, tenantID, invoiceID uuid.UUID) ([]models.DunningEvent, error) {
+	return s.repo.ListEvents(ctx, tenantID, invoiceID)
+}
+
+// dunInvoice takes the steps due for one invoice. The late fee goes first so
+// that a reminder sent on the same day quotes the new balance.
+func (s *dunningService) dunInvoice(ctx context.Context, invoice *models.Invoice, policy *models.DunningPolicy, profile *models.CustomerDunningProfile, asOf time.Time, result *DunningRunResult) error {
+	daysOverdue := daysBetween(invoice.DueDate, asOf)
+
+	if daysOverdue > 0 && (invoice.Status == models.InvoiceStatusSent || invoice.Status == models.InvoiceStatusViewed) {
+		invoice.Status = models.InvoiceStatusOverdue
+		if err := s.invoiceRepo.Update(ctx, invoice); err != nil {
+			return err
+		}
+	}
+
+	if policy.ChargesLateFee() && daysOverdue >= policy.LateFeeAfterDays {
+		done, err := s.repo.HasEvent(ctx, invoice.ID, models.DunningActionLateFee, policy.LateFeeAfterDays)
+		if err != nil {
+			return err
+		}
+		// A late fee is added to the invoice, so the ledger must still have
+		// the invoice's period open
+		if !done {
+			if err := s.periodLock.CheckOpen(ctx, invoice.TenantID, "", invoice.InvoiceDate); err != nil {
+				result.fail(invoice, models.DunningActionLateFee, err)
+			} else {
+				fee, err := s.addLateFee(ctx, invoice, policy, daysOverdue)
+				if err != nil {
+					return err
+				}
+				result.LateFeesAdded++
+				result.LateFeeTotal = result.LateFeeTotal.Add(fee)
+			}
+		}
+	}
+
+	if step, ok := policy.DueReminder(daysOverdue); ok {
+		done, err := s.repo.HasEvent(ctx, invoice.ID, models.DunningActionReminder, step)
+		if err != nil {
+			return err
+		}
+		if !done {
+			if err := s.sendReminder(ctx, invoice, policy, step, daysOverdue); err != nil {
+				result.fail(invoice, models.DunningActionReminder, err)
+			} else {
+				result.RemindersSent++
+			}
+		}
+	}
+
+	if policy.EscalateAfterDays > 0 && daysOverdue >= policy.EscalateAfterDays {
+		done, err := s.repo.HasEvent(ctx, invoice.ID, models.DunningActionEscalation, policy.EscalateAfterDays)
+		if err != nil {
+			return err
+		}
+		if !done {
+			if err := s.escalate(ctx, invoice, policy, profile, daysOverdue); err != nil {
+				result.fail(invoice, models.DunningActionEscalation, err)
+			} else {
+				result.Escalations++
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *dunningService) addLateFee(ctx context.Context, invoice *models.Invoice, policy *models.DunningPolicy, daysOverdue int) (decimal.Decimal, error) {
+	fee := policy.LateFee(invoice.CollectibleAmount())
+	invoice.AddLateFee(fmt.Sprintf("Late payment fee (%d days overdue)", daysOverdue), fee, policy.LateFeeGSTRate)
+
+	if err := s.invoiceRepo.Update(ctx, invoice); err != nil {
+		return decimal.Zero, err
+	}
+
+	return fee, s.repo.CreateEvent(ctx, &models.DunningEvent{
+		TenantID:    invoice.TenantID,
+		InvoiceID:   invoice.ID,
+		Action:      models.DunningActionLateFee,
+		Step:        policy.LateFeeAfterDays,
+		PolicyID:    policy.ID,
+		DaysOverdue: daysOverdue,
+		Amount:      fee,
+	})
+}
+
+func (s *dunningService) sendReminder(ctx context.Context, invoice *models.Invoice, policy *models.DunningPolicy, step, daysOverdue int) error {
+	if invoice.CustomerEmail == "" && invoice.CustomerPhone == "" {
+		return errors.New("invoice has no customer email or phone")
+	}
+
+	var message string
+	switch {
+	case daysOverdue < 0:
+		message = fmt.Sprintf("Invoice %s for %s is due on %s.",
+			invoice.InvoiceNumber, models.FormatINR(invoice.CollectibleAmount()), invoice.DueDate.Format("02 Jan 2006"))
+	case daysOverdue == 0:
+		message = fmt.Sprintf("Invoice %s for %s is due today.",
+			invoice.InvoiceNumber, models.FormatINR(invoice.CollectibleAmount()))
+	default:
+		message = fmt.Sprintf("Invoice %s for %s was due on %s and is %d days overdue.",
+			invoice.InvoiceNumber, models.FormatINR(invoice.CollectibleAmount()), invoice.DueDate.Format("02 Jan 2006"), daysOverdue)
+	}
+
+	var recipients []string
+	if invoice.CustomerEmail != "" {
+		err := s.notifier.Send(ctx, clients.Notification{
+			TenantID: invoice.TenantID.String(),
+			Channel:  clients.NotificationChannelEmail,
+			Email:    invoice.CustomerEmail,
+			Title:    fmt.Sprintf("Payment reminder: invoice %s", invoice.InvoiceNumber),
+			Message:  message,
+			Type:     "warning",
+			Language: invoice.Language,
+		})
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, invoice.CustomerEmail)
+	}
+
+	// The reminder also goes to the customer's phone on the tenant's
+	// reminder channels; once it is emailed, that is best effort
+	deliveries, err := s.messages.SendReminder(ctx, invoice, daysOverdue)
+	if err != nil {
+		if len(recipients) == 0 && len(deliveries) == 0 {
+			return err
+		}
+		log.Printf("Failed to message reminder for invoice %s: %v", invoice.ID, err)
+	}
+	if len(deliveries) > 0 {
+		recipients = append(recipients, invoice.CustomerPhone)
+	}
+	if len(recipients) == 0 {
+		return errors.New("invoice has no customer email and the reminder was not messaged to the customer's phone")
+	}
+
+	return s.repo.CreateEvent(ctx, &models.DunningEvent{
+		TenantID:    invoice.TenantID,
+		InvoiceID:   invoice.ID,
+		Action:      models.DunningActionReminder,
+		Step:        step,
+		PolicyID:    policy.ID,
+		DaysOverdue: daysOverdue,
+		Amount:      invoice.CollectibleAmount(),
+		Recipient:   strings.Join(recipients, ", "),
+	})
+}
+
+func (s *dunningService) escalate(ctx context.Context, invoice *models.Invoice, policy *models.DunningPolicy, profile *models.CustomerDunningProfile, daysOverdue int) error {
+	manager := policy.EscalationUserID
+	if profile != nil && profile.AccountManagerID != nil {
+		manager = profile.AccountManagerID
+	}
+	if manager == nil {
+		return errors.New("no account manager to escalate to")
+	}
+
+	err := s.notifier.Send(ctx, clients.Notification{
+		TenantID: invoice.TenantID.String(),
+		Channel:  clients.NotificationChannelInApp,
+		UserID:   manager,
+		Title:    fmt.Sprintf("Overdue invoice %s escalated", invoice.InvoiceNumber),
+		Message: fmt.Sprintf("%s has not paid invoice %s (%s outstanding), now %d days overdue.",
+			invoice.CustomerName, invoice.InvoiceNumber, models.FormatINR(invoice.CollectibleAmount()), daysOverdue),
+		Type: "error",
+		Link: "/invoices/" + invoice.ID.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.CreateEvent(ctx, &models.DunningEvent{
+		TenantID:    invoice.TenantID,
+		InvoiceID:   invoice.ID,
+		Action:      models.DunningActionEscalation,
+		Step:        policy.EscalateAfterDays,
+		PolicyID:    policy.ID,
+		DaysOverdue: daysOverdue,
+		Amount:      invoice.CollectibleAmount(),
+		Recipient:   manager.String(),
+	})
+}
+
+func (r *DunningRunResult) fail(invoice *models.Invoice, action models.DunningAction, err error) {
+	r.Failures = append(r.Failures, DunningFailure{
+		InvoiceID:     invoice.ID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		Action:        action,
+		Error:         err.Error(),
+	})
+}
+
+// daysBetween returns the number of calendar days from one date to another,
+// negative when to is earlier
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// checkCreditGrades makes sure no other policy of the tenant already covers
+// one of the grades, so each grade leads to a single policy
+func (s *dunningService) checkCreditGrades(ctx context.Context, tenantID, policyID uuid.UUID, grades []string) error {
+	if len(grades) == 0 {
+		return nil
+	}
+	policies, err := s.repo.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		if policy.ID == policyID {
+			continue
+		}
+		for _, taken := range policy.CreditGrades {
+			for _, grade := range grades {
+				if grade == taken {
+					return ErrCreditGradeTaken
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isCreditGrade(grade string) bool {
+	for _, valid := range models.CreditGrades {
+		if grade == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func validateDunningPolicy(req DunningPolicyRequest) error {
+	if strings.TrimSpace(req.Segment) == "" || strings.TrimSpace(req.Name) == "" {
+		return ErrInvalidDunningPolicy
+	}
+	if req.EscalateAfterDays < 0 || req.LateFeeAfterDays < 0 {
+		return ErrInvalidDunningPolicy
+	}
+	if req.LateFeeValue.IsNegative() || req.LateFeeGSTRate.IsNegative() {
+		return ErrInvalidDunningPolicy
+	}
+	for _, grade := range req.CreditGrades {
+		if !isCreditGrade(grade) {
+			return ErrInvalidDunningPolicy
+		}
+	}
+	switch req.LateFeeType {
+	case "", models.LateFeeTypeFixed:
+	case models.LateFeeTypePercentage:
+		if req.LateFeeValue.GreaterThan(decimal.NewFromInt(100)) {
+			return ErrInvalidDunningPolicy
+		}
+	default:
+		return ErrInvalidDunningPolicy
+	}
+	return nil
+}
+
+func applyDunningPolicyRequest(policy *models.DunningPolicy, req DunningPolicyRequest) {
+	policy.Segment = strings.TrimSpace(req.Segment)
+	policy.Name = strings.TrimSpace(req.Name)
+	policy.ReminderDays = models.ReminderSchedule(req.ReminderDays)
+	policy.EscalateAfterDays = req.EscalateAfterDays
+	policy.EscalationUserID = req.EscalationUserID
+	policy.LateFeeAfterDays = req.LateFeeAfterDays
+	policy.LateFeeType = req.LateFeeType
+	if policy.LateFeeType == "" {
+		policy.LateFeeType = models.LateFeeTypePercentage
+	}
+	policy.LateFeeValue = req.LateFeeValue
+	policy.LateFeeGSTRate = req.LateFeeGSTRate
+	if req.StopOnDispute != nil {
+		policy.StopOnDispute = *req.StopOnDispute
+	}
+	policy.CreditGrades = append(pq.StringArray{}, req.CreditGrades...)
+	policy.IsDefault = req.IsDefault
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+}
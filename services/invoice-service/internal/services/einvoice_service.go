@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/lifecycle"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/einvoice"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrEInvoiceUnavailable   = errors.New("e-invoicing is not configured")
+	ErrEInvoiceNoCredentials = errors.New("e-invoice portal credentials are not set up")
+	ErrEInvoiceNotIssued     = errors.New("only issued invoices can be e-invoiced")
+	ErrEInvoiceGenerated     = errors.New("invoice already has an IRN")
+	ErrEInvoiceNotGenerated  = errors.New("invoice has no active IRN")
+	// ErrEInvoiceCancelWindow is returned once an IRN is past the 24 hours
+	// the IRP allows for its cancellation; a credit note reverses it instead
+	ErrEInvoiceCancelWindow = errors.New("IRNs can only be cancelled within 24 hours of generation; issue a credit note instead")
+	ErrInvalidCancelReason  = errors.New("reason must be duplicate, data_entry_mistake, order_cancelled or other")
+	ErrInvalidGSTIN         = errors.New("invalid GSTIN")
+)
+
+// einvoiceCancelWindow is how long after its acknowledgement the IRP lets
+// an IRN be cancelled
+const einvoiceCancelWindow = 24 * time.Hour
+
+// SaveEInvoiceCredentialsRequest sets the tenant's API user on the IRP
+type SaveEInvoiceCredentialsRequest struct {
+	GSTIN    string `json:"gstin" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// GenerateEInvoiceRequest registers an invoice with the IRP
+type GenerateEInvoiceRequest struct {
+	TenantID      uuid.UUID `json:"-"`
+	InvoiceID     uuid.UUID `json:"-"`
+	Authorization string    `json:"-"` // Used to read the seller's details
+}
+
+// CancelEInvoiceRequest cancels an invoice's IRN
+type CancelEInvoiceRequest struct {
+	TenantID  uuid.UUID `json:"-"`
+	InvoiceID uuid.UUID `json:"-"`
+	Reason    string    `json:"reason" binding:"required"`
+	Remark    string    `json:"remark"`
+}
+
+// EInvoiceStatus is where an invoice stands with the IRP
+type EInvoiceStatus struct {
+	Status           string     `json:"status"`
+	IRN              string     `json:"irn,omitempty"`
+	AckNo            string     `json:"ack_no,omitempty"`
+	AckDate          *time.Time `json:"ack_date,omitempty"`
+	QRCode           string     `json:"qr_code,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	CancellableUntil *time.Time `json:"cancellable_until,omitempty"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// EInvoiceService registers invoices with the GST e-invoice portal (IRP)
+// under the tenant's API user, keeps the IRN and signed QR code it returns,
+// and cancels IRNs within the IRP's 24-hour window
+type EInvoiceService interface {
+	GetCredentials(ctx context.Context, tenantID uuid.UUID) (*models.EInvoiceCredential, error)
+	SaveCredentials(ctx context.Context, tenantID, userID uuid.UUID, req SaveEInvoiceCredentialsRequest) (*models.EInvoiceCredential, error)
+
+	// Generate registers an invoice. Invoices the IRP refuses are left
+	// failed with its reasons, and may be corrected and generated again.
+	Generate(ctx context.Context, req GenerateEInvoiceRequest) (*models.Invoice, error)
+	Status(ctx context.Context, tenantID, invoiceID uuid.UUID) (*EInvoiceStatus, error)
+	Cancel(ctx context.Context, req CancelEInvoiceRequest) (*models.Invoice, error)
+}
+
+type einvoiceService struct {
+	einvoiceRepo repository.EInvoiceRepository
+	invoiceRepo  repository.InvoiceRepository
+	client       einvoice.Client
+	cipher       CredentialCipher
+	tenantClient clients.TenantClient
+	tracker      *lifecycle.Tracker
+}
+
+// NewEInvoiceService creates a new e-invoice service. client is nil when
+// no IRP is configured.
+func NewEInvoiceService(
+	einvoiceRepo repository.EInvoiceRepository,
+	invoiceRepo repository.InvoiceRepository,
+	client einvoice.Client,
+	cipher CredentialCipher,
+	tenantClient clients.TenantClient,
+	tracker *lifecycle.Tracker,
+) EInvoiceService {
+	return &einvoiceService{
+		einvoiceRepo: einvoiceRepo,
+		invoiceRepo:  invoiceRepo,
+		client:       client,
+		cipher:       cipher,
+		tenantClient: tenantClient,
+		tracker:      tracker,
+	}
+}
+
+func (s *einvoiceService) GetCredentials(ctx context.Context, tenantID uuid.UUID) (*models.EInvoiceCredential, error) {
+	credential, err := s.einvoiceRepo.GetCredential(ctx, tenantID)
+	if errors.Is(err, repository.ErrEInvoiceCredentialNotFound) {
+		return nil, ErrEInvoiceNoCredentials
+	}
+	return credential, err
+}
+
+func (s *einvoiceService) SaveCredentials(ctx context.Context, tenantID, userID uuid.UUID, req SaveEInvoiceCredentialsRequest) (*models.EInvoiceCredential, error) {
+	gstin := strings.ToUpper(strings.TrimSpace(req.GSTIN))
+	if len(gstin) != 15 {
+		return nil, ErrInvalidGSTIN
+	}
+
+	password, err := s.cipher.Encrypt(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt e-invoice password: %w", err)
+	}
+	credential := &models.EInvoiceCredential{
+		TenantID:  tenantID,
+		GSTIN:     gstin,
+		Username:  strings.TrimSpace(req.Username),
+		Password:  password,
+		UpdatedBy: &userID,
+	}
+	if err := s.einvoiceRepo.SaveCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *einvoiceService) Generate(ctx context.Context, req GenerateEInvoiceRequest) (*models.Invoice, error) {
+	invoice, err := s.invoice(ctx, req.TenantID, req.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.IRN != "" {
+		return nil, ErrEInvoiceGenerated
+	}
+	if invoice.Status == models.InvoiceStatusDraft || invoice.Status == models.InvoiceStatusCancelled {
+		return nil, ErrEInvoiceNotIssued
+	}
+	if s.client == nil {
+		return nil, ErrEInvoiceUnavailable
+	}
+	creds, err := s.credentials(ctx, invoice.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	seller, err := s.tenantClient.GetTenant(ctx, req.Authorization, invoice.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("get seller details: %w", err)
+	}
+	doc, err := einvoice.Build(invoice, seller)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invoice.EInvoiceStatus = models.EInvoicePending
+	invoice.EInvoiceDate = &now
+	invoice.EInvoiceError = ""
+	if err := s.invoiceRepo.UpdateEInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+	track(ctx, s.tracker, einvoiceDocument(invoice), lifecycle.Move{To: models.EInvoicePending})
+
+	irn, err := s.client.Generate(ctx, *creds, doc)
+	if err != nil {
+		// An attempt whose answer was lost shows as a duplicate when
+		// retried, which returns the IRN already registered
+		invoice.EInvoiceStatus = models.EInvoiceFailed
+		invoice.EInvoiceError = err.Error()
+		if updateErr := s.invoiceRepo.UpdateEInvoice(ctx, invoice); updateErr != nil {
+			return nil, updateErr
+		}
+		track(ctx, s.tracker, einvoiceDocument(invoice), lifecycle.Move{To: models.EInvoiceFailed, Note: err.Error()})
+		return nil, err
+	}
+
+	invoice.IRN = irn.IRN
+	invoice.AckNo = irn.AckNo
+	invoice.AckDate = &irn.AckDate
+	invoice.QRCode = irn.SignedQRCode
+	invoice.SignedInvoice = irn.SignedInvoice
+	invoice.EInvoiceStatus = models.EInvoiceGenerated
+	if err := s.invoiceRepo.UpdateEInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+	track(ctx, s.tracker, einvoiceDocument(invoice), lifecycle.Move{To: models.EInvoiceGenerated, Note: "IRN " + irn.IRN})
+
+	return invoice, nil
+}
+
+func (s *einvoiceService) Status(ctx context.Context, tenantID, invoiceID uuid.UUID) (*EInvoiceStatus, error) {
+	invoice, err := s.invoice(ctx, tenantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &EInvoiceStatus{
+		Status:      invoice.EInvoiceStatus,
+		IRN:         invoice.IRN,
+		AckNo:       invoice.AckNo,
+		AckDate:     invoice.AckDate,
+		QRCode:      invoice.QRCode,
+		Error:       invoice.EInvoiceError,
+		CancelledAt: invoice.EInvoiceCancelledAt,
+	}
+	if invoice.EInvoiceStatus == models.EInvoiceGenerated && invoice.AckDate != nil {
+		until := invoice.AckDate.Add(einvoiceCancelWindow)
+		if time.Now().Before(until) {
+			status.CancellableUntil = &until
+		}
+	}
+	return status, nil
+}
+
+func (s *einvoiceService) Cancel(ctx context.Context, req CancelEInvoiceRequest) (*models.Invoice, error) {
+	reason, ok := einvoice.CancelReasons[req.Reason]
+	if !ok {
+		return nil, ErrInvalidCancelReason
+	}
+
+	invoice, err := s.invoice(ctx, req.TenantID, req.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.IRN == "" || invoice.EInvoiceStatus != models.EInvoiceGenerated {
+		return nil, ErrEInvoiceNotGenerated
+	}
+	if invoice.AckDate == nil || time.Now().After(invoice.AckDate.Add(einvoiceCancelWindow)) {
+		return nil, ErrEInvoiceCancelWindow
+	}
+	if s.client == nil {
+		return nil, ErrEInvoiceUnavailable
+	}
+	creds, err := s.credentials(ctx, invoice.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The IRP requires a remark of up to 100 characters
+	remark := strings.TrimSpace(req.Remark)
+	if remark == "" {
+		remark = strings.ReplaceAll(req.Reason, "_", " ")
+	}
+	if runes := []rune(remark); len(runes) > 100 {
+		remark = string(runes[:100])
+	}
+
+	cancellation, err := s.client.Cancel(ctx, *creds, invoice.IRN, reason, remark)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelledAt := cancellation.CancelledAt
+	if cancelledAt.IsZero() {
+		cancelledAt = time.Now()
+	}
+	invoice.EInvoiceStatus = models.EInvoiceCancelled
+	invoice.EInvoiceCancelledAt = &cancelledAt
+	if err := s.invoiceRepo.UpdateEInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+	track(ctx, s.tracker, einvoiceDocument(invoice), lifecycle.Move{To: models.EInvoiceCancelled, Note: req.Reason + ": " + remark})
+
+	return invoice, nil
+}
+
+func (s *einvoiceService) invoice(ctx context.Context, tenantID, invoiceID uuid.UUID) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil || invoice.TenantID != tenantID {
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// credentials returns the tenant's API user with its password decrypted
+func (s *einvoiceService) credentials(ctx context.Context, tenantID uuid.UUID) (*einvoice.Credentials, error) {
+	credential, err := s.GetCredentials(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	password, err := s.cipher.Decrypt(credential.Password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt e-invoice password: %w", err)
+	}
+	return &einvoice.Credentials{
+		GSTIN:    credential.GSTIN,
+		Username: credential.Username,
+		Password: password,
+	}, nil
+}
+
+func einvoiceDocument(invoice *models.Invoice) lifecycle.Document {
+	return lifecycle.Document{
+		TenantID: invoice.TenantID,
+		Kind:     LifecycleEInvoice,
+		ID:       invoice.ID,
+		Label:    invoice.InvoiceNumber,
+	}
+}
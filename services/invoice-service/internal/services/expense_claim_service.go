@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrExpenseClaimNotFound  = errors.New("expense claim not found")
+	ErrInvalidExpenseClaim   = errors.New("invalid expense claim data")
+	ErrExpenseClaimReviewed  = errors.New("expense claim has already been reviewed")
+	ErrSelfApproval          = errors.New("expense claims cannot be reviewed by the member who submitted them")
+	ErrEmployeePartyRequired = errors.New("an employee party is required to approve the claim")
+)
+
+// SubmitExpenseClaimRequest represents a member's expense claim
+type SubmitExpenseClaimRequest struct {
+	TenantID        uuid.UUID                 `json:"-"`
+	MemberID        uuid.UUID                 `json:"-"`
+	EmployeeName    string                    `json:"employee_name" binding:"required"`
+	EmployeePartyID *uuid.UUID                `json:"employee_party_id"`
+	Title           string                    `json:"title" binding:"required"`
+	Project         string                    `json:"project"`
+	Items           []ExpenseClaimItemRequest `json:"items" binding:"required,min=1"`
+	Notes           string                    `json:"notes"`
+}
+
+// ExpenseClaimItemRequest represents a single expense on a claim
+type ExpenseClaimItemRequest struct {
+	ExpenseDate string          `json:"expense_date" binding:"required"`
+	Category    string          `json:"category" binding:"required"`
+	Description string          `json:"description" binding:"required"`
+	Amount      decimal.Decimal `json:"amount" binding:"required"`
+	ReceiptURL  string          `json:"receipt_url"`
+}
+
+// ApproveExpenseClaimRequest represents an approver's decision to reimburse a
+// claim. The employee party may be set here if the member did not give one.
+type ApproveExpenseClaimRequest struct {
+	TenantID        uuid.UUID  `json:"-"`
+	ReviewerID      uuid.UUID  `json:"-"`
+	EmployeePartyID *uuid.UUID `json:"employee_party_id"`
+	EmployeeState   string     `json:"employee_state"`
+	DueDate         string     `json:"due_date"`
+
+	// Why the claim is reimbursed although it breaks the expense policy;
+	// required when it does
+	PolicyOverrideReason string `json:"policy_override_reason"`
+
+	// The reimbursement bill is raised and posted on behalf of the approver
+	Authorization string `json:"-"`
+}
+
+// RejectExpenseClaimRequest represents an approver's rejection of a claim
+type RejectExpenseClaimRequest struct {
+	TenantID   uuid.UUID `json:"-"`
+	ReviewerID uuid.UUID `json:"-"`
+	Reason     string    `json:"reason" binding:"required"`
+}
+
+// ExpenseClaimService handles the expense claim workflow
+type ExpenseClaimService interface {
+	Submit(ctx context.Context, req SubmitExpenseClaimRequest) (*models.ExpenseClaim, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.ExpenseClaim, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.ExpenseClaimFilters) ([]models.ExpenseClaim, error)
+	Approve(ctx context.Context, id uuid.UUID, req ApproveExpenseClaimRequest) (*models.ExpenseClaim, error)
+	Reject(ctx context.Context, id uuid.UUID, req RejectExpenseClaimRequest) (*models.ExpenseClaim, error)
+	StatusReport(ctx context.Context, tenantID uuid.UUID, filters repository.ExpenseClaimFilters) ([]repository.MemberClaimSummary, error)
+}
+
+type expenseClaimService struct {
+	repo          repository.ExpenseClaimRepository
+	billService   BillService
+	policyService ExpensePolicyService
+}
+
+// NewExpenseClaimService creates a new expense claim service
+func NewExpenseClaimService(repo repository.ExpenseClaimRepository, billService BillService, policyService ExpensePolicyService) ExpenseClaimService {
+	return &expenseClaimService{
+		repo:          repo,
+		billService:   billService,
+		policyService: policyService,
+	}
+}
+
+func (s *expenseClaimService) Submit(ctx context.Context, req SubmitExpenseClaimRequest) (*models.ExpenseClaim, error) {
+	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.EmployeeName) == "" {
+		return nil, ErrInvalidExpenseClaim
+	}
+
+	claimNumber, err := s.repo.GetNextClaimNumber(ctx, req.TenantID, fmt.Sprintf("EXP-%s", time.Now().Format("0601")))
+	if err != nil {
+		return nil, err
+	}
+
+	claim := &models.ExpenseClaim{
+		TenantID:        req.TenantID,
+		ClaimNumber:     claimNumber,
+		MemberID:        req.MemberID,
+		EmployeeName:    strings.TrimSpace(req.EmployeeName),
+		EmployeePartyID: req.EmployeePartyID,
+		Title:           strings.TrimSpace(req.Title),
+		Project:         strings.TrimSpace(req.Project),
+		Status:          models.ExpenseClaimStatusSubmitted,
+		Notes:           req.Notes,
+	}
+
+	for _, itemReq := range req.Items {
+		expenseDate, err := time.Parse("2006-01-02", itemReq.ExpenseDate)
+		if err != nil || expenseDate.After(time.Now()) {
+			return nil, ErrInvalidExpenseClaim
+		}
+		if !itemReq.Amount.IsPositive() {
+			return nil, ErrInvalidExpenseClaim
+		}
+		claim.Items = append(claim.Items, models.ExpenseClaimItem{
+			ExpenseDate: expenseDate,
+			Category:    strings.TrimSpace(itemReq.Category),
+			Description: itemReq.Description,
+			Amount:      itemReq.Amount,
+			ReceiptURL:  itemReq.ReceiptURL,
+		})
+	}
+	claim.CalculateTotal()
+
+	violations, err := s.policyService.CheckClaim(ctx, claim)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, claim); err != nil {
+		return nil, err
+	}
+	if err := s.policyService.Record(ctx, claim.TenantID, models.PolicyDocumentExpenseClaim, claim.ID, violations); err != nil {
+		return nil, err
+	}
+	claim.PolicyViolations = violations
+
+	return claim, nil
+}
+
+func (s *expenseClaimService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.ExpenseClaim, error) {
+	claim, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrExpenseClaimNotFound
+	}
+
+	claim.PolicyViolations, err = s.policyService.Violations(ctx, tenantID, models.PolicyDocumentExpenseClaim, claim.ID)
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (s *expenseClaimService) List(ctx context.Context, tenantID uuid.UUID, filters repository.ExpenseClaimFilters) ([]models.ExpenseClaim, error) {
+	return s.repo.List(ctx, tenantID, filters)
+}
+
+// Approve converts the claim into an approved bill payable to the employee,
+// one bill line per expense. A claim breaking the expense policy needs an
+// override reason.
+func (s *expenseClaimService) Approve(ctx context.Context, id uuid.UUID, req ApproveExpenseClaimRequest) (*models.ExpenseClaim, error) {
+	claim, err := s.reviewable(ctx, req.TenantID, id, req.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EmployeePartyID != nil {
+		claim.EmployeePartyID = req.EmployeePartyID
+	}
+	if claim.EmployeePartyID == nil {
+		return nil, ErrEmployeePartyRequired
+	}
+	if err := s.policyService.Override(ctx, claim.TenantID, models.PolicyDocumentExpenseClaim, claim.ID, req.ReviewerID, req.PolicyOverrideReason); err != nil {
+		return nil, err
+	}
+
+	billReq := CreateBillRequest{
+		TenantID:     claim.TenantID,
+		CreatedBy:    req.ReviewerID,
+		VendorID:     *claim.EmployeePartyID,
+		VendorName:   claim.EmployeeName,
+		VendorState:  req.EmployeeState,
+		VendorBillNo: claim.ClaimNumber,
+		BillDate:     time.Now().Format("2006-01-02"),
+		DueDate:      req.DueDate,
+		Notes:        fmt.Sprintf("Expense claim %s: %s", claim.ClaimNumber, claim.Title),
+
+		Authorization: req.Authorization,
+	}
+	for _, item := range claim.Items {
+		billReq.Items = append(billReq.Items, CreateBillItemRequest{
+			Description: fmt.Sprintf("%s - %s (%s)", item.Category, item.Description, item.ExpenseDate.Format("02 Jan 2006")),
+			Quantity:    decimal.NewFromInt(1),
+			Unit:        "nos",
+			Rate:        item.Amount,
+		})
+	}
+
+	bill, err := s.billService.Create(ctx, billReq)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.billService.Approve(ctx, bill.ID, req.ReviewerID, "", req.Authorization); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claim.Status = models.ExpenseClaimStatusApproved
+	claim.ReviewedBy = &req.ReviewerID
+	claim.ReviewedAt = &now
+	claim.BillID = &bill.ID
+
+	if err := s.repo.Update(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+func (s *expenseClaimService) Reject(ctx context.Context, id uuid.UUID, req RejectExpenseClaimRequest) (*models.ExpenseClaim, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, ErrInvalidExpenseClaim
+	}
+
+	claim, err := s.reviewable(ctx, req.TenantID, id, req.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claim.Status = models.ExpenseClaimStatusRejected
+	claim.ReviewedBy = &req.ReviewerID
+	claim.ReviewedAt = &now
+	claim.RejectionReason = strings.TrimSpace(req.Reason)
+
+	if err := s.repo.Update(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+func (s *expenseClaimService) StatusReport(ctx context.Context, tenantID uuid.UUID, filters repository.ExpenseClaimFilters) ([]repository.MemberClaimSummary, error) {
+	return s.repo.GetMemberSummary(ctx, tenantID, filters)
+}
+
+// reviewable loads a claim that is awaiting review by someone other than the
+// member who submitted it
+func (s *expenseClaimService) reviewable(ctx context.Context, tenantID, id, reviewerID uuid.UUID) (*models.ExpenseClaim, error) {
+	claim, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrExpenseClaimNotFound
+	}
+	if claim.Status != models.ExpenseClaimStatusSubmitted {
+		return nil, ErrExpenseClaimReviewed
+	}
+	if claim.MemberID == reviewerID {
+		return nil, ErrSelfApproval
+	}
+	return claim, nil
+}
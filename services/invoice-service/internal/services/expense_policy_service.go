@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrInvalidExpensePolicy = errors.New("invalid expense policy")
+	// ErrPolicyOverrideRequired is returned when a claim or bill breaking
+	// the expense policy is approved without an override reason
+	ErrPolicyOverrideRequired = errors.New("the document breaks the expense policy; give a policy override reason to approve it")
+)
+
+// UpdateExpensePolicyRequest replaces a tenant's expense policy
+type UpdateExpensePolicyRequest struct {
+	ReceiptRequiredAbove decimal.Decimal              `json:"receipt_required_above"`
+	Rules                []ExpenseCategoryRuleRequest `json:"rules"`
+}
+
+// ExpenseCategoryRuleRequest limits what may be spent in one category
+type ExpenseCategoryRuleRequest struct {
+	Category    string          `json:"category" binding:"required"`
+	Prohibited  bool            `json:"prohibited"`
+	DailyLimit  decimal.Decimal `json:"daily_limit"`
+	PerDiemRate decimal.Decimal `json:"per_diem_rate"`
+}
+
+// ExpensePolicyReport lists the recorded violations of the expense policy
+type ExpensePolicyReport struct {
+	Violations []models.ExpensePolicyViolation `json:"violations"`
+	Count      int                             `json:"count"`
+	Overridden int                             `json:"overridden"`
+	ByRule     map[string]int                  `json:"by_rule"`
+}
+
+// ExpensePolicyService checks expense claims and bills against the tenant's
+// expense policy: prohibited categories, daily caps and per-diem rates by
+// category, and the amount above which a receipt is required. A document
+// breaking the policy is still submitted, with its violations recorded, but
+// can only be approved once they are overridden with a reason.
+type ExpensePolicyService interface {
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.ExpensePolicy, error)
+	UpdatePolicy(ctx context.Context, tenantID, userID uuid.UUID, req UpdateExpensePolicyRequest) (*models.ExpensePolicy, error)
+
+	// CheckClaim returns the violations of a claim before it is saved,
+	// counting what the member claimed for the same days on other claims
+	CheckClaim(ctx context.Context, claim *models.ExpenseClaim) ([]models.ExpensePolicyViolation, error)
+	// CheckBill returns the violations of a bill before it is saved
+	CheckBill(ctx context.Context, bill *models.Bill) ([]models.ExpensePolicyViolation, error)
+	// Record saves the violations returned by a check once their document
+	// is saved, replacing any recorded before
+	Record(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID, violations []models.ExpensePolicyViolation) error
+	Violations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID) ([]models.ExpensePolicyViolation, error)
+	// Override marks a document's open violations overridden by the
+	// approver. It fails with ErrPolicyOverrideRequired when there are any
+	// and no reason is given.
+	Override(ctx context.Context, tenantID uuid.UUID, documentType string, documentID, userID uuid.UUID, reason string) error
+
+	Report(ctx context.Context, tenantID uuid.UUID, filters repository.ExpenseViolationFilters) (*ExpensePolicyReport, error)
+}
+
+type expensePolicyService struct {
+	policyRepo repository.ExpensePolicyRepository
+}
+
+// NewExpensePolicyService creates a new expense policy service
+func NewExpensePolicyService(policyRepo repository.ExpensePolicyRepository) ExpensePolicyService {
+	return &expensePolicyService{policyRepo: policyRepo}
+}
+
+// expenseLine is a claim or bill line as the policy sees it
+type expenseLine struct {
+	date       time.Time
+	category   string
+	amount     decimal.Decimal
+	hasReceipt bool
+}
+
+// expenseDay keys what was spent in a category on a day
+type expenseDay struct {
+	category string
+	date     string
+}
+
+func (s *expensePolicyService) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.ExpensePolicy, error) {
+	return s.policyRepo.GetPolicy(ctx, tenantID)
+}
+
+func (s *expensePolicyService) UpdatePolicy(ctx context.Context, tenantID, userID uuid.UUID, req UpdateExpensePolicyRequest) (*models.ExpensePolicy, error) {
+	if req.ReceiptRequiredAbove.IsNegative() {
+		return nil, ErrInvalidExpensePolicy
+	}
+
+	policy := &models.ExpensePolicy{
+		TenantID:             tenantID,
+		ReceiptRequiredAbove: req.ReceiptRequiredAbove,
+		Rules:                []models.ExpenseCategoryRule{},
+		UpdatedBy:            &userID,
+	}
+	seen := make(map[string]bool)
+	for _, ruleReq := range req.Rules {
+		category := models.NormalizeExpenseCategory(ruleReq.Category)
+		if category == "" || seen[category] {
+			return nil, ErrInvalidExpensePolicy
+		}
+		if ruleReq.DailyLimit.IsNegative() || ruleReq.PerDiemRate.IsNegative() {
+			return nil, ErrInvalidExpensePolicy
+		}
+		seen[category] = true
+
+		policy.Rules = append(policy.Rules, models.ExpenseCategoryRule{
+			TenantID:    tenantID,
+			Category:    category,
+			Prohibited:  ruleReq.Prohibited,
+			DailyLimit:  ruleReq.DailyLimit,
+			PerDiemRate: ruleReq.PerDiemRate,
+		})
+	}
+	sort.Slice(policy.Rules, func(i, j int) bool { return policy.Rules[i].Category < policy.Rules[j].Category })
+
+	if err := s.policyRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (s *expensePolicyService) CheckClaim(ctx context.Context, claim *models.ExpenseClaim) ([]models.ExpensePolicyViolation, error) {
+	if len(claim.Items) == 0 {
+		return nil, nil
+	}
+
+	lines := make([]expenseLine, len(claim.Items))
+	from, to := claim.Items[0].ExpenseDate, claim.Items[0].ExpenseDate
+	for i, item := range claim.Items {
+		lines[i] = expenseLine{
+			date:       item.ExpenseDate,
+			category:   item.Category,
+			amount:     item.Amount,
+			hasReceipt: strings.TrimSpace(item.ReceiptURL) != "",
+		}
+		if item.ExpenseDate.Before(from) {
+			from = item.ExpenseDate
+		}
+		if item.ExpenseDate.After(to) {
+			to = item.ExpenseDate
+		}
+	}
+
+	// The daily caps are per person, so what the member claimed for the
+	// same days on other claims counts towards them
+	claimed, err := s.policyRepo.ClaimedByMember(ctx, claim.TenantID, claim.MemberID, claim.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[expenseDay]decimal.Decimal, len(claimed))
+	for _, day := range claimed {
+		spent[expenseDay{category: day.Category, date: day.ExpenseDate.Format("2006-01-02")}] = day.Amount
+	}
+
+	return s.check(ctx, models.ExpensePolicyViolation{
+		TenantID:       claim.TenantID,
+		DocumentType:   models.PolicyDocumentExpenseClaim,
+		DocumentID:     claim.ID,
+		DocumentNumber: claim.ClaimNumber,
+		PartyName:      claim.EmployeeName,
+	}, lines, spent)
+}
+
+func (s *expensePolicyService) CheckBill(ctx context.Context, bill *models.Bill) ([]models.ExpensePolicyViolation, error) {
+	// A bill is one vendor document, so each line is backed by it or not,
+	// and the lines' value is before GST, which is mostly claimed back
+	hasReceipt := bill.HasReceipt()
+	lines := make([]expenseLine, 0, len(bill.Items))
+	for _, item := range bill.Items {
+		lines = append(lines, expenseLine{
+			date:       bill.BillDate,
+			category:   item.ExpenseCategory,
+			amount:     item.Amount,
+			hasReceipt: hasReceipt,
+		})
+	}
+
+	return s.check(ctx, models.ExpensePolicyViolation{
+		TenantID:       bill.TenantID,
+		DocumentType:   models.PolicyDocumentBill,
+		DocumentID:     bill.ID,
+		DocumentNumber: bill.BillNumber,
+		PartyName:      bill.VendorName,
+	}, lines, nil)
+}
+
+// check returns the lines' violations of the tenant's policy, each filled in
+// from base. spent is what was already spent in each category on each day
+// outside the lines.
+func (s *expensePolicyService) check(ctx context.Context, base models.ExpensePolicyViolation, lines []expenseLine, spent map[expenseDay]decimal.Decimal) ([]models.ExpensePolicyViolation, error) {
+	policy, err := s.policyRepo.GetPolicy(ctx, base.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []models.ExpensePolicyViolation
+	add := func(rule, category string, date time.Time, amount, limit decimal.Decimal, message string) {
+		violation := base
+		violation.Rule = rule
+		violation.Category = category
+		violation.ExpenseDate = date
+		violation.Amount = amount
+		violation.Limit = limit
+		violation.Message = message
+		violations = append(violations, violation)
+	}
+
+	days := make(map[expenseDay]decimal.Decimal)
+	dates := make(map[expenseDay]time.Time)
+	for _, line := range lines {
+		category := models.NormalizeExpenseCategory(line.category)
+		rule := policy.Rule(category)
+
+		if rule != nil && rule.Prohibited {
+			add(models.PolicyRuleProhibited, category, line.date, line.amount, decimal.Zero,
+				fmt.Sprintf("%s on %s: %s expenses are not allowed", models.FormatINR(line.amount), line.date.Format("02 Jan 2006"), category))
+			continue
+		}
+
+		// Per-diem allowances are paid at the rate without receipts
+		threshold := policy.ReceiptRequiredAbove
+		if !line.hasReceipt && threshold.IsPositive() && line.amount.GreaterThan(threshold) && (rule == nil || !rule.IsPerDiem()) {
+			add(models.PolicyRuleReceiptRequired, category, line.date, line.amount, threshold,
+				fmt.Sprintf("%s on %s for %s needs a receipt above %s", models.FormatINR(line.amount), line.date.Format("02 Jan 2006"), category, models.FormatINR(threshold)))
+		}
+
+		if rule != nil {
+			day := expenseDay{category: category, date: line.date.Format("2006-01-02")}
+			days[day] = days[day].Add(line.amount)
+			dates[day] = line.date
+		}
+	}
+
+	keys := make([]expenseDay, 0, len(days))
+	for day := range days {
+		keys = append(keys, day)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].date != keys[j].date {
+			return keys[i].date < keys[j].date
+		}
+		return keys[i].category < keys[j].category
+	})
+
+	for _, day := range keys {
+		rule := policy.Rule(day.category)
+		total := days[day].Add(spent[day])
+		date := dates[day]
+
+		if rule.IsPerDiem() && total.GreaterThan(rule.PerDiemRate) {
+			add(models.PolicyRulePerDiem, day.category, date, total, rule.PerDiemRate,
+				fmt.Sprintf("%s for %s on %s is over the per-diem rate of %s", models.FormatINR(total), day.category, date.Format("02 Jan 2006"), models.FormatINR(rule.PerDiemRate)))
+		}
+		if rule.DailyLimit.IsPositive() && total.GreaterThan(rule.DailyLimit) {
+			add(models.PolicyRuleDailyLimit, day.category, date, total, rule.DailyLimit,
+				fmt.Sprintf("%s for %s on %s is over the daily limit of %s", models.FormatINR(total), day.category, date.Format("02 Jan 2006"), models.FormatINR(rule.DailyLimit)))
+		}
+	}
+
+	return violations, nil
+}
+
+func (s *expensePolicyService) Record(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID, violations []models.ExpensePolicyViolation) error {
+	for i := range violations {
+		violations[i].DocumentID = documentID
+	}
+	return s.policyRepo.ReplaceViolations(ctx, tenantID, documentType, documentID, violations)
+}
+
+func (s *expensePolicyService) Violations(ctx context.Context, tenantID uuid.UUID, documentType string, documentID uuid.UUID) ([]models.ExpensePolicyViolation, error) {
+	return s.policyRepo.ListDocumentViolations(ctx, tenantID, documentType, documentID)
+}
+
+func (s *expensePolicyService) Override(ctx context.Context, tenantID uuid.UUID, documentType string, documentID, userID uuid.UUID, reason string) error {
+	violations, err := s.policyRepo.ListDocumentViolations(ctx, tenantID, documentType, documentID)
+	if err != nil {
+		return err
+	}
+
+	open := 0
+	for _, violation := range violations {
+		if !violation.Overridden {
+			open++
+		}
+	}
+	if open == 0 {
+		return nil
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrPolicyOverrideRequired
+	}
+	_, err = s.policyRepo.OverrideViolations(ctx, tenantID, documentType, documentID, userID, reason)
+	return err
+}
+
+func (s *expensePolicyService) Report(ctx context.Context, tenantID uuid.UUID, filters repository.ExpenseViolationFilters) (*ExpensePolicyReport, error) {
+	violations, err := s.policyRepo.ListViolations(ctx, tenantID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ExpensePolicyReport{Violations: violations, Count: len(violations), ByRule: make(map[string]int)}
+	for _, violation := range violations {
+		report.ByRule[violation.Rule]++
+		if violation.Overridden {
+			report.Overridden++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+var (
+	ErrInvalidExport = errors.New("invalid export invoice")
+	ErrNotExport     = errors.New("invoice is not an export invoice")
+	ErrInvalidPeriod = errors.New("period must be MMYYYY")
+)
+
+// ExportDetailsRequest makes an invoice an export invoice. On a foreign
+// currency invoice the item rates are in that currency.
+type ExportDetailsRequest struct {
+	ExportType         models.ExportType `json:"export_type" binding:"required,oneof=WPAY WOPAY"`
+	Currency           string            `json:"currency"` // ISO 4217, INR by default
+	ExchangeRate       decimal.Decimal   `json:"exchange_rate"`
+	IECCode            string            `json:"iec_code" binding:"required"`
+	LUTReference       string            `json:"lut_reference"`
+	PortCode           string            `json:"port_code"`
+	ShippingBillNumber string            `json:"shipping_bill_number"`
+	ShippingBillDate   string            `json:"shipping_bill_date"` // YYYY-MM-DD
+	DestinationCountry string            `json:"destination_country"`
+}
+
+// ShippingBillRequest records the shipping bill of an export, which is
+// usually filed after the invoice is issued
+type ShippingBillRequest struct {
+	PortCode           string `json:"port_code" binding:"required"`
+	ShippingBillNumber string `json:"shipping_bill_number" binding:"required"`
+	ShippingBillDate   string `json:"shipping_bill_date" binding:"required"` // YYYY-MM-DD
+}
+
+// applyExport sets the export fields of an invoice whose items are already
+// built, converting foreign currency rates to rupees
+func applyExport(invoice *models.Invoice, req *ExportDetailsRequest) error {
+	if req == nil {
+		return nil
+	}
+
+	invoice.ExportType = req.ExportType
+	invoice.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if invoice.Currency == "" {
+		invoice.Currency = "INR"
+	}
+	invoice.ExchangeRate = req.ExchangeRate
+	if invoice.Currency == "INR" && invoice.ExchangeRate.IsZero() {
+		invoice.ExchangeRate = decimal.NewFromInt(1)
+	}
+	invoice.IECCode = strings.ToUpper(strings.TrimSpace(req.IECCode))
+	invoice.LUTReference = strings.TrimSpace(req.LUTReference)
+	invoice.PortCode = strings.ToUpper(strings.TrimSpace(req.PortCode))
+	invoice.ShippingBillNumber = strings.TrimSpace(req.ShippingBillNumber)
+	invoice.ShippingBillDate = nil
+	if req.ShippingBillDate != "" {
+		date, err := time.Parse("2006-01-02", req.ShippingBillDate)
+		if err != nil {
+			return fmt.Errorf("%w: invalid shipping bill date", ErrInvalidExport)
+		}
+		invoice.ShippingBillDate = &date
+	}
+	invoice.DestinationCountry = strings.TrimSpace(req.DestinationCountry)
+
+	if invoice.IsForeignCurrency() {
+		for n := range invoice.Items {
+			invoice.Items[n].ForeignRate = invoice.Items[n].Rate
+		}
+		invoice.ApplyExchangeRate()
+	}
+	if err := invoice.ValidateExport(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidExport, err)
+	}
+	return nil
+}
+
+// UpdateShippingBill records the shipping bill details of an export
+// invoice, in any status
+func (s *invoiceService) UpdateShippingBill(ctx context.Context, id uuid.UUID, req ShippingBillRequest) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvoiceNotFound
+	}
+	if !invoice.IsExport() {
+		return nil, ErrNotExport
+	}
+
+	date, err := time.Parse("2006-01-02", req.ShippingBillDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid shipping bill date", ErrInvalidExport)
+	}
+	before := *invoice
+	invoice.PortCode = strings.ToUpper(strings.TrimSpace(req.PortCode))
+	invoice.ShippingBillNumber = strings.TrimSpace(req.ShippingBillNumber)
+	invoice.ShippingBillDate = &date
+	if err := invoice.ValidateExport(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidExport, err)
+	}
+
+	err = s.invoiceRepo.UpdateShippingBill(ctx, id, map[string]interface{}{
+		"port_code":            invoice.PortCode,
+		"shipping_bill_number": invoice.ShippingBillNumber,
+		"shipping_bill_date":   invoice.ShippingBillDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventEdited, "Shipping bill recorded",
+		timeline.Diff(before, invoice, "port_code", "shipping_bill_number", "shipping_bill_date"))
+	return invoice, nil
+}
@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrFinancingConsentNotFound = errors.New("financing consent not found")
+	ErrFinancingConsentRequired = errors.New("consent to share receivables data must be accepted")
+	ErrFinancingConsentInactive = errors.New("financing consent has been revoked or has expired")
+	ErrInvalidConsentPeriod     = errors.New("consent must be valid from today for at most one year")
+	ErrInvalidFinancingFormat   = errors.New("format must be csv or json")
+	ErrInvalidAsOfDate          = errors.New("as-of date must be a past date in YYYY-MM-DD format")
+)
+
+// FinancingSchemaVersion identifies the layout of the data pack, so lenders
+// can tell when columns change
+const FinancingSchemaVersion = "receivables-financing/1.0"
+
+// financingHistoryMonths is how far back customer credit history goes
+const financingHistoryMonths = 12
+
+// GrantFinancingConsentRequest records consent to share receivables with a
+// lender
+type GrantFinancingConsentRequest struct {
+	TenantID              uuid.UUID `json:"-"`
+	UserID                uuid.UUID `json:"-"`
+	ClientIP              string    `json:"-"`
+	LenderName            string    `json:"lender_name" binding:"required,max=200"`
+	LenderReference       string    `json:"lender_reference" binding:"max=100"`
+	Purpose               string    `json:"purpose"`
+	ShareCustomerIdentity bool      `json:"share_customer_identity"`
+	ValidUntil            string    `json:"valid_until" binding:"required"` // YYYY-MM-DD
+
+	// Accepted confirms the user agrees to the consent text returned with
+	// the consent
+	Accepted bool `json:"accepted"`
+}
+
+// FinancingExportRequest asks for a data pack under a consent
+type FinancingExportRequest struct {
+	TenantID  uuid.UUID
+	UserID    uuid.UUID
+	ClientIP  string
+	ConsentID uuid.UUID
+	Format    string
+	AsOf      string // YYYY-MM-DD, defaults to today
+}
+
+// FinancingDataPack is the receivables data a lender assesses for invoice
+// financing: the open invoices, their aging and dilution, and each
+// customer's credit history
+type FinancingDataPack struct {
+	SchemaVersion   string                  `json:"schema_version"`
+	ExportID        uuid.UUID               `json:"export_id"`
+	ConsentID       uuid.UUID               `json:"consent_id"`
+	LenderName      string                  `json:"lender_name"`
+	LenderReference string                  `json:"lender_reference,omitempty"`
+	SellerID        uuid.UUID               `json:"seller_id"`
+	AsOf            string                  `json:"as_of"`
+	HistoryFrom     string                  `json:"history_from"`
+	GeneratedAt     time.Time               `json:"generated_at"`
+	Currency        string                  `json:"currency"`
+	Summary         FinancingSummary        `json:"summary"`
+	Invoices        []FinancedInvoice       `json:"invoices"`
+	Customers       []CustomerCreditHistory `json:"customers"`
+}
+
+// FinancingSummary totals the open invoices in a data pack
+type FinancingSummary struct {
+	InvoiceCount     int                        `json:"invoice_count"`
+	CustomerCount    int                        `json:"customer_count"`
+	TotalOutstanding decimal.Decimal            `json:"total_outstanding"`
+	OverdueAmount    decimal.Decimal            `json:"overdue_amount"`
+	DisputedAmount   decimal.Decimal            `json:"disputed_amount"`
+	Aging            map[string]decimal.Decimal `json:"aging"`
+	DilutionRate     decimal.Decimal            `json:"dilution_rate"` // Credit notes as a share of invoicing over the history period
+}
+
+// FinancedInvoice is an open invoice in a data pack
+type FinancedInvoice struct {
+	InvoiceNumber  string          `json:"invoice_number"`
+	IRN            string          `json:"irn,omitempty"`
+	InvoiceDate    string          `json:"invoice_date"`
+	DueDate        string          `json:"due_date"`
+	CustomerRef    uuid.UUID       `json:"customer_ref"`
+	CustomerName   string          `json:"customer_name,omitempty"`
+	CustomerGSTIN  string          `json:"customer_gstin,omitempty"`
+	InvoiceAmount  decimal.Decimal `json:"invoice_amount"`
+	AmountPaid     decimal.Decimal `json:"amount_paid"`
+	CreditNotes    decimal.Decimal `json:"credit_notes"`
+	Outstanding    decimal.Decimal `json:"outstanding"`
+	DisputedAmount decimal.Decimal `json:"disputed_amount"`
+	DaysPastDue    int             `json:"days_past_due"`
+	AgingBucket    string          `json:"aging_bucket"`
+}
+
+// CustomerCreditHistory is a customer's record with the seller over the
+// history period, and what it owes as of the data pack date
+type CustomerCreditHistory struct {
+	CustomerRef       uuid.UUID       `json:"customer_ref"`
+	CustomerName      string          `json:"customer_name,omitempty"`
+	CustomerGSTIN     string          `json:"customer_gstin,omitempty"`
+	InvoicesIssued    int             `json:"invoices_issued"`
+	TotalInvoiced     decimal.Decimal `json:"total_invoiced"`
+	TotalCollected    decimal.Decimal `json:"total_collected"`
+	CreditNotes       decimal.Decimal `json:"credit_notes"`
+	DilutionRate      decimal.Decimal `json:"dilution_rate"`
+	AverageDaysToPay  decimal.Decimal `json:"average_days_to_pay"`
+	OnTimePaymentRate decimal.Decimal `json:"on_time_payment_rate"`
+	Outstanding       decimal.Decimal `json:"outstanding"`
+	OverdueAmount     decimal.Decimal `json:"overdue_amount"`
+}
+
+// agingBuckets are the days-past-due bands of a data pack, in order
+var agingBuckets = []string{"current", "1-30", "31-60", "61-90", "90+"}
+
+// FinancingService manages consents to share receivables with lenders and
+// produces the data packs they ask for
+type FinancingService interface {
+	GrantConsent(ctx context.Context, req GrantFinancingConsentRequest) (*models.FinancingConsent, error)
+	RevokeConsent(ctx context.Context, tenantID, id, userID uuid.UUID) (*models.FinancingConsent, error)
+	ListConsents(ctx context.Context, tenantID uuid.UUID) ([]models.FinancingConsent, error)
+	ListExports(ctx context.Context, tenantID, consentID uuid.UUID) ([]models.FinancingExport, error)
+
+	// Export builds a data pack under an active consent and logs it
+	Export(ctx context.Context, req FinancingExportRequest) (*FinancingDataPack, error)
+}
+
+type financingService struct {
+	repo repository.FinancingRepository
+}
+
+// NewFinancingService creates a new financing service
+func NewFinancingService(repo repository.FinancingRepository) FinancingService {
+	return &financingService{repo: repo}
+}
+
+func (s *financingService) GrantConsent(ctx context.Context, req GrantFinancingConsentRequest) (*models.FinancingConsent, error) {
+	if !req.Accepted {
+		return nil, ErrFinancingConsentRequired
+	}
+
+	today := truncateDay(time.Now())
+	validUntil, err := time.Parse("2006-01-02", req.ValidUntil)
+	if err != nil || validUntil.Before(today) || validUntil.After(today.AddDate(1, 0, 0)) {
+		return nil, ErrInvalidConsentPeriod
+	}
+
+	consent := &models.FinancingConsent{
+		TenantID:              req.TenantID,
+		LenderName:            req.LenderName,
+		LenderReference:       req.LenderReference,
+		Purpose:               req.Purpose,
+		ShareCustomerIdentity: req.ShareCustomerIdentity,
+		GrantedBy:             req.UserID,
+		GrantedAt:             time.Now(),
+		GrantedIP:             req.ClientIP,
+		ValidUntil:            validUntil,
+	}
+	consent.ConsentText = financingConsentText(consent)
+
+	if err := s.repo.CreateConsent(ctx, consent); err != nil {
+		return nil, err
+	}
+
+	return consent, nil
+}
+
+func (s *financingService) RevokeConsent(ctx context.Context, tenantID, id, userID uuid.UUID) (*models.FinancingConsent, error) {
+	consent, err := s.repo.GetConsent(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrFinancingConsentNotFound
+	}
+	if consent.RevokedAt != nil {
+		return consent, nil
+	}
+
+	now := time.Now()
+	consent.RevokedBy = &userID
+	consent.RevokedAt = &now
+
+	if err := s.repo.UpdateConsent(ctx, consent); err != nil {
+		return nil, err
+	}
+
+	return consent, nil
+}
+
+func (s *financingService) ListConsents(ctx context.Context, tenantID uuid.UUID) ([]models.FinancingConsent, error) {
+	return s.repo.ListConsents(ctx, tenantID)
+}
+
+func (s *financingService) ListExports(ctx context.Context, tenantID, consentID uuid.UUID) ([]models.FinancingExport, error) {
+	return s.repo.ListExports(ctx, tenantID, consentID)
+}
+
+func (s *financingService) Export(ctx context.Context, req FinancingExportRequest) (*FinancingDataPack, error) {
+	if req.Format == "" {
+		req.Format = models.FinancingFormatCSV
+	}
+	if req.Format != models.FinancingFormatCSV && req.Format != models.FinancingFormatJSON {
+		return nil, ErrInvalidFinancingFormat
+	}
+
+	today := truncateDay(time.Now())
+	asOf := today
+	if req.AsOf != "" {
+		parsed, err := time.Parse("2006-01-02", req.AsOf)
+		if err != nil || parsed.After(today) {
+			return nil, ErrInvalidAsOfDate
+		}
+		asOf = parsed
+	}
+
+	consent, err := s.repo.GetConsent(ctx, req.TenantID, req.ConsentID)
+	if err != nil {
+		return nil, ErrFinancingConsentNotFound
+	}
+	if !consent.IsActive(time.Now()) {
+		return nil, ErrFinancingConsentInactive
+	}
+
+	pack, err := s.buildDataPack(ctx, consent, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.FinancingExport{
+		ID:               pack.ExportID,
+		TenantID:         req.TenantID,
+		ConsentID:        consent.ID,
+		LenderName:       consent.LenderName,
+		Format:           req.Format,
+		AsOf:             asOf,
+		InvoiceCount:     pack.Summary.InvoiceCount,
+		CustomerCount:    pack.Summary.CustomerCount,
+		TotalOutstanding: pack.Summary.TotalOutstanding,
+		ExportedBy:       req.UserID,
+		ExportedIP:       req.ClientIP,
+	}
+	if err := s.repo.CreateExport(ctx, export); err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+// buildDataPack gathers the open invoices as of a date with the credit
+// notes against them, and the credit history of the customers owing them
+func (s *financingService) buildDataPack(ctx context.Context, consent *models.FinancingConsent, asOf time.Time) (*FinancingDataPack, error) {
+	invoices, err := s.repo.ListOpenInvoices(ctx, consent.TenantID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	invoiceIDs := make([]uuid.UUID, len(invoices))
+	for i := range invoices {
+		invoiceIDs[i] = invoices[i].ID
+	}
+	credited, err := s.repo.SumCreditNotesByInvoice(ctx, consent.TenantID, invoiceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	historyFrom := asOf.AddDate(0, -financingHistoryMonths, 0)
+	pack := &FinancingDataPack{
+		SchemaVersion:   FinancingSchemaVersion,
+		ExportID:        uuid.New(),
+		ConsentID:       consent.ID,
+		LenderName:      consent.LenderName,
+		LenderReference: consent.LenderReference,
+		SellerID:        consent.TenantID,
+		AsOf:            asOf.Format("2006-01-02"),
+		HistoryFrom:     historyFrom.Format("2006-01-02"),
+		GeneratedAt:     time.Now(),
+		Currency:        "INR",
+		Summary:         FinancingSummary{Aging: make(map[string]decimal.Decimal, len(agingBuckets))},
+		Invoices:        make([]FinancedInvoice, 0, len(invoices)),
+		Customers:       []CustomerCreditHistory{},
+	}
+	for _, bucket := range agingBuckets {
+		pack.Summary.Aging[bucket] = decimal.Zero
+	}
+
+	customers := make(map[uuid.UUID]*CustomerCreditHistory)
+	var customerIDs []uuid.UUID
+	for i := range invoices {
+		invoice := &invoices[i]
+
+		daysPastDue := int(asOf.Sub(truncateDay(invoice.DueDate)).Hours() / 24)
+		if daysPastDue < 0 {
+			daysPastDue = 0
+		}
+		bucket := agingBucket(daysPastDue)
+
+		line := FinancedInvoice{
+			InvoiceNumber:  invoice.InvoiceNumber,
+			IRN:            invoice.IRN,
+			InvoiceDate:    invoice.InvoiceDate.Format("2006-01-02"),
+			DueDate:        invoice.DueDate.Format("2006-01-02"),
+			CustomerRef:    invoice.CustomerID,
+			InvoiceAmount:  invoice.TotalAmount,
+			AmountPaid:     invoice.AmountPaid,
+			CreditNotes:    credited[invoice.ID],
+			Outstanding:    invoice.BalanceDue,
+			DisputedAmount: decimal.Min(invoice.DisputedAmount, invoice.BalanceDue),
+			DaysPastDue:    daysPastDue,
+			AgingBucket:    bucket,
+		}
+		if consent.ShareCustomerIdentity {
+			line.CustomerName = invoice.CustomerName
+			line.CustomerGSTIN = invoice.CustomerGSTIN
+		}
+		pack.Invoices = append(pack.Invoices, line)
+
+		customer, ok := customers[invoice.CustomerID]
+		if !ok {
+			customer = &CustomerCreditHistory{
+				CustomerRef:   invoice.CustomerID,
+				CustomerName:  line.CustomerName,
+				CustomerGSTIN: line.CustomerGSTIN,
+			}
+			customers[invoice.CustomerID] = customer
+			customerIDs = append(customerIDs, invoice.CustomerID)
+		}
+		customer.Outstanding = customer.Outstanding.Add(invoice.BalanceDue)
+
+		summary := &pack.Summary
+		summary.InvoiceCount++
+		summary.TotalOutstanding = summary.TotalOutstanding.Add(invoice.BalanceDue)
+		summary.DisputedAmount = summary.DisputedAmount.Add(line.DisputedAmount)
+		summary.Aging[bucket] = summary.Aging[bucket].Add(invoice.BalanceDue)
+		if daysPastDue > 0 {
+			customer.OverdueAmount = customer.OverdueAmount.Add(invoice.BalanceDue)
+			summary.OverdueAmount = summary.OverdueAmount.Add(invoice.BalanceDue)
+		}
+	}
+
+	history, err := s.repo.GetCustomerCreditHistory(ctx, consent.TenantID, customerIDs, historyFrom, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalInvoiced, totalCredited decimal.Decimal
+	for _, id := range customerIDs {
+		customer := customers[id]
+		if row, ok := history[id]; ok {
+			customer.InvoicesIssued = row.InvoicesIssued
+			customer.TotalInvoiced = row.TotalInvoiced
+			customer.TotalCollected = row.TotalCollected
+			customer.CreditNotes = row.CreditNotes
+			customer.DilutionRate = ratio(row.CreditNotes, row.TotalInvoiced)
+			customer.AverageDaysToPay = decimal.NewFromFloat(row.AverageDaysToPay).Round(1)
+			customer.OnTimePaymentRate = decimal.NewFromFloat(row.OnTimeShare).Round(4)
+			totalInvoiced = totalInvoiced.Add(row.TotalInvoiced)
+			totalCredited = totalCredited.Add(row.CreditNotes)
+		}
+		pack.Customers = append(pack.Customers, *customer)
+	}
+	sort.Slice(pack.Customers, func(i, j int) bool {
+		return pack.Customers[i].Outstanding.GreaterThan(pack.Customers[j].Outstanding)
+	})
+
+	pack.Summary.CustomerCount = len(pack.Customers)
+	pack.Summary.DilutionRate = ratio(totalCredited, totalInvoiced)
+
+	return pack, nil
+}
+
+// WriteCSV writes the data pack as one row per open invoice, each carrying
+// its customer's credit history
+func (p *FinancingDataPack) WriteCSV(w io.Writer) error {
+	history := make(map[uuid.UUID]*CustomerCreditHistory, len(p.Customers))
+	for i := range p.Customers {
+		history[p.Customers[i].CustomerRef] = &p.Customers[i]
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{
+		"schema_version", "seller_id", "as_of",
+		"invoice_number", "irn", "invoice_date", "due_date",
+		"customer_ref", "customer_name", "customer_gstin",
+		"invoice_amount", "amount_paid", "credit_notes", "outstanding", "disputed_amount",
+		"days_past_due", "aging_bucket",
+		"customer_invoices_issued", "customer_total_invoiced", "customer_total_collected",
+		"customer_credit_notes", "customer_dilution_rate", "customer_average_days_to_pay",
+		"customer_on_time_payment_rate", "customer_outstanding", "customer_overdue_amount",
+	}); err != nil {
+		return err
+	}
+
+	for _, invoice := range p.Invoices {
+		customer := history[invoice.CustomerRef]
+		if err := out.Write([]string{
+			p.SchemaVersion, p.SellerID.String(), p.AsOf,
+			invoice.InvoiceNumber, invoice.IRN, invoice.InvoiceDate, invoice.DueDate,
+			invoice.CustomerRef.String(), invoice.CustomerName, invoice.CustomerGSTIN,
+			invoice.InvoiceAmount.StringFixed(2), invoice.AmountPaid.StringFixed(2), invoice.CreditNotes.StringFixed(2),
+			invoice.Outstanding.StringFixed(2), invoice.DisputedAmount.StringFixed(2),
+			strconv.Itoa(invoice.DaysPastDue), invoice.AgingBucket,
+			strconv.Itoa(customer.InvoicesIssued), customer.TotalInvoiced.StringFixed(2), customer.TotalCollected.StringFixed(2),
+			customer.CreditNotes.StringFixed(2), customer.DilutionRate.String(), customer.AverageDaysToPay.String(),
+			customer.OnTimePaymentRate.String(), customer.Outstanding.StringFixed(2), customer.OverdueAmount.StringFixed(2),
+		}); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// financingConsentText is the statement the user accepts when granting a
+// consent
+func financingConsentText(consent *models.FinancingConsent) string {
+	identity := "Customers will be identified by an opaque reference only."
+	if consent.ShareCustomerIdentity {
+		identity = "Customer names and GSTINs will be included."
+	}
+	return fmt.Sprintf(
+		"I authorise the export of our open invoices, their aging, credit notes against them and our customers' payment history "+
+			"for sharing with %s for the purpose of invoice financing or a loan against receivables, until %s. %s "+
+			"Each export is logged and this consent may be revoked at any time.",
+		consent.LenderName, consent.ValidUntil.Format("2006-01-02"), identity)
+}
+
+// agingBucket returns the days-past-due band of an invoice
+func agingBucket(daysPastDue int) string {
+	switch {
+	case daysPastDue <= 0:
+		return agingBuckets[0]
+	case daysPastDue <= 30:
+		return agingBuckets[1]
+	case daysPastDue <= 60:
+		return agingBuckets[2]
+	case daysPastDue <= 90:
+		return agingBuckets[3]
+	default:
+		return agingBuckets[4]
+	}
+}
+
+// ratio returns part as a share of whole to four decimal places
+func ratio(part, whole decimal.Decimal) decimal.Decimal {
+	if !whole.IsPositive() {
+		return decimal.Zero
+	}
+	return part.Div(whole).Round(4)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	// ErrInvalidFinancialYear is returned for a financial year not in the
+	// form 2024-25
+	ErrInvalidFinancialYear = errors.New("financial year must be in the form 2024-25")
+	// ErrInvalidReturnPeriod is returned for a return period not in the
+	// form MMYYYY
+	ErrInvalidReturnPeriod = errors.New("return period must be in the form MMYYYY")
+)
+
+// GSTTotals is the taxable value and GST of a group of documents
+type GSTTotals struct {
+	Taxable decimal.Decimal `json:"taxable_value"`
+	CGST    decimal.Decimal `json:"cgst"`
+	SGST    decimal.Decimal `json:"sgst"`
+	IGST    decimal.Decimal `json:"igst"`
+	Cess    decimal.Decimal `json:"cess"`
+}
+
+// AnnualGSTBooks is what the books show for a financial year, in the heads
+// of the annual return (GSTR-9). Documents of the year recorded or issued
+// after it ended, up to the last date for amending the year's returns (30
+// November), are shown separately as they belong in tables 10 and 11.
+type AnnualGSTBooks struct {
+	FinancialYear   string    `json:"financial_year"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	AmendmentsUntil time.Time `json:"amendments_until"`
+
+	B2B                   GSTTotals `json:"b2b"`
+	B2C                   GSTTotals `json:"b2c"`
+	ExportsWithPayment    GSTTotals `json:"exports_with_payment"`
+	ExportsWithoutPayment GSTTotals `json:"exports_without_payment"`
+	NilExempt             GSTTotals `json:"nil_exempt"`
+	Advances              GSTTotals `json:"advances"` // Received less refunded
+	CreditNotes           GSTTotals `json:"credit_notes"`
+
+	ReverseCharge    GSTTotals `json:"reverse_charge"` // Self-invoices for URD purchases
+	ReverseChargeITC GSTTotals `json:"reverse_charge_itc"`
+	InwardITC        GSTTotals `json:"inward_itc"`     // Net of DebitNoteITC
+	BlockedITC       GSTTotals `json:"blocked_itc"`    // Lines not eligible, e.g. section 17(5)
+	DebitNoteITC     GSTTotals `json:"debit_note_itc"` // Reversed on purchase returns
+
+	InvoicesAfterYearEnd    GSTTotals `json:"invoices_after_year_end"`     // Dated in the year, recorded after it
+	CreditNotesAfterYearEnd GSTTotals `json:"credit_notes_after_year_end"` // Against the year's invoices
+}
+
+// MonthlyGSTBooks is what the books show for a month, in the heads the
+// monthly return (GSTR-3B) pays tax under
+type MonthlyGSTBooks struct {
+	Period string    `json:"period"` // MMYYYY
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	B2B                   GSTTotals `json:"b2b"`
+	B2C                   GSTTotals `json:"b2c"`
+	ExportsWithPayment    GSTTotals `json:"exports_with_payment"`
+	ExportsWithoutPayment GSTTotals `json:"exports_without_payment"`
+	NilExempt             GSTTotals `json:"nil_exempt"`
+	Advances              GSTTotals `json:"advances"` // Received less refunded
+	CreditNotes           GSTTotals `json:"credit_notes"`
+
+	ReverseCharge    GSTTotals `json:"reverse_charge"` // Self-invoices for URD purchases
+	ReverseChargeITC GSTTotals `json:"reverse_charge_itc"`
+	InwardITC        GSTTotals `json:"inward_itc"`     // Net of DebitNoteITC
+	BlockedITC       GSTTotals `json:"blocked_itc"`    // Lines not eligible, e.g. section 17(5)
+	DebitNoteITC     GSTTotals `json:"debit_note_itc"` // Reversed on purchase returns
+}
+
+// GSTAnnualService totals the books for the annual and monthly GST returns
+type GSTAnnualService interface {
+	Books(ctx context.Context, tenantID uuid.UUID, financialYear string) (*AnnualGSTBooks, error)
+	// MonthlyBooks totals a month's documents, such as 072025 for July 2025
+	MonthlyBooks(ctx context.Context, tenantID uuid.UUID, period string) (*MonthlyGSTBooks, error)
+}
+
+type gstAnnualService struct {
+	repo repository.GSTAnnualRepository
+}
+
+// NewGSTAnnualService creates a new annual GST service
+func NewGSTAnnualService(repo repository.GSTAnnualRepository) GSTAnnualService {
+	return &gstAnnualService{repo: repo}
+}
+
+// Books totals a financial year's invoices, credit notes, advances,
+// self-invoices, bills and debit notes
+func (s *gstAnnualService) Books(ctx context.Context, tenantID uuid.UUID, financialYear string) (*AnnualGSTBooks, error) {
+	var startYear, endYear int
+	if _, err := fmt.Sscanf(financialYear, "%4d-%2d", &startYear, &endYear); err != nil || (startYear+1)%100 != endYear {
+		return nil, ErrInvalidFinancialYear
+	}
+	from := time.Date(startYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	amendmentsEnd := time.Date(startYear+1, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	books := &AnnualGSTBooks{
+		FinancialYear:   financialYear,
+		From:            from,
+		To:              to.AddDate(0, 0, -1),
+		AmendmentsUntil: amendmentsEnd.AddDate(0, 0, -1),
+	}
+
+	outward, err := s.repo.OutwardTotals(ctx, tenantID, from, to, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range outward {
+		switch row.Category {
+		case repository.OutwardB2B:
+			books.B2B = gstTotals(&row)
+		case repository.OutwardB2C:
+			books.B2C = gstTotals(&row)
+		case repository.OutwardExportWithPayment:
+			books.ExportsWithPayment = gstTotals(&row)
+		case repository.OutwardExportWithoutPayment:
+			books.ExportsWithoutPayment = gstTotals(&row)
+		case repository.OutwardNilExempt:
+			books.NilExempt = gstTotals(&row)
+		}
+	}
+
+	late, err := s.repo.OutwardTotals(ctx, tenantID, from, to, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range late {
+		books.InvoicesAfterYearEnd = addGSTTotals(books.InvoicesAfterYearEnd, gstTotals(&row))
+	}
+
+	creditNotes, err := s.repo.CreditNoteTotals(ctx, tenantID, from, to, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	books.CreditNotes = gstTotals(creditNotes)
+
+	lateCreditNotes, err := s.repo.CreditNoteTotals(ctx, tenantID, to, amendmentsEnd, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+	books.CreditNotesAfterYearEnd = gstTotals(lateCreditNotes)
+
+	advances, err := s.repo.AdvanceTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.Advances = gstTotals(advances)
+
+	reverseCharge, reverseChargeITC, err := s.repo.ReverseChargeTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.ReverseCharge = gstTotals(reverseCharge)
+	books.ReverseChargeITC = gstTotals(reverseChargeITC)
+
+	eligible, blocked, err := s.repo.InwardITCTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.BlockedITC = gstTotals(blocked)
+
+	reversed, err := s.repo.DebitNoteITCTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.DebitNoteITC = gstTotals(reversed)
+	books.InwardITC = subGSTTotals(gstTotals(eligible), books.DebitNoteITC)
+
+	return books, nil
+}
+
+// MonthlyBooks totals a month's invoices, credit notes, advances,
+// self-invoices, bills and debit notes, whenever they were recorded
+func (s *gstAnnualService) MonthlyBooks(ctx context.Context, tenantID uuid.UUID, period string) (*MonthlyGSTBooks, error) {
+	from, err := time.Parse("012006", period)
+	if err != nil {
+		return nil, ErrInvalidReturnPeriod
+	}
+	to := from.AddDate(0, 1, 0)
+
+	books := &MonthlyGSTBooks{Period: period, From: from, To: to.AddDate(0, 0, -1)}
+
+	outward, err := s.repo.OutwardTotals(ctx, tenantID, from, to, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range outward {
+		switch row.Category {
+		case repository.OutwardB2B:
+			books.B2B = gstTotals(&row)
+		case repository.OutwardB2C:
+			books.B2C = gstTotals(&row)
+		case repository.OutwardExportWithPayment:
+			books.ExportsWithPayment = gstTotals(&row)
+		case repository.OutwardExportWithoutPayment:
+			books.ExportsWithoutPayment = gstTotals(&row)
+		case repository.OutwardNilExempt:
+			books.NilExempt = gstTotals(&row)
+		}
+	}
+
+	creditNotes, err := s.repo.CreditNoteTotals(ctx, tenantID, from, to, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	books.CreditNotes = gstTotals(creditNotes)
+
+	advances, err := s.repo.AdvanceTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.Advances = gstTotals(advances)
+
+	reverseCharge, reverseChargeITC, err := s.repo.ReverseChargeTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.ReverseCharge = gstTotals(reverseCharge)
+	books.ReverseChargeITC = gstTotals(reverseChargeITC)
+
+	eligible, blocked, err := s.repo.InwardITCTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.BlockedITC = gstTotals(blocked)
+
+	reversed, err := s.repo.DebitNoteITCTotals(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	books.DebitNoteITC = gstTotals(reversed)
+	books.InwardITC = subGSTTotals(gstTotals(eligible), books.DebitNoteITC)
+
+	return books, nil
+}
+
+func gstTotals(row *repository.GSTTotalsRow) GSTTotals {
+	return GSTTotals{Taxable: row.Taxable, CGST: row.CGST, SGST: row.SGST, IGST: row.IGST, Cess: row.Cess}
+}
+
+func addGSTTotals(a, b GSTTotals) GSTTotals {
+	return GSTTotals{
+		Taxable: a.Taxable.Add(b.Taxable),
+		CGST:    a.CGST.Add(b.CGST),
+		SGST:    a.SGST.Add(b.SGST),
+		IGST:    a.IGST.Add(b.IGST),
+		Cess:    a.Cess.Add(b.Cess),
+	}
+}
+
+func subGSTTotals(a, b GSTTotals) GSTTotals {
+	return GSTTotals{
+		Taxable: a.Taxable.Sub(b.Taxable),
+		CGST:    a.CGST.Sub(b.CGST),
+		SGST:    a.SGST.Sub(b.SGST),
+		IGST:    a.IGST.Sub(b.IGST),
+		Cess:    a.Cess.Sub(b.Cess),
+	}
+}
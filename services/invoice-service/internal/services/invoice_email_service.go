@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/email"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/documents"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrInvoiceNotEmailable  = errors.New("only invoices that have been sent, and are not cancelled, can be emailed")
+	ErrNoCustomerEmail      = errors.New("the invoice has no customer email address")
+	ErrInvalidCustomerEmail = errors.New("customer_email is not an email address")
+)
+
+// invoiceEmailReference is the prefix of the delivery reference of invoice
+// emails, followed by the invoice ID
+const invoiceEmailReference = "invoice:"
+
+// InvoiceEmailService emails invoices to their customers with the PDF and
+// follows the emails' delivery on the invoices
+type InvoiceEmailService interface {
+	// Send queues the invoice to the customer's email address, read from
+	// the tenant service on behalf of the caller identified by
+	// authorization. It returns nil when the invoice has no email address.
+	Send(ctx context.Context, authorization string, invoice *models.Invoice) (*email.Delivery, error)
+	// Email emails a sent invoice again, first correcting the customer's
+	// address when one is given, as after a bounce
+	Email(ctx context.Context, authorization string, invoice *models.Invoice, address string) (*email.Delivery, error)
+	// TrackDelivery updates the invoice an email was about when the
+	// provider reports on it. It is registered as the mailer's listener.
+	TrackDelivery(ctx context.Context, delivery *email.Delivery)
+}
+
+type invoiceEmailService struct {
+	invoiceRepo    repository.InvoiceRepository
+	tenantClient   clients.TenantClient
+	brandingClient clients.BrandingClient
+	mailer         *email.Mailer
+	history        *timeline.Store
+}
+
+// NewInvoiceEmailService creates a new invoice email service
+func NewInvoiceEmailService(invoiceRepo repository.InvoiceRepository, tenantClient clients.TenantClient, brandingClient clients.BrandingClient, mailer *email.Mailer, history *timeline.Store) InvoiceEmailService {
+	return &invoiceEmailService{
+		invoiceRepo:    invoiceRepo,
+		tenantClient:   tenantClient,
+		brandingClient: brandingClient,
+		mailer:         mailer,
+		history:        history,
+	}
+}
+
+func (s *invoiceEmailService) Send(ctx context.Context, authorization string, invoice *models.Invoice) (*email.Delivery, error) {
+	if invoice.CustomerEmail == "" {
+		return nil, nil
+	}
+
+	// As with downloads, the invoice goes out without the seller's details
+	// or branding when they cannot be read
+	seller, err := s.tenantClient.GetTenant(ctx, authorization, invoice.TenantID)
+	if err != nil {
+		log.Printf("Failed to read tenant %s to email invoice %s: %v", invoice.TenantID, invoice.ID, err)
+		seller = nil
+	}
+	branding, err := s.brandingClient.GetBranding(ctx, invoice.TenantID)
+	if err != nil {
+		log.Printf("Failed to read the branding of tenant %s to email invoice %s: %v", invoice.TenantID, invoice.ID, err)
+		branding = nil
+	}
+
+	var pdf bytes.Buffer
+	if err := documents.WriteInvoicePDF(&pdf, invoice, seller, documents.InvoicePDFOptions{Branding: branding}); err != nil {
+		return nil, err
+	}
+
+	data := email.Invoice{
+		CustomerName:  invoice.CustomerName,
+		InvoiceNumber: invoice.InvoiceNumber,
+		InvoiceDate:   invoice.InvoiceDate,
+		DueDate:       invoice.DueDate,
+		Amount:        invoice.Currency + " " + invoice.TotalAmount.StringFixed(2),
+		Notes:         invoice.Notes,
+	}
+	// Customers' replies go to the seller rather than the no-reply sender
+	replyTo := ""
+	if seller != nil {
+		data.SellerName = seller.Name
+		if _, err := mail.ParseAddress(seller.Email); err == nil {
+			replyTo = seller.Email
+		}
+	}
+	msg, err := email.Render(email.TemplateInvoice, data)
+	if err != nil {
+		return nil, err
+	}
+	msg.To = invoice.CustomerEmail
+	msg.ToName = invoice.CustomerName
+	msg.ReplyTo = replyTo
+	msg.Attachments = []email.Attachment{{
+		Filename:    invoice.InvoiceNumber + ".pdf",
+		ContentType: "application/pdf",
+		Content:     pdf.Bytes(),
+	}}
+
+	delivery, err := s.mailer.Send(ctx, msg, email.SendOptions{
+		TenantID:  &invoice.TenantID,
+		Template:  email.TemplateInvoice,
+		Reference: invoiceEmailReference + invoice.ID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The email is queued whether or not the invoice shows it
+	now := time.Now()
+	invoice.EmailDeliveryID = &delivery.ID
+	invoice.EmailStatus = delivery.Status
+	invoice.EmailStatusAt = &now
+	invoice.EmailBounceReason = ""
+	if err := s.invoiceRepo.UpdateEmail(ctx, invoice); err != nil {
+		log.Printf("Failed to save email %s of invoice %s: %v", delivery.ID, invoice.ID, err)
+	}
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventEmailed,
+		fmt.Sprintf("Emailed to %s", delivery.ToAddress), nil)
+
+	return delivery, nil
+}
+
+func (s *invoiceEmailService) Email(ctx context.Context, authorization string, invoice *models.Invoice, address string) (*email.Delivery, error) {
+	if invoice.Status == models.InvoiceStatusDraft || invoice.Status == models.InvoiceStatusCancelled {
+		return nil, ErrInvoiceNotEmailable
+	}
+	if address != "" {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, ErrInvalidCustomerEmail
+		}
+		invoice.CustomerEmail = parsed.Address
+	}
+	if invoice.CustomerEmail == "" {
+		return nil, ErrNoCustomerEmail
+	}
+	return s.Send(ctx, authorization, invoice)
+}
+
+func (s *invoiceEmailService) TrackDelivery(ctx context.Context, delivery *email.Delivery) {
+	if !strings.HasPrefix(delivery.Reference, invoiceEmailReference) || delivery.TenantID == nil {
+		return
+	}
+	invoiceID, err := uuid.Parse(strings.TrimPrefix(delivery.Reference, invoiceEmailReference))
+	if err != nil {
+		return
+	}
+
+	at := time.Now()
+	switch {
+	case delivery.Status == email.StatusDelivered && delivery.DeliveredAt != nil:
+		at = *delivery.DeliveredAt
+	case delivery.Status == email.StatusOpened && delivery.OpenedAt != nil:
+		at = *delivery.OpenedAt
+	case delivery.Status == email.StatusBounced && delivery.BouncedAt != nil:
+		at = *delivery.BouncedAt
+	}
+
+	// Reports on an earlier email of the invoice are not shown on it
+	latest, err := s.invoiceRepo.UpdateEmailStatus(ctx, invoiceID, delivery.ID, delivery.Status, delivery.BounceReason, at)
+	if err != nil {
+		log.Printf("Failed to save email status of invoice %s: %v", invoiceID, err)
+		return
+	}
+	if latest && delivery.Status == email.StatusBounced {
+		doc := timeline.Document{TenantID: *delivery.TenantID, Type: timeline.DocumentInvoice, ID: invoiceID}
+		summary := fmt.Sprintf("Email to %s bounced", delivery.ToAddress)
+		if delivery.BounceReason != "" {
+			summary += ": " + delivery.BounceReason
+		}
+		record(ctx, s.history, doc, timeline.EventEmailBounced, summary, nil)
+	}
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/lifecycle"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/webhook"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/documents"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Invoice export queue jobs
+const (
+	JobExportInvoicePDFs   = "invoices.export_pdfs"
+	JobPurgeInvoiceExports = "invoices.purge_exports"
+)
+
+const (
+	invoiceExportLinkTTL     = 24 * time.Hour
+	invoiceExportProgressGap = 25 // Invoices rendered between progress updates
+)
+
+var (
+	ErrInvoiceExportNotFound    = errors.New("invoice export not found")
+	ErrInvoiceExportActive      = errors.New("an invoice export is already queued or in progress")
+	ErrInvalidInvoiceExport     = errors.New("from_date and to_date must be dates in YYYY-MM-DD format, in order and at most a year apart")
+	ErrInvoiceExportUnavailable = errors.New("invoice export has not completed or has expired")
+	ErrInvalidExportLink        = errors.New("download link is invalid or has expired")
+)
+
+// StartInvoiceExportRequest asks for the PDFs of the invoices dated within
+// a range
+type StartInvoiceExportRequest struct {
+	TenantID      uuid.UUID `json:"-"`
+	UserID        uuid.UUID `json:"-"`
+	Authorization string    `json:"-"` // Used to read the seller's details
+	FromDate      string    `json:"from_date" binding:"required"`
+	ToDate        string    `json:"to_date" binding:"required"`
+}
+
+// ExportInvoicePDFsPayload is the payload of a JobExportInvoicePDFs job. The
+// seller is read when the export is requested, as the job runs without the
+// caller's credentials.
+type ExportInvoicePDFsPayload struct {
+	ExportID uuid.UUID       `json:"export_id"`
+	Seller   *clients.Tenant `json:"seller,omitempty"`
+}
+
+// InvoiceExportService renders the invoices of a period to PDFs, zipped in
+// one folder per month, in the background. Finished zips are downloaded
+// through a signed link that needs no login, so it can be handed to an
+// auditor.
+type InvoiceExportService interface {
+	Start(ctx context.Context, req *StartInvoiceExportRequest) (*models.InvoiceExport, error)
+	// Get returns an export with a fresh download link once it has completed
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceExport, error)
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.InvoiceExport, error)
+
+	// Process builds an export's zip. A failed export is rebuilt from the
+	// start when retried.
+	Process(ctx context.Context, payload ExportInvoicePDFsPayload) error
+
+	// Download returns an export with its zip, given the expiry and
+	// signature of its download link
+	Download(ctx context.Context, id uuid.UUID, expires, signature string) (*models.InvoiceExport, error)
+
+	// PurgeExpired drops the zips of exports past their retention
+	PurgeExpired(ctx context.Context) error
+}
+
+type invoiceExportService struct {
+	repo           repository.InvoiceExportRepository
+	tenantClient   clients.TenantClient
+	brandingClient clients.BrandingClient
+	queue          *jobs.Queue
+	linkSecret     string
+	tracker        *lifecycle.Tracker
+}
+
+// NewInvoiceExportService creates a new invoice export service. linkSecret
+// signs the download links.
+func NewInvoiceExportService(repo repository.InvoiceExportRepository, tenantClient clients.TenantClient, brandingClient clients.BrandingClient, queue *jobs.Queue, linkSecret string, tracker *lifecycle.Tracker) InvoiceExportService {
+	return &invoiceExportService{repo: repo, tenantClient: tenantClient, brandingClient: brandingClient, queue: queue, linkSecret: linkSecret, tracker: tracker}
+}
+
+func (s *invoiceExportService) Start(ctx context.Context, req *StartInvoiceExportRequest) (*models.InvoiceExport, error) {
+	from, err := time.Parse("2006-01-02", req.FromDate)
+	if err != nil {
+		return nil, ErrInvalidInvoiceExport
+	}
+	to, err := time.Parse("2006-01-02", req.ToDate)
+	if err != nil {
+		return nil, ErrInvalidInvoiceExport
+	}
+	if to.Before(from) || to.After(from.AddDate(1, 0, 0)) {
+		return nil, ErrInvalidInvoiceExport
+	}
+
+	active, err := s.repo.HasActive(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrInvoiceExportActive
+	}
+
+	// The invoices still print without the seller's details if the tenant
+	// service cannot be reached
+	seller, err := s.tenantClient.GetTenant(ctx, req.Authorization, req.TenantID)
+	if err != nil {
+		log.Printf("Failed to read tenant %s for invoice export: %v", req.TenantID, err)
+		seller = nil
+	}
+
+	export := &models.InvoiceExport{
+		TenantID:  req.TenantID,
+		FromDate:  from,
+		ToDate:    to,
+		Status:    models.InvoiceExportQueued,
+		FileName:  fmt.Sprintf("invoices-%s-%s.zip", from.Format("20060102"), to.Format("20060102")),
+		CreatedBy: req.UserID,
+	}
+	if err := s.repo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	track(ctx, s.tracker, exportDocument(export), lifecycle.Move{To: models.InvoiceExportQueued, ActorID: &export.CreatedBy})
+
+	_, err = s.queue.Enqueue(ctx, JobExportInvoicePDFs, ExportInvoicePDFsPayload{ExportID: export.ID, Seller: seller}, jobs.EnqueueOptions{
+		TenantID:  &export.TenantID,
+		UniqueKey: "invoice_export:" + export.ID.String(),
+	})
+	if err != nil {
+		export.Status = models.InvoiceExportFailed
+		export.Error = err.Error()
+		_ = s.repo.Update(ctx, export)
+		track(ctx, s.tracker, exportDocument(export), lifecycle.Move{To: export.Status, Note: export.Error})
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (s *invoiceExportService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.InvoiceExport, error) {
+	export, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceExportNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if export.Available(now) {
+		// The link lasts a day, or until the zip is purged if that is sooner
+		expires := now.Add(invoiceExportLinkTTL).Truncate(time.Second)
+		if export.ExpiresAt.Before(expires) {
+			expires = *export.ExpiresAt
+		}
+		export.DownloadURL = s.downloadURL(export.ID, expires)
+		export.LinkExpires = &expires
+	}
+	return export, nil
+}
+
+func (s *invoiceExportService) List(ctx context.Context, tenantID uuid.UUID) ([]models.InvoiceExport, error) {
+	return s.repo.List(ctx, tenantID)
+}
+
+func (s *invoiceExportService) Process(ctx context.Context, payload ExportInvoicePDFsPayload) error {
+	export, err := s.repo.GetByIDAny(ctx, payload.ExportID)
+	if err != nil {
+		return err
+	}
+	if export.Status == models.InvoiceExportCompleted {
+		return nil
+	}
+
+	now := time.Now()
+	export.Status = models.InvoiceExportRunning
+	export.Error = ""
+	export.Rendered = 0
+	if export.StartedAt == nil {
+		export.StartedAt = &now
+	}
+	if err := s.repo.Update(ctx, export); err != nil {
+		return err
+	}
+	track(ctx, s.tracker, exportDocument(export), lifecycle.Move{To: export.Status})
+
+	content, err := s.build(ctx, export, payload.Seller)
+	if err != nil {
+		export.Status = models.InvoiceExportFailed
+		export.Error = err.Error()
+		_ = s.repo.Update(ctx, export)
+		track(ctx, s.tracker, exportDocument(export), lifecycle.Move{To: export.Status, Note: export.Error})
+		return err
+	}
+
+	completed := time.Now()
+	expires := completed.Add(models.InvoiceExportRetention)
+	export.Status = models.InvoiceExportCompleted
+	export.Content = content
+	export.Size = int64(len(content))
+	export.CompletedAt = &completed
+	export.ExpiresAt = &expires
+	if err := s.repo.Complete(ctx, export); err != nil {
+		return err
+	}
+	track(ctx, s.tracker, exportDocument(export), lifecycle.Move{To: export.Status})
+	return nil
+}
+
+func exportDocument(export *models.InvoiceExport) lifecycle.Document {
+	return lifecycle.Document{
+		TenantID: export.TenantID,
+		Kind:     LifecycleInvoiceExport,
+		ID:       export.ID,
+		Label:    export.FileName,
+	}
+}
+
+// build renders every invoice of the export into a zip with a folder per
+// month, such as 2025-07/INV-2507-00001.pdf, in the tenant's template and
+// branding
+func (s *invoiceExportService) build(ctx context.Context, export *models.InvoiceExport, seller *clients.Tenant) ([]byte, error) {
+	invoices, err := s.repo.ListInvoices(ctx, export.TenantID, export.FromDate, export.ToDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// The invoices print plain if the branding cannot be read
+	branding, err := s.brandingClient.GetBranding(ctx, export.TenantID)
+	if err != nil {
+		log.Printf("Failed to read the branding of tenant %s for export %s: %v", export.TenantID, export.ID, err)
+		branding = nil
+	}
+
+	export.Total = len(invoices)
+	if err := s.repo.Update(ctx, export); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	used := make(map[string]bool, len(invoices))
+	for i := range invoices {
+		invoice := &invoices[i]
+		name := invoiceExportEntryName(invoice)
+		if used[name] {
+			name = strings.TrimSuffix(name, ".pdf") + "-" + invoice.ID.String()[:8] + ".pdf"
+		}
+		used[name] = true
+
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: invoice.UpdatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := documents.WriteInvoicePDF(entry, invoice, seller, documents.InvoicePDFOptions{Branding: branding}); err != nil {
+			return nil, fmt.Errorf("invoice %s: %w", invoice.InvoiceNumber, err)
+		}
+
+		export.Rendered = i + 1
+		if export.Rendered%invoiceExportProgressGap == 0 {
+			if err := s.repo.UpdateProgress(ctx, export.ID, export.Rendered); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// invoiceExportEntryName is an invoice's path in the zip. Slashes, which
+// GST allows in invoice numbers, would otherwise start a folder.
+func invoiceExportEntryName(invoice *models.Invoice) string {
+	number := strings.NewReplacer("/", "-", "\\", "-").Replace(invoice.InvoiceNumber)
+	return invoice.InvoiceDate.Format("2006-01") + "/" + number + ".pdf"
+}
+
+func (s *invoiceExportService) Download(ctx context.Context, id uuid.UUID, expires, signature string) (*models.InvoiceExport, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrInvalidExportLink
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expiresAt))) {
+		return nil, ErrInvalidExportLink
+	}
+
+	export, err := s.repo.GetContent(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceExportNotFound
+		}
+		return nil, err
+	}
+	if !export.Available(time.Now()) || len(export.Content) == 0 {
+		return nil, ErrInvoiceExportUnavailable
+	}
+	return export, nil
+}
+
+func (s *invoiceExportService) PurgeExpired(ctx context.Context) error {
+	purged, err := s.repo.PurgeExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %d expired invoice exports", purged)
+	}
+	return nil
+}
+
+// downloadURL is the path of an export's zip, signed to be valid until
+// expires
+func (s *invoiceExportService) downloadURL(id uuid.UUID, expires time.Time) string {
+	return fmt.Sprintf("/api/v1/public/invoice-exports/%s/download?expires=%d&signature=%s",
+		id, expires.Unix(), s.sign(id, expires.Unix()))
+}
+
+func (s *invoiceExportService) sign(id uuid.UUID, expires int64) string {
+	return webhook.Compute(s.linkSecret, expires, []byte("invoice_export:"+id.String()))
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/messaging"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// InvoiceMessageService messages invoices and payment reminders to the
+// customer's phone on the channels their seller chose, alongside the email
+type InvoiceMessageService interface {
+	// SendInvoice queues a message about the invoice on each of the
+	// tenant's invoice channels. It returns nothing when the invoice has no
+	// phone number or the tenant messages on no channel.
+	SendInvoice(ctx context.Context, invoice *models.Invoice) ([]*messaging.Delivery, error)
+	// SendReminder does the same for a payment reminder on the tenant's
+	// reminder channels, daysOverdue days after the due date
+	SendReminder(ctx context.Context, invoice *models.Invoice, daysOverdue int) ([]*messaging.Delivery, error)
+}
+
+type invoiceMessageService struct {
+	messenger   *messaging.Messenger
+	preferences *messaging.PreferencesClient
+}
+
+// NewInvoiceMessageService creates a new invoice message service
+func NewInvoiceMessageService(messenger *messaging.Messenger, preferences *messaging.PreferencesClient) InvoiceMessageService {
+	return &invoiceMessageService{
+		messenger:   messenger,
+		preferences: preferences,
+	}
+}
+
+func (s *invoiceMessageService) SendInvoice(ctx context.Context, invoice *models.Invoice) ([]*messaging.Delivery, error) {
+	if invoice.CustomerPhone == "" {
+		return nil, nil
+	}
+	preferences, err := s.preferences.Get(ctx, invoice.TenantID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	content := messaging.Invoice(preferences.TenantName, invoice.InvoiceNumber,
+		invoice.Currency+" "+invoice.TotalAmount.StringFixed(2), invoice.DueDate.Format("02 Jan 2006"))
+	return s.send(ctx, invoice, preferences.InvoiceChannels, content)
+}
+
+func (s *invoiceMessageService) SendReminder(ctx context.Context, invoice *models.Invoice, daysOverdue int) ([]*messaging.Delivery, error) {
+	if invoice.CustomerPhone == "" {
+		return nil, nil
+	}
+	preferences, err := s.preferences.Get(ctx, invoice.TenantID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	content := messaging.PaymentReminder(preferences.TenantName, invoice.InvoiceNumber,
+		models.FormatINR(invoice.CollectibleAmount()), invoice.DueDate.Format("02 Jan 2006"), daysOverdue)
+	return s.send(ctx, invoice, preferences.ReminderChannels, content)
+}
+
+// send queues content on each channel, skipping those the customer opted
+// out of. A channel that fails does not stop the others; the first error
+// is returned with what was queued.
+func (s *invoiceMessageService) send(ctx context.Context, invoice *models.Invoice, channels []string, content messaging.Content) ([]*messaging.Delivery, error) {
+	var deliveries []*messaging.Delivery
+	var firstErr error
+	for _, channel := range channels {
+		delivery, err := s.messenger.Send(ctx, invoice.CustomerPhone, channel, content, messaging.SendOptions{
+			TenantID:  &invoice.TenantID,
+			Reference: "invoice:" + invoice.ID.String(),
+		})
+		if errors.Is(err, messaging.ErrOptedOut) {
+			log.Printf("Not messaging invoice %s by %s: the customer opted out", invoice.ID, channel)
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, firstErr
+}
@@ -8,6 +8,11 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
 )
@@ -16,34 +21,83 @@ var (
 	ErrInvoiceNotFound = errors.New("invoice not found")
 	ErrInvalidInvoice  = errors.New("invalid invoice data")
 	ErrCannotModify    = errors.New("cannot modify invoice in current status")
+	ErrTCSUnavailable  = errors.New("unable to determine TCS for invoice")
+	ErrPaymentNotFound = errors.New("payment not found")
+	ErrPaymentBounced  = errors.New("payment has already bounced")
 )
 
+// tcsThreshold206C1H is the yearly sale value per buyer above which TCS
+// under section 206C(1H) applies (Rs. 50 lakh)
+var tcsThreshold206C1H = decimal.NewFromInt(5000000)
+
 // InvoiceService handles invoice business logic
 type InvoiceService interface {
 	Create(ctx context.Context, req CreateInvoiceRequest) (*models.Invoice, error)
 	Get(ctx context.Context, id uuid.UUID) (*models.Invoice, error)
 	List(ctx context.Context, tenantID uuid.UUID, filters repository.InvoiceFilters) ([]models.Invoice, int64, error)
 	Update(ctx context.Context, id uuid.UUID, req UpdateInvoiceRequest) (*models.Invoice, error)
-	Delete(ctx context.Context, id uuid.UUID) error
-	Send(ctx context.Context, id uuid.UUID) error
+	// Delete and Send check the invoice's period is open on behalf of the
+	// caller identified by authorization, and Send posts the invoice to the
+	// ledger as them
+	Delete(ctx context.Context, id uuid.UUID, authorization string) error
+	Send(ctx context.Context, id uuid.UUID, authorization string) error
 	RecordPayment(ctx context.Context, invoiceID uuid.UUID, req RecordPaymentRequest) (*models.Payment, error)
-	GenerateEInvoice(ctx context.Context, id uuid.UUID) (*models.Invoice, error)
-	CancelEInvoice(ctx context.Context, id uuid.UUID, reason string) error
+	BouncePayment(ctx context.Context, invoiceID, paymentID uuid.UUID, req BouncePaymentRequest) (*models.Payment, error)
+
+	// Exports
+	UpdateShippingBill(ctx context.Context, id uuid.UUID, req ShippingBillRequest) (*models.Invoice, error)
+
+	// ForReturnPeriod returns the invoices issued in a month, such as
+	// 072025, with their items, for the outward return
+	ForReturnPeriod(ctx context.Context, tenantID uuid.UUID, period string) ([]models.Invoice, error)
+
+	// Tags
+	SetTags(ctx context.Context, id, tenantID uuid.UUID, tagList []string) (*models.Invoice, error)
+	ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error)
+	RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error)
+	DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error)
 }
 
 type invoiceService struct {
-	invoiceRepo repository.InvoiceRepository
-	paymentRepo repository.PaymentRepository
+	invoiceRepo     repository.InvoiceRepository
+	paymentRepo     repository.PaymentRepository
+	roundingService RoundingService
+	taxClient       clients.TaxClient
+	snapshotService TaxSnapshotService
+	paymentTerms    PaymentTermService
+	periodLock      PeriodLock
+	history         *timeline.Store
+	cashLimits      CashLimitService
+	rules           validation.Checker
+	ledger          DocumentLedger
 }
 
 // NewInvoiceService creates a new invoice service
 func NewInvoiceService(
 	invoiceRepo repository.InvoiceRepository,
 	paymentRepo repository.PaymentRepository,
+	roundingService RoundingService,
+	taxClient clients.TaxClient,
+	snapshotService TaxSnapshotService,
+	paymentTerms PaymentTermService,
+	periodLock PeriodLock,
+	history *timeline.Store,
+	cashLimits CashLimitService,
+	rules validation.Checker,
+	ledger DocumentLedger,
 ) InvoiceService {
 	return &invoiceService{
-		invoiceRepo: invoiceRepo,
-		paymentRepo: paymentRepo,
+		invoiceRepo:     invoiceRepo,
+		paymentRepo:     paymentRepo,
+		roundingService: roundingService,
+		taxClient:       taxClient,
+		snapshotService: snapshotService,
+		paymentTerms:    paymentTerms,
+		periodLock:      periodLock,
+		history:         history,
+		cashLimits:      cashLimits,
+		rules:           rules,
+		ledger:          ledger,
 	}
 }
 
@@ -51,61 +105,92 @@ func NewInvoiceService(
 type CreateInvoiceRequest struct {
 	TenantID        uuid.UUID                `json:"-"`
 	CreatedBy       uuid.UUID                `json:"-"`
+	Authorization   string                   `json:"-"` // Used to check the invoice's period is open and the tenant's validation rules
 	CustomerID      uuid.UUID                `json:"customer_id"`
 	CustomerName    string                   `json:"customer_name" binding:"required"`
 	CustomerGSTIN   string                   `json:"customer_gstin"`
+	CustomerPAN     string                   `json:"customer_pan"`
 	CustomerAddress string                   `json:"customer_address"`
 	CustomerState   string                   `json:"customer_state" binding:"required"`
 	CustomerEmail   string                   `json:"customer_email"`
 	CustomerPhone   string                   `json:"customer_phone"`
+	PONumber        string                   `json:"po_number" binding:"max=50"`
 	InvoiceDate     string                   `json:"invoice_date" binding:"required"`
 	DueDate         string                   `json:"due_date"`
+	PaymentTermID   *uuid.UUID               `json:"payment_term_id"`
 	Items           []CreateInvoiceItemRequest `json:"items" binding:"required,min=1"`
 	DiscountType    string                   `json:"discount_type"`
 	DiscountValue   decimal.Decimal          `json:"discount_value"`
 	Notes           string                   `json:"notes"`
 	Terms           string                   `json:"terms"`
+	Language        string                   `json:"language" binding:"omitempty,oneof=en hi gu ta mr"`
+	Tags            []string                 `json:"tags"`
+	Export          *ExportDetailsRequest    `json:"export"` // Set for an export invoice
 }
 
 // CreateInvoiceItemRequest represents a line item in the invoice
 type CreateInvoiceItemRequest struct {
-	ProductID   *uuid.UUID      `json:"product_id"`
-	Description string          `json:"description" binding:"required"`
-	HSNCode     string          `json:"hsn_code"`
-	Quantity    decimal.Decimal `json:"quantity" binding:"required"`
-	Unit        string          `json:"unit"`
-	Rate        decimal.Decimal `json:"rate" binding:"required"`
-	CGSTRate    decimal.Decimal `json:"cgst_rate"`
-	SGSTRate    decimal.Decimal `json:"sgst_rate"`
-	IGSTRate    decimal.Decimal `json:"igst_rate"`
-	CessRate    decimal.Decimal `json:"cess_rate"`
+	ProductID        *uuid.UUID      `json:"product_id"`
+	Description      string          `json:"description" binding:"required"`
+	HSNCode          string          `json:"hsn_code"`
+	Quantity         decimal.Decimal `json:"quantity" binding:"required"`
+	Unit             string          `json:"unit"`
+	Rate             decimal.Decimal `json:"rate" binding:"required"`
+	CGSTRate         decimal.Decimal `json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `json:"igst_rate"`
+	CessRate         decimal.Decimal `json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `json:"cess_specific_rate"`
 }
 
 // UpdateInvoiceRequest represents a request to update an invoice
 type UpdateInvoiceRequest struct {
+	Authorization   string                   `json:"-"` // Used to check the invoice's period is open and the tenant's validation rules
 	CustomerName    string                   `json:"customer_name"`
 	CustomerGSTIN   string                   `json:"customer_gstin"`
+	CustomerPAN     string                   `json:"customer_pan"`
 	CustomerAddress string                   `json:"customer_address"`
 	CustomerState   string                   `json:"customer_state"`
 	CustomerEmail   string                   `json:"customer_email"`
 	CustomerPhone   string                   `json:"customer_phone"`
+	PONumber        string                   `json:"po_number" binding:"max=50"`
 	DueDate         string                   `json:"due_date"`
+	PaymentTermID   *uuid.UUID               `json:"payment_term_id"`
 	Items           []CreateInvoiceItemRequest `json:"items"`
 	DiscountType    string                   `json:"discount_type"`
 	DiscountValue   decimal.Decimal          `json:"discount_value"`
 	Notes           string                   `json:"notes"`
 	Terms           string                   `json:"terms"`
+	Language        string                   `json:"language" binding:"omitempty,oneof=en hi gu ta mr"`
+	Tags            []string                 `json:"tags"` // Replaces the tags when present
+	Export          *ExportDetailsRequest    `json:"export"` // Replaces the export details when present
 }
 
 // RecordPaymentRequest represents a request to record a payment
 type RecordPaymentRequest struct {
 	TenantID      uuid.UUID       `json:"-"`
 	CreatedBy     uuid.UUID       `json:"-"`
+	Authorization string          `json:"-"` // Used to check the payment's period is open
 	PaymentDate   string          `json:"payment_date" binding:"required"`
 	Amount        decimal.Decimal `json:"amount" binding:"required"`
 	PaymentMethod string          `json:"payment_method" binding:"required"`
 	Reference     string          `json:"reference"`
 	Notes         string          `json:"notes"`
+
+	// Set to record the payment without taking an early-payment discount the
+	// invoice is still eligible for
+	SkipEarlyPaymentDiscount bool `json:"skip_early_payment_discount"`
+
+	// Why a cash receipt over the section 269ST limit is taken anyway,
+	// where the tenant blocks those
+	CashOverrideReason string `json:"cash_override_reason"`
+}
+
+// BouncePaymentRequest records that a payment was dishonoured
+type BouncePaymentRequest struct {
+	TenantID  uuid.UUID `json:"-"`
+	BouncedOn string    `json:"bounced_on"` // YYYY-MM-DD, defaults to today
+	Reason    string    `json:"reason" binding:"required,max=255"`
 }
 
 func (s *invoiceService) Create(ctx context.Context, req CreateInvoiceRequest) (*models.Invoice, error) {
@@ -114,11 +199,18 @@ func (s *invoiceService) Create(ctx context.Context, req CreateInvoiceRequest) (
 		return nil, ErrInvalidInvoice
 	}
 
-	var dueDate time.Time
-	if req.DueDate != "" {
-		dueDate, _ = time.Parse("2006-01-02", req.DueDate)
-	} else {
-		dueDate = invoiceDate.AddDate(0, 0, 30) // Default 30 days
+	if err := s.periodLock.CheckOpen(ctx, req.TenantID, req.Authorization, invoiceDate); err != nil {
+		return nil, err
+	}
+
+	tagList, err := tags.NormalizeAll(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	term, err := s.paymentTerms.Resolve(ctx, req.TenantID, req.CustomerID, req.PaymentTermID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate invoice number
@@ -129,45 +221,72 @@ func (s *invoiceService) Create(ctx context.Context, req CreateInvoiceRequest) (
 	}
 
 	invoice := &models.Invoice{
+		ID:              uuid.New(),
 		TenantID:        req.TenantID,
 		InvoiceNumber:   invoiceNumber,
 		CustomerID:      req.CustomerID,
 		CustomerName:    req.CustomerName,
 		CustomerGSTIN:   req.CustomerGSTIN,
+		CustomerPAN:     req.CustomerPAN,
 		CustomerAddress: req.CustomerAddress,
 		CustomerState:   req.CustomerState,
 		CustomerEmail:   req.CustomerEmail,
 		CustomerPhone:   req.CustomerPhone,
+		PONumber:        req.PONumber,
 		InvoiceDate:     invoiceDate,
-		DueDate:         dueDate,
+		DueDate:         invoiceDate.AddDate(0, 0, 30), // Default 30 days
 		Status:          models.InvoiceStatusDraft,
 		DiscountType:    req.DiscountType,
 		DiscountValue:   req.DiscountValue,
 		Notes:           req.Notes,
 		Terms:           req.Terms,
+		Language:        req.Language,
+		Tags:            tagList,
 		CreatedBy:       req.CreatedBy,
 	}
+	if invoice.Language == "" {
+		invoice.Language = i18n.DefaultLanguage
+	}
+
+	if term != nil {
+		invoice.ApplyPaymentTerm(term)
+	}
+	if req.DueDate != "" {
+		invoice.DueDate, _ = time.Parse("2006-01-02", req.DueDate)
+	}
 
 	// Create invoice items
 	for _, itemReq := range req.Items {
 		item := models.InvoiceItem{
-			ProductID:   itemReq.ProductID,
-			Description: itemReq.Description,
-			HSNCode:     itemReq.HSNCode,
-			Quantity:    itemReq.Quantity,
-			Unit:        itemReq.Unit,
-			Rate:        itemReq.Rate,
-			CGSTRate:    itemReq.CGSTRate,
-			SGSTRate:    itemReq.SGSTRate,
-			IGSTRate:    itemReq.IGSTRate,
-			CessRate:    itemReq.CessRate,
+			ProductID:        itemReq.ProductID,
+			Description:      itemReq.Description,
+			HSNCode:          itemReq.HSNCode,
+			Quantity:         itemReq.Quantity,
+			Unit:             itemReq.Unit,
+			Rate:             itemReq.Rate,
+			CGSTRate:         itemReq.CGSTRate,
+			SGSTRate:         itemReq.SGSTRate,
+			IGSTRate:         itemReq.IGSTRate,
+			CessRate:         itemReq.CessRate,
+			CessSpecificRate: itemReq.CessSpecificRate,
 		}
 		item.CalculateAmounts()
 		invoice.Items = append(invoice.Items, item)
 	}
+	if err := applyExport(invoice, req.Export); err != nil {
+		return nil, err
+	}
 
+	invoice.ApplyRoundingRule(s.roundingService.GetRule(ctx, req.TenantID, models.DocumentTypeInvoice))
 	invoice.CalculateTotals()
 
+	if err := s.applyTCS(ctx, invoice); err != nil {
+		return nil, err
+	}
+	if err := s.rules.Check(ctx, invoice.TenantID.String(), req.Authorization, invoiceRuleDocument(invoice)); err != nil {
+		return nil, err
+	}
+
 	if err := s.invoiceRepo.Create(ctx, invoice); err != nil {
 		return nil, err
 	}
@@ -193,6 +312,10 @@ func (s *invoiceService) Update(ctx context.Context, id uuid.UUID, req UpdateInv
 	if invoice.Status != models.InvoiceStatusDraft {
 		return nil, ErrCannotModify
 	}
+	if err := s.periodLock.CheckOpen(ctx, invoice.TenantID, req.Authorization, invoice.InvoiceDate); err != nil {
+		return nil, err
+	}
+	before := *invoice
 
 	// Update fields
 	if req.CustomerName != "" {
@@ -201,6 +324,9 @@ func (s *invoiceService) Update(ctx context.Context, id uuid.UUID, req UpdateInv
 	if req.CustomerGSTIN != "" {
 		invoice.CustomerGSTIN = req.CustomerGSTIN
 	}
+	if req.CustomerPAN != "" {
+		invoice.CustomerPAN = req.CustomerPAN
+	}
 	if req.CustomerAddress != "" {
 		invoice.CustomerAddress = req.CustomerAddress
 	}
@@ -213,6 +339,16 @@ func (s *invoiceService) Update(ctx context.Context, id uuid.UUID, req UpdateInv
 	if req.CustomerPhone != "" {
 		invoice.CustomerPhone = req.CustomerPhone
 	}
+	if req.PONumber != "" {
+		invoice.PONumber = req.PONumber
+	}
+	if req.PaymentTermID != nil {
+		term, err := s.paymentTerms.Get(ctx, invoice.TenantID, *req.PaymentTermID)
+		if err != nil {
+			return nil, err
+		}
+		invoice.ApplyPaymentTerm(term)
+	}
 	if req.DueDate != "" {
 		dueDate, _ := time.Parse("2006-01-02", req.DueDate)
 		invoice.DueDate = dueDate
@@ -223,39 +359,78 @@ func (s *invoiceService) Update(ctx context.Context, id uuid.UUID, req UpdateInv
 	invoice.DiscountValue = req.DiscountValue
 	invoice.Notes = req.Notes
 	invoice.Terms = req.Terms
+	if req.Language != "" {
+		invoice.Language = req.Language
+	}
+	if req.Tags != nil {
+		tagList, err := tags.NormalizeAll(req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Tags = tagList
+	}
 
 	// Update items if provided
 	if len(req.Items) > 0 {
 		invoice.Items = nil
 		for _, itemReq := range req.Items {
 			item := models.InvoiceItem{
-				InvoiceID:   invoice.ID,
-				ProductID:   itemReq.ProductID,
-				Description: itemReq.Description,
-				HSNCode:     itemReq.HSNCode,
-				Quantity:    itemReq.Quantity,
-				Unit:        itemReq.Unit,
-				Rate:        itemReq.Rate,
-				CGSTRate:    itemReq.CGSTRate,
-				SGSTRate:    itemReq.SGSTRate,
-				IGSTRate:    itemReq.IGSTRate,
-				CessRate:    itemReq.CessRate,
+				InvoiceID:        invoice.ID,
+				ProductID:        itemReq.ProductID,
+				Description:      itemReq.Description,
+				HSNCode:          itemReq.HSNCode,
+				Quantity:         itemReq.Quantity,
+				Unit:             itemReq.Unit,
+				Rate:             itemReq.Rate,
+				CGSTRate:         itemReq.CGSTRate,
+				SGSTRate:         itemReq.SGSTRate,
+				IGSTRate:         itemReq.IGSTRate,
+				CessRate:         itemReq.CessRate,
+				CessSpecificRate: itemReq.CessSpecificRate,
 			}
 			item.CalculateAmounts()
 			invoice.Items = append(invoice.Items, item)
 		}
+		if req.Export == nil && invoice.IsForeignCurrency() {
+			// New item rates are in the invoice currency too
+			for n := range invoice.Items {
+				invoice.Items[n].ForeignRate = invoice.Items[n].Rate
+			}
+			invoice.ApplyExchangeRate()
+		}
+	}
+	if req.Export != nil {
+		if len(req.Items) == 0 && invoice.IsForeignCurrency() {
+			// Item rates stay in the invoice currency, now the new one
+			for n := range invoice.Items {
+				invoice.Items[n].Rate = invoice.Items[n].ForeignRate
+				invoice.Items[n].CalculateAmounts()
+			}
+		}
+		if err := applyExport(invoice, req.Export); err != nil {
+			return nil, err
+		}
 	}
 
 	invoice.CalculateTotals()
 
+	if err := s.applyTCS(ctx, invoice); err != nil {
+		return nil, err
+	}
+	if err := s.rules.Check(ctx, invoice.TenantID.String(), req.Authorization, invoiceRuleDocument(invoice)); err != nil {
+		return nil, err
+	}
+
 	if err := s.invoiceRepo.Update(ctx, invoice); err != nil {
 		return nil, err
 	}
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventEdited, "Invoice edited",
+		timeline.Diff(before, invoice, invoiceTimelineFields...))
 
 	return invoice, nil
 }
 
-func (s *invoiceService) Delete(ctx context.Context, id uuid.UUID) error {
+func (s *invoiceService) Delete(ctx context.Context, id uuid.UUID, authorization string) error {
 	invoice, err := s.invoiceRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrInvoiceNotFound
@@ -265,11 +440,14 @@ func (s *invoiceService) Delete(ctx context.Context, id uuid.UUID) error {
 	if invoice.Status != models.InvoiceStatusDraft {
 		return ErrCannotModify
 	}
+	if err := s.periodLock.CheckOpen(ctx, invoice.TenantID, authorization, invoice.InvoiceDate); err != nil {
+		return err
+	}
 
 	return s.invoiceRepo.Delete(ctx, id)
 }
 
-func (s *invoiceService) Send(ctx context.Context, id uuid.UUID) error {
+func (s *invoiceService) Send(ctx context.Context, id uuid.UUID, authorization string) error {
 	invoice, err := s.invoiceRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrInvoiceNotFound
@@ -278,10 +456,35 @@ func (s *invoiceService) Send(ctx context.Context, id uuid.UUID) error {
 	if invoice.Status != models.InvoiceStatusDraft {
 		return ErrCannotModify
 	}
+	if err := s.periodLock.CheckOpen(ctx, invoice.TenantID, authorization, invoice.InvoiceDate); err != nil {
+		return err
+	}
+
+	// Re-check TCS against the customer's sales as of finalization
+	if err := s.applyTCS(ctx, invoice); err != nil {
+		return err
+	}
+	if invoice.TCSApplicable && !invoice.TCSRecorded {
+		if err := s.recordTCSCollection(ctx, invoice); err != nil {
+			return err
+		}
+		invoice.TCSRecorded = true
+	}
+
+	if err := s.snapshotService.CaptureInvoice(ctx, invoice); err != nil {
+		return err
+	}
+	if err := s.ledger.PostInvoice(ctx, authorization, invoice); err != nil {
+		return err
+	}
 
 	invoice.Status = models.InvoiceStatusSent
 
-	return s.invoiceRepo.Update(ctx, invoice)
+	if err := s.invoiceRepo.Update(ctx, invoice); err != nil {
+		return err
+	}
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventSent, "Invoice "+invoice.InvoiceNumber+" sent", nil)
+	return nil
 }
 
 func (s *invoiceService) RecordPayment(ctx context.Context, invoiceID uuid.UUID, req RecordPaymentRequest) (*models.Payment, error) {
@@ -294,6 +497,9 @@ func (s *invoiceService) RecordPayment(ctx context.Context, invoiceID uuid.UUID,
 	if err != nil {
 		return nil, ErrInvalidInvoice
 	}
+	if err := s.periodLock.CheckOpen(ctx, invoice.TenantID, req.Authorization, paymentDate); err != nil {
+		return nil, err
+	}
 
 	payment := &models.Payment{
 		TenantID:      req.TenantID,
@@ -306,17 +512,36 @@ func (s *invoiceService) RecordPayment(ctx context.Context, invoiceID uuid.UUID,
 		CreatedBy:     req.CreatedBy,
 	}
 
+	// The early-payment discount is only allowed when this payment settles
+	// what remains after the discount
+	if !req.SkipEarlyPaymentDiscount {
+		discount := invoice.EarlyPaymentDiscountFor(paymentDate)
+		if discount.IsPositive() && req.Amount.Add(discount).GreaterThanOrEqual(invoice.BalanceDue) {
+			payment.Discount = discount
+		}
+	}
+
+	cashFlag, err := s.cashLimits.CheckReceipt(ctx, invoice, payment, req.CashOverrideReason)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := s.paymentRepo.Create(ctx, payment); err != nil {
 		return nil, err
 	}
+	if cashFlag != nil {
+		s.cashLimits.Flag(ctx, cashFlag, payment.ID)
+		payment.Warnings = append(payment.Warnings, cashLimitWarning(cashFlag))
+	}
 
 	// Update invoice amounts
 	invoice.AmountPaid = invoice.AmountPaid.Add(req.Amount)
-	invoice.BalanceDue = invoice.TotalAmount.Sub(invoice.AmountPaid)
+	invoice.EarlyPaymentDiscount = invoice.EarlyPaymentDiscount.Add(payment.Discount)
+	invoice.BalanceDue = invoice.TotalAmount.Sub(invoice.AmountPaid).Sub(invoice.EarlyPaymentDiscount).Sub(invoice.AmountCredited)
 
 	if invoice.BalanceDue.LessThanOrEqual(decimal.Zero) {
 		invoice.Status = models.InvoiceStatusPaid
-	} else if invoice.AmountPaid.GreaterThan(decimal.Zero) {
+	} else if invoice.AmountPaid.GreaterThan(decimal.Zero) || invoice.AmountCredited.IsPositive() {
 		invoice.Status = models.InvoiceStatusPartial
 	}
 
@@ -327,43 +552,197 @@ func (s *invoiceService) RecordPayment(ctx context.Context, invoiceID uuid.UUID,
 	return payment, nil
 }
 
-func (s *invoiceService) GenerateEInvoice(ctx context.Context, id uuid.UUID) (*models.Invoice, error) {
-	invoice, err := s.invoiceRepo.GetByID(ctx, id)
-	if err != nil {
+// BouncePayment marks a dishonoured payment as bounced and takes it, with
+// any early-payment discount it earned, back off the invoice
+func (s *invoiceService) BouncePayment(ctx context.Context, invoiceID, paymentID uuid.UUID, req BouncePaymentRequest) (*models.Payment, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil || invoice.TenantID != req.TenantID {
 		return nil, ErrInvoiceNotFound
 	}
 
-	// TODO: Integrate with GST E-Invoice portal
-	// This would involve:
-	// 1. Building the E-Invoice JSON payload
-	// 2. Signing with GSP credentials
-	// 3. Calling the IRP API
-	// 4. Storing the IRN and QR code
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil || payment.InvoiceID != invoice.ID {
+		return nil, ErrPaymentNotFound
+	}
+	if payment.BouncedAt != nil {
+		return nil, ErrPaymentBounced
+	}
 
-	invoice.EInvoiceStatus = "pending"
-	now := time.Now()
-	invoice.EInvoiceDate = &now
+	bouncedAt := time.Now()
+	if req.BouncedOn != "" {
+		bouncedAt, err = time.Parse("2006-01-02", req.BouncedOn)
+		if err != nil || bouncedAt.Before(payment.PaymentDate) {
+			return nil, ErrInvalidInvoice
+		}
+	}
+
+	payment.BouncedAt = &bouncedAt
+	payment.BounceReason = req.Reason
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	invoice.AmountPaid = invoice.AmountPaid.Sub(payment.Amount)
+	invoice.EarlyPaymentDiscount = invoice.EarlyPaymentDiscount.Sub(payment.Discount)
+	invoice.BalanceDue = invoice.TotalAmount.Sub(invoice.AmountPaid).Sub(invoice.EarlyPaymentDiscount).Sub(invoice.AmountCredited)
+
+	switch {
+	case invoice.AmountPaid.IsPositive(), invoice.AmountCredited.IsPositive():
+		invoice.Status = models.InvoiceStatusPartial
+	case time.Now().After(invoice.DueDate):
+		invoice.Status = models.InvoiceStatusOverdue
+	default:
+		invoice.Status = models.InvoiceStatusSent
+	}
 
 	if err := s.invoiceRepo.Update(ctx, invoice); err != nil {
 		return nil, err
 	}
+	summary := fmt.Sprintf("Payment %s of %s bounced", payment.PaymentNumber, payment.Amount.StringFixed(2))
+	if payment.BounceReason != "" {
+		summary += ": " + payment.BounceReason
+	}
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventPaymentBounced, summary, nil)
 
-	return invoice, nil
+	return payment, nil
 }
 
-func (s *invoiceService) CancelEInvoice(ctx context.Context, id uuid.UUID, reason string) error {
-	invoice, err := s.invoiceRepo.GetByID(ctx, id)
+// applyTCS adds TCS under section 206C(1H) once the customer's sales in the
+// financial year cross the threshold, then recalculates the invoice totals.
+// Rates come from the tax service.
+func (s *invoiceService) applyTCS(ctx context.Context, invoice *models.Invoice) error {
+	invoice.TCSApplicable = false
+	invoice.TCSSection = ""
+	invoice.TCSRate = decimal.Zero
+	invoice.TCSAmount = decimal.Zero
+	defer invoice.CalculateTotals()
+
+	if s.taxClient == nil || invoice.CustomerID == uuid.Nil {
+		return nil
+	}
+
+	fyStart, fyEnd := financialYearRange(invoice.InvoiceDate)
+	cumulative, err := s.invoiceRepo.GetCustomerSalesTotal(ctx, invoice.TenantID, invoice.CustomerID, fyStart, fyEnd, invoice.ID)
 	if err != nil {
-		return ErrInvoiceNotFound
+		return err
 	}
 
-	if invoice.IRN == "" {
-		return errors.New("e-invoice not generated")
+	saleValue := invoice.SaleValue()
+	if cumulative.Add(saleValue).LessThanOrEqual(tcsThreshold206C1H) {
+		return nil
 	}
 
-	// TODO: Call GST E-Invoice cancellation API
+	calc, err := s.taxClient.CalculateTCS(ctx, clients.TCSCalculationRequest{
+		TenantID:        invoice.TenantID.String(),
+		CustomerID:      invoice.CustomerID,
+		CustomerName:    invoice.CustomerName,
+		CustomerPAN:     invoice.CustomerPAN,
+		Section:         clients.TCSSection206C1H,
+		SaleAmount:      saleValue,
+		InvoiceID:       invoice.ID,
+		InvoiceDate:     invoice.InvoiceDate.Format("2006-01-02"),
+		CumulativeSales: &cumulative,
+	})
+	if err != nil {
+		return ErrTCSUnavailable
+	}
+
+	if calc.TCSAmount.IsPositive() {
+		invoice.TCSApplicable = true
+		invoice.TCSSection = calc.Section
+		invoice.TCSRate = calc.TCSRate
+		invoice.TCSAmount = calc.TCSAmount.Round(2)
+	}
 
-	invoice.EInvoiceStatus = "cancelled"
+	return nil
+}
 
-	return s.invoiceRepo.Update(ctx, invoice)
+func (s *invoiceService) recordTCSCollection(ctx context.Context, invoice *models.Invoice) error {
+	err := s.taxClient.RecordTCSCollection(ctx, clients.TCSCollectionRequest{
+		TenantID:       invoice.TenantID.String(),
+		InvoiceID:      invoice.ID,
+		CustomerID:     invoice.CustomerID,
+		CustomerName:   invoice.CustomerName,
+		CustomerPAN:    invoice.CustomerPAN,
+		Section:        invoice.TCSSection,
+		SaleAmount:     invoice.SaleValue(),
+		TCSRate:        invoice.TCSRate,
+		TCSAmount:      invoice.TCSAmount,
+		CollectionDate: invoice.InvoiceDate.Format("2006-01-02"),
+	})
+	if err != nil {
+		return ErrTCSUnavailable
+	}
+	return nil
+}
+
+// financialYearRange returns the first and last day of the Indian financial
+// year (April-March) containing date
+func financialYearRange(date time.Time) (time.Time, time.Time) {
+	year := date.Year()
+	if date.Month() < time.April {
+		year--
+	}
+	start := time.Date(year, time.April, 1, 0, 0, 0, 0, date.Location())
+	end := time.Date(year+1, time.March, 31, 23, 59, 59, 0, date.Location())
+	return start, end
+}
+
+// SetTags replaces an invoice's tags. Tags are analytical only, so issued
+// and paid invoices can be retagged.
+func (s *invoiceService) SetTags(ctx context.Context, id, tenantID uuid.UUID, tagList []string) (*models.Invoice, error) {
+	normalized, err := tags.NormalizeAll(tagList)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.invoiceRepo.GetByID(ctx, id)
+	if err != nil || invoice.TenantID != tenantID {
+		return nil, ErrInvoiceNotFound
+	}
+
+	if err := s.invoiceRepo.UpdateTags(ctx, id, normalized); err != nil {
+		return nil, err
+	}
+	before := *invoice
+	invoice.Tags = normalized
+	record(ctx, s.history, invoiceTimelineDocument(invoice), timeline.EventEdited, "Tags changed",
+		timeline.Diff(before, invoice, "tags"))
+
+	return invoice, nil
+}
+
+func (s *invoiceService) ForReturnPeriod(ctx context.Context, tenantID uuid.UUID, period string) ([]models.Invoice, error) {
+	from, err := time.Parse("012006", period)
+	if err != nil {
+		return nil, ErrInvalidReturnPeriod
+	}
+	return s.invoiceRepo.ListIssued(ctx, tenantID, from, from.AddDate(0, 1, 0))
+}
+
+func (s *invoiceService) ListTags(ctx context.Context, tenantID uuid.UUID) ([]tags.Usage, error) {
+	return s.invoiceRepo.ListTags(ctx, tenantID)
+}
+
+func (s *invoiceService) RenameTag(ctx context.Context, tenantID uuid.UUID, from, to string) (int64, error) {
+	from, err := tags.Normalize(from)
+	if err != nil {
+		return 0, err
+	}
+	to, err = tags.Normalize(to)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, nil
+	}
+	return s.invoiceRepo.RenameTag(ctx, tenantID, from, to)
+}
+
+func (s *invoiceService) DeleteTag(ctx context.Context, tenantID uuid.UUID, tag string) (int64, error) {
+	tag, err := tags.Normalize(tag)
+	if err != nil {
+		return 0, err
+	}
+	return s.invoiceRepo.DeleteTag(ctx, tenantID, tag)
 }
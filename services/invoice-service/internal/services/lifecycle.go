@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/lifecycle"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+)
+
+// Kinds of documents whose processing is tracked
+const (
+	LifecycleEInvoice      = "einvoice"
+	LifecycleInvoiceExport = "invoice_export"
+)
+
+// EInvoiceLifecycle follows an e-invoice through the IRP. An IRN pending for
+// more than 15 minutes has likely been lost by the portal.
+var EInvoiceLifecycle = lifecycle.Machine{
+	Kind:    LifecycleEInvoice,
+	Initial: []string{"pending"},
+	Transitions: map[string][]string{
+		"pending":   {"generated", "failed"},
+		"failed":    {"pending"},
+		"generated": {"cancelled"},
+	},
+	SLA: map[string]time.Duration{
+		"pending": 15 * time.Minute,
+	},
+}
+
+// InvoiceExportLifecycle follows an invoice export through its job. A
+// failed export is rebuilt when its job is retried.
+var InvoiceExportLifecycle = lifecycle.Machine{
+	Kind:    LifecycleInvoiceExport,
+	Initial: []string{"queued"},
+	Transitions: map[string][]string{
+		"queued":  {"running", "failed"},
+		"running": {"completed", "failed"},
+		"failed":  {"running"},
+	},
+	SLA: map[string]time.Duration{
+		"queued":  15 * time.Minute,
+		"running": time.Hour,
+	},
+}
+
+// track records a document's move to a new state, by the user serving the
+// request if there is one. The lifecycle is only kept for visibility, so a
+// failure to record it is logged and the work goes on.
+func track(ctx context.Context, tracker *lifecycle.Tracker, doc lifecycle.Document, move lifecycle.Move) {
+	if tracker == nil {
+		return
+	}
+	if actorID, ok := timeline.ActorFrom(ctx); ok && move.ActorID == nil {
+		move.ActorID = &actorID
+	}
+	if _, err := tracker.Move(ctx, doc, move); err != nil {
+		log.Printf("Failed to record %s %s moving to %s: %v", doc.Kind, doc.ID, move.To, err)
+	}
+}
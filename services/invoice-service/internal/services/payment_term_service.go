@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrPaymentTermNotFound  = errors.New("payment term not found")
+	ErrInvalidPaymentTerm   = errors.New("invalid payment term")
+	ErrDuplicatePaymentTerm = errors.New("a payment term with this name already exists")
+	ErrPaymentTermInUse     = errors.New("payment term is the default for one or more customers")
+)
+
+// PaymentTermRequest creates or updates a payment term
+type PaymentTermRequest struct {
+	TenantID        uuid.UUID       `json:"-"`
+	Name            string          `json:"name" binding:"required"`
+	Description     string          `json:"description"`
+	NetDays         int             `json:"net_days" binding:"min=0,max=365"`
+	DiscountPercent decimal.Decimal `json:"discount_percent"`
+	DiscountDays    int             `json:"discount_days" binding:"min=0"`
+	IsDefault       bool            `json:"is_default"`
+	IsActive        *bool           `json:"is_active"`
+}
+
+// PaymentTermService manages the tenant's payment terms catalog and
+// customers' default terms
+type PaymentTermService interface {
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.PaymentTerm, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.PaymentTerm, error)
+	Create(ctx context.Context, req PaymentTermRequest) (*models.PaymentTerm, error)
+	Update(ctx context.Context, id uuid.UUID, req PaymentTermRequest) (*models.PaymentTerm, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	GetCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID) (*models.PaymentTerm, error)
+	SetCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID, paymentTermID *uuid.UUID) (*models.PaymentTerm, error)
+	Resolve(ctx context.Context, tenantID, customerID uuid.UUID, paymentTermID *uuid.UUID) (*models.PaymentTerm, error)
+}
+
+type paymentTermService struct {
+	repo repository.PaymentTermRepository
+}
+
+// NewPaymentTermService creates a new payment term service
+func NewPaymentTermService(repo repository.PaymentTermRepository) PaymentTermService {
+	return &paymentTermService{repo: repo}
+}
+
+// List returns the tenant's payment terms, creating the standard set the
+// first time a tenant looks at them
+func (s *paymentTermService) List(ctx context.Context, tenantID uuid.UUID) ([]models.PaymentTerm, error) {
+	terms, err := s.repo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) > 0 {
+		return terms, nil
+	}
+
+	if err := s.repo.CreateBatch(ctx, models.DefaultPaymentTerms(tenantID)); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, tenantID)
+}
+
+func (s *paymentTermService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.PaymentTerm, error) {
+	term, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrPaymentTermNotFound
+	}
+	return term, nil
+}
+
+func (s *paymentTermService) Create(ctx context.Context, req PaymentTermRequest) (*models.PaymentTerm, error) {
+	if err := validatePaymentTerm(req); err != nil {
+		return nil, err
+	}
+	// Make sure the standard terms exist before the tenant's first custom one
+	if _, err := s.List(ctx, req.TenantID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByName(ctx, req.TenantID, req.Name); err == nil {
+		return nil, ErrDuplicatePaymentTerm
+	}
+
+	term := &models.PaymentTerm{TenantID: req.TenantID, IsActive: true}
+	applyPaymentTermRequest(term, req)
+
+	if term.IsDefault {
+		if err := s.repo.ClearDefault(ctx, req.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Create(ctx, term); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+func (s *paymentTermService) Update(ctx context.Context, id uuid.UUID, req PaymentTermRequest) (*models.PaymentTerm, error) {
+	if err := validatePaymentTerm(req); err != nil {
+		return nil, err
+	}
+
+	term, err := s.repo.GetByID(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, ErrPaymentTermNotFound
+	}
+	if existing, err := s.repo.GetByName(ctx, req.TenantID, req.Name); err == nil && existing.ID != term.ID {
+		return nil, ErrDuplicatePaymentTerm
+	}
+
+	applyPaymentTermRequest(term, req)
+
+	if term.IsDefault {
+		if err := s.repo.ClearDefault(ctx, req.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Update(ctx, term); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+// Delete removes a payment term. Documents keep the terms they were issued
+// with; terms still assigned to customers must be reassigned first.
+func (s *paymentTermService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	if _, err := s.repo.GetByID(ctx, tenantID, id); err != nil {
+		return ErrPaymentTermNotFound
+	}
+
+	inUse, err := s.repo.CountCustomerTerms(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if inUse > 0 {
+		return ErrPaymentTermInUse
+	}
+
+	return s.repo.Delete(ctx, tenantID, id)
+}
+
+// GetCustomerTerm returns the customer's default term, or the tenant
+// default when the customer has none
+func (s *paymentTermService) GetCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID) (*models.PaymentTerm, error) {
+	return s.Resolve(ctx, tenantID, customerID, nil)
+}
+
+// SetCustomerTerm assigns a default term to a customer; nil clears it
+func (s *paymentTermService) SetCustomerTerm(ctx context.Context, tenantID, customerID uuid.UUID, paymentTermID *uuid.UUID) (*models.PaymentTerm, error) {
+	if paymentTermID == nil {
+		if err := s.repo.DeleteCustomerTerm(ctx, tenantID, customerID); err != nil {
+			return nil, err
+		}
+		return s.Resolve(ctx, tenantID, customerID, nil)
+	}
+
+	term, err := s.repo.GetByID(ctx, tenantID, *paymentTermID)
+	if err != nil || !term.IsActive {
+		return nil, ErrPaymentTermNotFound
+	}
+
+	assignment, err := s.repo.GetCustomerTerm(ctx, tenantID, customerID)
+	if err != nil {
+		assignment = &models.CustomerPaymentTerm{TenantID: tenantID, CustomerID: customerID}
+	}
+	assignment.PaymentTermID = term.ID
+
+	if err := s.repo.SaveCustomerTerm(ctx, assignment); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+// Resolve picks the payment term for a new document: the one requested,
+// else the customer's default, else the tenant's default. It returns nil
+// when none applies.
+func (s *paymentTermService) Resolve(ctx context.Context, tenantID, customerID uuid.UUID, paymentTermID *uuid.UUID) (*models.PaymentTerm, error) {
+	if paymentTermID != nil {
+		term, err := s.repo.GetByID(ctx, tenantID, *paymentTermID)
+		if err != nil || !term.IsActive {
+			return nil, ErrPaymentTermNotFound
+		}
+		return term, nil
+	}
+
+	if customerID != uuid.Nil {
+		if assignment, err := s.repo.GetCustomerTerm(ctx, tenantID, customerID); err == nil && assignment.PaymentTerm != nil && assignment.PaymentTerm.IsActive {
+			return assignment.PaymentTerm, nil
+		}
+	}
+
+	if term, err := s.repo.GetDefault(ctx, tenantID); err == nil {
+		return term, nil
+	}
+	return nil, nil
+}
+
+func validatePaymentTerm(req PaymentTermRequest) error {
+	if strings.TrimSpace(req.Name) == "" || req.NetDays < 0 || req.DiscountDays < 0 {
+		return ErrInvalidPaymentTerm
+	}
+	if req.DiscountPercent.IsNegative() || req.DiscountPercent.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+		return ErrInvalidPaymentTerm
+	}
+	// The discount window must close on or before the due date
+	if req.DiscountPercent.IsPositive() && (req.DiscountDays == 0 || req.DiscountDays > req.NetDays) {
+		return ErrInvalidPaymentTerm
+	}
+	return nil
+}
+
+func applyPaymentTermRequest(term *models.PaymentTerm, req PaymentTermRequest) {
+	term.Name = strings.TrimSpace(req.Name)
+	term.Description = req.Description
+	term.NetDays = req.NetDays
+	term.DiscountPercent = req.DiscountPercent
+	term.DiscountDays = req.DiscountDays
+	if !req.DiscountPercent.IsPositive() {
+		term.DiscountDays = 0
+	}
+	term.IsDefault = req.IsDefault
+	if req.IsActive != nil {
+		term.IsActive = *req.IsActive
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+)
+
+var (
+	// ErrPeriodClosed is returned when a document is dated in an accounting
+	// period closed in the ledger
+	ErrPeriodClosed = errors.New("the accounting period of this date is closed")
+	// ErrPeriodLockUnavailable is returned when the ledger could not say
+	// whether the period is closed
+	ErrPeriodLockUnavailable = errors.New("unable to check whether the accounting period is closed")
+)
+
+// PeriodLock keeps invoices and bills dated in a closed accounting period
+// from being created, edited or deleted, as the ledger does for
+// transactions
+type PeriodLock interface {
+	// CheckOpen returns ErrPeriodClosed if the tenant's date falls in a
+	// closed period. The check is made on behalf of the caller identified
+	// by authorization; documents generated in the background, without a
+	// caller, are checked with the service's own credentials.
+	CheckOpen(ctx context.Context, tenantID uuid.UUID, authorization string, date time.Time) error
+}
+
+type periodLock struct {
+	bookkeepingClient clients.BookkeepingClient
+	credentials       *middleware.ServiceCredentials
+}
+
+// NewPeriodLock creates a period lock that reads closed periods from the
+// bookkeeping service
+func NewPeriodLock(bookkeepingClient clients.BookkeepingClient, credentials *middleware.ServiceCredentials) PeriodLock {
+	return &periodLock{bookkeepingClient: bookkeepingClient, credentials: credentials}
+}
+
+func (l *periodLock) CheckOpen(ctx context.Context, tenantID uuid.UUID, authorization string, date time.Time) error {
+	if authorization == "" {
+		var err error
+		if authorization, err = l.credentials.Authorization(tenantID.String()); err != nil {
+			return ErrPeriodLockUnavailable
+		}
+	}
+	status, err := l.bookkeepingClient.GetPeriodStatus(ctx, authorization, date.Format("2006-01-02"))
+	if err != nil {
+		return ErrPeriodLockUnavailable
+	}
+	if status.Closed {
+		return ErrPeriodClosed
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// ImportKindProducts is the import job kind for product CSV uploads
+const ImportKindProducts = "products"
+
+var ErrProductNameRequired = errors.New("name is required")
+
+// productColumns are the column indices of a product CSV, -1 for columns the
+// file does not have
+type productColumns struct {
+	typ, name, sku, description, sellingPrice, costPrice, unit int
+	hsn, sac, gstRate, exempt, category, trackInventory        int
+	openingStock, reorderLevel                                 int
+}
+
+func productColumnsOf(header imports.Header) productColumns {
+	return productColumns{
+		typ:            header.Find("type", "product type"),
+		name:           header.Find("name", "product name", "item name"),
+		sku:            header.Find("sku", "item code"),
+		description:    header.Find("description"),
+		sellingPrice:   header.Find("selling_price", "selling price", "sale price", "rate"),
+		costPrice:      header.Find("cost_price", "cost price", "purchase price"),
+		unit:           header.Find("unit_of_measure", "unit", "uom"),
+		hsn:            header.Find("hsn_code", "hsn code", "hsn"),
+		sac:            header.Find("sac_code", "sac code", "sac"),
+		gstRate:        header.Find("gst_rate", "gst rate", "gst %", "gst"),
+		exempt:         header.Find("is_exempt", "exempt"),
+		category:       header.Find("category"),
+		trackInventory: header.Find("track_inventory", "track inventory"),
+		openingStock:   header.Find("current_stock", "opening stock", "stock"),
+		reorderLevel:   header.Find("reorder_level", "reorder level"),
+	}
+}
+
+// ImportProducts queues a product CSV import. Rows are created one at a time
+// through Create, so a SKU clash with an existing product (or an earlier row
+// of the same file) fails just that row.
+func (s *productService) ImportProducts(ctx context.Context, tenantID, createdBy uuid.UUID, fileName string, file io.Reader) (*imports.Job, error) {
+	job := &imports.Job{
+		TenantID:  tenantID,
+		Kind:      ImportKindProducts,
+		FileName:  fileName,
+		CreatedBy: &createdBy,
+	}
+	if err := s.importRunner.Start(ctx, job, file, s.importProductChunk); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *productService) importProductChunk(ctx context.Context, job *imports.Job, chunk imports.Chunk) (imports.ChunkResult, error) {
+	var result imports.ChunkResult
+
+	cols := productColumnsOf(chunk.Header)
+	if cols.name == -1 {
+		return result, fmt.Errorf("required column not found: name")
+	}
+
+	for _, row := range chunk.Rows {
+		req, err := cols.parse(row)
+		if err != nil {
+			result.Fail(row, err)
+			continue
+		}
+		req.TenantID = job.TenantID
+		if job.CreatedBy != nil {
+			req.CreatedBy = *job.CreatedBy
+		}
+
+		if _, err := s.Create(ctx, req); err != nil {
+			result.Fail(row, err)
+			continue
+		}
+		result.Imported++
+	}
+
+	if result.Imported > 0 {
+		if err := s.repo.Touch(ctx, job.TenantID); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (cols productColumns) parse(row imports.Row) (CreateProductRequest, error) {
+	req := CreateProductRequest{
+		Name:          row.Value(cols.name),
+		SKU:           row.Value(cols.sku),
+		Description:   row.Value(cols.description),
+		UnitOfMeasure: row.Value(cols.unit),
+		HSNCode:       row.Value(cols.hsn),
+		SACCode:       row.Value(cols.sac),
+		Category:      row.Value(cols.category),
+	}
+	if req.Name == "" {
+		return req, ErrProductNameRequired
+	}
+
+	// Without a type column, a SAC code marks a service
+	switch typ := strings.ToLower(row.Value(cols.typ)); {
+	case typ != "":
+		req.Type = models.ProductType(typ)
+	case req.SACCode != "" && req.HSNCode == "":
+		req.Type = models.ProductTypeService
+	default:
+		req.Type = models.ProductTypeGoods
+	}
+
+	decimals := []struct {
+		col    int
+		name   string
+		target *decimal.Decimal
+	}{
+		{cols.sellingPrice, "selling price", &req.SellingPrice},
+		{cols.costPrice, "cost price", &req.CostPrice},
+		{cols.gstRate, "GST rate", &req.GSTRate},
+		{cols.openingStock, "opening stock", &req.CurrentStock},
+		{cols.reorderLevel, "reorder level", &req.ReorderLevel},
+	}
+	for _, d := range decimals {
+		value, err := parseImportDecimal(row.Value(d.col))
+		if err != nil {
+			return req, fmt.Errorf("invalid %s: %q", d.name, row.Value(d.col))
+		}
+		*d.target = value
+	}
+
+	var err error
+	if req.IsExempt, err = parseImportBool(row.Value(cols.exempt)); err != nil {
+		return req, fmt.Errorf("invalid exempt flag: %q", row.Value(cols.exempt))
+	}
+	if req.TrackInventory, err = parseImportBool(row.Value(cols.trackInventory)); err != nil {
+		return req, fmt.Errorf("invalid track inventory flag: %q", row.Value(cols.trackInventory))
+	}
+
+	return req, nil
+}
+
+// parseImportDecimal parses an amount as typed in a spreadsheet, allowing
+// thousands separators and a trailing percent sign
+func parseImportDecimal(s string) (decimal.Decimal, error) {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, ",", ""), "%")
+	if s == "" {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(strings.TrimSpace(s))
+}
+
+func parseImportBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return false, nil
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
@@ -3,9 +3,11 @@ package services
 import (
 	"context"
 	"errors"
+	"io"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/imports"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
 )
@@ -70,17 +72,18 @@ type ProductService interface {
 	Update(ctx context.Context, id uuid.UUID, req UpdateProductRequest) (*models.Product, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetCategories(ctx context.Context, tenantID uuid.UUID) ([]string, error)
-	ImportProducts(ctx context.Context, tenantID uuid.UUID, createdBy uuid.UUID, products []CreateProductRequest) (int, []error)
+	ImportProducts(ctx context.Context, tenantID, createdBy uuid.UUID, fileName string, file io.Reader) (*imports.Job, error)
 	UpdateStock(ctx context.Context, productID uuid.UUID, quantity float64) error
 }
 
 type productService struct {
-	repo repository.ProductRepository
+	repo         repository.ProductRepository
+	importRunner *imports.Runner
 }
 
 // NewProductService creates a new product service
-func NewProductService(repo repository.ProductRepository) ProductService {
-	return &productService{repo: repo}
+func NewProductService(repo repository.ProductRepository, importRunner *imports.Runner) ProductService {
+	return &productService{repo: repo, importRunner: importRunner}
 }
 
 func (s *productService) Create(ctx context.Context, req CreateProductRequest) (*models.Product, error) {
@@ -231,24 +234,6 @@ func (s *productService) GetCategories(ctx context.Context, tenantID uuid.UUID)
 	return s.repo.GetCategories(ctx, tenantID)
 }
 
-func (s *productService) ImportProducts(ctx context.Context, tenantID uuid.UUID, createdBy uuid.UUID, products []CreateProductRequest) (int, []error) {
-	var errs []error
-	successCount := 0
-
-	for _, req := range products {
-		req.TenantID = tenantID
-		req.CreatedBy = createdBy
-		_, err := s.Create(ctx, req)
-		if err != nil {
-			errs = append(errs, err)
-		} else {
-			successCount++
-		}
-	}
-
-	return successCount, errs
-}
-
 func (s *productService) UpdateStock(ctx context.Context, productID uuid.UUID, quantity float64) error {
 	return s.repo.UpdateStock(ctx, productID, quantity)
 }
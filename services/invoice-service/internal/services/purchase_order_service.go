@@ -0,0 +1,779 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrPurchaseOrderNotFound     = errors.New("purchase order not found")
+	ErrInvalidPurchaseOrder      = errors.New("invalid purchase order data")
+	ErrPurchaseOrderNotEditable  = errors.New("only draft purchase orders can be edited, issued or deleted")
+	ErrPurchaseOrderNotReceiving = errors.New("goods can only be received against an issued purchase order")
+	ErrPurchaseOrderNotBillable  = errors.New("bills can only be raised from an issued purchase order")
+	ErrPurchaseOrderNotClosable  = errors.New("only issued purchase orders can be closed")
+	ErrPurchaseOrderInUse        = errors.New("purchase order has goods received or bills raised against it")
+	ErrInvalidGoodsReceipt       = errors.New("invalid goods receipt; each line must be of the purchase order, with a positive quantity")
+	ErrReceiptExceedsOrder       = errors.New("quantity received exceeds what is still to be received on the line")
+	ErrNothingToBill             = errors.New("nothing received on the purchase order is left to bill")
+)
+
+// Statuses of a purchase order line in its three-way match
+const (
+	POMatchMatched         = "matched"
+	POMatchAwaitingReceipt = "awaiting_receipt" // Ordered, not all received
+	POMatchAwaitingBill    = "awaiting_bill"    // Received, not all billed
+	POMatchOverBilled      = "over_billed"      // Billed more than received, beyond the tolerance
+	POMatchRateVariance    = "rate_variance"    // Billed above the order's rate, beyond the tolerance
+)
+
+// CreatePurchaseOrderRequest represents a request to create a draft purchase
+// order
+type CreatePurchaseOrderRequest struct {
+	TenantID        uuid.UUID               `json:"-"`
+	CreatedBy       uuid.UUID               `json:"-"`
+	VendorID        uuid.UUID               `json:"vendor_id" binding:"required"`
+	VendorName      string                  `json:"vendor_name" binding:"required"`
+	VendorGSTIN     string                  `json:"vendor_gstin"`
+	VendorPAN       string                  `json:"vendor_pan"`
+	VendorAddress   string                  `json:"vendor_address"`
+	VendorState     string                  `json:"vendor_state" binding:"required"`
+	VendorEmail     string                  `json:"vendor_email" binding:"omitempty,email"`
+	VendorPhone     string                  `json:"vendor_phone"`
+	OrderDate       string                  `json:"order_date" binding:"required"`
+	ExpectedDate    string                  `json:"expected_date"`
+	Items           []CreateBillItemRequest `json:"items" binding:"required,min=1"`
+	DeliveryAddress string                  `json:"delivery_address"`
+	Notes           string                  `json:"notes"`
+	Terms           string                  `json:"terms"`
+}
+
+// UpdatePurchaseOrderRequest represents a request to update a draft purchase
+// order. Empty fields are left as they are; items are replaced when present.
+type UpdatePurchaseOrderRequest struct {
+	VendorName      string                  `json:"vendor_name"`
+	VendorGSTIN     string                  `json:"vendor_gstin"`
+	VendorPAN       string                  `json:"vendor_pan"`
+	VendorAddress   string                  `json:"vendor_address"`
+	VendorState     string                  `json:"vendor_state"`
+	VendorEmail     string                  `json:"vendor_email" binding:"omitempty,email"`
+	VendorPhone     string                  `json:"vendor_phone"`
+	OrderDate       string                  `json:"order_date"`
+	ExpectedDate    string                  `json:"expected_date"`
+	Items           []CreateBillItemRequest `json:"items"`
+	DeliveryAddress string                  `json:"delivery_address"`
+	Notes           string                  `json:"notes"`
+	Terms           string                  `json:"terms"`
+}
+
+// ReceiveGoodsRequest records goods received against a purchase order
+type ReceiveGoodsRequest struct {
+	TenantID     uuid.UUID                 `json:"-"`
+	ReceivedBy   uuid.UUID                 `json:"-"`
+	ReceivedDate string                    `json:"received_date"` // YYYY-MM-DD, defaults to today
+	DeliveryNote string                    `json:"delivery_note"`
+	Notes        string                    `json:"notes"`
+	Items        []ReceiveGoodsItemRequest `json:"items" binding:"required,min=1"`
+}
+
+// ReceiveGoodsItemRequest is the quantity of an order line received
+type ReceiveGoodsItemRequest struct {
+	PurchaseOrderItemID uuid.UUID       `json:"purchase_order_item_id" binding:"required"`
+	Quantity            decimal.Decimal `json:"quantity" binding:"required"`
+}
+
+// BillPurchaseOrderRequest raises the vendor's bill from a purchase order
+type BillPurchaseOrderRequest struct {
+	TenantID      uuid.UUID `json:"-"`
+	CreatedBy     uuid.UUID `json:"-"`
+	Authorization string    `json:"-"` // Used to check the bill's period is open
+	VendorBillNo  string    `json:"vendor_bill_no" binding:"required"`
+	BillDate      string    `json:"bill_date" binding:"required"`
+	DueDate       string    `json:"due_date"`
+	// Lines as the vendor billed them. Defaults to what was received and
+	// not yet billed, at the order's rates.
+	Items       []BillPurchaseOrderItemRequest `json:"items"`
+	ITCEligible bool                           `json:"itc_eligible"`
+	ITCCategory string                         `json:"itc_category"`
+	Notes       string                         `json:"notes"`
+}
+
+// BillPurchaseOrderItemRequest is an order line as the vendor billed it
+type BillPurchaseOrderItemRequest struct {
+	PurchaseOrderItemID uuid.UUID       `json:"purchase_order_item_id" binding:"required"`
+	Quantity            decimal.Decimal `json:"quantity" binding:"required"`
+	Rate                decimal.Decimal `json:"rate"` // Defaults to the order's rate
+}
+
+// PurchaseOrderMatchLine is an order line with what was ordered, received
+// and billed on it
+type PurchaseOrderMatchLine struct {
+	PurchaseOrderItemID uuid.UUID       `json:"purchase_order_item_id"`
+	Description         string          `json:"description"`
+	Unit                string          `json:"unit"`
+	OrderedQuantity     decimal.Decimal `json:"ordered_quantity"`
+	ReceivedQuantity    decimal.Decimal `json:"received_quantity"`
+	BilledQuantity      decimal.Decimal `json:"billed_quantity"`
+	OrderRate           decimal.Decimal `json:"order_rate"`
+	BilledRate          decimal.Decimal `json:"billed_rate"` // Highest rate billed
+	Status              string          `json:"status"`
+}
+
+// PurchaseOrderMatch is the three-way match of a purchase order against its
+// goods receipts and bills
+type PurchaseOrderMatch struct {
+	PurchaseOrderID uuid.UUID                  `json:"purchase_order_id"`
+	PONumber        string                     `json:"po_number"`
+	Status          models.PurchaseOrderStatus `json:"status"`
+	// False when any line is over-billed or billed above its rate
+	Matched  bool                      `json:"matched"`
+	Settings *models.BillMatchSettings `json:"settings"`
+	Lines    []PurchaseOrderMatchLine  `json:"lines"`
+}
+
+// PurchaseOrderBill is a bill raised from a purchase order, with the order's
+// match once it is
+type PurchaseOrderBill struct {
+	Bill  *models.Bill        `json:"bill"`
+	Match *PurchaseOrderMatch `json:"match"`
+}
+
+// OpenPurchaseOrder is an order with goods still to be received or billed
+type OpenPurchaseOrder struct {
+	PurchaseOrderID     uuid.UUID                  `json:"purchase_order_id"`
+	PONumber            string                     `json:"po_number"`
+	VendorID            uuid.UUID                  `json:"vendor_id"`
+	VendorName          string                     `json:"vendor_name"`
+	OrderDate           string                     `json:"order_date"`
+	ExpectedDate        string                     `json:"expected_date,omitempty"`
+	Status              models.PurchaseOrderStatus `json:"status"`
+	AgeDays             int                        `json:"age_days"`
+	Overdue             bool                       `json:"overdue"` // Past its expected date with goods to receive
+	TotalAmount         decimal.Decimal            `json:"total_amount"`
+	PendingReceiptValue decimal.Decimal            `json:"pending_receipt_value"` // Before tax, at the order's rates
+	PendingBillingValue decimal.Decimal            `json:"pending_billing_value"` // Received and not yet billed
+}
+
+// OpenPurchaseOrderReport lists the purchase orders with goods still to be
+// received or billed
+type OpenPurchaseOrderReport struct {
+	Orders              []OpenPurchaseOrder `json:"orders"`
+	Count               int                 `json:"count"`
+	Overdue             int                 `json:"overdue"`
+	PendingReceiptValue decimal.Decimal     `json:"pending_receipt_value"`
+	PendingBillingValue decimal.Decimal     `json:"pending_billing_value"`
+}
+
+// PurchaseOrderService manages purchase orders. An issued order has goods
+// received against it, in part or in full, and the vendor's bills raised
+// from it, which are matched against what was ordered and received within
+// the tenant's bill match tolerances.
+type PurchaseOrderService interface {
+	Create(ctx context.Context, req CreatePurchaseOrderRequest) (*models.PurchaseOrder, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.PurchaseOrderFilters) ([]models.PurchaseOrder, int64, error)
+	Update(ctx context.Context, tenantID, id uuid.UUID, req UpdatePurchaseOrderRequest) (*models.PurchaseOrder, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	Issue(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error)
+	// Close short-closes an order; nothing more is received against it
+	Close(ctx context.Context, tenantID, id uuid.UUID, reason string) (*models.PurchaseOrder, error)
+	// Cancel cancels an order nothing was received or billed against
+	Cancel(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error)
+
+	Receive(ctx context.Context, id uuid.UUID, req ReceiveGoodsRequest) (*models.GoodsReceipt, error)
+	ListReceipts(ctx context.Context, tenantID, id uuid.UUID) ([]models.GoodsReceipt, error)
+
+	CreateBill(ctx context.Context, id uuid.UUID, req BillPurchaseOrderRequest) (*PurchaseOrderBill, error)
+	Match(ctx context.Context, tenantID, id uuid.UUID) (*PurchaseOrderMatch, error)
+	OpenReport(ctx context.Context, tenantID, vendorID uuid.UUID) (*OpenPurchaseOrderReport, error)
+}
+
+type purchaseOrderService struct {
+	poRepo       repository.PurchaseOrderRepository
+	billService  BillService
+	matchService BillMatchService
+}
+
+// NewPurchaseOrderService creates a new purchase order service
+func NewPurchaseOrderService(poRepo repository.PurchaseOrderRepository, billService BillService, matchService BillMatchService) PurchaseOrderService {
+	return &purchaseOrderService{
+		poRepo:       poRepo,
+		billService:  billService,
+		matchService: matchService,
+	}
+}
+
+func (s *purchaseOrderService) Create(ctx context.Context, req CreatePurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	orderDate, err := time.Parse("2006-01-02", req.OrderDate)
+	if err != nil {
+		return nil, ErrInvalidPurchaseOrder
+	}
+	expectedDate, err := parseExpectedDate(req.ExpectedDate, orderDate)
+	if err != nil {
+		return nil, err
+	}
+	items, err := purchaseOrderItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	poNumber, err := s.poRepo.GetNextPONumber(ctx, req.TenantID, fmt.Sprintf("PO-%s", time.Now().Format("0601")))
+	if err != nil {
+		return nil, err
+	}
+
+	po := &models.PurchaseOrder{
+		TenantID:        req.TenantID,
+		PONumber:        poNumber,
+		VendorID:        req.VendorID,
+		VendorName:      strings.TrimSpace(req.VendorName),
+		VendorGSTIN:     req.VendorGSTIN,
+		VendorPAN:       req.VendorPAN,
+		VendorAddress:   req.VendorAddress,
+		VendorState:     req.VendorState,
+		VendorEmail:     req.VendorEmail,
+		VendorPhone:     req.VendorPhone,
+		OrderDate:       orderDate,
+		ExpectedDate:    expectedDate,
+		Status:          models.PurchaseOrderStatusDraft,
+		Items:           items,
+		DeliveryAddress: req.DeliveryAddress,
+		Notes:           req.Notes,
+		Terms:           req.Terms,
+		CreatedBy:       req.CreatedBy,
+	}
+	po.CalculateTotals()
+
+	if err := s.poRepo.Create(ctx, po); err != nil {
+		return nil, err
+	}
+
+	return po, nil
+}
+
+func (s *purchaseOrderService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error) {
+	po, err := s.poRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPurchaseOrderNotFound) {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		return nil, err
+	}
+	return po, nil
+}
+
+func (s *purchaseOrderService) List(ctx context.Context, tenantID uuid.UUID, filters repository.PurchaseOrderFilters) ([]models.PurchaseOrder, int64, error) {
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 || filters.Limit > 100 {
+		filters.Limit = 20
+	}
+	return s.poRepo.List(ctx, tenantID, filters)
+}
+
+func (s *purchaseOrderService) Update(ctx context.Context, tenantID, id uuid.UUID, req UpdatePurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	po, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft {
+		return nil, ErrPurchaseOrderNotEditable
+	}
+
+	if req.VendorName != "" {
+		po.VendorName = strings.TrimSpace(req.VendorName)
+	}
+	if req.VendorGSTIN != "" {
+		po.VendorGSTIN = req.VendorGSTIN
+	}
+	if req.VendorPAN != "" {
+		po.VendorPAN = req.VendorPAN
+	}
+	if req.VendorAddress != "" {
+		po.VendorAddress = req.VendorAddress
+	}
+	if req.VendorState != "" {
+		po.VendorState = req.VendorState
+	}
+	if req.VendorEmail != "" {
+		po.VendorEmail = req.VendorEmail
+	}
+	if req.VendorPhone != "" {
+		po.VendorPhone = req.VendorPhone
+	}
+	if req.OrderDate != "" {
+		orderDate, err := time.Parse("2006-01-02", req.OrderDate)
+		if err != nil {
+			return nil, ErrInvalidPurchaseOrder
+		}
+		po.OrderDate = orderDate
+	}
+	if req.ExpectedDate != "" {
+		if po.ExpectedDate, err = parseExpectedDate(req.ExpectedDate, po.OrderDate); err != nil {
+			return nil, err
+		}
+	} else if po.ExpectedDate != nil && po.ExpectedDate.Before(po.OrderDate) {
+		return nil, ErrInvalidPurchaseOrder
+	}
+	if req.DeliveryAddress != "" {
+		po.DeliveryAddress = req.DeliveryAddress
+	}
+	if req.Notes != "" {
+		po.Notes = req.Notes
+	}
+	if req.Terms != "" {
+		po.Terms = req.Terms
+	}
+
+	if len(req.Items) > 0 {
+		items, err := purchaseOrderItems(req.Items)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i].PurchaseOrderID = po.ID
+		}
+		po.Items = items
+	}
+	po.CalculateTotals()
+
+	if err := s.poRepo.Update(ctx, po); err != nil {
+		return nil, err
+	}
+
+	return po, nil
+}
+
+func (s *purchaseOrderService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	po, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft {
+		return ErrPurchaseOrderNotEditable
+	}
+	return s.poRepo.Delete(ctx, tenantID, id)
+}
+
+func (s *purchaseOrderService) Issue(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error) {
+	po, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft {
+		return nil, ErrPurchaseOrderNotEditable
+	}
+
+	now := time.Now()
+	po.Status = models.PurchaseOrderStatusIssued
+	po.IssuedAt = &now
+	if err := s.poRepo.Save(ctx, po); err != nil {
+		return nil, err
+	}
+
+	return po, nil
+}
+
+func (s *purchaseOrderService) Close(ctx context.Context, tenantID, id uuid.UUID, reason string) (*models.PurchaseOrder, error) {
+	po, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch po.Status {
+	case models.PurchaseOrderStatusIssued, models.PurchaseOrderStatusPartiallyReceived, models.PurchaseOrderStatusReceived:
+	default:
+		return nil, ErrPurchaseOrderNotClosable
+	}
+
+	now := time.Now()
+	po.Status = models.PurchaseOrderStatusClosed
+	po.ClosedAt = &now
+	po.CloseReason = strings.TrimSpace(reason)
+	if err := s.poRepo.Save(ctx, po); err != nil {
+		return nil, err
+	}
+
+	return po, nil
+}
+
+func (s *purchaseOrderService) Cancel(ctx context.Context, tenantID, id uuid.UUID) (*models.PurchaseOrder, error) {
+	po, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if po.Status != models.PurchaseOrderStatusDraft && po.Status != models.PurchaseOrderStatusIssued {
+		return nil, ErrPurchaseOrderInUse
+	}
+	billed, err := s.poRepo.BilledQuantities(ctx, []uuid.UUID{po.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(billed) > 0 {
+		return nil, ErrPurchaseOrderInUse
+	}
+
+	po.Status = models.PurchaseOrderStatusCancelled
+	if err := s.poRepo.Save(ctx, po); err != nil {
+		return nil, err
+	}
+
+	return po, nil
+}
+
+// Receive records a goods receipt. A line may be received over what is
+// still to be received by up to the tenant's quantity tolerance.
+func (s *purchaseOrderService) Receive(ctx context.Context, id uuid.UUID, req ReceiveGoodsRequest) (*models.GoodsReceipt, error) {
+	po, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !po.CanReceive() {
+		return nil, ErrPurchaseOrderNotReceiving
+	}
+
+	receivedDate := truncateDay(time.Now())
+	if req.ReceivedDate != "" {
+		if receivedDate, err = time.Parse("2006-01-02", req.ReceivedDate); err != nil {
+			return nil, ErrInvalidGoodsReceipt
+		}
+	}
+	if receivedDate.Before(truncateDay(po.OrderDate)) {
+		return nil, ErrInvalidGoodsReceipt
+	}
+
+	settings, err := s.matchService.GetSettings(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[uuid.UUID]*models.PurchaseOrderItem, len(po.Items))
+	for i := range po.Items {
+		lines[po.Items[i].ID] = &po.Items[i]
+	}
+	receiving := make(map[uuid.UUID]decimal.Decimal, len(req.Items))
+	var items []models.GoodsReceiptItem
+	for _, itemReq := range req.Items {
+		line, ok := lines[itemReq.PurchaseOrderItemID]
+		if !ok || !itemReq.Quantity.IsPositive() {
+			return nil, ErrInvalidGoodsReceipt
+		}
+		receiving[line.ID] = receiving[line.ID].Add(itemReq.Quantity)
+		if !withinTolerance(line.PendingReceipt(), receiving[line.ID], settings.QuantityTolerancePct) {
+			return nil, ErrReceiptExceedsOrder
+		}
+		items = append(items, models.GoodsReceiptItem{
+			PurchaseOrderItemID: line.ID,
+			Description:         line.Description,
+			Quantity:            itemReq.Quantity,
+		})
+	}
+
+	receiptNumber, err := s.poRepo.GetNextReceiptNumber(ctx, req.TenantID, fmt.Sprintf("GRN-%s", time.Now().Format("0601")))
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &models.GoodsReceipt{
+		TenantID:        req.TenantID,
+		ReceiptNumber:   receiptNumber,
+		PurchaseOrderID: po.ID,
+		ReceivedDate:    receivedDate,
+		DeliveryNote:    strings.TrimSpace(req.DeliveryNote),
+		Notes:           req.Notes,
+		Items:           items,
+		ReceivedBy:      req.ReceivedBy,
+	}
+	if err := s.poRepo.CreateReceipt(ctx, receipt); err != nil {
+		return nil, err
+	}
+
+	// The receipt added to the lines in the database; reload them to move
+	// the order on
+	po, err = s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	po.Status = po.ReceiptStatus()
+	if err := s.poRepo.Save(ctx, po); err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+func (s *purchaseOrderService) ListReceipts(ctx context.Context, tenantID, id uuid.UUID) ([]models.GoodsReceipt, error) {
+	if _, err := s.Get(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+	return s.poRepo.ListReceipts(ctx, tenantID, id)
+}
+
+// CreateBill raises a draft bill from the order, one bill line per order line
+// billed, and returns it with the order's match. Lines billed beyond what
+// was received or above the order's rate are raised as billed and flagged
+// by the match rather than refused.
+func (s *purchaseOrderService) CreateBill(ctx context.Context, id uuid.UUID, req BillPurchaseOrderRequest) (*PurchaseOrderBill, error) {
+	po, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !po.CanBill() {
+		return nil, ErrPurchaseOrderNotBillable
+	}
+
+	lines := make(map[uuid.UUID]*models.PurchaseOrderItem, len(po.Items))
+	for i := range po.Items {
+		lines[po.Items[i].ID] = &po.Items[i]
+	}
+
+	itemReqs := req.Items
+	if len(itemReqs) == 0 {
+		billed, err := s.poRepo.BilledQuantities(ctx, []uuid.UUID{po.ID})
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range po.Items {
+			unbilled := line.QuantityReceived.Sub(billed[line.ID].Quantity)
+			if unbilled.IsPositive() {
+				itemReqs = append(itemReqs, BillPurchaseOrderItemRequest{PurchaseOrderItemID: line.ID, Quantity: unbilled})
+			}
+		}
+		if len(itemReqs) == 0 {
+			return nil, ErrNothingToBill
+		}
+	}
+
+	billReq := CreateBillRequest{
+		TenantID:            req.TenantID,
+		CreatedBy:           req.CreatedBy,
+		Authorization:       req.Authorization,
+		VendorID:            po.VendorID,
+		VendorName:          po.VendorName,
+		VendorGSTIN:         po.VendorGSTIN,
+		VendorPAN:           po.VendorPAN,
+		VendorAddress:       po.VendorAddress,
+		VendorState:         po.VendorState,
+		VendorEmail:         po.VendorEmail,
+		VendorPhone:         po.VendorPhone,
+		VendorBillNo:        strings.TrimSpace(req.VendorBillNo),
+		BillDate:            req.BillDate,
+		DueDate:             req.DueDate,
+		ITCEligible:         req.ITCEligible,
+		ITCCategory:         req.ITCCategory,
+		Notes:               req.Notes,
+		PurchaseOrderID:     &po.ID,
+		PurchaseOrderNumber: po.PONumber,
+	}
+	if billReq.Notes == "" {
+		billReq.Notes = fmt.Sprintf("Against purchase order %s", po.PONumber)
+	}
+	for _, itemReq := range itemReqs {
+		line, ok := lines[itemReq.PurchaseOrderItemID]
+		if !ok || !itemReq.Quantity.IsPositive() || itemReq.Rate.IsNegative() {
+			return nil, ErrInvalidBill
+		}
+		rate := itemReq.Rate
+		if rate.IsZero() {
+			rate = line.Rate
+		}
+		lineID := line.ID
+		billReq.Items = append(billReq.Items, CreateBillItemRequest{
+			ProductID:           line.ProductID,
+			Description:         line.Description,
+			HSNCode:             line.HSNCode,
+			SACCode:             line.SACCode,
+			Quantity:            itemReq.Quantity,
+			Unit:                line.Unit,
+			Rate:                rate,
+			CGSTRate:            line.CGSTRate,
+			SGSTRate:            line.SGSTRate,
+			IGSTRate:            line.IGSTRate,
+			CessRate:            line.CessRate,
+			CessSpecificRate:    line.CessSpecificRate,
+			ITCEligible:         req.ITCEligible,
+			ExpenseAccountID:    line.ExpenseAccountID,
+			PurchaseOrderItemID: &lineID,
+		})
+	}
+
+	bill, err := s.billService.Create(ctx, billReq)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := s.match(ctx, po)
+	if err != nil {
+		return nil, err
+	}
+	return &PurchaseOrderBill{Bill: bill, Match: match}, nil
+}
+
+func (s *purchaseOrderService) Match(ctx context.Context, tenantID, id uuid.UUID) (*PurchaseOrderMatch, error) {
+	po, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.match(ctx, po)
+}
+
+// match matches each of the order's lines against what was received and
+// billed on it, within the tenant's tolerances
+func (s *purchaseOrderService) match(ctx context.Context, po *models.PurchaseOrder) (*PurchaseOrderMatch, error) {
+	settings, err := s.matchService.GetSettings(ctx, po.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	billed, err := s.poRepo.BilledQuantities(ctx, []uuid.UUID{po.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	match := &PurchaseOrderMatch{
+		PurchaseOrderID: po.ID,
+		PONumber:        po.PONumber,
+		Status:          po.Status,
+		Matched:         true,
+		Settings:        settings,
+		Lines:           []PurchaseOrderMatchLine{},
+	}
+	for _, item := range po.Items {
+		line := PurchaseOrderMatchLine{
+			PurchaseOrderItemID: item.ID,
+			Description:         item.Description,
+			Unit:                item.Unit,
+			OrderedQuantity:     item.Quantity,
+			ReceivedQuantity:    item.QuantityReceived,
+			BilledQuantity:      billed[item.ID].Quantity,
+			OrderRate:           item.Rate,
+			BilledRate:          billed[item.ID].MaxRate,
+		}
+
+		switch {
+		case !withinTolerance(line.ReceivedQuantity, line.BilledQuantity, settings.QuantityTolerancePct):
+			line.Status = POMatchOverBilled
+		case !withinTolerance(line.OrderRate, line.BilledRate, settings.RateTolerancePct):
+			line.Status = POMatchRateVariance
+		case line.ReceivedQuantity.LessThan(line.OrderedQuantity) && po.Status != models.PurchaseOrderStatusClosed:
+			line.Status = POMatchAwaitingReceipt
+		case line.BilledQuantity.LessThan(line.ReceivedQuantity):
+			line.Status = POMatchAwaitingBill
+		default:
+			line.Status = POMatchMatched
+		}
+		if line.Status == POMatchOverBilled || line.Status == POMatchRateVariance {
+			match.Matched = false
+		}
+		match.Lines = append(match.Lines, line)
+	}
+
+	return match, nil
+}
+
+func (s *purchaseOrderService) OpenReport(ctx context.Context, tenantID, vendorID uuid.UUID) (*OpenPurchaseOrderReport, error) {
+	orders, err := s.poRepo.ListOpen(ctx, tenantID, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(orders))
+	for i, po := range orders {
+		ids[i] = po.ID
+	}
+	billed, err := s.poRepo.BilledQuantities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	today := truncateDay(time.Now())
+	report := &OpenPurchaseOrderReport{Orders: []OpenPurchaseOrder{}}
+	for _, po := range orders {
+		open := OpenPurchaseOrder{
+			PurchaseOrderID: po.ID,
+			PONumber:        po.PONumber,
+			VendorID:        po.VendorID,
+			VendorName:      po.VendorName,
+			OrderDate:       po.OrderDate.Format("2006-01-02"),
+			Status:          po.Status,
+			AgeDays:         int(today.Sub(truncateDay(po.OrderDate)).Hours() / 24),
+			TotalAmount:     po.TotalAmount,
+		}
+		for _, item := range po.Items {
+			open.PendingReceiptValue = open.PendingReceiptValue.Add(item.PendingReceipt().Mul(item.Rate))
+			unbilled := decimal.Max(item.QuantityReceived.Sub(billed[item.ID].Quantity), decimal.Zero)
+			open.PendingBillingValue = open.PendingBillingValue.Add(unbilled.Mul(item.Rate))
+		}
+		if open.PendingReceiptValue.IsZero() && open.PendingBillingValue.IsZero() {
+			continue
+		}
+		open.PendingReceiptValue = open.PendingReceiptValue.Round(2)
+		open.PendingBillingValue = open.PendingBillingValue.Round(2)
+		if po.ExpectedDate != nil {
+			open.ExpectedDate = po.ExpectedDate.Format("2006-01-02")
+			open.Overdue = open.PendingReceiptValue.IsPositive() && po.ExpectedDate.Before(today)
+		}
+
+		report.Orders = append(report.Orders, open)
+		report.PendingReceiptValue = report.PendingReceiptValue.Add(open.PendingReceiptValue)
+		report.PendingBillingValue = report.PendingBillingValue.Add(open.PendingBillingValue)
+		if open.Overdue {
+			report.Overdue++
+		}
+	}
+	report.Count = len(report.Orders)
+
+	return report, nil
+}
+
+// purchaseOrderItems builds an order's lines from the request
+func purchaseOrderItems(reqs []CreateBillItemRequest) ([]models.PurchaseOrderItem, error) {
+	items := make([]models.PurchaseOrderItem, 0, len(reqs))
+	for i, itemReq := range reqs {
+		if strings.TrimSpace(itemReq.Description) == "" || !itemReq.Quantity.IsPositive() || itemReq.Rate.IsNegative() {
+			return nil, ErrInvalidPurchaseOrder
+		}
+		item := models.PurchaseOrderItem{
+			ProductID:        itemReq.ProductID,
+			Description:      strings.TrimSpace(itemReq.Description),
+			HSNCode:          itemReq.HSNCode,
+			SACCode:          itemReq.SACCode,
+			Quantity:         itemReq.Quantity,
+			Unit:             itemReq.Unit,
+			Rate:             itemReq.Rate,
+			CGSTRate:         itemReq.CGSTRate,
+			SGSTRate:         itemReq.SGSTRate,
+			IGSTRate:         itemReq.IGSTRate,
+			CessRate:         itemReq.CessRate,
+			CessSpecificRate: itemReq.CessSpecificRate,
+			ExpenseAccountID: itemReq.ExpenseAccountID,
+			SortOrder:        i,
+		}
+		if item.Unit == "" {
+			item.Unit = "pcs"
+		}
+		item.CalculateAmounts()
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseExpectedDate parses an order's expected delivery date, which may be
+// left out but not come before the order
+func parseExpectedDate(value string, orderDate time.Time) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	expected, err := time.Parse("2006-01-02", value)
+	if err != nil || expected.Before(orderDate) {
+		return nil, ErrInvalidPurchaseOrder
+	}
+	return &expected, nil
+}
@@ -0,0 +1,578 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+// JobExpireQuotes marks sent quotes past their validity expired
+const JobExpireQuotes = "quotes.expire"
+
+const defaultQuoteValidity = 30 // days
+
+var (
+	ErrQuoteNotFound       = errors.New("quote not found")
+	ErrInvalidQuote        = errors.New("quote_date and valid_until must be dates in YYYY-MM-DD format, valid_until not before quote_date")
+	ErrQuoteNotEditable    = errors.New("only draft quotes can be edited or deleted")
+	ErrQuoteNotSendable    = errors.New("only draft or sent quotes can be sent")
+	ErrQuoteExpired        = errors.New("quote is past its validity")
+	ErrQuoteAnswered       = errors.New("quote has already been accepted or declined")
+	ErrQuoteNotConvertible = errors.New("only sent or accepted quotes can be converted to an invoice")
+	ErrQuoteConverted      = repository.ErrQuoteConverted
+	ErrInvalidQuoteLink    = errors.New("quote link is invalid")
+)
+
+// CreateQuoteRequest represents a request to create a quote
+type CreateQuoteRequest struct {
+	TenantID        uuid.UUID                  `json:"-"`
+	CreatedBy       uuid.UUID                  `json:"-"`
+	CustomerID      uuid.UUID                  `json:"customer_id"`
+	CustomerName    string                     `json:"customer_name" binding:"required"`
+	CustomerGSTIN   string                     `json:"customer_gstin"`
+	CustomerPAN     string                     `json:"customer_pan"`
+	CustomerAddress string                     `json:"customer_address"`
+	CustomerState   string                     `json:"customer_state" binding:"required"`
+	CustomerEmail   string                     `json:"customer_email" binding:"omitempty,email"`
+	CustomerPhone   string                     `json:"customer_phone"`
+	QuoteDate       string                     `json:"quote_date" binding:"required"`
+	ValidUntil      string                     `json:"valid_until"` // Defaults to 30 days after the quote date
+	Items           []CreateInvoiceItemRequest `json:"items" binding:"required,min=1"`
+	DiscountType    string                     `json:"discount_type"`
+	DiscountValue   decimal.Decimal            `json:"discount_value"`
+	Notes           string                     `json:"notes"`
+	Terms           string                     `json:"terms"`
+	Language        string                     `json:"language" binding:"omitempty,oneof=en hi gu ta mr"`
+}
+
+// UpdateQuoteRequest represents a request to update a draft quote. Empty
+// fields are left as they are; items are replaced when present.
+type UpdateQuoteRequest struct {
+	CustomerName    string                     `json:"customer_name"`
+	CustomerGSTIN   string                     `json:"customer_gstin"`
+	CustomerPAN     string                     `json:"customer_pan"`
+	CustomerAddress string                     `json:"customer_address"`
+	CustomerState   string                     `json:"customer_state"`
+	CustomerEmail   string                     `json:"customer_email" binding:"omitempty,email"`
+	CustomerPhone   string                     `json:"customer_phone"`
+	QuoteDate       string                     `json:"quote_date"`
+	ValidUntil      string                     `json:"valid_until"`
+	Items           []CreateInvoiceItemRequest `json:"items"`
+	DiscountType    string                     `json:"discount_type"`
+	DiscountValue   decimal.Decimal            `json:"discount_value"`
+	Notes           string                     `json:"notes"`
+	Terms           string                     `json:"terms"`
+	Language        string                     `json:"language" binding:"omitempty,oneof=en hi gu ta mr"`
+}
+
+// ConvertQuoteRequest raises the invoice for a quote
+type ConvertQuoteRequest struct {
+	TenantID      uuid.UUID  `json:"-"`
+	CreatedBy     uuid.UUID  `json:"-"`
+	Authorization string     `json:"-"`            // Used to check the invoice's period is open
+	InvoiceDate   string     `json:"invoice_date"` // YYYY-MM-DD, defaults to today
+	DueDate       string     `json:"due_date"`
+	PaymentTermID *uuid.UUID `json:"payment_term_id"`
+}
+
+// AcceptQuoteRequest is the customer's acceptance through the quote link
+type AcceptQuoteRequest struct {
+	Name string `json:"name" binding:"required,max=200"` // Who accepted, for the record
+	IP   string `json:"-"`
+}
+
+// DeclineQuoteRequest is the customer's refusal through the quote link
+type DeclineQuoteRequest struct {
+	Reason string `json:"reason" binding:"max=1000"`
+	IP     string `json:"-"`
+}
+
+// SentQuote is returned when a quote is sent. The link's token is not
+// stored and cannot be retrieved again; sending the quote again replaces
+// it.
+type SentQuote struct {
+	Quote *models.Quote `json:"quote"`
+	Link  string        `json:"link"`
+}
+
+// QuoteService manages quotations. A quote is sent to the customer with a
+// link to accept or decline it, expires when its validity ends unanswered,
+// and is converted to an invoice carrying over its items and taxes.
+type QuoteService interface {
+	Create(ctx context.Context, req CreateQuoteRequest) (*models.Quote, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.Quote, error)
+	List(ctx context.Context, tenantID uuid.UUID, filters repository.QuoteFilters) ([]models.Quote, int64, error)
+	Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateQuoteRequest) (*models.Quote, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	// Send issues the quote's accept link, emailing it to the customer when
+	// the quote has their address
+	Send(ctx context.Context, tenantID, id uuid.UUID) (*SentQuote, error)
+	// Convert raises a draft invoice from the quote
+	Convert(ctx context.Context, id uuid.UUID, req ConvertQuoteRequest) (*models.Invoice, error)
+	// ExpireLapsed marks sent quotes past their validity expired
+	ExpireLapsed(ctx context.Context) error
+
+	// Public, token-authenticated operations used by the customer
+	GetByToken(ctx context.Context, token string) (*models.Quote, error)
+	Accept(ctx context.Context, token string, req AcceptQuoteRequest) (*models.Quote, error)
+	Decline(ctx context.Context, token string, req DeclineQuoteRequest) (*models.Quote, error)
+}
+
+type quoteService struct {
+	quoteRepo      repository.QuoteRepository
+	invoiceService InvoiceService
+	notifier       clients.NotificationClient
+	portalURL      string
+}
+
+// NewQuoteService creates a new quote service. Customers answer quotes on
+// the page at portalURL, which is given the link token.
+func NewQuoteService(
+	quoteRepo repository.QuoteRepository,
+	invoiceService InvoiceService,
+	notifier clients.NotificationClient,
+	portalURL string,
+) QuoteService {
+	return &quoteService{
+		quoteRepo:      quoteRepo,
+		invoiceService: invoiceService,
+		notifier:       notifier,
+		portalURL:      portalURL,
+	}
+}
+
+func (s *quoteService) Create(ctx context.Context, req CreateQuoteRequest) (*models.Quote, error) {
+	quoteDate, err := time.Parse("2006-01-02", req.QuoteDate)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+	validUntil := quoteDate.AddDate(0, 0, defaultQuoteValidity)
+	if req.ValidUntil != "" {
+		if validUntil, err = time.Parse("2006-01-02", req.ValidUntil); err != nil {
+			return nil, ErrInvalidQuote
+		}
+	}
+	if validUntil.Before(quoteDate) {
+		return nil, ErrInvalidQuote
+	}
+
+	prefix := fmt.Sprintf("QT-%s", time.Now().Format("0601"))
+	quoteNumber, err := s.quoteRepo.GetNextQuoteNumber(ctx, req.TenantID, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.Quote{
+		ID:              uuid.New(),
+		TenantID:        req.TenantID,
+		QuoteNumber:     quoteNumber,
+		CustomerID:      req.CustomerID,
+		CustomerName:    req.CustomerName,
+		CustomerGSTIN:   req.CustomerGSTIN,
+		CustomerPAN:     req.CustomerPAN,
+		CustomerAddress: req.CustomerAddress,
+		CustomerState:   req.CustomerState,
+		CustomerEmail:   req.CustomerEmail,
+		CustomerPhone:   req.CustomerPhone,
+		QuoteDate:       quoteDate,
+		ValidUntil:      validUntil,
+		Status:          models.QuoteStatusDraft,
+		DiscountType:    req.DiscountType,
+		DiscountValue:   req.DiscountValue,
+		Notes:           req.Notes,
+		Terms:           req.Terms,
+		Language:        req.Language,
+		CreatedBy:       req.CreatedBy,
+	}
+	if quote.Language == "" {
+		quote.Language = i18n.DefaultLanguage
+	}
+	quote.Items = quoteItems(quote.ID, req.Items)
+	quote.CalculateTotals()
+
+	if err := s.quoteRepo.Create(ctx, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (s *quoteService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.Quote, error) {
+	quote, err := s.quoteRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrQuoteNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (s *quoteService) List(ctx context.Context, tenantID uuid.UUID, filters repository.QuoteFilters) ([]models.Quote, int64, error) {
+	return s.quoteRepo.List(ctx, tenantID, filters)
+}
+
+func (s *quoteService) Update(ctx context.Context, tenantID, id uuid.UUID, req UpdateQuoteRequest) (*models.Quote, error) {
+	quote, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != models.QuoteStatusDraft {
+		return nil, ErrQuoteNotEditable
+	}
+
+	if req.CustomerName != "" {
+		quote.CustomerName = req.CustomerName
+	}
+	if req.CustomerGSTIN != "" {
+		quote.CustomerGSTIN = req.CustomerGSTIN
+	}
+	if req.CustomerPAN != "" {
+		quote.CustomerPAN = req.CustomerPAN
+	}
+	if req.CustomerAddress != "" {
+		quote.CustomerAddress = req.CustomerAddress
+	}
+	if req.CustomerState != "" {
+		quote.CustomerState = req.CustomerState
+	}
+	if req.CustomerEmail != "" {
+		quote.CustomerEmail = req.CustomerEmail
+	}
+	if req.CustomerPhone != "" {
+		quote.CustomerPhone = req.CustomerPhone
+	}
+	if req.QuoteDate != "" {
+		if quote.QuoteDate, err = time.Parse("2006-01-02", req.QuoteDate); err != nil {
+			return nil, ErrInvalidQuote
+		}
+	}
+	if req.ValidUntil != "" {
+		if quote.ValidUntil, err = time.Parse("2006-01-02", req.ValidUntil); err != nil {
+			return nil, ErrInvalidQuote
+		}
+	}
+	if quote.ValidUntil.Before(quote.QuoteDate) {
+		return nil, ErrInvalidQuote
+	}
+	if req.DiscountType != "" {
+		quote.DiscountType = req.DiscountType
+	}
+	quote.DiscountValue = req.DiscountValue
+	quote.Notes = req.Notes
+	quote.Terms = req.Terms
+	if req.Language != "" {
+		quote.Language = req.Language
+	}
+	if len(req.Items) > 0 {
+		quote.Items = quoteItems(quote.ID, req.Items)
+	}
+	quote.CalculateTotals()
+
+	if err := s.quoteRepo.Update(ctx, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (s *quoteService) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	quote, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if quote.Status != models.QuoteStatusDraft {
+		return ErrQuoteNotEditable
+	}
+	return s.quoteRepo.Delete(ctx, tenantID, id)
+}
+
+func (s *quoteService) Send(ctx context.Context, tenantID, id uuid.UUID) (*SentQuote, error) {
+	quote, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != models.QuoteStatusDraft && quote.Status != models.QuoteStatusSent {
+		return nil, ErrQuoteNotSendable
+	}
+	if quote.ValidUntil.Before(truncateDay(time.Now())) {
+		return nil, ErrQuoteExpired
+	}
+
+	token, err := newQuoteToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	quote.TokenHash = hashQuoteToken(token)
+	quote.Status = models.QuoteStatusSent
+	quote.SentAt = &now
+
+	if err := s.quoteRepo.Save(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	link := s.portalURL + "?token=" + url.QueryEscape(token)
+	if quote.CustomerEmail != "" {
+		// The link is returned either way, so a failed email can be made up
+		// for by sharing it another way
+		err := s.notifier.Send(ctx, clients.Notification{
+			TenantID: quote.TenantID.String(),
+			Channel:  clients.NotificationChannelEmail,
+			Email:    quote.CustomerEmail,
+			Title:    fmt.Sprintf("Quotation %s", quote.QuoteNumber),
+			Message: fmt.Sprintf("Dear %s, please find our quotation %s for %s, valid until %s. You can accept or decline it online.",
+				quote.CustomerName, quote.QuoteNumber, models.FormatINR(quote.TotalAmount), quote.ValidUntil.Format("02 Jan 2006")),
+			Type:     "info",
+			Link:     link,
+			Language: quote.Language,
+		})
+		if err != nil {
+			log.Printf("Failed to email quote %s: %v", quote.ID, err)
+		}
+	}
+
+	return &SentQuote{Quote: quote, Link: link}, nil
+}
+
+func (s *quoteService) Convert(ctx context.Context, id uuid.UUID, req ConvertQuoteRequest) (*models.Invoice, error) {
+	quote, err := s.Get(ctx, req.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.IsConverted() {
+		return nil, ErrQuoteConverted
+	}
+	if quote.Status != models.QuoteStatusSent && quote.Status != models.QuoteStatusAccepted {
+		return nil, ErrQuoteNotConvertible
+	}
+	if quote.HasLapsed(truncateDay(time.Now())) {
+		return nil, ErrQuoteExpired
+	}
+
+	invoiceDate := req.InvoiceDate
+	if invoiceDate == "" {
+		invoiceDate = time.Now().Format("2006-01-02")
+	}
+	invoiceReq := CreateInvoiceRequest{
+		TenantID:        req.TenantID,
+		CreatedBy:       req.CreatedBy,
+		Authorization:   req.Authorization,
+		CustomerID:      quote.CustomerID,
+		CustomerName:    quote.CustomerName,
+		CustomerGSTIN:   quote.CustomerGSTIN,
+		CustomerPAN:     quote.CustomerPAN,
+		CustomerAddress: quote.CustomerAddress,
+		CustomerState:   quote.CustomerState,
+		CustomerEmail:   quote.CustomerEmail,
+		CustomerPhone:   quote.CustomerPhone,
+		InvoiceDate:     invoiceDate,
+		DueDate:         req.DueDate,
+		PaymentTermID:   req.PaymentTermID,
+		DiscountType:    quote.DiscountType,
+		DiscountValue:   quote.DiscountValue,
+		Notes:           quote.Notes,
+		Terms:           quote.Terms,
+		Language:        quote.Language,
+	}
+	for _, item := range quote.Items {
+		invoiceReq.Items = append(invoiceReq.Items, CreateInvoiceItemRequest{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
+		})
+	}
+
+	invoice, err := s.invoiceService.Create(ctx, invoiceReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Converting a sent quote takes it as accepted
+	now := time.Now()
+	if quote.Status == models.QuoteStatusSent {
+		quote.Status = models.QuoteStatusAccepted
+		quote.RespondedAt = &now
+	}
+	quote.InvoiceID = &invoice.ID
+	quote.InvoiceNumber = invoice.InvoiceNumber
+	quote.ConvertedAt = &now
+
+	if err := s.quoteRepo.MarkConverted(ctx, quote); err != nil {
+		// Another request converted it first; drop the duplicate draft
+		if delErr := s.invoiceService.Delete(ctx, invoice.ID, req.Authorization); delErr != nil {
+			log.Printf("Failed to delete duplicate invoice %s of quote %s: %v", invoice.ID, quote.ID, delErr)
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (s *quoteService) ExpireLapsed(ctx context.Context) error {
+	expired, err := s.quoteRepo.ExpireLapsed(ctx, truncateDay(time.Now()))
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Expired %d quotes past their validity", expired)
+	}
+	return nil
+}
+
+func (s *quoteService) GetByToken(ctx context.Context, token string) (*models.Quote, error) {
+	if token == "" {
+		return nil, ErrInvalidQuoteLink
+	}
+	quote, err := s.quoteRepo.GetByTokenHash(ctx, hashQuoteToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrQuoteNotFound) {
+			return nil, ErrInvalidQuoteLink
+		}
+		return nil, err
+	}
+
+	if quote.HasLapsed(truncateDay(time.Now())) {
+		quote.Status = models.QuoteStatusExpired
+		if err := s.quoteRepo.Save(ctx, quote); err != nil {
+			return nil, err
+		}
+	}
+	return quote, nil
+}
+
+func (s *quoteService) Accept(ctx context.Context, token string, req AcceptQuoteRequest) (*models.Quote, error) {
+	quote, err := s.answerable(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	quote.Status = models.QuoteStatusAccepted
+	quote.AcceptedBy = strings.TrimSpace(req.Name)
+	quote.RespondedAt = &now
+	quote.ResponseIP = req.IP
+
+	if err := s.quoteRepo.Save(ctx, quote); err != nil {
+		return nil, err
+	}
+	s.notifyAnswer(ctx, quote, fmt.Sprintf("%s accepted quote %s for %s", quote.AcceptedBy, quote.QuoteNumber, models.FormatINR(quote.TotalAmount)), "success")
+	return quote, nil
+}
+
+func (s *quoteService) Decline(ctx context.Context, token string, req DeclineQuoteRequest) (*models.Quote, error) {
+	quote, err := s.answerable(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	quote.Status = models.QuoteStatusDeclined
+	quote.DeclineReason = strings.TrimSpace(req.Reason)
+	quote.RespondedAt = &now
+	quote.ResponseIP = req.IP
+
+	if err := s.quoteRepo.Save(ctx, quote); err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("%s declined quote %s", quote.CustomerName, quote.QuoteNumber)
+	if quote.DeclineReason != "" {
+		message += ": " + quote.DeclineReason
+	}
+	s.notifyAnswer(ctx, quote, message, "warning")
+	return quote, nil
+}
+
+// answerable returns the quote of a link if the customer can still accept
+// or decline it
+func (s *quoteService) answerable(ctx context.Context, token string) (*models.Quote, error) {
+	quote, err := s.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	switch quote.Status {
+	case models.QuoteStatusSent:
+		return quote, nil
+	case models.QuoteStatusExpired:
+		return nil, ErrQuoteExpired
+	default:
+		return nil, ErrQuoteAnswered
+	}
+}
+
+// notifyAnswer tells whoever created the quote that the customer answered
+// it. The answer is already saved, so a failure is only logged.
+func (s *quoteService) notifyAnswer(ctx context.Context, quote *models.Quote, message, kind string) {
+	recipient := quote.CreatedBy
+	err := s.notifier.Send(ctx, clients.Notification{
+		TenantID: quote.TenantID.String(),
+		Channel:  clients.NotificationChannelInApp,
+		UserID:   &recipient,
+		Title:    fmt.Sprintf("Quote %s %s", quote.QuoteNumber, quote.Status),
+		Message:  message,
+		Type:     kind,
+		Link:     "/quotes/" + quote.ID.String(),
+	})
+	if err != nil {
+		log.Printf("Failed to notify answer to quote %s: %v", quote.ID, err)
+	}
+}
+
+// Helper functions
+
+func quoteItems(quoteID uuid.UUID, requests []CreateInvoiceItemRequest) []models.QuoteItem {
+	items := make([]models.QuoteItem, 0, len(requests))
+	for n, itemReq := range requests {
+		item := models.QuoteItem{
+			QuoteID:          quoteID,
+			ProductID:        itemReq.ProductID,
+			Description:      itemReq.Description,
+			HSNCode:          itemReq.HSNCode,
+			Quantity:         itemReq.Quantity,
+			Unit:             itemReq.Unit,
+			Rate:             itemReq.Rate,
+			CGSTRate:         itemReq.CGSTRate,
+			SGSTRate:         itemReq.SGSTRate,
+			IGSTRate:         itemReq.IGSTRate,
+			CessRate:         itemReq.CessRate,
+			CessSpecificRate: itemReq.CessSpecificRate,
+			SortOrder:        n,
+		}
+		item.CalculateAmounts()
+		items = append(items, item)
+	}
+	return items
+}
+
+func newQuoteToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashQuoteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
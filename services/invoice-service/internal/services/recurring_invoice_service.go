@@ -44,16 +44,17 @@ type CreateRecurringInvoiceRequest struct {
 
 // RecurringInvoiceItemReq defines a line item for recurring invoice request
 type RecurringInvoiceItemReq struct {
-	ProductID   *uuid.UUID      `json:"product_id"`
-	Description string          `json:"description" binding:"required"`
-	HSNCode     string          `json:"hsn_code"`
-	Quantity    decimal.Decimal `json:"quantity" binding:"required"`
-	Unit        string          `json:"unit"`
-	Rate        decimal.Decimal `json:"rate" binding:"required"`
-	CGSTRate    decimal.Decimal `json:"cgst_rate"`
-	SGSTRate    decimal.Decimal `json:"sgst_rate"`
-	IGSTRate    decimal.Decimal `json:"igst_rate"`
-	CessRate    decimal.Decimal `json:"cess_rate"`
+	ProductID        *uuid.UUID      `json:"product_id"`
+	Description      string          `json:"description" binding:"required"`
+	HSNCode          string          `json:"hsn_code"`
+	Quantity         decimal.Decimal `json:"quantity" binding:"required"`
+	Unit             string          `json:"unit"`
+	Rate             decimal.Decimal `json:"rate" binding:"required"`
+	CGSTRate         decimal.Decimal `json:"cgst_rate"`
+	SGSTRate         decimal.Decimal `json:"sgst_rate"`
+	IGSTRate         decimal.Decimal `json:"igst_rate"`
+	CessRate         decimal.Decimal `json:"cess_rate"`
+	CessSpecificRate decimal.Decimal `json:"cess_specific_rate"`
 }
 
 // UpdateRecurringInvoiceRequest defines the request for updating a recurring invoice
@@ -72,6 +73,10 @@ type UpdateRecurringInvoiceRequest struct {
 	Terms          string                    `json:"terms"`
 }
 
+// JobGenerateRecurringInvoices is the scheduled job that raises the
+// recurring invoices that have fallen due
+const JobGenerateRecurringInvoices = "recurring_invoices.generate"
+
 // RecurringInvoiceService defines the interface for recurring invoice business logic
 type RecurringInvoiceService interface {
 	Create(ctx context.Context, req CreateRecurringInvoiceRequest) (*models.RecurringInvoice, error)
@@ -158,16 +163,17 @@ func (s *recurringInvoiceService) Create(ctx context.Context, req CreateRecurrin
 		}
 
 		item := models.RecurringInvoiceItem{
-			ProductID:   itemReq.ProductID,
-			Description: itemReq.Description,
-			HSNCode:     itemReq.HSNCode,
-			Quantity:    itemReq.Quantity,
-			Unit:        unit,
-			Rate:        itemReq.Rate,
-			CGSTRate:    itemReq.CGSTRate,
-			SGSTRate:    itemReq.SGSTRate,
-			IGSTRate:    itemReq.IGSTRate,
-			CessRate:    itemReq.CessRate,
+			ProductID:        itemReq.ProductID,
+			Description:      itemReq.Description,
+			HSNCode:          itemReq.HSNCode,
+			Quantity:         itemReq.Quantity,
+			Unit:             unit,
+			Rate:             itemReq.Rate,
+			CGSTRate:         itemReq.CGSTRate,
+			SGSTRate:         itemReq.SGSTRate,
+			IGSTRate:         itemReq.IGSTRate,
+			CessRate:         itemReq.CessRate,
+			CessSpecificRate: itemReq.CessSpecificRate,
 		}
 		item.CalculateAmounts()
 		recurring.Items = append(recurring.Items, item)
@@ -268,6 +274,7 @@ func (s *recurringInvoiceService) Update(ctx context.Context, id uuid.UUID, req
 				SGSTRate:           itemReq.SGSTRate,
 				IGSTRate:           itemReq.IGSTRate,
 				CessRate:           itemReq.CessRate,
+				CessSpecificRate:   itemReq.CessSpecificRate,
 			}
 			item.CalculateAmounts()
 			recurring.Items = append(recurring.Items, item)
@@ -355,16 +362,17 @@ func (s *recurringInvoiceService) generateInvoiceFromRecurring(ctx context.Conte
 	var invoiceItems []CreateInvoiceItemRequest
 	for _, item := range recurring.Items {
 		invoiceItems = append(invoiceItems, CreateInvoiceItemRequest{
-			ProductID:   item.ProductID,
-			Description: item.Description,
-			HSNCode:     item.HSNCode,
-			Quantity:    item.Quantity,
-			Unit:        item.Unit,
-			Rate:        item.Rate,
-			CGSTRate:    item.CGSTRate,
-			SGSTRate:    item.SGSTRate,
-			IGSTRate:    item.IGSTRate,
-			CessRate:    item.CessRate,
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			HSNCode:          item.HSNCode,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			Rate:             item.Rate,
+			CGSTRate:         item.CGSTRate,
+			SGSTRate:         item.SGSTRate,
+			IGSTRate:         item.IGSTRate,
+			CessRate:         item.CessRate,
+			CessSpecificRate: item.CessSpecificRate,
 		})
 	}
 
@@ -425,7 +433,7 @@ func (s *recurringInvoiceService) generateInvoiceFromRecurring(ctx context.Conte
 
 	// Auto-send if enabled
 	if recurring.AutoSend && recurring.CustomerEmail != "" {
-		_ = s.invoiceService.Send(ctx, invoice.ID)
+		_ = s.invoiceService.Send(ctx, invoice.ID, "")
 	}
 
 	return invoice, nil
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrInvalidRoundingMode  = errors.New("invalid rounding mode")
+	ErrInvalidDocumentType  = errors.New("invalid document type")
+	ErrInvalidRoundingValue = errors.New("round_to must be greater than zero")
+)
+
+// roundingDocumentTypes lists the document types that support total rounding
+var roundingDocumentTypes = []models.DocumentType{
+	models.DocumentTypeInvoice,
+	models.DocumentTypeBill,
+	models.DocumentTypeCreditNote,
+}
+
+// UpdateRoundingRuleRequest represents a request to configure rounding for a document type.
+// Fields left out keep their setting; the rounding account is removed, so
+// the ledger's Round Off account is used, with ClearRoundingAccount.
+type UpdateRoundingRuleRequest struct {
+	Mode                 models.RoundingMode `json:"mode" binding:"required"`
+	RoundTo              *decimal.Decimal    `json:"round_to"`
+	RoundingAccountID    *uuid.UUID          `json:"rounding_account_id"`
+	ClearRoundingAccount bool                `json:"clear_rounding_account"`
+}
+
+// RoundingService handles document rounding configuration
+type RoundingService interface {
+	GetRule(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType) *models.RoundingRule
+	ListRules(ctx context.Context, tenantID uuid.UUID) ([]models.RoundingRule, error)
+	UpdateRule(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType, req UpdateRoundingRuleRequest) (*models.RoundingRule, error)
+}
+
+type roundingService struct {
+	repo repository.RoundingRuleRepository
+}
+
+// NewRoundingService creates a new rounding service
+func NewRoundingService(repo repository.RoundingRuleRepository) RoundingService {
+	return &roundingService{repo: repo}
+}
+
+// GetRule returns the tenant's rule for a document type, falling back to the default
+func (s *roundingService) GetRule(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType) *models.RoundingRule {
+	rule, err := s.repo.GetByDocumentType(ctx, tenantID, docType)
+	if err != nil {
+		return models.DefaultRoundingRule(tenantID, docType)
+	}
+	return rule
+}
+
+// ListRules returns the effective rule for every supported document type
+func (s *roundingService) ListRules(ctx context.Context, tenantID uuid.UUID) ([]models.RoundingRule, error) {
+	configured, err := s.repo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[models.DocumentType]models.RoundingRule, len(configured))
+	for _, rule := range configured {
+		byType[rule.DocumentType] = rule
+	}
+
+	rules := make([]models.RoundingRule, 0, len(roundingDocumentTypes))
+	for _, docType := range roundingDocumentTypes {
+		if rule, ok := byType[docType]; ok {
+			rules = append(rules, rule)
+			continue
+		}
+		rules = append(rules, *models.DefaultRoundingRule(tenantID, docType))
+	}
+
+	return rules, nil
+}
+
+func (s *roundingService) UpdateRule(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType, req UpdateRoundingRuleRequest) (*models.RoundingRule, error) {
+	if !isRoundingDocumentType(docType) {
+		return nil, ErrInvalidDocumentType
+	}
+	if !req.Mode.IsValid() {
+		return nil, ErrInvalidRoundingMode
+	}
+	if req.RoundTo != nil && !req.RoundTo.IsPositive() {
+		return nil, ErrInvalidRoundingValue
+	}
+
+	rule, err := s.repo.GetByDocumentType(ctx, tenantID, docType)
+	if err != nil {
+		rule = models.DefaultRoundingRule(tenantID, docType)
+	}
+
+	rule.Mode = req.Mode
+	if req.RoundTo != nil {
+		rule.RoundTo = *req.RoundTo
+	}
+	if req.RoundingAccountID != nil {
+		rule.RoundingAccountID = req.RoundingAccountID
+	} else if req.ClearRoundingAccount {
+		rule.RoundingAccountID = nil
+	}
+
+	if err := s.repo.Save(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func isRoundingDocumentType(docType models.DocumentType) bool {
+	for _, t := range roundingDocumentTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
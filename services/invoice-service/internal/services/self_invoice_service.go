@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var (
+	ErrSelfInvoiceNotFound = errors.New("self-invoice not found")
+	ErrNotReverseCharge    = errors.New("bill is not flagged for reverse charge on a purchase from an unregistered vendor")
+	ErrBillNotApproved     = errors.New("bill must be approved before a self-invoice is issued")
+	ErrBillSelfInvoiced    = errors.New("bill already has a self-invoice")
+	ErrInvalidSelfInvoice  = errors.New("self-invoice date must be on or after the bill date")
+)
+
+// GenerateSelfInvoiceRequest issues the self-invoice for a reverse charge
+// bill. InvoiceDate defaults to today.
+type GenerateSelfInvoiceRequest struct {
+	TenantID    uuid.UUID `json:"-"`
+	CreatedBy   uuid.UUID `json:"-"`
+	InvoiceDate string    `json:"invoice_date"`
+}
+
+// ReverseChargeTaxRow totals the value and tax of reverse charge supplies
+// in the shape of GSTR-3B
+type ReverseChargeTaxRow struct {
+	Taxable decimal.Decimal `json:"txval"`
+	IGST    decimal.Decimal `json:"iamt"`
+	CGST    decimal.Decimal `json:"camt"`
+	SGST    decimal.Decimal `json:"samt"`
+	Cess    decimal.Decimal `json:"csamt"`
+}
+
+// ReverseChargeTax is what a period's self-invoices add to GSTR-3B: the
+// inward supplies liable to reverse charge in table 3.1(d), on which the tax
+// is paid in cash, and the input tax credit on them in table 4(A)(3)
+type ReverseChargeTax struct {
+	Period         string              `json:"period"`
+	InvoiceNumbers []string            `json:"invoice_numbers"`
+	InwardSupply   ReverseChargeTaxRow `json:"isup_rev"`
+	ITCAvailable   ReverseChargeITCRow `json:"itc_isrc"`
+}
+
+// ReverseChargeITCRow totals the input tax credit on reverse charge
+// supplies in the shape of GSTR-3B
+type ReverseChargeITCRow struct {
+	IGST decimal.Decimal `json:"iamt"`
+	CGST decimal.Decimal `json:"camt"`
+	SGST decimal.Decimal `json:"samt"`
+	Cess decimal.Decimal `json:"csamt"`
+}
+
+// SelfInvoiceService handles self-invoices for reverse charge purchases
+// from unregistered vendors
+type SelfInvoiceService interface {
+	Generate(ctx context.Context, billID uuid.UUID, req GenerateSelfInvoiceRequest) (*models.SelfInvoice, error)
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.SelfInvoice, error)
+	List(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.SelfInvoice, error)
+	GSTR3B(ctx context.Context, tenantID uuid.UUID, period string) (*ReverseChargeTax, error)
+}
+
+type selfInvoiceService struct {
+	repo     repository.SelfInvoiceRepository
+	billRepo repository.BillRepository
+}
+
+// NewSelfInvoiceService creates a new self-invoice service
+func NewSelfInvoiceService(repo repository.SelfInvoiceRepository, billRepo repository.BillRepository) SelfInvoiceService {
+	return &selfInvoiceService{repo: repo, billRepo: billRepo}
+}
+
+// Generate issues the self-invoice for an approved bill flagged URD-RCM,
+// numbered in its own SI series
+func (s *selfInvoiceService) Generate(ctx context.Context, billID uuid.UUID, req GenerateSelfInvoiceRequest) (*models.SelfInvoice, error) {
+	bill, err := s.billRepo.GetByID(ctx, billID)
+	if err != nil || bill.TenantID != req.TenantID {
+		return nil, ErrBillNotFound
+	}
+	if !bill.URDReverseCharge {
+		return nil, ErrNotReverseCharge
+	}
+	if bill.SelfInvoiceID != nil {
+		return nil, ErrBillSelfInvoiced
+	}
+	switch bill.Status {
+	case models.BillStatusDraft, models.BillStatusPending, models.BillStatusCancelled:
+		return nil, ErrBillNotApproved
+	}
+
+	invoiceDate := time.Now().Truncate(24 * time.Hour)
+	if req.InvoiceDate != "" {
+		if invoiceDate, err = time.Parse("2006-01-02", req.InvoiceDate); err != nil {
+			return nil, ErrInvalidSelfInvoice
+		}
+	}
+	if invoiceDate.Before(bill.BillDate.Truncate(24 * time.Hour)) {
+		return nil, ErrInvalidSelfInvoice
+	}
+
+	number, err := s.repo.GetNextNumber(ctx, req.TenantID, fmt.Sprintf("SI-%s", invoiceDate.Format("0601")))
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := models.NewSelfInvoiceFromBill(bill)
+	invoice.InvoiceNumber = number
+	invoice.InvoiceDate = invoiceDate
+	invoice.CreatedBy = req.CreatedBy
+
+	if err := s.repo.Create(ctx, invoice); err != nil {
+		if err == repository.ErrBillSelfInvoiced {
+			return nil, ErrBillSelfInvoiced
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (s *selfInvoiceService) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.SelfInvoice, error) {
+	invoice, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrSelfInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+func (s *selfInvoiceService) List(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.SelfInvoice, error) {
+	return s.repo.List(ctx, tenantID, from, to)
+}
+
+// GSTR3B totals the self-invoices of a return period (MMYYYY). Tax on
+// lines not eligible for input tax credit is still payable, so it counts
+// towards 3.1(d) but not 4(A)(3).
+func (s *selfInvoiceService) GSTR3B(ctx context.Context, tenantID uuid.UUID, period string) (*ReverseChargeTax, error) {
+	start, err := time.Parse("012006", period)
+	if err != nil {
+		return nil, ErrInvalidPeriod
+	}
+	invoices, err := s.repo.List(ctx, tenantID, start, start.AddDate(0, 1, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	tax := &ReverseChargeTax{Period: period, InvoiceNumbers: []string{}}
+	for _, invoice := range invoices {
+		tax.InvoiceNumbers = append(tax.InvoiceNumbers, invoice.InvoiceNumber)
+		tax.InwardSupply.Taxable = tax.InwardSupply.Taxable.Add(invoice.TaxableAmount)
+		tax.InwardSupply.IGST = tax.InwardSupply.IGST.Add(invoice.IGSTAmount)
+		tax.InwardSupply.CGST = tax.InwardSupply.CGST.Add(invoice.CGSTAmount)
+		tax.InwardSupply.SGST = tax.InwardSupply.SGST.Add(invoice.SGSTAmount)
+		tax.InwardSupply.Cess = tax.InwardSupply.Cess.Add(invoice.CessAmount)
+
+		for _, item := range invoice.Items {
+			if !item.ITCEligible {
+				continue
+			}
+			tax.ITCAvailable.IGST = tax.ITCAvailable.IGST.Add(item.IGSTAmount)
+			tax.ITCAvailable.CGST = tax.ITCAvailable.CGST.Add(item.CGSTAmount)
+			tax.ITCAvailable.SGST = tax.ITCAvailable.SGST.Add(item.SGSTAmount)
+			tax.ITCAvailable.Cess = tax.ITCAvailable.Cess.Add(item.CessAmount)
+		}
+	}
+	return tax, nil
+}
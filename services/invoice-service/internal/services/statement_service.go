@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/statement"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// JobSendStatements is the queue job that sends the statements of a run
+const JobSendStatements = "statements.send"
+
+var (
+	ErrStatementRunNotFound = errors.New("statement run not found")
+	ErrStatementRunActive   = errors.New("a statement run is already queued or in progress")
+	ErrInvalidStatementRun  = errors.New("month must be a past month in YYYY-MM format and the minimum balance cannot be negative")
+)
+
+// StartStatementRunRequest asks for the statements of a month to be emailed
+type StartStatementRunRequest struct {
+	TenantID   uuid.UUID       `json:"-"`
+	UserID     uuid.UUID       `json:"-"`
+	Month      string          `json:"month"`       // YYYY-MM, defaults to last month
+	MinBalance decimal.Decimal `json:"min_balance"` // Customers owing less are left out
+}
+
+// SendStatementsPayload is the payload of a JobSendStatements job
+type SendStatementsPayload struct {
+	RunID uuid.UUID `json:"run_id"`
+}
+
+// StatementService emails customers their statement of account for a month:
+// the opening balance, the month's invoices, payments and credit notes, and
+// what they owe at the month end, as a PDF attachment
+type StatementService interface {
+	// StartRun queues a run for every customer owing at least the minimum
+	// balance at the end of the month
+	StartRun(ctx context.Context, req *StartStatementRunRequest) (*models.StatementRun, error)
+	GetRun(ctx context.Context, tenantID, id uuid.UUID) (*models.StatementRun, error)
+	ListRuns(ctx context.Context, tenantID uuid.UUID) ([]models.StatementRun, error)
+
+	// ProcessRun sends the statements of a run. Statements already sent are
+	// not sent again, so a failed run can be retried.
+	ProcessRun(ctx context.Context, runID uuid.UUID) error
+}
+
+type statementService struct {
+	repo     repository.StatementRepository
+	notifier clients.NotificationClient
+	queue    *jobs.Queue
+}
+
+// NewStatementService creates a new statement service
+func NewStatementService(repo repository.StatementRepository, notifier clients.NotificationClient, queue *jobs.Queue) StatementService {
+	return &statementService{repo: repo, notifier: notifier, queue: queue}
+}
+
+func (s *statementService) StartRun(ctx context.Context, req *StartStatementRunRequest) (*models.StatementRun, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if req.Month != "" {
+		month, err := time.Parse("2006-01", req.Month)
+		if err != nil {
+			return nil, ErrInvalidStatementRun
+		}
+		start = month
+	}
+	end := start.AddDate(0, 1, -1)
+	if !end.Before(now) || req.MinBalance.IsNegative() {
+		return nil, ErrInvalidStatementRun
+	}
+
+	active, err := s.repo.HasActiveRun(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrStatementRunActive
+	}
+
+	run := &models.StatementRun{
+		TenantID:    req.TenantID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		MinBalance:  req.MinBalance,
+		Status:      models.StatementRunQueued,
+		CreatedBy:   req.UserID,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	_, err = s.queue.Enqueue(ctx, JobSendStatements, SendStatementsPayload{RunID: run.ID}, jobs.EnqueueOptions{
+		TenantID:  &run.TenantID,
+		UniqueKey: "statements:" + run.ID.String(),
+	})
+	if err != nil {
+		run.Status = models.StatementRunFailed
+		run.Error = err.Error()
+		_ = s.repo.UpdateRun(ctx, run)
+		return nil, err
+	}
+
+	return run, nil
+}
+
+func (s *statementService) GetRun(ctx context.Context, tenantID, id uuid.UUID) (*models.StatementRun, error) {
+	run, err := s.repo.GetRun(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStatementRunNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *statementService) ListRuns(ctx context.Context, tenantID uuid.UUID) ([]models.StatementRun, error) {
+	return s.repo.ListRuns(ctx, tenantID)
+}
+
+func (s *statementService) ProcessRun(ctx context.Context, runID uuid.UUID) error {
+	run, err := s.repo.GetRunByID(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if run.Status == models.StatementRunCompleted && run.Failed == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	run.Status = models.StatementRunRunning
+	run.Error = ""
+	if run.StartedAt == nil {
+		run.StartedAt = &now
+	}
+	if err := s.repo.UpdateRun(ctx, run); err != nil {
+		return err
+	}
+
+	if err := s.process(ctx, run); err != nil {
+		run.Status = models.StatementRunFailed
+		run.Error = err.Error()
+		_ = s.repo.UpdateRun(ctx, run)
+		return err
+	}
+
+	counts, err := s.repo.CountDeliveries(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+	completed := time.Now()
+	run.Status = models.StatementRunCompleted
+	run.Sent = counts[models.StatementDeliverySent]
+	run.Failed = counts[models.StatementDeliveryFailed]
+	run.Skipped = counts[models.StatementDeliverySkipped]
+	run.CompletedAt = &completed
+	if err := s.repo.UpdateRun(ctx, run); err != nil {
+		return err
+	}
+
+	// Failing the job has the queue retry the statements that failed
+	if run.Failed > 0 {
+		return fmt.Errorf("%d of %d statements could not be sent", run.Failed, run.Recipients)
+	}
+	return nil
+}
+
+// process picks the recipients on the first pass and sends every statement
+// not sent yet
+func (s *statementService) process(ctx context.Context, run *models.StatementRun) error {
+	if run.Recipients == 0 {
+		balances, err := s.repo.ListCustomerBalances(ctx, run.TenantID, run.PeriodEnd, run.MinBalance)
+		if err != nil {
+			return err
+		}
+
+		deliveries := make([]models.StatementDelivery, len(balances))
+		for i, row := range balances {
+			deliveries[i] = models.StatementDelivery{
+				RunID:        run.ID,
+				TenantID:     run.TenantID,
+				CustomerID:   row.CustomerID,
+				CustomerName: row.CustomerName,
+				Email:        row.CustomerEmail,
+				Language:     row.Language,
+				Balance:      row.Balance,
+				Status:       models.StatementDeliveryPending,
+			}
+			if row.CustomerEmail == "" {
+				deliveries[i].Status = models.StatementDeliverySkipped
+				deliveries[i].Error = "customer has no email address"
+			}
+		}
+		if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+			return err
+		}
+
+		run.Recipients = len(deliveries)
+		if err := s.repo.UpdateRun(ctx, run); err != nil {
+			return err
+		}
+	}
+
+	company, err := s.repo.GetTenantName(ctx, run.TenantID)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, run.ID, []string{models.StatementDeliveryPending, models.StatementDeliveryFailed})
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		delivery.Attempts++
+		if err := s.send(ctx, run, delivery, company); err != nil {
+			delivery.Status = models.StatementDeliveryFailed
+			delivery.Error = err.Error()
+		} else {
+			sentAt := time.Now()
+			delivery.Status = models.StatementDeliverySent
+			delivery.Error = ""
+			delivery.SentAt = &sentAt
+		}
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send emails a customer their statement for the run's month
+func (s *statementService) send(ctx context.Context, run *models.StatementRun, delivery *models.StatementDelivery, company string) error {
+	opening, err := s.repo.GetCustomerBalance(ctx, run.TenantID, delivery.CustomerID, run.PeriodStart)
+	if err != nil {
+		return err
+	}
+	entries, err := s.repo.ListCustomerLedger(ctx, run.TenantID, delivery.CustomerID, run.PeriodStart, run.PeriodEnd)
+	if err != nil {
+		return err
+	}
+
+	stmt := &statement.Statement{
+		Company:        company,
+		Name:           delivery.CustomerName,
+		From:           run.PeriodStart,
+		To:             run.PeriodEnd,
+		OpeningBalance: opening.InexactFloat64(),
+		Rows:           make([]statement.Row, len(entries)),
+	}
+	balance := opening
+	for i, entry := range entries {
+		balance = balance.Add(entry.Debit).Sub(entry.Credit)
+		stmt.Rows[i] = statement.Row{
+			Date:          entry.Date,
+			Particulars:   entry.Particulars,
+			VoucherType:   statement.VoucherType(entryVoucherTypes[entry.Kind]),
+			VoucherNumber: entry.Number,
+			Debit:         entry.Debit.InexactFloat64(),
+			Credit:        entry.Credit.InexactFloat64(),
+			Balance:       balance.InexactFloat64(),
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := statement.Write(&buf, statement.FormatPDF, stmt); err != nil {
+		return err
+	}
+
+	month := run.PeriodStart.Format("January 2006")
+	return s.notifier.Send(ctx, clients.Notification{
+		TenantID: run.TenantID.String(),
+		Channel:  clients.NotificationChannelEmail,
+		Email:    delivery.Email,
+		Title:    fmt.Sprintf("Statement of account for %s", month),
+		Message: fmt.Sprintf("Please find attached your statement of account with %s for %s. The balance due as of %s is %s.",
+			company, month, run.PeriodEnd.Format("02 Jan 2006"), models.FormatINR(balance)),
+		Type:     "info",
+		Language: delivery.Language,
+		Attachments: []clients.NotificationAttachment{{
+			FileName:    fmt.Sprintf("statement-%s.pdf", run.PeriodStart.Format("2006-01")),
+			ContentType: statement.ContentType(statement.FormatPDF),
+			Content:     buf.Bytes(),
+		}},
+	})
+}
+
+// entryVoucherTypes maps customer ledger entries to the transaction types
+// statement voucher types are named by
+var entryVoucherTypes = map[string]string{
+	"invoice":     "sale",
+	"payment":     "receipt",
+	"credit_note": "credit_note",
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/lifecycle"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/support"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+// InvoiceSupportDocument loads invoices, with their items and payments,
+// for support bundles
+func InvoiceSupportDocument(invoiceRepo repository.InvoiceRepository, dunningRepo repository.DunningRepository, disputeRepo repository.DisputeRepository, tracker *lifecycle.Tracker) support.DocumentConfig {
+	return support.DocumentConfig{
+		Type: timeline.DocumentInvoice,
+		Load: func(ctx context.Context, tenantID, id uuid.UUID) (interface{}, error) {
+			invoice, err := invoiceRepo.GetByID(ctx, id)
+			if err != nil || invoice.TenantID != tenantID {
+				return nil, support.ErrDocumentNotFound
+			}
+			return invoice, nil
+		},
+		Timeline: InvoiceTimeline(invoiceRepo, dunningRepo, disputeRepo, tracker),
+	}
+}
+
+// BillSupportDocument loads bills, with their items and payments, for
+// support bundles
+func BillSupportDocument(billRepo repository.BillRepository) support.DocumentConfig {
+	return support.DocumentConfig{
+		Type: timeline.DocumentBill,
+		Load: func(ctx context.Context, tenantID, id uuid.UUID) (interface{}, error) {
+			bill, err := billRepo.GetByID(ctx, id)
+			if err != nil || bill.TenantID != tenantID {
+				return nil, support.ErrDocumentNotFound
+			}
+			return bill, nil
+		},
+		Timeline: BillTimeline(billRepo),
+	}
+}
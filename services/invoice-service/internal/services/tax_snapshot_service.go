@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+var ErrTaxSnapshotNotFound = errors.New("tax snapshot not found")
+
+// TaxSnapshotService captures the tax calculation applied to documents when
+// they are finalized
+type TaxSnapshotService interface {
+	CaptureInvoice(ctx context.Context, invoice *models.Invoice) error
+	CaptureBill(ctx context.Context, bill *models.Bill) error
+	Get(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType, docID uuid.UUID) (*models.TaxSnapshot, error)
+}
+
+type taxSnapshotService struct {
+	snapshotRepo repository.TaxSnapshotRepository
+	productRepo  repository.ProductRepository
+}
+
+// NewTaxSnapshotService creates a new tax snapshot service
+func NewTaxSnapshotService(
+	snapshotRepo repository.TaxSnapshotRepository,
+	productRepo repository.ProductRepository,
+) TaxSnapshotService {
+	return &taxSnapshotService{
+		snapshotRepo: snapshotRepo,
+		productRepo:  productRepo,
+	}
+}
+
+func (s *taxSnapshotService) CaptureInvoice(ctx context.Context, invoice *models.Invoice) error {
+	lines := make(models.TaxSnapshotLines, 0, len(invoice.Items))
+	for _, item := range invoice.Items {
+		line := models.TaxSnapshotLine{
+			LineID:        item.ID,
+			ProductID:     item.ProductID,
+			Description:   item.Description,
+			HSNCode:       item.HSNCode,
+			TaxableAmount: item.Amount,
+			CGSTRate:      item.CGSTRate,
+			SGSTRate:      item.SGSTRate,
+			IGSTRate:      item.IGSTRate,
+			CessRate:      item.CessRate,
+			CGSTAmount:    item.CGSTAmount,
+			SGSTAmount:    item.SGSTAmount,
+			IGSTAmount:    item.IGSTAmount,
+			CessAmount:    item.CessAmount,
+		}
+		withSpecificCess(&line, item.Quantity, item.CessSpecificRate)
+		lines = append(lines, line)
+	}
+
+	rules := models.TaxSnapshotRules{
+		PartyState:        invoice.CustomerState,
+		Interstate:        invoice.IGSTAmount.IsPositive(),
+		RoundingMode:      invoice.RoundingMode,
+		RoundTo:           invoice.RoundTo,
+		RoundingAccountID: invoice.RoundingAccountID,
+	}
+	if invoice.TCSApplicable {
+		rules.WithholdingType = "TCS"
+		rules.WithholdingSection = invoice.TCSSection
+		rules.WithholdingRate = invoice.TCSRate
+	}
+
+	return s.capture(ctx, &models.TaxSnapshot{
+		TenantID:       invoice.TenantID,
+		DocumentType:   models.DocumentTypeInvoice,
+		DocumentID:     invoice.ID,
+		DocumentNumber: invoice.InvoiceNumber,
+		DocumentDate:   invoice.InvoiceDate,
+		Lines:          lines,
+		Rules:          rules,
+		TaxableAmount:  invoice.TaxableAmount,
+		TotalTax:       invoice.TotalTax,
+		TotalAmount:    invoice.TotalAmount,
+	})
+}
+
+func (s *taxSnapshotService) CaptureBill(ctx context.Context, bill *models.Bill) error {
+	lines := make(models.TaxSnapshotLines, 0, len(bill.Items))
+	for _, item := range bill.Items {
+		line := models.TaxSnapshotLine{
+			LineID:        item.ID,
+			ProductID:     item.ProductID,
+			Description:   item.Description,
+			HSNCode:       item.HSNCode,
+			SACCode:       item.SACCode,
+			TaxableAmount: item.Amount,
+			CGSTRate:      item.CGSTRate,
+			SGSTRate:      item.SGSTRate,
+			IGSTRate:      item.IGSTRate,
+			CessRate:      item.CessRate,
+			CGSTAmount:    item.CGSTAmount,
+			SGSTAmount:    item.SGSTAmount,
+			IGSTAmount:    item.IGSTAmount,
+			CessAmount:    item.CessAmount,
+		}
+		withSpecificCess(&line, item.Quantity, item.CessSpecificRate)
+		lines = append(lines, line)
+	}
+
+	rules := models.TaxSnapshotRules{
+		PartyState:        bill.VendorState,
+		Interstate:        bill.IGSTAmount.IsPositive(),
+		RoundingMode:      bill.RoundingMode,
+		RoundTo:           bill.RoundTo,
+		RoundingAccountID: bill.RoundingAccountID,
+	}
+	if bill.TDSApplicable {
+		rules.WithholdingType = "TDS"
+		rules.WithholdingSection = bill.TDSSection
+		rules.WithholdingRate = bill.TDSRate
+	}
+
+	return s.capture(ctx, &models.TaxSnapshot{
+		TenantID:       bill.TenantID,
+		DocumentType:   models.DocumentTypeBill,
+		DocumentID:     bill.ID,
+		DocumentNumber: bill.BillNumber,
+		DocumentDate:   bill.BillDate,
+		Lines:          lines,
+		Rules:          rules,
+		TaxableAmount:  bill.TaxableAmount,
+		TotalTax:       bill.TotalTax,
+		TotalAmount:    bill.TotalAmount,
+	})
+}
+
+func (s *taxSnapshotService) Get(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType, docID uuid.UUID) (*models.TaxSnapshot, error) {
+	snapshot, err := s.snapshotRepo.GetByDocument(ctx, tenantID, docType, docID)
+	if err != nil {
+		return nil, ErrTaxSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func withSpecificCess(line *models.TaxSnapshotLine, quantity, rate decimal.Decimal) {
+	if rate.IsZero() {
+		return
+	}
+	line.Quantity = &quantity
+	line.CessSpecificRate = &rate
+}
+
+// capture adds the catalog tax settings of each line's product and stores the
+// snapshot. A document is only snapshotted once; later calls are no-ops.
+func (s *taxSnapshotService) capture(ctx context.Context, snapshot *models.TaxSnapshot) error {
+	if _, err := s.snapshotRepo.GetByDocument(ctx, snapshot.TenantID, snapshot.DocumentType, snapshot.DocumentID); err == nil {
+		return nil
+	}
+
+	products := make(map[uuid.UUID]*models.Product)
+	for i := range snapshot.Lines {
+		line := &snapshot.Lines[i]
+		if line.ProductID == nil {
+			continue
+		}
+
+		product, ok := products[*line.ProductID]
+		if !ok {
+			product, _ = s.productRepo.GetByID(ctx, *line.ProductID)
+			products[*line.ProductID] = product
+		}
+		if product == nil {
+			continue
+		}
+
+		gstRate := product.GSTRate
+		line.ProductCategory = product.Category
+		line.ProductTaxRateID = product.TaxRateID
+		line.ProductGSTRate = &gstRate
+		line.ProductExempt = product.IsExempt
+		if line.SACCode == "" {
+			line.SACCode = product.SACCode
+		}
+	}
+
+	snapshot.Version = models.TaxSnapshotVersion
+	snapshot.CapturedAt = time.Now()
+	snapshot.Checksum = snapshot.ComputeChecksum()
+
+	return s.snapshotRepo.Create(ctx, snapshot)
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/lifecycle"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/timeline"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/repository"
+)
+
+// Fields whose edits are shown on an invoice's timeline, as named in its
+// JSON. Items show through the totals they change.
+var invoiceTimelineFields = []string{
+	"customer_name", "customer_gstin", "customer_pan", "customer_address", "customer_state",
+	"customer_email", "customer_phone", "po_number", "due_date", "payment_term_name", "discount_type",
+	"discount_value", "subtotal", "total_tax", "tcs_amount", "total_amount", "language",
+	"currency", "exchange_rate", "export_type", "port_code", "shipping_bill_number",
+	"shipping_bill_date", "notes", "terms", "tags",
+}
+
+// Fields whose edits are shown on a bill's timeline
+var billTimelineFields = []string{
+	"vendor_name", "vendor_gstin", "vendor_pan", "vendor_address", "vendor_state",
+	"vendor_email", "vendor_phone", "vendor_bill_no", "due_date", "discount_type",
+	"discount_value", "subtotal", "total_tax", "tds_section", "tds_amount", "total_amount",
+	"itc_eligible", "itc_category", "urd_rcm", "notes",
+}
+
+// einvoiceEvents are the timeline events of e-invoice states
+var einvoiceEvents = map[string]string{
+	"pending":   timeline.EventEInvoiceRequested,
+	"generated": timeline.EventEInvoiceGenerated,
+	"failed":    timeline.EventEInvoiceFailed,
+	"cancelled": timeline.EventEInvoiceCancelled,
+}
+
+// record adds an event to a document's timeline. The timeline is kept for
+// display, so a failure to record is logged and the work goes on.
+func record(ctx context.Context, store *timeline.Store, doc timeline.Document, eventType, summary string, changes []timeline.Change) {
+	if store == nil {
+		return
+	}
+	if err := store.Record(ctx, doc, eventType, summary, changes); err != nil {
+		log.Printf("Failed to record %s on %s %s: %v", eventType, doc.Type, doc.ID, err)
+	}
+}
+
+func invoiceTimelineDocument(invoice *models.Invoice) timeline.Document {
+	return timeline.Document{TenantID: invoice.TenantID, Type: timeline.DocumentInvoice, ID: invoice.ID}
+}
+
+func billTimelineDocument(bill *models.Bill) timeline.Document {
+	return timeline.Document{TenantID: bill.TenantID, Type: timeline.DocumentBill, ID: bill.ID}
+}
+
+// InvoiceTimeline composes an invoice's creation and payments from the
+// invoice, its e-invoice states from their lifecycle, and its dunning and
+// disputes from their own records
+func InvoiceTimeline(invoiceRepo repository.InvoiceRepository, dunningRepo repository.DunningRepository, disputeRepo repository.DisputeRepository, tracker *lifecycle.Tracker) timeline.Compose {
+	return func(ctx context.Context, doc timeline.Document) ([]timeline.Event, error) {
+		invoice, err := invoiceRepo.GetByID(ctx, doc.ID)
+		if err != nil || invoice.TenantID != doc.TenantID {
+			return nil, timeline.ErrDocumentNotFound
+		}
+
+		events := []timeline.Event{
+			composed(doc, invoice.ID, timeline.EventCreated, fmt.Sprintf("Invoice %s created for %s", invoice.InvoiceNumber, invoice.TotalAmount.StringFixed(2)), &invoice.CreatedBy, invoice.CreatedAt),
+		}
+		for _, payment := range invoice.Payments {
+			events = append(events, composed(doc, payment.ID, timeline.EventPaymentRecorded,
+				fmt.Sprintf("Payment %s of %s received by %s", payment.PaymentNumber, payment.Amount.StringFixed(2), payment.PaymentMethod),
+				&payment.CreatedBy, payment.CreatedAt))
+		}
+
+		if tracker != nil {
+			transitions, err := tracker.History(ctx, doc.TenantID, LifecycleEInvoice, doc.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, transition := range transitions {
+				summary := "E-invoice " + transition.To
+				if transition.Note != "" {
+					summary += ": " + transition.Note
+				}
+				event := composed(doc, transition.ID, einvoiceEvents[transition.To], summary, transition.ActorID, transition.At)
+				if transition.ActorID == nil {
+					event.Source = timeline.SourceIntegration
+				}
+				events = append(events, event)
+			}
+		}
+
+		dunning, err := dunningRepo.ListEvents(ctx, doc.TenantID, doc.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range dunning {
+			event := composed(doc, step.ID, timeline.EventReminderSent,
+				fmt.Sprintf("Payment %s sent to %s, %d days overdue", step.Action, step.Recipient, step.DaysOverdue), nil, step.CreatedAt)
+			if step.Action == models.DunningActionLateFee {
+				event.Type = timeline.EventLateFeeCharged
+				event.Summary = fmt.Sprintf("Late fee of %s charged, %d days overdue", step.Amount.StringFixed(2), step.DaysOverdue)
+			}
+			events = append(events, event)
+		}
+
+		disputes, err := disputeRepo.List(ctx, doc.TenantID, repository.DisputeFilters{InvoiceID: doc.ID})
+		if err != nil {
+			return nil, err
+		}
+		for _, dispute := range disputes {
+			dispute, err := disputeRepo.GetByID(ctx, doc.TenantID, dispute.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, change := range dispute.History {
+				summary := fmt.Sprintf("Dispute of %s %s", change.Amount.StringFixed(2), change.Action)
+				if change.Note != "" {
+					summary += ": " + change.Note
+				}
+				events = append(events, composed(doc, change.ID, timeline.EventDisputed, summary, &change.UserID, change.CreatedAt))
+			}
+		}
+
+		return events, nil
+	}
+}
+
+// BillTimeline composes a bill's creation, approval and payments from the
+// bill
+func BillTimeline(billRepo repository.BillRepository) timeline.Compose {
+	return func(ctx context.Context, doc timeline.Document) ([]timeline.Event, error) {
+		bill, err := billRepo.GetByID(ctx, doc.ID)
+		if err != nil || bill.TenantID != doc.TenantID {
+			return nil, timeline.ErrDocumentNotFound
+		}
+
+		events := []timeline.Event{
+			composed(doc, bill.ID, timeline.EventCreated, fmt.Sprintf("Bill %s created for %s", bill.BillNumber, bill.TotalAmount.StringFixed(2)), &bill.CreatedBy, bill.CreatedAt),
+		}
+		if bill.ApprovedAt != nil {
+			events = append(events, composed(doc, bill.ID, timeline.EventApproved, "Bill approved for payment", bill.ApprovedBy, *bill.ApprovedAt))
+		}
+		for _, payment := range bill.Payments {
+			summary := fmt.Sprintf("Payment %s of %s made by %s", payment.PaymentNumber, payment.Amount.StringFixed(2), payment.PaymentMethod)
+			if payment.TDSAmount.IsPositive() {
+				summary += fmt.Sprintf(", %s TDS withheld", payment.TDSAmount.StringFixed(2))
+			}
+			events = append(events, composed(doc, payment.ID, timeline.EventPaymentRecorded, summary, &payment.CreatedBy, payment.CreatedAt))
+		}
+
+		return events, nil
+	}
+}
+
+// composed builds an event of the timeline from the record it comes from,
+// by a user when actorID is set and by the service otherwise
+func composed(doc timeline.Document, id uuid.UUID, eventType, summary string, actorID *uuid.UUID, at time.Time) timeline.Event {
+	event := timeline.Event{
+		ID:           id,
+		TenantID:     doc.TenantID,
+		DocumentType: doc.Type,
+		DocumentID:   doc.ID,
+		Type:         eventType,
+		Summary:      summary,
+		Source:       timeline.SourceSystem,
+		At:           at,
+	}
+	if actorID != nil && *actorID != uuid.Nil {
+		event.ActorID = actorID
+		event.Source = timeline.SourceUser
+	}
+	return event
+}
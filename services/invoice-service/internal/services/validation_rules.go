@@ -0,0 +1,53 @@
+package services
+
+import (
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+// invoiceRuleDocument is an invoice in the form the tenant's validation
+// rules check. Amount thresholds are in rupees.
+func invoiceRuleDocument(invoice *models.Invoice) validation.Document {
+	doc := validation.Document{
+		Type:   validation.DocumentInvoice,
+		Amount: invoice.TotalAmount.InexactFloat64(),
+		Fields: map[string]string{
+			"po_number":        invoice.PONumber,
+			"customer_gstin":   invoice.CustomerGSTIN,
+			"customer_email":   invoice.CustomerEmail,
+			"customer_phone":   invoice.CustomerPhone,
+			"customer_address": invoice.CustomerAddress,
+			"notes":            invoice.Notes,
+			"terms":            invoice.Terms,
+		},
+	}
+	for _, item := range invoice.Items {
+		doc.Lines = append(doc.Lines, map[string]string{
+			"hsn_code": item.HSNCode,
+			"unit":     item.Unit,
+		})
+	}
+	return doc
+}
+
+// billRuleDocument is a bill in the form the tenant's validation rules
+// check
+func billRuleDocument(bill *models.Bill) validation.Document {
+	doc := validation.Document{
+		Type:   validation.DocumentBill,
+		Amount: bill.TotalAmount.InexactFloat64(),
+		Fields: map[string]string{
+			"vendor_bill_no":        bill.VendorBillNo,
+			"vendor_gstin":          bill.VendorGSTIN,
+			"purchase_order_number": bill.PurchaseOrderNumber,
+			"notes":                 bill.Notes,
+		},
+	}
+	for _, item := range bill.Items {
+		doc.Lines = append(doc.Lines, map[string]string{
+			"hsn_code":         item.HSNCode,
+			"expense_category": item.ExpenseCategory,
+		})
+	}
+	return doc
+}
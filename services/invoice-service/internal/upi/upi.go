@@ -0,0 +1,168 @@
+// Package upi builds UPI payment requests for invoices: the upi://pay intent
+// any UPI app opens, and the QR code customers scan to pay it
+package upi
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/invoice-service/internal/models"
+)
+
+var (
+	ErrNoUPIID    = errors.New("the business has no UPI ID set up")
+	ErrInvalidVPA = errors.New("the business's UPI ID is not valid")
+	ErrNotPayable = errors.New("invoice has no rupee balance to collect")
+)
+
+// The longest reference and note UPI apps accept
+const (
+	maxReferenceLength = 35
+	maxNoteLength      = 80
+)
+
+// quietZone is the blank border, in modules, scanners need around a QR code
+const quietZone = 4
+
+var vpaRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{2,256}@[A-Za-z][A-Za-z0-9]{1,63}$`)
+
+// Payment is a request to pay an amount in rupees to a UPI ID
+type Payment struct {
+	PayeeVPA  string          `json:"upi_id"`
+	PayeeName string          `json:"payee_name"`
+	Amount    decimal.Decimal `json:"amount"`
+	// Reference is carried into the payment's narration on both sides'
+	// bank statements, so the receipt can be matched back to the invoice
+	Reference string `json:"reference"`
+	Note      string `json:"note"`
+}
+
+// ForInvoice requests the invoice's collectible balance, referenced by its
+// number. Invoices in other currencies, cancelled or paid ones cannot be
+// paid by UPI.
+func ForInvoice(invoice *models.Invoice, payee *clients.Tenant) (Payment, error) {
+	if payee == nil || payee.UPIID == nil || strings.TrimSpace(*payee.UPIID) == "" {
+		return Payment{}, ErrNoUPIID
+	}
+	vpa := strings.TrimSpace(*payee.UPIID)
+	if !vpaRegex.MatchString(vpa) {
+		return Payment{}, ErrInvalidVPA
+	}
+
+	amount := invoice.CollectibleAmount()
+	if invoice.IsForeignCurrency() || !amount.IsPositive() ||
+		invoice.Status == models.InvoiceStatusCancelled || invoice.Status == models.InvoiceStatusPaid {
+		return Payment{}, ErrNotPayable
+	}
+
+	name := payee.LegalName
+	if name == "" {
+		name = payee.Name
+	}
+	return Payment{
+		PayeeVPA:  vpa,
+		PayeeName: name,
+		Amount:    amount.Round(2),
+		Reference: truncate(invoice.InvoiceNumber, maxReferenceLength),
+		Note:      truncate("Payment for invoice "+invoice.InvoiceNumber, maxNoteLength),
+	}, nil
+}
+
+// Intent returns the payment's upi://pay URI. Spaces are written as %20
+// and the @ of the UPI ID left as is, which every UPI app reads.
+func (p Payment) Intent() string {
+	params := []struct{ key, value string }{
+		{"pa", p.PayeeVPA},
+		{"pn", p.PayeeName},
+		{"am", p.Amount.StringFixed(2)},
+		{"cu", "INR"},
+		{"tr", p.Reference},
+		{"tn", p.Note},
+	}
+
+	var parts []string
+	for _, param := range params {
+		if param.value != "" {
+			parts = append(parts, param.key+"="+escape(param.value))
+		}
+	}
+	return "upi://pay?" + strings.Join(parts, "&")
+}
+
+// QR is a QR code's modules, true where dark, row by row
+type QR [][]bool
+
+// QR encodes the payment's intent as a QR code
+func (p Payment) QR() (QR, error) {
+	code, err := qr.Encode(p.Intent(), qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := code.Bounds()
+	modules := make(QR, bounds.Dy())
+	for y := range modules {
+		modules[y] = make([]bool, bounds.Dx())
+		for x := range modules[y] {
+			r, _, _, _ := code.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			modules[y][x] = r == 0
+		}
+	}
+	return modules, nil
+}
+
+// Size returns the number of modules along each side
+func (q QR) Size() int {
+	return len(q)
+}
+
+// PNG draws the code moduleSize pixels to a module, inside its quiet zone
+func (q QR) PNG(moduleSize int) ([]byte, error) {
+	if moduleSize < 1 {
+		moduleSize = 1
+	}
+	side := (q.Size() + 2*quietZone) * moduleSize
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y, row := range q {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < moduleSize; dy++ {
+				for dx := 0; dx < moduleSize; dx++ {
+					img.SetGray((x+quietZone)*moduleSize+dx, (y+quietZone)*moduleSize+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func escape(value string) string {
+	value = strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+	return strings.ReplaceAll(value, "%40", "@")
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length]
+}
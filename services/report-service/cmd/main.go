@@ -10,10 +10,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/config"
 	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/handlers"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/services"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/i18n"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
 )
 
@@ -39,6 +43,15 @@ func main() {
 		MaxIdleConns:    cfg.Database.MaxIdleConns,
 		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
 		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -46,45 +59,98 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Initialize services
-	reportService := services.NewReportService(db)
+	// Group consolidation and aging snapshots keep their own tables
+	// alongside the core schema
+	if err := db.AutoMigrate(
+		&models.GroupAccount{},
+		&models.GroupAccountMapping{},
+		&models.AgingSnapshot{},
+		&jobs.Job{},
+	); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Initialize services. Reports read from the replica when one is
+	// configured; group setup stays on the primary so edits read back at once.
+	readSource := services.NewReadSource(db, cfg.ReplicaMaxLag, cfg.ReplicaLagCheckInterval)
+	reportService := services.NewReportService(readSource)
+	consolidationService := services.NewConsolidationService(database.UsePrimary(db), readSource)
+	agingSnapshotService := services.NewAgingSnapshotService(database.UsePrimary(db), reportService)
+
+	// Who sees which reports, and which figures are masked, follows the
+	// caller's role permissions in the tenant service
+	reportAccess := services.NewReportAccessService(
+		clients.NewTenantClient(cfg.Network.TenantServiceURL),
+		services.DefaultReportPolicies(),
+		cfg.ReportPermissionCacheTTL,
+	)
+
+	// Aging is snapshotted weekly, queued once across all instances
+	jobQueue := jobs.NewQueue(db, jobs.Config{})
+	jobQueue.Register(services.JobSnapshotAging, func(ctx context.Context, job *jobs.Job) error {
+		return agingSnapshotService.SnapshotAll(ctx)
+	}, jobs.Options{MaxAttempts: 3})
+	jobQueue.Every(services.JobSnapshotAging, 7*24*time.Hour)
+	jobQueue.Start(context.Background())
 
 	// Initialize handlers
 	reportHandler := handlers.NewReportHandler(reportService)
+	consolidationHandler := handlers.NewConsolidationHandler(consolidationService)
+	agingHandler := handlers.NewAgingHandler(agingSnapshotService)
 	healthHandler := handlers.NewHealthHandler(db)
 
 	// Setup router
 	router := gin.New()
 
-	// Allowed CORS origins
-	allowedOrigins := []string{
-		"https://app.bookkeep.in",
-		"https://www.bookkeep.in",
-		"https://bookkeep.in",
-	}
-	if !cfg.IsProduction() {
-		allowedOrigins = append(allowedOrigins,
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"exp://localhost:19000",
-		)
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(router, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// Apply middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.CORSMiddleware(allowedOrigins))
+	router.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
+	router.Use(i18n.Middleware(i18n.DefaultLanguage))
 
 	// Health endpoints (no auth required)
 	router.GET("/health", healthHandler.Health)
 	router.GET("/ready", healthHandler.Ready)
+	router.GET("/metrics", gin.WrapH(database.MetricsHandler(db)))
+
+	// Tenants' IP and country restrictions, read from the tenant service
+	networkPolicies := middleware.NewNetworkPolicyClient(cfg.Network.TenantServiceURL, cfg.Network.PolicyCacheTTL)
+
+	// Bulk exports need the data:export permission and are recorded in the
+	// tenant's audit log
+	exportGuard := middleware.NewExportGuard(middleware.NewExportAuditClient(cfg.Network.TenantServiceURL))
+
+	// Reports auditors may read: those covering the dates their grant's
+	// year is held to. The dashboard, aging and sales comparisons show
+	// figures from outside it.
+	auditorRoutes := []string{
+		"/api/v1/reports/profit-loss",
+		"/api/v1/reports/balance-sheet",
+		"/api/v1/reports/trial-balance",
+		"/api/v1/reports/gst-summary",
+		"/api/v1/reports/cash-flow",
+		"/api/v1/reports/revenue-breakdown",
+		"/api/v1/reports/tags",
+	}
 
 	// Protected endpoints
 	jwtConfig := middleware.JWTConfig{
-		Secret:    cfg.JWT.Secret,
-		Issuer:    cfg.JWT.Issuer,
-		SkipPaths: []string{"/health", "/ready"},
+		Secret:          cfg.JWT.Secret,
+		Secrets:         cfg.JWTSecrets,
+		RefreshSecrets:  cfg.RefreshJWTSecrets,
+		Issuer:          cfg.JWT.Issuer,
+		SkipPaths:       []string{"/health", "/ready", "/metrics"},
+		NetworkPolicies: networkPolicies,
+		CountryHeader:   cfg.Network.CountryHeader,
+		AuditorRoutes:   auditorRoutes,
+		AuditorGrants:   middleware.NewAuditorGrantClient(cfg.Network.AuthServiceURL, cfg.Network.PolicyCacheTTL, middleware.NewServiceCredentials(cfg.App.Name, cfg.JWT.Issuer, cfg.JWTSecret)),
 	}
 
 	api := router.Group("/api/v1")
@@ -93,14 +159,34 @@ func main() {
 	{
 		reports := api.Group("/reports")
 		{
-			reports.GET("/dashboard", reportHandler.GetDashboard)
-			reports.GET("/profit-loss", reportHandler.GetProfitLoss)
-			reports.GET("/balance-sheet", reportHandler.GetBalanceSheet)
-			reports.GET("/trial-balance", reportHandler.GetTrialBalance)
-			reports.GET("/gst-summary", reportHandler.GetGSTSummary)
-			reports.GET("/receivables-aging", reportHandler.GetReceivablesAging)
-			reports.GET("/payables-aging", reportHandler.GetPayablesAging)
-			reports.GET("/cash-flow", reportHandler.GetCashFlow)
+			reports.GET("/dashboard", handlers.RequireReport(reportAccess, services.ReportDashboard), reportHandler.GetDashboard)
+			reports.GET("/profit-loss", handlers.RequireReport(reportAccess, services.ReportProfitLoss), reportHandler.GetProfitLoss)
+			reports.GET("/balance-sheet", handlers.RequireReport(reportAccess, services.ReportBalanceSheet), reportHandler.GetBalanceSheet)
+			reports.GET("/trial-balance", handlers.RequireReport(reportAccess, services.ReportTrialBalance), reportHandler.GetTrialBalance)
+			reports.GET("/gst-summary", handlers.RequireReport(reportAccess, services.ReportGSTSummary), reportHandler.GetGSTSummary)
+			reports.GET("/receivables-aging", handlers.RequireReport(reportAccess, services.ReportReceivablesAging), reportHandler.GetReceivablesAging)
+			reports.GET("/payables-aging", handlers.RequireReport(reportAccess, services.ReportPayablesAging), reportHandler.GetPayablesAging)
+			reports.GET("/aging-trend", handlers.RequireReport(reportAccess, services.ReportAgingTrend), agingHandler.GetTrend)
+			reports.GET("/cash-flow", handlers.RequireReport(reportAccess, services.ReportCashFlow), reportHandler.GetCashFlow)
+			reports.GET("/revenue-breakdown", handlers.RequireReport(reportAccess, services.ReportRevenueBreakdown), reportHandler.GetRevenueBreakdown)
+			reports.GET("/tags", handlers.RequireReport(reportAccess, services.ReportTags), reportHandler.GetTagReport)
+			reports.GET("/sales/top-customers", handlers.RequireReport(reportAccess, services.ReportTopCustomers), exportGuard.ExportWhen("sales_by_customer", middleware.FormatNotJSON), reportHandler.GetTopCustomers)
+			reports.GET("/sales/top-products", handlers.RequireReport(reportAccess, services.ReportTopProducts), exportGuard.ExportWhen("sales_by_product", middleware.FormatNotJSON), reportHandler.GetTopProducts)
+			reports.GET("/sales/by-state", handlers.RequireReport(reportAccess, services.ReportSalesByState), exportGuard.ExportWhen("sales_by_state", middleware.FormatNotJSON), reportHandler.GetSalesByState)
+		}
+
+		// Group consolidation (requesting tenant must be the group parent)
+		group := api.Group("/group")
+		{
+			requireGroup := handlers.RequireReport(reportAccess, services.ReportGroup)
+			group.GET("", requireGroup, consolidationHandler.GetGroup)
+			group.GET("/accounts", requireGroup, consolidationHandler.ListGroupAccounts)
+			group.POST("/accounts", requireGroup, consolidationHandler.CreateGroupAccount)
+			group.DELETE("/accounts/:id", requireGroup, consolidationHandler.DeleteGroupAccount)
+			group.GET("/mappings", requireGroup, consolidationHandler.ListMappings)
+			group.PUT("/mappings", requireGroup, consolidationHandler.UpdateMappings)
+			group.GET("/consolidated/profit-loss", handlers.RequireReport(reportAccess, services.ReportConsolidatedProfitLoss), consolidationHandler.GetConsolidatedProfitLoss)
+			group.GET("/consolidated/balance-sheet", handlers.RequireReport(reportAccess, services.ReportConsolidatedBalanceSheet), consolidationHandler.GetConsolidatedBalanceSheet)
 		}
 	}
 
@@ -108,9 +194,9 @@ func main() {
 	srv := &http.Server{
 		Addr:         cfg.GetServerAddress(),
 		Handler:      router,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Start server in goroutine
@@ -129,13 +215,16 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Let running jobs finish
+	jobQueue.Stop()
+
 	// Close database connection
 	if err := database.Close(db); err != nil {
 		log.Printf("Error closing database: %v", err)
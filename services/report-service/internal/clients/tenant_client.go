@@ -0,0 +1,41 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TenantClient reads from the tenant service
+type TenantClient interface {
+	// GetMyPermissions returns the permissions of the caller identified by
+	// authorization in the tenant, from their role there
+	GetMyPermissions(ctx context.Context, authorization, tenantID string) ([]string, error)
+}
+
+type tenantClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTenantClient creates a new tenant service client
+func NewTenantClient(baseURL string) TenantClient {
+	return &tenantClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *tenantClient) GetMyPermissions(ctx context.Context, authorization, tenantID string) ([]string, error) {
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+
+	var resp struct {
+		Data []string `json:"data"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.baseURL+"/api/v1/tenants/"+tenantID+"/permissions/me", header, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
@@ -1,25 +1,54 @@
 package config
 
 import (
+	"time"
+
 	sharedConfig "github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
 )
 
 // Config holds report service configuration
 type Config struct {
 	*sharedConfig.Config
+
+	// ReplicaMaxLag is how far the read replica may trail the primary before
+	// reports are read from the primary instead
+	ReplicaMaxLag time.Duration
+
+	// ReplicaLagCheckInterval is how often the replica lag is probed
+	ReplicaLagCheckInterval time.Duration
+
+	// ReportPermissionCacheTTL is how long a user's report permissions are
+	// cached, i.e. how long role changes take to apply to reports
+	ReportPermissionCacheTTL time.Duration
 }
 
 // Load loads report service configuration
 func Load() (*Config, error) {
-	cfg, err := sharedConfig.Load("report-service")
+	// Reports are read from the core database
+	cfg, err := sharedConfig.LoadWithDefaults("report-service", sharedConfig.Defaults{
+		DatabaseName: "bookkeep_core",
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Override default database name - connect to core database for reports
-	if cfg.Database.DBName == "report-service_db" {
-		cfg.Database.DBName = "bookkeep_core"
+	env := sharedConfig.NewEnv("report-service")
+	reportCfg := &Config{
+		Config:                   cfg,
+		ReplicaMaxLag:            env.Duration("REPORT_REPLICA_MAX_LAG", 30*time.Second),
+		ReplicaLagCheckInterval:  env.Duration("REPORT_REPLICA_LAG_CHECK_INTERVAL", 10*time.Second),
+		ReportPermissionCacheTTL: env.Duration("REPORT_PERMISSION_CACHE_TTL", time.Minute),
 	}
-
-	return &Config{Config: cfg}, nil
+	if reportCfg.ReplicaMaxLag <= 0 || reportCfg.ReplicaLagCheckInterval <= 0 {
+		env.Fail("REPORT_REPLICA_MAX_LAG and REPORT_REPLICA_LAG_CHECK_INTERVAL must be positive")
+	}
+	if reportCfg.ReportPermissionCacheTTL < 0 {
+		env.Fail("REPORT_PERMISSION_CACHE_TTL must not be negative")
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
+	return reportCfg, nil
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/services"
+)
+
+// AgingHandler handles the aging trend endpoints
+type AgingHandler struct {
+	agingSnapshotService services.AgingSnapshotService
+}
+
+// NewAgingHandler creates a new aging handler
+func NewAgingHandler(agingSnapshotService services.AgingSnapshotService) *AgingHandler {
+	return &AgingHandler{agingSnapshotService: agingSnapshotService}
+}
+
+// GetTrend returns overdue receivables or payables by bucket over the last
+// weeks (?ledger=receivables|payables&weeks=12)
+func (h *AgingHandler) GetTrend(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	ledger := c.DefaultQuery("ledger", models.AgingLedgerReceivables)
+
+	var weeks int
+	if weeksStr := c.Query("weeks"); weeksStr != "" {
+		if weeks, err = strconv.Atoi(weeksStr); err != nil || weeks <= 0 {
+			response.BadRequest(c, "Invalid weeks", nil)
+			return
+		}
+	}
+
+	report, err := h.agingSnapshotService.GetTrend(c.Request.Context(), tenantID, ledger, weeks)
+	if err != nil {
+		if err == services.ErrInvalidAgingLedger {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to generate aging trend")
+		return
+	}
+
+	respondReport(c, report)
+}
+
+func (h *AgingHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/services"
+)
+
+// ConsolidationHandler handles group chart of accounts and consolidated
+// report endpoints
+type ConsolidationHandler struct {
+	consolidationService services.ConsolidationService
+}
+
+// NewConsolidationHandler creates a new consolidation handler
+func NewConsolidationHandler(consolidationService services.ConsolidationService) *ConsolidationHandler {
+	return &ConsolidationHandler{consolidationService: consolidationService}
+}
+
+// GetGroup returns the group the tenant is parent of, with its members
+func (h *ConsolidationHandler) GetGroup(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	group, err := h.consolidationService.GetGroup(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, group)
+}
+
+// ListGroupAccounts lists the group chart of accounts
+func (h *ConsolidationHandler) ListGroupAccounts(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	accounts, err := h.consolidationService.ListGroupAccounts(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, accounts)
+}
+
+// CreateGroupAccount adds an account to the group chart of accounts
+func (h *ConsolidationHandler) CreateGroupAccount(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req services.CreateGroupAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	account, err := h.consolidationService.CreateGroupAccount(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, account)
+}
+
+// DeleteGroupAccount removes a group account and its mappings
+func (h *ConsolidationHandler) DeleteGroupAccount(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid group account ID", nil)
+		return
+	}
+
+	if err := h.consolidationService.DeleteGroupAccount(c.Request.Context(), tenantID, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Group account deleted"})
+}
+
+// ListMappings lists member tenant accounts with their group account
+// mapping. Pass unmapped=true to list only accounts still to be mapped.
+func (h *ConsolidationHandler) ListMappings(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	accounts, err := h.consolidationService.ListMemberAccounts(c.Request.Context(), tenantID, c.Query("unmapped") == "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, accounts)
+}
+
+// UpdateMappings maps member accounts onto group accounts
+func (h *ConsolidationHandler) UpdateMappings(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	var req services.UpdateGroupMappingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	accounts, err := h.consolidationService.UpdateMappings(c.Request.Context(), tenantID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, accounts)
+}
+
+// GetConsolidatedProfitLoss handles the consolidated P&L request
+func (h *ConsolidationHandler) GetConsolidatedProfitLoss(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	// Parse dates
+	fromDateStr := c.Query("from_date")
+	toDateStr := c.Query("to_date")
+
+	var fromDate, toDate time.Time
+
+	if fromDateStr == "" {
+		// Default to current financial year (April 1)
+		now := time.Now()
+		year := now.Year()
+		if now.Month() < 4 {
+			year--
+		}
+		fromDate = time.Date(year, 4, 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		fromDate, err = time.Parse("2006-01-02", fromDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid from_date format", nil)
+			return
+		}
+	}
+
+	if toDateStr == "" {
+		toDate = time.Now()
+	} else {
+		toDate, err = time.Parse("2006-01-02", toDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid to_date format", nil)
+			return
+		}
+	}
+
+	if toDate.Before(fromDate) {
+		response.BadRequest(c, "to_date must not be before from_date", nil)
+		return
+	}
+
+	report, err := h.consolidationService.GetConsolidatedProfitLoss(c.Request.Context(), tenantID, fromDate, toDate)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	respondReport(c, report)
+}
+
+// GetConsolidatedBalanceSheet handles the consolidated balance sheet request
+func (h *ConsolidationHandler) GetConsolidatedBalanceSheet(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	asOfDateStr := c.Query("as_of")
+	var asOfDate time.Time
+
+	if asOfDateStr == "" {
+		asOfDate = time.Now()
+	} else {
+		asOfDate, err = time.Parse("2006-01-02", asOfDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid as_of date format", nil)
+			return
+		}
+	}
+
+	report, err := h.consolidationService.GetConsolidatedBalanceSheet(c.Request.Context(), tenantID, asOfDate)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	respondReport(c, report)
+}
+
+func (h *ConsolidationHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrGroupNotFound, services.ErrGroupAccountNotFound:
+		response.NotFound(c, err.Error())
+	case services.ErrGroupAccountExists:
+		response.Conflict(c, err.Error())
+	case services.ErrMemberAccountNotFound, services.ErrMappingTypeMismatch:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, "Failed to process group request")
+	}
+}
+
+func (h *ConsolidationHandler) getTenantIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	tenantIDStr, exists := c.Get("tenant_id")
+	if !exists {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(tenantIDStr.(string))
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/services"
+)
+
+const reportAccessKey = "report_access"
+
+// RequireReport allows the request only if the caller's role in the tenant
+// grants the report, and records what of it is masked for them
+func RequireReport(reportAccess services.ReportAccessService, report string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Auditors are not members of the tenant; their token grants the
+		// full reports of the year they audit
+		if middleware.IsAuditor(c) {
+			c.Set(reportAccessKey, &services.ReportAccess{})
+			c.Next()
+			return
+		}
+
+		tenantID, _ := c.Get("tenant_id")
+		userID, _ := c.Get("user_id")
+		tenantIDStr, _ := tenantID.(string)
+		userIDStr, _ := userID.(string)
+
+		access, err := reportAccess.Check(c.Request.Context(), c.GetHeader("Authorization"), tenantIDStr, userIDStr, report)
+		if err != nil {
+			if err == services.ErrReportForbidden {
+				response.Forbidden(c, err.Error())
+			} else {
+				response.ServiceUnavailable(c, "Could not check report permissions")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set(reportAccessKey, access)
+		c.Next()
+	}
+}
+
+// respondReport sends the report, less the fields masked for the caller.
+// A masked report lists the fields left out under masked_fields.
+func respondReport(c *gin.Context, report interface{}) {
+	value, _ := c.Get(reportAccessKey)
+	access, _ := value.(*services.ReportAccess)
+	if access == nil || len(access.MaskedFields) == 0 {
+		response.Success(c, report)
+		return
+	}
+
+	masked, err := services.MaskFields(report, access.MaskedFields)
+	if err != nil {
+		response.InternalError(c, "Failed to prepare report")
+		return
+	}
+	masked["masked_fields"] = access.MaskedFields
+	response.Success(c, masked)
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/services"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
 	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/tags"
 )
 
 // ReportHandler handles report-related endpoints
@@ -34,7 +36,7 @@ func (h *ReportHandler) GetDashboard(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, summary)
+	respondReport(c, summary)
 }
 
 // GetProfitLoss handles P&L report request
@@ -77,13 +79,19 @@ func (h *ReportHandler) GetProfitLoss(c *gin.Context) {
 		}
 	}
 
-	report, err := h.reportService.GetProfitLoss(c.Request.Context(), tenantID, fromDate, toDate)
+	includeAdjustments, err := strconv.ParseBool(c.DefaultQuery("include_adjustments", "true"))
+	if err != nil {
+		response.BadRequest(c, "Invalid include_adjustments value", nil)
+		return
+	}
+
+	report, err := h.reportService.GetProfitLoss(c.Request.Context(), tenantID, fromDate, toDate, includeAdjustments)
 	if err != nil {
 		response.InternalError(c, "Failed to generate P&L report")
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
 }
 
 // GetBalanceSheet handles balance sheet report request
@@ -113,7 +121,7 @@ func (h *ReportHandler) GetBalanceSheet(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
 }
 
 // GetGSTSummary handles GST summary report request
@@ -140,13 +148,18 @@ func (h *ReportHandler) GetGSTSummary(c *gin.Context) {
 		}
 	}
 
+	if !middleware.WithinAuditorPeriod(c, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)) {
+		response.Forbidden(c, "Month is outside the audited financial year")
+		return
+	}
+
 	report, err := h.reportService.GetGSTSummary(c.Request.Context(), tenantID, month, year)
 	if err != nil {
 		response.InternalError(c, "Failed to generate GST summary")
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
 }
 
 // GetReceivablesAging handles receivables aging report request
@@ -163,7 +176,7 @@ func (h *ReportHandler) GetReceivablesAging(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
 }
 
 // GetCashFlow handles cash flow report request
@@ -205,7 +218,7 @@ func (h *ReportHandler) GetCashFlow(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
 }
 
 // GetPayablesAging handles payables aging report request (AP Aging)
@@ -222,7 +235,7 @@ func (h *ReportHandler) GetPayablesAging(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
 }
 
 // GetTrialBalance handles trial balance report request
@@ -246,13 +259,136 @@ func (h *ReportHandler) GetTrialBalance(c *gin.Context) {
 		}
 	}
 
-	report, err := h.reportService.GetTrialBalance(c.Request.Context(), tenantID, asOfDate)
+	includeAdjustments, err := strconv.ParseBool(c.DefaultQuery("include_adjustments", "true"))
+	if err != nil {
+		response.BadRequest(c, "Invalid include_adjustments value", nil)
+		return
+	}
+
+	report, err := h.reportService.GetTrialBalance(c.Request.Context(), tenantID, asOfDate, includeAdjustments)
 	if err != nil {
 		response.InternalError(c, "Failed to generate trial balance report")
 		return
 	}
 
-	response.Success(c, report)
+	respondReport(c, report)
+}
+
+// GetRevenueBreakdown handles revenue breakdown report request
+func (h *ReportHandler) GetRevenueBreakdown(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	// Parse dates
+	fromDateStr := c.Query("from_date")
+	toDateStr := c.Query("to_date")
+
+	var fromDate, toDate time.Time
+
+	if fromDateStr == "" {
+		// Default to current financial year (April 1)
+		now := time.Now()
+		year := now.Year()
+		if now.Month() < 4 {
+			year--
+		}
+		fromDate = time.Date(year, 4, 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		fromDate, err = time.Parse("2006-01-02", fromDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid from_date format", nil)
+			return
+		}
+	}
+
+	if toDateStr == "" {
+		toDate = time.Now()
+	} else {
+		toDate, err = time.Parse("2006-01-02", toDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid to_date format", nil)
+			return
+		}
+	}
+
+	if toDate.Before(fromDate) {
+		response.BadRequest(c, "to_date must not be before from_date", nil)
+		return
+	}
+
+	report, err := h.reportService.GetRevenueBreakdown(c.Request.Context(), tenantID, fromDate, toDate)
+	if err != nil {
+		response.InternalError(c, "Failed to generate revenue breakdown report")
+		return
+	}
+
+	respondReport(c, report)
+}
+
+// GetTagReport handles the revenue and spend by tag report request
+func (h *ReportHandler) GetTagReport(c *gin.Context) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	// Parse dates
+	fromDateStr := c.Query("from_date")
+	toDateStr := c.Query("to_date")
+
+	var fromDate, toDate time.Time
+
+	if fromDateStr == "" {
+		// Default to current financial year (April 1)
+		now := time.Now()
+		year := now.Year()
+		if now.Month() < 4 {
+			year--
+		}
+		fromDate = time.Date(year, 4, 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		fromDate, err = time.Parse("2006-01-02", fromDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid from_date format", nil)
+			return
+		}
+	}
+
+	if toDateStr == "" {
+		toDate = time.Now()
+	} else {
+		toDate, err = time.Parse("2006-01-02", toDateStr)
+		if err != nil {
+			response.BadRequest(c, "Invalid to_date format", nil)
+			return
+		}
+	}
+
+	if toDate.Before(fromDate) {
+		response.BadRequest(c, "to_date must not be before from_date", nil)
+		return
+	}
+
+	var tag string
+	if tagStr := c.Query("tag"); tagStr != "" {
+		tag, err = tags.Normalize(tagStr)
+		if err != nil {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+
+	report, err := h.reportService.GetTagReport(c.Request.Context(), tenantID, fromDate, toDate, tag)
+	if err != nil {
+		response.InternalError(c, "Failed to generate tag report")
+		return
+	}
+
+	respondReport(c, report)
 }
 
 // Helper methods
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/services"
+)
+
+// GetTopCustomers handles the top customers request
+// (?from_date=&to_date=&compare=previous_period|previous_year&limit=10&format=json|csv)
+func (h *ReportHandler) GetTopCustomers(c *gin.Context) {
+	h.getSalesAnalytics(c, models.SalesByCustomer, 10)
+}
+
+// GetTopProducts handles the top products request, with the same
+// parameters as GetTopCustomers
+func (h *ReportHandler) GetTopProducts(c *gin.Context) {
+	h.getSalesAnalytics(c, models.SalesByProduct, 10)
+}
+
+// GetSalesByState handles the sales by place of supply request, with the
+// same parameters as GetTopCustomers. All states are listed by default.
+func (h *ReportHandler) GetSalesByState(c *gin.Context) {
+	h.getSalesAnalytics(c, models.SalesByState, 0)
+}
+
+func (h *ReportHandler) getSalesAnalytics(c *gin.Context, dimension string, defaultLimit int) {
+	tenantID, err := h.getTenantIDFromContext(c)
+	if err != nil {
+		response.BadRequest(c, "Tenant ID required", nil)
+		return
+	}
+
+	query := services.SalesAnalyticsQuery{
+		Dimension: dimension,
+		Compare:   c.DefaultQuery("compare", models.CompareSalesPreviousPeriod),
+		Limit:     defaultLimit,
+	}
+
+	// Default to the current financial year (April 1) to date
+	if fromDateStr := c.Query("from_date"); fromDateStr == "" {
+		now := time.Now()
+		year := now.Year()
+		if now.Month() < 4 {
+			year--
+		}
+		query.From = time.Date(year, 4, 1, 0, 0, 0, 0, time.UTC)
+	} else if query.From, err = time.Parse("2006-01-02", fromDateStr); err != nil {
+		response.BadRequest(c, "Invalid from_date format", nil)
+		return
+	}
+
+	if toDateStr := c.Query("to_date"); toDateStr == "" {
+		now := time.Now()
+		query.To = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	} else if query.To, err = time.Parse("2006-01-02", toDateStr); err != nil {
+		response.BadRequest(c, "Invalid to_date format", nil)
+		return
+	}
+
+	if query.To.Before(query.From) {
+		response.BadRequest(c, "to_date must not be before from_date", nil)
+		return
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if query.Limit, err = strconv.Atoi(limitStr); err != nil || query.Limit < 0 {
+			response.BadRequest(c, "Invalid limit", nil)
+			return
+		}
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.BadRequest(c, "format must be json or csv", nil)
+		return
+	}
+
+	report, err := h.reportService.GetSalesAnalytics(c.Request.Context(), tenantID, query)
+	if err != nil {
+		if err == services.ErrInvalidSalesCompare {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to generate sales analytics")
+		return
+	}
+
+	if format == "json" {
+		respondReport(c, report)
+		return
+	}
+
+	middleware.SetExportRows(c, len(report.Lines))
+	var buf bytes.Buffer
+	if err := services.WriteSalesAnalyticsCSV(&buf, report); err != nil {
+		response.InternalError(c, "Failed to export sales analytics")
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"sales-by-"+dimension+"-"+query.From.Format("2006-01-02")+"-"+query.To.Format("2006-01-02")+".csv\"")
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
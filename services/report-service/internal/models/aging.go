@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Aging ledgers that are snapshotted
+const (
+	AgingLedgerReceivables = "receivables"
+	AgingLedgerPayables    = "payables"
+)
+
+// AgingSnapshot is a tenant's receivables or payables aging as it stood on
+// SnapshotDate. Snapshots are taken weekly so the trend can be followed.
+type AgingSnapshot struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_aging_snapshot" json:"tenant_id"`
+	Ledger       string    `gorm:"size:20;not null;uniqueIndex:idx_aging_snapshot" json:"ledger"`
+	SnapshotDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_aging_snapshot" json:"snapshot_date"`
+
+	Current    float64 `gorm:"type:decimal(15,2);default:0" json:"current"`
+	Days1To30  float64 `gorm:"type:decimal(15,2);default:0" json:"1_30_days"`
+	Days31To60 float64 `gorm:"type:decimal(15,2);default:0" json:"31_60_days"`
+	Days61To90 float64 `gorm:"type:decimal(15,2);default:0" json:"61_90_days"`
+	Over90Days float64 `gorm:"type:decimal(15,2);default:0" json:"over_90_days"`
+	Total      float64 `gorm:"type:decimal(15,2);default:0" json:"total"`
+	Disputed   float64 `gorm:"type:decimal(15,2);default:0" json:"disputed"` // Receivables held by open disputes, not included in Total
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (AgingSnapshot) TableName() string {
+	return "aging_snapshots"
+}
+
+// BeforeCreate hook
+func (s *AgingSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// AgingTrendReport shows overdue receivables or payables week by week,
+// oldest first, so owners can see whether collections are improving
+type AgingTrendReport struct {
+	Ledger string           `json:"ledger"`
+	Weeks  []AgingTrendWeek `json:"weeks"`
+
+	// Overdue of the latest week less that of the first; negative means
+	// less is overdue than at the start of the trend
+	OverdueChange float64 `json:"overdue_change"`
+}
+
+// AgingTrendWeek is the aging of the last snapshot taken in a week
+type AgingTrendWeek struct {
+	WeekOf       time.Time `json:"week_of"` // Monday of the week
+	SnapshotDate time.Time `json:"snapshot_date"`
+	Days1To30    float64   `json:"1_30_days"`
+	Days31To60   float64   `json:"31_60_days"`
+	Days61To90   float64   `json:"61_90_days"`
+	Over90Days   float64   `json:"over_90_days"`
+	Overdue      float64   `json:"overdue"` // Sum of the overdue buckets
+	Current      float64   `json:"current"`
+	Total        float64   `json:"total"`
+	Change       float64   `json:"change"` // Overdue change since the previous week shown
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GroupAccount is an account in a tenant group's consolidated chart of
+// accounts. Member tenants keep their own charts; their accounts are mapped
+// onto group accounts for consolidation.
+type GroupAccount struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	GroupID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_group_account_code" json:"group_id"`
+	Code      string    `gorm:"size:20;not null;uniqueIndex:idx_group_account_code" json:"code"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	Type      string    `gorm:"size:20;not null" json:"type"` // asset, liability, equity, income, expense
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (GroupAccount) TableName() string {
+	return "group_accounts"
+}
+
+// BeforeCreate hook
+func (a *GroupAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// GroupAccountMapping maps a member tenant's account onto a group account
+type GroupAccountMapping struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	GroupID        uuid.UUID `gorm:"type:uuid;not null;index" json:"group_id"`
+	TenantID       uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	AccountID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"account_id"`
+	GroupAccountID uuid.UUID `gorm:"type:uuid;not null;index" json:"group_account_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (GroupAccountMapping) TableName() string {
+	return "group_account_mappings"
+}
+
+// BeforeCreate hook
+func (m *GroupAccountMapping) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// GroupInfo identifies the group and its member tenants in consolidated reports
+type GroupInfo struct {
+	ID      uuid.UUID     `json:"id"`
+	Name    string        `json:"name"`
+	Tenants []GroupTenant `json:"tenants"`
+}
+
+// GroupTenant is a member tenant of a group
+type GroupTenant struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Name     string    `json:"name"`
+	IsParent bool      `json:"is_parent"`
+}
+
+// GroupMemberAccount is a member tenant's account with its group mapping
+type GroupMemberAccount struct {
+	TenantID       uuid.UUID  `json:"tenant_id"`
+	TenantName     string     `json:"tenant_name"`
+	AccountID      uuid.UUID  `json:"account_id"`
+	AccountCode    string     `json:"account_code"`
+	AccountName    string     `json:"account_name"`
+	AccountType    string     `json:"account_type"`
+	GroupAccountID *uuid.UUID `json:"group_account_id"`
+}
+
+// ConsolidatedLine is a group account's balance across the group. Amounts
+// are signed by the account's nature: debits are positive for assets and
+// expenses, credits for liabilities, equity and income. Eliminated is the
+// part arising from transactions with other group tenants, and is excluded
+// from Consolidated.
+type ConsolidatedLine struct {
+	GroupAccountID *uuid.UUID            `json:"group_account_id"` // nil for unmapped accounts
+	Code           string                `json:"code"`
+	Name           string                `json:"name"`
+	ByTenant       map[uuid.UUID]float64 `json:"by_tenant"`
+	Total          float64               `json:"total"`
+	Eliminated     float64               `json:"eliminated"`
+	Consolidated   float64               `json:"consolidated"`
+}
+
+// ConsolidatedSection groups the lines of one account type
+type ConsolidatedSection struct {
+	Lines        []ConsolidatedLine `json:"lines"`
+	Total        float64            `json:"total"`
+	Eliminated   float64            `json:"eliminated"`
+	Consolidated float64            `json:"consolidated"`
+}
+
+// ConsolidatedProfitLoss is the group's consolidated P&L for a period
+type ConsolidatedProfitLoss struct {
+	Period    ReportPeriod         `json:"period"`
+	Group     GroupInfo            `json:"group"`
+	Income    ConsolidatedSection  `json:"income"`
+	Expenses  ConsolidatedSection  `json:"expenses"`
+	NetProfit float64              `json:"net_profit"`
+	Unmapped  []GroupMemberAccount `json:"unmapped_accounts"`
+	DataAsOf  time.Time            `json:"data_as_of"`
+}
+
+// ConsolidatedBalanceSheet is the group's consolidated balance sheet.
+// Difference is non-zero when inter-company balances were not tagged on
+// both sides, or when member books do not balance.
+type ConsolidatedBalanceSheet struct {
+	AsOfDate                  time.Time            `json:"as_of_date"`
+	Group                     GroupInfo            `json:"group"`
+	Assets                    ConsolidatedSection  `json:"assets"`
+	Liabilities               ConsolidatedSection  `json:"liabilities"`
+	Equity                    ConsolidatedSection  `json:"equity"`
+	RetainedEarnings          float64              `json:"retained_earnings"`
+	TotalAssets               float64              `json:"total_assets"`
+	TotalLiabilitiesAndEquity float64              `json:"total_liabilities_and_equity"`
+	Difference                float64              `json:"difference"`
+	Unmapped                  []GroupMemberAccount `json:"unmapped_accounts"`
+	DataAsOf                  time.Time            `json:"data_as_of"`
+}
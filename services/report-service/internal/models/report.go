@@ -14,6 +14,14 @@ type DashboardSummary struct {
 	CashPosition CashPositionSummary `json:"cash_position"`
 	RecentTransactions []TransactionSummary `json:"recent_transactions"`
 	OverdueInvoices []InvoiceSummary `json:"overdue_invoices"`
+
+	// CloseReadiness is how far last month's close has got; nil until its
+	// checklist is opened
+	CloseReadiness *CloseReadinessSummary `json:"close_readiness,omitempty"`
+
+	// DataAsOf is the point the underlying data is current as of. Reports
+	// read from the replica may trail the request time slightly.
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // TodaySummary represents today's transaction summary
@@ -46,6 +54,16 @@ type CashPositionSummary struct {
 	Total       float64 `json:"total"`
 }
 
+// CloseReadinessSummary represents the month-end close checklist progress
+type CloseReadinessSummary struct {
+	Period       string  `json:"period"`
+	Status       string  `json:"status"`
+	Score        float64 `json:"score"`
+	TasksTotal   int     `json:"tasks_total"`
+	TasksDone    int     `json:"tasks_done"`
+	TasksOverdue int     `json:"tasks_overdue"`
+}
+
 // TransactionSummary represents a transaction summary for dashboard
 type TransactionSummary struct {
 	ID              uuid.UUID `json:"id"`
@@ -76,6 +94,12 @@ type ProfitLossReport struct {
 	OperatingProfit float64       `json:"operating_profit"`
 	NetProfit     float64         `json:"net_profit"`
 	NetMargin     float64         `json:"net_margin_percent"`
+
+	// Whether adjustment journals (depreciation, provisions, accruals) are
+	// included, i.e. finalised rather than operational numbers
+	IncludesAdjustments bool `json:"includes_adjustments"`
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // ReportPeriod represents the period for a report
@@ -114,6 +138,8 @@ type BalanceSheet struct {
 	Assets      AssetsSection  `json:"assets"`
 	Liabilities LiabilitiesSection `json:"liabilities"`
 	Equity      EquitySection  `json:"equity"`
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // AssetsSection represents assets in balance sheet
@@ -158,6 +184,8 @@ type GSTSummary struct {
 	OutwardSupplies GSTSupplies       `json:"outward_supplies"`
 	InwardSupplies  GSTSupplies       `json:"inward_supplies"`
 	TaxLiability    GSTTaxLiability   `json:"tax_liability"`
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // GSTSupplies represents GST supplies (inward or outward)
@@ -178,10 +206,19 @@ type GSTTaxLiability struct {
 	Total float64 `json:"total"`
 }
 
-// ReceivablesAgingReport represents receivables aging report
+// ReceivablesAgingReport represents receivables aging report. Summary and
+// ByCustomer show the collectible balance; amounts held by open invoice
+// disputes are aged separately in Disputed. Credit notes already applied
+// are out of the invoices' balances; those not yet applied are shown in
+// UnappliedCredits.
 type ReceivablesAgingReport struct {
 	Summary    AgingSummary       `json:"summary"`
 	ByCustomer []CustomerAging    `json:"by_customer"`
+	Disputed   AgingSummary       `json:"disputed"`
+
+	UnappliedCredits float64 `json:"unapplied_credits"` // Not included in Summary
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // AgingSummary represents aging summary
@@ -204,6 +241,9 @@ type CustomerAging struct {
 	Days61To90   float64   `json:"61_90_days"`
 	Over90Days   float64   `json:"over_90_days"`
 	Total        float64   `json:"total"`
+	Disputed     float64   `json:"disputed"` // Not included in Total
+
+	UnappliedCredits float64 `json:"unapplied_credits"` // Approved credit notes' balances, not included in Total
 }
 
 // CashFlowReport represents cash flow report
@@ -215,6 +255,8 @@ type CashFlowReport struct {
 	FinancingActivities CashFlowSection `json:"financing_activities"`
 	NetCashFlow        float64      `json:"net_cash_flow"`
 	ClosingBalance     float64      `json:"closing_balance"`
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // CashFlowSection represents a section in cash flow
@@ -228,6 +270,8 @@ type CashFlowSection struct {
 type PayablesAgingReport struct {
 	Summary  AgingSummary    `json:"summary"`
 	ByVendor []VendorAging   `json:"by_vendor"`
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // VendorAging represents aging for a single vendor
@@ -248,6 +292,14 @@ type TrialBalanceReport struct {
 	Accounts   []TrialBalanceEntry `json:"accounts"`
 	TotalDebit  float64            `json:"total_debit"`
 	TotalCredit float64            `json:"total_credit"`
+
+	// The adjustment layer: adjustment journals' movements, whether or not
+	// they are included in the balances
+	IncludesAdjustments bool    `json:"includes_adjustments"`
+	AdjustmentDebit     float64 `json:"adjustment_debit"`
+	AdjustmentCredit    float64 `json:"adjustment_credit"`
+
+	DataAsOf time.Time `json:"data_as_of"`
 }
 
 // TrialBalanceEntry represents a single account entry in trial balance
@@ -258,4 +310,59 @@ type TrialBalanceEntry struct {
 	AccountType   string    `json:"account_type"`
 	DebitBalance  float64   `json:"debit_balance"`
 	CreditBalance float64   `json:"credit_balance"`
+
+	// Movements from adjustment journals up to the date
+	AdjustmentDebit  float64 `json:"adjustment_debit"`
+	AdjustmentCredit float64 `json:"adjustment_credit"`
+}
+
+// RevenueBreakdownReport splits invoiced revenue by product category, goods
+// vs services and customer segment. Amounts are the taxable value of invoice
+// lines, net of invoice-level discounts.
+type RevenueBreakdownReport struct {
+	Period     ReportPeriod           `json:"period"`
+	Total      float64                `json:"total"`
+	ByCategory []RevenueBreakdownLine `json:"by_category"`
+	ByType     []RevenueBreakdownLine `json:"by_type"`
+	BySegment  []RevenueBreakdownLine `json:"by_segment"`
+	Trend      []RevenueTrendMonth    `json:"trend"`
+
+	DataAsOf time.Time `json:"data_as_of"`
+}
+
+// TagReport totals tagged revenue and spend. A document with several tags
+// counts towards each of them, so the lines do not add up to the books.
+type TagReport struct {
+	Period ReportPeriod    `json:"period"`
+	Tags   []TagReportLine `json:"tags"`
+
+	DataAsOf time.Time `json:"data_as_of"`
+}
+
+// TagReportLine is the revenue and spend carrying one tag. Amounts exclude
+// GST: revenue is the taxable value of issued invoices plus sale
+// transactions, and spend is expense and purchase transactions.
+type TagReportLine struct {
+	Tag              string  `json:"tag"`
+	Revenue          float64 `json:"revenue"`
+	Spend            float64 `json:"spend"`
+	Net              float64 `json:"net"`
+	InvoiceCount     int64   `json:"invoice_count"`
+	TransactionCount int64   `json:"transaction_count"`
+}
+
+// RevenueBreakdownLine represents revenue for a single group
+type RevenueBreakdownLine struct {
+	Name    string  `json:"name"`
+	Amount  float64 `json:"amount"`
+	Percent float64 `json:"percent"`
+}
+
+// RevenueTrendMonth represents a month of the revenue breakdown trend
+type RevenueTrendMonth struct {
+	Month      string             `json:"month"` // YYYY-MM
+	Total      float64            `json:"total"`
+	ByCategory map[string]float64 `json:"by_category"`
+	ByType     map[string]float64 `json:"by_type"`
+	BySegment  map[string]float64 `json:"by_segment"`
 }
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Sales analytics dimensions
+const (
+	SalesByCustomer = "customer"
+	SalesByProduct  = "product"
+	SalesByState    = "state"
+)
+
+// Periods sales analytics compare against
+const (
+	CompareSalesPreviousPeriod = "previous_period" // The same number of days just before
+	CompareSalesPreviousYear   = "previous_year"   // The same dates a year earlier
+)
+
+// SalesAnalyticsReport ranks a period's sales by customer, product or
+// place of supply against a previous period. Amounts are taxable values:
+// issued invoices, net of invoice-level discounts, less credit notes.
+type SalesAnalyticsReport struct {
+	Dimension      string       `json:"dimension"`
+	Period         ReportPeriod `json:"period"`
+	PreviousPeriod ReportPeriod `json:"previous_period"`
+	Compare        string       `json:"compare"`
+
+	// Totals cover every customer, product or state, not only the lines
+	// listed
+	Total         float64 `json:"total"`
+	PreviousTotal float64 `json:"previous_total"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"change_percent"` // Zero when there were no previous sales
+
+	Lines []SalesAnalyticsLine `json:"lines"`
+
+	DataAsOf time.Time `json:"data_as_of"`
+}
+
+// SalesAnalyticsLine is the sales of one customer, product or place of
+// supply, ranked by amount
+type SalesAnalyticsLine struct {
+	Rank int    `json:"rank"`
+	Key  string `json:"key"` // Customer or product ID, or state code
+	Name string `json:"name"`
+	Code string `json:"code,omitempty"` // Customer GSTIN or product HSN/SAC code
+
+	InvoiceCount int64   `json:"invoice_count"`
+	Quantity     float64 `json:"quantity,omitempty"` // Products only, as invoiced
+	Invoiced     float64 `json:"invoiced"`
+	Credited     float64 `json:"credited"`
+	Amount       float64 `json:"amount"` // Invoiced less credited
+	Tax          float64 `json:"tax"`    // GST on the amount
+	Share        float64 `json:"share"`  // Percent of the total
+
+	PreviousAmount float64 `json:"previous_amount"`
+	PreviousRank   int     `json:"previous_rank,omitempty"` // Zero when there were no previous sales
+	Change         float64 `json:"change"`
+	ChangePercent  float64 `json:"change_percent"`
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobSnapshotAging is the queue job that snapshots every tenant's aging
+const JobSnapshotAging = "reports.aging_snapshot"
+
+const (
+	defaultAgingTrendWeeks = 12
+	maxAgingTrendWeeks     = 104
+)
+
+var ErrInvalidAgingLedger = errors.New("ledger must be receivables or payables")
+
+// AgingSnapshotService records receivables and payables aging over time and
+// reports how the overdue buckets moved
+type AgingSnapshotService interface {
+	// SnapshotAll records today's aging of every active tenant
+	SnapshotAll(ctx context.Context) error
+	Snapshot(ctx context.Context, tenantID uuid.UUID) error
+
+	// GetTrend returns the aging of the last weeks, taking the latest
+	// snapshot of each week
+	GetTrend(ctx context.Context, tenantID uuid.UUID, ledger string, weeks int) (*models.AgingTrendReport, error)
+}
+
+type agingSnapshotService struct {
+	db      *gorm.DB
+	reports ReportService
+}
+
+// NewAgingSnapshotService creates a new aging snapshot service. Snapshots
+// are kept in db; the aging itself comes from the report service.
+func NewAgingSnapshotService(db *gorm.DB, reports ReportService) AgingSnapshotService {
+	return &agingSnapshotService{db: db, reports: reports}
+}
+
+func (s *agingSnapshotService) SnapshotAll(ctx context.Context) error {
+	var tenantIDs []uuid.UUID
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT id FROM tenants WHERE status = 'active' AND deleted_at IS NULL
+	`).Scan(&tenantIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		if err := s.Snapshot(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *agingSnapshotService) Snapshot(ctx context.Context, tenantID uuid.UUID) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	receivables, err := s.reports.GetReceivablesAging(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	payables, err := s.reports.GetPayablesAging(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	snapshots := []models.AgingSnapshot{
+		agingSnapshot(tenantID, models.AgingLedgerReceivables, today, receivables.Summary),
+		agingSnapshot(tenantID, models.AgingLedgerPayables, today, payables.Summary),
+	}
+	snapshots[0].Disputed = receivables.Disputed.Total
+
+	// A second run on the same day replaces that day's snapshot
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "ledger"}, {Name: "snapshot_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"current", "days1_to30", "days31_to60", "days61_to90", "over90_days", "total", "disputed",
+		}),
+	}).Create(&snapshots).Error
+}
+
+func (s *agingSnapshotService) GetTrend(ctx context.Context, tenantID uuid.UUID, ledger string, weeks int) (*models.AgingTrendReport, error) {
+	if ledger != models.AgingLedgerReceivables && ledger != models.AgingLedgerPayables {
+		return nil, ErrInvalidAgingLedger
+	}
+	if weeks <= 0 {
+		weeks = defaultAgingTrendWeeks
+	}
+	if weeks > maxAgingTrendWeeks {
+		weeks = maxAgingTrendWeeks
+	}
+
+	// The current week is the last of the weeks shown
+	since := startOfWeek(time.Now().UTC()).AddDate(0, 0, -7*(weeks-1))
+
+	var snapshots []models.AgingSnapshot
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (date_trunc('week', snapshot_date)) *
+		FROM aging_snapshots
+		WHERE tenant_id = ? AND ledger = ? AND snapshot_date >= ?
+		ORDER BY date_trunc('week', snapshot_date), snapshot_date DESC
+	`, tenantID, ledger, since.Format("2006-01-02")).Scan(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AgingTrendReport{
+		Ledger: ledger,
+		Weeks:  make([]models.AgingTrendWeek, 0, len(snapshots)),
+	}
+	for i, snapshot := range snapshots {
+		week := models.AgingTrendWeek{
+			WeekOf:       startOfWeek(snapshot.SnapshotDate),
+			SnapshotDate: snapshot.SnapshotDate,
+			Days1To30:    snapshot.Days1To30,
+			Days31To60:   snapshot.Days31To60,
+			Days61To90:   snapshot.Days61To90,
+			Over90Days:   snapshot.Over90Days,
+			Overdue:      snapshot.Days1To30 + snapshot.Days31To60 + snapshot.Days61To90 + snapshot.Over90Days,
+			Current:      snapshot.Current,
+			Total:        snapshot.Total,
+		}
+		if i > 0 {
+			week.Change = week.Overdue - report.Weeks[i-1].Overdue
+		}
+		report.Weeks = append(report.Weeks, week)
+	}
+
+	if n := len(report.Weeks); n > 1 {
+		report.OverdueChange = report.Weeks[n-1].Overdue - report.Weeks[0].Overdue
+	}
+	return report, nil
+}
+
+func agingSnapshot(tenantID uuid.UUID, ledger string, date time.Time, summary models.AgingSummary) models.AgingSnapshot {
+	return models.AgingSnapshot{
+		TenantID:     tenantID,
+		Ledger:       ledger,
+		SnapshotDate: date,
+		Current:      summary.Current,
+		Days1To30:    summary.Days1To30,
+		Days31To60:   summary.Days31To60,
+		Days61To90:   summary.Days61To90,
+		Over90Days:   summary.Over90Days,
+		Total:        summary.Total,
+	}
+}
+
+// startOfWeek returns the Monday of t's week, as Postgres date_trunc does
+func startOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
@@ -0,0 +1,580 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGroupNotFound         = errors.New("this tenant is not the parent of a tenant group")
+	ErrGroupAccountNotFound  = errors.New("group account not found")
+	ErrGroupAccountExists    = errors.New("a group account with this code already exists")
+	ErrMemberAccountNotFound = errors.New("account does not belong to a tenant in the group")
+	ErrMappingTypeMismatch   = errors.New("account type does not match the group account type")
+)
+
+// creditNatured lists account types whose balances are reported as credits
+var creditNatured = map[string]bool{
+	"liability": true,
+	"equity":    true,
+	"income":    true,
+}
+
+// CreateGroupAccountRequest represents the request to add a group account
+type CreateGroupAccountRequest struct {
+	Code string `json:"code" binding:"required,max=20"`
+	Name string `json:"name" binding:"required,max=255"`
+	Type string `json:"type" binding:"required,oneof=asset liability equity income expense"`
+}
+
+// GroupAccountMappingInput maps a member account onto a group account. A nil
+// GroupAccountID removes the mapping.
+type GroupAccountMappingInput struct {
+	AccountID      uuid.UUID  `json:"account_id" binding:"required"`
+	GroupAccountID *uuid.UUID `json:"group_account_id"`
+}
+
+// UpdateGroupMappingsRequest represents a batch of account mapping changes
+type UpdateGroupMappingsRequest struct {
+	Mappings []GroupAccountMappingInput `json:"mappings" binding:"required,min=1,dive"`
+}
+
+// ConsolidationService defines the interface for group consolidation. The
+// group is always resolved from the requesting tenant, which must be the
+// group's parent.
+type ConsolidationService interface {
+	GetGroup(ctx context.Context, tenantID uuid.UUID) (*models.GroupInfo, error)
+
+	// Group chart of accounts
+	ListGroupAccounts(ctx context.Context, tenantID uuid.UUID) ([]models.GroupAccount, error)
+	CreateGroupAccount(ctx context.Context, tenantID uuid.UUID, req CreateGroupAccountRequest) (*models.GroupAccount, error)
+	DeleteGroupAccount(ctx context.Context, tenantID, id uuid.UUID) error
+
+	// Member account mappings
+	ListMemberAccounts(ctx context.Context, tenantID uuid.UUID, unmappedOnly bool) ([]models.GroupMemberAccount, error)
+	UpdateMappings(ctx context.Context, tenantID uuid.UUID, req UpdateGroupMappingsRequest) ([]models.GroupMemberAccount, error)
+
+	// Consolidated statements
+	GetConsolidatedProfitLoss(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.ConsolidatedProfitLoss, error)
+	GetConsolidatedBalanceSheet(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time) (*models.ConsolidatedBalanceSheet, error)
+}
+
+type consolidationService struct {
+	db    *gorm.DB
+	reads ReadSource
+}
+
+// NewConsolidationService creates a new consolidation service. Group setup
+// is read and written through db; consolidated statements are read through
+// reads like the other reports.
+func NewConsolidationService(db *gorm.DB, reads ReadSource) ConsolidationService {
+	return &consolidationService{db: db, reads: reads}
+}
+
+func (s *consolidationService) GetGroup(ctx context.Context, tenantID uuid.UUID) (*models.GroupInfo, error) {
+	return s.group(s.db.WithContext(ctx), tenantID)
+}
+
+func (s *consolidationService) group(db *gorm.DB, tenantID uuid.UUID) (*models.GroupInfo, error) {
+	var row struct {
+		ID   uuid.UUID
+		Name string
+	}
+	err := db.Raw(`
+		SELECT id, name
+		FROM tenant_groups
+		WHERE parent_tenant_id = ? AND deleted_at IS NULL
+	`, tenantID).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	if row.ID == uuid.Nil {
+		return nil, ErrGroupNotFound
+	}
+
+	group := models.GroupInfo{ID: row.ID, Name: row.Name}
+
+	err = db.Raw(`
+		SELECT m.tenant_id, t.name, m.tenant_id = ? AS is_parent
+		FROM tenant_group_members m
+		JOIN tenants t ON t.id = m.tenant_id
+		WHERE m.group_id = ?
+		ORDER BY is_parent DESC, t.name
+	`, tenantID, group.ID).Scan(&group.Tenants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (s *consolidationService) ListGroupAccounts(ctx context.Context, tenantID uuid.UUID) ([]models.GroupAccount, error) {
+	group, err := s.GetGroup(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []models.GroupAccount
+	err = s.db.WithContext(ctx).
+		Where("group_id = ?", group.ID).
+		Order("code").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+func (s *consolidationService) CreateGroupAccount(ctx context.Context, tenantID uuid.UUID, req CreateGroupAccountRequest) (*models.GroupAccount, error) {
+	group, err := s.GetGroup(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	code := strings.TrimSpace(req.Code)
+
+	var count int64
+	s.db.WithContext(ctx).Model(&models.GroupAccount{}).
+		Where("group_id = ? AND code = ?", group.ID, code).
+		Count(&count)
+	if count > 0 {
+		return nil, ErrGroupAccountExists
+	}
+
+	account := &models.GroupAccount{
+		GroupID: group.ID,
+		Code:    code,
+		Name:    strings.TrimSpace(req.Name),
+		Type:    req.Type,
+	}
+	if err := s.db.WithContext(ctx).Create(account).Error; err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (s *consolidationService) DeleteGroupAccount(ctx context.Context, tenantID, id uuid.UUID) error {
+	group, err := s.GetGroup(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND group_id = ?", id, group.ID).Delete(&models.GroupAccount{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrGroupAccountNotFound
+		}
+
+		// Accounts mapped here fall back to the unmapped lines
+		return tx.Where("group_account_id = ?", id).Delete(&models.GroupAccountMapping{}).Error
+	})
+}
+
+func (s *consolidationService) ListMemberAccounts(ctx context.Context, tenantID uuid.UUID, unmappedOnly bool) ([]models.GroupMemberAccount, error) {
+	group, err := s.GetGroup(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.memberAccounts(ctx, group, unmappedOnly)
+}
+
+func (s *consolidationService) UpdateMappings(ctx context.Context, tenantID uuid.UUID, req UpdateGroupMappingsRequest) ([]models.GroupMemberAccount, error) {
+	group, err := s.GetGroup(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs := make([]uuid.UUID, 0, len(req.Mappings))
+	groupAccountIDs := make([]uuid.UUID, 0, len(req.Mappings))
+	for _, m := range req.Mappings {
+		accountIDs = append(accountIDs, m.AccountID)
+		if m.GroupAccountID != nil {
+			groupAccountIDs = append(groupAccountIDs, *m.GroupAccountID)
+		}
+	}
+
+	// Mapped accounts must belong to a group tenant
+	type memberAccount struct {
+		ID       uuid.UUID
+		TenantID uuid.UUID
+		Type     string
+	}
+	var members []memberAccount
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT id, tenant_id, type
+		FROM accounts
+		WHERE id IN ? AND tenant_id IN ? AND deleted_at IS NULL
+	`, accountIDs, groupTenantIDs(group)).Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	memberByID := make(map[uuid.UUID]memberAccount, len(members))
+	for _, m := range members {
+		memberByID[m.ID] = m
+	}
+
+	groupAccountByID := make(map[uuid.UUID]models.GroupAccount)
+	if len(groupAccountIDs) > 0 {
+		var groupAccounts []models.GroupAccount
+		err = s.db.WithContext(ctx).
+			Where("group_id = ? AND id IN ?", group.ID, groupAccountIDs).
+			Find(&groupAccounts).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range groupAccounts {
+			groupAccountByID[a.ID] = a
+		}
+	}
+
+	for _, m := range req.Mappings {
+		member, ok := memberByID[m.AccountID]
+		if !ok {
+			return nil, ErrMemberAccountNotFound
+		}
+		if m.GroupAccountID == nil {
+			continue
+		}
+		groupAccount, ok := groupAccountByID[*m.GroupAccountID]
+		if !ok {
+			return nil, ErrGroupAccountNotFound
+		}
+		if groupAccount.Type != member.Type {
+			return nil, ErrMappingTypeMismatch
+		}
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range req.Mappings {
+			if m.GroupAccountID == nil {
+				if err := tx.Where("account_id = ?", m.AccountID).Delete(&models.GroupAccountMapping{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			mapping := &models.GroupAccountMapping{
+				GroupID:        group.ID,
+				TenantID:       memberByID[m.AccountID].TenantID,
+				AccountID:      m.AccountID,
+				GroupAccountID: *m.GroupAccountID,
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "account_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"group_id", "tenant_id", "group_account_id", "updated_at"}),
+			}).Create(mapping).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.memberAccounts(ctx, group, false)
+}
+
+// GetConsolidatedProfitLoss sums the P&L of all group tenants by group
+// account, eliminating income and expenses from transactions tagged with
+// another group tenant as counterparty.
+func (s *consolidationService) GetConsolidatedProfitLoss(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.ConsolidatedProfitLoss, error) {
+	db, asOf := s.reads.Reader(ctx)
+
+	group, err := s.group(db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.accountBalances(db, group, []string{"income", "expense"}, fromDate, toDate, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, unmapped, err := s.consolidate(db, group, balances)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ConsolidatedProfitLoss{
+		Period: models.ReportPeriod{
+			From: fromDate,
+			To:   toDate,
+		},
+		Group:    *group,
+		Income:   sections["income"],
+		Expenses: sections["expense"],
+		Unmapped: unmapped,
+		DataAsOf: asOf,
+	}
+	report.NetProfit = roundAmount(report.Income.Consolidated - report.Expenses.Consolidated)
+
+	return report, nil
+}
+
+// GetConsolidatedBalanceSheet combines the balance sheets of all group
+// tenants as of a date. Inter-company receivables, payables and investments
+// are eliminated the same way as in the P&L; group earnings to date are
+// shown as retained earnings.
+func (s *consolidationService) GetConsolidatedBalanceSheet(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time) (*models.ConsolidatedBalanceSheet, error) {
+	db, asOf := s.reads.Reader(ctx)
+
+	group, err := s.group(db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	types := []string{"asset", "liability", "equity", "income", "expense"}
+	balances, err := s.accountBalances(db, group, types, time.Time{}, asOfDate, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, unmapped, err := s.consolidate(db, group, balances)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ConsolidatedBalanceSheet{
+		AsOfDate:    asOfDate,
+		Group:       *group,
+		Assets:      sections["asset"],
+		Liabilities: sections["liability"],
+		Equity:      sections["equity"],
+		Unmapped:    unmapped,
+		DataAsOf:    asOf,
+	}
+
+	income := sections["income"]
+	expenses := sections["expense"]
+	report.RetainedEarnings = roundAmount(income.Consolidated - expenses.Consolidated)
+	report.TotalAssets = report.Assets.Consolidated
+	report.TotalLiabilitiesAndEquity = roundAmount(report.Liabilities.Consolidated + report.Equity.Consolidated + report.RetainedEarnings)
+	report.Difference = roundAmount(report.TotalAssets - report.TotalLiabilitiesAndEquity)
+
+	return report, nil
+}
+
+// accountBalance is a member account's net debit movement, with the part
+// arising from inter-company transactions
+type accountBalance struct {
+	TenantID        uuid.UUID
+	AccountID       uuid.UUID
+	Code            string
+	Name            string
+	Type            string
+	OpeningDebit    float64
+	NetDebit        float64
+	EliminatedDebit float64
+}
+
+func (s *consolidationService) accountBalances(db *gorm.DB, group *models.GroupInfo, types []string, fromDate, toDate time.Time, withOpening bool) ([]accountBalance, error) {
+	tenantIDs := groupTenantIDs(group)
+
+	var rows []accountBalance
+	err := db.Raw(`
+		SELECT
+			a.tenant_id,
+			a.id AS account_id,
+			a.code,
+			a.name,
+			a.type,
+			CASE WHEN a.normal_balance = 'credit'
+				THEN -COALESCE(a.opening_balance, 0)
+				ELSE COALESCE(a.opening_balance, 0)
+			END AS opening_debit,
+			COALESCE(mv.net_debit, 0) AS net_debit,
+			COALESCE(mv.eliminated_debit, 0) AS eliminated_debit
+		FROM accounts a
+		LEFT JOIN (
+			SELECT
+				tl.account_id,
+				SUM(tl.debit_amount - tl.credit_amount) AS net_debit,
+				SUM(CASE WHEN t.counterparty_tenant_id IN ? AND t.counterparty_tenant_id <> t.tenant_id
+					THEN tl.debit_amount - tl.credit_amount ELSE 0 END) AS eliminated_debit
+			FROM transaction_lines tl
+			JOIN transactions t ON t.id = tl.transaction_id
+			WHERE t.tenant_id IN ? AND t.transaction_date >= ? AND t.transaction_date <= ?
+			AND t.status = 'posted' AND t.deleted_at IS NULL
+			GROUP BY tl.account_id
+		) mv ON mv.account_id = a.id
+		WHERE a.tenant_id IN ? AND a.type IN ? AND a.deleted_at IS NULL
+	`, tenantIDs, tenantIDs, fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"), tenantIDs, types).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if !withOpening {
+		for i := range rows {
+			rows[i].OpeningDebit = 0
+		}
+	}
+
+	return rows, nil
+}
+
+// consolidate rolls member account balances up into group account lines,
+// one section per account type. Accounts without a mapping (or mapped to a
+// group account of another type) are collected in an "Unmapped" line so the
+// statements still add up, and listed for the user to map.
+func (s *consolidationService) consolidate(db *gorm.DB, group *models.GroupInfo, balances []accountBalance) (map[string]models.ConsolidatedSection, []models.GroupMemberAccount, error) {
+	var groupAccounts []models.GroupAccount
+	if err := db.Where("group_id = ?", group.ID).Find(&groupAccounts).Error; err != nil {
+		return nil, nil, err
+	}
+	groupAccountByID := make(map[uuid.UUID]models.GroupAccount, len(groupAccounts))
+	for _, a := range groupAccounts {
+		groupAccountByID[a.ID] = a
+	}
+
+	var mappings []models.GroupAccountMapping
+	if err := db.Where("group_id = ?", group.ID).Find(&mappings).Error; err != nil {
+		return nil, nil, err
+	}
+	mappedTo := make(map[uuid.UUID]uuid.UUID, len(mappings))
+	for _, m := range mappings {
+		mappedTo[m.AccountID] = m.GroupAccountID
+	}
+
+	tenantNames := make(map[uuid.UUID]string, len(group.Tenants))
+	for _, t := range group.Tenants {
+		tenantNames[t.TenantID] = t.Name
+	}
+
+	lines := make(map[string]map[uuid.UUID]*models.ConsolidatedLine)
+	var unmapped []models.GroupMemberAccount
+
+	for _, b := range balances {
+		amount := b.OpeningDebit + b.NetDebit
+		eliminated := b.EliminatedDebit
+		if creditNatured[b.Type] {
+			amount, eliminated = -amount, -eliminated
+		}
+		if amount == 0 && eliminated == 0 {
+			continue
+		}
+
+		// Unmapped accounts share the uuid.Nil key within their type
+		key := uuid.Nil
+		if id, ok := mappedTo[b.AccountID]; ok && groupAccountByID[id].Type == b.Type {
+			key = id
+		} else {
+			unmapped = append(unmapped, models.GroupMemberAccount{
+				TenantID:    b.TenantID,
+				TenantName:  tenantNames[b.TenantID],
+				AccountID:   b.AccountID,
+				AccountCode: b.Code,
+				AccountName: b.Name,
+				AccountType: b.Type,
+			})
+		}
+
+		if lines[b.Type] == nil {
+			lines[b.Type] = make(map[uuid.UUID]*models.ConsolidatedLine)
+		}
+		line := lines[b.Type][key]
+		if line == nil {
+			line = &models.ConsolidatedLine{ByTenant: make(map[uuid.UUID]float64)}
+			if key == uuid.Nil {
+				line.Name = "Unmapped " + b.Type
+			} else {
+				groupAccountID := key
+				line.GroupAccountID = &groupAccountID
+				line.Code = groupAccountByID[key].Code
+				line.Name = groupAccountByID[key].Name
+			}
+			lines[b.Type][key] = line
+		}
+
+		line.ByTenant[b.TenantID] += amount
+		line.Total += amount
+		line.Eliminated += eliminated
+	}
+
+	sections := make(map[string]models.ConsolidatedSection)
+	for accountType, byKey := range lines {
+		var section models.ConsolidatedSection
+		for _, line := range byKey {
+			for tenantID, amount := range line.ByTenant {
+				line.ByTenant[tenantID] = roundAmount(amount)
+			}
+			line.Total = roundAmount(line.Total)
+			line.Eliminated = roundAmount(line.Eliminated)
+			line.Consolidated = roundAmount(line.Total - line.Eliminated)
+
+			section.Lines = append(section.Lines, *line)
+			section.Total += line.Total
+			section.Eliminated += line.Eliminated
+		}
+
+		// Group accounts by code, unmapped line last
+		sort.Slice(section.Lines, func(i, j int) bool {
+			if (section.Lines[i].GroupAccountID == nil) != (section.Lines[j].GroupAccountID == nil) {
+				return section.Lines[j].GroupAccountID == nil
+			}
+			return section.Lines[i].Code < section.Lines[j].Code
+		})
+
+		section.Total = roundAmount(section.Total)
+		section.Eliminated = roundAmount(section.Eliminated)
+		section.Consolidated = roundAmount(section.Total - section.Eliminated)
+		sections[accountType] = section
+	}
+
+	sort.Slice(unmapped, func(i, j int) bool {
+		if unmapped[i].TenantName != unmapped[j].TenantName {
+			return unmapped[i].TenantName < unmapped[j].TenantName
+		}
+		return unmapped[i].AccountCode < unmapped[j].AccountCode
+	})
+
+	return sections, unmapped, nil
+}
+
+func (s *consolidationService) memberAccounts(ctx context.Context, group *models.GroupInfo, unmappedOnly bool) ([]models.GroupMemberAccount, error) {
+	query := `
+		SELECT
+			a.tenant_id,
+			t.name AS tenant_name,
+			a.id AS account_id,
+			a.code AS account_code,
+			a.name AS account_name,
+			a.type AS account_type,
+			m.group_account_id
+		FROM accounts a
+		JOIN tenants t ON t.id = a.tenant_id
+		LEFT JOIN group_account_mappings m ON m.account_id = a.id AND m.group_id = ?
+		WHERE a.tenant_id IN ? AND a.deleted_at IS NULL
+	`
+	if unmappedOnly {
+		query += " AND m.id IS NULL"
+	}
+	query += " ORDER BY t.name, a.code"
+
+	var accounts []models.GroupMemberAccount
+	err := s.db.WithContext(ctx).Raw(query, group.ID, groupTenantIDs(group)).Scan(&accounts).Error
+	return accounts, err
+}
+
+func groupTenantIDs(group *models.GroupInfo) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(group.Tenants))
+	for _, t := range group.Tenants {
+		ids = append(ids, t.TenantID)
+	}
+	return ids
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
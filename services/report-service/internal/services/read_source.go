@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"gorm.io/gorm"
+)
+
+// replicaProbeTimeout bounds a single replica lag check so a struggling
+// replica cannot hold up report requests
+const replicaProbeTimeout = 2 * time.Second
+
+// ReadSource picks the connection reports are read from. Reads go to the
+// read replica while it stays within the allowed lag and fall back to the
+// primary once it falls further behind or cannot be reached.
+type ReadSource interface {
+	// Reader returns a session for the report queries together with the
+	// point in time the data it reads is current as of
+	Reader(ctx context.Context) (*gorm.DB, time.Time)
+}
+
+type readSource struct {
+	db            *gorm.DB
+	maxLag        time.Duration
+	checkInterval time.Duration
+
+	mu         sync.Mutex
+	checkedAt  time.Time
+	lag        time.Duration
+	useReplica bool
+}
+
+// NewReadSource creates a read source over db. The replica lag is probed
+// at most once per checkInterval and shared by all requests in between.
+func NewReadSource(db *gorm.DB, maxLag, checkInterval time.Duration) ReadSource {
+	return &readSource{
+		db:            db,
+		maxLag:        maxLag,
+		checkInterval: checkInterval,
+		useReplica:    true,
+	}
+}
+
+func (r *readSource) Reader(ctx context.Context) (*gorm.DB, time.Time) {
+	db := r.db.WithContext(ctx)
+	if database.Replica(r.db) == nil {
+		return db, time.Now()
+	}
+
+	lag, ok := r.replicaLag(ctx)
+	if !ok {
+		return database.UsePrimary(db), time.Now()
+	}
+	return db, time.Now().Add(-lag)
+}
+
+// replicaLag returns the last known replica lag and whether the replica is
+// fit to serve reports, probing it again once the cached result is stale
+func (r *readSource) replicaLag(ctx context.Context) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.checkedAt.IsZero() && time.Since(r.checkedAt) < r.checkInterval {
+		return r.lag, r.useReplica
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, replicaProbeTimeout)
+	defer cancel()
+
+	lag, _, err := database.ReplicaLag(probeCtx, r.db)
+	useReplica := err == nil && lag <= r.maxLag
+
+	if useReplica != r.useReplica {
+		switch {
+		case err != nil:
+			log.Printf("Report reads falling back to primary: %v", err)
+		case !useReplica:
+			log.Printf("Report reads falling back to primary: replica lag %s exceeds %s", lag.Round(time.Second), r.maxLag)
+		default:
+			log.Printf("Report reads back on replica (lag %s)", lag.Round(time.Second))
+		}
+	}
+
+	r.checkedAt = time.Now()
+	r.lag = lag
+	r.useReplica = useReplica
+	return lag, useReplica
+}
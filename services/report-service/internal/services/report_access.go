@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/clients"
+)
+
+// Report permissions, granted by the caller's role in the tenant service
+const (
+	PermDashboardView        = "dashboard:view"
+	PermReportsView          = "reports:view"
+	PermReportsSales         = "reports:sales"
+	PermReportsProfitability = "reports:profitability"
+)
+
+// Reports with an access policy
+const (
+	ReportDashboard                = "dashboard"
+	ReportProfitLoss               = "profit-loss"
+	ReportBalanceSheet             = "balance-sheet"
+	ReportTrialBalance             = "trial-balance"
+	ReportGSTSummary               = "gst-summary"
+	ReportReceivablesAging         = "receivables-aging"
+	ReportPayablesAging            = "payables-aging"
+	ReportAgingTrend               = "aging-trend"
+	ReportCashFlow                 = "cash-flow"
+	ReportRevenueBreakdown         = "revenue-breakdown"
+	ReportTags                     = "tags"
+	ReportTopCustomers             = "top-customers"
+	ReportTopProducts              = "top-products"
+	ReportSalesByState             = "sales-by-state"
+	ReportGroup                    = "group"
+	ReportConsolidatedProfitLoss   = "consolidated-profit-loss"
+	ReportConsolidatedBalanceSheet = "consolidated-balance-sheet"
+)
+
+var (
+	// ErrReportForbidden is returned when the caller's role does not allow
+	// the report
+	ErrReportForbidden = errors.New("your role does not allow this report")
+	// ErrPermissionsUnavailable is returned when the caller's permissions
+	// could not be read from the tenant service
+	ErrPermissionsUnavailable = errors.New("permissions unavailable")
+)
+
+// ReportPolicy is who may see a report and what is hidden from those who
+// may not see profitability
+type ReportPolicy struct {
+	// Any of these permissions grants the report
+	Permissions []string
+	// The report reveals profit however it is masked (a balance sheet's
+	// retained earnings, a trial balance's expense accounts), so it needs
+	// reports:profitability as well
+	RequiresProfitability bool
+	// JSON fields hidden without reports:profitability. Paths are dotted;
+	// "[]" steps into each element of an array.
+	MaskedFields []string
+}
+
+// DefaultReportPolicies are the access policies of the reports. Sales-side
+// reports are open to reports:sales; COGS, expenses and profit are masked
+// for anyone without reports:profitability.
+func DefaultReportPolicies() map[string]ReportPolicy {
+	sales := []string{PermReportsView, PermReportsSales}
+	full := []string{PermReportsView}
+
+	return map[string]ReportPolicy{
+		ReportDashboard: {
+			Permissions:  append(sales, PermDashboardView),
+			MaskedFields: []string{"today.expenses", "today.net", "this_month.expenses", "this_month.net"},
+		},
+		ReportProfitLoss: {
+			Permissions: full,
+			MaskedFields: []string{
+				"expenses.cost_of_goods_sold", "expenses.total",
+				"gross_profit", "gross_margin_percent",
+				"operating_profit", "net_profit", "net_margin_percent",
+			},
+		},
+		ReportBalanceSheet:     {Permissions: full, RequiresProfitability: true},
+		ReportTrialBalance:     {Permissions: full, RequiresProfitability: true},
+		ReportGSTSummary:       {Permissions: full},
+		ReportReceivablesAging: {Permissions: sales},
+		ReportPayablesAging:    {Permissions: full},
+		ReportAgingTrend:       {Permissions: full},
+		ReportCashFlow:         {Permissions: full},
+		ReportRevenueBreakdown: {Permissions: sales},
+		ReportTags: {
+			Permissions:  sales,
+			MaskedFields: []string{"tags[].spend", "tags[].net"},
+		},
+		ReportTopCustomers: {Permissions: sales},
+		ReportTopProducts:  {Permissions: sales},
+		ReportSalesByState: {Permissions: sales},
+		ReportGroup:        {Permissions: full},
+		ReportConsolidatedProfitLoss: {
+			Permissions:  full,
+			MaskedFields: []string{"expenses", "net_profit"},
+		},
+		ReportConsolidatedBalanceSheet: {Permissions: full, RequiresProfitability: true},
+	}
+}
+
+// ReportAccess is what the caller may see of a report
+type ReportAccess struct {
+	// Fields to hide from the report, none if the caller may see it all
+	MaskedFields []string
+}
+
+// ReportAccessService decides who may see which reports, from the
+// permissions of their role in the tenant
+type ReportAccessService interface {
+	// Check returns what the caller may see of the report, or
+	// ErrReportForbidden
+	Check(ctx context.Context, authorization, tenantID, userID, report string) (*ReportAccess, error)
+}
+
+type reportAccessService struct {
+	tenants  clients.TenantClient
+	policies map[string]ReportPolicy
+	ttl      time.Duration
+
+	mu          sync.Mutex
+	permissions map[string]cachedPermissions
+}
+
+type cachedPermissions struct {
+	permissions map[string]bool
+	loadedAt    time.Time
+}
+
+// NewReportAccessService creates a report access service. Role changes
+// take up to ttl to apply.
+func NewReportAccessService(tenants clients.TenantClient, policies map[string]ReportPolicy, ttl time.Duration) ReportAccessService {
+	return &reportAccessService{
+		tenants:     tenants,
+		policies:    policies,
+		ttl:         ttl,
+		permissions: make(map[string]cachedPermissions),
+	}
+}
+
+func (s *reportAccessService) Check(ctx context.Context, authorization, tenantID, userID, report string) (*ReportAccess, error) {
+	policy, ok := s.policies[report]
+	if !ok {
+		return nil, ErrReportForbidden
+	}
+
+	permissions, err := s.permissionsOf(ctx, authorization, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowed bool
+	for _, permission := range policy.Permissions {
+		allowed = allowed || permissions[permission]
+	}
+	profitability := permissions[PermReportsProfitability]
+	if !allowed || (policy.RequiresProfitability && !profitability) {
+		return nil, ErrReportForbidden
+	}
+
+	access := &ReportAccess{}
+	if !profitability {
+		access.MaskedFields = policy.MaskedFields
+	}
+	return access, nil
+}
+
+// permissionsOf returns the caller's permissions in the tenant, cached for
+// a while. A failed lookup keeps serving the last permissions read.
+func (s *reportAccessService) permissionsOf(ctx context.Context, authorization, tenantID, userID string) (map[string]bool, error) {
+	key := tenantID + "/" + userID
+
+	s.mu.Lock()
+	cached, ok := s.permissions[key]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < s.ttl {
+		return cached.permissions, nil
+	}
+
+	list, err := s.tenants.GetMyPermissions(ctx, authorization, tenantID)
+	if err != nil {
+		if errors.Is(err, clients.ErrForbidden) || errors.Is(err, clients.ErrNotFound) {
+			return nil, ErrReportForbidden
+		}
+		if ok {
+			return cached.permissions, nil
+		}
+		return nil, ErrPermissionsUnavailable
+	}
+
+	permissions := make(map[string]bool, len(list))
+	for _, permission := range list {
+		permissions[permission] = true
+	}
+
+	s.mu.Lock()
+	s.permissions[key] = cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return permissions, nil
+}
+
+// MaskFields returns the report as generic JSON with the fields removed
+func MaskFields(report interface{}, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	var masked map[string]interface{}
+	if err := json.Unmarshal(data, &masked); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		removeField(masked, strings.Split(field, "."))
+	}
+	return masked, nil
+}
+
+// removeField deletes the field at path, stepping into each element of an
+// array for a "[]" suffix
+func removeField(value interface{}, path []string) {
+	object, ok := value.(map[string]interface{})
+	if !ok || len(path) == 0 {
+		return
+	}
+
+	key := path[0]
+	if len(path) == 1 {
+		delete(object, key)
+		return
+	}
+
+	if name, ok := strings.CutSuffix(key, "[]"); ok {
+		items, _ := object[name].([]interface{})
+		for _, item := range items {
+			removeField(item, path[1:])
+		}
+		return
+	}
+	removeField(object[key], path[1:])
+}
@@ -2,45 +2,50 @@ package services
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
-	"gorm.io/gorm"
 )
 
 // ReportService defines the interface for report business logic
 type ReportService interface {
 	GetDashboardSummary(ctx context.Context, tenantID uuid.UUID) (*models.DashboardSummary, error)
-	GetProfitLoss(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.ProfitLossReport, error)
+	GetProfitLoss(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time, includeAdjustments bool) (*models.ProfitLossReport, error)
 	GetBalanceSheet(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time) (*models.BalanceSheet, error)
 	GetGSTSummary(ctx context.Context, tenantID uuid.UUID, month, year int) (*models.GSTSummary, error)
 	GetReceivablesAging(ctx context.Context, tenantID uuid.UUID) (*models.ReceivablesAgingReport, error)
 	GetPayablesAging(ctx context.Context, tenantID uuid.UUID) (*models.PayablesAgingReport, error)
 	GetCashFlow(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.CashFlowReport, error)
-	GetTrialBalance(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time) (*models.TrialBalanceReport, error)
+	GetTrialBalance(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time, includeAdjustments bool) (*models.TrialBalanceReport, error)
+	GetRevenueBreakdown(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.RevenueBreakdownReport, error)
+	GetTagReport(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time, tag string) (*models.TagReport, error)
+	GetSalesAnalytics(ctx context.Context, tenantID uuid.UUID, query SalesAnalyticsQuery) (*models.SalesAnalyticsReport, error)
 }
 
 type reportService struct {
-	db *gorm.DB
+	reads ReadSource
 }
 
 // NewReportService creates a new report service
-func NewReportService(db *gorm.DB) ReportService {
-	return &reportService{db: db}
+func NewReportService(reads ReadSource) ReportService {
+	return &reportService{reads: reads}
 }
 
 func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.UUID) (*models.DashboardSummary, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	today := time.Now().Truncate(24 * time.Hour)
 	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
 	lastMonthStart := monthStart.AddDate(0, -1, 0)
 	lastMonthEnd := monthStart.AddDate(0, 0, -1)
 
-	summary := &models.DashboardSummary{}
+	summary := &models.DashboardSummary{DataAsOf: asOf}
 
 	// Today's summary
 	var todaySales, todayExpenses float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT
 			COALESCE(SUM(CASE WHEN transaction_type = 'sale' THEN total_amount ELSE 0 END), 0) as sales,
 			COALESCE(SUM(CASE WHEN transaction_type = 'expense' THEN total_amount ELSE 0 END), 0) as expenses
@@ -56,7 +61,7 @@ func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.U
 
 	// This month summary
 	var monthSales, monthExpenses float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT
 			COALESCE(SUM(CASE WHEN transaction_type = 'sale' THEN total_amount ELSE 0 END), 0) as sales,
 			COALESCE(SUM(CASE WHEN transaction_type = 'expense' THEN total_amount ELSE 0 END), 0) as expenses
@@ -66,7 +71,7 @@ func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.U
 
 	// Last month sales for comparison
 	var lastMonthSales float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(total_amount), 0)
 		FROM transactions
 		WHERE tenant_id = ? AND transaction_date >= ? AND transaction_date <= ?
@@ -87,13 +92,13 @@ func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.U
 
 	// Outstanding receivables and payables
 	var receivables, payables float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts
 		WHERE tenant_id = ? AND sub_type = 'receivable' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&receivables)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts
 		WHERE tenant_id = ? AND sub_type = 'payable' AND deleted_at IS NULL
@@ -106,13 +111,13 @@ func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.U
 
 	// Cash position
 	var cash, bank float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts
 		WHERE tenant_id = ? AND sub_type = 'cash' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&cash)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts
 		WHERE tenant_id = ? AND sub_type = 'bank' AND deleted_at IS NULL
@@ -126,7 +131,7 @@ func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.U
 
 	// Recent transactions
 	var recentTxns []models.TransactionSummary
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT id, transaction_date as date, transaction_type as type, description, total_amount as amount, party_name
 		FROM transactions
 		WHERE tenant_id = ? AND status = 'posted' AND deleted_at IS NULL
@@ -135,15 +140,38 @@ func (s *reportService) GetDashboardSummary(ctx context.Context, tenantID uuid.U
 	`, tenantID).Scan(&recentTxns)
 	summary.RecentTransactions = recentTxns
 
+	// Close readiness of last month
+	var readiness models.CloseReadinessSummary
+	result := db.Raw(`
+		SELECT c.period, c.status, c.readiness_score AS score,
+			COUNT(t.id) FILTER (WHERE t.status <> 'skipped') AS tasks_total,
+			COUNT(t.id) FILTER (WHERE t.status = 'done') AS tasks_done,
+			COUNT(t.id) FILTER (WHERE t.status IN ('pending', 'in_progress') AND t.due_date < ?) AS tasks_overdue
+		FROM close_checklists c
+		LEFT JOIN close_tasks t ON t.checklist_id = c.id
+		WHERE c.tenant_id = ? AND c.period = ?
+		GROUP BY c.id
+	`, today.Format("2006-01-02"), tenantID, lastMonthStart.Format("2006-01")).Scan(&readiness)
+	if result.Error == nil && result.RowsAffected > 0 {
+		summary.CloseReadiness = &readiness
+	}
+
 	return summary, nil
 }
 
-func (s *reportService) GetProfitLoss(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.ProfitLossReport, error) {
+// GetProfitLoss returns the P&L for a period. Without adjustments it
+// leaves out the adjustment layer (depreciation, provisions, accruals),
+// showing operational numbers before the period is finalised.
+func (s *reportService) GetProfitLoss(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time, includeAdjustments bool) (*models.ProfitLossReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	report := &models.ProfitLossReport{
 		Period: models.ReportPeriod{
 			From: fromDate,
 			To:   toDate,
 		},
+		IncludesAdjustments: includeAdjustments,
+		DataAsOf:            asOf,
 	}
 
 	fromStr := fromDate.Format("2006-01-02")
@@ -151,25 +179,27 @@ func (s *reportService) GetProfitLoss(ctx context.Context, tenantID uuid.UUID, f
 
 	// Revenue
 	var sales, otherIncome float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.credit_amount - tl.debit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.sub_type = 'sales'
-	`, tenantID, fromStr, toStr).Row().Scan(&sales)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&sales)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.credit_amount - tl.debit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.type = 'income' AND a.sub_type != 'sales'
-	`, tenantID, fromStr, toStr).Row().Scan(&otherIncome)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&otherIncome)
 
 	report.Revenue = models.RevenueSection{
 		Sales:       sales,
@@ -179,68 +209,74 @@ func (s *reportService) GetProfitLoss(ctx context.Context, tenantID uuid.UUID, f
 
 	// Cost of Goods Sold
 	var cogs float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.debit_amount - tl.credit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.sub_type IN ('purchase', 'direct_expense')
-	`, tenantID, fromStr, toStr).Row().Scan(&cogs)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&cogs)
 
 	// Operating Expenses
 	var rent, salaries, utilities, marketing, otherExp float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.debit_amount - tl.credit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.code = '5300'
-	`, tenantID, fromStr, toStr).Row().Scan(&rent)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&rent)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.debit_amount - tl.credit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.code = '5400'
-	`, tenantID, fromStr, toStr).Row().Scan(&salaries)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&salaries)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.debit_amount - tl.credit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.code = '5500'
-	`, tenantID, fromStr, toStr).Row().Scan(&utilities)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&utilities)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.debit_amount - tl.credit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.code = '5600'
-	`, tenantID, fromStr, toStr).Row().Scan(&marketing)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&marketing)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(tl.debit_amount - tl.credit_amount), 0)
 		FROM transaction_lines tl
 		JOIN transactions t ON t.id = tl.transaction_id
 		JOIN accounts a ON a.id = tl.account_id
 		WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
 		AND t.status = 'posted' AND t.deleted_at IS NULL
+		AND (? OR NOT t.is_adjustment)
 		AND a.type = 'expense' AND a.sub_type = 'indirect_expense'
 		AND a.code NOT IN ('5300', '5400', '5500', '5600')
-	`, tenantID, fromStr, toStr).Row().Scan(&otherExp)
+	`, tenantID, fromStr, toStr, includeAdjustments).Row().Scan(&otherExp)
 
 	opExpTotal := rent + salaries + utilities + marketing + otherExp
 	report.Expenses = models.ExpenseSection{
@@ -271,28 +307,31 @@ func (s *reportService) GetProfitLoss(ctx context.Context, tenantID uuid.UUID, f
 }
 
 func (s *reportService) GetBalanceSheet(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time) (*models.BalanceSheet, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	bs := &models.BalanceSheet{
 		AsOfDate: asOfDate,
+		DataAsOf: asOf,
 	}
 
 	// Current Assets
 	var cash, bank, receivables, inventory float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'cash' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&cash)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'bank' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&bank)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'receivable' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&receivables)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'inventory' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&inventory)
@@ -301,7 +340,7 @@ func (s *reportService) GetBalanceSheet(ctx context.Context, tenantID uuid.UUID,
 
 	// Fixed Assets
 	var fixedAssets float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'fixed_asset' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&fixedAssets)
@@ -320,12 +359,12 @@ func (s *reportService) GetBalanceSheet(ctx context.Context, tenantID uuid.UUID,
 
 	// Liabilities
 	var payables, taxPayable float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'payable' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&payables)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'tax' AND type = 'liability' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&taxPayable)
@@ -342,7 +381,7 @@ func (s *reportService) GetBalanceSheet(ctx context.Context, tenantID uuid.UUID,
 
 	// Equity
 	var capital, retained float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(current_balance), 0)
 		FROM accounts WHERE tenant_id = ? AND sub_type = 'capital' AND deleted_at IS NULL
 	`, tenantID).Row().Scan(&capital)
@@ -360,18 +399,21 @@ func (s *reportService) GetBalanceSheet(ctx context.Context, tenantID uuid.UUID,
 }
 
 func (s *reportService) GetGSTSummary(ctx context.Context, tenantID uuid.UUID, month, year int) (*models.GSTSummary, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(0, 1, -1)
 
 	summary := &models.GSTSummary{
-		Period: startDate.Format("January 2006"),
+		Period:   startDate.Format("January 2006"),
+		DataAsOf: asOf,
 	}
 
 	// Outward supplies (Sales)
 	var outTaxable, outCGST, outSGST, outIGST float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT
-			COALESCE(SUM(total_amount - tax_amount), 0) as taxable,
+			COALESCE(SUM(total_amount - tax_amount - round_off_amount), 0) as taxable,
 			COALESCE(SUM(tax_amount / 2), 0) as cgst,
 			COALESCE(SUM(tax_amount / 2), 0) as sgst,
 			0 as igst
@@ -390,9 +432,9 @@ func (s *reportService) GetGSTSummary(ctx context.Context, tenantID uuid.UUID, m
 
 	// Inward supplies (Purchases)
 	var inTaxable, inCGST, inSGST, inIGST float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT
-			COALESCE(SUM(total_amount - tax_amount), 0) as taxable,
+			COALESCE(SUM(total_amount - tax_amount - round_off_amount), 0) as taxable,
 			COALESCE(SUM(tax_amount / 2), 0) as cgst,
 			COALESCE(SUM(tax_amount / 2), 0) as sgst,
 			0 as igst
@@ -421,46 +463,139 @@ func (s *reportService) GetGSTSummary(ctx context.Context, tenantID uuid.UUID, m
 }
 
 func (s *reportService) GetReceivablesAging(ctx context.Context, tenantID uuid.UUID) (*models.ReceivablesAgingReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	today := time.Now()
-	report := &models.ReceivablesAgingReport{}
+	report := &models.ReceivablesAgingReport{DataAsOf: asOf}
 
-	// This is a simplified implementation
-	// In production, you'd query actual invoice data with due dates
-	var current, days1to30, days31to60, days61to90, over90 float64
+	// Query invoices with outstanding balances, splitting off the amount
+	// held by open disputes
+	type agingRow struct {
+		CustomerID   uuid.UUID
+		CustomerName string
+		DueDate      time.Time
+		Balance      float64
+		Disputed     float64
+	}
 
-	// For now, return the total receivables as current
-	s.db.WithContext(ctx).Raw(`
-		SELECT COALESCE(SUM(current_balance), 0)
-		FROM accounts WHERE tenant_id = ? AND sub_type = 'receivable' AND deleted_at IS NULL
-	`, tenantID).Row().Scan(&current)
+	var rows []agingRow
+	db.Raw(`
+		SELECT
+			customer_id,
+			customer_name,
+			due_date,
+			balance_due as balance,
+			LEAST(COALESCE(disputed_amount, 0), balance_due) as disputed
+		FROM invoices
+		WHERE tenant_id = ?
+		AND status NOT IN ('draft', 'paid', 'cancelled')
+		AND balance_due > 0
+		AND deleted_at IS NULL
+	`, tenantID).Scan(&rows)
+
+	// Group by customer and calculate aging buckets
+	customerMap := make(map[uuid.UUID]*models.CustomerAging)
+	summary := models.AgingSummary{}
+	disputed := models.AgingSummary{}
+
+	for _, row := range rows {
+		daysOverdue := int(today.Sub(row.DueDate).Hours() / 24)
+		collectible := row.Balance - row.Disputed
+
+		if _, exists := customerMap[row.CustomerID]; !exists {
+			customerMap[row.CustomerID] = &models.CustomerAging{
+				CustomerID:   row.CustomerID,
+				CustomerName: row.CustomerName,
+			}
+		}
 
-	report.Summary = models.AgingSummary{
-		Current:    current,
-		Days1To30:  days1to30,
-		Days31To60: days31to60,
-		Days61To90: days61to90,
-		Over90Days: over90,
-		Total:      current + days1to30 + days31to60 + days61to90 + over90,
+		customer := customerMap[row.CustomerID]
+
+		switch {
+		case daysOverdue <= 0:
+			customer.Current += collectible
+			summary.Current += collectible
+			disputed.Current += row.Disputed
+		case daysOverdue <= 30:
+			customer.Days1To30 += collectible
+			summary.Days1To30 += collectible
+			disputed.Days1To30 += row.Disputed
+		case daysOverdue <= 60:
+			customer.Days31To60 += collectible
+			summary.Days31To60 += collectible
+			disputed.Days31To60 += row.Disputed
+		case daysOverdue <= 90:
+			customer.Days61To90 += collectible
+			summary.Days61To90 += collectible
+			disputed.Days61To90 += row.Disputed
+		default:
+			customer.Over90Days += collectible
+			summary.Over90Days += collectible
+			disputed.Over90Days += row.Disputed
+		}
+
+		customer.Total += collectible
+		customer.Disputed += row.Disputed
+		summary.Total += collectible
+		disputed.Total += row.Disputed
+	}
+
+	// Approved credit notes not yet applied, which the customer can still
+	// set against what they owe
+	type creditRow struct {
+		CustomerID   uuid.UUID
+		CustomerName string
+		Balance      float64
+	}
+
+	var credits []creditRow
+	db.Raw(`
+		SELECT customer_id, MAX(customer_name) as customer_name, SUM(balance_amount) as balance
+		FROM credit_notes
+		WHERE tenant_id = ?
+		AND status = 'approved'
+		AND balance_amount > 0
+		AND deleted_at IS NULL
+		GROUP BY customer_id
+	`, tenantID).Scan(&credits)
+
+	for _, credit := range credits {
+		if _, exists := customerMap[credit.CustomerID]; !exists {
+			customerMap[credit.CustomerID] = &models.CustomerAging{
+				CustomerID:   credit.CustomerID,
+				CustomerName: credit.CustomerName,
+			}
+		}
+		customerMap[credit.CustomerID].UnappliedCredits += credit.Balance
+		report.UnappliedCredits += credit.Balance
 	}
 
-	_ = today // Would be used for actual aging calculation
+	// Convert map to slice
+	for _, customer := range customerMap {
+		report.ByCustomer = append(report.ByCustomer, *customer)
+	}
 
+	report.Summary = summary
+	report.Disputed = disputed
 	return report, nil
 }
 
 func (s *reportService) GetCashFlow(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.CashFlowReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	report := &models.CashFlowReport{
 		Period: models.ReportPeriod{
 			From: fromDate,
 			To:   toDate,
 		},
+		DataAsOf: asOf,
 	}
 
 	fromStr := fromDate.Format("2006-01-02")
 	toStr := toDate.Format("2006-01-02")
 
 	// Opening balance
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(a.opening_balance), 0)
 		FROM accounts a
 		WHERE a.tenant_id = ? AND a.sub_type IN ('cash', 'bank') AND a.deleted_at IS NULL
@@ -468,14 +603,14 @@ func (s *reportService) GetCashFlow(ctx context.Context, tenantID uuid.UUID, fro
 
 	// Operating activities
 	var opInflow, opOutflow float64
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(total_amount), 0)
 		FROM transactions
 		WHERE tenant_id = ? AND transaction_date >= ? AND transaction_date <= ?
 		AND transaction_type IN ('sale', 'receipt') AND status = 'posted' AND deleted_at IS NULL
 	`, tenantID, fromStr, toStr).Row().Scan(&opInflow)
 
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT COALESCE(SUM(total_amount), 0)
 		FROM transactions
 		WHERE tenant_id = ? AND transaction_date >= ? AND transaction_date <= ?
@@ -500,8 +635,10 @@ func (s *reportService) GetCashFlow(ctx context.Context, tenantID uuid.UUID, fro
 }
 
 func (s *reportService) GetPayablesAging(ctx context.Context, tenantID uuid.UUID) (*models.PayablesAgingReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	today := time.Now()
-	report := &models.PayablesAgingReport{}
+	report := &models.PayablesAgingReport{DataAsOf: asOf}
 
 	// Query bills with outstanding balances and calculate aging buckets
 	type agingRow struct {
@@ -512,7 +649,7 @@ func (s *reportService) GetPayablesAging(ctx context.Context, tenantID uuid.UUID
 	}
 
 	var rows []agingRow
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT
 			vendor_id,
 			vendor_name,
@@ -572,27 +709,37 @@ func (s *reportService) GetPayablesAging(ctx context.Context, tenantID uuid.UUID
 	return report, nil
 }
 
-func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time) (*models.TrialBalanceReport, error) {
+// GetTrialBalance returns account balances as of a date. Adjustment
+// journals are shown per account as a separate layer and included in the
+// balances only with includeAdjustments, so the owner can see operational
+// balances while the CA sees the finalised ones.
+func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID, asOfDate time.Time, includeAdjustments bool) (*models.TrialBalanceReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
 	report := &models.TrialBalanceReport{
-		AsOfDate: asOfDate,
+		AsOfDate:            asOfDate,
+		IncludesAdjustments: includeAdjustments,
+		DataAsOf:            asOf,
 	}
 
 	asOfStr := asOfDate.Format("2006-01-02")
 
 	// Get all accounts with their balances as of the specified date
 	type accountRow struct {
-		ID             uuid.UUID
-		Code           string
-		Name           string
-		Type           string
-		NormalBalance  string
-		OpeningBalance float64
-		DebitMovements float64
-		CreditMovements float64
+		ID                        uuid.UUID
+		Code                      string
+		Name                      string
+		Type                      string
+		NormalBalance             string
+		OpeningBalance            float64
+		DebitMovements            float64
+		CreditMovements           float64
+		AdjustmentDebitMovements  float64
+		AdjustmentCreditMovements float64
 	}
 
 	var rows []accountRow
-	s.db.WithContext(ctx).Raw(`
+	db.Raw(`
 		SELECT
 			a.id,
 			a.code,
@@ -600,8 +747,10 @@ func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID,
 			a.type,
 			a.normal_balance,
 			COALESCE(a.opening_balance, 0) as opening_balance,
-			COALESCE(SUM(tl.debit_amount), 0) as debit_movements,
-			COALESCE(SUM(tl.credit_amount), 0) as credit_movements
+			COALESCE(SUM(CASE WHEN t.id IS NOT NULL AND NOT t.is_adjustment THEN tl.debit_amount END), 0) as debit_movements,
+			COALESCE(SUM(CASE WHEN t.id IS NOT NULL AND NOT t.is_adjustment THEN tl.credit_amount END), 0) as credit_movements,
+			COALESCE(SUM(CASE WHEN t.is_adjustment THEN tl.debit_amount END), 0) as adjustment_debit_movements,
+			COALESCE(SUM(CASE WHEN t.is_adjustment THEN tl.credit_amount END), 0) as adjustment_credit_movements
 		FROM accounts a
 		LEFT JOIN transaction_lines tl ON tl.account_id = a.id
 		LEFT JOIN transactions t ON t.id = tl.transaction_id
@@ -617,14 +766,22 @@ func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID,
 
 	for _, row := range rows {
 		entry := models.TrialBalanceEntry{
-			AccountID:   row.ID,
-			AccountCode: row.Code,
-			AccountName: row.Name,
-			AccountType: row.Type,
+			AccountID:        row.ID,
+			AccountCode:      row.Code,
+			AccountName:      row.Name,
+			AccountType:      row.Type,
+			AdjustmentDebit:  row.AdjustmentDebitMovements,
+			AdjustmentCredit: row.AdjustmentCreditMovements,
+		}
+
+		debitMovements, creditMovements := row.DebitMovements, row.CreditMovements
+		if includeAdjustments {
+			debitMovements += row.AdjustmentDebitMovements
+			creditMovements += row.AdjustmentCreditMovements
 		}
 
 		// Calculate net balance
-		netBalance := row.OpeningBalance + row.DebitMovements - row.CreditMovements
+		netBalance := row.OpeningBalance + debitMovements - creditMovements
 
 		// Assign to debit or credit column based on normal balance and net amount
 		if row.NormalBalance == "debit" {
@@ -635,7 +792,7 @@ func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID,
 			}
 		} else {
 			// Credit normal balance
-			netBalance = row.OpeningBalance + row.CreditMovements - row.DebitMovements
+			netBalance = row.OpeningBalance + creditMovements - debitMovements
 			if netBalance >= 0 {
 				entry.CreditBalance = netBalance
 			} else {
@@ -645,9 +802,11 @@ func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID,
 
 		totalDebit += entry.DebitBalance
 		totalCredit += entry.CreditBalance
+		report.AdjustmentDebit += entry.AdjustmentDebit
+		report.AdjustmentCredit += entry.AdjustmentCredit
 
-		// Only include accounts with non-zero balances
-		if entry.DebitBalance != 0 || entry.CreditBalance != 0 {
+		// Only include accounts with non-zero balances or adjustments
+		if entry.DebitBalance != 0 || entry.CreditBalance != 0 || entry.AdjustmentDebit != 0 || entry.AdjustmentCredit != 0 {
 			report.Accounts = append(report.Accounts, entry)
 		}
 	}
@@ -657,3 +816,176 @@ func (s *reportService) GetTrialBalance(ctx context.Context, tenantID uuid.UUID,
 
 	return report, nil
 }
+
+// GetRevenueBreakdown groups invoice line revenue by product category, item
+// type and customer segment. Lines without a catalogue product are typed by
+// their HSN/SAC code; customers without a dunning profile are "unassigned".
+func (s *reportService) GetRevenueBreakdown(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time) (*models.RevenueBreakdownReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
+	report := &models.RevenueBreakdownReport{
+		Period: models.ReportPeriod{
+			From: fromDate,
+			To:   toDate,
+		},
+		DataAsOf: asOf,
+	}
+
+	type revenueRow struct {
+		Month    string
+		Category string
+		ItemType string
+		Segment  string
+		Amount   float64
+	}
+
+	// Invoice-level discounts are spread over the lines in proportion to
+	// their amount so the groups add up to the taxable value
+	var rows []revenueRow
+	err := db.Raw(`
+		SELECT
+			to_char(i.invoice_date, 'YYYY-MM') as month,
+			COALESCE(NULLIF(p.category, ''), 'Uncategorised') as category,
+			CASE
+				WHEN p.type IS NOT NULL THEN p.type
+				WHEN ii.hsn_code LIKE '99%' THEN 'service'
+				WHEN ii.hsn_code != '' THEN 'goods'
+				ELSE 'other'
+			END as item_type,
+			COALESCE(dp.segment, 'unassigned') as segment,
+			COALESCE(SUM(CASE
+				WHEN i.subtotal > 0 THEN ii.amount * (i.subtotal - i.discount_amount) / i.subtotal
+				ELSE ii.amount
+			END), 0) as amount
+		FROM invoice_items ii
+		JOIN invoices i ON i.id = ii.invoice_id
+		LEFT JOIN products p ON p.id = ii.product_id
+		LEFT JOIN customer_dunning_profiles cdp ON cdp.tenant_id = i.tenant_id AND cdp.customer_id = i.customer_id
+		LEFT JOIN dunning_policies dp ON dp.id = cdp.policy_id
+		WHERE i.tenant_id = ? AND i.invoice_date >= ? AND i.invoice_date <= ?
+		AND i.status NOT IN ('draft', 'cancelled')
+		AND i.deleted_at IS NULL
+		GROUP BY 1, 2, 3, 4
+	`, tenantID, fromDate.Format("2006-01-02"), toDate.Format("2006-01-02")).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Every month of the period appears in the trend, even without sales
+	months := make(map[string]*models.RevenueTrendMonth)
+	var order []string
+	start := time.Date(fromDate.Year(), fromDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for m := start; !m.After(toDate); m = m.AddDate(0, 1, 0) {
+		month := &models.RevenueTrendMonth{
+			Month:      m.Format("2006-01"),
+			ByCategory: make(map[string]float64),
+			ByType:     make(map[string]float64),
+			BySegment:  make(map[string]float64),
+		}
+		months[month.Month] = month
+		order = append(order, month.Month)
+	}
+
+	byCategory := make(map[string]float64)
+	byType := make(map[string]float64)
+	bySegment := make(map[string]float64)
+
+	for _, row := range rows {
+		byCategory[row.Category] += row.Amount
+		byType[row.ItemType] += row.Amount
+		bySegment[row.Segment] += row.Amount
+		report.Total += row.Amount
+
+		if month, ok := months[row.Month]; ok {
+			month.ByCategory[row.Category] += row.Amount
+			month.ByType[row.ItemType] += row.Amount
+			month.BySegment[row.Segment] += row.Amount
+			month.Total += row.Amount
+		}
+	}
+
+	for _, month := range order {
+		report.Trend = append(report.Trend, *months[month])
+	}
+
+	report.ByCategory = revenueBreakdownLines(byCategory, report.Total)
+	report.ByType = revenueBreakdownLines(byType, report.Total)
+	report.BySegment = revenueBreakdownLines(bySegment, report.Total)
+
+	return report, nil
+}
+
+// GetTagReport totals revenue and spend by tag for the period, optionally
+// for a single tag
+func (s *reportService) GetTagReport(ctx context.Context, tenantID uuid.UUID, fromDate, toDate time.Time, tag string) (*models.TagReport, error) {
+	db, asOf := s.reads.Reader(ctx)
+
+	report := &models.TagReport{
+		Period: models.ReportPeriod{
+			From: fromDate,
+			To:   toDate,
+		},
+		Tags:     []models.TagReportLine{},
+		DataAsOf: asOf,
+	}
+
+	from := fromDate.Format("2006-01-02")
+	to := toDate.Format("2006-01-02")
+
+	err := db.Raw(`
+		SELECT
+			tag,
+			COALESCE(SUM(revenue), 0) as revenue,
+			COALESCE(SUM(spend), 0) as spend,
+			COALESCE(SUM(revenue), 0) - COALESCE(SUM(spend), 0) as net,
+			COUNT(*) FILTER (WHERE source = 'invoice') as invoice_count,
+			COUNT(*) FILTER (WHERE source = 'transaction') as transaction_count
+		FROM (
+			SELECT tag, 'invoice' as source, i.taxable_amount as revenue, 0 as spend
+			FROM invoices i, unnest(i.tags) as tag
+			WHERE i.tenant_id = ? AND i.invoice_date >= ? AND i.invoice_date <= ?
+			AND i.status NOT IN ('draft', 'cancelled')
+			AND i.deleted_at IS NULL
+
+			UNION ALL
+
+			SELECT
+				tag,
+				'transaction' as source,
+				CASE WHEN t.transaction_type = 'sale' THEN t.total_amount - t.tax_amount ELSE 0 END as revenue,
+				CASE WHEN t.transaction_type IN ('expense', 'purchase') THEN t.total_amount - t.tax_amount ELSE 0 END as spend
+			FROM transactions t, unnest(t.tags) as tag
+			WHERE t.tenant_id = ? AND t.transaction_date >= ? AND t.transaction_date <= ?
+			AND t.transaction_type IN ('sale', 'expense', 'purchase')
+			AND t.status = 'posted'
+			AND t.deleted_at IS NULL
+		) tagged
+		WHERE ? = '' OR tag = ?
+		GROUP BY tag
+		ORDER BY revenue + spend DESC, tag
+	`, tenantID, from, to, tenantID, from, to, tag, tag).Scan(&report.Tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// revenueBreakdownLines converts grouped revenue into lines ordered by amount
+func revenueBreakdownLines(groups map[string]float64, total float64) []models.RevenueBreakdownLine {
+	lines := make([]models.RevenueBreakdownLine, 0, len(groups))
+	for name, amount := range groups {
+		line := models.RevenueBreakdownLine{Name: name, Amount: amount}
+		if total != 0 {
+			line.Percent = amount / total * 100
+		}
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Amount == lines[j].Amount {
+			return lines[i].Name < lines[j].Name
+		}
+		return lines[i].Amount > lines[j].Amount
+	})
+	return lines
+}
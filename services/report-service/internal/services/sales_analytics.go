@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/gst"
+	"github.com/tesseract-nexus/bookkeeping-app/report-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxSalesAnalyticsLines caps the lines of a sales analytics report
+const maxSalesAnalyticsLines = 100
+
+var (
+	ErrInvalidSalesDimension = errors.New("dimension must be customer, product or state")
+	ErrInvalidSalesCompare   = errors.New("compare must be previous_period or previous_year")
+)
+
+// SalesAnalyticsQuery selects the sales analytics of a period
+type SalesAnalyticsQuery struct {
+	Dimension string
+	From      time.Time
+	To        time.Time
+	Compare   string // previous_period (the default) or previous_year
+	Limit     int    // Lines to list; zero lists them all, up to 100
+}
+
+// salesRow is the sales of one customer, product or place of supply from
+// invoices or credit notes
+type salesRow struct {
+	Key          string
+	Name         string
+	Code         string
+	InvoiceCount int64
+	Quantity     float64
+	Amount       float64
+	Tax          float64
+}
+
+// placeOfSupplySQL is an invoice's place of supply: abroad for exports, the
+// state of the customer's GSTIN when registered and their state otherwise
+const placeOfSupplySQL = `CASE
+	WHEN COALESCE(i.export_type, '') != '' THEN '96'
+	WHEN i.customer_gstin ~ '^[0-9]{2}' THEN substr(i.customer_gstin, 1, 2)
+	ELSE COALESCE(i.customer_state, '')
+END`
+
+// GetSalesAnalytics ranks the period's sales by customer, product or place
+// of supply and compares each with the previous period
+func (s *reportService) GetSalesAnalytics(ctx context.Context, tenantID uuid.UUID, query SalesAnalyticsQuery) (*models.SalesAnalyticsReport, error) {
+	if query.Dimension != models.SalesByCustomer && query.Dimension != models.SalesByProduct && query.Dimension != models.SalesByState {
+		return nil, ErrInvalidSalesDimension
+	}
+	if query.Compare == "" {
+		query.Compare = models.CompareSalesPreviousPeriod
+	}
+
+	var previous models.ReportPeriod
+	switch query.Compare {
+	case models.CompareSalesPreviousPeriod:
+		days := int(query.To.Sub(query.From).Hours()/24) + 1
+		previous.To = query.From.AddDate(0, 0, -1)
+		previous.From = previous.To.AddDate(0, 0, -(days - 1))
+	case models.CompareSalesPreviousYear:
+		previous.From = query.From.AddDate(-1, 0, 0)
+		previous.To = query.To.AddDate(-1, 0, 0)
+	default:
+		return nil, ErrInvalidSalesCompare
+	}
+	if query.Limit <= 0 || query.Limit > maxSalesAnalyticsLines {
+		query.Limit = maxSalesAnalyticsLines
+	}
+
+	db, asOf := s.reads.Reader(ctx)
+
+	current, err := salesLines(db, tenantID, query.Dimension, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	before, err := salesLines(db, tenantID, query.Dimension, previous.From, previous.To)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SalesAnalyticsReport{
+		Dimension:      query.Dimension,
+		Period:         models.ReportPeriod{From: query.From, To: query.To},
+		PreviousPeriod: previous,
+		Compare:        query.Compare,
+		Lines:          []models.SalesAnalyticsLine{},
+		DataAsOf:       asOf,
+	}
+
+	previousRanks := make(map[string]*models.SalesAnalyticsLine, len(before))
+	for i := range before {
+		report.PreviousTotal += before[i].Amount
+		previousRanks[before[i].Key] = &before[i]
+	}
+	for _, line := range current {
+		report.Total += line.Amount
+	}
+	report.Change = report.Total - report.PreviousTotal
+	report.ChangePercent = changePercent(report.Total, report.PreviousTotal)
+
+	for _, line := range current {
+		if len(report.Lines) == query.Limit {
+			break
+		}
+		if report.Total != 0 {
+			line.Share = line.Amount / report.Total * 100
+		}
+		if prior, ok := previousRanks[line.Key]; ok {
+			line.PreviousAmount = prior.Amount
+			line.PreviousRank = prior.Rank
+		}
+		line.Change = line.Amount - line.PreviousAmount
+		line.ChangePercent = changePercent(line.Amount, line.PreviousAmount)
+		report.Lines = append(report.Lines, line)
+	}
+
+	return report, nil
+}
+
+// salesLines totals the sales of the period by the dimension, ranked by
+// amount
+func salesLines(db *gorm.DB, tenantID uuid.UUID, dimension string, from, to time.Time) ([]models.SalesAnalyticsLine, error) {
+	var invoiced, credited []salesRow
+	var err error
+	switch dimension {
+	case models.SalesByCustomer:
+		invoiced, credited, err = customerSales(db, tenantID, from, to)
+	case models.SalesByProduct:
+		invoiced, credited, err = productSales(db, tenantID, from, to)
+	case models.SalesByState:
+		invoiced, credited, err = stateSales(db, tenantID, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]*models.SalesAnalyticsLine)
+	line := func(row salesRow) *models.SalesAnalyticsLine {
+		key, name := row.Key, row.Name
+		if dimension == models.SalesByState {
+			code, state := gst.PlaceOfSupply(row.Key)
+			key, name = code, state
+			if code == "" {
+				key = strings.ToLower(state)
+			}
+		}
+		l, ok := lines[key]
+		if !ok {
+			l = &models.SalesAnalyticsLine{Key: key, Name: name, Code: row.Code}
+			lines[key] = l
+		}
+		if l.Name == "" {
+			l.Name = name
+		}
+		if l.Code == "" {
+			l.Code = row.Code
+		}
+		return l
+	}
+
+	for _, row := range invoiced {
+		l := line(row)
+		l.InvoiceCount += row.InvoiceCount
+		l.Quantity += row.Quantity
+		l.Invoiced += row.Amount
+		l.Tax += row.Tax
+	}
+	for _, row := range credited {
+		l := line(row)
+		l.Credited += row.Amount
+		l.Tax -= row.Tax
+	}
+
+	ranked := make([]models.SalesAnalyticsLine, 0, len(lines))
+	for _, l := range lines {
+		l.Amount = l.Invoiced - l.Credited
+		ranked = append(ranked, *l)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Amount == ranked[j].Amount {
+			return ranked[i].Name < ranked[j].Name
+		}
+		return ranked[i].Amount > ranked[j].Amount
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return ranked, nil
+}
+
+// customerSales totals invoices and credit notes by customer
+func customerSales(db *gorm.DB, tenantID uuid.UUID, from, to time.Time) ([]salesRow, []salesRow, error) {
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	var invoiced []salesRow
+	err := db.Raw(`
+		SELECT
+			i.customer_id::text as key,
+			MAX(i.customer_name) as name,
+			MAX(COALESCE(i.customer_gstin, '')) as code,
+			COUNT(*) as invoice_count,
+			COALESCE(SUM(i.taxable_amount), 0) as amount,
+			COALESCE(SUM(i.total_tax), 0) as tax
+		FROM invoices i
+		WHERE i.tenant_id = ? AND i.invoice_date >= ? AND i.invoice_date <= ?
+		AND i.status NOT IN ('draft', 'cancelled')
+		AND i.deleted_at IS NULL
+		GROUP BY i.customer_id
+	`, tenantID, fromStr, toStr).Scan(&invoiced).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var credited []salesRow
+	err = db.Raw(`
+		SELECT
+			cn.customer_id::text as key,
+			MAX(cn.customer_name) as name,
+			COALESCE(SUM(cn.subtotal), 0) as amount,
+			COALESCE(SUM(cn.total_tax), 0) as tax
+		FROM credit_notes cn
+		WHERE cn.tenant_id = ? AND cn.credit_note_date >= ? AND cn.credit_note_date <= ?
+		AND cn.status NOT IN ('draft', 'cancelled')
+		AND cn.deleted_at IS NULL
+		GROUP BY cn.customer_id
+	`, tenantID, fromStr, toStr).Scan(&credited).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return invoiced, credited, nil
+}
+
+// productSales totals invoice and credit note lines by catalogue product,
+// or by description for lines without one. As in the revenue breakdown,
+// invoice-level discounts are spread over the lines in proportion to their
+// amount.
+func productSales(db *gorm.DB, tenantID uuid.UUID, from, to time.Time) ([]salesRow, []salesRow, error) {
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	var invoiced []salesRow
+	err := db.Raw(`
+		SELECT
+			COALESCE(ii.product_id::text, lower(trim(ii.description))) as key,
+			COALESCE(MAX(p.name), MIN(ii.description)) as name,
+			MAX(COALESCE(ii.hsn_code, '')) as code,
+			COUNT(DISTINCT i.id) as invoice_count,
+			COALESCE(SUM(ii.quantity), 0) as quantity,
+			COALESCE(SUM(CASE
+				WHEN i.subtotal > 0 THEN ii.amount * (i.subtotal - i.discount_amount) / i.subtotal
+				ELSE ii.amount
+			END), 0) as amount,
+			COALESCE(SUM(ii.cgst_amount + ii.sgst_amount + ii.igst_amount + ii.cess_amount), 0) as tax
+		FROM invoice_items ii
+		JOIN invoices i ON i.id = ii.invoice_id
+		LEFT JOIN products p ON p.id = ii.product_id
+		WHERE i.tenant_id = ? AND i.invoice_date >= ? AND i.invoice_date <= ?
+		AND i.status NOT IN ('draft', 'cancelled')
+		AND i.deleted_at IS NULL
+		GROUP BY 1
+	`, tenantID, fromStr, toStr).Scan(&invoiced).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var credited []salesRow
+	err = db.Raw(`
+		SELECT
+			COALESCE(cni.product_id::text, lower(trim(cni.description))) as key,
+			COALESCE(MAX(p.name), MIN(cni.description)) as name,
+			MAX(COALESCE(cni.hsn_sac_code, '')) as code,
+			COALESCE(SUM(cni.quantity * cni.unit_price), 0) as amount,
+			COALESCE(SUM(cni.cgst_amount + cni.sgst_amount + cni.igst_amount), 0) as tax
+		FROM credit_note_items cni
+		JOIN credit_notes cn ON cn.id = cni.credit_note_id
+		LEFT JOIN products p ON p.id = cni.product_id
+		WHERE cn.tenant_id = ? AND cn.credit_note_date >= ? AND cn.credit_note_date <= ?
+		AND cn.status NOT IN ('draft', 'cancelled')
+		AND cn.deleted_at IS NULL
+		GROUP BY 1
+	`, tenantID, fromStr, toStr).Scan(&credited).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return invoiced, credited, nil
+}
+
+// stateSales totals invoices and credit notes by place of supply. Credit
+// notes without one take that of the invoice they credit.
+func stateSales(db *gorm.DB, tenantID uuid.UUID, from, to time.Time) ([]salesRow, []salesRow, error) {
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	var invoiced []salesRow
+	err := db.Raw(`
+		SELECT
+			`+placeOfSupplySQL+` as key,
+			COUNT(*) as invoice_count,
+			COALESCE(SUM(i.taxable_amount), 0) as amount,
+			COALESCE(SUM(i.total_tax), 0) as tax
+		FROM invoices i
+		WHERE i.tenant_id = ? AND i.invoice_date >= ? AND i.invoice_date <= ?
+		AND i.status NOT IN ('draft', 'cancelled')
+		AND i.deleted_at IS NULL
+		GROUP BY 1
+	`, tenantID, fromStr, toStr).Scan(&invoiced).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var credited []salesRow
+	err = db.Raw(`
+		SELECT
+			COALESCE(NULLIF(cn.place_of_supply, ''), `+placeOfSupplySQL+`) as key,
+			COALESCE(SUM(cn.subtotal), 0) as amount,
+			COALESCE(SUM(cn.total_tax), 0) as tax
+		FROM credit_notes cn
+		LEFT JOIN invoices i ON i.id = cn.invoice_id
+		WHERE cn.tenant_id = ? AND cn.credit_note_date >= ? AND cn.credit_note_date <= ?
+		AND cn.status NOT IN ('draft', 'cancelled')
+		AND cn.deleted_at IS NULL
+		GROUP BY 1
+	`, tenantID, fromStr, toStr).Scan(&credited).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return invoiced, credited, nil
+}
+
+// changePercent is the change from previous to current in percent, zero
+// when there was nothing before
+func changePercent(current, previous float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return (current - previous) / previous * 100
+}
+
+// WriteSalesAnalyticsCSV writes the report as one row per line
+func WriteSalesAnalyticsCSV(w io.Writer, report *models.SalesAnalyticsReport) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{
+		"rank", "key", "name", "code", "invoice_count", "quantity",
+		"invoiced", "credited", "amount", "tax", "share_percent",
+		"previous_rank", "previous_amount", "change", "change_percent",
+	}); err != nil {
+		return err
+	}
+
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	for _, line := range report.Lines {
+		previousRank := ""
+		if line.PreviousRank > 0 {
+			previousRank = strconv.Itoa(line.PreviousRank)
+		}
+		if err := out.Write([]string{
+			strconv.Itoa(line.Rank), line.Key, line.Name, line.Code,
+			strconv.FormatInt(line.InvoiceCount, 10), strconv.FormatFloat(line.Quantity, 'f', -1, 64),
+			amount(line.Invoiced), amount(line.Credited), amount(line.Amount), amount(line.Tax), amount(line.Share),
+			previousRank, amount(line.PreviousAmount), amount(line.Change), amount(line.ChangePercent),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := out.Write([]string{
+		"", "", "Total", "", "", "",
+		"", "", amount(report.Total), "", "",
+		"", amount(report.PreviousTotal), amount(report.Change), amount(report.ChangePercent),
+	}); err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
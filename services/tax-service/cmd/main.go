@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/config"
 	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/handlers"
 	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
@@ -19,10 +22,33 @@ import (
 
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Connect to database
-	db, err := config.InitDB(cfg)
+	db, err := database.Connect(database.Config{
+		Host:            cfg.Database.Host,
+		Port:            cfg.Database.Port,
+		User:            cfg.Database.User,
+		Password:        cfg.Database.Password,
+		DBName:          cfg.Database.DBName,
+		SSLMode:         cfg.Database.SSLMode,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -38,10 +64,14 @@ func main() {
 		&models.TDSDeduction{},
 		&models.TCSRate{},
 		&models.TCSCollection{},
+		&models.TaxChallan{},
 		&models.InputTaxCredit{},
 		&models.ITCReconciliation{},
 		&models.GSTRFiling{},
 		&models.TaxCalculationCache{},
+		&models.GSTINFilingStatus{},
+		&models.ITCEligibilityRule{},
+		&database.ResourceVersion{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -51,30 +81,70 @@ func main() {
 	taxRepo := repository.NewTaxRepository(db)
 
 	// Initialize services
-	cacheTTL := time.Duration(cfg.CacheTTLMinutes) * time.Minute
-	taxCalculator := services.NewTaxCalculator(taxRepo, cacheTTL)
+	salesTaxProvider, err := clients.NewSalesTaxProvider(clients.SalesTaxProviderConfig{
+		Provider:           cfg.SalesTaxProvider,
+		TaxJarAPIKey:       cfg.TaxJarAPIKey,
+		TaxJarBaseURL:      cfg.TaxJarBaseURL,
+		AvalaraAccountID:   cfg.AvalaraAccountID,
+		AvalaraLicenseKey:  cfg.AvalaraLicenseKey,
+		AvalaraCompanyCode: cfg.AvalaraCompanyCode,
+		AvalaraBaseURL:     cfg.AvalaraBaseURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure sales tax provider: %v", err)
+	}
+	if salesTaxProvider == nil {
+		log.Println("No sales tax provider configured; US sales tax uses configured jurisdiction rates")
+	}
+	vatValidator := clients.NewVIESClient(cfg.VIESBaseURL)
+	taxCalculator := services.NewTaxCalculator(taxRepo, cfg.CacheTTL, vatValidator, salesTaxProvider)
+	challanService := services.NewChallanService(taxRepo)
+	gspClient := clients.NewGSPClient(cfg.GSPBaseURL, cfg.GSPClientID, cfg.GSPClientSecret)
+	if gspClient == nil {
+		log.Println("No GSP configured; counterparty filing status is not refreshed")
+	}
+	itcEligibilityService := services.NewITCEligibilityService(taxRepo)
+	filingStatusService := services.NewFilingStatusService(taxRepo, gspClient, cfg.FilingStatusRefreshTime)
+	invoiceClient := clients.NewInvoiceClient(cfg.InvoiceServiceURL)
+	gstr9Service := services.NewGSTR9Service(taxRepo, invoiceClient)
+	gstSetOffService := services.NewGSTSetOffService(taxRepo, invoiceClient)
+	gstReturnService := services.NewGSTReturnService(invoiceClient)
 
 	// Initialize handlers
 	taxHandler := handlers.NewTaxHandler(taxCalculator, taxRepo)
+	challanHandler := handlers.NewChallanHandler(challanService)
+	filingStatusHandler := handlers.NewFilingStatusHandler(filingStatusService)
+	itcEligibilityHandler := handlers.NewITCEligibilityHandler(itcEligibilityService)
+	gstr9Handler := handlers.NewGSTR9Handler(gstr9Service)
+	gstSetOffHandler := handlers.NewGSTSetOffHandler(gstSetOffService)
+	gstReturnHandler := handlers.NewGSTReturnHandler(gstReturnService)
 	healthHandler := handlers.NewHealthHandler(db)
 
+	// Re-check key suppliers' registration and GSTR-1 filings
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := filingStatusService.RefreshDue(context.Background()); err != nil {
+				log.Printf("Failed to refresh counterparty filing status: %v", err)
+			}
+		}
+	}()
+
 	// Setup router
-	isProduction := cfg.Environment == "production"
-	if isProduction {
-		gin.SetMode(gin.ReleaseMode)
-	}
+	gin.SetMode(cfg.App.Environment)
 	router := gin.Default()
 
-	// Allowed CORS origins
-	allowedOrigins := map[string]bool{
-		"https://app.bookkeep.in": true,
-		"https://www.bookkeep.in": true,
-		"https://bookkeep.in":     true,
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(router, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
-	if !isProduction {
-		allowedOrigins["http://localhost:3000"] = true
-		allowedOrigins["http://localhost:3001"] = true
-		allowedOrigins["exp://localhost:19000"] = true
+
+	// Allowed CORS origins
+	allowedOrigins := make(map[string]bool, len(cfg.CORS.AllowedOrigins))
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		allowedOrigins[origin] = true
 	}
 
 	// CORS middleware with security headers
@@ -130,22 +200,49 @@ func main() {
 			tds.POST("/calculate", taxHandler.CalculateTDS)
 			tds.GET("/rates", taxHandler.ListTDSRates)
 			tds.POST("/deductions", taxHandler.CreateTDSDeduction)
+			tds.POST("/deductions/payments/:payment_id/void", taxHandler.VoidTDSDeduction)
 			tds.GET("/deductions", taxHandler.ListTDSDeductions)
+			tds.GET("/dashboard", challanHandler.GetTDSDashboard)
 		}
 
 		// TCS endpoints
 		tcs := v1.Group("/tcs")
 		{
 			tcs.POST("/calculate", taxHandler.CalculateTCS)
+			tcs.POST("/collections", taxHandler.CreateTCSCollection)
 			tcs.GET("/collections", taxHandler.ListTCSCollections)
 		}
 
+		// TDS/TCS challan deposits
+		challans := v1.Group("/challans")
+		{
+			challans.POST("", challanHandler.CreateChallan)
+			challans.GET("", challanHandler.ListChallans)
+			challans.GET("/pending-ageing", challanHandler.GetPendingDepositAgeing)
+			challans.GET("/:id", challanHandler.GetChallan)
+			challans.POST("/:id/link", challanHandler.LinkChallan)
+		}
+
 		// ITC endpoints
 		itc := v1.Group("/itc")
 		{
 			itc.POST("", taxHandler.RecordITC)
 			itc.GET("", taxHandler.ListITC)
 			itc.GET("/summary", taxHandler.GetITCSummary)
+			itc.GET("/blocked", itcEligibilityHandler.GetBlockedCreditReport)
+			itc.POST("/eligibility", itcEligibilityHandler.CheckEligibility)
+			itc.GET("/rules", itcEligibilityHandler.ListRules)
+			itc.POST("/rules", itcEligibilityHandler.CreateRule)
+			itc.DELETE("/rules/:id", itcEligibilityHandler.DeleteRule)
+		}
+
+		// Vendor and customer registration and GSTR-1 filing status
+		filingStatus := v1.Group("/filing-status")
+		{
+			filingStatus.GET("", filingStatusHandler.ListFilingStatuses)
+			filingStatus.POST("", filingStatusHandler.WatchGSTIN)
+			filingStatus.GET("/:gstin", filingStatusHandler.CheckGSTIN)
+			filingStatus.DELETE("/:gstin", filingStatusHandler.UnwatchGSTIN)
 		}
 
 		// GSTR endpoints
@@ -153,6 +250,9 @@ func main() {
 		{
 			gstr.GET("/filings", taxHandler.ListGSTRFilings)
 			gstr.GET("/filings/:type/:period", taxHandler.GetGSTRFiling)
+			gstr.GET("/gstr1", gstReturnHandler.GetGSTR1)
+			gstr.GET("/gstr9/workpaper", gstr9Handler.GetWorkpaper)
+			gstr.POST("/gstr3b/set-off-simulation", gstSetOffHandler.Simulate)
 		}
 
 		// Jurisdiction management
@@ -161,10 +261,12 @@ func main() {
 			jurisdictions.GET("", taxHandler.ListJurisdictions)
 			jurisdictions.GET("/:id", taxHandler.GetJurisdiction)
 			jurisdictions.POST("", taxHandler.CreateJurisdiction)
+			jurisdictions.PUT("/:id/rounding", taxHandler.UpdateJurisdictionRounding)
 		}
 
-		// Product categories (HSN/SAC)
-		categories := v1.Group("/categories")
+		// Product categories (HSN/SAC), revalidated by mobile clients with
+		// conditional requests
+		categories := v1.Group("/categories", middleware.ConditionalRequests(database.NewVersionStore(db), "tax_categories"))
 		{
 			categories.GET("", taxHandler.ListProductCategories)
 			categories.POST("", taxHandler.CreateProductCategory)
@@ -173,13 +275,16 @@ func main() {
 
 	// Create server
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+		Addr:         cfg.GetServerAddress(),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Graceful shutdown
 	go func() {
-		log.Printf("Tax Service starting on port %s (env: %s)", cfg.Port, cfg.Environment)
+		log.Printf("Tax Service starting on %s (env: %s)", cfg.GetServerAddress(), cfg.App.Environment)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -192,7 +297,7 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -0,0 +1,163 @@
+package clients
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// DefaultAvalaraBaseURL is the AvaTax production API
+const DefaultAvalaraBaseURL = "https://rest.avatax.com"
+
+// avalaraFreightTaxCode is AvaTax's tax code for shipping charges
+const avalaraFreightTaxCode = "FR020100"
+
+type avalaraClient struct {
+	baseURL     string
+	accountID   string
+	licenseKey  string
+	companyCode string
+	httpClient  *http.Client
+}
+
+// NewAvalaraClient creates an Avalara AvaTax sales tax provider
+func NewAvalaraClient(baseURL, accountID, licenseKey, companyCode string) SalesTaxProvider {
+	if baseURL == "" {
+		baseURL = DefaultAvalaraBaseURL
+	}
+	if companyCode == "" {
+		companyCode = "DEFAULT"
+	}
+	return &avalaraClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		accountID:   accountID,
+		licenseKey:  licenseKey,
+		companyCode: companyCode,
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *avalaraClient) Name() string {
+	return SalesTaxProviderAvalara
+}
+
+type avalaraAddress struct {
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Country    string `json:"country"`
+	PostalCode string `json:"postalCode,omitempty"`
+}
+
+type avalaraLine struct {
+	Number   string  `json:"number"`
+	Quantity float64 `json:"quantity"`
+	Amount   float64 `json:"amount"`
+	TaxCode  string  `json:"taxCode,omitempty"`
+}
+
+type avalaraTransactionRequest struct {
+	Type         string                    `json:"type"`
+	CompanyCode  string                    `json:"companyCode"`
+	Date         string                    `json:"date"`
+	CustomerCode string                    `json:"customerCode"`
+	ExemptionNo  string                    `json:"exemptionNo,omitempty"`
+	Addresses    map[string]avalaraAddress `json:"addresses"`
+	Lines        []avalaraLine             `json:"lines"`
+	Commit       bool                      `json:"commit"`
+}
+
+type avalaraTransactionResponse struct {
+	TotalTax     float64 `json:"totalTax"`
+	TotalTaxable float64 `json:"totalTaxable"`
+	Summary      []struct {
+		JurisType string  `json:"jurisType"`
+		JurisName string  `json:"jurisName"`
+		TaxName   string  `json:"taxName"`
+		Rate      float64 `json:"rate"`
+		Taxable   float64 `json:"taxable"`
+		Tax       float64 `json:"tax"`
+	} `json:"summary"`
+}
+
+func (c *avalaraClient) CalculateSalesTax(ctx context.Context, req SalesTaxRequest) (*SalesTaxResult, error) {
+	customerCode := req.CustomerCode
+	if customerCode == "" {
+		customerCode = "GUEST"
+	}
+
+	// A SalesOrder is a quote; nothing is recorded on the AvaTax side
+	body := avalaraTransactionRequest{
+		Type:         "SalesOrder",
+		CompanyCode:  c.companyCode,
+		Date:         time.Now().Format("2006-01-02"),
+		CustomerCode: customerCode,
+		ExemptionNo:  req.ExemptionCode,
+		Addresses: map[string]avalaraAddress{
+			"shipFrom": toAvalaraAddress(req.From),
+			"shipTo":   toAvalaraAddress(req.To),
+		},
+	}
+	for i, line := range req.Lines {
+		body.Lines = append(body.Lines, avalaraLine{
+			Number:   strconv.Itoa(i + 1),
+			Quantity: line.Quantity,
+			Amount:   line.Amount,
+			TaxCode:  line.TaxCode,
+		})
+	}
+	if req.ShippingAmount > 0 {
+		body.Lines = append(body.Lines, avalaraLine{
+			Number:   "shipping",
+			Quantity: 1,
+			Amount:   req.ShippingAmount,
+			TaxCode:  avalaraFreightTaxCode,
+		})
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.accountID+":"+c.licenseKey)))
+
+	var resp avalaraTransactionResponse
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/api/v2/transactions/create", header, body, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &SalesTaxResult{
+		Provider:      SalesTaxProviderAvalara,
+		TaxableAmount: resp.TotalTaxable,
+		TaxAmount:     resp.TotalTax,
+		Breakdown:     []models.TaxBreakdown{},
+	}
+	for _, s := range resp.Summary {
+		result.Breakdown = appendSalesTaxComponent(result.Breakdown, s.JurisName, avalaraTaxType(s.JurisType), s.Rate, s.Taxable, s.Tax)
+	}
+
+	return result, nil
+}
+
+func toAvalaraAddress(addr models.AddressInput) avalaraAddress {
+	return avalaraAddress{
+		City:       addr.City,
+		Region:     stateCodeOf(addr),
+		Country:    countryCodeOf(addr),
+		PostalCode: addr.Zip,
+	}
+}
+
+func avalaraTaxType(jurisType string) models.TaxType {
+	switch jurisType {
+	case "State":
+		return models.TaxTypeState
+	case "County":
+		return models.TaxTypeCounty
+	case "City":
+		return models.TaxTypeCity
+	default:
+		return models.TaxTypeSpecial
+	}
+}
@@ -0,0 +1,127 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrGSPUnavailable is returned when the GST Suvidha Provider cannot answer
+var ErrGSPUnavailable = errors.New("GSP unavailable")
+
+// ErrGSPNotConfigured is returned when no GSP credentials are configured
+var ErrGSPNotConfigured = errors.New("GSP not configured")
+
+// TaxpayerDetails is the GST portal's registration record for a GSTIN
+type TaxpayerDetails struct {
+	GSTIN       string     `json:"gstin"`
+	LegalName   string     `json:"legalName"`
+	Status      string     `json:"status"` // Active, Cancelled, Suspended
+	CancelledOn *time.Time `json:"cancelledOn,omitempty"`
+}
+
+// ReturnFiling is one return filed by a GSTIN
+type ReturnFiling struct {
+	ReturnType string    `json:"returnType"` // GSTR1, GSTR3B, ...
+	Period     string    `json:"period"`     // MMYYYY
+	FiledOn    time.Time `json:"filedOn"`
+	Status     string    `json:"status"`
+}
+
+// GSTFilingSource looks up registration and return filing records of other
+// taxpayers' GSTINs
+type GSTFilingSource interface {
+	GetTaxpayer(ctx context.Context, gstin string) (*TaxpayerDetails, error)
+	// ListReturnFilings returns the returns filed for a financial year (e.g. "2024-25")
+	ListReturnFilings(ctx context.Context, gstin, financialYear string) ([]ReturnFiling, error)
+}
+
+type gspClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// NewGSPClient creates a GSP public search API client. It returns nil when
+// no credentials are configured.
+func NewGSPClient(baseURL, clientID, clientSecret string) GSTFilingSource {
+	if baseURL == "" || clientID == "" {
+		return nil
+	}
+	return &gspClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *gspClient) GetTaxpayer(ctx context.Context, gstin string) (*TaxpayerDetails, error) {
+	var result struct {
+		GSTIN       string `json:"gstin"`
+		LegalName   string `json:"lgnm"`
+		Status      string `json:"sts"`
+		CancelledOn string `json:"cxdt"` // DD/MM/YYYY, empty unless cancelled
+	}
+
+	endpoint := fmt.Sprintf("%s/commonapi/v1.1/search?action=TP&gstin=%s", c.baseURL, url.QueryEscape(gstin))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, c.header(), nil, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGSPUnavailable, err)
+	}
+
+	details := &TaxpayerDetails{
+		GSTIN:     gstin,
+		LegalName: strings.TrimSpace(result.LegalName),
+		Status:    strings.TrimSpace(result.Status),
+	}
+	if result.CancelledOn != "" {
+		if t, err := time.Parse("02/01/2006", result.CancelledOn); err == nil {
+			details.CancelledOn = &t
+		}
+	}
+	return details, nil
+}
+
+func (c *gspClient) ListReturnFilings(ctx context.Context, gstin, financialYear string) ([]ReturnFiling, error) {
+	var result struct {
+		Filed []struct {
+			ReturnType string `json:"rtntype"`
+			Period     string `json:"ret_prd"`
+			FiledOn    string `json:"dof"` // DD-MM-YYYY
+			Status     string `json:"status"`
+		} `json:"EFiledlist"`
+	}
+
+	endpoint := fmt.Sprintf("%s/commonapi/v1.0/returns?action=RETTRACK&gstin=%s&fy=%s",
+		c.baseURL, url.QueryEscape(gstin), url.QueryEscape(financialYear))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, c.header(), nil, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGSPUnavailable, err)
+	}
+
+	filings := make([]ReturnFiling, 0, len(result.Filed))
+	for _, f := range result.Filed {
+		filedOn, err := time.Parse("02-01-2006", f.FiledOn)
+		if err != nil {
+			continue
+		}
+		filings = append(filings, ReturnFiling{
+			ReturnType: strings.ReplaceAll(strings.ToUpper(f.ReturnType), "-", ""),
+			Period:     f.Period,
+			FiledOn:    filedOn,
+			Status:     f.Status,
+		})
+	}
+	return filings, nil
+}
+
+func (c *gspClient) header() http.Header {
+	header := http.Header{}
+	header.Set("client_id", c.clientID)
+	header.Set("client_secret", c.clientSecret)
+	return header
+}
@@ -0,0 +1,297 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// GSTTotals is the taxable value and GST of a group of documents, as
+// returned by the invoice service
+type GSTTotals struct {
+	Taxable decimal.Decimal `json:"taxable_value"`
+	CGST    decimal.Decimal `json:"cgst"`
+	SGST    decimal.Decimal `json:"sgst"`
+	IGST    decimal.Decimal `json:"igst"`
+	Cess    decimal.Decimal `json:"cess"`
+}
+
+// Tax is the total GST of the group
+func (t GSTTotals) Tax() decimal.Decimal {
+	return t.CGST.Add(t.SGST).Add(t.IGST).Add(t.Cess)
+}
+
+// AnnualGSTBooks is the invoice service's books totals for a financial year
+type AnnualGSTBooks struct {
+	FinancialYear   string    `json:"financial_year"`
+	AmendmentsUntil time.Time `json:"amendments_until"`
+
+	B2B                   GSTTotals `json:"b2b"`
+	B2C                   GSTTotals `json:"b2c"`
+	ExportsWithPayment    GSTTotals `json:"exports_with_payment"`
+	ExportsWithoutPayment GSTTotals `json:"exports_without_payment"`
+	NilExempt             GSTTotals `json:"nil_exempt"`
+	Advances              GSTTotals `json:"advances"`
+	CreditNotes           GSTTotals `json:"credit_notes"`
+
+	ReverseCharge    GSTTotals `json:"reverse_charge"`
+	ReverseChargeITC GSTTotals `json:"reverse_charge_itc"`
+	InwardITC        GSTTotals `json:"inward_itc"`
+	BlockedITC       GSTTotals `json:"blocked_itc"`
+
+	InvoicesAfterYearEnd    GSTTotals `json:"invoices_after_year_end"`
+	CreditNotesAfterYearEnd GSTTotals `json:"credit_notes_after_year_end"`
+}
+
+// MonthlyGSTBooks is the invoice service's books totals for a month
+type MonthlyGSTBooks struct {
+	Period string `json:"period"`
+
+	B2B                   GSTTotals `json:"b2b"`
+	B2C                   GSTTotals `json:"b2c"`
+	ExportsWithPayment    GSTTotals `json:"exports_with_payment"`
+	ExportsWithoutPayment GSTTotals `json:"exports_without_payment"`
+	NilExempt             GSTTotals `json:"nil_exempt"`
+	Advances              GSTTotals `json:"advances"`
+	CreditNotes           GSTTotals `json:"credit_notes"`
+
+	ReverseCharge    GSTTotals `json:"reverse_charge"`
+	ReverseChargeITC GSTTotals `json:"reverse_charge_itc"`
+	InwardITC        GSTTotals `json:"inward_itc"`
+	BlockedITC       GSTTotals `json:"blocked_itc"`
+}
+
+// Invoice is an invoice issued to a customer, as returned by the invoice
+// service. Export invoices have an ExportType, WPAY or WOPAY.
+type Invoice struct {
+	InvoiceNumber      string          `json:"invoice_number"`
+	InvoiceDate        time.Time       `json:"invoice_date"`
+	CustomerGSTIN      string          `json:"customer_gstin"`
+	CustomerState      string          `json:"customer_state"`
+	TotalAmount        decimal.Decimal `json:"total_amount"`
+	IGSTAmount         decimal.Decimal `json:"igst_amount"`
+	ExportType         string          `json:"export_type"`
+	PortCode           string          `json:"port_code"`
+	ShippingBillNumber string          `json:"shipping_bill_number"`
+	ShippingBillDate   *time.Time      `json:"shipping_bill_date"`
+	Items              []DocumentItem  `json:"items"`
+}
+
+// CreditNote is a credit note issued to a customer, as returned by the
+// invoice service. Notes raised against an invoice carry the customer's
+// GSTIN and the invoice's export type and value.
+type CreditNote struct {
+	CreditNoteNumber  string           `json:"credit_note_number"`
+	CreditNoteDate    time.Time        `json:"credit_note_date"`
+	Reason            string           `json:"reason"`
+	CustomerGSTIN     string           `json:"customer_gstin"`
+	PlaceOfSupply     string           `json:"place_of_supply"`
+	TotalAmount       decimal.Decimal  `json:"total_amount"`
+	IGSTAmount        decimal.Decimal  `json:"igst_amount"`
+	InvoiceExportType string           `json:"invoice_export_type"`
+	InvoiceValue      decimal.Decimal  `json:"invoice_value"`
+	Items             []CreditNoteItem `json:"items"`
+}
+
+// CreditNoteItem is a line of a credit note
+type CreditNoteItem struct {
+	Description string          `json:"description"`
+	HSNCode     string          `json:"hsn_sac_code"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	CGSTRate    decimal.Decimal `json:"cgst_rate"`
+	CGSTAmount  decimal.Decimal `json:"cgst_amount"`
+	SGSTRate    decimal.Decimal `json:"sgst_rate"`
+	SGSTAmount  decimal.Decimal `json:"sgst_amount"`
+	IGSTRate    decimal.Decimal `json:"igst_rate"`
+	IGSTAmount  decimal.Decimal `json:"igst_amount"`
+	CessAmount  decimal.Decimal `json:"cess_amount"`
+	LineTotal   decimal.Decimal `json:"line_total"`
+}
+
+// DocumentItem returns the line as a document line, its taxable amount
+// being what is left of the line total after tax
+func (i CreditNoteItem) DocumentItem() DocumentItem {
+	return DocumentItem{
+		Description: i.Description,
+		HSNCode:     i.HSNCode,
+		Quantity:    i.Quantity,
+		Amount:      i.LineTotal.Sub(i.CGSTAmount).Sub(i.SGSTAmount).Sub(i.IGSTAmount).Sub(i.CessAmount),
+		CGSTRate:    i.CGSTRate,
+		CGSTAmount:  i.CGSTAmount,
+		SGSTRate:    i.SGSTRate,
+		SGSTAmount:  i.SGSTAmount,
+		IGSTRate:    i.IGSTRate,
+		IGSTAmount:  i.IGSTAmount,
+		CessAmount:  i.CessAmount,
+	}
+}
+
+// DebitNote is a debit note raised on a vendor for a purchase return, as
+// returned by the invoice service
+type DebitNote struct {
+	DebitNoteNumber string          `json:"debit_note_number"`
+	DebitNoteDate   time.Time       `json:"debit_note_date"`
+	VendorGSTIN     string          `json:"vendor_gstin"`
+	PlaceOfSupply   string          `json:"place_of_supply"`
+	TotalAmount     decimal.Decimal `json:"total_amount"`
+	Items           []DocumentItem  `json:"items"`
+}
+
+// DocumentItem is a line of an invoice or a note, with its taxable amount
+type DocumentItem struct {
+	Description string          `json:"description"`
+	HSNCode     string          `json:"hsn_code"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Unit        string          `json:"unit"`
+	Amount      decimal.Decimal `json:"amount"`
+	CGSTRate    decimal.Decimal `json:"cgst_rate"`
+	CGSTAmount  decimal.Decimal `json:"cgst_amount"`
+	SGSTRate    decimal.Decimal `json:"sgst_rate"`
+	SGSTAmount  decimal.Decimal `json:"sgst_amount"`
+	IGSTRate    decimal.Decimal `json:"igst_rate"`
+	IGSTAmount  decimal.Decimal `json:"igst_amount"`
+	CessAmount  decimal.Decimal `json:"cess_amount"`
+}
+
+// AdvanceTax is the tax on advances received (AT) and refunded (TXPD) in
+// a month, by place of supply and rate, as returned by the invoice service
+type AdvanceTax struct {
+	Received []AdvanceTaxRow `json:"at"`
+	Refunded []AdvanceTaxRow `json:"txpd"`
+}
+
+// AdvanceTaxRow is the tax on advances at one place of supply and rate
+type AdvanceTaxRow struct {
+	PlaceOfSupply string          `json:"pos"`
+	Rate          decimal.Decimal `json:"rt"`
+	Taxable       decimal.Decimal `json:"ad_amt"`
+	IGST          decimal.Decimal `json:"iamt"`
+	CGST          decimal.Decimal `json:"camt"`
+	SGST          decimal.Decimal `json:"samt"`
+	Cess          decimal.Decimal `json:"csamt"`
+}
+
+// InvoiceClient reads sales and purchase totals from the invoice service
+type InvoiceClient interface {
+	// GetAnnualGSTBooks returns a financial year's books totals, on behalf
+	// of the caller identified by authorization (the incoming Authorization
+	// header)
+	GetAnnualGSTBooks(ctx context.Context, authorization, financialYear string) (*AnnualGSTBooks, error)
+	// GetMonthlyGSTBooks returns a month's books totals, for a period such
+	// as 072025
+	GetMonthlyGSTBooks(ctx context.Context, authorization, period string) (*MonthlyGSTBooks, error)
+	// GetInvoices returns the invoices issued in a month, for a period such
+	// as 072025
+	GetInvoices(ctx context.Context, authorization, period string) ([]Invoice, error)
+	// GetCreditNotes returns the credit notes issued in a month, for a
+	// period such as 072025
+	GetCreditNotes(ctx context.Context, authorization, period string) ([]CreditNote, error)
+	// GetAdvanceTax returns the tax on advances received and refunded in a
+	// month, for a period such as 072025
+	GetAdvanceTax(ctx context.Context, authorization, period string) (*AdvanceTax, error)
+	// GetDebitNotes returns the debit notes issued in a month, for a period
+	// such as 072025
+	GetDebitNotes(ctx context.Context, authorization, period string) ([]DebitNote, error)
+}
+
+type invoiceClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewInvoiceClient creates an invoice service client
+func NewInvoiceClient(baseURL string) InvoiceClient {
+	return &invoiceClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *invoiceClient) GetAnnualGSTBooks(ctx context.Context, authorization, financialYear string) (*AnnualGSTBooks, error) {
+	var result struct {
+		Data AnnualGSTBooks `json:"data"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	endpoint := fmt.Sprintf("%s/api/v1/gst-annual/books?financial_year=%s", c.baseURL, url.QueryEscape(financialYear))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, header, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+func (c *invoiceClient) GetMonthlyGSTBooks(ctx context.Context, authorization, period string) (*MonthlyGSTBooks, error) {
+	var result struct {
+		Data MonthlyGSTBooks `json:"data"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	endpoint := fmt.Sprintf("%s/api/v1/gst-monthly/books?period=%s", c.baseURL, url.QueryEscape(period))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, header, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+func (c *invoiceClient) GetInvoices(ctx context.Context, authorization, period string) ([]Invoice, error) {
+	var result struct {
+		Data []Invoice `json:"data"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	endpoint := fmt.Sprintf("%s/api/v1/gst-monthly/invoices?period=%s", c.baseURL, url.QueryEscape(period))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, header, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+func (c *invoiceClient) GetCreditNotes(ctx context.Context, authorization, period string) ([]CreditNote, error) {
+	var result struct {
+		Data []CreditNote `json:"data"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	endpoint := fmt.Sprintf("%s/api/v1/gst-monthly/credit-notes?period=%s", c.baseURL, url.QueryEscape(period))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, header, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+func (c *invoiceClient) GetAdvanceTax(ctx context.Context, authorization, period string) (*AdvanceTax, error) {
+	var result struct {
+		Data AdvanceTax `json:"data"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	endpoint := fmt.Sprintf("%s/api/v1/advances/returns?period=%s", c.baseURL, url.QueryEscape(period))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, header, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+func (c *invoiceClient) GetDebitNotes(ctx context.Context, authorization, period string) ([]DebitNote, error) {
+	var result struct {
+		Data []DebitNote `json:"data"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", authorization)
+	endpoint := fmt.Sprintf("%s/api/v1/gst-monthly/debit-notes?period=%s", c.baseURL, url.QueryEscape(period))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, header, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
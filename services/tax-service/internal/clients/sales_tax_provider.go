@@ -0,0 +1,84 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// Sales tax providers that can be configured
+const (
+	SalesTaxProviderTaxJar  = "taxjar"
+	SalesTaxProviderAvalara = "avalara"
+)
+
+// SalesTaxLine is a line sent to an external sales tax provider
+type SalesTaxLine struct {
+	ID        string
+	Quantity  float64
+	UnitPrice float64
+	Amount    float64
+	TaxCode   string // Provider product tax code, from the product tax category
+}
+
+// SalesTaxRequest is a provider-neutral sales tax quote request
+type SalesTaxRequest struct {
+	TenantID       string
+	CustomerCode   string
+	From           models.AddressInput
+	To             models.AddressInput
+	ShippingAmount float64
+	Lines          []SalesTaxLine
+	ExemptionCode  string
+}
+
+// SalesTaxResult is the tax an external provider calculated
+type SalesTaxResult struct {
+	Provider      string
+	TaxableAmount float64
+	TaxAmount     float64
+	Breakdown     []models.TaxBreakdown
+}
+
+// SalesTaxProvider calculates US sales tax through an external service such
+// as TaxJar or Avalara, which track the thousands of local rates and nexus
+// rules that are impractical to maintain here.
+type SalesTaxProvider interface {
+	Name() string
+	CalculateSalesTax(ctx context.Context, req SalesTaxRequest) (*SalesTaxResult, error)
+}
+
+// SalesTaxProviderConfig selects and configures a sales tax provider
+type SalesTaxProviderConfig struct {
+	Provider string
+
+	TaxJarAPIKey  string
+	TaxJarBaseURL string
+
+	AvalaraAccountID   string
+	AvalaraLicenseKey  string
+	AvalaraCompanyCode string
+	AvalaraBaseURL     string
+}
+
+// NewSalesTaxProvider builds the configured provider. It returns nil when no
+// provider is configured.
+func NewSalesTaxProvider(cfg SalesTaxProviderConfig) (SalesTaxProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case SalesTaxProviderTaxJar:
+		if cfg.TaxJarAPIKey == "" {
+			return nil, fmt.Errorf("TAXJAR_API_KEY is required for the %s provider", cfg.Provider)
+		}
+		return NewTaxJarClient(cfg.TaxJarBaseURL, cfg.TaxJarAPIKey), nil
+	case SalesTaxProviderAvalara:
+		if cfg.AvalaraAccountID == "" || cfg.AvalaraLicenseKey == "" {
+			return nil, fmt.Errorf("AVALARA_ACCOUNT_ID and AVALARA_LICENSE_KEY are required for the %s provider", cfg.Provider)
+		}
+		return NewAvalaraClient(cfg.AvalaraBaseURL, cfg.AvalaraAccountID, cfg.AvalaraLicenseKey, cfg.AvalaraCompanyCode), nil
+	default:
+		return nil, fmt.Errorf("unknown sales tax provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,163 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// DefaultTaxJarBaseURL is TaxJar's production API
+const DefaultTaxJarBaseURL = "https://api.taxjar.com/v2"
+
+type taxJarClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewTaxJarClient creates a TaxJar sales tax provider
+func NewTaxJarClient(baseURL, apiKey string) SalesTaxProvider {
+	if baseURL == "" {
+		baseURL = DefaultTaxJarBaseURL
+	}
+	return &taxJarClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *taxJarClient) Name() string {
+	return SalesTaxProviderTaxJar
+}
+
+type taxJarLineItem struct {
+	ID             string  `json:"id"`
+	Quantity       float64 `json:"quantity"`
+	UnitPrice      float64 `json:"unit_price"`
+	ProductTaxCode string  `json:"product_tax_code,omitempty"`
+}
+
+type taxJarRequest struct {
+	FromCountry   string           `json:"from_country,omitempty"`
+	FromZip       string           `json:"from_zip,omitempty"`
+	FromState     string           `json:"from_state,omitempty"`
+	FromCity      string           `json:"from_city,omitempty"`
+	ToCountry     string           `json:"to_country"`
+	ToZip         string           `json:"to_zip,omitempty"`
+	ToState       string           `json:"to_state,omitempty"`
+	ToCity        string           `json:"to_city,omitempty"`
+	Amount        float64          `json:"amount"`
+	Shipping      float64          `json:"shipping"`
+	CustomerID    string           `json:"customer_id,omitempty"`
+	ExemptionType string           `json:"exemption_type,omitempty"`
+	LineItems     []taxJarLineItem `json:"line_items"`
+}
+
+type taxJarResponse struct {
+	Tax struct {
+		TaxableAmount   float64 `json:"taxable_amount"`
+		AmountToCollect float64 `json:"amount_to_collect"`
+		Breakdown       *struct {
+			StateTaxableAmount            float64 `json:"state_taxable_amount"`
+			StateTaxRate                  float64 `json:"state_tax_rate"`
+			StateTaxCollectable           float64 `json:"state_tax_collectable"`
+			CountyTaxableAmount           float64 `json:"county_taxable_amount"`
+			CountyTaxRate                 float64 `json:"county_tax_rate"`
+			CountyTaxCollectable          float64 `json:"county_tax_collectable"`
+			CityTaxableAmount             float64 `json:"city_taxable_amount"`
+			CityTaxRate                   float64 `json:"city_tax_rate"`
+			CityTaxCollectable            float64 `json:"city_tax_collectable"`
+			SpecialDistrictTaxableAmount  float64 `json:"special_district_taxable_amount"`
+			SpecialTaxRate                float64 `json:"special_tax_rate"`
+			SpecialDistrictTaxCollectable float64 `json:"special_district_tax_collectable"`
+		} `json:"breakdown"`
+		Jurisdictions struct {
+			State  string `json:"state"`
+			County string `json:"county"`
+			City   string `json:"city"`
+		} `json:"jurisdictions"`
+	} `json:"tax"`
+}
+
+func (c *taxJarClient) CalculateSalesTax(ctx context.Context, req SalesTaxRequest) (*SalesTaxResult, error) {
+	body := taxJarRequest{
+		FromCountry:   countryCodeOf(req.From),
+		FromZip:       req.From.Zip,
+		FromState:     stateCodeOf(req.From),
+		FromCity:      req.From.City,
+		ToCountry:     countryCodeOf(req.To),
+		ToZip:         req.To.Zip,
+		ToState:       stateCodeOf(req.To),
+		ToCity:        req.To.City,
+		Shipping:      req.ShippingAmount,
+		CustomerID:    req.CustomerCode,
+		ExemptionType: req.ExemptionCode,
+	}
+	for _, line := range req.Lines {
+		body.Amount += line.Amount
+		body.LineItems = append(body.LineItems, taxJarLineItem{
+			ID:             line.ID,
+			Quantity:       line.Quantity,
+			UnitPrice:      line.UnitPrice,
+			ProductTaxCode: line.TaxCode,
+		})
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var resp taxJarResponse
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/taxes", header, body, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &SalesTaxResult{
+		Provider:      SalesTaxProviderTaxJar,
+		TaxableAmount: resp.Tax.TaxableAmount,
+		TaxAmount:     resp.Tax.AmountToCollect,
+		Breakdown:     []models.TaxBreakdown{},
+	}
+
+	if b := resp.Tax.Breakdown; b != nil {
+		j := resp.Tax.Jurisdictions
+		result.Breakdown = appendSalesTaxComponent(result.Breakdown, j.State, models.TaxTypeState, b.StateTaxRate, b.StateTaxableAmount, b.StateTaxCollectable)
+		result.Breakdown = appendSalesTaxComponent(result.Breakdown, j.County, models.TaxTypeCounty, b.CountyTaxRate, b.CountyTaxableAmount, b.CountyTaxCollectable)
+		result.Breakdown = appendSalesTaxComponent(result.Breakdown, j.City, models.TaxTypeCity, b.CityTaxRate, b.CityTaxableAmount, b.CityTaxCollectable)
+		result.Breakdown = appendSalesTaxComponent(result.Breakdown, "Special district", models.TaxTypeSpecial, b.SpecialTaxRate, b.SpecialDistrictTaxableAmount, b.SpecialDistrictTaxCollectable)
+	}
+
+	return result, nil
+}
+
+// appendSalesTaxComponent adds a jurisdiction level to the breakdown when it
+// has tax. Providers return rates as fractions; the breakdown uses percent.
+func appendSalesTaxComponent(breakdown []models.TaxBreakdown, name string, taxType models.TaxType, rate, taxable, tax float64) []models.TaxBreakdown {
+	if tax == 0 {
+		return breakdown
+	}
+	return append(breakdown, models.TaxBreakdown{
+		JurisdictionName: name,
+		TaxType:          string(taxType),
+		Rate:             rate * 100,
+		TaxableAmount:    taxable,
+		TaxAmount:        tax,
+	})
+}
+
+func countryCodeOf(addr models.AddressInput) string {
+	if addr.CountryCode != "" {
+		return addr.CountryCode
+	}
+	return addr.Country
+}
+
+func stateCodeOf(addr models.AddressInput) string {
+	if addr.StateCode != "" {
+		return addr.StateCode
+	}
+	return addr.State
+}
@@ -0,0 +1,77 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultVIESBaseURL is the European Commission's VIES REST endpoint
+const DefaultVIESBaseURL = "https://ec.europa.eu/taxation_customs/vies/rest-api"
+
+// ErrVIESUnavailable is returned when VIES (or the member state behind it)
+// cannot answer; the number is neither confirmed nor rejected.
+var ErrVIESUnavailable = errors.New("VIES validation unavailable")
+
+// VATNumberCheck is the VIES answer for a VAT number
+type VATNumberCheck struct {
+	CountryCode string    `json:"countryCode"`
+	VATNumber   string    `json:"vatNumber"`
+	Valid       bool      `json:"valid"`
+	Name        string    `json:"name,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+// VATValidator validates EU VAT numbers
+type VATValidator interface {
+	ValidateVATNumber(ctx context.Context, countryCode, vatNumber string) (*VATNumberCheck, error)
+}
+
+type viesClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewVIESClient creates a VIES VAT number validator
+func NewVIESClient(baseURL string) VATValidator {
+	if baseURL == "" {
+		baseURL = DefaultVIESBaseURL
+	}
+	return &viesClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *viesClient) ValidateVATNumber(ctx context.Context, countryCode, vatNumber string) (*VATNumberCheck, error) {
+	var result struct {
+		IsValid   bool   `json:"isValid"`
+		UserError string `json:"userError"`
+		Name      string `json:"name"`
+		Address   string `json:"address"`
+	}
+
+	endpoint := fmt.Sprintf("%s/ms/%s/vat/%s", c.baseURL, url.PathEscape(countryCode), url.PathEscape(vatNumber))
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, endpoint, nil, nil, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVIESUnavailable, err)
+	}
+
+	// VIES reports member state outages through userError rather than status
+	if !result.IsValid && result.UserError != "" && result.UserError != "VALID" && result.UserError != "INVALID" {
+		return nil, fmt.Errorf("%w: %s", ErrVIESUnavailable, result.UserError)
+	}
+
+	return &VATNumberCheck{
+		CountryCode: countryCode,
+		VATNumber:   vatNumber,
+		Valid:       result.IsValid,
+		Name:        strings.TrimSpace(result.Name),
+		Address:     strings.TrimSpace(result.Address),
+		CheckedAt:   time.Now(),
+	}, nil
+}
@@ -1,87 +1,93 @@
 package config
 
 import (
-	"fmt"
-	"os"
-	"strconv"
+	"time"
 
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	sharedConfig "github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
 )
 
-// Config holds application configuration
+// Config holds tax service configuration
 type Config struct {
-	// Database
-	DBHost     string
-	DBPort     int
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
+	*sharedConfig.Config
 
-	// Server
-	Port            string
-	Environment     string
-	LogLevel        string
-	CacheTTLMinutes int
+	// CacheTTL is how long tax calculations are cached
+	CacheTTL time.Duration
 
 	// Service URLs
 	InvoiceServiceURL  string
 	CustomerServiceURL string
-}
-
-// Load creates a new configuration from environment variables
-func Load() *Config {
-	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
-	cacheTTLMinutes, _ := strconv.Atoi(getEnv("CACHE_TTL_MINUTES", "60"))
 
-	return &Config{
-		// Database
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     dbPort,
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "password"),
-		DBName:     getEnv("DB_NAME", "bookkeep_tax"),
-		DBSSLMode:  getEnv("DB_SSL_MODE", "disable"),
+	// External tax services
+	VIESBaseURL        string
+	SalesTaxProvider   string // "taxjar", "avalara" or empty for configured rates only
+	TaxJarAPIKey       string
+	TaxJarBaseURL      string
+	AvalaraAccountID   string
+	AvalaraLicenseKey  string
+	AvalaraCompanyCode string
+	AvalaraBaseURL     string
 
-		// Server
-		Port:            getEnv("PORT", "8085"),
-		Environment:     getEnv("ENVIRONMENT", "development"),
-		LogLevel:        getEnv("LOG_LEVEL", "info"),
-		CacheTTLMinutes: cacheTTLMinutes,
+	// GST Suvidha Provider for counterparty registration and filing status
+	GSPBaseURL              string
+	GSPClientID             string
+	GSPClientSecret         string
+	FilingStatusRefreshTime time.Duration // How often key suppliers are re-checked
+}
 
-		// Service URLs
-		InvoiceServiceURL:  getEnv("INVOICE_SERVICE_URL", "http://bookkeeping-invoice-service:8080"),
-		CustomerServiceURL: getEnv("CUSTOMER_SERVICE_URL", "http://bookkeeping-customer-service:8080"),
+// Load loads tax service configuration
+func Load() (*Config, error) {
+	cfg, err := sharedConfig.LoadWithDefaults("tax-service", sharedConfig.Defaults{DatabaseName: "bookkeep_tax", Port: 8085})
+	if err != nil {
+		return nil, err
 	}
-}
 
-// InitDB initializes the database connection
-func InitDB(cfg *Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode)
+	env := sharedConfig.NewEnv("tax-service")
+	taxCfg := &Config{
+		Config:   cfg,
+		CacheTTL: time.Duration(env.Int("CACHE_TTL_MINUTES", 60)) * time.Minute,
 
-	var logLevel logger.LogLevel
-	if cfg.Environment == "production" {
-		logLevel = logger.Error
-	} else {
-		logLevel = logger.Info
-	}
+		InvoiceServiceURL:  env.String("INVOICE_SERVICE_URL", "http://bookkeeping-invoice-service:8080"),
+		CustomerServiceURL: env.String("CUSTOMER_SERVICE_URL", "http://bookkeeping-customer-service:8080"),
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
+		VIESBaseURL:        env.String("VIES_BASE_URL", "https://ec.europa.eu/taxation_customs/vies/rest-api"),
+		SalesTaxProvider:   env.String("SALES_TAX_PROVIDER", ""),
+		TaxJarAPIKey:       cfg.Secrets.GetOr("TAXJAR_API_KEY", ""),
+		TaxJarBaseURL:      env.String("TAXJAR_BASE_URL", "https://api.taxjar.com/v2"),
+		AvalaraAccountID:   env.String("AVALARA_ACCOUNT_ID", ""),
+		AvalaraLicenseKey:  cfg.Secrets.GetOr("AVALARA_LICENSE_KEY", ""),
+		AvalaraCompanyCode: env.String("AVALARA_COMPANY_CODE", ""),
+		AvalaraBaseURL:     env.String("AVALARA_BASE_URL", "https://rest.avatax.com"),
 
-	return db, nil
-}
+		GSPBaseURL:              env.String("GSP_BASE_URL", ""),
+		GSPClientID:             env.String("GSP_CLIENT_ID", ""),
+		GSPClientSecret:         cfg.Secrets.GetOr("GSP_CLIENT_SECRET", ""),
+		FilingStatusRefreshTime: time.Duration(env.Int("FILING_STATUS_REFRESH_HOURS", 24)) * time.Hour,
+	}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	if taxCfg.CacheTTL < 0 {
+		env.Fail("CACHE_TTL_MINUTES must not be negative")
+	}
+	if taxCfg.FilingStatusRefreshTime <= 0 {
+		env.Fail("FILING_STATUS_REFRESH_HOURS must be positive")
+	}
+	if taxCfg.GSPClientID != "" && (taxCfg.GSPBaseURL == "" || taxCfg.GSPClientSecret == "") {
+		env.Fail("GSP_BASE_URL and GSP_CLIENT_SECRET are required when GSP_CLIENT_ID is set")
+	}
+	switch taxCfg.SalesTaxProvider {
+	case "":
+	case "taxjar":
+		if taxCfg.TaxJarAPIKey == "" {
+			env.Fail("TAXJAR_API_KEY is required when SALES_TAX_PROVIDER is taxjar")
+		}
+	case "avalara":
+		if taxCfg.AvalaraAccountID == "" || taxCfg.AvalaraLicenseKey == "" {
+			env.Fail("AVALARA_ACCOUNT_ID and AVALARA_LICENSE_KEY are required when SALES_TAX_PROVIDER is avalara")
+		}
+	default:
+		env.Fail("SALES_TAX_PROVIDER: %q must be taxjar, avalara or empty", taxCfg.SalesTaxProvider)
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
 	}
-	return defaultValue
+	return taxCfg, nil
 }
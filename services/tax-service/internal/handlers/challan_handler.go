@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/services"
+)
+
+// ChallanHandler handles TDS/TCS challan HTTP requests
+type ChallanHandler struct {
+	challanService *services.ChallanService
+}
+
+// NewChallanHandler creates a new challan handler
+func NewChallanHandler(challanService *services.ChallanService) *ChallanHandler {
+	return &ChallanHandler{challanService: challanService}
+}
+
+// CreateChallan handles POST /api/v1/challans
+func (h *ChallanHandler) CreateChallan(c *gin.Context) {
+	var req models.CreateChallanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	if req.TenantID == "" {
+		req.TenantID = getTenantID(c)
+	}
+
+	challan, err := h.challanService.CreateChallan(c.Request.Context(), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidChallan:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challan", "message": err.Error()})
+		case services.ErrDuplicateChallan:
+			c.JSON(http.StatusConflict, gin.H{"error": "Duplicate challan", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challan", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, challan)
+}
+
+// ListChallans handles GET /api/v1/challans
+func (h *ChallanHandler) ListChallans(c *gin.Context) {
+	tenantID := getTenantID(c)
+	challanType := models.ChallanType(c.Query("type"))
+	fy := c.Query("financialYear")
+
+	var quarter int
+	if q, err := strconv.Atoi(c.Query("quarter")); err == nil {
+		quarter = q
+	}
+
+	challans, err := h.challanService.ListChallans(c.Request.Context(), tenantID, challanType, fy, quarter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list challans", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": challans})
+}
+
+// GetChallan handles GET /api/v1/challans/:id
+func (h *ChallanHandler) GetChallan(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challan ID"})
+		return
+	}
+
+	challan, err := h.challanService.GetChallan(c.Request.Context(), getTenantID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challan not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, challan)
+}
+
+// LinkChallan handles POST /api/v1/challans/:id/link
+func (h *ChallanHandler) LinkChallan(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challan ID"})
+		return
+	}
+
+	var req models.LinkChallanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	result, err := h.challanService.LinkChallan(c.Request.Context(), getTenantID(c), id, req)
+	if err != nil {
+		switch err {
+		case services.ErrChallanNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Challan not found"})
+		case services.ErrChallanRowsNotEligible, services.ErrNothingToLink, services.ErrChallanInsufficient:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cannot link challan", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link challan", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPendingDepositAgeing handles GET /api/v1/challans/pending-ageing
+func (h *ChallanHandler) GetPendingDepositAgeing(c *gin.Context) {
+	asOf := time.Now()
+	if asOfStr := c.Query("asOf"); asOfStr != "" {
+		parsed, err := time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf date", "message": err.Error()})
+			return
+		}
+		asOf = parsed
+	}
+
+	report, err := h.challanService.PendingDepositAgeing(c.Request.Context(), getTenantID(c), models.ChallanType(c.Query("type")), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build pending deposit report", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetTDSDashboard handles GET /api/v1/tds/dashboard
+func (h *ChallanHandler) GetTDSDashboard(c *gin.Context) {
+	asOf := time.Now()
+	if asOfStr := c.Query("asOf"); asOfStr != "" {
+		parsed, err := time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf date", "message": err.Error()})
+			return
+		}
+		asOf = parsed
+	}
+
+	var quarter int
+	if q, err := strconv.Atoi(c.Query("quarter")); err == nil {
+		quarter = q
+	}
+
+	alertDays := 7 // Alert a week ahead of the 7th-of-month deadline
+	if d, err := strconv.Atoi(c.Query("alertDays")); err == nil && d >= 0 {
+		alertDays = d
+	}
+
+	dashboard, err := h.challanService.TDSDashboard(c.Request.Context(), getTenantID(c), c.Query("financialYear"), quarter, asOf, alertDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build TDS dashboard", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/services"
+)
+
+// FilingStatusHandler handles vendor/customer GST filing status HTTP requests
+type FilingStatusHandler struct {
+	filingStatusService *services.FilingStatusService
+}
+
+// NewFilingStatusHandler creates a new filing status handler
+func NewFilingStatusHandler(filingStatusService *services.FilingStatusService) *FilingStatusHandler {
+	return &FilingStatusHandler{filingStatusService: filingStatusService}
+}
+
+// WatchGSTIN handles POST /api/v1/filing-status
+func (h *FilingStatusHandler) WatchGSTIN(c *gin.Context) {
+	var req models.WatchGSTINRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	if req.TenantID == "" {
+		req.TenantID = getTenantID(c)
+	}
+
+	status, err := h.filingStatusService.Watch(c.Request.Context(), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidGSTIN:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid GSTIN", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to watch GSTIN", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListFilingStatuses handles GET /api/v1/filing-status
+func (h *FilingStatusHandler) ListFilingStatuses(c *gin.Context) {
+	statuses, err := h.filingStatusService.List(c.Request.Context(), getTenantID(c), c.Query("counterparty"), c.Query("flagged") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list filing statuses", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statuses})
+}
+
+// CheckGSTIN handles GET /api/v1/filing-status/:gstin
+func (h *FilingStatusHandler) CheckGSTIN(c *gin.Context) {
+	result, err := h.filingStatusService.Check(c.Request.Context(), getTenantID(c), c.Param("gstin"), c.Query("refresh") == "true")
+	if err != nil {
+		switch err {
+		case services.ErrInvalidGSTIN:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid GSTIN", "message": err.Error()})
+		case services.ErrFilingSourceDown:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Filing status unavailable", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check GSTIN", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UnwatchGSTIN handles DELETE /api/v1/filing-status/:gstin
+func (h *FilingStatusHandler) UnwatchGSTIN(c *gin.Context) {
+	if err := h.filingStatusService.Unwatch(c.Request.Context(), getTenantID(c), c.Param("gstin")); err != nil {
+		switch err {
+		case services.ErrGSTINNotWatched:
+			c.JSON(http.StatusNotFound, gin.H{"error": "GSTIN not monitored"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop monitoring GSTIN", "message": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/services"
+)
+
+// GSTReturnHandler handles GST return generation HTTP requests
+type GSTReturnHandler struct {
+	returnService *services.GSTReturnService
+}
+
+// NewGSTReturnHandler creates a new GST return handler
+func NewGSTReturnHandler(returnService *services.GSTReturnService) *GSTReturnHandler {
+	return &GSTReturnHandler{returnService: returnService}
+}
+
+// GetGSTR1 handles GET /api/v1/gstr/gstr1
+func (h *GSTReturnHandler) GetGSTR1(c *gin.Context) {
+	gstr1, err := h.returnService.GenerateGSTR1(c.Request.Context(), c.GetHeader("Authorization"), c.Query("gstin"), c.Query("period"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidReturnPeriod):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period", "message": err.Error()})
+		case errors.Is(err, services.ErrBooksUnavailable):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Books unavailable", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate GSTR-1", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gstr1)
+}
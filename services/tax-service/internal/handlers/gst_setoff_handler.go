@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/services"
+)
+
+// GSTSetOffHandler handles GST set-off simulation HTTP requests
+type GSTSetOffHandler struct {
+	setOffService *services.GSTSetOffService
+}
+
+// NewGSTSetOffHandler creates a new GST set-off handler
+func NewGSTSetOffHandler(setOffService *services.GSTSetOffService) *GSTSetOffHandler {
+	return &GSTSetOffHandler{setOffService: setOffService}
+}
+
+// Simulate handles POST /api/v1/gstr/gstr3b/set-off-simulation
+func (h *GSTSetOffHandler) Simulate(c *gin.Context) {
+	var req models.GSTSetOffSimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	simulation, err := h.setOffService.Simulate(c.Request.Context(), getTenantID(c), c.GetHeader("Authorization"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidReturnPeriod):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period", "message": err.Error()})
+		case errors.Is(err, services.ErrBooksUnavailable):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Books unavailable", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate GST set-off", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, simulation)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/services"
+)
+
+// GSTR9Handler handles annual return (GSTR-9) HTTP requests
+type GSTR9Handler struct {
+	gstr9Service *services.GSTR9Service
+}
+
+// NewGSTR9Handler creates a new GSTR-9 handler
+func NewGSTR9Handler(gstr9Service *services.GSTR9Service) *GSTR9Handler {
+	return &GSTR9Handler{gstr9Service: gstr9Service}
+}
+
+// GetWorkpaper handles GET /api/v1/gstr/gstr9/workpaper
+func (h *GSTR9Handler) GetWorkpaper(c *gin.Context) {
+	workpaper, err := h.gstr9Service.Workpaper(c.Request.Context(), getTenantID(c), c.GetHeader("Authorization"), c.Query("financialYear"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidFinancialYear):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid financial year", "message": err.Error()})
+		case errors.Is(err, services.ErrBooksUnavailable):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Books unavailable", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare GSTR-9 workpaper", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, workpaper)
+}
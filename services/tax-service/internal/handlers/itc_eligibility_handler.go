@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/services"
+)
+
+// ITCEligibilityHandler handles blocked credit (section 17(5)) HTTP requests
+type ITCEligibilityHandler struct {
+	eligibilityService *services.ITCEligibilityService
+}
+
+// NewITCEligibilityHandler creates a new ITC eligibility handler
+func NewITCEligibilityHandler(eligibilityService *services.ITCEligibilityService) *ITCEligibilityHandler {
+	return &ITCEligibilityHandler{eligibilityService: eligibilityService}
+}
+
+// CheckEligibility handles POST /api/v1/itc/eligibility
+func (h *ITCEligibilityHandler) CheckEligibility(c *gin.Context) {
+	var req models.ITCEligibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	if req.TenantID == "" {
+		req.TenantID = getTenantID(c)
+	}
+
+	result, err := h.eligibilityService.Evaluate(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ITC eligibility", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListRules handles GET /api/v1/itc/rules
+func (h *ITCEligibilityHandler) ListRules(c *gin.Context) {
+	rules, err := h.eligibilityService.ListRules(c.Request.Context(), getTenantID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list ITC rules", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// CreateRule handles POST /api/v1/itc/rules
+func (h *ITCEligibilityHandler) CreateRule(c *gin.Context) {
+	var req models.CreateITCRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	if req.TenantID == "" {
+		req.TenantID = getTenantID(c)
+	}
+
+	rule, err := h.eligibilityService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidITCRule:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule", "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ITC rule", "message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// DeleteRule handles DELETE /api/v1/itc/rules/:id
+func (h *ITCEligibilityHandler) DeleteRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	if err := h.eligibilityService.DeleteRule(c.Request.Context(), getTenantID(c), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetBlockedCreditReport handles GET /api/v1/itc/blocked
+func (h *ITCEligibilityHandler) GetBlockedCreditReport(c *gin.Context) {
+	report, err := h.eligibilityService.BlockedCreditReport(c.Request.Context(), getTenantID(c), c.Query("period"), c.Query("financialYear"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to build blocked credit report", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -39,6 +40,10 @@ func (h *TaxHandler) CalculateTax(c *gin.Context) {
 
 	response, err := h.calculator.CalculateTax(c.Request.Context(), req)
 	if err != nil {
+		if errors.Is(err, services.ErrSalesTaxProviderUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Sales tax provider unavailable", "message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate tax", "message": err.Error()})
 		return
 	}
@@ -87,6 +92,14 @@ func (h *TaxHandler) CreateTDSDeduction(c *gin.Context) {
 		return
 	}
 
+	// TDS is deducted once per payment, so retries return the existing record
+	if req.PaymentID != nil {
+		if existing, err := h.repo.GetTDSDeductionByPayment(c.Request.Context(), req.TenantID, *req.PaymentID); err == nil {
+			c.JSON(http.StatusOK, existing)
+			return
+		}
+	}
+
 	netAmount := req.GrossAmount.Sub(req.TDSAmount)
 	fy := getFinancialYear(deductionDate)
 	quarter := getQuarter(deductionDate)
@@ -117,6 +130,35 @@ func (h *TaxHandler) CreateTDSDeduction(c *gin.Context) {
 	c.JSON(http.StatusCreated, deduction)
 }
 
+// VoidTDSDeduction handles POST /api/v1/tds/deductions/payments/:payment_id/void,
+// withdrawing the deduction recorded for a payment that could not be
+// completed. Deductions already deposited can't be withdrawn; voiding a
+// payment with no deduction succeeds, so the call can be retried.
+func (h *TaxHandler) VoidTDSDeduction(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("payment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	deduction, err := h.repo.GetTDSDeductionByPayment(c.Request.Context(), getTenantID(c), paymentID)
+	if err != nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if deduction.Status != "PENDING" {
+		c.JSON(http.StatusConflict, gin.H{"error": "TDS deduction already deposited", "message": "deduction " + deduction.ID.String() + " is " + deduction.Status})
+		return
+	}
+
+	if err := h.repo.DeleteTDSDeduction(c.Request.Context(), deduction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void TDS deduction", "message": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // ListTDSDeductions handles GET /api/v1/tds/deductions
 func (h *TaxHandler) ListTDSDeductions(c *gin.Context) {
 	tenantID := getTenantID(c)
@@ -174,6 +216,54 @@ func (h *TaxHandler) CalculateTCS(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// CreateTCSCollection handles POST /api/v1/tcs/collections
+func (h *TaxHandler) CreateTCSCollection(c *gin.Context) {
+	var req models.CreateTCSCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	if req.TenantID == "" {
+		req.TenantID = getTenantID(c)
+	}
+
+	collectionDate, err := time.Parse("2006-01-02", req.CollectionDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection date", "message": err.Error()})
+		return
+	}
+
+	// An invoice is finalized once, so repeat calls return the existing record
+	if existing, err := h.repo.GetTCSCollectionByInvoice(c.Request.Context(), req.TenantID, req.InvoiceID); err == nil {
+		c.JSON(http.StatusOK, existing)
+		return
+	}
+
+	collection := &models.TCSCollection{
+		TenantID:       req.TenantID,
+		InvoiceID:      req.InvoiceID,
+		CustomerID:     req.CustomerID,
+		CustomerName:   req.CustomerName,
+		CustomerPAN:    req.CustomerPAN,
+		Section:        req.Section,
+		SaleAmount:     req.SaleAmount,
+		TCSRate:        req.TCSRate,
+		TCSAmount:      req.TCSAmount,
+		CollectionDate: collectionDate,
+		FinancialYear:  getFinancialYear(collectionDate),
+		Quarter:        getQuarter(collectionDate),
+		Status:         "PENDING",
+	}
+
+	if err := h.repo.CreateTCSCollection(c.Request.Context(), collection); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create TCS collection", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
 // ListTCSCollections handles GET /api/v1/tcs/collections
 func (h *TaxHandler) ListTCSCollections(c *gin.Context) {
 	tenantID := getTenantID(c)
@@ -329,6 +419,44 @@ func (h *TaxHandler) CreateJurisdiction(c *gin.Context) {
 	c.JSON(http.StatusCreated, jurisdiction)
 }
 
+// UpdateJurisdictionRounding handles PUT /api/v1/jurisdictions/:id/rounding
+func (h *TaxHandler) UpdateJurisdictionRounding(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid jurisdiction ID"})
+		return
+	}
+
+	var req models.UpdateRoundingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+
+	jurisdiction, err := h.repo.GetJurisdiction(c.Request.Context(), id)
+	if err != nil || jurisdiction.TenantID != getTenantID(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jurisdiction not found"})
+		return
+	}
+	if jurisdiction.Type != models.JurisdictionTypeCountry {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rounding rules can only be set on country jurisdictions"})
+		return
+	}
+
+	jurisdiction.RoundingLevel = req.Level
+	jurisdiction.RoundingMethod = req.Method
+	jurisdiction.RoundingPrecision = req.Precision
+	jurisdiction.Parent = nil
+	jurisdiction.Children = nil
+	jurisdiction.TaxRates = nil
+	if err := h.repo.UpdateJurisdiction(c.Request.Context(), jurisdiction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update jurisdiction", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jurisdiction)
+}
+
 // ============ Product Category CRUD ============
 
 func (h *TaxHandler) ListProductCategories(c *gin.Context) {
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
@@ -15,6 +17,9 @@ type CalculateTaxRequest struct {
 	CustomerID      *uuid.UUID      `json:"customerId"`
 	CustomerGSTIN   string          `json:"customerGstin"`
 	IsB2B           bool            `json:"isB2b"`
+
+	// EU - buyer's VAT number, checked against VIES for reverse charge
+	CustomerVATNumber string `json:"customerVatNumber"`
 }
 
 // AddressInput represents an address for tax calculation
@@ -51,6 +56,17 @@ type TaxCalculationResponse struct {
 	ReverseCharge  bool           `json:"reverseCharge,omitempty"`
 	GSTSummary     *GSTSummary    `json:"gstSummary,omitempty"`
 	VATSummary     *VATSummary    `json:"vatSummary,omitempty"`
+	Provider       string         `json:"provider,omitempty"` // External sales tax provider used, if any
+	RoundingLevel  RoundingLevel  `json:"roundingLevel,omitempty"`
+	RoundingMethod RoundingMethod `json:"roundingMethod,omitempty"`
+	Warnings       []string       `json:"warnings,omitempty"`
+}
+
+// UpdateRoundingPolicyRequest sets a jurisdiction's tax rounding rules
+type UpdateRoundingPolicyRequest struct {
+	Level     RoundingLevel  `json:"level" binding:"required,oneof=LINE INVOICE"`
+	Method    RoundingMethod `json:"method" binding:"required,oneof=HALF_UP HALF_EVEN"`
+	Precision *int           `json:"precision" binding:"omitempty,min=0,max=4"`
 }
 
 // TaxBreakdown represents individual tax components
@@ -64,6 +80,14 @@ type TaxBreakdown struct {
 	HSNCode          string    `json:"hsnCode,omitempty"`
 	SACCode          string    `json:"sacCode,omitempty"`
 	IsCompound       bool      `json:"isCompound,omitempty"`
+
+	// Specific (per unit) component of a CESS entry; Rate holds the ad
+	// valorem component
+	SpecificRate   float64 `json:"specificRate,omitempty"`
+	SpecificPer    float64 `json:"specificPer,omitempty"`
+	SpecificUnit   string  `json:"specificUnit,omitempty"`
+	Quantity       float64 `json:"quantity,omitempty"`
+	SpecificAmount float64 `json:"specificAmount,omitempty"`
 }
 
 // GSTSummary represents India GST summary
@@ -83,6 +107,9 @@ type VATSummary struct {
 	VATAmount       float64 `json:"vatAmount"`
 	IsReverseCharge bool    `json:"isReverseCharge"`
 	BuyerVATNumber  string  `json:"buyerVatNumber,omitempty"`
+	VATNumberStatus string  `json:"vatNumberStatus,omitempty"` // VALID, INVALID or UNVERIFIED
+	PlaceOfSupply   string  `json:"placeOfSupply,omitempty"`   // Country whose VAT applies
+	IsExport        bool    `json:"isExport,omitempty"`
 }
 
 // ValidateAddressRequest for address validation
@@ -109,6 +136,10 @@ type CalculateTDSRequest struct {
 	GrossAmount   decimal.Decimal `json:"grossAmount" binding:"required"`
 	PaymentDate   string         `json:"paymentDate" binding:"required"` // YYYY-MM-DD
 	InvoiceID     *uuid.UUID     `json:"invoiceId"`
+
+	// CumulativeAmount is the amount paid to the deductee in the FY before
+	// this payment. When omitted it is derived from recorded deductions.
+	CumulativeAmount *decimal.Decimal `json:"cumulativeAmount"`
 }
 
 // CalculateTDSResponse for TDS calculation result
@@ -152,6 +183,24 @@ type CalculateTCSRequest struct {
 	SaleAmount    decimal.Decimal `json:"saleAmount" binding:"required"`
 	InvoiceID     uuid.UUID       `json:"invoiceId" binding:"required"`
 	InvoiceDate   string          `json:"invoiceDate" binding:"required"`
+
+	// CumulativeSales is the customer's sales in the FY before this invoice.
+	// When omitted it is derived from recorded TCS collections.
+	CumulativeSales *decimal.Decimal `json:"cumulativeSales"`
+}
+
+// CreateTCSCollectionRequest for recording TCS collected on an invoice
+type CreateTCSCollectionRequest struct {
+	TenantID       string          `json:"tenantId"`
+	InvoiceID      uuid.UUID       `json:"invoiceId" binding:"required"`
+	CustomerID     uuid.UUID       `json:"customerId" binding:"required"`
+	CustomerName   string          `json:"customerName" binding:"required"`
+	CustomerPAN    string          `json:"customerPan"`
+	Section        TCSSection      `json:"section" binding:"required"`
+	SaleAmount     decimal.Decimal `json:"saleAmount" binding:"required"`
+	TCSRate        decimal.Decimal `json:"tcsRate" binding:"required"`
+	TCSAmount      decimal.Decimal `json:"tcsAmount" binding:"required"`
+	CollectionDate string          `json:"collectionDate" binding:"required"`
 }
 
 // CalculateTCSResponse for TCS calculation result
@@ -186,6 +235,7 @@ type RecordITCRequest struct {
 	SGSTAmount        decimal.Decimal `json:"sgstAmount"`
 	IGSTAmount        decimal.Decimal `json:"igstAmount"`
 	CessAmount        decimal.Decimal `json:"cessAmount"`
+	AccountID         *uuid.UUID      `json:"accountId"`
 }
 
 // ITCSummaryResponse for ITC summary
@@ -253,6 +303,119 @@ type GSTR3BSummary struct {
 	LateFee         decimal.Decimal `json:"lateFee"`
 }
 
+// GSTR9Row is a line of a GSTR-9 table. TotalTax is always set; the heads
+// are left at zero where the source only records a total.
+type GSTR9Row struct {
+	Table       string          `json:"table"` // e.g. 4A
+	Description string          `json:"description"`
+	Taxable     decimal.Decimal `json:"taxable"`
+	CGST        decimal.Decimal `json:"cgst"`
+	SGST        decimal.Decimal `json:"sgst"`
+	IGST        decimal.Decimal `json:"igst"`
+	Cess        decimal.Decimal `json:"cess"`
+	TotalTax    decimal.Decimal `json:"totalTax"`
+}
+
+// GSTR9Table is a part of the GSTR-9 workpaper
+type GSTR9Table struct {
+	Table string     `json:"table"`
+	Title string     `json:"title"`
+	Rows  []GSTR9Row `json:"rows"`
+}
+
+// GSTR9Difference is a difference between the books and the year's returns
+// that has to be explained or adjusted in the annual return
+type GSTR9Difference struct {
+	Table       string          `json:"table"` // Table the adjustment goes in
+	Description string          `json:"description"`
+	Books       decimal.Decimal `json:"books"`
+	Returns     decimal.Decimal `json:"returns"`
+	Difference  decimal.Decimal `json:"difference"`
+	Action      string          `json:"action"`
+}
+
+// GSTR9Workpaper is the table-wise preparation of the annual return for a
+// financial year from the books and the returns filed during the year
+type GSTR9Workpaper struct {
+	FinancialYear        string            `json:"financialYear"`
+	AmendmentsUntil      time.Time         `json:"amendmentsUntil"`
+	GSTR1PeriodsFiled    int               `json:"gstr1PeriodsFiled"`
+	GSTR3BPeriodsFiled   int               `json:"gstr3bPeriodsFiled"`
+	MissingGSTR3BPeriods []string          `json:"missingGstr3bPeriods"`
+	Tables               []GSTR9Table      `json:"tables"`
+	Differences          []GSTR9Difference `json:"differences"`
+	GeneratedAt          time.Time         `json:"generatedAt"`
+}
+
+// GSTHeads are amounts under each GST head
+type GSTHeads struct {
+	IGST decimal.Decimal `json:"igst"`
+	CGST decimal.Decimal `json:"cgst"`
+	SGST decimal.Decimal `json:"sgst"`
+	Cess decimal.Decimal `json:"cess"`
+}
+
+// Total is the sum of the heads
+func (h GSTHeads) Total() decimal.Decimal {
+	return h.IGST.Add(h.CGST).Add(h.SGST).Add(h.Cess)
+}
+
+// GSTSetOffSimulationRequest asks what filing a month's GSTR-3B today would
+// cost
+type GSTSetOffSimulationRequest struct {
+	Period string `json:"period" binding:"required,len=6"` // MMYYYY
+	// CreditLedgerBalance is the electronic credit ledger's balance before
+	// the month's credit, as the GST portal shows it. Without it the credit
+	// left over from the previous month's books is assumed.
+	CreditLedgerBalance *GSTHeads `json:"creditLedgerBalance"`
+	// AggregateTurnover of the previous financial year sets the late fee
+	// cap; without it the highest cap is assumed
+	AggregateTurnover *decimal.Decimal `json:"aggregateTurnover"`
+}
+
+// GSTSetOffUse is credit of one head used to pay tax of another
+type GSTSetOffUse struct {
+	Credit    string          `json:"credit"` // IGST, CGST, SGST or Cess
+	Liability string          `json:"liability"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+// GSTSetOff is a month's tax set off against input tax credit in the order
+// sections 49 and 49A of the CGST Act require, and the cash left to pay.
+// Reverse charge tax cannot be paid with credit and is all cash.
+type GSTSetOff struct {
+	Period                string          `json:"period"`
+	OutputTax             GSTHeads        `json:"outputTax"` // Forward charge, net of credit notes
+	ReverseChargeTax      GSTHeads        `json:"reverseChargeTax"`
+	CreditBroughtForward  GSTHeads        `json:"creditBroughtForward"`
+	CreditForMonth        GSTHeads        `json:"creditForMonth"`
+	CreditAvailable       GSTHeads        `json:"creditAvailable"`
+	SetOff                []GSTSetOffUse  `json:"setOff"`
+	CreditUsed            GSTHeads        `json:"creditUsed"`
+	CashPayable           GSTHeads        `json:"cashPayable"` // Tax only
+	CreditCarriedForward  GSTHeads        `json:"creditCarriedForward"`
+	TotalTaxPayableInCash decimal.Decimal `json:"totalTaxPayableInCash"`
+}
+
+// GSTSetOffSimulation is what filing a month's GSTR-3B today would cost:
+// the set-off of the books' tax against credit, with interest and late fee
+// when the due date has passed, beside the previous month's set-off
+type GSTSetOffSimulation struct {
+	GSTSetOff
+	AsOf     time.Time `json:"asOf"`
+	DueDate  time.Time `json:"dueDate"`
+	Filed    bool      `json:"filed"` // The month's GSTR-3B has been filed
+	DaysLate int       `json:"daysLate"`
+	Interest GSTHeads  `json:"interest"` // At 18% a year on the tax paid in cash
+	LateFee  GSTHeads  `json:"lateFee"`  // CGST and SGST; nothing under IGST
+	// TotalCashPayable is the tax, interest and late fee to pay in cash
+	TotalCashPayable decimal.Decimal `json:"totalCashPayable"`
+
+	PreviousMonth *GSTSetOff      `json:"previousMonth"`
+	ChangeInCash  decimal.Decimal `json:"changeInCash"` // Tax payable in cash, against the previous month
+	Notes         []string        `json:"notes"`
+}
+
 // TDSReturn26QRequest for generating 26Q TDS return
 type TDSReturn26QRequest struct {
 	TenantID      string `json:"tenantId" binding:"required"`
@@ -273,3 +436,191 @@ type TDSReturn26QSummary struct {
 	PendingAmount    decimal.Decimal `json:"pendingAmount"`
 	Deductions       []TDSDeduction  `json:"deductions"`
 }
+
+// ============ Challan Request/Response ============
+
+// CreateChallanRequest for recording a TDS/TCS challan
+type CreateChallanRequest struct {
+	TenantID       string          `json:"tenantId"`
+	ChallanType    ChallanType     `json:"challanType" binding:"required,oneof=TDS TCS"`
+	Section        string          `json:"section"`
+	ChallanNumber  string          `json:"challanNumber" binding:"required"`
+	BSRCode        string          `json:"bsrCode" binding:"required,len=7"`
+	DepositDate    string          `json:"depositDate" binding:"required"` // YYYY-MM-DD
+	FinancialYear  string          `json:"financialYear" binding:"required"`
+	Quarter        int             `json:"quarter" binding:"required,min=1,max=4"`
+	TaxAmount      decimal.Decimal `json:"taxAmount" binding:"required"`
+	InterestAmount decimal.Decimal `json:"interestAmount"`
+	FeeAmount      decimal.Decimal `json:"feeAmount"`
+}
+
+// LinkChallanRequest for linking pending deductions/collections to a challan.
+// When IDs is empty every pending row for the challan's quarter (and section,
+// if set) is linked.
+type LinkChallanRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// LinkChallanResponse for challan linking result
+type LinkChallanResponse struct {
+	Challan        *TaxChallan     `json:"challan"`
+	LinkedCount    int             `json:"linkedCount"`
+	LinkedAmount   decimal.Decimal `json:"linkedAmount"`
+	UnlinkedAmount decimal.Decimal `json:"unlinkedAmount"`
+}
+
+// PendingDepositItem is a deduction/collection awaiting deposit
+type PendingDepositItem struct {
+	ID              uuid.UUID       `json:"id"`
+	ChallanType     ChallanType     `json:"challanType"`
+	Section         string          `json:"section"`
+	PartyName       string          `json:"partyName"`
+	Amount          decimal.Decimal `json:"amount"`
+	TransactionDate string          `json:"transactionDate"`
+	DueDate         string          `json:"dueDate"`
+	DaysOverdue     int             `json:"daysOverdue"`
+	Interest        decimal.Decimal `json:"interest"` // Estimated late deposit interest
+	Bucket          string          `json:"bucket"`
+}
+
+// AgeingBucket summarises pending deposits by days overdue
+type AgeingBucket struct {
+	Label  string          `json:"label"`
+	Count  int             `json:"count"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// PendingDepositAgeingResponse for the pending deposit ageing report
+type PendingDepositAgeingResponse struct {
+	AsOf          string               `json:"asOf"`
+	Buckets       []AgeingBucket       `json:"buckets"`
+	Items         []PendingDepositItem `json:"items"`
+	TotalPending  decimal.Decimal      `json:"totalPending"`
+	TotalInterest decimal.Decimal      `json:"totalInterest"`
+}
+
+// ============ TDS Dashboard Response ============
+
+// TDSSectionSummary summarises TDS liability for a section
+type TDSSectionSummary struct {
+	Section   TDSSection      `json:"section"`
+	Count     int             `json:"count"`
+	Deducted  decimal.Decimal `json:"deducted"`
+	Deposited decimal.Decimal `json:"deposited"`
+	Pending   decimal.Decimal `json:"pending"`
+}
+
+// TDSMonthSummary summarises TDS liability for a month of deduction
+type TDSMonthSummary struct {
+	Month     string          `json:"month"` // YYYY-MM
+	DueDate   string          `json:"dueDate"`
+	Deducted  decimal.Decimal `json:"deducted"`
+	Deposited decimal.Decimal `json:"deposited"`
+	Pending   decimal.Decimal `json:"pending"`
+	Interest  decimal.Decimal `json:"interest"`
+}
+
+// TDSAlert flags pending TDS that is due soon or overdue
+type TDSAlert struct {
+	Severity  string          `json:"severity"` // DUE_SOON, OVERDUE
+	Month     string          `json:"month"`
+	DueDate   string          `json:"dueDate"`
+	DaysToDue int             `json:"daysToDue"` // Negative when overdue
+	Amount    decimal.Decimal `json:"amount"`
+	Interest  decimal.Decimal `json:"interest"`
+	Message   string          `json:"message"`
+}
+
+// TDSDashboardResponse for the TDS liability dashboard
+type TDSDashboardResponse struct {
+	FinancialYear  string              `json:"financialYear"`
+	Quarter        int                 `json:"quarter,omitempty"`
+	AsOf           string              `json:"asOf"`
+	TotalDeducted  decimal.Decimal     `json:"totalDeducted"`
+	TotalDeposited decimal.Decimal     `json:"totalDeposited"`
+	TotalPending   decimal.Decimal     `json:"totalPending"`
+	TotalInterest  decimal.Decimal     `json:"totalInterest"`
+	BySection      []TDSSectionSummary `json:"bySection"`
+	ByMonth        []TDSMonthSummary   `json:"byMonth"`
+	Alerts         []TDSAlert          `json:"alerts"`
+}
+
+// ============ Counterparty Filing Status ============
+
+// WatchGSTINRequest adds a vendor or customer GSTIN to filing status monitoring
+type WatchGSTINRequest struct {
+	TenantID     string     `json:"tenantId"`
+	GSTIN        string     `json:"gstin" binding:"required,len=15"`
+	PartyID      *uuid.UUID `json:"partyId"`
+	PartyName    string     `json:"partyName"`
+	Counterparty string     `json:"counterparty"` // VENDOR (default) or CUSTOMER
+	KeySupplier  bool       `json:"keySupplier"`
+}
+
+// GSTINCheckResponse is a GSTIN's filing status with warnings to show when
+// entering a document for it
+type GSTINCheckResponse struct {
+	Status   *GSTINFilingStatus `json:"status"`
+	Warnings []string           `json:"warnings,omitempty"`
+}
+
+// ============ ITC Eligibility ============
+
+// ITCEligibilityLine is a purchase line checked against the blocked credit rules
+type ITCEligibilityLine struct {
+	HSNCode   string          `json:"hsnCode"`
+	SACCode   string          `json:"sacCode"`
+	AccountID *uuid.UUID      `json:"accountId"`
+	TaxAmount decimal.Decimal `json:"taxAmount"`
+}
+
+// ITCEligibilityRequest for checking purchase lines before they are saved
+type ITCEligibilityRequest struct {
+	TenantID string               `json:"tenantId"`
+	Lines    []ITCEligibilityLine `json:"lines" binding:"required,min=1"`
+}
+
+// ITCLineEligibility is the outcome for one line, in request order
+type ITCLineEligibility struct {
+	Eligible    bool            `json:"eligible"`
+	Category    string          `json:"category,omitempty"`
+	Section     string          `json:"section,omitempty"`
+	Reason      string          `json:"reason,omitempty"`
+	BlockedITC  decimal.Decimal `json:"blockedItc"`
+	EligibleITC decimal.Decimal `json:"eligibleItc"`
+}
+
+// ITCEligibilityResponse for an eligibility check
+type ITCEligibilityResponse struct {
+	Lines        []ITCLineEligibility `json:"lines"`
+	TotalBlocked decimal.Decimal      `json:"totalBlocked"`
+}
+
+// CreateITCRuleRequest for adding a tenant ITC eligibility rule
+type CreateITCRuleRequest struct {
+	TenantID    string     `json:"tenantId"`
+	Category    string     `json:"category" binding:"required"`
+	Section     string     `json:"section"`
+	MatchType   string     `json:"matchType" binding:"required"`
+	Code        string     `json:"code"`
+	AccountID   *uuid.UUID `json:"accountId"`
+	Blocked     *bool      `json:"blocked"` // Defaults to true
+	Description string     `json:"description"`
+}
+
+// BlockedCreditCategory totals blocked credit for a category
+type BlockedCreditCategory struct {
+	Category      string          `json:"category"`
+	Section       string          `json:"section"`
+	Count         int             `json:"count"`
+	TaxableAmount decimal.Decimal `json:"taxableAmount"`
+	BlockedITC    decimal.Decimal `json:"blockedItc"`
+}
+
+// BlockedCreditReport for ITC blocked under section 17(5) in a period
+type BlockedCreditReport struct {
+	Period        string                  `json:"period,omitempty"` // MMYYYY
+	FinancialYear string                  `json:"financialYear,omitempty"`
+	Categories    []BlockedCreditCategory `json:"categories"`
+	TotalBlocked  decimal.Decimal         `json:"totalBlocked"`
+}
@@ -44,6 +44,33 @@ const (
 	TaxTypeQST     TaxType = "QST" // Canada - Quebec Sales Tax
 )
 
+// VATRateType selects which of a country's VAT rates applies to a product
+type VATRateType string
+
+const (
+	VATRateStandard      VATRateType = "STANDARD"
+	VATRateReduced       VATRateType = "REDUCED"        // Higher reduced rate, e.g. 10% in France
+	VATRateSecondReduced VATRateType = "SECOND_REDUCED" // Lower reduced rate, e.g. 5.5% in France
+	VATRateSuperReduced  VATRateType = "SUPER_REDUCED"  // e.g. 2.1% in France
+	VATRateZero          VATRateType = "ZERO"
+)
+
+// RoundingLevel controls where tax amounts are rounded
+type RoundingLevel string
+
+const (
+	RoundingLevelLine    RoundingLevel = "LINE"    // Each line's tax is rounded, totals are sums of rounded lines
+	RoundingLevelInvoice RoundingLevel = "INVOICE" // Tax is computed per rate on the summed taxable value and rounded once
+)
+
+// RoundingMethod controls how ties are rounded
+type RoundingMethod string
+
+const (
+	RoundingMethodHalfUp   RoundingMethod = "HALF_UP"   // 0.005 -> 0.01
+	RoundingMethodHalfEven RoundingMethod = "HALF_EVEN" // Banker's rounding: 0.005 -> 0.00, 0.015 -> 0.02
+)
+
 // TaxJurisdiction represents a tax jurisdiction (country, state, city, etc.)
 type TaxJurisdiction struct {
 	ID        uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -54,8 +81,14 @@ type TaxJurisdiction struct {
 	StateCode string           `json:"stateCode" gorm:"type:varchar(10)"` // India state code (MH, KA, etc.)
 	ParentID  *uuid.UUID       `json:"parentId" gorm:"type:uuid"`
 	IsActive  bool             `json:"isActive" gorm:"default:true"`
-	CreatedAt time.Time        `json:"createdAt"`
-	UpdatedAt time.Time        `json:"updatedAt"`
+
+	// Tax rounding rules; only used on country jurisdictions
+	RoundingLevel     RoundingLevel  `json:"roundingLevel" gorm:"type:varchar(20)"`
+	RoundingMethod    RoundingMethod `json:"roundingMethod" gorm:"type:varchar(20)"`
+	RoundingPrecision *int           `json:"roundingPrecision"` // Decimal places, default 2
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 
 	// Relationships
 	Parent   *TaxJurisdiction  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
@@ -95,8 +128,38 @@ type ProductTaxCategory struct {
 	IsTaxExempt bool      `json:"isTaxExempt" gorm:"default:false"`
 	IsNilRated  bool      `json:"isNilRated" gorm:"default:false"` // 0% GST but not exempt
 	IsZeroRated bool      `json:"isZeroRated" gorm:"default:false"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// India - Compensation cess. Ad valorem and specific components are added
+	// together, e.g. cigarettes: 5% + ₹4,170 per 1,000 sticks.
+	CessRate         float64 `json:"cessRate" gorm:"type:decimal(6,2);default:0"`          // Ad valorem %, can exceed 100
+	CessSpecificRate float64 `json:"cessSpecificRate" gorm:"type:decimal(12,2);default:0"` // Amount per CessSpecificPer units
+	CessSpecificPer  float64 `json:"cessSpecificPer" gorm:"type:decimal(12,3);default:1"`  // Units the specific rate is quoted per
+	CessUnit         string  `json:"cessUnit" gorm:"type:varchar(20)"`                     // e.g. "sticks", "kg", "tonne"
+
+	// EU - which of the destination country's VAT rates applies
+	VATRateType VATRateType `json:"vatRateType" gorm:"type:varchar(20)"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// HasCess reports whether compensation cess applies to the category
+func (p *ProductTaxCategory) HasCess() bool {
+	return p.CessRate > 0 || p.CessSpecificRate > 0
+}
+
+// Cess returns the ad valorem and specific cess on a line of the given
+// taxable value and quantity
+func (p *ProductTaxCategory) Cess(taxableAmount, quantity float64) (adValorem, specific float64) {
+	adValorem = taxableAmount * (p.CessRate / 100.0)
+	if p.CessSpecificRate > 0 {
+		per := p.CessSpecificPer
+		if per <= 0 {
+			per = 1
+		}
+		specific = quantity / per * p.CessSpecificRate
+	}
+	return adValorem, specific
 }
 
 // TaxNexus represents a location where business has tax collection obligation
@@ -166,6 +229,7 @@ type TDSDeduction struct {
 	NetAmount       decimal.Decimal `json:"netAmount" gorm:"type:decimal(12,2);not null"`
 	DeductionDate   time.Time       `json:"deductionDate" gorm:"type:date;not null"`
 	DepositDate     *time.Time      `json:"depositDate" gorm:"type:date"`
+	ChallanID       *uuid.UUID      `json:"challanId" gorm:"type:uuid;index"`
 	ChallanNumber   string          `json:"challanNumber" gorm:"type:varchar(50)"`
 	BSRCode         string          `json:"bsrCode" gorm:"type:varchar(10)"`
 	CertificateNo   string          `json:"certificateNo" gorm:"type:varchar(50)"` // Form 16A number
@@ -217,6 +281,7 @@ type TCSCollection struct {
 	TCSAmount       decimal.Decimal `json:"tcsAmount" gorm:"type:decimal(12,2);not null"`
 	CollectionDate  time.Time       `json:"collectionDate" gorm:"type:date;not null"`
 	DepositDate     *time.Time      `json:"depositDate" gorm:"type:date"`
+	ChallanID       *uuid.UUID      `json:"challanId" gorm:"type:uuid;index"`
 	ChallanNumber   string          `json:"challanNumber" gorm:"type:varchar(50)"`
 	FinancialYear   string          `json:"financialYear" gorm:"type:varchar(10);not null"`
 	Quarter         int             `json:"quarter" gorm:"not null"`
@@ -225,6 +290,50 @@ type TCSCollection struct {
 	UpdatedAt       time.Time       `json:"updatedAt"`
 }
 
+// ============ BOOKKEEPING SPECIFIC: Challan Models ============
+
+// ChallanType identifies whether a challan deposits TDS or TCS
+type ChallanType string
+
+const (
+	ChallanTypeTDS ChallanType = "TDS"
+	ChallanTypeTCS ChallanType = "TCS"
+)
+
+// Challan statuses
+const (
+	ChallanStatusUnlinked = "UNLINKED"
+	ChallanStatusPartial  = "PARTIAL"
+	ChallanStatusLinked   = "LINKED"
+)
+
+// TaxChallan represents a TDS/TCS deposit made with challan ITNS 281. A
+// challan is identified by the BSR code, deposit date and challan serial number.
+type TaxChallan struct {
+	ID             uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID       string          `json:"tenantId" gorm:"type:varchar(255);not null;uniqueIndex:idx_tax_challan_identity"`
+	ChallanType    ChallanType     `json:"challanType" gorm:"type:varchar(10);not null"`
+	Section        string          `json:"section" gorm:"type:varchar(20)"` // Nature of payment; empty covers all sections
+	ChallanNumber  string          `json:"challanNumber" gorm:"type:varchar(20);not null;uniqueIndex:idx_tax_challan_identity"`
+	BSRCode        string          `json:"bsrCode" gorm:"type:varchar(10);not null;uniqueIndex:idx_tax_challan_identity"`
+	DepositDate    time.Time       `json:"depositDate" gorm:"type:date;not null;uniqueIndex:idx_tax_challan_identity"`
+	FinancialYear  string          `json:"financialYear" gorm:"type:varchar(10);not null;index"`
+	Quarter        int             `json:"quarter" gorm:"not null"`
+	TaxAmount      decimal.Decimal `json:"taxAmount" gorm:"type:decimal(12,2);not null"`
+	InterestAmount decimal.Decimal `json:"interestAmount" gorm:"type:decimal(12,2);default:0"`
+	FeeAmount      decimal.Decimal `json:"feeAmount" gorm:"type:decimal(12,2);default:0"` // Late filing fee u/s 234E
+	TotalAmount    decimal.Decimal `json:"totalAmount" gorm:"type:decimal(12,2);not null"`
+	LinkedAmount   decimal.Decimal `json:"linkedAmount" gorm:"type:decimal(12,2);default:0"` // Tax covered by linked deductions/collections
+	Status         string          `json:"status" gorm:"type:varchar(20);default:'UNLINKED'"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+}
+
+// UnlinkedAmount returns the tax deposited that is not yet linked
+func (c *TaxChallan) UnlinkedAmount() decimal.Decimal {
+	return c.TaxAmount.Sub(c.LinkedAmount)
+}
+
 // ============ BOOKKEEPING SPECIFIC: ITC Models ============
 
 // ITCType represents types of Input Tax Credit
@@ -272,10 +381,45 @@ type InputTaxCredit struct {
 	GSTR2BMatched     bool            `json:"gstr2bMatched" gorm:"default:false"`
 	ReversalReason    string          `json:"reversalReason" gorm:"type:varchar(255)"`
 	ReversalAmount    decimal.Decimal `json:"reversalAmount" gorm:"type:decimal(12,2);default:0"`
+	AccountID         *uuid.UUID      `json:"accountId,omitempty" gorm:"type:uuid"`              // Expense account the purchase is booked to
+	BlockedCategory   string          `json:"blockedCategory,omitempty" gorm:"type:varchar(30)"` // Blocked credit under section 17(5)
+	BlockedSection    string          `json:"blockedSection,omitempty" gorm:"type:varchar(20)"`
 	CreatedAt         time.Time       `json:"createdAt"`
 	UpdatedAt         time.Time       `json:"updatedAt"`
 }
 
+// Blocked credit categories under section 17(5) of the CGST Act
+const (
+	BlockedCreditFoodBeverages       = "FOOD_BEVERAGES"       // 17(5)(b)(i)
+	BlockedCreditMotorVehicles       = "MOTOR_VEHICLES"       // 17(5)(a), hire of vehicles 17(5)(b)(i)
+	BlockedCreditPersonalConsumption = "PERSONAL_CONSUMPTION" // 17(5)(g)
+)
+
+// ITC eligibility rule match types
+const (
+	ITCRuleMatchCode    = "CODE"    // HSN or SAC code prefix
+	ITCRuleMatchAccount = "ACCOUNT" // Expense account
+)
+
+// ITCEligibilityRule maps an HSN/SAC code prefix or an expense account to a
+// blocked credit category. Tenant rules take precedence over the built-in
+// defaults; a rule with Blocked false allows credit a default would block
+// (e.g. motor vehicles bought by a taxi operator).
+type ITCEligibilityRule struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    string     `json:"tenantId" gorm:"type:varchar(255);not null;index"`
+	Category    string     `json:"category" gorm:"type:varchar(30);not null"`
+	Section     string     `json:"section" gorm:"type:varchar(20)"`
+	MatchType   string     `json:"matchType" gorm:"type:varchar(10);not null"`
+	Code        string     `json:"code,omitempty" gorm:"type:varchar(10)"`
+	AccountID   *uuid.UUID `json:"accountId,omitempty" gorm:"type:uuid"`
+	Blocked     bool       `json:"blocked" gorm:"default:true"`
+	Description string     `json:"description" gorm:"type:varchar(255)"`
+	IsActive    bool       `json:"isActive" gorm:"default:true"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
 // ITCReconciliation represents ITC reconciliation with GSTR-2A/2B
 type ITCReconciliation struct {
 	ID               uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -375,6 +519,48 @@ type GSTRFiling struct {
 	UpdatedAt       time.Time `json:"updatedAt"`
 }
 
+// ============ Counterparty Filing Status Models ============
+
+// GSTIN registration statuses reported by the GST portal
+const (
+	GSTINStatusActive    = "ACTIVE"
+	GSTINStatusCancelled = "CANCELLED"
+	GSTINStatusSuspended = "SUSPENDED"
+)
+
+// Counterparty roles being monitored
+const (
+	CounterpartyVendor   = "VENDOR"
+	CounterpartyCustomer = "CUSTOMER"
+)
+
+// GSTINFilingStatus is the last known registration and GSTR-1 filing record of
+// a vendor or customer GSTIN. A vendor's ITC only shows up in our GSTR-2B once
+// it has filed GSTR-1, so key suppliers are watched and refreshed from the GSP.
+type GSTINFilingStatus struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID           string     `json:"tenantId" gorm:"type:varchar(255);not null;uniqueIndex:idx_gstin_filing_status"`
+	GSTIN              string     `json:"gstin" gorm:"type:varchar(15);not null;uniqueIndex:idx_gstin_filing_status"`
+	PartyID            *uuid.UUID `json:"partyId,omitempty" gorm:"type:uuid"`
+	PartyName          string     `json:"partyName" gorm:"type:varchar(255)"`
+	Counterparty       string     `json:"counterparty" gorm:"type:varchar(10);default:'VENDOR'"`
+	KeySupplier        bool       `json:"keySupplier" gorm:"default:false"` // Refreshed periodically
+	LegalName          string     `json:"legalName" gorm:"type:varchar(255)"`
+	RegistrationStatus string     `json:"registrationStatus" gorm:"type:varchar(20)"`
+	CancelledOn        *time.Time `json:"cancelledOn,omitempty" gorm:"type:date"`
+	LastGSTR1Period    string     `json:"lastGstr1Period" gorm:"type:varchar(10)"` // MMYYYY
+	LastGSTR1FiledOn   *time.Time `json:"lastGstr1FiledOn,omitempty" gorm:"type:date"`
+	PeriodsChecked     int        `json:"periodsChecked" gorm:"default:0"`
+	LateFilings        int        `json:"lateFilings" gorm:"default:0"`    // Among PeriodsChecked
+	PendingFilings     int        `json:"pendingFilings" gorm:"default:0"` // Past due and not filed
+	HabituallyLate     bool       `json:"habituallyLate" gorm:"default:false"`
+	Flagged            bool       `json:"flagged" gorm:"default:false;index"`
+	LastCheckedAt      *time.Time `json:"lastCheckedAt,omitempty"`
+	LastCheckError     string     `json:"lastCheckError,omitempty" gorm:"type:text"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+}
+
 // ============ Helper Types ============
 
 // JSONB is a custom type for PostgreSQL JSONB fields
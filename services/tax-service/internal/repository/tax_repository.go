@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -63,6 +64,28 @@ func (r *TaxRepository) GetJurisdictionByStateCode(ctx context.Context, tenantID
 	return &jurisdiction, nil
 }
 
+// GetCountryJurisdiction returns the tenant's jurisdiction for a country,
+// falling back to the global one
+func (r *TaxRepository) GetCountryJurisdiction(ctx context.Context, tenantID, countryCode string) (*models.TaxJurisdiction, error) {
+	var jurisdictions []models.TaxJurisdiction
+	err := r.db.WithContext(ctx).
+		Where("tenant_id IN ? AND type = ? AND code = ? AND is_active = true", []string{tenantID, GlobalTenantID}, models.JurisdictionTypeCountry, countryCode).
+		Find(&jurisdictions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jurisdictions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	for i := range jurisdictions {
+		if jurisdictions[i].TenantID == tenantID {
+			return &jurisdictions[i], nil
+		}
+	}
+	return &jurisdictions[0], nil
+}
+
 func (r *TaxRepository) ListJurisdictions(ctx context.Context, tenantID string) ([]models.TaxJurisdiction, error) {
 	var jurisdictions []models.TaxJurisdiction
 	err := r.db.WithContext(ctx).
@@ -260,6 +283,15 @@ func (r *TaxRepository) GetTDSDeduction(ctx context.Context, id uuid.UUID) (*mod
 	return &deduction, nil
 }
 
+func (r *TaxRepository) GetTDSDeductionByPayment(ctx context.Context, tenantID string, paymentID uuid.UUID) (*models.TDSDeduction, error) {
+	var deduction models.TDSDeduction
+	err := r.db.WithContext(ctx).First(&deduction, "tenant_id = ? AND payment_id = ?", tenantID, paymentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deduction, nil
+}
+
 func (r *TaxRepository) ListTDSDeductions(ctx context.Context, tenantID, financialYear string, quarter int) ([]models.TDSDeduction, error) {
 	var deductions []models.TDSDeduction
 	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
@@ -288,6 +320,10 @@ func (r *TaxRepository) UpdateTDSDeduction(ctx context.Context, deduction *model
 	return r.db.WithContext(ctx).Save(deduction).Error
 }
 
+func (r *TaxRepository) DeleteTDSDeduction(ctx context.Context, deduction *models.TDSDeduction) error {
+	return r.db.WithContext(ctx).Delete(deduction).Error
+}
+
 // ============ TCS Methods ============
 
 func (r *TaxRepository) GetTCSRate(ctx context.Context, tenantID string, section models.TCSSection) (*models.TCSRate, error) {
@@ -308,6 +344,15 @@ func (r *TaxRepository) CreateTCSCollection(ctx context.Context, collection *mod
 	return r.db.WithContext(ctx).Create(collection).Error
 }
 
+func (r *TaxRepository) GetTCSCollectionByInvoice(ctx context.Context, tenantID string, invoiceID uuid.UUID) (*models.TCSCollection, error) {
+	var collection models.TCSCollection
+	err := r.db.WithContext(ctx).First(&collection, "tenant_id = ? AND invoice_id = ?", tenantID, invoiceID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
 func (r *TaxRepository) ListTCSCollections(ctx context.Context, tenantID, financialYear string, quarter int) ([]models.TCSCollection, error) {
 	var collections []models.TCSCollection
 	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
@@ -432,6 +477,28 @@ func (r *TaxRepository) UpdateGSTRFiling(ctx context.Context, filing *models.GST
 	return r.db.WithContext(ctx).Save(filing).Error
 }
 
+// ListITCReconciliations returns a financial year's books vs GSTR-2B ITC
+// reconciliations
+func (r *TaxRepository) ListITCReconciliations(ctx context.Context, tenantID, financialYear string) ([]models.ITCReconciliation, error) {
+	var reconciliations []models.ITCReconciliation
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND financial_year = ?", tenantID, financialYear).
+		Order("period").
+		Find(&reconciliations).Error
+	return reconciliations, err
+}
+
+// ListInputTaxCreditsByInvoiceDate returns ITC on purchase invoices dated
+// within [from, to)
+func (r *TaxRepository) ListInputTaxCreditsByInvoiceDate(ctx context.Context, tenantID string, from, to time.Time) ([]models.InputTaxCredit, error) {
+	var itcs []models.InputTaxCredit
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND invoice_date >= ? AND invoice_date < ?", tenantID, from, to).
+		Order("invoice_date").
+		Find(&itcs).Error
+	return itcs, err
+}
+
 // ============ Cache Methods ============
 
 func (r *TaxRepository) GetCachedTaxCalculation(ctx context.Context, cacheKey string) (*models.TaxCalculationCache, error) {
@@ -448,3 +515,208 @@ func (r *TaxRepository) GetCachedTaxCalculation(ctx context.Context, cacheKey st
 func (r *TaxRepository) CacheTaxCalculation(ctx context.Context, cache *models.TaxCalculationCache) error {
 	return r.db.WithContext(ctx).Create(cache).Error
 }
+
+// ============ Challan Methods ============
+
+func (r *TaxRepository) CreateChallan(ctx context.Context, challan *models.TaxChallan) error {
+	return r.db.WithContext(ctx).Create(challan).Error
+}
+
+func (r *TaxRepository) GetChallan(ctx context.Context, tenantID string, id uuid.UUID) (*models.TaxChallan, error) {
+	var challan models.TaxChallan
+	err := r.db.WithContext(ctx).First(&challan, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &challan, nil
+}
+
+func (r *TaxRepository) GetChallanByIdentity(ctx context.Context, tenantID, bsrCode, challanNumber string, depositDate time.Time) (*models.TaxChallan, error) {
+	var challan models.TaxChallan
+	err := r.db.WithContext(ctx).
+		First(&challan, "tenant_id = ? AND bsr_code = ? AND challan_number = ? AND deposit_date = ?",
+			tenantID, bsrCode, challanNumber, depositDate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &challan, nil
+}
+
+func (r *TaxRepository) ListChallans(ctx context.Context, tenantID string, challanType models.ChallanType, financialYear string, quarter int) ([]models.TaxChallan, error) {
+	var challans []models.TaxChallan
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if challanType != "" {
+		query = query.Where("challan_type = ?", challanType)
+	}
+	if financialYear != "" {
+		query = query.Where("financial_year = ?", financialYear)
+	}
+	if quarter > 0 {
+		query = query.Where("quarter = ?", quarter)
+	}
+	err := query.Order("deposit_date DESC").Find(&challans).Error
+	return challans, err
+}
+
+// ListPendingTDSDeductions returns deductions not yet deposited. Empty filters
+// are ignored; ids restricts the result to the given deductions.
+func (r *TaxRepository) ListPendingTDSDeductions(ctx context.Context, tenantID, financialYear string, quarter int, section string, ids []uuid.UUID) ([]models.TDSDeduction, error) {
+	var deductions []models.TDSDeduction
+	query := r.db.WithContext(ctx).Where("tenant_id = ? AND status = ?", tenantID, "PENDING")
+	if financialYear != "" {
+		query = query.Where("financial_year = ?", financialYear)
+	}
+	if quarter > 0 {
+		query = query.Where("quarter = ?", quarter)
+	}
+	if section != "" {
+		query = query.Where("section = ?", section)
+	}
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	err := query.Order("deduction_date ASC").Find(&deductions).Error
+	return deductions, err
+}
+
+// ListPendingTCSCollections returns collections not yet deposited. Empty
+// filters are ignored; ids restricts the result to the given collections.
+func (r *TaxRepository) ListPendingTCSCollections(ctx context.Context, tenantID, financialYear string, quarter int, section string, ids []uuid.UUID) ([]models.TCSCollection, error) {
+	var collections []models.TCSCollection
+	query := r.db.WithContext(ctx).Where("tenant_id = ? AND status = ?", tenantID, "PENDING")
+	if financialYear != "" {
+		query = query.Where("financial_year = ?", financialYear)
+	}
+	if quarter > 0 {
+		query = query.Where("quarter = ?", quarter)
+	}
+	if section != "" {
+		query = query.Where("section = ?", section)
+	}
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	err := query.Order("collection_date ASC").Find(&collections).Error
+	return collections, err
+}
+
+// LinkChallan marks the given deductions (TDS challan) or collections (TCS
+// challan) as deposited against the challan and saves the challan, in one
+// transaction. It fails if any row is no longer pending.
+func (r *TaxRepository) LinkChallan(ctx context.Context, challan *models.TaxChallan, ids []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var model interface{} = &models.TDSDeduction{}
+		if challan.ChallanType == models.ChallanTypeTCS {
+			model = &models.TCSCollection{}
+		}
+
+		updates := map[string]interface{}{
+			"status":         "DEPOSITED",
+			"challan_id":     challan.ID,
+			"challan_number": challan.ChallanNumber,
+			"deposit_date":   challan.DepositDate,
+			"updated_at":     time.Now(),
+		}
+		if challan.ChallanType == models.ChallanTypeTDS {
+			updates["bsr_code"] = challan.BSRCode
+		}
+
+		result := tx.Model(model).
+			Where("tenant_id = ? AND status = ? AND id IN ?", challan.TenantID, "PENDING", ids).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			return fmt.Errorf("expected to link %d rows, linked %d", len(ids), result.RowsAffected)
+		}
+
+		challan.UpdatedAt = time.Now()
+		return tx.Save(challan).Error
+	})
+}
+
+// ============ Counterparty Filing Status Methods ============
+
+func (r *TaxRepository) GetGSTINFilingStatus(ctx context.Context, tenantID, gstin string) (*models.GSTINFilingStatus, error) {
+	var status models.GSTINFilingStatus
+	err := r.db.WithContext(ctx).First(&status, "tenant_id = ? AND gstin = ?", tenantID, gstin).Error
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (r *TaxRepository) SaveGSTINFilingStatus(ctx context.Context, status *models.GSTINFilingStatus) error {
+	return r.db.WithContext(ctx).Save(status).Error
+}
+
+func (r *TaxRepository) DeleteGSTINFilingStatus(ctx context.Context, tenantID, gstin string) error {
+	return r.db.WithContext(ctx).Delete(&models.GSTINFilingStatus{}, "tenant_id = ? AND gstin = ?", tenantID, gstin).Error
+}
+
+func (r *TaxRepository) ListGSTINFilingStatuses(ctx context.Context, tenantID, counterparty string, flaggedOnly bool) ([]models.GSTINFilingStatus, error) {
+	var statuses []models.GSTINFilingStatus
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if counterparty != "" {
+		query = query.Where("counterparty = ?", counterparty)
+	}
+	if flaggedOnly {
+		query = query.Where("flagged = ?", true)
+	}
+	err := query.Order("flagged DESC, party_name ASC").Find(&statuses).Error
+	return statuses, err
+}
+
+// ListKeySupplierStatusesCheckedBefore returns key suppliers of every tenant
+// that have not been checked since the cutoff
+func (r *TaxRepository) ListKeySupplierStatusesCheckedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.GSTINFilingStatus, error) {
+	var statuses []models.GSTINFilingStatus
+	err := r.db.WithContext(ctx).
+		Where("key_supplier = ? AND (last_checked_at IS NULL OR last_checked_at < ?)", true, cutoff).
+		Order("last_checked_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&statuses).Error
+	return statuses, err
+}
+
+// ============ ITC Eligibility Rule Methods ============
+
+func (r *TaxRepository) ListITCEligibilityRules(ctx context.Context, tenantID string) ([]models.ITCEligibilityRule, error) {
+	var rules []models.ITCEligibilityRule
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Order("category ASC, code ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *TaxRepository) CreateITCEligibilityRule(ctx context.Context, rule *models.ITCEligibilityRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *TaxRepository) DeleteITCEligibilityRule(ctx context.Context, tenantID string, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.ITCEligibilityRule{}, "tenant_id = ? AND id = ?", tenantID, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListBlockedInputTaxCredits returns ITC entries blocked under section 17(5)
+// for a claim period (MMYYYY) or, when period is empty, invoices dated in
+// [from, to)
+func (r *TaxRepository) ListBlockedInputTaxCredits(ctx context.Context, tenantID, period string, from, to time.Time) ([]models.InputTaxCredit, error) {
+	var itcs []models.InputTaxCredit
+	query := r.db.WithContext(ctx).Where("tenant_id = ? AND blocked_category <> ''", tenantID)
+	if period != "" {
+		query = query.Where("claim_period = ?", period)
+	} else {
+		query = query.Where("invoice_date >= ? AND invoice_date < ?", from, to)
+	}
+	err := query.Order("invoice_date ASC").Find(&itcs).Error
+	return itcs, err
+}
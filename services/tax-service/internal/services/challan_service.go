@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/repository"
+)
+
+var (
+	ErrChallanNotFound        = errors.New("challan not found")
+	ErrDuplicateChallan       = errors.New("challan already recorded")
+	ErrInvalidChallan         = errors.New("invalid challan data")
+	ErrChallanRowsNotEligible = errors.New("one or more rows are not pending for the challan's quarter or were made after the deposit date")
+	ErrNothingToLink          = errors.New("no pending rows to link")
+	ErrChallanInsufficient    = errors.New("challan amount does not cover the linked rows")
+)
+
+// Monthly interest on late deposit: 1.5% u/s 201(1A) for TDS, 1% u/s 206C(7) for TCS
+var (
+	tdsLateDepositInterest = decimal.NewFromFloat(1.5)
+	tcsLateDepositInterest = decimal.NewFromInt(1)
+)
+
+// pendingRow is a TDS deduction or TCS collection awaiting deposit
+type pendingRow struct {
+	id        uuid.UUID
+	section   string
+	partyName string
+	amount    decimal.Decimal
+	date      time.Time
+}
+
+// ChallanService handles TDS/TCS challan deposits and reconciliation
+type ChallanService struct {
+	repo *repository.TaxRepository
+}
+
+// NewChallanService creates a new challan service
+func NewChallanService(repo *repository.TaxRepository) *ChallanService {
+	return &ChallanService{repo: repo}
+}
+
+// CreateChallan records a challan deposit
+func (s *ChallanService) CreateChallan(ctx context.Context, req models.CreateChallanRequest) (*models.TaxChallan, error) {
+	depositDate, err := time.Parse("2006-01-02", req.DepositDate)
+	if err != nil || !req.TaxAmount.IsPositive() || req.InterestAmount.IsNegative() || req.FeeAmount.IsNegative() {
+		return nil, ErrInvalidChallan
+	}
+
+	if _, err := s.repo.GetChallanByIdentity(ctx, req.TenantID, req.BSRCode, req.ChallanNumber, depositDate); err == nil {
+		return nil, ErrDuplicateChallan
+	}
+
+	challan := &models.TaxChallan{
+		TenantID:       req.TenantID,
+		ChallanType:    req.ChallanType,
+		Section:        req.Section,
+		ChallanNumber:  req.ChallanNumber,
+		BSRCode:        req.BSRCode,
+		DepositDate:    depositDate,
+		FinancialYear:  req.FinancialYear,
+		Quarter:        req.Quarter,
+		TaxAmount:      req.TaxAmount,
+		InterestAmount: req.InterestAmount,
+		FeeAmount:      req.FeeAmount,
+		TotalAmount:    req.TaxAmount.Add(req.InterestAmount).Add(req.FeeAmount),
+		LinkedAmount:   decimal.Zero,
+		Status:         models.ChallanStatusUnlinked,
+	}
+
+	if err := s.repo.CreateChallan(ctx, challan); err != nil {
+		return nil, err
+	}
+
+	return challan, nil
+}
+
+// GetChallan returns a challan by ID
+func (s *ChallanService) GetChallan(ctx context.Context, tenantID string, id uuid.UUID) (*models.TaxChallan, error) {
+	challan, err := s.repo.GetChallan(ctx, tenantID, id)
+	if err != nil {
+		return nil, ErrChallanNotFound
+	}
+	return challan, nil
+}
+
+// ListChallans returns challans filtered by type and period
+func (s *ChallanService) ListChallans(ctx context.Context, tenantID string, challanType models.ChallanType, financialYear string, quarter int) ([]models.TaxChallan, error) {
+	return s.repo.ListChallans(ctx, tenantID, challanType, financialYear, quarter)
+}
+
+// LinkChallan marks pending deductions/collections of the challan's quarter as
+// deposited. The rows linked must be covered by the challan's unlinked tax.
+func (s *ChallanService) LinkChallan(ctx context.Context, tenantID string, challanID uuid.UUID, req models.LinkChallanRequest) (*models.LinkChallanResponse, error) {
+	challan, err := s.repo.GetChallan(ctx, tenantID, challanID)
+	if err != nil {
+		return nil, ErrChallanNotFound
+	}
+
+	rows, err := s.pendingRows(ctx, tenantID, challan.ChallanType, challan.FinancialYear, challan.Quarter, challan.Section, req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tax cannot be deposited before it was deducted or collected
+	eligible := rows[:0]
+	for _, row := range rows {
+		if !row.date.After(challan.DepositDate) {
+			eligible = append(eligible, row)
+		}
+	}
+	if len(req.IDs) > 0 && len(eligible) != len(req.IDs) {
+		return nil, ErrChallanRowsNotEligible
+	}
+	if len(eligible) == 0 {
+		return nil, ErrNothingToLink
+	}
+
+	ids := make([]uuid.UUID, 0, len(eligible))
+	linkedAmount := decimal.Zero
+	for _, row := range eligible {
+		ids = append(ids, row.id)
+		linkedAmount = linkedAmount.Add(row.amount)
+	}
+
+	if linkedAmount.GreaterThan(challan.UnlinkedAmount()) {
+		return nil, ErrChallanInsufficient
+	}
+
+	challan.LinkedAmount = challan.LinkedAmount.Add(linkedAmount)
+	if challan.UnlinkedAmount().IsZero() {
+		challan.Status = models.ChallanStatusLinked
+	} else {
+		challan.Status = models.ChallanStatusPartial
+	}
+
+	if err := s.repo.LinkChallan(ctx, challan, ids); err != nil {
+		return nil, err
+	}
+
+	return &models.LinkChallanResponse{
+		Challan:        challan,
+		LinkedCount:    len(ids),
+		LinkedAmount:   linkedAmount,
+		UnlinkedAmount: challan.UnlinkedAmount(),
+	}, nil
+}
+
+// PendingDepositAgeing reports TDS/TCS awaiting deposit, bucketed by days past
+// the statutory due date, with estimated interest for late deposit as of asOf.
+// An empty challanType includes both.
+func (s *ChallanService) PendingDepositAgeing(ctx context.Context, tenantID string, challanType models.ChallanType, asOf time.Time) (*models.PendingDepositAgeingResponse, error) {
+	types := []models.ChallanType{models.ChallanTypeTDS, models.ChallanTypeTCS}
+	if challanType != "" {
+		types = []models.ChallanType{challanType}
+	}
+
+	report := &models.PendingDepositAgeingResponse{
+		AsOf: asOf.Format("2006-01-02"),
+		Buckets: []models.AgeingBucket{
+			{Label: "Not due"},
+			{Label: "1-30 days"},
+			{Label: "31-60 days"},
+			{Label: "61-90 days"},
+			{Label: "Over 90 days"},
+		},
+		Items:         []models.PendingDepositItem{},
+		TotalPending:  decimal.Zero,
+		TotalInterest: decimal.Zero,
+	}
+	for i := range report.Buckets {
+		report.Buckets[i].Amount = decimal.Zero
+	}
+
+	for _, t := range types {
+		rows, err := s.pendingRows(ctx, tenantID, t, "", 0, "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			dueDate := depositDueDate(t, row.date)
+			daysOverdue := 0
+			if asOf.After(dueDate) {
+				daysOverdue = int(asOf.Sub(dueDate).Hours() / 24)
+			}
+
+			interest := decimal.Zero
+			if daysOverdue > 0 {
+				interest = lateDepositInterest(t, row.amount, row.date, asOf)
+			}
+
+			bucket := ageingBucket(daysOverdue)
+			report.Buckets[bucket].Count++
+			report.Buckets[bucket].Amount = report.Buckets[bucket].Amount.Add(row.amount)
+
+			report.Items = append(report.Items, models.PendingDepositItem{
+				ID:              row.id,
+				ChallanType:     t,
+				Section:         row.section,
+				PartyName:       row.partyName,
+				Amount:          row.amount,
+				TransactionDate: row.date.Format("2006-01-02"),
+				DueDate:         dueDate.Format("2006-01-02"),
+				DaysOverdue:     daysOverdue,
+				Interest:        interest,
+				Bucket:          report.Buckets[bucket].Label,
+			})
+			report.TotalPending = report.TotalPending.Add(row.amount)
+			report.TotalInterest = report.TotalInterest.Add(interest)
+		}
+	}
+
+	return report, nil
+}
+
+func (s *ChallanService) pendingRows(ctx context.Context, tenantID string, challanType models.ChallanType, financialYear string, quarter int, section string, ids []uuid.UUID) ([]pendingRow, error) {
+	var rows []pendingRow
+
+	if challanType == models.ChallanTypeTCS {
+		collections, err := s.repo.ListPendingTCSCollections(ctx, tenantID, financialYear, quarter, section, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range collections {
+			rows = append(rows, pendingRow{id: c.ID, section: string(c.Section), partyName: c.CustomerName, amount: c.TCSAmount, date: c.CollectionDate})
+		}
+		return rows, nil
+	}
+
+	deductions, err := s.repo.ListPendingTDSDeductions(ctx, tenantID, financialYear, quarter, section, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deductions {
+		rows = append(rows, pendingRow{id: d.ID, section: string(d.Section), partyName: d.DeducteeName, amount: d.TDSAmount, date: d.DeductionDate})
+	}
+	return rows, nil
+}
+
+// depositDueDate returns the date by which tax deducted/collected on date must
+// be deposited: the 7th of the following month, or 30 April for TDS deducted
+// in March.
+func depositDueDate(challanType models.ChallanType, date time.Time) time.Time {
+	if challanType == models.ChallanTypeTDS && date.Month() == time.March {
+		return time.Date(date.Year(), time.April, 30, 0, 0, 0, 0, date.Location())
+	}
+	return time.Date(date.Year(), date.Month()+1, 7, 0, 0, 0, 0, date.Location())
+}
+
+// lateDepositInterest returns interest, rounded to the rupee, on amount
+// deducted/collected on from and deposited (or still pending) on to
+func lateDepositInterest(challanType models.ChallanType, amount decimal.Decimal, from, to time.Time) decimal.Decimal {
+	rate := tdsLateDepositInterest
+	if challanType == models.ChallanTypeTCS {
+		rate = tcsLateDepositInterest
+	}
+	months := decimal.NewFromInt(int64(monthsOrPart(from, to)))
+	return amount.Mul(rate).Mul(months).Div(decimal.NewFromInt(100)).Round(0)
+}
+
+// monthsOrPart counts calendar months, including part months, from from to to
+func monthsOrPart(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+}
+
+func ageingBucket(daysOverdue int) int {
+	switch {
+	case daysOverdue <= 0:
+		return 0
+	case daysOverdue <= 30:
+		return 1
+	case daysOverdue <= 60:
+		return 2
+	case daysOverdue <= 90:
+		return 3
+	default:
+		return 4
+	}
+}
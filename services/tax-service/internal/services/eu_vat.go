@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// VAT number statuses reported in the VAT summary
+const (
+	VATNumberValid      = "VALID"
+	VATNumberInvalid    = "INVALID"
+	VATNumberUnverified = "UNVERIFIED"
+)
+
+// euVATRates holds a member state's VAT rates in percent. Zero means the
+// country has no such rate.
+type euVATRates struct {
+	Name          string
+	Standard      float64
+	Reduced       float64
+	SecondReduced float64
+	SuperReduced  float64
+}
+
+// euVATTable lists EU member state VAT rates as published by the European
+// Commission (2025). Keyed by ISO country code; Greece uses EL in VAT numbers.
+var euVATTable = map[string]euVATRates{
+	"AT": {Name: "Austria", Standard: 20, Reduced: 13, SecondReduced: 10},
+	"BE": {Name: "Belgium", Standard: 21, Reduced: 12, SecondReduced: 6},
+	"BG": {Name: "Bulgaria", Standard: 20, Reduced: 9},
+	"HR": {Name: "Croatia", Standard: 25, Reduced: 13, SecondReduced: 5},
+	"CY": {Name: "Cyprus", Standard: 19, Reduced: 9, SecondReduced: 5},
+	"CZ": {Name: "Czechia", Standard: 21, Reduced: 12},
+	"DK": {Name: "Denmark", Standard: 25},
+	"EE": {Name: "Estonia", Standard: 24, Reduced: 13, SecondReduced: 9},
+	"FI": {Name: "Finland", Standard: 25.5, Reduced: 14, SecondReduced: 10},
+	"FR": {Name: "France", Standard: 20, Reduced: 10, SecondReduced: 5.5, SuperReduced: 2.1},
+	"DE": {Name: "Germany", Standard: 19, Reduced: 7},
+	"GR": {Name: "Greece", Standard: 24, Reduced: 13, SecondReduced: 6},
+	"HU": {Name: "Hungary", Standard: 27, Reduced: 18, SecondReduced: 5},
+	"IE": {Name: "Ireland", Standard: 23, Reduced: 13.5, SecondReduced: 9, SuperReduced: 4.8},
+	"IT": {Name: "Italy", Standard: 22, Reduced: 10, SecondReduced: 5, SuperReduced: 4},
+	"LV": {Name: "Latvia", Standard: 21, Reduced: 12, SecondReduced: 5},
+	"LT": {Name: "Lithuania", Standard: 21, Reduced: 9, SecondReduced: 5},
+	"LU": {Name: "Luxembourg", Standard: 17, Reduced: 14, SecondReduced: 8, SuperReduced: 3},
+	"MT": {Name: "Malta", Standard: 18, Reduced: 7, SecondReduced: 5},
+	"NL": {Name: "Netherlands", Standard: 21, Reduced: 9},
+	"PL": {Name: "Poland", Standard: 23, Reduced: 8, SecondReduced: 5},
+	"PT": {Name: "Portugal", Standard: 23, Reduced: 13, SecondReduced: 6},
+	"RO": {Name: "Romania", Standard: 21, Reduced: 11},
+	"SK": {Name: "Slovakia", Standard: 23, Reduced: 19, SecondReduced: 5},
+	"SI": {Name: "Slovenia", Standard: 22, Reduced: 9.5, SecondReduced: 5},
+	"ES": {Name: "Spain", Standard: 21, Reduced: 10, SuperReduced: 4},
+	"SE": {Name: "Sweden", Standard: 25, Reduced: 12, SecondReduced: 6},
+}
+
+func isEUCountry(countryCode string) bool {
+	_, ok := euVATTable[countryCode]
+	return ok
+}
+
+// rateFor returns the rate of the given type, falling back to the next
+// higher rate the country has
+func (r euVATRates) rateFor(rateType models.VATRateType) float64 {
+	switch rateType {
+	case models.VATRateZero:
+		return 0
+	case models.VATRateSuperReduced:
+		if r.SuperReduced > 0 {
+			return r.SuperReduced
+		}
+		fallthrough
+	case models.VATRateSecondReduced:
+		if r.SecondReduced > 0 {
+			return r.SecondReduced
+		}
+		fallthrough
+	case models.VATRateReduced:
+		if r.Reduced > 0 {
+			return r.Reduced
+		}
+	}
+	return r.Standard
+}
+
+// splitVATNumber returns the country prefix and number of an EU VAT number,
+// mapping Greece's EL prefix to GR
+func splitVATNumber(vatNumber string) (string, string) {
+	n := strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(vatNumber))
+	if len(n) < 3 {
+		return "", n
+	}
+	prefix := n[:2]
+	if prefix == "EL" {
+		prefix = "GR"
+	}
+	return prefix, n[2:]
+}
+
+// calculateEUVAT calculates VAT for supplies made from or into the EU:
+//   - domestic and B2C cross-border sales are taxed at the destination
+//     country's rate (OSS)
+//   - B2B cross-border sales to a VIES-validated VAT number are reverse charged
+//   - sales from the EU to outside the EU are zero-rated exports
+func (c *TaxCalculator) calculateEUVAT(ctx context.Context, req models.CalculateTaxRequest, originCountry, destCountry string) (*models.TaxCalculationResponse, error) {
+	subtotal := c.calculateSubtotal(req.LineItems)
+	if originCountry == "" {
+		originCountry = destCountry
+	}
+
+	response := &models.TaxCalculationResponse{
+		Subtotal:       subtotal,
+		ShippingAmount: req.ShippingAmount,
+		Total:          subtotal + req.ShippingAmount,
+		TaxBreakdown:   []models.TaxBreakdown{},
+		VATSummary:     &models.VATSummary{PlaceOfSupply: destCountry},
+	}
+
+	if !isEUCountry(destCountry) {
+		response.IsExempt = true
+		response.ExemptReason = "Export of goods outside the EU (Art. 146 VAT Directive)"
+		response.VATSummary.IsExport = true
+		return response, nil
+	}
+
+	if req.CustomerVATNumber != "" {
+		response.VATSummary.BuyerVATNumber = req.CustomerVATNumber
+		response.VATSummary.VATNumberStatus = VATNumberUnverified
+	}
+
+	if req.IsB2B && req.CustomerVATNumber != "" && originCountry != destCountry {
+		status := c.checkVATNumber(ctx, req.CustomerVATNumber, destCountry)
+		response.VATSummary.VATNumberStatus = status
+		if status == VATNumberValid {
+			response.ReverseCharge = true
+			response.ExemptReason = "Intra-community supply - VAT reverse charged to the customer (Art. 196 VAT Directive)"
+			response.VATSummary.IsReverseCharge = true
+			return response, nil
+		}
+		if status == VATNumberUnverified {
+			response.Warnings = append(response.Warnings, "VIES could not confirm the customer's VAT number; VAT was charged instead of reverse charge")
+		}
+	}
+
+	rates := euVATTable[destCountry]
+	policy := c.getRoundingPolicy(ctx, req.TenantID, destCountry)
+	lines := newTaxLines(policy)
+	for _, item := range req.LineItems {
+		category := c.getProductCategory(ctx, req.TenantID, item)
+		if category != nil && category.IsTaxExempt {
+			continue
+		}
+
+		var rateType models.VATRateType
+		if category != nil {
+			rateType = category.VATRateType
+		}
+		rate := rates.rateFor(rateType)
+		if rate == 0 {
+			continue
+		}
+
+		lines.add(models.TaxBreakdown{
+			JurisdictionName: rates.Name,
+			TaxType:          string(models.TaxTypeVAT),
+			Rate:             rate,
+			TaxableAmount:    item.Subtotal,
+			TaxAmount:        item.Subtotal * (rate / 100.0),
+		})
+	}
+
+	// Shipping follows the standard rate of the place of supply
+	if req.ShippingAmount > 0 {
+		lines.add(models.TaxBreakdown{
+			JurisdictionName: rates.Name,
+			TaxType:          string(models.TaxTypeVAT),
+			Rate:             rates.Standard,
+			TaxableAmount:    req.ShippingAmount,
+			TaxAmount:        req.ShippingAmount * (rates.Standard / 100.0),
+		})
+	}
+
+	taxBreakdown, totalTax := lines.breakdown()
+	response.TaxBreakdown = taxBreakdown
+	response.TaxAmount = totalTax
+	response.Total = subtotal + req.ShippingAmount + totalTax
+	response.RoundingLevel = policy.Level
+	response.RoundingMethod = policy.Method
+	response.VATSummary.VATRate = rates.Standard
+	response.VATSummary.VATAmount = totalTax
+
+	return response, nil
+}
+
+// checkVATNumber validates the buyer's VAT number with VIES. A number that
+// cannot be checked is treated as unverified so VAT is charged rather than
+// wrongly reverse charged.
+func (c *TaxCalculator) checkVATNumber(ctx context.Context, vatNumber, destCountry string) string {
+	prefix, number := splitVATNumber(vatNumber)
+	if prefix != destCountry || number == "" {
+		return VATNumberInvalid
+	}
+	if c.vatValidator == nil {
+		return VATNumberUnverified
+	}
+
+	if prefix == "GR" {
+		prefix = "EL"
+	}
+	check, err := c.vatValidator.ValidateVATNumber(ctx, prefix, number)
+	if err != nil {
+		return VATNumberUnverified
+	}
+	if !check.Valid {
+		return VATNumberInvalid
+	}
+	return VATNumberValid
+}
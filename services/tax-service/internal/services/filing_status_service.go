@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/repository"
+)
+
+var (
+	ErrGSTINNotWatched  = errors.New("GSTIN is not being monitored")
+	ErrInvalidGSTIN     = errors.New("invalid GSTIN")
+	ErrFilingSourceDown = errors.New("unable to check filing status with the GSP")
+)
+
+const (
+	// filingLookbackPeriods is how many past GSTR-1 periods are checked
+	filingLookbackPeriods = 12
+	// habitualLateFilings is the number of late or missing GSTR-1 returns in
+	// the lookback that marks a counterparty as a habitual late filer
+	habitualLateFilings = 3
+	// filingStatusStaleAfter is how old a stored status may be before a
+	// lookup at bill entry refreshes it from the GSP
+	filingStatusStaleAfter = 7 * 24 * time.Hour
+	// filingRefreshBatch caps the key suppliers refreshed per run
+	filingRefreshBatch = 200
+)
+
+// FilingStatusService monitors the GST registration and GSTR-1 filing record
+// of vendors and customers
+type FilingStatusService struct {
+	repo            *repository.TaxRepository
+	source          clients.GSTFilingSource
+	refreshInterval time.Duration
+}
+
+// NewFilingStatusService creates a filing status service. source may be nil
+// when no GSP is configured; stored statuses are still served.
+func NewFilingStatusService(repo *repository.TaxRepository, source clients.GSTFilingSource, refreshInterval time.Duration) *FilingStatusService {
+	return &FilingStatusService{repo: repo, source: source, refreshInterval: refreshInterval}
+}
+
+// Watch adds a GSTIN to monitoring (or updates its party details) and checks
+// it straight away. A failed check is recorded on the status, not returned.
+func (s *FilingStatusService) Watch(ctx context.Context, req models.WatchGSTINRequest) (*models.GSTINFilingStatus, error) {
+	gstin := strings.ToUpper(strings.TrimSpace(req.GSTIN))
+	if len(gstin) != 15 {
+		return nil, ErrInvalidGSTIN
+	}
+	counterparty := req.Counterparty
+	if counterparty == "" {
+		counterparty = models.CounterpartyVendor
+	}
+	if counterparty != models.CounterpartyVendor && counterparty != models.CounterpartyCustomer {
+		return nil, ErrInvalidGSTIN
+	}
+
+	status, err := s.repo.GetGSTINFilingStatus(ctx, req.TenantID, gstin)
+	if err != nil {
+		status = &models.GSTINFilingStatus{TenantID: req.TenantID, GSTIN: gstin}
+	}
+	status.PartyID = req.PartyID
+	status.PartyName = req.PartyName
+	status.Counterparty = counterparty
+	status.KeySupplier = req.KeySupplier
+
+	s.refresh(ctx, status, time.Now())
+	if err := s.repo.SaveGSTINFilingStatus(ctx, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Unwatch stops monitoring a GSTIN
+func (s *FilingStatusService) Unwatch(ctx context.Context, tenantID, gstin string) error {
+	if _, err := s.repo.GetGSTINFilingStatus(ctx, tenantID, strings.ToUpper(gstin)); err != nil {
+		return ErrGSTINNotWatched
+	}
+	return s.repo.DeleteGSTINFilingStatus(ctx, tenantID, strings.ToUpper(gstin))
+}
+
+// List returns monitored GSTINs, optionally only flagged ones
+func (s *FilingStatusService) List(ctx context.Context, tenantID, counterparty string, flaggedOnly bool) ([]models.GSTINFilingStatus, error) {
+	return s.repo.ListGSTINFilingStatuses(ctx, tenantID, counterparty, flaggedOnly)
+}
+
+// Check returns a GSTIN's filing status with warnings for document entry.
+// Unknown or stale GSTINs are looked up at the GSP (and remembered); when the
+// GSP cannot answer, the last stored status is returned.
+func (s *FilingStatusService) Check(ctx context.Context, tenantID, gstin string, forceRefresh bool) (*models.GSTINCheckResponse, error) {
+	gstin = strings.ToUpper(strings.TrimSpace(gstin))
+	if len(gstin) != 15 {
+		return nil, ErrInvalidGSTIN
+	}
+
+	now := time.Now()
+	status, err := s.repo.GetGSTINFilingStatus(ctx, tenantID, gstin)
+	if err != nil {
+		if s.source == nil {
+			return nil, ErrFilingSourceDown
+		}
+		status = &models.GSTINFilingStatus{TenantID: tenantID, GSTIN: gstin, Counterparty: models.CounterpartyVendor}
+	}
+
+	stale := status.LastCheckedAt == nil || now.Sub(*status.LastCheckedAt) > filingStatusStaleAfter
+	if s.source != nil && (forceRefresh || stale) {
+		s.refresh(ctx, status, now)
+		if status.LastCheckedAt == nil {
+			return nil, ErrFilingSourceDown
+		}
+		if err := s.repo.SaveGSTINFilingStatus(ctx, status); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.GSTINCheckResponse{Status: status, Warnings: filingWarnings(status)}, nil
+}
+
+// RefreshDue re-checks key suppliers not checked within the refresh interval
+func (s *FilingStatusService) RefreshDue(ctx context.Context) error {
+	if s.source == nil {
+		return nil
+	}
+
+	now := time.Now()
+	statuses, err := s.repo.ListKeySupplierStatusesCheckedBefore(ctx, now.Add(-s.refreshInterval), filingRefreshBatch)
+	if err != nil {
+		return err
+	}
+	for i := range statuses {
+		status := &statuses[i]
+		s.refresh(ctx, status, now)
+		if status.LastCheckError != "" {
+			log.Printf("Filing status check for %s (tenant %s) failed: %s", status.GSTIN, status.TenantID, status.LastCheckError)
+		}
+		if err := s.repo.SaveGSTINFilingStatus(ctx, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refresh updates status from the GSP. A failure leaves the last known
+// figures in place and records the error.
+func (s *FilingStatusService) refresh(ctx context.Context, status *models.GSTINFilingStatus, now time.Time) {
+	if s.source == nil {
+		status.LastCheckError = clients.ErrGSPNotConfigured.Error()
+		return
+	}
+
+	taxpayer, err := s.source.GetTaxpayer(ctx, status.GSTIN)
+	if err != nil {
+		status.LastCheckError = err.Error()
+		return
+	}
+
+	periods := pastGSTR1Periods(now, filingLookbackPeriods)
+	filed := make(map[string]time.Time)
+	fetched := make(map[string]bool)
+	for _, period := range periods {
+		fy := getFinancialYear(period)
+		if fetched[fy] {
+			continue
+		}
+		fetched[fy] = true
+		filings, err := s.source.ListReturnFilings(ctx, status.GSTIN, fy)
+		if err != nil {
+			status.LastCheckError = err.Error()
+			return
+		}
+		for _, f := range filings {
+			if f.ReturnType == string(models.GSTRType1) {
+				filed[f.Period] = f.FiledOn
+			}
+		}
+	}
+
+	status.LegalName = taxpayer.LegalName
+	status.RegistrationStatus = strings.ToUpper(taxpayer.Status)
+	status.CancelledOn = taxpayer.CancelledOn
+	status.LastCheckError = ""
+	status.LastCheckedAt = &now
+
+	// Quarterly (QRMP) filers only file GSTR-1 for the last month of a quarter
+	quarterly := len(filed) > 0
+	for period := range filed {
+		if month, err := periodMonth(period); err != nil || month%3 != 0 {
+			quarterly = false
+			break
+		}
+	}
+
+	status.PeriodsChecked, status.LateFilings, status.PendingFilings = 0, 0, 0
+	status.LastGSTR1Period, status.LastGSTR1FiledOn = "", nil
+	var latest time.Time
+	for _, period := range periods {
+		if quarterly && period.Month()%3 != 0 {
+			continue
+		}
+		due := gstr1DueDate(period, quarterly)
+		if !now.After(due) {
+			continue
+		}
+		// No return is due for periods after the registration was cancelled
+		if status.CancelledOn != nil && period.After(*status.CancelledOn) {
+			continue
+		}
+
+		status.PeriodsChecked++
+		key := period.Format("012006")
+		filedOn, ok := filed[key]
+		switch {
+		case !ok:
+			status.PendingFilings++
+		case filedOn.After(due):
+			status.LateFilings++
+		}
+		if ok && period.After(latest) {
+			latest = period
+			status.LastGSTR1Period = key
+			status.LastGSTR1FiledOn = &filedOn
+		}
+	}
+
+	status.HabituallyLate = status.LateFilings+status.PendingFilings >= habitualLateFilings
+	status.Flagged = status.RegistrationStatus != models.GSTINStatusActive || status.PendingFilings > 0 || status.HabituallyLate
+}
+
+// filingWarnings describes what is wrong with a counterparty's registration
+// or filing record
+func filingWarnings(status *models.GSTINFilingStatus) []string {
+	var warnings []string
+	switch status.RegistrationStatus {
+	case models.GSTINStatusCancelled:
+		since := ""
+		if status.CancelledOn != nil {
+			since = " with effect from " + status.CancelledOn.Format("2006-01-02")
+		}
+		warnings = append(warnings, fmt.Sprintf("GSTIN %s is cancelled%s; ITC is not available on its invoices after cancellation", status.GSTIN, since))
+	case models.GSTINStatusSuspended:
+		warnings = append(warnings, fmt.Sprintf("GSTIN %s is suspended; its invoices may not reach GSTR-2B", status.GSTIN))
+	}
+	if status.PendingFilings > 0 {
+		last := "none in the period checked"
+		if status.LastGSTR1Period != "" {
+			last = status.LastGSTR1Period
+		}
+		warnings = append(warnings, fmt.Sprintf("%s has not filed GSTR-1 for %d past-due period(s) (last filed: %s)", status.GSTIN, status.PendingFilings, last))
+	}
+	if status.HabituallyLate {
+		warnings = append(warnings, fmt.Sprintf("%s filed GSTR-1 late or not at all for %d of the last %d periods; ITC may not show in GSTR-2B on time",
+			status.GSTIN, status.LateFilings+status.PendingFilings, status.PeriodsChecked))
+	}
+	return warnings
+}
+
+// pastGSTR1Periods returns the first day of each of the n months before now,
+// most recent first
+func pastGSTR1Periods(now time.Time, n int) []time.Time {
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	periods := make([]time.Time, 0, n)
+	for i := 1; i <= n; i++ {
+		periods = append(periods, current.AddDate(0, -i, 0))
+	}
+	return periods
+}
+
+// gstr1DueDate is the end of the GSTR-1 due date for a period: the 11th of the
+// following month for monthly filers, the 13th for quarterly (QRMP) filers
+func gstr1DueDate(period time.Time, quarterly bool) time.Time {
+	day := 11
+	if quarterly {
+		day = 13
+	}
+	return time.Date(period.Year(), period.Month()+1, day, 23, 59, 59, 0, period.Location())
+}
+
+// periodMonth returns the month of an MMYYYY return period
+func periodMonth(period string) (int, error) {
+	t, err := time.Parse("012006", period)
+	if err != nil {
+		return 0, err
+	}
+	return int(t.Month()), nil
+}
@@ -1,20 +1,27 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/gst"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
 )
 
 // GSTReturnService handles GST return generation
-type GSTReturnService struct{}
+type GSTReturnService struct {
+	invoices clients.InvoiceClient
+}
 
-// NewGSTReturnService creates a new GST return service
-func NewGSTReturnService() *GSTReturnService {
-	return &GSTReturnService{}
+// NewGSTReturnService creates a new GST return service reading the
+// documents to report from the invoice service
+func NewGSTReturnService(invoices clients.InvoiceClient) *GSTReturnService {
+	return &GSTReturnService{invoices: invoices}
 }
 
 // GSTR1Data represents the complete GSTR-1 return data
@@ -22,12 +29,13 @@ type GSTR1Data struct {
 	GSTIN        string          `json:"gstin"`
 	ReturnPeriod string          `json:"ret_period"` // MMYYYY format
 	B2B          []GSTR1B2B      `json:"b2b"`        // B2B invoices
-	B2CL         []GSTR1B2CL     `json:"b2cl"`       // B2C Large (>2.5L interstate)
+	B2CL         []GSTR1B2CL     `json:"b2cl"`       // B2C Large (>1L interstate)
 	B2CS         []GSTR1B2CS     `json:"b2cs"`       // B2C Small (summary by state)
 	CDNR         []GSTR1CDNR     `json:"cdnr"`       // Credit/Debit notes to registered
 	CDNUR        []GSTR1CDNUR    `json:"cdnur"`      // Credit/Debit notes to unregistered
 	EXP          []GSTR1Export   `json:"exp"`        // Export invoices
 	AT           []GSTR1Advance  `json:"at"`         // Advances received
+	TXPD         []GSTR1Advance  `json:"txpd"`       // Advances adjusted or refunded
 	NIL          GSTR1Nil        `json:"nil"`        // Nil rated, exempt supplies
 	HSN          []GSTR1HSN      `json:"hsn"`        // HSN-wise summary
 	DOCS         []GSTR1DocIssued `json:"doc_issue"` // Document issued summary
@@ -63,7 +71,7 @@ type GSTR1ItemDetails struct {
 	Cess    decimal.Decimal `json:"csamt"` // Cess amount
 }
 
-// GSTR1B2CL represents B2C Large invoice (>2.5L interstate)
+// GSTR1B2CL represents B2C Large invoice (>1L interstate)
 type GSTR1B2CL struct {
 	POS      string             `json:"pos"` // Place of supply
 	Invoices []GSTR1B2CLInvoice `json:"inv"`
@@ -106,6 +114,7 @@ type GSTR1CDNote struct {
 
 // GSTR1CDNUR represents Credit/Debit note to unregistered
 type GSTR1CDNUR struct {
+	Type       string             `json:"typ"` // B2CL, EXPWP or EXPWOP
 	NoteNumber string             `json:"ntnum"`
 	NoteType   string             `json:"ntty"`
 	NoteDate   string             `json:"nt_dt"`
@@ -233,22 +242,58 @@ type GSTR3BSec51 struct {
 	LateFee  decimal.Decimal `json:"ltfee_amt,omitempty"`
 }
 
-// GenerateGSTR1 generates empty GSTR-1 structure for a period
-// TODO: Implement with actual invoice data from database
-func (s *GSTReturnService) GenerateGSTR1(tenantID uuid.UUID, gstin, period string) *GSTR1Data {
-	return &GSTR1Data{
-		GSTIN:        gstin,
-		ReturnPeriod: period,
-		B2B:          []GSTR1B2B{},
-		B2CL:         []GSTR1B2CL{},
-		B2CS:         []GSTR1B2CS{},
-		CDNR:         []GSTR1CDNR{},
-		CDNUR:        []GSTR1CDNUR{},
-		EXP:          []GSTR1Export{},
-		AT:           []GSTR1Advance{},
-		HSN:          []GSTR1HSN{},
-		DOCS:         []GSTR1DocIssued{},
+// gstr1B2CLLimit is the invoice value above which inter-state sales to
+// unregistered customers are reported invoice by invoice in B2CL rather
+// than summed in B2CS
+var gstr1B2CLLimit = decimal.NewFromInt(100000)
+
+// GenerateGSTR1 generates the GSTR-1 structure for a period such as 072025
+// from the month's documents in the invoice service, read on behalf of
+// authorization. gstin is the filer's; its state code decides which
+// supplies are inter-state.
+//
+// Invoices go in B2B, B2CL, B2CS or EXP, their lines at a nil rate in NIL
+// and all their lines in HSN. Credit notes go in CDNR, CDNUR, or reduce
+// B2CS for small sales to unregistered customers. Debit notes raised on
+// vendors for purchase returns are reported in CDNR, or CDNUR for vendors
+// without a GSTIN. AT and TXPD are the advances received and refunded.
+// The document summary (DOCS) is left for the filer, as are amendments.
+func (s *GSTReturnService) GenerateGSTR1(ctx context.Context, authorization, gstin, period string) (*GSTR1Data, error) {
+	if _, err := time.Parse("012006", period); err != nil {
+		return nil, ErrInvalidReturnPeriod
+	}
+
+	invoices, err := s.invoices.GetInvoices(ctx, authorization, period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
 	}
+	creditNotes, err := s.invoices.GetCreditNotes(ctx, authorization, period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
+	}
+	debitNotes, err := s.invoices.GetDebitNotes(ctx, authorization, period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
+	}
+	advances, err := s.invoices.GetAdvanceTax(ctx, authorization, period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
+	}
+
+	b := newGSTR1Builder(gstin, period)
+	for _, inv := range invoices {
+		b.addInvoice(inv)
+	}
+	for _, cn := range creditNotes {
+		b.addCreditNote(cn)
+	}
+	for _, dn := range debitNotes {
+		b.addDebitNote(dn)
+	}
+	b.gstr1.AT = gstr1Advances(advances.Received)
+	b.gstr1.TXPD = gstr1Advances(advances.Refunded)
+
+	return b.gstr1, nil
 }
 
 // GenerateGSTR3B generates empty GSTR-3B structure for a period
@@ -261,8 +306,11 @@ func (s *GSTReturnService) GenerateGSTR3B(tenantID uuid.UUID, gstin, period stri
 }
 
 // ExportGSTR1JSON exports GSTR-1 data as JSON string
-func (s *GSTReturnService) ExportGSTR1JSON(tenantID uuid.UUID, gstin, period string) (string, error) {
-	gstr1 := s.GenerateGSTR1(tenantID, gstin, period)
+func (s *GSTReturnService) ExportGSTR1JSON(ctx context.Context, authorization, gstin, period string) (string, error) {
+	gstr1, err := s.GenerateGSTR1(ctx, authorization, gstin, period)
+	if err != nil {
+		return "", err
+	}
 
 	jsonData, err := json.MarshalIndent(gstr1, "", "  ")
 	if err != nil {
@@ -286,6 +334,379 @@ func (s *GSTReturnService) ExportGSTR3BJSON(tenantID uuid.UUID, gstin, period st
 
 // Helper functions
 
+// gstr1Builder fills the sections of a GSTR-1 one document at a time,
+// keeping the entries of each section in the order first seen
+type gstr1Builder struct {
+	gstr1 *GSTR1Data
+	state string // The filer's state code
+
+	b2b  map[string]int // By customer GSTIN
+	b2cl map[string]int // By place of supply
+	b2cs map[string]int // By place of supply and rate
+	cdnr map[string]int // By counterparty GSTIN
+	exp  map[string]int // By export type
+	hsn  map[string]int // By HSN code and unit
+}
+
+func newGSTR1Builder(gstin, period string) *gstr1Builder {
+	b := &gstr1Builder{
+		gstr1: &GSTR1Data{
+			GSTIN:        gstin,
+			ReturnPeriod: period,
+			B2B:          []GSTR1B2B{},
+			B2CL:         []GSTR1B2CL{},
+			B2CS:         []GSTR1B2CS{},
+			CDNR:         []GSTR1CDNR{},
+			CDNUR:        []GSTR1CDNUR{},
+			EXP:          []GSTR1Export{},
+			AT:           []GSTR1Advance{},
+			TXPD:         []GSTR1Advance{},
+			HSN:          []GSTR1HSN{},
+			DOCS:         []GSTR1DocIssued{},
+		},
+		b2b:  make(map[string]int),
+		b2cl: make(map[string]int),
+		b2cs: make(map[string]int),
+		cdnr: make(map[string]int),
+		exp:  make(map[string]int),
+		hsn:  make(map[string]int),
+	}
+	if len(gstin) >= 2 {
+		b.state = gstin[:2]
+	}
+	return b
+}
+
+func (b *gstr1Builder) addInvoice(inv clients.Invoice) {
+	for _, line := range inv.Items {
+		b.addHSN(line, false)
+	}
+
+	date := inv.InvoiceDate.Format("02-01-2006")
+	if inv.ExportType != "" {
+		exp := GSTR1ExportInvoice{
+			InvoiceNumber: inv.InvoiceNumber,
+			InvoiceDate:   date,
+			Value:         inv.TotalAmount,
+			ShippingBill:  inv.ShippingBillNumber,
+			ShippingPort:  inv.PortCode,
+			Items:         gstr1Items(inv.Items),
+		}
+		if inv.ShippingBillDate != nil {
+			exp.ShippingDate = inv.ShippingBillDate.Format("02-01-2006")
+		}
+		i, ok := b.exp[inv.ExportType]
+		if !ok {
+			i = len(b.gstr1.EXP)
+			b.exp[inv.ExportType] = i
+			b.gstr1.EXP = append(b.gstr1.EXP, GSTR1Export{ExportType: inv.ExportType})
+		}
+		b.gstr1.EXP[i].Invoices = append(b.gstr1.EXP[i].Invoices, exp)
+		return
+	}
+
+	pos := b.placeOfSupply(inv.CustomerState, inv.CustomerGSTIN, inv.IGSTAmount)
+	interState := b.interState(pos, inv.IGSTAmount)
+	var taxed []clients.DocumentItem
+	for _, line := range inv.Items {
+		if gstr1Rate(line).IsZero() {
+			b.addNil(line.Amount, interState)
+			continue
+		}
+		taxed = append(taxed, line)
+	}
+	if len(taxed) == 0 {
+		return
+	}
+	items := gstr1Items(taxed)
+
+	switch {
+	case inv.CustomerGSTIN != "":
+		i, ok := b.b2b[inv.CustomerGSTIN]
+		if !ok {
+			i = len(b.gstr1.B2B)
+			b.b2b[inv.CustomerGSTIN] = i
+			b.gstr1.B2B = append(b.gstr1.B2B, GSTR1B2B{CustomerGSTIN: inv.CustomerGSTIN})
+		}
+		b.gstr1.B2B[i].Invoices = append(b.gstr1.B2B[i].Invoices, GSTR1B2BInvoice{
+			InvoiceNumber: inv.InvoiceNumber,
+			InvoiceDate:   date,
+			Value:         inv.TotalAmount,
+			POS:           pos,
+			ReverseCharge: "N",
+			InvoiceType:   "R",
+			Items:         items,
+		})
+	case interState && inv.TotalAmount.GreaterThan(gstr1B2CLLimit):
+		i, ok := b.b2cl[pos]
+		if !ok {
+			i = len(b.gstr1.B2CL)
+			b.b2cl[pos] = i
+			b.gstr1.B2CL = append(b.gstr1.B2CL, GSTR1B2CL{POS: pos})
+		}
+		b.gstr1.B2CL[i].Invoices = append(b.gstr1.B2CL[i].Invoices, GSTR1B2CLInvoice{
+			InvoiceNumber: inv.InvoiceNumber,
+			InvoiceDate:   date,
+			Value:         inv.TotalAmount,
+			Items:         items,
+		})
+	default:
+		for _, item := range items {
+			b.addB2CS(pos, item.ItemDetails, false)
+		}
+	}
+}
+
+// addCreditNote reports a credit note where the invoice it reduces was
+// reported: in CDNR for registered customers, in CDNUR against exports and
+// large inter-state sales, and otherwise as a reduction of B2CS
+func (b *gstr1Builder) addCreditNote(cn clients.CreditNote) {
+	lines := make([]clients.DocumentItem, 0, len(cn.Items))
+	for _, line := range cn.Items {
+		lines = append(lines, line.DocumentItem())
+	}
+	// Only returned goods take quantities back out of the HSN summary
+	returned := cn.Reason == "goods_returned" || cn.Reason == "defective_goods"
+	for _, line := range lines {
+		if !returned {
+			line.Quantity = decimal.Zero
+		}
+		b.addHSN(line, true)
+	}
+
+	pos := b.placeOfSupply(cn.PlaceOfSupply, cn.CustomerGSTIN, cn.IGSTAmount)
+	note := GSTR1CDNote{
+		NoteNumber: cn.CreditNoteNumber,
+		NoteType:   "C",
+		NoteDate:   cn.CreditNoteDate.Format("02-01-2006"),
+		Value:      cn.TotalAmount,
+		POS:        pos,
+		Items:      gstr1Items(lines),
+	}
+
+	var unregistered string
+	switch {
+	case cn.CustomerGSTIN != "":
+		b.addCDNR(cn.CustomerGSTIN, note)
+		return
+	case cn.InvoiceExportType == "WPAY":
+		unregistered = "EXPWP"
+	case cn.InvoiceExportType == "WOPAY":
+		unregistered = "EXPWOP"
+	case b.interState(pos, cn.IGSTAmount) && cn.InvoiceValue.GreaterThan(gstr1B2CLLimit):
+		unregistered = "B2CL"
+	default:
+		for _, item := range note.Items {
+			b.addB2CS(pos, item.ItemDetails, true)
+		}
+		return
+	}
+	b.gstr1.CDNUR = append(b.gstr1.CDNUR, GSTR1CDNUR{
+		Type:       unregistered,
+		NoteNumber: note.NoteNumber,
+		NoteType:   note.NoteType,
+		NoteDate:   note.NoteDate,
+		Value:      note.Value,
+		POS:        note.POS,
+		Items:      note.Items,
+	})
+}
+
+func (b *gstr1Builder) addDebitNote(dn clients.DebitNote) {
+	note := GSTR1CDNote{
+		NoteNumber: dn.DebitNoteNumber,
+		NoteType:   "D",
+		NoteDate:   dn.DebitNoteDate.Format("02-01-2006"),
+		Value:      dn.TotalAmount,
+		POS:        dn.PlaceOfSupply,
+		Items:      gstr1Items(dn.Items),
+	}
+	if dn.VendorGSTIN == "" {
+		b.gstr1.CDNUR = append(b.gstr1.CDNUR, GSTR1CDNUR{
+			Type:       "B2CL",
+			NoteNumber: note.NoteNumber,
+			NoteType:   note.NoteType,
+			NoteDate:   note.NoteDate,
+			Value:      note.Value,
+			POS:        note.POS,
+			Items:      note.Items,
+		})
+		return
+	}
+	b.addCDNR(dn.VendorGSTIN, note)
+}
+
+func (b *gstr1Builder) addCDNR(gstin string, note GSTR1CDNote) {
+	i, ok := b.cdnr[gstin]
+	if !ok {
+		i = len(b.gstr1.CDNR)
+		b.cdnr[gstin] = i
+		b.gstr1.CDNR = append(b.gstr1.CDNR, GSTR1CDNR{CustomerGSTIN: gstin})
+	}
+	b.gstr1.CDNR[i].Notes = append(b.gstr1.CDNR[i].Notes, note)
+}
+
+func (b *gstr1Builder) addB2CS(pos string, details GSTR1ItemDetails, subtract bool) {
+	key := pos + "|" + details.Rate.String()
+	i, ok := b.b2cs[key]
+	if !ok {
+		i = len(b.gstr1.B2CS)
+		b.b2cs[key] = i
+		b.gstr1.B2CS = append(b.gstr1.B2CS, GSTR1B2CS{Type: "OE", POS: pos, Rate: details.Rate})
+	}
+	if subtract {
+		details.Taxable, details.IGST, details.CGST, details.SGST, details.Cess =
+			details.Taxable.Neg(), details.IGST.Neg(), details.CGST.Neg(), details.SGST.Neg(), details.Cess.Neg()
+	}
+	row := &b.gstr1.B2CS[i]
+	row.Taxable = row.Taxable.Add(details.Taxable)
+	row.IGST = row.IGST.Add(details.IGST)
+	row.CGST = row.CGST.Add(details.CGST)
+	row.SGST = row.SGST.Add(details.SGST)
+	row.Cess = row.Cess.Add(details.Cess)
+}
+
+func (b *gstr1Builder) addNil(amount decimal.Decimal, interState bool) {
+	if interState {
+		b.gstr1.NIL.NilInter = b.gstr1.NIL.NilInter.Add(amount)
+	} else {
+		b.gstr1.NIL.NilIntra = b.gstr1.NIL.NilIntra.Add(amount)
+	}
+}
+
+// addHSN adds a line to the HSN summary. Services (SAC codes, starting 99)
+// are reported without a quantity.
+func (b *gstr1Builder) addHSN(line clients.DocumentItem, subtract bool) {
+	code := strings.TrimSpace(line.HSNCode)
+	if code == "" {
+		return
+	}
+	uqc, quantity := gstr1UQC(line.Unit), line.Quantity
+	if strings.HasPrefix(code, "99") {
+		uqc, quantity = "NA", decimal.Zero
+	}
+	amount, tax := line.Amount, line.CGSTAmount.Add(line.SGSTAmount).Add(line.IGSTAmount).Add(line.CessAmount)
+	igst, cgst, sgst, cess := line.IGSTAmount, line.CGSTAmount, line.SGSTAmount, line.CessAmount
+	if subtract {
+		quantity, amount, tax = quantity.Neg(), amount.Neg(), tax.Neg()
+		igst, cgst, sgst, cess = igst.Neg(), cgst.Neg(), sgst.Neg(), cess.Neg()
+	}
+
+	key := code + "|" + uqc
+	i, ok := b.hsn[key]
+	if !ok {
+		i = len(b.gstr1.HSN)
+		b.hsn[key] = i
+		b.gstr1.HSN = append(b.gstr1.HSN, GSTR1HSN{HSNCode: code, Description: line.Description, UQC: uqc})
+	}
+	row := &b.gstr1.HSN[i]
+	row.Quantity = row.Quantity.Add(quantity)
+	row.TotalValue = row.TotalValue.Add(amount).Add(tax)
+	row.Taxable = row.Taxable.Add(amount)
+	row.IGST = row.IGST.Add(igst)
+	row.CGST = row.CGST.Add(cgst)
+	row.SGST = row.SGST.Add(sgst)
+	row.Cess = row.Cess.Add(cess)
+}
+
+// placeOfSupply is the state code a document is reported under: that of
+// the state entered on it, else the customer's GSTIN, else the filer's own
+// for documents charged CGST and SGST
+func (b *gstr1Builder) placeOfSupply(place, gstin string, igst decimal.Decimal) string {
+	if code, _ := gst.PlaceOfSupply(place); code != "" {
+		return code
+	}
+	if len(gstin) >= 2 {
+		return gstin[:2]
+	}
+	if !igst.IsPositive() {
+		return b.state
+	}
+	return ""
+}
+
+// interState reports whether a supply crosses state lines: it was charged
+// IGST, or its place of supply is not the filer's state
+func (b *gstr1Builder) interState(pos string, igst decimal.Decimal) bool {
+	return igst.IsPositive() || (b.state != "" && pos != "" && pos != b.state)
+}
+
+func gstr1Advances(rows []clients.AdvanceTaxRow) []GSTR1Advance {
+	advances := make([]GSTR1Advance, 0, len(rows))
+	for _, row := range rows {
+		advances = append(advances, GSTR1Advance{
+			POS:     row.PlaceOfSupply,
+			Rate:    row.Rate,
+			Taxable: row.Taxable,
+			IGST:    row.IGST,
+			CGST:    row.CGST,
+			SGST:    row.SGST,
+			Cess:    row.Cess,
+		})
+	}
+	return advances
+}
+
+// gstr1UQC is the unit quantity code GSTR-1 reports a unit by
+func gstr1UQC(unit string) string {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "pcs", "pc", "piece", "pieces":
+		return "PCS"
+	case "nos", "no", "number", "numbers", "unit", "units":
+		return "NOS"
+	case "kg", "kgs", "kilogram", "kilograms":
+		return "KGS"
+	case "g", "gm", "gms", "gram", "grams":
+		return "GMS"
+	case "l", "ltr", "ltrs", "litre", "litres":
+		return "LTR"
+	case "ml":
+		return "MLT"
+	case "m", "mtr", "mtrs", "metre", "metres":
+		return "MTR"
+	case "box", "boxes":
+		return "BOX"
+	case "set", "sets":
+		return "SET"
+	case "pair", "pairs":
+		return "PRS"
+	case "dozen", "doz":
+		return "DOZ"
+	}
+	return "OTH"
+}
+
+// gstr1Rate is the GST rate of a line
+func gstr1Rate(line clients.DocumentItem) decimal.Decimal {
+	return line.IGSTRate.Add(line.CGSTRate).Add(line.SGSTRate)
+}
+
+// gstr1Items totals a document's lines by tax rate, as the return reports
+// them
+func gstr1Items(lines []clients.DocumentItem) []GSTR1InvoiceItem {
+	items := []GSTR1InvoiceItem{}
+	byRate := make(map[string]int)
+	for _, line := range lines {
+		rate := gstr1Rate(line)
+		i, ok := byRate[rate.String()]
+		if !ok {
+			i = len(items)
+			byRate[rate.String()] = i
+			items = append(items, GSTR1InvoiceItem{
+				ItemNumber:  i + 1,
+				ItemDetails: GSTR1ItemDetails{Rate: rate},
+			})
+		}
+		details := &items[i].ItemDetails
+		details.Taxable = details.Taxable.Add(line.Amount)
+		details.IGST = details.IGST.Add(line.IGSTAmount)
+		details.CGST = details.CGST.Add(line.CGSTAmount)
+		details.SGST = details.SGST.Add(line.SGSTAmount)
+		details.Cess = details.Cess.Add(line.CessAmount)
+	}
+	return items
+}
+
 func parsePeriod(period string) (int, int) {
 	if len(period) != 6 {
 		return 1, 2024
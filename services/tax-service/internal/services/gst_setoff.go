@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidReturnPeriod is returned for a return period not in the form
+// MMYYYY
+var ErrInvalidReturnPeriod = errors.New("return period must be in the form MMYYYY")
+
+var (
+	// gstLateInterestRate is the interest, in percent a year, on tax paid
+	// in cash after the due date (section 50(1))
+	gstLateInterestRate = decimal.NewFromInt(18)
+	// Late fee a day under each of CGST and SGST (section 47), less for a
+	// nil return
+	gstLateFeePerDay    = decimal.NewFromInt(25)
+	gstNilLateFeePerDay = decimal.NewFromInt(10)
+	gstNilLateFeeCap    = decimal.NewFromInt(250)
+)
+
+// gstLateFeeCaps are the most late fee charged under each head on a
+// GSTR-3B, by the previous year's aggregate turnover (notification
+// 19/2021-Central Tax); above the last bracket it is gstLateFeeTopCap
+var (
+	gstLateFeeCaps = []struct {
+		turnoverUpTo decimal.Decimal
+		cap          decimal.Decimal
+	}{
+		{decimal.NewFromInt(15000000), decimal.NewFromInt(1000)},
+		{decimal.NewFromInt(50000000), decimal.NewFromInt(2500)},
+	}
+	gstLateFeeTopCap = decimal.NewFromInt(5000)
+)
+
+// GSTSetOffService simulates paying a month's GST: setting the tax in the
+// books off against input tax credit, and the cash, interest and late fee
+// left to pay
+type GSTSetOffService struct {
+	repo     *repository.TaxRepository
+	invoices clients.InvoiceClient
+}
+
+// NewGSTSetOffService creates a new GST set-off service
+func NewGSTSetOffService(repo *repository.TaxRepository, invoices clients.InvoiceClient) *GSTSetOffService {
+	return &GSTSetOffService{repo: repo, invoices: invoices}
+}
+
+// Simulate works out what filing the month's GSTR-3B today would cost from
+// the books as they stand, beside the previous month. authorization is
+// passed on to the invoice service to read the books. Monthly filers are
+// assumed; the due date is the 20th of the following month.
+func (s *GSTSetOffService) Simulate(ctx context.Context, tenantID, authorization string, req models.GSTSetOffSimulationRequest) (*models.GSTSetOffSimulation, error) {
+	month, err := time.Parse("012006", req.Period)
+	if err != nil {
+		return nil, ErrInvalidReturnPeriod
+	}
+	previousPeriod := month.AddDate(0, -1, 0).Format("012006")
+
+	books, err := s.invoices.GetMonthlyGSTBooks(ctx, authorization, req.Period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
+	}
+	previousBooks, err := s.invoices.GetMonthlyGSTBooks(ctx, authorization, previousPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
+	}
+
+	notes := []string{}
+	previous := setOff(previousPeriod, previousBooks, models.GSTHeads{})
+	broughtForward := previous.CreditCarriedForward
+	if req.CreditLedgerBalance != nil {
+		broughtForward = *req.CreditLedgerBalance
+	} else {
+		notes = append(notes, "Credit brought forward is what the previous month's books leave over; enter the electronic credit ledger balance from the GST portal for an exact figure")
+	}
+	current := setOff(req.Period, books, broughtForward)
+
+	now := time.Now().UTC()
+	simulation := &models.GSTSetOffSimulation{
+		GSTSetOff:     current,
+		AsOf:          now,
+		DueDate:       gstr3bDueDate(month),
+		PreviousMonth: &previous,
+		ChangeInCash:  current.TotalTaxPayableInCash.Sub(previous.TotalTaxPayableInCash),
+		Notes:         notes,
+	}
+
+	filed, err := s.filed(ctx, tenantID, req.Period)
+	if err != nil {
+		return nil, err
+	}
+	previousFiled, err := s.filed(ctx, tenantID, previousPeriod)
+	if err != nil {
+		return nil, err
+	}
+	simulation.Filed = filed
+	if filed {
+		simulation.Notes = append(simulation.Notes, "The GSTR-3B for "+req.Period+" has been filed; this is what the books show now")
+	}
+	if !previousFiled && now.After(gstr3bDueDate(month.AddDate(0, -1, 0))) {
+		simulation.Notes = append(simulation.Notes, "The GSTR-3B for "+previousPeriod+" has not been filed; the portal accepts returns only in order")
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !filed && today.After(simulation.DueDate) {
+		simulation.DaysLate = int(today.Sub(simulation.DueDate).Hours() / 24)
+		simulation.Interest = lateInterest(current.CashPayable, simulation.DaysLate)
+		simulation.LateFee = lateFee(current, simulation.DaysLate, req.AggregateTurnover)
+		if req.AggregateTurnover == nil {
+			simulation.Notes = append(simulation.Notes, "Late fee is capped for the highest turnover bracket; give the previous year's aggregate turnover for the cap that applies")
+		}
+	}
+	simulation.TotalCashPayable = current.TotalTaxPayableInCash.Add(simulation.Interest.Total()).Add(simulation.LateFee.Total())
+
+	return simulation, nil
+}
+
+// filed reports whether the period's GSTR-3B has been filed
+func (s *GSTSetOffService) filed(ctx context.Context, tenantID, period string) (bool, error) {
+	filing, err := s.repo.GetGSTRFiling(ctx, tenantID, models.GSTRType3B, period)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return filing.Status == models.GSTRStatusFiled, nil
+}
+
+// gstr3bDueDate is the last day to file a monthly GSTR-3B: the 20th of the
+// following month
+func gstr3bDueDate(month time.Time) time.Time {
+	return time.Date(month.Year(), month.Month()+1, 20, 0, 0, 0, 0, time.UTC)
+}
+
+// setOff sets a month's tax in the books off against the credit brought
+// forward and the month's credit. IGST credit goes first, against IGST and
+// then, where their own credit falls short, CGST and SGST (section 49A and
+// rule 88A); CGST and SGST credit go against their own head and then IGST,
+// SGST only once CGST is used up; cess credit pays only cess.
+func setOff(period string, books *clients.MonthlyGSTBooks, broughtForward models.GSTHeads) models.GSTSetOff {
+	output := headsOf(books.B2B)
+	for _, totals := range []clients.GSTTotals{books.B2C, books.ExportsWithPayment, books.Advances} {
+		output = addHeads(output, headsOf(totals))
+	}
+	// Credit notes beyond the month's tax are not refunded here
+	output = subHeads(output, headsOf(books.CreditNotes))
+	output = models.GSTHeads{
+		IGST: decimal.Max(output.IGST, decimal.Zero),
+		CGST: decimal.Max(output.CGST, decimal.Zero),
+		SGST: decimal.Max(output.SGST, decimal.Zero),
+		Cess: decimal.Max(output.Cess, decimal.Zero),
+	}
+
+	result := models.GSTSetOff{
+		Period:               period,
+		OutputTax:            output,
+		ReverseChargeTax:     headsOf(books.ReverseCharge),
+		CreditBroughtForward: broughtForward,
+		CreditForMonth:       addHeads(headsOf(books.InwardITC), headsOf(books.ReverseChargeITC)),
+		SetOff:               []models.GSTSetOffUse{},
+	}
+	result.CreditAvailable = addHeads(result.CreditBroughtForward, result.CreditForMonth)
+
+	credit, due := result.CreditAvailable, output
+	use := func(creditHead string, credit *decimal.Decimal, liabilityHead string, liability *decimal.Decimal, most decimal.Decimal) {
+		amount := decimal.Min(*credit, *liability, most)
+		if !amount.IsPositive() {
+			return
+		}
+		*credit = credit.Sub(amount)
+		*liability = liability.Sub(amount)
+		result.SetOff = append(result.SetOff, models.GSTSetOffUse{Credit: creditHead, Liability: liabilityHead, Amount: amount})
+	}
+
+	use("IGST", &credit.IGST, "IGST", &due.IGST, due.IGST)
+	// IGST credit covers what CGST and SGST credit cannot, and as it must be
+	// used up first, any left then goes against them too
+	use("IGST", &credit.IGST, "CGST", &due.CGST, due.CGST.Sub(credit.CGST))
+	use("IGST", &credit.IGST, "SGST", &due.SGST, due.SGST.Sub(credit.SGST))
+	use("IGST", &credit.IGST, "CGST", &due.CGST, due.CGST)
+	use("IGST", &credit.IGST, "SGST", &due.SGST, due.SGST)
+	use("CGST", &credit.CGST, "CGST", &due.CGST, due.CGST)
+	use("CGST", &credit.CGST, "IGST", &due.IGST, due.IGST)
+	use("SGST", &credit.SGST, "SGST", &due.SGST, due.SGST)
+	use("SGST", &credit.SGST, "IGST", &due.IGST, due.IGST)
+	use("Cess", &credit.Cess, "Cess", &due.Cess, due.Cess)
+
+	result.CreditUsed = subHeads(result.CreditAvailable, credit)
+	result.CreditCarriedForward = credit
+	result.CashPayable = addHeads(due, result.ReverseChargeTax)
+	result.TotalTaxPayableInCash = result.CashPayable.Total()
+	return result
+}
+
+// lateInterest is interest, to the rupee, on the tax paid in cash for the
+// days it is late
+func lateInterest(cash models.GSTHeads, daysLate int) models.GSTHeads {
+	interest := func(amount decimal.Decimal) decimal.Decimal {
+		return amount.Mul(gstLateInterestRate).Div(decimal.NewFromInt(100)).
+			Mul(decimal.NewFromInt(int64(daysLate))).Div(decimal.NewFromInt(365)).Round(0)
+	}
+	return models.GSTHeads{
+		IGST: interest(cash.IGST),
+		CGST: interest(cash.CGST),
+		SGST: interest(cash.SGST),
+		Cess: interest(cash.Cess),
+	}
+}
+
+// lateFee is the late fee under each of CGST and SGST for the days late,
+// capped by turnover. A month with no tax is a nil return. Without the
+// turnover the highest cap is assumed.
+func lateFee(month models.GSTSetOff, daysLate int, aggregateTurnover *decimal.Decimal) models.GSTHeads {
+	perDay, most := gstLateFeePerDay, gstLateFeeTopCap
+	if month.OutputTax.Total().IsZero() && month.ReverseChargeTax.Total().IsZero() {
+		perDay, most = gstNilLateFeePerDay, gstNilLateFeeCap
+	} else if aggregateTurnover != nil {
+		for _, bracket := range gstLateFeeCaps {
+			if aggregateTurnover.LessThanOrEqual(bracket.turnoverUpTo) {
+				most = bracket.cap
+				break
+			}
+		}
+	}
+	fee := decimal.Min(perDay.Mul(decimal.NewFromInt(int64(daysLate))), most)
+	return models.GSTHeads{CGST: fee, SGST: fee}
+}
+
+func headsOf(totals clients.GSTTotals) models.GSTHeads {
+	return models.GSTHeads{IGST: totals.IGST, CGST: totals.CGST, SGST: totals.SGST, Cess: totals.Cess}
+}
+
+func addHeads(a, b models.GSTHeads) models.GSTHeads {
+	return models.GSTHeads{IGST: a.IGST.Add(b.IGST), CGST: a.CGST.Add(b.CGST), SGST: a.SGST.Add(b.SGST), Cess: a.Cess.Add(b.Cess)}
+}
+
+func subHeads(a, b models.GSTHeads) models.GSTHeads {
+	return models.GSTHeads{IGST: a.IGST.Sub(b.IGST), CGST: a.CGST.Sub(b.CGST), SGST: a.SGST.Sub(b.SGST), Cess: a.Cess.Sub(b.Cess)}
+}
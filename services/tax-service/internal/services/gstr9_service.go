@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/repository"
+)
+
+var (
+	ErrInvalidFinancialYear = errors.New("financial year must be in the form 2024-25")
+	ErrBooksUnavailable     = errors.New("unable to read the books from the invoice service")
+)
+
+// gstr9Tolerance is the largest difference left unflagged, to absorb
+// rounding across the year's returns
+var gstr9Tolerance = decimal.NewFromInt(1)
+
+// GSTR9Service prepares the annual return workpaper
+type GSTR9Service struct {
+	repo     *repository.TaxRepository
+	invoices clients.InvoiceClient
+}
+
+// NewGSTR9Service creates a new GSTR-9 service
+func NewGSTR9Service(repo *repository.TaxRepository, invoices clients.InvoiceClient) *GSTR9Service {
+	return &GSTR9Service{repo: repo, invoices: invoices}
+}
+
+// Workpaper builds the GSTR-9 tables for a financial year from the books,
+// the GSTR-1 and GSTR-3B returns filed for it, the GSTR-2B reconciliations
+// and the ITC register, and lists the differences that need an adjustment
+// in table 8 or tables 10 to 13. authorization is passed on to the invoice
+// service to read the books.
+func (s *GSTR9Service) Workpaper(ctx context.Context, tenantID, authorization, financialYear string) (*models.GSTR9Workpaper, error) {
+	var startYear, endYear int
+	if _, err := fmt.Sscanf(financialYear, "%4d-%2d", &startYear, &endYear); err != nil || (startYear+1)%100 != endYear {
+		return nil, ErrInvalidFinancialYear
+	}
+	from := time.Date(startYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	// Returns of the year can be amended, and its credit availed, until 30
+	// November of the following year
+	amendmentsEnd := time.Date(startYear+1, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	books, err := s.invoices.GetAnnualGSTBooks(ctx, authorization, financialYear)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBooksUnavailable, err)
+	}
+	filings, err := s.repo.ListGSTRFilings(ctx, tenantID, financialYear)
+	if err != nil {
+		return nil, err
+	}
+	reconciliations, err := s.repo.ListITCReconciliations(ctx, tenantID, financialYear)
+	if err != nil {
+		return nil, err
+	}
+	itcs, err := s.repo.ListInputTaxCreditsByInvoiceDate(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	workpaper := &models.GSTR9Workpaper{
+		FinancialYear:        financialYear,
+		AmendmentsUntil:      amendmentsEnd.AddDate(0, 0, -1),
+		MissingGSTR3BPeriods: []string{},
+		Differences:          []models.GSTR9Difference{},
+		GeneratedAt:          now,
+	}
+
+	// Returns filed for the year
+	var gstr1, gstr3bPayable, gstr3bPaid clients.GSTTotals
+	itcAvailed := decimal.Zero
+	filed3B := make(map[string]bool)
+	for _, f := range filings {
+		if f.Status != models.GSTRStatusFiled {
+			continue
+		}
+		switch f.ReturnType {
+		case models.GSTRType1:
+			workpaper.GSTR1PeriodsFiled++
+			gstr1 = addTotals(gstr1, clients.GSTTotals{Taxable: f.TotalOutward, CGST: f.TaxPayableCGST, SGST: f.TaxPayableSGST, IGST: f.TaxPayableIGST, Cess: f.TaxPayableCess})
+		case models.GSTRType3B:
+			workpaper.GSTR3BPeriodsFiled++
+			filed3B[f.Period] = true
+			gstr3bPayable = addTotals(gstr3bPayable, clients.GSTTotals{Taxable: f.TotalOutward, CGST: f.TaxPayableCGST, SGST: f.TaxPayableSGST, IGST: f.TaxPayableIGST, Cess: f.TaxPayableCess})
+			gstr3bPaid = addTotals(gstr3bPaid, clients.GSTTotals{CGST: f.TaxPaidCGST, SGST: f.TaxPaidSGST, IGST: f.TaxPaidIGST, Cess: f.TaxPaidCess})
+			itcAvailed = itcAvailed.Add(f.ITCAvailed).Sub(f.ITCReversed)
+		}
+	}
+	for month := from; month.Before(to); month = month.AddDate(0, 1, 0) {
+		period := month.Format("012006")
+		due := time.Date(month.Year(), month.Month()+1, 20, 23, 59, 59, 0, time.UTC)
+		if !filed3B[period] && now.After(due) {
+			workpaper.MissingGSTR3BPeriods = append(workpaper.MissingGSTR3BPeriods, period)
+		}
+	}
+
+	// ITC as per GSTR-2B
+	var gstr2B clients.GSTTotals
+	for _, r := range reconciliations {
+		gstr2B = addTotals(gstr2B, clients.GSTTotals{CGST: r.GSTR2BITCCGST, SGST: r.GSTR2BITCSGST, IGST: r.GSTR2BITCIGST, Cess: r.GSTR2BITCCess})
+	}
+
+	// Credit on the year's invoices availed, or reversed, after it ended
+	var availedNextYear clients.GSTTotals
+	availedNextYearTotal, reversedNextYear := decimal.Zero, decimal.Zero
+	for _, itc := range itcs {
+		claimed, err := time.Parse("012006", itc.ClaimPeriod)
+		if err != nil {
+			continue
+		}
+		if !claimed.Before(to) && claimed.Before(amendmentsEnd) && itc.EligibleITC.IsPositive() {
+			availedNextYear = addTotals(availedNextYear, clients.GSTTotals{Taxable: itc.TaxableAmount, CGST: itc.CGSTAmount, SGST: itc.SGSTAmount, IGST: itc.IGSTAmount, Cess: itc.CessAmount})
+			availedNextYearTotal = availedNextYearTotal.Add(itc.EligibleITC)
+		}
+		// Blocked credit is reversed when it is recorded, never availed
+		if claimed.Before(to) && itc.BlockedCategory == "" && itc.ReversalAmount.IsPositive() &&
+			!itc.UpdatedAt.Before(to) && itc.UpdatedAt.Before(amendmentsEnd) {
+			reversedNextYear = reversedNextYear.Add(itc.ReversalAmount)
+		}
+	}
+
+	// Table 4: outward supplies on which tax is payable
+	subtotal4 := addTotals(books.B2C, books.B2B, books.ExportsWithPayment, books.Advances, books.ReverseCharge)
+	total4 := subTotals(subtotal4, books.CreditNotes)
+	// Table 5: outward supplies on which tax is not payable
+	total5 := addTotals(books.ExportsWithoutPayment, books.NilExempt)
+	// Table 6: ITC availed
+	itcBooks := addTotals(books.InwardITC, books.ReverseChargeITC)
+
+	workpaper.Tables = []models.GSTR9Table{
+		{Table: "4", Title: "Outward supplies, advances and inward supplies on which tax is payable", Rows: []models.GSTR9Row{
+			gstr9Row("4A", "Supplies made to unregistered persons (B2C)", books.B2C),
+			gstr9Row("4B", "Supplies made to registered persons (B2B)", books.B2B),
+			gstr9Row("4C", "Zero rated supply (export) on payment of tax", books.ExportsWithPayment),
+			gstr9Row("4F", "Advances on which tax has been paid but invoice has not been issued", books.Advances),
+			gstr9Row("4G", "Inward supplies on which tax is to be paid on reverse charge basis", books.ReverseCharge),
+			gstr9Row("4H", "Sub-total (A to G above)", subtotal4),
+			gstr9Row("4I", "Credit notes issued in respect of transactions specified in (B) to (E) above (-)", books.CreditNotes),
+			gstr9Row("4N", "Supplies and advances on which tax is to be paid (H - I)", total4),
+		}},
+		{Table: "5", Title: "Outward supplies on which tax is not payable", Rows: []models.GSTR9Row{
+			gstr9Row("5A", "Zero rated supply (export) without payment of tax", books.ExportsWithoutPayment),
+			gstr9Row("5D/5E", "Exempted and nil rated supplies", books.NilExempt),
+			gstr9Row("5N", "Total turnover (4N + 5A + 5D/5E)", addTotals(total4, total5)),
+		}},
+		{Table: "6", Title: "ITC availed during the financial year", Rows: []models.GSTR9Row{
+			gstr9TotalRow("6A", "Total amount of input tax credit availed through GSTR-3B", itcAvailed),
+			gstr9Row("6B", "Inward supplies (other than imports and inward supplies liable to reverse charge)", books.InwardITC),
+			gstr9Row("6C/6D", "Inward supplies received from unregistered persons liable to reverse charge", books.ReverseChargeITC),
+			gstr9Row("6O", "Total ITC availed as per books (6B to 6D)", itcBooks),
+			gstr9TotalRow("6J", "Difference (6A - 6O)", itcAvailed.Sub(itcBooks.Tax())),
+		}},
+		{Table: "7", Title: "Ineligible ITC", Rows: []models.GSTR9Row{
+			gstr9Row("7E", "ITC blocked under section 17(5), not availed", books.BlockedITC),
+		}},
+		{Table: "8", Title: "Other ITC related information", Rows: []models.GSTR9Row{
+			gstr9Row("8A", "ITC as per GSTR-2B", gstr2B),
+			gstr9Row("8B", "ITC as per sum total of 6(B) and 6(H) above", books.InwardITC),
+			gstr9TotalRow("8C", "ITC on inward supplies received during the year but availed in the next financial year", availedNextYearTotal),
+			gstr9TotalRow("8D", "Difference [A - (B + C)]", gstr2B.Tax().Sub(books.InwardITC.Tax()).Sub(availedNextYearTotal)),
+		}},
+		{Table: "9", Title: "Details of tax paid as declared in returns filed during the financial year", Rows: []models.GSTR9Row{
+			gstr9Row("9", "Tax payable as per books (4N)", total4),
+			gstr9Row("9", "Tax payable as per GSTR-1", gstr1),
+			gstr9Row("9", "Tax payable as per GSTR-3B", gstr3bPayable),
+			gstr9Row("9", "Tax paid through GSTR-3B", gstr3bPaid),
+		}},
+		{Table: "10-13", Title: "Transactions of the year declared in returns of the next financial year", Rows: []models.GSTR9Row{
+			gstr9Row("10", "Supplies / tax declared through amendments (+) (net of debit notes)", books.InvoicesAfterYearEnd),
+			gstr9Row("11", "Supplies / tax reduced through amendments (-) (net of credit notes)", books.CreditNotesAfterYearEnd),
+			gstr9TotalRow("12", "Reversal of ITC availed during previous financial year", reversedNextYear),
+			withTotal(gstr9Row("13", "ITC availed for the previous financial year", availedNextYear), availedNextYearTotal),
+		}},
+	}
+
+	workpaper.Differences = gstr9Differences(workpaper.AmendmentsUntil.Format("2 January 2006"), books, gstr1, gstr3bPayable, gstr3bPaid,
+		total4, total5, itcAvailed, itcBooks, gstr2B, availedNextYearTotal, reversedNextYear)
+	return workpaper, nil
+}
+
+// gstr9Differences compares the books with the year's returns and points
+// each difference at the table it has to be explained in
+func gstr9Differences(until string, books *clients.AnnualGSTBooks, gstr1, gstr3bPayable, gstr3bPaid, total4, total5 clients.GSTTotals,
+	itcAvailed decimal.Decimal, itcBooks, gstr2B clients.GSTTotals, availedNextYear, reversedNextYear decimal.Decimal) []models.GSTR9Difference {
+	differences := []models.GSTR9Difference{}
+	add := func(table, description string, books, returns decimal.Decimal, action string) {
+		diff := books.Sub(returns)
+		if diff.Abs().GreaterThan(gstr9Tolerance) {
+			differences = append(differences, models.GSTR9Difference{
+				Table: table, Description: description, Books: books, Returns: returns, Difference: diff, Action: action,
+			})
+		}
+	}
+
+	// Turnover: GSTR-1 carries neither advances nor inward reverse charge supplies
+	turnover := subTotals(addTotals(total4, total5), books.Advances, books.ReverseCharge).Taxable
+	if turnover.GreaterThan(gstr1.Taxable) {
+		add("10", "Supplies in the books not reported in GSTR-1", turnover, gstr1.Taxable,
+			"Report supplies declared in returns up to "+until+" in table 10; pay tax on the rest through DRC-03")
+	} else {
+		add("11", "Supplies reported in GSTR-1 exceed the books", turnover, gstr1.Taxable,
+			"Report supplies reduced through amendments or credit notes up to "+until+" in table 11, or correct the books")
+	}
+
+	add("9", "Tax on supplies in the books differs from the liability declared in GSTR-3B", total4.Tax(), gstr3bPayable.Tax(),
+		"Pay any short-declared tax through DRC-03 and show it in table 9")
+	add("9", "Liability declared in GSTR-1 differs from GSTR-3B", gstr1.Tax(), gstr3bPayable.Tax(),
+		"Reconcile the period-wise GSTR-1 and GSTR-3B liability; pay any shortfall through DRC-03")
+	add("9", "Tax paid through GSTR-3B differs from the tax payable", gstr3bPayable.Tax(), gstr3bPaid.Tax(),
+		"Pay the unpaid liability with interest through DRC-03")
+
+	if itcBooks.Tax().GreaterThan(itcAvailed) {
+		add("13", "ITC in the books not availed in the year's GSTR-3B", itcBooks.Tax(), itcAvailed,
+			"Credit availed in returns up to "+until+" goes in table 13 (and 8C); the rest lapses")
+	} else {
+		add("12", "ITC availed in GSTR-3B exceeds the books", itcBooks.Tax(), itcAvailed,
+			"Reverse the excess credit in returns up to "+until+" and show it in table 12, or pay it through DRC-03")
+	}
+
+	add("8", "ITC in GSTR-2B differs from ITC availed on the year's purchases (8D)", gstr2B.Tax(), books.InwardITC.Tax().Add(availedNextYear),
+		"Credit in GSTR-2B but not availed lapses; credit availed but missing from GSTR-2B needs supplier follow-up or reversal")
+
+	zero := decimal.Zero
+	add("10", "Invoices of the year recorded after it ended", books.InvoicesAfterYearEnd.Taxable, zero,
+		"Confirm they were reported in GSTR-1 by "+until+" and show them in table 10")
+	add("11", "Credit notes against the year's invoices issued after it ended", books.CreditNotesAfterYearEnd.Taxable, zero,
+		"Show them in table 11")
+	add("12", "ITC of the year reversed after it ended", reversedNextYear, zero,
+		"Show the reversal in table 12")
+	add("13", "ITC on the year's purchases availed after it ended", availedNextYear, zero,
+		"Show it in tables 8C and 13")
+
+	return differences
+}
+
+func gstr9Row(table, description string, t clients.GSTTotals) models.GSTR9Row {
+	return models.GSTR9Row{
+		Table:       table,
+		Description: description,
+		Taxable:     t.Taxable,
+		CGST:        t.CGST,
+		SGST:        t.SGST,
+		IGST:        t.IGST,
+		Cess:        t.Cess,
+		TotalTax:    t.Tax(),
+	}
+}
+
+// gstr9TotalRow is a row for which only the total tax is known
+func gstr9TotalRow(table, description string, total decimal.Decimal) models.GSTR9Row {
+	return models.GSTR9Row{Table: table, Description: description, TotalTax: total}
+}
+
+func withTotal(row models.GSTR9Row, total decimal.Decimal) models.GSTR9Row {
+	row.TotalTax = total
+	return row
+}
+
+func addTotals(totals ...clients.GSTTotals) clients.GSTTotals {
+	var sum clients.GSTTotals
+	for _, t := range totals {
+		sum.Taxable = sum.Taxable.Add(t.Taxable)
+		sum.CGST = sum.CGST.Add(t.CGST)
+		sum.SGST = sum.SGST.Add(t.SGST)
+		sum.IGST = sum.IGST.Add(t.IGST)
+		sum.Cess = sum.Cess.Add(t.Cess)
+	}
+	return sum
+}
+
+func subTotals(a clients.GSTTotals, less ...clients.GSTTotals) clients.GSTTotals {
+	for _, t := range less {
+		a.Taxable = a.Taxable.Sub(t.Taxable)
+		a.CGST = a.CGST.Sub(t.CGST)
+		a.SGST = a.SGST.Sub(t.SGST)
+		a.IGST = a.IGST.Sub(t.IGST)
+		a.Cess = a.Cess.Sub(t.Cess)
+	}
+	return a
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/repository"
+)
+
+var (
+	ErrInvalidITCRule  = errors.New("invalid ITC eligibility rule")
+	ErrITCRuleNotFound = errors.New("ITC eligibility rule not found")
+)
+
+// defaultITCRules are the section 17(5) blocks that can be recognised from
+// the HSN/SAC code alone. Personal consumption depends on what an expense is
+// for, so it is only blocked through tenant account rules.
+var defaultITCRules = []models.ITCEligibilityRule{
+	{Category: models.BlockedCreditFoodBeverages, Section: "17(5)(b)(i)", MatchType: models.ITCRuleMatchCode, Blocked: true, Code: "99633", Description: "Food and beverage serving services, including outdoor catering"},
+	{Category: models.BlockedCreditMotorVehicles, Section: "17(5)(a)", MatchType: models.ITCRuleMatchCode, Blocked: true, Code: "8703", Description: "Motor cars and other vehicles for the transport of persons"},
+	{Category: models.BlockedCreditMotorVehicles, Section: "17(5)(a)", MatchType: models.ITCRuleMatchCode, Blocked: true, Code: "8711", Description: "Motorcycles"},
+	{Category: models.BlockedCreditMotorVehicles, Section: "17(5)(b)(i)", MatchType: models.ITCRuleMatchCode, Blocked: true, Code: "997311", Description: "Leasing or rental of motor cars"},
+	{Category: models.BlockedCreditMotorVehicles, Section: "17(5)(b)(i)", MatchType: models.ITCRuleMatchCode, Blocked: true, Code: "996601", Description: "Rental of motor cars with operator (rent-a-cab)"},
+}
+
+// ITCEligibilityService applies the blocked credit rules of section 17(5)
+type ITCEligibilityService struct {
+	repo *repository.TaxRepository
+}
+
+// NewITCEligibilityService creates a new ITC eligibility service
+func NewITCEligibilityService(repo *repository.TaxRepository) *ITCEligibilityService {
+	return &ITCEligibilityService{repo: repo}
+}
+
+// Evaluate checks purchase lines against the tenant's rules and the defaults
+func (s *ITCEligibilityService) Evaluate(ctx context.Context, req models.ITCEligibilityRequest) (*models.ITCEligibilityResponse, error) {
+	tenantRules, err := s.repo.ListITCEligibilityRules(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ITCEligibilityResponse{
+		Lines:        make([]models.ITCLineEligibility, 0, len(req.Lines)),
+		TotalBlocked: decimal.Zero,
+	}
+	for _, line := range req.Lines {
+		result := models.ITCLineEligibility{Eligible: true, BlockedITC: decimal.Zero, EligibleITC: line.TaxAmount}
+		if rule := matchITCRule(tenantRules, line); rule != nil && rule.Blocked {
+			result = models.ITCLineEligibility{
+				Category:    rule.Category,
+				Section:     rule.Section,
+				Reason:      blockedCreditReason(rule),
+				BlockedITC:  line.TaxAmount,
+				EligibleITC: decimal.Zero,
+			}
+			response.TotalBlocked = response.TotalBlocked.Add(line.TaxAmount)
+		}
+		response.Lines = append(response.Lines, result)
+	}
+	return response, nil
+}
+
+// ListRules returns the tenant's rules followed by the built-in defaults
+func (s *ITCEligibilityService) ListRules(ctx context.Context, tenantID string) ([]models.ITCEligibilityRule, error) {
+	rules, err := s.repo.ListITCEligibilityRules(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range defaultITCRules {
+		rule.TenantID = repository.GlobalTenantID
+		rule.IsActive = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// CreateRule adds a tenant rule
+func (s *ITCEligibilityService) CreateRule(ctx context.Context, req models.CreateITCRuleRequest) (*models.ITCEligibilityRule, error) {
+	rule := &models.ITCEligibilityRule{
+		TenantID:    req.TenantID,
+		Category:    strings.ToUpper(strings.TrimSpace(req.Category)),
+		Section:     req.Section,
+		MatchType:   req.MatchType,
+		Code:        strings.TrimSpace(req.Code),
+		AccountID:   req.AccountID,
+		Blocked:     req.Blocked == nil || *req.Blocked,
+		Description: req.Description,
+		IsActive:    true,
+	}
+
+	switch rule.MatchType {
+	case models.ITCRuleMatchCode:
+		if rule.Code == "" || rule.AccountID != nil {
+			return nil, ErrInvalidITCRule
+		}
+	case models.ITCRuleMatchAccount:
+		if rule.AccountID == nil || rule.Code != "" {
+			return nil, ErrInvalidITCRule
+		}
+	default:
+		return nil, ErrInvalidITCRule
+	}
+	if rule.Category == "" {
+		return nil, ErrInvalidITCRule
+	}
+
+	if err := s.repo.CreateITCEligibilityRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a tenant rule
+func (s *ITCEligibilityService) DeleteRule(ctx context.Context, tenantID string, id uuid.UUID) error {
+	if err := s.repo.DeleteITCEligibilityRule(ctx, tenantID, id); err != nil {
+		return ErrITCRuleNotFound
+	}
+	return nil
+}
+
+// BlockedCreditReport totals ITC blocked under section 17(5) by category for
+// a claim period (MMYYYY) or, when period is empty, a financial year
+func (s *ITCEligibilityService) BlockedCreditReport(ctx context.Context, tenantID, period, financialYear string) (*models.BlockedCreditReport, error) {
+	report := &models.BlockedCreditReport{
+		Categories:   []models.BlockedCreditCategory{},
+		TotalBlocked: decimal.Zero,
+	}
+
+	var from, to time.Time
+	if period != "" {
+		if _, err := time.Parse("012006", period); err != nil {
+			return nil, fmt.Errorf("invalid period %q: expected MMYYYY", period)
+		}
+		report.Period = period
+	} else {
+		if financialYear == "" {
+			financialYear = getFinancialYear(time.Now())
+		}
+		var startYear int
+		if _, err := fmt.Sscanf(financialYear, "%d-", &startYear); err != nil {
+			return nil, fmt.Errorf("invalid financial year %q", financialYear)
+		}
+		from = time.Date(startYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(1, 0, 0)
+		report.FinancialYear = financialYear
+	}
+
+	itcs, err := s.repo.ListBlockedInputTaxCredits(ctx, tenantID, period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[string]*models.BlockedCreditCategory)
+	for _, itc := range itcs {
+		key := itc.BlockedCategory + "|" + itc.BlockedSection
+		category, ok := categories[key]
+		if !ok {
+			category = &models.BlockedCreditCategory{
+				Category:      itc.BlockedCategory,
+				Section:       itc.BlockedSection,
+				TaxableAmount: decimal.Zero,
+				BlockedITC:    decimal.Zero,
+			}
+			categories[key] = category
+		}
+		category.Count++
+		category.TaxableAmount = category.TaxableAmount.Add(itc.TaxableAmount)
+		category.BlockedITC = category.BlockedITC.Add(itc.ReversalAmount)
+		report.TotalBlocked = report.TotalBlocked.Add(itc.ReversalAmount)
+	}
+
+	for _, category := range categories {
+		report.Categories = append(report.Categories, *category)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		if report.Categories[i].Category != report.Categories[j].Category {
+			return report.Categories[i].Category < report.Categories[j].Category
+		}
+		return report.Categories[i].Section < report.Categories[j].Section
+	})
+	return report, nil
+}
+
+// matchITCRule returns the rule that decides a line's eligibility, if any. An
+// account rule wins over code rules; among code rules the longest matching
+// prefix wins, and a tenant rule wins over a default of the same length.
+func matchITCRule(tenantRules []models.ITCEligibilityRule, line models.ITCEligibilityLine) *models.ITCEligibilityRule {
+	if line.AccountID != nil {
+		for i := range tenantRules {
+			rule := &tenantRules[i]
+			if rule.MatchType == models.ITCRuleMatchAccount && rule.AccountID != nil && *rule.AccountID == *line.AccountID {
+				return rule
+			}
+		}
+	}
+
+	var best *models.ITCEligibilityRule
+	consider := func(rule *models.ITCEligibilityRule) {
+		if rule.MatchType != models.ITCRuleMatchCode || rule.Code == "" {
+			return
+		}
+		if !strings.HasPrefix(line.HSNCode, rule.Code) && !strings.HasPrefix(line.SACCode, rule.Code) {
+			return
+		}
+		if best == nil || len(rule.Code) > len(best.Code) {
+			best = rule
+		}
+	}
+	for i := range tenantRules {
+		consider(&tenantRules[i])
+	}
+	for i := range defaultITCRules {
+		consider(&defaultITCRules[i])
+	}
+	return best
+}
+
+func blockedCreditReason(rule *models.ITCEligibilityRule) string {
+	reason := fmt.Sprintf("ITC blocked under section %s", rule.Section)
+	if rule.Section == "" {
+		reason = "ITC blocked under section 17(5)"
+	}
+	if rule.Description != "" {
+		reason += ": " + rule.Description
+	}
+	return reason
+}
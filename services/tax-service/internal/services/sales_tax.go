@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// ErrSalesTaxProviderUnavailable is returned when the configured sales tax
+// provider fails. No tax is assumed in that case, to avoid undertaxing.
+var ErrSalesTaxProviderUnavailable = errors.New("sales tax provider unavailable")
+
+// calculateUSSalesTax calculates US sales tax through the configured
+// provider, falling back to the tenant's own jurisdiction rates
+func (c *TaxCalculator) calculateUSSalesTax(ctx context.Context, req models.CalculateTaxRequest) (*models.TaxCalculationResponse, error) {
+	if c.salesTaxProvider == nil {
+		return c.calculateStandardTax(ctx, req)
+	}
+
+	quote := clients.SalesTaxRequest{
+		TenantID:       req.TenantID,
+		To:             req.ShippingAddress,
+		ShippingAmount: req.ShippingAmount,
+	}
+	if req.OriginAddress != nil {
+		quote.From = *req.OriginAddress
+	}
+	if req.CustomerID != nil {
+		quote.CustomerCode = req.CustomerID.String()
+	}
+
+	for i, item := range req.LineItems {
+		line := clients.SalesTaxLine{
+			ID:        fmt.Sprintf("%d", i+1),
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Amount:    item.Subtotal,
+		}
+		if item.ProductID != nil {
+			line.ID = item.ProductID.String()
+		}
+		if category := c.getProductCategory(ctx, req.TenantID, item); category != nil {
+			line.TaxCode = category.TaxCode
+		}
+		if line.Quantity == 0 {
+			line.Quantity = 1
+			line.UnitPrice = item.Subtotal
+		}
+		quote.Lines = append(quote.Lines, line)
+	}
+
+	result, err := c.salesTaxProvider.CalculateSalesTax(ctx, quote)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrSalesTaxProviderUnavailable, c.salesTaxProvider.Name(), err)
+	}
+
+	subtotal := c.calculateSubtotal(req.LineItems)
+	response := &models.TaxCalculationResponse{
+		Subtotal:       subtotal,
+		ShippingAmount: req.ShippingAmount,
+		TaxAmount:      result.TaxAmount,
+		Total:          subtotal + req.ShippingAmount + result.TaxAmount,
+		TaxBreakdown:   result.Breakdown,
+		Provider:       result.Provider,
+	}
+
+	c.cacheResult(ctx, c.generateCacheKey(req), response)
+	return response, nil
+}
+
+// calculateStandardTax applies the tax rates configured for the destination's
+// jurisdictions (country, state, city, ZIP). Rates are applied in priority
+// order; compound rates are charged on the amount plus earlier taxes.
+// Rounding follows the country jurisdiction's policy.
+func (c *TaxCalculator) calculateStandardTax(ctx context.Context, req models.CalculateTaxRequest) (*models.TaxCalculationResponse, error) {
+	subtotal := c.calculateSubtotal(req.LineItems)
+	response := &models.TaxCalculationResponse{
+		Subtotal:       subtotal,
+		ShippingAmount: req.ShippingAmount,
+		Total:          subtotal + req.ShippingAmount,
+		TaxBreakdown:   []models.TaxBreakdown{},
+	}
+
+	addr := req.ShippingAddress
+	countryCode := addr.CountryCode
+	if countryCode == "" {
+		countryCode = addr.Country
+	}
+	stateCode := addr.StateCode
+	if stateCode == "" {
+		stateCode = addr.State
+	}
+
+	jurisdictions, err := c.repo.GetJurisdictionByLocation(ctx, req.TenantID, countryCode, stateCode, addr.City, addr.Zip)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []models.TaxRate
+	names := make(map[uuid.UUID]string, len(jurisdictions))
+	if len(jurisdictions) > 0 {
+		ids := make([]uuid.UUID, 0, len(jurisdictions))
+		for _, j := range jurisdictions {
+			ids = append(ids, j.ID)
+			names[j.ID] = j.Name
+		}
+		rates, err = c.repo.GetActiveTaxRates(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rates) == 0 {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("No tax rates are configured for %s; no tax was applied", countryCode))
+		return response, nil
+	}
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Priority < rates[j].Priority })
+
+	policy := c.getRoundingPolicy(ctx, req.TenantID, countryCode)
+	lines := newTaxLines(policy)
+	apply := func(amount float64) {
+		var taxSoFar float64
+		for _, rate := range rates {
+			base := amount
+			if rate.IsCompound {
+				base += taxSoFar
+			}
+			tax := base * (rate.Rate / 100.0)
+			taxSoFar += tax
+			lines.add(models.TaxBreakdown{
+				JurisdictionID:   rate.JurisdictionID,
+				JurisdictionName: names[rate.JurisdictionID],
+				TaxType:          string(rate.TaxType),
+				Rate:             rate.Rate,
+				TaxableAmount:    base,
+				TaxAmount:        tax,
+				IsCompound:       rate.IsCompound,
+			})
+		}
+	}
+
+	for _, item := range req.LineItems {
+		if category := c.getProductCategory(ctx, req.TenantID, item); category != nil && category.IsTaxExempt {
+			continue
+		}
+		apply(item.Subtotal)
+	}
+	if req.ShippingAmount > 0 {
+		apply(req.ShippingAmount)
+	}
+
+	taxBreakdown, totalTax := lines.breakdown()
+	response.TaxBreakdown = taxBreakdown
+	response.TaxAmount = totalTax
+	response.Total = subtotal + req.ShippingAmount + totalTax
+	response.RoundingLevel = policy.Level
+	response.RoundingMethod = policy.Method
+	return response, nil
+}
@@ -9,21 +9,32 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/clients"
 	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
 	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/repository"
 )
 
 // TaxCalculator handles all tax calculation logic
 type TaxCalculator struct {
-	repo     *repository.TaxRepository
-	cacheTTL time.Duration
+	repo             *repository.TaxRepository
+	cacheTTL         time.Duration
+	vatValidator     clients.VATValidator
+	salesTaxProvider clients.SalesTaxProvider
 }
 
-// NewTaxCalculator creates a new tax calculator
-func NewTaxCalculator(repo *repository.TaxRepository, cacheTTL time.Duration) *TaxCalculator {
+// NewTaxCalculator creates a new tax calculator. vatValidator and
+// salesTaxProvider are optional.
+func NewTaxCalculator(
+	repo *repository.TaxRepository,
+	cacheTTL time.Duration,
+	vatValidator clients.VATValidator,
+	salesTaxProvider clients.SalesTaxProvider,
+) *TaxCalculator {
 	return &TaxCalculator{
-		repo:     repo,
-		cacheTTL: cacheTTL,
+		repo:             repo,
+		cacheTTL:         cacheTTL,
+		vatValidator:     vatValidator,
+		salesTaxProvider: salesTaxProvider,
 	}
 }
 
@@ -45,10 +56,22 @@ func (c *TaxCalculator) CalculateTax(ctx context.Context, req models.CalculateTa
 		countryCode = req.ShippingAddress.Country
 	}
 
+	originCountry := ""
+	if req.OriginAddress != nil {
+		originCountry = req.OriginAddress.CountryCode
+		if originCountry == "" {
+			originCountry = req.OriginAddress.Country
+		}
+	}
+
 	// Route to country-specific calculation
-	switch countryCode {
-	case "IN":
+	switch {
+	case countryCode == "IN":
 		return c.calculateIndiaGST(ctx, req)
+	case isEUCountry(countryCode) || isEUCountry(originCountry):
+		return c.calculateEUVAT(ctx, req, originCountry, countryCode)
+	case countryCode == "US":
+		return c.calculateUSSalesTax(ctx, req)
 	default:
 		return c.calculateStandardTax(ctx, req)
 	}
@@ -76,78 +99,53 @@ func (c *TaxCalculator) calculateIndiaGST(ctx context.Context, req models.Calcul
 
 	isInterstate := originStateCode != "" && destStateCode != "" && originStateCode != destStateCode
 
-	var totalTax float64
-	var taxBreakdown []models.TaxBreakdown
-	gstSummary := &models.GSTSummary{IsInterstate: isInterstate}
+	policy := c.getRoundingPolicy(ctx, req.TenantID, "IN")
+	lines := newTaxLines(policy)
 
 	// Calculate tax for each line item
 	for _, item := range req.LineItems {
-		gstSlab := c.getGSTSlab(ctx, req.TenantID, item)
+		category := c.getProductCategory(ctx, req.TenantID, item)
+		gstSlab := gstSlabFor(category)
 		if gstSlab == 0 {
 			continue
 		}
 
-		if isInterstate {
-			igstAmount := item.Subtotal * (gstSlab / 100.0)
-			totalTax += igstAmount
-			gstSummary.IGST += igstAmount
+		addGST(lines, req, isInterstate, gstSlab, item.Subtotal, item.HSNCode, item.SACCode)
 
-			taxBreakdown = append(taxBreakdown, models.TaxBreakdown{
-				JurisdictionName: "India",
-				TaxType:          "IGST",
-				Rate:             gstSlab,
+		// Compensation cess is levied on top of GST on the same taxable value
+		if category != nil && category.HasCess() {
+			adValorem, specific := category.Cess(item.Subtotal, item.Quantity)
+			lines.add(models.TaxBreakdown{
+				JurisdictionName: "India - Compensation Cess",
+				TaxType:          string(models.TaxTypeCESS),
+				Rate:             category.CessRate,
 				TaxableAmount:    item.Subtotal,
-				TaxAmount:        igstAmount,
-				HSNCode:          item.HSNCode,
-				SACCode:          item.SACCode,
-			})
-		} else {
-			halfRate := gstSlab / 2.0
-			cgstAmount := item.Subtotal * (halfRate / 100.0)
-			sgstAmount := item.Subtotal * (halfRate / 100.0)
-			totalTax += cgstAmount + sgstAmount
-			gstSummary.CGST += cgstAmount
-			gstSummary.SGST += sgstAmount
-
-			taxBreakdown = append(taxBreakdown, models.TaxBreakdown{
-				JurisdictionName: "India - Central",
-				TaxType:          "CGST",
-				Rate:             halfRate,
-				TaxableAmount:    item.Subtotal,
-				TaxAmount:        cgstAmount,
-				HSNCode:          item.HSNCode,
-				SACCode:          item.SACCode,
-			})
-			taxBreakdown = append(taxBreakdown, models.TaxBreakdown{
-				JurisdictionName: req.ShippingAddress.State,
-				TaxType:          "SGST",
-				Rate:             halfRate,
-				TaxableAmount:    item.Subtotal,
-				TaxAmount:        sgstAmount,
+				TaxAmount:        adValorem + specific,
 				HSNCode:          item.HSNCode,
 				SACCode:          item.SACCode,
+				SpecificRate:     category.CessSpecificRate,
+				SpecificPer:      category.CessSpecificPer,
+				SpecificUnit:     category.CessUnit,
+				Quantity:         item.Quantity,
+				SpecificAmount:   specific,
 			})
 		}
 	}
 
 	// Shipping tax
 	if req.ShippingAmount > 0 {
-		shippingGSTSlab := 18.0
-		if isInterstate {
-			igstAmount := req.ShippingAmount * (shippingGSTSlab / 100.0)
-			totalTax += igstAmount
-			gstSummary.IGST += igstAmount
-		} else {
-			halfRate := shippingGSTSlab / 2.0
-			cgstAmount := req.ShippingAmount * (halfRate / 100.0)
-			sgstAmount := req.ShippingAmount * (halfRate / 100.0)
-			totalTax += cgstAmount + sgstAmount
-			gstSummary.CGST += cgstAmount
-			gstSummary.SGST += sgstAmount
-		}
+		addGST(lines, req, isInterstate, 18.0, req.ShippingAmount, "", "")
 	}
 
-	gstSummary.TotalGST = totalTax
+	taxBreakdown, totalTax := lines.breakdown()
+	gstSummary := &models.GSTSummary{
+		IsInterstate: isInterstate,
+		CGST:         totalOf(taxBreakdown, models.TaxTypeCGST),
+		SGST:         totalOf(taxBreakdown, models.TaxTypeSGST),
+		IGST:         totalOf(taxBreakdown, models.TaxTypeIGST),
+		CESS:         totalOf(taxBreakdown, models.TaxTypeCESS),
+	}
+	gstSummary.TotalGST = policy.round(gstSummary.CGST + gstSummary.SGST + gstSummary.IGST)
 
 	response := &models.TaxCalculationResponse{
 		Subtotal:       subtotal,
@@ -157,6 +155,8 @@ func (c *TaxCalculator) calculateIndiaGST(ctx context.Context, req models.Calcul
 		TaxBreakdown:   taxBreakdown,
 		IsExempt:       false,
 		GSTSummary:     gstSummary,
+		RoundingLevel:  policy.Level,
+		RoundingMethod: policy.Method,
 	}
 
 	// Cache result
@@ -166,50 +166,79 @@ func (c *TaxCalculator) calculateIndiaGST(ctx context.Context, req models.Calcul
 	return response, nil
 }
 
-func (c *TaxCalculator) calculateStandardTax(ctx context.Context, req models.CalculateTaxRequest) (*models.TaxCalculationResponse, error) {
-	subtotal := c.calculateSubtotal(req.LineItems)
-	return &models.TaxCalculationResponse{
-		Subtotal:       subtotal,
-		ShippingAmount: req.ShippingAmount,
-		TaxAmount:      0,
-		Total:          subtotal + req.ShippingAmount,
-		TaxBreakdown:   []models.TaxBreakdown{},
-		IsExempt:       false,
-	}, nil
+// addGST adds IGST, or CGST and SGST at half the slab each, on an amount.
+// CGST and SGST are computed separately from the same base so they round to
+// the same value.
+func addGST(lines *taxLines, req models.CalculateTaxRequest, isInterstate bool, gstSlab, amount float64, hsnCode, sacCode string) {
+	if isInterstate {
+		lines.add(models.TaxBreakdown{
+			JurisdictionName: "India",
+			TaxType:          string(models.TaxTypeIGST),
+			Rate:             gstSlab,
+			TaxableAmount:    amount,
+			TaxAmount:        amount * (gstSlab / 100.0),
+			HSNCode:          hsnCode,
+			SACCode:          sacCode,
+		})
+		return
+	}
+
+	halfRate := gstSlab / 2.0
+	lines.add(models.TaxBreakdown{
+		JurisdictionName: "India - Central",
+		TaxType:          string(models.TaxTypeCGST),
+		Rate:             halfRate,
+		TaxableAmount:    amount,
+		TaxAmount:        amount * (halfRate / 100.0),
+		HSNCode:          hsnCode,
+		SACCode:          sacCode,
+	})
+	lines.add(models.TaxBreakdown{
+		JurisdictionName: req.ShippingAddress.State,
+		TaxType:          string(models.TaxTypeSGST),
+		Rate:             halfRate,
+		TaxableAmount:    amount,
+		TaxAmount:        amount * (halfRate / 100.0),
+		HSNCode:          hsnCode,
+		SACCode:          sacCode,
+	})
 }
 
-func (c *TaxCalculator) getGSTSlab(ctx context.Context, tenantID string, item models.LineItemInput) float64 {
+// getProductCategory resolves the tax category of a line by HSN, then SAC,
+// then explicit category
+func (c *TaxCalculator) getProductCategory(ctx context.Context, tenantID string, item models.LineItemInput) *models.ProductTaxCategory {
 	if item.HSNCode != "" {
 		category, err := c.repo.GetProductCategoryByHSN(ctx, tenantID, item.HSNCode)
 		if err == nil && category != nil {
-			if category.IsTaxExempt || category.IsNilRated {
-				return 0
-			}
-			return category.GSTSlab
+			return category
 		}
 	}
 
 	if item.SACCode != "" {
 		category, err := c.repo.GetProductCategoryBySAC(ctx, tenantID, item.SACCode)
 		if err == nil && category != nil {
-			if category.IsTaxExempt || category.IsNilRated {
-				return 0
-			}
-			return category.GSTSlab
+			return category
 		}
 	}
 
 	if item.CategoryID != nil && *item.CategoryID != uuid.Nil {
 		category, err := c.repo.GetProductCategory(ctx, *item.CategoryID)
 		if err == nil && category != nil {
-			if category.IsTaxExempt || category.IsNilRated {
-				return 0
-			}
-			return category.GSTSlab
+			return category
 		}
 	}
 
-	return 18.0 // Default GST slab
+	return nil
+}
+
+func gstSlabFor(category *models.ProductTaxCategory) float64 {
+	if category == nil {
+		return 18.0 // Default GST slab
+	}
+	if category.IsTaxExempt || category.IsNilRated {
+		return 0
+	}
+	return category.GSTSlab
 }
 
 // CalculateTDS calculates TDS for a payment
@@ -229,9 +258,14 @@ func (c *TaxCalculator) CalculateTDS(ctx context.Context, req models.CalculateTD
 	quarter := getQuarter(paymentDate)
 
 	// Check threshold - get cumulative payments in FY
-	cumulativeAmount, err := c.repo.GetTDSSummaryByDeductee(ctx, req.TenantID, req.DeducteeID, fy)
-	if err != nil {
-		cumulativeAmount = decimal.Zero
+	var cumulativeAmount decimal.Decimal
+	if req.CumulativeAmount != nil {
+		cumulativeAmount = *req.CumulativeAmount
+	} else {
+		cumulativeAmount, err = c.repo.GetTDSSummaryByDeductee(ctx, req.TenantID, req.DeducteeID, fy)
+		if err != nil {
+			cumulativeAmount = decimal.Zero
+		}
 	}
 
 	totalWithCurrent := cumulativeAmount.Add(req.GrossAmount)
@@ -297,9 +331,14 @@ func (c *TaxCalculator) CalculateTCS(ctx context.Context, req models.CalculateTC
 	quarter := getQuarter(invoiceDate)
 
 	// Check threshold - get cumulative sales in FY
-	cumulativeAmount, err := c.repo.GetTCSSummaryByCustomer(ctx, req.TenantID, req.CustomerID, fy)
-	if err != nil {
-		cumulativeAmount = decimal.Zero
+	var cumulativeAmount decimal.Decimal
+	if req.CumulativeSales != nil {
+		cumulativeAmount = *req.CumulativeSales
+	} else {
+		cumulativeAmount, err = c.repo.GetTCSSummaryByCustomer(ctx, req.TenantID, req.CustomerID, fy)
+		if err != nil {
+			cumulativeAmount = decimal.Zero
+		}
 	}
 
 	totalWithCurrent := cumulativeAmount.Add(req.SaleAmount)
@@ -389,8 +428,24 @@ func (c *TaxCalculator) RecordITC(ctx context.Context, req models.RecordITCReque
 	// Calculate total ITC
 	totalITC := req.CGSTAmount.Add(req.SGSTAmount).Add(req.IGSTAmount).Add(req.CessAmount)
 
-	// For now, eligible ITC = total ITC (can add reversal logic later)
+	// Credit blocked under section 17(5) is reversed in full
 	eligibleITC := totalITC
+	status := models.ITCStatusAvailable
+	reversal := decimal.Zero
+	var blocked *models.ITCEligibilityRule
+	if req.HSNCode != "" || req.AccountID != nil {
+		tenantRules, err := c.repo.ListITCEligibilityRules(ctx, req.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		line := models.ITCEligibilityLine{HSNCode: req.HSNCode, SACCode: req.HSNCode, AccountID: req.AccountID, TaxAmount: totalITC}
+		if rule := matchITCRule(tenantRules, line); rule != nil && rule.Blocked {
+			blocked = rule
+			eligibleITC = decimal.Zero
+			reversal = totalITC
+			status = models.ITCStatusReversed
+		}
+	}
 
 	// Determine claim period (MMYYYY format)
 	claimPeriod := fmt.Sprintf("%02d%04d", invoiceDate.Month(), invoiceDate.Year())
@@ -412,8 +467,15 @@ func (c *TaxCalculator) RecordITC(ctx context.Context, req models.RecordITCReque
 		CessAmount:        req.CessAmount,
 		TotalITC:          totalITC,
 		EligibleITC:       eligibleITC,
-		Status:            models.ITCStatusAvailable,
+		Status:            status,
 		ClaimPeriod:       claimPeriod,
+		ReversalAmount:    reversal,
+		AccountID:         req.AccountID,
+	}
+	if blocked != nil {
+		itc.ReversalReason = blockedCreditReason(blocked)
+		itc.BlockedCategory = blocked.Category
+		itc.BlockedSection = blocked.Section
 	}
 
 	err = c.repo.CreateInputTaxCredit(ctx, itc)
@@ -439,21 +501,28 @@ func (c *TaxCalculator) calculateSubtotal(items []models.LineItemInput) float64
 }
 
 func (c *TaxCalculator) generateCacheKey(req models.CalculateTaxRequest) string {
-	key := fmt.Sprintf("%s:%s:%s:%s:%s:%f",
+	key := fmt.Sprintf("%s:%s:%s:%s:%s:%s:%s:%f:%t:%s",
 		req.TenantID,
 		req.ShippingAddress.Country,
+		req.ShippingAddress.CountryCode,
 		req.ShippingAddress.State,
+		req.ShippingAddress.StateCode,
 		req.ShippingAddress.City,
 		req.ShippingAddress.Zip,
 		req.ShippingAmount,
+		req.IsB2B,
+		req.CustomerVATNumber,
 	)
+	if req.OriginAddress != nil {
+		key += fmt.Sprintf(":%s:%s:%s", req.OriginAddress.CountryCode, req.OriginAddress.Country, req.OriginAddress.StateCode)
+	}
 
 	for _, item := range req.LineItems {
 		categoryID := "nil"
 		if item.CategoryID != nil {
 			categoryID = item.CategoryID.String()
 		}
-		key += fmt.Sprintf(":%s:%f", categoryID, item.Subtotal)
+		key += fmt.Sprintf(":%s:%s:%s:%f:%f", categoryID, item.HSNCode, item.SACCode, item.Quantity, item.Subtotal)
 	}
 
 	if req.CustomerID != nil {
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// roundingPolicy is the tax rounding rule of a jurisdiction
+type roundingPolicy struct {
+	Level     models.RoundingLevel
+	Method    models.RoundingMethod
+	Precision int32
+}
+
+// defaultRoundingPolicy rounds each line half-up to 2 places, which matches
+// how invoice and bill lines are stored
+var defaultRoundingPolicy = roundingPolicy{
+	Level:     models.RoundingLevelLine,
+	Method:    models.RoundingMethodHalfUp,
+	Precision: 2,
+}
+
+// getRoundingPolicy returns the rounding rules configured on the country
+// jurisdiction, defaulting any unset part
+func (c *TaxCalculator) getRoundingPolicy(ctx context.Context, tenantID, countryCode string) roundingPolicy {
+	policy := defaultRoundingPolicy
+	if countryCode == "" {
+		return policy
+	}
+
+	jurisdiction, err := c.repo.GetCountryJurisdiction(ctx, tenantID, countryCode)
+	if err != nil || jurisdiction == nil {
+		return policy
+	}
+	if jurisdiction.RoundingLevel != "" {
+		policy.Level = jurisdiction.RoundingLevel
+	}
+	if jurisdiction.RoundingMethod != "" {
+		policy.Method = jurisdiction.RoundingMethod
+	}
+	if jurisdiction.RoundingPrecision != nil {
+		policy.Precision = int32(*jurisdiction.RoundingPrecision)
+	}
+	return policy
+}
+
+func (p roundingPolicy) round(v float64) float64 {
+	d := decimal.NewFromFloat(v)
+	if p.Method == models.RoundingMethodHalfEven {
+		d = d.RoundBank(p.Precision)
+	} else {
+		d = d.Round(p.Precision)
+	}
+	f, _ := d.Float64()
+	return f
+}
+
+// taxLines collects tax breakdown entries and rounds them according to the
+// policy. At line level every entry is rounded as it is added; at invoice
+// level entries of the same tax and rate are merged and rounded once, so
+// that e.g. CGST and SGST on an invoice always match the invoice's own
+// per-rate totals.
+type taxLines struct {
+	policy  roundingPolicy
+	entries []models.TaxBreakdown
+	index   map[string]int
+}
+
+func newTaxLines(policy roundingPolicy) *taxLines {
+	return &taxLines{policy: policy, index: make(map[string]int)}
+}
+
+// add records an unrounded tax entry
+func (t *taxLines) add(entry models.TaxBreakdown) {
+	if t.policy.Level != models.RoundingLevelInvoice {
+		entry.TaxAmount = t.policy.round(entry.TaxAmount)
+		entry.SpecificAmount = t.policy.round(entry.SpecificAmount)
+		t.entries = append(t.entries, entry)
+		return
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%g|%g|%t", entry.TaxType, entry.JurisdictionID, entry.JurisdictionName, entry.Rate, entry.SpecificRate, entry.IsCompound)
+	i, ok := t.index[key]
+	if !ok {
+		t.index[key] = len(t.entries)
+		t.entries = append(t.entries, entry)
+		return
+	}
+
+	merged := &t.entries[i]
+	merged.TaxableAmount += entry.TaxableAmount
+	merged.TaxAmount += entry.TaxAmount
+	merged.Quantity += entry.Quantity
+	merged.SpecificAmount += entry.SpecificAmount
+	if merged.HSNCode != entry.HSNCode {
+		merged.HSNCode = ""
+	}
+	if merged.SACCode != entry.SACCode {
+		merged.SACCode = ""
+	}
+}
+
+// breakdown returns the rounded entries and their total
+func (t *taxLines) breakdown() ([]models.TaxBreakdown, float64) {
+	entries := make([]models.TaxBreakdown, 0, len(t.entries))
+	var total float64
+	for _, entry := range t.entries {
+		if t.policy.Level == models.RoundingLevelInvoice {
+			entry.TaxAmount = t.policy.round(entry.TaxAmount)
+			entry.SpecificAmount = t.policy.round(entry.SpecificAmount)
+		}
+		total += entry.TaxAmount
+		entries = append(entries, entry)
+	}
+	return entries, t.policy.round(total)
+}
+
+// totalOf sums the breakdown amounts of one tax type
+func totalOf(entries []models.TaxBreakdown, taxType models.TaxType) float64 {
+	var total float64
+	for _, entry := range entries {
+		if entry.TaxType == string(taxType) {
+			total += entry.TaxAmount
+		}
+	}
+	return total
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tesseract-nexus/bookkeeping-app/tax-service/internal/models"
+)
+
+// Alert severities on the TDS dashboard
+const (
+	TDSAlertDueSoon = "DUE_SOON"
+	TDSAlertOverdue = "OVERDUE"
+)
+
+// TDSDashboard summarises TDS liability for a financial year (optionally one
+// quarter) as of asOf: deducted, deposited and pending by section and by month,
+// interest on late deposit, and alerts for pending TDS due within alertDays or
+// already overdue.
+func (s *ChallanService) TDSDashboard(ctx context.Context, tenantID, financialYear string, quarter int, asOf time.Time, alertDays int) (*models.TDSDashboardResponse, error) {
+	if financialYear == "" {
+		financialYear = getFinancialYear(asOf)
+	}
+
+	deductions, err := s.repo.ListTDSDeductions(ctx, tenantID, financialYear, quarter)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &models.TDSDashboardResponse{
+		FinancialYear:  financialYear,
+		Quarter:        quarter,
+		AsOf:           asOf.Format("2006-01-02"),
+		TotalDeducted:  decimal.Zero,
+		TotalDeposited: decimal.Zero,
+		TotalPending:   decimal.Zero,
+		TotalInterest:  decimal.Zero,
+		BySection:      []models.TDSSectionSummary{},
+		ByMonth:        []models.TDSMonthSummary{},
+		Alerts:         []models.TDSAlert{},
+	}
+
+	sections := make(map[models.TDSSection]*models.TDSSectionSummary)
+	months := make(map[string]*models.TDSMonthSummary)
+
+	for _, d := range deductions {
+		deposited := d.Status != "PENDING"
+
+		section, ok := sections[d.Section]
+		if !ok {
+			section = &models.TDSSectionSummary{Section: d.Section, Deducted: decimal.Zero, Deposited: decimal.Zero, Pending: decimal.Zero}
+			sections[d.Section] = section
+		}
+
+		monthKey := d.DeductionDate.Format("2006-01")
+		dueDate := depositDueDate(models.ChallanTypeTDS, d.DeductionDate)
+		month, ok := months[monthKey]
+		if !ok {
+			month = &models.TDSMonthSummary{
+				Month:     monthKey,
+				DueDate:   dueDate.Format("2006-01-02"),
+				Deducted:  decimal.Zero,
+				Deposited: decimal.Zero,
+				Pending:   decimal.Zero,
+				Interest:  decimal.Zero,
+			}
+			months[monthKey] = month
+		}
+
+		// Interest runs from deduction to deposit, or to asOf while pending
+		interest := decimal.Zero
+		switch {
+		case deposited && d.DepositDate != nil && d.DepositDate.After(dueDate):
+			interest = lateDepositInterest(models.ChallanTypeTDS, d.TDSAmount, d.DeductionDate, *d.DepositDate)
+		case !deposited && asOf.After(dueDate):
+			interest = lateDepositInterest(models.ChallanTypeTDS, d.TDSAmount, d.DeductionDate, asOf)
+		}
+
+		section.Count++
+		section.Deducted = section.Deducted.Add(d.TDSAmount)
+		month.Deducted = month.Deducted.Add(d.TDSAmount)
+		month.Interest = month.Interest.Add(interest)
+		dashboard.TotalDeducted = dashboard.TotalDeducted.Add(d.TDSAmount)
+		dashboard.TotalInterest = dashboard.TotalInterest.Add(interest)
+
+		if deposited {
+			section.Deposited = section.Deposited.Add(d.TDSAmount)
+			month.Deposited = month.Deposited.Add(d.TDSAmount)
+			dashboard.TotalDeposited = dashboard.TotalDeposited.Add(d.TDSAmount)
+		} else {
+			section.Pending = section.Pending.Add(d.TDSAmount)
+			month.Pending = month.Pending.Add(d.TDSAmount)
+			dashboard.TotalPending = dashboard.TotalPending.Add(d.TDSAmount)
+		}
+	}
+
+	for _, section := range sections {
+		dashboard.BySection = append(dashboard.BySection, *section)
+	}
+	sort.Slice(dashboard.BySection, func(i, j int) bool {
+		return dashboard.BySection[i].Section < dashboard.BySection[j].Section
+	})
+
+	for _, month := range months {
+		dashboard.ByMonth = append(dashboard.ByMonth, *month)
+	}
+	sort.Slice(dashboard.ByMonth, func(i, j int) bool {
+		return dashboard.ByMonth[i].Month < dashboard.ByMonth[j].Month
+	})
+
+	today := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location())
+	for _, month := range dashboard.ByMonth {
+		if !month.Pending.IsPositive() {
+			continue
+		}
+
+		dueDate, _ := time.ParseInLocation("2006-01-02", month.DueDate, asOf.Location())
+		daysToDue := int(dueDate.Sub(today).Hours() / 24)
+
+		switch {
+		case daysToDue < 0:
+			dashboard.Alerts = append(dashboard.Alerts, models.TDSAlert{
+				Severity:  TDSAlertOverdue,
+				Month:     month.Month,
+				DueDate:   month.DueDate,
+				DaysToDue: daysToDue,
+				Amount:    month.Pending,
+				Interest:  month.Interest,
+				Message: fmt.Sprintf("TDS of %s deducted in %s was due on %s; interest of %s has accrued at 1.5%% per month",
+					month.Pending.StringFixed(2), month.Month, month.DueDate, month.Interest.StringFixed(2)),
+			})
+		case daysToDue <= alertDays:
+			dashboard.Alerts = append(dashboard.Alerts, models.TDSAlert{
+				Severity:  TDSAlertDueSoon,
+				Month:     month.Month,
+				DueDate:   month.DueDate,
+				DaysToDue: daysToDue,
+				Amount:    month.Pending,
+				Interest:  decimal.Zero,
+				Message: fmt.Sprintf("TDS of %s deducted in %s is due on %s (%d days)",
+					month.Pending.StringFixed(2), month.Month, month.DueDate, daysToDue),
+			})
+		}
+	}
+
+	return dashboard, nil
+}
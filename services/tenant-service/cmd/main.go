@@ -1,23 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
-	"os"
+	"net/http"
+	"time"
 
-	"github.com/bookkeep/go-shared/config"
-	"github.com/bookkeep/go-shared/database"
-	"github.com/bookkeep/go-shared/middleware"
 	"github.com/bookkeep/tenant-service/internal/handlers"
 	"github.com/bookkeep/tenant-service/internal/models"
 	"github.com/bookkeep/tenant-service/internal/repository"
 	"github.com/bookkeep/tenant-service/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/config"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/database"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/email"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/jobs"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
 )
 
 func main() {
 	// Load configuration
-	cfg, err := config.Load("tenant-service")
+	cfg, err := config.LoadWithDefaults("tenant-service", config.Defaults{DatabaseName: "bookkeep_tenant", Port: 8083})
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
@@ -34,6 +39,15 @@ func main() {
 		MaxIdleConns:    cfg.Database.MaxIdleConns,
 		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
 		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+
+		DisablePreparedStatements: cfg.Database.DisablePreparedStatements,
+		StatementTimeout:          cfg.Database.StatementTimeout,
+		SlowQueryThreshold:        cfg.Database.SlowQueryThreshold,
+		LogLevel:                  cfg.Database.LogLevel,
+		ReplicaHost:               cfg.Database.ReplicaHost,
+		ReplicaPort:               cfg.Database.ReplicaPort,
+		ReplicaUser:               cfg.Database.ReplicaUser,
+		ReplicaPassword:           cfg.Database.ReplicaPassword,
 	}
 	db, err := database.Connect(dbConfig)
 	if err != nil {
@@ -48,6 +62,22 @@ func main() {
 		&models.Role{},
 		&models.RolePermission{},
 		&models.AuditLog{},
+		&models.TenantGroup{},
+		&models.TenantGroupMember{},
+		&models.TenantBranding{},
+		&models.TenantLogo{},
+		&models.TenantDataExport{},
+		&models.TenantDeletion{},
+		&models.TenantNetworkPolicy{},
+		&models.TenantBackup{},
+		&models.TenantRestore{},
+		&models.Partner{},
+		&models.TenantReferral{},
+		&models.TenantValidationRule{},
+		&storage.Document{},
+		&jobs.Job{},
+		&email.Delivery{},
+		&email.DeliveryAttachment{},
 	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -58,37 +88,87 @@ func main() {
 	// Initialize repositories
 	tenantRepo := repository.NewTenantRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+	brandingRepo := repository.NewBrandingRepository(db)
+	deletionRepo := repository.NewDeletionRepository(db)
+	networkPolicyRepo := repository.NewNetworkPolicyRepository(db)
+	backupRepo := repository.NewBackupRepository(db)
+	partnerRepo := repository.NewPartnerRepository(db)
+	validationRuleRepo := repository.NewValidationRuleRepository(db)
+	exportActivityRepo := repository.NewExportActivityRepository(db)
+	if err := deletionRepo.FailInterrupted(context.Background()); err != nil {
+		log.Printf("Failed to clean up interrupted data exports: %v", err)
+	}
+	if err := backupRepo.FailInterrupted(context.Background()); err != nil {
+		log.Printf("Failed to clean up interrupted backups: %v", err)
+	}
+	if err := brandingRepo.RecordStorage(context.Background()); err != nil {
+		log.Printf("Failed to record logo storage: %v", err)
+	}
+
+	// Invitation emails are sent from a background job queue
+	jobQueue := jobs.NewQueue(db, jobs.Config{})
+	emailDriver, err := email.NewDriver(cfg.Email, cfg.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to set up email: %v", err)
+	}
+	mailer := email.NewMailer(db, jobQueue, emailDriver, cfg.Email)
+	jobQueue.Start(context.Background())
 
 	// Initialize services
-	tenantService := services.NewTenantService(tenantRepo, roleRepo)
+	partnerService := services.NewPartnerService(partnerRepo, tenantRepo)
+	tenantService := services.NewTenantService(tenantRepo, roleRepo, partnerService, mailer, cfg.Email.AppURL)
+	groupService := services.NewGroupService(groupRepo, tenantService)
+	storageService := services.NewStorageService(db)
+	deletionService := services.NewDeletionService(deletionRepo, tenantRepo)
+	backupService := services.NewBackupService(backupRepo, deletionRepo, tenantRepo)
+	networkPolicyService := services.NewNetworkPolicyService(networkPolicyRepo, tenantRepo, roleRepo)
+	validationRuleService := services.NewValidationRuleService(validationRuleRepo, roleRepo)
+	exportActivityService := services.NewExportActivityService(exportActivityRepo, tenantRepo, roleRepo)
+	brandingService := services.NewBrandingService(brandingRepo, tenantRepo, config.GetEnv("PUBLIC_API_URL", "https://api.bookkeep.in"))
 
 	// Initialize handlers
 	tenantHandler := handlers.NewTenantHandler(tenantService, roleRepo)
+	groupHandler := handlers.NewGroupHandler(groupService)
+	brandingHandler := handlers.NewBrandingHandler(brandingService)
+	storageHandler := handlers.NewStorageHandler(storageService)
+	deletionHandler := handlers.NewDeletionHandler(deletionService, exportActivityService)
+	backupHandler := handlers.NewBackupHandler(backupService)
+	networkPolicyHandler := handlers.NewNetworkPolicyHandler(networkPolicyService, cfg.Network.CountryHeader)
+	partnerHandler := handlers.NewPartnerHandler(partnerService)
+	validationRuleHandler := handlers.NewValidationRuleHandler(validationRuleService)
+	exportActivityHandler := handlers.NewExportActivityHandler(exportActivityService)
+
+	// Carry out tenant deletions whose cooling-off period has ended, and drop
+	// expired backups. Each deletion is claimed under a row lock, so every
+	// instance can run the sweep.
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := deletionService.RunDueDeletions(context.Background()); err != nil {
+				log.Printf("Failed to run due tenant deletions: %v", err)
+			}
+			if err := backupService.PurgeExpired(context.Background()); err != nil {
+				log.Printf("Failed to purge expired backups: %v", err)
+			}
+		}
+	}()
 
 	// Setup Gin router
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
-	}
+	gin.SetMode(cfg.App.Environment)
 
 	r := gin.Default()
 
-	// Allowed CORS origins
-	allowedOrigins := []string{
-		"https://app.bookkeep.in",
-		"https://www.bookkeep.in",
-		"https://bookkeep.in",
-	}
-	if os.Getenv("GIN_MODE") != "release" {
-		allowedOrigins = append(allowedOrigins,
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"exp://localhost:19000",
-		)
+	// Client addresses are only read from forwarding headers set by the
+	// proxies in front of the service
+	if err := middleware.TrustProxies(r, cfg.Network.TrustedProxies, cfg.Network.TrustedPlatform); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// Apply global middleware
 	r.Use(middleware.SecurityHeaders())
-	r.Use(middleware.CORSMiddleware(allowedOrigins))
+	r.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
 	r.Use(middleware.RequestIDMiddleware())
 	r.Use(middleware.LoggerMiddleware())
 	r.Use(middleware.RecoveryMiddleware())
@@ -98,13 +178,26 @@ func main() {
 		c.JSON(200, gin.H{"status": "healthy", "service": "tenant-service"})
 	})
 
+	// Database pool metrics
+	r.GET("/metrics", gin.WrapH(database.MetricsHandler(db)))
+
 	// JWT config for auth middleware
 	jwtConfig := middleware.JWTConfig{
-		Secret:    cfg.JWT.Secret,
-		Issuer:    cfg.JWT.Issuer,
-		SkipPaths: cfg.JWT.SkipPaths,
+		Secret:          cfg.JWT.Secret,
+		Secrets:         cfg.JWTSecrets,
+		RefreshSecrets:  cfg.RefreshJWTSecrets,
+		Issuer:          cfg.JWT.Issuer,
+		SkipPaths:       cfg.JWT.SkipPaths,
+		NetworkPolicies: networkPolicyService,
+		CountryHeader:   cfg.Network.CountryHeader,
 	}
 
+	// Routes a user blocked by their tenant's network policy still reaches:
+	// the policy itself, which other services read and report blocked
+	// requests to, and an owner's emergency bypass
+	unrestrictedJWTConfig := jwtConfig
+	unrestrictedJWTConfig.NetworkPolicies = nil
+
 	// Public routes
 	api := r.Group("/api/v1")
 	{
@@ -113,6 +206,19 @@ func main() {
 
 		// Accept invitation (authenticated but no tenant required)
 		api.POST("/invitations/:token/accept", middleware.AuthMiddleware(jwtConfig), tenantHandler.AcceptInvitation)
+
+		// Branding shown to a tenant's customers, used by the customer
+		// portal, emails and PDFs
+		api.GET("/tenants/branding", brandingHandler.ResolveDomain)
+		api.GET("/tenants/:tenant_id/branding/public", brandingHandler.GetPublicBranding)
+		api.GET("/tenants/:tenant_id/branding/logo", brandingHandler.GetLogo)
+
+		// Channels the services sending invoices, reminders and login codes
+		// message a tenant's customers and members on
+		api.GET("/tenants/:tenant_id/messaging-preferences", tenantHandler.GetMessagingPreferences)
+
+		// Partner a referral code entered at signup belongs to
+		api.GET("/referral-codes/:code", partnerHandler.CheckReferralCode)
 	}
 
 	// Authenticated routes
@@ -124,6 +230,18 @@ func main() {
 
 		// Create new tenant
 		auth.POST("/tenants", tenantHandler.CreateTenant)
+
+		// A partner's referred tenants and commissions
+		auth.GET("/partners/me/dashboard", partnerHandler.MyDashboard)
+	}
+
+	networkPolicy := api.Group("/tenants/:tenant_id/network-policy")
+	networkPolicy.Use(middleware.AuthMiddleware(unrestrictedJWTConfig))
+	networkPolicy.Use(TenantMiddleware(tenantRepo))
+	{
+		networkPolicy.GET("", networkPolicyHandler.GetPolicy)
+		networkPolicy.POST("/blocked", networkPolicyHandler.RecordBlocked)
+		networkPolicy.POST("/bypass", middleware.RequireRecentAuth(cfg.JWT.StepUpMaxAge), networkPolicyHandler.Bypass)
 	}
 
 	// Tenant-scoped routes (requires tenant membership)
@@ -134,13 +252,13 @@ func main() {
 		// Tenant management
 		tenant.GET("", RequirePermission(tenantService, models.PermTenantView), tenantHandler.GetTenant)
 		tenant.PUT("", RequirePermission(tenantService, models.PermTenantEdit), tenantHandler.UpdateTenant)
-		tenant.DELETE("", RequirePermission(tenantService, models.PermTenantDelete), tenantHandler.DeleteTenant)
 
 		// My permissions
 		tenant.GET("/permissions/me", tenantHandler.GetMyPermissions)
 
 		// Team management
 		tenant.GET("/members", RequirePermission(tenantService, models.PermTeamView), tenantHandler.ListMembers)
+		tenant.GET("/owners", tenantHandler.ListOwners)
 		tenant.PUT("/members/:member_id", RequirePermission(tenantService, models.PermTeamEdit), tenantHandler.UpdateMember)
 		tenant.DELETE("/members/:member_id", RequirePermission(tenantService, models.PermTeamRemove), tenantHandler.RemoveMember)
 
@@ -151,16 +269,92 @@ func main() {
 
 		// Roles
 		tenant.GET("/roles", RequirePermission(tenantService, models.PermTeamView), tenantHandler.ListRoles)
+
+		// Group (consolidated reporting across tenants)
+		tenant.GET("/group", RequirePermission(tenantService, models.PermTenantView), groupHandler.GetGroup)
+		tenant.POST("/group", RequirePermission(tenantService, models.PermTenantEdit), groupHandler.CreateGroup)
+		tenant.PUT("/group", RequirePermission(tenantService, models.PermTenantEdit), groupHandler.UpdateGroup)
+		tenant.DELETE("/group", RequirePermission(tenantService, models.PermTenantEdit), groupHandler.DeleteGroup)
+		tenant.POST("/group/members", RequirePermission(tenantService, models.PermTenantEdit), groupHandler.AddMember)
+		tenant.DELETE("/group/members/:member_tenant_id", RequirePermission(tenantService, models.PermTenantEdit), groupHandler.RemoveMember)
+
+		// Branding and white-label settings
+		tenant.GET("/branding", RequirePermission(tenantService, models.PermTenantView), brandingHandler.GetBranding)
+		tenant.PUT("/branding", RequirePermission(tenantService, models.PermTenantEdit), brandingHandler.UpdateBranding)
+		tenant.PUT("/branding/logo", RequirePermission(tenantService, models.PermTenantEdit), brandingHandler.UploadLogo)
+		tenant.DELETE("/branding/logo", RequirePermission(tenantService, models.PermTenantEdit), brandingHandler.DeleteLogo)
+		tenant.POST("/branding/domain/verify", RequirePermission(tenantService, models.PermTenantEdit), brandingHandler.VerifyDomain)
+
+		// Data export and deletion. Deletion needs a recent export, the
+		// owner's password and a cooling-off period during which it can be
+		// cancelled.
+		tenant.GET("/exports", RequirePermission(tenantService, models.PermTenantDelete), deletionHandler.ListExports)
+		tenant.POST("/exports", RequirePermission(tenantService, models.PermTenantDelete), RequirePermission(tenantService, models.PermDataExport), deletionHandler.StartExport)
+		tenant.GET("/exports/:export_id", RequirePermission(tenantService, models.PermTenantDelete), deletionHandler.GetExport)
+		tenant.GET("/exports/:export_id/download", RequirePermission(tenantService, models.PermTenantDelete), RequirePermission(tenantService, models.PermDataExport), deletionHandler.DownloadExport)
+		tenant.GET("/deletion", RequirePermission(tenantService, models.PermTenantView), deletionHandler.GetDeletion)
+		tenant.POST("/deletion", RequirePermission(tenantService, models.PermTenantDelete), deletionHandler.ScheduleDeletion)
+		tenant.DELETE("/deletion", RequirePermission(tenantService, models.PermTenantDelete), deletionHandler.CancelDeletion)
+
+		// IP and country restrictions
+		tenant.PUT("/network-policy", RequirePermission(tenantService, models.PermTenantEdit), middleware.RequireRecentAuth(cfg.JWT.StepUpMaxAge), networkPolicyHandler.UpdatePolicy)
+		tenant.DELETE("/network-policy", RequirePermission(tenantService, models.PermTenantEdit), middleware.RequireRecentAuth(cfg.JWT.StepUpMaxAge), networkPolicyHandler.DeletePolicy)
+		tenant.DELETE("/network-policy/bypass", networkPolicyHandler.RevokeBypass)
+
+		// Data validation rules, which every member's documents are checked
+		// against by the service keeping them
+		tenant.GET("/validation-rules", validationRuleHandler.ListRules)
+		tenant.GET("/validation-rules/catalog", validationRuleHandler.GetCatalog)
+		tenant.GET("/validation-rules/:rule_id", validationRuleHandler.GetRule)
+		tenant.POST("/validation-rules", RequirePermission(tenantService, models.PermSettingsEdit), validationRuleHandler.CreateRule)
+		tenant.PUT("/validation-rules/:rule_id", RequirePermission(tenantService, models.PermSettingsEdit), validationRuleHandler.UpdateRule)
+		tenant.DELETE("/validation-rules/:rule_id", RequirePermission(tenantService, models.PermSettingsEdit), validationRuleHandler.DeleteRule)
+
+		// Exports of the tenant's data made in any service, recorded by the
+		// services with the exporting user's token; only owners see them
+		tenant.POST("/export-activity", exportActivityHandler.RecordExport)
+		tenant.GET("/export-activity", exportActivityHandler.ListExports)
+		tenant.GET("/export-activity/summary", exportActivityHandler.GetSummary)
+
+		// Document storage usage
+		tenant.GET("/storage", RequirePermission(tenantService, models.PermTenantView), storageHandler.GetUsage)
+		tenant.GET("/storage/largest", RequirePermission(tenantService, models.PermTenantView), storageHandler.LargestDocuments)
+	}
+
+	// Platform admin: per-tenant backups, and restores into staging tenants
+	// to check a tenant can be recovered; partners and the tenants they
+	// referred
+	admin := api.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(jwtConfig))
+	admin.Use(middleware.RequireRole("admin"))
+	{
+		admin.GET("/tenants/:tenant_id/backups", backupHandler.ListBackups)
+		admin.POST("/tenants/:tenant_id/backups", backupHandler.StartBackup)
+		admin.GET("/tenants/:tenant_id/restore-points", backupHandler.ListRestorePoints)
+		admin.GET("/tenants/:tenant_id/restores", backupHandler.ListRestores)
+		admin.GET("/backups/:backup_id", backupHandler.GetBackup)
+		admin.POST("/backups/:backup_id/restore", backupHandler.StartRestore)
+		admin.GET("/restores/:restore_id", backupHandler.GetRestore)
+
+		admin.GET("/partners", partnerHandler.ListPartners)
+		admin.POST("/partners", partnerHandler.CreatePartner)
+		admin.GET("/partners/:partner_id", partnerHandler.GetPartner)
+		admin.PUT("/partners/:partner_id", partnerHandler.UpdatePartner)
+		admin.GET("/partners/:partner_id/dashboard", partnerHandler.PartnerDashboard)
+		admin.POST("/tenants/:tenant_id/referral", partnerHandler.AttributeTenant)
 	}
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8083"
+	srv := &http.Server{
+		Addr:         cfg.GetServerAddress(),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	log.Printf("Tenant service starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	log.Printf("Tenant service starting on %s", cfg.GetServerAddress())
+	if err := srv.ListenAndServe(); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
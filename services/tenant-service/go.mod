@@ -3,9 +3,11 @@ module github.com/bookkeep/tenant-service
 go 1.25
 
 require (
-	github.com/bookkeep/go-shared v0.0.0
 	github.com/gin-gonic/gin v1.10.0
 	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	github.com/tesseract-nexus/bookkeeping-app/go-shared v0.0.0
+	golang.org/x/crypto v0.31.0
 	gorm.io/gorm v1.25.12
 )
 
@@ -37,7 +39,6 @@ require (
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.2.12 // indirect
 	golang.org/x/arch v0.8.0 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
@@ -47,4 +48,4 @@ require (
 	gorm.io/driver/postgres v1.5.11 // indirect
 )
 
-replace github.com/bookkeep/go-shared => ../../packages/go-shared
+replace github.com/tesseract-nexus/bookkeeping-app/go-shared => ../../packages/go-shared
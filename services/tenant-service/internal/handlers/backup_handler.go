@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// BackupHandler serves the platform admin API for tenant backups and
+// restores
+type BackupHandler struct {
+	backupService services.BackupService
+}
+
+func NewBackupHandler(backupService services.BackupService) *BackupHandler {
+	return &BackupHandler{backupService: backupService}
+}
+
+// StartBackup backs up all of a tenant's data in the background
+// @Summary Start a tenant backup
+// @Tags Admin Backups
+// @Accept json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param body body services.StartBackupRequest false "Backup note"
+// @Success 202 {object} models.TenantBackup
+// @Router /admin/tenants/{tenant_id}/backups [post]
+func (h *BackupHandler) StartBackup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.StartBackupRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	backup, err := h.backupService.StartBackup(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, backup)
+}
+
+// ListBackups lists a tenant's backups, latest first
+// @Summary List tenant backups
+// @Tags Admin Backups
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Success 200 {array} models.TenantBackup
+// @Router /admin/tenants/{tenant_id}/backups [get]
+func (h *BackupHandler) ListBackups(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	backups, err := h.backupService.ListBackups(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, backups)
+}
+
+// ListRestorePoints lists the backups a tenant can be restored from
+// @Summary List restore points
+// @Tags Admin Backups
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Success 200 {array} models.TenantBackup
+// @Router /admin/tenants/{tenant_id}/restore-points [get]
+func (h *BackupHandler) ListRestorePoints(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	backups, err := h.backupService.ListRestorePoints(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, backups)
+}
+
+// ListRestores lists the restores of a tenant's backups, latest first
+// @Summary List tenant restores
+// @Tags Admin Backups
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Success 200 {array} models.TenantRestore
+// @Router /admin/tenants/{tenant_id}/restores [get]
+func (h *BackupHandler) ListRestores(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	restores, err := h.backupService.ListRestores(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, restores)
+}
+
+// GetBackup returns a backup's progress
+// @Summary Get a backup
+// @Tags Admin Backups
+// @Produce json
+// @Param backup_id path string true "Backup ID"
+// @Success 200 {object} models.TenantBackup
+// @Router /admin/backups/{backup_id} [get]
+func (h *BackupHandler) GetBackup(c *gin.Context) {
+	backupID, err := uuid.Parse(c.Param("backup_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid backup ID", nil)
+		return
+	}
+
+	backup, err := h.backupService.GetBackup(c.Request.Context(), backupID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, backup)
+}
+
+// StartRestore restores a backup into a new staging tenant in the
+// background
+// @Summary Restore a backup into a staging tenant
+// @Tags Admin Backups
+// @Produce json
+// @Param backup_id path string true "Backup ID"
+// @Success 202 {object} models.TenantRestore
+// @Router /admin/backups/{backup_id}/restore [post]
+func (h *BackupHandler) StartRestore(c *gin.Context) {
+	backupID, err := uuid.Parse(c.Param("backup_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid backup ID", nil)
+		return
+	}
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	restore, err := h.backupService.StartRestore(c.Request.Context(), backupID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, restore)
+}
+
+// GetRestore returns a restore's progress and, once done, how each table
+// was restored
+// @Summary Get a restore
+// @Tags Admin Backups
+// @Produce json
+// @Param restore_id path string true "Restore ID"
+// @Success 200 {object} models.TenantRestore
+// @Router /admin/restores/{restore_id} [get]
+func (h *BackupHandler) GetRestore(c *gin.Context) {
+	restoreID, err := uuid.Parse(c.Param("restore_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid restore ID", nil)
+		return
+	}
+
+	restore, err := h.backupService.GetRestore(c.Request.Context(), restoreID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, restore)
+}
+
+func (h *BackupHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case repository.ErrBackupNotFound, repository.ErrRestoreNotFound, repository.ErrTenantNotFound:
+		response.NotFound(c, err.Error())
+	case services.ErrBackupInProgress:
+		response.Conflict(c, err.Error())
+	case services.ErrBackupUnavailable:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
+)
+
+type BrandingHandler struct {
+	brandingService services.BrandingService
+}
+
+func NewBrandingHandler(brandingService services.BrandingService) *BrandingHandler {
+	return &BrandingHandler{brandingService: brandingService}
+}
+
+// GetBranding returns the tenant's branding settings
+// @Summary Get tenant branding
+// @Tags Branding
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantBranding
+// @Router /tenants/{id}/branding [get]
+func (h *BrandingHandler) GetBranding(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	branding, err := h.brandingService.GetBranding(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+// UpdateBranding replaces the tenant's branding settings
+// @Summary Update tenant branding
+// @Tags Branding
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body services.UpdateBrandingRequest true "Branding settings"
+// @Success 200 {object} models.TenantBranding
+// @Router /tenants/{id}/branding [put]
+func (h *BrandingHandler) UpdateBranding(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.UpdateBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	branding, err := h.brandingService.UpdateBranding(c.Request.Context(), tenantID.(uuid.UUID), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+// UploadLogo replaces the tenant's logo (multipart form, field "logo")
+// @Summary Upload tenant logo
+// @Tags Branding
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param logo formData file true "PNG, JPEG, GIF or WebP image, at most 1 MB"
+// @Success 200 {object} models.TenantBranding
+// @Router /tenants/{id}/branding/logo [put]
+func (h *BrandingHandler) UploadLogo(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	file, err := c.FormFile("logo")
+	if err != nil {
+		response.BadRequest(c, "Logo file is required", nil)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "Failed to read logo", nil)
+		return
+	}
+	defer f.Close()
+
+	// Read one byte past the limit so oversized files are rejected by the
+	// service rather than silently truncated
+	content, err := io.ReadAll(io.LimitReader(f, services.MaxLogoSize+1))
+	if err != nil {
+		response.BadRequest(c, "Failed to read logo", nil)
+		return
+	}
+
+	branding, err := h.brandingService.UploadLogo(c.Request.Context(), tenantID.(uuid.UUID), userID, http.DetectContentType(content), content)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+// DeleteLogo removes the tenant's logo
+// @Summary Delete tenant logo
+// @Tags Branding
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantBranding
+// @Router /tenants/{id}/branding/logo [delete]
+func (h *BrandingHandler) DeleteLogo(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	branding, err := h.brandingService.DeleteLogo(c.Request.Context(), tenantID.(uuid.UUID), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+// VerifyDomain checks the custom domain's DNS verification record
+// @Summary Verify custom domain
+// @Tags Branding
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantBranding
+// @Router /tenants/{id}/branding/domain/verify [post]
+func (h *BrandingHandler) VerifyDomain(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	branding, err := h.brandingService.VerifyDomain(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+// GetLogo serves a tenant's logo (public, embedded in PDFs, emails and the
+// customer portal)
+// @Summary Get tenant logo
+// @Tags Branding
+// @Produce image/png
+// @Param id path string true "Tenant ID"
+// @Success 200 {file} binary
+// @Router /tenants/{id}/branding/logo [get]
+func (h *BrandingHandler) GetLogo(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	logo, err := h.brandingService.GetLogo(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// URLs carry the upload time, so a given URL always serves the same image
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("Content-Length", strconv.Itoa(len(logo.Content)))
+	c.Data(http.StatusOK, logo.ContentType, logo.Content)
+}
+
+// GetPublicBranding returns the branding a tenant's customers see (public)
+// @Summary Get public tenant branding
+// @Tags Branding
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.PublicBranding
+// @Router /tenants/{id}/branding/public [get]
+func (h *BrandingHandler) GetPublicBranding(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	branding, err := h.brandingService.GetPublicBranding(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+// ResolveDomain returns the branding of the tenant owning a verified custom
+// domain, for the customer portal to theme itself (public)
+// @Summary Resolve custom domain branding
+// @Tags Branding
+// @Produce json
+// @Param domain query string true "Custom domain"
+// @Success 200 {object} models.PublicBranding
+// @Router /tenants/branding [get]
+func (h *BrandingHandler) ResolveDomain(c *gin.Context) {
+	domain := c.Query("domain")
+	if domain == "" {
+		response.BadRequest(c, "domain is required", nil)
+		return
+	}
+
+	branding, err := h.brandingService.GetPublicBrandingByDomain(c.Request.Context(), domain)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, branding)
+}
+
+func (h *BrandingHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case repository.ErrTenantNotFound, repository.ErrBrandingNotFound, repository.ErrLogoNotFound:
+		response.NotFound(c, err.Error())
+	case repository.ErrDomainTaken:
+		response.Conflict(c, err.Error())
+	case storage.ErrQuotaExceeded:
+		response.Forbidden(c, "Storage quota exceeded; remove documents or upgrade your plan")
+	case services.ErrInvalidDomain, services.ErrInvalidReplyTo, services.ErrLogoTooLarge,
+		services.ErrInvalidLogoType, services.ErrNoCustomDomain, services.ErrDomainNotVerifiable:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
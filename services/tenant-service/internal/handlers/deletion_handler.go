@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+type DeletionHandler struct {
+	deletionService       services.DeletionService
+	exportActivityService services.ExportActivityService
+}
+
+func NewDeletionHandler(deletionService services.DeletionService, exportActivityService services.ExportActivityService) *DeletionHandler {
+	return &DeletionHandler{
+		deletionService:       deletionService,
+		exportActivityService: exportActivityService,
+	}
+}
+
+// StartExport exports all of the tenant's data in the background
+// @Summary Start a data export
+// @Tags Tenant Deletion
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 202 {object} models.TenantDataExport
+// @Router /tenants/{id}/exports [post]
+func (h *DeletionHandler) StartExport(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	export, err := h.deletionService.StartExport(c.Request.Context(), tenantID.(uuid.UUID), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Accepted(c, export)
+}
+
+// ListExports lists the tenant's data exports, latest first
+// @Summary List data exports
+// @Tags Tenant Deletion
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {array} models.TenantDataExport
+// @Router /tenants/{id}/exports [get]
+func (h *DeletionHandler) ListExports(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	exports, err := h.deletionService.ListExports(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, exports)
+}
+
+// GetExport returns a data export's progress
+// @Summary Get a data export
+// @Tags Tenant Deletion
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param export_id path string true "Export ID"
+// @Success 200 {object} models.TenantDataExport
+// @Router /tenants/{id}/exports/{export_id} [get]
+func (h *DeletionHandler) GetExport(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	exportID, err := uuid.Parse(c.Param("export_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid export ID", nil)
+		return
+	}
+
+	export, err := h.deletionService.GetExport(c.Request.Context(), tenantID.(uuid.UUID), exportID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, export)
+}
+
+// DownloadExport downloads a completed data export as a zip
+// @Summary Download a data export
+// @Tags Tenant Deletion
+// @Produce application/zip
+// @Param id path string true "Tenant ID"
+// @Param export_id path string true "Export ID"
+// @Success 200 {file} binary
+// @Router /tenants/{id}/exports/{export_id}/download [get]
+func (h *DeletionHandler) DownloadExport(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	exportID, err := uuid.Parse(c.Param("export_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid export ID", nil)
+		return
+	}
+
+	export, err := h.deletionService.DownloadExport(c.Request.Context(), tenantID.(uuid.UUID), exportID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.recordDownload(c, tenantID.(uuid.UUID), exportID)
+
+	c.Header("Content-Disposition", "attachment; filename=\""+export.FileName+"\"")
+	c.Data(http.StatusOK, "application/zip", export.Content)
+}
+
+// recordDownload adds a download of all of the tenant's data to its audit
+// log, alongside the exports made in other services
+func (h *DeletionHandler) recordDownload(c *gin.Context, tenantID, exportID uuid.UUID) {
+	userID, _ := getUserID(c)
+	_ = h.exportActivityService.Record(c.Request.Context(), middleware.ExportRecord{
+		TenantID:  tenantID.String(),
+		UserID:    userID.String(),
+		Resource:  "tenant_data",
+		Status:    middleware.ExportSucceeded,
+		Filters:   map[string]string{"export_id": exportID.String()},
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+		At:        time.Now().UTC(),
+	})
+}
+
+// GetDeletion returns the tenant's scheduled deletion
+// @Summary Get the scheduled deletion
+// @Tags Tenant Deletion
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantDeletion
+// @Router /tenants/{id}/deletion [get]
+func (h *DeletionHandler) GetDeletion(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	deletion, err := h.deletionService.GetDeletion(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, deletion)
+}
+
+// ScheduleDeletion schedules the tenant for deletion after the cooling-off
+// period
+// @Summary Schedule tenant deletion
+// @Tags Tenant Deletion
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body services.ScheduleDeletionRequest true "Re-authentication and confirmation"
+// @Success 201 {object} models.TenantDeletion
+// @Router /tenants/{id}/deletion [post]
+func (h *DeletionHandler) ScheduleDeletion(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.ScheduleDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	deletion, err := h.deletionService.ScheduleDeletion(c.Request.Context(), tenantID.(uuid.UUID), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, deletion)
+}
+
+// CancelDeletion cancels the tenant's scheduled deletion
+// @Summary Cancel tenant deletion
+// @Tags Tenant Deletion
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantDeletion
+// @Router /tenants/{id}/deletion [delete]
+func (h *DeletionHandler) CancelDeletion(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	deletion, err := h.deletionService.CancelDeletion(c.Request.Context(), tenantID.(uuid.UUID), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, deletion)
+}
+
+func (h *DeletionHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case repository.ErrExportNotFound, repository.ErrDeletionNotFound, repository.ErrTenantNotFound:
+		response.NotFound(c, err.Error())
+	case services.ErrNotOwner:
+		response.Forbidden(c, err.Error())
+	case services.ErrReauthFailed, repository.ErrUserNotFound:
+		response.Unauthorized(c, services.ErrReauthFailed.Error())
+	case services.ErrExportInProgress, services.ErrDeletionScheduled:
+		response.Conflict(c, err.Error())
+	case services.ErrConfirmationMismatch, services.ErrExportRequired, services.ErrExportUnavailable:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+type ExportActivityHandler struct {
+	exportActivityService services.ExportActivityService
+}
+
+func NewExportActivityHandler(exportActivityService services.ExportActivityService) *ExportActivityHandler {
+	return &ExportActivityHandler{exportActivityService: exportActivityService}
+}
+
+// RecordExport adds an export made in another service to the tenant's
+// audit log. It is sent with the exporting user's token, so the export
+// must be theirs.
+// @Summary Record a data export
+// @Tags Export Activity
+// @Accept json
+// @Param id path string true "Tenant ID"
+// @Success 204
+// @Router /tenants/{id}/export-activity [post]
+func (h *ExportActivityHandler) RecordExport(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var record middleware.ExportRecord
+	if err := c.ShouldBindJSON(&record); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	if record.UserID != userID.String() || record.TenantID != tenantID.(uuid.UUID).String() {
+		response.Forbidden(c, services.ErrExportRecordForged.Error())
+		return
+	}
+
+	if err := h.exportActivityService.Record(c.Request.Context(), record); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// ListExports returns the tenant's exports, latest first, to an owner
+// @Summary List data exports
+// @Tags Export Activity
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param user_id query string false "Member who exported"
+// @Param resource query string false "Kind of data exported"
+// @Param from_date query string false "From date (YYYY-MM-DD), default 30 days ago"
+// @Param to_date query string false "To date (YYYY-MM-DD)"
+// @Success 200 {array} services.ExportActivity
+// @Router /tenants/{id}/export-activity [get]
+func (h *ExportActivityHandler) ListExports(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+	filters, ok := exportActivityFilters(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	filters.Limit = limit
+	filters.Offset = (page - 1) * limit
+
+	activity, total, err := h.exportActivityService.List(c.Request.Context(), tenantID.(uuid.UUID), userID, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Paginated(c, activity, page, limit, total)
+}
+
+// GetSummary totals the tenant's exports by member and by kind of data, for
+// an owner
+// @Summary Summarise data exports
+// @Tags Export Activity
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param from_date query string false "From date (YYYY-MM-DD), default 30 days ago"
+// @Param to_date query string false "To date (YYYY-MM-DD)"
+// @Success 200 {object} services.ExportActivitySummary
+// @Router /tenants/{id}/export-activity/summary [get]
+func (h *ExportActivityHandler) GetSummary(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+	filters, ok := exportActivityFilters(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.exportActivityService.Summary(c.Request.Context(), tenantID.(uuid.UUID), userID, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+func exportActivityFilters(c *gin.Context) (repository.ExportActivityFilters, bool) {
+	filters := repository.ExportActivityFilters{
+		Resource:  c.Query("resource"),
+		StartDate: c.Query("from_date"),
+		EndDate:   c.Query("to_date"),
+	}
+	for _, date := range []string{filters.StartDate, filters.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			response.BadRequest(c, "Dates must be in the form YYYY-MM-DD", nil)
+			return filters, false
+		}
+	}
+	if member := c.Query("user_id"); member != "" {
+		memberID, err := uuid.Parse(member)
+		if err != nil {
+			response.BadRequest(c, "Invalid user ID", nil)
+			return filters, false
+		}
+		filters.UserID = &memberID
+	}
+	return filters, true
+}
+
+func (h *ExportActivityHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotOwner):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, services.ErrInvalidExportRecord):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+type GroupHandler struct {
+	groupService services.GroupService
+}
+
+func NewGroupHandler(groupService services.GroupService) *GroupHandler {
+	return &GroupHandler{groupService: groupService}
+}
+
+// GetGroup returns the group the tenant belongs to
+// @Summary Get the tenant's group
+// @Tags Groups
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantGroup
+// @Router /tenants/{id}/group [get]
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	group, err := h.groupService.GetGroup(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, group)
+}
+
+// CreateGroup creates a group with the tenant as its parent
+// @Summary Create a tenant group
+// @Tags Groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body services.CreateGroupRequest true "Group details"
+// @Success 201 {object} models.TenantGroup
+// @Router /tenants/{id}/group [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(c.Request.Context(), tenantID.(uuid.UUID), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, group)
+}
+
+// UpdateGroup renames the tenant's group
+// @Summary Update a tenant group
+// @Tags Groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body services.CreateGroupRequest true "Group details"
+// @Success 200 {object} models.TenantGroup
+// @Router /tenants/{id}/group [put]
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	var req services.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	group, err := h.groupService.UpdateGroup(c.Request.Context(), tenantID.(uuid.UUID), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, group)
+}
+
+// DeleteGroup dissolves the tenant's group
+// @Summary Delete a tenant group
+// @Tags Groups
+// @Param id path string true "Tenant ID"
+// @Success 204
+// @Router /tenants/{id}/group [delete]
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	if err := h.groupService.DeleteGroup(c.Request.Context(), tenantID.(uuid.UUID)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddMember adds another tenant to the group
+// @Summary Add a tenant to the group
+// @Tags Groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body services.AddGroupMemberRequest true "Member tenant"
+// @Success 200 {object} models.TenantGroup
+// @Router /tenants/{id}/group/members [post]
+func (h *GroupHandler) AddMember(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.AddGroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	group, err := h.groupService.AddMember(c.Request.Context(), tenantID.(uuid.UUID), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, group)
+}
+
+// RemoveMember removes a tenant from the group
+// @Summary Remove a tenant from the group
+// @Tags Groups
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param member_tenant_id path string true "Member tenant ID"
+// @Success 200 {object} models.TenantGroup
+// @Router /tenants/{id}/group/members/{member_tenant_id} [delete]
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	memberTenantID, err := uuid.Parse(c.Param("member_tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid member tenant ID", nil)
+		return
+	}
+
+	group, err := h.groupService.RemoveMember(c.Request.Context(), tenantID.(uuid.UUID), memberTenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, group)
+}
+
+func (h *GroupHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case repository.ErrGroupNotFound, repository.ErrGroupMemberNotFound, repository.ErrTenantNotFound:
+		response.NotFound(c, err.Error())
+	case repository.ErrTenantInGroup:
+		response.Conflict(c, err.Error())
+	case services.ErrNotGroupParent, services.ErrGroupAccessDenied:
+		response.Forbidden(c, err.Error())
+	case services.ErrCannotRemoveParent:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
+// getUserID reads the authenticated user's ID set by the auth middleware
+func getUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDVal, _ := c.Get("user_id")
+	userIDStr, ok := userIDVal.(string)
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
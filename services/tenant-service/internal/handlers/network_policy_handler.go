@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+type NetworkPolicyHandler struct {
+	networkPolicyService services.NetworkPolicyService
+
+	// countryHeader names the header the edge reports the client's country
+	// in, empty when country rules aren't enforced
+	countryHeader string
+}
+
+func NewNetworkPolicyHandler(networkPolicyService services.NetworkPolicyService, countryHeader string) *NetworkPolicyHandler {
+	return &NetworkPolicyHandler{
+		networkPolicyService: networkPolicyService,
+		countryHeader:        countryHeader,
+	}
+}
+
+// GetPolicy returns the tenant's network policy. Other services read it with
+// the user's token to enforce it.
+// @Summary Get network policy
+// @Tags Network Policy
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantNetworkPolicy
+// @Router /tenants/{id}/network-policy [get]
+func (h *NetworkPolicyHandler) GetPolicy(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	policy, err := h.networkPolicyService.GetPolicy(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// UpdatePolicy replaces the tenant's IP and country restrictions
+// @Summary Update network policy
+// @Tags Network Policy
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param request body services.UpdateNetworkPolicyRequest true "Network policy"
+// @Success 200 {object} models.TenantNetworkPolicy
+// @Router /tenants/{id}/network-policy [put]
+func (h *NetworkPolicyHandler) UpdatePolicy(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.UpdateNetworkPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	policy, err := h.networkPolicyService.UpdatePolicy(c.Request.Context(), tenantID.(uuid.UUID), userID, req, h.origin(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// DeletePolicy lifts all of the tenant's network restrictions
+// @Summary Delete network policy
+// @Tags Network Policy
+// @Param id path string true "Tenant ID"
+// @Success 204
+// @Router /tenants/{id}/network-policy [delete]
+func (h *NetworkPolicyHandler) DeletePolicy(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.networkPolicyService.DeletePolicy(c.Request.Context(), tenantID.(uuid.UUID), userID, h.origin(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// Bypass lets an owner who is locked out connect from anywhere for an hour
+// to correct the policy. It needs a fresh login or step-up.
+// @Summary Bypass network policy in an emergency
+// @Tags Network Policy
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantNetworkPolicy
+// @Router /tenants/{id}/network-policy/bypass [post]
+func (h *NetworkPolicyHandler) Bypass(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	policy, err := h.networkPolicyService.Bypass(c.Request.Context(), tenantID.(uuid.UUID), userID, h.origin(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// RevokeBypass ends an owner's emergency bypass early
+// @Summary Revoke network policy bypass
+// @Tags Network Policy
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.TenantNetworkPolicy
+// @Router /tenants/{id}/network-policy/bypass [delete]
+func (h *NetworkPolicyHandler) RevokeBypass(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	policy, err := h.networkPolicyService.RevokeBypass(c.Request.Context(), tenantID.(uuid.UUID), userID, h.origin(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// RecordBlocked adds a request another service refused under the policy to
+// the tenant's audit log. It is sent with the blocked user's token, so the
+// attempt must be theirs.
+// @Summary Record a blocked request
+// @Tags Network Policy
+// @Accept json
+// @Param id path string true "Tenant ID"
+// @Success 204
+// @Router /tenants/{id}/network-policy/blocked [post]
+func (h *NetworkPolicyHandler) RecordBlocked(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var attempt middleware.BlockedAttempt
+	if err := c.ShouldBindJSON(&attempt); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+	if attempt.UserID != userID.String() || attempt.TenantID != tenantID.(uuid.UUID).String() {
+		response.Forbidden(c, services.ErrBlockedAttemptForged.Error())
+		return
+	}
+
+	if err := h.networkPolicyService.LogBlocked(c.Request.Context(), attempt); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// origin describes where the request came from, for the lock-out check and
+// the audit log
+func (h *NetworkPolicyHandler) origin(c *gin.Context) services.RequestOrigin {
+	return services.RequestOrigin{
+		IPAddress: c.ClientIP(),
+		Country:   middleware.EdgeCountry(c, h.countryHeader),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+	}
+}
+
+func (h *NetworkPolicyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNetworkPolicyNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrNotOwner):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, services.ErrInvalidNetworkPolicy):
+		response.BadRequest(c, err.Error(), nil)
+	case errors.Is(err, services.ErrPolicyLocksOutCaller):
+		response.Conflict(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
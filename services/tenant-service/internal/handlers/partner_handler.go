@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+// PartnerHandler serves the partner program: the platform admin API for
+// partners and attributions, the check of a referral code at signup, and a
+// partner's own dashboard
+type PartnerHandler struct {
+	partnerService services.PartnerService
+}
+
+func NewPartnerHandler(partnerService services.PartnerService) *PartnerHandler {
+	return &PartnerHandler{partnerService: partnerService}
+}
+
+// CheckReferralCode tells the signup form whose referral code was entered
+// @Summary Check a referral code
+// @Tags Partners
+// @Produce json
+// @Param code path string true "Referral code"
+// @Success 200 {object} map[string]string
+// @Router /referral-codes/{code} [get]
+func (h *PartnerHandler) CheckReferralCode(c *gin.Context) {
+	partner, err := h.partnerService.ResolveReferralCode(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"referral_code": partner.ReferralCode,
+		"partner_name":  partner.Name,
+		"partner_type":  partner.Type,
+	})
+}
+
+// MyDashboard returns the signed-in partner's referred tenants and
+// commissions
+// @Summary Get my partner dashboard
+// @Tags Partners
+// @Produce json
+// @Success 200 {object} services.PartnerDashboard
+// @Router /partners/me/dashboard [get]
+func (h *PartnerHandler) MyDashboard(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	dashboard, err := h.partnerService.UserDashboard(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, dashboard)
+}
+
+// ListPartners lists all partners
+// @Summary List partners
+// @Tags Admin Partners
+// @Produce json
+// @Success 200 {array} models.Partner
+// @Router /admin/partners [get]
+func (h *PartnerHandler) ListPartners(c *gin.Context) {
+	partners, err := h.partnerService.ListPartners(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, partners)
+}
+
+// CreatePartner registers a partner with their referral code and commission
+// @Summary Create a partner
+// @Tags Admin Partners
+// @Accept json
+// @Produce json
+// @Param body body services.CreatePartnerRequest true "Partner details"
+// @Success 201 {object} models.Partner
+// @Router /admin/partners [post]
+func (h *PartnerHandler) CreatePartner(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.CreatePartnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	partner, err := h.partnerService.CreatePartner(c.Request.Context(), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, partner)
+}
+
+// GetPartner returns a partner
+// @Summary Get a partner
+// @Tags Admin Partners
+// @Produce json
+// @Param partner_id path string true "Partner ID"
+// @Success 200 {object} models.Partner
+// @Router /admin/partners/{partner_id} [get]
+func (h *PartnerHandler) GetPartner(c *gin.Context) {
+	partnerID, ok := h.parsePartnerID(c)
+	if !ok {
+		return
+	}
+
+	partner, err := h.partnerService.GetPartner(c.Request.Context(), partnerID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, partner)
+}
+
+// UpdatePartner updates a partner, or deactivates their referral code
+// @Summary Update a partner
+// @Tags Admin Partners
+// @Accept json
+// @Produce json
+// @Param partner_id path string true "Partner ID"
+// @Param body body services.UpdatePartnerRequest true "Partner details"
+// @Success 200 {object} models.Partner
+// @Router /admin/partners/{partner_id} [put]
+func (h *PartnerHandler) UpdatePartner(c *gin.Context) {
+	partnerID, ok := h.parsePartnerID(c)
+	if !ok {
+		return
+	}
+
+	var req services.UpdatePartnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	partner, err := h.partnerService.UpdatePartner(c.Request.Context(), partnerID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, partner)
+}
+
+// PartnerDashboard returns a partner's referred tenants and commissions
+// @Summary Get a partner's dashboard
+// @Tags Admin Partners
+// @Produce json
+// @Param partner_id path string true "Partner ID"
+// @Success 200 {object} services.PartnerDashboard
+// @Router /admin/partners/{partner_id}/dashboard [get]
+func (h *PartnerHandler) PartnerDashboard(c *gin.Context) {
+	partnerID, ok := h.parsePartnerID(c)
+	if !ok {
+		return
+	}
+
+	dashboard, err := h.partnerService.Dashboard(c.Request.Context(), partnerID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, dashboard)
+}
+
+// AttributeTenant attributes a tenant that signed up without a referral code
+// to a partner
+// @Summary Attribute a tenant to a partner
+// @Tags Admin Partners
+// @Accept json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param body body services.AttributeTenantRequest true "Partner's referral code"
+// @Success 201 {object} models.TenantReferral
+// @Router /admin/tenants/{tenant_id}/referral [post]
+func (h *PartnerHandler) AttributeTenant(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.AttributeTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	referral, err := h.partnerService.AttributeTenant(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, referral)
+}
+
+func (h *PartnerHandler) parsePartnerID(c *gin.Context) (uuid.UUID, bool) {
+	partnerID, err := uuid.Parse(c.Param("partner_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid partner ID", nil)
+		return uuid.Nil, false
+	}
+	return partnerID, true
+}
+
+func (h *PartnerHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case repository.ErrPartnerNotFound, repository.ErrTenantNotFound, services.ErrInvalidReferralCode:
+		response.NotFound(c, err.Error())
+	case repository.ErrReferralCodeExists, repository.ErrPartnerUserExists, repository.ErrTenantAlreadyReferred:
+		response.Conflict(c, err.Error())
+	case services.ErrInvalidPartner:
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
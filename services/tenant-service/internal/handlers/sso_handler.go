@@ -7,11 +7,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/bookkeep/go-shared/response"
 	"github.com/bookkeep/tenant-service/internal/models"
 	"github.com/bookkeep/tenant-service/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
 )
 
 // SSOHandler handles Enterprise SSO configuration
@@ -19,6 +20,10 @@ type SSOHandler struct {
 	ssoRepo     repository.SSORepository
 	roleRepo    repository.RoleRepository
 	encryption  EncryptionService
+
+	// stepUpMaxAge is how recently an owner must have entered their
+	// password or MFA code to disable SSO
+	stepUpMaxAge time.Duration
 }
 
 // EncryptionService interface for secret encryption
@@ -27,11 +32,12 @@ type EncryptionService interface {
 	Decrypt(ciphertext string, tenantID uuid.UUID) (string, error)
 }
 
-func NewSSOHandler(ssoRepo repository.SSORepository, roleRepo repository.RoleRepository, encryption EncryptionService) *SSOHandler {
+func NewSSOHandler(ssoRepo repository.SSORepository, roleRepo repository.RoleRepository, encryption EncryptionService, stepUpMaxAge time.Duration) *SSOHandler {
 	return &SSOHandler{
-		ssoRepo:    ssoRepo,
-		roleRepo:   roleRepo,
-		encryption: encryption,
+		ssoRepo:      ssoRepo,
+		roleRepo:     roleRepo,
+		encryption:   encryption,
+		stepUpMaxAge: stepUpMaxAge,
 	}
 }
 
@@ -395,6 +401,13 @@ func (h *SSOHandler) DisableSSO(c *gin.Context) {
 		return
 	}
 
+	// Turning SSO off reopens password logins, so the owner confirms who
+	// they are first
+	if !middleware.RecentlyAuthenticated(c, h.stepUpMaxAge) {
+		middleware.AbortStepUpRequired(c, h.stepUpMaxAge)
+		return
+	}
+
 	config, err := h.ssoRepo.GetByTenantID(c.Request.Context(), tenantID.(uuid.UUID))
 	if err != nil {
 		if err == repository.ErrSSOConfigNotFound {
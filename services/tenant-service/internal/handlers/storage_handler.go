@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+type StorageHandler struct {
+	storageService services.StorageService
+}
+
+func NewStorageHandler(storageService services.StorageService) *StorageHandler {
+	return &StorageHandler{storageService: storageService}
+}
+
+// GetUsage returns the tenant's document storage use against its quota
+// @Summary Get storage usage
+// @Tags Storage
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} storage.Usage
+// @Router /tenants/{id}/storage [get]
+func (h *StorageHandler) GetUsage(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	usage, err := h.storageService.GetUsage(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		response.InternalError(c, "Failed to get storage usage")
+		return
+	}
+
+	response.Success(c, usage)
+}
+
+// LargestDocuments lists the tenant's largest documents
+// @Summary List largest documents
+// @Tags Storage
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param limit query int false "Number of documents (default 20, max 100)"
+// @Success 200 {array} storage.Document
+// @Router /tenants/{id}/storage/largest [get]
+func (h *StorageHandler) LargestDocuments(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		response.BadRequest(c, "limit must be between 1 and 100", nil)
+		return
+	}
+
+	documents, err := h.storageService.LargestDocuments(c.Request.Context(), tenantID.(uuid.UUID), limit)
+	if err != nil {
+		response.InternalError(c, "Failed to list documents")
+		return
+	}
+
+	response.Success(c, documents)
+}
@@ -3,12 +3,12 @@ package handlers
 import (
 	"net/http"
 
-	"github.com/bookkeep/go-shared/response"
 	"github.com/bookkeep/tenant-service/internal/models"
 	"github.com/bookkeep/tenant-service/internal/repository"
 	"github.com/bookkeep/tenant-service/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
 )
 
 type TenantHandler struct {
@@ -83,6 +83,35 @@ func (h *TenantHandler) GetTenant(c *gin.Context) {
 	response.Success(c, tenant)
 }
 
+// GetMessagingPreferences returns the phone channels a tenant's invoices,
+// reminders and login codes go out on, for the services sending them
+// (public)
+// @Summary Get tenant messaging preferences
+// @Tags Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} messaging.Preferences
+// @Router /tenants/{id}/messaging-preferences [get]
+func (h *TenantHandler) GetMessagingPreferences(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID", nil)
+		return
+	}
+
+	tenant, err := h.tenantService.GetTenant(c.Request.Context(), tenantID)
+	if err != nil {
+		if err == repository.ErrTenantNotFound {
+			response.NotFound(c, "Tenant not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, tenant.MessagingPreferences())
+}
+
 // UpdateTenant updates a tenant
 // @Summary Update tenant details
 // @Tags Tenants
@@ -110,23 +139,6 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	response.Success(c, tenant)
 }
 
-// DeleteTenant soft-deletes a tenant
-// @Summary Delete a tenant
-// @Tags Tenants
-// @Param id path string true "Tenant ID"
-// @Success 204
-// @Router /tenants/{id} [delete]
-func (h *TenantHandler) DeleteTenant(c *gin.Context) {
-	tenantID, _ := c.Get("tenant_id")
-
-	if err := h.tenantService.DeleteTenant(c.Request.Context(), tenantID.(uuid.UUID)); err != nil {
-		response.BadRequest(c, err.Error(), nil)
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
-
 // GetMyTenants retrieves all tenants for the current user
 // @Summary Get user's tenants
 // @Tags Tenants
@@ -166,6 +178,26 @@ func (h *TenantHandler) ListMembers(c *gin.Context) {
 	response.Success(c, members)
 }
 
+// ListOwners lists the tenant's owners. Any member may read it, so other
+// services can alert owners on the member's behalf.
+// @Summary List tenant owners
+// @Tags Team
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {array} models.TenantMember
+// @Router /tenants/{id}/owners [get]
+func (h *TenantHandler) ListOwners(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	owners, err := h.tenantService.ListOwners(c.Request.Context(), tenantID.(uuid.UUID))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, owners)
+}
+
 // InviteMember invites a new member to the tenant
 // @Summary Invite a team member
 // @Tags Team
@@ -368,9 +400,13 @@ func (h *TenantHandler) GetAllPermissions(c *gin.Context) {
 // @Router /tenants/{id}/permissions/me [get]
 func (h *TenantHandler) GetMyPermissions(c *gin.Context) {
 	tenantID, _ := c.Get("tenant_id")
-	userID, _ := c.Get("user_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
 
-	permissions, err := h.tenantService.GetUserPermissions(c.Request.Context(), tenantID.(uuid.UUID), userID.(uuid.UUID))
+	permissions, err := h.tenantService.GetUserPermissions(c.Request.Context(), tenantID.(uuid.UUID), userID)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/bookkeep/tenant-service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/response"
+)
+
+type ValidationRuleHandler struct {
+	validationRuleService services.ValidationRuleService
+}
+
+func NewValidationRuleHandler(validationRuleService services.ValidationRuleService) *ValidationRuleHandler {
+	return &ValidationRuleHandler{validationRuleService: validationRuleService}
+}
+
+// GetCatalog lists the documents and fields validation rules can be set for
+// @Summary List fields validation rules can check
+// @Tags Validation Rules
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {array} services.ValidationCatalog
+// @Router /tenants/{id}/validation-rules/catalog [get]
+func (h *ValidationRuleHandler) GetCatalog(c *gin.Context) {
+	response.Success(c, h.validationRuleService.Catalog())
+}
+
+// ListRules returns the tenant's validation rules. Other services read the
+// active ones with the user's token to check documents as they are saved.
+// @Summary List validation rules
+// @Tags Validation Rules
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param active query bool false "Only active rules"
+// @Success 200 {array} validation.Rule
+// @Router /tenants/{id}/validation-rules [get]
+func (h *ValidationRuleHandler) ListRules(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+
+	rules, err := h.validationRuleService.ListRules(c.Request.Context(), tenantID.(uuid.UUID), c.Query("active") == "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// GetRule returns a validation rule
+// @Summary Get validation rule
+// @Tags Validation Rules
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param rule_id path string true "Rule ID"
+// @Success 200 {object} models.TenantValidationRule
+// @Router /tenants/{id}/validation-rules/{rule_id} [get]
+func (h *ValidationRuleHandler) GetRule(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID", nil)
+		return
+	}
+
+	rule, err := h.validationRuleService.GetRule(c.Request.Context(), tenantID.(uuid.UUID), ruleID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// CreateRule adds a validation rule
+// @Summary Create validation rule
+// @Tags Validation Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param request body services.ValidationRuleRequest true "Validation rule"
+// @Success 201 {object} models.TenantValidationRule
+// @Router /tenants/{id}/validation-rules [post]
+func (h *ValidationRuleHandler) CreateRule(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+
+	var req services.ValidationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	rule, err := h.validationRuleService.CreateRule(c.Request.Context(), tenantID.(uuid.UUID), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, rule)
+}
+
+// UpdateRule replaces a validation rule
+// @Summary Update validation rule
+// @Tags Validation Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param rule_id path string true "Rule ID"
+// @Param request body services.ValidationRuleRequest true "Validation rule"
+// @Success 200 {object} models.TenantValidationRule
+// @Router /tenants/{id}/validation-rules/{rule_id} [put]
+func (h *ValidationRuleHandler) UpdateRule(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID", nil)
+		return
+	}
+
+	var req services.ValidationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", nil)
+		return
+	}
+
+	rule, err := h.validationRuleService.UpdateRule(c.Request.Context(), tenantID.(uuid.UUID), ruleID, userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// DeleteRule removes a validation rule
+// @Summary Delete validation rule
+// @Tags Validation Rules
+// @Param id path string true "Tenant ID"
+// @Param rule_id path string true "Rule ID"
+// @Success 204
+// @Router /tenants/{id}/validation-rules/{rule_id} [delete]
+func (h *ValidationRuleHandler) DeleteRule(c *gin.Context) {
+	tenantID, _ := c.Get("tenant_id")
+	userID, ok := getUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Invalid user ID")
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID", nil)
+		return
+	}
+
+	if err := h.validationRuleService.DeleteRule(c.Request.Context(), tenantID.(uuid.UUID), ruleID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+func (h *ValidationRuleHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrValidationRuleNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidValidationRule):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantStatusStaging marks a tenant created by restoring a backup. Staging
+// tenants are for checking a restore and are not meant to be used.
+const TenantStatusStaging = "staging"
+
+// BackupRetention is how long a backup can be restored from
+const BackupRetention = 35 * 24 * time.Hour
+
+// TenantBackup is a logical backup of one tenant: a zip with a JSON Lines
+// file per table holding the tenant's rows, in the data export format. Each
+// completed backup is a point the tenant can be restored to. Backups and
+// restores move through the data export statuses.
+type TenantBackup struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null" json:"requested_by"`
+	Note        string     `gorm:"size:255" json:"note,omitempty"`
+	Status      string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	FileName    string     `gorm:"size:255" json:"file_name,omitempty"`
+	Size        int64      `gorm:"default:0" json:"size"`
+	Checksum    string     `gorm:"size:64" json:"checksum,omitempty"` // SHA-256 of the zip
+	Tables      int        `gorm:"default:0" json:"tables"`
+	Rows        int64      `gorm:"default:0" json:"rows"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	Content     []byte     `gorm:"type:bytea" json:"-"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // The point in time the backup restores to
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // The content is purged after this
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TenantBackup) TableName() string {
+	return "tenant_backups"
+}
+
+// Restorable reports whether the backup completed and has not expired
+func (b *TenantBackup) Restorable(now time.Time) bool {
+	return b.Status == ExportStatusCompleted && b.ExpiresAt != nil && now.Before(*b.ExpiresAt)
+}
+
+// TenantRestore restores a backup into a new staging tenant, so a recovery
+// can be checked without touching the tenant that was backed up or any
+// other tenant
+type TenantRestore struct {
+	ID              uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BackupID        uuid.UUID     `gorm:"type:uuid;not null;index" json:"backup_id"`
+	SourceTenantID  uuid.UUID     `gorm:"type:uuid;not null;index" json:"source_tenant_id"`
+	StagingTenantID *uuid.UUID    `gorm:"type:uuid" json:"staging_tenant_id,omitempty"`
+	RequestedBy     uuid.UUID     `gorm:"type:uuid;not null" json:"requested_by"`
+	Status          string        `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Rows            int64         `gorm:"default:0" json:"rows"`
+	Verified        bool          `gorm:"default:false" json:"verified"` // Every backed up row was restored
+	Tables          RestoreTables `gorm:"type:jsonb" json:"tables"`
+	Error           string        `gorm:"type:text" json:"error,omitempty"`
+	StartedAt       *time.Time    `json:"started_at,omitempty"`
+	CompletedAt     *time.Time    `json:"completed_at,omitempty"`
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TenantRestore) TableName() string {
+	return "tenant_restores"
+}
+
+// RestoreTable is how one table of a backup was restored
+type RestoreTable struct {
+	Table    string `json:"table"`
+	BackedUp int64  `json:"backed_up"`
+	Restored int64  `json:"restored"`
+	Skipped  string `json:"skipped,omitempty"` // Why the table was not restored
+}
+
+// RestoreTables is the per-table outcome of a restore. Stored as JSONB.
+type RestoreTables []RestoreTable
+
+// Value implements driver.Valuer
+func (t RestoreTables) Value() (driver.Value, error) {
+	if t == nil {
+		return json.Marshal([]RestoreTable{})
+	}
+	return json.Marshal([]RestoreTable(t))
+}
+
+// Scan implements sql.Scanner
+func (t *RestoreTables) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for RestoreTables")
+	}
+	return json.Unmarshal(data, t)
+}
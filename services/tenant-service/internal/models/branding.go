@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantBranding holds a tenant's white-label settings, used on invoice
+// PDFs, the customer portal and outgoing emails
+type TenantBranding struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primary_key" json:"tenant_id"`
+
+	// Colors as #rrggbb
+	PrimaryColor   string `gorm:"size:7" json:"primary_color"`
+	SecondaryColor string `gorm:"size:7" json:"secondary_color"`
+	AccentColor    string `gorm:"size:7" json:"accent_color"`
+
+	// Custom domain for the customer portal. It is only served once the
+	// tenant proves ownership by publishing the verification token in a
+	// DNS TXT record, and a verified domain belongs to one tenant.
+	CustomDomain            *string    `gorm:"size:255;uniqueIndex:idx_branding_verified_domain,where:domain_verified_at IS NOT NULL" json:"custom_domain"`
+	DomainVerificationToken string     `gorm:"size:64" json:"domain_verification_token,omitempty"`
+	DomainVerifiedAt        *time.Time `json:"domain_verified_at"`
+
+	// Outgoing email
+	EmailSenderName string  `gorm:"size:100" json:"email_sender_name"`
+	EmailReplyTo    *string `gorm:"size:255" json:"email_reply_to"`
+
+	// Invoice PDFs: the template they are laid out with, the text printed
+	// at their bottom, and the terms printed on invoices that carry none
+	InvoiceTemplate string `gorm:"size:20" json:"invoice_template"`
+	InvoiceFooter   string `gorm:"type:text" json:"invoice_footer"`
+	InvoiceTerms    string `gorm:"type:text" json:"invoice_terms"`
+
+	// Logo metadata; the image itself is in TenantLogo
+	LogoContentType string     `gorm:"size:100" json:"logo_content_type,omitempty"`
+	LogoUpdatedAt   *time.Time `json:"logo_updated_at"`
+	LogoURL         string     `gorm:"-" json:"logo_url"`
+
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (TenantBranding) TableName() string {
+	return "tenant_brandings"
+}
+
+// DomainVerified reports whether the custom domain has been verified
+func (b *TenantBranding) DomainVerified() bool {
+	return b.CustomDomain != nil && b.DomainVerifiedAt != nil
+}
+
+// StorageKindLogo identifies tenant logos in storage usage
+const StorageKindLogo = "tenant_logo"
+
+// TenantLogo is a tenant's uploaded logo, kept apart from TenantBranding so
+// reading the settings does not load the image
+type TenantLogo struct {
+	TenantID    uuid.UUID `gorm:"type:uuid;primary_key" json:"tenant_id"`
+	ContentType string    `gorm:"size:100;not null" json:"content_type"`
+	Content     []byte    `gorm:"type:bytea;not null" json:"-"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (TenantLogo) TableName() string {
+	return "tenant_logos"
+}
+
+// PublicBranding is the part of a tenant's branding shown to its customers,
+// served without authentication to the portal and email renderer
+type PublicBranding struct {
+	TenantID        uuid.UUID `json:"tenant_id"`
+	Name            string    `json:"name"`
+	LogoURL         string    `json:"logo_url,omitempty"`
+	PrimaryColor    string    `json:"primary_color,omitempty"`
+	SecondaryColor  string    `json:"secondary_color,omitempty"`
+	AccentColor     string    `json:"accent_color,omitempty"`
+	EmailSenderName string    `json:"email_sender_name,omitempty"`
+	InvoiceTemplate string    `json:"invoice_template,omitempty"`
+	InvoiceFooter   string    `json:"invoice_footer,omitempty"`
+	InvoiceTerms    string    `json:"invoice_terms,omitempty"`
+}
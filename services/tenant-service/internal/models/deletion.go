@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Data export statuses
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// Tenant deletion statuses
+const (
+	DeletionStatusScheduled = "scheduled"
+	DeletionStatusCancelled = "cancelled"
+	DeletionStatusCompleted = "completed"
+)
+
+const (
+	// DeletionCoolingOff is how long a scheduled deletion waits, and can be
+	// cancelled, before it is carried out
+	DeletionCoolingOff = 30 * 24 * time.Hour
+
+	// ExportValidity is how recent a completed export must be for deletion
+	// to be scheduled, and how long it can be downloaded
+	ExportValidity = 7 * 24 * time.Hour
+
+	// RecordRetentionYears is how long books of account and GST records are
+	// kept after a tenant is deleted: eight years under section 128 of the
+	// Companies Act, which covers the 72 months of section 36 of the CGST Act
+	RecordRetentionYears = 8
+)
+
+// TenantDataExport is a full copy of a tenant's data: a zip with a JSON
+// Lines file per table. Owners must take one before deleting the tenant.
+type TenantDataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null" json:"requested_by"`
+	Status      string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	FileName    string     `gorm:"size:255" json:"file_name,omitempty"`
+	Size        int64      `gorm:"default:0" json:"size"`
+	Tables      int        `gorm:"default:0" json:"tables"`
+	Rows        int64      `gorm:"default:0" json:"rows"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	Content     []byte     `gorm:"type:bytea" json:"-"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // The content is purged after this
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TenantDataExport) TableName() string {
+	return "tenant_data_exports"
+}
+
+// Available reports whether the export completed and can still be
+// downloaded
+func (e *TenantDataExport) Available(now time.Time) bool {
+	return e.Status == ExportStatusCompleted && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// TenantDeletion is an owner's request to delete a tenant. It is carried out
+// once the cooling-off period has passed unless cancelled: personal data is
+// anonymized and the tenant closed, while financial records are kept until
+// RetainUntil as the law requires.
+type TenantDeletion struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	RequestedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"requested_by"`
+	ExportID     uuid.UUID  `gorm:"type:uuid;not null" json:"export_id"` // The export taken before deleting
+	Reason       *string    `gorm:"type:text" json:"reason"`
+	Status       string     `gorm:"size:20;not null;default:'scheduled';index" json:"status"`
+	ScheduledFor time.Time  `gorm:"not null;index" json:"scheduled_for"`
+	CancelledBy  *uuid.UUID `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	RetainUntil  *time.Time `json:"retain_until,omitempty"` // Financial records may be purged after this
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TenantDeletion) TableName() string {
+	return "tenant_deletions"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TenantGroup links the tenants of a business group for consolidated
+// reporting. The parent tenant (usually the holding company) owns the group
+// and manages its members.
+type TenantGroup struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name           string    `gorm:"size:255;not null" json:"name"`
+	ParentTenantID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"parent_tenant_id"`
+	Description    *string   `gorm:"type:text" json:"description"`
+	CreatedBy      uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+
+	// Timestamps
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Associations
+	Members []TenantGroupMember `gorm:"foreignKey:GroupID" json:"members,omitempty"`
+}
+
+func (TenantGroup) TableName() string {
+	return "tenant_groups"
+}
+
+// BeforeCreate hook
+func (g *TenantGroup) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
+// TenantGroupMember is a tenant belonging to a group. A tenant can be in one
+// group at a time.
+type TenantGroupMember struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	GroupID   uuid.UUID `gorm:"type:uuid;not null;index" json:"group_id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"tenant_id"`
+	AddedBy   uuid.UUID `gorm:"type:uuid;not null" json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Associations
+	Tenant Tenant `gorm:"foreignKey:TenantID" json:"tenant,omitempty"`
+}
+
+func (TenantGroupMember) TableName() string {
+	return "tenant_group_members"
+}
+
+// BeforeCreate hook
+func (m *TenantGroupMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
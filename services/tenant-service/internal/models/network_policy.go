@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+)
+
+// NetworkBypassDuration is how long an owner's emergency bypass of the
+// network policy lasts
+const NetworkBypassDuration = time.Hour
+
+// Audit log actions of network policies
+const (
+	AuditNetworkPolicyUpdate  = "network_policy:update"
+	AuditNetworkPolicyDelete  = "network_policy:delete"
+	AuditNetworkBypass        = "network_policy:bypass"
+	AuditNetworkBypassRevoke  = "network_policy:bypass_revoke"
+	AuditNetworkAccessBlocked = "network_policy:blocked"
+)
+
+// TenantNetworkPolicy restricts the IP ranges and countries the tenant's
+// users may connect from, enforced by every service's auth middleware
+type TenantNetworkPolicy struct {
+	ID               uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID         uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex" json:"tenant_id"`
+	AllowedCIDRs     pq.StringArray `gorm:"type:text[];default:'{}'" json:"allowed_cidrs"`
+	DeniedCIDRs      pq.StringArray `gorm:"type:text[];default:'{}'" json:"denied_cidrs"`
+	AllowedCountries pq.StringArray `gorm:"type:text[];default:'{}'" json:"allowed_countries"`
+	DeniedCountries  pq.StringArray `gorm:"type:text[];default:'{}'" json:"denied_countries"`
+
+	// An owner locked out by the policy may bypass it for a short while
+	// to correct it
+	BypassUserID *uuid.UUID `gorm:"type:uuid" json:"bypass_user_id,omitempty"`
+	BypassUntil  *time.Time `json:"bypass_until,omitempty"`
+
+	UpdatedBy uuid.UUID `gorm:"type:uuid;not null" json:"updated_by"`
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TenantNetworkPolicy) TableName() string {
+	return "tenant_network_policies"
+}
+
+// Rules returns the policy in the form the auth middleware enforces
+func (p *TenantNetworkPolicy) Rules() *middleware.NetworkPolicy {
+	rules := &middleware.NetworkPolicy{
+		AllowedCIDRs:     p.AllowedCIDRs,
+		DeniedCIDRs:      p.DeniedCIDRs,
+		AllowedCountries: p.AllowedCountries,
+		DeniedCountries:  p.DeniedCountries,
+		BypassUntil:      p.BypassUntil,
+	}
+	if p.BypassUserID != nil {
+		rules.BypassUserID = p.BypassUserID.String()
+	}
+	return rules
+}
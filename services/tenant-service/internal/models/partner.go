@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of partner who onboard clients
+const (
+	PartnerTypeCA       = "ca"
+	PartnerTypeReseller = "reseller"
+)
+
+// PlanMonthlyPrice is the monthly price of each plan in paise, on which
+// partner commissions are worked out
+var PlanMonthlyPrice = map[string]int64{
+	"free":         0,
+	"starter":      49900,
+	"professional": 149900,
+	"enterprise":   499900,
+}
+
+// Partner is a chartered accountant or reseller who brings clients to the
+// platform. Tenants that sign up with the partner's referral code are
+// attributed to them, and earn them a share of the tenant's plan price for
+// CommissionMonths after signing up.
+type Partner struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name         string     `gorm:"size:255;not null" json:"name"`
+	Type         string     `gorm:"size:20;not null" json:"type"` // ca or reseller
+	Email        string     `gorm:"size:255;not null" json:"email"`
+	Phone        string     `gorm:"size:20" json:"phone"`
+	UserID       *uuid.UUID `gorm:"type:uuid;uniqueIndex" json:"user_id,omitempty"` // Account that sees the partner dashboard
+	ReferralCode string     `gorm:"size:20;not null;uniqueIndex" json:"referral_code"`
+
+	// Commission on referred tenants' plan price, for a number of months
+	// after each signs up; zero months pays for as long as the tenant stays
+	CommissionPercent float64 `gorm:"type:decimal(5,2);not null" json:"commission_percent"`
+	CommissionMonths  int     `gorm:"default:0" json:"commission_months"`
+
+	Status    string    `gorm:"size:20;default:'active'" json:"status"` // active or inactive
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+
+	// Timestamps
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Partner) TableName() string {
+	return "partners"
+}
+
+// BeforeCreate hook
+func (p *Partner) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive reports whether the partner's referral code is accepted
+func (p *Partner) IsActive() bool {
+	return p.Status == "active"
+}
+
+// TenantReferral attributes a tenant to the partner who referred it. A
+// tenant is attributed to one partner only.
+type TenantReferral struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"tenant_id"`
+	PartnerID    uuid.UUID `gorm:"type:uuid;not null;index" json:"partner_id"`
+	ReferralCode string    `gorm:"size:20;not null" json:"referral_code"`
+	Source       string    `gorm:"size:20;not null" json:"source"` // signup, or admin when attributed later
+	AttributedBy uuid.UUID `gorm:"type:uuid;not null" json:"attributed_by"`
+	AttributedAt time.Time `gorm:"not null" json:"attributed_at"`
+
+	// Associations
+	Tenant Tenant `gorm:"foreignKey:TenantID" json:"tenant,omitempty"`
+}
+
+func (TenantReferral) TableName() string {
+	return "tenant_referrals"
+}
+
+// BeforeCreate hook
+func (r *TenantReferral) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
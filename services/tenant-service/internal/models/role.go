@@ -13,6 +13,14 @@ const (
 	PermDashboardView        = "dashboard:view"
 	PermReportsView          = "reports:view"
 	PermReportsExport        = "reports:export"
+	// Sales-side reports only (dashboard, revenue, receivables), for roles
+	// without reports:view
+	PermReportsSales         = "reports:sales"
+	// COGS, expenses and profit figures; without it they are masked
+	PermReportsProfitability = "reports:profitability"
+	// Bulk exports and downloads of the tenant's data, in any service.
+	// Each one is recorded in the audit log.
+	PermDataExport           = "data:export"
 
 	// Transactions
 	PermTransactionView      = "transaction:view"
@@ -20,6 +28,8 @@ const (
 	PermTransactionEdit      = "transaction:edit"
 	PermTransactionDelete    = "transaction:delete"
 	PermTransactionApprove   = "transaction:approve"
+	// Reopen a closed accounting period
+	PermPeriodReopen         = "period:reopen"
 
 	// Invoices
 	PermInvoiceView          = "invoice:view"
@@ -73,8 +83,8 @@ const (
 // AllPermissions returns all available permissions in the system
 func AllPermissions() []string {
 	return []string{
-		PermDashboardView, PermReportsView, PermReportsExport,
-		PermTransactionView, PermTransactionCreate, PermTransactionEdit, PermTransactionDelete, PermTransactionApprove,
+		PermDashboardView, PermReportsView, PermReportsExport, PermReportsSales, PermReportsProfitability, PermDataExport,
+		PermTransactionView, PermTransactionCreate, PermTransactionEdit, PermTransactionDelete, PermTransactionApprove, PermPeriodReopen,
 		PermInvoiceView, PermInvoiceCreate, PermInvoiceEdit, PermInvoiceDelete, PermInvoiceSend, PermInvoiceVoid,
 		PermPartyView, PermPartyCreate, PermPartyEdit, PermPartyDelete,
 		PermProductView, PermProductCreate, PermProductEdit, PermProductDelete,
@@ -168,7 +178,7 @@ func DefaultRoles() []Role {
 		},
 		{
 			Name:        "Staff",
-			Description: strPtr("Can create transactions and invoices. Limited editing rights."),
+			Description: strPtr("Can create transactions and invoices and see sales reports. Limited editing rights."),
 			IsSystem:    true,
 			IsDefault:   true,
 		},
@@ -186,8 +196,8 @@ func DefaultRolePermissions() map[string][]string {
 	return map[string][]string{
 		"Owner": AllPermissions(),
 		"Admin": {
-			PermDashboardView, PermReportsView, PermReportsExport,
-			PermTransactionView, PermTransactionCreate, PermTransactionEdit, PermTransactionDelete, PermTransactionApprove,
+			PermDashboardView, PermReportsView, PermReportsExport, PermReportsProfitability, PermDataExport,
+			PermTransactionView, PermTransactionCreate, PermTransactionEdit, PermTransactionDelete, PermTransactionApprove, PermPeriodReopen,
 			PermInvoiceView, PermInvoiceCreate, PermInvoiceEdit, PermInvoiceDelete, PermInvoiceSend, PermInvoiceVoid,
 			PermPartyView, PermPartyCreate, PermPartyEdit, PermPartyDelete,
 			PermProductView, PermProductCreate, PermProductEdit, PermProductDelete,
@@ -198,7 +208,7 @@ func DefaultRolePermissions() map[string][]string {
 			PermTenantView, PermTenantEdit,
 		},
 		"Accountant": {
-			PermDashboardView, PermReportsView, PermReportsExport,
+			PermDashboardView, PermReportsView, PermReportsExport, PermReportsProfitability, PermDataExport,
 			PermTransactionView, PermTransactionCreate, PermTransactionEdit, PermTransactionApprove,
 			PermInvoiceView, PermInvoiceCreate, PermInvoiceEdit, PermInvoiceSend,
 			PermPartyView, PermPartyCreate, PermPartyEdit,
@@ -209,7 +219,7 @@ func DefaultRolePermissions() map[string][]string {
 			PermTenantView,
 		},
 		"Staff": {
-			PermDashboardView,
+			PermDashboardView, PermReportsSales,
 			PermTransactionView, PermTransactionCreate,
 			PermInvoiceView, PermInvoiceCreate,
 			PermPartyView, PermPartyCreate,
@@ -218,7 +228,7 @@ func DefaultRolePermissions() map[string][]string {
 			PermTenantView,
 		},
 		"Viewer": {
-			PermDashboardView, PermReportsView,
+			PermDashboardView, PermReportsView, PermReportsProfitability,
 			PermTransactionView,
 			PermInvoiceView,
 			PermPartyView,
@@ -264,3 +274,7 @@ type AuditLog struct {
 func (AuditLog) TableName() string {
 	return "audit_logs"
 }
+
+// AuditDataExport is the audit log action of a bulk export or download of
+// the tenant's data, made or refused in any service
+const AuditDataExport = "data:export"
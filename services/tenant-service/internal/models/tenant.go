@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/messaging"
 	"gorm.io/gorm"
 )
 
@@ -36,6 +38,13 @@ type Tenant struct {
 	FinancialYearStart int     `gorm:"default:4" json:"financial_year_start"` // Month (1-12), default April
 	Currency           string  `gorm:"size:3;default:'INR'" json:"currency"`
 	DateFormat         string  `gorm:"size:20;default:'DD/MM/YYYY'" json:"date_format"`
+	Language           string  `gorm:"size:5;default:'en'" json:"language"` // Default for API messages and documents
+
+	// Amount formatting in PDFs, reports and exports; empty or nil
+	// settings use the currency's defaults
+	CurrencySymbol     string  `gorm:"size:10" json:"currency_symbol"`
+	DecimalPlaces      *int    `json:"decimal_places"`
+	NumberGrouping     string  `gorm:"size:20;default:'indian'" json:"number_grouping"` // indian (1,00,000) or international (100,000)
 
 	// Invoice Settings
 	InvoicePrefix      string  `gorm:"size:20;default:'INV'" json:"invoice_prefix"`
@@ -48,6 +57,14 @@ type Tenant struct {
 	BankAccountNumber  *string `gorm:"size:50" json:"bank_account_number"`
 	BankIFSC           *string `gorm:"size:11" json:"bank_ifsc"`
 	BankBranch         *string `gorm:"size:255" json:"bank_branch"`
+	UPIID              *string `gorm:"size:100" json:"upi_id"` // VPA customers pay to by QR code, e.g. business@okaxis
+
+	// Messaging: the phone channels (sms, whatsapp) invoices and payment
+	// reminders go out on besides email, and the one members' login codes
+	// are sent on
+	InvoiceChannels    pq.StringArray `gorm:"type:text[];default:'{}'" json:"invoice_channels"`
+	ReminderChannels   pq.StringArray `gorm:"type:text[];default:'{}'" json:"reminder_channels"`
+	OTPChannel         string  `gorm:"size:20;default:'sms'" json:"otp_channel"`
 
 	// Subscription & Limits
 	Plan               string  `gorm:"size:50;default:'free'" json:"plan"`
@@ -58,7 +75,7 @@ type Tenant struct {
 	Status      string         `gorm:"size:20;default:'active'" json:"status"` // active, suspended, deleted
 	VerifiedAt  *time.Time     `json:"verified_at"`
 
-	// Logo
+	// Logo, kept in step with TenantBranding for older readers
 	LogoURL     *string        `gorm:"size:512" json:"logo_url"`
 
 	// Timestamps
@@ -75,6 +92,27 @@ func (Tenant) TableName() string {
 	return "tenants"
 }
 
+// MessagingPreferences returns the channels the tenant's messages go out
+// on, as other services read them
+func (t *Tenant) MessagingPreferences() *messaging.Preferences {
+	preferences := &messaging.Preferences{
+		TenantName:       t.Name,
+		InvoiceChannels:  []string(t.InvoiceChannels),
+		ReminderChannels: []string(t.ReminderChannels),
+		OTPChannel:       t.OTPChannel,
+	}
+	if preferences.InvoiceChannels == nil {
+		preferences.InvoiceChannels = []string{}
+	}
+	if preferences.ReminderChannels == nil {
+		preferences.ReminderChannels = []string{}
+	}
+	if preferences.OTPChannel == "" {
+		preferences.OTPChannel = messaging.ChannelSMS
+	}
+	return preferences
+}
+
 // TenantMember represents a user's membership in a tenant with their role
 type TenantMember struct {
 	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+)
+
+// Audit log actions of validation rules
+const (
+	AuditValidationRuleCreate = "validation_rule:create"
+	AuditValidationRuleUpdate = "validation_rule:update"
+	AuditValidationRuleDelete = "validation_rule:delete"
+)
+
+// TenantValidationRule is a rule the tenant sets for a field of its
+// documents, such as a narration on every journal, checked by the service
+// that keeps the document when it is saved
+type TenantValidationRule struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Document string    `gorm:"size:30;not null" json:"document"` // invoice, bill, journal
+	Field    string    `gorm:"size:60;not null" json:"field"`
+	Check    string    `gorm:"size:30;not null" json:"check"`
+
+	// The rule applies only to documents of at least this total; zero for
+	// all
+	MinAmount float64 `gorm:"type:decimal(15,2);default:0" json:"min_amount"`
+
+	// Message and MessageKey replace the default message and the key the
+	// UI translates it by
+	Message    string `gorm:"size:255" json:"message,omitempty"`
+	MessageKey string `gorm:"size:100" json:"message_key,omitempty"`
+
+	Active bool `gorm:"default:true" json:"active"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	UpdatedBy uuid.UUID `gorm:"type:uuid;not null" json:"updated_by"`
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TenantValidationRule) TableName() string {
+	return "tenant_validation_rules"
+}
+
+// Rule returns the rule in the form the services check documents against
+func (r *TenantValidationRule) Rule() validation.Rule {
+	return validation.Rule{
+		ID:         r.ID.String(),
+		Document:   r.Document,
+		Field:      r.Field,
+		Check:      r.Check,
+		MinAmount:  r.MinAmount,
+		Message:    r.Message,
+		MessageKey: r.MessageKey,
+		Active:     r.Active,
+	}
+}
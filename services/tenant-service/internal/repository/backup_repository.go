@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBackupNotFound  = errors.New("backup not found")
+	ErrRestoreNotFound = errors.New("restore not found")
+)
+
+// TableRows are the rows of one table to restore, each a JSON object keyed
+// by column
+type TableRows struct {
+	Table string
+	Rows  [][]byte
+}
+
+type BackupRepository interface {
+	CreateBackup(ctx context.Context, backup *models.TenantBackup) error
+	UpdateBackup(ctx context.Context, backup *models.TenantBackup) error
+
+	// GetBackup and ListBackups leave out the backup file; GetBackupFile
+	// includes it
+	GetBackup(ctx context.Context, id uuid.UUID) (*models.TenantBackup, error)
+	GetBackupFile(ctx context.Context, id uuid.UUID) (*models.TenantBackup, error)
+	ListBackups(ctx context.Context, tenantID uuid.UUID) ([]models.TenantBackup, error)
+
+	// ListRestorePoints returns the tenant's completed backups that have not
+	// expired, latest first
+	ListRestorePoints(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]models.TenantBackup, error)
+
+	CreateRestore(ctx context.Context, restore *models.TenantRestore) error
+	UpdateRestore(ctx context.Context, restore *models.TenantRestore) error
+	GetRestore(ctx context.Context, id uuid.UUID) (*models.TenantRestore, error)
+	ListRestores(ctx context.Context, tenantID uuid.UUID) ([]models.TenantRestore, error)
+
+	// FailInterrupted fails backups and restores a restart cut off
+	FailInterrupted(ctx context.Context) error
+
+	// PurgeExpired drops the files of backups past their expiry
+	PurgeExpired(ctx context.Context, now time.Time) error
+
+	// Restore inserts rows into their tables in one transaction. The first
+	// table must hold the tenant the rest belong to; the restore fails if
+	// it can't be inserted. Other tables that can't be restored, because
+	// they no longer exist or their rows are refused, are reported as
+	// skipped.
+	Restore(ctx context.Context, tables []TableRows) (models.RestoreTables, error)
+}
+
+type backupRepository struct {
+	db *gorm.DB
+}
+
+func NewBackupRepository(db *gorm.DB) BackupRepository {
+	return &backupRepository{db: db}
+}
+
+// Backups
+
+func (r *backupRepository) CreateBackup(ctx context.Context, backup *models.TenantBackup) error {
+	return r.db.WithContext(ctx).Create(backup).Error
+}
+
+func (r *backupRepository) UpdateBackup(ctx context.Context, backup *models.TenantBackup) error {
+	return r.db.WithContext(ctx).Save(backup).Error
+}
+
+func (r *backupRepository) GetBackup(ctx context.Context, id uuid.UUID) (*models.TenantBackup, error) {
+	var backup models.TenantBackup
+	err := r.db.WithContext(ctx).Omit("content").First(&backup, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (r *backupRepository) GetBackupFile(ctx context.Context, id uuid.UUID) (*models.TenantBackup, error) {
+	var backup models.TenantBackup
+	err := r.db.WithContext(ctx).First(&backup, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (r *backupRepository) ListBackups(ctx context.Context, tenantID uuid.UUID) ([]models.TenantBackup, error) {
+	var backups []models.TenantBackup
+	err := r.db.WithContext(ctx).
+		Omit("content").
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&backups).Error
+	return backups, err
+}
+
+func (r *backupRepository) ListRestorePoints(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]models.TenantBackup, error) {
+	var backups []models.TenantBackup
+	err := r.db.WithContext(ctx).
+		Omit("content").
+		Where("tenant_id = ? AND status = ? AND expires_at > ?", tenantID, models.ExportStatusCompleted, now).
+		Order("completed_at DESC").
+		Find(&backups).Error
+	return backups, err
+}
+
+// Restores
+
+func (r *backupRepository) CreateRestore(ctx context.Context, restore *models.TenantRestore) error {
+	return r.db.WithContext(ctx).Create(restore).Error
+}
+
+func (r *backupRepository) UpdateRestore(ctx context.Context, restore *models.TenantRestore) error {
+	return r.db.WithContext(ctx).Save(restore).Error
+}
+
+func (r *backupRepository) GetRestore(ctx context.Context, id uuid.UUID) (*models.TenantRestore, error) {
+	var restore models.TenantRestore
+	err := r.db.WithContext(ctx).First(&restore, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRestoreNotFound
+		}
+		return nil, err
+	}
+	return &restore, nil
+}
+
+func (r *backupRepository) ListRestores(ctx context.Context, tenantID uuid.UUID) ([]models.TenantRestore, error) {
+	var restores []models.TenantRestore
+	err := r.db.WithContext(ctx).
+		Where("source_tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&restores).Error
+	return restores, err
+}
+
+func (r *backupRepository) FailInterrupted(ctx context.Context) error {
+	running := []string{models.ExportStatusPending, models.ExportStatusRunning}
+	failed := map[string]interface{}{
+		"status": models.ExportStatusFailed,
+		"error":  "interrupted by a restart, please try again",
+	}
+	err := r.db.WithContext(ctx).Model(&models.TenantBackup{}).Where("status IN ?", running).Updates(failed).Error
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Model(&models.TenantRestore{}).Where("status IN ?", running).Updates(failed).Error
+}
+
+func (r *backupRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.TenantBackup{}).
+		Where("expires_at < ? AND content IS NOT NULL", now).
+		Update("content", nil).Error
+}
+
+func (r *backupRepository) Restore(ctx context.Context, tables []TableRows) (models.RestoreTables, error) {
+	if len(tables) == 0 {
+		return nil, errors.New("nothing to restore")
+	}
+
+	result := make(models.RestoreTables, len(tables))
+	for i, table := range tables {
+		result[i] = models.RestoreTable{Table: table.Table, BackedUp: int64(len(table.Rows))}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		restored, err := insertRows(tx, tables[0])
+		if err != nil {
+			return fmt.Errorf("restoring %s: %w", tables[0].Table, err)
+		}
+		result[0].Restored = restored
+
+		var pending []int
+		for i := 1; i < len(tables); i++ {
+			var exists bool
+			if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", tables[i].Table).Scan(&exists).Error; err != nil {
+				return err
+			}
+			if !exists {
+				result[i].Skipped = "table no longer exists"
+				continue
+			}
+			pending = append(pending, i)
+		}
+
+		// Foreign keys between the tables make the order matter, so tables
+		// whose rows are refused are tried again until a pass restores none
+		for len(pending) > 0 {
+			var failed []int
+			for _, i := range pending {
+				savepoint := fmt.Sprintf("restore_table_%d", i)
+				if err := tx.SavePoint(savepoint).Error; err != nil {
+					return err
+				}
+				restored, err := insertRows(tx, tables[i])
+				if err != nil {
+					if err := tx.RollbackTo(savepoint).Error; err != nil {
+						return err
+					}
+					result[i].Skipped = err.Error()
+					failed = append(failed, i)
+					continue
+				}
+				result[i].Restored = restored
+				result[i].Skipped = ""
+			}
+			if len(failed) == len(pending) {
+				break
+			}
+			pending = failed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// insertRows inserts JSON rows into a table, leaving out keys that are not
+// columns of it
+func insertRows(tx *gorm.DB, table TableRows) (int64, error) {
+	insert := "INSERT INTO " + quoteIdent(table.Table) +
+		" SELECT * FROM json_populate_record(NULL::" + quoteIdent(table.Table) + ", ?::json)"
+
+	var count int64
+	for _, row := range table.Rows {
+		if err := tx.Exec(insert, string(row)).Error; err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
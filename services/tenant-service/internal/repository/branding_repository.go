@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrBrandingNotFound = errors.New("branding not found")
+	ErrLogoNotFound     = errors.New("logo not found")
+	ErrDomainTaken      = errors.New("custom domain is already in use by another tenant")
+)
+
+type BrandingRepository interface {
+	Get(ctx context.Context, tenantID uuid.UUID) (*models.TenantBranding, error)
+	// GetByDomain returns the branding with the given verified custom domain
+	GetByDomain(ctx context.Context, domain string) (*models.TenantBranding, error)
+	Save(ctx context.Context, branding *models.TenantBranding) error
+
+	// MarkDomainVerified verifies the branding's custom domain, failing with
+	// ErrDomainTaken if another tenant has already verified it
+	MarkDomainVerified(ctx context.Context, branding *models.TenantBranding) error
+
+	GetLogo(ctx context.Context, tenantID uuid.UUID) (*models.TenantLogo, error)
+	// SaveLogo stores the logo and the branding that now refers to it
+	SaveLogo(ctx context.Context, logo *models.TenantLogo, branding *models.TenantBranding) error
+	// DeleteLogo removes the logo and saves the branding without it
+	DeleteLogo(ctx context.Context, branding *models.TenantBranding) error
+
+	// RecordStorage counts logos uploaded before storage accounting against
+	// their tenants' usage
+	RecordStorage(ctx context.Context) error
+}
+
+type brandingRepository struct {
+	db *gorm.DB
+}
+
+func NewBrandingRepository(db *gorm.DB) BrandingRepository {
+	return &brandingRepository{db: db}
+}
+
+func (r *brandingRepository) Get(ctx context.Context, tenantID uuid.UUID) (*models.TenantBranding, error) {
+	var branding models.TenantBranding
+	err := r.db.WithContext(ctx).First(&branding, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBrandingNotFound
+		}
+		return nil, err
+	}
+	return &branding, nil
+}
+
+func (r *brandingRepository) GetByDomain(ctx context.Context, domain string) (*models.TenantBranding, error) {
+	var branding models.TenantBranding
+	err := r.db.WithContext(ctx).
+		Where("custom_domain = ? AND domain_verified_at IS NOT NULL", domain).
+		First(&branding).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBrandingNotFound
+		}
+		return nil, err
+	}
+	return &branding, nil
+}
+
+func (r *brandingRepository) Save(ctx context.Context, branding *models.TenantBranding) error {
+	return r.db.WithContext(ctx).Save(branding).Error
+}
+
+func (r *brandingRepository) MarkDomainVerified(ctx context.Context, branding *models.TenantBranding) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.TenantBranding{}).
+			Where("custom_domain = ? AND domain_verified_at IS NOT NULL AND tenant_id <> ?", branding.CustomDomain, branding.TenantID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDomainTaken
+		}
+		return tx.Save(branding).Error
+	})
+}
+
+func (r *brandingRepository) GetLogo(ctx context.Context, tenantID uuid.UUID) (*models.TenantLogo, error) {
+	var logo models.TenantLogo
+	err := r.db.WithContext(ctx).First(&logo, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLogoNotFound
+		}
+		return nil, err
+	}
+	return &logo, nil
+}
+
+func (r *brandingRepository) SaveLogo(ctx context.Context, logo *models.TenantLogo, branding *models.TenantBranding) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"content_type", "content", "updated_at"}),
+		}).Create(logo).Error
+		if err != nil {
+			return err
+		}
+		err = storage.Record(tx, &storage.Document{
+			TenantID:    logo.TenantID,
+			Kind:        models.StorageKindLogo,
+			RefID:       logo.TenantID,
+			FileName:    "logo",
+			ContentType: logo.ContentType,
+			Size:        int64(len(logo.Content)),
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Save(branding).Error
+	})
+}
+
+func (r *brandingRepository) DeleteLogo(ctx context.Context, branding *models.TenantBranding) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.TenantLogo{}, "tenant_id = ?", branding.TenantID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLogoNotFound
+		}
+		if err := storage.Remove(tx, models.StorageKindLogo, branding.TenantID); err != nil {
+			return err
+		}
+		return tx.Save(branding).Error
+	})
+}
+
+func (r *brandingRepository) RecordStorage(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO stored_documents (id, tenant_id, kind, ref_id, file_name, content_type, size, created_at)
+		SELECT gen_random_uuid(), tenant_id, ?, tenant_id, 'logo', content_type, octet_length(content), updated_at
+		FROM tenant_logos
+		ON CONFLICT (kind, ref_id) DO NOTHING`, models.StorageKindLogo).Error
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrExportNotFound   = errors.New("data export not found")
+	ErrDeletionNotFound = errors.New("no deletion is scheduled for this tenant")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+// exportExcludedTables are tenant tables left out of data exports and
+// backups: earlier exports and backups and the internal job queue
+var exportExcludedTables = map[string]bool{
+	"tenant_data_exports": true,
+	"tenant_backups":      true,
+	"jobs":                true,
+}
+
+type DeletionRepository interface {
+	CreateExport(ctx context.Context, export *models.TenantDataExport) error
+	UpdateExport(ctx context.Context, export *models.TenantDataExport) error
+
+	// GetExport and ListExports leave out the export file; GetExportFile
+	// includes it
+	GetExport(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error)
+	GetExportFile(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error)
+	ListExports(ctx context.Context, tenantID uuid.UUID) ([]models.TenantDataExport, error)
+
+	// FailInterrupted fails exports a restart cut off, so they don't show
+	// as running forever
+	FailInterrupted(ctx context.Context) error
+
+	// PurgeExpiredExports drops the files of exports past their expiry
+	PurgeExpiredExports(ctx context.Context, now time.Time) error
+
+	// ListTenantTables returns the tables holding tenant data: the tenants
+	// table and every table with a tenant_id column
+	ListTenantTables(ctx context.Context) ([]string, error)
+
+	// ExportRows calls fn with each of the tenant's rows of a table as JSON
+	// and returns how many there were
+	ExportRows(ctx context.Context, table string, tenantID uuid.UUID, fn func(row []byte) error) (int64, error)
+
+	CreateDeletion(ctx context.Context, deletion *models.TenantDeletion) error
+	UpdateDeletion(ctx context.Context, deletion *models.TenantDeletion) error
+	GetScheduledDeletion(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error)
+
+	// ListDueDeletions returns the scheduled deletions whose cooling-off
+	// period has ended
+	ListDueDeletions(ctx context.Context, now time.Time) ([]models.TenantDeletion, error)
+
+	// Anonymize carries out a scheduled deletion in one transaction. It
+	// fails with ErrDeletionNotFound when the deletion was cancelled or
+	// carried out meanwhile.
+	Anonymize(ctx context.Context, deletion *models.TenantDeletion, now time.Time) error
+
+	// GetPasswordHash returns the user's password hash, for re-authentication
+	GetPasswordHash(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+type deletionRepository struct {
+	db *gorm.DB
+}
+
+func NewDeletionRepository(db *gorm.DB) DeletionRepository {
+	return &deletionRepository{db: db}
+}
+
+// Data exports
+
+func (r *deletionRepository) CreateExport(ctx context.Context, export *models.TenantDataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *deletionRepository) UpdateExport(ctx context.Context, export *models.TenantDataExport) error {
+	return r.db.WithContext(ctx).Save(export).Error
+}
+
+func (r *deletionRepository) GetExport(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error) {
+	var export models.TenantDataExport
+	err := r.db.WithContext(ctx).
+		Omit("content").
+		First(&export, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *deletionRepository) GetExportFile(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error) {
+	var export models.TenantDataExport
+	err := r.db.WithContext(ctx).First(&export, "tenant_id = ? AND id = ?", tenantID, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *deletionRepository) ListExports(ctx context.Context, tenantID uuid.UUID) ([]models.TenantDataExport, error) {
+	var exports []models.TenantDataExport
+	err := r.db.WithContext(ctx).
+		Omit("content").
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *deletionRepository) FailInterrupted(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Model(&models.TenantDataExport{}).
+		Where("status IN ?", []string{models.ExportStatusPending, models.ExportStatusRunning}).
+		Updates(map[string]interface{}{
+			"status": models.ExportStatusFailed,
+			"error":  "interrupted by a restart, please export again",
+		}).Error
+}
+
+func (r *deletionRepository) PurgeExpiredExports(ctx context.Context, now time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.TenantDataExport{}).
+		Where("expires_at < ? AND content IS NOT NULL", now).
+		Update("content", nil).Error
+}
+
+func (r *deletionRepository) ListTenantTables(ctx context.Context) ([]string, error) {
+	var tables []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT table_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND column_name = 'tenant_id'
+		ORDER BY table_name`).Scan(&tables).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{"tenants"}
+	for _, table := range tables {
+		if !exportExcludedTables[table] {
+			result = append(result, table)
+		}
+	}
+	return result, nil
+}
+
+func (r *deletionRepository) ExportRows(ctx context.Context, table string, tenantID uuid.UUID, fn func(row []byte) error) (int64, error) {
+	column := "tenant_id"
+	if table == "tenants" {
+		column = "id"
+	}
+
+	rows, err := r.db.WithContext(ctx).
+		Raw("SELECT row_to_json(t)::text FROM "+quoteIdent(table)+" t WHERE t."+column+" = ?", tenantID).
+		Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return count, err
+		}
+		if err := fn(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// Deletions
+
+func (r *deletionRepository) CreateDeletion(ctx context.Context, deletion *models.TenantDeletion) error {
+	return r.db.WithContext(ctx).Create(deletion).Error
+}
+
+func (r *deletionRepository) UpdateDeletion(ctx context.Context, deletion *models.TenantDeletion) error {
+	return r.db.WithContext(ctx).Save(deletion).Error
+}
+
+func (r *deletionRepository) GetScheduledDeletion(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error) {
+	var deletion models.TenantDeletion
+	err := r.db.WithContext(ctx).
+		First(&deletion, "tenant_id = ? AND status = ?", tenantID, models.DeletionStatusScheduled).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeletionNotFound
+		}
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r *deletionRepository) ListDueDeletions(ctx context.Context, now time.Time) ([]models.TenantDeletion, error) {
+	var deletions []models.TenantDeletion
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND scheduled_for <= ?", models.DeletionStatusScheduled, now).
+		Order("scheduled_for").
+		Find(&deletions).Error
+	return deletions, err
+}
+
+// anonymizeSteps remove or blank the tenant's personal and configuration
+// data. Books of account, GST records, invoices, bills and the audit trail
+// are left as they are: they must be kept for the retention period. Tables
+// owned by other services are skipped when absent.
+var anonymizeSteps = []struct {
+	table string
+	sql   string
+}{
+	// Team members keep their user IDs so the audit trail still resolves
+	{"tenant_members", `UPDATE tenant_members SET email = '', phone = '', first_name = 'Deleted', last_name = 'member',
+		status = 'inactive', deleted_at = COALESCE(deleted_at, @now) WHERE tenant_id = @tenant`},
+	{"tenant_invitations", `DELETE FROM tenant_invitations WHERE tenant_id = @tenant`},
+	{"tenant_group_members", `DELETE FROM tenant_group_members WHERE tenant_id = @tenant
+		OR group_id IN (SELECT id FROM tenant_groups WHERE parent_tenant_id = @tenant)`},
+	{"tenant_groups", `UPDATE tenant_groups SET deleted_at = @now WHERE parent_tenant_id = @tenant AND deleted_at IS NULL`},
+	{"tenant_brandings", `DELETE FROM tenant_brandings WHERE tenant_id = @tenant`},
+	{"tenant_logos", `DELETE FROM tenant_logos WHERE tenant_id = @tenant`},
+	{"stored_documents", `DELETE FROM stored_documents WHERE tenant_id = @tenant AND kind = 'tenant_logo'`},
+	{"tenant_sso_configs", `DELETE FROM tenant_sso_configs WHERE tenant_id = @tenant`},
+	{"sso_group_mappings", `DELETE FROM sso_group_mappings WHERE tenant_id = @tenant`},
+	{"sso_login_attempts", `DELETE FROM sso_login_attempts WHERE tenant_id = @tenant`},
+	{"keycloak_configs", `DELETE FROM keycloak_configs WHERE tenant_id = @tenant`},
+
+	// Customers and vendors keep the name, GSTIN and address their invoices
+	// and bills were issued under; contact details go
+	{"party_contacts", `DELETE FROM party_contacts WHERE party_id IN (SELECT id FROM parties WHERE tenant_id = @tenant)`},
+	{"party_bank_details", `DELETE FROM party_bank_details WHERE party_id IN (SELECT id FROM parties WHERE tenant_id = @tenant)`},
+	{"parties", `UPDATE parties SET email = '', phone = '', alternate_phone = '', notes = '' WHERE tenant_id = @tenant`},
+
+	{"tenant_data_exports", `UPDATE tenant_data_exports SET content = NULL WHERE tenant_id = @tenant`},
+
+	// The tenant keeps the identity its books were kept under
+	{"tenants", `UPDATE tenants SET status = 'deleted', email = '', phone = '', website = NULL, logo_url = NULL,
+		bank_name = NULL, bank_account_number = NULL, bank_ifsc = NULL, bank_branch = NULL, upi_id = NULL,
+		deleted_at = COALESCE(deleted_at, @now) WHERE id = @tenant`},
+}
+
+func (r *deletionRepository) Anonymize(ctx context.Context, deletion *models.TenantDeletion, now time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the deletion so it can't be cancelled or carried out twice
+		// meanwhile
+		var current models.TenantDeletion
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", deletion.ID).Error
+		if err != nil {
+			return err
+		}
+		if current.Status != models.DeletionStatusScheduled {
+			return ErrDeletionNotFound
+		}
+
+		params := map[string]interface{}{"tenant": deletion.TenantID, "now": now}
+		for _, step := range anonymizeSteps {
+			var exists bool
+			if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", step.table).Scan(&exists).Error; err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			if err := tx.Exec(step.sql, params).Error; err != nil {
+				return err
+			}
+		}
+
+		retainUntil := now.AddDate(models.RecordRetentionYears, 0, 0)
+		deletion.Status = models.DeletionStatusCompleted
+		deletion.CompletedAt = &now
+		deletion.RetainUntil = &retainUntil
+		return tx.Save(deletion).Error
+	})
+}
+
+func (r *deletionRepository) GetPasswordHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	var hashes []string
+	err := r.db.WithContext(ctx).
+		Raw("SELECT password_hash FROM users WHERE id = ? AND deleted_at IS NULL", userID).
+		Scan(&hashes).Error
+	if err != nil {
+		return "", err
+	}
+	if len(hashes) == 0 {
+		return "", ErrUserNotFound
+	}
+	return hashes[0], nil
+}
+
+// quoteIdent quotes a table name for use in SQL
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
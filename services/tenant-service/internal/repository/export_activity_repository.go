@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportActivityFilters narrows the exports in the tenant's audit log
+type ExportActivityFilters struct {
+	UserID    *uuid.UUID
+	Resource  string
+	StartDate string
+	EndDate   string
+	Limit     int
+	Offset    int
+}
+
+// ExportActivityTotal counts a user's exports of a resource, with the rows
+// exported where the exporting service counted them
+type ExportActivityTotal struct {
+	UserID   uuid.UUID
+	Resource string
+	Status   string
+	Exports  int64
+	Rows     int64
+}
+
+// ExportActivityRepository reads the exports recorded in audit logs
+type ExportActivityRepository interface {
+	List(ctx context.Context, tenantID uuid.UUID, filters ExportActivityFilters) ([]models.AuditLog, int64, error)
+	Totals(ctx context.Context, tenantID uuid.UUID, filters ExportActivityFilters) ([]ExportActivityTotal, error)
+}
+
+type exportActivityRepository struct {
+	db *gorm.DB
+}
+
+func NewExportActivityRepository(db *gorm.DB) ExportActivityRepository {
+	return &exportActivityRepository{db: db}
+}
+
+func (r *exportActivityRepository) List(ctx context.Context, tenantID uuid.UUID, filters ExportActivityFilters) ([]models.AuditLog, int64, error) {
+	var logs []models.AuditLog
+	var total int64
+
+	query := r.filtered(ctx, tenantID, filters)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&logs).Error
+	return logs, total, err
+}
+
+func (r *exportActivityRepository) Totals(ctx context.Context, tenantID uuid.UUID, filters ExportActivityFilters) ([]ExportActivityTotal, error) {
+	var totals []ExportActivityTotal
+	err := r.filtered(ctx, tenantID, filters).
+		Select("user_id, resource, status, COUNT(*) AS exports, COALESCE(SUM((new_value->>'rows')::bigint), 0) AS rows").
+		Group("user_id, resource, status").
+		Order("exports DESC").
+		Scan(&totals).Error
+	return totals, err
+}
+
+func (r *exportActivityRepository) filtered(ctx context.Context, tenantID uuid.UUID, filters ExportActivityFilters) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{}).
+		Where("tenant_id = ? AND action = ?", tenantID, models.AuditDataExport)
+
+	if filters.UserID != nil {
+		query = query.Where("user_id = ?", *filters.UserID)
+	}
+	if filters.Resource != "" {
+		query = query.Where("resource = ?", filters.Resource)
+	}
+	if filters.StartDate != "" {
+		query = query.Where("created_at >= ?", filters.StartDate)
+	}
+	if filters.EndDate != "" {
+		query = query.Where("created_at < (?::date + 1)", filters.EndDate)
+	}
+	return query
+}
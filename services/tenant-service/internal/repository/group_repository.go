@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGroupNotFound       = errors.New("tenant group not found")
+	ErrGroupMemberNotFound = errors.New("tenant is not a member of the group")
+	ErrTenantInGroup       = errors.New("tenant already belongs to a group")
+)
+
+type GroupRepository interface {
+	// Create stores the group with its parent tenant as the first member
+	Create(ctx context.Context, group *models.TenantGroup) error
+	GetByParentTenant(ctx context.Context, parentTenantID uuid.UUID) (*models.TenantGroup, error)
+	GetByMemberTenant(ctx context.Context, tenantID uuid.UUID) (*models.TenantGroup, error)
+	Update(ctx context.Context, group *models.TenantGroup) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	AddMember(ctx context.Context, member *models.TenantGroupMember) error
+	RemoveMember(ctx context.Context, groupID, tenantID uuid.UUID) error
+}
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) GroupRepository {
+	return &groupRepository{db: db}
+}
+
+func (r *groupRepository) Create(ctx context.Context, group *models.TenantGroup) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.ensureUngrouped(tx, group.ParentTenantID); err != nil {
+			return err
+		}
+		if err := tx.Omit("Members").Create(group).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TenantGroupMember{
+			GroupID:  group.ID,
+			TenantID: group.ParentTenantID,
+			AddedBy:  group.CreatedBy,
+		}).Error
+	})
+}
+
+func (r *groupRepository) GetByParentTenant(ctx context.Context, parentTenantID uuid.UUID) (*models.TenantGroup, error) {
+	var group models.TenantGroup
+	err := r.db.WithContext(ctx).
+		Preload("Members.Tenant").
+		First(&group, "parent_tenant_id = ?", parentTenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *groupRepository) GetByMemberTenant(ctx context.Context, tenantID uuid.UUID) (*models.TenantGroup, error) {
+	var member models.TenantGroupMember
+	err := r.db.WithContext(ctx).First(&member, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+
+	var group models.TenantGroup
+	err = r.db.WithContext(ctx).
+		Preload("Members.Tenant").
+		First(&group, "id = ?", member.GroupID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *groupRepository) Update(ctx context.Context, group *models.TenantGroup) error {
+	return r.db.WithContext(ctx).Omit("Members").Save(group).Error
+}
+
+func (r *groupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.TenantGroupMember{}, "group_id = ?", id).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.TenantGroup{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrGroupNotFound
+		}
+		return nil
+	})
+}
+
+func (r *groupRepository) AddMember(ctx context.Context, member *models.TenantGroupMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.ensureUngrouped(tx, member.TenantID); err != nil {
+			return err
+		}
+		return tx.Create(member).Error
+	})
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, tenantID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.TenantGroupMember{},
+		"group_id = ? AND tenant_id = ?", groupID, tenantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGroupMemberNotFound
+	}
+	return nil
+}
+
+func (r *groupRepository) ensureUngrouped(tx *gorm.DB, tenantID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.TenantGroupMember{}).
+		Where("tenant_id = ?", tenantID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTenantInGroup
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrNetworkPolicyNotFound = errors.New("network policy not found")
+
+type NetworkPolicyRepository interface {
+	Get(ctx context.Context, tenantID uuid.UUID) (*models.TenantNetworkPolicy, error)
+	Save(ctx context.Context, policy *models.TenantNetworkPolicy) error
+	Delete(ctx context.Context, tenantID uuid.UUID) error
+}
+
+type networkPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewNetworkPolicyRepository(db *gorm.DB) NetworkPolicyRepository {
+	return &networkPolicyRepository{db: db}
+}
+
+func (r *networkPolicyRepository) Get(ctx context.Context, tenantID uuid.UUID) (*models.TenantNetworkPolicy, error) {
+	var policy models.TenantNetworkPolicy
+	err := r.db.WithContext(ctx).First(&policy, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNetworkPolicyNotFound
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *networkPolicyRepository) Save(ctx context.Context, policy *models.TenantNetworkPolicy) error {
+	return r.db.WithContext(ctx).Save(policy).Error
+}
+
+func (r *networkPolicyRepository) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.TenantNetworkPolicy{}, "tenant_id = ?", tenantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNetworkPolicyNotFound
+	}
+	return nil
+}
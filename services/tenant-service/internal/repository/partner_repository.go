@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPartnerNotFound       = errors.New("partner not found")
+	ErrReferralCodeExists    = errors.New("referral code is already in use")
+	ErrPartnerUserExists     = errors.New("user already has a partner account")
+	ErrTenantAlreadyReferred = errors.New("tenant is already attributed to a partner")
+)
+
+type PartnerRepository interface {
+	Create(ctx context.Context, partner *models.Partner) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Partner, error)
+	GetByReferralCode(ctx context.Context, code string) (*models.Partner, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Partner, error)
+	List(ctx context.Context) ([]models.Partner, error)
+	Update(ctx context.Context, partner *models.Partner) error
+
+	// CreateReferral attributes a tenant to a partner, unless it already
+	// is to one
+	CreateReferral(ctx context.Context, referral *models.TenantReferral) error
+
+	// ListReferrals returns the partner's referred tenants, deleted ones
+	// included, latest first
+	ListReferrals(ctx context.Context, partnerID uuid.UUID) ([]models.TenantReferral, error)
+}
+
+type partnerRepository struct {
+	db *gorm.DB
+}
+
+func NewPartnerRepository(db *gorm.DB) PartnerRepository {
+	return &partnerRepository{db: db}
+}
+
+func (r *partnerRepository) Create(ctx context.Context, partner *models.Partner) error {
+	if err := r.ensureUnique(ctx, partner); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(partner).Error
+}
+
+func (r *partnerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Partner, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+func (r *partnerRepository) GetByReferralCode(ctx context.Context, code string) (*models.Partner, error) {
+	return r.get(ctx, "referral_code = ?", code)
+}
+
+func (r *partnerRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Partner, error) {
+	return r.get(ctx, "user_id = ?", userID)
+}
+
+func (r *partnerRepository) get(ctx context.Context, query string, args ...interface{}) (*models.Partner, error) {
+	var partner models.Partner
+	err := r.db.WithContext(ctx).Where(query, args...).First(&partner).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPartnerNotFound
+		}
+		return nil, err
+	}
+	return &partner, nil
+}
+
+func (r *partnerRepository) List(ctx context.Context) ([]models.Partner, error) {
+	var partners []models.Partner
+	err := r.db.WithContext(ctx).Order("name").Find(&partners).Error
+	return partners, err
+}
+
+func (r *partnerRepository) Update(ctx context.Context, partner *models.Partner) error {
+	if err := r.ensureUnique(ctx, partner); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Save(partner).Error
+}
+
+// ensureUnique checks no other partner has the partner's referral code or
+// user
+func (r *partnerRepository) ensureUnique(ctx context.Context, partner *models.Partner) error {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Partner{}).
+		Where("referral_code = ? AND id <> ?", partner.ReferralCode, partner.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrReferralCodeExists
+	}
+
+	if partner.UserID == nil {
+		return nil
+	}
+	err = r.db.WithContext(ctx).Unscoped().Model(&models.Partner{}).
+		Where("user_id = ? AND id <> ?", *partner.UserID, partner.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPartnerUserExists
+	}
+	return nil
+}
+
+func (r *partnerRepository) CreateReferral(ctx context.Context, referral *models.TenantReferral) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.TenantReferral{}).
+			Where("tenant_id = ?", referral.TenantID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTenantAlreadyReferred
+		}
+		return tx.Omit("Tenant").Create(referral).Error
+	})
+}
+
+func (r *partnerRepository) ListReferrals(ctx context.Context, partnerID uuid.UUID) ([]models.TenantReferral, error) {
+	var referrals []models.TenantReferral
+	err := r.db.WithContext(ctx).
+		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Where("partner_id = ?", partnerID).
+		Order("attributed_at DESC").
+		Find(&referrals).Error
+	return referrals, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrValidationRuleNotFound = errors.New("validation rule not found")
+
+type ValidationRuleRepository interface {
+	Create(ctx context.Context, rule *models.TenantValidationRule) error
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantValidationRule, error)
+	// List returns the tenant's rules by document and field, only the
+	// active ones if activeOnly is set
+	List(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]models.TenantValidationRule, error)
+	Update(ctx context.Context, rule *models.TenantValidationRule) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+}
+
+type validationRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewValidationRuleRepository(db *gorm.DB) ValidationRuleRepository {
+	return &validationRuleRepository{db: db}
+}
+
+func (r *validationRuleRepository) Create(ctx context.Context, rule *models.TenantValidationRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *validationRuleRepository) Get(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantValidationRule, error) {
+	var rule models.TenantValidationRule
+	err := r.db.WithContext(ctx).First(&rule, "id = ? AND tenant_id = ?", id, tenantID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrValidationRuleNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *validationRuleRepository) List(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]models.TenantValidationRule, error) {
+	var rules []models.TenantValidationRule
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	err := query.Order("document, field, min_amount").Find(&rules).Error
+	return rules, err
+}
+
+func (r *validationRuleRepository) Update(ctx context.Context, rule *models.TenantValidationRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *validationRuleRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.TenantValidationRule{}, "id = ? AND tenant_id = ?", id, tenantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrValidationRuleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBackupInProgress  = errors.New("a backup of this tenant is already in progress")
+	ErrBackupUnavailable = errors.New("backup is not complete or has expired")
+)
+
+// restoreSkippedTables are not restored into staging tenants: they would let
+// people sign in to the staging tenant, invite them to it or join it to
+// another tenant's group
+var restoreSkippedTables = map[string]bool{
+	"tenant_invitations":   true,
+	"tenant_group_members": true,
+	"tenant_sso_configs":   true,
+	"sso_group_mappings":   true,
+	"sso_login_attempts":   true,
+	"keycloak_configs":     true,
+	"tenant_deletions":     true,
+}
+
+// restoreOverrides are columns set on restored rows, per table, so the
+// staging tenant is not mistaken for the real one
+var restoreOverrides = map[string]map[string]interface{}{
+	"tenants":          {"status": models.TenantStatusStaging},
+	"tenant_members":   {"status": "inactive"},
+	"tenant_brandings": {"custom_domain": nil, "domain_verified_at": nil},
+}
+
+// StartBackupRequest is an admin's request to back up a tenant
+type StartBackupRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+// BackupService takes logical backups of single tenants and restores them
+// into staging tenants to check they can be recovered. It is for platform
+// admins.
+type BackupService interface {
+	// StartBackup backs up all of the tenant's data in the background
+	StartBackup(ctx context.Context, tenantID, userID uuid.UUID, req StartBackupRequest) (*models.TenantBackup, error)
+	GetBackup(ctx context.Context, id uuid.UUID) (*models.TenantBackup, error)
+	ListBackups(ctx context.Context, tenantID uuid.UUID) ([]models.TenantBackup, error)
+
+	// ListRestorePoints lists the backups the tenant can be restored from
+	ListRestorePoints(ctx context.Context, tenantID uuid.UUID) ([]models.TenantBackup, error)
+
+	// StartRestore restores a backup into a new staging tenant in the
+	// background. Every row gets a new ID, so the backed up tenant and all
+	// other tenants are left untouched.
+	StartRestore(ctx context.Context, backupID, userID uuid.UUID) (*models.TenantRestore, error)
+	GetRestore(ctx context.Context, id uuid.UUID) (*models.TenantRestore, error)
+	ListRestores(ctx context.Context, tenantID uuid.UUID) ([]models.TenantRestore, error)
+
+	// PurgeExpired drops the files of expired backups
+	PurgeExpired(ctx context.Context) error
+}
+
+type backupService struct {
+	backupRepo   repository.BackupRepository
+	deletionRepo repository.DeletionRepository
+	tenantRepo   repository.TenantRepository
+}
+
+func NewBackupService(backupRepo repository.BackupRepository, deletionRepo repository.DeletionRepository, tenantRepo repository.TenantRepository) BackupService {
+	return &backupService{
+		backupRepo:   backupRepo,
+		deletionRepo: deletionRepo,
+		tenantRepo:   tenantRepo,
+	}
+}
+
+// Backups
+
+func (s *backupService) StartBackup(ctx context.Context, tenantID, userID uuid.UUID, req StartBackupRequest) (*models.TenantBackup, error) {
+	if _, err := s.tenantRepo.GetByID(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	backups, err := s.backupRepo.ListBackups(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups {
+		if backup.Status == models.ExportStatusPending || backup.Status == models.ExportStatusRunning {
+			return nil, ErrBackupInProgress
+		}
+	}
+
+	backup := &models.TenantBackup{
+		TenantID:    tenantID,
+		RequestedBy: userID,
+		Note:        req.Note,
+		Status:      models.ExportStatusPending,
+	}
+	if err := s.backupRepo.CreateBackup(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	// The backup outlives the request that started it
+	go s.runBackup(context.Background(), *backup)
+
+	return backup, nil
+}
+
+func (s *backupService) GetBackup(ctx context.Context, id uuid.UUID) (*models.TenantBackup, error) {
+	return s.backupRepo.GetBackup(ctx, id)
+}
+
+func (s *backupService) ListBackups(ctx context.Context, tenantID uuid.UUID) ([]models.TenantBackup, error) {
+	return s.backupRepo.ListBackups(ctx, tenantID)
+}
+
+func (s *backupService) ListRestorePoints(ctx context.Context, tenantID uuid.UUID) ([]models.TenantBackup, error) {
+	return s.backupRepo.ListRestorePoints(ctx, tenantID, time.Now())
+}
+
+func (s *backupService) runBackup(ctx context.Context, backup models.TenantBackup) {
+	started := time.Now()
+	backup.Status = models.ExportStatusRunning
+	backup.StartedAt = &started
+	if err := s.backupRepo.UpdateBackup(ctx, &backup); err != nil {
+		log.Printf("Failed to start backup %s: %v", backup.ID, err)
+		return
+	}
+
+	content, manifest, err := writeTenantData(ctx, s.deletionRepo, s.tenantRepo, backup.TenantID)
+	if err != nil {
+		backup.Status = models.ExportStatusFailed
+		backup.Error = err.Error()
+		if err := s.backupRepo.UpdateBackup(ctx, &backup); err != nil {
+			log.Printf("Failed to record backup %s failure: %v", backup.ID, err)
+		}
+		return
+	}
+
+	completed := time.Now()
+	expires := completed.Add(models.BackupRetention)
+	checksum := sha256.Sum256(content)
+	backup.Status = models.ExportStatusCompleted
+	backup.FileName = fmt.Sprintf("backup-%s-%s.zip", backup.TenantID, completed.Format("20060102150405"))
+	backup.Content = content
+	backup.Size = int64(len(content))
+	backup.Checksum = hex.EncodeToString(checksum[:])
+	backup.Tables = len(manifest.Tables)
+	for _, rows := range manifest.Tables {
+		backup.Rows += rows
+	}
+	backup.CompletedAt = &completed
+	backup.ExpiresAt = &expires
+	if err := s.backupRepo.UpdateBackup(ctx, &backup); err != nil {
+		log.Printf("Failed to save backup %s: %v", backup.ID, err)
+	}
+}
+
+// Restores
+
+func (s *backupService) StartRestore(ctx context.Context, backupID, userID uuid.UUID) (*models.TenantRestore, error) {
+	backup, err := s.backupRepo.GetBackup(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+	if !backup.Restorable(time.Now()) {
+		return nil, ErrBackupUnavailable
+	}
+
+	restore := &models.TenantRestore{
+		BackupID:       backup.ID,
+		SourceTenantID: backup.TenantID,
+		RequestedBy:    userID,
+		Status:         models.ExportStatusPending,
+	}
+	if err := s.backupRepo.CreateRestore(ctx, restore); err != nil {
+		return nil, err
+	}
+
+	go s.runRestore(context.Background(), *restore)
+
+	return restore, nil
+}
+
+func (s *backupService) GetRestore(ctx context.Context, id uuid.UUID) (*models.TenantRestore, error) {
+	return s.backupRepo.GetRestore(ctx, id)
+}
+
+func (s *backupService) ListRestores(ctx context.Context, tenantID uuid.UUID) ([]models.TenantRestore, error) {
+	return s.backupRepo.ListRestores(ctx, tenantID)
+}
+
+func (s *backupService) runRestore(ctx context.Context, restore models.TenantRestore) {
+	started := time.Now()
+	restore.Status = models.ExportStatusRunning
+	restore.StartedAt = &started
+	if err := s.backupRepo.UpdateRestore(ctx, &restore); err != nil {
+		log.Printf("Failed to start restore %s: %v", restore.ID, err)
+		return
+	}
+
+	if err := s.restore(ctx, &restore); err != nil {
+		restore.Status = models.ExportStatusFailed
+		restore.Error = err.Error()
+		restore.StagingTenantID = nil
+		if err := s.backupRepo.UpdateRestore(ctx, &restore); err != nil {
+			log.Printf("Failed to record restore %s failure: %v", restore.ID, err)
+		}
+		return
+	}
+
+	completed := time.Now()
+	restore.Status = models.ExportStatusCompleted
+	restore.CompletedAt = &completed
+	if err := s.backupRepo.UpdateRestore(ctx, &restore); err != nil {
+		log.Printf("Failed to save restore %s: %v", restore.ID, err)
+	}
+}
+
+// restore reads the backup, gives every row a new ID and inserts the rows
+// as a new staging tenant
+func (s *backupService) restore(ctx context.Context, restore *models.TenantRestore) error {
+	backup, err := s.backupRepo.GetBackupFile(ctx, restore.BackupID)
+	if err != nil {
+		return err
+	}
+	if !backup.Restorable(time.Now()) || len(backup.Content) == 0 {
+		return ErrBackupUnavailable
+	}
+	checksum := sha256.Sum256(backup.Content)
+	if hex.EncodeToString(checksum[:]) != backup.Checksum {
+		return errors.New("backup file does not match its checksum")
+	}
+
+	tables, err := readTenantData(backup.Content)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 || tables[0].table != "tenants" || len(tables[0].rows) != 1 {
+		return errors.New("backup does not hold the tenant")
+	}
+
+	// New IDs for every row, so references between the tenant's rows follow
+	// them while references to anything outside the tenant stay as they are
+	ids := make(map[string]string)
+	for _, table := range tables {
+		for _, row := range table.rows {
+			if id, ok := row["id"].(string); ok {
+				if _, err := uuid.Parse(id); err == nil {
+					ids[id] = uuid.NewString()
+				}
+			}
+		}
+	}
+
+	stagingID, err := uuid.Parse(ids[backup.TenantID.String()])
+	if err != nil {
+		return errors.New("backup does not hold the tenant")
+	}
+	tenant := tables[0].rows[0]
+	suffix := restore.ID.String()[:8]
+	tenant["slug"] = fmt.Sprintf("%v-restore-%s", tenant["slug"], suffix)
+	tenant["name"] = fmt.Sprintf("%v (restore of %s)", tenant["name"], backup.CompletedAt.Format("02 Jan 2006 15:04"))
+
+	var rows []repository.TableRows
+	var skipped models.RestoreTables
+	for _, table := range tables {
+		if restoreSkippedTables[table.table] {
+			skipped = append(skipped, models.RestoreTable{
+				Table:    table.table,
+				BackedUp: int64(len(table.rows)),
+				Skipped:  "not restored into staging tenants",
+			})
+			continue
+		}
+
+		restored := repository.TableRows{Table: table.table, Rows: make([][]byte, 0, len(table.rows))}
+		for _, row := range table.rows {
+			for column, value := range row {
+				if id, ok := value.(string); ok {
+					if newID, ok := ids[id]; ok {
+						row[column] = newID
+					}
+				}
+			}
+			for column, value := range restoreOverrides[table.table] {
+				row[column] = value
+			}
+			data, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			restored.Rows = append(restored.Rows, data)
+		}
+		rows = append(rows, restored)
+	}
+
+	result, err := s.backupRepo.Restore(ctx, rows)
+	if err != nil {
+		return err
+	}
+
+	restore.StagingTenantID = &stagingID
+	restore.Tables = append(result, skipped...)
+	restore.Verified = true
+	restore.Rows = 0
+	for _, table := range result {
+		restore.Rows += table.Restored
+		if table.Restored != table.BackedUp {
+			restore.Verified = false
+		}
+	}
+	return nil
+}
+
+func (s *backupService) PurgeExpired(ctx context.Context) error {
+	return s.backupRepo.PurgeExpired(ctx, time.Now())
+}
+
+// backupTable is one table's rows read back from a backup
+type backupTable struct {
+	table string
+	rows  []map[string]interface{}
+}
+
+// readTenantData reads the tables of a data export or backup zip, the
+// tenants table first
+func readTenantData(content []byte) ([]backupTable, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifestFile, ok := files["manifest.json"]
+	if !ok {
+		return nil, errors.New("backup has no manifest")
+	}
+	var manifest DataExportManifest
+	if err := readZipJSON(manifestFile, &manifest); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(manifest.Tables))
+	for name := range manifest.Tables {
+		if name != "tenants" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{"tenants"}, names...)
+
+	tables := make([]backupTable, 0, len(names))
+	for _, name := range names {
+		f, ok := files[name+".jsonl"]
+		if !ok {
+			return nil, fmt.Errorf("backup is missing %s", name)
+		}
+		rows, err := readZipRows(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if int64(len(rows)) != manifest.Tables[name] {
+			return nil, fmt.Errorf("backup holds %d rows of %s, its manifest %d", len(rows), name, manifest.Tables[name])
+		}
+		tables = append(tables, backupTable{table: name, rows: rows})
+	}
+	return tables, nil
+}
+
+func readZipJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// readZipRows reads a JSON Lines file, keeping numbers exactly as written
+func readZipRows(f *zip.File) ([]map[string]interface{}, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var rows []map[string]interface{}
+	dec := json.NewDecoder(bufio.NewReader(rc))
+	dec.UseNumber()
+	for {
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				return rows, nil
+			}
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidDomain       = errors.New("invalid custom domain")
+	ErrInvalidReplyTo      = errors.New("invalid reply-to email address")
+	ErrLogoTooLarge        = errors.New("logo must be at most 1 MB")
+	ErrInvalidLogoType     = errors.New("logo must be a PNG, JPEG, GIF or WebP image")
+	ErrNoCustomDomain      = errors.New("no custom domain is configured")
+	ErrDomainNotVerifiable = errors.New("verification TXT record not found for the custom domain")
+)
+
+// MaxLogoSize is the largest logo that can be uploaded
+const MaxLogoSize = 1 << 20
+
+// DomainVerificationPrefix is the DNS label under which tenants publish the
+// verification token of their custom domain
+const DomainVerificationPrefix = "_bookkeep-verification."
+
+// Logos are rendered by browsers, email clients and the PDF generator, so
+// only raster formats are accepted; SVG can carry scripts
+var logoContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// UpdateBrandingRequest replaces a tenant's branding settings. Empty values
+// clear a setting.
+type UpdateBrandingRequest struct {
+	PrimaryColor    string  `json:"primary_color" binding:"omitempty,hexcolor"`
+	SecondaryColor  string  `json:"secondary_color" binding:"omitempty,hexcolor"`
+	AccentColor     string  `json:"accent_color" binding:"omitempty,hexcolor"`
+	CustomDomain    *string `json:"custom_domain"`
+	EmailSenderName string  `json:"email_sender_name" binding:"max=100"`
+	EmailReplyTo    *string `json:"email_reply_to"`
+	InvoiceTemplate string  `json:"invoice_template" binding:"omitempty,oneof=classic modern gst_detailed"`
+	InvoiceFooter   string  `json:"invoice_footer" binding:"max=2000"`
+	InvoiceTerms    string  `json:"invoice_terms" binding:"max=4000"`
+}
+
+type BrandingService interface {
+	// GetBranding returns the tenant's branding, with defaults if it has
+	// never been set
+	GetBranding(ctx context.Context, tenantID uuid.UUID) (*models.TenantBranding, error)
+	UpdateBranding(ctx context.Context, tenantID, userID uuid.UUID, req UpdateBrandingRequest) (*models.TenantBranding, error)
+
+	UploadLogo(ctx context.Context, tenantID, userID uuid.UUID, contentType string, content []byte) (*models.TenantBranding, error)
+	DeleteLogo(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantBranding, error)
+	GetLogo(ctx context.Context, tenantID uuid.UUID) (*models.TenantLogo, error)
+
+	// VerifyDomain checks the DNS TXT record of the custom domain and marks
+	// it verified when it holds the verification token
+	VerifyDomain(ctx context.Context, tenantID uuid.UUID) (*models.TenantBranding, error)
+
+	// GetPublicBranding returns the branding shown to a tenant's customers
+	GetPublicBranding(ctx context.Context, tenantID uuid.UUID) (*models.PublicBranding, error)
+	// GetPublicBrandingByDomain resolves a verified custom domain to the
+	// branding of the tenant that owns it
+	GetPublicBrandingByDomain(ctx context.Context, domain string) (*models.PublicBranding, error)
+}
+
+type brandingService struct {
+	brandingRepo repository.BrandingRepository
+	tenantRepo   repository.TenantRepository
+	publicURL    string
+	lookupTXT    func(ctx context.Context, name string) ([]string, error)
+}
+
+// NewBrandingService creates a branding service. publicURL is the external
+// base URL of the API, used in logo links embedded in PDFs and emails.
+func NewBrandingService(brandingRepo repository.BrandingRepository, tenantRepo repository.TenantRepository, publicURL string) BrandingService {
+	return &brandingService{
+		brandingRepo: brandingRepo,
+		tenantRepo:   tenantRepo,
+		publicURL:    strings.TrimRight(publicURL, "/"),
+		lookupTXT:    net.DefaultResolver.LookupTXT,
+	}
+}
+
+func (s *brandingService) GetBranding(ctx context.Context, tenantID uuid.UUID) (*models.TenantBranding, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(ctx, tenant)
+}
+
+// load returns the tenant's branding, or an unsaved default one
+func (s *brandingService) load(ctx context.Context, tenant *models.Tenant) (*models.TenantBranding, error) {
+	branding, err := s.brandingRepo.Get(ctx, tenant.ID)
+	if errors.Is(err, repository.ErrBrandingNotFound) {
+		branding = &models.TenantBranding{TenantID: tenant.ID}
+	} else if err != nil {
+		return nil, err
+	}
+	s.setLogoURL(branding, tenant)
+	return branding, nil
+}
+
+// setLogoURL points the branding at its uploaded logo. Tenants that have
+// not uploaded one keep the logo URL set before branding existed.
+func (s *brandingService) setLogoURL(branding *models.TenantBranding, tenant *models.Tenant) {
+	switch {
+	case branding.LogoUpdatedAt != nil:
+		branding.LogoURL = s.logoURL(branding.TenantID, *branding.LogoUpdatedAt)
+	case tenant.LogoURL != nil:
+		branding.LogoURL = *tenant.LogoURL
+	default:
+		branding.LogoURL = ""
+	}
+}
+
+// logoURL is versioned by upload time so caches pick up a new logo
+func (s *brandingService) logoURL(tenantID uuid.UUID, updatedAt time.Time) string {
+	return fmt.Sprintf("%s/api/v1/tenants/%s/branding/logo?v=%d", s.publicURL, tenantID, updatedAt.Unix())
+}
+
+func (s *brandingService) UpdateBranding(ctx context.Context, tenantID, userID uuid.UUID, req UpdateBrandingRequest) (*models.TenantBranding, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	branding, err := s.load(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := normalizeDomain(req.CustomDomain)
+	if err != nil {
+		return nil, err
+	}
+	if !sameDomain(domain, branding.CustomDomain) {
+		branding.CustomDomain = domain
+		branding.DomainVerifiedAt = nil
+		branding.DomainVerificationToken = ""
+		if domain != nil {
+			branding.DomainVerificationToken = newVerificationToken()
+		}
+	}
+
+	var replyTo *string
+	if req.EmailReplyTo != nil && strings.TrimSpace(*req.EmailReplyTo) != "" {
+		addr, err := mail.ParseAddress(strings.TrimSpace(*req.EmailReplyTo))
+		if err != nil {
+			return nil, ErrInvalidReplyTo
+		}
+		replyTo = &addr.Address
+	}
+
+	branding.PrimaryColor = strings.ToLower(req.PrimaryColor)
+	branding.SecondaryColor = strings.ToLower(req.SecondaryColor)
+	branding.AccentColor = strings.ToLower(req.AccentColor)
+	branding.EmailSenderName = strings.TrimSpace(req.EmailSenderName)
+	branding.EmailReplyTo = replyTo
+	branding.InvoiceTemplate = req.InvoiceTemplate
+	branding.InvoiceFooter = strings.TrimSpace(req.InvoiceFooter)
+	branding.InvoiceTerms = strings.TrimSpace(req.InvoiceTerms)
+	branding.UpdatedBy = &userID
+
+	if err := s.brandingRepo.Save(ctx, branding); err != nil {
+		return nil, err
+	}
+	return branding, nil
+}
+
+// normalizeDomain lower-cases a custom domain and checks it is a host name
+// outside our own domain. Empty values clear the domain.
+func normalizeDomain(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(*value)), ".")
+	if domain == "" {
+		return nil, nil
+	}
+	if len(domain) > 253 || !domainPattern.MatchString(domain) {
+		return nil, ErrInvalidDomain
+	}
+	if domain == "bookkeep.in" || strings.HasSuffix(domain, ".bookkeep.in") {
+		return nil, ErrInvalidDomain
+	}
+	return &domain, nil
+}
+
+func sameDomain(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func newVerificationToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "bookkeep-verify=" + hex.EncodeToString(b)
+}
+
+func (s *brandingService) UploadLogo(ctx context.Context, tenantID, userID uuid.UUID, contentType string, content []byte) (*models.TenantBranding, error) {
+	if len(content) > MaxLogoSize {
+		return nil, ErrLogoTooLarge
+	}
+	if !logoContentTypes[contentType] {
+		return nil, ErrInvalidLogoType
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	branding, err := s.load(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	logo := &models.TenantLogo{
+		TenantID:    tenantID,
+		ContentType: contentType,
+		Content:     content,
+		UpdatedAt:   now,
+	}
+	branding.LogoContentType = contentType
+	branding.LogoUpdatedAt = &now
+	branding.UpdatedBy = &userID
+
+	if err := s.brandingRepo.SaveLogo(ctx, logo, branding); err != nil {
+		return nil, err
+	}
+	s.setLogoURL(branding, tenant)
+
+	// Tenant.LogoURL predates branding; keep it pointing at the current
+	// logo for readers that have not moved over
+	tenant.LogoURL = &branding.LogoURL
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, err
+	}
+	return branding, nil
+}
+
+func (s *brandingService) DeleteLogo(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantBranding, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	branding, err := s.load(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case branding.LogoUpdatedAt != nil:
+		branding.LogoContentType = ""
+		branding.LogoUpdatedAt = nil
+		branding.UpdatedBy = &userID
+		if err := s.brandingRepo.DeleteLogo(ctx, branding); err != nil {
+			return nil, err
+		}
+	case tenant.LogoURL == nil:
+		return nil, repository.ErrLogoNotFound
+	}
+
+	tenant.LogoURL = nil
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, err
+	}
+	s.setLogoURL(branding, tenant)
+	return branding, nil
+}
+
+func (s *brandingService) GetLogo(ctx context.Context, tenantID uuid.UUID) (*models.TenantLogo, error) {
+	return s.brandingRepo.GetLogo(ctx, tenantID)
+}
+
+func (s *brandingService) VerifyDomain(ctx context.Context, tenantID uuid.UUID) (*models.TenantBranding, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	branding, err := s.load(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if branding.CustomDomain == nil {
+		return nil, ErrNoCustomDomain
+	}
+	if branding.DomainVerified() {
+		return branding, nil
+	}
+
+	records, err := s.lookupTXT(ctx, DomainVerificationPrefix+*branding.CustomDomain)
+	if err != nil {
+		return nil, ErrDomainNotVerifiable
+	}
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == branding.DomainVerificationToken {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrDomainNotVerifiable
+	}
+
+	now := time.Now()
+	branding.DomainVerifiedAt = &now
+	if err := s.brandingRepo.MarkDomainVerified(ctx, branding); err != nil {
+		return nil, err
+	}
+	return branding, nil
+}
+
+func (s *brandingService) GetPublicBranding(ctx context.Context, tenantID uuid.UUID) (*models.PublicBranding, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	branding, err := s.load(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return publicBranding(tenant, branding), nil
+}
+
+func (s *brandingService) GetPublicBrandingByDomain(ctx context.Context, domain string) (*models.PublicBranding, error) {
+	normalized, err := normalizeDomain(&domain)
+	if err != nil || normalized == nil {
+		return nil, repository.ErrBrandingNotFound
+	}
+	branding, err := s.brandingRepo.GetByDomain(ctx, *normalized)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := s.tenantRepo.GetByID(ctx, branding.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	s.setLogoURL(branding, tenant)
+	return publicBranding(tenant, branding), nil
+}
+
+func publicBranding(tenant *models.Tenant, branding *models.TenantBranding) *models.PublicBranding {
+	return &models.PublicBranding{
+		TenantID:        tenant.ID,
+		Name:            tenant.Name,
+		LogoURL:         branding.LogoURL,
+		PrimaryColor:    branding.PrimaryColor,
+		SecondaryColor:  branding.SecondaryColor,
+		AccentColor:     branding.AccentColor,
+		EmailSenderName: branding.EmailSenderName,
+		InvoiceTemplate: branding.InvoiceTemplate,
+		InvoiceFooter:   branding.InvoiceFooter,
+		InvoiceTerms:    branding.InvoiceTerms,
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrNotOwner             = errors.New("only an owner of the tenant can do this")
+	ErrReauthFailed         = errors.New("password is incorrect")
+	ErrConfirmationMismatch = errors.New("confirmation does not match the tenant name")
+	ErrExportRequired       = errors.New("a data export completed in the last 7 days is required before deleting the tenant")
+	ErrExportInProgress     = errors.New("a data export is already in progress")
+	ErrExportUnavailable    = errors.New("data export is not ready or has expired")
+	ErrDeletionScheduled    = errors.New("deletion is already scheduled for this tenant")
+)
+
+// ScheduleDeletionRequest represents an owner's request to delete a tenant
+type ScheduleDeletionRequest struct {
+	Password    string  `json:"password" binding:"required"`       // The owner's password, checked again
+	ExportID    string  `json:"export_id" binding:"required,uuid"` // A completed export of the tenant's data
+	ConfirmName string  `json:"confirm_name" binding:"required"`   // The tenant's name, typed out
+	Reason      *string `json:"reason"`
+}
+
+// DataExportManifest describes the contents of a data export
+type DataExportManifest struct {
+	TenantID    uuid.UUID        `json:"tenant_id"`
+	TenantName  string           `json:"tenant_name"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Tables      map[string]int64 `json:"tables"` // Rows per table
+}
+
+type DeletionService interface {
+	// StartExport exports all of the tenant's data in the background
+	StartExport(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantDataExport, error)
+	GetExport(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error)
+	ListExports(ctx context.Context, tenantID uuid.UUID) ([]models.TenantDataExport, error)
+
+	// DownloadExport returns a completed export with its file
+	DownloadExport(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error)
+
+	// GetDeletion returns the tenant's scheduled deletion
+	GetDeletion(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error)
+
+	// ScheduleDeletion schedules the tenant for deletion after the cooling-off
+	// period. Only an owner can, after entering their password again, and
+	// only with a recent completed data export.
+	ScheduleDeletion(ctx context.Context, tenantID, userID uuid.UUID, req ScheduleDeletionRequest) (*models.TenantDeletion, error)
+
+	// CancelDeletion cancels a scheduled deletion during the cooling-off
+	// period
+	CancelDeletion(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantDeletion, error)
+
+	// RunDueDeletions carries out the deletions whose cooling-off period has
+	// ended and purges expired exports
+	RunDueDeletions(ctx context.Context) error
+}
+
+type deletionService struct {
+	deletionRepo repository.DeletionRepository
+	tenantRepo   repository.TenantRepository
+}
+
+func NewDeletionService(deletionRepo repository.DeletionRepository, tenantRepo repository.TenantRepository) DeletionService {
+	return &deletionService{
+		deletionRepo: deletionRepo,
+		tenantRepo:   tenantRepo,
+	}
+}
+
+// Data exports
+
+func (s *deletionService) StartExport(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantDataExport, error) {
+	exports, err := s.deletionRepo.ListExports(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == models.ExportStatusPending || export.Status == models.ExportStatusRunning {
+			return nil, ErrExportInProgress
+		}
+	}
+
+	export := &models.TenantDataExport{
+		TenantID:    tenantID,
+		RequestedBy: userID,
+		Status:      models.ExportStatusPending,
+	}
+	if err := s.deletionRepo.CreateExport(ctx, export); err != nil {
+		return nil, err
+	}
+
+	// The export outlives the request that started it
+	go s.runExport(context.Background(), *export)
+
+	return export, nil
+}
+
+func (s *deletionService) GetExport(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error) {
+	return s.deletionRepo.GetExport(ctx, tenantID, id)
+}
+
+func (s *deletionService) ListExports(ctx context.Context, tenantID uuid.UUID) ([]models.TenantDataExport, error) {
+	return s.deletionRepo.ListExports(ctx, tenantID)
+}
+
+func (s *deletionService) DownloadExport(ctx context.Context, tenantID, id uuid.UUID) (*models.TenantDataExport, error) {
+	export, err := s.deletionRepo.GetExportFile(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !export.Available(time.Now()) || len(export.Content) == 0 {
+		return nil, ErrExportUnavailable
+	}
+	return export, nil
+}
+
+// runExport writes the tenant's rows of every tenant table into a zip with a
+// JSON Lines file per table and a manifest
+func (s *deletionService) runExport(ctx context.Context, export models.TenantDataExport) {
+	started := time.Now()
+	export.Status = models.ExportStatusRunning
+	export.StartedAt = &started
+	if err := s.deletionRepo.UpdateExport(ctx, &export); err != nil {
+		log.Printf("Failed to start data export %s: %v", export.ID, err)
+		return
+	}
+
+	content, manifest, err := writeTenantData(ctx, s.deletionRepo, s.tenantRepo, export.TenantID)
+	if err != nil {
+		export.Status = models.ExportStatusFailed
+		export.Error = err.Error()
+		if err := s.deletionRepo.UpdateExport(ctx, &export); err != nil {
+			log.Printf("Failed to record data export %s failure: %v", export.ID, err)
+		}
+		return
+	}
+
+	completed := time.Now()
+	expires := completed.Add(models.ExportValidity)
+	export.Status = models.ExportStatusCompleted
+	export.FileName = fmt.Sprintf("export-%s-%s.zip", export.TenantID, completed.Format("20060102"))
+	export.Content = content
+	export.Size = int64(len(content))
+	export.Tables = len(manifest.Tables)
+	for _, rows := range manifest.Tables {
+		export.Rows += rows
+	}
+	export.CompletedAt = &completed
+	export.ExpiresAt = &expires
+	if err := s.deletionRepo.UpdateExport(ctx, &export); err != nil {
+		log.Printf("Failed to save data export %s: %v", export.ID, err)
+	}
+}
+
+// writeTenantData writes the tenant's rows of every tenant table into a zip.
+// Data exports and backups share the format.
+func writeTenantData(ctx context.Context, deletionRepo repository.DeletionRepository, tenantRepo repository.TenantRepository, tenantID uuid.UUID) ([]byte, *DataExportManifest, error) {
+	tenant, err := tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	tables, err := deletionRepo.ListTenantTables(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest := &DataExportManifest{
+		TenantID:    tenantID,
+		TenantName:  tenant.Name,
+		GeneratedAt: time.Now(),
+		Tables:      make(map[string]int64, len(tables)),
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, table := range tables {
+		w, err := zw.Create(table + ".jsonl")
+		if err != nil {
+			return nil, nil, err
+		}
+		rows, err := deletionRepo.ExportRows(ctx, table, tenantID, func(row []byte) error {
+			if _, err := w.Write(row); err != nil {
+				return err
+			}
+			_, err := w.Write([]byte("\n"))
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("exporting %s: %w", table, err)
+		}
+		manifest.Tables[table] = rows
+	}
+
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), manifest, nil
+}
+
+// Deletion
+
+func (s *deletionService) GetDeletion(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error) {
+	return s.deletionRepo.GetScheduledDeletion(ctx, tenantID)
+}
+
+func (s *deletionService) ScheduleDeletion(ctx context.Context, tenantID, userID uuid.UUID, req ScheduleDeletionRequest) (*models.TenantDeletion, error) {
+	if err := requireOwner(ctx, s.tenantRepo, tenantID, userID); err != nil {
+		return nil, err
+	}
+
+	// Re-authenticate: a stolen session alone must not be enough
+	hash, err := s.deletionRepo.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+		return nil, ErrReauthFailed
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(req.ConfirmName), tenant.Name) {
+		return nil, ErrConfirmationMismatch
+	}
+
+	exportID, _ := uuid.Parse(req.ExportID)
+	export, err := s.deletionRepo.GetExport(ctx, tenantID, exportID)
+	if err != nil {
+		if errors.Is(err, repository.ErrExportNotFound) {
+			return nil, ErrExportRequired
+		}
+		return nil, err
+	}
+	if !export.Available(time.Now()) {
+		return nil, ErrExportRequired
+	}
+
+	if _, err := s.deletionRepo.GetScheduledDeletion(ctx, tenantID); err == nil {
+		return nil, ErrDeletionScheduled
+	} else if !errors.Is(err, repository.ErrDeletionNotFound) {
+		return nil, err
+	}
+
+	deletion := &models.TenantDeletion{
+		TenantID:     tenantID,
+		RequestedBy:  userID,
+		ExportID:     export.ID,
+		Reason:       req.Reason,
+		Status:       models.DeletionStatusScheduled,
+		ScheduledFor: time.Now().Add(models.DeletionCoolingOff),
+	}
+	if err := s.deletionRepo.CreateDeletion(ctx, deletion); err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+func (s *deletionService) CancelDeletion(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantDeletion, error) {
+	if err := requireOwner(ctx, s.tenantRepo, tenantID, userID); err != nil {
+		return nil, err
+	}
+
+	deletion, err := s.deletionRepo.GetScheduledDeletion(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deletion.Status = models.DeletionStatusCancelled
+	deletion.CancelledBy = &userID
+	deletion.CancelledAt = &now
+	if err := s.deletionRepo.UpdateDeletion(ctx, deletion); err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+func (s *deletionService) RunDueDeletions(ctx context.Context) error {
+	now := time.Now()
+	deletions, err := s.deletionRepo.ListDueDeletions(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range deletions {
+		err := s.deletionRepo.Anonymize(ctx, &deletions[i], now)
+		if err != nil && !errors.Is(err, repository.ErrDeletionNotFound) {
+			return fmt.Errorf("deleting tenant %s: %w", deletions[i].TenantID, err)
+		}
+	}
+
+	return s.deletionRepo.PurgeExpiredExports(ctx, now)
+}
+
+// requireOwner checks the user holds the tenant's Owner role
+func requireOwner(ctx context.Context, tenantRepo repository.TenantRepository, tenantID, userID uuid.UUID) error {
+	member, err := tenantRepo.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			return ErrNotOwner
+		}
+		return err
+	}
+	if !member.Role.IsSystem || member.Role.Name != "Owner" {
+		return ErrNotOwner
+	}
+	return nil
+}
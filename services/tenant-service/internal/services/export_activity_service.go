@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+)
+
+var (
+	ErrExportRecordForged  = errors.New("exports can only be recorded by the user who made them")
+	ErrInvalidExportRecord = errors.New("invalid export record")
+)
+
+// exportActivityDays is how far back the export activity report looks when
+// no start date is given
+const exportActivityDays = 30
+
+// ExportActivity is an export made, or refused, in any service
+type ExportActivity struct {
+	ID        uuid.UUID         `json:"id"`
+	UserID    uuid.UUID         `json:"user_id"`
+	UserEmail string            `json:"user_email,omitempty"`
+	Resource  string            `json:"resource"`
+	Status    string            `json:"status"`
+	Filters   map[string]string `json:"filters,omitempty"`
+	Rows      *int              `json:"rows,omitempty"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	IPAddress string            `json:"ip_address"`
+	At        time.Time         `json:"at"`
+}
+
+// ExportActivityUser is what a member exported over the report's period
+type ExportActivityUser struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email,omitempty"`
+	Exports   int64     `json:"exports"`
+	Denied    int64     `json:"denied"`
+	Rows      int64     `json:"rows"`
+	Resources []string  `json:"resources"`
+}
+
+// ExportActivityResource is how often a kind of data was exported over the
+// report's period
+type ExportActivityResource struct {
+	Resource string `json:"resource"`
+	Exports  int64  `json:"exports"`
+	Denied   int64  `json:"denied"`
+	Rows     int64  `json:"rows"`
+	Users    int    `json:"users"`
+}
+
+// ExportActivitySummary totals the tenant's exports by user and by
+// resource. Rows only count exports whose service reported them.
+type ExportActivitySummary struct {
+	From       string                   `json:"from"`
+	To         string                   `json:"to,omitempty"`
+	Exports    int64                    `json:"exports"`
+	Denied     int64                    `json:"denied"`
+	Rows       int64                    `json:"rows"`
+	ByUser     []ExportActivityUser     `json:"by_user"`
+	ByResource []ExportActivityResource `json:"by_resource"`
+}
+
+type ExportActivityService interface {
+	// Record adds an export to the tenant's audit log
+	Record(ctx context.Context, record middleware.ExportRecord) error
+
+	// List and Summary report the tenant's exports to its owners. A filter
+	// without a start date covers the last 30 days.
+	List(ctx context.Context, tenantID, userID uuid.UUID, filters repository.ExportActivityFilters) ([]ExportActivity, int64, error)
+	Summary(ctx context.Context, tenantID, userID uuid.UUID, filters repository.ExportActivityFilters) (*ExportActivitySummary, error)
+}
+
+type exportActivityService struct {
+	activityRepo repository.ExportActivityRepository
+	tenantRepo   repository.TenantRepository
+	roleRepo     repository.RoleRepository
+}
+
+func NewExportActivityService(activityRepo repository.ExportActivityRepository, tenantRepo repository.TenantRepository, roleRepo repository.RoleRepository) ExportActivityService {
+	return &exportActivityService{
+		activityRepo: activityRepo,
+		tenantRepo:   tenantRepo,
+		roleRepo:     roleRepo,
+	}
+}
+
+func (s *exportActivityService) Record(ctx context.Context, record middleware.ExportRecord) error {
+	tenantID, err := uuid.Parse(record.TenantID)
+	if err != nil {
+		return ErrInvalidExportRecord
+	}
+	userID, err := uuid.Parse(record.UserID)
+	if err != nil || record.Resource == "" {
+		return ErrInvalidExportRecord
+	}
+	if record.Status != middleware.ExportDenied {
+		record.Status = middleware.ExportSucceeded
+	}
+
+	detail, _ := json.Marshal(record)
+	value := string(detail)
+	entry := &models.AuditLog{
+		TenantID:  tenantID,
+		UserID:    userID,
+		Action:    models.AuditDataExport,
+		Resource:  truncate(record.Resource, 100),
+		NewValue:  &value,
+		IPAddress: truncate(record.IPAddress, 45),
+		Status:    record.Status,
+	}
+	if record.UserAgent != "" {
+		userAgent := truncate(record.UserAgent, 500)
+		entry.UserAgent = &userAgent
+	}
+	if record.RequestID != "" {
+		requestID := truncate(record.RequestID, 100)
+		entry.RequestID = &requestID
+	}
+	return s.roleRepo.CreateAuditLog(ctx, entry)
+}
+
+func (s *exportActivityService) List(ctx context.Context, tenantID, userID uuid.UUID, filters repository.ExportActivityFilters) ([]ExportActivity, int64, error) {
+	if err := requireOwner(ctx, s.tenantRepo, tenantID, userID); err != nil {
+		return nil, 0, err
+	}
+	defaultExportActivityPeriod(&filters)
+
+	logs, total, err := s.activityRepo.List(ctx, tenantID, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	emails, err := s.memberEmails(ctx, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	activity := make([]ExportActivity, 0, len(logs))
+	for _, log := range logs {
+		entry := ExportActivity{
+			ID:        log.ID,
+			UserID:    log.UserID,
+			UserEmail: emails[log.UserID],
+			Resource:  log.Resource,
+			Status:    log.Status,
+			IPAddress: log.IPAddress,
+			At:        log.CreatedAt,
+		}
+		if log.NewValue != nil {
+			var record middleware.ExportRecord
+			if json.Unmarshal([]byte(*log.NewValue), &record) == nil {
+				entry.Filters = record.Filters
+				entry.Rows = record.Rows
+				entry.Method = record.Method
+				entry.Path = record.Path
+			}
+		}
+		activity = append(activity, entry)
+	}
+	return activity, total, nil
+}
+
+func (s *exportActivityService) Summary(ctx context.Context, tenantID, userID uuid.UUID, filters repository.ExportActivityFilters) (*ExportActivitySummary, error) {
+	if err := requireOwner(ctx, s.tenantRepo, tenantID, userID); err != nil {
+		return nil, err
+	}
+	defaultExportActivityPeriod(&filters)
+
+	totals, err := s.activityRepo.Totals(ctx, tenantID, filters)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := s.memberEmails(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ExportActivitySummary{
+		From:       filters.StartDate,
+		To:         filters.EndDate,
+		ByUser:     []ExportActivityUser{},
+		ByResource: []ExportActivityResource{},
+	}
+	users := map[uuid.UUID]*ExportActivityUser{}
+	resources := map[string]*ExportActivityResource{}
+	resourceUsers := map[string]map[uuid.UUID]bool{}
+	for _, total := range totals {
+		user, ok := users[total.UserID]
+		if !ok {
+			user = &ExportActivityUser{UserID: total.UserID, Email: emails[total.UserID], Resources: []string{}}
+			users[total.UserID] = user
+		}
+		resource, ok := resources[total.Resource]
+		if !ok {
+			resource = &ExportActivityResource{Resource: total.Resource}
+			resources[total.Resource] = resource
+			resourceUsers[total.Resource] = map[uuid.UUID]bool{}
+		}
+
+		if total.Status == middleware.ExportDenied {
+			summary.Denied += total.Exports
+			user.Denied += total.Exports
+			resource.Denied += total.Exports
+			continue
+		}
+		summary.Exports += total.Exports
+		summary.Rows += total.Rows
+		user.Exports += total.Exports
+		user.Rows += total.Rows
+		user.Resources = append(user.Resources, total.Resource)
+		resource.Exports += total.Exports
+		resource.Rows += total.Rows
+		resourceUsers[total.Resource][total.UserID] = true
+	}
+
+	for _, user := range users {
+		sort.Strings(user.Resources)
+		summary.ByUser = append(summary.ByUser, *user)
+	}
+	for name, resource := range resources {
+		resource.Users = len(resourceUsers[name])
+		summary.ByResource = append(summary.ByResource, *resource)
+	}
+	sort.Slice(summary.ByUser, func(i, j int) bool {
+		if summary.ByUser[i].Exports != summary.ByUser[j].Exports {
+			return summary.ByUser[i].Exports > summary.ByUser[j].Exports
+		}
+		return summary.ByUser[i].Email < summary.ByUser[j].Email
+	})
+	sort.Slice(summary.ByResource, func(i, j int) bool {
+		if summary.ByResource[i].Exports != summary.ByResource[j].Exports {
+			return summary.ByResource[i].Exports > summary.ByResource[j].Exports
+		}
+		return summary.ByResource[i].Resource < summary.ByResource[j].Resource
+	})
+	return summary, nil
+}
+
+// memberEmails maps the tenant's members to their email addresses
+func (s *exportActivityService) memberEmails(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID]string, error) {
+	members, err := s.tenantRepo.ListMembers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	emails := make(map[uuid.UUID]string, len(members))
+	for _, member := range members {
+		emails[member.UserID] = member.Email
+	}
+	return emails, nil
+}
+
+func defaultExportActivityPeriod(filters *repository.ExportActivityFilters) {
+	if filters.StartDate == "" {
+		filters.StartDate = time.Now().UTC().AddDate(0, 0, -exportActivityDays).Format("2006-01-02")
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotGroupParent     = errors.New("only the parent tenant can manage the group")
+	ErrCannotRemoveParent = errors.New("cannot remove the parent tenant from its group")
+	ErrGroupAccessDenied  = errors.New("you must be able to edit a tenant to add it to a group")
+)
+
+// CreateGroupRequest represents the request to create a tenant group
+type CreateGroupRequest struct {
+	Name        string  `json:"name" binding:"required,min=2,max=255"`
+	Description *string `json:"description"`
+}
+
+// AddGroupMemberRequest represents the request to add a tenant to a group
+type AddGroupMemberRequest struct {
+	TenantID string `json:"tenant_id" binding:"required,uuid"`
+}
+
+type GroupService interface {
+	CreateGroup(ctx context.Context, parentTenantID, userID uuid.UUID, req CreateGroupRequest) (*models.TenantGroup, error)
+	UpdateGroup(ctx context.Context, parentTenantID uuid.UUID, req CreateGroupRequest) (*models.TenantGroup, error)
+	DeleteGroup(ctx context.Context, parentTenantID uuid.UUID) error
+
+	// GetGroup returns the group the tenant belongs to
+	GetGroup(ctx context.Context, tenantID uuid.UUID) (*models.TenantGroup, error)
+
+	// AddMember adds another tenant to the parent tenant's group. The user
+	// must be able to edit the tenant being added.
+	AddMember(ctx context.Context, parentTenantID, userID uuid.UUID, req AddGroupMemberRequest) (*models.TenantGroup, error)
+	RemoveMember(ctx context.Context, parentTenantID, memberTenantID uuid.UUID) (*models.TenantGroup, error)
+}
+
+type groupService struct {
+	groupRepo     repository.GroupRepository
+	tenantService TenantService
+}
+
+func NewGroupService(groupRepo repository.GroupRepository, tenantService TenantService) GroupService {
+	return &groupService{
+		groupRepo:     groupRepo,
+		tenantService: tenantService,
+	}
+}
+
+func (s *groupService) CreateGroup(ctx context.Context, parentTenantID, userID uuid.UUID, req CreateGroupRequest) (*models.TenantGroup, error) {
+	group := &models.TenantGroup{
+		Name:           strings.TrimSpace(req.Name),
+		ParentTenantID: parentTenantID,
+		Description:    req.Description,
+		CreatedBy:      userID,
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return s.groupRepo.GetByParentTenant(ctx, parentTenantID)
+}
+
+func (s *groupService) UpdateGroup(ctx context.Context, parentTenantID uuid.UUID, req CreateGroupRequest) (*models.TenantGroup, error) {
+	group, err := s.parentGroup(ctx, parentTenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Name = strings.TrimSpace(req.Name)
+	group.Description = req.Description
+
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *groupService) DeleteGroup(ctx context.Context, parentTenantID uuid.UUID) error {
+	group, err := s.parentGroup(ctx, parentTenantID)
+	if err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(ctx, group.ID)
+}
+
+func (s *groupService) GetGroup(ctx context.Context, tenantID uuid.UUID) (*models.TenantGroup, error) {
+	return s.groupRepo.GetByMemberTenant(ctx, tenantID)
+}
+
+func (s *groupService) AddMember(ctx context.Context, parentTenantID, userID uuid.UUID, req AddGroupMemberRequest) (*models.TenantGroup, error) {
+	group, err := s.parentGroup(ctx, parentTenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberTenantID, err := uuid.Parse(req.TenantID)
+	if err != nil {
+		return nil, repository.ErrTenantNotFound
+	}
+
+	// Grouping exposes the tenant's books to the parent's consolidated
+	// reports, so it needs the consent of someone who can edit the tenant
+	allowed, err := s.tenantService.CheckPermission(ctx, memberTenantID, userID, models.PermTenantEdit)
+	if err != nil || !allowed {
+		return nil, ErrGroupAccessDenied
+	}
+
+	member := &models.TenantGroupMember{
+		GroupID:  group.ID,
+		TenantID: memberTenantID,
+		AddedBy:  userID,
+	}
+	if err := s.groupRepo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+
+	return s.groupRepo.GetByParentTenant(ctx, parentTenantID)
+}
+
+func (s *groupService) RemoveMember(ctx context.Context, parentTenantID, memberTenantID uuid.UUID) (*models.TenantGroup, error) {
+	group, err := s.parentGroup(ctx, parentTenantID)
+	if err != nil {
+		return nil, err
+	}
+	if memberTenantID == parentTenantID {
+		return nil, ErrCannotRemoveParent
+	}
+
+	if err := s.groupRepo.RemoveMember(ctx, group.ID, memberTenantID); err != nil {
+		return nil, err
+	}
+
+	return s.groupRepo.GetByParentTenant(ctx, parentTenantID)
+}
+
+// parentGroup returns the group owned by the tenant
+func (s *groupService) parentGroup(ctx context.Context, parentTenantID uuid.UUID) (*models.TenantGroup, error) {
+	group, err := s.groupRepo.GetByParentTenant(ctx, parentTenantID)
+	if err == repository.ErrGroupNotFound {
+		// The tenant may still be a member of a group it does not own
+		if _, memberErr := s.groupRepo.GetByMemberTenant(ctx, parentTenantID); memberErr == nil {
+			return nil, ErrNotGroupParent
+		}
+	}
+	return group, err
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/middleware"
+)
+
+var (
+	ErrInvalidNetworkPolicy = errors.New("invalid network policy")
+	ErrPolicyLocksOutCaller = errors.New("the policy would block the network you are connecting from")
+	ErrBlockedAttemptForged = errors.New("blocked attempt does not match the requesting user")
+)
+
+// UpdateNetworkPolicyRequest replaces a tenant's network policy. Addresses
+// are CIDR ranges or single IPs, countries ISO 3166 alpha-2 codes.
+type UpdateNetworkPolicyRequest struct {
+	AllowedCIDRs     []string `json:"allowed_cidrs"`
+	DeniedCIDRs      []string `json:"denied_cidrs"`
+	AllowedCountries []string `json:"allowed_countries"`
+	DeniedCountries  []string `json:"denied_countries"`
+}
+
+// RequestOrigin is where a request to the tenant service came from
+type RequestOrigin struct {
+	IPAddress string
+	Country   string // Empty when the edge did not report one
+	UserAgent string
+	RequestID string
+}
+
+type NetworkPolicyService interface {
+	// GetPolicy returns the tenant's network policy, or
+	// repository.ErrNetworkPolicyNotFound when access is unrestricted
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.TenantNetworkPolicy, error)
+
+	// UpdatePolicy replaces the tenant's network policy. It is refused if it
+	// would block the network the change is made from.
+	UpdatePolicy(ctx context.Context, tenantID, userID uuid.UUID, req UpdateNetworkPolicyRequest, origin RequestOrigin) (*models.TenantNetworkPolicy, error)
+
+	// DeletePolicy lifts all network restrictions of the tenant
+	DeletePolicy(ctx context.Context, tenantID, userID uuid.UUID, origin RequestOrigin) error
+
+	// Bypass lets an owner locked out by the policy connect from anywhere
+	// for NetworkBypassDuration, so they can correct it
+	Bypass(ctx context.Context, tenantID, userID uuid.UUID, origin RequestOrigin) (*models.TenantNetworkPolicy, error)
+
+	// RevokeBypass ends an owner's bypass early
+	RevokeBypass(ctx context.Context, tenantID, userID uuid.UUID, origin RequestOrigin) (*models.TenantNetworkPolicy, error)
+
+	// LogBlocked adds a request refused by the policy to the tenant's audit
+	// log
+	LogBlocked(ctx context.Context, attempt middleware.BlockedAttempt) error
+
+	// Policy and RecordBlocked let the tenant service's own auth middleware
+	// enforce policies straight from the database
+	middleware.NetworkPolicies
+}
+
+type networkPolicyService struct {
+	policyRepo repository.NetworkPolicyRepository
+	tenantRepo repository.TenantRepository
+	roleRepo   repository.RoleRepository
+}
+
+func NewNetworkPolicyService(policyRepo repository.NetworkPolicyRepository, tenantRepo repository.TenantRepository, roleRepo repository.RoleRepository) NetworkPolicyService {
+	return &networkPolicyService{
+		policyRepo: policyRepo,
+		tenantRepo: tenantRepo,
+		roleRepo:   roleRepo,
+	}
+}
+
+func (s *networkPolicyService) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*models.TenantNetworkPolicy, error) {
+	return s.policyRepo.Get(ctx, tenantID)
+}
+
+func (s *networkPolicyService) UpdatePolicy(ctx context.Context, tenantID, userID uuid.UUID, req UpdateNetworkPolicyRequest, origin RequestOrigin) (*models.TenantNetworkPolicy, error) {
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil && !errors.Is(err, repository.ErrNetworkPolicyNotFound) {
+		return nil, err
+	}
+	var old *models.TenantNetworkPolicy
+	if policy == nil {
+		policy = &models.TenantNetworkPolicy{TenantID: tenantID}
+	} else {
+		previous := *policy
+		old = &previous
+	}
+
+	policy.AllowedCIDRs = cleanList(req.AllowedCIDRs, false)
+	policy.DeniedCIDRs = cleanList(req.DeniedCIDRs, false)
+	policy.AllowedCountries = cleanList(req.AllowedCountries, true)
+	policy.DeniedCountries = cleanList(req.DeniedCountries, true)
+	policy.UpdatedBy = userID
+
+	rules := policy.Rules()
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNetworkPolicy, err)
+	}
+	// Judge the new rules on their own, without the caller's bypass
+	rules.BypassUserID = ""
+	if rules.Check(origin.IPAddress, origin.Country, userID.String()) != "" {
+		return nil, ErrPolicyLocksOutCaller
+	}
+
+	if err := s.policyRepo.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditNetworkPolicyUpdate, origin, old, policy)
+	return policy, nil
+}
+
+func (s *networkPolicyService) DeletePolicy(ctx context.Context, tenantID, userID uuid.UUID, origin RequestOrigin) error {
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.policyRepo.Delete(ctx, tenantID); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditNetworkPolicyDelete, origin, policy, nil)
+	return nil
+}
+
+func (s *networkPolicyService) Bypass(ctx context.Context, tenantID, userID uuid.UUID, origin RequestOrigin) (*models.TenantNetworkPolicy, error) {
+	if err := requireOwner(ctx, s.tenantRepo, tenantID, userID); err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	until := time.Now().Add(models.NetworkBypassDuration)
+	policy.BypassUserID = &userID
+	policy.BypassUntil = &until
+	if err := s.policyRepo.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditNetworkBypass, origin, nil, policy)
+	return policy, nil
+}
+
+func (s *networkPolicyService) RevokeBypass(ctx context.Context, tenantID, userID uuid.UUID, origin RequestOrigin) (*models.TenantNetworkPolicy, error) {
+	if err := requireOwner(ctx, s.tenantRepo, tenantID, userID); err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.BypassUserID == nil {
+		return policy, nil
+	}
+
+	policy.BypassUserID = nil
+	policy.BypassUntil = nil
+	if err := s.policyRepo.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditNetworkBypassRevoke, origin, nil, nil)
+	return policy, nil
+}
+
+func (s *networkPolicyService) LogBlocked(ctx context.Context, attempt middleware.BlockedAttempt) error {
+	tenantID, err := uuid.Parse(attempt.TenantID)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(attempt.UserID)
+	if err != nil {
+		return err
+	}
+
+	detail, _ := json.Marshal(attempt)
+	value := string(detail)
+	reason := attempt.Reason
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		Action:       models.AuditNetworkAccessBlocked,
+		Resource:     "network_policy",
+		NewValue:     &value,
+		IPAddress:    truncate(attempt.IPAddress, 45),
+		Status:       "denied",
+		ErrorMessage: &reason,
+	}
+	if attempt.UserAgent != "" {
+		userAgent := truncate(attempt.UserAgent, 500)
+		entry.UserAgent = &userAgent
+	}
+	if attempt.RequestID != "" {
+		entry.RequestID = &attempt.RequestID
+	}
+	return s.roleRepo.CreateAuditLog(ctx, entry)
+}
+
+func (s *networkPolicyService) Policy(ctx context.Context, tenantID, authorization string) (*middleware.NetworkPolicy, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, nil
+	}
+	policy, err := s.policyRepo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNetworkPolicyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return policy.Rules(), nil
+}
+
+func (s *networkPolicyService) RecordBlocked(ctx context.Context, authorization string, attempt middleware.BlockedAttempt) error {
+	return s.LogBlocked(ctx, attempt)
+}
+
+// audit records a change to the network policy in the tenant's audit log
+func (s *networkPolicyService) audit(ctx context.Context, tenantID, userID uuid.UUID, action string, origin RequestOrigin, old, current *models.TenantNetworkPolicy) {
+	entry := &models.AuditLog{
+		TenantID:  tenantID,
+		UserID:    userID,
+		Action:    action,
+		Resource:  "network_policy",
+		IPAddress: truncate(origin.IPAddress, 45),
+		Status:    "success",
+	}
+	if old != nil {
+		value, _ := json.Marshal(old)
+		oldValue := string(value)
+		entry.OldValue = &oldValue
+	}
+	if current != nil {
+		value, _ := json.Marshal(current)
+		newValue := string(value)
+		entry.NewValue = &newValue
+		entry.ResourceID = &current.ID
+	}
+	if origin.UserAgent != "" {
+		userAgent := truncate(origin.UserAgent, 500)
+		entry.UserAgent = &userAgent
+	}
+	if origin.RequestID != "" {
+		entry.RequestID = &origin.RequestID
+	}
+	_ = s.roleRepo.CreateAuditLog(ctx, entry)
+}
+
+// cleanList trims and drops empty entries, upper-casing country codes
+func cleanList(values []string, upper bool) pq.StringArray {
+	cleaned := pq.StringArray{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if upper {
+			value = strings.ToUpper(value)
+		}
+		cleaned = append(cleaned, value)
+	}
+	return cleaned
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
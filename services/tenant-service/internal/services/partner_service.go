@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Sources of a tenant's attribution to a partner
+const (
+	ReferralSourceSignup = "signup"
+	ReferralSourceAdmin  = "admin"
+)
+
+var (
+	ErrInvalidReferralCode = errors.New("referral code is not valid")
+	ErrInvalidPartner      = errors.New("referral code must be 4 to 20 letters and digits, commission between 0 and 100 percent")
+)
+
+var referralCodePattern = regexp.MustCompile(`^[A-Z0-9]{4,20}$`)
+
+// CreatePartnerRequest represents the request to register a partner. A
+// referral code is generated when none is given.
+type CreatePartnerRequest struct {
+	Name              string  `json:"name" binding:"required,min=2,max=255"`
+	Type              string  `json:"type" binding:"required,oneof=ca reseller"`
+	Email             string  `json:"email" binding:"required,email"`
+	Phone             string  `json:"phone"`
+	UserID            *string `json:"user_id" binding:"omitempty,uuid"`
+	ReferralCode      string  `json:"referral_code"`
+	CommissionPercent float64 `json:"commission_percent"`
+	CommissionMonths  int     `json:"commission_months" binding:"min=0"`
+}
+
+// UpdatePartnerRequest represents the request to update a partner. Empty
+// fields are left as they are.
+type UpdatePartnerRequest struct {
+	Name              string   `json:"name" binding:"omitempty,min=2,max=255"`
+	Type              string   `json:"type" binding:"omitempty,oneof=ca reseller"`
+	Email             string   `json:"email" binding:"omitempty,email"`
+	Phone             string   `json:"phone"`
+	UserID            *string  `json:"user_id" binding:"omitempty,uuid"`
+	ReferralCode      string   `json:"referral_code"`
+	CommissionPercent *float64 `json:"commission_percent"`
+	CommissionMonths  *int     `json:"commission_months" binding:"omitempty,min=0"`
+	Status            string   `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// AttributeTenantRequest attributes an existing tenant to a partner, for
+// clients who signed up without the partner's code
+type AttributeTenantRequest struct {
+	ReferralCode string `json:"referral_code" binding:"required"`
+}
+
+// ReferredTenant is a tenant on a partner's dashboard with the commission
+// it earns them
+type ReferredTenant struct {
+	TenantID     uuid.UUID  `json:"tenant_id"`
+	Name         string     `json:"name"`
+	Plan         string     `json:"plan"`
+	Status       string     `json:"status"` // The tenant's: active, suspended or deleted
+	Source       string     `json:"source"`
+	AttributedAt time.Time  `json:"attributed_at"`
+	CommissionTo *time.Time `json:"commission_to,omitempty"` // End of the commission period, if limited
+
+	// Earning is whether the tenant is on a paid plan, active and within
+	// the commission period. Accrued commission is worked out on the
+	// current plan for each full month since the tenant signed up, until
+	// it was deleted.
+	Earning                bool  `json:"earning"`
+	MonthlyCommissionPaise int64 `json:"monthly_commission_paise"`
+	AccruedCommissionPaise int64 `json:"accrued_commission_paise"`
+}
+
+// PartnerDashboard shows a partner their referred tenants and commissions
+type PartnerDashboard struct {
+	Partner                models.Partner   `json:"partner"`
+	ReferredTenants        int              `json:"referred_tenants"`
+	ActiveTenants          int              `json:"active_tenants"`
+	PaidTenants            int              `json:"paid_tenants"`
+	MonthlyCommissionPaise int64            `json:"monthly_commission_paise"`
+	AccruedCommissionPaise int64            `json:"accrued_commission_paise"`
+	Tenants                []ReferredTenant `json:"tenants"`
+}
+
+type PartnerService interface {
+	CreatePartner(ctx context.Context, createdBy uuid.UUID, req CreatePartnerRequest) (*models.Partner, error)
+	GetPartner(ctx context.Context, id uuid.UUID) (*models.Partner, error)
+	ListPartners(ctx context.Context) ([]models.Partner, error)
+	UpdatePartner(ctx context.Context, id uuid.UUID, req UpdatePartnerRequest) (*models.Partner, error)
+
+	// ResolveReferralCode returns the active partner a referral code
+	// belongs to, or ErrInvalidReferralCode
+	ResolveReferralCode(ctx context.Context, code string) (*models.Partner, error)
+
+	// Attribute records that the partner referred the tenant
+	Attribute(ctx context.Context, tenantID uuid.UUID, partner *models.Partner, source string, attributedBy uuid.UUID) (*models.TenantReferral, error)
+
+	// AttributeTenant attributes an existing tenant to the partner whose
+	// referral code is given
+	AttributeTenant(ctx context.Context, tenantID, attributedBy uuid.UUID, req AttributeTenantRequest) (*models.TenantReferral, error)
+
+	// Dashboard returns the partner's referred tenants, their plans and the
+	// commissions they earn
+	Dashboard(ctx context.Context, partnerID uuid.UUID) (*PartnerDashboard, error)
+
+	// UserDashboard returns the dashboard of the partner the user signs in
+	// for
+	UserDashboard(ctx context.Context, userID uuid.UUID) (*PartnerDashboard, error)
+}
+
+type partnerService struct {
+	partnerRepo repository.PartnerRepository
+	tenantRepo  repository.TenantRepository
+}
+
+func NewPartnerService(partnerRepo repository.PartnerRepository, tenantRepo repository.TenantRepository) PartnerService {
+	return &partnerService{
+		partnerRepo: partnerRepo,
+		tenantRepo:  tenantRepo,
+	}
+}
+
+func (s *partnerService) CreatePartner(ctx context.Context, createdBy uuid.UUID, req CreatePartnerRequest) (*models.Partner, error) {
+	code := normalizeReferralCode(req.ReferralCode)
+	if code == "" {
+		var err error
+		if code, err = newReferralCode(req.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	partner := &models.Partner{
+		Name:              strings.TrimSpace(req.Name),
+		Type:              req.Type,
+		Email:             req.Email,
+		Phone:             req.Phone,
+		ReferralCode:      code,
+		CommissionPercent: req.CommissionPercent,
+		CommissionMonths:  req.CommissionMonths,
+		Status:            "active",
+		CreatedBy:         createdBy,
+	}
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			return nil, ErrInvalidPartner
+		}
+		partner.UserID = &userID
+	}
+	if err := validatePartner(partner); err != nil {
+		return nil, err
+	}
+
+	if err := s.partnerRepo.Create(ctx, partner); err != nil {
+		return nil, err
+	}
+	return partner, nil
+}
+
+func (s *partnerService) GetPartner(ctx context.Context, id uuid.UUID) (*models.Partner, error) {
+	return s.partnerRepo.GetByID(ctx, id)
+}
+
+func (s *partnerService) ListPartners(ctx context.Context) ([]models.Partner, error) {
+	return s.partnerRepo.List(ctx)
+}
+
+func (s *partnerService) UpdatePartner(ctx context.Context, id uuid.UUID, req UpdatePartnerRequest) (*models.Partner, error) {
+	partner, err := s.partnerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		partner.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Type != "" {
+		partner.Type = req.Type
+	}
+	if req.Email != "" {
+		partner.Email = req.Email
+	}
+	if req.Phone != "" {
+		partner.Phone = req.Phone
+	}
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			return nil, ErrInvalidPartner
+		}
+		partner.UserID = &userID
+	}
+	// Tenants already referred stay attributed when the code changes
+	if code := normalizeReferralCode(req.ReferralCode); code != "" {
+		partner.ReferralCode = code
+	}
+	if req.CommissionPercent != nil {
+		partner.CommissionPercent = *req.CommissionPercent
+	}
+	if req.CommissionMonths != nil {
+		partner.CommissionMonths = *req.CommissionMonths
+	}
+	if req.Status != "" {
+		partner.Status = req.Status
+	}
+	if err := validatePartner(partner); err != nil {
+		return nil, err
+	}
+
+	if err := s.partnerRepo.Update(ctx, partner); err != nil {
+		return nil, err
+	}
+	return partner, nil
+}
+
+func (s *partnerService) ResolveReferralCode(ctx context.Context, code string) (*models.Partner, error) {
+	partner, err := s.partnerRepo.GetByReferralCode(ctx, normalizeReferralCode(code))
+	if err != nil {
+		if errors.Is(err, repository.ErrPartnerNotFound) {
+			return nil, ErrInvalidReferralCode
+		}
+		return nil, err
+	}
+	if !partner.IsActive() {
+		return nil, ErrInvalidReferralCode
+	}
+	return partner, nil
+}
+
+func (s *partnerService) Attribute(ctx context.Context, tenantID uuid.UUID, partner *models.Partner, source string, attributedBy uuid.UUID) (*models.TenantReferral, error) {
+	referral := &models.TenantReferral{
+		TenantID:     tenantID,
+		PartnerID:    partner.ID,
+		ReferralCode: partner.ReferralCode,
+		Source:       source,
+		AttributedBy: attributedBy,
+		AttributedAt: time.Now(),
+	}
+	if err := s.partnerRepo.CreateReferral(ctx, referral); err != nil {
+		return nil, err
+	}
+	return referral, nil
+}
+
+func (s *partnerService) AttributeTenant(ctx context.Context, tenantID, attributedBy uuid.UUID, req AttributeTenantRequest) (*models.TenantReferral, error) {
+	if _, err := s.tenantRepo.GetByID(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	partner, err := s.ResolveReferralCode(ctx, req.ReferralCode)
+	if err != nil {
+		return nil, err
+	}
+	return s.Attribute(ctx, tenantID, partner, ReferralSourceAdmin, attributedBy)
+}
+
+func (s *partnerService) Dashboard(ctx context.Context, partnerID uuid.UUID) (*PartnerDashboard, error) {
+	partner, err := s.partnerRepo.GetByID(ctx, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.dashboard(ctx, partner, time.Now())
+}
+
+func (s *partnerService) UserDashboard(ctx context.Context, userID uuid.UUID) (*PartnerDashboard, error) {
+	partner, err := s.partnerRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.dashboard(ctx, partner, time.Now())
+}
+
+func (s *partnerService) dashboard(ctx context.Context, partner *models.Partner, now time.Time) (*PartnerDashboard, error) {
+	referrals, err := s.partnerRepo.ListReferrals(ctx, partner.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &PartnerDashboard{
+		Partner:         *partner,
+		ReferredTenants: len(referrals),
+		Tenants:         make([]ReferredTenant, 0, len(referrals)),
+	}
+	for _, referral := range referrals {
+		tenant := referral.Tenant
+		entry := ReferredTenant{
+			TenantID:     referral.TenantID,
+			Name:         tenant.Name,
+			Plan:         tenant.Plan,
+			Status:       tenant.Status,
+			Source:       referral.Source,
+			AttributedAt: referral.AttributedAt,
+		}
+		end := now
+		if tenant.DeletedAt.Valid {
+			entry.Status = "deleted"
+			end = tenant.DeletedAt.Time
+		}
+
+		months := monthsBetween(referral.AttributedAt, end)
+		if partner.CommissionMonths > 0 {
+			commissionTo := referral.AttributedAt.AddDate(0, partner.CommissionMonths, 0)
+			entry.CommissionTo = &commissionTo
+			if months > partner.CommissionMonths {
+				months = partner.CommissionMonths
+			}
+		}
+
+		price := models.PlanMonthlyPrice[tenant.Plan]
+		entry.MonthlyCommissionPaise = int64(math.Round(float64(price) * partner.CommissionPercent / 100))
+		entry.AccruedCommissionPaise = entry.MonthlyCommissionPaise * int64(months)
+		entry.Earning = entry.Status == "active" && price > 0 &&
+			(entry.CommissionTo == nil || now.Before(*entry.CommissionTo))
+
+		if entry.Status == "active" {
+			dashboard.ActiveTenants++
+			if price > 0 {
+				dashboard.PaidTenants++
+			}
+		}
+		if entry.Earning {
+			dashboard.MonthlyCommissionPaise += entry.MonthlyCommissionPaise
+		}
+		dashboard.AccruedCommissionPaise += entry.AccruedCommissionPaise
+		dashboard.Tenants = append(dashboard.Tenants, entry)
+	}
+	return dashboard, nil
+}
+
+// Helper functions
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validatePartner(partner *models.Partner) error {
+	if !referralCodePattern.MatchString(partner.ReferralCode) ||
+		partner.CommissionPercent < 0 || partner.CommissionPercent > 100 {
+		return ErrInvalidPartner
+	}
+	return nil
+}
+
+// newReferralCode makes a code from the first letters of the partner's name
+// and a random suffix
+func newReferralCode(name string) (string, error) {
+	prefix := regexp.MustCompile("[^A-Z0-9]+").ReplaceAllString(strings.ToUpper(name), "")
+	if len(prefix) > 6 {
+		prefix = prefix[:6]
+	}
+	suffix, err := generateToken(3)
+	if err != nil {
+		return "", err
+	}
+	return prefix + strings.ToUpper(suffix), nil
+}
+
+// monthsBetween counts the full months from one time to another
+func monthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if to.Before(from.AddDate(0, months, 0)) {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/storage"
+	"gorm.io/gorm"
+)
+
+// StorageService reports a tenant's document storage across all services
+type StorageService interface {
+	GetUsage(ctx context.Context, tenantID uuid.UUID) (*storage.Usage, error)
+	// LargestDocuments returns the tenant's biggest documents so users can
+	// see what to remove when they near their quota
+	LargestDocuments(ctx context.Context, tenantID uuid.UUID, limit int) ([]storage.Document, error)
+}
+
+type storageService struct {
+	db *gorm.DB
+}
+
+func NewStorageService(db *gorm.DB) StorageService {
+	return &storageService{db: db}
+}
+
+func (s *storageService) GetUsage(ctx context.Context, tenantID uuid.UUID) (*storage.Usage, error) {
+	return storage.GetUsage(ctx, s.db, tenantID)
+}
+
+func (s *storageService) LargestDocuments(ctx context.Context, tenantID uuid.UUID, limit int) ([]storage.Document, error) {
+	return storage.Largest(ctx, s.db, tenantID, limit)
+}
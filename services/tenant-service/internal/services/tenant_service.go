@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"log"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -12,6 +14,8 @@ import (
 	"github.com/bookkeep/tenant-service/internal/models"
 	"github.com/bookkeep/tenant-service/internal/repository"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/email"
 )
 
 var (
@@ -35,36 +39,44 @@ type CreateTenantRequest struct {
 	State        string  `json:"state"`
 	StateCode    string  `json:"state_code"`
 	PinCode      string  `json:"pin_code"`
+	ReferralCode string  `json:"referral_code"` // Partner who referred the business
 }
 
 // UpdateTenantRequest represents the request to update a tenant
 type UpdateTenantRequest struct {
-	Name               string  `json:"name"`
-	LegalName          string  `json:"legal_name"`
-	GSTIN              *string `json:"gstin"`
-	PAN                *string `json:"pan"`
-	TAN                *string `json:"tan"`
-	CIN                *string `json:"cin"`
-	Email              string  `json:"email"`
-	Phone              string  `json:"phone"`
-	Website            *string `json:"website"`
-	AddressLine1       string  `json:"address_line1"`
-	AddressLine2       *string `json:"address_line2"`
-	City               string  `json:"city"`
-	State              string  `json:"state"`
-	StateCode          string  `json:"state_code"`
-	PinCode            string  `json:"pin_code"`
-	FinancialYearStart int     `json:"financial_year_start"`
-	Currency           string  `json:"currency"`
-	DateFormat         string  `json:"date_format"`
-	InvoicePrefix      string  `json:"invoice_prefix"`
-	InvoiceTerms       *string `json:"invoice_terms"`
-	InvoiceNotes       *string `json:"invoice_notes"`
-	BankName           *string `json:"bank_name"`
-	BankAccountNumber  *string `json:"bank_account_number"`
-	BankIFSC           *string `json:"bank_ifsc"`
-	BankBranch         *string `json:"bank_branch"`
-	LogoURL            *string `json:"logo_url"`
+	Name               string   `json:"name"`
+	LegalName          string   `json:"legal_name"`
+	GSTIN              *string  `json:"gstin"`
+	PAN                *string  `json:"pan"`
+	TAN                *string  `json:"tan"`
+	CIN                *string  `json:"cin"`
+	Email              string   `json:"email"`
+	Phone              string   `json:"phone"`
+	Website            *string  `json:"website"`
+	AddressLine1       string   `json:"address_line1"`
+	AddressLine2       *string  `json:"address_line2"`
+	City               string   `json:"city"`
+	State              string   `json:"state"`
+	StateCode          string   `json:"state_code"`
+	PinCode            string   `json:"pin_code"`
+	FinancialYearStart int      `json:"financial_year_start"`
+	Currency           string   `json:"currency"`
+	DateFormat         string   `json:"date_format"`
+	Language           string   `json:"language" binding:"omitempty,oneof=en hi gu ta mr"`
+	CurrencySymbol     *string  `json:"currency_symbol" binding:"omitempty,max=10"`
+	DecimalPlaces      *int     `json:"decimal_places" binding:"omitempty,min=0,max=4"`
+	NumberGrouping     string   `json:"number_grouping" binding:"omitempty,oneof=indian international"`
+	InvoicePrefix      string   `json:"invoice_prefix"`
+	InvoiceTerms       *string  `json:"invoice_terms"`
+	InvoiceNotes       *string  `json:"invoice_notes"`
+	BankName           *string  `json:"bank_name"`
+	BankAccountNumber  *string  `json:"bank_account_number"`
+	BankIFSC           *string  `json:"bank_ifsc"`
+	BankBranch         *string  `json:"bank_branch"`
+	UPIID              *string  `json:"upi_id" binding:"omitempty,max=100,contains=@"`
+	InvoiceChannels    []string `json:"invoice_channels" binding:"omitempty,dive,oneof=sms whatsapp"` // Nil leaves them unchanged, empty clears them
+	ReminderChannels   []string `json:"reminder_channels" binding:"omitempty,dive,oneof=sms whatsapp"`
+	OTPChannel         string   `json:"otp_channel" binding:"omitempty,oneof=sms whatsapp"`
 }
 
 // InviteMemberRequest represents the request to invite a new member
@@ -86,7 +98,6 @@ type TenantService interface {
 	CreateTenant(ctx context.Context, req CreateTenantRequest, ownerUserID uuid.UUID, ownerInfo OwnerInfo) (*models.Tenant, error)
 	GetTenant(ctx context.Context, id uuid.UUID) (*models.Tenant, error)
 	UpdateTenant(ctx context.Context, id uuid.UUID, req UpdateTenantRequest) (*models.Tenant, error)
-	DeleteTenant(ctx context.Context, id uuid.UUID) error
 
 	// Member Management
 	InviteMember(ctx context.Context, tenantID, inviterID uuid.UUID, req InviteMemberRequest) (*models.TenantInvitation, error)
@@ -95,6 +106,10 @@ type TenantService interface {
 	ListInvitations(ctx context.Context, tenantID uuid.UUID) ([]models.TenantInvitation, error)
 
 	ListMembers(ctx context.Context, tenantID uuid.UUID) ([]models.TenantMember, error)
+
+	// ListOwners returns the tenant's active owners, whom other services
+	// alert about sensitive changes
+	ListOwners(ctx context.Context, tenantID uuid.UUID) ([]models.TenantMember, error)
 	GetMember(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantMember, error)
 	UpdateMember(ctx context.Context, tenantID, memberID uuid.UUID, req UpdateMemberRequest) (*models.TenantMember, error)
 	RemoveMember(ctx context.Context, tenantID, memberID, requesterID uuid.UUID) error
@@ -122,14 +137,22 @@ type MemberInfo struct {
 }
 
 type tenantService struct {
-	tenantRepo repository.TenantRepository
-	roleRepo   repository.RoleRepository
+	tenantRepo     repository.TenantRepository
+	roleRepo       repository.RoleRepository
+	partnerService PartnerService
+	mailer         *email.Mailer
+	appURL         string
 }
 
-func NewTenantService(tenantRepo repository.TenantRepository, roleRepo repository.RoleRepository) TenantService {
+// NewTenantService creates a tenant service. Invitation emails link to the
+// web app at appURL.
+func NewTenantService(tenantRepo repository.TenantRepository, roleRepo repository.RoleRepository, partnerService PartnerService, mailer *email.Mailer, appURL string) TenantService {
 	return &tenantService{
-		tenantRepo: tenantRepo,
-		roleRepo:   roleRepo,
+		tenantRepo:     tenantRepo,
+		roleRepo:       roleRepo,
+		partnerService: partnerService,
+		mailer:         mailer,
+		appURL:         strings.TrimRight(appURL, "/"),
 	}
 }
 
@@ -150,6 +173,15 @@ func (s *tenantService) CreateTenant(ctx context.Context, req CreateTenantReques
 		}
 	}
 
+	// Check the referral code before anything is created
+	var partner *models.Partner
+	if strings.TrimSpace(req.ReferralCode) != "" {
+		var err error
+		if partner, err = s.partnerService.ResolveReferralCode(ctx, req.ReferralCode); err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate slug from name
 	slug := generateSlug(req.Name)
 
@@ -197,6 +229,14 @@ func (s *tenantService) CreateTenant(ctx context.Context, req CreateTenantReques
 		return nil, err
 	}
 
+	// The tenant exists by now; a failed attribution can be recorded by an
+	// admin later
+	if partner != nil {
+		if _, err := s.partnerService.Attribute(ctx, tenant.ID, partner, ReferralSourceSignup, ownerUserID); err != nil {
+			log.Printf("Failed to attribute tenant %s to partner %s: %v", tenant.ID, partner.ID, err)
+		}
+	}
+
 	return tenant, nil
 }
 
@@ -270,6 +310,18 @@ func (s *tenantService) UpdateTenant(ctx context.Context, id uuid.UUID, req Upda
 	if req.DateFormat != "" {
 		tenant.DateFormat = req.DateFormat
 	}
+	if req.Language != "" {
+		tenant.Language = req.Language
+	}
+	if req.CurrencySymbol != nil {
+		tenant.CurrencySymbol = *req.CurrencySymbol
+	}
+	if req.DecimalPlaces != nil {
+		tenant.DecimalPlaces = req.DecimalPlaces
+	}
+	if req.NumberGrouping != "" {
+		tenant.NumberGrouping = req.NumberGrouping
+	}
 	if req.InvoicePrefix != "" {
 		tenant.InvoicePrefix = req.InvoicePrefix
 	}
@@ -280,7 +332,17 @@ func (s *tenantService) UpdateTenant(ctx context.Context, id uuid.UUID, req Upda
 	tenant.BankAccountNumber = req.BankAccountNumber
 	tenant.BankIFSC = req.BankIFSC
 	tenant.BankBranch = req.BankBranch
-	tenant.LogoURL = req.LogoURL
+	tenant.UPIID = req.UPIID
+
+	if req.InvoiceChannels != nil {
+		tenant.InvoiceChannels = uniqueChannels(req.InvoiceChannels)
+	}
+	if req.ReminderChannels != nil {
+		tenant.ReminderChannels = uniqueChannels(req.ReminderChannels)
+	}
+	if req.OTPChannel != "" {
+		tenant.OTPChannel = req.OTPChannel
+	}
 
 	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
 		return nil, err
@@ -289,10 +351,6 @@ func (s *tenantService) UpdateTenant(ctx context.Context, id uuid.UUID, req Upda
 	return tenant, nil
 }
 
-func (s *tenantService) DeleteTenant(ctx context.Context, id uuid.UUID) error {
-	return s.tenantRepo.Delete(ctx, id)
-}
-
 // Member Management
 
 func (s *tenantService) InviteMember(ctx context.Context, tenantID, inviterID uuid.UUID, req InviteMemberRequest) (*models.TenantInvitation, error) {
@@ -359,11 +417,43 @@ func (s *tenantService) InviteMember(ctx context.Context, tenantID, inviterID uu
 		return nil, err
 	}
 
-	// TODO: Send invitation email/SMS
+	// The invitation stands if its email cannot be queued; the invitee can
+	// still be given the link
+	if err := s.sendInvitation(ctx, tenant, role, invitation); err != nil {
+		log.Printf("Failed to send invitation %s to %s: %v", invitation.ID, invitation.Email, err)
+	}
 
 	return invitation, nil
 }
 
+// sendInvitation emails the invitee a link to accept the invitation
+func (s *tenantService) sendInvitation(ctx context.Context, tenant *models.Tenant, role *models.Role, invitation *models.TenantInvitation) error {
+	inviterName := "A colleague"
+	if inviter, err := s.tenantRepo.GetMember(ctx, tenant.ID, invitation.InvitedByID); err == nil {
+		if name := strings.TrimSpace(inviter.FirstName + " " + inviter.LastName); name != "" {
+			inviterName = name
+		}
+	}
+
+	data := email.TenantInvitation{
+		TenantName:  tenant.Name,
+		InviterName: inviterName,
+		RoleName:    role.Name,
+		Link:        s.appURL + "/invitations/accept?token=" + url.QueryEscape(invitation.Token),
+		ExpiresAt:   invitation.ExpiresAt,
+	}
+	if invitation.Message != nil {
+		data.Message = *invitation.Message
+	}
+	// The link is the invitation itself, so its body is not kept
+	_, err := s.mailer.SendTemplate(ctx, invitation.Email, "", email.TemplateTenantInvitation, data, email.SendOptions{
+		TenantID:  &tenant.ID,
+		Reference: "invitation:" + invitation.ID.String(),
+		Sensitive: true,
+	})
+	return err
+}
+
 func (s *tenantService) AcceptInvitation(ctx context.Context, token string, userID uuid.UUID, userInfo MemberInfo) (*models.TenantMember, error) {
 	invitation, err := s.tenantRepo.GetInvitationByToken(ctx, token)
 	if err != nil {
@@ -416,6 +506,21 @@ func (s *tenantService) ListMembers(ctx context.Context, tenantID uuid.UUID) ([]
 	return s.tenantRepo.ListMembers(ctx, tenantID)
 }
 
+func (s *tenantService) ListOwners(ctx context.Context, tenantID uuid.UUID) ([]models.TenantMember, error) {
+	members, err := s.tenantRepo.ListMembers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []models.TenantMember{}
+	for _, member := range members {
+		if member.Status == "active" && member.Role.IsSystem && member.Role.Name == "Owner" {
+			owners = append(owners, member)
+		}
+	}
+	return owners, nil
+}
+
 func (s *tenantService) GetMember(ctx context.Context, tenantID, userID uuid.UUID) (*models.TenantMember, error) {
 	return s.tenantRepo.GetMember(ctx, tenantID, userID)
 }
@@ -529,3 +634,16 @@ func isValidPAN(pan string) bool {
 	matched, _ := regexp.MatchString(pattern, pan)
 	return matched
 }
+
+// uniqueChannels drops repeated channels, keeping their order
+func uniqueChannels(channels []string) pq.StringArray {
+	unique := pq.StringArray{}
+	seen := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		if !seen[channel] {
+			seen[channel] = true
+			unique = append(unique, channel)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bookkeep/tenant-service/internal/models"
+	"github.com/bookkeep/tenant-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/tesseract-nexus/bookkeeping-app/go-shared/validation"
+)
+
+var ErrInvalidValidationRule = errors.New("invalid validation rule")
+
+// ValidationRuleRequest creates or replaces a validation rule. Check
+// defaults to required and Active to true.
+type ValidationRuleRequest struct {
+	Document   string  `json:"document" binding:"required"`
+	Field      string  `json:"field" binding:"required"`
+	Check      string  `json:"check"`
+	MinAmount  float64 `json:"min_amount" binding:"gte=0"`
+	Message    string  `json:"message" binding:"max=255"`
+	MessageKey string  `json:"message_key" binding:"max=100"`
+	Active     *bool   `json:"active"`
+}
+
+// ValidationCatalog lists the fields of a document rules can be set for
+type ValidationCatalog struct {
+	Document string             `json:"document"`
+	Checks   []string           `json:"checks"`
+	Fields   []validation.Field `json:"fields"`
+}
+
+type ValidationRuleService interface {
+	// Catalog returns the documents and fields rules can be set for
+	Catalog() []ValidationCatalog
+
+	// ListRules returns the tenant's rules, only the active ones if
+	// activeOnly is set. Other services read them with the user's token to
+	// check documents as they are saved.
+	ListRules(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]validation.Rule, error)
+
+	GetRule(ctx context.Context, tenantID, ruleID uuid.UUID) (*models.TenantValidationRule, error)
+	CreateRule(ctx context.Context, tenantID, userID uuid.UUID, req ValidationRuleRequest) (*models.TenantValidationRule, error)
+	UpdateRule(ctx context.Context, tenantID, ruleID, userID uuid.UUID, req ValidationRuleRequest) (*models.TenantValidationRule, error)
+	DeleteRule(ctx context.Context, tenantID, ruleID, userID uuid.UUID) error
+}
+
+type validationRuleService struct {
+	ruleRepo repository.ValidationRuleRepository
+	roleRepo repository.RoleRepository
+}
+
+func NewValidationRuleService(ruleRepo repository.ValidationRuleRepository, roleRepo repository.RoleRepository) ValidationRuleService {
+	return &validationRuleService{
+		ruleRepo: ruleRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+func (s *validationRuleService) Catalog() []ValidationCatalog {
+	var catalog []ValidationCatalog
+	for _, document := range validation.Documents() {
+		catalog = append(catalog, ValidationCatalog{
+			Document: document,
+			Checks:   []string{validation.CheckRequired},
+			Fields:   validation.Fields(document),
+		})
+	}
+	return catalog
+}
+
+func (s *validationRuleService) ListRules(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]validation.Rule, error) {
+	stored, err := s.ruleRepo.List(ctx, tenantID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]validation.Rule, 0, len(stored))
+	for n := range stored {
+		rules = append(rules, stored[n].Rule())
+	}
+	return rules, nil
+}
+
+func (s *validationRuleService) GetRule(ctx context.Context, tenantID, ruleID uuid.UUID) (*models.TenantValidationRule, error) {
+	return s.ruleRepo.Get(ctx, tenantID, ruleID)
+}
+
+func (s *validationRuleService) CreateRule(ctx context.Context, tenantID, userID uuid.UUID, req ValidationRuleRequest) (*models.TenantValidationRule, error) {
+	rule := &models.TenantValidationRule{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		CreatedBy: userID,
+	}
+	if err := applyValidationRule(rule, userID, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditValidationRuleCreate, nil, rule)
+	return rule, nil
+}
+
+func (s *validationRuleService) UpdateRule(ctx context.Context, tenantID, ruleID, userID uuid.UUID, req ValidationRuleRequest) (*models.TenantValidationRule, error) {
+	rule, err := s.ruleRepo.Get(ctx, tenantID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	old := *rule
+	if err := applyValidationRule(rule, userID, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditValidationRuleUpdate, &old, rule)
+	return rule, nil
+}
+
+func (s *validationRuleService) DeleteRule(ctx context.Context, tenantID, ruleID, userID uuid.UUID) error {
+	rule, err := s.ruleRepo.Get(ctx, tenantID, ruleID)
+	if err != nil {
+		return err
+	}
+	if err := s.ruleRepo.Delete(ctx, tenantID, ruleID); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, userID, models.AuditValidationRuleDelete, rule, nil)
+	return nil
+}
+
+// applyValidationRule sets the rule from the request, checking it names a
+// field and check the services know
+func applyValidationRule(rule *models.TenantValidationRule, userID uuid.UUID, req ValidationRuleRequest) error {
+	rule.Document = strings.ToLower(strings.TrimSpace(req.Document))
+	rule.Field = strings.ToLower(strings.TrimSpace(req.Field))
+	rule.Check = strings.ToLower(strings.TrimSpace(req.Check))
+	if rule.Check == "" {
+		rule.Check = validation.CheckRequired
+	}
+	rule.MinAmount = req.MinAmount
+	rule.Message = strings.TrimSpace(req.Message)
+	rule.MessageKey = strings.TrimSpace(req.MessageKey)
+	rule.Active = req.Active == nil || *req.Active
+	rule.UpdatedBy = userID
+
+	if err := rule.Rule().Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValidationRule, err)
+	}
+	return nil
+}
+
+func (s *validationRuleService) audit(ctx context.Context, tenantID, userID uuid.UUID, action string, old, current *models.TenantValidationRule) {
+	entry := &models.AuditLog{
+		TenantID: tenantID,
+		UserID:   userID,
+		Action:   action,
+		Resource: "validation_rule",
+		Status:   "success",
+	}
+	if old != nil {
+		value, _ := json.Marshal(old)
+		oldValue := string(value)
+		entry.OldValue = &oldValue
+		entry.ResourceID = &old.ID
+	}
+	if current != nil {
+		value, _ := json.Marshal(current)
+		newValue := string(value)
+		entry.NewValue = &newValue
+		entry.ResourceID = &current.ID
+	}
+	_ = s.roleRepo.CreateAuditLog(ctx, entry)
+}